@@ -46,6 +46,8 @@ type AddrManager struct {
 	nNew           int
 	lamtx          sync.Mutex
 	localAddresses map[string]*localAddress
+	reportedAddrs  map[string]*reportedLocalAddress
+	reportedBest   string
 }
 
 type serializedKnownAddress struct {
@@ -88,6 +90,10 @@ const (
 	// HTTPPrio signifies the address was obtained from an external HTTP service.
 	HTTPPrio
 
+	// PeerPrio signifies the address was discovered from the addresses
+	// peers reported seeing the local node as.
+	PeerPrio
+
 	// ManualPrio signifies the address was provided by --externalip.
 	ManualPrio
 )
@@ -1093,6 +1099,7 @@ func New(dataDir string, lookupFunc func(string) ([]net.IP, error)) *AddrManager
 		rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
 		quit:           make(chan struct{}),
 		localAddresses: make(map[string]*localAddress),
+		reportedAddrs:  make(map[string]*reportedLocalAddress),
 	}
 	am.reset()
 	return &am
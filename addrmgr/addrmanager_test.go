@@ -209,6 +209,62 @@ func TestAddLocalAddress(t *testing.T) {
 	}
 }
 
+func TestAddReportedLocalAddress(t *testing.T) {
+	amgr := addrmgr.New("testaddreportedlocaladdress", nil)
+	first := wire.NetAddress{IP: net.ParseIP("204.124.1.1"), Port: 9108}
+	second := wire.NetAddress{IP: net.ParseIP("204.124.2.2"), Port: 9108}
+	report := func(local wire.NetAddress, remoteIP string) *wire.NetAddress {
+		remote := wire.NetAddress{IP: net.ParseIP(remoteIP)}
+		return amgr.AddReportedLocalAddress(&local, &remote)
+	}
+
+	// Unroutable reported addresses must be ignored.
+	unroutable := wire.NetAddress{IP: net.ParseIP("192.168.0.100")}
+	for _, remoteIP := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		if na := report(unroutable, remoteIP); na != nil {
+			t.Fatalf("unroutable address %s was advertised", na.IP)
+		}
+	}
+
+	// Reports from peers in the same network group must only count once.
+	for _, remoteIP := range []string{"1.1.1.1", "1.1.2.2", "1.1.3.3"} {
+		if na := report(first, remoteIP); na != nil {
+			t.Fatalf("address %s was advertised before being "+
+				"reported by enough groups", na.IP)
+		}
+	}
+	if na := report(first, "2.2.2.2"); na != nil {
+		t.Fatalf("address %s was advertised before being reported by "+
+			"enough groups", na.IP)
+	}
+	na := report(first, "3.3.3.3")
+	if na == nil || !na.IP.Equal(first.IP) {
+		t.Fatalf("address %s was not advertised", first.IP)
+	}
+	remote := wire.NetAddress{IP: net.ParseIP("4.4.4.4")}
+	if got := amgr.GetBestLocalAddress(&remote); !got.IP.Equal(first.IP) {
+		t.Fatalf("unexpected best local address -- got %s, want %s",
+			got.IP, first.IP)
+	}
+
+	// A different address must only replace the advertised one once it
+	// has been reported by more groups.
+	for _, remoteIP := range []string{"5.5.5.5", "6.6.6.6", "7.7.7.7"} {
+		if na := report(second, remoteIP); na != nil {
+			t.Fatalf("address %s was advertised before overtaking "+
+				"the advertised address", na.IP)
+		}
+	}
+	na = report(second, "8.8.8.8")
+	if na == nil || !na.IP.Equal(second.IP) {
+		t.Fatalf("address %s was not advertised", second.IP)
+	}
+	if got := amgr.GetBestLocalAddress(&remote); !got.IP.Equal(second.IP) {
+		t.Fatalf("unexpected best local address -- got %s, want %s",
+			got.IP, second.IP)
+	}
+}
+
 func TestAttempt(t *testing.T) {
 	n := addrmgr.New("testattempt", lookupFunc)
 
@@ -251,6 +307,74 @@ func TestConnected(t *testing.T) {
 	}
 }
 
+func TestExportImportAddresses(t *testing.T) {
+	src := addrmgr.New("testexportsrc", lookupFunc)
+	for _, addrIP := range []string{someIP + ":8333", "1.2.3.4:9108"} {
+		if err := src.AddAddressByIP(addrIP); err != nil {
+			t.Fatalf("Adding address failed: %v", err)
+		}
+	}
+	src.Good(src.GetAddress().NetAddress())
+
+	exported := src.ExportAddresses()
+	if len(exported) != 2 {
+		t.Fatalf("Exported %d addresses, want 2", len(exported))
+	}
+	if exported[0].Addr != "1.2.3.4:9108" || exported[1].Addr != someIP+":8333" {
+		t.Fatalf("Exported addresses are not ordered by address: %v",
+			exported)
+	}
+	var numTried int
+	for _, pa := range exported {
+		if pa.Tried {
+			numTried++
+		}
+	}
+	if numTried != 1 {
+		t.Fatalf("Exported %d tried addresses, want 1", numTried)
+	}
+
+	// Invalid addresses must cause the entire import to fail.
+	dst := addrmgr.New("testexportdst", lookupFunc)
+	invalid := append(exported, addrmgr.PortableAddress{Addr: "example.com:9108"})
+	if _, err := dst.ImportAddresses(invalid); err == nil {
+		t.Fatalf("Importing a host name succeeded")
+	}
+	if dst.NumAddresses() != 0 {
+		t.Fatalf("Failed import added %d addresses", dst.NumAddresses())
+	}
+
+	// Non-routable addresses are skipped and future timestamps clamped.
+	exported[0].TimeStamp = time.Now().Add(time.Hour)
+	withLocal := append(exported, addrmgr.PortableAddress{Addr: "127.0.0.1:9108"})
+	added, err := dst.ImportAddresses(withLocal)
+	if err != nil {
+		t.Fatalf("Importing addresses failed: %v", err)
+	}
+	if added != 2 || dst.NumAddresses() != 2 {
+		t.Fatalf("Imported %d addresses for a total of %d, want 2",
+			added, dst.NumAddresses())
+	}
+	for _, pa := range dst.ExportAddresses() {
+		if pa.Tried {
+			t.Errorf("Imported address %s is in the tried set", pa.Addr)
+		}
+		if pa.TimeStamp.After(time.Now()) {
+			t.Errorf("Imported address %s has a timestamp in the "+
+				"future", pa.Addr)
+		}
+	}
+
+	// Importing the same addresses again must not add anything.
+	added, err = dst.ImportAddresses(exported)
+	if err != nil {
+		t.Fatalf("Importing addresses failed: %v", err)
+	}
+	if added != 0 {
+		t.Fatalf("Imported %d already known addresses", added)
+	}
+}
+
 func TestNeedMoreAddresses(t *testing.T) {
 	n := addrmgr.New("testneedmoreaddresses", lookupFunc)
 	addrsToAdd := 1500
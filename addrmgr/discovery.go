@@ -0,0 +1,118 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr
+
+import (
+	"time"
+
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// minLocalAddressReports is the minimum number of distinct network
+	// groups peers reporting the same local address must come from before
+	// the address is advertised.
+	minLocalAddressReports = 3
+
+	// maxReportedLocalAddresses is the maximum number of candidate local
+	// addresses reported by peers that are tracked.
+	maxReportedLocalAddresses = 64
+
+	// maxLocalAddressReporters is the maximum number of distinct network
+	// groups that are tracked for each candidate local address.
+	maxLocalAddressReporters = 64
+)
+
+// reportedLocalAddress houses a candidate local address reported by peers
+// along with the network groups of the peers that reported it.
+type reportedLocalAddress struct {
+	na        *wire.NetAddress
+	reporters map[string]struct{}
+	lastSeen  time.Time
+}
+
+// AddReportedLocalAddress records that the peer with the passed remote address
+// reported seeing the local node at the passed local address.  Each candidate
+// address is scored by the number of distinct network groups of the peers that
+// reported it, which limits the influence of any single entity controlling
+// many peers, and the best scored candidate is advertised with PeerPrio once
+// it has been reported by enough groups.  A previously advertised candidate is
+// replaced when a different candidate overtakes it, such as when the external
+// address changes.
+//
+// It returns the address that was newly advertised as a result of the report
+// or nil when the advertised address did not change.
+//
+// This function is safe for concurrent access.
+func (a *AddrManager) AddReportedLocalAddress(local, remote *wire.NetAddress) *wire.NetAddress {
+	if !IsRoutable(local) || !IsRoutable(remote) {
+		return nil
+	}
+
+	a.lamtx.Lock()
+	defer a.lamtx.Unlock()
+
+	key := NetAddressKey(local)
+	reported, ok := a.reportedAddrs[key]
+	if !ok {
+		// Evict the least recently reported candidate when the maximum
+		// number of candidates would be exceeded.
+		if len(a.reportedAddrs)+1 > maxReportedLocalAddresses {
+			var oldestKey string
+			var oldest *reportedLocalAddress
+			for k, r := range a.reportedAddrs {
+				if k == a.reportedBest {
+					continue
+				}
+				if oldest == nil || r.lastSeen.Before(oldest.lastSeen) {
+					oldestKey, oldest = k, r
+				}
+			}
+			delete(a.reportedAddrs, oldestKey)
+		}
+
+		reported = &reportedLocalAddress{
+			na:        local,
+			reporters: make(map[string]struct{}),
+		}
+		a.reportedAddrs[key] = reported
+	}
+	reported.lastSeen = time.Now()
+	if len(reported.reporters) < maxLocalAddressReporters {
+		reported.reporters[GroupKey(remote)] = struct{}{}
+	}
+
+	// Nothing more to do when the reported address is already advertised
+	// or has not been reported by enough distinct groups to overtake it.
+	if key == a.reportedBest || len(reported.reporters) < minLocalAddressReports {
+		return nil
+	}
+	if best, ok := a.reportedAddrs[a.reportedBest]; ok &&
+		len(best.reporters) >= len(reported.reporters) {
+
+		return nil
+	}
+
+	// Stop advertising the previously discovered address unless it was
+	// also added by some other means.
+	if la, ok := a.localAddresses[a.reportedBest]; ok && la.score == PeerPrio {
+		delete(a.localAddresses, a.reportedBest)
+	}
+	a.reportedBest = key
+
+	la, ok := a.localAddresses[key]
+	if ok && la.score >= PeerPrio {
+		return nil
+	}
+	if ok {
+		la.score = PeerPrio
+	} else {
+		a.localAddresses[key] = &localAddress{
+			na:    local,
+			score: PeerPrio,
+		}
+	}
+	return local
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// PortableVersion is the current version of the portable address format
+// produced by ExportAddresses.  Unlike the peers file, the portable format does
+// not depend on the secret bucket key of the address manager, so it may be used
+// to seed other nodes and to analyze the addresses known to a node.
+const PortableVersion = 1
+
+// PortableAddress describes a known address in the portable address format.
+type PortableAddress struct {
+	// Addr and Src are the address and the address of the peer it was
+	// learned from in the form of ip:port, [ip]:port, or onion:port.
+	Addr string
+	Src  string
+
+	// Services are the services the address was last known to support.
+	Services wire.ServiceFlag
+
+	// Attempts is the number of failed connection attempts since the last
+	// successful connection.
+	Attempts int
+
+	// TimeStamp is the last time the address was seen while LastAttempt and
+	// LastSuccess are the last times a connection to the address was
+	// attempted and succeeded, respectively.
+	TimeStamp   time.Time
+	LastAttempt time.Time
+	LastSuccess time.Time
+
+	// Tried is whether or not the address was in the tried set.
+	Tried bool
+}
+
+// ExportAddresses returns all addresses known to the address manager in the
+// portable address format ordered by address.
+//
+// This function is safe for concurrent access.
+func (a *AddrManager) ExportAddresses() []PortableAddress {
+	a.mtx.Lock()
+	addrs := make([]PortableAddress, 0, len(a.addrIndex))
+	for k, v := range a.addrIndex {
+		addrs = append(addrs, PortableAddress{
+			Addr:        k,
+			Src:         NetAddressKey(v.srcAddr),
+			Services:    v.na.Services,
+			Attempts:    v.attempts,
+			TimeStamp:   v.na.Timestamp,
+			LastAttempt: v.lastattempt,
+			LastSuccess: v.lastsuccess,
+			Tried:       v.tried,
+		})
+	}
+	a.mtx.Unlock()
+
+	sort.Sort(portableAddrSorter(addrs))
+	return addrs
+}
+
+// portableAddrSorter implements sort.Interface to allow a slice of portable
+// addresses to be sorted by address.
+type portableAddrSorter []PortableAddress
+
+// Len returns the number of addresses in the slice.  It is part of the
+// sort.Interface implementation.
+func (s portableAddrSorter) Len() int {
+	return len(s)
+}
+
+// Swap swaps the addresses at the passed indices.  It is part of the
+// sort.Interface implementation.
+func (s portableAddrSorter) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Less returns whether the address with index i should sort before the address
+// with index j.  It is part of the sort.Interface implementation.
+func (s portableAddrSorter) Less(i, j int) bool {
+	return s[i].Addr < s[j].Addr
+}
+
+// parsePortableAddress converts the passed address in the portable address
+// format to a *wire.NetAddress.  Unlike DeserializeNetAddress, host names are
+// rejected so importing addresses never results in name lookups.
+func (a *AddrManager) parsePortableAddress(addr string, services wire.ServiceFlag) (*wire.NetAddress, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %s: %v", portStr, err)
+	}
+	isOnion := len(host) == 22 && host[16:] == ".onion"
+	if !isOnion && net.ParseIP(host) == nil {
+		return nil, fmt.Errorf("invalid ip address %s", host)
+	}
+	return a.HostToNetAddress(host, uint16(port), services)
+}
+
+// ImportAddresses adds the passed addresses in the portable address format to
+// the address manager.  All of the addresses are validated before any of them
+// are added, so an error means no addresses were imported.
+//
+// Imported addresses are added to the new address buckets the same way
+// addresses received from peers are, including those that were in the tried
+// set of the node that exported them, since the local node has not connected
+// to them itself.  Timestamps in the future are clamped to the current time.
+// The connection history of an address is only imported when the address was
+// not already known.
+//
+// It returns the number of addresses that were newly added.  Addresses that
+// are already known or are not routable are skipped.
+//
+// This function is safe for concurrent access.
+func (a *AddrManager) ImportAddresses(addrs []PortableAddress) (int, error) {
+	type importAddr struct {
+		na      *wire.NetAddress
+		srcAddr *wire.NetAddress
+		pa      *PortableAddress
+	}
+	now := time.Unix(time.Now().Unix(), 0)
+	clamp := func(t time.Time) time.Time {
+		if t.After(now) {
+			return now
+		}
+		return t
+	}
+
+	toImport := make([]importAddr, 0, len(addrs))
+	for i := range addrs {
+		pa := &addrs[i]
+		na, err := a.parsePortableAddress(pa.Addr, pa.Services)
+		if err != nil {
+			return 0, fmt.Errorf("address %d (%s): %v", i, pa.Addr,
+				err)
+		}
+		na.Timestamp = clamp(pa.TimeStamp)
+
+		// Treat the address as its own source when the source is not
+		// specified.
+		srcAddr := na
+		if pa.Src != "" {
+			srcAddr, err = a.parsePortableAddress(pa.Src,
+				wire.SFNodeNetwork)
+			if err != nil {
+				return 0, fmt.Errorf("source of address %d "+
+					"(%s): %v", i, pa.Src, err)
+			}
+		}
+		toImport = append(toImport, importAddr{na, srcAddr, pa})
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	var added int
+	for _, v := range toImport {
+		if a.find(v.na) != nil {
+			continue
+		}
+		a.updateAddress(v.na, v.srcAddr)
+		ka := a.find(v.na)
+		if ka == nil {
+			continue
+		}
+		ka.attempts = v.pa.Attempts
+		ka.lastattempt = clamp(v.pa.LastAttempt)
+		ka.lastsuccess = clamp(v.pa.LastSuccess)
+		added++
+	}
+
+	log.Infof("Imported %d of %d addresses", added, len(addrs))
+	return added, nil
+}
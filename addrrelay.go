@@ -0,0 +1,182 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/decred/dcrd/addrmgr"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// addrTokenRate is the rate, in addresses per second, at which the
+	// number of addresses a peer may send is replenished.  Addresses that
+	// exceed the limit are ignored.
+	addrTokenRate = 0.1
+
+	// maxAddrTokens is the maximum number of addresses a peer may send at
+	// once, which is also the number of addresses the peer may send in
+	// response to a getaddr request.
+	maxAddrTokens = wire.MaxAddrPerMsg
+
+	// maxRelayAddrsPerMsg is the maximum number of addresses an addr
+	// message may contain in order for the addresses to be relayed.
+	// Larger messages are typically responses to getaddr requests rather
+	// than announcements of new addresses.
+	maxRelayAddrsPerMsg = 10
+
+	// maxRelayAddrAge is the maximum age of the timestamp of an address in
+	// order for it to be relayed.
+	maxRelayAddrAge = 10 * time.Minute
+
+	// addrRelayTargets is the number of peers each relayed address is sent
+	// to.
+	addrRelayTargets = 2
+
+	// addrRelayPeriod is the period over which the peers an address is
+	// relayed to remain the same.
+	addrRelayPeriod = 24 * time.Hour
+
+	// maxLocalAddrDelaySecs is the maximum number of seconds to wait after
+	// connecting to an outbound peer before advertising the local address
+	// to it.
+	maxLocalAddrDelaySecs = 600
+)
+
+// relayAddrMsg packages addresses to relay along with the peer they were
+// received from.
+type relayAddrMsg struct {
+	addrs  []*wire.NetAddress
+	source *serverPeer
+}
+
+// updateAddrTokens replenishes the number of addresses the peer may send based
+// on the time elapsed since it was last updated.  It must only be called from
+// the input handler of the peer.
+func (sp *serverPeer) updateAddrTokens(now time.Time) {
+	if sp.addrTokensUpdated.IsZero() {
+		// Allow peers to advertise their own address once they connect.
+		sp.addrTokens++
+	} else {
+		elapsed := now.Sub(sp.addrTokensUpdated).Seconds()
+		if elapsed > 0 {
+			sp.addrTokens += elapsed * addrTokenRate
+		}
+	}
+	if sp.addrTokens > maxAddrTokens {
+		sp.addrTokens = maxAddrTokens
+	}
+	sp.addrTokensUpdated = now
+}
+
+// requestAddrs requests known addresses from the peer and allows it to send a
+// full addr message in response.  It must only be called from the input
+// handler of the peer.
+func (sp *serverPeer) requestAddrs() {
+	sp.addrTokens += maxAddrTokens
+	if sp.addrTokens > maxAddrTokens {
+		sp.addrTokens = maxAddrTokens
+	}
+	sp.QueueMessage(wire.NewMsgGetAddr(), nil)
+}
+
+// advertiseLocalAddr sends the passed local address to the peer after a random
+// delay.  Advertising the address immediately upon connecting would allow
+// observers to determine which addresses belong to the node which first
+// announced them from the timing of the announcements.
+func (sp *serverPeer) advertiseLocalAddr(na *wire.NetAddress) {
+	delay := time.Duration(randomUint16Number(maxLocalAddrDelaySecs)) *
+		time.Second
+	time.AfterFunc(delay, func() {
+		if sp.Connected() {
+			sp.pushAddrMsg([]*wire.NetAddress{na})
+		}
+	})
+}
+
+// addrRelayKey returns the key used to select the peers the passed address is
+// relayed to.  The peers with the lowest keys are selected.  The key depends on
+// a secret which is generated when the server starts and only changes once per
+// relay period, so the selected peers can neither be predicted by other nodes
+// nor probed by repeatedly announcing the same address.
+func (s *server) addrRelayKey(na *wire.NetAddress, period uint64, peerID int32) uint64 {
+	addrKey := addrmgr.NetAddressKey(na)
+	buf := make([]byte, 0, len(s.addrRelaySecret)+len(addrKey)+12)
+	buf = append(buf, s.addrRelaySecret[:]...)
+	buf = append(buf, addrKey...)
+	var scratch [12]byte
+	binary.LittleEndian.PutUint64(scratch[:8], period)
+	binary.LittleEndian.PutUint32(scratch[8:], uint32(peerID))
+	buf = append(buf, scratch[:]...)
+	return binary.LittleEndian.Uint64(chainhash.HashB(buf))
+}
+
+// addrRelayCandidate houses a peer addresses may be relayed to along with its
+// id, which selects the addresses relayed to it.
+type addrRelayCandidate struct {
+	sp *serverPeer
+	id int32
+}
+
+// addrRelayPeers returns the peers the passed address is relayed to during the
+// passed relay period, which are the addrRelayTargets passed candidates with
+// the lowest relay keys ordered by their keys.
+func (s *server) addrRelayPeers(na *wire.NetAddress, period uint64, candidates []addrRelayCandidate) []*serverPeer {
+	var targets [addrRelayTargets]*serverPeer
+	var keys [addrRelayTargets]uint64
+	numTargets := 0
+	for _, candidate := range candidates {
+		// Insert the peer into the targets which are kept sorted by
+		// key.
+		key := s.addrRelayKey(na, period, candidate.id)
+		for i := range targets {
+			if i == numTargets || key < keys[i] {
+				copy(targets[i+1:], targets[i:])
+				copy(keys[i+1:], keys[i:])
+				targets[i], keys[i] = candidate.sp, key
+				if numTargets < addrRelayTargets {
+					numTargets++
+				}
+				break
+			}
+		}
+	}
+	return targets[:numTargets]
+}
+
+// handleRelayAddrMsg deals with relaying addresses to a subset of the connected
+// peers.  Each address is sent to the addrRelayTargets peers other than the one
+// it was received from with the lowest relay keys.  It is invoked from the
+// peerHandler goroutine.
+func (s *server) handleRelayAddrMsg(state *peerState, msg *relayAddrMsg) {
+	var candidates []addrRelayCandidate
+	state.forAllPeers(func(sp *serverPeer) {
+		if sp == msg.source || !sp.Connected() || !sp.VersionKnown() {
+			return
+		}
+		candidates = append(candidates, addrRelayCandidate{sp, sp.ID()})
+	})
+
+	period := uint64(time.Now().Unix() / int64(addrRelayPeriod/time.Second))
+	addrsByPeer := make(map[*serverPeer][]*wire.NetAddress)
+	for _, na := range msg.addrs {
+		for _, sp := range s.addrRelayPeers(na, period, candidates) {
+			addrsByPeer[sp] = append(addrsByPeer[sp], na)
+		}
+	}
+
+	for sp, addrs := range addrsByPeer {
+		sp.pushAddrMsg(addrs)
+	}
+}
+
+// RelayAddresses relays the passed recently announced addresses, which were
+// received from the passed peer, to a subset of the other connected peers.
+func (s *server) RelayAddresses(addrs []*wire.NetAddress, source *serverPeer) {
+	s.relayAddrs <- relayAddrMsg{addrs: addrs, source: source}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// TestAddrTokens ensures the number of addresses a peer may send starts with
+// its own address, is replenished at the token rate up to the maximum, is not
+// replenished when the clock goes backwards, and is refilled by requesting
+// addresses from the peer.
+func TestAddrTokens(t *testing.T) {
+	sp := relayHistoryTestPeer(t, "127.0.0.1:9108")
+	now := time.Now()
+
+	sp.updateAddrTokens(now)
+	if sp.addrTokens != 1 {
+		t.Fatalf("updateAddrTokens: unexpected tokens after connecting - "+
+			"got %v, want 1", sp.addrTokens)
+	}
+
+	// Consume the token and replenish one over the time it takes at the
+	// token rate.
+	sp.addrTokens--
+	now = now.Add(time.Duration(float64(time.Second) / addrTokenRate))
+	sp.updateAddrTokens(now)
+	if sp.addrTokens != 1 {
+		t.Fatalf("updateAddrTokens: unexpected tokens after replenishing "+
+			"- got %v, want 1", sp.addrTokens)
+	}
+	sp.updateAddrTokens(now.Add(-time.Hour))
+	if sp.addrTokens != 1 {
+		t.Fatalf("updateAddrTokens: unexpected tokens after the clock "+
+			"went backwards - got %v, want 1", sp.addrTokens)
+	}
+
+	// The tokens never exceed the maximum.
+	sp.updateAddrTokens(now.Add(24 * time.Hour))
+	if sp.addrTokens != maxAddrTokens {
+		t.Fatalf("updateAddrTokens: unexpected tokens after a day - got "+
+			"%v, want %v", sp.addrTokens, maxAddrTokens)
+	}
+
+	// Requesting addresses allows the peer to send a full addr message
+	// regardless of the remaining tokens.
+	sp.addrTokens = 0.5
+	sp.requestAddrs()
+	if sp.addrTokens != maxAddrTokens {
+		t.Fatalf("requestAddrs: unexpected tokens - got %v, want %v",
+			sp.addrTokens, maxAddrTokens)
+	}
+}
+
+// TestAddrRelayPeers ensures each address is relayed to the candidates with the
+// lowest relay keys and that the selected peers depend on the address, the
+// relay period, and the secret of the server.
+func TestAddrRelayPeers(t *testing.T) {
+	s := &server{addrRelaySecret: [32]byte{0x01}}
+	candidates := make([]addrRelayCandidate, 0, 8)
+	for i := 0; i < cap(candidates); i++ {
+		candidates = append(candidates, addrRelayCandidate{
+			sp: &serverPeer{},
+			id: int32(i + 1),
+		})
+	}
+	addrs := make([]*wire.NetAddress, 0, 16)
+	for i := 0; i < cap(addrs); i++ {
+		ip := net.IPv4(203, 0, 113, byte(i+1))
+		addrs = append(addrs, wire.NewNetAddressIPPort(ip, 9108,
+			wire.SFNodeNetwork))
+	}
+	selected := func(s *server, na *wire.NetAddress, period uint64) string {
+		var ids []byte
+		for _, sp := range s.addrRelayPeers(na, period, candidates) {
+			for _, candidate := range candidates {
+				if candidate.sp == sp {
+					ids = append(ids, byte(candidate.id))
+				}
+			}
+		}
+		return string(ids)
+	}
+
+	const period = 1000
+	for i, na := range addrs {
+		// The selected peers are the ones with fewer than the number of
+		// targets candidates with lower keys, ordered by their keys.
+		peers := s.addrRelayPeers(na, period, candidates)
+		if len(peers) != addrRelayTargets {
+			t.Fatalf("addrRelayPeers #%d: unexpected number of peers - "+
+				"got %d, want %d", i, len(peers), addrRelayTargets)
+		}
+		var prevKey uint64
+		for j, sp := range peers {
+			var key uint64
+			lower := 0
+			for _, candidate := range candidates {
+				if candidate.sp == sp {
+					key = s.addrRelayKey(na, period, candidate.id)
+				}
+			}
+			for _, candidate := range candidates {
+				if s.addrRelayKey(na, period, candidate.id) < key {
+					lower++
+				}
+			}
+			if lower != j || (j > 0 && key <= prevKey) {
+				t.Fatalf("addrRelayPeers #%d: peer %d does not have "+
+					"the next lowest key", i, j)
+			}
+			prevKey = key
+		}
+	}
+
+	// The peers differ between addresses, periods, and secrets for at
+	// least some of the addresses.
+	other := &server{addrRelaySecret: [32]byte{0x02}}
+	var addrDiffers, periodDiffers, secretDiffers bool
+	for i, na := range addrs {
+		want := selected(s, na, period)
+		addrDiffers = addrDiffers ||
+			selected(s, addrs[(i+1)%len(addrs)], period) != want
+		periodDiffers = periodDiffers || selected(s, na, period+1) != want
+		secretDiffers = secretDiffers || selected(other, na, period) != want
+	}
+	if !addrDiffers || !periodDiffers || !secretDiffers {
+		t.Fatalf("addrRelayPeers: peers do not change - address %v, "+
+			"period %v, secret %v", addrDiffers, periodDiffers,
+			secretDiffers)
+	}
+
+	// All candidates are selected when there are no more than the number
+	// of targets, and none when there are no candidates.
+	peers := s.addrRelayPeers(addrs[0], period, candidates[:1])
+	if len(peers) != 1 || peers[0] != candidates[0].sp {
+		t.Fatalf("addrRelayPeers: unexpected peers for a single "+
+			"candidate %v", peers)
+	}
+	if peers := s.addrRelayPeers(addrs[0], period, nil); len(peers) != 0 {
+		t.Fatalf("addrRelayPeers: unexpected peers without candidates %v",
+			peers)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"math/big"
 	"time"
 
 	"github.com/decred/dcrd/blockchain/stake"
@@ -161,6 +162,21 @@ func (b *BlockChain) checkBlockContext(block *dcrutil.Block, prevNode *blockNode
 			}
 		}
 
+		// Ensure all transactions in the block only use transaction and
+		// output script versions which are active.
+		for _, tx := range block.Transactions() {
+			err := b.checkTransactionVersions(prevNode, tx.MsgTx())
+			if err != nil {
+				return err
+			}
+		}
+		for _, stx := range block.STransactions() {
+			err := b.checkTransactionVersions(prevNode, stx.MsgTx())
+			if err != nil {
+				return err
+			}
+		}
+
 		// Check that the node is at the correct height in the blockchain,
 		// as specified in the block header.
 		if blockHeight != int64(block.MsgBlock().Header.Height) {
@@ -281,6 +297,7 @@ func (b *BlockChain) maybeAcceptBlock(block *dcrutil.Block,
 		newNode.parent = prevNode
 		newNode.height = blockHeight
 		newNode.workSum.Add(prevNode.workSum, newNode.workSum)
+		b.setSkip(newNode)
 	}
 
 	// Fetching a stake node could enable a new DoS vector, so restrict
@@ -306,9 +323,14 @@ func (b *BlockChain) maybeAcceptBlock(block *dcrutil.Block,
 	// chain.  The caller would typically want to react by relaying the
 	// inventory to other peers.
 	if !dryRun {
+		ntfnData := &BlockAcceptedNtfnsData{
+			OnMainChain: onMainChain,
+			Block:       block,
+			WorkSum:     new(big.Int).Set(newNode.workSum),
+			BestWorkSum: new(big.Int).Set(b.bestNode.workSum),
+		}
 		b.chainLock.Unlock()
-		b.sendNotification(NTBlockAccepted,
-			&BlockAcceptedNtfnsData{onMainChain, block})
+		b.sendNotification(NTBlockAccepted, ntfnData)
 		b.chainLock.Lock()
 	}
 
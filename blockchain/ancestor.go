@@ -0,0 +1,148 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/wire"
+)
+
+// invertLowestOne turns the lowest 1 bit in the binary representation of the
+// passed number into a 0.
+func invertLowestOne(n int64) int64 {
+	return n & (n - 1)
+}
+
+// skipHeight returns the height of the ancestor the skip pointer of a block
+// node at the passed height refers to.  Any height lower than the passed height
+// works, however, this choice allows reaching any ancestor in a number of steps
+// which is logarithmic in the distance to it.
+func skipHeight(height int64) int64 {
+	if height < 2 {
+		return 0
+	}
+	if height&1 == 1 {
+		return invertLowestOne(invertLowestOne(height-1)) + 1
+	}
+	return invertLowestOne(height)
+}
+
+// setSkip sets the skip pointer of the passed node, which must already be
+// linked to its parent, to its ancestor at the skip height when that ancestor
+// is in memory.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) setSkip(node *blockNode) {
+	if node.parent == nil {
+		return
+	}
+	node.skip = b.memoryAncestor(node.parent, skipHeight(node.height))
+}
+
+// skipToward returns the skip pointer of the passed node when following it is
+// the fastest way to the ancestor at the passed height, or nil when the parent
+// should be followed instead.  Skip pointers to nodes which are no longer in
+// memory are never followed.
+//
+// This function MUST be called with either the chain state lock or the block
+// index lock held (for reads).
+func (b *BlockChain) skipToward(node *blockNode, height int64) *blockNode {
+	skip := node.skip
+	if skip == nil || skip.height < height || b.index[skip.hash] != skip {
+		return nil
+	}
+
+	// Prefer the parent when its skip pointer gets closer to the ancestor
+	// without overshooting it.
+	prevSkipHeight := skipHeight(node.height - 1)
+	if skip.height > height && prevSkipHeight < skip.height-2 &&
+		prevSkipHeight >= height {
+		return nil
+	}
+	return skip
+}
+
+// memoryAncestor returns the ancestor of the passed node at the provided height
+// by following the skip pointers and parents of the nodes in memory.  Unlike
+// ancestorNode, no nodes are loaded from the database, so nil is returned when
+// the ancestor is not reachable in memory or the height is after the height of
+// the passed node or less than zero.
+//
+// This function MUST be called with either the chain state lock or the block
+// index lock held (for reads).
+func (b *BlockChain) memoryAncestor(node *blockNode, height int64) *blockNode {
+	if height > node.height || height < 0 {
+		return nil
+	}
+
+	iterNode := node
+	for iterNode != nil && iterNode.height > height {
+		if skip := b.skipToward(iterNode, height); skip != nil {
+			iterNode = skip
+			continue
+		}
+		iterNode = iterNode.parent
+	}
+	return iterNode
+}
+
+// AncestorHeader returns the header of the ancestor at the provided height of
+// the block with the passed hash, which may either be in the main chain or on a
+// side chain.  The header of the block itself is returned when the height is
+// its own height.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) AncestorHeader(hash *chainhash.Hash, height int64) (*wire.BlockHeader, error) {
+	b.indexLock.RLock()
+	defer b.indexLock.RUnlock()
+
+	// Walk back along side chain nodes until either the ancestor or a main
+	// chain node is reached.  All ancestors of main chain nodes are in the
+	// main chain themselves, so they are looked up from the database.
+	blockHeight := int64(-1)
+	if node, ok := b.index[*hash]; ok {
+		blockHeight = node.height
+		if height <= node.height {
+			for node != nil && node.height > height && !node.inMainChain {
+				if skip := b.skipToward(node, height); skip != nil {
+					node = skip
+					continue
+				}
+				node = node.parent
+			}
+			if node == nil {
+				return nil, fmt.Errorf("ancestor at height %d of "+
+					"block %v is not in memory", height, hash)
+			}
+			if node.height == height {
+				header := node.header
+				return &header, nil
+			}
+		}
+	}
+
+	var header *wire.BlockHeader
+	err := b.db.View(func(dbTx database.Tx) error {
+		if blockHeight == -1 {
+			var err error
+			blockHeight, err = dbFetchHeightByHash(dbTx, hash)
+			if err != nil {
+				return err
+			}
+		}
+		if height > blockHeight || height < 0 {
+			return fmt.Errorf("no ancestor at height %d for block %v "+
+				"at height %d", height, hash, blockHeight)
+		}
+
+		var err error
+		header, err = DBFetchHeaderByHeight(dbTx, height)
+		return err
+	})
+	return header, err
+}
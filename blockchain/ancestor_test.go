@@ -0,0 +1,108 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestMemoryAncestor ensures finding ancestors via the skip pointers of the
+// nodes in memory returns the same nodes as walking back one node at a time
+// and ignores skip pointers to nodes which are no longer in memory.
+func TestMemoryAncestor(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	bc := newFakeChain(params)
+	node := genesisBlockNode(params)
+	bc.index[node.hash] = node
+
+	const numNodes = 5000
+	nodes := make([]*blockNode, 0, numNodes)
+	nodes = append(nodes, node)
+	for height := int64(1); height < numNodes; height++ {
+		node = newFakeNode(1, height, node)
+		bc.setSkip(node)
+		bc.index[node.hash] = node
+		nodes = append(nodes, node)
+	}
+
+	for _, node := range []*blockNode{nodes[numNodes-1], nodes[4097],
+		nodes[1023], nodes[2]} {
+
+		for height := int64(0); height <= node.height; height++ {
+			ancestor := bc.memoryAncestor(node, height)
+			if ancestor != nodes[height] {
+				t.Fatalf("memoryAncestor(%d, %d): unexpected node "+
+					"at height %d", node.height, height,
+					ancestor.height)
+			}
+		}
+		if ancestor := bc.memoryAncestor(node, node.height+1); ancestor != nil {
+			t.Fatalf("memoryAncestor(%d, %d): unexpected ancestor",
+				node.height, node.height+1)
+		}
+		if ancestor := bc.memoryAncestor(node, -1); ancestor != nil {
+			t.Fatalf("memoryAncestor(%d, -1): unexpected ancestor",
+				node.height)
+		}
+	}
+
+	// Simulate the removal of the oldest nodes from memory and ensure the
+	// skip pointers which refer to them are no longer followed.
+	for _, node := range nodes[:1000] {
+		delete(bc.index, node.hash)
+	}
+	nodes[1000].parent = nil
+	tip := nodes[numNodes-1]
+	if ancestor := bc.memoryAncestor(tip, 1000); ancestor != nodes[1000] {
+		t.Fatalf("memoryAncestor(%d, 1000): unexpected ancestor",
+			tip.height)
+	}
+	if ancestor := bc.memoryAncestor(tip, 999); ancestor != nil {
+		t.Fatalf("memoryAncestor(%d, 999): unexpected ancestor at "+
+			"height %d", tip.height, ancestor.height)
+	}
+}
+
+// TestBlockLocatorHeights ensures the heights of the blocks of block locators
+// are generated as expected.
+func TestBlockLocatorHeights(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		height int64
+		want   []int64
+	}{
+		{0, []int64{0}},
+		{1, []int64{1, 0}},
+		{5, []int64{5, 4, 3, 2, 1, 0}},
+		{17, []int64{17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 5, 1, 0}},
+	}
+	for _, test := range tests {
+		got := BlockLocatorHeights(test.height)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("BlockLocatorHeights(%d): got %v, want %v",
+				test.height, got, test.want)
+		}
+	}
+
+	// Ensure the locators of long chains are limited in size, strictly
+	// decreasing, and end with the genesis block.
+	heights := BlockLocatorHeights(1 << 40)
+	if len(heights) > wire.MaxBlockLocatorsPerMsg {
+		t.Fatalf("too many locator heights: %d", len(heights))
+	}
+	for i := 1; i < len(heights); i++ {
+		if heights[i] >= heights[i-1] {
+			t.Fatalf("locator heights are not decreasing: %v", heights)
+		}
+	}
+	if heights[len(heights)-1] != 0 {
+		t.Fatalf("locator heights do not end with genesis: %v", heights)
+	}
+}
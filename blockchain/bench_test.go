@@ -1,9 +1,197 @@
 // Copyright (c) 2015 The btcsuite developers
-// Copyright (c) 2015-2016 The Decred developers
+// Copyright (c) 2015-2017 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
 package blockchain_test
 
-// TODO Make benchmarking tests for various functions, such as sidechain
-// evaluation.
+import (
+	"compress/bzip2"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrutil"
+)
+
+// loadBenchBlocks loads the blocks in the passed test data file, which houses
+// a gob-encoded map of block heights to serialized blocks, and returns them
+// keyed by height.
+func loadBenchBlocks(b *testing.B, filename string) map[int64]*dcrutil.Block {
+	fi, err := os.Open(filepath.Join("testdata/", filename))
+	if err != nil {
+		b.Fatalf("failed to open %s: %v", filename, err)
+	}
+	defer fi.Close()
+
+	var serialized map[int64][]byte
+	decoder := gob.NewDecoder(bzip2.NewReader(fi))
+	if err := decoder.Decode(&serialized); err != nil {
+		b.Fatalf("failed to decode %s: %v", filename, err)
+	}
+
+	blocks := make(map[int64]*dcrutil.Block, len(serialized))
+	for height, blockBytes := range serialized {
+		block, err := dcrutil.NewBlockFromBytes(blockBytes)
+		if err != nil {
+			b.Fatalf("failed to deserialize block %d of %s: %v", height,
+				filename, err)
+		}
+		block.SetHeight(height)
+		blocks[height] = block
+	}
+	return blocks
+}
+
+// processBenchBlocks processes the blocks at the passed heights, inclusive,
+// from the passed blocks.
+func processBenchBlocks(b *testing.B, chain *blockchain.BlockChain, blocks map[int64]*dcrutil.Block, first, last int64) {
+	for height := first; height <= last; height++ {
+		// Use a copy of the block since the block caches data which
+		// would otherwise skew the results of later iterations.
+		blockBytes, err := blocks[height].Bytes()
+		if err != nil {
+			b.Fatalf("failed to serialize block %d: %v", height, err)
+		}
+		block, err := dcrutil.NewBlockFromBytes(blockBytes)
+		if err != nil {
+			b.Fatalf("failed to deserialize block %d: %v", height, err)
+		}
+		block.SetHeight(height)
+
+		_, _, err = chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil {
+			b.Fatalf("ProcessBlock error at height %d: %v", height, err)
+		}
+	}
+}
+
+// BenchmarkProcessBlocks benchmarks fully validating and connecting the blocks
+// in the test chain to a new chain instance.
+func BenchmarkProcessBlocks(b *testing.B) {
+	blocks := loadBenchBlocks(b, "blocks0to168.bz2")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		chain, teardownFunc, err := chainSetup("benchprocessblocks",
+			simNetParams)
+		if err != nil {
+			b.Fatalf("failed to setup chain instance: %v", err)
+		}
+		b.StartTimer()
+
+		processBenchBlocks(b, chain, blocks, 1, 168)
+
+		b.StopTimer()
+		teardownFunc()
+		b.StartTimer()
+	}
+}
+
+// BenchmarkReorganize benchmarks processing a side chain which forks from the
+// main chain 49 blocks before its tip and ultimately causes a reorganization to
+// it, which involves both disconnecting and connecting blocks.
+func BenchmarkReorganize(b *testing.B) {
+	shortChain := loadBenchBlocks(b, "reorgto179.bz2")
+	longChain := loadBenchBlocks(b, "reorgto180.bz2")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		chain, teardownFunc, err := chainSetup("benchreorganize",
+			simNetParams)
+		if err != nil {
+			b.Fatalf("failed to setup chain instance: %v", err)
+		}
+		processBenchBlocks(b, chain, shortChain, 1, 179)
+		b.StartTimer()
+
+		processBenchBlocks(b, chain, longChain, 131, 180)
+
+		b.StopTimer()
+		teardownFunc()
+		b.StartTimer()
+	}
+}
+
+// BenchmarkCheckBlockSanity benchmarks the context-free sanity checks of the
+// blocks in the test chain.
+func BenchmarkCheckBlockSanity(b *testing.B) {
+	blocks := loadBenchBlocks(b, "blocks0to168.bz2")
+	timeSource := blockchain.NewMedianTime()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		block := blocks[int64(i%168)+1]
+		err := blockchain.CheckBlockSanity(block, timeSource, simNetParams)
+		if err != nil {
+			b.Fatalf("CheckBlockSanity error at height %d: %v",
+				block.Height(), err)
+		}
+	}
+}
+
+// benchValidateScripts benchmarks validating the scripts of all of the
+// transactions in the final block of the test chain against the state of the
+// chain prior to that block with the passed signature cache.
+func benchValidateScripts(b *testing.B, sigCache *txscript.SigCache) {
+	blocks := loadBenchBlocks(b, "blocks0to168.bz2")
+	chain, teardownFunc, err := chainSetup("benchvalidatescripts",
+		simNetParams)
+	if err != nil {
+		b.Fatalf("failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+	processBenchBlocks(b, chain, blocks, 1, 167)
+
+	// Fetch the outputs spent by the transactions in the final block.  The
+	// coinbase is skipped since it does not spend any outputs.
+	block := blocks[168]
+	var txns []*dcrutil.Tx
+	txns = append(txns, block.Transactions()[1:]...)
+	txns = append(txns, block.STransactions()...)
+	views := make([]*blockchain.UtxoViewpoint, 0, len(txns))
+	for _, tx := range txns {
+		view, err := chain.FetchUtxoView(tx, true)
+		if err != nil {
+			b.Fatalf("failed to fetch utxos for %v: %v", tx.Hash(), err)
+		}
+		views = append(views, view)
+	}
+	flags, err := chain.ConsensusScriptFlags()
+	if err != nil {
+		b.Fatalf("failed to obtain script flags: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, tx := range txns {
+			err := blockchain.ValidateTransactionScripts(tx, views[j],
+				flags, sigCache)
+			if err != nil {
+				b.Fatalf("failed to validate scripts for %v: %v",
+					tx.Hash(), err)
+			}
+		}
+	}
+}
+
+// BenchmarkValidateTransactionScripts benchmarks validating the scripts of the
+// transactions in a block without a signature cache.
+func BenchmarkValidateTransactionScripts(b *testing.B) {
+	benchValidateScripts(b, nil)
+}
+
+// BenchmarkValidateTransactionScriptsSigCache benchmarks validating the scripts
+// of the transactions in a block with a signature cache, which is the case for
+// transactions which were already validated when they were accepted to the
+// memory pool.
+func BenchmarkValidateTransactionScriptsSigCache(b *testing.B) {
+	benchValidateScripts(b, txscript.NewSigCache(1000))
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+)
+
+const (
+	// MaxBlockAnnotationKeySize is the maximum size in bytes of the key of a
+	// block annotation.
+	MaxBlockAnnotationKeySize = 64
+
+	// MaxBlockAnnotationValueSize is the maximum size in bytes of the value
+	// of a block annotation.
+	MaxBlockAnnotationValueSize = 256
+
+	// MaxBlockAnnotations is the maximum number of annotations which may be
+	// attached to a single block.
+	MaxBlockAnnotations = 16
+
+	// AnnotationFirstSeen is the key of the block annotation which records
+	// the time the block was first seen by the local node.
+	AnnotationFirstSeen = "firstseen"
+
+	// AnnotationRelayPeer is the key of the block annotation which records
+	// the address of the peer which relayed the block to the local node.
+	AnnotationRelayPeer = "relaypeer"
+)
+
+// BlockAnnotation is a small key and value pair which local subsystems or
+// operators attach to a block, such as the time it was first seen.  Annotations
+// are not part of the consensus state and are kept regardless of whether the
+// block is part of the main chain, so they survive reorganizations.
+type BlockAnnotation struct {
+	Key   string
+	Value string
+}
+
+// -----------------------------------------------------------------------------
+// The block annotations are stored in a nested bucket per annotated block
+// within the block annotations bucket.  The nested buckets are keyed by the
+// hash of the block and house the values of the annotations keyed by their
+// keys.  Nested buckets are removed along with the last annotation they house.
+// -----------------------------------------------------------------------------
+
+// dbFetchBlockAnnotations uses an existing database transaction to fetch the
+// annotations attached to the passed block ordered by their keys.
+func dbFetchBlockAnnotations(dbTx database.Tx, hash *chainhash.Hash) ([]BlockAnnotation, error) {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.BlockAnnotationsBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+	blockBucket := bucket.Bucket(hash[:])
+	if blockBucket == nil {
+		return nil, nil
+	}
+
+	var annotations []BlockAnnotation
+	err := blockBucket.ForEach(func(k, v []byte) error {
+		annotations = append(annotations, BlockAnnotation{
+			Key:   string(k),
+			Value: string(v),
+		})
+		return nil
+	})
+	return annotations, err
+}
+
+// dbPutBlockAnnotation uses an existing database transaction to attach the
+// passed annotation to the passed block, replacing any existing annotation with
+// the same key.
+func dbPutBlockAnnotation(dbTx database.Tx, hash *chainhash.Hash, annotation *BlockAnnotation) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+		dbnamespace.BlockAnnotationsBucketName)
+	if err != nil {
+		return err
+	}
+	blockBucket, err := bucket.CreateBucketIfNotExists(hash[:])
+	if err != nil {
+		return err
+	}
+
+	// Enforce the maximum number of annotations per block unless an
+	// existing annotation is replaced.
+	key := []byte(annotation.Key)
+	if blockBucket.Get(key) == nil {
+		var numAnnotations int
+		err := blockBucket.ForEach(func(k, v []byte) error {
+			numAnnotations++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if numAnnotations >= MaxBlockAnnotations {
+			str := fmt.Sprintf("block %v already has the maximum of "+
+				"%d annotations", hash, MaxBlockAnnotations)
+			return AnnotationError(str)
+		}
+	}
+
+	return blockBucket.Put(key, []byte(annotation.Value))
+}
+
+// dbRemoveBlockAnnotation uses an existing database transaction to remove the
+// annotation with the passed key from the passed block.  It is not an error if
+// there is no such annotation.
+func dbRemoveBlockAnnotation(dbTx database.Tx, hash *chainhash.Hash, key string) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.BlockAnnotationsBucketName)
+	if bucket == nil {
+		return nil
+	}
+	blockBucket := bucket.Bucket(hash[:])
+	if blockBucket == nil {
+		return nil
+	}
+	if err := blockBucket.Delete([]byte(key)); err != nil {
+		return err
+	}
+
+	// Remove the nested bucket of the block once it is empty.
+	if blockBucket.Cursor().First() {
+		return nil
+	}
+	return bucket.DeleteBucket(hash[:])
+}
+
+// dbCheckAnnotatedBlock uses an existing database transaction to ensure the
+// passed block, which annotations are requested for, is known.
+func dbCheckAnnotatedBlock(dbTx database.Tx, hash *chainhash.Hash) error {
+	exists, err := dbTx.HasBlock(hash)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return HashError(hash.String())
+	}
+	return nil
+}
+
+// SetBlockAnnotation attaches the annotation with the passed key and value to
+// the passed block, replacing any existing annotation with the same key.  The
+// block must be known, however, it does not need to be part of the main chain.
+//
+// AnnotationError is returned when the key is empty, the key or value exceed
+// their maximum sizes, or the block already has the maximum number of
+// annotations.  HashError is returned when the block is not known.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) SetBlockAnnotation(hash *chainhash.Hash, key, value string) error {
+	if len(key) == 0 || len(key) > MaxBlockAnnotationKeySize {
+		str := fmt.Sprintf("key size of %d bytes is not between 1 and "+
+			"%d bytes", len(key), MaxBlockAnnotationKeySize)
+		return AnnotationError(str)
+	}
+	if len(value) > MaxBlockAnnotationValueSize {
+		str := fmt.Sprintf("value size of %d bytes exceeds the maximum "+
+			"of %d bytes", len(value), MaxBlockAnnotationValueSize)
+		return AnnotationError(str)
+	}
+
+	return b.db.Update(func(dbTx database.Tx) error {
+		if err := dbCheckAnnotatedBlock(dbTx, hash); err != nil {
+			return err
+		}
+		return dbPutBlockAnnotation(dbTx, hash, &BlockAnnotation{
+			Key:   key,
+			Value: value,
+		})
+	})
+}
+
+// RemoveBlockAnnotation removes the annotation with the passed key from the
+// passed block.  It is not an error if the block has no such annotation.
+// HashError is returned when the block is not known.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) RemoveBlockAnnotation(hash *chainhash.Hash, key string) error {
+	return b.db.Update(func(dbTx database.Tx) error {
+		if err := dbCheckAnnotatedBlock(dbTx, hash); err != nil {
+			return err
+		}
+		return dbRemoveBlockAnnotation(dbTx, hash, key)
+	})
+}
+
+// BlockAnnotations returns the annotations attached to the passed block ordered
+// by their keys.  HashError is returned when the block is not known.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BlockAnnotations(hash *chainhash.Hash) ([]BlockAnnotation, error) {
+	var annotations []BlockAnnotation
+	err := b.db.View(func(dbTx database.Tx) error {
+		if err := dbCheckAnnotatedBlock(dbTx, hash); err != nil {
+			return err
+		}
+
+		var err error
+		annotations, err = dbFetchBlockAnnotations(dbTx, hash)
+		return err
+	})
+	return annotations, err
+}
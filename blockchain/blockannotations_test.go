@@ -0,0 +1,116 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestBlockAnnotations ensures annotations can be attached to, replaced on,
+// and removed from known blocks and that the limits on them are enforced.
+func TestBlockAnnotations(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	chain, teardownFunc, err := chainSetup("blockannotations", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Ensure annotations are attached and returned ordered by their keys,
+	// and that setting an existing key replaces its value.
+	hash := params.GenesisHash
+	setAnnotations := []blockchain.BlockAnnotation{
+		{Key: "tag", Value: "first"},
+		{Key: blockchain.AnnotationFirstSeen, Value: "2017-10-01T00:00:00Z"},
+		{Key: "tag", Value: "second"},
+	}
+	for _, annotation := range setAnnotations {
+		err := chain.SetBlockAnnotation(hash, annotation.Key,
+			annotation.Value)
+		if err != nil {
+			t.Fatalf("SetBlockAnnotation: unexpected error: %v", err)
+		}
+	}
+	annotations, err := chain.BlockAnnotations(hash)
+	if err != nil {
+		t.Fatalf("BlockAnnotations: unexpected error: %v", err)
+	}
+	want := []blockchain.BlockAnnotation{
+		{Key: blockchain.AnnotationFirstSeen, Value: "2017-10-01T00:00:00Z"},
+		{Key: "tag", Value: "second"},
+	}
+	if !reflect.DeepEqual(annotations, want) {
+		t.Fatalf("BlockAnnotations: unexpected annotations -- got %v, "+
+			"want %v", annotations, want)
+	}
+
+	// Ensure removing annotations, including ones which do not exist,
+	// works as expected.
+	for _, key := range []string{"tag", "tag", blockchain.AnnotationFirstSeen} {
+		if err := chain.RemoveBlockAnnotation(hash, key); err != nil {
+			t.Fatalf("RemoveBlockAnnotation: unexpected error: %v", err)
+		}
+	}
+	annotations, err = chain.BlockAnnotations(hash)
+	if err != nil {
+		t.Fatalf("BlockAnnotations: unexpected error: %v", err)
+	}
+	if len(annotations) != 0 {
+		t.Fatalf("BlockAnnotations: unexpected annotations after "+
+			"removal: %v", annotations)
+	}
+
+	// Ensure annotations exceeding the limits are rejected.
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"empty key", "", "value"},
+		{"key too large", strings.Repeat("k",
+			blockchain.MaxBlockAnnotationKeySize+1), "value"},
+		{"value too large", "key", strings.Repeat("v",
+			blockchain.MaxBlockAnnotationValueSize+1)},
+	}
+	for _, test := range tests {
+		err := chain.SetBlockAnnotation(hash, test.key, test.value)
+		if _, ok := err.(blockchain.AnnotationError); !ok {
+			t.Errorf("%s: unexpected error -- got %v (%T), want "+
+				"AnnotationError", test.name, err, err)
+		}
+	}
+	for i := 0; i < blockchain.MaxBlockAnnotations; i++ {
+		err := chain.SetBlockAnnotation(hash, fmt.Sprintf("tag%02d", i),
+			"value")
+		if err != nil {
+			t.Fatalf("SetBlockAnnotation: unexpected error: %v", err)
+		}
+	}
+	err = chain.SetBlockAnnotation(hash, "onetoomany", "value")
+	if _, ok := err.(blockchain.AnnotationError); !ok {
+		t.Errorf("unexpected error for too many annotations -- got %v "+
+			"(%T), want AnnotationError", err, err)
+	}
+
+	// Ensure annotations for unknown blocks are rejected.
+	unknownHash := &chainhash.Hash{0x01}
+	err = chain.SetBlockAnnotation(unknownHash, "tag", "value")
+	if _, ok := err.(blockchain.HashError); !ok {
+		t.Errorf("unexpected error for unknown block -- got %v (%T), "+
+			"want HashError", err, err)
+	}
+	_, err = chain.BlockAnnotations(unknownHash)
+	if _, ok := err.(blockchain.HashError); !ok {
+		t.Errorf("unexpected error for unknown block -- got %v (%T), "+
+			"want HashError", err, err)
+	}
+}
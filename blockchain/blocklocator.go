@@ -28,6 +28,36 @@ import (
 // [17a 16a 15 14 13 12 11 10 9 8 6 2 genesis]
 type BlockLocator []*chainhash.Hash
 
+// BlockLocatorHeights returns the heights of the blocks a block locator for a
+// block at the passed height consists of, starting with the passed height and
+// ending with the genesis block, according to the algorithm described by
+// BlockLocator.  This allows building block locators from any source of block
+// hashes by height.
+func BlockLocatorHeights(height int64) []int64 {
+	heights := make([]int64, 0, wire.MaxBlockLocatorsPerMsg)
+	heights = append(heights, height)
+	if height <= 0 {
+		return heights
+	}
+
+	// Leave room for the final genesis height.
+	increment := int64(1)
+	for len(heights) < wire.MaxBlockLocatorsPerMsg-1 {
+		// Once there are 10 locators, exponentially increase the
+		// distance between each block locator.
+		if len(heights) > 10 {
+			increment *= 2
+		}
+		height -= increment
+		if height < 1 {
+			break
+		}
+		heights = append(heights, height)
+	}
+
+	return append(heights, 0)
+}
+
 // blockLocatorFromHash returns a block locator for the passed block hash.
 // See BlockLocator for details on the algotirhm used to create a block locator.
 //
@@ -39,7 +69,8 @@ type BlockLocator []*chainhash.Hash
 //  - If the passed hash is not currently known, the block locator will only
 //    consist of the passed hash
 //
-// This function MUST be called with the chain state lock held (for reads).
+// This function MUST be called with either the chain state lock or the block
+// index lock held (for reads).
 func (b *BlockChain) blockLocatorFromHash(hash *chainhash.Hash) BlockLocator {
 	// The locator contains the requested hash at the very least.
 	locator := make(BlockLocator, 0, wire.MaxBlockLocatorsPerMsg)
@@ -87,41 +118,33 @@ func (b *BlockChain) blockLocatorFromHash(hash *chainhash.Hash) BlockLocator {
 	}
 
 	// Generate the block locators according to the algorithm described in
-	// in the BlockLocator comment and make sure to leave room for the final
-	// genesis hash.
+	// in the BlockLocator comment.  The first height is the block itself
+	// and the final one is the genesis block, which are both added
+	// separately.
 	//
 	// The error is intentionally ignored here since the only way the code
 	// could fail is if there is something wrong with the database which
 	// will be caught in short order anyways and it's also safe to ignore
 	// block locators.
+	heights := BlockLocatorHeights(blockHeight)
 	_ = b.db.View(func(dbTx database.Tx) error {
 		iterNode := node
-		increment := int64(1)
-		for len(locator) < wire.MaxBlockLocatorsPerMsg-1 {
-			// Once there are 10 locators, exponentially increase
-			// the distance between each block locator.
-			if len(locator) > 10 {
-				increment *= 2
-			}
-			blockHeight -= increment
-			if blockHeight < 1 {
+		for _, height := range heights[1:] {
+			if height < 1 {
 				break
 			}
 
-			// As long as this is still on the side chain, walk
-			// backwards along the side chain nodes to each block
-			// height.
-			if forkHeight != -1 && blockHeight > forkHeight {
-				// Intentionally use parent field instead of the
-				// getPrevNodeFromNode function since we don't
-				// want to dynamically load nodes when building
-				// block locators.  Side chain blocks should
-				// always be in memory already, and if they
-				// aren't for some reason it's ok to skip them.
-				for iterNode != nil && blockHeight > iterNode.height {
-					iterNode = iterNode.parent
-				}
-				if iterNode != nil && iterNode.height == blockHeight {
+			// As long as this is still on the side chain, find the
+			// side chain node at each block height.
+			if forkHeight != -1 && height > forkHeight {
+				// Intentionally only follow the nodes in memory
+				// since we don't want to dynamically load nodes
+				// when building block locators.  Side chain
+				// blocks should always be in memory already,
+				// and if they aren't for some reason it's ok to
+				// skip them.
+				if ancestor := b.memoryAncestor(iterNode, height); ancestor != nil {
+					iterNode = ancestor
 					locator = append(locator, &iterNode.hash)
 				}
 				continue
@@ -129,13 +152,13 @@ func (b *BlockChain) blockLocatorFromHash(hash *chainhash.Hash) BlockLocator {
 
 			// The desired block height is in the main chain, so
 			// look it up from the main chain database.
-			h, err := dbFetchHashByHeight(dbTx, blockHeight)
+			h, err := dbFetchHashByHeight(dbTx, height)
 			if err != nil {
 				// This shouldn't happen and it's ok to ignore
 				// block locators, so just continue to the next
 				// one.
 				log.Warnf("Lookup of known valid height failed %v",
-					blockHeight)
+					height)
 				continue
 			}
 			locator = append(locator, h)
@@ -162,9 +185,9 @@ func (b *BlockChain) blockLocatorFromHash(hash *chainhash.Hash) BlockLocator {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) BlockLocatorFromHash(hash *chainhash.Hash) BlockLocator {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	locator := b.blockLocatorFromHash(hash)
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 	return locator
 }
 
@@ -173,8 +196,8 @@ func (b *BlockChain) BlockLocatorFromHash(hash *chainhash.Hash) BlockLocator {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) LatestBlockLocator() (BlockLocator, error) {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	locator := b.blockLocatorFromHash(&b.bestNode.hash)
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 	return locator, nil
 }
@@ -0,0 +1,80 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"github.com/decred/dcrd/chaincfg"
+)
+
+const (
+	// MinPruneTarget is the minimum target size in bytes of the stored block
+	// data when pruning is enabled.  Block data is removed in units of
+	// entire block files, so smaller targets would not leave room for the
+	// blocks which must always be kept.
+	MinPruneTarget = 1024 * 1024 * 1024 // 1 GiB
+
+	// pruneBlocksInterval is the number of blocks connected to the main
+	// chain between attempts to prune the stored block data.
+	pruneBlocksInterval = 144
+)
+
+// minPruneDepth returns the number of the most recent blocks of the main chain
+// whose data is never pruned.
+//
+// The depth covers the stake and reorganization safety windows.  That is to
+// say it covers the immature tickets whose purchases are needed to rebuild
+// stake nodes, the rule change and stake version intervals which tally the
+// votes in the blocks, the blocks which might need to be replayed into the
+// utxo cache, and the nodes kept in memory to handle reorganizations.
+func minPruneDepth(params *chaincfg.Params) int64 {
+	depth := int64(params.RuleChangeActivationInterval) * 2
+	for _, windowDepth := range []int64{
+		params.StakeVersionInterval * 4,
+		int64(params.TicketMaturity) + 1,
+		utxoCacheMaxUnflushedBlocks,
+		minMemoryNodes,
+	} {
+		if windowDepth > depth {
+			depth = windowDepth
+		}
+	}
+	return depth
+}
+
+// pruneBlocks removes the data of the oldest blocks of the main chain which are
+// buried deeper than the minimum prune depth until the stored block data does
+// not exceed the prune target.  The headers of the blocks and all other chain
+// state remain intact.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) pruneBlocks() error {
+	pruneHeight := b.bestNode.height - minPruneDepth(b.chainParams)
+	if pruneHeight <= 0 {
+		return nil
+	}
+
+	remaining, err := b.db.PruneBlocks(b.pruneTarget, uint32(pruneHeight))
+	if err != nil {
+		return err
+	}
+	log.Debugf("Stored block data uses %d MiB after pruning blocks below "+
+		"height %d", remaining/1024/1024, pruneHeight)
+	return nil
+}
+
+// pruneBlocksIfNeeded prunes the stored block data when pruning is enabled and
+// the passed main chain node is at a pruning interval.  Errors are only logged
+// since the blocks remain usable regardless.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) pruneBlocksIfNeeded(node *blockNode) {
+	if b.pruneTarget == 0 || node.height%pruneBlocksInterval != 0 {
+		return
+	}
+
+	if err := b.pruneBlocks(); err != nil {
+		log.Warnf("Unable to prune blocks: %v", err)
+	}
+}
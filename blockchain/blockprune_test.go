@@ -0,0 +1,50 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+)
+
+// TestMinPruneDepth ensures the minimum prune depth covers the stake and
+// reorganization safety windows of all networks.
+func TestMinPruneDepth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		params *chaincfg.Params
+		want   int64
+	}{
+		{"mainnet", &chaincfg.MainNetParams, 16128},
+		{"testnet", &chaincfg.TestNet2Params, 10080},
+		{"simnet", &chaincfg.SimNetParams, 2880},
+	}
+	for _, test := range tests {
+		params := test.params
+		depth := minPruneDepth(params)
+		if depth != test.want {
+			t.Errorf("%s: unexpected depth -- got %d, want %d",
+				test.name, depth, test.want)
+			continue
+		}
+
+		windows := []int64{
+			int64(params.RuleChangeActivationInterval) * 2,
+			params.StakeVersionInterval * 4,
+			int64(params.TicketMaturity) + 1,
+			utxoCacheMaxUnflushedBlocks,
+			minMemoryNodes,
+		}
+		for _, window := range windows {
+			if depth < window {
+				t.Errorf("%s: depth %d does not cover window %d",
+					test.name, depth, window)
+			}
+		}
+	}
+}
@@ -65,6 +65,12 @@ type blockNode struct {
 	// parent is the parent block for this node.
 	parent *blockNode
 
+	// skip is an ancestor of this node further back than the parent which
+	// allows finding any ancestor of the node in a logarithmic number of
+	// steps.  It is nil when the ancestor was not in memory when the node
+	// was linked to its parent.  See skipHeight for details.
+	skip *blockNode
+
 	// children contains the child nodes for this node.  Typically there
 	// will only be one, but sometimes there can be more than one and that
 	// is when the best chain selection algorithm is used.
@@ -173,6 +179,7 @@ type BestState struct {
 	NumTxns      uint64          // The number of txns in the block.
 	TotalTxns    uint64          // The total number of txns in the chain.
 	TotalSubsidy int64           // The total subsidy for the chain.
+	WorkSum      *big.Int        // The total work in the chain.
 }
 
 // newBestState returns a new best stats instance for the given parameters.
@@ -185,6 +192,7 @@ func newBestState(node *blockNode, blockSize, numTxns, totalTxns uint64, totalSu
 		NumTxns:      numTxns,
 		TotalTxns:    totalTxns,
 		TotalSubsidy: totalSubsidy,
+		WorkSum:      new(big.Int).Set(node.workSum),
 	}
 }
 
@@ -193,14 +201,23 @@ func newBestState(node *blockNode, blockSize, numTxns, totalTxns uint64, totalSu
 // follow all rules, orphan handling, checkpoint handling, and best chain
 // selection with reorganization.
 type BlockChain struct {
+	// forcedStakeDiff is the stake difficulty which overrides the
+	// calculated stake difficulty when it is nonzero.  It may only be set
+	// on the simulation test network and must only be accessed atomically.
+	// It is the first field so it is 64-bit aligned on all platforms.
+	forcedStakeDiff int64
+
 	// The following fields are set when the instance is created and can't
 	// be changed afterwards, so there is no need to protect them with a
 	// separate mutex.
+	checkpoints         []chaincfg.Checkpoint
 	checkpointsByHeight map[int64]*chaincfg.Checkpoint
+	assumeValid         *chaincfg.Checkpoint
 	db                  database.DB
 	dbInfo              *databaseInfo
 	chainParams         *chaincfg.Params
 	timeSource          MedianTimeSource
+	clock               Clock
 	notifications       NotificationCallback
 	sigCache            *txscript.SigCache
 	indexManager        IndexManager
@@ -209,20 +226,70 @@ type BlockChain struct {
 	// values.
 	subsidyCache *SubsidyCache
 
+	// utxoCache is the write-back cache of the utxo set which is used by
+	// all utxo viewpoints of the main chain.  It is safe for concurrent
+	// access, however, it is only modified with the chain lock held and it
+	// is only consistent with the best node while the utxo lock is held.
+	utxoCache *utxoCache
+
+	// pruneTarget is the target size in bytes of the stored block data.
+	// The data of old blocks is not pruned when it is zero.
+	pruneTarget uint64
+
+	// scriptFlagsAdd and scriptFlagsRemove are the script verification
+	// flags which are added to and removed from the flags enforced by the
+	// consensus rules for the blocks at or above scriptFlagsHeight as
+	// specified by the configuration.
+	scriptFlagsAdd    txscript.ScriptFlags
+	scriptFlagsRemove txscript.ScriptFlags
+	scriptFlagsHeight int64
+
+	// migrations houses the background database migrations which are
+	// pending.  It is only accessed by RunMigrations once the instance is
+	// created.
+	migrations []*dbMigration
+
+	// validationStats accumulates the time each stage of validating blocks
+	// takes.  It is safe for concurrent access.
+	validationStats ValidationStats
+
 	// chainLock protects concurrent access to the vast majority of the
-	// fields in this struct below this point.
+	// fields in this struct below this point.  It is held for writes for
+	// the entire duration of processing a block, so queries which do not
+	// need the full chain state avoid it in favor of the more fine-grained
+	// locks below.
 	chainLock sync.RWMutex
 
 	// These fields are configuration parameters that can be toggled at
-	// runtime.  They are protected by the chain lock.
-	noVerify      bool
-	noCheckpoints bool
-
-	// These fields are related to the memory block index.  They are
-	// protected by the chain lock.
-	bestNode *blockNode
-	index    map[chainhash.Hash]*blockNode
-	depNodes map[chainhash.Hash][]*blockNode
+	// runtime.  They are protected by the chain lock, and modifying the
+	// checkpoint mode additionally requires the checkpoint lock to be held
+	// for writes so it can be read with only the checkpoint lock held.
+	noVerify       bool
+	checkpointLock sync.RWMutex
+	checkpointMode CheckpointMode
+
+	// These fields are related to the memory block index.  Modifying them,
+	// the links between the nodes, or whether the nodes are part of the
+	// main chain requires both the chain lock and the index lock to be held
+	// for writes.  Reading them only requires one of the locks to be held,
+	// so queries which only need the block index hold the index lock for
+	// reads and do not wait for blocks being processed.  The best node may
+	// also be read with the utxo lock held as described below.
+	indexLock sync.RWMutex
+	bestNode  *blockNode
+	index     map[chainhash.Hash]*blockNode
+	depNodes  map[chainhash.Hash][]*blockNode
+
+	// utxoLock is held for writes along with the chain lock while the utxo
+	// set and the best node are updated for a connected or disconnected
+	// block.  Queries of the utxo set as of the best node hold it for reads
+	// instead of the chain lock, so they only wait for those updates rather
+	// than for the entire processing of blocks.  Since the best node is
+	// only replaced while it is held for writes, holding it for reads is
+	// also sufficient to read the best node, which ensures the best node
+	// matches the utxo set.  When both are needed, the utxo lock must be
+	// acquired before the index lock.
+	utxoLock sync.RWMutex
 
 	// These fields are related to handling of orphan blocks.  They are
 	// protected by a combination of the chain lock and the orphan lock.
@@ -239,10 +306,14 @@ type BlockChain struct {
 	mainchainBlockCache     map[chainhash.Hash]*dcrutil.Block
 	mainchainBlockCacheSize int
 
+	// invalidBlocks houses the blocks which recently failed validation.  It
+	// has its own lock.
+	invalidBlocks *knownInvalidBlocks
+
 	// These fields are related to checkpoint handling.  They are protected
 	// by the chain lock.
-	nextCheckpoint  *chaincfg.Checkpoint
-	checkpointBlock *dcrutil.Block
+	nextCheckpoint   *chaincfg.Checkpoint
+	checkpointHeader *wire.BlockHeader
 
 	// The state is used as a fairly efficient way to cache information
 	// about the current best chain state that is returned to callers when
@@ -258,6 +329,17 @@ type BlockChain struct {
 	stateLock     sync.RWMutex
 	stateSnapshot *BestState
 
+	// coinSupply is the coin supply as of the end of the main chain.
+	// Modifying it requires both the chain lock and the state lock to be
+	// held, while reading it only requires one of them.
+	coinSupply CoinSupply
+
+	// utxoCommitments houses the hashes of the utxo set which results from
+	// recently connected blocks by block hash for the experimental utxo
+	// commitments.  It is nil when they are disabled.  It is protected by
+	// the chain lock.
+	utxoCommitments map[chainhash.Hash]*utxoSetState
+
 	// The following caches are used to efficiently keep track of the
 	// current deployment threshold state of each rule change deployment.
 	//
@@ -399,11 +481,7 @@ func (b *BlockChain) DisableVerify(disable bool) {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) TotalSubsidy() int64 {
-	b.chainLock.RLock()
-	ts := b.BestSnapshot().TotalSubsidy
-	b.chainLock.RUnlock()
-
-	return ts
+	return b.BestSnapshot().TotalSubsidy
 }
 
 // FetchSubsidyCache returns the current subsidy cache from the blockchain.
@@ -419,8 +497,8 @@ func (b *BlockChain) FetchSubsidyCache() *SubsidyCache {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) HaveBlock(hash *chainhash.Hash) (bool, error) {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
+	b.indexLock.RLock()
+	defer b.indexLock.RUnlock()
 
 	exists, err := b.blockExists(hash)
 	if err != nil {
@@ -522,7 +600,7 @@ func (b *BlockChain) removeOrphanBlock(orphan *orphanBlock) {
 func (b *BlockChain) addOrphanBlock(block *dcrutil.Block) {
 	// Remove expired orphan blocks.
 	for _, oBlock := range b.orphans {
-		if time.Now().After(oBlock.expiration) {
+		if b.clock.Now().After(oBlock.expiration) {
 			b.removeOrphanBlock(oBlock)
 			continue
 		}
@@ -550,7 +628,7 @@ func (b *BlockChain) addOrphanBlock(block *dcrutil.Block) {
 
 	// Insert the block into the orphan map with an expiration time
 	// 1 hour from now.
-	expiration := time.Now().Add(time.Hour)
+	expiration := b.clock.Now().Add(time.Hour)
 	oBlock := &orphanBlock{
 		block:      block,
 		expiration: expiration,
@@ -612,15 +690,31 @@ func (b *BlockChain) GetGeneration(hash chainhash.Hash) ([]chainhash.Hash, error
 // This function MUST be called with the chain state lock held (for writes).
 // The database transaction may be read-only.
 func (b *BlockChain) loadBlockNode(dbTx database.Tx, hash *chainhash.Hash) (*blockNode, error) {
+	// The data of blocks buried deeper than the minimum prune depth might
+	// have been pruned, in which case the node is created from the header
+	// alone.  Such nodes lack the stake data of the block, which is only
+	// needed within the windows covered by the prune depth.
+	var node *blockNode
 	block, err := dbFetchBlockByHash(dbTx, hash)
-	if err != nil {
+	switch {
+	case isDbBlockPrunedErr(err):
+		header, err := dbFetchHeaderByHash(dbTx, hash)
+		if err != nil {
+			return nil, err
+		}
+		node = newBlockNode(header, hash, int64(header.Height), nil, nil,
+			nil)
+
+	case err != nil:
 		return nil, err
-	}
 
-	blockHeader := block.MsgBlock().Header
-	node := newBlockNode(&blockHeader, hash, int64(blockHeader.Height),
-		ticketsSpentInBlock(block), ticketsRevokedInBlock(block),
-		voteBitsInBlock(block))
+	default:
+		header := &block.MsgBlock().Header
+		node = newBlockNode(header, hash, int64(header.Height),
+			ticketsSpentInBlock(block), ticketsRevokedInBlock(block),
+			voteBitsInBlock(block))
+	}
+	blockHeader := node.header
 	node.inMainChain = true
 	prevHash := &blockHeader.PrevBlock
 
@@ -637,14 +731,17 @@ func (b *BlockChain) loadBlockNode(dbTx database.Tx, hash *chainhash.Hash) (*blo
 		// the parent node and set this node's parent to the parent
 		// node.
 		node.workSum = node.workSum.Add(parentNode.workSum, node.workSum)
+		b.indexLock.Lock()
 		parentNode.children = append(parentNode.children, node)
 		node.parent = parentNode
+		b.setSkip(node)
 	} else if childNodes, ok := b.depNodes[*hash]; ok {
 		// Case 2 -- This node is the parent of one or more nodes.
 		// Update the node's work sum by subtracting this node's work
 		// from the sum of its first child, and connect the node to all
 		// of its children.
 		node.workSum.Sub(childNodes[0].workSum, node.workSum)
+		b.indexLock.Lock()
 		for _, childNode := range childNodes {
 			childNode.parent = node
 			node.children = append(node.children, childNode)
@@ -660,17 +757,22 @@ func (b *BlockChain) loadBlockNode(dbTx database.Tx, hash *chainhash.Hash) (*blo
 		foundParent, err := b.findNode(&node.header.PrevBlock, maxSearchDepth)
 		if err == nil {
 			node.workSum = node.workSum.Add(foundParent.workSum, node.workSum)
+			b.indexLock.Lock()
 			foundParent.children = append(foundParent.children, node)
 			node.parent = foundParent
+			b.setSkip(node)
 		} else {
 			str := "loadBlockNode: attempt to insert orphan block %v"
 			return nil, AssertError(fmt.Sprintf(str, hash))
 		}
 	}
 
-	// Add the new node to the indices for faster lookups.
+	// Add the new node to the indices for faster lookups.  The index lock
+	// was acquired above once the node to link the new node to was found,
+	// since finding it might require loading further nodes.
 	b.index[*hash] = node
 	b.depNodes[*prevHash] = append(b.depNodes[*prevHash], node)
+	b.indexLock.Unlock()
 
 	return node, nil
 }
@@ -795,8 +897,9 @@ func (b *BlockChain) getPrevNodeFromNode(node *blockNode) (*blockNode, error) {
 // ancestorNode returns the ancestor block node at the provided height by
 // following the chain backwards from the given node while dynamically loading
 // any pruned nodes from the database and updating the memory block chain as
-// needed.  The returned block will be nil when a height is requested that is
-// after the height of the passed node or is less than zero.
+// needed.  Skip pointers are followed whenever possible to avoid walking back
+// one node at a time.  The returned block will be nil when a height is
+// requested that is after the height of the passed node or is less than zero.
 //
 // This function MUST be called with the chain state lock held (for writes).
 func (b *BlockChain) ancestorNode(node *blockNode, height int64) (*blockNode, error) {
@@ -808,6 +911,11 @@ func (b *BlockChain) ancestorNode(node *blockNode, height int64) (*blockNode, er
 	// Iterate backwards until the requested height is reached.
 	iterNode := node
 	for iterNode != nil && iterNode.height > height {
+		if skip := b.skipToward(iterNode, height); skip != nil {
+			iterNode = skip
+			continue
+		}
+
 		// Get the previous block node.  This function is used over
 		// simply accessing iterNode.parent directly as it will
 		// dynamically create previous block nodes as needed.  This
@@ -895,14 +1003,20 @@ func (b *BlockChain) removeBlockNode(node *blockNode) error {
 			node.hash))
 	}
 
+	b.indexLock.Lock()
+	defer b.indexLock.Unlock()
+
 	// Remove the node from the node index.
 	delete(b.index, node.hash)
 
-	// Unlink all of the node's children.
+	// Unlink all of the node's children.  The skip pointers of nodes still
+	// in memory might continue to refer to the node, so its own skip
+	// pointer is cleared to avoid keeping further removed nodes alive.
 	for _, child := range node.children {
 		child.parent = nil
 	}
 	node.children = nil
+	node.skip = nil
 
 	// Remove the reference from the dependency index.
 	prevHash := &node.header.PrevBlock
@@ -1151,6 +1265,135 @@ func (b *BlockChain) CalcPastMedianTime() (time.Time, error) {
 	return b.calcPastMedianTime(b.bestNode)
 }
 
+// dbCalcPastMedianTime calculates the median time of the previous few blocks
+// prior to, and including, the block with the passed hash using the block
+// headers stored in the database.  Unlike calcPastMedianTime, it does not
+// require the block nodes to be loaded, so it works for any stored block
+// regardless of whether or not it is part of the main chain.  The result is
+// the same as calcPastMedianTime for the associated node.
+func dbCalcPastMedianTime(dbTx database.Tx, hash *chainhash.Hash) (time.Time, error) {
+	timestamps := make([]time.Time, 0, medianTimeBlocks)
+	for len(timestamps) < medianTimeBlocks {
+		header, err := dbFetchHeaderByHash(dbTx, hash)
+		if err != nil {
+			return time.Time{}, err
+		}
+		timestamps = append(timestamps, header.Timestamp)
+		if header.Height == 0 {
+			break
+		}
+		hash = &header.PrevBlock
+	}
+
+	// See calcPastMedianTime for details about the median of an even
+	// number of timestamps.
+	sort.Sort(timeSorter(timestamps))
+	return timestamps[len(timestamps)/2], nil
+}
+
+// PastMedianTimeByHash returns the median time of the previous few blocks
+// prior to, and including, the block with the passed hash, which is the
+// time the timestamp of a block building on it must exceed.  The block must be
+// stored, but it does not need to be part of the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) PastMedianTimeByHash(hash *chainhash.Hash) (time.Time, error) {
+	var medianTime time.Time
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		medianTime, err = dbCalcPastMedianTime(dbTx, hash)
+		return err
+	})
+	return medianTime, err
+}
+
+// calcChainWork calculates the total work of the chain up to and including the
+// block with the passed hash.  The work of blocks which do not have a node in
+// memory is looked up in the database, and it is only determined from the block
+// headers stored in the database for blocks whose work is not stored yet while
+// the chain work migration is in progress.
+//
+// This function MUST be called with either the chain state lock or the block
+// index lock held (for reads).
+func (b *BlockChain) calcChainWork(dbTx database.Tx, hash *chainhash.Hash) (*big.Int, error) {
+	// Walk backwards from the block until reaching either a block which has
+	// a node in memory, a block whose work is stored, or a block which is
+	// part of the main chain while summing the work of each block along the
+	// way.
+	//
+	// Note that a block which is being connected is part of the main chain
+	// in the database before its node is added to the block index, so main
+	// chain blocks after the best node are treated like any other block.
+	work := new(big.Int)
+	var height int64
+	for {
+		if node, ok := b.index[*hash]; ok {
+			return work.Add(work, node.workSum), nil
+		}
+		if workSum := dbFetchChainWork(dbTx, hash); workSum != nil {
+			return work.Add(work, workSum), nil
+		}
+		if dbMainChainHasBlock(dbTx, hash) {
+			var err error
+			height, err = dbFetchHeightByHash(dbTx, hash)
+			if err != nil {
+				return nil, err
+			}
+			if height <= b.bestNode.height {
+				break
+			}
+		}
+		header, err := dbFetchHeaderByHash(dbTx, hash)
+		if err != nil {
+			return nil, err
+		}
+		work.Add(work, CalcWork(header.Bits))
+		hash = &header.PrevBlock
+	}
+
+	// The block is part of the main chain prior to the oldest node in
+	// memory, so its work sum is that of the oldest node less the work of
+	// the main chain blocks after it.
+	oldest := b.bestNode
+	for oldest.parent != nil {
+		oldest = oldest.parent
+	}
+	if height > oldest.height {
+		return nil, AssertError(fmt.Sprintf("calcChainWork: main chain "+
+			"block %v at height %d is missing from the block index",
+			hash, height))
+	}
+	workSum := new(big.Int).Set(oldest.workSum)
+	header := &oldest.header
+	for h := oldest.height; h > height; h-- {
+		workSum.Sub(workSum, CalcWork(header.Bits))
+		var err error
+		header, err = dbFetchHeaderByHash(dbTx, &header.PrevBlock)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return work.Add(work, workSum), nil
+}
+
+// ChainWorkByHash returns the total work of the chain up to and including the
+// block with the passed hash.  The block must be stored, but it does not need
+// to be part of the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ChainWorkByHash(hash *chainhash.Hash) (*big.Int, error) {
+	b.indexLock.RLock()
+	defer b.indexLock.RUnlock()
+
+	var work *big.Int
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		work, err = b.calcChainWork(dbTx, hash)
+		return err
+	})
+	return work, err
+}
+
 // getReorganizeNodes finds the fork point between the main chain and the passed
 // node and returns a list of block nodes that would need to be detached from
 // the main chain and a list of block nodes that would need to be attached to
@@ -1284,6 +1527,8 @@ func (b *BlockChain) connectBlock(node *blockNode, block *dcrutil.Block,
 	blockSize := uint64(block.MsgBlock().Header.Size)
 	state := newBestState(node, blockSize, numTxns, curTotalTxns+numTxns,
 		curTotalSubsidy+subsidy)
+	addedSupply := b.addedCoinSupply(block, parent)
+	coinSupply := b.coinSupply.connect(node, &addedSupply)
 
 	// Get the stake node for this node, filling in any data that
 	// may have yet to have been filled in.  In all cases this
@@ -1294,7 +1539,21 @@ func (b *BlockChain) connectBlock(node *blockNode, block *dcrutil.Block,
 		return err
 	}
 
-	// Atomically insert info into the database.
+	// The treasury state is only tracked once the treasury agenda is
+	// active.
+	treasuryActive, err := b.isTreasuryAgendaActive(node.parent)
+	if err != nil {
+		return err
+	}
+
+	// Determine whether the utxo cache must be flushed along with the
+	// block.
+	flushUtxos := b.utxoCache.needsFlush(node.height)
+
+	// Atomically insert info into the database.  Queries of the utxo set
+	// are blocked until the best node reflects the block.
+	updateStart := time.Now()
+	b.utxoLock.Lock()
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -1302,6 +1561,12 @@ func (b *BlockChain) connectBlock(node *blockNode, block *dcrutil.Block,
 			return err
 		}
 
+		// Update the coin supply of the main chain.
+		err = dbPutCoinSupply(dbTx, &coinSupply)
+		if err != nil {
+			return err
+		}
+
 		// Add the block hash and height to the block index which tracks
 		// the main chain.
 		err = dbPutBlockIndex(dbTx, block.Hash(), node.height)
@@ -1309,14 +1574,26 @@ func (b *BlockChain) connectBlock(node *blockNode, block *dcrutil.Block,
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails removing all of the utxos spent and adding the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
+		// Store the total work of the chain up to the block so it does
+		// not need to be recalculated once the node is no longer in
+		// memory.
+		err = dbPutChainWork(dbTx, block.Hash(), node.workSum)
 		if err != nil {
 			return err
 		}
 
+		// Update the utxo set using the state of the utxo view when the
+		// utxo cache is flushed along with the block.  This entails
+		// removing all of the utxos spent and adding the new ones created
+		// by the block.  Otherwise, the view is only committed to the
+		// cache below.
+		if flushUtxos {
+			err = b.utxoCache.dbFlush(dbTx, view, node)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Update the transaction spend journal by adding a record for
 		// the block that contains all txos spent by it.
 		err = dbPutSpendJournalEntry(dbTx, block.Hash(), stxos)
@@ -1324,6 +1601,15 @@ func (b *BlockChain) connectBlock(node *blockNode, block *dcrutil.Block,
 			return err
 		}
 
+		// Update the treasury state with the changes applied by the
+		// block when the treasury agenda is active.
+		if treasuryActive {
+			err = dbConnectTreasury(dbTx, node, block, parent, stxos)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Insert the block into the database if it's not already there.
 		err = dbMaybeStoreBlock(dbTx, block)
 		if err != nil {
@@ -1349,21 +1635,28 @@ func (b *BlockChain) connectBlock(node *blockNode, block *dcrutil.Block,
 		return nil
 	})
 	if err != nil {
+		b.utxoLock.Unlock()
 		return err
 	}
+	b.validationStats.Record(StageUpdate, time.Since(updateStart))
 
-	// Prune fully spent entries and mark all entries in the view unmodified
-	// now that the modifications have been committed to the database.
+	// Commit the modifications to the utxo cache, and then prune fully
+	// spent entries and mark all entries in the view unmodified.
+	b.utxoCache.commit(view)
+	if flushUtxos {
+		b.utxoCache.markFlushed(node)
+	}
 	view.commit()
 
 	// Add the new node to the memory main chain indices for faster
-	// lookups.
+	// lookups.  This node is now the end of the best chain.
+	b.indexLock.Lock()
 	node.inMainChain = true
 	b.index[node.hash] = node
 	b.depNodes[prevHash] = append(b.depNodes[prevHash], node)
-
-	// This node is now the end of the best chain.
 	b.bestNode = node
+	b.indexLock.Unlock()
+	b.utxoLock.Unlock()
 
 	// Update the state for the best block.  Notice how this replaces the
 	// entire struct instead of updating the existing one.  This effectively
@@ -1372,8 +1665,16 @@ func (b *BlockChain) connectBlock(node *blockNode, block *dcrutil.Block,
 	// comments on the state variable for more details.
 	b.stateLock.Lock()
 	b.stateSnapshot = state
+	b.coinSupply = coinSupply
 	b.stateLock.Unlock()
 
+	// Record the hash of the utxo set which results from the block for the
+	// commitments of its children.
+	err = b.connectUtxoCommitment(node, block, parent, stxos)
+	if err != nil {
+		return err
+	}
+
 	// Send stake notifications about the new block.
 	if node.height >= b.chainParams.StakeEnabledHeight {
 		nextStakeDiff, err := b.calcNextRequiredStakeDifficulty(node)
@@ -1403,6 +1704,11 @@ func (b *BlockChain) connectBlock(node *blockNode, block *dcrutil.Block,
 			})
 	}
 
+	// Notify of any stake boundaries crossed by the new block.
+	for _, ntfn := range stakeBoundaries(node, stakeNode, b.chainParams) {
+		b.sendNotification(NTStakeBoundary, ntfn)
+	}
+
 	// Assemble the current block and the parent into a slice.
 	blockAndParent := []*dcrutil.Block{block, parent}
 
@@ -1425,6 +1731,10 @@ func (b *BlockChain) connectBlock(node *blockNode, block *dcrutil.Block,
 
 	b.pushMainChainBlockCache(block)
 
+	// Prune the data of old blocks as needed now that the block is
+	// connected.
+	b.pruneBlocksIfNeeded(node)
+
 	return nil
 }
 
@@ -1482,6 +1792,8 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *dcrutil.Block,
 
 	state := newBestState(prevNode, parentBlockSize, numTxns, newTotalTxns,
 		newTotalSubsidy)
+	addedSupply := b.addedCoinSupply(block, parent)
+	coinSupply := b.coinSupply.disconnect(prevNode, &addedSupply)
 
 	// Prepare the information required to update the stake database
 	// contents.
@@ -1494,6 +1806,16 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *dcrutil.Block,
 		return err
 	}
 
+	// Determine whether the utxo cache must be flushed along with the
+	// block.  The utxo set in the database must never represent a block
+	// which is not in the main chain, so the cache is always flushed when
+	// the block it was last flushed at is disconnected.
+	flushUtxos := b.utxoCache.isFlushedAt(node) ||
+		b.utxoCache.needsFlush(prevNode.height)
+
+	// Queries of the utxo set are blocked until the best node reflects the
+	// disconnected block.
+	b.utxoLock.Lock()
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -1501,6 +1823,12 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *dcrutil.Block,
 			return err
 		}
 
+		// Update the coin supply of the main chain.
+		err = dbPutCoinSupply(dbTx, &coinSupply)
+		if err != nil {
+			return err
+		}
+
 		// Remove the block hash and height from the block index which
 		// tracks the main chain.
 		err = dbRemoveBlockIndex(dbTx, block.Hash(), node.height)
@@ -1508,12 +1836,16 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *dcrutil.Block,
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails restoring all of the utxos spent and removing the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
-		if err != nil {
-			return err
+		// Update the utxo set using the state of the utxo view when the
+		// utxo cache is flushed along with the block.  This entails
+		// restoring all of the utxos spent and removing the new ones
+		// created by the block.  Otherwise, the view is only committed
+		// to the cache below.
+		if flushUtxos {
+			err = b.utxoCache.dbFlush(dbTx, view, prevNode)
+			if err != nil {
+				return err
+			}
 		}
 
 		// Update the transaction spend journal by removing the record
@@ -1523,6 +1855,13 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *dcrutil.Block,
 			return err
 		}
 
+		// Remove the treasury state as of the block, which leaves the
+		// state as of its parent.
+		err = dbRemoveTreasuryState(dbTx, block.Hash())
+		if err != nil {
+			return err
+		}
+
 		err = stake.WriteDisconnectedBestNode(dbTx, parentStakeNode,
 			node.parent.hash, childStakeNode.UndoData())
 		if err != nil {
@@ -1542,21 +1881,29 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *dcrutil.Block,
 		return nil
 	})
 	if err != nil {
+		b.utxoLock.Unlock()
 		return err
 	}
 
-	// Prune fully spent entries and mark all entries in the view unmodified
-	// now that the modifications have been committed to the database.
+	// Commit the modifications to the utxo cache, and then prune fully
+	// spent entries and mark all entries in the view unmodified.
+	b.utxoCache.commit(view)
+	if flushUtxos {
+		b.utxoCache.markFlushed(prevNode)
+	}
 	view.commit()
 
 	// Put block in the side chain cache.
-	node.inMainChain = false
 	b.blockCacheLock.Lock()
 	b.blockCache[node.hash] = block
 	b.blockCacheLock.Unlock()
 
 	// This node's parent is now the end of the best chain.
+	b.indexLock.Lock()
+	node.inMainChain = false
 	b.bestNode = node.parent
+	b.indexLock.Unlock()
+	b.utxoLock.Unlock()
 
 	// Update the state for the best block.  Notice how this replaces the
 	// entire struct instead of updating the existing one.  This effectively
@@ -1565,6 +1912,7 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *dcrutil.Block,
 	// comments on the state variable for more details.
 	b.stateLock.Lock()
 	b.stateSnapshot = state
+	b.coinSupply = coinSupply
 	b.stateLock.Unlock()
 
 	// Assemble the current block and the parent into a slice.
@@ -1628,7 +1976,9 @@ func countNumberOfTransactions(block, parent *dcrutil.Block) uint64 {
 // end of the current best chain.  Specifically, nodes that are being
 // disconnected must be in reverse order (think of popping them off the end of
 // the chain) and nodes the are being attached must be in forwards order
-// (think pushing them onto the end of the chain).
+// (think pushing them onto the end of the chain).  The attach list may be
+// empty, in which case the chain is only disconnected back to the fork point,
+// however, the detach list must not be empty then.
 //
 // The flags modify the behavior of this function as follows:
 //  - BFDryRun: Only the checks which ensure the reorganize can be completed
@@ -1640,6 +1990,19 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 	formerBestHash := b.bestNode.hash
 	formerBestHeight := b.bestNode.height
 
+	// Ensure the reorganize does not disconnect any final blocks.
+	if forkElem := detachNodes.Back(); forkElem != nil {
+		err := b.checkReorganizeFinality(forkElem.Value.(*blockNode))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Ensure none of the blocks to attach is known to be invalid.
+	if err := b.checkAttachNodes(attachNodes); err != nil {
+		return err
+	}
+
 	// Ensure all of the needed side chain blocks are in the cache.
 	for e := attachNodes.Front(); e != nil; e = e.Next() {
 		n := e.Value.(*blockNode)
@@ -1710,6 +2073,10 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 		if err != nil {
 			return err
 		}
+		err = b.disconnectUtxoCommitment(n, block, parent, stxos)
+		if err != nil {
+			return err
+		}
 
 		i++
 	}
@@ -1736,13 +2103,44 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 		// Notice the spent txout details are not requested here and
 		// thus will not be generated.  This is done because the state
 		// is not being immediately written to the database, so it is
-		// not needed.
-		err := b.checkConnectBlock(n, block, view, nil)
+		// not needed.  The exception is the experimental utxo
+		// commitments, which need them to calculate the utxo set the
+		// next block to attach commits to.
+		var stxos *[]spentTxOut
+		if b.utxoCommitments != nil {
+			stxos = new([]spentTxOut)
+		}
+		err := b.checkConnectBlock(n, block, view, stxos)
 		if err != nil {
 			return err
 		}
+		if stxos != nil {
+			parent, err := b.fetchBlockFromHash(&n.header.PrevBlock)
+			if err != nil {
+				return err
+			}
+			err = b.connectUtxoCommitment(n, block, parent, *stxos)
+			if err != nil {
+				return err
+			}
+		}
 		topBlock = n
 	}
+
+	// The fork point becomes the new best chain head when there are no
+	// blocks to attach.
+	if topBlock == nil {
+		forkElem := detachNodes.Back()
+		if forkElem == nil {
+			return AssertError("reorganizeChain called without any " +
+				"blocks to detach or attach")
+		}
+		var err error
+		topBlock, err = b.getPrevNodeFromNode(forkElem.Value.(*blockNode))
+		if err != nil {
+			return err
+		}
+	}
 	newHash := topBlock.hash
 	newHeight := topBlock.height
 	log.Debugf("New best chain validation completed successfully, " +
@@ -1785,7 +2183,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 
 		// Load all of the utxos referenced by the block that aren't
 		// already in the view.
-		err = view.fetchInputUtxos(b.db, block, parent)
+		err = view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return err
 		}
@@ -1835,23 +2233,30 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 		delete(b.blockCache, n.hash)
 	}
 
-	// Log the point where the chain forked.
-	firstAttachNode := attachNodes.Front().Value.(*blockNode)
-	forkNode, err := b.getPrevNodeFromNode(firstAttachNode)
-	if err == nil {
+	// Log the point where the chain forked, which is the new best chain
+	// head when there were no blocks to attach.
+	forkNode := b.bestNode
+	if firstAttachElem := attachNodes.Front(); firstAttachElem != nil {
+		var err error
+		firstAttachNode := firstAttachElem.Value.(*blockNode)
+		forkNode, err = b.getPrevNodeFromNode(firstAttachNode)
+		if err != nil {
+			forkNode = nil
+		}
+	}
+	if forkNode != nil {
 		log.Infof("REORGANIZE: Chain forks at %v, height %v",
 			forkNode.hash,
 			forkNode.height)
 	}
 
 	// Log the old and new best chain heads.
-	lastAttachNode := attachNodes.Back().Value.(*blockNode)
 	log.Infof("REORGANIZE: Old best chain head was %v, height %v",
 		formerBestHash,
 		formerBestHeight)
 	log.Infof("REORGANIZE: New best chain head is %v, height %v",
-		lastAttachNode.hash,
-		lastAttachNode.height)
+		b.bestNode.hash,
+		b.bestNode.height)
 
 	return nil
 }
@@ -1927,8 +2332,14 @@ func (b *BlockChain) forceHeadReorganization(formerBest chainhash.Hash, newBest
 	if err != nil {
 		return err
 	}
+	err = b.disconnectUtxoCommitment(formerBestNode, formerBestBlock,
+		commonParentBlock, stxos)
+	if err != nil {
+		return err
+	}
 
-	err = checkBlockSanity(newBestBlock, b.timeSource, BFNone, b.chainParams)
+	err = checkBlockSanity(newBestBlock, b.timeSource, b.clock, BFNone,
+		b.chainParams)
 	if err != nil {
 		return err
 	}
@@ -2009,7 +2420,7 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *dcrutil.Block,
 		// utxos, spend them, and add the new utxos being created by
 		// this block.
 		if fastAdd {
-			err := view.fetchInputUtxos(b.db, block, parent)
+			err := view.fetchInputUtxos(b.utxoCache, block, parent)
 			if err != nil {
 				return false, err
 			}
@@ -2027,7 +2438,9 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *dcrutil.Block,
 
 		// Connect the parent node to this node.
 		if node.parent != nil {
+			b.indexLock.Lock()
 			node.parent.children = append(node.parent.children, node)
+			b.indexLock.Unlock()
 		}
 
 		validateStr := "validating"
@@ -2060,21 +2473,25 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *dcrutil.Block,
 	b.blockCacheLock.Lock()
 	b.blockCache[node.hash] = block
 	b.blockCacheLock.Unlock()
+	b.indexLock.Lock()
 	b.index[node.hash] = node
 
 	// Connect the parent node to this node.
 	node.inMainChain = false
 	node.parent.children = append(node.parent.children, node)
+	b.indexLock.Unlock()
 
 	// Remove the block from the side chain cache and disconnect it from the
 	// parent node when the function returns when running in dry run mode.
 	if dryRun {
 		defer func() {
+			b.indexLock.Lock()
 			children := node.parent.children
 			children = removeChildNode(children, node)
 			node.parent.children = children
 
 			delete(b.index, node.hash)
+			b.indexLock.Unlock()
 			b.blockCacheLock.Lock()
 			delete(b.blockCache, node.hash)
 			b.blockCacheLock.Unlock()
@@ -2272,6 +2689,9 @@ type Config struct {
 
 	// TimeSource defines the median time source to use for things such as
 	// block processing and determining whether or not the chain is current.
+	// The local clock of time sources created by NewMedianTimeWithClock is
+	// also used for the checks which are not adjusted by the median time,
+	// such as ensuring block timestamps are not too far in the future.
 	//
 	// The caller is expected to keep a reference to the time source as well
 	// and add time samples from other peers on the network so the local
@@ -2302,6 +2722,71 @@ type Config struct {
 	// This field can be nil if the caller does not wish to make use of an
 	// index manager.
 	IndexManager IndexManager
+
+	// Checkpoints defines additional checkpoints, such as those supplied by
+	// the operator, which are combined with the checkpoints of the chain
+	// parameters.  They must not conflict with the checkpoints of the
+	// chain parameters.
+	//
+	// This field can be nil if the caller does not wish to add any
+	// checkpoints.
+	Checkpoints []chaincfg.Checkpoint
+
+	// AssumeValid defines the block which, along with all of its
+	// ancestors, is assumed to have valid scripts.  The scripts of those
+	// blocks are not validated while all other consensus rules are still
+	// enforced, and blocks at its height which do not match it are
+	// rejected.  The chain parameters define a default which callers
+	// typically provide.
+	//
+	// This field can be nil to validate the scripts of all blocks.
+	AssumeValid *chaincfg.Checkpoint
+
+	// UtxoCacheMaxSize defines the maximum number of bytes the cache of the
+	// utxo set may consume before it is flushed to the database.  See
+	// DefaultUtxoCacheMaxSize for a sensible default.
+	//
+	// A zero value results in the utxo set being written to the database
+	// along with every block.
+	UtxoCacheMaxSize uint64
+
+	// PruneTarget defines the target size in bytes of the stored block
+	// data.  The data of the oldest blocks is removed once it is exceeded,
+	// however, the blocks within the stake and reorganization safety
+	// windows are always kept, as are the headers of all blocks, the utxo
+	// set, and the ticket database.  It must be at least MinPruneTarget
+	// when set.
+	//
+	// A zero value disables pruning.  Note that optional indexes which
+	// are not caught up to the main chain are unable to index the blocks
+	// whose data was pruned.
+	PruneTarget uint64
+
+	// UtxoCommitments enables the experimental utxo commitments, which
+	// require the extra data of every block that is connected to commit to
+	// the utxo set which results from its parent.  See UtxoCommitment for
+	// the commitment to include in new blocks.
+	//
+	// It is only permitted on the simulation test network.
+	UtxoCommitments bool
+
+	// ScriptFlagsAdd and ScriptFlagsRemove are the names of script
+	// verification flags, as reported by the getblockchaininfo RPC, which
+	// are respectively added to and removed from the flags enforced by the
+	// consensus rules and required by the standardness policy regardless
+	// of the state of any agendas.  They allow script changes to be tested
+	// before the agendas which enable them are active.  Nodes which do not
+	// use the same overrides will reject the blocks of the others, so they
+	// are only permitted on the test networks.
+	ScriptFlagsAdd    []string
+	ScriptFlagsRemove []string
+
+	// ScriptFlagsHeight is the height of the first block the script
+	// verification flag overrides apply to, which keeps the blocks before
+	// it valid under the rules they were accepted with.
+	//
+	// A zero value applies the overrides to all blocks.
+	ScriptFlagsHeight int64
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -2313,23 +2798,60 @@ func New(config *Config) (*BlockChain, error) {
 	if config.ChainParams == nil {
 		return nil, AssertError("blockchain.New chain parameters nil")
 	}
+	if config.PruneTarget != 0 && config.PruneTarget < MinPruneTarget {
+		return nil, AssertError("blockchain.New prune target is less " +
+			"than the minimum")
+	}
+	if config.UtxoCommitments && config.ChainParams.Net != wire.SimNet {
+		return nil, AssertError("blockchain.New utxo commitments are " +
+			"only permitted on the simulation test network")
+	}
 
-	// Generate a checkpoint by height map from the provided checkpoints.
+	// The script verification flags may only be overridden on the test
+	// networks.
 	params := config.ChainParams
+	if params.Net == wire.MainNet && (len(config.ScriptFlagsAdd) > 0 ||
+		len(config.ScriptFlagsRemove) > 0) {
+		return nil, AssertError("blockchain.New script verification " +
+			"flags may not be overridden on the main network")
+	}
+	if config.ScriptFlagsHeight < 0 {
+		return nil, AssertError("blockchain.New script verification " +
+			"flag override height is negative")
+	}
+	scriptFlagsAdd, err := scriptFlagsFromNames(config.ScriptFlagsAdd)
+	if err != nil {
+		return nil, err
+	}
+	scriptFlagsRemove, err := scriptFlagsFromNames(config.ScriptFlagsRemove)
+	if err != nil {
+		return nil, err
+	}
+
+	// Combine the additional checkpoints with those of the chain
+	// parameters and generate a checkpoint by height map from them.
+	checkpoints, err := mergeCheckpoints(params.Checkpoints,
+		config.Checkpoints)
+	if err != nil {
+		return nil, err
+	}
 	var checkpointsByHeight map[int64]*chaincfg.Checkpoint
-	if len(params.Checkpoints) > 0 {
+	if len(checkpoints) > 0 {
 		checkpointsByHeight = make(map[int64]*chaincfg.Checkpoint)
-		for i := range params.Checkpoints {
-			checkpoint := &params.Checkpoints[i]
+		for i := range checkpoints {
+			checkpoint := &checkpoints[i]
 			checkpointsByHeight[checkpoint.Height] = checkpoint
 		}
 	}
 
 	b := BlockChain{
+		checkpoints:                   checkpoints,
 		checkpointsByHeight:           checkpointsByHeight,
+		assumeValid:                   config.AssumeValid,
 		db:                            config.DB,
 		chainParams:                   params,
 		timeSource:                    config.TimeSource,
+		clock:                         localClock(config.TimeSource),
 		notifications:                 config.Notifications,
 		sigCache:                      config.SigCache,
 		indexManager:                  config.IndexManager,
@@ -2341,12 +2863,21 @@ func New(config *Config) (*BlockChain, error) {
 		blockCache:                    make(map[chainhash.Hash]*dcrutil.Block),
 		mainchainBlockCache:           make(map[chainhash.Hash]*dcrutil.Block),
 		mainchainBlockCacheSize:       mainchainBlockCacheSize,
+		invalidBlocks:                 newKnownInvalidBlocks(),
 		deploymentCaches:              newThresholdCaches(params),
 		isVoterMajorityVersionCache:   make(map[[stakeMajorityCacheKeySize]byte]bool),
 		isStakeMajorityVersionCache:   make(map[[stakeMajorityCacheKeySize]byte]bool),
 		calcPriorStakeVersionCache:    make(map[[chainhash.HashSize]byte]uint32),
 		calcVoterVersionIntervalCache: make(map[[chainhash.HashSize]byte]uint32),
 		calcStakeVersionCache:         make(map[[chainhash.HashSize]byte]uint32),
+		utxoCache:                     newUtxoCache(config.DB, config.UtxoCacheMaxSize),
+		pruneTarget:                   config.PruneTarget,
+		scriptFlagsAdd:                scriptFlagsAdd,
+		scriptFlagsRemove:             scriptFlagsRemove,
+		scriptFlagsHeight:             config.ScriptFlagsHeight,
+	}
+	if config.UtxoCommitments {
+		b.utxoCommitments = make(map[chainhash.Hash]*utxoSetState)
 	}
 
 	// Initialize the chain state from the passed database.  When the db
@@ -2356,6 +2887,23 @@ func New(config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
+	// Catch the utxo set up to the best chain when the utxo cache was not
+	// flushed before the last shutdown.
+	if err := b.initUtxoCache(); err != nil {
+		return nil, err
+	}
+
+	// Calculate the hash of the utxo set for the experimental utxo
+	// commitments.
+	if err := b.initUtxoCommitments(); err != nil {
+		return nil, err
+	}
+
+	// Load the blocks which were manually invalidated.
+	if err := b.loadInvalidatedBlocks(); err != nil {
+		return nil, err
+	}
+
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	if config.IndexManager != nil {
@@ -2370,8 +2918,22 @@ func New(config *Config) (*BlockChain, error) {
 	}
 
 	b.subsidyCache = NewSubsidyCache(b.bestNode.height, b.chainParams)
+
+	// Load the coin supply of the main chain, which also requires the
+	// subsidy cache.
+	if err := b.initCoinSupply(); err != nil {
+		return nil, err
+	}
 	b.pruner = newChainPruner(&b)
 
+	// Prune the data of old blocks which were connected while pruning was
+	// not enabled or its target was larger.
+	if b.pruneTarget != 0 {
+		if err := b.pruneBlocks(); err != nil {
+			return nil, err
+		}
+	}
+
 	log.Infof("Blockchain database version %v loaded",
 		b.dbInfo.version)
 
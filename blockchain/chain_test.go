@@ -9,11 +9,13 @@ import (
 	"bytes"
 	"compress/bzip2"
 	"encoding/gob"
+	"math/big"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrutil"
 )
 
@@ -98,3 +100,178 @@ func TestBlockchainFunctions(t *testing.T) {
 			totalSubsidy)
 	}
 }
+
+// TestChainWorkByHash ensures the total work of the chain up to each main
+// chain block is the sum of the work of the blocks up to and including it.
+func TestChainWorkByHash(t *testing.T) {
+	chain, teardownFunc, err := chainSetup("chainworkunittest",
+		simNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	blocks, err := loadTestBlocks("blocks0to168.bz2")
+	if err != nil {
+		t.Fatalf("Failed to load chain: %v", err)
+	}
+	const tipHeight = 60
+	genesisHeader := &simNetParams.GenesisBlock.Header
+	wantWork := []*big.Int{blockchain.CalcWork(genesisHeader.Bits)}
+	hashes := []*chainhash.Hash{simNetParams.GenesisHash}
+	for i := int64(1); i <= tipHeight; i++ {
+		bl, err := dcrutil.NewBlockFromBytes(blocks[i])
+		if err != nil {
+			t.Fatalf("NewBlockFromBytes error: %v", err)
+		}
+		bl.SetHeight(i)
+		_, _, err = chain.ProcessBlock(bl, blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock error at height %v: %v", i, err)
+		}
+		work := blockchain.CalcWork(bl.MsgBlock().Header.Bits)
+		wantWork = append(wantWork, work.Add(work, wantWork[i-1]))
+		hashes = append(hashes, bl.Hash())
+	}
+
+	for height, hash := range hashes {
+		work, err := chain.ChainWorkByHash(hash)
+		if err != nil {
+			t.Fatalf("ChainWorkByHash(%v): unexpected error: %v", hash,
+				err)
+		}
+		if work.Cmp(wantWork[height]) != 0 {
+			t.Fatalf("ChainWorkByHash(%v): got %v, want %v", hash,
+				work, wantWork[height])
+		}
+	}
+	if best := chain.BestSnapshot(); best.WorkSum.Cmp(wantWork[tipHeight]) != 0 {
+		t.Fatalf("BestSnapshot: got work sum %v, want %v", best.WorkSum,
+			wantWork[tipHeight])
+	}
+
+	// Ensure requesting the work of an unknown block fails.
+	if _, err := chain.ChainWorkByHash(&chainhash.Hash{0x01}); err == nil {
+		t.Fatal("ChainWorkByHash: did not receive expected error for " +
+			"unknown block")
+	}
+
+	// Ensure the work of every block is stored as it is connected, and that
+	// the background migration stores it again once it is removed.
+	checkStoredWork := func(desc string) {
+		for height, hash := range hashes {
+			work, err := chain.TstStoredChainWork(hash)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", desc, err)
+			}
+			if work == nil || work.Cmp(wantWork[height]) != 0 {
+				t.Fatalf("%s: got stored work %v for block %v, "+
+					"want %v", desc, work, hash, wantWork[height])
+			}
+		}
+	}
+	checkStoredWork("connected blocks")
+	if err := chain.TstRemoveStoredChainWork(); err != nil {
+		t.Fatalf("TstRemoveStoredChainWork: unexpected error: %v", err)
+	}
+	if work, _ := chain.TstStoredChainWork(hashes[1]); work != nil {
+		t.Fatalf("TstRemoveStoredChainWork: work is still stored")
+	}
+	for height, hash := range hashes {
+		work, err := chain.ChainWorkByHash(hash)
+		if err != nil || work.Cmp(wantWork[height]) != 0 {
+			t.Fatalf("ChainWorkByHash(%v): got %v (err %v) without "+
+				"stored work, want %v", hash, work, err,
+				wantWork[height])
+		}
+	}
+	if err := chain.RunMigrations(make(chan struct{})); err != nil {
+		t.Fatalf("RunMigrations: unexpected error: %v", err)
+	}
+	checkStoredWork("migrated blocks")
+}
+
+// TestStakeBoundaryNotifications ensures stake boundary notifications are sent
+// for the blocks which begin a new ticket price interval or mature tickets.
+func TestStakeBoundaryNotifications(t *testing.T) {
+	var ntfns []*blockchain.StakeBoundaryNtfnsData
+	callback := func(n *blockchain.Notification) {
+		if n.Type == blockchain.NTStakeBoundary {
+			ntfn := n.Data.(*blockchain.StakeBoundaryNtfnsData)
+			ntfns = append(ntfns, ntfn)
+		}
+	}
+	chain, teardownFunc, err := chainSetupWithNotifications(
+		"stakeboundaryunittest", simNetParams, callback)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	blocks, err := loadTestBlocks("blocks0to168.bz2")
+	if err != nil {
+		t.Fatalf("Failed to load chain: %v", err)
+	}
+	const tipHeight = 60
+	sbits := []int64{simNetParams.GenesisBlock.Header.SBits}
+	for i := int64(1); i <= tipHeight; i++ {
+		bl, err := dcrutil.NewBlockFromBytes(blocks[i])
+		if err != nil {
+			t.Fatalf("NewBlockFromBytes error: %v", err)
+		}
+		bl.SetHeight(i)
+		_, _, err = chain.ProcessBlock(bl, blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock error at height %v: %v", i, err)
+		}
+		sbits = append(sbits, bl.MsgBlock().Header.SBits)
+	}
+
+	window := simNetParams.StakeDiffWindowSize
+	var numIntervals, numMatured int
+	for _, ntfn := range ntfns {
+		if ntfn.StakeDifficulty != sbits[ntfn.Height] {
+			t.Fatalf("%v at height %d: got stake difficulty %d, "+
+				"want %d", ntfn.Boundary, ntfn.Height,
+				ntfn.StakeDifficulty, sbits[ntfn.Height])
+		}
+		switch ntfn.Boundary {
+		case blockchain.SBTicketPriceInterval:
+			numIntervals++
+			if ntfn.Height%window != 0 {
+				t.Fatalf("ticket price interval at height %d",
+					ntfn.Height)
+			}
+			if ntfn.PrevStakeDifficulty != sbits[ntfn.Height-1] {
+				t.Fatalf("ticket price interval at height %d: "+
+					"got previous stake difficulty %d, want %d",
+					ntfn.Height, ntfn.PrevStakeDifficulty,
+					sbits[ntfn.Height-1])
+			}
+			if ntfn.IntervalEndHeight != ntfn.Height+window-1 {
+				t.Fatalf("ticket price interval at height %d: "+
+					"got interval end height %d", ntfn.Height,
+					ntfn.IntervalEndHeight)
+			}
+
+		case blockchain.SBTicketsMatured:
+			numMatured++
+			if len(ntfn.Tickets) == 0 || ntfn.PoolSize == 0 {
+				t.Fatalf("tickets matured at height %d: no "+
+					"tickets in notification %+v", ntfn.Height,
+					ntfn)
+			}
+
+		default:
+			t.Fatalf("unexpected stake boundary %v at height %d",
+				ntfn.Boundary, ntfn.Height)
+		}
+	}
+	if want := int(tipHeight / window); numIntervals != want {
+		t.Fatalf("got %d ticket price interval notifications, want %d",
+			numIntervals, want)
+	}
+	if numMatured == 0 {
+		t.Fatal("did not receive any tickets matured notifications")
+	}
+}
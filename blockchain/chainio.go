@@ -73,7 +73,7 @@ const (
 
 	// currentDatabaseVersion indicates what the current database
 	// version is.
-	currentDatabaseVersion = 2
+	currentDatabaseVersion = 3
 )
 
 // errNotInMainChain signifies that a block hash or height that is not in the
@@ -115,6 +115,13 @@ func isDbBucketNotFoundErr(err error) bool {
 	return ok && dbErr.ErrorCode == database.ErrBucketNotFound
 }
 
+// isDbBlockPrunedErr returns whether or not the passed error is a
+// database.Error with an error code of database.ErrBlockPruned.
+func isDbBlockPrunedErr(err error) bool {
+	dbErr, ok := err.(database.Error)
+	return ok && dbErr.ErrorCode == database.ErrBlockPruned
+}
+
 // -----------------------------------------------------------------------------
 // The staking system requires some extra information to be stored for tickets
 // to maintain consensus rules. The full set of minimal outputs are thus required
@@ -615,10 +622,12 @@ func dbRemoveSpendJournalEntry(dbTx database.Tx, blockHash *chainhash.Hash) erro
 }
 
 // -----------------------------------------------------------------------------
-// The unspent transaction output (utxo) set consists of an entry for each
-// transaction which contains a utxo serialized using a format that is highly
-// optimized to reduce space using domain specific compression algorithms.  This
-// format is a slightly modified version of the format used in Bitcoin Core.
+// The legacy unspent transaction output (utxo) set consists of an entry for
+// each transaction which contains a utxo serialized using a format that is
+// highly optimized to reduce space using domain specific compression
+// algorithms.  This format is a slightly modified version of the format used in
+// Bitcoin Core.  It has been superseded by the version 2 utxo set described
+// below and is only read while migrating existing databases.
 //
 // The serialized format is:
 //
@@ -926,16 +935,240 @@ func deserializeUtxoEntry(serialized []byte) (*UtxoEntry, error) {
 	return entry, nil
 }
 
+// -----------------------------------------------------------------------------
+// The version 2 unspent transaction output (utxo) set consists of an entry for
+// each unspent output keyed by its outpoint, which avoids rewriting all of the
+// remaining unspent outputs of a transaction each time one of them is spent.
+// The details of the containing transaction are repeated in every entry so
+// that each entry is self contained.
+//
+// The serialized key format is:
+//
+//   <hash><output index>
+//
+//   Field                 Type             Size
+//   hash                  chainhash.Hash   chainhash.HashSize
+//   output index          VLQ              variable
+//
+// The serialized value format is:
+//
+//   <version><height><index><flags><compressed txout>[<stakeExtra>]
+//
+//   Field                 Type     Size
+//   transaction version   VLQ      variable
+//   block height          VLQ      variable
+//   block index           VLQ      variable
+//   flags                 VLQ      variable (currently 1 byte)
+//   compressed txout
+//     compressed amount   VLQ      variable
+//     compressed version  VLQ      variable
+//     compressed script   []byte   variable
+//   stakeExtra            []byte   variable
+//
+// The flags and stake extra fields are identical to those of the legacy
+// format described above.  In particular, the stake extra field is only
+// encoded for tickets.
+// -----------------------------------------------------------------------------
+
+// outpointKey returns the key used to store the unspent output at the provided
+// output index of the transaction with the provided hash in the version 2 utxo
+// set.
+func outpointKey(hash *chainhash.Hash, outputIndex uint32) []byte {
+	key := make([]byte, chainhash.HashSize+serializeSizeVLQ(uint64(outputIndex)))
+	copy(key, hash[:])
+	putVLQ(key[chainhash.HashSize:], uint64(outputIndex))
+	return key
+}
+
+// decodeOutpointKey returns the output index encoded in the provided version 2
+// utxo set key.
+func decodeOutpointKey(key []byte) (uint32, error) {
+	if len(key) <= chainhash.HashSize {
+		return 0, errDeserialize("unexpected end of data for outpoint key")
+	}
+	outputIndex, _ := deserializeVLQ(key[chainhash.HashSize:])
+	return uint32(outputIndex), nil
+}
+
+// serializeUtxoOutput returns the provided output of the provided entry
+// serialized to the version 2 format that is suitable for long-term storage.
+// The format is described in detail above.
+func serializeUtxoOutput(entry *UtxoEntry, out *utxoOutput) []byte {
+	// Calculate the size needed to serialize the output.
+	flags := encodeFlags(entry.isCoinBase, entry.hasExpiry, entry.txType, false)
+	size := serializeSizeVLQ(uint64(entry.txVersion)) +
+		serializeSizeVLQ(uint64(entry.height)) +
+		serializeSizeVLQ(uint64(entry.index)) +
+		serializeSizeVLQ(uint64(flags)) +
+		compressedTxOutSize(uint64(out.amount), out.scriptVersion,
+			out.pkScript, currentCompressionVersion, out.compressed, true)
+	if entry.txType == stake.TxTypeSStx {
+		size += len(entry.stakeExtra)
+	}
+
+	// Serialize the version, block height, block index, and flags of the
+	// containing transaction followed by the compressed output.  Outputs
+	// that are already compressed are serialized without modifications.
+	serialized := make([]byte, size)
+	offset := putVLQ(serialized, uint64(entry.txVersion))
+	offset += putVLQ(serialized[offset:], uint64(entry.height))
+	offset += putVLQ(serialized[offset:], uint64(entry.index))
+	offset += putVLQ(serialized[offset:], uint64(flags))
+	offset += putCompressedTxOut(serialized[offset:], uint64(out.amount),
+		out.scriptVersion, out.pkScript, currentCompressionVersion,
+		out.compressed, true)
+
+	if entry.txType == stake.TxTypeSStx {
+		copy(serialized[offset:], entry.stakeExtra)
+	}
+
+	return serialized
+}
+
+// deserializeUtxoOutput decodes a version 2 utxo entry from the passed
+// serialized byte slice into a new UtxoEntry which contains the details of the
+// containing transaction and no outputs along with the output it describes.
+// The format is described in detail above.
+func deserializeUtxoOutput(serialized []byte) (*UtxoEntry, *utxoOutput, error) {
+	// Deserialize the version.
+	version, bytesRead := deserializeVLQ(serialized)
+	offset := bytesRead
+	if offset >= len(serialized) {
+		return nil, nil, errDeserialize("unexpected end of data after " +
+			"version")
+	}
+
+	// Deserialize the block height.
+	blockHeight, bytesRead := deserializeVLQ(serialized[offset:])
+	offset += bytesRead
+	if offset >= len(serialized) {
+		return nil, nil, errDeserialize("unexpected end of data after " +
+			"height")
+	}
+
+	// Deserialize the block index.
+	blockIndex, bytesRead := deserializeVLQ(serialized[offset:])
+	offset += bytesRead
+	if offset >= len(serialized) {
+		return nil, nil, errDeserialize("unexpected end of data after " +
+			"index")
+	}
+
+	// Deserialize the flags.
+	flags, bytesRead := deserializeVLQ(serialized[offset:])
+	offset += bytesRead
+	if offset >= len(serialized) {
+		return nil, nil, errDeserialize("unexpected end of data after " +
+			"flags")
+	}
+	isCoinBase, hasExpiry, txType, _ := decodeFlags(byte(flags))
+
+	// Decode the output.  The script and amount fields of the output are
+	// left compressed so decompression can be avoided on those that are not
+	// accessed.
+	//
+	// 'true' below instructs the method to deserialize a stored amount.
+	amount, scriptVersion, compScript, bytesRead, err :=
+		decodeCompressedTxOut(serialized[offset:], currentCompressionVersion,
+			true)
+	if err != nil {
+		return nil, nil, errDeserialize(fmt.Sprintf("unable to decode "+
+			"utxo: %v", err))
+	}
+	offset += bytesRead
+
+	entry := newUtxoEntry(int32(version), uint32(blockHeight),
+		uint32(blockIndex), isCoinBase, hasExpiry, txType)
+	out := &utxoOutput{
+		spent:         false,
+		compressed:    true,
+		scriptVersion: scriptVersion,
+		pkScript:      compScript,
+		amount:        amount,
+	}
+
+	// Copy the stake extra data if this was a ticket.
+	if entry.txType == stake.TxTypeSStx {
+		stakeExtra := make([]byte, len(serialized[offset:]))
+		copy(stakeExtra, serialized[offset:])
+		entry.stakeExtra = stakeExtra
+	}
+
+	return entry, out, nil
+}
+
+// dbFetchUtxoOutputs loads all of the unspent outputs for the provided
+// transaction hash from the provided version 2 utxo set bucket.  The outputs
+// are all stored under keys that are prefixed by the hash, so it seeks to the
+// first one and iterates until the prefix no longer matches.
+//
+// When there are no outputs for the provided hash, nil will be returned for the
+// both the entry and the error.
+func dbFetchUtxoOutputs(utxoBucket database.Bucket, hash *chainhash.Hash) (*UtxoEntry, error) {
+	var entry *UtxoEntry
+	cursor := utxoBucket.Cursor()
+	for ok := cursor.Seek(hash[:]); ok; ok = cursor.Next() {
+		key := cursor.Key()
+		if !bytes.HasPrefix(key, hash[:]) {
+			break
+		}
+
+		outputIndex, err := decodeOutpointKey(key)
+		if err != nil {
+			return nil, err
+		}
+		outEntry, out, err := deserializeUtxoOutput(cursor.Value())
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			entry = outEntry
+		}
+		entry.sparseOutputs[outputIndex] = out
+	}
+
+	return entry, nil
+}
+
 // dbFetchUtxoEntry uses an existing database transaction to fetch all unspent
-// outputs for the provided Bitcoin transaction hash from the utxo set.
+// outputs for the provided Decred transaction hash from the utxo set.
+//
+// Entries which have not been migrated to the version 2 utxo set yet are
+// loaded from the legacy utxo set when it still exists.
 //
 // When there is no entry for the provided hash, nil will be returned for the
 // both the entry and the error.
 func dbFetchUtxoEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error) {
-	// Fetch the unspent transaction output information for the passed
-	// transaction hash.  Return now when there is no entry.
-	utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
-	serializedUtxo := utxoBucket.Get(hash[:])
+	// Attempt to load the entry from the version 2 utxo set first.  Note
+	// that the bucket does not exist until the migration to it starts.
+	meta := dbTx.Metadata()
+	if utxoBucket := meta.Bucket(dbnamespace.UtxoSetV2BucketName); utxoBucket != nil {
+		entry, err := dbFetchUtxoOutputs(utxoBucket, hash)
+		if err != nil {
+			// Ensure any deserialization errors are returned as
+			// database corruption errors.
+			if isDeserializeErr(err) {
+				return nil, database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt utxo "+
+						"entry for %v: %v", hash, err),
+				}
+			}
+
+			return nil, err
+		}
+		if entry != nil {
+			return entry, nil
+		}
+	}
+
+	// Fall back to the legacy utxo set when it has not been fully migrated
+	// yet.  Return now when there is no entry.
+	legacyBucket := meta.Bucket(dbnamespace.UtxoSetBucketName)
+	if legacyBucket == nil {
+		return nil, nil
+	}
+	serializedUtxo := legacyBucket.Get(hash[:])
 	if serializedUtxo == nil {
 		return nil, nil
 	}
@@ -966,42 +1199,98 @@ func dbFetchUtxoEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error
 	return entry, nil
 }
 
+// dbRemoveUtxoEntry uses an existing database transaction to remove all
+// unspent outputs for the provided transaction hash from the version 2 utxo
+// set.
+func dbRemoveUtxoEntry(utxoBucket database.Bucket, hash *chainhash.Hash) error {
+	// Collect the keys before removing them since modifying the bucket
+	// while iterating it with a cursor is not allowed.
+	var keys [][]byte
+	cursor := utxoBucket.Cursor()
+	for ok := cursor.Seek(hash[:]); ok; ok = cursor.Next() {
+		key := cursor.Key()
+		if !bytes.HasPrefix(key, hash[:]) {
+			break
+		}
+		keys = append(keys, append([]byte(nil), key...))
+	}
+	for _, key := range keys {
+		if err := utxoBucket.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dbPutUtxoEntry uses an existing database transaction to store the provided
+// utxo entry for the transaction with the provided hash in the version 2 utxo
+// set.  Only the outputs which have been marked as modified are written unless
+// the all flag is set, in which case every unspent output is written.
+func dbPutUtxoEntry(utxoBucket database.Bucket, hash *chainhash.Hash, entry *UtxoEntry, all bool) error {
+	// Remove all of the outputs of the transaction when it is now fully
+	// spent.  This is done by prefix since the outputs of transactions which
+	// were disconnected are no longer tracked by the entry.
+	if entry.IsFullySpent() {
+		return dbRemoveUtxoEntry(utxoBucket, hash)
+	}
+
+	for outputIndex, out := range entry.sparseOutputs {
+		if !out.modified && !all {
+			continue
+		}
+
+		key := outpointKey(hash, outputIndex)
+		if out.spent {
+			if err := utxoBucket.Delete(key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := utxoBucket.Put(key, serializeUtxoOutput(entry, out))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // dbPutUtxoView uses an existing database transaction to update the utxo set
 // in the database based on the provided utxo view contents and state.  In
-// particular, only the entries that have been marked as modified are written
-// to the database.
+// particular, only the outputs of entries that have been marked as modified are
+// written to the database.
+//
+// Entries which still exist in the legacy utxo set are migrated to the version
+// 2 utxo set as they are written.
 func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
-	utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+	meta := dbTx.Metadata()
+	utxoBucket := meta.Bucket(dbnamespace.UtxoSetV2BucketName)
+	legacyBucket := meta.Bucket(dbnamespace.UtxoSetBucketName)
 	for txHashIter, entry := range view.entries {
 		// No need to update the database if the entry was not modified.
 		if entry == nil || !entry.modified {
 			continue
 		}
 
-		// Serialize the utxo entry without any entries that have been
-		// spent.
-		serialized, err := serializeUtxoEntry(entry)
-		if err != nil {
-			return err
-		}
-
 		// Make a copy of the hash because the iterator changes on each
 		// loop iteration and thus slicing it directly would cause the
 		// data to change out from under the put/delete funcs below.
 		txHash := txHashIter
 
-		// Remove the utxo entry if it is now fully spent.
-		if serialized == nil {
-			if err := utxoBucket.Delete(txHash[:]); err != nil {
+		// Remove the entry from the legacy utxo set when it has not been
+		// migrated yet and write all of its unspent outputs since they
+		// are not in the version 2 utxo set.
+		var migrate bool
+		if legacyBucket != nil && legacyBucket.Get(txHash[:]) != nil {
+			if err := legacyBucket.Delete(txHash[:]); err != nil {
 				return err
 			}
-
-			continue
+			migrate = true
 		}
 
-		// At this point the utxo entry is not fully spent, so store its
-		// serialization in the database.
-		err = utxoBucket.Put(txHash[:], serialized)
+		err := dbPutUtxoEntry(utxoBucket, &txHash, entry, migrate)
 		if err != nil {
 			return err
 		}
@@ -1080,6 +1369,12 @@ func dbFetchHeightByHash(dbTx database.Tx, hash *chainhash.Hash) (int64, error)
 	return int64(dbnamespace.ByteOrder.Uint32(serializedHeight)), nil
 }
 
+// DBFetchHeightByHash uses an existing database transaction to retrieve the
+// height of the main chain block with the provided hash.
+func DBFetchHeightByHash(dbTx database.Tx, hash *chainhash.Hash) (int64, error) {
+	return dbFetchHeightByHash(dbTx, hash)
+}
+
 // dbFetchHashByHeight uses an existing database transaction to retrieve the
 // hash for the provided height from the index.
 func dbFetchHashByHeight(dbTx database.Tx, height int64) (*chainhash.Hash, error) {
@@ -1099,6 +1394,44 @@ func dbFetchHashByHeight(dbTx database.Tx, height int64) (*chainhash.Hash, error
 	return &hash, nil
 }
 
+// -----------------------------------------------------------------------------
+// The chain work bucket contains an entry for every block which has been part
+// of the main chain.  The key is the block hash and the value is the total work
+// of the chain up to and including the block.  Since the total work of a block
+// does not depend on the main chain, entries are not removed when blocks are
+// disconnected.
+//
+// The serialized format for values in the bucket is:
+//   <work sum>
+//
+//   Field      Type     Size
+//   work sum   big.Int  variable (big endian)
+// -----------------------------------------------------------------------------
+
+// dbPutChainWork uses an existing database transaction to store the total work
+// of the chain up to and including the block with the passed hash.
+func dbPutChainWork(dbTx database.Tx, hash *chainhash.Hash, workSum *big.Int) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.ChainWorkBucketName)
+	return bucket.Put(hash[:], workSum.Bytes())
+}
+
+// dbFetchChainWork uses an existing database transaction to fetch the total
+// work of the chain up to and including the block with the passed hash.  Nil is
+// returned when the work of the block is not stored, which is the case for
+// blocks which have never been part of the main chain and blocks which were
+// connected before the bucket was created and have not been migrated yet.
+func dbFetchChainWork(dbTx database.Tx, hash *chainhash.Hash) *big.Int {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.ChainWorkBucketName)
+	if bucket == nil {
+		return nil
+	}
+	serialized := bucket.Get(hash[:])
+	if serialized == nil {
+		return nil
+	}
+	return new(big.Int).SetBytes(serialized)
+}
+
 // -----------------------------------------------------------------------------
 // The database information contains information about the version and date
 // of the blockchain database.
@@ -1314,6 +1647,24 @@ func dbPutBestState(dbTx database.Tx, snapshot *BestState,
 	return dbTx.Metadata().Put(dbnamespace.ChainStateKeyName, serializedData)
 }
 
+// dbFetchBestState uses an existing database transaction to fetch the best
+// chain state.  Since the state is updated along with the main chain, it
+// describes the main chain exactly as it is seen by the transaction.
+func dbFetchBestState(dbTx database.Tx) (bestChainState, error) {
+	serializedData := dbTx.Metadata().Get(dbnamespace.ChainStateKeyName)
+	return deserializeBestChainState(serializedData)
+}
+
+// DBFetchBestHeight uses an existing database transaction to fetch the height
+// of the end of the main chain as it is seen by the transaction.
+func DBFetchBestHeight(dbTx database.Tx) (int64, error) {
+	state, err := dbFetchBestState(dbTx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(state.height), nil
+}
+
 // createChainState initializes both the database and the chain state to the
 // genesis block.  This includes creating the necessary buckets and inserting
 // the genesis block, so it must only be called on an uninitialized database.
@@ -1379,7 +1730,7 @@ func (b *BlockChain) createChainState() error {
 		// Create the bucket that houses the utxo set.  Note that the
 		// genesis block coinbase transaction is intentionally not
 		// inserted here since it is not spendable by consensus rules.
-		_, err = meta.CreateBucket(dbnamespace.UtxoSetBucketName)
+		_, err = meta.CreateBucket(dbnamespace.UtxoSetV2BucketName)
 		if err != nil {
 			return err
 		}
@@ -1391,6 +1742,17 @@ func (b *BlockChain) createChainState() error {
 			return err
 		}
 
+		// Create the bucket that houses the total work of the main chain
+		// blocks and add the work of the genesis block.
+		_, err = meta.CreateBucket(dbnamespace.ChainWorkBucketName)
+		if err != nil {
+			return err
+		}
+		err = dbPutChainWork(dbTx, &b.bestNode.hash, b.bestNode.workSum)
+		if err != nil {
+			return err
+		}
+
 		// Store the current best chain state into the database.
 		err = dbPutBestState(dbTx, b.stateSnapshot, b.bestNode.workSum)
 		if err != nil {
@@ -1709,11 +2071,10 @@ func (b *BlockChain) BlockByHeight(blockHeight int64) (*dcrutil.Block, error) {
 // BlockByHash returns the block from the main chain with the given hash with
 // the appropriate chain height set.
 //
-// This function is safe for concurrent access.
+// This function is safe for concurrent access.  It does not wait for blocks
+// being connected since the block caches and the database it reads from are
+// safe for concurrent access on their own.
 func (b *BlockChain) BlockByHash(hash *chainhash.Hash) (*dcrutil.Block, error) {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
-
 	return b.fetchBlockFromHash(hash)
 }
 
@@ -1735,31 +2096,33 @@ func (b *BlockChain) HeightRange(startHeight, endHeight int64) ([]chainhash.Hash
 	}
 
 	// There is nothing to do when the start and end heights are the same,
-	// so return now to avoid the chain lock and a database transaction.
+	// so return now to avoid a database transaction.
 	if startHeight == endHeight {
 		return nil, nil
 	}
 
-	// Grab a lock on the chain to prevent it from changing due to a reorg
-	// while building the hashes.
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
-
-	// When the requested start height is after the most recent best chain
-	// height, there is nothing to do.
-	latestHeight := b.bestNode.height
-	if startHeight > latestHeight {
-		return nil, nil
-	}
-
-	// Limit the ending height to the latest height of the chain.
-	if endHeight > latestHeight+1 {
-		endHeight = latestHeight + 1
-	}
-
-	// Fetch as many as are available within the specified range.
+	// Fetch as many as are available within the specified range.  The
+	// latest height of the chain is read from the same database transaction
+	// as the hashes, so the hashes are consistent even when a reorg happens
+	// concurrently, without waiting for blocks being connected.
 	var hashList []chainhash.Hash
 	err := b.db.View(func(dbTx database.Tx) error {
+		// When the requested start height is after the most recent best
+		// chain height, there is nothing to do.
+		state, err := dbFetchBestState(dbTx)
+		if err != nil {
+			return err
+		}
+		latestHeight := int64(state.height)
+		if startHeight > latestHeight {
+			return nil
+		}
+
+		// Limit the ending height to the latest height of the chain.
+		if endHeight > latestHeight+1 {
+			endHeight = latestHeight + 1
+		}
+
 		hashes := make([]chainhash.Hash, 0, endHeight-startHeight)
 		for i := startHeight; i < endHeight; i++ {
 			hash, err := dbFetchHashByHeight(dbTx, i)
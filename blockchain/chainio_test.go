@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/decred/dcrd/blockchain/stake"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/database"
 	"github.com/decred/dcrd/wire"
@@ -1017,6 +1018,216 @@ func TestUtxoEntryDeserializeErrors(t *testing.T) {
 	}
 }
 
+// TestUtxoOutputSerialization ensures serializing and deserializing individual
+// unspent outputs in the version 2 utxo set format works as expected.
+func TestUtxoOutputSerialization(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		entry       *UtxoEntry
+		outputIndex uint32
+		key         []byte
+		serialized  []byte
+	}{
+		{
+			name: "output 0, not coinbase, has expiry",
+			entry: &UtxoEntry{
+				txVersion:  1,
+				isCoinBase: false,
+				hasExpiry:  true,
+				txType:     stake.TxTypeRegular,
+				height:     99999,
+				index:      3,
+				sparseOutputs: map[uint32]*utxoOutput{
+					0: {
+						amount:        20000000,
+						scriptVersion: 0,
+						pkScript:      hexToBytes("76a914e2ccd6ec7c6e2e581349c77e067385fa8236bf8a88ac"),
+						compressed:    false,
+					},
+				},
+			},
+			outputIndex: 0,
+			key:         hexToBytes("000000000000000000000000000000000000000000000000000000000000000000"),
+			serialized:  hexToBytes("01858c1f0302120000e2ccd6ec7c6e2e581349c77e067385fa8236bf8a"),
+		},
+		{
+			name: "output 300, coinbase",
+			entry: &UtxoEntry{
+				txVersion:  1,
+				isCoinBase: true,
+				hasExpiry:  false,
+				txType:     stake.TxTypeRegular,
+				height:     12345,
+				index:      0,
+				sparseOutputs: map[uint32]*utxoOutput{
+					300: {
+						amount:        15000000,
+						scriptVersion: 0,
+						pkScript:      hexToBytes("76a914b8025be1b3efc63b0ad48e7f9f10e87544528d5888ac"),
+						compressed:    false,
+					},
+				},
+			},
+			outputIndex: 300,
+			key:         hexToBytes("0000000000000000000000000000000000000000000000000000000000000000812c"),
+			serialized:  hexToBytes("01df39000180090000b8025be1b3efc63b0ad48e7f9f10e87544528d58"),
+		},
+		{
+			name: "output 0, ticket with stake extra",
+			entry: &UtxoEntry{
+				txVersion:  1,
+				isCoinBase: false,
+				hasExpiry:  false,
+				txType:     stake.TxTypeSStx,
+				height:     100,
+				index:      0,
+				stakeExtra: hexToBytes("0102030405"),
+				sparseOutputs: map[uint32]*utxoOutput{
+					0: {
+						amount:        20000000,
+						scriptVersion: 0,
+						pkScript:      hexToBytes("76a914e2ccd6ec7c6e2e581349c77e067385fa8236bf8a88ac"),
+						compressed:    false,
+					},
+				},
+			},
+			outputIndex: 0,
+			key:         hexToBytes("000000000000000000000000000000000000000000000000000000000000000000"),
+			serialized:  hexToBytes("01640004120000e2ccd6ec7c6e2e581349c77e067385fa8236bf8a0102030405"),
+		},
+	}
+
+	for i, test := range tests {
+		// Ensure the outpoint key serializes to the expected value and
+		// decodes back to the same output index.
+		var hash chainhash.Hash
+		gotKey := outpointKey(&hash, test.outputIndex)
+		if !bytes.Equal(gotKey, test.key) {
+			t.Errorf("outpointKey #%d (%s): mismatched bytes - got "+
+				"%x, want %x", i, test.name, gotKey, test.key)
+			continue
+		}
+		gotIndex, err := decodeOutpointKey(gotKey)
+		if err != nil {
+			t.Errorf("decodeOutpointKey #%d (%s) unexpected error: "+
+				"%v", i, test.name, err)
+			continue
+		}
+		if gotIndex != test.outputIndex {
+			t.Errorf("decodeOutpointKey #%d (%s): mismatched index - "+
+				"got %d, want %d", i, test.name, gotIndex,
+				test.outputIndex)
+			continue
+		}
+
+		// Ensure the output serializes to the expected value.
+		out := test.entry.sparseOutputs[test.outputIndex]
+		gotBytes := serializeUtxoOutput(test.entry, out)
+		if !bytes.Equal(gotBytes, test.serialized) {
+			t.Errorf("serializeUtxoOutput #%d (%s): mismatched "+
+				"bytes - got %x, want %x", i, test.name,
+				gotBytes, test.serialized)
+			continue
+		}
+
+		// Ensure the deserialized entry has the same properties for the
+		// containing transaction and the output matches.
+		gotEntry, gotOut, err := deserializeUtxoOutput(test.serialized)
+		if err != nil {
+			t.Errorf("deserializeUtxoOutput #%d (%s) unexpected "+
+				"error: %v", i, test.name, err)
+			continue
+		}
+		if gotEntry.TxVersion() != test.entry.TxVersion() ||
+			gotEntry.BlockHeight() != test.entry.BlockHeight() ||
+			gotEntry.BlockIndex() != test.entry.BlockIndex() ||
+			gotEntry.IsCoinBase() != test.entry.IsCoinBase() ||
+			gotEntry.HasExpiry() != test.entry.HasExpiry() ||
+			gotEntry.TransactionType() != test.entry.TransactionType() {
+			t.Errorf("deserializeUtxoOutput #%d (%s): mismatched "+
+				"entry - got %v, want %v", i, test.name,
+				gotEntry, test.entry)
+			continue
+		}
+		if !bytes.Equal(gotEntry.stakeExtra, test.entry.stakeExtra) {
+			t.Errorf("deserializeUtxoOutput #%d (%s): mismatched "+
+				"stake extra - got %x, want %x", i, test.name,
+				gotEntry.stakeExtra, test.entry.stakeExtra)
+			continue
+		}
+		gotOut.maybeDecompress(currentCompressionVersion)
+		if gotOut.amount != out.amount {
+			t.Errorf("deserializeUtxoOutput #%d (%s): mismatched "+
+				"amount - got %d, want %d", i, test.name,
+				gotOut.amount, out.amount)
+			continue
+		}
+		if !bytes.Equal(gotOut.pkScript, out.pkScript) {
+			t.Errorf("deserializeUtxoOutput #%d (%s): mismatched "+
+				"script - got %x, want %x", i, test.name,
+				gotOut.pkScript, out.pkScript)
+			continue
+		}
+	}
+}
+
+// TestUtxoOutputDeserializeErrors performs negative tests against deserializing
+// version 2 unspent outputs to ensure error paths work as expected.
+func TestUtxoOutputDeserializeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		serialized []byte
+		errType    error
+	}{
+		{
+			name:       "no data after version",
+			serialized: hexToBytes("01"),
+			errType:    errDeserialize(""),
+		},
+		{
+			name:       "no data after block height",
+			serialized: hexToBytes("0101"),
+			errType:    errDeserialize(""),
+		},
+		{
+			name:       "no data after block index",
+			serialized: hexToBytes("010100"),
+			errType:    errDeserialize(""),
+		},
+		{
+			name:       "no data after flags",
+			serialized: hexToBytes("01010000"),
+			errType:    errDeserialize(""),
+		},
+		{
+			name:       "incomplete compressed txout",
+			serialized: hexToBytes("0101000032"),
+			errType:    errDeserialize(""),
+		},
+	}
+
+	for _, test := range tests {
+		// Ensure the expected error type is returned and the returned
+		// entry and output are nil.
+		entry, out, err := deserializeUtxoOutput(test.serialized)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.errType) {
+			t.Errorf("deserializeUtxoOutput (%s): expected error "+
+				"type does not match - got %T, want %T",
+				test.name, err, test.errType)
+			continue
+		}
+		if entry != nil || out != nil {
+			t.Errorf("deserializeUtxoOutput (%s): returned entry "+
+				"is not nil", test.name)
+			continue
+		}
+	}
+}
+
 // TestDatabaseInfoSerialization ensures serializing and deserializing the
 // database version information works as expected.
 func TestDatabaseInfoSerialization(t *testing.T) {
@@ -0,0 +1,282 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// ChainView provides snapshot-consistent reads of the main chain as of the
+// best chain tip at the time the view was created.  It is intended to be used
+// by callers that need to perform a sequence of related reads, such as a block,
+// the hash of the block after it, and the unspent outputs of its transactions,
+// without observing a torn state when a reorganization happens part way
+// through the sequence.
+//
+// Every read verifies the pinned tip is still part of the main chain.  Blocks,
+// headers, and hashes at or below the pinned tip can therefore be read for as
+// long as the chain only extends the pinned tip.  Unspent transaction outputs,
+// on the other hand, are only tracked for the current best chain tip, so they
+// may only be read while the pinned tip is still the best chain tip.  Reads
+// that can no longer be answered consistently return a StaleChainViewError and
+// the caller should create a new view and restart the sequence.
+//
+// A ChainView does not hold any locks or database transactions between reads,
+// so it is safe to keep one around for the duration of a multi-step request.
+type ChainView struct {
+	chain *BlockChain
+	tip   *BestState
+}
+
+// NewChainView returns a new view of the main chain pinned to the current best
+// chain tip.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) NewChainView() *ChainView {
+	return &ChainView{chain: b, tip: b.BestSnapshot()}
+}
+
+// Tip returns information about the best chain tip the view is pinned to.  The
+// returned instance must be treated as immutable since it is shared by all
+// callers.
+//
+// This function is safe for concurrent access.
+func (v *ChainView) Tip() *BestState {
+	return v.tip
+}
+
+// view invokes the passed function with a managed read-only database
+// transaction after verifying the pinned tip is still part of the main chain.
+// The database transaction alone provides a consistent view of the main chain,
+// so reads do not wait for blocks being connected.
+func (v *ChainView) view(fn func(dbTx database.Tx) error) error {
+	return v.chain.db.View(func(dbTx database.Tx) error {
+		if !dbMainChainHasBlock(dbTx, v.tip.Hash) {
+			return StaleChainViewError(v.tip.Hash.String())
+		}
+		return fn(dbTx)
+	})
+}
+
+// checkHeight returns an error if the passed height is after the pinned tip.
+func (v *ChainView) checkHeight(height int64) error {
+	if height < 0 || height > v.tip.Height {
+		return fmt.Errorf("height %d is out of range for the chain view "+
+			"(tip height %d)", height, v.tip.Height)
+	}
+	return nil
+}
+
+// MainChainHasBlock returns whether or not the block with the given hash is in
+// the main chain as of the pinned tip.
+//
+// This function is safe for concurrent access.
+func (v *ChainView) MainChainHasBlock(hash *chainhash.Hash) (bool, error) {
+	var exists bool
+	err := v.view(func(dbTx database.Tx) error {
+		if !dbMainChainHasBlock(dbTx, hash) {
+			return nil
+		}
+		height, err := dbFetchHeightByHash(dbTx, hash)
+		if err != nil {
+			return err
+		}
+		exists = height <= v.tip.Height
+		return nil
+	})
+	return exists, err
+}
+
+// BlockHashByHeight returns the hash of the block at the given height in the
+// main chain as of the pinned tip.
+//
+// This function is safe for concurrent access.
+func (v *ChainView) BlockHashByHeight(height int64) (*chainhash.Hash, error) {
+	if err := v.checkHeight(height); err != nil {
+		return nil, err
+	}
+
+	var hash *chainhash.Hash
+	err := v.view(func(dbTx database.Tx) error {
+		var err error
+		hash, err = dbFetchHashByHeight(dbTx, height)
+		return err
+	})
+	return hash, err
+}
+
+// HeaderByHeight returns the header of the block at the given height in the
+// main chain as of the pinned tip.
+//
+// This function is safe for concurrent access.
+func (v *ChainView) HeaderByHeight(height int64) (*wire.BlockHeader, error) {
+	if err := v.checkHeight(height); err != nil {
+		return nil, err
+	}
+
+	var header *wire.BlockHeader
+	err := v.view(func(dbTx database.Tx) error {
+		var err error
+		header, err = dbFetchHeaderByHeight(dbTx, height)
+		return err
+	})
+	return header, err
+}
+
+// PastMedianTimeByHeight returns the median time of the previous few blocks
+// prior to, and including, the block at the given height in the main chain as
+// of the pinned tip.
+//
+// This function is safe for concurrent access.
+func (v *ChainView) PastMedianTimeByHeight(height int64) (time.Time, error) {
+	if err := v.checkHeight(height); err != nil {
+		return time.Time{}, err
+	}
+
+	var medianTime time.Time
+	err := v.view(func(dbTx database.Tx) error {
+		hash, err := dbFetchHashByHeight(dbTx, height)
+		if err != nil {
+			return err
+		}
+		medianTime, err = dbCalcPastMedianTime(dbTx, hash)
+		return err
+	})
+	return medianTime, err
+}
+
+// BlockHashByMedianTime returns the hash and height of the most recent block in
+// the main chain as of the pinned tip which has a past median time at or before
+// the passed time.  In other words, it returns the tip of the main chain as it
+// was at the passed time according to median time past semantics.  Since the
+// past median time never decreases along the chain, the block is located with
+// a binary search.
+//
+// An error is returned when the passed time is before the past median time of
+// the genesis block.
+//
+// This function is safe for concurrent access.
+func (v *ChainView) BlockHashByMedianTime(t time.Time) (*chainhash.Hash, int64, error) {
+	var hash *chainhash.Hash
+	var height int64
+	err := v.view(func(dbTx database.Tx) error {
+		medianTimeAt := func(height int64) (*chainhash.Hash, time.Time, error) {
+			hash, err := dbFetchHashByHeight(dbTx, height)
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			medianTime, err := dbCalcPastMedianTime(dbTx, hash)
+			return hash, medianTime, err
+		}
+
+		genesisHash, medianTime, err := medianTimeAt(0)
+		if err != nil {
+			return err
+		}
+		if t.Before(medianTime) {
+			return fmt.Errorf("time %v is before the median time %v "+
+				"of the genesis block", t, medianTime)
+		}
+
+		// Find the last height with a median time at or before the
+		// passed time.  The median time at the low height is always at
+		// or before the passed time.
+		hash, height = genesisHash, 0
+		low, high := int64(0), v.tip.Height
+		for low < high {
+			mid := low + (high-low+1)/2
+			midHash, medianTime, err := medianTimeAt(mid)
+			if err != nil {
+				return err
+			}
+			if medianTime.After(t) {
+				high = mid - 1
+				continue
+			}
+			low = mid
+			hash, height = midHash, mid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return hash, height, nil
+}
+
+// BlockByHeight returns the block at the given height in the main chain as of
+// the pinned tip.
+//
+// This function is safe for concurrent access.
+func (v *ChainView) BlockByHeight(height int64) (*dcrutil.Block, error) {
+	if err := v.checkHeight(height); err != nil {
+		return nil, err
+	}
+
+	var block *dcrutil.Block
+	err := v.view(func(dbTx database.Tx) error {
+		var err error
+		block, err = dbFetchBlockByHeight(dbTx, height)
+		return err
+	})
+	return block, err
+}
+
+// BlockByHash returns the block with the given hash from the main chain as of
+// the pinned tip with the appropriate chain height set.
+//
+// This function is safe for concurrent access.
+func (v *ChainView) BlockByHash(hash *chainhash.Hash) (*dcrutil.Block, error) {
+	var block *dcrutil.Block
+	err := v.view(func(dbTx database.Tx) error {
+		if !dbMainChainHasBlock(dbTx, hash) {
+			return HashError(hash.String())
+		}
+		var err error
+		block, err = dbFetchBlockByHash(dbTx, hash)
+		if err != nil {
+			return err
+		}
+		if block.Height() > v.tip.Height {
+			block = nil
+			return HashError(hash.String())
+		}
+		return nil
+	})
+	return block, err
+}
+
+// FetchUtxoEntry loads and returns the unspent transaction output entry for
+// the passed hash as of the pinned tip.  Since unspent outputs are only
+// tracked for the best chain tip, a StaleChainViewError is returned once the
+// best chain has moved past the pinned tip.
+//
+// See BlockChain.FetchUtxoEntry for details about the returned entry.
+//
+// This function is safe for concurrent access however the returned entry (if
+// any) is NOT.
+func (v *ChainView) FetchUtxoEntry(txHash *chainhash.Hash) (*UtxoEntry, error) {
+	// The utxo cache is only consistent with the best chain tip while the
+	// utxo lock is held.
+	v.chain.utxoLock.RLock()
+	defer v.chain.utxoLock.RUnlock()
+
+	var entry *UtxoEntry
+	err := v.view(func(dbTx database.Tx) error {
+		if v.chain.bestNode.hash != *v.tip.Hash {
+			return StaleChainViewError(v.tip.Hash.String())
+		}
+		var err error
+		entry, err = v.chain.utxoCache.fetchEntry(dbTx, txHash)
+		return err
+	})
+	return entry, err
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain_test
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrutil"
+)
+
+// loadTestBlocks loads the gob encoded map of serialized blocks keyed by
+// height from the passed bzip2 compressed test data file.
+func loadTestBlocks(filename string) (map[int64][]byte, error) {
+	fi, err := os.Open(filepath.Join("testdata/", filename))
+	if err != nil {
+		return nil, err
+	}
+	defer fi.Close()
+
+	bcBuf := new(bytes.Buffer)
+	bcBuf.ReadFrom(bzip2.NewReader(fi))
+
+	blocks := make(map[int64][]byte)
+	if err := gob.NewDecoder(bcBuf).Decode(&blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// TestChainView ensures chain views continue to serve reads as the chain is
+// extended and report they are stale once a reorganization removes the pinned
+// tip from the main chain.
+func TestChainView(t *testing.T) {
+	chain, teardownFunc, err := chainSetup("chainviewunittest",
+		simNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	shortChain, err := loadTestBlocks("reorgto179.bz2")
+	if err != nil {
+		t.Fatalf("Failed to load short chain: %v", err)
+	}
+	longChain, err := loadTestBlocks("reorgto180.bz2")
+	if err != nil {
+		t.Fatalf("Failed to load long chain: %v", err)
+	}
+	processBlocks := func(blocks map[int64][]byte, start, end int64) {
+		for i := start; i <= end; i++ {
+			bl, err := dcrutil.NewBlockFromBytes(blocks[i])
+			if err != nil {
+				t.Fatalf("NewBlockFromBytes error: %v", err)
+			}
+			bl.SetHeight(i)
+			_, _, err = chain.ProcessBlock(bl, blockchain.BFNone)
+			if err != nil {
+				t.Fatalf("ProcessBlock error at height %v: %v", i,
+					err)
+			}
+		}
+	}
+
+	// Pin a view in the middle of the short chain.
+	processBlocks(shortChain, 1, 150)
+	view := chain.NewChainView()
+	if view.Tip().Height != 150 {
+		t.Fatalf("Tip: unexpected height -- got %d, want 150",
+			view.Tip().Height)
+	}
+	blockByHeight := func(height int64) *dcrutil.Block {
+		block, err := view.BlockByHeight(height)
+		if err != nil {
+			t.Fatalf("BlockByHeight(%d): unexpected error: %v", height,
+				err)
+		}
+		return block
+	}
+	tipCoinbase := blockByHeight(150).Transactions()[0].Hash()
+	if _, err := view.FetchUtxoEntry(tipCoinbase); err != nil {
+		t.Fatalf("FetchUtxoEntry: unexpected error: %v", err)
+	}
+	if _, err := view.BlockByHeight(151); err == nil {
+		t.Fatal("BlockByHeight: did not receive expected error for " +
+			"height after the pinned tip")
+	}
+
+	// Extending the chain must not affect reads at or below the pinned tip,
+	// but unspent outputs can no longer be read consistently.
+	processBlocks(shortChain, 151, 179)
+	if block := blockByHeight(140); block.Height() != 140 {
+		t.Fatalf("BlockByHeight: unexpected height -- got %d, want 140",
+			block.Height())
+	}
+	if _, err := view.BlockByHeight(151); err == nil {
+		t.Fatal("BlockByHeight: did not receive expected error for " +
+			"height after the pinned tip")
+	}
+	_, err = view.FetchUtxoEntry(tipCoinbase)
+	if _, ok := err.(blockchain.StaleChainViewError); !ok {
+		t.Fatalf("FetchUtxoEntry: unexpected error -- got %v (%T), "+
+			"want StaleChainViewError", err, err)
+	}
+
+	// Reorganizing the pinned tip out of the main chain must cause all reads
+	// to report the view is stale while a new view sees the new chain.
+	view = chain.NewChainView()
+	processBlocks(longChain, 131, 180)
+	_, err = view.BlockByHeight(100)
+	if _, ok := err.(blockchain.StaleChainViewError); !ok {
+		t.Fatalf("BlockByHeight: unexpected error -- got %v (%T), "+
+			"want StaleChainViewError", err, err)
+	}
+	view = chain.NewChainView()
+	if view.Tip().Height != 180 {
+		t.Fatalf("Tip: unexpected height -- got %d, want 180",
+			view.Tip().Height)
+	}
+	hash, err := view.BlockHashByHeight(131)
+	if err != nil {
+		t.Fatalf("BlockHashByHeight: unexpected error: %v", err)
+	}
+	wantBlock, err := dcrutil.NewBlockFromBytes(longChain[131])
+	if err != nil {
+		t.Fatalf("NewBlockFromBytes error: %v", err)
+	}
+	if *hash != *wantBlock.Hash() {
+		t.Fatalf("BlockHashByHeight: unexpected hash -- got %v, want %v",
+			hash, wantBlock.Hash())
+	}
+}
+
+// TestChainViewMedianTime ensures the past median time of main chain blocks is
+// calculated from the expected timestamps and that blocks are located by their
+// past median time.
+func TestChainViewMedianTime(t *testing.T) {
+	chain, teardownFunc, err := chainSetup("chainviewmediantimeunittest",
+		simNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	blocks, err := loadTestBlocks("reorgto179.bz2")
+	if err != nil {
+		t.Fatalf("Failed to load chain: %v", err)
+	}
+	const tipHeight = 60
+	timestamps := []time.Time{simNetParams.GenesisBlock.Header.Timestamp}
+	for i := int64(1); i <= tipHeight; i++ {
+		bl, err := dcrutil.NewBlockFromBytes(blocks[i])
+		if err != nil {
+			t.Fatalf("NewBlockFromBytes error: %v", err)
+		}
+		bl.SetHeight(i)
+		_, _, err = chain.ProcessBlock(bl, blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock error at height %v: %v", i, err)
+		}
+		timestamps = append(timestamps, bl.MsgBlock().Header.Timestamp)
+	}
+
+	// The past median time is the median of the timestamps of the block
+	// and up to ten blocks before it.
+	wantMedianTime := func(height int64) time.Time {
+		start := height - 10
+		if start < 0 {
+			start = 0
+		}
+		var window []time.Time
+		for _, ts := range timestamps[start : height+1] {
+			i := len(window)
+			window = append(window, ts)
+			for ; i > 0 && ts.Before(window[i-1]); i-- {
+				window[i] = window[i-1]
+			}
+			window[i] = ts
+		}
+		return window[len(window)/2]
+	}
+
+	view := chain.NewChainView()
+	for height := int64(0); height <= tipHeight; height++ {
+		medianTime, err := view.PastMedianTimeByHeight(height)
+		if err != nil {
+			t.Fatalf("PastMedianTimeByHeight(%d): unexpected error: %v",
+				height, err)
+		}
+		if want := wantMedianTime(height); !medianTime.Equal(want) {
+			t.Fatalf("PastMedianTimeByHeight(%d): unexpected median "+
+				"time -- got %v, want %v", height, medianTime, want)
+		}
+
+		// The block located by the median time must be the last block
+		// with that median time.
+		_, gotHeight, err := view.BlockHashByMedianTime(medianTime)
+		if err != nil {
+			t.Fatalf("BlockHashByMedianTime(%v): unexpected error: %v",
+				medianTime, err)
+		}
+		wantHeight := height
+		for wantHeight < tipHeight &&
+			wantMedianTime(wantHeight+1).Equal(medianTime) {
+			wantHeight++
+		}
+		if gotHeight != wantHeight {
+			t.Fatalf("BlockHashByMedianTime(%v): unexpected height -- "+
+				"got %d, want %d", medianTime, gotHeight, wantHeight)
+		}
+	}
+
+	// The past median time of the tip must match the one calculated for
+	// the best chain and blocks must be located by hash as well.
+	medianTime, err := chain.CalcPastMedianTime()
+	if err != nil {
+		t.Fatalf("CalcPastMedianTime: unexpected error: %v", err)
+	}
+	byHash, err := chain.PastMedianTimeByHash(view.Tip().Hash)
+	if err != nil {
+		t.Fatalf("PastMedianTimeByHash: unexpected error: %v", err)
+	}
+	if !byHash.Equal(medianTime) {
+		t.Fatalf("PastMedianTimeByHash: unexpected median time -- got "+
+			"%v, want %v", byHash, medianTime)
+	}
+
+	// Times before the genesis block must be rejected.
+	genesisTime := simNetParams.GenesisBlock.Header.Timestamp
+	_, _, err = view.BlockHashByMedianTime(genesisTime.Add(-time.Second))
+	if err == nil {
+		t.Fatal("BlockHashByMedianTime: did not receive expected error " +
+			"for time before the genesis block")
+	}
+}
@@ -7,11 +7,13 @@ package blockchain
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/database"
 	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
 	"github.com/decred/dcrutil"
 )
 
@@ -28,44 +30,162 @@ func newHashFromStr(hexStr string) *chainhash.Hash {
 	return hash
 }
 
+// CheckpointMode identifies how checkpoints are treated when validating blocks.
+type CheckpointMode int
+
+// These constants define the supported checkpoint modes.
+const (
+	// CheckpointModeEnforce rejects blocks which do not match the
+	// checkpoints or which fork the main chain before the most recent known
+	// checkpoint.  It also allows callers to perform less validation of the
+	// blocks before the final checkpoint.  This is the default mode.
+	CheckpointModeEnforce CheckpointMode = iota
+
+	// CheckpointModeAdvisory only warns about blocks which do not match the
+	// checkpoints.  Blocks are never rejected due to the checkpoints, so
+	// the entire chain is fully validated.
+	CheckpointModeAdvisory
+
+	// CheckpointModeDisabled ignores the checkpoints entirely.
+	CheckpointModeDisabled
+)
+
+// checkpointModeStrings is a map of checkpoint modes back to the names used to
+// select them.
+var checkpointModeStrings = map[CheckpointMode]string{
+	CheckpointModeEnforce:  "enforce",
+	CheckpointModeAdvisory: "advisory",
+	CheckpointModeDisabled: "disabled",
+}
+
+// String returns the CheckpointMode in human-readable form.
+func (m CheckpointMode) String() string {
+	if s, ok := checkpointModeStrings[m]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown CheckpointMode (%d)", int(m))
+}
+
+// checkpointSorter implements sort.Interface to allow a slice of checkpoints to
+// be sorted by height.
+type checkpointSorter []chaincfg.Checkpoint
+
+// Len returns the number of checkpoints in the slice.  It is part of the
+// sort.Interface implementation.
+func (s checkpointSorter) Len() int {
+	return len(s)
+}
+
+// Swap swaps the checkpoints at the passed indices.  It is part of the
+// sort.Interface implementation.
+func (s checkpointSorter) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Less returns whether the checkpoint with index i is at a lower height than
+// the checkpoint with index j.  It is part of the sort.Interface
+// implementation.
+func (s checkpointSorter) Less(i, j int) bool {
+	return s[i].Height < s[j].Height
+}
+
+// mergeCheckpoints returns the passed network checkpoints combined with the
+// additional checkpoints sorted by height.  Duplicate checkpoints are only
+// included once, while checkpoints for the same height with different hashes
+// result in an error.
+func mergeCheckpoints(checkpoints, additional []chaincfg.Checkpoint) ([]chaincfg.Checkpoint, error) {
+	if len(additional) == 0 {
+		return checkpoints, nil
+	}
+
+	byHeight := make(map[int64]*chainhash.Hash, len(checkpoints)+
+		len(additional))
+	merged := make([]chaincfg.Checkpoint, 0, len(checkpoints)+
+		len(additional))
+	for _, set := range [][]chaincfg.Checkpoint{checkpoints, additional} {
+		for _, checkpoint := range set {
+			if hash, ok := byHeight[checkpoint.Height]; ok {
+				if !hash.IsEqual(checkpoint.Hash) {
+					return nil, fmt.Errorf("conflicting "+
+						"checkpoints %v and %v at "+
+						"height %d", hash,
+						checkpoint.Hash,
+						checkpoint.Height)
+				}
+				continue
+			}
+			byHeight[checkpoint.Height] = checkpoint.Hash
+			merged = append(merged, checkpoint)
+		}
+	}
+	sort.Sort(checkpointSorter(merged))
+	return merged, nil
+}
+
 // DisableCheckpoints provides a mechanism to disable validation against
 // checkpoints which you DO NOT want to do in production.  It is provided only
-// for debug purposes.
+// for debug purposes.  Passing false restores the default enforce mode.
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) DisableCheckpoints(disable bool) {
+	mode := CheckpointModeEnforce
+	if disable {
+		mode = CheckpointModeDisabled
+	}
+	b.SetCheckpointMode(mode)
+}
+
+// SetCheckpointMode sets how checkpoints are treated when validating blocks.
+// See the CheckpointMode constants for details on the available modes.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) SetCheckpointMode(mode CheckpointMode) {
 	b.chainLock.Lock()
-	b.noCheckpoints = disable
+	b.checkpointLock.Lock()
+	b.checkpointMode = mode
+	b.checkpointLock.Unlock()
 	b.chainLock.Unlock()
 }
 
+// CheckpointMode returns how checkpoints are treated when validating blocks.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CheckpointMode() CheckpointMode {
+	b.checkpointLock.RLock()
+	mode := b.checkpointMode
+	b.checkpointLock.RUnlock()
+	return mode
+}
+
 // Checkpoints returns a slice of checkpoints (regardless of whether they are
-// already known).  When checkpoints are disabled or there are no checkpoints
-// for the active network, it will return nil.
+// already known).  This includes any additional checkpoints provided when the
+// chain instance was created.  When checkpoints are disabled or there are no
+// checkpoints for the active network, it will return nil.
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) Checkpoints() []chaincfg.Checkpoint {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
+	b.checkpointLock.RLock()
+	defer b.checkpointLock.RUnlock()
 
-	if b.noCheckpoints || len(b.chainParams.Checkpoints) == 0 {
+	if b.checkpointMode == CheckpointModeDisabled || len(b.checkpoints) == 0 {
 		return nil
 	}
 
-	return b.chainParams.Checkpoints
+	return b.checkpoints
 }
 
 // latestCheckpoint returns the most recent checkpoint (regardless of whether it
 // is already known).  When checkpoints are disabled or there are no checkpoints
 // for the active network, it will return nil.
 //
-// This function MUST be called with the chain state lock held (for reads).
+// This function MUST be called with either the chain state lock or the
+// checkpoint lock held (for reads).
 func (b *BlockChain) latestCheckpoint() *chaincfg.Checkpoint {
-	if b.noCheckpoints || len(b.chainParams.Checkpoints) == 0 {
+	if b.checkpointMode == CheckpointModeDisabled || len(b.checkpoints) == 0 {
 		return nil
 	}
 
-	checkpoints := b.chainParams.Checkpoints
+	checkpoints := b.checkpoints
 	return &checkpoints[len(checkpoints)-1]
 }
 
@@ -75,19 +195,20 @@ func (b *BlockChain) latestCheckpoint() *chaincfg.Checkpoint {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) LatestCheckpoint() *chaincfg.Checkpoint {
-	b.chainLock.RLock()
+	b.checkpointLock.RLock()
 	checkpoint := b.latestCheckpoint()
-	b.chainLock.RUnlock()
+	b.checkpointLock.RUnlock()
 	return checkpoint
 }
 
 // verifyCheckpoint returns whether the passed block height and hash combination
-// match the hard-coded checkpoint data.  It also returns true if there is no
-// checkpoint data for the passed block height.
+// match the checkpoint data.  It also returns true if there is no checkpoint
+// data for the passed block height or when checkpoints are only advisory, in
+// which case a mismatch is only logged.
 //
 // This function MUST be called with the chain lock held (for reads).
 func (b *BlockChain) verifyCheckpoint(height int64, hash *chainhash.Hash) bool {
-	if b.noCheckpoints || len(b.chainParams.Checkpoints) == 0 {
+	if b.checkpointMode == CheckpointModeDisabled || len(b.checkpoints) == 0 {
 		return true
 	}
 
@@ -98,6 +219,11 @@ func (b *BlockChain) verifyCheckpoint(height int64, hash *chainhash.Hash) bool {
 	}
 
 	if !checkpoint.Hash.IsEqual(hash) {
+		if b.checkpointMode == CheckpointModeAdvisory {
+			log.Warnf("Block %s at height %d does not match "+
+				"checkpoint %s", hash, height, checkpoint.Hash)
+			return true
+		}
 		return false
 	}
 
@@ -106,19 +232,52 @@ func (b *BlockChain) verifyCheckpoint(height int64, hash *chainhash.Hash) bool {
 	return true
 }
 
+// isAssumedValid returns whether or not the scripts of the passed node are
+// assumed to be valid since it is the assumed valid block or one of its
+// ancestors.  The assumed valid block is typically not known yet when its
+// ancestors are connected, so all blocks up to its height are treated as its
+// ancestors.  This is safe since blocks at its height which do not match it
+// are rejected, so a chain which does not contain it never becomes part of the
+// main chain beyond its height.
+//
+// This function MUST be called with the chain lock held (for reads).
+func (b *BlockChain) isAssumedValid(node *blockNode) bool {
+	return b.assumeValid != nil && node.height <= b.assumeValid.Height
+}
+
+// verifyAssumeValid returns whether the passed block height and hash
+// combination match the assumed valid block.  It also returns true when there
+// is no assumed valid block or it is at a different height.
+//
+// This function MUST be called with the chain lock held (for reads).
+func (b *BlockChain) verifyAssumeValid(height int64, hash *chainhash.Hash) bool {
+	if b.assumeValid == nil || b.assumeValid.Height != height {
+		return true
+	}
+	if !b.assumeValid.Hash.IsEqual(hash) {
+		return false
+	}
+
+	log.Infof("Verified assumed valid block at height %d/block %s", height,
+		hash)
+	return true
+}
+
 // findPreviousCheckpoint finds the most recent checkpoint that is already
-// available in the downloaded portion of the block chain and returns the
-// associated block.  It returns nil if a checkpoint can't be found (this should
-// really only happen for blocks before the first checkpoint).
+// available in the downloaded portion of the block chain and returns the header
+// of the associated block.  It returns nil if a checkpoint can't be found (this
+// should really only happen for blocks before the first checkpoint) or
+// checkpoints are not enforced.  Only the header is loaded since the data of
+// the block might have been pruned.
 //
 // This function MUST be called with the chain lock held (for reads).
-func (b *BlockChain) findPreviousCheckpoint() (*dcrutil.Block, error) {
-	if b.noCheckpoints || len(b.chainParams.Checkpoints) == 0 {
+func (b *BlockChain) findPreviousCheckpoint() (*wire.BlockHeader, error) {
+	if b.checkpointMode != CheckpointModeEnforce || len(b.checkpoints) == 0 {
 		return nil, nil
 	}
 
 	// No checkpoints.
-	checkpoints := b.chainParams.Checkpoints
+	checkpoints := b.checkpoints
 	numCheckpoints := len(checkpoints)
 	if numCheckpoints == 0 {
 		return nil, nil
@@ -127,7 +286,7 @@ func (b *BlockChain) findPreviousCheckpoint() (*dcrutil.Block, error) {
 	// Perform the initial search to find and cache the latest known
 	// checkpoint if the best chain is not known yet or we haven't already
 	// previously searched.
-	if b.checkpointBlock == nil && b.nextCheckpoint == nil {
+	if b.checkpointHeader == nil && b.nextCheckpoint == nil {
 		// Loop backwards through the available checkpoints to find one
 		// that is already available.
 		checkpointIndex := -1
@@ -153,14 +312,14 @@ func (b *BlockChain) findPreviousCheckpoint() (*dcrutil.Block, error) {
 			return nil, nil
 		}
 
-		// Cache the latest known checkpoint block for future lookups.
+		// Cache the latest known checkpoint header for future lookups.
 		checkpoint := checkpoints[checkpointIndex]
 		err = b.db.View(func(dbTx database.Tx) error {
-			block, err := dbFetchBlockByHash(dbTx, checkpoint.Hash)
+			header, err := dbFetchHeaderByHash(dbTx, checkpoint.Hash)
 			if err != nil {
 				return err
 			}
-			b.checkpointBlock = block
+			b.checkpointHeader = header
 
 			// Set the next expected checkpoint block accordingly.
 			b.nextCheckpoint = nil
@@ -174,21 +333,21 @@ func (b *BlockChain) findPreviousCheckpoint() (*dcrutil.Block, error) {
 			return nil, err
 		}
 
-		return b.checkpointBlock, nil
+		return b.checkpointHeader, nil
 	}
 
 	// At this point we've already searched for the latest known checkpoint,
 	// so when there is no next checkpoint, the current checkpoint lockin
 	// will always be the latest known checkpoint.
 	if b.nextCheckpoint == nil {
-		return b.checkpointBlock, nil
+		return b.checkpointHeader, nil
 	}
 
 	// When there is a next checkpoint and the height of the current best
 	// chain does not exceed it, the current checkpoint lockin is still
 	// the latest known checkpoint.
 	if b.bestNode.height < b.nextCheckpoint.Height {
-		return b.checkpointBlock, nil
+		return b.checkpointHeader, nil
 	}
 
 	// We've reached or exceeded the next checkpoint height.  Note that
@@ -196,16 +355,16 @@ func (b *BlockChain) findPreviousCheckpoint() (*dcrutil.Block, error) {
 	// any blocks before the checkpoint, so we don't have to worry about the
 	// checkpoint going away out from under us due to a chain reorganize.
 
-	// Cache the latest known checkpoint block for future lookups.  Note
+	// Cache the latest known checkpoint header for future lookups.  Note
 	// that if this lookup fails something is very wrong since the chain
 	// has already passed the checkpoint which was verified as accurate
 	// before inserting it.
 	err := b.db.View(func(tx database.Tx) error {
-		block, err := dbFetchBlockByHash(tx, b.nextCheckpoint.Hash)
+		header, err := dbFetchHeaderByHash(tx, b.nextCheckpoint.Hash)
 		if err != nil {
 			return err
 		}
-		b.checkpointBlock = block
+		b.checkpointHeader = header
 		return nil
 	})
 	if err != nil {
@@ -225,7 +384,7 @@ func (b *BlockChain) findPreviousCheckpoint() (*dcrutil.Block, error) {
 		b.nextCheckpoint = &checkpoints[checkpointIndex+1]
 	}
 
-	return b.checkpointBlock, nil
+	return b.checkpointHeader, nil
 }
 
 // isNonstandardTransaction determines whether a transaction contains any
@@ -259,11 +418,8 @@ func isNonstandardTransaction(tx *dcrutil.Tx) bool {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) IsCheckpointCandidate(block *dcrutil.Block) (bool, error) {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
-
 	// Checkpoints must be enabled.
-	if b.noCheckpoints {
+	if b.CheckpointMode() == CheckpointModeDisabled {
 		return false, fmt.Errorf("checkpoints are disabled")
 	}
 
@@ -290,8 +446,13 @@ func (b *BlockChain) IsCheckpointCandidate(block *dcrutil.Block) (bool, error) {
 		}
 
 		// A checkpoint must be at least CheckpointConfirmations blocks
-		// before the end of the main chain.
-		mainChainHeight := b.bestNode.height
+		// before the end of the main chain.  The best height is read
+		// from the same database transaction as the main chain so they
+		// are consistent during reorganizations.
+		mainChainHeight, err := DBFetchBestHeight(dbTx)
+		if err != nil {
+			return err
+		}
 		if blockHeight > (mainChainHeight - CheckpointConfirmations) {
 			return nil
 		}
@@ -0,0 +1,172 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestMergeCheckpoints ensures additional checkpoints are combined with the
+// network checkpoints in height order and that conflicting checkpoints are
+// rejected.
+func TestMergeCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	checkpoint1 := chaincfg.Checkpoint{Height: 10, Hash: &chainhash.Hash{0x01}}
+	checkpoint2 := chaincfg.Checkpoint{Height: 20, Hash: &chainhash.Hash{0x02}}
+	checkpoint3 := chaincfg.Checkpoint{Height: 30, Hash: &chainhash.Hash{0x03}}
+	checkpoint4 := chaincfg.Checkpoint{Height: 40, Hash: &chainhash.Hash{0x04}}
+	conflicting := chaincfg.Checkpoint{Height: 30, Hash: &chainhash.Hash{0x05}}
+	network := []chaincfg.Checkpoint{checkpoint1, checkpoint3}
+
+	tests := []struct {
+		name       string
+		additional []chaincfg.Checkpoint
+		want       []chaincfg.Checkpoint
+		wantErr    bool
+	}{
+		{
+			name: "no additional checkpoints",
+			want: network,
+		},
+		{
+			name:       "additional checkpoint between",
+			additional: []chaincfg.Checkpoint{checkpoint2},
+			want: []chaincfg.Checkpoint{checkpoint1, checkpoint2,
+				checkpoint3},
+		},
+		{
+			name:       "duplicate checkpoint",
+			additional: []chaincfg.Checkpoint{checkpoint4, checkpoint1},
+			want: []chaincfg.Checkpoint{checkpoint1, checkpoint3,
+				checkpoint4},
+		},
+		{
+			name:       "conflicting checkpoint",
+			additional: []chaincfg.Checkpoint{conflicting},
+			wantErr:    true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := mergeCheckpoints(network, test.additional)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: did not receive expected error",
+					test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: mismatched checkpoints -- got %v, want %v",
+				test.name, got, test.want)
+		}
+	}
+}
+
+// TestVerifyCheckpointModes ensures blocks which do not match a checkpoint are
+// only rejected when checkpoints are enforced.
+func TestVerifyCheckpointModes(t *testing.T) {
+	t.Parallel()
+
+	checkpoint := chaincfg.Checkpoint{Height: 10, Hash: &chainhash.Hash{0x01}}
+	b := &BlockChain{
+		checkpoints: []chaincfg.Checkpoint{checkpoint},
+		checkpointsByHeight: map[int64]*chaincfg.Checkpoint{
+			checkpoint.Height: &checkpoint,
+		},
+	}
+	otherHash := &chainhash.Hash{0x02}
+
+	tests := []struct {
+		mode      CheckpointMode
+		wantMatch bool
+		wantOther bool
+	}{
+		{CheckpointModeEnforce, true, false},
+		{CheckpointModeAdvisory, true, true},
+		{CheckpointModeDisabled, true, true},
+	}
+
+	for _, test := range tests {
+		b.SetCheckpointMode(test.mode)
+		if got := b.CheckpointMode(); got != test.mode {
+			t.Errorf("CheckpointMode: got %v, want %v", got, test.mode)
+			continue
+		}
+		got := b.verifyCheckpoint(checkpoint.Height, checkpoint.Hash)
+		if got != test.wantMatch {
+			t.Errorf("%v: verifyCheckpoint for checkpoint hash -- "+
+				"got %v, want %v", test.mode, got, test.wantMatch)
+		}
+		got = b.verifyCheckpoint(checkpoint.Height, otherHash)
+		if got != test.wantOther {
+			t.Errorf("%v: verifyCheckpoint for other hash -- got "+
+				"%v, want %v", test.mode, got, test.wantOther)
+		}
+		got = b.verifyCheckpoint(checkpoint.Height+1, otherHash)
+		if !got {
+			t.Errorf("%v: verifyCheckpoint rejected block without "+
+				"checkpoint", test.mode)
+		}
+	}
+}
+
+// TestAssumeValid ensures the assumed valid block and the blocks before it are
+// assumed to have valid scripts and blocks at its height which do not match it
+// are rejected.
+func TestAssumeValid(t *testing.T) {
+	t.Parallel()
+
+	assumeValid := chaincfg.Checkpoint{Height: 10, Hash: &chainhash.Hash{0x01}}
+	otherHash := &chainhash.Hash{0x02}
+	b := &BlockChain{assumeValid: &assumeValid}
+
+	tests := []struct {
+		height      int64
+		wantAssumed bool
+	}{
+		{1, true},
+		{assumeValid.Height, true},
+		{assumeValid.Height + 1, false},
+	}
+	for _, test := range tests {
+		node := &blockNode{height: test.height}
+		if got := b.isAssumedValid(node); got != test.wantAssumed {
+			t.Errorf("isAssumedValid at height %d: got %v, want %v",
+				test.height, got, test.wantAssumed)
+		}
+	}
+
+	if !b.verifyAssumeValid(assumeValid.Height, assumeValid.Hash) {
+		t.Error("verifyAssumeValid rejected the assumed valid block")
+	}
+	if b.verifyAssumeValid(assumeValid.Height, otherHash) {
+		t.Error("verifyAssumeValid accepted other block at the height " +
+			"of the assumed valid block")
+	}
+	if !b.verifyAssumeValid(assumeValid.Height+1, otherHash) {
+		t.Error("verifyAssumeValid rejected block at other height")
+	}
+
+	// Ensure nothing is assumed valid without an assumed valid block.
+	b.assumeValid = nil
+	if b.isAssumedValid(&blockNode{height: 1}) {
+		t.Error("isAssumedValid: block assumed valid without an assumed " +
+			"valid block")
+	}
+	if !b.verifyAssumeValid(assumeValid.Height, otherHash) {
+		t.Error("verifyAssumeValid rejected block without an assumed " +
+			"valid block")
+	}
+}
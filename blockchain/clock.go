@@ -0,0 +1,78 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock provides the current time to time-dependent logic such as ensuring
+// block timestamps are not too far in the future.  It allows the logic to be
+// exercised deterministically by tests and the time to be warped on the
+// simulation test network.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// systemClock provides an implementation of the Clock interface which reports
+// the current time of the system clock.
+type systemClock struct{}
+
+// Now returns the current time of the system clock.
+//
+// This is part of the Clock interface implementation.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock returns a clock which reports the current time of the system
+// clock.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+// WarpClock provides an implementation of the Clock interface which reports
+// the time of an underlying clock shifted by an adjustable offset.
+type WarpClock struct {
+	// offset is the offset in nanoseconds.  It must only be used
+	// atomically.
+	offset int64
+
+	clock Clock
+}
+
+// Ensure WarpClock implements the Clock interface.
+var _ Clock = (*WarpClock)(nil)
+
+// Now returns the time of the underlying clock shifted by the offset.
+//
+// This function is safe for concurrent access and is part of the Clock
+// interface implementation.
+func (c *WarpClock) Now() time.Time {
+	return c.clock.Now().Add(c.Offset())
+}
+
+// Warp shifts the clock by the passed duration, which may be negative, and
+// returns the resulting offset.
+//
+// This function is safe for concurrent access.
+func (c *WarpClock) Warp(d time.Duration) time.Duration {
+	return time.Duration(atomic.AddInt64(&c.offset, int64(d)))
+}
+
+// Offset returns the offset the underlying clock is shifted by.
+//
+// This function is safe for concurrent access.
+func (c *WarpClock) Offset() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.offset))
+}
+
+// NewWarpClock returns a new clock which reports the time of the passed clock
+// shifted by an offset which is initially zero.
+func NewWarpClock(clock Clock) *WarpClock {
+	return &WarpClock{clock: clock}
+}
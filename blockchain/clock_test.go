@@ -0,0 +1,57 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/blockchain"
+)
+
+// fixedClock is a blockchain.Clock which always reports the same time.
+type fixedClock time.Time
+
+// Now returns the fixed time of the clock.
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// TestWarpClock ensures warp clocks and the median time sources created with
+// them report the time of the underlying clock shifted by the warped offset.
+func TestWarpClock(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock := blockchain.NewWarpClock(fixedClock(now))
+	timeSource := blockchain.NewMedianTimeWithClock(clock)
+
+	tests := []struct {
+		warp       time.Duration
+		wantOffset time.Duration
+	}{
+		{0, 0},
+		{time.Hour, time.Hour},
+		{time.Minute * 30, time.Minute * 90},
+		{-time.Hour * 2, -time.Minute * 30},
+	}
+	for i, test := range tests {
+		if offset := clock.Warp(test.warp); offset != test.wantOffset {
+			t.Fatalf("Warp #%d: unexpected offset - got %v, want %v",
+				i, offset, test.wantOffset)
+		}
+		if offset := clock.Offset(); offset != test.wantOffset {
+			t.Fatalf("Offset #%d: unexpected offset - got %v, want %v",
+				i, offset, test.wantOffset)
+		}
+		want := now.Add(test.wantOffset)
+		if got := clock.Now(); !got.Equal(want) {
+			t.Fatalf("Now #%d: unexpected time - got %v, want %v", i,
+				got, want)
+		}
+		if got := timeSource.AdjustedTime(); !got.Equal(want) {
+			t.Fatalf("AdjustedTime #%d: unexpected time - got %v, "+
+				"want %v", i, got, want)
+		}
+	}
+}
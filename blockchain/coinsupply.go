@@ -0,0 +1,254 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
+	"github.com/decred/dcrd/blockchain/internal/progresslog"
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrutil"
+)
+
+// CoinSupply houses the total amount of coins mined by the main chain as of a
+// block split by the source of the subsidy which created them.  All amounts are
+// in atoms.
+type CoinSupply struct {
+	Hash       chainhash.Hash // The hash of the block.
+	Height     int64          // The height of the block.
+	Premine    int64          // The subsidy of block one.
+	PoW        int64          // The work subsidy paid to miners.
+	PoS        int64          // The vote subsidy paid to voters.
+	DevSubsidy int64          // The tax subsidy paid to the organization.
+}
+
+// Total returns the total amount of coins mined from all subsidy sources.
+func (s *CoinSupply) Total() int64 {
+	return s.Premine + s.PoW + s.PoS + s.DevSubsidy
+}
+
+// addedCoinSupply returns the amount of coins the passed block and its parent
+// add to the coin supply split by the subsidy source.  As for the total
+// subsidy, the subsidy of the coinbase of the parent is only added when the
+// block approves the regular transaction tree of the parent.
+//
+// The blocks MUST be valid blocks that have already been confirmed to abide by
+// the consensus rules of the network, or the function might panic.
+func (b *BlockChain) addedCoinSupply(block, parent *dcrutil.Block) CoinSupply {
+	var added CoinSupply
+	regularTxTreeValid := dcrutil.IsFlagSet16(block.MsgBlock().Header.VoteBits,
+		dcrutil.BlockValid)
+	parentHeader := &parent.MsgBlock().Header
+	if regularTxTreeValid && parentHeader.Height > 0 {
+		subsidy := parent.MsgBlock().Transactions[0].TxIn[0].ValueIn
+		if parentHeader.Height == 1 {
+			added.Premine = subsidy
+		} else {
+			added.DevSubsidy = CalcBlockTaxSubsidy(b.subsidyCache,
+				int64(parentHeader.Height), parentHeader.Voters,
+				b.chainParams)
+			added.PoW = subsidy - added.DevSubsidy
+		}
+	}
+
+	for _, stx := range block.MsgBlock().STransactions {
+		if isSSGen, _ := stake.IsSSGen(stx); isSSGen {
+			added.PoS += stx.TxIn[0].ValueIn
+		}
+	}
+
+	return added
+}
+
+// connect returns the coin supply which results from connecting the passed
+// block, which has the passed amounts added by it, on top of the block the
+// current coin supply is for.
+func (s *CoinSupply) connect(node *blockNode, added *CoinSupply) CoinSupply {
+	return CoinSupply{
+		Hash:       node.hash,
+		Height:     node.height,
+		Premine:    s.Premine + added.Premine,
+		PoW:        s.PoW + added.PoW,
+		PoS:        s.PoS + added.PoS,
+		DevSubsidy: s.DevSubsidy + added.DevSubsidy,
+	}
+}
+
+// disconnect returns the coin supply which results from disconnecting the block
+// the current coin supply is for, which has the passed amounts added by it,
+// leaving the passed parent as the end of the main chain.
+func (s *CoinSupply) disconnect(prevNode *blockNode, added *CoinSupply) CoinSupply {
+	return CoinSupply{
+		Hash:       prevNode.hash,
+		Height:     prevNode.height,
+		Premine:    s.Premine - added.Premine,
+		PoW:        s.PoW - added.PoW,
+		PoS:        s.PoS - added.PoS,
+		DevSubsidy: s.DevSubsidy - added.DevSubsidy,
+	}
+}
+
+// -----------------------------------------------------------------------------
+// The coin supply as of the end of the main chain is stored in the metadata
+// bucket and is updated along with the best chain state whenever a block is
+// connected or disconnected.
+//
+// The serialized format is:
+//
+//   <block hash><premine><pow><pos><dev subsidy>
+//
+//   Field          Type             Size
+//   block hash     chainhash.Hash   chainhash.HashSize
+//   premine        int64            8
+//   pow            int64            8
+//   pos            int64            8
+//   dev subsidy    int64            8
+// -----------------------------------------------------------------------------
+
+// coinSupplySize is the size of a serialized coin supply.
+const coinSupplySize = chainhash.HashSize + 8*4
+
+// serializeCoinSupply returns the serialization of the passed coin supply.
+func serializeCoinSupply(supply *CoinSupply) []byte {
+	serialized := make([]byte, coinSupplySize)
+	copy(serialized, supply.Hash[:])
+	offset := chainhash.HashSize
+	for _, amount := range []int64{supply.Premine, supply.PoW, supply.PoS,
+		supply.DevSubsidy} {
+
+		dbnamespace.ByteOrder.PutUint64(serialized[offset:], uint64(amount))
+		offset += 8
+	}
+	return serialized
+}
+
+// deserializeCoinSupply decodes the passed serialized coin supply for the block
+// at the passed height.
+func deserializeCoinSupply(serialized []byte, height int64) (*CoinSupply, error) {
+	if len(serialized) != coinSupplySize {
+		return nil, errDeserialize(fmt.Sprintf("unexpected coin supply "+
+			"size; want %d, got %d", coinSupplySize, len(serialized)))
+	}
+
+	supply := CoinSupply{Height: height}
+	copy(supply.Hash[:], serialized[:chainhash.HashSize])
+	offset := chainhash.HashSize
+	for _, amount := range []*int64{&supply.Premine, &supply.PoW,
+		&supply.PoS, &supply.DevSubsidy} {
+
+		*amount = int64(dbnamespace.ByteOrder.Uint64(serialized[offset:]))
+		offset += 8
+	}
+	return &supply, nil
+}
+
+// dbPutCoinSupply uses an existing database transaction to store the passed
+// coin supply as the coin supply of the main chain.
+func dbPutCoinSupply(dbTx database.Tx, supply *CoinSupply) error {
+	return dbTx.Metadata().Put(dbnamespace.CoinSupplyKeyName,
+		serializeCoinSupply(supply))
+}
+
+// dbFetchCoinSupply uses an existing database transaction to fetch the coin
+// supply of the main chain, which is for the block at the passed height.  Nil
+// is returned when it has not been stored yet.
+func dbFetchCoinSupply(dbTx database.Tx, height int64) (*CoinSupply, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.CoinSupplyKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+
+	supply, err := deserializeCoinSupply(serialized, height)
+	if err != nil {
+		// Ensure any deserialization errors are returned as database
+		// corruption errors.
+		if isDeserializeErr(err) {
+			return nil, database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt coin supply: %v",
+					err),
+			}
+		}
+
+		return nil, err
+	}
+	return supply, nil
+}
+
+// initCoinSupply loads the coin supply of the main chain from the database.
+// Databases which do not store the coin supply yet are upgraded by calculating
+// it from all blocks of the main chain.
+func (b *BlockChain) initCoinSupply() error {
+	best := b.bestNode
+	var supply *CoinSupply
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		supply, err = dbFetchCoinSupply(dbTx, best.height)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if supply != nil {
+		if supply.Hash != best.hash {
+			return AssertError(fmt.Sprintf("coin supply is for block "+
+				"%v instead of the best block %v", supply.Hash,
+				best.hash))
+		}
+		b.coinSupply = *supply
+		return nil
+	}
+
+	log.Infof("Calculating the coin supply of the main chain")
+	progressLogger := progresslog.NewBlockProgressLogger("Processed", log)
+	supply = &CoinSupply{Hash: *b.chainParams.GenesisHash}
+	err = b.db.Update(func(dbTx database.Tx) error {
+		parent, err := dbFetchBlockByHeight(dbTx, 0)
+		if err != nil {
+			return err
+		}
+
+		for height := int64(1); height <= best.height; height++ {
+			block, err := dbFetchBlockByHeight(dbTx, height)
+			if err != nil {
+				return err
+			}
+
+			added := b.addedCoinSupply(block, parent)
+			supply.Hash = *block.Hash()
+			supply.Height = height
+			supply.Premine += added.Premine
+			supply.PoW += added.PoW
+			supply.PoS += added.PoS
+			supply.DevSubsidy += added.DevSubsidy
+
+			progressLogger.LogBlockHeight(block.MsgBlock(),
+				parent.MsgBlock())
+			parent = block
+		}
+
+		return dbPutCoinSupply(dbTx, supply)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.coinSupply = *supply
+	return nil
+}
+
+// CoinSupply returns the total amount of coins mined by the main chain as of
+// the end of the main chain split by the source of the subsidy.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CoinSupply() CoinSupply {
+	b.stateLock.RLock()
+	supply := b.coinSupply
+	b.stateLock.RUnlock()
+	return supply
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// TestCoinSupplySerialization ensures serializing and deserializing the coin
+// supply works as expected.
+func TestCoinSupplySerialization(t *testing.T) {
+	t.Parallel()
+
+	supply := &CoinSupply{
+		Hash:       chainhash.Hash{0x0c},
+		Height:     152841,
+		Premine:    168000000000000,
+		PoW:        263358112968045,
+		PoS:        131686470728971,
+		DevSubsidy: 44388332150247,
+	}
+	serialized := serializeCoinSupply(supply)
+	got, err := deserializeCoinSupply(serialized, supply.Height)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, supply) {
+		t.Fatalf("mismatched coin supply -- got %+v, want %+v", got, supply)
+	}
+	if got.Total() != 607432915847263 {
+		t.Fatalf("unexpected total -- got %d, want %d", got.Total(),
+			int64(607432915847263))
+	}
+
+	// Ensure truncated coin supplies are rejected.
+	_, err = deserializeCoinSupply(serialized[:len(serialized)-1],
+		supply.Height)
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated coin supply -- got %v",
+			err)
+	}
+}
+
+// TestAddedCoinSupply ensures the subsidy of the coinbase of the parent of a
+// block is added to the coin supply split by the subsidy source as expected.
+func TestAddedCoinSupply(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+	b := &BlockChain{
+		chainParams:  params,
+		subsidyCache: NewSubsidyCache(0, params),
+	}
+
+	const voters = 5
+	work := CalcBlockWorkSubsidy(b.subsidyCache, 4096, voters, params)
+	tax := CalcBlockTaxSubsidy(b.subsidyCache, 4096, voters, params)
+	blockOneSubsidy := params.BlockOneSubsidy()
+
+	tests := []struct {
+		name         string
+		parentHeight uint32
+		valueIn      int64
+		voteBits     uint16
+		want         CoinSupply
+	}{
+		{
+			name:         "block one approved",
+			parentHeight: 1,
+			valueIn:      blockOneSubsidy,
+			voteBits:     dcrutil.BlockValid,
+			want:         CoinSupply{Premine: blockOneSubsidy},
+		},
+		{
+			name:         "parent approved",
+			parentHeight: 4096,
+			valueIn:      work + tax,
+			voteBits:     dcrutil.BlockValid,
+			want:         CoinSupply{PoW: work, DevSubsidy: tax},
+		},
+		{
+			name:         "parent disapproved",
+			parentHeight: 4096,
+			valueIn:      work + tax,
+			voteBits:     0,
+		},
+	}
+	for _, test := range tests {
+		parent := dcrutil.NewBlock(&wire.MsgBlock{
+			Header: wire.BlockHeader{
+				Height: test.parentHeight,
+				Voters: voters,
+			},
+			Transactions: []*wire.MsgTx{{
+				TxIn: []*wire.TxIn{{ValueIn: test.valueIn}},
+			}},
+		})
+		block := dcrutil.NewBlock(&wire.MsgBlock{
+			Header: wire.BlockHeader{
+				Height:   test.parentHeight + 1,
+				VoteBits: test.voteBits,
+			},
+		})
+		added := b.addedCoinSupply(block, parent)
+		if !reflect.DeepEqual(added, test.want) {
+			t.Errorf("%s: unexpected added coin supply -- got %+v, "+
+				"want %+v", test.name, added, test.want)
+		}
+	}
+}
@@ -60,6 +60,14 @@ func isSupportedDbType(dbType string) bool {
 // block already inserted.  In addition to the new chain instnce, it returns
 // a teardown function the caller should invoke when done testing to clean up.
 func chainSetup(dbName string, params *chaincfg.Params) (*blockchain.BlockChain, func(), error) {
+	return chainSetupWithNotifications(dbName, params, nil)
+}
+
+// chainSetupWithNotifications is chainSetup with the passed callback provided
+// to the chain instance for notifications.
+func chainSetupWithNotifications(dbName string, params *chaincfg.Params,
+	ntfns blockchain.NotificationCallback) (*blockchain.BlockChain, func(), error) {
+
 	if !isSupportedDbType(testDbType) {
 		return nil, nil, fmt.Errorf("unsupported db type %v", testDbType)
 	}
@@ -114,9 +122,10 @@ func chainSetup(dbName string, params *chaincfg.Params) (*blockchain.BlockChain,
 
 	// Create the main chain instance.
 	chain, err := blockchain.New(&blockchain.Config{
-		DB:          db,
-		ChainParams: &paramsCopy,
-		TimeSource:  blockchain.NewMedianTime(),
+		DB:            db,
+		ChainParams:   &paramsCopy,
+		TimeSource:    blockchain.NewMedianTime(),
+		Notifications: ntfns,
 	})
 
 	if err != nil {
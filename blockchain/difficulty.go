@@ -491,6 +491,12 @@ func mergeDifficulty(oldDiff int64, newDiff1 int64, newDiff2 int64) int64 {
 // TODO: You can combine the first and second for loops below for a speed up
 // if you'd like, I'm not sure how much it matters.
 func (b *BlockChain) calcNextRequiredStakeDifficulty(curNode *blockNode) (int64, error) {
+	// Use the forced stake difficulty when one has been set on the
+	// simulation test network.
+	if sbits := b.forcedStakeDifficulty(); sbits != 0 {
+		return sbits, nil
+	}
+
 	alpha := b.chainParams.StakeDiffAlpha
 	stakeDiffStartHeight := int64(b.chainParams.CoinbaseMaturity) +
 		1
@@ -756,6 +762,12 @@ func (b *BlockChain) CalcNextRequiredStakeDifficulty() (int64, error) {
 // maximum number of tickets the user can try.
 func (b *BlockChain) estimateNextStakeDifficulty(curNode *blockNode,
 	ticketsInWindow int64, useMaxTickets bool) (int64, error) {
+	// Use the forced stake difficulty when one has been set on the
+	// simulation test network.
+	if sbits := b.forcedStakeDifficulty(); sbits != 0 {
+		return sbits, nil
+	}
+
 	alpha := b.chainParams.StakeDiffAlpha
 	stakeDiffStartHeight := int64(b.chainParams.CoinbaseMaturity) +
 		1
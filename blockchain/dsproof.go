@@ -0,0 +1,131 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+)
+
+// newDoubleSpendProofSpend returns a spend for a double spend proof from a copy
+// of the passed transaction with the signature scripts of all inputs other than
+// the one which spends the outpoint removed.  False is returned when the
+// transaction does not spend the outpoint.
+func newDoubleSpendProofSpend(outPoint *wire.OutPoint, tx *wire.MsgTx) (*wire.DoubleSpendProofSpend, bool) {
+	spend := &wire.DoubleSpendProofSpend{Tx: *tx.Copy()}
+	found := false
+	for i, txIn := range spend.Tx.TxIn {
+		if !found && txIn.PreviousOutPoint == *outPoint {
+			spend.InputIndex = uint32(i)
+			found = true
+			continue
+		}
+		txIn.SignatureScript = nil
+	}
+	return spend, found
+}
+
+// NewDoubleSpendProof returns a double spend proof which shows the passed
+// transactions both spend the provided outpoint.  The signature scripts of the
+// inputs which do not spend the outpoint are removed from the copies of the
+// transactions in the proof to keep it compact, and the spends are ordered by
+// transaction hash so the same proof is created regardless of the order in
+// which the transactions were seen.
+//
+// An error is returned when either transaction does not spend the outpoint or
+// the transactions have the same hash.  The proof is not otherwise checked, so
+// callers which did not already validate the scripts of both transactions
+// should use CheckDoubleSpendProof to do so.
+func NewDoubleSpendProof(outPoint *wire.OutPoint, tx1, tx2 *wire.MsgTx) (*wire.MsgDoubleSpendProof, error) {
+	spend1, ok := newDoubleSpendProofSpend(outPoint, tx1)
+	if !ok {
+		str := fmt.Sprintf("transaction %v does not spend %v",
+			tx1.TxHash(), outPoint)
+		return nil, ruleError(ErrBadDoubleSpendProof, str)
+	}
+	spend2, ok := newDoubleSpendProofSpend(outPoint, tx2)
+	if !ok {
+		str := fmt.Sprintf("transaction %v does not spend %v",
+			tx2.TxHash(), outPoint)
+		return nil, ruleError(ErrBadDoubleSpendProof, str)
+	}
+
+	hash1, hash2 := tx1.TxHash(), tx2.TxHash()
+	switch bytes.Compare(hash1[:], hash2[:]) {
+	case 0:
+		str := fmt.Sprintf("transaction %v can not double spend "+
+			"itself", hash1)
+		return nil, ruleError(ErrBadDoubleSpendProof, str)
+	case 1:
+		spend1, spend2 = spend2, spend1
+	}
+
+	return wire.NewMsgDoubleSpendProof(outPoint, spend1, spend2), nil
+}
+
+// CheckDoubleSpendProof ensures the passed double spend proof shows two
+// different regular transactions which both spend the outpoint with valid
+// signature scripts.  The public key script and script version are those of the
+// output referenced by the outpoint, and the script flags and signature cache
+// are used when executing the scripts.
+//
+// Stake transactions are not accepted since votes which spend the same ticket
+// are expected and do not indicate an attempt to defraud anyone.
+func CheckDoubleSpendProof(proof *wire.MsgDoubleSpendProof, pkScript []byte, scriptVersion uint16, flags txscript.ScriptFlags, sigCache *txscript.SigCache) error {
+	// The spends must be ordered by their hashes which also ensures they
+	// are different transactions.
+	hash1 := proof.Spends[0].Tx.TxHash()
+	hash2 := proof.Spends[1].Tx.TxHash()
+	if bytes.Compare(hash1[:], hash2[:]) >= 0 {
+		str := fmt.Sprintf("double spend proof spends %v and %v are "+
+			"not in ascending order", hash1, hash2)
+		return ruleError(ErrBadDoubleSpendProof, str)
+	}
+
+	for i := range proof.Spends {
+		spend := &proof.Spends[i]
+		tx := &spend.Tx
+		txHash := tx.TxHash()
+		if spend.InputIndex >= uint32(len(tx.TxIn)) {
+			str := fmt.Sprintf("double spend proof input index %d "+
+				"is out of range for transaction %v with %d "+
+				"inputs", spend.InputIndex, txHash, len(tx.TxIn))
+			return ruleError(ErrBadDoubleSpendProof, str)
+		}
+		txIn := tx.TxIn[spend.InputIndex]
+		if txIn.PreviousOutPoint != proof.OutPoint {
+			str := fmt.Sprintf("transaction %v input %d spends %v "+
+				"instead of %v", txHash, spend.InputIndex,
+				txIn.PreviousOutPoint, proof.OutPoint)
+			return ruleError(ErrBadDoubleSpendProof, str)
+		}
+		if stake.DetermineTxType(tx) != stake.TxTypeRegular {
+			str := fmt.Sprintf("transaction %v is a stake "+
+				"transaction", txHash)
+			return ruleError(ErrBadDoubleSpendProof, str)
+		}
+
+		vm, err := txscript.NewEngine(pkScript, tx,
+			int(spend.InputIndex), flags, scriptVersion, sigCache)
+		if err != nil {
+			str := fmt.Sprintf("failed to parse input %v:%d which "+
+				"references output %v - %v", txHash,
+				spend.InputIndex, proof.OutPoint, err)
+			return ruleError(ErrBadDoubleSpendProof, str)
+		}
+		if err := vm.Execute(); err != nil {
+			str := fmt.Sprintf("failed to validate input %v:%d "+
+				"which references output %v - %v", txHash,
+				spend.InputIndex, proof.OutPoint, err)
+			return ruleError(ErrBadDoubleSpendProof, str)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestDoubleSpendProof ensures double spend proofs are created with the
+// expected spends and that only valid proofs pass the checks.
+func TestDoubleSpendProof(t *testing.T) {
+	t.Parallel()
+
+	// The outpoint is locked by a script which anyone can spend so the
+	// spends only need an empty signature script.
+	pkScript := []byte{txscript.OP_TRUE}
+	outPoint := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 1}
+	otherOutPoint := wire.OutPoint{Hash: chainhash.Hash{0x02}}
+	newSpend := func(value int64) *wire.MsgTx {
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(wire.NewTxIn(&otherOutPoint, []byte{txscript.OP_TRUE}))
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil))
+		tx.AddTxOut(wire.NewTxOut(value, pkScript))
+		return tx
+	}
+	tx1, tx2 := newSpend(1), newSpend(2)
+
+	// Ensure the proof does not depend on the order of the transactions
+	// and that the other signature scripts are removed.
+	proof, err := NewDoubleSpendProof(&outPoint, tx1, tx2)
+	if err != nil {
+		t.Fatalf("NewDoubleSpendProof: unexpected error: %v", err)
+	}
+	reversed, err := NewDoubleSpendProof(&outPoint, tx2, tx1)
+	if err != nil {
+		t.Fatalf("NewDoubleSpendProof: unexpected error: %v", err)
+	}
+	var buf1, buf2 bytes.Buffer
+	if err := proof.BtcEncode(&buf1, wire.ProtocolVersion); err != nil {
+		t.Fatalf("BtcEncode: unexpected error: %v", err)
+	}
+	if err := reversed.BtcEncode(&buf2, wire.ProtocolVersion); err != nil {
+		t.Fatalf("BtcEncode: unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf1.Bytes(), buf2.Bytes()) {
+		t.Fatal("NewDoubleSpendProof: proof depends on transaction order")
+	}
+	for i, spend := range proof.Spends {
+		if spend.InputIndex != 1 {
+			t.Fatalf("NewDoubleSpendProof: spend %d has input index "+
+				"%d, want 1", i, spend.InputIndex)
+		}
+		if len(spend.Tx.TxIn[0].SignatureScript) != 0 {
+			t.Fatalf("NewDoubleSpendProof: spend %d kept signature "+
+				"script of other input", i)
+		}
+	}
+	if len(tx1.TxIn[0].SignatureScript) == 0 {
+		t.Fatal("NewDoubleSpendProof: modified the passed transaction")
+	}
+
+	// Ensure proofs which can not be created are rejected.
+	if _, err := NewDoubleSpendProof(&outPoint, tx1, tx1); err == nil {
+		t.Fatal("NewDoubleSpendProof: created proof for a single " +
+			"transaction")
+	}
+	unrelated := newSpend(3)
+	unrelated.TxIn = unrelated.TxIn[:1]
+	if _, err := NewDoubleSpendProof(&outPoint, tx1, unrelated); err == nil {
+		t.Fatal("NewDoubleSpendProof: created proof for a " +
+			"transaction which does not spend the outpoint")
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(proof *wire.MsgDoubleSpendProof)
+		pkScript []byte
+		valid    bool
+	}{
+		{
+			name:     "valid proof",
+			modify:   func(proof *wire.MsgDoubleSpendProof) {},
+			pkScript: pkScript,
+			valid:    true,
+		},
+		{
+			name: "spends out of order",
+			modify: func(proof *wire.MsgDoubleSpendProof) {
+				proof.Spends[0], proof.Spends[1] =
+					proof.Spends[1], proof.Spends[0]
+			},
+			pkScript: pkScript,
+		},
+		{
+			name: "same transaction",
+			modify: func(proof *wire.MsgDoubleSpendProof) {
+				proof.Spends[1] = proof.Spends[0]
+			},
+			pkScript: pkScript,
+		},
+		{
+			name: "input index out of range",
+			modify: func(proof *wire.MsgDoubleSpendProof) {
+				proof.Spends[1].InputIndex = 2
+			},
+			pkScript: pkScript,
+		},
+		{
+			name: "input does not spend outpoint",
+			modify: func(proof *wire.MsgDoubleSpendProof) {
+				proof.Spends[0].InputIndex = 0
+			},
+			pkScript: pkScript,
+		},
+		{
+			name:     "invalid script",
+			modify:   func(proof *wire.MsgDoubleSpendProof) {},
+			pkScript: []byte{txscript.OP_FALSE},
+		},
+	}
+	for _, test := range tests {
+		p, err := NewDoubleSpendProof(&outPoint, tx1, tx2)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		test.modify(p)
+		err = CheckDoubleSpendProof(p, test.pkScript, 0,
+			txscript.ScriptBip16, nil)
+		if test.valid {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok {
+			t.Errorf("%s: unexpected error type -- got %T, want "+
+				"RuleError", test.name, err)
+			continue
+		}
+		if rerr.ErrorCode != ErrBadDoubleSpendProof {
+			t.Errorf("%s: unexpected error code -- got %v, want %v",
+				test.name, rerr.ErrorCode, ErrBadDoubleSpendProof)
+		}
+	}
+}
@@ -7,6 +7,8 @@ package blockchain
 
 import (
 	"fmt"
+
+	"github.com/decred/dcrd/wire"
 )
 
 // VoteVersionError identifies an error that indicates a vote version was
@@ -29,6 +31,17 @@ func (e HashError) Error() string {
 	return fmt.Sprintf("hash %v does not exist", string(e))
 }
 
+// StaleChainViewError identifies an error that indicates a read could not be
+// answered by a ChainView because the main chain no longer agrees with the
+// block the view is pinned to.
+type StaleChainViewError string
+
+// Error returns the assertion error as a human-readable string and satisfies
+// the error interface.
+func (e StaleChainViewError) Error() string {
+	return fmt.Sprintf("chain view pinned to block %v is stale", string(e))
+}
+
 // DeploymentError identifies an error that indicates a deployment ID was
 // specified that does not exist.
 type DeploymentError string
@@ -39,6 +52,16 @@ func (e DeploymentError) Error() string {
 	return fmt.Sprintf("deployment ID %v does not exist", string(e))
 }
 
+// AnnotationError identifies an error that indicates a block annotation was
+// rejected because it does not meet the limits on annotations.
+type AnnotationError string
+
+// Error returns the annotation error as a human-readable string and satisfies
+// the error interface.
+func (e AnnotationError) Error() string {
+	return "invalid block annotation: " + string(e)
+}
+
 // AssertError identifies an error that indicates an internal code consistency
 // issue and should be treated as a critical and unrecoverable error.
 type AssertError string
@@ -50,6 +73,11 @@ func (e AssertError) Error() string {
 }
 
 // ErrorCode identifies a kind of error.
+//
+// The numeric values of the error codes are stable since they are exposed to
+// RPC clients so they may programmatically handle rejections.  New codes must
+// only ever be added to the end of the list and existing codes must never be
+// removed or reordered.
 type ErrorCode int
 
 // These constants are used to identify a specific RuleError.
@@ -452,6 +480,54 @@ const (
 	// ErrInvalidEarlyVoteBits indicates that a block before stake validation
 	// height had an unallowed vote bits value.
 	ErrInvalidEarlyVoteBits
+
+	// ErrKnownInvalidBlock indicates that the block previously failed
+	// validation.
+	ErrKnownInvalidBlock
+
+	// ErrInvalidAncestor indicates that the block builds on a block which
+	// failed validation.
+	ErrInvalidAncestor
+
+	// ErrInactiveTxVersion indicates that a transaction uses a transaction
+	// version which is not valid until an agenda that is not yet active.
+	ErrInactiveTxVersion
+
+	// ErrInactiveScriptVersion indicates that a transaction output uses a
+	// script version which is not valid until an agenda that is not yet
+	// active.
+	ErrInactiveScriptVersion
+
+	// ErrBadDoubleSpendProof indicates that a double spend proof does not
+	// show two different valid transactions spending the same output.
+	ErrBadDoubleSpendProof
+
+	// ErrForkBeforeFinalized indicates a block is attempting to fork the
+	// block chain at or before the most recent block which is final
+	// according to the finality depth enforced by the consensus rules.
+	ErrForkBeforeFinalized
+
+	// ErrReorgBeyondFinality indicates that a reorganize would disconnect a
+	// block which is final according to the finality depth enforced by the
+	// consensus rules.
+	ErrReorgBeyondFinality
+
+	// ErrBadTreasurySpend indicates a transaction spends a treasury output
+	// without being authorized to do so by one of the treasury keys
+	// defined by the network, or spends it from the stake transaction tree.
+	ErrBadTreasurySpend
+
+	// ErrInvalidatedBlock indicates that the block was manually invalidated.
+	ErrInvalidatedBlock
+
+	// ErrBadAssumeValid indicates a block that is expected to be at the
+	// height of the assumed valid block does not match it.
+	ErrBadAssumeValid
+
+	// ErrBadUtxoCommitment indicates the utxo set commitment in the extra
+	// data of a block does not match the utxo set which results from its
+	// parent while the experimental utxo commitments are enabled.
+	ErrBadUtxoCommitment
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
@@ -548,6 +624,17 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrFraudBlockIndex:        "ErrFraudBlockIndex",
 	ErrZeroValueOutputSpend:   "ErrZeroValueOutputSpend",
 	ErrInvalidEarlyVoteBits:   "ErrInvalidEarlyVoteBits",
+	ErrKnownInvalidBlock:      "ErrKnownInvalidBlock",
+	ErrInvalidAncestor:        "ErrInvalidAncestor",
+	ErrInactiveTxVersion:      "ErrInactiveTxVersion",
+	ErrInactiveScriptVersion:  "ErrInactiveScriptVersion",
+	ErrBadDoubleSpendProof:    "ErrBadDoubleSpendProof",
+	ErrForkBeforeFinalized:    "ErrForkBeforeFinalized",
+	ErrReorgBeyondFinality:    "ErrReorgBeyondFinality",
+	ErrBadTreasurySpend:       "ErrBadTreasurySpend",
+	ErrInvalidatedBlock:       "ErrInvalidatedBlock",
+	ErrBadAssumeValid:         "ErrBadAssumeValid",
+	ErrBadUtxoCommitment:      "ErrBadUtxoCommitment",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -563,9 +650,23 @@ func (e ErrorCode) String() string {
 // rules.  The caller can use type assertions to determine if a failure was
 // specifically due to a rule violation and access the ErrorCode field to
 // ascertain the specific reason for the rule violation.
+//
+// The Context field, when non-nil, provides structured details about where the
+// rule violation was found.
 type RuleError struct {
-	ErrorCode   ErrorCode // Describes the kind of error
-	Description string    // Human readable description of the issue
+	ErrorCode   ErrorCode         // Describes the kind of error
+	Description string            // Human readable description of the issue
+	Context     *RuleErrorContext // Optional location of the violation
+}
+
+// RuleErrorContext houses details about where a rule violation was found.  Any
+// details which are not known are set to -1 and the TxTree field is set to
+// wire.TxTreeUnknown.
+type RuleErrorContext struct {
+	Height     int64 // Height of the block containing the violation
+	TxTree     int8  // Tree of the transaction containing the violation
+	TxIndex    int   // Index of the transaction within its tree
+	InputIndex int   // Index of the offending transaction input
 }
 
 // Error satisfies the error interface and prints human-readable errors.
@@ -577,3 +678,51 @@ func (e RuleError) Error() string {
 func ruleError(c ErrorCode, desc string) RuleError {
 	return RuleError{ErrorCode: c, Description: desc}
 }
+
+// inputRuleError creates a RuleError for a violation by the transaction input
+// at the provided index.
+func inputRuleError(c ErrorCode, desc string, inputIdx int) RuleError {
+	return RuleError{
+		ErrorCode:   c,
+		Description: desc,
+		Context: &RuleErrorContext{
+			Height:     -1,
+			TxTree:     wire.TxTreeUnknown,
+			TxIndex:    -1,
+			InputIndex: inputIdx,
+		},
+	}
+}
+
+// addRuleErrorContext returns the passed error with the provided block height
+// and transaction location added to its context when it is a RuleError.  Any
+// details the context already specifies are retained since they were provided
+// closer to the violation, and negative values are ignored.  All other errors,
+// including nil, are returned unmodified.
+func addRuleErrorContext(err error, height int64, txTree int8, txIdx int) error {
+	rerr, ok := err.(RuleError)
+	if !ok {
+		return err
+	}
+
+	ctx := RuleErrorContext{
+		Height:     -1,
+		TxTree:     wire.TxTreeUnknown,
+		TxIndex:    -1,
+		InputIndex: -1,
+	}
+	if rerr.Context != nil {
+		ctx = *rerr.Context
+	}
+	if ctx.Height < 0 {
+		ctx.Height = height
+	}
+	if ctx.TxTree == wire.TxTreeUnknown {
+		ctx.TxTree = txTree
+	}
+	if ctx.TxIndex < 0 {
+		ctx.TxIndex = txIdx
+	}
+	rerr.Context = &ctx
+	return rerr
+}
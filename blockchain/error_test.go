@@ -54,6 +54,17 @@ func TestErrorCodeStringer(t *testing.T) {
 		{blockchain.ErrBadCoinbaseValue, "ErrBadCoinbaseValue"},
 		{blockchain.ErrScriptMalformed, "ErrScriptMalformed"},
 		{blockchain.ErrScriptValidation, "ErrScriptValidation"},
+		{blockchain.ErrKnownInvalidBlock, "ErrKnownInvalidBlock"},
+		{blockchain.ErrInvalidAncestor, "ErrInvalidAncestor"},
+		{blockchain.ErrInactiveTxVersion, "ErrInactiveTxVersion"},
+		{blockchain.ErrInactiveScriptVersion, "ErrInactiveScriptVersion"},
+		{blockchain.ErrBadDoubleSpendProof, "ErrBadDoubleSpendProof"},
+		{blockchain.ErrForkBeforeFinalized, "ErrForkBeforeFinalized"},
+		{blockchain.ErrReorgBeyondFinality, "ErrReorgBeyondFinality"},
+		{blockchain.ErrBadTreasurySpend, "ErrBadTreasurySpend"},
+		{blockchain.ErrInvalidatedBlock, "ErrInvalidatedBlock"},
+		{blockchain.ErrBadAssumeValid, "ErrBadAssumeValid"},
+		{blockchain.ErrBadUtxoCommitment, "ErrBadUtxoCommitment"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
@@ -68,6 +79,130 @@ func TestErrorCodeStringer(t *testing.T) {
 	}
 }
 
+// TestErrorCodeValues ensures the numeric values of the error codes, which are
+// exposed over RPC, do not change.
+func TestErrorCodeValues(t *testing.T) {
+	tests := []struct {
+		in   blockchain.ErrorCode
+		want int
+	}{
+		{blockchain.ErrDuplicateBlock, 0},
+		{blockchain.ErrMissingParent, 1},
+		{blockchain.ErrBlockTooBig, 2},
+		{blockchain.ErrWrongBlockSize, 3},
+		{blockchain.ErrBlockVersionTooOld, 4},
+		{blockchain.ErrBadStakeVersion, 5},
+		{blockchain.ErrInvalidTime, 6},
+		{blockchain.ErrTimeTooOld, 7},
+		{blockchain.ErrTimeTooNew, 8},
+		{blockchain.ErrDifficultyTooLow, 9},
+		{blockchain.ErrUnexpectedDifficulty, 10},
+		{blockchain.ErrHighHash, 11},
+		{blockchain.ErrBadMerkleRoot, 12},
+		{blockchain.ErrBadCheckpoint, 13},
+		{blockchain.ErrForkTooOld, 14},
+		{blockchain.ErrCheckpointTimeTooOld, 15},
+		{blockchain.ErrNoTransactions, 16},
+		{blockchain.ErrTooManyTransactions, 17},
+		{blockchain.ErrNoTxInputs, 18},
+		{blockchain.ErrNoTxOutputs, 19},
+		{blockchain.ErrTxTooBig, 20},
+		{blockchain.ErrBadTxOutValue, 21},
+		{blockchain.ErrDuplicateTxInputs, 22},
+		{blockchain.ErrBadTxInput, 23},
+		{blockchain.ErrMissingTx, 24},
+		{blockchain.ErrUnfinalizedTx, 25},
+		{blockchain.ErrDuplicateTx, 26},
+		{blockchain.ErrOverwriteTx, 27},
+		{blockchain.ErrImmatureSpend, 28},
+		{blockchain.ErrDoubleSpend, 29},
+		{blockchain.ErrSpendTooHigh, 30},
+		{blockchain.ErrBadFees, 31},
+		{blockchain.ErrTooManySigOps, 32},
+		{blockchain.ErrFirstTxNotCoinbase, 33},
+		{blockchain.ErrCoinbaseHeight, 34},
+		{blockchain.ErrMultipleCoinbases, 35},
+		{blockchain.ErrStakeTxInRegularTree, 36},
+		{blockchain.ErrRegTxInStakeTree, 37},
+		{blockchain.ErrBadCoinbaseScriptLen, 38},
+		{blockchain.ErrBadCoinbaseValue, 39},
+		{blockchain.ErrBadCoinbaseOutpoint, 40},
+		{blockchain.ErrBadCoinbaseFraudProof, 41},
+		{blockchain.ErrBadCoinbaseAmountIn, 42},
+		{blockchain.ErrBadStakebaseAmountIn, 43},
+		{blockchain.ErrBadStakebaseScriptLen, 44},
+		{blockchain.ErrBadStakebaseScrVal, 45},
+		{blockchain.ErrScriptMalformed, 46},
+		{blockchain.ErrScriptValidation, 47},
+		{blockchain.ErrNotEnoughStake, 48},
+		{blockchain.ErrStakeBelowMinimum, 49},
+		{blockchain.ErrNonstandardStakeTx, 50},
+		{blockchain.ErrNotEnoughVotes, 51},
+		{blockchain.ErrTooManyVotes, 52},
+		{blockchain.ErrFreshStakeMismatch, 53},
+		{blockchain.ErrTooManySStxs, 54},
+		{blockchain.ErrInvalidEarlyStakeTx, 55},
+		{blockchain.ErrTicketUnavailable, 56},
+		{blockchain.ErrVotesOnWrongBlock, 57},
+		{blockchain.ErrVotesMismatch, 58},
+		{blockchain.ErrIncongruentVotebit, 59},
+		{blockchain.ErrInvalidSSRtx, 60},
+		{blockchain.ErrRevocationsMismatch, 61},
+		{blockchain.ErrTooManyRevocations, 62},
+		{blockchain.ErrSStxCommitment, 63},
+		{blockchain.ErrUnparseableSSGen, 64},
+		{blockchain.ErrInvalidSSGenInput, 65},
+		{blockchain.ErrSSGenPayeeNum, 66},
+		{blockchain.ErrSSGenPayeeOuts, 67},
+		{blockchain.ErrSSGenSubsidy, 68},
+		{blockchain.ErrSStxInImmature, 69},
+		{blockchain.ErrSStxInScrType, 70},
+		{blockchain.ErrInvalidSSRtxInput, 71},
+		{blockchain.ErrSSRtxPayeesMismatch, 72},
+		{blockchain.ErrSSRtxPayees, 73},
+		{blockchain.ErrTxSStxOutSpend, 74},
+		{blockchain.ErrRegTxSpendStakeOut, 75},
+		{blockchain.ErrInvalidFinalState, 76},
+		{blockchain.ErrPoolSize, 77},
+		{blockchain.ErrForceReorgWrongChain, 78},
+		{blockchain.ErrForceReorgMissingChild, 79},
+		{blockchain.ErrBadStakebaseValue, 80},
+		{blockchain.ErrDiscordantTxTree, 81},
+		{blockchain.ErrStakeFees, 82},
+		{blockchain.ErrNoStakeTx, 83},
+		{blockchain.ErrBadBlockHeight, 84},
+		{blockchain.ErrBlockOneTx, 85},
+		{blockchain.ErrBlockOneInputs, 86},
+		{blockchain.ErrBlockOneOutputs, 87},
+		{blockchain.ErrNoTax, 88},
+		{blockchain.ErrExpiredTx, 89},
+		{blockchain.ErrExpiryTxSpentEarly, 90},
+		{blockchain.ErrFraudAmountIn, 91},
+		{blockchain.ErrFraudBlockHeight, 92},
+		{blockchain.ErrFraudBlockIndex, 93},
+		{blockchain.ErrZeroValueOutputSpend, 94},
+		{blockchain.ErrInvalidEarlyVoteBits, 95},
+		{blockchain.ErrKnownInvalidBlock, 96},
+		{blockchain.ErrInvalidAncestor, 97},
+		{blockchain.ErrInactiveTxVersion, 98},
+		{blockchain.ErrInactiveScriptVersion, 99},
+		{blockchain.ErrBadDoubleSpendProof, 100},
+		{blockchain.ErrForkBeforeFinalized, 101},
+		{blockchain.ErrReorgBeyondFinality, 102},
+		{blockchain.ErrBadTreasurySpend, 103},
+		{blockchain.ErrInvalidatedBlock, 104},
+		{blockchain.ErrBadAssumeValid, 105},
+		{blockchain.ErrBadUtxoCommitment, 106},
+	}
+
+	for _, test := range tests {
+		if int(test.in) != test.want {
+			t.Errorf("%v: unexpected value - got %d, want %d", test.in,
+				int(test.in), test.want)
+		}
+	}
+}
+
 // TestRuleError tests the error output for the RuleError type.
 func TestRuleError(t *testing.T) {
 	tests := []struct {
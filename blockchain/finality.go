@@ -0,0 +1,163 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// agendaFinality describes a finality depth which is enforced by the consensus
+// rules once the agenda with the given stake version and vote ID is active.
+// Blocks which are buried under at least depth blocks of the main chain are
+// final and may no longer be disconnected by a reorganize.
+type agendaFinality struct {
+	version uint32
+	voteID  string
+	depth   int64
+}
+
+// finalityAgendas houses the finality depths which are enforced once the
+// associated agendas are active.  Agendas which introduce or change the
+// finality depth must add an entry here so that block validation and the
+// reported finalized height always agree on the rules.  There are currently no
+// such agendas.
+var finalityAgendas []agendaFinality
+
+// finalityDepthForAgendas returns the finality depth which is enforced by the
+// consensus rules given the passed agendas and a function that returns whether
+// or not the agenda with a given stake version and vote ID is active.  The
+// smallest depth of all active agendas is enforced.  A depth of zero indicates
+// no finality depth is enforced.
+func finalityDepthForAgendas(agendas []agendaFinality, isActive func(version uint32, voteID string) (bool, error)) (int64, error) {
+	var depth int64
+	for _, agenda := range agendas {
+		if agenda.depth <= 0 || (depth != 0 && agenda.depth >= depth) {
+			continue
+		}
+		active, err := isActive(agenda.version, agenda.voteID)
+		if err != nil {
+			return 0, err
+		}
+		if active {
+			depth = agenda.depth
+		}
+	}
+	return depth, nil
+}
+
+// finalizedHeightForDepth returns the height of the most recent final block of
+// a main chain which ends at the passed height given the passed finality depth.
+// The genesis block is always final, so zero is returned when no finality depth
+// is enforced or the main chain is not yet long enough.
+func finalizedHeightForDepth(bestHeight, depth int64) int64 {
+	if depth <= 0 || bestHeight < depth {
+		return 0
+	}
+	return bestHeight - depth
+}
+
+// finalityDepth returns the finality depth which is enforced by the consensus
+// rules for the main chain which ends at the given node.  A depth of zero
+// indicates no finality depth is enforced.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) finalityDepth(node *blockNode) (int64, error) {
+	return finalityDepthForAgendas(finalityAgendas, func(version uint32, voteID string) (bool, error) {
+		return b.isAgendaActive(node, version, voteID)
+	})
+}
+
+// finalizedHeight returns the height of the most recent final block of the
+// current best chain.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) finalizedHeight() (int64, error) {
+	depth, err := b.finalityDepth(b.bestNode)
+	if err != nil {
+		return 0, err
+	}
+	return finalizedHeightForDepth(b.bestNode.height, depth), nil
+}
+
+// checkForkFinality returns an error when a block at the passed height would
+// fork the current best chain at or before its most recent final block.  Such
+// blocks can never become part of the main chain, so they are rejected rather
+// than stored.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkForkFinality(blockHeight int64) error {
+	finalized, err := b.finalizedHeight()
+	if err != nil {
+		return err
+	}
+	if blockHeight <= finalized {
+		str := fmt.Sprintf("block at height %d forks the main chain at "+
+			"or before the finalized block at height %d", blockHeight,
+			finalized)
+		return ruleError(ErrForkBeforeFinalized, str)
+	}
+	return nil
+}
+
+// checkReorganizeFinality returns an error when disconnecting the passed block
+// node, which is the earliest block detached by a reorganize, would disconnect
+// a final block from the current best chain.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkReorganizeFinality(forkNode *blockNode) error {
+	finalized, err := b.finalizedHeight()
+	if err != nil {
+		return err
+	}
+	if forkNode.height <= finalized {
+		str := fmt.Sprintf("reorganize would disconnect block %v at "+
+			"height %d which is at or before the finalized block at "+
+			"height %d", forkNode.hash, forkNode.height, finalized)
+		return ruleError(ErrReorgBeyondFinality, str)
+	}
+	return nil
+}
+
+// FinalityState describes the block finality which is enforced by the
+// consensus rules for the current best chain.
+type FinalityState struct {
+	Active bool           // Whether or not a finality depth is enforced
+	Depth  int64          // Number of blocks after which a block is final
+	Height int64          // Height of the most recent final block
+	Hash   chainhash.Hash // Hash of the most recent final block
+}
+
+// FinalityState returns the block finality which is enforced by the consensus
+// rules for the current best chain.  Blocks at and before the returned height
+// may not be disconnected by a reorganize.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FinalityState() (*FinalityState, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	depth, err := b.finalityDepth(b.bestNode)
+	if err != nil {
+		return nil, err
+	}
+	height := finalizedHeightForDepth(b.bestNode.height, depth)
+	node, err := b.ancestorNode(b.bestNode, height)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, AssertError(fmt.Sprintf("unable to find finalized "+
+			"block at height %d", height))
+	}
+
+	return &FinalityState{
+		Active: depth != 0,
+		Depth:  depth,
+		Height: height,
+		Hash:   node.hash,
+	}, nil
+}
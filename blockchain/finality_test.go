@@ -0,0 +1,95 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestFinalityDepthForAgendas ensures the finality depth enforced by the
+// consensus rules is derived correctly from the state of the agendas.
+func TestFinalityDepthForAgendas(t *testing.T) {
+	t.Parallel()
+
+	agendas := []agendaFinality{
+		{version: 5, voteID: "agendaa", depth: 4096},
+		{version: 6, voteID: "agendab", depth: 288},
+	}
+	tests := []struct {
+		name   string
+		active map[string]bool
+		want   int64
+	}{
+		{
+			name:   "no agendas active",
+			active: nil,
+			want:   0,
+		},
+		{
+			name:   "first agenda active",
+			active: map[string]bool{"agendaa": true},
+			want:   4096,
+		},
+		{
+			name:   "second agenda active",
+			active: map[string]bool{"agendab": true},
+			want:   288,
+		},
+		{
+			name:   "all agendas active",
+			active: map[string]bool{"agendaa": true, "agendab": true},
+			want:   288,
+		},
+	}
+	for _, test := range tests {
+		depth, err := finalityDepthForAgendas(agendas, func(version uint32, voteID string) (bool, error) {
+			return test.active[voteID], nil
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if depth != test.want {
+			t.Errorf("%s: unexpected depth -- got %d, want %d",
+				test.name, depth, test.want)
+		}
+	}
+
+	// Ensure errors determining the state of an agenda are returned.
+	testErr := errors.New("test error")
+	_, err := finalityDepthForAgendas(agendas, func(uint32, string) (bool, error) {
+		return false, testErr
+	})
+	if err != testErr {
+		t.Errorf("unexpected error -- got %v, want %v", err, testErr)
+	}
+}
+
+// TestFinalizedHeightForDepth ensures the height of the most recent final block
+// is calculated correctly from the height of the best chain and the finality
+// depth.
+func TestFinalizedHeightForDepth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		bestHeight int64
+		depth      int64
+		want       int64
+	}{
+		{"no finality depth", 10000, 0, 0},
+		{"chain shorter than depth", 100, 288, 0},
+		{"chain as long as depth", 288, 288, 0},
+		{"chain longer than depth", 10000, 288, 9712},
+	}
+	for _, test := range tests {
+		got := finalizedHeightForDepth(test.bestHeight, test.depth)
+		if got != test.want {
+			t.Errorf("%s: unexpected finalized height -- got %d, want %d",
+				test.name, got, test.want)
+		}
+	}
+}
@@ -921,8 +921,13 @@ func (idx *AddrIndex) TxRegionsForAddress(dbTx database.Tx, addr dcrutil.Address
 			return dbFetchBlockHashBySerializedID(dbTx, id)
 		}
 
+		// The index does not exist while it is being dropped or rebuilt
+		// while the chain is running.
 		var err error
 		addrIdxBucket := dbTx.Metadata().Bucket(addrIndexKey)
+		if addrIdxBucket == nil {
+			return nil
+		}
 		regions, skipped, err = dbFetchAddrIndexEntries(addrIdxBucket,
 			addrKey, numToSkip, numRequested, reverse,
 			fetchBlockHash)
@@ -1096,5 +1101,5 @@ func NewAddrIndex(db database.DB, chainParams *chaincfg.Params) *AddrIndex {
 // DropAddrIndex drops the address index from the provided database if it
 // exists.
 func DropAddrIndex(db database.DB) error {
-	return dropIndex(db, addrIndexKey, addrIndexName)
+	return dropIndex(db, addrIndexKey, addrIndexName, nil, nil)
 }
@@ -0,0 +1,492 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// balanceIndexName is the human-readable name for the index.
+	balanceIndexName = "balance index"
+
+	// balanceEntrySize is the size of a serialized balance entry.
+	balanceEntrySize = 8 + 4
+
+	// balanceUtxoKeySize is the size of the key of a serialized unspent
+	// output entry.
+	balanceUtxoKeySize = chainhash.HashSize + spendKeySize
+
+	// balanceUtxoEntrySize is the size of a serialized unspent output
+	// entry.
+	balanceUtxoEntrySize = 8 + 4
+)
+
+var (
+	// balanceIndexKey is the key of the balance index and the db bucket
+	// used to house it.
+	balanceIndexKey = []byte("balanceidx")
+)
+
+// -----------------------------------------------------------------------------
+// The balance index consists of the confirmed balance and the unspent outputs
+// of every public key script which is paid by an unspent output of the main
+// chain.  Scripts are identified by their hash so the keys have a fixed size.
+//
+// The outputs of the regular transaction tree of a block are only confirmed
+// once the block is approved by the next block, so they are added when the
+// next block approves them and are removed when that block is disconnected.
+// The recorded height is still the height of the block that contains the
+// transaction.  Provably unspendable outputs are not indexed.
+//
+// There are two kinds of entries in the balance index bucket.  The balance of
+// a script is keyed by the script hash alone and each of its unspent outputs is
+// keyed by the script hash followed by the outpoint, so the balance of a script
+// is immediately followed by its unspent outputs.  The balance of a script is
+// removed along with its last unspent output.
+//
+// The serialized format for keys and values of balances is:
+//   <script hash> = <balance><num utxos>
+//
+//   Field           Type              Size
+//   script hash     chainhash.Hash    32 bytes
+//   -----
+//   balance         uint64            8 bytes
+//   num utxos       uint32            4 bytes
+//   -----
+//   Total: 44 bytes
+//
+// The serialized format for keys and values of unspent outputs is:
+//   <script hash><outpoint> = <amount><height>
+//
+//   Field           Type              Size
+//   script hash     chainhash.Hash    32 bytes
+//   outpoint hash   chainhash.Hash    32 bytes
+//   outpoint index  uint32            4 bytes
+//   outpoint tree   int8              1 byte
+//   -----
+//   amount          uint64            8 bytes
+//   height          uint32            4 bytes
+//   -----
+//   Total: 81 bytes
+// -----------------------------------------------------------------------------
+
+// ScriptUtxo describes an unspent output of the main chain which pays a public
+// key script.
+type ScriptUtxo struct {
+	// OutPoint identifies the unspent output.
+	OutPoint wire.OutPoint
+
+	// Amount is the amount of the output in atoms.
+	Amount int64
+
+	// Height is the height of the block which contains the transaction
+	// that created the output.
+	Height int64
+}
+
+// balanceScriptKey returns the database key which identifies the passed public
+// key script.
+func balanceScriptKey(pkScript []byte) []byte {
+	return chainhash.HashB(pkScript)
+}
+
+// balanceUtxoKey returns the database key of the unspent output entry for the
+// passed outpoint which pays the script identified by the passed script key.
+func balanceUtxoKey(scriptKey []byte, outPoint *wire.OutPoint) []byte {
+	key := make([]byte, 0, balanceUtxoKeySize)
+	key = append(key, scriptKey...)
+	return append(key, spendKey(outPoint)...)
+}
+
+// serializeBalanceEntry returns the serialization of the passed balance and
+// number of unspent outputs according to the format described above.
+func serializeBalanceEntry(balance int64, numUtxos uint32) []byte {
+	serialized := make([]byte, balanceEntrySize)
+	byteOrder.PutUint64(serialized, uint64(balance))
+	byteOrder.PutUint32(serialized[8:], numUtxos)
+	return serialized
+}
+
+// deserializeBalanceEntry decodes the passed serialized balance entry into the
+// balance and the number of unspent outputs.
+func deserializeBalanceEntry(serialized []byte) (int64, uint32, error) {
+	if len(serialized) < balanceEntrySize {
+		return 0, 0, errDeserialize("unexpected end of data for " +
+			"balance entry")
+	}
+
+	balance := int64(byteOrder.Uint64(serialized))
+	numUtxos := byteOrder.Uint32(serialized[8:])
+	return balance, numUtxos, nil
+}
+
+// serializeBalanceUtxoEntry returns the serialization of the value of the
+// passed unspent output according to the format described above.
+func serializeBalanceUtxoEntry(utxo *ScriptUtxo) []byte {
+	serialized := make([]byte, balanceUtxoEntrySize)
+	byteOrder.PutUint64(serialized, uint64(utxo.Amount))
+	byteOrder.PutUint32(serialized[8:], uint32(utxo.Height))
+	return serialized
+}
+
+// deserializeBalanceUtxoEntry decodes the passed serialized key and value of an
+// unspent output entry.
+func deserializeBalanceUtxoEntry(key, serialized []byte) (*ScriptUtxo, error) {
+	if len(key) < balanceUtxoKeySize {
+		return nil, errDeserialize("unexpected end of data for " +
+			"unspent output key")
+	}
+	if len(serialized) < balanceUtxoEntrySize {
+		return nil, errDeserialize("unexpected end of data for " +
+			"unspent output entry")
+	}
+
+	var utxo ScriptUtxo
+	offset := chainhash.HashSize
+	copy(utxo.OutPoint.Hash[:], key[offset:offset+chainhash.HashSize])
+	offset += chainhash.HashSize
+	utxo.OutPoint.Index = byteOrder.Uint32(key[offset:])
+	utxo.OutPoint.Tree = int8(key[offset+4])
+	utxo.Amount = int64(byteOrder.Uint64(serialized))
+	utxo.Height = int64(byteOrder.Uint32(serialized[8:]))
+	return &utxo, nil
+}
+
+// dbFetchBalance uses an existing database bucket to fetch the balance and the
+// number of unspent outputs of the script identified by the passed script key.
+func dbFetchBalance(bucket internalBucket, scriptKey []byte) (int64, uint32, error) {
+	serialized := bucket.Get(scriptKey)
+	if serialized == nil {
+		return 0, 0, nil
+	}
+
+	return deserializeBalanceEntry(serialized)
+}
+
+// dbUpdateBalance uses an existing database bucket to add the passed amount to
+// the balance of the script identified by the passed script key and to adjust
+// its number of unspent outputs by the passed delta.  The balance is removed
+// when the script no longer has any unspent outputs.
+func dbUpdateBalance(bucket internalBucket, scriptKey []byte, amount int64, delta int32) error {
+	balance, numUtxos, err := dbFetchBalance(bucket, scriptKey)
+	if err != nil {
+		return err
+	}
+
+	balance += amount
+	numUtxos = uint32(int32(numUtxos) + delta)
+	if numUtxos == 0 {
+		return bucket.Delete(scriptKey)
+	}
+	return bucket.Put(scriptKey, serializeBalanceEntry(balance, numUtxos))
+}
+
+// dbAddBalanceUtxo uses an existing database bucket to add the passed unspent
+// output of the passed public key script to the index.  Provably unspendable
+// outputs are ignored.
+func dbAddBalanceUtxo(bucket internalBucket, pkScript []byte, utxo *ScriptUtxo) error {
+	if txscript.IsUnspendable(utxo.Amount, pkScript) {
+		return nil
+	}
+
+	scriptKey := balanceScriptKey(pkScript)
+	err := bucket.Put(balanceUtxoKey(scriptKey, &utxo.OutPoint),
+		serializeBalanceUtxoEntry(utxo))
+	if err != nil {
+		return err
+	}
+	return dbUpdateBalance(bucket, scriptKey, utxo.Amount, 1)
+}
+
+// dbRemoveBalanceUtxo uses an existing database bucket to remove the passed
+// outpoint of the passed public key script from the index.  Outpoints which are
+// not indexed are ignored.
+func dbRemoveBalanceUtxo(bucket internalBucket, pkScript []byte, outPoint *wire.OutPoint) error {
+	scriptKey := balanceScriptKey(pkScript)
+	key := balanceUtxoKey(scriptKey, outPoint)
+	serialized := bucket.Get(key)
+	if serialized == nil {
+		return nil
+	}
+	utxo, err := deserializeBalanceUtxoEntry(key, serialized)
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.Delete(key); err != nil {
+		return err
+	}
+	return dbUpdateBalance(bucket, scriptKey, -utxo.Amount, -1)
+}
+
+// BalanceIndex implements an index of the confirmed balance and the unspent
+// outputs of each public key script paid by the main chain.
+type BalanceIndex struct {
+	db database.DB
+}
+
+// Ensure the BalanceIndex type implements the Indexer interface.
+var _ Indexer = (*BalanceIndex)(nil)
+
+// Ensure the BalanceIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*BalanceIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to properly create the index.
+//
+// This implements the NeedsInputser interface.
+func (idx *BalanceIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *BalanceIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *BalanceIndex) Key() []byte {
+	return balanceIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *BalanceIndex) Name() string {
+	return balanceIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the balance
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *BalanceIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(balanceIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer removes the outputs spent by the
+// transactions which take effect with the block and adds the outputs they
+// create.
+//
+// This is part of the Indexer interface.
+func (idx *BalanceIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(balanceIndexKey)
+	for _, spender := range blockSpendingTxns(block, parent) {
+		err := spentOutPoints(spender.tx, func(txIn *wire.TxIn, index uint32) error {
+			// The view should always have the input since the index
+			// contract requires it, however, be safe and simply
+			// ignore any missing entries.
+			origin := &txIn.PreviousOutPoint
+			entry := view.LookupEntry(&origin.Hash)
+			if entry == nil {
+				log.Warnf("Missing input %v for tx %v while indexing "+
+					"block %v (height %v)", origin.Hash,
+					spender.tx.Hash(), block.Hash(), block.Height())
+				return nil
+			}
+
+			pkScript := entry.PkScriptByIndex(origin.Index)
+			return dbRemoveBalanceUtxo(bucket, pkScript, origin)
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, txOut := range spender.tx.MsgTx().TxOut {
+			utxo := ScriptUtxo{
+				OutPoint: wire.OutPoint{
+					Hash:  *spender.tx.Hash(),
+					Index: uint32(i),
+					Tree:  spender.tree,
+				},
+				Amount: txOut.Value,
+				Height: spender.height,
+			}
+			err := dbAddBalanceUtxo(bucket, txOut.PkScript, &utxo)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// outputHeight returns the height of the block which contains the transaction
+// of the passed utxo entry.  The height is looked up in the transaction index
+// when the entry was not loaded from the utxo set, such as when the index is
+// caught up.
+func outputHeight(dbTx database.Tx, block *dcrutil.Block, hash *chainhash.Hash, entry *blockchain.UtxoEntry) (int64, error) {
+	if entry.BlockHeight() != int64(wire.NullBlockHeight) {
+		return entry.BlockHeight(), nil
+	}
+
+	blockRegion, err := dbFetchTxIndexEntry(dbTx, *hash)
+	if err != nil {
+		return 0, err
+	}
+	if blockRegion == nil {
+		return 0, fmt.Errorf("transaction %v not found in the txindex",
+			hash)
+	}
+
+	// The block being disconnected is already removed from the main chain.
+	if *blockRegion.Hash == *block.Hash() {
+		return block.Height(), nil
+	}
+	return blockchain.DBFetchHeightByHash(dbTx, blockRegion.Hash)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the outputs created
+// by the transactions which took effect with the block and restores the outputs
+// they spent.
+//
+// This is part of the Indexer interface.
+func (idx *BalanceIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, view *blockchain.UtxoViewpoint) error {
+	// Undo the transactions in reverse order so outputs which are both
+	// created and spent by the transactions are restored before they are
+	// removed.
+	bucket := dbTx.Metadata().Bucket(balanceIndexKey)
+	txns := blockSpendingTxns(block, parent)
+	for i := len(txns) - 1; i >= 0; i-- {
+		spender := &txns[i]
+		for j, txOut := range spender.tx.MsgTx().TxOut {
+			outPoint := wire.OutPoint{
+				Hash:  *spender.tx.Hash(),
+				Index: uint32(j),
+				Tree:  spender.tree,
+			}
+			err := dbRemoveBalanceUtxo(bucket, txOut.PkScript, &outPoint)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := spentOutPoints(spender.tx, func(txIn *wire.TxIn, index uint32) error {
+			// The view should always have the input since the index
+			// contract requires it, however, be safe and simply
+			// ignore any missing entries.
+			origin := &txIn.PreviousOutPoint
+			entry := view.LookupEntry(&origin.Hash)
+			if entry == nil {
+				log.Warnf("Missing input %v for tx %v while "+
+					"unindexing block %v (height %v)", origin.Hash,
+					spender.tx.Hash(), block.Hash(), block.Height())
+				return nil
+			}
+
+			height, err := outputHeight(dbTx, block, &origin.Hash, entry)
+			if err != nil {
+				return err
+			}
+			utxo := ScriptUtxo{
+				OutPoint: *origin,
+				Amount:   entry.AmountByIndex(origin.Index),
+				Height:   height,
+			}
+			pkScript := entry.PkScriptByIndex(origin.Index)
+			return dbAddBalanceUtxo(bucket, pkScript, &utxo)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Balance returns the confirmed balance in atoms and the number of unspent
+// outputs of the passed public key script.
+//
+// This function is safe for concurrent access.
+func (idx *BalanceIndex) Balance(pkScript []byte) (int64, uint32, error) {
+	var balance int64
+	var numUtxos uint32
+	err := idx.db.View(func(dbTx database.Tx) error {
+		// The index does not exist while it is being dropped or rebuilt
+		// while the chain is running.
+		bucket := dbTx.Metadata().Bucket(balanceIndexKey)
+		if bucket == nil {
+			return nil
+		}
+		var err error
+		balance, numUtxos, err = dbFetchBalance(bucket,
+			balanceScriptKey(pkScript))
+		return err
+	})
+	return balance, numUtxos, err
+}
+
+// Utxos returns the confirmed unspent outputs of the passed public key script
+// ordered by outpoint.
+//
+// This function is safe for concurrent access.
+func (idx *BalanceIndex) Utxos(pkScript []byte) ([]ScriptUtxo, error) {
+	var utxos []ScriptUtxo
+	err := idx.db.View(func(dbTx database.Tx) error {
+		// The index does not exist while it is being dropped or rebuilt
+		// while the chain is running.
+		bucket := dbTx.Metadata().Bucket(balanceIndexKey)
+		if bucket == nil {
+			return nil
+		}
+
+		// The balance entry of the script precedes its unspent outputs
+		// and is skipped.
+		scriptKey := balanceScriptKey(pkScript)
+		cursor := bucket.Cursor()
+		for ok := cursor.Seek(scriptKey); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if !bytes.HasPrefix(key, scriptKey) {
+				break
+			}
+			if len(key) == len(scriptKey) {
+				continue
+			}
+
+			utxo, err := deserializeBalanceUtxoEntry(key, cursor.Value())
+			if err != nil {
+				return err
+			}
+			utxos = append(utxos, *utxo)
+		}
+		return nil
+	})
+	return utxos, err
+}
+
+// NewBalanceIndex returns a new instance of an indexer that is used to maintain
+// an index of the confirmed balance and the unspent outputs of each public key
+// script paid by the main chain.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewBalanceIndex(db database.DB) *BalanceIndex {
+	return &BalanceIndex{db: db}
+}
+
+// DropBalanceIndex drops the balance index from the provided database if it
+// exists.
+func DropBalanceIndex(db database.DB) error {
+	return dropIndex(db, balanceIndexKey, balanceIndexName, nil, nil)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+)
+
+// balanceIndexBucket is a mock bucket used to house the entries of the balance
+// index which implements the internalBucket interface.
+type balanceIndexBucket map[string][]byte
+
+// Get returns the value associated with the key from the mock bucket.
+//
+// This is part of the internalBucket interface.
+func (b balanceIndexBucket) Get(key []byte) []byte {
+	return b[string(key)]
+}
+
+// Put stores the provided key/value pair to the mock bucket.
+//
+// This is part of the internalBucket interface.
+func (b balanceIndexBucket) Put(key []byte, value []byte) error {
+	b[string(key)] = value
+	return nil
+}
+
+// Delete removes the provided key from the mock bucket.
+//
+// This is part of the internalBucket interface.
+func (b balanceIndexBucket) Delete(key []byte) error {
+	delete(b, string(key))
+	return nil
+}
+
+// TestBalanceIndexEntries ensures adding and removing the unspent outputs of a
+// script maintains its balance and number of unspent outputs as expected.
+func TestBalanceIndexEntries(t *testing.T) {
+	t.Parallel()
+
+	pkScript := []byte{txscript.OP_TRUE}
+	scriptKey := balanceScriptKey(pkScript)
+	utxos := []ScriptUtxo{{
+		OutPoint: *wire.NewOutPoint(&chainhash.Hash{0x01}, 0,
+			wire.TxTreeRegular),
+		Amount: 100000,
+		Height: 20,
+	}, {
+		OutPoint: *wire.NewOutPoint(&chainhash.Hash{0x01}, 0,
+			wire.TxTreeStake),
+		Amount: 250000,
+		Height: 21,
+	}}
+
+	bucket := make(balanceIndexBucket)
+	for i := range utxos {
+		if err := dbAddBalanceUtxo(bucket, pkScript, &utxos[i]); err != nil {
+			t.Fatalf("dbAddBalanceUtxo: unexpected error: %v", err)
+		}
+	}
+
+	// Ensure provably unspendable outputs are not indexed.
+	unspendable := ScriptUtxo{
+		OutPoint: *wire.NewOutPoint(&chainhash.Hash{0x02}, 0,
+			wire.TxTreeRegular),
+	}
+	if err := dbAddBalanceUtxo(bucket, pkScript, &unspendable); err != nil {
+		t.Fatalf("dbAddBalanceUtxo: unexpected error: %v", err)
+	}
+	if len(bucket) != len(utxos)+1 {
+		t.Fatalf("unexpected number of entries - got %d, want %d",
+			len(bucket), len(utxos)+1)
+	}
+
+	balance, numUtxos, err := dbFetchBalance(bucket, scriptKey)
+	if err != nil {
+		t.Fatalf("dbFetchBalance: unexpected error: %v", err)
+	}
+	if balance != 350000 || numUtxos != 2 {
+		t.Fatalf("unexpected balance - got %d (%d utxos), want 350000 "+
+			"(2 utxos)", balance, numUtxos)
+	}
+
+	// Ensure the serialized unspent outputs match the added ones.
+	for i := range utxos {
+		key := balanceUtxoKey(scriptKey, &utxos[i].OutPoint)
+		got, err := deserializeBalanceUtxoEntry(key, bucket.Get(key))
+		if err != nil {
+			t.Fatalf("deserializeBalanceUtxoEntry: unexpected error: %v",
+				err)
+		}
+		if *got != utxos[i] {
+			t.Fatalf("mismatched utxo - got %+v, want %+v", *got,
+				utxos[i])
+		}
+	}
+
+	// Ensure removing an outpoint which is not indexed has no effect.
+	err = dbRemoveBalanceUtxo(bucket, pkScript, &unspendable.OutPoint)
+	if err != nil {
+		t.Fatalf("dbRemoveBalanceUtxo: unexpected error: %v", err)
+	}
+	balance, numUtxos, err = dbFetchBalance(bucket, scriptKey)
+	if err != nil {
+		t.Fatalf("dbFetchBalance: unexpected error: %v", err)
+	}
+	if balance != 350000 || numUtxos != 2 {
+		t.Fatalf("unexpected balance - got %d (%d utxos), want 350000 "+
+			"(2 utxos)", balance, numUtxos)
+	}
+
+	// Ensure the balance is removed along with the last unspent output.
+	for i := range utxos {
+		err := dbRemoveBalanceUtxo(bucket, pkScript, &utxos[i].OutPoint)
+		if err != nil {
+			t.Fatalf("dbRemoveBalanceUtxo: unexpected error: %v", err)
+		}
+	}
+	if len(bucket) != 0 {
+		t.Fatalf("unexpected entries after removing all utxos: %d",
+			len(bucket))
+	}
+
+	// Ensure truncated data is detected.
+	_, _, err = deserializeBalanceEntry(make([]byte, balanceEntrySize-1))
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated data - got %v", err)
+	}
+	key := balanceUtxoKey(scriptKey, &utxos[0].OutPoint)
+	_, err = deserializeBalanceUtxoEntry(key[:len(key)-1],
+		make([]byte, balanceUtxoEntrySize))
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated key - got %v", err)
+	}
+}
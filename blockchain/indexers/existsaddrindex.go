@@ -23,6 +23,27 @@ var (
 	// existsAddrIndexKey is the key of the ever seen address index and
 	// the db bucket used to house it.
 	existsAddrIndexKey = []byte("existsaddridx")
+
+	// existsAddrFilterBucketName is the name of the db bucket used to house
+	// the xor filters that summarize the addresses in the exists address
+	// index.
+	existsAddrFilterBucketName = []byte("existsaddrfilteridx")
+
+	// existsAddrPendingBucketName is the name of the db bucket used to
+	// house the addresses that have been added to the exists address index
+	// since the most recent xor filter was constructed.
+	existsAddrPendingBucketName = []byte("existsaddrpendingidx")
+)
+
+const (
+	// existsAddrFilterSegmentSize is the number of blocks covered by each
+	// xor filter that summarizes the addresses first seen in those blocks.
+	existsAddrFilterSegmentSize = 4096
+
+	// existsAddrFilterMaxKeys is the maximum number of addresses included
+	// in each xor filter when building the filters from an existing
+	// index.
+	existsAddrFilterMaxKeys = 1 << 20
 )
 
 // ExistsAddrIndex implements an "ever seen" address index.  Any address that
@@ -35,6 +56,13 @@ var (
 // In addition, support is provided for a memory-only index of unconfirmed
 // transactions such as those which are kept in the memory pool before inclusion
 // in a block.
+//
+// The addresses are also summarized by a sidecar of xor filters, one for each
+// segment of existsAddrFilterSegmentSize blocks, which are kept in memory.
+// Since the vast majority of queries are for addresses that have never been
+// used, such as those made by wallets during address discovery, this allows
+// most queries to be answered without a database lookup.  The index is only
+// consulted when one of the filters reports a possible match.
 type ExistsAddrIndex struct {
 	// The following fields are set when the instance is created and can't
 	// be changed afterwards, so there is no need to protect them with a
@@ -57,6 +85,19 @@ type ExistsAddrIndex struct {
 	// once they are included into a block.
 	unconfirmedLock sync.RWMutex
 	mpExistsAddr    map[[addrKeySize]byte]struct{}
+
+	// The following fields house the in-memory copy of the xor filter
+	// sidecar and are protected by the filterLock field.  The pendingAddrs
+	// field contains the addresses added since the most recent filter was
+	// constructed.
+	//
+	// The in-memory copy is always a superset of the sidecar in the
+	// database so that a failed database transaction can only result in
+	// additional index lookups as opposed to incorrect results.
+	filterLock   sync.RWMutex
+	filters      []*xorFilter
+	pendingAddrs map[[addrKeySize]byte]struct{}
+	nextFilterID uint32
 }
 
 // NewExistsAddrIndex returns a new instance of an indexer that is used to
@@ -70,6 +111,7 @@ func NewExistsAddrIndex(db database.DB, chainParams *chaincfg.Params) *ExistsAdd
 		db:           db,
 		chainParams:  chainParams,
 		mpExistsAddr: make(map[[addrKeySize]byte]struct{}),
+		pendingAddrs: make(map[[addrKeySize]byte]struct{}),
 	}
 }
 
@@ -87,12 +129,209 @@ func (idx *ExistsAddrIndex) NeedsInputs() bool {
 	return false
 }
 
-// Init is only provided to satisfy the Indexer interface as there is nothing to
-// initialize for this index.
+// Init loads the xor filter sidecar into memory, building it from the current
+// contents of the index first when it does not exist yet, such as when the
+// index was created by a version that did not maintain it.
 //
 // This is part of the Indexer interface.
 func (idx *ExistsAddrIndex) Init() error {
-	// Nothing to do.
+	err := idx.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if meta.Bucket(existsAddrFilterBucketName) != nil {
+			return nil
+		}
+		return idx.buildFilters(dbTx)
+	})
+	if err != nil {
+		return err
+	}
+
+	var filters []*xorFilter
+	var nextFilterID uint32
+	pending := make(map[[addrKeySize]byte]struct{})
+	err = idx.db.View(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		err := meta.Bucket(existsAddrFilterBucketName).ForEach(
+			func(k, v []byte) error {
+				if len(k) != 4 {
+					return errDeserialize("unexpected exists " +
+						"address filter key length")
+				}
+				f, err := deserializeXorFilter(v)
+				if err != nil {
+					return err
+				}
+				filters = append(filters, f)
+				if id := byteOrder.Uint32(k); id >= nextFilterID {
+					nextFilterID = id + 1
+				}
+				return nil
+			})
+		if err != nil {
+			return err
+		}
+
+		return meta.Bucket(existsAddrPendingBucketName).ForEach(
+			func(k, v []byte) error {
+				var addrKey [addrKeySize]byte
+				copy(addrKey[:], k)
+				pending[addrKey] = struct{}{}
+				return nil
+			})
+	})
+	if err != nil {
+		return err
+	}
+
+	idx.filterLock.Lock()
+	idx.filters = filters
+	idx.pendingAddrs = pending
+	idx.nextFilterID = nextFilterID
+	idx.filterLock.Unlock()
+
+	log.Debugf("Loaded %d exists address filters with %d pending "+
+		"addresses", len(filters), len(pending))
+	return nil
+}
+
+// existsAddrFilterKey returns the key for the xor filter with the passed id.
+func existsAddrFilterKey(id uint32) []byte {
+	var key [4]byte
+	byteOrder.PutUint32(key[:], id)
+	return key[:]
+}
+
+// addrKeyFilterHash returns the key used for the passed address key in the xor
+// filters.  The address keys consist of a hash160, so folding them is
+// sufficient to produce uniformly distributed filter keys.
+func addrKeyFilterHash(k [addrKeySize]byte) uint64 {
+	return byteOrder.Uint64(k[1:9]) ^ byteOrder.Uint64(k[9:17]) ^
+		uint64(byteOrder.Uint32(k[17:21])) ^ uint64(k[0])<<56
+}
+
+// buildFilters creates the xor filter sidecar buckets and populates them with
+// filters that cover every address currently in the index.
+func (idx *ExistsAddrIndex) buildFilters(dbTx database.Tx) error {
+	meta := dbTx.Metadata()
+	filterBucket, err := meta.CreateBucket(existsAddrFilterBucketName)
+	if err != nil {
+		return err
+	}
+	if _, err := meta.CreateBucket(existsAddrPendingBucketName); err != nil {
+		return err
+	}
+
+	var id uint32
+	keys := make([]uint64, 0, existsAddrFilterMaxKeys)
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		f, err := newXorFilter(keys)
+		if err != nil {
+			return err
+		}
+		err = filterBucket.Put(existsAddrFilterKey(id), f.serialize())
+		if err != nil {
+			return err
+		}
+		id++
+		keys = keys[:0]
+		return nil
+	}
+
+	log.Infof("Building exists address filters.  This might take a " +
+		"while...")
+	err = meta.Bucket(existsAddrIndexKey).ForEach(func(k, v []byte) error {
+		if len(k) != addrKeySize {
+			return nil
+		}
+		var addrKey [addrKeySize]byte
+		copy(addrKey[:], k)
+		keys = append(keys, addrKeyFilterHash(addrKey))
+		if len(keys) == existsAddrFilterMaxKeys {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// mayExist returns whether or not the passed address key is possibly in the
+// index according to the xor filter sidecar.  A false result means the address
+// is definitely not in the index.
+//
+// This function is safe for concurrent access.
+func (idx *ExistsAddrIndex) mayExist(k [addrKeySize]byte) bool {
+	idx.filterLock.RLock()
+	defer idx.filterLock.RUnlock()
+
+	if _, ok := idx.pendingAddrs[k]; ok {
+		return true
+	}
+	filterKey := addrKeyFilterHash(k)
+	for _, f := range idx.filters {
+		if f.Contains(filterKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFilters adds the passed newly indexed addresses to the pending set of
+// the xor filter sidecar and constructs a new filter from the pending set once
+// the passed block height completes a segment.
+func (idx *ExistsAddrIndex) updateFilters(dbTx database.Tx, height int64, newAddrs map[[addrKeySize]byte]struct{}) error {
+	meta := dbTx.Metadata()
+	pendingBucket := meta.Bucket(existsAddrPendingBucketName)
+
+	idx.filterLock.Lock()
+	defer idx.filterLock.Unlock()
+
+	for k := range newAddrs {
+		idx.pendingAddrs[k] = struct{}{}
+		if err := pendingBucket.Put(k[:], nil); err != nil {
+			return err
+		}
+	}
+
+	if height%existsAddrFilterSegmentSize != 0 || len(idx.pendingAddrs) == 0 {
+		return nil
+	}
+
+	keys := make([]uint64, 0, len(idx.pendingAddrs))
+	for k := range idx.pendingAddrs {
+		keys = append(keys, addrKeyFilterHash(k))
+	}
+	f, err := newXorFilter(keys)
+	if err != nil {
+		return err
+	}
+
+	// Update the in-memory copy before the database so it remains a
+	// superset of the database should the transaction fail.
+	idx.filters = append(idx.filters, f)
+	pending := idx.pendingAddrs
+	idx.pendingAddrs = make(map[[addrKeySize]byte]struct{})
+	id := idx.nextFilterID
+	idx.nextFilterID++
+
+	filterBucket := meta.Bucket(existsAddrFilterBucketName)
+	err = filterBucket.Put(existsAddrFilterKey(id), f.serialize())
+	if err != nil {
+		return err
+	}
+	for k := range pending {
+		if err := pendingBucket.Delete(k[:]); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Constructed exists address filter %d with %d addresses at "+
+		"height %d", id, len(pending), height)
 	return nil
 }
 
@@ -116,7 +355,14 @@ func (idx *ExistsAddrIndex) Name() string {
 //
 // This is part of the Indexer interface.
 func (idx *ExistsAddrIndex) Create(dbTx database.Tx) error {
-	_, err := dbTx.Metadata().CreateBucket(existsAddrIndexKey)
+	meta := dbTx.Metadata()
+	if _, err := meta.CreateBucket(existsAddrFilterBucketName); err != nil {
+		return err
+	}
+	if _, err := meta.CreateBucket(existsAddrPendingBucketName); err != nil {
+		return err
+	}
+	_, err := meta.CreateBucket(existsAddrIndexKey)
 	return err
 }
 
@@ -148,16 +394,25 @@ func (idx *ExistsAddrIndex) ExistsAddress(addr dcrutil.Address) (bool, error) {
 		return false, err
 	}
 
+	// Only query the database when the filters report the address might
+	// be in the index.
 	var exists bool
-	err = idx.db.View(func(dbTx database.Tx) error {
-		meta := dbTx.Metadata()
-		existsAddrIndex := meta.Bucket(existsAddrIndexKey)
-		exists = existsAddrIndex.Get(k[:]) != nil
+	if idx.mayExist(k) {
+		err = idx.db.View(func(dbTx database.Tx) error {
+			// The index does not exist while it is being dropped
+			// or rebuilt while the chain is running.
+			meta := dbTx.Metadata()
+			existsAddrIndex := meta.Bucket(existsAddrIndexKey)
+			if existsAddrIndex == nil {
+				return nil
+			}
+			exists = existsAddrIndex.Get(k[:]) != nil
 
-		return nil
-	})
-	if err != nil {
-		return false, err
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
 	}
 
 	// Only check the in memory map if needed.
@@ -183,18 +438,32 @@ func (idx *ExistsAddrIndex) ExistsAddresses(addrs []dcrutil.Address) ([]bool, er
 		}
 	}
 
-	err := idx.db.View(func(dbTx database.Tx) error {
-		for i := range addrKeys {
+	// Only query the database for the addresses the filters report might
+	// be in the index.
+	var maybeExists []int
+	for i := range addrKeys {
+		if idx.mayExist(addrKeys[i]) {
+			maybeExists = append(maybeExists, i)
+		}
+	}
+	if len(maybeExists) > 0 {
+		err := idx.db.View(func(dbTx database.Tx) error {
+			// The index does not exist while it is being dropped
+			// or rebuilt while the chain is running.
 			meta := dbTx.Metadata()
 			existsAddrIndex := meta.Bucket(existsAddrIndexKey)
+			if existsAddrIndex == nil {
+				return nil
+			}
+			for _, i := range maybeExists {
+				exists[i] = existsAddrIndex.Get(addrKeys[i][:]) != nil
+			}
 
-			exists[i] = existsAddrIndex.Get(addrKeys[i][:]) != nil
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
 	idx.unconfirmedLock.RLock()
@@ -318,7 +587,7 @@ func (idx *ExistsAddrIndex) ConnectBlock(dbTx database.Tx, block, parent *dcruti
 		}
 	}
 
-	return nil
+	return idx.updateFilters(dbTx, block.Height(), newUsedAddrs)
 }
 
 // DisconnectBlock is invoked by the index manager when a block has been
@@ -413,5 +682,24 @@ func (idx *ExistsAddrIndex) AddUnconfirmedTx(tx *wire.MsgTx) {
 // DropExistsAddrIndex drops the exists address index from the provided
 // database if it exists.
 func DropExistsAddrIndex(db database.DB) error {
-	return dropIndex(db, existsAddrIndexKey, existsAddressIndexName)
+	return dropIndex(db, existsAddrIndexKey, existsAddressIndexName, nil,
+		nil)
+}
+
+// dropExistsAddrFilters drops the xor filter sidecar of the exists address
+// index.
+func dropExistsAddrFilters(db database.DB) error {
+	return db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if meta.Bucket(existsAddrFilterBucketName) != nil {
+			err := meta.DeleteBucket(existsAddrFilterBucketName)
+			if err != nil {
+				return err
+			}
+		}
+		if meta.Bucket(existsAddrPendingBucketName) != nil {
+			return meta.DeleteBucket(existsAddrPendingBucketName)
+		}
+		return nil
+	})
 }
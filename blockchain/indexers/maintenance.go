@@ -0,0 +1,579 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/blockchain/internal/progresslog"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrutil"
+)
+
+// verifyBlocksPerTx is the maximum number of blocks the entries of an index
+// are verified for in a single database transaction.
+const verifyBlocksPerTx = 100
+
+// errInterruptRequested indicates that an operation was cancelled due to a
+// user-requested interrupt.
+var errInterruptRequested = errors.New("interrupt requested")
+
+// interruptRequested returns true when the provided channel has been closed.
+// This simplifies early shutdown slightly since the caller can just use an if
+// statement instead of a select.
+func interruptRequested(interrupted <-chan struct{}) bool {
+	select {
+	case <-interrupted:
+		return true
+	default:
+	}
+
+	return false
+}
+
+// IndexOp identifies an operation which maintains an enabled index while the
+// chain is running.
+type IndexOp string
+
+// These constants define the supported index maintenance operations.
+const (
+	// IndexOpDrop deletes an index.  The index is no longer updated as
+	// blocks are connected and disconnected, and it is created and caught
+	// up again on the next start when it is still enabled.
+	IndexOpDrop IndexOp = "drop"
+
+	// IndexOpRebuild deletes an index and indexes the entire main chain
+	// again.  The index is updated as blocks are connected and
+	// disconnected again once it is caught up to the end of the main
+	// chain.
+	IndexOpRebuild IndexOp = "rebuild"
+
+	// IndexOpVerify ensures the tip of an index is part of the main chain
+	// and, for indexes which support it, that the entries of every block
+	// of the main chain are consistent with the block.
+	IndexOpVerify IndexOp = "verify"
+)
+
+// IndexStatus describes the state of an enabled index along with the progress
+// of the most recent maintenance operation performed on it.
+type IndexStatus struct {
+	// Name is the human-readable name of the index.
+	Name string
+
+	// Active is whether the index is updated as blocks are connected and
+	// disconnected.  It is false while the index is being dropped or
+	// rebuilt and once it has been dropped.
+	Active bool
+
+	// Op is the most recent maintenance operation of the index.  It is
+	// empty when no operation has been started.
+	Op IndexOp
+
+	// Running is whether the operation is still in progress.
+	Running bool
+
+	// Height is the height of the most recent block the operation processed
+	// and TargetHeight is the height the operation is working towards.
+	// They are only set by rebuilds and verifications.
+	Height       int64
+	TargetHeight int64
+
+	// DeletedKeys is the number of keys which were deleted from the index
+	// by a drop or rebuild.
+	DeletedKeys uint64
+
+	// Err is the error the operation failed with, if any.
+	Err error
+}
+
+// indexDependencies maps the names of the indexes which rely on the entries of
+// other indexes to the names of those indexes.  The address index refers to the
+// internal block ids of the transaction index and the balance index looks up
+// the heights of spent outputs in the transaction index, so neither of them can
+// be dropped from or caught up to the main chain independently of it.
+var indexDependencies = map[string][]string{
+	addrIndexName:    {txIndexName},
+	balanceIndexName: {txIndexName},
+}
+
+// dependsOn returns whether the index with the first passed name relies on the
+// entries of the index with the second passed name.
+func dependsOn(name, dependency string) bool {
+	for _, dep := range indexDependencies[name] {
+		if dep == dependency {
+			return true
+		}
+	}
+	return false
+}
+
+// indexVerifier provides an interface for an index which is able to verify its
+// entries for a block of the main chain against the block.
+type indexVerifier interface {
+	verifyBlock(dbTx database.Tx, block, parent *dcrutil.Block) error
+}
+
+// dbFetchBlock uses an existing database transaction to retrieve the block for
+// the provided hash regardless of whether it is part of the main chain and
+// returns it with the provided height set.
+func dbFetchBlock(dbTx database.Tx, hash *chainhash.Hash, height int64) (*dcrutil.Block, error) {
+	blockBytes, err := dbTx.FetchBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	block, err := dcrutil.NewBlockFromBytes(blockBytes)
+	if err != nil {
+		return nil, err
+	}
+	block.SetHeight(height)
+	return block, nil
+}
+
+// isActive returns whether the passed index is updated as blocks are connected
+// and disconnected.
+//
+// This function is safe for concurrent access.
+func (m *Manager) isActive(indexer Indexer) bool {
+	m.mtx.Lock()
+	active := m.statuses[indexer.Name()].Active
+	m.mtx.Unlock()
+	return active
+}
+
+// updateStatuses invokes the passed function with the status of each of the
+// passed indexes while holding the manager mutex.
+//
+// This function is safe for concurrent access.
+func (m *Manager) updateStatuses(indexes []Indexer, fn func(status *IndexStatus)) {
+	m.mtx.Lock()
+	for _, indexer := range indexes {
+		fn(m.statuses[indexer.Name()])
+	}
+	m.mtx.Unlock()
+}
+
+// IndexStatuses returns the status of each of the enabled indexes in the order
+// they were enabled.
+//
+// This function is safe for concurrent access.
+func (m *Manager) IndexStatuses() []IndexStatus {
+	m.mtx.Lock()
+	statuses := make([]IndexStatus, 0, len(m.enabledIndexes))
+	for _, indexer := range m.enabledIndexes {
+		statuses = append(statuses, *m.statuses[indexer.Name()])
+	}
+	m.mtx.Unlock()
+	return statuses
+}
+
+// MaintainIndex starts the passed maintenance operation for the passed enabled
+// index.  The operation is performed in the background while the chain keeps
+// running, and its progress is reported by IndexStatuses.  Dropping or
+// rebuilding the transaction index also drops or rebuilds the address and
+// balance indexes when they are enabled, since both of them rely on the
+// entries of the transaction index.
+//
+// The operation is interrupted when the passed channel is closed, which may be
+// nil when the operation should only be interrupted by Stop.  The returned
+// channel receives the result of the operation once it is finished.
+//
+// An error is returned when the index is not enabled, when an operation is
+// already in progress for it, when it has been dropped and the operation is
+// not a rebuild, or when it is rebuilt while an index it relies on has been
+// dropped.
+//
+// This function is safe for concurrent access.
+func (m *Manager) MaintainIndex(op IndexOp, indexer Indexer, interrupt <-chan struct{}) (<-chan error, error) {
+	var run func(indexes []Indexer, interrupt <-chan struct{}) error
+	switch op {
+	case IndexOpDrop:
+		run = m.dropIndexes
+	case IndexOpRebuild:
+		run = m.rebuildIndexes
+	case IndexOpVerify:
+		run = m.verifyIndexes
+	default:
+		return nil, fmt.Errorf("unknown index operation %q", op)
+	}
+
+	indexes, err := m.maintainedIndexes(op, indexer)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if interruptRequested(m.quit) {
+		return nil, fmt.Errorf("the index manager is stopping")
+	}
+	if op == IndexOpRebuild {
+		for _, dependency := range m.enabledIndexes {
+			if !dependsOn(indexer.Name(), dependency.Name()) {
+				continue
+			}
+			if !m.statuses[dependency.Name()].Active {
+				return nil, fmt.Errorf("the %s can not be rebuilt "+
+					"while the %s is not active", indexer.Name(),
+					dependency.Name())
+			}
+		}
+	}
+	for _, enabled := range indexes {
+		status := m.statuses[enabled.Name()]
+		if status.Running {
+			return nil, fmt.Errorf("the %s is busy with a %s operation",
+				enabled.Name(), status.Op)
+		}
+		if !status.Active && op != IndexOpRebuild {
+			return nil, fmt.Errorf("the %s has been dropped",
+				enabled.Name())
+		}
+	}
+	for _, enabled := range indexes {
+		status := m.statuses[enabled.Name()]
+		*status = IndexStatus{
+			Name:    status.Name,
+			Active:  status.Active,
+			Op:      op,
+			Running: true,
+		}
+	}
+
+	// The operation is interrupted when either the manager is stopped or
+	// the caller requests it.
+	opInterrupt := make(chan struct{})
+	opDone := make(chan struct{})
+	m.wg.Add(2)
+	go func() {
+		defer m.wg.Done()
+		select {
+		case <-m.quit:
+		case <-interrupt:
+		case <-opDone:
+			return
+		}
+		close(opInterrupt)
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		defer m.wg.Done()
+		defer close(opDone)
+
+		log.Infof("Starting %s of the %s", op, indexer.Name())
+		err := run(indexes, opInterrupt)
+		m.updateStatuses(indexes, func(status *IndexStatus) {
+			status.Running = false
+			status.Err = err
+		})
+		switch {
+		case err == errInterruptRequested:
+			log.Infof("Interrupted %s of the %s", op, indexer.Name())
+		case err != nil:
+			log.Errorf("Failed %s of the %s: %v", op, indexer.Name(),
+				err)
+		default:
+			log.Infof("Finished %s of the %s", op, indexer.Name())
+		}
+		done <- err
+	}()
+
+	return done, nil
+}
+
+// maintainedIndexes returns the enabled indexes the passed maintenance
+// operation for the passed index applies to in the order they were enabled.
+// Drops and rebuilds also apply to the enabled indexes which rely on the
+// entries of the passed index.  An error is returned when the index is not
+// enabled.
+func (m *Manager) maintainedIndexes(op IndexOp, indexer Indexer) ([]Indexer, error) {
+	var found bool
+	var indexes []Indexer
+	for _, enabled := range m.enabledIndexes {
+		switch {
+		case enabled.Name() == indexer.Name():
+			found = true
+		case op != IndexOpVerify && dependsOn(enabled.Name(),
+			indexer.Name()):
+		default:
+			continue
+		}
+		indexes = append(indexes, enabled)
+	}
+	if !found {
+		return nil, fmt.Errorf("the %s is not enabled", indexer.Name())
+	}
+	return indexes, nil
+}
+
+// Stop interrupts the index maintenance operations which are in progress and
+// waits for them to stop.  Interrupted drops are resumed and interrupted
+// rebuilds are caught up on the next start.
+//
+// This function is safe for concurrent access.
+func (m *Manager) Stop() {
+	m.mtx.Lock()
+	if !interruptRequested(m.quit) {
+		close(m.quit)
+	}
+	m.mtx.Unlock()
+
+	m.wg.Wait()
+}
+
+// dropIndexes stops updating the passed indexes and drops them in reverse
+// order since later indexes can depend on earlier ones.
+func (m *Manager) dropIndexes(indexes []Indexer, interrupt <-chan struct{}) error {
+	m.updateStatuses(indexes, func(status *IndexStatus) {
+		status.Active = false
+	})
+
+	for i := len(indexes) - 1; i >= 0; i-- {
+		indexer := indexes[i]
+		progress := func(totalDeleted uint64) {
+			m.updateStatuses(indexes[i:i+1], func(status *IndexStatus) {
+				status.DeletedKeys = totalDeleted
+			})
+		}
+		err := dropIndex(m.db, indexer.Key(), indexer.Name(), interrupt,
+			progress)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildIndexes drops the passed indexes, creates them again, and catches
+// them up to the end of the main chain.
+func (m *Manager) rebuildIndexes(indexes []Indexer, interrupt <-chan struct{}) error {
+	if err := m.dropIndexes(indexes, interrupt); err != nil {
+		return err
+	}
+
+	err := m.db.Update(func(dbTx database.Tx) error {
+		for _, indexer := range indexes {
+			if err := m.createIndex(dbTx, indexer); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, indexer := range indexes {
+		if err := indexer.Init(); err != nil {
+			return err
+		}
+	}
+
+	return m.catchUpIndexes(indexes, interrupt)
+}
+
+// catchUpIndexes connects the blocks of the main chain after the tip of the
+// passed indexes, which must share the same tip, to them until they reach the
+// end of the main chain.  Each block is indexed in its own database
+// transaction so blocks are still connected to the chain in the mean time.
+// The tip is disconnected from the indexes instead when it is no longer part of
+// the main chain due to a reorganization.
+//
+// The indexes are marked active in the same database transaction they are
+// found to have reached the end of the main chain in, so they are updated
+// along with every block which is connected to the chain afterwards.
+func (m *Manager) catchUpIndexes(indexes []Indexer, interrupt <-chan struct{}) error {
+	progressLogger := progresslog.NewBlockProgressLogger("Indexed", log)
+	for {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		var caughtUp bool
+		var block, parent *dcrutil.Block
+		err := m.db.Update(func(dbTx database.Tx) error {
+			tipHash, height, err := dbFetchIndexerTip(dbTx,
+				indexes[0].Key())
+			if err != nil {
+				return err
+			}
+			tipHeight := int64(height)
+			bestHeight, err := blockchain.DBFetchBestHeight(dbTx)
+			if err != nil {
+				return err
+			}
+
+			// Remove all of the index entries associated with the
+			// tip when it is no longer part of the main chain.
+			// This has to be done in reverse order because later
+			// indexes can depend on earlier ones.
+			if !blockchain.DBMainChainHasBlock(dbTx, tipHash) {
+				tip, err := dbFetchBlock(dbTx, tipHash, tipHeight)
+				if err != nil {
+					return err
+				}
+				tipParent, err := dbFetchBlock(dbTx,
+					&tip.MsgBlock().Header.PrevBlock, tipHeight-1)
+				if err != nil {
+					return err
+				}
+
+				var view *blockchain.UtxoViewpoint
+				for i := len(indexes) - 1; i >= 0; i-- {
+					indexer := indexes[i]
+					if view == nil && indexNeedsInputs(indexer) {
+						view, err = makeUtxoView(dbTx, tip,
+							tipParent)
+						if err != nil {
+							return err
+						}
+					}
+					err := dbIndexDisconnectBlock(dbTx, indexer,
+						tip, tipParent, view)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			// Resume updating the indexes along with the chain once
+			// they reached the end of the main chain.
+			if tipHeight >= bestHeight {
+				m.updateStatuses(indexes, func(status *IndexStatus) {
+					status.Active = true
+					status.Height = tipHeight
+					status.TargetHeight = bestHeight
+				})
+				caughtUp = true
+				return nil
+			}
+
+			// Connect the block after the tip to all of the indexes.
+			parent, err = dbFetchBlock(dbTx, tipHash, tipHeight)
+			if err != nil {
+				return err
+			}
+			block, err = blockchain.DBFetchBlockByHeight(dbTx,
+				tipHeight+1)
+			if err != nil {
+				return err
+			}
+			var view *blockchain.UtxoViewpoint
+			for _, indexer := range indexes {
+				if view == nil && indexNeedsInputs(indexer) {
+					view, err = makeUtxoView(dbTx, block, parent)
+					if err != nil {
+						return err
+					}
+				}
+				err := dbIndexConnectBlock(dbTx, indexer, block,
+					parent, view)
+				if err != nil {
+					return err
+				}
+			}
+			m.updateStatuses(indexes, func(status *IndexStatus) {
+				status.Height = block.Height()
+				status.TargetHeight = bestHeight
+			})
+			return nil
+		})
+		if err != nil {
+			// The indexes are not caught up when the database
+			// transaction which marked them active failed.
+			if caughtUp {
+				m.updateStatuses(indexes, func(status *IndexStatus) {
+					status.Active = false
+				})
+			}
+			return err
+		}
+		if caughtUp {
+			return nil
+		}
+		if block != nil {
+			progressLogger.LogBlockHeight(block.MsgBlock(),
+				parent.MsgBlock())
+		}
+	}
+}
+
+// verifyIndexes verifies each of the passed indexes.
+func (m *Manager) verifyIndexes(indexes []Indexer, interrupt <-chan struct{}) error {
+	for _, indexer := range indexes {
+		if err := m.verifyIndex(indexer, interrupt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyIndex ensures the tip of the passed index is part of the main chain
+// and, when the index supports it, verifies its entries for every block of the
+// main chain up to the tip.  The blocks are verified in batches of database
+// transactions so blocks are still connected to the chain in the mean time.
+func (m *Manager) verifyIndex(indexer Indexer, interrupt <-chan struct{}) error {
+	verifier, verifiesBlocks := indexer.(indexVerifier)
+	height := int64(1)
+	for {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		var done bool
+		err := m.db.View(func(dbTx database.Tx) error {
+			tipHash, tip, err := dbFetchIndexerTip(dbTx, indexer.Key())
+			if err != nil {
+				return err
+			}
+			tipHeight := int64(tip)
+			if !blockchain.DBMainChainHasBlock(dbTx, tipHash) {
+				return fmt.Errorf("the tip %v of the %s is not "+
+					"part of the main chain", tipHash,
+					indexer.Name())
+			}
+			if !verifiesBlocks {
+				height = tipHeight + 1
+			}
+
+			var parent *dcrutil.Block
+			for i := 0; i < verifyBlocksPerTx && height <= tipHeight; i++ {
+				if parent == nil {
+					parent, err = blockchain.DBFetchBlockByHeight(
+						dbTx, height-1)
+					if err != nil {
+						return err
+					}
+				}
+				block, err := blockchain.DBFetchBlockByHeight(dbTx,
+					height)
+				if err != nil {
+					return err
+				}
+				err = verifier.verifyBlock(dbTx, block, parent)
+				if err != nil {
+					return err
+				}
+				parent = block
+				height++
+			}
+			done = height > tipHeight
+
+			m.updateStatuses([]Indexer{indexer}, func(status *IndexStatus) {
+				status.Height = height - 1
+				status.TargetHeight = tipHeight
+			})
+			return nil
+		})
+		if err != nil || done {
+			return err
+		}
+	}
+}
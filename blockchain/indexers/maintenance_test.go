@@ -0,0 +1,249 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrutil"
+)
+
+// testIndexer is an index which only has a name and which implements the
+// Indexer interface.
+type testIndexer string
+
+// Key returns the key of the index derived from its name.
+//
+// This is part of the Indexer interface.
+func (idx testIndexer) Key() []byte {
+	return []byte(idx)
+}
+
+// Name returns the name of the index.
+//
+// This is part of the Indexer interface.
+func (idx testIndexer) Name() string {
+	return string(idx)
+}
+
+// Create does nothing.
+//
+// This is part of the Indexer interface.
+func (idx testIndexer) Create(dbTx database.Tx) error {
+	return nil
+}
+
+// Init does nothing.
+//
+// This is part of the Indexer interface.
+func (idx testIndexer) Init() error {
+	return nil
+}
+
+// ConnectBlock does nothing.
+//
+// This is part of the Indexer interface.
+func (idx testIndexer) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, view *blockchain.UtxoViewpoint) error {
+	return nil
+}
+
+// DisconnectBlock does nothing.
+//
+// This is part of the Indexer interface.
+func (idx testIndexer) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, view *blockchain.UtxoViewpoint) error {
+	return nil
+}
+
+// newMaintenanceTestManager returns a manager without a database which has
+// test indexes with the passed names enabled in the passed order.
+func newMaintenanceTestManager(names ...string) *Manager {
+	indexes := make([]Indexer, 0, len(names))
+	for _, name := range names {
+		indexes = append(indexes, testIndexer(name))
+	}
+	return NewManager(nil, indexes, &chaincfg.SimNetParams)
+}
+
+// TestMaintainedIndexes ensures drops and rebuilds of an index also apply to
+// the enabled indexes which rely on its entries in the order the indexes were
+// enabled, while verifications only apply to the index itself.
+func TestMaintainedIndexes(t *testing.T) {
+	t.Parallel()
+
+	m := newMaintenanceTestManager(txIndexName, addrIndexName,
+		existsAddressIndexName, balanceIndexName)
+	tests := []struct {
+		op    IndexOp
+		index string
+		want  []string
+	}{
+		{IndexOpDrop, txIndexName, []string{txIndexName, addrIndexName,
+			balanceIndexName}},
+		{IndexOpRebuild, txIndexName, []string{txIndexName,
+			addrIndexName, balanceIndexName}},
+		{IndexOpVerify, txIndexName, []string{txIndexName}},
+		{IndexOpDrop, addrIndexName, []string{addrIndexName}},
+		{IndexOpRebuild, balanceIndexName, []string{balanceIndexName}},
+		{IndexOpDrop, existsAddressIndexName, []string{
+			existsAddressIndexName}},
+	}
+	for i, test := range tests {
+		indexes, err := m.maintainedIndexes(test.op, testIndexer(test.index))
+		if err != nil {
+			t.Errorf("maintainedIndexes #%d: unexpected error: %v", i,
+				err)
+			continue
+		}
+		got := make([]string, 0, len(indexes))
+		for _, indexer := range indexes {
+			got = append(got, indexer.Name())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("maintainedIndexes #%d: unexpected indexes - got "+
+				"%v, want %v", i, got, test.want)
+		}
+	}
+
+	// Only enabled dependent indexes are included.
+	m = newMaintenanceTestManager(balanceIndexName, txIndexName)
+	indexes, err := m.maintainedIndexes(IndexOpDrop, testIndexer(txIndexName))
+	if err != nil {
+		t.Fatalf("maintainedIndexes: unexpected error: %v", err)
+	}
+	if len(indexes) != 2 || indexes[0].Name() != balanceIndexName {
+		t.Fatalf("maintainedIndexes: unexpected indexes %v", indexes)
+	}
+	_, err = m.maintainedIndexes(IndexOpVerify, testIndexer(addrIndexName))
+	if err == nil {
+		t.Fatal("maintainedIndexes: no error for index which is not " +
+			"enabled")
+	}
+}
+
+// TestMaintainIndexErrors ensures maintenance operations which can't be
+// performed are rejected without changing the status of any index.
+func TestMaintainIndexErrors(t *testing.T) {
+	t.Parallel()
+
+	m := newMaintenanceTestManager(txIndexName, addrIndexName,
+		balanceIndexName)
+	setStatus := func(name string, active, running bool) {
+		m.updateStatuses([]Indexer{testIndexer(name)}, func(status *IndexStatus) {
+			status.Active = active
+			status.Running = running
+			if running {
+				status.Op = IndexOpVerify
+			}
+		})
+	}
+	tests := []struct {
+		name   string
+		setup  func()
+		op     IndexOp
+		index  string
+		reason string
+	}{{
+		name:   "unknown operation",
+		op:     IndexOp("compact"),
+		index:  txIndexName,
+		reason: "unknown index operation",
+	}, {
+		name:   "index not enabled",
+		op:     IndexOpVerify,
+		index:  spendIndexName,
+		reason: "not enabled",
+	}, {
+		name:   "dependent index busy",
+		setup:  func() { setStatus(balanceIndexName, true, true) },
+		op:     IndexOpDrop,
+		index:  txIndexName,
+		reason: "busy",
+	}, {
+		name:   "verify dropped index",
+		setup:  func() { setStatus(addrIndexName, false, false) },
+		op:     IndexOpVerify,
+		index:  addrIndexName,
+		reason: "dropped",
+	}, {
+		name:   "drop dropped dependent index",
+		setup:  func() { setStatus(addrIndexName, false, false) },
+		op:     IndexOpDrop,
+		index:  txIndexName,
+		reason: "dropped",
+	}, {
+		name:   "rebuild without the transaction index",
+		setup:  func() { setStatus(txIndexName, false, false) },
+		op:     IndexOpRebuild,
+		index:  balanceIndexName,
+		reason: "not active",
+	}}
+	for _, test := range tests {
+		for _, name := range []string{txIndexName, addrIndexName,
+			balanceIndexName} {
+
+			setStatus(name, true, false)
+		}
+		if test.setup != nil {
+			test.setup()
+		}
+		before := m.IndexStatuses()
+
+		done, err := m.MaintainIndex(test.op, testIndexer(test.index), nil)
+		if err == nil || done != nil {
+			t.Errorf("%s: operation was not rejected", test.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if after := m.IndexStatuses(); !reflect.DeepEqual(after, before) {
+			t.Errorf("%s: statuses changed - got %+v, want %+v",
+				test.name, after, before)
+		}
+	}
+}
+
+// TestIndexStatuses ensures the statuses of the enabled indexes are reported in
+// the order the indexes were enabled, that they are copies, and that no
+// operations are started once the manager is stopped.
+func TestIndexStatuses(t *testing.T) {
+	t.Parallel()
+
+	names := []string{balanceIndexName, txIndexName, addrIndexName}
+	m := newMaintenanceTestManager(names...)
+	statuses := m.IndexStatuses()
+	if len(statuses) != len(names) {
+		t.Fatalf("IndexStatuses: unexpected number of statuses - got %d, "+
+			"want %d", len(statuses), len(names))
+	}
+	for i, status := range statuses {
+		want := IndexStatus{Name: names[i], Active: true}
+		if !reflect.DeepEqual(status, want) {
+			t.Fatalf("IndexStatuses: unexpected status #%d - got %+v, "+
+				"want %+v", i, status, want)
+		}
+	}
+	statuses[0].Active = false
+	if !m.isActive(testIndexer(names[0])) {
+		t.Fatal("IndexStatuses: returned status is not a copy")
+	}
+
+	// Stopping the manager more than once is fine, and operations are
+	// rejected afterwards.
+	m.Stop()
+	m.Stop()
+	if !interruptRequested(m.quit) {
+		t.Fatal("Stop: quit channel not closed")
+	}
+	_, err := m.MaintainIndex(IndexOpVerify, testIndexer(txIndexName), nil)
+	if err == nil || !strings.Contains(err.Error(), "stopping") {
+		t.Fatalf("MaintainIndex: unexpected error after stop: %v", err)
+	}
+}
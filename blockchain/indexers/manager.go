@@ -8,6 +8,7 @@ package indexers
 import (
 	"bytes"
 	"fmt"
+	"sync"
 
 	"github.com/decred/dcrd/blockchain"
 	"github.com/decred/dcrd/blockchain/internal/progresslog"
@@ -133,6 +134,15 @@ type Manager struct {
 	params         *chaincfg.Params
 	db             database.DB
 	enabledIndexes []Indexer
+
+	// The following fields are related to maintaining the enabled indexes
+	// while the chain is running.  The statuses of the indexes are keyed
+	// by their names and protected by the mutex.  The indexes which are
+	// not active are not updated as blocks are connected and disconnected.
+	mtx      sync.Mutex
+	statuses map[string]*IndexStatus
+	quit     chan struct{}
+	wg       sync.WaitGroup
 }
 
 // Ensure the Manager type implements the blockchain.IndexManager interface.
@@ -184,7 +194,7 @@ func (m *Manager) maybeFinishDrops() error {
 		}
 
 		log.Infof("Resuming %s drop", indexer.Name())
-		err := dropIndex(m.db, indexer.Key(), indexer.Name())
+		err := dropIndex(m.db, indexer.Key(), indexer.Name(), nil, nil)
 		if err != nil {
 			return err
 		}
@@ -199,23 +209,12 @@ func (m *Manager) maybeCreateIndexes(dbTx database.Tx) error {
 	indexesBucket := dbTx.Metadata().Bucket(indexTipsBucketName)
 	for _, indexer := range m.enabledIndexes {
 		// Nothing to do if the index tip already exists.
-		idxKey := indexer.Key()
-		if indexesBucket.Get(idxKey) != nil {
+		if indexesBucket.Get(indexer.Key()) != nil {
 			continue
 		}
 
-		// The tip for the index does not exist, so create it and
-		// invoke the create callback for the index so it can perform
-		// any one-time initialization it requires.
-		if err := indexer.Create(dbTx); err != nil {
-			return err
-		}
-
-		// Set the tip for the index to values which represent an
-		// uninitialized index (the genesis block hack and height).
-		genesisBlockHash := m.params.GenesisBlock.BlockHash()
-		err := dbPutIndexerTip(dbTx, idxKey, &genesisBlockHash, 0)
-		if err != nil {
+		// The tip for the index does not exist, so create it.
+		if err := m.createIndex(dbTx, indexer); err != nil {
 			return err
 		}
 	}
@@ -223,6 +222,19 @@ func (m *Manager) maybeCreateIndexes(dbTx database.Tx) error {
 	return nil
 }
 
+// createIndex invokes the create callback for the passed index so it can
+// perform any one-time initialization it requires and creates its tip.
+func (m *Manager) createIndex(dbTx database.Tx, indexer Indexer) error {
+	if err := indexer.Create(dbTx); err != nil {
+		return err
+	}
+
+	// Set the tip for the index to values which represent an uninitialized
+	// index (the genesis block hack and height).
+	genesisBlockHash := m.params.GenesisBlock.BlockHash()
+	return dbPutIndexerTip(dbTx, indexer.Key(), &genesisBlockHash, 0)
+}
+
 // Init initializes the enabled indexes.  This is called during chain
 // initialization and primarily consists of catching up all indexes to the
 // current best chain tip.  This is necessary since each index can be disabled
@@ -577,6 +589,9 @@ func (m *Manager) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, v
 	// Call each of the currently active optional indexes with the block
 	// being connected so they can update accordingly.
 	for _, index := range m.enabledIndexes {
+		if !m.isActive(index) {
+			continue
+		}
 		err := dbIndexConnectBlock(dbTx, index, block, parent, view)
 		if err != nil {
 			return err
@@ -595,6 +610,9 @@ func (m *Manager) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block
 	// Call each of the currently active optional indexes with the block
 	// being disconnected so they can update accordingly.
 	for _, index := range m.enabledIndexes {
+		if !m.isActive(index) {
+			continue
+		}
 		err := dbIndexDisconnectBlock(dbTx, index, block, parent, view)
 		if err != nil {
 			return err
@@ -608,10 +626,19 @@ func (m *Manager) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block
 // The manager returned satisfies the blockchain.IndexManager interface and thus
 // cleanly plugs into the normal blockchain processing path.
 func NewManager(db database.DB, enabledIndexes []Indexer, params *chaincfg.Params) *Manager {
+	statuses := make(map[string]*IndexStatus, len(enabledIndexes))
+	for _, indexer := range enabledIndexes {
+		statuses[indexer.Name()] = &IndexStatus{
+			Name:   indexer.Name(),
+			Active: true,
+		}
+	}
 	return &Manager{
 		db:             db,
 		enabledIndexes: enabledIndexes,
 		params:         params,
+		statuses:       statuses,
+		quit:           make(chan struct{}),
 	}
 }
 
@@ -620,7 +647,11 @@ func NewManager(db database.DB, enabledIndexes []Indexer, params *chaincfg.Param
 // keep memory usage to reasonable levels.  It also marks the drop in progress
 // so the drop can be resumed if it is stopped before it is done before the
 // index can be used again.
-func dropIndex(db database.DB, idxKey []byte, idxName string) error {
+//
+// The drop is stopped early with errInterruptRequested when the interrupt
+// channel is closed, and the progress function, when it is not nil, is invoked
+// with the total number of deleted keys after each database transaction.
+func dropIndex(db database.DB, idxKey []byte, idxName string, interrupt <-chan struct{}, progress func(totalDeleted uint64)) error {
 	// Nothing to do if the index doesn't already exist.
 	var needsDelete bool
 	err := db.View(func(dbTx database.Tx) error {
@@ -659,6 +690,10 @@ func dropIndex(db database.DB, idxKey []byte, idxName string) error {
 	const maxDeletions = 2000000
 	var totalDeleted uint64
 	for numDeleted := maxDeletions; numDeleted == maxDeletions; {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
 		numDeleted = 0
 		err := db.Update(func(dbTx database.Tx) error {
 			bucket := dbTx.Metadata().Bucket(idxKey)
@@ -681,6 +716,9 @@ func dropIndex(db database.DB, idxKey []byte, idxName string) error {
 			totalDeleted += uint64(numDeleted)
 			log.Infof("Deleted %d keys (%d total) from %s",
 				numDeleted, totalDeleted, idxName)
+			if progress != nil {
+				progress(totalDeleted)
+			}
 		}
 	}
 
@@ -691,6 +729,14 @@ func dropIndex(db database.DB, idxKey []byte, idxName string) error {
 		}
 	}
 
+	// Call extra index specific deinitialization for the exists address
+	// index.
+	if idxName == existsAddressIndexName {
+		if err := dropExistsAddrFilters(db); err != nil {
+			return err
+		}
+	}
+
 	// Remove the index tip, index bucket, and in-progress drop flag now
 	// that all index entries have been removed.
 	err = db.Update(func(dbTx database.Tx) error {
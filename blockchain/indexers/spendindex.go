@@ -0,0 +1,323 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// spendIndexName is the human-readable name for the index.
+	spendIndexName = "spend index"
+
+	// spendKeySize is the size of a serialized spent outpoint.
+	spendKeySize = chainhash.HashSize + 4 + 1
+
+	// spendEntrySize is the size of a serialized spend entry.
+	spendEntrySize = chainhash.HashSize + 4 + 4
+)
+
+var (
+	// spendIndexKey is the key of the spend index and the db bucket used
+	// to house it.
+	spendIndexKey = []byte("spendidx")
+)
+
+// -----------------------------------------------------------------------------
+// The spend index consists of an entry for every transaction output which is
+// spent by a transaction in the main chain.  Each entry identifies the input
+// which spends the output and the height of the block that contains the
+// spending transaction.  Outputs which are unspent, or only spent by
+// transactions that are not in the main chain, do not have an entry.
+//
+// The regular transaction tree of a block only spends outputs once it is
+// approved by the next block, so the entries for the regular transactions of a
+// block are added when the next block approves them and are removed when that
+// block is disconnected.  The recorded height is still the height of the block
+// that contains the spending transaction.  Coinbase and stakebase inputs do not
+// spend any outputs and therefore are not indexed.
+//
+// The serialized format for keys and values in the spend index bucket is:
+//   <outpoint> = <spender hash><input index><height>
+//
+//   Field           Type              Size
+//   outpoint hash   chainhash.Hash    32 bytes
+//   outpoint index  uint32            4 bytes
+//   outpoint tree   int8              1 byte
+//   -----
+//   spender hash    chainhash.Hash    32 bytes
+//   input index     uint32            4 bytes
+//   height          uint32            4 bytes
+//   -----
+//   Total: 77 bytes
+// -----------------------------------------------------------------------------
+
+// SpendInfo identifies the transaction input which spends an output in the
+// main chain.
+type SpendInfo struct {
+	// SpenderHash is the hash of the spending transaction.
+	SpenderHash chainhash.Hash
+
+	// InputIndex is the index of the input of the spending transaction
+	// which spends the output.
+	InputIndex uint32
+
+	// Height is the height of the block which contains the spending
+	// transaction.
+	Height int64
+}
+
+// spendKey returns the database key for the passed outpoint.
+func spendKey(outPoint *wire.OutPoint) []byte {
+	key := make([]byte, spendKeySize)
+	copy(key, outPoint.Hash[:])
+	byteOrder.PutUint32(key[chainhash.HashSize:], outPoint.Index)
+	key[chainhash.HashSize+4] = byte(outPoint.Tree)
+	return key
+}
+
+// serializeSpendEntry returns the serialization of the passed spend
+// information according to the format described above.
+func serializeSpendEntry(info *SpendInfo) []byte {
+	serialized := make([]byte, spendEntrySize)
+	copy(serialized, info.SpenderHash[:])
+	offset := chainhash.HashSize
+	byteOrder.PutUint32(serialized[offset:], info.InputIndex)
+	byteOrder.PutUint32(serialized[offset+4:], uint32(info.Height))
+	return serialized
+}
+
+// deserializeSpendEntry decodes the passed serialized spend entry.
+func deserializeSpendEntry(serialized []byte) (*SpendInfo, error) {
+	if len(serialized) < spendEntrySize {
+		return nil, errDeserialize("unexpected end of data for spend " +
+			"entry")
+	}
+
+	var info SpendInfo
+	copy(info.SpenderHash[:], serialized[:chainhash.HashSize])
+	offset := chainhash.HashSize
+	info.InputIndex = byteOrder.Uint32(serialized[offset:])
+	info.Height = int64(byteOrder.Uint32(serialized[offset+4:]))
+	return &info, nil
+}
+
+// dbFetchSpendEntry uses an existing database bucket to fetch the spend
+// information for the passed outpoint.  When the outpoint is not spent in the
+// main chain, nil will be returned for both the entry and the error.
+func dbFetchSpendEntry(bucket internalBucket, outPoint *wire.OutPoint) (*SpendInfo, error) {
+	serialized := bucket.Get(spendKey(outPoint))
+	if serialized == nil {
+		return nil, nil
+	}
+
+	return deserializeSpendEntry(serialized)
+}
+
+// spendingTx houses a transaction whose inputs are indexed along with the
+// transaction tree and height of the block which contains it.
+type spendingTx struct {
+	tx     *dcrutil.Tx
+	tree   int8
+	height int64
+}
+
+// blockSpendingTxns returns the transactions whose inputs take effect when the
+// passed block is connected, which are the regular transactions of the parent
+// when the block approves them and the stake transactions of the block.
+func blockSpendingTxns(block, parent *dcrutil.Block) []spendingTx {
+	var txns []spendingTx
+	regularTxTreeValid := dcrutil.IsFlagSet16(block.MsgBlock().Header.VoteBits,
+		dcrutil.BlockValid)
+	if regularTxTreeValid && block.Height() > 1 {
+		for _, tx := range parent.Transactions() {
+			txns = append(txns, spendingTx{tx, wire.TxTreeRegular,
+				parent.Height()})
+		}
+	}
+	for _, stx := range block.STransactions() {
+		txns = append(txns, spendingTx{stx, wire.TxTreeStake,
+			block.Height()})
+	}
+	return txns
+}
+
+// spentOutPoints invokes the passed function with each input of the passed
+// transaction which spends an output.
+func spentOutPoints(tx *dcrutil.Tx, fn func(txIn *wire.TxIn, index uint32) error) error {
+	msgTx := tx.MsgTx()
+	if blockchain.IsCoinBaseTx(msgTx) {
+		return nil
+	}
+	isSSGen, _ := stake.IsSSGen(msgTx)
+	for i, txIn := range msgTx.TxIn {
+		// The first input of a vote is the stakebase.
+		if isSSGen && i == 0 {
+			continue
+		}
+		if err := fn(txIn, uint32(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SpendIndex implements an index of the transaction inputs which spend the
+// outputs of the main chain.
+type SpendIndex struct {
+	db database.DB
+}
+
+// Ensure the SpendIndex type implements the Indexer interface.
+var _ Indexer = (*SpendIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Key() []byte {
+	return spendIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Name() string {
+	return spendIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the spend
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(spendIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry for every output
+// spent by the transactions which take effect with the block.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(spendIndexKey)
+	for _, spender := range blockSpendingTxns(block, parent) {
+		err := spentOutPoints(spender.tx, func(txIn *wire.TxIn, index uint32) error {
+			info := SpendInfo{
+				SpenderHash: *spender.tx.Hash(),
+				InputIndex:  index,
+				Height:      spender.height,
+			}
+			return bucket.Put(spendKey(&txIn.PreviousOutPoint),
+				serializeSpendEntry(&info))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the entries for the
+// outputs spent by the transactions which took effect with the block.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(spendIndexKey)
+	for _, spender := range blockSpendingTxns(block, parent) {
+		err := spentOutPoints(spender.tx, func(txIn *wire.TxIn, index uint32) error {
+			return bucket.Delete(spendKey(&txIn.PreviousOutPoint))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyBlock ensures the spend index has the expected entry for every output
+// spent by the transactions which take effect with the passed block of the main
+// chain.
+func (idx *SpendIndex) verifyBlock(dbTx database.Tx, block, parent *dcrutil.Block) error {
+	bucket := dbTx.Metadata().Bucket(spendIndexKey)
+	for _, spender := range blockSpendingTxns(block, parent) {
+		err := spentOutPoints(spender.tx, func(txIn *wire.TxIn, index uint32) error {
+			outPoint := &txIn.PreviousOutPoint
+			info, err := dbFetchSpendEntry(bucket, outPoint)
+			if err != nil {
+				return err
+			}
+			want := SpendInfo{
+				SpenderHash: *spender.tx.Hash(),
+				InputIndex:  index,
+				Height:      spender.height,
+			}
+			if info == nil || *info != want {
+				return fmt.Errorf("%s entry for output %v does not "+
+					"refer to input %d of transaction %v", spendIndexName,
+					outPoint, index, spender.tx.Hash())
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SpendInfo returns the input of the main chain which spends the passed
+// outpoint.  When the outpoint is not spent in the main chain, nil will be
+// returned for both the spend information and the error.
+//
+// This function is safe for concurrent access.
+func (idx *SpendIndex) SpendInfo(outPoint *wire.OutPoint) (*SpendInfo, error) {
+	var info *SpendInfo
+	err := idx.db.View(func(dbTx database.Tx) error {
+		// The index does not exist while it is being dropped or rebuilt
+		// while the chain is running.
+		bucket := dbTx.Metadata().Bucket(spendIndexKey)
+		if bucket == nil {
+			return nil
+		}
+		var err error
+		info, err = dbFetchSpendEntry(bucket, outPoint)
+		return err
+	})
+	return info, err
+}
+
+// NewSpendIndex returns a new instance of an indexer that is used to maintain
+// an index of the transaction inputs which spend the outputs of the main chain.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewSpendIndex(db database.DB) *SpendIndex {
+	return &SpendIndex{db: db}
+}
+
+// DropSpendIndex drops the spend index from the provided database if it exists.
+func DropSpendIndex(db database.DB) error {
+	return dropIndex(db, spendIndexKey, spendIndexName, nil, nil)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestSpendSerialization ensures serializing and deserializing spend entries
+// and outpoint keys works as expected.
+func TestSpendSerialization(t *testing.T) {
+	t.Parallel()
+
+	info := SpendInfo{
+		SpenderHash: chainhash.Hash{0x01, 0x02, 0x03},
+		InputIndex:  7,
+		Height:      150000,
+	}
+	serialized := serializeSpendEntry(&info)
+	if len(serialized) != spendEntrySize {
+		t.Fatalf("unexpected serialized size - got %d, want %d",
+			len(serialized), spendEntrySize)
+	}
+	got, err := deserializeSpendEntry(serialized)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *got != info {
+		t.Fatalf("mismatched entry - got %+v, want %+v", *got, info)
+	}
+
+	// Ensure truncated data is detected.
+	_, err = deserializeSpendEntry(serialized[:len(serialized)-1])
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated data - got %v", err)
+	}
+
+	// Ensure the keys of outpoints which only differ by index or tree are
+	// distinct.
+	hash := chainhash.Hash{0xaa}
+	keys := [][]byte{
+		spendKey(wire.NewOutPoint(&hash, 0, wire.TxTreeRegular)),
+		spendKey(wire.NewOutPoint(&hash, 1, wire.TxTreeRegular)),
+		spendKey(wire.NewOutPoint(&hash, 0, wire.TxTreeStake)),
+	}
+	for i := range keys {
+		if len(keys[i]) != spendKeySize {
+			t.Fatalf("unexpected key size - got %d, want %d",
+				len(keys[i]), spendKeySize)
+		}
+		for j := i + 1; j < len(keys); j++ {
+			if bytes.Equal(keys[i], keys[j]) {
+				t.Fatalf("keys %d and %d are not distinct", i, j)
+			}
+		}
+	}
+}
@@ -189,7 +189,12 @@ func dbPutTxIndexEntry(dbTx database.Tx, txHash chainhash.Hash, serializedData [
 // the region and the error.
 func dbFetchTxIndexEntry(dbTx database.Tx, txHash chainhash.Hash) (*database.BlockRegion, error) {
 	// Load the record from the database and return now if it doesn't exist.
+	// The index itself does not exist while it is being dropped or rebuilt
+	// while the chain is running.
 	txIndex := dbTx.Metadata().Bucket(txIndexKey)
+	if txIndex == nil {
+		return nil, nil
+	}
 	serializedData := txIndex.Get(txHash[:])
 	if len(serializedData) == 0 {
 		return nil, nil
@@ -474,6 +479,61 @@ func (idx *TxIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Blo
 	return nil
 }
 
+// dbVerifyTxIndexEntries uses an existing database transaction to ensure each
+// of the passed transactions of the passed block has a transaction index entry
+// which refers to the passed location of it within the block.
+func dbVerifyTxIndexEntries(dbTx database.Tx, block *dcrutil.Block, txns []*dcrutil.Tx, txLocs []wire.TxLoc) error {
+	for i, tx := range txns {
+		region, err := dbFetchTxIndexEntry(dbTx, *tx.Hash())
+		if err != nil {
+			return err
+		}
+		if region == nil {
+			return fmt.Errorf("transaction %v of block %v is missing "+
+				"from the %s", tx.Hash(), block.Hash(), txIndexName)
+		}
+		if *region.Hash != *block.Hash() ||
+			region.Offset != uint32(txLocs[i].TxStart) ||
+			region.Len != uint32(txLocs[i].TxLen) {
+
+			return fmt.Errorf("%s entry for transaction %v does not "+
+				"refer to its location in block %v", txIndexName,
+				tx.Hash(), block.Hash())
+		}
+	}
+
+	return nil
+}
+
+// verifyBlock ensures each transaction which is indexed along with the passed
+// block has an entry which refers to its location.
+//
+// This is part of the indexVerifier interface.
+func (idx *TxIndex) verifyBlock(dbTx database.Tx, block, parent *dcrutil.Block) error {
+	// The regular transactions of the parent are only indexed along with
+	// the block when the block approves of them.
+	regularTxTreeValid := dcrutil.IsFlagSet16(block.MsgBlock().Header.VoteBits,
+		dcrutil.BlockValid)
+	if regularTxTreeValid && block.Height() > 1 {
+		parentTxLocs, _, err := parent.TxLoc()
+		if err != nil {
+			return err
+		}
+		err = dbVerifyTxIndexEntries(dbTx, parent, parent.Transactions(),
+			parentTxLocs)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, blockStxLocs, err := block.TxLoc()
+	if err != nil {
+		return err
+	}
+	return dbVerifyTxIndexEntries(dbTx, block, block.STransactions(),
+		blockStxLocs)
+}
+
 // TxBlockRegion returns the block region for the provided transaction hash
 // from the transaction index.  The block region can in turn be used to load the
 // raw transaction bytes.  When there is no entry for the provided hash, nil
@@ -518,9 +578,10 @@ func dropBlockIDIndex(db database.DB) error {
 // exists.  Since the address index relies on it, the address index will also be
 // dropped when it exists.
 func DropTxIndex(db database.DB) error {
-	if err := dropIndex(db, addrIndexKey, addrIndexName); err != nil {
+	err := dropIndex(db, addrIndexKey, addrIndexName, nil, nil)
+	if err != nil {
 		return err
 	}
 
-	return dropIndex(db, txIndexKey, txIndexName)
+	return dropIndex(db, txIndexKey, txIndexName, nil, nil)
 }
@@ -0,0 +1,398 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// windowAggIndexName is the human-readable name for the index.
+	windowAggIndexName = "window aggregate index"
+
+	// windowAggEntrySize is the size of a serialized window aggregate
+	// entry.
+	windowAggEntrySize = 4 + 4 + 8 + 8 + 8 + 8 + 8 + 4 + 4 + 4
+)
+
+var (
+	// windowAggIndexKey is the key of the window aggregate index and the
+	// db bucket used to house it.
+	windowAggIndexKey = []byte("windowaggidx")
+)
+
+// -----------------------------------------------------------------------------
+// The window aggregate index consists of an entry for every stake difficulty
+// adjustment window in the main chain.  Each entry holds the running totals of
+// the blocks connected within that window so that historical analytics such as
+// fees and the subsidy split per window may be served without rescanning the
+// blocks.  The entry is updated as the blocks of the window are connected and
+// disconnected and therefore is complete once the final block of the window
+// has been connected.
+//
+// Amounts follow the same accounting as the total subsidy of the chain state.
+// That is to say the regular transaction tree of a block, including its
+// coinbase, is attributed to the block that approves it, while the stake
+// transaction tree is attributed to the block that contains it.
+//
+// The serialized format for keys and values in the window aggregate bucket is:
+//   <window> = <num blocks><bits><sbits><fees><work><stake><tax><votes>
+//              <tickets><revocations>
+//
+//   Field           Type      Size
+//   window          uint32    4 bytes
+//   -----
+//   num blocks      uint32    4 bytes
+//   bits            uint32    4 bytes
+//   sbits           int64     8 bytes
+//   fees            int64     8 bytes
+//   work            int64     8 bytes
+//   stake           int64     8 bytes
+//   tax             int64     8 bytes
+//   votes           uint32    4 bytes
+//   tickets         uint32    4 bytes
+//   revocations     uint32    4 bytes
+//   -----
+//   Total: 64 bytes
+//
+// The bits field is the proof-of-work difficulty of the first block in the
+// window while the sbits field is the stake difficulty which, by definition,
+// is the same for every block in the window.
+// -----------------------------------------------------------------------------
+
+// WindowAggregate houses the aggregate information about the blocks connected
+// within a single stake difficulty adjustment window.
+type WindowAggregate struct {
+	// Window is the index of the window, which is the height of any block
+	// in the window divided by the stake difficulty window size.
+	Window uint32
+
+	// NumBlocks is the number of blocks of the window that are currently
+	// connected to the main chain.
+	NumBlocks uint32
+
+	// Bits is the proof-of-work difficulty of the first block in the
+	// window.
+	Bits uint32
+
+	// SBits is the stake difficulty of the window.
+	SBits int64
+
+	// TotalFees is the total amount of transaction fees in atoms.
+	TotalFees int64
+
+	// WorkSubsidy, StakeSubsidy, and TaxSubsidy are the total amounts in
+	// atoms paid to proof-of-work miners, voters, and the organization,
+	// respectively.
+	WorkSubsidy  int64
+	StakeSubsidy int64
+	TaxSubsidy   int64
+
+	// Votes, Tickets, and Revocations are the total number of votes,
+	// ticket purchases, and revocations included in the window.
+	Votes       uint32
+	Tickets     uint32
+	Revocations uint32
+}
+
+// serializeWindowAggEntry returns the serialization of the passed window
+// aggregate according to the format described above.
+func serializeWindowAggEntry(agg *WindowAggregate) []byte {
+	serialized := make([]byte, windowAggEntrySize)
+	byteOrder.PutUint32(serialized[0:4], agg.NumBlocks)
+	byteOrder.PutUint32(serialized[4:8], agg.Bits)
+	byteOrder.PutUint64(serialized[8:16], uint64(agg.SBits))
+	byteOrder.PutUint64(serialized[16:24], uint64(agg.TotalFees))
+	byteOrder.PutUint64(serialized[24:32], uint64(agg.WorkSubsidy))
+	byteOrder.PutUint64(serialized[32:40], uint64(agg.StakeSubsidy))
+	byteOrder.PutUint64(serialized[40:48], uint64(agg.TaxSubsidy))
+	byteOrder.PutUint32(serialized[48:52], agg.Votes)
+	byteOrder.PutUint32(serialized[52:56], agg.Tickets)
+	byteOrder.PutUint32(serialized[56:60], agg.Revocations)
+	return serialized
+}
+
+// deserializeWindowAggEntry decodes the passed serialized window aggregate
+// entry for the given window.
+func deserializeWindowAggEntry(window uint32, serialized []byte) (*WindowAggregate, error) {
+	if len(serialized) < windowAggEntrySize {
+		return nil, errDeserialize(fmt.Sprintf("unexpected end of "+
+			"data for window aggregate %d", window))
+	}
+
+	return &WindowAggregate{
+		Window:       window,
+		NumBlocks:    byteOrder.Uint32(serialized[0:4]),
+		Bits:         byteOrder.Uint32(serialized[4:8]),
+		SBits:        int64(byteOrder.Uint64(serialized[8:16])),
+		TotalFees:    int64(byteOrder.Uint64(serialized[16:24])),
+		WorkSubsidy:  int64(byteOrder.Uint64(serialized[24:32])),
+		StakeSubsidy: int64(byteOrder.Uint64(serialized[32:40])),
+		TaxSubsidy:   int64(byteOrder.Uint64(serialized[40:48])),
+		Votes:        byteOrder.Uint32(serialized[48:52]),
+		Tickets:      byteOrder.Uint32(serialized[52:56]),
+		Revocations:  byteOrder.Uint32(serialized[56:60]),
+	}, nil
+}
+
+// windowAggKey returns the database key for the passed window.
+func windowAggKey(window uint32) []byte {
+	key := make([]byte, 4)
+	byteOrder.PutUint32(key, window)
+	return key
+}
+
+// dbFetchWindowAggEntry uses an existing database bucket to fetch the
+// aggregate for the passed window.  When there is no entry for the window, nil
+// will be returned for both the entry and the error.
+func dbFetchWindowAggEntry(bucket internalBucket, window uint32) (*WindowAggregate, error) {
+	serialized := bucket.Get(windowAggKey(window))
+	if serialized == nil {
+		return nil, nil
+	}
+
+	return deserializeWindowAggEntry(window, serialized)
+}
+
+// txFees returns the total fees paid by the passed transactions.  It relies on
+// the input amounts committed to by the transactions which have already been
+// proven to match the referenced outputs by full validation.  Coinbase
+// transactions do not pay fees and therefore must not be included.  Note that
+// the stakebase input amount of a vote is also paid out by its outputs, so it
+// does not contribute to the fees.
+func txFees(txns []*dcrutil.Tx) int64 {
+	var fees int64
+	for _, tx := range txns {
+		msgTx := tx.MsgTx()
+		for _, txIn := range msgTx.TxIn {
+			fees += txIn.ValueIn
+		}
+		for _, txOut := range msgTx.TxOut {
+			fees -= txOut.Value
+		}
+	}
+	return fees
+}
+
+// WindowAggIndex implements an index of aggregate information about each stake
+// difficulty adjustment window of the main chain.
+type WindowAggIndex struct {
+	db           database.DB
+	chainParams  *chaincfg.Params
+	subsidyCache *blockchain.SubsidyCache
+	windowSize   int64
+}
+
+// Ensure the WindowAggIndex type implements the Indexer interface.
+var _ Indexer = (*WindowAggIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *WindowAggIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *WindowAggIndex) Key() []byte {
+	return windowAggIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *WindowAggIndex) Name() string {
+	return windowAggIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the window
+// aggregate index.
+//
+// This is part of the Indexer interface.
+func (idx *WindowAggIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(windowAggIndexKey)
+	return err
+}
+
+// blockAggregate returns the aggregate contribution of the passed block to the
+// window it belongs to.
+func (idx *WindowAggIndex) blockAggregate(block, parent *dcrutil.Block) *WindowAggregate {
+	header := &block.MsgBlock().Header
+	agg := &WindowAggregate{
+		Window:      uint32(block.Height() / idx.windowSize),
+		NumBlocks:   1,
+		Bits:        header.Bits,
+		SBits:       header.SBits,
+		Votes:       uint32(header.Voters),
+		Tickets:     uint32(header.FreshStake),
+		Revocations: uint32(header.Revocations),
+	}
+
+	// The regular transaction tree of the parent, including its coinbase,
+	// only takes effect when it is approved by the block.  Note that the
+	// genesis block coinbase is not spendable and block one distributes
+	// the initial tokens without paying any tax.
+	regularTxTreeValid := dcrutil.IsFlagSet16(header.VoteBits,
+		dcrutil.BlockValid)
+	if regularTxTreeValid && block.Height() > 1 {
+		parentTxns := parent.Transactions()
+		coinbase := parentTxns[0].MsgTx()
+		var taxSubsidy int64
+		if parent.Height() > 1 {
+			taxSubsidy = blockchain.CalcBlockTaxSubsidy(idx.subsidyCache,
+				parent.Height(), parent.MsgBlock().Header.Voters,
+				idx.chainParams)
+		}
+		agg.TaxSubsidy = taxSubsidy
+		agg.WorkSubsidy = coinbase.TxIn[0].ValueIn - taxSubsidy
+		agg.TotalFees += txFees(parentTxns[1:])
+	}
+
+	for _, stx := range block.STransactions() {
+		msgTx := stx.MsgTx()
+		if isSSGen, _ := stake.IsSSGen(msgTx); isSSGen {
+			agg.StakeSubsidy += msgTx.TxIn[0].ValueIn
+		}
+	}
+	agg.TotalFees += txFees(block.STransactions())
+
+	return agg
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds the contribution of the
+// block to the aggregate of the window it belongs to.
+//
+// This is part of the Indexer interface.
+func (idx *WindowAggIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(windowAggIndexKey)
+	blockAgg := idx.blockAggregate(block, parent)
+	agg, err := dbFetchWindowAggEntry(bucket, blockAgg.Window)
+	if err != nil {
+		return err
+	}
+
+	// The first block of the window establishes the difficulties.
+	if agg == nil {
+		return bucket.Put(windowAggKey(blockAgg.Window),
+			serializeWindowAggEntry(blockAgg))
+	}
+
+	agg.NumBlocks++
+	agg.TotalFees += blockAgg.TotalFees
+	agg.WorkSubsidy += blockAgg.WorkSubsidy
+	agg.StakeSubsidy += blockAgg.StakeSubsidy
+	agg.TaxSubsidy += blockAgg.TaxSubsidy
+	agg.Votes += blockAgg.Votes
+	agg.Tickets += blockAgg.Tickets
+	agg.Revocations += blockAgg.Revocations
+	return bucket.Put(windowAggKey(agg.Window), serializeWindowAggEntry(agg))
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the contribution of
+// the block from the aggregate of the window it belongs to.
+//
+// This is part of the Indexer interface.
+func (idx *WindowAggIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(windowAggIndexKey)
+	blockAgg := idx.blockAggregate(block, parent)
+	agg, err := dbFetchWindowAggEntry(bucket, blockAgg.Window)
+	if err != nil {
+		return err
+	}
+	if agg == nil {
+		return AssertError(fmt.Sprintf("missing window aggregate entry "+
+			"for block %v (height %d)", block.Hash(), block.Height()))
+	}
+
+	// Remove the entry entirely once the first block of the window is
+	// disconnected.
+	if agg.NumBlocks <= 1 {
+		return bucket.Delete(windowAggKey(agg.Window))
+	}
+
+	agg.NumBlocks--
+	agg.TotalFees -= blockAgg.TotalFees
+	agg.WorkSubsidy -= blockAgg.WorkSubsidy
+	agg.StakeSubsidy -= blockAgg.StakeSubsidy
+	agg.TaxSubsidy -= blockAgg.TaxSubsidy
+	agg.Votes -= blockAgg.Votes
+	agg.Tickets -= blockAgg.Tickets
+	agg.Revocations -= blockAgg.Revocations
+	return bucket.Put(windowAggKey(agg.Window), serializeWindowAggEntry(agg))
+}
+
+// WindowSize returns the number of blocks in each window tracked by the index.
+func (idx *WindowAggIndex) WindowSize() int64 {
+	return idx.windowSize
+}
+
+// WindowAggregates returns the aggregates for the windows in the passed range,
+// inclusive, in ascending order.  Windows which do not have any blocks
+// connected to the main chain are omitted from the result.
+//
+// This function is safe for concurrent access.
+func (idx *WindowAggIndex) WindowAggregates(startWindow, endWindow uint32) ([]*WindowAggregate, error) {
+	var aggs []*WindowAggregate
+	err := idx.db.View(func(dbTx database.Tx) error {
+		// The index does not exist while it is being dropped or rebuilt
+		// while the chain is running.
+		bucket := dbTx.Metadata().Bucket(windowAggIndexKey)
+		if bucket == nil {
+			return nil
+		}
+		for window := startWindow; window <= endWindow; window++ {
+			agg, err := dbFetchWindowAggEntry(bucket, window)
+			if err != nil {
+				return err
+			}
+			if agg != nil {
+				aggs = append(aggs, agg)
+			}
+
+			// Prevent overflow when the end of the range is the
+			// maximum window.
+			if window == ^uint32(0) {
+				break
+			}
+		}
+		return nil
+	})
+	return aggs, err
+}
+
+// NewWindowAggIndex returns a new instance of an indexer that is used to
+// maintain aggregate information about each stake difficulty adjustment window
+// of the main chain.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewWindowAggIndex(db database.DB, chainParams *chaincfg.Params) *WindowAggIndex {
+	return &WindowAggIndex{
+		db:           db,
+		chainParams:  chainParams,
+		subsidyCache: blockchain.NewSubsidyCache(0, chainParams),
+		windowSize:   chainParams.StakeDiffWindowSize,
+	}
+}
+
+// DropWindowAggIndex drops the window aggregate index from the provided
+// database if it exists.
+func DropWindowAggIndex(db database.DB) error {
+	return dropIndex(db, windowAggIndexKey, windowAggIndexName, nil, nil)
+}
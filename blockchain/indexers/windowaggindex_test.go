@@ -0,0 +1,110 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"reflect"
+	"testing"
+)
+
+// windowAggBucket provides a mock window aggregate index database bucket by
+// implementing the internalBucket interface.
+type windowAggBucket struct {
+	entries map[string][]byte
+}
+
+// Get returns the value associated with the key from the mock bucket.
+//
+// This is part of the internalBucket interface.
+func (b *windowAggBucket) Get(key []byte) []byte {
+	return b.entries[string(key)]
+}
+
+// Put stores the provided key/value pair to the mock bucket.
+//
+// This is part of the internalBucket interface.
+func (b *windowAggBucket) Put(key []byte, value []byte) error {
+	b.entries[string(key)] = value
+	return nil
+}
+
+// Delete removes the provided key from the mock bucket.
+//
+// This is part of the internalBucket interface.
+func (b *windowAggBucket) Delete(key []byte) error {
+	delete(b.entries, string(key))
+	return nil
+}
+
+// TestWindowAggSerialization ensures serializing and deserializing window
+// aggregate entries works as expected.
+func TestWindowAggSerialization(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		agg  WindowAggregate
+	}{
+		{
+			name: "empty",
+			agg:  WindowAggregate{Window: 0},
+		},
+		{
+			name: "typical window",
+			agg: WindowAggregate{
+				Window:       1234,
+				NumBlocks:    144,
+				Bits:         0x1b01ffff,
+				SBits:        9812345678,
+				TotalFees:    123456789,
+				WorkSubsidy:  4402513957,
+				StakeSubsidy: 1321742306,
+				TaxSubsidy:   440251395,
+				Votes:        720,
+				Tickets:      2880,
+				Revocations:  3,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		serialized := serializeWindowAggEntry(&test.agg)
+		if len(serialized) != windowAggEntrySize {
+			t.Errorf("%s: unexpected serialized size - got %d, want %d",
+				test.name, len(serialized), windowAggEntrySize)
+			continue
+		}
+
+		bucket := &windowAggBucket{entries: make(map[string][]byte)}
+		bucket.Put(windowAggKey(test.agg.Window), serialized)
+		agg, err := dbFetchWindowAggEntry(bucket, test.agg.Window)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*agg, test.agg) {
+			t.Errorf("%s: mismatched entry - got %+v, want %+v",
+				test.name, *agg, test.agg)
+			continue
+		}
+
+		// Ensure a missing entry returns a nil entry and error.
+		agg, err = dbFetchWindowAggEntry(bucket, test.agg.Window+1)
+		if agg != nil || err != nil {
+			t.Errorf("%s: unexpected result for missing entry - got "+
+				"(%v, %v)", test.name, agg, err)
+			continue
+		}
+
+		// Ensure truncated data is detected.
+		_, err = deserializeWindowAggEntry(test.agg.Window,
+			serialized[:len(serialized)-1])
+		if !isDeserializeErr(err) {
+			t.Errorf("%s: unexpected error for truncated data - "+
+				"got %v", test.name, err)
+			continue
+		}
+	}
+}
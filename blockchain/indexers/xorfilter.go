@@ -0,0 +1,225 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"errors"
+	"sort"
+)
+
+const (
+	// xorFilterMaxAttempts is the maximum number of seeds that are tried
+	// when constructing an xor filter before giving up.  Construction
+	// succeeds with a probability of roughly 88% per seed, so reaching this
+	// limit is effectively impossible for sets of distinct keys.
+	xorFilterMaxAttempts = 100
+
+	// xorFilterHeaderSize is the size of the header of a serialized xor
+	// filter.  It consists of the 8 byte seed followed by the 4 byte block
+	// length.
+	xorFilterHeaderSize = 8 + 4
+)
+
+// xorFilter is a static probabilistic set membership filter which answers
+// whether a key is possibly in the set it was constructed from with no false
+// negatives and a false positive rate of roughly 1/256 while only consuming
+// about 9.84 bits per key.
+//
+// See "Xor Filters: Faster and Smaller Than Bloom and Cuckoo Filters" by Graf
+// and Lemire for details about the construction.
+type xorFilter struct {
+	seed         uint64
+	blockLength  uint32
+	fingerprints []uint8
+}
+
+// xorMix is the finalizer of the 64-bit murmur3 hash function.  It is used to
+// mix the key with the filter seed.
+func xorMix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// xorReduce maps the passed hash uniformly to the range [0, n) without
+// division.
+func xorReduce(h, n uint32) uint32 {
+	return uint32((uint64(h) * uint64(n)) >> 32)
+}
+
+// xorRotl64 rotates the passed value left by the passed number of bits.
+func xorRotl64(h uint64, bits uint) uint64 {
+	return (h << bits) | (h >> (64 - bits))
+}
+
+// indexes returns the three fingerprint indexes associated with the passed
+// mixed hash.  There is one from each of the three blocks of the filter.
+func (f *xorFilter) indexes(h uint64) (uint32, uint32, uint32) {
+	h0 := xorReduce(uint32(h), f.blockLength)
+	h1 := xorReduce(uint32(xorRotl64(h, 21)), f.blockLength) + f.blockLength
+	h2 := xorReduce(uint32(xorRotl64(h, 42)), f.blockLength) + 2*f.blockLength
+	return h0, h1, h2
+}
+
+// xorFingerprint returns the fingerprint associated with the passed mixed
+// hash.
+func xorFingerprint(h uint64) uint8 {
+	return uint8(h ^ (h >> 32))
+}
+
+// Contains returns whether or not the passed key is possibly in the set the
+// filter was constructed from.  A false result means the key is definitely not
+// in the set.
+func (f *xorFilter) Contains(key uint64) bool {
+	h := xorMix(key + f.seed)
+	h0, h1, h2 := f.indexes(h)
+	return xorFingerprint(h) == f.fingerprints[h0]^f.fingerprints[h1]^
+		f.fingerprints[h2]
+}
+
+// uint64Sorter implements sort.Interface to allow a slice of uint64s to be
+// sorted.
+type uint64Sorter []uint64
+
+func (s uint64Sorter) Len() int           { return len(s) }
+func (s uint64Sorter) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s uint64Sorter) Less(i, j int) bool { return s[i] < s[j] }
+
+// newXorFilter constructs an xor filter for the passed keys.  Duplicate keys
+// are permitted.  The passed slice is sorted in place.
+func newXorFilter(keys []uint64) (*xorFilter, error) {
+	// Construction fails for duplicate keys, so remove them first.
+	sort.Sort(uint64Sorter(keys))
+	unique := keys[:0]
+	for i, key := range keys {
+		if i == 0 || key != keys[i-1] {
+			unique = append(unique, key)
+		}
+	}
+	keys = unique
+
+	capacity := 32 + uint32(len(keys)) + uint32(len(keys))*23/100 + 1
+	capacity = capacity / 3 * 3
+	f := &xorFilter{
+		blockLength:  capacity / 3,
+		fingerprints: make([]uint8, capacity),
+	}
+
+	type keyIndex struct {
+		hash  uint64
+		index uint32
+	}
+	xorMasks := make([]uint64, capacity)
+	counts := make([]uint32, capacity)
+	queue := make([]uint32, 0, capacity)
+	stack := make([]keyIndex, 0, len(keys))
+	seed := uint64(0x9e3779b97f4a7c15)
+	for attempt := 0; attempt < xorFilterMaxAttempts; attempt++ {
+		seed = xorMix(seed + 0x9e3779b97f4a7c15)
+		f.seed = seed
+		for i := range xorMasks {
+			xorMasks[i] = 0
+			counts[i] = 0
+		}
+		queue = queue[:0]
+		stack = stack[:0]
+
+		// Map every key to its three locations.
+		for _, key := range keys {
+			h := xorMix(key + f.seed)
+			h0, h1, h2 := f.indexes(h)
+			xorMasks[h0] ^= h
+			counts[h0]++
+			xorMasks[h1] ^= h
+			counts[h1]++
+			xorMasks[h2] ^= h
+			counts[h2]++
+		}
+
+		// Repeatedly peel off locations that only have a single key
+		// mapped to them.
+		for i, count := range counts {
+			if count == 1 {
+				queue = append(queue, uint32(i))
+			}
+		}
+		for len(queue) > 0 {
+			index := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			if counts[index] != 1 {
+				continue
+			}
+			h := xorMasks[index]
+			stack = append(stack, keyIndex{hash: h, index: index})
+			h0, h1, h2 := f.indexes(h)
+			for _, i := range [3]uint32{h0, h1, h2} {
+				xorMasks[i] ^= h
+				counts[i]--
+				if counts[i] == 1 {
+					queue = append(queue, i)
+				}
+			}
+		}
+
+		// Try again with a different seed when not every key could be
+		// peeled.
+		if len(stack) != len(keys) {
+			continue
+		}
+
+		// Assign the fingerprints in the reverse order the keys were
+		// peeled so each key is the last to claim one of its locations.
+		for i := len(stack) - 1; i >= 0; i-- {
+			ki := stack[i]
+			h0, h1, h2 := f.indexes(ki.hash)
+			f.fingerprints[ki.index] = 0
+			f.fingerprints[ki.index] = xorFingerprint(ki.hash) ^
+				f.fingerprints[h0] ^ f.fingerprints[h1] ^
+				f.fingerprints[h2]
+		}
+		return f, nil
+	}
+
+	return nil, errors.New("unable to construct xor filter")
+}
+
+// serialize returns the serialization of the filter.
+//
+// The serialized format is:
+//
+//   <seed><block length><fingerprints>
+//
+//   Field           Type              Size
+//   seed            uint64            8 bytes
+//   block length    uint32            4 bytes
+//   fingerprints    []uint8           3 * block length bytes
+func (f *xorFilter) serialize() []byte {
+	serialized := make([]byte, xorFilterHeaderSize+len(f.fingerprints))
+	byteOrder.PutUint64(serialized[0:8], f.seed)
+	byteOrder.PutUint32(serialized[8:12], f.blockLength)
+	copy(serialized[xorFilterHeaderSize:], f.fingerprints)
+	return serialized
+}
+
+// deserializeXorFilter decodes the passed serialized xor filter.
+func deserializeXorFilter(serialized []byte) (*xorFilter, error) {
+	if len(serialized) < xorFilterHeaderSize {
+		return nil, errDeserialize("unexpected end of data")
+	}
+	f := &xorFilter{
+		seed:        byteOrder.Uint64(serialized[0:8]),
+		blockLength: byteOrder.Uint32(serialized[8:12]),
+	}
+	fingerprints := serialized[xorFilterHeaderSize:]
+	if uint64(len(fingerprints)) != 3*uint64(f.blockLength) {
+		return nil, errDeserialize("unexpected xor filter size")
+	}
+	f.fingerprints = make([]uint8, len(fingerprints))
+	copy(f.fingerprints, fingerprints)
+	return f, nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestXorFilter ensures xor filters contain every key they were constructed
+// from, have a reasonable false positive rate, and survive a serialization
+// round trip.
+func TestXorFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		numKeys int
+	}{
+		{name: "empty", numKeys: 0},
+		{name: "single", numKeys: 1},
+		{name: "small", numKeys: 100},
+		{name: "large", numKeys: 50000},
+	}
+
+	rng := rand.New(rand.NewSource(0))
+	for _, test := range tests {
+		keys := make([]uint64, test.numKeys)
+		members := make(map[uint64]struct{}, test.numKeys)
+		for i := range keys {
+			keys[i] = uint64(rng.Int63())<<1 | uint64(rng.Int63n(2))
+			members[keys[i]] = struct{}{}
+		}
+
+		// Include duplicates to ensure they are handled.
+		if len(keys) > 0 {
+			keys = append(keys, keys[0])
+		}
+
+		f, err := newXorFilter(keys)
+		if err != nil {
+			t.Errorf("%s: unexpected error constructing filter: %v",
+				test.name, err)
+			continue
+		}
+
+		serialized := f.serialize()
+		f2, err := deserializeXorFilter(serialized)
+		if err != nil {
+			t.Errorf("%s: unexpected error deserializing filter: %v",
+				test.name, err)
+			continue
+		}
+
+		for key := range members {
+			if !f.Contains(key) || !f2.Contains(key) {
+				t.Errorf("%s: filter does not contain key %x",
+					test.name, key)
+				break
+			}
+		}
+
+		// Ensure the false positive rate is roughly 1/256.
+		const numProbes = 100000
+		var falsePositives int
+		for i := 0; i < numProbes; i++ {
+			key := uint64(rng.Int63())<<1 | uint64(rng.Int63n(2))
+			if _, ok := members[key]; ok {
+				continue
+			}
+			if f2.Contains(key) {
+				falsePositives++
+			}
+		}
+		if falsePositives > numProbes/100 {
+			t.Errorf("%s: unexpected false positive rate -- got %d "+
+				"of %d", test.name, falsePositives, numProbes)
+		}
+	}
+
+	// Ensure truncated and malformed filters are rejected.
+	f, err := newXorFilter([]uint64{1, 2, 3})
+	if err != nil {
+		t.Fatalf("unexpected error constructing filter: %v", err)
+	}
+	serialized := f.serialize()
+	if _, err := deserializeXorFilter(serialized[:xorFilterHeaderSize-1]); err == nil {
+		t.Error("deserialize of truncated header did not fail")
+	}
+	if _, err := deserializeXorFilter(serialized[:len(serialized)-1]); err == nil {
+		t.Error("deserialize of truncated fingerprints did not fail")
+	}
+}
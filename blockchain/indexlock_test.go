@@ -0,0 +1,67 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestQueriesDuringBlockProcessing ensures queries which only need the block
+// index, the best chain state, or the checkpoints do not wait for the chain
+// lock, which is held for writes while blocks are processed.
+func TestQueriesDuringBlockProcessing(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	bc := newFakeChain(params)
+	node := genesisBlockNode(params)
+	bc.bestNode = node
+	bc.index[node.hash] = node
+	bc.depNodes = make(map[chainhash.Hash][]*blockNode)
+	bc.stateSnapshot = newBestState(node, 0, 1, 1, 0)
+
+	// Simulate processing a block for the duration of the queries.
+	bc.chainLock.Lock()
+	defer bc.chainLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if exists, err := bc.HaveBlock(&node.hash); err != nil || !exists {
+			t.Errorf("HaveBlock: got %v (%v), want true", exists, err)
+		}
+		locator, err := bc.LatestBlockLocator()
+		if err != nil || len(locator) != 1 || *locator[0] != node.hash {
+			t.Errorf("LatestBlockLocator: unexpected locator %v (%v)",
+				locator, err)
+		}
+		locator = bc.BlockLocatorFromHash(&node.hash)
+		if len(locator) != 1 || *locator[0] != node.hash {
+			t.Errorf("BlockLocatorFromHash: unexpected locator %v",
+				locator)
+		}
+		if tip := bc.NewChainView().Tip(); *tip.Hash != node.hash {
+			t.Errorf("NewChainView: unexpected tip %v", tip.Hash)
+		}
+		if supply := bc.TotalSubsidy(); supply != 0 {
+			t.Errorf("TotalSubsidy: unexpected subsidy %d", supply)
+		}
+		bc.CoinSupply()
+		if mode := bc.CheckpointMode(); mode != CheckpointModeEnforce {
+			t.Errorf("CheckpointMode: unexpected mode %v", mode)
+		}
+		bc.LatestCheckpoint()
+		bc.Checkpoints()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queries are waiting for the chain lock")
+	}
+}
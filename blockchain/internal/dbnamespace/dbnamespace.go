@@ -34,6 +34,43 @@ var (
 	SpendJournalBucketName = []byte("spendjournal")
 
 	// UtxoSetBucketName is the name of the db bucket used to house the
-	// unspent transaction output set.
+	// legacy unspent transaction output set which contains an entry per
+	// transaction.  It only exists in databases which have not finished
+	// migrating to the version 2 utxo set.
 	UtxoSetBucketName = []byte("utxoset")
+
+	// UtxoSetV2BucketName is the name of the db bucket used to house the
+	// unspent transaction output set which contains an entry per output.
+	UtxoSetV2BucketName = []byte("utxosetv2")
+
+	// UtxoSetStateKeyName is the name of the db key used to store the block
+	// the utxo set represents, which lags behind the best chain state until
+	// the utxo cache is flushed.
+	UtxoSetStateKeyName = []byte("utxosetstate")
+
+	// CoinSupplyKeyName is the name of the db key used to store the coin
+	// supply of the main chain split by the source of the subsidy.
+	CoinSupplyKeyName = []byte("coinsupply")
+
+	// MigrationsBucketName is the name of the db bucket used to house the
+	// progress of background database migrations.
+	MigrationsBucketName = []byte("migrations")
+
+	// TreasuryBucketName is the name of the db bucket used to house the
+	// state of the treasury as of each block of the main chain.
+	TreasuryBucketName = []byte("treasury")
+
+	// BlockAnnotationsBucketName is the name of the db bucket used to house
+	// the annotations attached to blocks.  It contains a nested bucket per
+	// annotated block keyed by the block hash.
+	BlockAnnotationsBucketName = []byte("blockannotations")
+
+	// InvalidatedBlocksBucketName is the name of the db bucket used to house
+	// the hashes of the blocks which were manually invalidated.
+	InvalidatedBlocksBucketName = []byte("invalidatedblocks")
+
+	// ChainWorkBucketName is the name of the db bucket used to house the
+	// total work of the chain up to and including each block which has been
+	// part of the main chain.
+	ChainWorkBucketName = []byte("chainwork")
 )
@@ -14,10 +14,13 @@ run.
 package blockchain
 
 import (
+	"math/big"
 	"sort"
 	"time"
 
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
 	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
 	"github.com/decred/dcrd/wire"
 )
 
@@ -52,3 +55,29 @@ func (b *BlockChain) TstCheckBlockHeaderContext(header *wire.BlockHeader, prevNo
 func TstNewBlockNode(blockHeader *wire.BlockHeader, blockHash *chainhash.Hash, height int64, ticketsSpent []chainhash.Hash, ticketsRevoked []chainhash.Hash, voteBits []VoteVersionTuple) *blockNode {
 	return newBlockNode(blockHeader, blockHash, height, ticketsSpent, ticketsRevoked, voteBits)
 }
+
+// TstStoredChainWork returns the total work of the chain up to and including
+// the block with the passed hash as stored in the database, or nil when it is
+// not stored.
+func (b *BlockChain) TstStoredChainWork(hash *chainhash.Hash) (*big.Int, error) {
+	var workSum *big.Int
+	err := b.db.View(func(dbTx database.Tx) error {
+		workSum = dbFetchChainWork(dbTx, hash)
+		return nil
+	})
+	return workSum, err
+}
+
+// TstRemoveStoredChainWork removes the total work of all blocks from the
+// database and queues the background migration which stores it again as
+// happens for databases created before it was stored.
+func (b *BlockChain) TstRemoveStoredChainWork() error {
+	err := b.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		return meta.DeleteBucket(dbnamespace.ChainWorkBucketName)
+	})
+	if err != nil {
+		return err
+	}
+	return b.prepareChainWorkMigration()
+}
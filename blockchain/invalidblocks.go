@@ -0,0 +1,485 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+)
+
+// maxKnownInvalidBlocks is the maximum number of blocks which failed validation
+// that are remembered.  The oldest blocks are evicted first.
+const maxKnownInvalidBlocks = 1000
+
+// knownInvalidBlocks houses a bounded cache of the hashes of blocks which
+// failed validation along with the reason they failed.  It allows repeated
+// attempts to process the same invalid block, or blocks which build on it, to
+// be rejected without validating them again.
+//
+// Blocks which were manually invalidated are also housed in the cache, however,
+// they are never evicted.
+type knownInvalidBlocks struct {
+	mtx     sync.Mutex
+	reasons map[chainhash.Hash]RuleError
+	order   []chainhash.Hash
+	manual  map[chainhash.Hash]struct{}
+}
+
+// newKnownInvalidBlocks returns a new empty cache of known invalid blocks.
+func newKnownInvalidBlocks() *knownInvalidBlocks {
+	return &knownInvalidBlocks{
+		reasons: make(map[chainhash.Hash]RuleError),
+		manual:  make(map[chainhash.Hash]struct{}),
+	}
+}
+
+// isPermanentRuleError returns whether or not the passed error is a rule error
+// that will always cause the block it was returned for to be rejected.
+//
+// Only failures which are determined by the block header and its ancestors are
+// permanent since the cache is keyed by the block hash, which does not commit
+// to the transactions of the block other than through the merkle roots in the
+// header.  Failures which depend on the transactions, such as a bad merkle root
+// or a duplicate transaction, may be caused by anybody relaying a mutated copy
+// of a valid block, so remembering them would reject the valid block as well.
+// Errors such as the block being a duplicate or an orphan, or its timestamp
+// being too far in the future, depend on the current state and are not
+// permanent either.
+func isPermanentRuleError(err error) (RuleError, bool) {
+	rerr, ok := err.(RuleError)
+	if !ok {
+		return RuleError{}, false
+	}
+
+	switch rerr.ErrorCode {
+	case ErrUnexpectedDifficulty, ErrHighHash, ErrInvalidTime,
+		ErrTimeTooOld, ErrBadCheckpoint, ErrBadAssumeValid,
+		ErrForkTooOld, ErrBlockVersionTooOld, ErrBadStakeVersion,
+		ErrBadBlockHeight, ErrInvalidAncestor:
+		return rerr, true
+	}
+	return RuleError{}, false
+}
+
+// add records that the block with the passed hash failed validation for the
+// passed reason.
+//
+// This function is safe for concurrent access.
+func (c *knownInvalidBlocks) add(hash *chainhash.Hash, reason RuleError) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.reasons[*hash]; ok {
+		return
+	}
+	c.reasons[*hash] = reason
+	c.order = append(c.order, *hash)
+
+	// Evict the oldest blocks once the maximum is exceeded.
+	if len(c.order) > maxKnownInvalidBlocks {
+		numEvict := len(c.order) - maxKnownInvalidBlocks
+		for _, evictHash := range c.order[:numEvict] {
+			delete(c.reasons, evictHash)
+		}
+		c.order = append(c.order[:0], c.order[numEvict:]...)
+	}
+}
+
+// addManual records that the block with the passed hash was manually
+// invalidated.  Unlike blocks which failed validation, it is not evicted until
+// it is removed.
+//
+// This function is safe for concurrent access.
+func (c *knownInvalidBlocks) addManual(hash *chainhash.Hash) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.removeLocked(hash)
+	str := fmt.Sprintf("block %v was manually invalidated", hash)
+	c.reasons[*hash] = ruleError(ErrInvalidatedBlock, str)
+	c.manual[*hash] = struct{}{}
+}
+
+// removeLocked forgets that the block with the passed hash is invalid.
+//
+// This function MUST be called with the cache lock held.
+func (c *knownInvalidBlocks) removeLocked(hash *chainhash.Hash) {
+	if _, ok := c.reasons[*hash]; !ok {
+		return
+	}
+	delete(c.reasons, *hash)
+	if _, ok := c.manual[*hash]; ok {
+		delete(c.manual, *hash)
+		return
+	}
+	for i := range c.order {
+		if c.order[i] == *hash {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// remove forgets that the block with the passed hash is invalid regardless of
+// whether it failed validation or was manually invalidated.  It returns whether
+// or not it was manually invalidated.
+//
+// This function is safe for concurrent access.
+func (c *knownInvalidBlocks) remove(hash *chainhash.Hash) bool {
+	c.mtx.Lock()
+	_, manual := c.manual[*hash]
+	c.removeLocked(hash)
+	c.mtx.Unlock()
+	return manual
+}
+
+// lookup returns the reason the block with the passed hash failed validation
+// and whether or not it is known to be invalid.
+//
+// This function is safe for concurrent access.
+func (c *knownInvalidBlocks) lookup(hash *chainhash.Hash) (RuleError, bool) {
+	c.mtx.Lock()
+	reason, ok := c.reasons[*hash]
+	c.mtx.Unlock()
+	return reason, ok
+}
+
+// recordInvalidBlock remembers that the block with the passed hash failed
+// validation when the passed error is a permanent rule error.  Blocks that are
+// only being tested for validity via the dry run flag are not remembered since
+// they have not been received from the network.
+func (b *BlockChain) recordInvalidBlock(hash *chainhash.Hash, err error, flags BehaviorFlags) {
+	if flags&BFDryRun == BFDryRun {
+		return
+	}
+	if rerr, ok := isPermanentRuleError(err); ok {
+		b.invalidBlocks.add(hash, rerr)
+	}
+}
+
+// IsKnownInvalidBlock returns whether or not the block with the passed hash
+// recently failed validation, was manually invalidated, or builds on a block
+// which did either.  Keep in mind that only a limited number of blocks which
+// failed validation are remembered, so this function must not be used as an
+// absolute way to test if a block is invalid.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsKnownInvalidBlock(hash *chainhash.Hash) bool {
+	_, ok := b.invalidBlocks.lookup(hash)
+	return ok
+}
+
+// -----------------------------------------------------------------------------
+// The blocks which were manually invalidated are stored in the invalidated
+// blocks bucket keyed by their hashes so they are remembered across restarts.
+// The values are empty.
+// -----------------------------------------------------------------------------
+
+// dbPutInvalidatedBlock uses an existing database transaction to record that
+// the passed block was manually invalidated.
+func dbPutInvalidatedBlock(dbTx database.Tx, hash *chainhash.Hash) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+		dbnamespace.InvalidatedBlocksBucketName)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(hash[:], nil)
+}
+
+// dbRemoveInvalidatedBlock uses an existing database transaction to remove the
+// record that the passed block was manually invalidated.  It is not an error if
+// there is no such record.
+func dbRemoveInvalidatedBlock(dbTx database.Tx, hash *chainhash.Hash) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.InvalidatedBlocksBucketName)
+	if bucket == nil {
+		return nil
+	}
+	return bucket.Delete(hash[:])
+}
+
+// loadInvalidatedBlocks loads the blocks which were manually invalidated from
+// the database into the cache of known invalid blocks.
+func (b *BlockChain) loadInvalidatedBlocks() error {
+	return b.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(
+			dbnamespace.InvalidatedBlocksBucketName)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var hash chainhash.Hash
+			copy(hash[:], k)
+			b.invalidBlocks.addManual(&hash)
+			return nil
+		})
+	})
+}
+
+// fetchKnownNode returns the block node for the passed block when it is either
+// part of the main chain or a side chain block in memory.  The returned node is
+// nil when the block is a stored side chain block which is no longer in memory.
+// HashError is returned when the block is not known.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) fetchKnownNode(hash *chainhash.Hash) (*blockNode, error) {
+	if node, ok := b.index[*hash]; ok {
+		return node, nil
+	}
+
+	var height int64
+	var inMainChain, exists bool
+	err := b.db.View(func(dbTx database.Tx) error {
+		if dbMainChainHasBlock(dbTx, hash) {
+			inMainChain = true
+			var err error
+			height, err = dbFetchHeightByHash(dbTx, hash)
+			return err
+		}
+
+		var err error
+		exists, err = dbTx.HasBlock(hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if inMainChain {
+		return b.ancestorNode(b.bestNode, height)
+	}
+	if !exists {
+		return nil, HashError(hash.String())
+	}
+	return nil, nil
+}
+
+// descendantNodes returns all of the nodes in memory which build on the passed
+// node.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func descendantNodes(node *blockNode) []*blockNode {
+	descendants := append([]*blockNode(nil), node.children...)
+	for i := 0; i < len(descendants); i++ {
+		descendants = append(descendants, descendants[i].children...)
+	}
+	return descendants
+}
+
+// isValidSideChain returns whether or not the side chain ending at the passed
+// node may become the main chain after the main chain is disconnected back to
+// the passed base node.  That is to say all of the side chain blocks must be
+// available in the side chain cache, none of them may be known to be invalid,
+// and the side chain must fork from the main chain at or before the base node.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) isValidSideChain(node, base *blockNode) bool {
+	n := node
+	for !n.inMainChain {
+		if _, ok := b.invalidBlocks.lookup(&n.hash); ok {
+			return false
+		}
+		b.blockCacheLock.RLock()
+		_, ok := b.blockCache[n.hash]
+		b.blockCacheLock.RUnlock()
+		if !ok || n.parent == nil {
+			return false
+		}
+		n = n.parent
+	}
+	return n.height <= base.height
+}
+
+// checkAttachNodes ensures none of the passed nodes which are to be attached to
+// the main chain is known to be invalid.  The nodes include every side chain
+// ancestor of the new tip, so checking all of them also rejects blocks which
+// build on a manually invalidated block after the record of the descendant
+// itself was evicted from the cache or was never added because the block was
+// not in memory when its ancestor was invalidated.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) checkAttachNodes(attachNodes *list.List) error {
+	tipElem := attachNodes.Back()
+	for e := attachNodes.Front(); e != nil; e = e.Next() {
+		n := e.Value.(*blockNode)
+		reason, ok := b.invalidBlocks.lookup(&n.hash)
+		if !ok {
+			continue
+		}
+		if e == tipElem {
+			return reason
+		}
+		tip := tipElem.Value.(*blockNode)
+		str := fmt.Sprintf("block %v builds on block %v which is known "+
+			"to be invalid: %v", tip.hash, n.hash, reason)
+		return ruleError(ErrInvalidAncestor, str)
+	}
+	return nil
+}
+
+// reorganizeToBestChain reorganizes the chain to the valid side chain with the
+// most cumulative work when it has more work than the passed main chain node.
+// Otherwise, the main chain is disconnected back to the passed node, if needed.
+// It is used to select the best chain after blocks are manually invalidated or
+// reconsidered.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) reorganizeToBestChain(base *blockNode) error {
+	var bestTip *blockNode
+	for _, node := range b.index {
+		if node.inMainChain || node.workSum.Cmp(base.workSum) <= 0 {
+			continue
+		}
+		if bestTip != nil && node.workSum.Cmp(bestTip.workSum) <= 0 {
+			continue
+		}
+		if b.isValidSideChain(node, base) {
+			bestTip = node
+		}
+	}
+
+	// Fall back to disconnecting the main chain back to the base node when
+	// the side chain turns out to be invalid.  The chain is not modified
+	// when the reorganize fails due to a rule violation.
+	if bestTip != nil {
+		detachNodes, attachNodes, err := b.getReorganizeNodes(bestTip)
+		if err != nil {
+			return err
+		}
+		err = b.reorganizeChain(detachNodes, attachNodes, BFNone)
+		if _, ok := err.(RuleError); !ok {
+			return err
+		}
+		log.Warnf("Unable to reorganize to side chain block %v: %v",
+			bestTip.hash, err)
+	}
+
+	detachNodes := list.New()
+	for n := b.bestNode; n != base; {
+		if n == nil {
+			return AssertError(fmt.Sprintf("block %v is not an "+
+				"ancestor of the best block", base.hash))
+		}
+		detachNodes.PushBack(n)
+		var err error
+		n, err = b.getPrevNodeFromNode(n)
+		if err != nil {
+			return err
+		}
+	}
+	if detachNodes.Len() == 0 {
+		return nil
+	}
+	return b.reorganizeChain(detachNodes, list.New(), BFNone)
+}
+
+// InvalidateBlock manually invalidates the passed block along with all of the
+// blocks which build on it.  When the block is part of the main chain, the
+// chain is reorganized to the valid side chain with the most cumulative work,
+// or disconnected back to the parent of the block when no side chain has more
+// work than the parent.  Blocks which build on an invalidated block are
+// rejected until it is reconsidered via ReconsiderBlock.  Invalidated blocks
+// are remembered across restarts.
+//
+// HashError is returned when the block is not known.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) InvalidateBlock(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if hash.IsEqual(b.chainParams.GenesisHash) {
+		return fmt.Errorf("the genesis block can not be invalidated")
+	}
+	node, err := b.fetchKnownNode(hash)
+	if err != nil {
+		return err
+	}
+
+	// Disconnect the block from the main chain as needed.  The block is
+	// marked invalid first so the chain is not reorganized to a side chain
+	// which builds on it.
+	b.invalidBlocks.addManual(hash)
+	if node != nil && node.inMainChain {
+		base, err := b.getPrevNodeFromNode(node)
+		if err == nil {
+			err = b.reorganizeToBestChain(base)
+		}
+		if err != nil {
+			b.invalidBlocks.remove(hash)
+			return err
+		}
+	}
+
+	// Reject the blocks in memory which build on the block as well.
+	if node != nil {
+		for _, n := range descendantNodes(node) {
+			str := fmt.Sprintf("block %v builds on block %v which "+
+				"was manually invalidated", n.hash, hash)
+			b.invalidBlocks.add(&n.hash, ruleError(ErrInvalidAncestor,
+				str))
+		}
+	}
+
+	log.Infof("Block %v was manually invalidated", hash)
+	return b.db.Update(func(dbTx database.Tx) error {
+		return dbPutInvalidatedBlock(dbTx, hash)
+	})
+}
+
+// ReconsiderBlock removes the invalidity of the passed block, along with its
+// ancestors and the blocks in memory which build on it, regardless of whether
+// they were manually invalidated or failed validation.  The chain is then
+// reorganized to the side chain with the most cumulative work when it has more
+// work than the main chain, which validates its blocks again.
+//
+// HashError is returned when the block is not known.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReconsiderBlock(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node, err := b.fetchKnownNode(hash)
+	if err != nil {
+		return err
+	}
+
+	// Forget the invalidity of the block, its side chain ancestors, and its
+	// descendants.
+	reconsidered := []chainhash.Hash{*hash}
+	if node != nil {
+		for n := node.parent; n != nil && !n.inMainChain; n = n.parent {
+			reconsidered = append(reconsidered, n.hash)
+		}
+		for _, n := range descendantNodes(node) {
+			reconsidered = append(reconsidered, n.hash)
+		}
+	}
+	var manual []chainhash.Hash
+	for i := range reconsidered {
+		if b.invalidBlocks.remove(&reconsidered[i]) {
+			manual = append(manual, reconsidered[i])
+		}
+	}
+	err = b.db.Update(func(dbTx database.Tx) error {
+		for i := range manual {
+			err := dbRemoveInvalidatedBlock(dbTx, &manual[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Block %v was reconsidered", hash)
+	return b.reorganizeToBestChain(b.bestNode)
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"container/list"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestKnownInvalidBlocks ensures the known invalid block cache only remembers
+// rule errors which are determined by the block header and evicts the oldest
+// blocks once it is full.
+func TestKnownInvalidBlocks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{name: "high hash", err: ruleError(ErrHighHash, ""), permanent: true},
+		{name: "unexpected difficulty", err: ruleError(ErrUnexpectedDifficulty, ""), permanent: true},
+		{name: "bad checkpoint", err: ruleError(ErrBadCheckpoint, ""), permanent: true},
+		{name: "invalid ancestor", err: ruleError(ErrInvalidAncestor, ""), permanent: true},
+		{name: "bad merkle root", err: ruleError(ErrBadMerkleRoot, ""), permanent: false},
+		{name: "duplicate tx", err: ruleError(ErrDuplicateTx, ""), permanent: false},
+		{name: "block too big", err: ruleError(ErrBlockTooBig, ""), permanent: false},
+		{name: "duplicate", err: ruleError(ErrDuplicateBlock, ""), permanent: false},
+		{name: "orphan", err: ruleError(ErrMissingParent, ""), permanent: false},
+		{name: "time too new", err: ruleError(ErrTimeTooNew, ""), permanent: false},
+		{name: "known invalid", err: ruleError(ErrKnownInvalidBlock, ""), permanent: false},
+		{name: "not a rule error", err: AssertError("test"), permanent: false},
+	}
+	for _, test := range tests {
+		if _, ok := isPermanentRuleError(test.err); ok != test.permanent {
+			t.Errorf("%s: unexpected permanent result -- got %v, want %v",
+				test.name, ok, test.permanent)
+		}
+	}
+
+	cache := newKnownInvalidBlocks()
+	hashForIndex := func(i int) *chainhash.Hash {
+		var hash chainhash.Hash
+		hash[0] = byte(i)
+		hash[1] = byte(i >> 8)
+		return &hash
+	}
+	for i := 0; i < maxKnownInvalidBlocks+10; i++ {
+		cache.add(hashForIndex(i), ruleError(ErrBadMerkleRoot, "bad"))
+	}
+
+	// Ensure the oldest blocks were evicted.
+	for i := 0; i < 10; i++ {
+		if _, ok := cache.lookup(hashForIndex(i)); ok {
+			t.Errorf("block %d was not evicted", i)
+		}
+	}
+	for i := 10; i < maxKnownInvalidBlocks+10; i++ {
+		reason, ok := cache.lookup(hashForIndex(i))
+		if !ok {
+			t.Errorf("block %d is not known to be invalid", i)
+			continue
+		}
+		if reason.ErrorCode != ErrBadMerkleRoot {
+			t.Errorf("block %d has unexpected reason %v", i,
+				reason.ErrorCode)
+		}
+	}
+}
+
+// TestKnownInvalidBlocksManual ensures manually invalidated blocks are never
+// evicted from the known invalid block cache and that blocks of either kind
+// can be removed from it.
+func TestKnownInvalidBlocksManual(t *testing.T) {
+	t.Parallel()
+
+	cache := newKnownInvalidBlocks()
+	hashForIndex := func(i int) *chainhash.Hash {
+		var hash chainhash.Hash
+		hash[0] = byte(i)
+		hash[1] = byte(i >> 8)
+		hash[2] = 1
+		return &hash
+	}
+	manualHash := hashForIndex(0)
+	cache.addManual(manualHash)
+	for i := 1; i < maxKnownInvalidBlocks+10; i++ {
+		cache.add(hashForIndex(i), ruleError(ErrBadMerkleRoot, "bad"))
+	}
+
+	// Ensure the manually invalidated block was not evicted and is not
+	// replaced by a later validation failure.
+	cache.add(manualHash, ruleError(ErrBadMerkleRoot, "bad"))
+	reason, ok := cache.lookup(manualHash)
+	if !ok || reason.ErrorCode != ErrInvalidatedBlock {
+		t.Fatalf("manually invalidated block has unexpected reason %v "+
+			"(known %v)", reason.ErrorCode, ok)
+	}
+	if _, ok := cache.lookup(hashForIndex(9)); ok {
+		t.Fatal("block 9 was not evicted")
+	}
+
+	// Ensure removing blocks reports whether they were manually
+	// invalidated and they are no longer known to be invalid.
+	if !cache.remove(manualHash) {
+		t.Fatal("remove: manually invalidated block not reported as such")
+	}
+	if cache.remove(hashForIndex(10)) {
+		t.Fatal("remove: failed block reported as manually invalidated")
+	}
+	for _, hash := range []*chainhash.Hash{manualHash, hashForIndex(10)} {
+		if _, ok := cache.lookup(hash); ok {
+			t.Fatalf("removed block %v is still known to be invalid",
+				hash)
+		}
+	}
+
+	// Ensure the removed block no longer counts towards the maximum.
+	cache.add(hashForIndex(0), ruleError(ErrBadMerkleRoot, "bad"))
+	if _, ok := cache.lookup(hashForIndex(11)); !ok {
+		t.Fatal("block 11 was evicted after a block was removed")
+	}
+}
+
+// TestCheckAttachNodes ensures side chains are rejected when any of their blocks
+// is known to be invalid, including blocks which only build on a manually
+// invalidated block without being known to be invalid themselves.
+func TestCheckAttachNodes(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.SimNetParams
+	bc := newFakeChain(params)
+	bc.invalidBlocks = newKnownInvalidBlocks()
+	attachNodes := list.New()
+	node := genesisBlockNode(params)
+	for i := int64(1); i <= 5; i++ {
+		node = newFakeNode(int32(i), i, node)
+		attachNodes.PushBack(node)
+	}
+	if err := bc.checkAttachNodes(attachNodes); err != nil {
+		t.Fatalf("checkAttachNodes: unexpected error: %v", err)
+	}
+
+	// Ensure the descendants of a manually invalidated block are rejected.
+	invalidated := attachNodes.Front().Next().Value.(*blockNode)
+	bc.invalidBlocks.addManual(&invalidated.hash)
+	err := bc.checkAttachNodes(attachNodes)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrInvalidAncestor {
+		t.Fatalf("checkAttachNodes: unexpected error -- got %v, want "+
+			"ErrInvalidAncestor", err)
+	}
+
+	// Ensure the reason is returned when the tip itself is invalid.
+	bc.invalidBlocks.remove(&invalidated.hash)
+	tip := attachNodes.Back().Value.(*blockNode)
+	bc.invalidBlocks.add(&tip.hash, ruleError(ErrBadStakeVersion, "bad"))
+	err = bc.checkAttachNodes(attachNodes)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrBadStakeVersion {
+		t.Fatalf("checkAttachNodes: unexpected error -- got %v, want "+
+			"ErrBadStakeVersion", err)
+	}
+}
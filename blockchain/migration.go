@@ -0,0 +1,144 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
+	"github.com/decred/dcrd/database"
+)
+
+// dbMigration describes a database upgrade which is performed incrementally in
+// the background while the chain is in use, as opposed to the upgrades which
+// must complete before the chain is loaded.  This allows upgrades which touch
+// large portions of the database, such as changes to the format of the utxo set
+// or the optional indexes, to proceed without delaying startup.
+//
+// The chain code must be able to handle the database in every intermediate
+// state of the migration since each step is committed independently.
+type dbMigration struct {
+	// name uniquely identifies the migration.  It is used as the key for
+	// the persisted progress of the migration.
+	name string
+
+	// step performs the next increment of the migration given the progress
+	// returned by the previous step, which is nil when the migration has
+	// not been started yet.  It returns the updated progress along with
+	// whether or not the migration is complete.  The progress is persisted
+	// in the same database transaction, which allows the migration to be
+	// resumed after a restart.
+	step func(dbTx database.Tx, progress []byte) ([]byte, bool, error)
+
+	// finish is invoked in the same database transaction as the final step
+	// once the migration is complete.  It may be nil.
+	finish func(dbTx database.Tx) error
+}
+
+// -----------------------------------------------------------------------------
+// The progress of background database migrations is stored in a bucket which
+// contains an entry for each migration that has been started but not finished.
+// The key is the name of the migration and the value is the migration specific
+// progress returned by its last step.
+// -----------------------------------------------------------------------------
+
+// dbFetchMigrationProgress uses an existing database transaction to fetch the
+// progress of the migration with the provided name.  Nil is returned when the
+// migration has not been started.
+func dbFetchMigrationProgress(dbTx database.Tx, name string) []byte {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.MigrationsBucketName)
+	if bucket == nil {
+		return nil
+	}
+
+	return bucket.Get([]byte(name))
+}
+
+// dbPutMigrationProgress uses an existing database transaction to store the
+// progress of the migration with the provided name.
+func dbPutMigrationProgress(dbTx database.Tx, name string, progress []byte) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+		dbnamespace.MigrationsBucketName)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put([]byte(name), progress)
+}
+
+// dbRemoveMigrationProgress uses an existing database transaction to remove
+// the progress of the migration with the provided name.
+func dbRemoveMigrationProgress(dbTx database.Tx, name string) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.MigrationsBucketName)
+	if bucket == nil {
+		return nil
+	}
+
+	return bucket.Delete([]byte(name))
+}
+
+// stepMigration performs the next step of the passed migration, persisting its
+// progress, and finishes it when it is complete.  It returns whether or not the
+// migration is complete.
+//
+// The chain lock is held while the step is performed so that it is atomic with
+// respect to the processing of blocks.
+func (b *BlockChain) stepMigration(m *dbMigration) (bool, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	var done bool
+	err := b.db.Update(func(dbTx database.Tx) error {
+		progress := dbFetchMigrationProgress(dbTx, m.name)
+		progress, isDone, err := m.step(dbTx, progress)
+		if err != nil {
+			return err
+		}
+		if !isDone {
+			return dbPutMigrationProgress(dbTx, m.name, progress)
+		}
+
+		if m.finish != nil {
+			if err := m.finish(dbTx); err != nil {
+				return err
+			}
+		}
+		done = true
+		return dbRemoveMigrationProgress(dbTx, m.name)
+	})
+	return done, err
+}
+
+// RunMigrations performs all of the pending background database migrations in
+// order.  It blocks until they are complete or the passed quit channel is
+// closed, in which case the migrations are resumed from their persisted
+// progress the next time the chain is loaded.  It is intended to be run in its
+// own goroutine and must only be called once.
+func (b *BlockChain) RunMigrations(quit <-chan struct{}) error {
+	for len(b.migrations) > 0 {
+		m := b.migrations[0]
+		log.Infof("Running background database migration %s", m.name)
+		for {
+			select {
+			case <-quit:
+				log.Infof("Background database migration %s "+
+					"interrupted; it will resume on the next "+
+					"start", m.name)
+				return nil
+			default:
+			}
+
+			done, err := b.stepMigration(m)
+			if err != nil {
+				return err
+			}
+			if done {
+				break
+			}
+		}
+		log.Infof("Background database migration %s is complete", m.name)
+		b.migrations = b.migrations[1:]
+	}
+
+	return nil
+}
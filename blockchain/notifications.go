@@ -7,7 +7,10 @@ package blockchain
 
 import (
 	"fmt"
+	"math/big"
 
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrutil"
 )
@@ -45,6 +48,10 @@ const (
 	// NTSpentAndMissedTickets indicates newly maturing tickets from a newly
 	// accepted block.
 	NTNewTickets
+
+	// NTStakeBoundary indicates a newly connected block crossed a stake
+	// boundary such as the start of a new ticket price interval.
+	NTStakeBoundary
 )
 
 // notificationTypeStrings is a map of notification types back to their constant
@@ -56,6 +63,7 @@ var notificationTypeStrings = map[NotificationType]string{
 	NTReorganization:        "NTReorganization",
 	NTSpentAndMissedTickets: "NTSpentAndMissedTickets",
 	NTNewTickets:            "NTNewTickets",
+	NTStakeBoundary:         "NTStakeBoundary",
 }
 
 // String returns the NotificationType in human-readable form.
@@ -67,10 +75,14 @@ func (n NotificationType) String() string {
 }
 
 // BlockAcceptedNtfnsData is the structure for data indicating information
-// about a block being accepted.
+// about a block being accepted.  WorkSum is the total work of the chain ending
+// with the block and BestWorkSum is the total work of the main chain once the
+// block was accepted.
 type BlockAcceptedNtfnsData struct {
 	OnMainChain bool
 	Block       *dcrutil.Block
+	WorkSum     *big.Int
+	BestWorkSum *big.Int
 }
 
 // ReorganizationNtfnsData is the structure for data indicating information
@@ -93,6 +105,60 @@ type TicketNotificationsData struct {
 	TicketsNew      []chainhash.Hash
 }
 
+// StakeBoundary identifies the kind of stake boundary crossed by a block.
+type StakeBoundary int
+
+// Constants for the kinds of stake boundaries.
+const (
+	// SBTicketPriceInterval indicates the block is the first block of a
+	// new ticket price interval.
+	SBTicketPriceInterval StakeBoundary = iota
+
+	// SBTicketsMatured indicates tickets matured and were added to the live
+	// ticket pool by the block.
+	SBTicketsMatured
+
+	// SBTicketsExpired indicates tickets expired and were removed from the
+	// live ticket pool by the block.
+	SBTicketsExpired
+)
+
+// stakeBoundaryStrings is a map of stake boundaries back to their constant
+// names for pretty printing.
+var stakeBoundaryStrings = map[StakeBoundary]string{
+	SBTicketPriceInterval: "SBTicketPriceInterval",
+	SBTicketsMatured:      "SBTicketsMatured",
+	SBTicketsExpired:      "SBTicketsExpired",
+}
+
+// String returns the StakeBoundary in human-readable form.
+func (sb StakeBoundary) String() string {
+	if s, ok := stakeBoundaryStrings[sb]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown Stake Boundary (%d)", int(sb))
+}
+
+// StakeBoundaryNtfnsData is the structure for data indicating a stake boundary
+// was crossed by a block connected to the main chain.  StakeDifficulty is the
+// ticket price in effect for the block and PoolSize is the size of the live
+// ticket pool once the block was connected.
+//
+// For SBTicketPriceInterval, PrevStakeDifficulty is the ticket price of the
+// previous interval and IntervalEndHeight is the height of the last block of
+// the new interval.  For SBTicketsMatured and SBTicketsExpired, Tickets are
+// the tickets which matured or expired.
+type StakeBoundaryNtfnsData struct {
+	Boundary            StakeBoundary
+	Hash                chainhash.Hash
+	Height              int64
+	StakeDifficulty     int64
+	PoolSize            int
+	PrevStakeDifficulty int64
+	IntervalEndHeight   int64
+	Tickets             []chainhash.Hash
+}
+
 // Notification defines notification that is sent to the caller via the callback
 // function provided during the call to New and consists of a notification type
 // as well as associated data that depends on the type as follows:
@@ -102,11 +168,51 @@ type TicketNotificationsData struct {
 //  - NTReorganization:        *ReorganizationNtfnsData
 //  - NTSpentAndMissedTickets: *TicketNotificationsData
 //  - NTNewTickets:            *TicketNotificationsData
+//  - NTStakeBoundary:         *StakeBoundaryNtfnsData
 type Notification struct {
 	Type NotificationType
 	Data interface{}
 }
 
+// stakeBoundaries returns the stake boundaries crossed by the block the passed
+// node represents along with the details of each.  The stake node of the node
+// and the header of its parent must be available.
+func stakeBoundaries(node *blockNode, stakeNode *stake.Node,
+	params *chaincfg.Params) []*StakeBoundaryNtfnsData {
+
+	newBoundary := func(boundary StakeBoundary) *StakeBoundaryNtfnsData {
+		return &StakeBoundaryNtfnsData{
+			Boundary:        boundary,
+			Hash:            node.hash,
+			Height:          node.height,
+			StakeDifficulty: node.header.SBits,
+			PoolSize:        stakeNode.PoolSize(),
+		}
+	}
+
+	var boundaries []*StakeBoundaryNtfnsData
+	if node.height > 0 && node.height%params.StakeDiffWindowSize == 0 {
+		ntfn := newBoundary(SBTicketPriceInterval)
+		if node.parent != nil {
+			ntfn.PrevStakeDifficulty = node.parent.header.SBits
+		}
+		ntfn.IntervalEndHeight = node.height + params.StakeDiffWindowSize - 1
+		boundaries = append(boundaries, ntfn)
+	}
+	if matured := stakeNode.NewTickets(); len(matured) > 0 {
+		ntfn := newBoundary(SBTicketsMatured)
+		ntfn.Tickets = matured
+		boundaries = append(boundaries, ntfn)
+	}
+	if expired := stakeNode.ExpiredByBlock(); len(expired) > 0 {
+		ntfn := newBoundary(SBTicketsExpired)
+		ntfn.Tickets = expired
+		boundaries = append(boundaries, ntfn)
+	}
+
+	return boundaries
+}
+
 // sendNotification sends a notification with the passed type and data if the
 // caller requested notifications by providing a callback function in the call
 // to New.
@@ -42,7 +42,8 @@ const (
 // blockExists determines whether a block with the given hash exists either in
 // the main chain or any side chains.
 //
-// This function MUST be called with the chain state lock held (for reads).
+// This function MUST be called with either the chain state lock or the block
+// index lock held (for reads).
 func (b *BlockChain) blockExists(hash *chainhash.Hash) (bool, error) {
 	// Check memory chain first (could be main chain or side chain blocks).
 	if _, ok := b.index[*hash]; ok {
@@ -105,6 +106,7 @@ func (b *BlockChain) processOrphans(hash *chainhash.Hash, flags BehaviorFlags) e
 			// Potentially accept the block into the block chain.
 			_, err := b.maybeAcceptBlock(orphan.block, flags)
 			if err != nil {
+				b.recordInvalidBlock(orphanHash, err, flags)
 				return err
 			}
 
@@ -142,6 +144,24 @@ func (b *BlockChain) ProcessBlock(block *dcrutil.Block, flags BehaviorFlags) (bo
 			blockHash, block.Height(), elapsedTime)
 	}()
 
+	// The block must not be known to have failed validation previously.
+	if reason, ok := b.invalidBlocks.lookup(blockHash); ok {
+		str := fmt.Sprintf("block %v is known to be invalid: %v",
+			blockHash, reason)
+		return false, false, ruleError(ErrKnownInvalidBlock, str)
+	}
+
+	// The block must not build on a block which is known to have failed
+	// validation.
+	prevHash := &block.MsgBlock().Header.PrevBlock
+	if _, ok := b.invalidBlocks.lookup(prevHash); ok {
+		str := fmt.Sprintf("block %v builds on block %v which is known "+
+			"to be invalid", blockHash, prevHash)
+		err := ruleError(ErrInvalidAncestor, str)
+		b.recordInvalidBlock(blockHash, err, flags)
+		return false, false, err
+	}
+
 	// The block must not already exist in the main chain or side chains.
 	exists, err := b.blockExists(blockHash)
 	if err != nil {
@@ -159,8 +179,11 @@ func (b *BlockChain) ProcessBlock(block *dcrutil.Block, flags BehaviorFlags) (bo
 	}
 
 	// Perform preliminary sanity checks on the block and its transactions.
-	err = checkBlockSanity(block, b.timeSource, flags, b.chainParams)
+	checksStart := time.Now()
+	err = checkBlockSanity(block, b.timeSource, b.clock, flags,
+		b.chainParams)
 	if err != nil {
+		b.recordInvalidBlock(blockHash, err, flags)
 		return false, false, err
 	}
 
@@ -171,19 +194,20 @@ func (b *BlockChain) ProcessBlock(block *dcrutil.Block, flags BehaviorFlags) (bo
 	// used to eat memory, and ensuring expected (versus claimed) proof of
 	// work requirements since the previous checkpoint are met.
 	blockHeader := &block.MsgBlock().Header
-	checkpointBlock, err := b.findPreviousCheckpoint()
+	checkpointHeader, err := b.findPreviousCheckpoint()
 	if err != nil {
 		return false, false, err
 	}
-	if checkpointBlock != nil {
+	if checkpointHeader != nil {
 		// Ensure the block timestamp is after the checkpoint timestamp.
-		checkpointHeader := &checkpointBlock.MsgBlock().Header
 		checkpointTime := checkpointHeader.Timestamp
 		if blockHeader.Timestamp.Before(checkpointTime) {
 			str := fmt.Sprintf("block %v has timestamp %v before "+
 				"last checkpoint timestamp %v", blockHash,
 				blockHeader.Timestamp, checkpointTime)
-			return false, false, ruleError(ErrCheckpointTimeTooOld, str)
+			err := ruleError(ErrCheckpointTimeTooOld, str)
+			b.recordInvalidBlock(blockHash, err, flags)
+			return false, false, err
 		}
 
 		if !fastAdd {
@@ -201,13 +225,16 @@ func (b *BlockChain) ProcessBlock(block *dcrutil.Block, flags BehaviorFlags) (bo
 				str := fmt.Sprintf("block target difficulty of %064x "+
 					"is too low when compared to the previous "+
 					"checkpoint", currentTarget)
-				return false, false, ruleError(ErrDifficultyTooLow, str)
+				err := ruleError(ErrDifficultyTooLow, str)
+				b.recordInvalidBlock(blockHash, err, flags)
+				return false, false, err
 			}
 		}
 	}
 
+	b.validationStats.Record(StageChecks, time.Since(checksStart))
+
 	// Handle orphan blocks.
-	prevHash := &blockHeader.PrevBlock
 	prevHashExists, err := b.blockExists(prevHash)
 	if err != nil {
 		return false, false, err
@@ -227,8 +254,12 @@ func (b *BlockChain) ProcessBlock(block *dcrutil.Block, flags BehaviorFlags) (bo
 	var onMainChain bool
 	onMainChain, err = b.maybeAcceptBlock(block, flags)
 	if err != nil {
+		b.recordInvalidBlock(blockHash, err, flags)
 		return false, false, err
 	}
+	if !dryRun {
+		b.validationStats.Record(StageTotal, time.Since(currentTime))
+	}
 
 	// Don't process any orphans or log when the dry run flag is set.
 	if !dryRun {
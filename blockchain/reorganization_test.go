@@ -370,3 +370,138 @@ func TestReorganization(t *testing.T) {
 	reorgTestShort(t)
 	reorgTestForced(t)
 }
+
+// loadReorgTestBlocks loads the serialized test blocks keyed by their heights
+// from the passed reorganization test data file.
+func loadReorgTestBlocks(t *testing.T, filename string) map[int64][]byte {
+	fi, err := os.Open(filepath.Join("testdata/", filename))
+	if err != nil {
+		t.Fatalf("failed to open test data %s: %v", filename, err)
+	}
+	defer fi.Close()
+
+	blocks := make(map[int64][]byte)
+	if err := gob.NewDecoder(bzip2.NewReader(fi)).Decode(&blocks); err != nil {
+		t.Fatalf("error decoding test blockchain %s: %v", filename, err)
+	}
+	return blocks
+}
+
+// reorgTestBlockHash returns the hash of the passed serialized test block.
+func reorgTestBlockHash(t *testing.T, serialized []byte) *chainhash.Hash {
+	bl, err := dcrutil.NewBlockFromBytes(serialized)
+	if err != nil {
+		t.Fatalf("NewBlockFromBytes error: %v", err)
+	}
+	return bl.Hash()
+}
+
+// TestInvalidateReconsiderBlock ensures manually invalidating blocks reorganizes
+// the chain away from them and rejects the blocks which build on them, and that
+// reconsidering them reorganizes the chain back to the chain with the most
+// cumulative work.
+func TestInvalidateReconsiderBlock(t *testing.T) {
+	chain, teardownFunc, err := chainSetup("invalidateblockunittest",
+		simNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Ensure the genesis block and unknown blocks can not be invalidated.
+	if err := chain.InvalidateBlock(simNetParams.GenesisHash); err == nil {
+		t.Fatal("InvalidateBlock: invalidated the genesis block")
+	}
+	var unknownHash chainhash.Hash
+	err = chain.InvalidateBlock(&unknownHash)
+	if _, ok := err.(blockchain.HashError); !ok {
+		t.Fatalf("InvalidateBlock: unexpected error for unknown block "+
+			"-- got %v, want HashError", err)
+	}
+
+	// Load the short chain followed by the long chain which forks from it
+	// at height 131 and becomes the main chain.
+	shortChain := loadReorgTestBlocks(t, "reorgto179.bz2")
+	longChain := loadReorgTestBlocks(t, "reorgto180.bz2")
+	process := func(blocks map[int64][]byte, start, end int64) {
+		for i := start; i <= end; i++ {
+			bl, err := dcrutil.NewBlockFromBytes(blocks[i])
+			if err != nil {
+				t.Fatalf("NewBlockFromBytes error: %v", err)
+			}
+			bl.SetHeight(i)
+
+			_, _, err = chain.ProcessBlock(bl, blockchain.BFNone)
+			if err != nil {
+				t.Fatalf("ProcessBlock error at height %v: %v", i,
+					err)
+			}
+		}
+	}
+	process(shortChain, 1, 179)
+	process(longChain, 131, 180)
+
+	checkBest := func(desc string, wantHash *chainhash.Hash, wantHeight int64) {
+		best := chain.BestSnapshot()
+		if *best.Hash != *wantHash || best.Height != wantHeight {
+			t.Fatalf("%s: unexpected best block -- got %v (height %d), "+
+				"want %v (height %d)", desc, best.Hash, best.Height,
+				wantHash, wantHeight)
+		}
+	}
+	longTip := reorgTestBlockHash(t, longChain[180])
+	checkBest("initial", longTip, 180)
+
+	// Ensure invalidating the first block of the long chain reorganizes to
+	// the short chain and rejects the blocks which build on it.
+	longFork := reorgTestBlockHash(t, longChain[131])
+	if err := chain.InvalidateBlock(longFork); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	checkBest("invalidate long chain", reorgTestBlockHash(t,
+		shortChain[179]), 179)
+	if !chain.IsKnownInvalidBlock(reorgTestBlockHash(t, longChain[150])) {
+		t.Fatal("IsKnownInvalidBlock: descendant of invalidated block " +
+			"is not known to be invalid")
+	}
+	longTipBlock, err := dcrutil.NewBlockFromBytes(longChain[180])
+	if err != nil {
+		t.Fatalf("NewBlockFromBytes error: %v", err)
+	}
+	_, _, err = chain.ProcessBlock(longTipBlock, blockchain.BFNone)
+	if rerr, ok := err.(blockchain.RuleError); !ok ||
+		rerr.ErrorCode != blockchain.ErrKnownInvalidBlock {
+
+		t.Fatalf("ProcessBlock: unexpected error for descendant of "+
+			"invalidated block -- got %v, want ErrKnownInvalidBlock",
+			err)
+	}
+
+	// Ensure reconsidering the block reorganizes back to the long chain.
+	if err := chain.ReconsiderBlock(longFork); err != nil {
+		t.Fatalf("ReconsiderBlock: unexpected error: %v", err)
+	}
+	checkBest("reconsider long chain", longTip, 180)
+	if chain.IsKnownInvalidBlock(reorgTestBlockHash(t, longChain[150])) {
+		t.Fatal("IsKnownInvalidBlock: descendant of reconsidered block " +
+			"is known to be invalid")
+	}
+
+	// Ensure invalidating a side chain block does not change the main
+	// chain and invalidating the tip of the main chain disconnects it when
+	// there is no valid side chain with more work.
+	if err := chain.InvalidateBlock(reorgTestBlockHash(t,
+		shortChain[140])); err != nil {
+
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	checkBest("invalidate side chain", longTip, 180)
+	if err := chain.InvalidateBlock(longTip); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	checkBest("invalidate tip", reorgTestBlockHash(t, longChain[179]), 179)
+	if err := chain.ReconsiderBlock(longTip); err != nil {
+		t.Fatalf("ReconsiderBlock: unexpected error: %v", err)
+	}
+	checkBest("reconsider tip", longTip, 180)
+}
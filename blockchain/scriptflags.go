@@ -0,0 +1,152 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/txscript"
+)
+
+// baseScriptFlags are the script flags which are enforced by the consensus
+// rules regardless of the state of any agendas.
+const baseScriptFlags = txscript.ScriptBip16 |
+	txscript.ScriptVerifyDERSignatures |
+	txscript.ScriptVerifyStrictEncoding |
+	txscript.ScriptVerifyMinimalData |
+	txscript.ScriptVerifyCleanStack |
+	txscript.ScriptVerifyCheckLockTimeVerify
+
+// agendaScriptFlags describes script flags which are enforced by the consensus
+// rules once the agenda with the given stake version and vote ID is active.
+type agendaScriptFlags struct {
+	version uint32
+	voteID  string
+	flags   txscript.ScriptFlags
+}
+
+// scriptFlagAgendas houses the script flags which are enforced once the
+// associated agendas are active.  Agendas which change the script verification
+// rules must add an entry here rather than deriving the flags themselves so
+// that block validation, the memory pool, and mining always agree on the rules.
+var scriptFlagAgendas = []agendaScriptFlags{
+	{
+		version: treasuryAgendaVersion,
+		voteID:  chaincfg.VoteIDTreasury,
+		flags:   txscript.ScriptVerifyTreasury,
+	},
+}
+
+// scriptFlagsForAgendas returns the script flags which are enforced by the
+// consensus rules given the passed agendas and a function that returns whether
+// or not the agenda with a given stake version and vote ID is active.
+func scriptFlagsForAgendas(agendas []agendaScriptFlags, isActive func(version uint32, voteID string) (bool, error)) (txscript.ScriptFlags, error) {
+	flags := baseScriptFlags
+	for _, agenda := range agendas {
+		active, err := isActive(agenda.version, agenda.voteID)
+		if err != nil {
+			return 0, err
+		}
+		if active {
+			flags |= agenda.flags
+		}
+	}
+	return flags, nil
+}
+
+// scriptFlagsByName houses the script verification flags which may be added
+// or removed by the ScriptFlagsAdd and ScriptFlagsRemove config fields keyed
+// by the names the getblockchaininfo RPC reports them with.
+var scriptFlagsByName = map[string]txscript.ScriptFlags{
+	"bip16":                    txscript.ScriptBip16,
+	"strictmultisig":           txscript.ScriptStrictMultiSig,
+	"discourageupgradablenops": txscript.ScriptDiscourageUpgradableNops,
+	"checklocktimeverify":      txscript.ScriptVerifyCheckLockTimeVerify,
+	"cleanstack":               txscript.ScriptVerifyCleanStack,
+	"dersignatures":            txscript.ScriptVerifyDERSignatures,
+	"lows":                     txscript.ScriptVerifyLowS,
+	"minimaldata":              txscript.ScriptVerifyMinimalData,
+	"sigpushonly":              txscript.ScriptVerifySigPushOnly,
+	"strictencoding":           txscript.ScriptVerifyStrictEncoding,
+	"treasury":                 txscript.ScriptVerifyTreasury,
+}
+
+// scriptFlagsFromNames returns the script verification flags with the passed
+// names.  An error is returned when any of the names is unknown.
+func scriptFlagsFromNames(names []string) (txscript.ScriptFlags, error) {
+	var flags txscript.ScriptFlags
+	for _, name := range names {
+		flag, ok := scriptFlagsByName[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown script verification flag %q",
+				name)
+		}
+		flags |= flag
+	}
+	return flags, nil
+}
+
+// scriptFlagOverrides returns the script flags which are added to and removed
+// from the flags enforced by the consensus rules for the block AFTER the given
+// node as specified by the configuration.  No flags are overridden for the
+// blocks before the configured override height.
+func (b *BlockChain) scriptFlagOverrides(prevNode *blockNode) (add, remove txscript.ScriptFlags) {
+	var height int64
+	if prevNode != nil {
+		height = prevNode.height + 1
+	}
+	if height < b.scriptFlagsHeight {
+		return 0, 0
+	}
+	return b.scriptFlagsAdd, b.scriptFlagsRemove
+}
+
+// consensusScriptFlags returns the script flags which are enforced by the
+// consensus rules for the block AFTER the given node.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) consensusScriptFlags(prevNode *blockNode) (txscript.ScriptFlags, error) {
+	flags, err := scriptFlagsForAgendas(scriptFlagAgendas, func(version uint32, voteID string) (bool, error) {
+		return b.isAgendaActive(prevNode, version, voteID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	add, remove := b.scriptFlagOverrides(prevNode)
+	return (flags | add) &^ remove, nil
+}
+
+// ConsensusScriptFlags returns the script flags which are enforced by the
+// consensus rules for the block AFTER the end of the current best chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ConsensusScriptFlags() (txscript.ScriptFlags, error) {
+	b.chainLock.Lock()
+	flags, err := b.consensusScriptFlags(b.bestNode)
+	b.chainLock.Unlock()
+	return flags, err
+}
+
+// StandardScriptFlags returns the script flags which are required for
+// transactions to be considered standard for the block AFTER the end of the
+// current best chain.  They consist of the flags enforced by the consensus
+// rules along with the stricter standard verification flags, which ensures the
+// policy is never less strict than the consensus rules, less any flags removed
+// by the ScriptFlagsRemove config field.  They are intended to be used when
+// accepting transactions into the memory pool and generating block templates.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) StandardScriptFlags() (txscript.ScriptFlags, error) {
+	b.chainLock.Lock()
+	prevNode := b.bestNode
+	flags, err := b.consensusScriptFlags(prevNode)
+	b.chainLock.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	_, remove := b.scriptFlagOverrides(prevNode)
+	return (flags | txscript.StandardVerifyFlags) &^ remove, nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/txscript"
+)
+
+// TestScriptFlagsForAgendas ensures the script flags enforced by the consensus
+// rules are derived correctly from the state of the agendas.
+func TestScriptFlagsForAgendas(t *testing.T) {
+	t.Parallel()
+
+	agendas := []agendaScriptFlags{
+		{version: 5, voteID: "agendaa", flags: txscript.ScriptVerifyLowS},
+		{version: 6, voteID: "agendab", flags: txscript.ScriptVerifySigPushOnly},
+	}
+	tests := []struct {
+		name   string
+		active map[string]bool
+		want   txscript.ScriptFlags
+	}{
+		{
+			name:   "no agendas active",
+			active: nil,
+			want:   baseScriptFlags,
+		},
+		{
+			name:   "first agenda active",
+			active: map[string]bool{"agendaa": true},
+			want:   baseScriptFlags | txscript.ScriptVerifyLowS,
+		},
+		{
+			name:   "all agendas active",
+			active: map[string]bool{"agendaa": true, "agendab": true},
+			want: baseScriptFlags | txscript.ScriptVerifyLowS |
+				txscript.ScriptVerifySigPushOnly,
+		},
+	}
+	for _, test := range tests {
+		flags, err := scriptFlagsForAgendas(agendas, func(version uint32, voteID string) (bool, error) {
+			return test.active[voteID], nil
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if flags != test.want {
+			t.Errorf("%s: unexpected flags -- got %x, want %x",
+				test.name, flags, test.want)
+		}
+	}
+
+	// Ensure errors determining the state of an agenda are returned.
+	testErr := errors.New("test error")
+	_, err := scriptFlagsForAgendas(agendas, func(uint32, string) (bool, error) {
+		return false, testErr
+	})
+	if err != testErr {
+		t.Errorf("unexpected error -- got %v, want %v", err, testErr)
+	}
+
+	// Ensure the standard verification flags are never less strict than the
+	// base consensus flags.
+	if txscript.StandardVerifyFlags&baseScriptFlags != baseScriptFlags {
+		t.Errorf("standard verify flags %x do not include the consensus "+
+			"flags %x", txscript.StandardVerifyFlags, baseScriptFlags)
+	}
+}
+
+// TestScriptFlagsFromNames ensures the script verification flags overridden by
+// the chain parameters are parsed from their names and unknown names are
+// rejected.
+func TestScriptFlagsFromNames(t *testing.T) {
+	t.Parallel()
+
+	flags, err := scriptFlagsFromNames([]string{"lows", "treasury"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := txscript.ScriptVerifyLowS | txscript.ScriptVerifyTreasury
+	if flags != want {
+		t.Fatalf("unexpected flags -- got %x, want %x", flags, want)
+	}
+
+	if flags, err := scriptFlagsFromNames(nil); err != nil || flags != 0 {
+		t.Fatalf("unexpected flags %x (error %v) for no names", flags, err)
+	}
+	if _, err := scriptFlagsFromNames([]string{"bogus"}); err == nil {
+		t.Fatal("no error for unknown flag name")
+	}
+}
+
+// TestScriptFlagOverrides ensures the configured script verification flag
+// overrides only apply from the configured height on.
+func TestScriptFlagOverrides(t *testing.T) {
+	t.Parallel()
+
+	b := &BlockChain{
+		scriptFlagsAdd:    txscript.ScriptVerifyLowS,
+		scriptFlagsRemove: txscript.ScriptVerifyTreasury,
+		scriptFlagsHeight: 100,
+	}
+	tests := []struct {
+		prevHeight int64
+		overridden bool
+	}{
+		{prevHeight: 0, overridden: false},
+		{prevHeight: 98, overridden: false},
+		{prevHeight: 99, overridden: true},
+		{prevHeight: 1000, overridden: true},
+	}
+	for _, test := range tests {
+		add, remove := b.scriptFlagOverrides(&blockNode{height: test.prevHeight})
+		if overridden := add != 0 || remove != 0; overridden != test.overridden {
+			t.Errorf("block after height %d: unexpected overrides -- "+
+				"got %x, %x", test.prevHeight, add, remove)
+		}
+	}
+
+	// Ensure the overrides apply to all blocks by default.
+	b.scriptFlagsHeight = 0
+	add, remove := b.scriptFlagOverrides(nil)
+	if add != b.scriptFlagsAdd || remove != b.scriptFlagsRemove {
+		t.Errorf("unexpected overrides for genesis block -- got %x, %x",
+			add, remove)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"math"
 	"runtime"
+	"sync"
 
 	"github.com/decred/dcrd/txscript"
 	"github.com/decred/dcrd/wire"
@@ -17,21 +18,89 @@ import (
 
 // txValidateItem holds a transaction along with which input to validate.
 type txValidateItem struct {
+	txIndex   int
 	txInIndex int
 	txIn      *wire.TxIn
 	tx        *dcrutil.Tx
+	sigHashes *txscript.TxSigHashes
 }
 
-// txValidator provides a type which asynchronously validates transaction
-// inputs.  It provides several channels for communication and a processing
-// function that is intended to be in run multiple goroutines.
+// ruleError creates a RuleError for a violation by the input of the item.  The
+// index of the transaction is only known when it is part of a block, otherwise
+// it is -1.
+func (item *txValidateItem) ruleError(c ErrorCode, desc string) RuleError {
+	rerr := inputRuleError(c, desc, item.txInIndex)
+	rerr.Context.TxIndex = item.txIndex
+	return rerr
+}
+
+// txValidateRequest is a request for the script validation worker pool to
+// validate an item with the passed validator.
+type txValidateRequest struct {
+	item      *txValidateItem
+	validator *txValidator
+}
+
+var (
+	// txValidatePoolOnce ensures the script validation worker pool is only
+	// started once.
+	txValidatePoolOnce sync.Once
+
+	// txValidatePoolChan is the channel the script validation worker pool
+	// receives requests on.
+	txValidatePoolChan chan txValidateRequest
+)
+
+// txValidatePool returns the channel to send requests to the script validation
+// worker pool on, starting the pool when it is not running yet.
+//
+// The pool is made up of a persistent goroutine per processor core which is
+// shared by all validators, so the validation of blocks and transactions that
+// are validated concurrently does not start more goroutines than there are
+// cores to run them on.
+//
+// This function is safe for concurrent access.
+func txValidatePool() chan<- txValidateRequest {
+	txValidatePoolOnce.Do(func() {
+		numWorkers := runtime.NumCPU()
+		if numWorkers <= 0 {
+			numWorkers = 1
+		}
+		txValidatePoolChan = make(chan txValidateRequest)
+		for i := 0; i < numWorkers; i++ {
+			go txValidateWorker(txValidatePoolChan)
+		}
+	})
+	return txValidatePoolChan
+}
+
+// txValidateWorker validates the items of the requests received on the passed
+// channel and returns the results to their validators.  It must be run as a
+// goroutine.
+func txValidateWorker(requests <-chan txValidateRequest) {
+	for req := range requests {
+		// Skip the validation when the validator was already aborted
+		// due to a validation error of another item.
+		v := req.validator
+		select {
+		case <-v.quitChan:
+			continue
+		default:
+		}
+
+		v.sendResult(v.validate(req.item))
+	}
+}
+
+// txValidator provides a type which validates transaction inputs using the
+// script validation worker pool.  It provides several channels for
+// communication with the pool.
 type txValidator struct {
-	validateChan chan *txValidateItem
-	quitChan     chan struct{}
-	resultChan   chan error
-	utxoView     *UtxoViewpoint
-	flags        txscript.ScriptFlags
-	sigCache     *txscript.SigCache
+	quitChan   chan struct{}
+	resultChan chan error
+	utxoView   *UtxoViewpoint
+	flags      txscript.ScriptFlags
+	sigCache   *txscript.SigCache
 }
 
 // sendResult sends the result of a script pair validation on the internal
@@ -45,110 +114,70 @@ func (v *txValidator) sendResult(result error) {
 	}
 }
 
-// validateHandler consumes items to validate from the internal validate channel
-// and returns the result of the validation on the internal result channel. It
-// must be run as a goroutine.
-func (v *txValidator) validateHandler() {
-out:
-	for {
-		select {
-		case txVI := <-v.validateChan:
-			// Ensure the referenced input transaction is available.
-			txIn := txVI.txIn
-			originTxHash := &txIn.PreviousOutPoint.Hash
-			originTxIndex := txIn.PreviousOutPoint.Index
-			txEntry := v.utxoView.LookupEntry(originTxHash)
-			if txEntry == nil {
-				str := fmt.Sprintf("unable to find input "+
-					"transaction %v referenced from "+
-					"transaction %v", originTxHash,
-					txVI.tx.Hash())
-				err := ruleError(ErrMissingTx, str)
-				v.sendResult(err)
-				break out
-			}
-
-			// Ensure the referenced input transaction public key
-			// script is available.
-			pkScript := txEntry.PkScriptByIndex(originTxIndex)
-			if pkScript == nil {
-				str := fmt.Sprintf("unable to find unspent "+
-					"output %v script referenced from "+
-					"transaction %s:%d",
-					txIn.PreviousOutPoint, txVI.tx.Hash(),
-					txVI.txInIndex)
-				err := ruleError(ErrBadTxInput, str)
-				v.sendResult(err)
-				break out
-			}
-
-			// Create a new script engine for the script pair.
-			sigScript := txIn.SignatureScript
-			version := txEntry.ScriptVersionByIndex(originTxIndex)
+// validate validates the script pair of the passed item.
+func (v *txValidator) validate(txVI *txValidateItem) error {
+	// Ensure the referenced input transaction is available.
+	txIn := txVI.txIn
+	originTxHash := &txIn.PreviousOutPoint.Hash
+	originTxIndex := txIn.PreviousOutPoint.Index
+	txEntry := v.utxoView.LookupEntry(originTxHash)
+	if txEntry == nil {
+		str := fmt.Sprintf("unable to find input transaction "+
+			"%v referenced from transaction %v", originTxHash,
+			txVI.tx.Hash())
+		return txVI.ruleError(ErrMissingTx, str)
+	}
 
-			vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(),
-				txVI.txInIndex, v.flags, version, v.sigCache)
-			if err != nil {
-				str := fmt.Sprintf("failed to parse input "+
-					"%s:%d which references output %s:%d - "+
-					"%v (input script bytes %x, prev output "+
-					"script bytes %x)", txVI.tx.Hash(),
-					txVI.txInIndex, originTxHash,
-					originTxIndex, err, sigScript, pkScript)
-				err := ruleError(ErrScriptMalformed, str)
-				v.sendResult(err)
-				break out
-			}
+	// Ensure the referenced input transaction public key script is
+	// available.
+	pkScript := txEntry.PkScriptByIndex(originTxIndex)
+	if pkScript == nil {
+		str := fmt.Sprintf("unable to find unspent output %v script "+
+			"referenced from transaction %s:%d",
+			txIn.PreviousOutPoint, txVI.tx.Hash(), txVI.txInIndex)
+		return txVI.ruleError(ErrBadTxInput, str)
+	}
 
-			// Execute the script pair.
-			if err := vm.Execute(); err != nil {
-				str := fmt.Sprintf("failed to validate input "+
-					"%s:%d which references output %s:%d - "+
-					"%v (input script bytes %x, prev output "+
-					"script bytes %x)", txVI.tx.Hash(),
-					txVI.txInIndex, originTxHash,
-					originTxIndex, err, sigScript, pkScript)
-				err := ruleError(ErrScriptValidation, str)
-				v.sendResult(err)
-				break out
-			}
+	// Create a new script engine for the script pair.
+	sigScript := txIn.SignatureScript
+	version := txEntry.ScriptVersionByIndex(originTxIndex)
 
-			// Validation succeeded.
-			v.sendResult(nil)
+	vm, err := txscript.NewEngineWithSigHashes(pkScript, txVI.tx.MsgTx(),
+		txVI.txInIndex, v.flags, version, v.sigCache, txVI.sigHashes)
+	if err != nil {
+		str := fmt.Sprintf("failed to parse input %s:%d which "+
+			"references output %s:%d - %v (input script bytes %x, "+
+			"prev output script bytes %x)", txVI.tx.Hash(),
+			txVI.txInIndex, originTxHash, originTxIndex, err,
+			sigScript, pkScript)
+		return txVI.ruleError(ErrScriptMalformed, str)
+	}
 
-		case <-v.quitChan:
-			break out
-		}
+	// Execute the script pair.
+	if err := vm.Execute(); err != nil {
+		str := fmt.Sprintf("failed to validate input %s:%d which "+
+			"references output %s:%d - %v (input script bytes %x, "+
+			"prev output script bytes %x)", txVI.tx.Hash(),
+			txVI.txInIndex, originTxHash, originTxIndex, err,
+			sigScript, pkScript)
+		return txVI.ruleError(ErrScriptValidation, str)
 	}
+
+	// Validation succeeded.
+	return nil
 }
 
 // Validate validates the scripts for all of the passed transaction inputs using
-// multiple goroutines.
+// the script validation worker pool.
 func (v *txValidator) Validate(items []*txValidateItem) error {
 	if len(items) == 0 {
 		return nil
 	}
 
-	// Limit the number of goroutines to do script validation based on the
-	// number of processor cores.  This help ensure the system stays
-	// reasonably responsive under heavy load.
-	maxGoRoutines := runtime.NumCPU() * 3
-	if maxGoRoutines <= 0 {
-		maxGoRoutines = 1
-	}
-	if maxGoRoutines > len(items) {
-		maxGoRoutines = len(items)
-	}
-
-	// Start up validation handlers that are used to asynchronously
-	// validate each transaction input.
-	for i := 0; i < maxGoRoutines; i++ {
-		go v.validateHandler()
-	}
-
 	// Validate each of the inputs.  The quit channel is closed when any
-	// errors occur so all processing goroutines exit regardless of which
-	// input had the validation error.
+	// errors occur so the workers skip the remaining inputs regardless of
+	// which input had the validation error.
+	pool := txValidatePool()
 	numInputs := len(items)
 	currentItem := 0
 	processedItems := 0
@@ -156,15 +185,18 @@ func (v *txValidator) Validate(items []*txValidateItem) error {
 		// Only send items while there are still items that need to
 		// be processed.  The select statement will never select a nil
 		// channel.
-		var validateChan chan *txValidateItem
-		var item *txValidateItem
+		var validateChan chan<- txValidateRequest
+		var req txValidateRequest
 		if currentItem < numInputs {
-			validateChan = v.validateChan
-			item = items[currentItem]
+			validateChan = pool
+			req = txValidateRequest{
+				item:      items[currentItem],
+				validator: v,
+			}
 		}
 
 		select {
-		case validateChan <- item:
+		case validateChan <- req:
 			currentItem++
 
 		case err := <-v.resultChan:
@@ -184,12 +216,11 @@ func (v *txValidator) Validate(items []*txValidateItem) error {
 // validating transaction scripts asynchronously.
 func newTxValidator(utxoView *UtxoViewpoint, flags txscript.ScriptFlags, sigCache *txscript.SigCache) *txValidator {
 	return &txValidator{
-		validateChan: make(chan *txValidateItem),
-		quitChan:     make(chan struct{}),
-		resultChan:   make(chan error),
-		utxoView:     utxoView,
-		sigCache:     sigCache,
-		flags:        flags,
+		quitChan:   make(chan struct{}),
+		resultChan: make(chan error),
+		utxoView:   utxoView,
+		sigCache:   sigCache,
+		flags:      flags,
 	}
 }
 
@@ -197,9 +228,11 @@ func newTxValidator(utxoView *UtxoViewpoint, flags txscript.ScriptFlags, sigCach
 // using multiple goroutines.
 func ValidateTransactionScripts(tx *dcrutil.Tx, utxoView *UtxoViewpoint, flags txscript.ScriptFlags, sigCache *txscript.SigCache) error {
 	// Collect all of the transaction inputs and required information for
-	// validation.
+	// validation.  The signature hashes which are the same for all inputs
+	// are only calculated once and shared by them.
 	txIns := tx.MsgTx().TxIn
 	txValItems := make([]*txValidateItem, 0, len(txIns))
+	sigHashes := txscript.NewTxSigHashes(tx.MsgTx())
 	for txInIdx, txIn := range txIns {
 		// Skip coinbases.
 		if txIn.PreviousOutPoint.Index == math.MaxUint32 {
@@ -207,9 +240,11 @@ func ValidateTransactionScripts(tx *dcrutil.Tx, utxoView *UtxoViewpoint, flags t
 		}
 
 		txVI := &txValidateItem{
+			txIndex:   -1,
 			txInIndex: txInIdx,
 			txIn:      txIn,
 			tx:        tx,
+			sigHashes: sigHashes,
 		}
 		txValItems = append(txValItems, txVI)
 	}
@@ -231,32 +266,43 @@ func checkBlockScripts(block *dcrutil.Block, utxoView *UtxoViewpoint, txTree boo
 	var txs []*dcrutil.Tx
 
 	// TxTreeRegular handling.
+	tree := wire.TxTreeRegular
 	if txTree {
 		txs = block.Transactions()
 	} else { // TxTreeStake
 		txs = block.STransactions()
+		tree = wire.TxTreeStake
 	}
 
 	for _, tx := range txs {
 		numInputs += len(tx.MsgTx().TxIn)
 	}
 	txValItems := make([]*txValidateItem, 0, numInputs)
-	for _, tx := range txs {
+	for txIdx, tx := range txs {
+		// The signature hashes which are the same for all inputs of the
+		// transaction are only calculated once and shared by them.
+		var sigHashes *txscript.TxSigHashes
 		for txInIdx, txIn := range tx.MsgTx().TxIn {
 			// Skip coinbases.
 			if txIn.PreviousOutPoint.Index == math.MaxUint32 {
 				continue
 			}
 
+			if sigHashes == nil {
+				sigHashes = txscript.NewTxSigHashes(tx.MsgTx())
+			}
 			txVI := &txValidateItem{
+				txIndex:   txIdx,
 				txInIndex: txInIdx,
 				txIn:      txIn,
 				tx:        tx,
+				sigHashes: sigHashes,
 			}
 			txValItems = append(txValItems, txVI)
 		}
 	}
 
 	// Validate all of the inputs.
-	return newTxValidator(utxoView, scriptFlags, sigCache).Validate(txValItems)
+	err := newTxValidator(utxoView, scriptFlags, sigCache).Validate(txValItems)
+	return addRuleErrorContext(err, block.Height(), tree, -1)
 }
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// errNotSimNet is returned when attempting to directly manipulate the chain
+// state on a network other than the simulation test network.
+var errNotSimNet = errors.New("the chain state may only be manipulated " +
+	"on the simulation test network")
+
+// forcedStakeDifficulty returns the stake difficulty which overrides the
+// calculated stake difficulty, or 0 when it is not overridden.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) forcedStakeDifficulty() int64 {
+	return atomic.LoadInt64(&b.forcedStakeDiff)
+}
+
+// ForceStakeDifficulty overrides the calculated stake difficulty of all blocks
+// with the passed value and returns the resulting stake difficulty of the next
+// block.  Passing 0 removes the override.  It allows tests to purchase tickets
+// at a known price regardless of the ticket pool state.
+//
+// Since the override also applies when validating blocks which were previously
+// accepted, it is only permitted on the simulation test network.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ForceStakeDifficulty(sbits int64) (int64, error) {
+	if b.chainParams.Net != wire.SimNet {
+		return 0, errNotSimNet
+	}
+	if sbits != 0 && sbits < b.chainParams.MinimumStakeDiff {
+		return 0, fmt.Errorf("stake difficulty %v is less than the "+
+			"minimum stake difficulty %v", sbits,
+			b.chainParams.MinimumStakeDiff)
+	}
+
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	atomic.StoreInt64(&b.forcedStakeDiff, sbits)
+	return b.calcNextRequiredStakeDifficulty(b.bestNode)
+}
+
+// CreditOutputs directly adds the passed outputs to the utxo set as the
+// outputs of a transaction which is not included in any block.  The outputs
+// are spendable in the regular transaction tree from the current best block
+// onwards and are not subject to coinbase maturity.
+//
+// The hash of the transaction and the block height which must be provided in
+// the fraud proof of the inputs which spend the outputs are returned.  The
+// block index of the fraud proof is wire.NullBlockIndex.
+//
+// Since the outputs do not originate from a block, other nodes are not aware of
+// them and blocks spending them are rejected by other nodes, so it is only
+// permitted on the simulation test network.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CreditOutputs(outputs []*wire.TxOut) (*chainhash.Hash, int64, error) {
+	if b.chainParams.Net != wire.SimNet {
+		return nil, 0, errNotSimNet
+	}
+	if len(outputs) == 0 {
+		return nil, 0, errors.New("no outputs to credit")
+	}
+
+	// The transaction spends a random previous output so that its hash is
+	// unique and it is not mistaken for a coinbase.
+	var prevHash chainhash.Hash
+	if _, err := rand.Read(prevHash[:]); err != nil {
+		return nil, 0, err
+	}
+	msgTx := wire.NewMsgTx()
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0,
+		wire.TxTreeRegular), nil))
+	for _, txOut := range outputs {
+		if txOut.Value <= 0 {
+			return nil, 0, fmt.Errorf("output value %v is not positive",
+				txOut.Value)
+		}
+		msgTx.AddTxOut(txOut)
+	}
+	tx := dcrutil.NewTx(msgTx)
+
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	height := b.bestNode.height
+	view := NewUtxoViewpoint()
+	view.SetBestHash(&b.bestNode.hash)
+	view.AddTxOuts(tx, height, wire.NullBlockIndex)
+	err := b.db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoView(dbTx, view)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Keep the utxo set hash of the experimental utxo commitments in sync
+	// with the utxo set.
+	if state, ok := b.utxoCommitments[b.bestNode.hash]; ok {
+		state.hash.addTxOuts(msgTx)
+	}
+
+	log.Infof("Credited %d outputs of transaction %v to the utxo set",
+		len(outputs), tx.Hash())
+	return tx.Hash(), height, nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain_test
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestSimNetStateManipulation ensures the stake difficulty may be forced and
+// outputs may be credited directly to the utxo set on the simulation test
+// network only.
+func TestSimNetStateManipulation(t *testing.T) {
+	chain, teardownFunc, err := chainSetup("simnetstateunittest",
+		simNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Ensure forcing the stake difficulty overrides the calculated stake
+	// difficulty until the override is removed.
+	minStakeDiff := simNetParams.MinimumStakeDiff
+	if _, err := chain.ForceStakeDifficulty(minStakeDiff - 1); err == nil {
+		t.Fatal("ForceStakeDifficulty: did not receive expected error " +
+			"for stake difficulty below the minimum")
+	}
+	tests := []struct {
+		sbits int64
+		want  int64
+	}{
+		{minStakeDiff * 10, minStakeDiff * 10},
+		{minStakeDiff, minStakeDiff},
+		{0, minStakeDiff},
+	}
+	for i, test := range tests {
+		next, err := chain.ForceStakeDifficulty(test.sbits)
+		if err != nil {
+			t.Fatalf("ForceStakeDifficulty #%d: unexpected error: %v",
+				i, err)
+		}
+		if next != test.want {
+			t.Fatalf("ForceStakeDifficulty #%d: got next stake "+
+				"difficulty %v, want %v", i, next, test.want)
+		}
+		estimate, err := chain.EstimateNextStakeDifficulty(0, true)
+		if err != nil {
+			t.Fatalf("EstimateNextStakeDifficulty #%d: unexpected "+
+				"error: %v", i, err)
+		}
+		if estimate != test.want {
+			t.Fatalf("EstimateNextStakeDifficulty #%d: got %v, want "+
+				"%v", i, estimate, test.want)
+		}
+	}
+
+	// Ensure credited outputs are added to the utxo set.
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(1e8, []byte{0x51}),
+		wire.NewTxOut(2e8, []byte{0x51}),
+	}
+	txHash, height, err := chain.CreditOutputs(outputs)
+	if err != nil {
+		t.Fatalf("CreditOutputs: unexpected error: %v", err)
+	}
+	entry, err := chain.FetchUtxoEntry(txHash)
+	if err != nil {
+		t.Fatalf("FetchUtxoEntry: unexpected error: %v", err)
+	}
+	if entry == nil {
+		t.Fatal("FetchUtxoEntry: credited outputs are not in the utxo set")
+	}
+	if entry.BlockHeight() != height || entry.BlockIndex() != wire.NullBlockIndex ||
+		entry.IsCoinBase() {
+		t.Fatalf("FetchUtxoEntry: unexpected entry height %v, index %v, "+
+			"coinbase %v", entry.BlockHeight(), entry.BlockIndex(),
+			entry.IsCoinBase())
+	}
+	for i, txOut := range outputs {
+		if amount := entry.AmountByIndex(uint32(i)); amount != txOut.Value {
+			t.Fatalf("AmountByIndex(%d): got %v, want %v", i, amount,
+				txOut.Value)
+		}
+	}
+
+	// Ensure the chain state may not be manipulated on other networks.
+	mainChain, mainTeardownFunc, err := chainSetup("mainnetstateunittest",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer mainTeardownFunc()
+	if _, err := mainChain.ForceStakeDifficulty(minStakeDiff); err == nil {
+		t.Fatal("ForceStakeDifficulty: did not receive expected error " +
+			"on mainnet")
+	}
+	if _, _, err := mainChain.CreditOutputs(outputs); err == nil {
+		t.Fatal("CreditOutputs: did not receive expected error on mainnet")
+	}
+}
@@ -21,6 +21,11 @@ var (
 	// chain state from the perspective of the stake database.
 	StakeChainStateKeyName = []byte("stakechainstate")
 
+	// StakeTreapSnapshotKeyName is the name of the db key used to store a
+	// snapshot of the live, missed, and revoked ticket treaps of the best
+	// chain state so they can be restored quickly on start up.
+	StakeTreapSnapshotKeyName = []byte("staketreapsnapshot")
+
 	// LiveTicketsBucketName is the name of the db bucket used to house the
 	// list of live tickets keyed to their entry height.
 	LiveTicketsBucketName = []byte("livetickets")
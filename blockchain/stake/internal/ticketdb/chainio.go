@@ -5,6 +5,7 @@
 package ticketdb
 
 import (
+	"bytes"
 	"fmt"
 	"time"
 
@@ -39,6 +40,11 @@ const (
 // contains the current height of the blockchain, which should be equivalent to
 // the height of the best chain on start up.
 //
+// A snapshot of the live, missed, and revoked ticket treaps is stored in a root
+// key named StakeTreapSnapshotKeyName on shut down.  It is only used on start
+// up when it was taken at the block of the best chain state, and the tickets
+// are loaded from their buckets otherwise.
+//
 // There are 5 buckets from the database reserved for tickets. These are:
 // 1. Live
 //     Live ticket bucket, for tickets currently in the lottery
@@ -626,11 +632,14 @@ func DbPutTicket(dbTx database.Tx, ticketBucket []byte, hash *chainhash.Hash,
 }
 
 // DbLoadAllTickets loads all the live tickets from the database into a treap.
+// The tickets are loaded into a mutable treap which is frozen once all of them
+// are loaded since that avoids copying the path to the root of the treap for
+// every ticket.
 func DbLoadAllTickets(dbTx database.Tx, ticketBucket []byte) (*tickettreap.Immutable, error) {
 	meta := dbTx.Metadata()
 	bucket := meta.Bucket(ticketBucket)
 
-	treap := tickettreap.NewImmutable()
+	treap := tickettreap.NewMutable()
 	err := bucket.ForEach(func(k []byte, v []byte) error {
 		if len(v) < 5 {
 			return ticketDBError(ErrLoadAllTickets, fmt.Sprintf("short "+
@@ -651,7 +660,7 @@ func DbLoadAllTickets(dbTx database.Tx, ticketBucket []byte) (*tickettreap.Immut
 			Expired: expired,
 		}
 
-		treap = treap.Put(treapKey, treapValue)
+		treap.Put(treapKey, treapValue)
 		return nil
 	})
 	if err != nil {
@@ -659,7 +668,111 @@ func DbLoadAllTickets(dbTx database.Tx, ticketBucket []byte) (*tickettreap.Immut
 			"load all tickets for the bucket %s", string(ticketBucket)))
 	}
 
-	return treap, nil
+	return treap.Freeze(), nil
+}
+
+// -----------------------------------------------------------------------------
+// The treap snapshot contains the live, missed, and revoked ticket treaps as of
+// a block so they can be restored without loading every ticket from the
+// buckets.
+//
+//   Field      Type      Size       Description
+//   hash       chainhash 32 bytes   The hash of the block
+//   height     uint32    4 bytes    The height of the block
+//   live       treap     variable   The live tickets
+//   missed     treap     variable   The missed tickets
+//   revoked    treap     variable   The revoked tickets
+//
+// Each treap is serialized with the Serialize method of the ticket treap.
+// -----------------------------------------------------------------------------
+
+// treapSnapshotHeaderSize is the size of the block hash and height which
+// prefix a serialized treap snapshot.
+const treapSnapshotHeaderSize = chainhash.HashSize + 4
+
+// TreapSnapshot is a snapshot of the live, missed, and revoked ticket treaps as
+// of the block with the given hash and height.
+type TreapSnapshot struct {
+	Hash    chainhash.Hash
+	Height  uint32
+	Live    *tickettreap.Immutable
+	Missed  *tickettreap.Immutable
+	Revoked *tickettreap.Immutable
+}
+
+// serializeTreapSnapshot returns the serialization of the passed treap
+// snapshot.
+func serializeTreapSnapshot(snapshot *TreapSnapshot) ([]byte, error) {
+	var header [treapSnapshotHeaderSize]byte
+	copy(header[:], snapshot.Hash[:])
+	dbnamespace.ByteOrder.PutUint32(header[chainhash.HashSize:],
+		snapshot.Height)
+
+	var buf bytes.Buffer
+	buf.Write(header[:])
+	treaps := []*tickettreap.Immutable{snapshot.Live, snapshot.Missed,
+		snapshot.Revoked}
+	for _, treap := range treaps {
+		if err := treap.Serialize(&buf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// deserializeTreapSnapshot deserializes the passed serialized treap snapshot.
+func deserializeTreapSnapshot(serializedData []byte) (*TreapSnapshot, error) {
+	if len(serializedData) < treapSnapshotHeaderSize {
+		return nil, ticketDBError(ErrTreapSnapshotCorrupt, "short read "+
+			"when deserializing treap snapshot")
+	}
+
+	snapshot := new(TreapSnapshot)
+	copy(snapshot.Hash[:], serializedData[:chainhash.HashSize])
+	snapshot.Height = dbnamespace.ByteOrder.Uint32(
+		serializedData[chainhash.HashSize:treapSnapshotHeaderSize])
+
+	r := bytes.NewReader(serializedData[treapSnapshotHeaderSize:])
+	treaps := []**tickettreap.Immutable{&snapshot.Live, &snapshot.Missed,
+		&snapshot.Revoked}
+	for _, treap := range treaps {
+		var err error
+		*treap, err = tickettreap.ParseTreap(r)
+		if err != nil {
+			return nil, ticketDBError(ErrTreapSnapshotCorrupt,
+				fmt.Sprintf("failed to deserialize treap "+
+					"snapshot: %v", err))
+		}
+	}
+	if r.Len() != 0 {
+		return nil, ticketDBError(ErrTreapSnapshotCorrupt, fmt.Sprintf(
+			"%d unexpected trailing bytes in treap snapshot", r.Len()))
+	}
+
+	return snapshot, nil
+}
+
+// DbFetchTreapSnapshot uses an existing database transaction to fetch the
+// snapshot of the ticket treaps.  It returns nil when there is no snapshot.
+func DbFetchTreapSnapshot(dbTx database.Tx) (*TreapSnapshot, error) {
+	v := dbTx.Metadata().Get(dbnamespace.StakeTreapSnapshotKeyName)
+	if v == nil {
+		return nil, nil
+	}
+
+	return deserializeTreapSnapshot(v)
+}
+
+// DbPutTreapSnapshot uses an existing database transaction to store the passed
+// snapshot of the ticket treaps, replacing any existing snapshot.
+func DbPutTreapSnapshot(dbTx database.Tx, snapshot *TreapSnapshot) error {
+	serializedData, err := serializeTreapSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Metadata().Put(dbnamespace.StakeTreapSnapshotKeyName,
+		serializedData)
 }
 
 // DbCreate initializes all the buckets required for the database and stores
@@ -230,6 +230,91 @@ func TestBestChainStateDeserializeErrors(t *testing.T) {
 	}
 }
 
+// treapEntries returns the key/value pairs of the passed treap in a map.
+func treapEntries(treap *tickettreap.Immutable) map[tickettreap.Key]tickettreap.Value {
+	entries := make(map[tickettreap.Key]tickettreap.Value)
+	treap.ForEach(func(k tickettreap.Key, v *tickettreap.Value) bool {
+		entries[k] = *v
+		return true
+	})
+	return entries
+}
+
+// TestTreapSnapshotSerialization ensures serializing and deserializing the
+// snapshot of the ticket treaps works as expected.
+func TestTreapSnapshotSerialization(t *testing.T) {
+	t.Parallel()
+
+	live := tickettreap.NewImmutable()
+	missed := tickettreap.NewImmutable()
+	for i := 0; i < 10; i++ {
+		key := tickettreap.Key(chainhash.HashH([]byte{byte(i)}))
+		live = live.Put(key, &tickettreap.Value{Height: uint32(i)})
+		missed = missed.Put(key, &tickettreap.Value{
+			Height:  uint32(i),
+			Missed:  true,
+			Expired: i%2 == 0,
+		})
+	}
+	snapshot := &TreapSnapshot{
+		Hash:    chainhash.HashH([]byte{0xff}),
+		Height:  12345,
+		Live:    live,
+		Missed:  missed,
+		Revoked: tickettreap.NewImmutable(),
+	}
+
+	serialized, err := serializeTreapSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("serializeTreapSnapshot: unexpected error: %v", err)
+	}
+	got, err := deserializeTreapSnapshot(serialized)
+	if err != nil {
+		t.Fatalf("deserializeTreapSnapshot: unexpected error: %v", err)
+	}
+	if got.Hash != snapshot.Hash || got.Height != snapshot.Height {
+		t.Fatalf("deserializeTreapSnapshot: mismatched block - got "+
+			"%v (%d), want %v (%d)", got.Hash, got.Height,
+			snapshot.Hash, snapshot.Height)
+	}
+	treaps := []struct {
+		name      string
+		got, want *tickettreap.Immutable
+	}{
+		{"live", got.Live, snapshot.Live},
+		{"missed", got.Missed, snapshot.Missed},
+		{"revoked", got.Revoked, snapshot.Revoked},
+	}
+	for _, treap := range treaps {
+		if !reflect.DeepEqual(treapEntries(treap.got),
+			treapEntries(treap.want)) {
+
+			t.Fatalf("deserializeTreapSnapshot: mismatched %s "+
+				"tickets", treap.name)
+		}
+	}
+
+	// Ensure snapshots which are truncated or have trailing bytes are
+	// rejected.
+	tests := []struct {
+		name       string
+		serialized []byte
+	}{
+		{"short read", serialized[:treapSnapshotHeaderSize-1]},
+		{"truncated treap", serialized[:len(serialized)-1]},
+		{"trailing bytes", append(serialized, 0x00)},
+	}
+	for _, test := range tests {
+		_, err := deserializeTreapSnapshot(test.serialized)
+		ticketDBErr, ok := err.(DBError)
+		if !ok || ticketDBErr.GetCode() != ErrTreapSnapshotCorrupt {
+			t.Errorf("deserializeTreapSnapshot (%s): unexpected "+
+				"error - got %v, want %v", test.name, err,
+				ErrTreapSnapshotCorrupt)
+		}
+	}
+}
+
 // TestBlockUndoDataSerializing ensures serializing and deserializing the
 // block undo data works as expected.
 func TestBlockUndoDataSerializing(t *testing.T) {
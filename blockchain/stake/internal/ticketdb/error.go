@@ -47,6 +47,10 @@ const (
 	// ErrLoadAllTickets indicates that there was an error loading the tickets
 	// from the database, presumably at startup.
 	ErrLoadAllTickets
+
+	// ErrTreapSnapshotCorrupt indicates that the serialized snapshot of the
+	// ticket treaps was corrupt.
+	ErrTreapSnapshotCorrupt
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
@@ -60,6 +64,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrChainStateShortRead:   "ErrChainStateShortRead",
 	ErrDatabaseInfoShortRead: "ErrDatabaseInfoShortRead",
 	ErrLoadAllTickets:        "ErrLoadAllTickets",
+	ErrTreapSnapshotCorrupt:  "ErrTreapSnapshotCorrupt",
 }
 
 // String returns the ErrorCode as a human-readable name.
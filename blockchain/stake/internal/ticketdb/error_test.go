@@ -26,6 +26,7 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ticketdb.ErrChainStateShortRead, "ErrChainStateShortRead"},
 		{ticketdb.ErrDatabaseInfoShortRead, "ErrDatabaseInfoShortRead"},
 		{ticketdb.ErrLoadAllTickets, "ErrLoadAllTickets"},
+		{ticketdb.ErrTreapSnapshotCorrupt, "ErrTreapSnapshotCorrupt"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
@@ -0,0 +1,232 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package tickettreap
+
+import (
+	"bytes"
+	"sort"
+)
+
+// keySlice implements sort.Interface to allow a slice of keys to be sorted in
+// ascending order.
+type keySlice []Key
+
+// Len returns the number of keys in the slice.  It is part of the
+// sort.Interface implementation.
+func (s keySlice) Len() int { return len(s) }
+
+// Swap swaps the keys at the passed indices.  It is part of the sort.Interface
+// implementation.
+func (s keySlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// Less returns whether the key with index i should sort before the key with
+// index j.  It is part of the sort.Interface implementation.
+func (s keySlice) Less(i, j int) bool {
+	return bytes.Compare(s[i][:], s[j][:]) < 0
+}
+
+// searchKeys returns the index of the first of the passed sorted keys which is
+// greater than or equal to the passed key along with whether that key is equal
+// to it.
+func searchKeys(keys []Key, key *Key) (int, bool) {
+	i := sort.Search(len(keys), func(i int) bool {
+		return bytes.Compare(keys[i][:], key[:]) >= 0
+	})
+	return i, i < len(keys) && keys[i] == *key
+}
+
+// splitTreap splits the treap rooted at the passed node into the treaps which
+// hold the keys less than and greater than the passed key, and returns them
+// along with the node which holds the key, if any.  The nodes on the path to
+// the key are cloned when clone is set so that the passed treap is not
+// modified, otherwise they are relinked in place.
+func splitTreap(node *treapNode, key *Key, clone bool) (*treapNode, *treapNode, *treapNode) {
+	if node == nil {
+		return nil, nil, nil
+	}
+
+	compareResult := bytes.Compare(key[:], node.key[:])
+	if compareResult == 0 {
+		return node.left, node, node.right
+	}
+	if clone {
+		node = cloneTreapNode(node)
+	}
+	if compareResult < 0 {
+		left, match, right := splitTreap(node.left, key, clone)
+		node.left = right
+		node.updateSize()
+		return left, match, node
+	}
+	left, match, right := splitTreap(node.right, key, clone)
+	node.right = left
+	node.updateSize()
+	return node, match, right
+}
+
+// mergeTreaps returns the treap which results from joining the passed treaps
+// where all keys of the left treap are less than the keys of the right treap.
+// The nodes on the spines where the treaps are joined are cloned so that
+// neither of the passed treaps is modified.
+func mergeTreaps(left, right *treapNode) *treapNode {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+
+	// The root with the lower priority becomes the root of the result in
+	// order to maintain the min-heap.
+	if left.priority <= right.priority {
+		node := cloneTreapNode(left)
+		node.right = mergeTreaps(left.right, right)
+		node.updateSize()
+		return node
+	}
+	node := cloneTreapNode(right)
+	node.left = mergeTreaps(left, right.left)
+	node.updateSize()
+	return node
+}
+
+// unionTreaps returns the treap which holds the keys of both the passed
+// immutable treap and the passed treap of new nodes.  The values of the new
+// nodes replace the values of existing keys.  The nodes of the immutable treap
+// which are modified are cloned while all of its unmodified subtrees are shared
+// with the result.  The new nodes are linked into the result in place.
+//
+// The passed count and size are reduced by the number and size of the nodes
+// which are discarded because another node with the same key replaced them.
+func unionTreaps(node, newNode *treapNode, count *int, totalSize *uint64) *treapNode {
+	if newNode == nil {
+		return node
+	}
+	if node == nil {
+		return newNode
+	}
+
+	// The root with the lower priority becomes the root of the result in
+	// order to maintain the min-heap.  The other treap is split by its key
+	// and each side is combined with the respective subtree.
+	if node.priority < newNode.priority {
+		left, match, right := splitTreap(newNode, &node.key, false)
+		nodeCopy := cloneTreapNode(node)
+		if match != nil {
+			nodeCopy.value = match.value
+			*count--
+			*totalSize -= nodeSize(match)
+		}
+		nodeCopy.left = unionTreaps(node.left, left, count, totalSize)
+		nodeCopy.right = unionTreaps(node.right, right, count, totalSize)
+		nodeCopy.updateSize()
+		return nodeCopy
+	}
+
+	left, match, right := splitTreap(node, &newNode.key, true)
+	if match != nil {
+		*count--
+		*totalSize -= nodeSize(match)
+	}
+	newLeft, newRight := newNode.left, newNode.right
+	newNode.left = unionTreaps(left, newLeft, count, totalSize)
+	newNode.right = unionTreaps(right, newRight, count, totalSize)
+	newNode.updateSize()
+	return newNode
+}
+
+// PutBatch inserts all of the passed key/value pairs and returns the resulting
+// treap.  It is equivalent to calling Put with each pair, however, the pairs
+// are applied in a single pass over the treap, so each modified node is only
+// replaced once rather than the ancestors up to and including the root being
+// replaced for every pair.  Pairs with a nil value are ignored.
+func (t *Immutable) PutBatch(pairs map[Key]*Value) *Immutable {
+	keys := make([]Key, 0, len(pairs))
+	for key, value := range pairs {
+		// Nil values are a NOOP just as they are for Put.
+		if value == nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return t
+	}
+	sort.Sort(keySlice(keys))
+
+	// Build a treap of new nodes for the pairs in linear time since the
+	// keys are in ascending order.  See ImmutableBuilder for more details.
+	var builder ImmutableBuilder
+	for _, key := range keys {
+		builder.add(key, pairs[key])
+	}
+	count := t.count + builder.count
+	totalSize := t.totalSize + builder.totalSize
+	newRoot := builder.root()
+
+	// Combine the new nodes with the existing treap.  Replacing the value of
+	// an existing key does not change the number of items or their size, so
+	// the union discounts the nodes it discards for existing keys.
+	root := unionTreaps(t.root, newRoot, &count, &totalSize)
+	return newImmutable(root, count, totalSize)
+}
+
+// deleteKeys returns the treap which results from removing the passed sorted
+// keys from the treap rooted at the passed node.  The nodes which are modified
+// are cloned while all of the unmodified subtrees are shared with the result.
+//
+// The passed count and size are reduced by the number and size of the removed
+// nodes.
+func deleteKeys(node *treapNode, keys []Key, count *int, totalSize *uint64) *treapNode {
+	if node == nil || len(keys) == 0 {
+		return node
+	}
+
+	// Split the keys into those which belong to the left and right subtrees
+	// and determine whether the node itself is removed.
+	i, found := searchKeys(keys, &node.key)
+	rightKeys := keys[i:]
+	if found {
+		rightKeys = keys[i+1:]
+	}
+	left := deleteKeys(node.left, keys[:i], count, totalSize)
+	right := deleteKeys(node.right, rightKeys, count, totalSize)
+	if found {
+		*count--
+		*totalSize -= nodeSize(node)
+		return mergeTreaps(left, right)
+	}
+
+	// Share the node when neither of its subtrees was modified.
+	if left == node.left && right == node.right {
+		return node
+	}
+	nodeCopy := cloneTreapNode(node)
+	nodeCopy.left = left
+	nodeCopy.right = right
+	nodeCopy.updateSize()
+	return nodeCopy
+}
+
+// DeleteBatch removes all of the passed keys from the treap and returns the
+// resulting treap.  It is equivalent to calling Delete with each key, however,
+// the keys are removed in a single pass over the treap, so each modified node
+// is only replaced once.  Keys which do not exist are ignored, and the original
+// immutable treap is returned if none of the keys exist.
+func (t *Immutable) DeleteBatch(keys []Key) *Immutable {
+	if len(keys) == 0 || t.root == nil {
+		return t
+	}
+	sorted := make([]Key, len(keys))
+	copy(sorted, keys)
+	sort.Sort(keySlice(sorted))
+
+	count, totalSize := t.count, t.totalSize
+	root := deleteKeys(t.root, sorted, &count, &totalSize)
+	if root == t.root {
+		return t
+	}
+	return newImmutable(root, count, totalSize)
+}
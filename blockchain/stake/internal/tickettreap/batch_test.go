@@ -0,0 +1,184 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package tickettreap
+
+import (
+	"reflect"
+	"testing"
+)
+
+// treapPairs returns the keys and values of the passed treap in ascending order.
+func treapPairs(testTreap *Immutable) ([]Key, []*Value) {
+	var keys []Key
+	var values []*Value
+	testTreap.ForEach(func(k Key, v *Value) bool {
+		keys = append(keys, k)
+		values = append(values, v)
+		return true
+	})
+	return keys, values
+}
+
+// assertTreapsEqual ensures the passed treaps hold the same key/value pairs,
+// report the same length and size, and that the got treap is valid.
+func assertTreapsEqual(t *testing.T, desc string, got, want *Immutable) {
+	gotKeys, gotValues := treapPairs(got)
+	wantKeys, wantValues := treapPairs(want)
+	if !reflect.DeepEqual(gotKeys, wantKeys) ||
+		!reflect.DeepEqual(gotValues, wantValues) {
+		t.Fatalf("%s: treap pairs do not match", desc)
+	}
+	if got.Len() != want.Len() {
+		t.Fatalf("%s: unexpected length - got %d, want %d", desc,
+			got.Len(), want.Len())
+	}
+	if got.Size() != want.Size() {
+		t.Fatalf("%s: unexpected byte size - got %d, want %d", desc,
+			got.Size(), want.Size())
+	}
+	if n := checkTreapInvariants(t, got.root, nil, nil); n != want.Len() {
+		t.Fatalf("%s: unexpected number of nodes - got %d, want %d",
+			desc, n, want.Len())
+	}
+}
+
+// TestImmutablePutBatch ensures putting a batch of key/value pairs into an
+// immutable treap is equivalent to putting each pair individually and does not
+// modify the original treap.
+func TestImmutablePutBatch(t *testing.T) {
+	t.Parallel()
+
+	// Create a treap with the even keys.
+	numItems := 1000
+	baseTreap := NewImmutable()
+	for i := 0; i < numItems; i += 2 {
+		baseTreap = baseTreap.Put(uint32ToKey(uint32(i)),
+			&Value{Height: uint32(i)})
+	}
+	baseKeys, baseValues := treapPairs(baseTreap)
+
+	tests := []struct {
+		name  string
+		start int
+		end   int
+		step  int
+	}{
+		{"empty", 0, 0, 1},
+		{"single new key", 1, 2, 1},
+		{"single existing key", 2, 3, 1},
+		{"new keys", 1, numItems, 2},
+		{"existing keys", 0, numItems, 2},
+		{"mixed keys", numItems / 4, numItems * 3 / 2, 1},
+	}
+	for _, test := range tests {
+		pairs := make(map[Key]*Value)
+		wantTreap := baseTreap
+		for i := test.start; i < test.end; i += test.step {
+			key := uint32ToKey(uint32(i))
+			value := &Value{Height: uint32(i) + 1, Spent: true}
+			pairs[key] = value
+			wantTreap = wantTreap.Put(key, value)
+		}
+
+		gotTreap := baseTreap.PutBatch(pairs)
+		assertTreapsEqual(t, "PutBatch "+test.name, gotTreap, wantTreap)
+
+		// Ensure the original treap is unchanged.
+		keys, values := treapPairs(baseTreap)
+		if !reflect.DeepEqual(keys, baseKeys) ||
+			!reflect.DeepEqual(values, baseValues) {
+			t.Fatalf("PutBatch %s: original treap was modified",
+				test.name)
+		}
+	}
+
+	// Ensure nil values are ignored and a batch of only nil values returns
+	// the original treap.
+	pairs := map[Key]*Value{uint32ToKey(1): nil, uint32ToKey(2): nil}
+	if gotTreap := baseTreap.PutBatch(pairs); gotTreap != baseTreap {
+		t.Fatal("PutBatch: batch of nil values did not return the " +
+			"original treap")
+	}
+	pairs[uint32ToKey(3)] = &Value{Height: 3}
+	wantTreap := baseTreap.Put(uint32ToKey(3), pairs[uint32ToKey(3)])
+	assertTreapsEqual(t, "PutBatch nil values", baseTreap.PutBatch(pairs),
+		wantTreap)
+
+	// Ensure putting a batch into an empty treap works as expected.
+	emptyTreap := NewImmutable()
+	pairs = make(map[Key]*Value)
+	wantTreap = emptyTreap
+	for i := 0; i < numItems; i++ {
+		key := uint32ToKey(uint32(i))
+		pairs[key] = &Value{Height: uint32(i)}
+		wantTreap = wantTreap.Put(key, pairs[key])
+	}
+	assertTreapsEqual(t, "PutBatch empty treap", emptyTreap.PutBatch(pairs),
+		wantTreap)
+}
+
+// TestImmutableDeleteBatch ensures deleting a batch of keys from an immutable
+// treap is equivalent to deleting each key individually and does not modify
+// the original treap.
+func TestImmutableDeleteBatch(t *testing.T) {
+	t.Parallel()
+
+	// Create a treap with the even keys.
+	numItems := 1000
+	baseTreap := NewImmutable()
+	for i := 0; i < numItems; i += 2 {
+		baseTreap = baseTreap.Put(uint32ToKey(uint32(i)),
+			&Value{Height: uint32(i)})
+	}
+	baseKeys, baseValues := treapPairs(baseTreap)
+
+	tests := []struct {
+		name  string
+		start int
+		end   int
+		step  int
+	}{
+		{"empty", 0, 0, 1},
+		{"single existing key", 2, 3, 1},
+		{"missing keys", 1, numItems, 2},
+		{"every fourth key", 0, numItems, 4},
+		{"mixed keys", numItems / 4, numItems * 3 / 2, 1},
+		{"all keys", 0, numItems, 2},
+	}
+	for _, test := range tests {
+		var keys []Key
+		for i := test.start; i < test.end; i += test.step {
+			keys = append(keys, uint32ToKey(uint32(i)))
+		}
+
+		// Delete the keys in descending order along with a duplicate
+		// to ensure the order of the batch and duplicates are handled.
+		wantTreap := baseTreap
+		batch := make([]Key, 0, len(keys)+1)
+		for i := len(keys) - 1; i >= 0; i-- {
+			batch = append(batch, keys[i])
+			wantTreap = wantTreap.Delete(keys[i])
+		}
+		if len(keys) > 0 {
+			batch = append(batch, keys[0])
+		}
+
+		gotTreap := baseTreap.DeleteBatch(batch)
+		assertTreapsEqual(t, "DeleteBatch "+test.name, gotTreap, wantTreap)
+
+		// Ensure the original treap is unchanged and returned when none
+		// of the keys exist.
+		keys, values := treapPairs(baseTreap)
+		if !reflect.DeepEqual(keys, baseKeys) ||
+			!reflect.DeepEqual(values, baseValues) {
+			t.Fatalf("DeleteBatch %s: original treap was modified",
+				test.name)
+		}
+		if wantTreap.Len() == baseTreap.Len() && gotTreap != baseTreap {
+			t.Fatalf("DeleteBatch %s: did not return the original "+
+				"treap", test.name)
+		}
+	}
+}
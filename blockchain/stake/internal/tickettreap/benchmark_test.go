@@ -6,6 +6,7 @@ package tickettreap
 
 import (
 	"crypto/sha256"
+	"sort"
 	"sync"
 	"testing"
 )
@@ -142,3 +143,61 @@ func BenchmarkImmutableIterate(b *testing.B) {
 	}
 
 }
+
+// BenchmarkImmutableLoad benchmarks how long it takes to load 'numTicketKeys'
+// entries into an immutable treap by putting each of them.
+func BenchmarkImmutableLoad(b *testing.B) {
+	ticketKeys := genTicketKeys()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		testTreap := NewImmutable()
+		for j := 0; j < len(ticketKeys); j++ {
+			value := &Value{Height: uint32(j)}
+			testTreap = testTreap.Put(ticketKeys[j], value)
+		}
+	}
+}
+
+// BenchmarkMutableFreezeLoad benchmarks how long it takes to load
+// 'numTicketKeys' entries into a mutable treap and freeze it into an immutable
+// treap.
+func BenchmarkMutableFreezeLoad(b *testing.B) {
+	ticketKeys := genTicketKeys()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		testTreap := NewMutable()
+		for j := 0; j < len(ticketKeys); j++ {
+			value := &Value{Height: uint32(j)}
+			testTreap.Put(ticketKeys[j], value)
+		}
+		_ = testTreap.Freeze()
+	}
+}
+
+// BenchmarkImmutableBuilderLoad benchmarks how long it takes to build an
+// immutable treap from 'numTicketKeys' entries in ascending order.
+func BenchmarkImmutableBuilderLoad(b *testing.B) {
+	ticketKeys := make([]Key, len(genTicketKeys()))
+	copy(ticketKeys, genTicketKeys())
+	sort.Sort(keySlice(ticketKeys))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		builder := NewImmutableBuilder()
+		for j := 0; j < len(ticketKeys); j++ {
+			value := &Value{Height: uint32(j)}
+			if err := builder.Add(ticketKeys[j], value); err != nil {
+				b.Fatalf("Add: unexpected error: %v", err)
+			}
+		}
+		_ = builder.Immutable()
+	}
+}
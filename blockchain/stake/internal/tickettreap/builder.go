@@ -0,0 +1,94 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package tickettreap
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ImmutableBuilder builds an immutable treap from a stream of key/value pairs
+// which are added in strictly ascending order of their keys.  Since each new
+// key is the greatest one so far, the treap is built directly in linear time
+// rather than by inserting each pair, which requires O(log n) time and replaces
+// the ancestors of every inserted node.  This makes it well suited for loading
+// large treaps, such as ticket pools, from sorted sources.
+//
+// The zero value is ready for use, however, a builder must not be used after
+// its treap is returned by Immutable.
+type ImmutableBuilder struct {
+	// spine houses the nodes on the right spine of the treap built so far.
+	// Each new node has the greatest key so far and therefore belongs on
+	// the right spine.  The nodes on the spine with a greater priority than
+	// the new node become its left subtree in order to maintain the
+	// min-heap.
+	spine     parentStack
+	count     int
+	totalSize uint64
+}
+
+// add links a new node for the passed key/value pair into the treap built so
+// far.  The key must be greater than all keys which were previously added.
+func (b *ImmutableBuilder) add(key Key, value *Value) {
+	node := newTreapNode(key, value, rng.Int())
+	var left *treapNode
+	for b.spine.Len() > 0 && b.spine.At(0).priority > node.priority {
+		left = b.spine.Pop()
+	}
+	node.left = left
+	if parent := b.spine.At(0); parent != nil {
+		parent.right = node
+	}
+	b.spine.Push(node)
+
+	b.count++
+	b.totalSize += nodeSize(node)
+}
+
+// root returns the root of the treap built so far after setting the subtree
+// sizes of all of its nodes, which are only known once all nodes are linked.
+func (b *ImmutableBuilder) root() *treapNode {
+	// The root of the treap is the bottom of the right spine.
+	root := b.spine.At(b.spine.Len() - 1)
+	updateSizes(root)
+	return root
+}
+
+// Add adds the passed key/value pair to the treap being built.  An error is
+// returned when the key is not greater than the key which was previously added.
+// Nil values are ignored just as they are by Put.
+func (b *ImmutableBuilder) Add(key Key, value *Value) error {
+	// Nil values are a NOOP just as they are for Put.
+	if value == nil {
+		return nil
+	}
+
+	if prev := b.spine.At(0); prev != nil &&
+		bytes.Compare(key[:], prev.key[:]) <= 0 {
+
+		return fmt.Errorf("treap key %x is not greater than the previous "+
+			"key %x", key, prev.key)
+	}
+	b.add(key, value)
+	return nil
+}
+
+// Len returns the number of items which were added to the treap being built.
+func (b *ImmutableBuilder) Len() int {
+	return b.count
+}
+
+// Immutable returns the immutable treap which holds all of the key/value pairs
+// which were added.
+func (b *ImmutableBuilder) Immutable() *Immutable {
+	return newImmutable(b.root(), b.count, b.totalSize)
+}
+
+// NewImmutableBuilder returns a new builder ready to build an immutable treap
+// from key/value pairs in ascending order.  See the documentation for the
+// ImmutableBuilder structure for more details.
+func NewImmutableBuilder() *ImmutableBuilder {
+	return &ImmutableBuilder{}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package tickettreap
+
+import (
+	"testing"
+)
+
+// TestImmutableBuilder ensures building an immutable treap from key/value pairs
+// in ascending order is equivalent to putting each pair individually and that
+// keys which are not in ascending order are rejected.
+func TestImmutableBuilder(t *testing.T) {
+	t.Parallel()
+
+	for _, numItems := range []int{0, 1, 2, 1000} {
+		builder := NewImmutableBuilder()
+		wantTreap := NewImmutable()
+		for i := 0; i < numItems; i++ {
+			key := uint32ToKey(uint32(i))
+			value := &Value{Height: uint32(i), Missed: i%2 == 0}
+			if err := builder.Add(key, value); err != nil {
+				t.Fatalf("Add #%d: unexpected error: %v", i, err)
+			}
+			wantTreap = wantTreap.Put(key, value)
+		}
+		if builder.Len() != numItems {
+			t.Fatalf("Len: unexpected length - got %d, want %d",
+				builder.Len(), numItems)
+		}
+		assertTreapsEqual(t, "ImmutableBuilder", builder.Immutable(),
+			wantTreap)
+	}
+
+	// Ensure nil values are ignored.
+	builder := NewImmutableBuilder()
+	if err := builder.Add(uint32ToKey(1), nil); err != nil {
+		t.Fatalf("Add: unexpected error for nil value: %v", err)
+	}
+	if builder.Len() != 0 {
+		t.Fatalf("Len: unexpected length after adding nil value - got %d, "+
+			"want 0", builder.Len())
+	}
+
+	// Ensure duplicate and descending keys are rejected.
+	if err := builder.Add(uint32ToKey(2), &Value{Height: 2}); err != nil {
+		t.Fatalf("Add: unexpected error: %v", err)
+	}
+	if err := builder.Add(uint32ToKey(2), &Value{Height: 2}); err == nil {
+		t.Fatal("Add: did not receive error for duplicate key")
+	}
+	if err := builder.Add(uint32ToKey(1), &Value{Height: 1}); err == nil {
+		t.Fatal("Add: did not receive error for descending key")
+	}
+}
@@ -6,6 +6,7 @@
 package tickettreap
 
 import (
+	"bytes"
 	"math/rand"
 	"sync"
 	"time"
@@ -26,8 +27,8 @@ const (
 	// technically it is smaller on 32-bit platforms, but overestimating the
 	// size in that case is acceptable since it avoids the need to import
 	// unsafe.  It consists of 8 bytes for each of the value, priority,
-	// left, and right fields (8*4).
-	nodeFieldsSize = 32
+	// size, left, and right fields (8*5).
+	nodeFieldsSize = 40
 
 	// nodeValueSize is the size of the fixed-size fields of a Value.
 	nodeValueSize = 4
@@ -88,8 +89,39 @@ type treapNode struct {
 	key      Key
 	value    *Value
 	priority int
-	left     *treapNode
-	right    *treapNode
+
+	// size is the number of nodes in the subtree rooted at the node
+	// including the node itself.  It allows the nodes to be looked up by
+	// their index in the sorted order of the keys.
+	size int
+
+	left  *treapNode
+	right *treapNode
+}
+
+// subtreeSize returns the number of nodes in the subtree rooted at the passed
+// node, which may be nil.
+func subtreeSize(node *treapNode) int {
+	if node == nil {
+		return 0
+	}
+	return node.size
+}
+
+// updateSize sets the subtree size of the node from the sizes of its children.
+// It must be called whenever the children of the node are changed.
+func (node *treapNode) updateSize() {
+	node.size = subtreeSize(node.left) + subtreeSize(node.right) + 1
+}
+
+// updateSizes sets the subtree sizes of all nodes in the subtree rooted at the
+// passed node and returns the size of the subtree.
+func updateSizes(node *treapNode) int {
+	if node == nil {
+		return 0
+	}
+	node.size = updateSizes(node.left) + updateSizes(node.right) + 1
+	return node.size
 }
 
 // nodeSize returns the number of bytes the specified node occupies including
@@ -101,7 +133,7 @@ func nodeSize(node *treapNode) uint64 {
 // newTreapNode returns a new node from the given key, value, and priority.  The
 // node is not initially linked to any others.
 func newTreapNode(key Key, value *Value, priority int) *treapNode {
-	return &treapNode{key: key, value: value, priority: priority}
+	return &treapNode{key: key, value: value, priority: priority, size: 1}
 }
 
 // parentStack represents a stack of parent treap nodes that are used during
@@ -180,3 +212,93 @@ func (s *parentStack) Push(node *treapNode) {
 	s.overflow[index] = node
 	s.index++
 }
+
+// forEachReverse invokes the passed function with every key/value pair in the
+// treap rooted at the passed node in descending order.  Only the keys which are
+// greater than or equal to the start key and less than the end key are
+// iterated.  A nil start or end key leaves the respective side of the range
+// unbounded.
+func forEachReverse(root *treapNode, start, end *Key, fn func(k Key, v *Value) bool) {
+	// Add the nodes on the path to the highest key in the range and all
+	// children to the right of them which are also in the range to the list
+	// of nodes to traverse.  Nodes with keys outside of the range are
+	// skipped along with their right subtrees as they only hold greater
+	// keys.
+	var parents parentStack
+	for node := root; node != nil; {
+		if end != nil && bytes.Compare(node.key[:], end[:]) >= 0 {
+			node = node.left
+			continue
+		}
+		parents.Push(node)
+		node = node.right
+	}
+
+	// Loop until the nodes to traverse, and all of their child nodes, have
+	// been traversed or a key that is less than the start key is reached.
+	for parents.Len() > 0 {
+		node := parents.Pop()
+		if start != nil && bytes.Compare(node.key[:], start[:]) < 0 {
+			return
+		}
+		if !fn(node.key, node.value) {
+			return
+		}
+
+		// Extend the nodes to traverse by all children to the right of
+		// the current node's left child.
+		for node := node.left; node != nil; node = node.right {
+			parents.Push(node)
+		}
+	}
+}
+
+// getByIndex returns the node at the passed index in ascending order of the
+// keys in the treap rooted at the passed node.  It returns nil when the index is
+// out of range.
+func getByIndex(root *treapNode, index int) *treapNode {
+	if index < 0 || index >= subtreeSize(root) {
+		return nil
+	}
+	for node := root; node != nil; {
+		// Traverse left when the index is within the left subtree or
+		// right, after skipping the left subtree and the node itself,
+		// when it is within the right subtree.
+		leftSize := subtreeSize(node.left)
+		if index < leftSize {
+			node = node.left
+			continue
+		}
+		if index > leftSize {
+			index -= leftSize + 1
+			node = node.right
+			continue
+		}
+		return node
+	}
+	return nil
+}
+
+// rank returns the index of the passed key in ascending order of the keys in
+// the treap rooted at the passed node.  It returns -1 when the key does not
+// exist.
+func rank(root *treapNode, key Key) int {
+	var index int
+	for node := root; node != nil; {
+		// Traverse left or right depending on the result of the
+		// comparison while counting the keys which are less than the
+		// key when moving right.
+		compareResult := bytes.Compare(key[:], node.key[:])
+		if compareResult < 0 {
+			node = node.left
+			continue
+		}
+		if compareResult > 0 {
+			index += subtreeSize(node.left) + 1
+			node = node.right
+			continue
+		}
+		return index + subtreeSize(node.left)
+	}
+	return -1
+}
@@ -6,6 +6,7 @@
 package tickettreap
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"reflect"
@@ -126,3 +127,103 @@ func init() {
 	// Force the same pseudo random numbers for each test run.
 	rng.Seed(0)
 }
+
+// testForEachReverseRange ensures the passed reverse range iteration function,
+// which must be for a treap holding the keys 0 through 99, iterates the
+// expected keys in descending order and stops iterating early.
+func testForEachReverseRange(t *testing.T, forEachReverseRange func(start, end *Key, fn func(k Key, v *Value) bool)) {
+	keyPtr := func(ui uint32) *Key {
+		key := uint32ToKey(ui)
+		return &key
+	}
+	tests := []struct {
+		name       string
+		start, end *Key
+		high, low  int
+	}{
+		{"unbounded", nil, nil, 99, 0},
+		{"bounded", keyPtr(20), keyPtr(50), 49, 20},
+		{"end only", nil, keyPtr(10), 9, 0},
+		{"start only", keyPtr(95), nil, 99, 95},
+		{"end past last key", keyPtr(90), keyPtr(1000), 99, 90},
+		{"empty", keyPtr(50), keyPtr(50), -1, 0},
+	}
+	for _, test := range tests {
+		want := test.high
+		forEachReverseRange(test.start, test.end, func(k Key, v *Value) bool {
+			wantKey := uint32ToKey(uint32(want))
+			if !bytes.Equal(k[:], wantKey[:]) {
+				t.Fatalf("%s: unexpected key - got %x, want %x",
+					test.name, k, wantKey)
+			}
+			if v.Height != uint32(want) {
+				t.Fatalf("%s: unexpected value - got %v, want %v",
+					test.name, v.Height, want)
+			}
+			want--
+			return true
+		})
+		if want != test.low-1 {
+			t.Fatalf("%s: unexpected iterate count - got %d, want %d",
+				test.name, test.high-want, test.high-test.low+1)
+		}
+	}
+
+	// Ensure iteration exits early on false return by caller.
+	var numIterated int
+	forEachReverseRange(nil, nil, func(k Key, v *Value) bool {
+		numIterated++
+		return numIterated != 5
+	})
+	if numIterated != 5 {
+		t.Fatalf("unexpected iterate count after stopping - got %d, "+
+			"want 5", numIterated)
+	}
+}
+
+// checkSubtreeSizes ensures the size of every node in the subtree rooted at the
+// passed node matches the number of nodes in its subtree and returns the size
+// of the subtree.
+func checkSubtreeSizes(t *testing.T, node *treapNode) int {
+	if node == nil {
+		return 0
+	}
+	size := checkSubtreeSizes(t, node.left) +
+		checkSubtreeSizes(t, node.right) + 1
+	if node.size != size {
+		t.Fatalf("unexpected subtree size for key %x - got %d, want %d",
+			node.key, node.size, size)
+	}
+	return size
+}
+
+// testGetByIndex ensures the passed index lookup and rank functions of a treap
+// that holds exactly the passed keys, in ascending order, with values whose
+// heights match the keys work as expected.
+func testGetByIndex(t *testing.T, keys []uint32, getByIndex func(i int) (Key, *Value), rank func(key Key) int) {
+	for i, n := range keys {
+		wantKey := uint32ToKey(n)
+		gotKey, gotValue := getByIndex(i)
+		if !bytes.Equal(gotKey[:], wantKey[:]) {
+			t.Fatalf("GetByIndex #%d: unexpected key - got %x, want %x",
+				i, gotKey, wantKey)
+		}
+		if gotValue == nil || gotValue.Height != n {
+			t.Fatalf("GetByIndex #%d: unexpected value - got %v, want "+
+				"height %d", i, gotValue, n)
+		}
+		if gotRank := rank(wantKey); gotRank != i {
+			t.Fatalf("Rank #%d: unexpected rank - got %d, want %d", i,
+				gotRank, i)
+		}
+	}
+
+	// Ensure out of range indices do not return a value.
+	for _, i := range []int{-1, len(keys), len(keys) + 1} {
+		if gotKey, gotValue := getByIndex(i); gotValue != nil ||
+			gotKey != (Key{}) {
+			t.Fatalf("GetByIndex #%d: unexpected key %x and value %v "+
+				"for out of range index", i, gotKey, gotValue)
+		}
+	}
+}
@@ -16,6 +16,7 @@ func cloneTreapNode(node *treapNode) *treapNode {
 		key:      node.key,
 		value:    node.value,
 		priority: node.priority,
+		size:     node.size,
 		left:     node.left,
 		right:    node.right,
 	}
@@ -105,6 +106,22 @@ func (t *Immutable) Get(key Key) *Value {
 	return nil
 }
 
+// GetByIndex returns the key/value pair at the passed index in ascending order
+// of the keys.  The function will return a nil value when the index is out of
+// range.  It is O(log n) since each node tracks the size of its subtree.
+func (t *Immutable) GetByIndex(index int) (Key, *Value) {
+	if node := getByIndex(t.root, index); node != nil {
+		return node.key, node.value
+	}
+	return Key{}, nil
+}
+
+// Rank returns the index of the passed key in ascending order of the keys.  The
+// function will return -1 when the key does not exist.
+func (t *Immutable) Rank(key Key) int {
+	return rank(t.root, key)
+}
+
 // Put inserts the passed key/value pair.  Passing a nil value will result in a
 // NOOP.
 func (t *Immutable) Put(key Key, value *Value) *Immutable {
@@ -162,7 +179,8 @@ func (t *Immutable) Put(key Key, value *Value) *Immutable {
 		return newImmutable(newRoot, t.count, t.totalSize)
 	}
 
-	// Link the new node into the binary tree in the correct position.
+	// Link the new node into the binary tree in the correct position and
+	// account for it in the subtree sizes of all of its ancestors.
 	node := newTreapNode(key, value, rng.Int())
 	parent := parents.At(0)
 	if compareResult < 0 {
@@ -170,6 +188,9 @@ func (t *Immutable) Put(key Key, value *Value) *Immutable {
 	} else {
 		parent.right = node
 	}
+	for i := 0; i < parents.Len(); i++ {
+		parents.At(i).size++
+	}
 
 	// Perform any rotations needed to maintain the min-heap and replace
 	// the ancestors up to and including the tree root.
@@ -189,6 +210,8 @@ func (t *Immutable) Put(key Key, value *Value) *Immutable {
 		} else {
 			node.left, parent.right = parent, node.left
 		}
+		parent.updateSize()
+		node.updateSize()
 
 		// Either set the new root of the tree when there is no
 		// grandparent or relink the grandparent to the node based on
@@ -266,6 +289,11 @@ func (t *Immutable) Delete(key Key) *Immutable {
 	delNode = newParents.Pop()
 	parent = newParents.At(0)
 
+	// All ancestors of the node to delete lose it from their subtrees.
+	for i := 0; i < newParents.Len(); i++ {
+		newParents.At(i).size--
+	}
+
 	// Perform rotations to move the node to delete to a leaf position while
 	// maintaining the min-heap while replacing the modified children.
 	var child *treapNode
@@ -289,12 +317,17 @@ func (t *Immutable) Delete(key Key) *Immutable {
 		// is on.  This has the effect of moving the node to delete
 		// towards the bottom of the tree while maintaining the
 		// min-heap.
+		//
+		// The child takes the place of the node in its subtree, which
+		// loses the node once it is deleted.
 		child = cloneTreapNode(child)
 		if isLeft {
 			child.right, delNode.left = delNode, child.right
 		} else {
 			child.left, delNode.right = delNode, child.left
 		}
+		child.size = delNode.size - 1
+		delNode.updateSize()
 
 		// Either set the new root of the tree when there is no
 		// grandparent or relink the grandparent to the node based on
@@ -351,6 +384,20 @@ func (t *Immutable) ForEach(fn func(k Key, v *Value) bool) {
 	}
 }
 
+// ForEachReverse invokes the passed function with every key/value pair in the
+// treap in descending order.
+func (t *Immutable) ForEachReverse(fn func(k Key, v *Value) bool) {
+	forEachReverse(t.root, nil, nil, fn)
+}
+
+// ForEachReverseRange invokes the passed function with every key/value pair in
+// the treap whose key is greater than or equal to the start key and less than
+// the end key in descending order.  A nil start or end key leaves the
+// respective side of the range unbounded.
+func (t *Immutable) ForEachReverseRange(start, end *Key, fn func(k Key, v *Value) bool) {
+	forEachReverse(t.root, start, end, fn)
+}
+
 // FetchWinnersAndExpired is a ticket database specific function which iterates
 // over the entire treap and finds winners at selected indexes and all tickets
 // whose height is less than or equal to the passed height. These are returned
@@ -420,6 +420,86 @@ func TestImmutableForEachStopIterator(t *testing.T) {
 	}
 }
 
+// TestImmutableForEachReverse ensures reverse iteration of an immutable treap visits
+// the expected keys in descending order.
+func TestImmutableForEachReverse(t *testing.T) {
+	t.Parallel()
+
+	// Insert keys out of order so the treap is not degenerate.
+	numItems := 100
+	testTreap := NewImmutable()
+	for i := 0; i < numItems; i++ {
+		n := uint32(i * 37 % numItems)
+		key := uint32ToKey(n)
+		value := &Value{Height: n}
+		testTreap = testTreap.Put(key, value)
+	}
+
+	// Ensure ForEachReverse iterates all keys in descending order.
+	want := numItems - 1
+	testTreap.ForEachReverse(func(k Key, v *Value) bool {
+		wantKey := uint32ToKey(uint32(want))
+		if !bytes.Equal(k[:], wantKey[:]) {
+			t.Fatalf("ForEachReverse #%d: unexpected key - got %x, "+
+				"want %x", numItems-1-want, k, wantKey)
+		}
+		want--
+		return true
+	})
+	if want != -1 {
+		t.Fatalf("ForEachReverse: unexpected iterate count - got %d, "+
+			"want %d", numItems-1-want, numItems)
+	}
+
+	testForEachReverseRange(t, testTreap.ForEachReverseRange)
+}
+
+// TestImmutableGetByIndex ensures looking up keys by their index and the rank
+// of keys work as expected for an immutable treap, including after deleting
+// keys.
+func TestImmutableGetByIndex(t *testing.T) {
+	t.Parallel()
+
+	// Ensure an empty treap does not return any values.
+	testTreap := NewImmutable()
+	testGetByIndex(t, nil, testTreap.GetByIndex, testTreap.Rank)
+
+	// Insert the even keys out of order so the treap is not degenerate.
+	numItems := 100
+	for i := 0; i < numItems; i++ {
+		n := uint32(i * 37 % numItems * 2)
+		testTreap = testTreap.Put(uint32ToKey(n), &Value{Height: n})
+	}
+	keys := make([]uint32, 0, numItems)
+	for i := 0; i < numItems; i++ {
+		keys = append(keys, uint32(i*2))
+	}
+	testGetByIndex(t, keys, testTreap.GetByIndex, testTreap.Rank)
+
+	// Ensure keys which do not exist do not have a rank.
+	for i := 1; i < numItems*2; i += 2 {
+		if gotRank := testTreap.Rank(uint32ToKey(uint32(i))); gotRank != -1 {
+			t.Fatalf("Rank: unexpected rank for missing key %d - got "+
+				"%d, want -1", i, gotRank)
+		}
+	}
+
+	// Delete every third key and ensure the remaining keys are looked up by
+	// their new indices while the original treap is unchanged.
+	treapSnap := testTreap
+	var remaining []uint32
+	for i, n := range keys {
+		if i%3 == 0 {
+			testTreap = testTreap.Delete(uint32ToKey(n))
+			continue
+		}
+		remaining = append(remaining, n)
+	}
+	checkSubtreeSizes(t, testTreap.root)
+	testGetByIndex(t, remaining, testTreap.GetByIndex, testTreap.Rank)
+	testGetByIndex(t, keys, treapSnap.GetByIndex, treapSnap.Rank)
+}
+
 // TestImmutableSnapshot ensures that immutable treaps are actually immutable by
 // keeping a reference to the previous treap, performing a mutation, and then
 // ensuring the referenced treap does not have the mutation applied.
@@ -139,7 +139,8 @@ func (t *Mutable) Put(key Key, value *Value) {
 		return
 	}
 
-	// Link the new node into the binary tree in the correct position.
+	// Link the new node into the binary tree in the correct position and
+	// account for it in the subtree sizes of all of its ancestors.
 	node := newTreapNode(key, value, rng.Int())
 	t.count++
 	t.totalSize += nodeSize(node)
@@ -149,6 +150,9 @@ func (t *Mutable) Put(key Key, value *Value) {
 	} else {
 		parent.right = node
 	}
+	for i := 0; i < parents.Len(); i++ {
+		parents.At(i).size++
+	}
 
 	// Perform any rotations needed to maintain the min-heap.
 	for parents.Len() > 0 {
@@ -166,6 +170,8 @@ func (t *Mutable) Put(key Key, value *Value) {
 		} else {
 			node.left, parent.right = parent, node.left
 		}
+		parent.updateSize()
+		node.updateSize()
 		t.relinkGrandparent(node, parent, parents.At(0))
 	}
 }
@@ -188,6 +194,16 @@ func (t *Mutable) Delete(key Key) {
 		return
 	}
 
+	// All ancestors of the node to delete lose it from their subtrees.
+	for ancestor := t.root; ancestor != node; {
+		ancestor.size--
+		if bytes.Compare(key[:], ancestor.key[:]) < 0 {
+			ancestor = ancestor.left
+		} else {
+			ancestor = ancestor.right
+		}
+	}
+
 	// Perform rotations to move the node to delete to a leaf position while
 	// maintaining the min-heap.
 	var isLeft bool
@@ -212,11 +228,16 @@ func (t *Mutable) Delete(key Key) {
 		// is on.  This has the effect of moving the node to delete
 		// towards the bottom of the tree while maintaining the
 		// min-heap.
+		//
+		// The child takes the place of the node in its subtree, which
+		// loses the node once it is deleted.
 		if isLeft {
 			child.right, node.left = node, child.right
 		} else {
 			child.left, node.right = node, child.left
 		}
+		child.size = node.size - 1
+		node.updateSize()
 		t.relinkGrandparent(child, node, parent)
 
 		// The parent for the node to delete is now what was previously
@@ -235,6 +256,22 @@ func (t *Mutable) Delete(key Key) {
 	t.totalSize -= nodeSize(node)
 }
 
+// GetByIndex returns the key/value pair at the passed index in ascending order
+// of the keys.  The function will return a nil value when the index is out of
+// range.  It is O(log n) since each node tracks the size of its subtree.
+func (t *Mutable) GetByIndex(index int) (Key, *Value) {
+	if node := getByIndex(t.root, index); node != nil {
+		return node.key, node.value
+	}
+	return Key{}, nil
+}
+
+// Rank returns the index of the passed key in ascending order of the keys.  The
+// function will return -1 when the key does not exist.
+func (t *Mutable) Rank(key Key) int {
+	return rank(t.root, key)
+}
+
 // ForEach invokes the passed function with every key/value pair in the treap
 // in ascending order.
 func (t *Mutable) ForEach(fn func(k Key, v *Value) bool) {
@@ -259,6 +296,20 @@ func (t *Mutable) ForEach(fn func(k Key, v *Value) bool) {
 	}
 }
 
+// ForEachReverse invokes the passed function with every key/value pair in the
+// treap in descending order.
+func (t *Mutable) ForEachReverse(fn func(k Key, v *Value) bool) {
+	forEachReverse(t.root, nil, nil, fn)
+}
+
+// ForEachReverseRange invokes the passed function with every key/value pair in
+// the treap whose key is greater than or equal to the start key and less than
+// the end key in descending order.  A nil start or end key leaves the
+// respective side of the range unbounded.
+func (t *Mutable) ForEachReverseRange(start, end *Key, fn func(k Key, v *Value) bool) {
+	forEachReverse(t.root, start, end, fn)
+}
+
 // Reset efficiently removes all items in the treap.
 func (t *Mutable) Reset() {
 	t.count = 0
@@ -266,6 +317,21 @@ func (t *Mutable) Reset() {
 	t.root = nil
 }
 
+// Freeze returns an immutable treap which holds all of the items in the treap
+// and resets the treap.  The nodes are transferred to the immutable treap rather
+// than copied, so the conversion is O(1).  This allows the cheaper in-place
+// modifications of a mutable treap to be used while building a large treap,
+// such as during initial chain sync, and the result to be shared safely once
+// it is complete.
+//
+// The treap is reset since further modifications in place would otherwise also
+// modify the returned immutable treap.
+func (t *Mutable) Freeze() *Immutable {
+	immutable := newImmutable(t.root, t.count, t.totalSize)
+	t.Reset()
+	return immutable
+}
+
 // NewMutable returns a new empty mutable treap ready for use.  See the
 // documentation for the Mutable structure for more details.
 func NewMutable() *Mutable {
@@ -470,3 +470,118 @@ func TestMutableForEachStopIterator(t *testing.T) {
 			numIterated, numItems/2)
 	}
 }
+
+// TestMutableForEachReverse ensures reverse iteration of a mutable treap visits
+// the expected keys in descending order.
+func TestMutableForEachReverse(t *testing.T) {
+	t.Parallel()
+
+	// Insert keys out of order so the treap is not degenerate.
+	numItems := 100
+	testTreap := NewMutable()
+	for i := 0; i < numItems; i++ {
+		n := uint32(i * 37 % numItems)
+		key := uint32ToKey(n)
+		value := &Value{Height: n}
+		testTreap.Put(key, value)
+	}
+
+	// Ensure ForEachReverse iterates all keys in descending order.
+	want := numItems - 1
+	testTreap.ForEachReverse(func(k Key, v *Value) bool {
+		wantKey := uint32ToKey(uint32(want))
+		if !bytes.Equal(k[:], wantKey[:]) {
+			t.Fatalf("ForEachReverse #%d: unexpected key - got %x, "+
+				"want %x", numItems-1-want, k, wantKey)
+		}
+		want--
+		return true
+	})
+	if want != -1 {
+		t.Fatalf("ForEachReverse: unexpected iterate count - got %d, "+
+			"want %d", numItems-1-want, numItems)
+	}
+
+	testForEachReverseRange(t, testTreap.ForEachReverseRange)
+}
+
+// TestMutableGetByIndex ensures looking up keys by their index and the rank of
+// keys work as expected for a mutable treap, including after deleting keys.
+func TestMutableGetByIndex(t *testing.T) {
+	t.Parallel()
+
+	// Ensure an empty treap does not return any values.
+	testTreap := NewMutable()
+	testGetByIndex(t, nil, testTreap.GetByIndex, testTreap.Rank)
+
+	// Insert the even keys out of order so the treap is not degenerate.
+	numItems := 100
+	for i := 0; i < numItems; i++ {
+		n := uint32(i * 37 % numItems * 2)
+		testTreap.Put(uint32ToKey(n), &Value{Height: n})
+	}
+	keys := make([]uint32, 0, numItems)
+	for i := 0; i < numItems; i++ {
+		keys = append(keys, uint32(i*2))
+	}
+	testGetByIndex(t, keys, testTreap.GetByIndex, testTreap.Rank)
+
+	// Ensure keys which do not exist do not have a rank.
+	for i := 1; i < numItems*2; i += 2 {
+		if gotRank := testTreap.Rank(uint32ToKey(uint32(i))); gotRank != -1 {
+			t.Fatalf("Rank: unexpected rank for missing key %d - got "+
+				"%d, want -1", i, gotRank)
+		}
+	}
+
+	// Delete every third key and ensure the remaining keys are looked up by
+	// their new indices.
+	var remaining []uint32
+	for i, n := range keys {
+		if i%3 == 0 {
+			testTreap.Delete(uint32ToKey(n))
+			continue
+		}
+		remaining = append(remaining, n)
+	}
+	checkSubtreeSizes(t, testTreap.root)
+	testGetByIndex(t, remaining, testTreap.GetByIndex, testTreap.Rank)
+}
+
+// TestMutableFreeze ensures freezing a mutable treap returns an immutable treap
+// with all of its items and resets the mutable treap.
+func TestMutableFreeze(t *testing.T) {
+	t.Parallel()
+
+	// Insert keys out of order so the treap is not degenerate.
+	numItems := 100
+	testTreap := NewMutable()
+	wantTreap := NewImmutable()
+	for i := 0; i < numItems; i++ {
+		n := uint32(i * 37 % numItems)
+		key := uint32ToKey(n)
+		value := &Value{Height: n}
+		testTreap.Put(key, value)
+		wantTreap = wantTreap.Put(key, value)
+	}
+	wantSize := testTreap.Size()
+
+	frozen := testTreap.Freeze()
+	assertTreapsEqual(t, "Freeze", frozen, wantTreap)
+	if frozen.Size() != wantSize {
+		t.Fatalf("Size: unexpected byte size - got %d, want %d",
+			frozen.Size(), wantSize)
+	}
+
+	// Ensure the mutable treap is reset and modifying it does not modify
+	// the frozen treap.
+	if testTreap.Len() != 0 || testTreap.Size() != 0 {
+		t.Fatalf("Freeze: mutable treap was not reset - got length %d "+
+			"and size %d", testTreap.Len(), testTreap.Size())
+	}
+	testTreap.Put(uint32ToKey(0), &Value{Height: 1000})
+	if v := frozen.Get(uint32ToKey(0)); v == nil || v.Height != 0 {
+		t.Fatalf("Get: frozen treap was modified - got %v", v)
+	}
+	assertTreapsEqual(t, "Freeze after modification", frozen, wantTreap)
+}
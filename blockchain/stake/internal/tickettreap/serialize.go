@@ -0,0 +1,141 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package tickettreap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+const (
+	// serializedCountSize is the size of the number of entries which
+	// prefixes a serialized treap.
+	serializedCountSize = 4
+
+	// serializedEntrySize is the size of each serialized key/value pair.
+	// It consists of the key, 4 bytes for the height, and 1 byte for the
+	// flags.
+	serializedEntrySize = chainhash.HashSize + 5
+)
+
+// These constants define the bits of the flags byte of serialized values.  They
+// match the bits used for ticket flags by the ticket database.
+const (
+	flagMissed  = 1 << 0
+	flagRevoked = 1 << 1
+	flagSpent   = 1 << 2
+	flagExpired = 1 << 3
+
+	// allFlags is the combination of all of the defined flags.
+	allFlags = flagMissed | flagRevoked | flagSpent | flagExpired
+)
+
+// Serialize writes the key/value pairs of the treap to the passed writer as a
+// single compact snapshot which can be restored with ParseTreap.
+//
+// The serialized format is:
+//
+//   <count><key><height><flags>...
+//
+//   Field   Type     Size
+//   count   uint32   4 bytes
+//   key     Key      32 bytes
+//   height  uint32   4 bytes
+//   flags   byte     1 byte
+//
+// The count is the number of key/value pairs which follow in ascending order
+// of their keys.  All integers are encoded in little endian.  The flags are the
+// bits 0 through 3 for the missed, revoked, spent, and expired flags of the
+// value respectively.
+func (t *Immutable) Serialize(w io.Writer) error {
+	if uint64(t.count) > math.MaxUint32 {
+		return fmt.Errorf("treap with %d entries is too large to "+
+			"serialize", t.count)
+	}
+
+	b := make([]byte, serializedCountSize+t.count*serializedEntrySize)
+	binary.LittleEndian.PutUint32(b, uint32(t.count))
+	offset := serializedCountSize
+	t.ForEach(func(k Key, v *Value) bool {
+		copy(b[offset:], k[:])
+		offset += chainhash.HashSize
+		binary.LittleEndian.PutUint32(b[offset:], v.Height)
+		offset += 4
+
+		var flags byte
+		if v.Missed {
+			flags |= flagMissed
+		}
+		if v.Revoked {
+			flags |= flagRevoked
+		}
+		if v.Spent {
+			flags |= flagSpent
+		}
+		if v.Expired {
+			flags |= flagExpired
+		}
+		b[offset] = flags
+		offset++
+		return true
+	})
+
+	_, err := w.Write(b)
+	return err
+}
+
+// ParseTreap returns the immutable treap restored from a snapshot written by
+// Serialize which is read from the passed reader.
+//
+// Since the key/value pairs of the snapshot are in ascending order, the treap
+// is built directly in linear time with an ImmutableBuilder.  Readers
+// which are not buffered, such as files, should be wrapped with a buffered
+// reader since each pair is read individually.
+func ParseTreap(r io.Reader) (*Immutable, error) {
+	var buf [serializedEntrySize]byte
+	if _, err := io.ReadFull(r, buf[:serializedCountSize]); err != nil {
+		return nil, err
+	}
+	count := binary.LittleEndian.Uint32(buf[:serializedCountSize])
+
+	var builder ImmutableBuilder
+	var prevKey Key
+	for i := uint32(0); i < count; i++ {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		var key Key
+		copy(key[:], buf[:chainhash.HashSize])
+		if i > 0 && bytes.Compare(key[:], prevKey[:]) <= 0 {
+			return nil, fmt.Errorf("serialized treap key %x is not "+
+				"greater than the previous key %x", key, prevKey)
+		}
+		flags := buf[serializedEntrySize-1]
+		if flags&^allFlags != 0 {
+			return nil, fmt.Errorf("serialized treap value for key %x "+
+				"has unknown flags %#x", key, flags)
+		}
+		value := &Value{
+			Height:  binary.LittleEndian.Uint32(buf[chainhash.HashSize:]),
+			Missed:  flags&flagMissed != 0,
+			Revoked: flags&flagRevoked != 0,
+			Spent:   flags&flagSpent != 0,
+			Expired: flags&flagExpired != 0,
+		}
+		builder.add(key, value)
+		prevKey = key
+	}
+
+	return builder.Immutable(), nil
+}
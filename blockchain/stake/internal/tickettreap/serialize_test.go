@@ -0,0 +1,162 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package tickettreap
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// checkTreapInvariants ensures the keys of the passed subtree are ordered, the
+// priorities of its nodes satisfy the min-heap property, and the subtree sizes
+// of its nodes are accurate.  It returns the number of nodes in the subtree.
+func checkTreapInvariants(t *testing.T, node *treapNode, min, max *Key) int {
+	if node == nil {
+		return 0
+	}
+	if min != nil && bytes.Compare(node.key[:], min[:]) <= 0 ||
+		max != nil && bytes.Compare(node.key[:], max[:]) >= 0 {
+		t.Fatalf("key %x is out of order", node.key)
+	}
+	for _, child := range []*treapNode{node.left, node.right} {
+		if child != nil && child.priority < node.priority {
+			t.Fatalf("priority of key %x is less than the priority "+
+				"of its parent %x", child.key, node.key)
+		}
+	}
+	size := checkTreapInvariants(t, node.left, min, &node.key) + 1 +
+		checkTreapInvariants(t, node.right, &node.key, max)
+	if node.size != size {
+		t.Fatalf("subtree size of key %x is %d instead of %d", node.key,
+			node.size, size)
+	}
+	return size
+}
+
+// TestImmutableSerialize ensures serializing an immutable treap and parsing
+// the result produces an equivalent and valid treap.
+func TestImmutableSerialize(t *testing.T) {
+	t.Parallel()
+
+	for _, numItems := range []int{0, 1, 2, 1000} {
+		testTreap := NewImmutable()
+		for i := 0; i < numItems; i++ {
+			value := &Value{
+				Height:  uint32(i),
+				Missed:  i%2 == 0,
+				Revoked: i%3 == 0,
+				Spent:   i%5 == 0,
+				Expired: i%7 == 0,
+			}
+			testTreap = testTreap.Put(uint32ToKey(uint32(i*3)), value)
+		}
+
+		var buf bytes.Buffer
+		if err := testTreap.Serialize(&buf); err != nil {
+			t.Fatalf("Serialize #%d: unexpected error: %v", numItems,
+				err)
+		}
+		wantLen := serializedCountSize + numItems*serializedEntrySize
+		if buf.Len() != wantLen {
+			t.Fatalf("Serialize #%d: unexpected length - got %d, "+
+				"want %d", numItems, buf.Len(), wantLen)
+		}
+
+		parsed, err := ParseTreap(&buf)
+		if err != nil {
+			t.Fatalf("ParseTreap #%d: unexpected error: %v", numItems,
+				err)
+		}
+		if gotLen := parsed.Len(); gotLen != numItems {
+			t.Fatalf("Len #%d: unexpected length - got %d, want %d",
+				numItems, gotLen, numItems)
+		}
+		if gotSize := parsed.Size(); gotSize != testTreap.Size() {
+			t.Fatalf("Size #%d: unexpected byte size - got %d, want "+
+				"%d", numItems, gotSize, testTreap.Size())
+		}
+		if n := checkTreapInvariants(t, parsed.root, nil, nil); n != numItems {
+			t.Fatalf("#%d: unexpected number of nodes - got %d, "+
+				"want %d", numItems, n, numItems)
+		}
+
+		// Ensure the parsed treap iterates the same pairs in the same
+		// order and the values can be looked up.
+		var wantKeys, gotKeys []Key
+		var wantValues, gotValues []Value
+		testTreap.ForEach(func(k Key, v *Value) bool {
+			wantKeys = append(wantKeys, k)
+			wantValues = append(wantValues, *v)
+			return true
+		})
+		parsed.ForEach(func(k Key, v *Value) bool {
+			gotKeys = append(gotKeys, k)
+			gotValues = append(gotValues, *v)
+			if got := parsed.Get(k); got != v {
+				t.Fatalf("Get #%d: unexpected value for key %x",
+					numItems, k)
+			}
+			return true
+		})
+		if !reflect.DeepEqual(gotKeys, wantKeys) ||
+			!reflect.DeepEqual(gotValues, wantValues) {
+			t.Fatalf("ForEach #%d: parsed treap does not match the "+
+				"serialized treap", numItems)
+		}
+	}
+}
+
+// TestParseTreapErrors ensures parsing malformed serialized treaps returns the
+// expected errors.
+func TestParseTreapErrors(t *testing.T) {
+	t.Parallel()
+
+	testTreap := NewImmutable()
+	for i := 0; i < 3; i++ {
+		testTreap = testTreap.Put(uint32ToKey(uint32(i)),
+			&Value{Height: uint32(i)})
+	}
+	var buf bytes.Buffer
+	if err := testTreap.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+	serialized := buf.Bytes()
+
+	// Ensure truncated snapshots are rejected.
+	truncations := []struct {
+		size    int
+		wantErr error
+	}{
+		{0, io.EOF},
+		{serializedCountSize - 1, io.ErrUnexpectedEOF},
+		{serializedCountSize, io.ErrUnexpectedEOF},
+		{serializedCountSize + serializedEntrySize - 1, io.ErrUnexpectedEOF},
+		{len(serialized) - 1, io.ErrUnexpectedEOF},
+	}
+	for _, test := range truncations {
+		_, err := ParseTreap(bytes.NewReader(serialized[:test.size]))
+		if err != test.wantErr {
+			t.Fatalf("ParseTreap (size %d): unexpected error - got %v, "+
+				"want %v", test.size, err, test.wantErr)
+		}
+	}
+
+	// Ensure keys which are not in strictly ascending order are rejected.
+	unordered := append([]byte(nil), serialized...)
+	second := unordered[serializedCountSize+serializedEntrySize:]
+	copy(second, unordered[serializedCountSize:][:serializedEntrySize])
+	if _, err := ParseTreap(bytes.NewReader(unordered)); err == nil {
+		t.Fatal("ParseTreap: did not receive error for duplicate keys")
+	}
+
+	// Ensure unknown flags are rejected.
+	badFlags := append([]byte(nil), serialized...)
+	badFlags[serializedCountSize+serializedEntrySize-1] = 1 << 4
+	if _, err := ParseTreap(bytes.NewReader(badFlags)); err == nil {
+		t.Fatal("ParseTreap: did not receive error for unknown flags")
+	}
+}
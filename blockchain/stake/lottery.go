@@ -9,8 +9,6 @@ package stake
 import (
 	"encoding/binary"
 	"fmt"
-	"math"
-	"sort"
 
 	"github.com/decred/dcrd/blockchain/stake/internal/tickettreap"
 	"github.com/decred/dcrd/chaincfg/chainhash"
@@ -158,11 +156,14 @@ func FindTicketIdxs(size int64, n int, prng *Hash256PRNG) ([]int, error) {
 	return findTicketIdxs(size, n, prng)
 }
 
-// fetchWinners is a ticket database specific function which iterates over the
-// entire treap and finds winners at selected indexes.  These are returned
-// as a slice of pointers to keys, which can be recast as []*chainhash.Hash.
-// Importantly, it maintains the list of winners in the same order as specified
-// in the original idxs passed to the function.
+// fetchWinners is a ticket database specific function which looks up the
+// winners at the selected indexes of the treap.  These are returned as a slice
+// of pointers to keys, which can be recast as []*chainhash.Hash.  Importantly,
+// it maintains the list of winners in the same order as specified in the
+// original idxs passed to the function.
+//
+// Each winner is looked up by its index in logarithmic time, so the cost does
+// not depend on the position of the winners within the treap.
 func fetchWinners(idxs []int, t *tickettreap.Immutable) ([]*tickettreap.Key, error) {
 	if idxs == nil {
 		return nil, fmt.Errorf("empty idxs list")
@@ -171,73 +172,17 @@ func fetchWinners(idxs []int, t *tickettreap.Immutable) ([]*tickettreap.Key, err
 		return nil, fmt.Errorf("missing or empty treap")
 	}
 
-	// maxInt returns the maximum integer from a list of integers.
-	maxInt := func(idxs []int) int {
-		max := math.MinInt32
-		for _, i := range idxs {
-			if i > max {
-				max = i
-			}
-		}
-		return max
-	}
-	max := maxInt(idxs)
-	if max >= t.Len() {
-		return nil, fmt.Errorf("idx %v out of bounds", max)
-	}
-
-	minInt := func(idxs []int) int {
-		min := math.MaxInt32
-		for _, i := range idxs {
-			if i < min {
-				min = i
-			}
-		}
-		return min
-	}
-	min := minInt(idxs)
-	if min < 0 {
-		return nil, fmt.Errorf("idx %v out of bounds", min)
-	}
-
-	originalIdxs := make([]int, len(idxs))
-	copy(originalIdxs[:], idxs[:])
-	sortedIdxs := sort.IntSlice(idxs)
-	sort.Sort(sortedIdxs)
-
-	// originalIdx returns the original index of the lucky
-	// number in the idxs slice, so that the order is correct.
-	originalIdx := func(idx int) int {
-		for i := range originalIdxs {
-			if idx == originalIdxs[i] {
-				return i
-			}
+	for _, idx := range idxs {
+		if idx < 0 || idx >= t.Len() {
+			return nil, fmt.Errorf("idx %v out of bounds", idx)
 		}
-
-		// This will cause a panic.  It should never, ever
-		// happen because the investigated index will always
-		// be in the original indexes.
-		return -1
 	}
 
-	idx := 0
-	winnerIdx := 0
 	winners := make([]*tickettreap.Key, len(idxs))
-	t.ForEach(func(k tickettreap.Key, v *tickettreap.Value) bool {
-		if idx > max {
-			return false
-		}
-
-		if idx == sortedIdxs[winnerIdx] {
-			winners[originalIdx(idx)] = &k
-			if winnerIdx+1 < len(sortedIdxs) {
-				winnerIdx++
-			}
-		}
-
-		idx++
-		return true
-	})
+	for i, idx := range idxs {
+		k, _ := t.GetByIndex(idx)
+		winners[i] = &k
+	}
 
 	return winners, nil
 }
@@ -97,6 +97,20 @@ func (sn *Node) MissedByBlock() []chainhash.Hash {
 	return missed
 }
 
+// ExpiredByBlock returns the tickets that expired in this block.  Note that
+// expired tickets are also considered missed, so they are included in the
+// tickets returned by MissedByBlock as well.
+func (sn *Node) ExpiredByBlock() []chainhash.Hash {
+	var expired []chainhash.Hash
+	for _, undo := range sn.databaseUndoUpdate {
+		if undo.Expired && !undo.Revoked {
+			expired = append(expired, undo.TicketHash)
+		}
+	}
+
+	return expired
+}
+
 // ExistsLiveTicket returns whether or not a ticket exists in the live ticket
 // treap for this stake node.
 func (sn *Node) ExistsLiveTicket(ticket chainhash.Hash) bool {
@@ -270,14 +284,41 @@ func LoadBestNode(dbTx database.Tx, height uint32, blockHash chainhash.Hash, hea
 		return nil, stakeRuleError(ErrDatabaseCorrupt, "best state corruption")
 	}
 
-	// Restore the best node treaps form the database.
+	// Restore the best node treaps from the snapshot written on shut down
+	// when it was taken at the best state since that avoids loading every
+	// ticket from the database buckets.  Otherwise, restore them from the
+	// buckets.  A snapshot which can't be read is not fatal since the
+	// buckets are always up to date.
 	node := new(Node)
 	node.height = height
 	node.params = params
-	node.liveTickets, err = ticketdb.DbLoadAllTickets(dbTx,
-		dbnamespace.LiveTicketsBucketName)
+	snapshot, err := ticketdb.DbFetchTreapSnapshot(dbTx)
 	if err != nil {
-		return nil, err
+		log.Warnf("Unable to load ticket treap snapshot: %v", err)
+		snapshot = nil
+	}
+	if snapshot != nil && snapshot.Hash == blockHash &&
+		snapshot.Height == height {
+
+		node.liveTickets = snapshot.Live
+		node.missedTickets = snapshot.Missed
+		node.revokedTickets = snapshot.Revoked
+	} else {
+		node.liveTickets, err = ticketdb.DbLoadAllTickets(dbTx,
+			dbnamespace.LiveTicketsBucketName)
+		if err != nil {
+			return nil, err
+		}
+		node.missedTickets, err = ticketdb.DbLoadAllTickets(dbTx,
+			dbnamespace.MissedTicketsBucketName)
+		if err != nil {
+			return nil, err
+		}
+		node.revokedTickets, err = ticketdb.DbLoadAllTickets(dbTx,
+			dbnamespace.RevokedTicketsBucketName)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if node.liveTickets.Len() != int(state.Live) {
 		return nil, stakeRuleError(ErrDatabaseCorrupt,
@@ -285,22 +326,12 @@ func LoadBestNode(dbTx database.Tx, height uint32, blockHash chainhash.Hash, hea
 				"%v in state but loaded %v)", int(state.Live),
 				node.liveTickets.Len()))
 	}
-	node.missedTickets, err = ticketdb.DbLoadAllTickets(dbTx,
-		dbnamespace.MissedTicketsBucketName)
-	if err != nil {
-		return nil, err
-	}
 	if node.missedTickets.Len() != int(state.Missed) {
 		return nil, stakeRuleError(ErrDatabaseCorrupt,
 			fmt.Sprintf("missed tickets corruption (got "+
 				"%v in state but loaded %v)", int(state.Missed),
 				node.missedTickets.Len()))
 	}
-	node.revokedTickets, err = ticketdb.DbLoadAllTickets(dbTx,
-		dbnamespace.RevokedTicketsBucketName)
-	if err != nil {
-		return nil, err
-	}
 	if node.revokedTickets.Len() != int(state.Revoked) {
 		return nil, stakeRuleError(ErrDatabaseCorrupt,
 			fmt.Sprintf("revoked tickets corruption (got "+
@@ -905,6 +936,22 @@ func WriteConnectedBestNode(dbTx database.Tx, node *Node, hash chainhash.Hash) e
 	})
 }
 
+// WriteTreapSnapshot writes a snapshot of the live, missed, and revoked ticket
+// treaps of the passed best node with the passed block hash to the database.
+// The next call to LoadBestNode restores the treaps from the snapshot instead
+// of loading every ticket from the database buckets as long as the best state
+// in the database is still at the same block.  It is intended to be called on
+// shut down.
+func WriteTreapSnapshot(dbTx database.Tx, node *Node, hash chainhash.Hash) error {
+	return ticketdb.DbPutTreapSnapshot(dbTx, &ticketdb.TreapSnapshot{
+		Hash:    hash,
+		Height:  node.height,
+		Live:    node.liveTickets,
+		Missed:  node.missedTickets,
+		Revoked: node.revokedTickets,
+	})
+}
+
 // WriteDisconnectedBestNode writes the newly connected best node to the database
 // under an atomic database transaction, performing all the necessary writes to
 // reverse the contents of the database buckets for live, missed, and revoked
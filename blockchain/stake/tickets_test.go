@@ -205,6 +205,10 @@ func nodesEqual(a *Node, b *Node) error {
 		return fmt.Errorf("missedbyblock were not equal between nodes; "+
 			"a: %x, b: %x", a.MissedByBlock(), b.MissedByBlock())
 	}
+	if !reflect.DeepEqual(a.ExpiredByBlock(), b.ExpiredByBlock()) {
+		return fmt.Errorf("expiredbyblock were not equal between nodes; "+
+			"a: %x, b: %x", a.ExpiredByBlock(), b.ExpiredByBlock())
+	}
 
 	return nil
 }
@@ -678,6 +682,28 @@ func TestTicketDBGeneral(t *testing.T) {
 					"in memory best node: %v", err.Error())
 			}
 			loadedNodesForward[i] = loadedNode
+
+			// Reload the node from a snapshot of its ticket treaps
+			// and make sure it's the same.  The snapshot is stale
+			// once the next node is written, so the next reload
+			// ensures such snapshots are not used.
+			err = WriteTreapSnapshot(dbTx, bestNode, *blockHash)
+			if err != nil {
+				return fmt.Errorf("failure writing the treap "+
+					"snapshot: %v", err.Error())
+			}
+			loadedNode, err = LoadBestNode(dbTx, bestNode.Height(),
+				*blockHash, header, simNetParams)
+			if err != nil {
+				return fmt.Errorf("failed to load the best node "+
+					"from the treap snapshot: %v", err.Error())
+			}
+			err = nodesEqual(loadedNode, bestNode)
+			if err != nil {
+				return fmt.Errorf("best node loaded from the treap "+
+					"snapshot was not same as in memory best "+
+					"node: %v", err.Error())
+			}
 		}
 
 		return nil
@@ -6,6 +6,7 @@
 package blockchain
 
 import (
+	"github.com/decred/dcrd/blockchain/stake"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/database"
 	"github.com/decred/dcrd/txscript"
@@ -18,11 +19,11 @@ import (
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) NextLotteryData() ([]chainhash.Hash, int, [6]byte, error) {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
+	b.indexLock.RLock()
+	sn := b.bestNode.stakeNode
+	b.indexLock.RUnlock()
 
-	return b.bestNode.stakeNode.Winners(), b.bestNode.stakeNode.PoolSize(),
-		b.bestNode.stakeNode.FinalState(), nil
+	return sn.Winners(), sn.PoolSize(), sn.FinalState(), nil
 }
 
 // lotteryDataForNode is a helper function that returns winning tickets
@@ -86,22 +87,22 @@ func (b *BlockChain) LotteryDataForBlock(hash *chainhash.Hash) ([]chainhash.Hash
 
 // LiveTickets returns all currently live tickets from the stake database.
 //
-// This function is NOT safe for concurrent access.
+// This function is safe for concurrent access.
 func (b *BlockChain) LiveTickets() ([]chainhash.Hash, error) {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	sn := b.bestNode.stakeNode
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 
 	return sn.LiveTickets(), nil
 }
 
 // MissedTickets returns all currently missed tickets from the stake database.
 //
-// This function is NOT safe for concurrent access.
+// This function is safe for concurrent access.
 func (b *BlockChain) MissedTickets() ([]chainhash.Hash, error) {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	sn := b.bestNode.stakeNode
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 
 	return sn.MissedTickets(), nil
 }
@@ -111,16 +112,18 @@ func (b *BlockChain) MissedTickets() ([]chainhash.Hash, error) {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) TicketsWithAddress(address dcrutil.Address) ([]chainhash.Hash, error) {
-	b.chainLock.RLock()
+	// The utxo lock guards the best node and ensures the utxo set matches
+	// the live tickets of the best node.
+	b.utxoLock.RLock()
+	defer b.utxoLock.RUnlock()
 	sn := b.bestNode.stakeNode
-	b.chainLock.RUnlock()
 
 	tickets := sn.LiveTickets()
 
 	var ticketsWithAddr []chainhash.Hash
 	err := b.db.View(func(dbTx database.Tx) error {
 		for _, hash := range tickets {
-			utxo, err := dbFetchUtxoEntry(dbTx, &hash)
+			utxo, err := b.utxoCache.fetchEntry(dbTx, &hash)
 			if err != nil {
 				return err
 			}
@@ -149,9 +152,9 @@ func (b *BlockChain) TicketsWithAddress(address dcrutil.Address) ([]chainhash.Ha
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) CheckLiveTicket(hash chainhash.Hash) bool {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	sn := b.bestNode.stakeNode
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 
 	return sn.ExistsLiveTicket(hash)
 }
@@ -161,9 +164,9 @@ func (b *BlockChain) CheckLiveTicket(hash chainhash.Hash) bool {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) CheckLiveTickets(hashes []chainhash.Hash) []bool {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	sn := b.bestNode.stakeNode
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 
 	existsSlice := make([]bool, len(hashes))
 	for i := range hashes {
@@ -177,9 +180,9 @@ func (b *BlockChain) CheckLiveTickets(hashes []chainhash.Hash) []bool {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) CheckExpiredTicket(hash chainhash.Hash) bool {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	sn := b.bestNode.stakeNode
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 
 	return sn.ExistsExpiredTicket(hash)
 }
@@ -189,9 +192,9 @@ func (b *BlockChain) CheckExpiredTicket(hash chainhash.Hash) bool {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) CheckExpiredTickets(hashes []chainhash.Hash) []bool {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	sn := b.bestNode.stakeNode
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 
 	existsSlice := make([]bool, len(hashes))
 	for i := range hashes {
@@ -208,14 +211,16 @@ func (b *BlockChain) CheckExpiredTickets(hashes []chainhash.Hash) []bool {
 // 256 blocks deep on mainnet, so the UTXO set should generally always have
 // the asked for transactions.
 func (b *BlockChain) TicketPoolValue() (dcrutil.Amount, error) {
-	b.chainLock.RLock()
+	// The utxo lock guards the best node and ensures the utxo set matches
+	// the live tickets of the best node.
+	b.utxoLock.RLock()
+	defer b.utxoLock.RUnlock()
 	sn := b.bestNode.stakeNode
-	b.chainLock.RUnlock()
 
 	var amt int64
 	err := b.db.View(func(dbTx database.Tx) error {
 		for _, hash := range sn.LiveTickets() {
-			utxo, err := dbFetchUtxoEntry(dbTx, &hash)
+			utxo, err := b.utxoCache.fetchEntry(dbTx, &hash)
 			if err != nil {
 				return err
 			}
@@ -229,3 +234,23 @@ func (b *BlockChain) TicketPoolValue() (dcrutil.Amount, error) {
 	}
 	return dcrutil.Amount(amt), nil
 }
+
+// WriteTicketSnapshot writes a snapshot of the live, missed, and revoked
+// tickets of the best node to the database so they can be restored without
+// loading every ticket from the stake database when the chain is loaded again.
+// It should be called on shutdown after the last block has been processed.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) WriteTicketSnapshot() error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.bestNode
+	stakeNode, err := b.fetchStakeNode(node)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(dbTx database.Tx) error {
+		return stake.WriteTreapSnapshot(dbTx, stakeNode, node.hash)
+	})
+}
@@ -285,6 +285,16 @@ func BlockOneCoinbasePaysTokens(tx *dcrutil.Tx,
 // tax to the developer organization.
 func CoinbasePaysTax(subsidyCache *SubsidyCache, tx *dcrutil.Tx, height uint32,
 	voters uint16, params *chaincfg.Params) error {
+	return coinbasePaysTaxTo(subsidyCache, tx, height, voters, params,
+		params.OrganizationPkScriptVersion, params.OrganizationPkScript)
+}
+
+// coinbasePaysTaxTo checks to see if a given block's coinbase correctly pays
+// tax to the passed output script, which is the organization script of the
+// network unless the treasury agenda is active.
+func coinbasePaysTaxTo(subsidyCache *SubsidyCache, tx *dcrutil.Tx, height uint32,
+	voters uint16, params *chaincfg.Params, taxScriptVersion uint16,
+	taxScript []byte) error {
 	// Taxes only apply from block 2 onwards.
 	if height <= 1 {
 		return nil
@@ -301,11 +311,11 @@ func CoinbasePaysTax(subsidyCache *SubsidyCache, tx *dcrutil.Tx, height uint32,
 	}
 
 	taxOutput := tx.MsgTx().TxOut[0]
-	if taxOutput.Version != params.OrganizationPkScriptVersion {
+	if taxOutput.Version != taxScriptVersion {
 		return ruleError(ErrNoTax,
 			"coinbase tax output uses incorrect script version")
 	}
-	if !bytes.Equal(taxOutput.PkScript, params.OrganizationPkScript) {
+	if !bytes.Equal(taxOutput.PkScript, taxScript) {
 		return ruleError(ErrNoTax,
 			"coinbase tax output script does not match the "+
 				"required script")
@@ -495,6 +495,26 @@ func (b *BlockChain) deploymentState(prevNode *blockNode, version uint32, deploy
 	return invalidState, DeploymentError(deploymentID)
 }
 
+// isAgendaActive returns whether or not the agenda with the passed stake
+// version and vote ID is active for the block AFTER the given node.  Agendas
+// which are not defined for the current network are never active.
+//
+// NOTE: The choice field of the threshold state is not examined here, so it
+// must only be used for agendas which only have a single choice that can be
+// active, which is yes.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) isAgendaActive(prevNode *blockNode, version uint32, voteID string) (bool, error) {
+	state, err := b.deploymentState(prevNode, version, voteID)
+	if err != nil {
+		if _, ok := err.(DeploymentError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return state.State == ThresholdActive, nil
+}
+
 // ThresholdState returns the current rule change threshold state of the given
 // deployment ID for the block AFTER the provided block hash.
 //
@@ -0,0 +1,452 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrutil"
+)
+
+// treasuryAgendaVersion is the stake version of the agenda which redirects the
+// block tax to the treasury.
+//
+// Once the agenda is active, the coinbase tax output of each block must pay to
+// the treasury script rather than the organization script of the network and
+// OP_TREASURY verifies the signatures of the transactions which spend treasury
+// outputs.  The consensus rules additionally require those transactions to be
+// signed by one of the treasury keys of the network.  The treasury is made up
+// of the unspent treasury outputs, so the usual utxo rules prevent it from
+// being overspent.
+const treasuryAgendaVersion = 5
+
+// TreasurySpend describes a transaction which spent from the treasury.
+type TreasurySpend struct {
+	Hash        chainhash.Hash // Hash of the transaction
+	BlockHash   chainhash.Hash // Hash of the block which applied the spend
+	BlockHeight int64          // Height of the block which applied the spend
+	Amount      int64          // Amount spent from the treasury in atoms
+}
+
+// treasuryState describes the state of the treasury as of a block of the main
+// chain along with the changes which were applied to it by the block.  Since a
+// block applies the regular transaction tree of its parent when it approves
+// it, the changes are those made by the regular transactions of the parent.
+//
+// The state of the parent of a block is what remains when the block is
+// disconnected, so the states also serve as the undo data of the treasury.
+type treasuryState struct {
+	balance int64
+	added   int64
+	spends  []TreasurySpend
+}
+
+// isTreasuryAgendaActive returns whether or not the treasury agenda is active
+// for the block AFTER the given node.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) isTreasuryAgendaActive(prevNode *blockNode) (bool, error) {
+	return b.isAgendaActive(prevNode, treasuryAgendaVersion,
+		chaincfg.VoteIDTreasury)
+}
+
+// coinbaseTaxScript returns the script version and public key script that the
+// coinbase tax output of the block AFTER the given node must pay to.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) coinbaseTaxScript(prevNode *blockNode) (uint16, []byte, error) {
+	active, err := b.isTreasuryAgendaActive(prevNode)
+	if err != nil {
+		return 0, nil, err
+	}
+	if active {
+		return 0, txscript.TreasuryScript(), nil
+	}
+	return b.chainParams.OrganizationPkScriptVersion,
+		b.chainParams.OrganizationPkScript, nil
+}
+
+// CoinbaseTaxScript returns the script version and public key script that the
+// coinbase tax output of the block AFTER the provided block hash must pay to.
+// It pays to the treasury once the treasury agenda is active and to the
+// organization script of the network otherwise.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CoinbaseTaxScript(hash *chainhash.Hash) (uint16, []byte, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node, ok := b.index[*hash]
+	if !ok {
+		return 0, nil, HashError(hash.String())
+	}
+	return b.coinbaseTaxScript(node)
+}
+
+// CheckTreasurySpends ensures that all inputs of the passed transaction which
+// spend treasury outputs are authorized by one of the treasury keys defined by
+// the network.  The signatures themselves are verified by OP_TREASURY when the
+// scripts are executed.  Treasury outputs may only be spent by regular
+// transactions.  Inputs whose referenced outputs are not available in the
+// passed view are ignored since they are reported by CheckTransactionInputs.
+//
+// The rules enforced by this function only apply once the treasury agenda is
+// active.
+func CheckTreasurySpends(tx *dcrutil.Tx, utxoView *UtxoViewpoint, params *chaincfg.Params) error {
+	msgTx := tx.MsgTx()
+	if IsCoinBaseTx(msgTx) {
+		return nil
+	}
+
+	isRegular := stake.DetermineTxType(msgTx) == stake.TxTypeRegular
+	for txInIdx, txIn := range msgTx.TxIn {
+		prevOut := &txIn.PreviousOutPoint
+		entry := utxoView.LookupEntry(&prevOut.Hash)
+		if entry == nil {
+			continue
+		}
+		if !txscript.IsTreasuryScript(entry.PkScriptByIndex(prevOut.Index)) {
+			continue
+		}
+
+		if !isRegular {
+			str := fmt.Sprintf("stake transaction %v input %d spends "+
+				"treasury output %v", tx.Hash(), txInIdx, prevOut)
+			return ruleError(ErrBadTreasurySpend, str)
+		}
+		pubKey, err := txscript.ExtractTreasurySpendPubKey(txIn.SignatureScript)
+		if err != nil {
+			str := fmt.Sprintf("transaction %v input %d spends "+
+				"treasury output %v: %v", tx.Hash(), txInIdx,
+				prevOut, err)
+			return ruleError(ErrBadTreasurySpend, str)
+		}
+		if !isTreasuryPubKey(pubKey, params) {
+			str := fmt.Sprintf("transaction %v input %d spends "+
+				"treasury output %v with unauthorized public key "+
+				"%x", tx.Hash(), txInIdx, prevOut, pubKey)
+			return ruleError(ErrBadTreasurySpend, str)
+		}
+	}
+
+	return nil
+}
+
+// isTreasuryPubKey returns whether or not the passed serialized public key is
+// one of the treasury keys defined by the network.
+func isTreasuryPubKey(pubKey []byte, params *chaincfg.Params) bool {
+	for _, treasuryPubKey := range params.TreasuryPubKeys {
+		if bytes.Equal(pubKey, treasuryPubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// treasuryChanges returns the amount added to the treasury and the spends from
+// it which are applied by connecting the passed block.  That is, the changes
+// made by the regular transaction tree of the parent when the block approves
+// it.  Any regular transaction may add to the treasury by paying to the
+// treasury script, which the coinbase does for the block tax.  The provided
+// spent txouts must be those of the block in the order they are spent, which
+// means the spent txouts for the regular transaction tree of the parent come
+// first.
+func treasuryChanges(block, parent *dcrutil.Block, stxos []spentTxOut) (int64, []TreasurySpend) {
+	regularTxTreeValid := dcrutil.IsFlagSet16(block.MsgBlock().Header.VoteBits,
+		dcrutil.BlockValid)
+	if !regularTxTreeValid || parent == nil || block.Height() == 0 {
+		return 0, nil
+	}
+
+	var added int64
+	var spends []TreasurySpend
+	var stxoIdx int
+	for txIdx, tx := range parent.Transactions() {
+		msgTx := tx.MsgTx()
+		for _, txOut := range msgTx.TxOut {
+			if txscript.IsTreasuryScript(txOut.PkScript) {
+				added += txOut.Value
+			}
+		}
+
+		// The coinbase does not spend anything.
+		if txIdx == 0 {
+			continue
+		}
+		var spent int64
+		for range msgTx.TxIn {
+			stxo := &stxos[stxoIdx]
+			stxoIdx++
+			if txscript.IsTreasuryScript(stxo.pkScript) {
+				spent += stxo.amount
+			}
+		}
+		if spent != 0 {
+			spends = append(spends, TreasurySpend{
+				Hash:        *tx.Hash(),
+				BlockHash:   *block.Hash(),
+				BlockHeight: block.Height(),
+				Amount:      spent,
+			})
+		}
+	}
+
+	return added, spends
+}
+
+// -----------------------------------------------------------------------------
+// The treasury state consists of an entry for each block of the main chain
+// which is connected while the treasury agenda is active.  The key is the hash
+// of the block.
+//
+// The serialized format is:
+//
+//   <balance><added><num spends><spend hash><spend amount>...
+//
+//   Field           Type              Size
+//   balance         int64             8 bytes
+//   added           int64             8 bytes
+//   num spends      uint32            4 bytes
+//   spends
+//     spend hash    chainhash.Hash    chainhash.HashSize
+//     spend amount  int64             8 bytes
+//
+// The block hash and height of each spend are those of the block the entry is
+// for, so they are not stored.
+// -----------------------------------------------------------------------------
+
+// serializeTreasuryState returns the serialization of the passed treasury state
+// according to the format described above.
+func serializeTreasuryState(state *treasuryState) []byte {
+	const spendSize = chainhash.HashSize + 8
+	serialized := make([]byte, 20+len(state.spends)*spendSize)
+	byteOrder := dbnamespace.ByteOrder
+	byteOrder.PutUint64(serialized[0:8], uint64(state.balance))
+	byteOrder.PutUint64(serialized[8:16], uint64(state.added))
+	byteOrder.PutUint32(serialized[16:20], uint32(len(state.spends)))
+	offset := 20
+	for _, spend := range state.spends {
+		copy(serialized[offset:], spend.Hash[:])
+		offset += chainhash.HashSize
+		byteOrder.PutUint64(serialized[offset:], uint64(spend.Amount))
+		offset += 8
+	}
+	return serialized
+}
+
+// deserializeTreasuryState decodes the passed serialized treasury state of the
+// block with the passed hash and height according to the format described
+// above.
+func deserializeTreasuryState(serialized []byte, blockHash *chainhash.Hash, blockHeight int64) (*treasuryState, error) {
+	const spendSize = chainhash.HashSize + 8
+	if len(serialized) < 20 {
+		return nil, errDeserialize("unexpected end of data")
+	}
+	byteOrder := dbnamespace.ByteOrder
+	numSpends := byteOrder.Uint32(serialized[16:20])
+	if uint64(len(serialized)) != 20+uint64(numSpends)*spendSize {
+		return nil, errDeserialize(fmt.Sprintf("unexpected length %d "+
+			"for %d spends", len(serialized), numSpends))
+	}
+
+	state := &treasuryState{
+		balance: int64(byteOrder.Uint64(serialized[0:8])),
+		added:   int64(byteOrder.Uint64(serialized[8:16])),
+	}
+	if numSpends > 0 {
+		state.spends = make([]TreasurySpend, numSpends)
+	}
+	offset := 20
+	for i := range state.spends {
+		spend := &state.spends[i]
+		copy(spend.Hash[:], serialized[offset:])
+		offset += chainhash.HashSize
+		spend.Amount = int64(byteOrder.Uint64(serialized[offset:]))
+		offset += 8
+		spend.BlockHash = *blockHash
+		spend.BlockHeight = blockHeight
+	}
+	return state, nil
+}
+
+// dbFetchTreasuryState uses an existing database transaction to fetch the
+// treasury state as of the passed block.  Nil is returned when there is no
+// state for the block, such as when it was connected before the treasury agenda
+// was active.
+func dbFetchTreasuryState(dbTx database.Tx, blockHash *chainhash.Hash, blockHeight int64) (*treasuryState, error) {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.TreasuryBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+	serialized := bucket.Get(blockHash[:])
+	if serialized == nil {
+		return nil, nil
+	}
+
+	state, err := deserializeTreasuryState(serialized, blockHash, blockHeight)
+	if err != nil {
+		// Ensure any deserialization errors are returned as database
+		// corruption errors.
+		if isDeserializeErr(err) {
+			return nil, database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt treasury "+
+					"state for %v: %v", blockHash, err),
+			}
+		}
+		return nil, err
+	}
+	return state, nil
+}
+
+// dbPutTreasuryState uses an existing database transaction to store the passed
+// treasury state as of the passed block.
+func dbPutTreasuryState(dbTx database.Tx, blockHash *chainhash.Hash, state *treasuryState) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+		dbnamespace.TreasuryBucketName)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(blockHash[:], serializeTreasuryState(state))
+}
+
+// dbRemoveTreasuryState uses an existing database transaction to remove the
+// treasury state as of the passed block.
+func dbRemoveTreasuryState(dbTx database.Tx, blockHash *chainhash.Hash) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.TreasuryBucketName)
+	if bucket == nil {
+		return nil
+	}
+	return bucket.Delete(blockHash[:])
+}
+
+// dbConnectTreasury uses an existing database transaction to store the treasury
+// state as of the passed block, which is being connected to the main chain with
+// the passed spent txouts.  The state is derived from that of the parent of the
+// block, which is treated as empty when it has none since the treasury agenda
+// was not active for it.
+func dbConnectTreasury(dbTx database.Tx, node *blockNode, block, parent *dcrutil.Block, stxos []spentTxOut) error {
+	prevState, err := dbFetchTreasuryState(dbTx, &node.parent.hash,
+		node.parent.height)
+	if err != nil {
+		return err
+	}
+	var balance int64
+	if prevState != nil {
+		balance = prevState.balance
+	}
+
+	added, spends := treasuryChanges(block, parent, stxos)
+	balance += added
+	for _, spend := range spends {
+		balance -= spend.Amount
+	}
+	if balance < 0 {
+		return AssertError(fmt.Sprintf("treasury balance of block %v is "+
+			"negative (%d)", node.hash, balance))
+	}
+
+	state := &treasuryState{
+		balance: balance,
+		added:   added,
+		spends:  spends,
+	}
+	return dbPutTreasuryState(dbTx, &node.hash, state)
+}
+
+// TreasuryBalance describes the balance of the treasury as of a block of the
+// main chain.
+type TreasuryBalance struct {
+	Active  bool           // Whether or not the treasury agenda is active
+	Hash    chainhash.Hash // Hash of the block
+	Height  int64          // Height of the block
+	Balance int64          // Balance of the treasury in atoms
+	Added   int64          // Amount added to the treasury by the block
+	Spent   int64          // Amount spent from the treasury by the block
+}
+
+// TreasuryBalance returns the balance of the treasury as of the end of the
+// current best chain.  The balance is zero when the treasury agenda is not yet
+// active.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) TreasuryBalance() (*TreasuryBalance, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.bestNode
+	active, err := b.isTreasuryAgendaActive(node)
+	if err != nil {
+		return nil, err
+	}
+	var state *treasuryState
+	err = b.db.View(func(dbTx database.Tx) error {
+		var err error
+		state, err = dbFetchTreasuryState(dbTx, &node.hash, node.height)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &TreasuryBalance{
+		Active: active,
+		Hash:   node.hash,
+		Height: node.height,
+	}
+	if state != nil {
+		balance.Balance = state.balance
+		balance.Added = state.added
+		for _, spend := range state.spends {
+			balance.Spent += spend.Amount
+		}
+	}
+	return balance, nil
+}
+
+// TreasurySpends returns the spends from the treasury which were applied by
+// the passed number of most recent blocks of the current best chain.  The
+// spends are ordered from the most recent block to the oldest one.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) TreasurySpends(numBlocks int64) ([]TreasurySpend, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	var spends []TreasurySpend
+	node := b.bestNode
+	for i := int64(0); i < numBlocks && node != nil; i++ {
+		var state *treasuryState
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			state, err = dbFetchTreasuryState(dbTx, &node.hash,
+				node.height)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// There are no states prior to the activation of the treasury
+		// agenda.
+		if state == nil {
+			break
+		}
+		spends = append(spends, state.spends...)
+
+		node, err = b.getPrevNodeFromNode(node)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return spends, nil
+}
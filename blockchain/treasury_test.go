@@ -0,0 +1,161 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// TestTreasuryStateSerialization ensures serializing and deserializing treasury
+// states works as expected.
+func TestTreasuryStateSerialization(t *testing.T) {
+	t.Parallel()
+
+	blockHash := chainhash.Hash{0x0b}
+	const blockHeight = 152841
+	tests := []struct {
+		name  string
+		state *treasuryState
+	}{
+		{
+			name:  "no changes",
+			state: &treasuryState{balance: 52718350912},
+		},
+		{
+			name: "adds and spends",
+			state: &treasuryState{
+				balance: 51531003399,
+				added:   313652487,
+				spends: []TreasurySpend{{
+					Hash:        chainhash.Hash{0x01},
+					BlockHash:   blockHash,
+					BlockHeight: blockHeight,
+					Amount:      1000000000,
+				}, {
+					Hash:        chainhash.Hash{0x02},
+					BlockHash:   blockHash,
+					BlockHeight: blockHeight,
+					Amount:      501000000,
+				}},
+			},
+		},
+	}
+	for _, test := range tests {
+		serialized := serializeTreasuryState(test.state)
+		state, err := deserializeTreasuryState(serialized, &blockHash,
+			blockHeight)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(state, test.state) {
+			t.Errorf("%s: mismatched state -- got %+v, want %+v",
+				test.name, state, test.state)
+		}
+
+		// Ensure truncated states are rejected.
+		_, err = deserializeTreasuryState(serialized[:len(serialized)-1],
+			&blockHash, blockHeight)
+		if !isDeserializeErr(err) {
+			t.Errorf("%s: unexpected error for truncated state -- "+
+				"got %v", test.name, err)
+		}
+	}
+}
+
+// TestTreasuryChanges ensures the changes applied to the treasury by a block
+// are derived correctly from the regular transaction tree of its parent.
+func TestTreasuryChanges(t *testing.T) {
+	t.Parallel()
+
+	treasuryScript := txscript.TreasuryScript()
+	otherScript := []byte{txscript.OP_TRUE}
+	coinbase := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{
+			{Value: 300, PkScript: treasuryScript},
+			{Value: 0, PkScript: []byte{txscript.OP_RETURN}},
+			{Value: 2000, PkScript: otherScript},
+		},
+	}
+	spend := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{}, {}},
+		TxOut: []*wire.TxOut{{Value: 1500, PkScript: otherScript}},
+	}
+	donation := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{{Value: 50, PkScript: treasuryScript}},
+	}
+	parent := dcrutil.NewBlock(&wire.MsgBlock{
+		Header:       wire.BlockHeader{Height: 1},
+		Transactions: []*wire.MsgTx{coinbase, spend, donation},
+	})
+
+	// The spent txouts are those of the inputs of the regular transactions
+	// of the parent in order.
+	stxos := []spentTxOut{
+		{amount: 1000, pkScript: treasuryScript},
+		{amount: 600, pkScript: treasuryScript},
+		{amount: 75, pkScript: otherScript},
+	}
+
+	tests := []struct {
+		name     string
+		voteBits uint16
+		added    int64
+		spent    int64
+	}{
+		{
+			name:     "parent approved",
+			voteBits: dcrutil.BlockValid,
+			added:    350,
+			spent:    1600,
+		},
+		{
+			name:     "parent disapproved",
+			voteBits: 0,
+		},
+	}
+	for _, test := range tests {
+		block := dcrutil.NewBlock(&wire.MsgBlock{
+			Header: wire.BlockHeader{
+				Height:   2,
+				VoteBits: test.voteBits,
+			},
+		})
+		added, spends := treasuryChanges(block, parent, stxos)
+		if added != test.added {
+			t.Errorf("%s: unexpected added amount -- got %d, want %d",
+				test.name, added, test.added)
+		}
+		var spent int64
+		for _, s := range spends {
+			spent += s.Amount
+		}
+		if spent != test.spent {
+			t.Errorf("%s: unexpected spent amount -- got %d, want %d",
+				test.name, spent, test.spent)
+		}
+		if test.spent == 0 {
+			continue
+		}
+		want := []TreasurySpend{{
+			Hash:        spend.TxHash(),
+			BlockHash:   *block.Hash(),
+			BlockHeight: 2,
+			Amount:      test.spent,
+		}}
+		if !reflect.DeepEqual(spends, want) {
+			t.Errorf("%s: unexpected spends -- got %+v, want %+v",
+				test.name, spends, want)
+		}
+	}
+}
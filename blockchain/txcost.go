@@ -0,0 +1,163 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrutil"
+)
+
+// TxCost houses the costs of a transaction which are limited by the consensus
+// rules and policy.  The consensus checks, the memory pool policy, and block
+// template generation all calculate the costs with CalcTxCost so that they
+// account for transactions identically.
+type TxCost struct {
+	// Size is the serialized size of the transaction in bytes.
+	Size int
+
+	// SigOps is the quickly counted number of signature operations in all
+	// input and output scripts of the transaction.
+	SigOps int
+
+	// P2SHSigOps is the precisely counted number of signature operations
+	// in the redeem scripts of the pay-to-script-hash outputs the
+	// transaction spends.
+	P2SHSigOps int
+
+	// AltSigOps is the number of the signature operations which verify
+	// signatures of the alternative signature types.  They are included in
+	// SigOps and P2SHSigOps.
+	AltSigOps int
+}
+
+// TotalSigOps returns the number of signature operations of the transaction
+// that count towards the signature operation limits.
+func (c *TxCost) TotalSigOps() int {
+	return c.SigOps + c.P2SHSigOps
+}
+
+// countSigOpCost returns the cost of the signature operations of all
+// transaction input and output scripts in the provided transaction.  This uses
+// the quicker, but imprecise, signature operation counting mechanism from
+// txscript.
+func countSigOpCost(tx *dcrutil.Tx, isCoinBaseTx bool, isSSGen bool) txscript.SigOpCost {
+	msgTx := tx.MsgTx()
+
+	// Accumulate the cost of the signature operations in all transaction
+	// inputs.
+	var cost txscript.SigOpCost
+	for i, txIn := range msgTx.TxIn {
+		// Skip coinbase inputs.
+		if isCoinBaseTx {
+			continue
+		}
+		// Skip stakebase inputs.
+		if isSSGen && i == 0 {
+			continue
+		}
+
+		cost.Add(txscript.GetSigOpCost(txIn.SignatureScript))
+	}
+
+	// Accumulate the cost of the signature operations in all transaction
+	// outputs.
+	for _, txOut := range msgTx.TxOut {
+		cost.Add(txscript.GetSigOpCost(txOut.PkScript))
+	}
+
+	return cost
+}
+
+// countP2SHSigOpCost returns the cost of the signature operations of all input
+// transactions which are of the pay-to-script-hash type.  This uses the
+// precise, signature operation counting mechanism from the script engine which
+// requires access to the input transaction scripts.
+func countP2SHSigOpCost(tx *dcrutil.Tx, isCoinBaseTx bool, isStakeBaseTx bool,
+	utxoView *UtxoViewpoint) (txscript.SigOpCost, error) {
+	// Coinbase transactions have no interesting inputs.
+	var cost txscript.SigOpCost
+	if isCoinBaseTx {
+		return cost, nil
+	}
+
+	// Stakebase (SSGen) transactions have no P2SH inputs.  Same with SSRtx,
+	// but they will still pass the checks below.
+	if isStakeBaseTx {
+		return cost, nil
+	}
+
+	// Accumulate the cost of the signature operations in all transaction
+	// inputs.
+	msgTx := tx.MsgTx()
+	for txInIndex, txIn := range msgTx.TxIn {
+		// Ensure the referenced input transaction is available.
+		originTxHash := &txIn.PreviousOutPoint.Hash
+		originTxIndex := txIn.PreviousOutPoint.Index
+		utxoEntry, ok := utxoView.entries[*originTxHash]
+		if !ok || utxoEntry == nil {
+			str := fmt.Sprintf("unable to find unspent transaction "+
+				"%v referenced from transaction %s:%d during "+
+				"CountP2SHSigOps: output missing",
+				txIn.PreviousOutPoint.Hash, tx.Hash(), txInIndex)
+			return txscript.SigOpCost{}, ruleError(ErrMissingTx, str)
+		}
+
+		if utxoEntry.IsOutputSpent(originTxIndex) {
+			str := fmt.Sprintf("unable to find unspent output "+
+				"%v referenced from transaction %s:%d during "+
+				"CountP2SHSigOps: output spent",
+				txIn.PreviousOutPoint, tx.Hash(), txInIndex)
+			return txscript.SigOpCost{}, ruleError(ErrMissingTx, str)
+		}
+
+		// We're only interested in pay-to-script-hash types, so skip
+		// this input if it's not one.
+		pkScript := utxoEntry.PkScriptByIndex(originTxIndex)
+		if !txscript.IsPayToScriptHash(pkScript) {
+			continue
+		}
+
+		// Calculate the precise cost of the signature operations in the
+		// referenced public key script.
+		sigScript := txIn.SignatureScript
+		inputCost := txscript.GetPreciseSigOpCost(sigScript, pkScript,
+			true)
+
+		// We could potentially overflow the accumulator so check for
+		// overflow.
+		lastSigOps := cost.SigOps
+		cost.Add(inputCost)
+		if cost.SigOps < lastSigOps {
+			str := fmt.Sprintf("the public key script from output "+
+				"%v contains too many signature operations - "+
+				"overflow", txIn.PreviousOutPoint)
+			return txscript.SigOpCost{}, ruleError(ErrTooManySigOps, str)
+		}
+	}
+
+	return cost, nil
+}
+
+// CalcTxCost returns the costs of the passed transaction.  The utxo view must
+// contain the outputs the transaction spends unless it is a coinbase.
+func CalcTxCost(tx *dcrutil.Tx, isCoinBaseTx bool, utxoView *UtxoViewpoint) (*TxCost, error) {
+	isSSGen, _ := stake.IsSSGen(tx.MsgTx())
+	sigOpCost := countSigOpCost(tx, isCoinBaseTx, isSSGen)
+	p2shSigOpCost, err := countP2SHSigOpCost(tx, isCoinBaseTx, isSSGen,
+		utxoView)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxCost{
+		Size:       tx.MsgTx().SerializeSize(),
+		SigOps:     sigOpCost.SigOps,
+		P2SHSigOps: p2shSigOpCost.SigOps,
+		AltSigOps:  sigOpCost.AltSigOps + p2shSigOpCost.AltSigOps,
+	}, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// agendaVersions describes a transaction version and/or output script version
+// which may only be used once the agenda with the given stake version and vote
+// ID is active.  A zero version indicates the agenda does not introduce a
+// version of that kind.  Note that the default versions can never be gated
+// since they are always valid.
+type agendaVersions struct {
+	version       uint32
+	voteID        string
+	txVersion     uint16
+	scriptVersion uint16
+}
+
+// versionAgendas houses the transaction and script versions which are gated on
+// the associated agendas being active.  Agendas which introduce new versions
+// must add an entry here so that block validation and the memory pool agree on
+// when they may be used.  There are currently no such agendas.
+var versionAgendas []agendaVersions
+
+// checkVersionsForAgendas returns an error when the passed transaction uses a
+// transaction or output script version introduced by one of the passed agendas
+// that is not active according to the passed function.
+func checkVersionsForAgendas(agendas []agendaVersions, tx *wire.MsgTx, isActive func(version uint32, voteID string) (bool, error)) error {
+	txVersion := uint16(tx.Version)
+	for _, agenda := range agendas {
+		usesTxVersion := agenda.txVersion != 0 &&
+			txVersion == agenda.txVersion
+		usesScriptVersion := false
+		if agenda.scriptVersion != 0 {
+			for _, txOut := range tx.TxOut {
+				if txOut.Version == agenda.scriptVersion {
+					usesScriptVersion = true
+					break
+				}
+			}
+		}
+		if !usesTxVersion && !usesScriptVersion {
+			continue
+		}
+
+		active, err := isActive(agenda.version, agenda.voteID)
+		if err != nil {
+			return err
+		}
+		if active {
+			continue
+		}
+		if usesTxVersion {
+			str := fmt.Sprintf("transaction version %d is not valid "+
+				"until agenda %s is active", txVersion,
+				agenda.voteID)
+			return ruleError(ErrInactiveTxVersion, str)
+		}
+		str := fmt.Sprintf("output script version %d is not valid "+
+			"until agenda %s is active", agenda.scriptVersion,
+			agenda.voteID)
+		return ruleError(ErrInactiveScriptVersion, str)
+	}
+	return nil
+}
+
+// checkTransactionVersions returns an error when the passed transaction uses a
+// transaction or output script version which is not yet active for the block
+// AFTER the given node.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkTransactionVersions(prevNode *blockNode, tx *wire.MsgTx) error {
+	return checkVersionsForAgendas(versionAgendas, tx, func(version uint32, voteID string) (bool, error) {
+		return b.isAgendaActive(prevNode, version, voteID)
+	})
+}
+
+// CheckTransactionVersions returns an error when the passed transaction uses a
+// transaction or output script version which is not yet active for the block
+// AFTER the end of the current best chain.  The error is a RuleError with the
+// ErrInactiveTxVersion or ErrInactiveScriptVersion error code in that case.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CheckTransactionVersions(tx *wire.MsgTx) error {
+	b.chainLock.Lock()
+	err := b.checkTransactionVersions(b.bestNode, tx)
+	b.chainLock.Unlock()
+	return err
+}
+
+// MaxTxVersion returns the highest transaction version which may be used in
+// the block AFTER the end of the current best chain.  It is intended to be used
+// by policy to determine which transaction versions are standard.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) MaxTxVersion() (uint16, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	maxVersion := wire.TxVersion
+	for _, agenda := range versionAgendas {
+		if agenda.txVersion <= maxVersion {
+			continue
+		}
+		active, err := b.isAgendaActive(b.bestNode, agenda.version,
+			agenda.voteID)
+		if err != nil {
+			return 0, err
+		}
+		if active {
+			maxVersion = agenda.txVersion
+		}
+	}
+	return maxVersion, nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// TestCheckVersionsForAgendas ensures transactions which use transaction or
+// output script versions gated on agendas are only accepted once the agendas
+// are active.
+func TestCheckVersionsForAgendas(t *testing.T) {
+	t.Parallel()
+
+	agendas := []agendaVersions{
+		{version: 5, voteID: "txversion", txVersion: 2},
+		{version: 5, voteID: "scriptversion", scriptVersion: 1},
+	}
+	newTx := func(txVersion int32, scriptVersion uint16) *wire.MsgTx {
+		return &wire.MsgTx{
+			Version: txVersion,
+			TxOut: []*wire.TxOut{
+				{Version: wire.DefaultPkScriptVersion},
+				{Version: scriptVersion},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		tx     *wire.MsgTx
+		active map[string]bool
+		err    error
+	}{
+		{
+			name: "default versions",
+			tx:   newTx(1, 0),
+			err:  nil,
+		},
+		{
+			name: "ungated tx version",
+			tx:   newTx(3, 0),
+			err:  nil,
+		},
+		{
+			name: "inactive tx version",
+			tx:   newTx(2, 0),
+			err:  ruleError(ErrInactiveTxVersion, ""),
+		},
+		{
+			name:   "active tx version",
+			tx:     newTx(2, 0),
+			active: map[string]bool{"txversion": true},
+			err:    nil,
+		},
+		{
+			name:   "inactive script version",
+			tx:     newTx(1, 1),
+			active: map[string]bool{"txversion": true},
+			err:    ruleError(ErrInactiveScriptVersion, ""),
+		},
+		{
+			name:   "active script version",
+			tx:     newTx(2, 1),
+			active: map[string]bool{"txversion": true, "scriptversion": true},
+			err:    nil,
+		},
+	}
+	for _, test := range tests {
+		err := checkVersionsForAgendas(agendas, test.tx, func(version uint32, voteID string) (bool, error) {
+			return test.active[voteID], nil
+		})
+		if test.err == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok {
+			t.Errorf("%s: unexpected error type -- got %T, want "+
+				"RuleError", test.name, err)
+			continue
+		}
+		if want := test.err.(RuleError).ErrorCode; rerr.ErrorCode != want {
+			t.Errorf("%s: unexpected error code -- got %v, want %v",
+				test.name, rerr.ErrorCode, want)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2016 The Decred developers
+// Copyright (c) 2015-2017 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
 package blockchain
 
 import (
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
 	"github.com/decred/dcrd/blockchain/internal/progresslog"
 	"github.com/decred/dcrd/blockchain/stake"
 	"github.com/decred/dcrd/chaincfg/chainhash"
@@ -98,6 +102,242 @@ func (b *BlockChain) upgradeToVersion2() error {
 	return nil
 }
 
+// utxoMigrationBatchSize is the maximum number of legacy utxo entries that are
+// migrated to the version 2 utxo set in a single step of the migration.
+const utxoMigrationBatchSize = 10000
+
+// utxoSetMigrationName is the name of the background migration which moves the
+// utxo set from an entry per transaction to an entry per output.
+const utxoSetMigrationName = "utxosetv2"
+
+// migrateUtxoBatch uses an existing database transaction to migrate up to the
+// passed number of entries from the legacy utxo set to the version 2 utxo set.
+// Each migrated entry is removed from the legacy utxo set.  It returns the
+// number of entries migrated, which will be less than the maximum once the
+// legacy utxo set is empty.
+func migrateUtxoBatch(dbTx database.Tx, maxEntries int) (int, error) {
+	meta := dbTx.Metadata()
+	legacyBucket := meta.Bucket(dbnamespace.UtxoSetBucketName)
+	utxoBucket := meta.Bucket(dbnamespace.UtxoSetV2BucketName)
+
+	// Collect the keys and values of the batch before modifying the buckets
+	// since modifying a bucket while iterating it with a cursor is not
+	// allowed.
+	var keys, values [][]byte
+	cursor := legacyBucket.Cursor()
+	for ok := cursor.First(); ok && len(keys) < maxEntries; ok = cursor.Next() {
+		keys = append(keys, append([]byte(nil), cursor.Key()...))
+		values = append(values, append([]byte(nil), cursor.Value()...))
+	}
+
+	for i, key := range keys {
+		var txHash chainhash.Hash
+		copy(txHash[:], key)
+		entry, err := deserializeUtxoEntry(values[i])
+		if err != nil {
+			return 0, err
+		}
+		err = dbPutUtxoEntry(utxoBucket, &txHash, entry, true)
+		if err != nil {
+			return 0, err
+		}
+		if err := legacyBucket.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
+
+// utxoSetMigration returns the background migration which upgrades a version 2
+// blockchain to version 3 by migrating the utxo set from an entry per
+// transaction to an entry per output.
+//
+// Each step atomically moves a batch of entries from the legacy utxo set to the
+// version 2 utxo set.  Since the utxo set code reads and writes both sets until
+// the legacy set is removed, the database is consistent after every step.  The
+// progress only tracks the number of migrated entries for logging purposes
+// since the remaining entries are always those left in the legacy utxo set.
+func (b *BlockChain) utxoSetMigration() *dbMigration {
+	return &dbMigration{
+		name: utxoSetMigrationName,
+		step: func(dbTx database.Tx, progress []byte) ([]byte, bool, error) {
+			var totalMigrated uint64
+			if len(progress) == 8 {
+				totalMigrated = dbnamespace.ByteOrder.Uint64(progress)
+			}
+
+			numMigrated, err := migrateUtxoBatch(dbTx,
+				utxoMigrationBatchSize)
+			if err != nil {
+				return nil, false, err
+			}
+			totalMigrated += uint64(numMigrated)
+			log.Infof("Migrated %d utxo entries to the per-output "+
+				"utxo set", totalMigrated)
+
+			progress = make([]byte, 8)
+			dbnamespace.ByteOrder.PutUint64(progress, totalMigrated)
+			return progress, numMigrated < utxoMigrationBatchSize, nil
+		},
+		finish: func(dbTx database.Tx) error {
+			// Remove the now empty legacy utxo set and write the new
+			// database version.
+			err := dbTx.Metadata().DeleteBucket(
+				dbnamespace.UtxoSetBucketName)
+			if err != nil {
+				return err
+			}
+
+			b.dbInfo.version = 3
+			return dbPutDatabaseInfo(dbTx, b.dbInfo)
+		},
+	}
+}
+
+// prepareUtxoSetMigration prepares a version 2 blockchain for the background
+// migration of the utxo set by creating the version 2 utxo set, which must
+// exist before any utxos are written, and queues the migration.
+func (b *BlockChain) prepareUtxoSetMigration() error {
+	err := b.db.Update(func(dbTx database.Tx) error {
+		_, err := dbTx.Metadata().CreateBucketIfNotExists(
+			dbnamespace.UtxoSetV2BucketName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("The utxo set will be migrated to database version 3 in " +
+		"the background")
+	b.migrations = append(b.migrations, b.utxoSetMigration())
+	return nil
+}
+
+// chainWorkMigrationBatchSize is the maximum number of main chain blocks whose
+// total work is stored in a single step of the chain work migration.
+const chainWorkMigrationBatchSize = 5000
+
+// chainWorkMigrationName is the name of the background migration which stores
+// the total work of the main chain blocks connected before the chain work
+// bucket existed.
+const chainWorkMigrationName = "chainwork"
+
+// migrateChainWorkBatch uses an existing database transaction to store the total
+// work of up to the passed number of main chain blocks starting with the block
+// at the passed height.  It returns the height of the next block to migrate
+// along with whether or not the end of the main chain was reached.
+//
+// The total work of each block is that of its parent plus its own work, so the
+// work of the parent of the first block must already be stored.  That is the
+// case for every block before the height returned by a previous batch, even
+// when the main chain was reorganized in between, since the work of connected
+// blocks is always stored.
+func migrateChainWorkBatch(dbTx database.Tx, height int64, maxBlocks int) (int64, bool, error) {
+	var workSum *big.Int
+	if height > 0 {
+		parentHash, err := dbFetchHashByHeight(dbTx, height-1)
+		if err != nil {
+			return 0, false, err
+		}
+		workSum = dbFetchChainWork(dbTx, parentHash)
+		if workSum == nil {
+			return 0, false, AssertError(fmt.Sprintf("migrateChainWorkBatch: "+
+				"work of main chain block %v at height %d is not "+
+				"stored", parentHash, height-1))
+		}
+	}
+
+	for i := 0; i < maxBlocks; i++ {
+		hash, err := dbFetchHashByHeight(dbTx, height)
+		if isNotInMainChainErr(err) {
+			return height, true, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		header, err := dbFetchHeaderByHash(dbTx, hash)
+		if err != nil {
+			return 0, false, err
+		}
+
+		work := CalcWork(header.Bits)
+		if workSum != nil {
+			work.Add(work, workSum)
+		}
+		workSum = work
+		if err := dbPutChainWork(dbTx, hash, workSum); err != nil {
+			return 0, false, err
+		}
+		height++
+	}
+
+	return height, false, nil
+}
+
+// chainWorkMigration returns the background migration which stores the total
+// work of the main chain blocks connected before the chain work bucket existed,
+// so calculating the work of old blocks does not require walking their headers.
+//
+// The progress is the height of the next block to migrate.  Since the work of
+// blocks which are not stored yet is still calculated from their headers, the
+// database is consistent after every step.
+func (b *BlockChain) chainWorkMigration() *dbMigration {
+	return &dbMigration{
+		name: chainWorkMigrationName,
+		step: func(dbTx database.Tx, progress []byte) ([]byte, bool, error) {
+			var height int64
+			if len(progress) == 8 {
+				height = int64(dbnamespace.ByteOrder.Uint64(progress))
+			}
+
+			height, done, err := migrateChainWorkBatch(dbTx, height,
+				chainWorkMigrationBatchSize)
+			if err != nil {
+				return nil, false, err
+			}
+			log.Infof("Stored the total work of %d main chain blocks",
+				height)
+
+			progress = make([]byte, 8)
+			dbnamespace.ByteOrder.PutUint64(progress, uint64(height))
+			return progress, done, nil
+		},
+	}
+}
+
+// prepareChainWorkMigration creates the chain work bucket and queues the
+// background migration which stores the total work of the existing main chain
+// blocks when the bucket does not exist yet or the migration was interrupted.
+func (b *BlockChain) prepareChainWorkMigration() error {
+	var needsMigration bool
+	err := b.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if meta.Bucket(dbnamespace.ChainWorkBucketName) != nil {
+			progress := dbFetchMigrationProgress(dbTx,
+				chainWorkMigrationName)
+			needsMigration = progress != nil
+			return nil
+		}
+
+		_, err := meta.CreateBucket(dbnamespace.ChainWorkBucketName)
+		if err != nil {
+			return err
+		}
+		needsMigration = true
+		return dbPutMigrationProgress(dbTx, chainWorkMigrationName,
+			make([]byte, 8))
+	})
+	if err != nil || !needsMigration {
+		return err
+	}
+
+	log.Infof("The total work of the main chain blocks will be stored in " +
+		"the background")
+	b.migrations = append(b.migrations, b.chainWorkMigration())
+	return nil
+}
+
 // upgrade applies all possible upgrades to the blockchain database iteratively,
 // updating old clients to the newest version.
 func (b *BlockChain) upgrade() error {
@@ -108,5 +348,17 @@ func (b *BlockChain) upgrade() error {
 		}
 	}
 
-	return nil
+	// The upgrade to version 3 is performed in the background by
+	// RunMigrations since it involves rewriting the entire utxo set.
+	if b.dbInfo.version == 2 {
+		err := b.prepareUtxoSetMigration()
+		if err != nil {
+			return err
+		}
+	}
+
+	// The total work of the main chain blocks is stored in the background
+	// as well for databases created before it was stored.  This does not
+	// change the database version since older software ignores the bucket.
+	return b.prepareChainWorkMigration()
 }
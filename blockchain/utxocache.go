@@ -0,0 +1,435 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+)
+
+const (
+	// DefaultUtxoCacheMaxSize is the default maximum number of bytes the
+	// utxo cache may consume before it is flushed to the database.
+	DefaultUtxoCacheMaxSize = 150 * 1024 * 1024
+
+	// utxoCacheFlushInterval is the maximum amount of time the utxo cache
+	// keeps modifications in memory before they are flushed to the
+	// database.
+	utxoCacheFlushInterval = 5 * time.Minute
+
+	// utxoCacheMaxUnflushedBlocks is the maximum number of blocks which may
+	// be connected to the main chain before the modifications they make to
+	// the utxo set are flushed to the database.  It bounds the number of
+	// blocks which must be replayed to catch the utxo set up to the best
+	// chain after an unclean shutdown.
+	utxoCacheMaxUnflushedBlocks = 2000
+
+	// utxoCacheEntryOverhead is an estimate of the number of bytes used by a
+	// cached utxo entry excluding its outputs and stake extra data.  It
+	// accounts for the map key and pointer along with the fields of the
+	// entry and its sparse outputs map.
+	utxoCacheEntryOverhead = chainhash.HashSize + 8 + 104
+
+	// utxoCacheOutputOverhead is an estimate of the number of bytes used by
+	// an output of a cached utxo entry excluding its public key script.  It
+	// accounts for the map key and pointer along with the fields of the
+	// output.
+	utxoCacheOutputOverhead = 4 + 8 + 40
+)
+
+// utxoEntrySize returns an estimate of the number of bytes the passed utxo
+// entry consumes in memory.
+func utxoEntrySize(entry *UtxoEntry) uint64 {
+	size := uint64(utxoCacheEntryOverhead + len(entry.stakeExtra))
+	for _, out := range entry.sparseOutputs {
+		size += uint64(utxoCacheOutputOverhead + len(out.pkScript))
+	}
+	return size
+}
+
+// clone returns a deep copy of the utxo entry so that modifications to the
+// copy do not affect the original.  The public key scripts and stake extra
+// data are shared since they are never modified in place.
+func (entry *UtxoEntry) clone() *UtxoEntry {
+	if entry == nil {
+		return nil
+	}
+
+	newEntry := *entry
+	newEntry.sparseOutputs = make(map[uint32]*utxoOutput,
+		len(entry.sparseOutputs))
+	for outputIndex, out := range entry.sparseOutputs {
+		outCopy := *out
+		newEntry.sparseOutputs[outputIndex] = &outCopy
+	}
+	return &newEntry
+}
+
+// utxoCache is a write-back cache which sits between the utxo viewpoints used
+// to connect and disconnect blocks and the utxo set in the database.  The
+// modifications made by each block are committed to the cache and only written
+// to the database once the cache is flushed, which avoids a round trip to the
+// database for every output which is created and then spent again before the
+// next flush.
+//
+// The cache is flushed along with the block which is connected or disconnected
+// once it exceeds its maximum size, once the flush interval has elapsed, or once
+// the maximum number of blocks have been connected since the last flush, as
+// well as on shutdown.  Each flush records the block the utxo set in the
+// database represents, which is always an ancestor of or the same as the best
+// chain, so any blocks connected since then can be replayed when the cache is
+// initialized after an unclean shutdown.
+type utxoCache struct {
+	db      database.DB
+	maxSize uint64
+
+	// mtx protects the remaining fields since entries are also loaded from
+	// the database by the chain queries which only hold the chain lock for
+	// reads, if at all.
+	//
+	// entries houses the cached utxo entries, which are marked as modified
+	// when they have changed since the last flush.  Fully spent entries are
+	// retained until the next flush so they are removed from the database.
+	//
+	// totalSize is the estimated number of bytes consumed by the entries.
+	//
+	// flushGeneration is incremented after every flush so entries loaded
+	// from the database concurrently with a flush are not added to the
+	// cache since they might be stale.
+	mtx             sync.Mutex
+	entries         map[chainhash.Hash]*UtxoEntry
+	totalSize       uint64
+	flushGeneration uint64
+	flushedHash     chainhash.Hash
+	flushedHeight   int64
+	lastFlush       time.Time
+}
+
+// newUtxoCache returns a new utxo cache backed by the passed database which is
+// flushed once it exceeds the passed maximum size in bytes.  A maximum size of
+// zero results in the cache being flushed along with every block.
+func newUtxoCache(db database.DB, maxSize uint64) *utxoCache {
+	return &utxoCache{
+		db:        db,
+		maxSize:   maxSize,
+		entries:   make(map[chainhash.Hash]*UtxoEntry),
+		lastFlush: time.Now(),
+	}
+}
+
+// lookupEntry returns a copy of the cached utxo entry for the passed hash along
+// with whether or not it is cached.  The returned entry is nil for cached
+// entries which are fully spent.
+func (c *utxoCache) lookupEntry(hash *chainhash.Hash) (*UtxoEntry, bool) {
+	c.mtx.Lock()
+	entry, ok := c.entries[*hash]
+	c.mtx.Unlock()
+	if !ok {
+		return nil, false
+	}
+	if entry.IsFullySpent() {
+		return nil, true
+	}
+	return entry.clone(), true
+}
+
+// fetchEntry returns the utxo entry for the passed hash from the cache, or from
+// the database using the passed database transaction when it is not cached, in
+// which case it is also added to the cache while the cache is below its
+// maximum size.  The returned entry is a copy, so the caller is free to modify
+// it.
+//
+// NOTE: Requesting a hash for which there is no data will NOT return an error.
+// Instead both the entry and the error will be nil.
+func (c *utxoCache) fetchEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error) {
+	if entry, ok := c.lookupEntry(hash); ok {
+		return entry, nil
+	}
+
+	c.mtx.Lock()
+	generation := c.flushGeneration
+	c.mtx.Unlock()
+
+	entry, err := dbFetchUtxoEntry(dbTx, hash)
+	if err != nil || entry == nil {
+		return entry, err
+	}
+
+	// Only add the entry when it was not modified in the mean time and the
+	// cache was not flushed, since it might be stale otherwise.
+	c.mtx.Lock()
+	if _, ok := c.entries[*hash]; !ok && generation == c.flushGeneration &&
+		c.totalSize < c.maxSize {
+
+		c.entries[*hash] = entry
+		c.totalSize += utxoEntrySize(entry)
+		entry = entry.clone()
+	}
+	c.mtx.Unlock()
+	return entry, nil
+}
+
+// commit adds the entries of the passed view which have been modified to the
+// cache and marks them as modified there.  The outputs which were already
+// modified in the cache remain marked as such so they are written by the next
+// flush.
+func (c *utxoCache) commit(view *UtxoViewpoint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for txHash, entry := range view.entries {
+		if entry == nil || !entry.modified {
+			continue
+		}
+
+		newEntry := entry.clone()
+		if oldEntry, ok := c.entries[txHash]; ok {
+			for outputIndex, out := range oldEntry.sparseOutputs {
+				newOut, ok := newEntry.sparseOutputs[outputIndex]
+				if ok && out.modified {
+					newOut.modified = true
+				}
+			}
+			c.totalSize -= utxoEntrySize(oldEntry)
+		}
+		c.entries[txHash] = newEntry
+		c.totalSize += utxoEntrySize(newEntry)
+	}
+}
+
+// needsFlush returns whether or not the cache must be flushed along with the
+// block at the passed height according to the flush policy.  Since the
+// modifications of the block are not committed to the cache until after the
+// flush, the cache may exceed its maximum size by the modifications of a single
+// block.
+func (c *utxoCache) needsFlush(height int64) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.totalSize >= c.maxSize ||
+		time.Since(c.lastFlush) >= utxoCacheFlushInterval ||
+		height-c.flushedHeight >= utxoCacheMaxUnflushedBlocks
+}
+
+// dbFlush uses an existing database transaction to write all modified cache
+// entries, followed by the modified entries of the passed view, if any, to the
+// utxo set in the database and to record the passed block as the block the
+// utxo set represents.
+//
+// The cache is not updated.  The view must be committed to the cache and the
+// flush must be marked complete via markFlushed once the database transaction
+// has been committed.
+func (c *utxoCache) dbFlush(dbTx database.Tx, view *UtxoViewpoint, node *blockNode) error {
+	c.mtx.Lock()
+	modified := NewUtxoViewpoint()
+	for txHash, entry := range c.entries {
+		if entry.modified {
+			modified.entries[txHash] = entry
+		}
+	}
+	err := dbPutUtxoView(dbTx, modified)
+	c.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if view != nil {
+		if err := dbPutUtxoView(dbTx, view); err != nil {
+			return err
+		}
+	}
+
+	return dbPutUtxoSetState(dbTx, &node.hash, node.height)
+}
+
+// markFlushed marks all cache entries unmodified, removes the fully spent ones,
+// and records the passed block as the block the utxo set in the database
+// represents once a flush has been committed.  All entries are evicted when the
+// cache still exceeds its maximum size.
+func (c *utxoCache) markFlushed(node *blockNode) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for txHash, entry := range c.entries {
+		if entry.IsFullySpent() {
+			c.totalSize -= utxoEntrySize(entry)
+			delete(c.entries, txHash)
+			continue
+		}
+
+		entry.modified = false
+		for _, out := range entry.sparseOutputs {
+			out.modified = false
+		}
+	}
+	if c.totalSize >= c.maxSize {
+		c.entries = make(map[chainhash.Hash]*UtxoEntry)
+		c.totalSize = 0
+	}
+
+	c.flushGeneration++
+	c.flushedHash = node.hash
+	c.flushedHeight = node.height
+	c.lastFlush = time.Now()
+}
+
+// isFlushedAt returns whether or not the utxo set in the database represents
+// the passed block.
+func (c *utxoCache) isFlushedAt(node *blockNode) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.flushedHash == node.hash
+}
+
+// -----------------------------------------------------------------------------
+// The utxo set state identifies the block the utxo set in the database
+// represents.  Since the utxo cache is only flushed periodically, it may lag
+// behind the best chain state, in which case the blocks after it are replayed
+// when the chain is loaded.
+//
+// The serialized format is:
+//
+//   <block hash><block height>
+//
+//   Field          Type             Size
+//   block hash     chainhash.Hash   chainhash.HashSize
+//   block height   uint32           4
+// -----------------------------------------------------------------------------
+
+// utxoSetStateSize is the size of a serialized utxo set state.
+const utxoSetStateSize = chainhash.HashSize + 4
+
+// dbPutUtxoSetState uses an existing database transaction to record the passed
+// block as the block the utxo set represents.
+func dbPutUtxoSetState(dbTx database.Tx, hash *chainhash.Hash, height int64) error {
+	var serialized [utxoSetStateSize]byte
+	copy(serialized[:], hash[:])
+	dbnamespace.ByteOrder.PutUint32(serialized[chainhash.HashSize:],
+		uint32(height))
+	return dbTx.Metadata().Put(dbnamespace.UtxoSetStateKeyName,
+		serialized[:])
+}
+
+// dbFetchUtxoSetState uses an existing database transaction to fetch the hash
+// and height of the block the utxo set represents.  A nil hash is returned when
+// the state has not been recorded yet.
+func dbFetchUtxoSetState(dbTx database.Tx) (*chainhash.Hash, int64, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.UtxoSetStateKeyName)
+	if serialized == nil {
+		return nil, 0, nil
+	}
+	if len(serialized) != utxoSetStateSize {
+		return nil, 0, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo set state size; "+
+				"want %d, got %d", utxoSetStateSize, len(serialized)),
+		}
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], serialized[:chainhash.HashSize])
+	height := dbnamespace.ByteOrder.Uint32(serialized[chainhash.HashSize:])
+	return &hash, int64(height), nil
+}
+
+// initUtxoCache loads the state of the utxo set from the database and replays
+// the blocks of the main chain which were connected after the utxo cache was
+// last flushed, which is the case after an unclean shutdown.  Databases which
+// do not record the state yet were written without a cache, so their utxo set
+// represents the best chain.
+func (b *BlockChain) initUtxoCache() error {
+	var flushedHash *chainhash.Hash
+	var flushedHeight int64
+	err := b.db.Update(func(dbTx database.Tx) error {
+		var err error
+		flushedHash, flushedHeight, err = dbFetchUtxoSetState(dbTx)
+		if err != nil || flushedHash != nil {
+			return err
+		}
+
+		flushedHash, flushedHeight = &b.bestNode.hash, b.bestNode.height
+		return dbPutUtxoSetState(dbTx, flushedHash, flushedHeight)
+	})
+	if err != nil {
+		return err
+	}
+
+	c := b.utxoCache
+	c.flushedHash = *flushedHash
+	c.flushedHeight = flushedHeight
+	if *flushedHash == b.bestNode.hash {
+		return nil
+	}
+
+	// Collect the main chain blocks after the block the utxo set represents.
+	// It must be an ancestor of the best chain since the cache is always
+	// flushed before the block it was last flushed at is disconnected.
+	var nodes []*blockNode
+	node := b.bestNode
+	for node != nil && node.height > flushedHeight {
+		nodes = append(nodes, node)
+
+		var err error
+		node, err = b.getPrevNodeFromNode(node)
+		if err != nil {
+			return err
+		}
+	}
+	if node == nil || node.hash != *flushedHash {
+		return AssertError(fmt.Sprintf("utxo set state %v (height %d) is "+
+			"not an ancestor of the best chain", flushedHash,
+			flushedHeight))
+	}
+
+	log.Infof("Replaying %d blocks to catch up the utxo set to the best "+
+		"chain", len(nodes))
+	for i := len(nodes) - 1; i >= 0; i-- {
+		node := nodes[i]
+		block, err := b.fetchBlockFromHash(&node.hash)
+		if err != nil {
+			return err
+		}
+		parent, err := b.fetchBlockFromHash(&node.header.PrevBlock)
+		if err != nil {
+			return err
+		}
+
+		view := NewUtxoViewpoint()
+		view.SetBestHash(&node.header.PrevBlock)
+		err = b.connectTransactions(view, block, parent, nil)
+		if err != nil {
+			return err
+		}
+		c.commit(view)
+	}
+
+	return nil
+}
+
+// FlushUtxoCache writes all modifications to the utxo set which are held by the
+// utxo cache to the database.  It should be called on shutdown to avoid
+// replaying the blocks connected since the last flush when the chain is loaded
+// again.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FlushUtxoCache() error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.bestNode
+	err := b.db.Update(func(dbTx database.Tx) error {
+		return b.utxoCache.dbFlush(dbTx, nil, node)
+	})
+	if err != nil {
+		return err
+	}
+	b.utxoCache.markFlushed(node)
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// TestUtxoCacheCommit ensures committing views to the utxo cache and marking
+// flushes complete tracks the modified entries and outputs as expected.
+func TestUtxoCacheCommit(t *testing.T) {
+	t.Parallel()
+
+	tx := dcrutil.NewTx(&wire.MsgTx{
+		Version: 1,
+		TxIn:    []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{
+			{Value: 1000, PkScript: []byte{0x51}},
+			{Value: 2000, PkScript: []byte{0x51}},
+		},
+	})
+	txHash := tx.Hash()
+	cache := newUtxoCache(nil, DefaultUtxoCacheMaxSize)
+
+	// Commit a view which creates the outputs of the transaction.
+	view := NewUtxoViewpoint()
+	view.AddTxOuts(tx, 100, 1)
+	cache.commit(view)
+	view.commit()
+	entry, ok := cache.lookupEntry(txHash)
+	if !ok || entry == nil {
+		t.Fatalf("committed entry is not cached")
+	}
+	if entry.AmountByIndex(1) != 2000 {
+		t.Fatalf("unexpected cached amount -- got %d, want %d",
+			entry.AmountByIndex(1), 2000)
+	}
+	if cache.totalSize != utxoEntrySize(entry) {
+		t.Fatalf("unexpected cache size -- got %d, want %d",
+			cache.totalSize, utxoEntrySize(entry))
+	}
+
+	// Ensure modifying the returned entry does not modify the cache.
+	entry.SpendOutput(0)
+	if cached, _ := cache.lookupEntry(txHash); cached.IsOutputSpent(0) {
+		t.Fatalf("modifying looked up entry modified the cache")
+	}
+
+	// Commit a view which spends the first output and ensure both outputs
+	// remain marked as modified since neither was flushed yet.
+	view = NewUtxoViewpoint()
+	view.entries[*txHash] = entry
+	cache.commit(view)
+	cached := cache.entries[*txHash]
+	if !cached.IsOutputSpent(0) || cached.IsOutputSpent(1) {
+		t.Fatalf("unexpected spent outputs after commit")
+	}
+	for outputIndex, out := range cached.sparseOutputs {
+		if !out.modified {
+			t.Fatalf("output %d is not marked modified", outputIndex)
+		}
+	}
+
+	// Ensure marking a flush complete marks the entries unmodified and
+	// records the flushed block.
+	node := &blockNode{hash: chainhash.Hash{0x01}, height: 101}
+	cache.markFlushed(node)
+	if !cache.isFlushedAt(node) {
+		t.Fatalf("flushed block is not recorded")
+	}
+	cached = cache.entries[*txHash]
+	if cached.modified || cached.sparseOutputs[0].modified ||
+		cached.sparseOutputs[1].modified {
+		t.Fatalf("entry is still marked modified after flush")
+	}
+
+	// Spend the remaining output and ensure the fully spent entry is
+	// reported as spent until it is removed by the next flush.
+	entry, _ = cache.lookupEntry(txHash)
+	entry.SpendOutput(1)
+	view = NewUtxoViewpoint()
+	view.entries[*txHash] = entry
+	cache.commit(view)
+	if entry, ok := cache.lookupEntry(txHash); !ok || entry != nil {
+		t.Fatalf("fully spent entry is not reported as spent")
+	}
+	cache.markFlushed(&blockNode{hash: chainhash.Hash{0x02}, height: 102})
+	if _, ok := cache.lookupEntry(txHash); ok {
+		t.Fatalf("fully spent entry is still cached after flush")
+	}
+	if cache.totalSize != 0 {
+		t.Fatalf("unexpected cache size -- got %d, want 0", cache.totalSize)
+	}
+}
+
+// TestUtxoCacheFlushPolicy ensures the utxo cache reports it must be flushed
+// according to its size and the number of unflushed blocks and that entries are
+// evicted when it exceeds its maximum size.
+func TestUtxoCacheFlushPolicy(t *testing.T) {
+	t.Parallel()
+
+	entry := newUtxoEntry(1, 100, 1, false, false, stake.TxTypeRegular)
+	entry.sparseOutputs[0] = &utxoOutput{amount: 1000, pkScript: []byte{0x51},
+		modified: true}
+	entry.modified = true
+	size := utxoEntrySize(entry)
+
+	cache := newUtxoCache(nil, size*2)
+	cache.markFlushed(&blockNode{height: 100})
+	if cache.needsFlush(101) {
+		t.Fatalf("empty cache needs flush")
+	}
+	if !cache.needsFlush(100 + utxoCacheMaxUnflushedBlocks) {
+		t.Fatalf("cache does not need flush after max unflushed blocks")
+	}
+
+	view := NewUtxoViewpoint()
+	view.entries[chainhash.Hash{0x01}] = entry
+	view.entries[chainhash.Hash{0x02}] = entry.clone()
+	cache.commit(view)
+	if !cache.needsFlush(101) {
+		t.Fatalf("cache at its maximum size does not need flush")
+	}
+	cache.markFlushed(&blockNode{height: 101})
+	if len(cache.entries) != 0 || cache.totalSize != 0 {
+		t.Fatalf("entries were not evicted from cache exceeding its " +
+			"maximum size")
+	}
+
+	// Ensure a cache without a maximum size always needs to be flushed.
+	cache = newUtxoCache(nil, 0)
+	if !cache.needsFlush(0) {
+		t.Fatalf("cache without maximum size does not need flush")
+	}
+}
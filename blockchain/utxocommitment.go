@@ -0,0 +1,460 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// The experimental utxo commitments prototype a consensus change which commits
+// to the utxo set in every block.  Each block commits to the utxo set which
+// results from connecting its parent, which is the state of the utxo set the
+// block is connected to, in the extra data of its header.
+//
+// The utxo set is hashed by MuHash, a rolling multiset hash which is the
+// product modulo a 3072-bit prime of the hashes of all unspent outputs mapped to
+// elements of the multiplicative group of integers modulo that prime.  This
+// allows the hash to be updated as blocks are connected and disconnected by
+// multiplying by the elements of the outputs that are created and dividing by
+// the elements of the outputs that are spent, without regard to the order in
+// which that happens.  Finding different sets of outputs with the same hash
+// requires solving the discrete logarithm problem in that group, unlike with an
+// additive hash modulo 2^256, for which colliding sets can be found with
+// generalized birthday attacks.  The commitment is the hash of the product
+// truncated to the space available in the extra data.
+//
+// NOTE: The commitments remain an experiment which is only available on the
+// simulation network.  They are meant to evaluate the cost of maintaining and
+// validating the commitments, and the serialization of the elements and the
+// commitment is subject to change.
+
+const (
+	// UtxoCommitmentOffset is the offset in the extra data of a block
+	// header at which the utxo set commitment is placed.  The bytes before
+	// it remain available to miners as extra nonce space.
+	UtxoCommitmentOffset = 8
+
+	// UtxoCommitmentSize is the number of bytes of a utxo set commitment,
+	// which is the remainder of the extra data of a block header.
+	UtxoCommitmentSize = 32 - UtxoCommitmentOffset
+
+	// utxoCommitmentsDepth is the number of blocks below a newly connected
+	// block for which the hash of the utxo set is retained.  The hashes
+	// of older blocks are calculated again from the spend journal when
+	// they are needed to reorganize the chain.
+	utxoCommitmentsDepth = 288
+
+	// utxoSetHashBits is the size in bits of the prime the utxo set hash
+	// is calculated modulo and of the elements it is calculated from.
+	utxoSetHashBits = 3072
+)
+
+// utxoSetHashPrime is the 3072-bit prime 2^3072 - 1103717 the utxo set hash is
+// calculated modulo, which is the largest 3072-bit safe prime.
+var utxoSetHashPrime = func() *big.Int {
+	p := new(big.Int).Lsh(big.NewInt(1), utxoSetHashBits)
+	return p.Sub(p, big.NewInt(1103717))
+}()
+
+// utxoSetHash is a rolling multiset hash of a utxo set.  It is kept as a
+// fraction so removing outputs does not require a modular inversion each time,
+// and a nil numerator or denominator is one, which makes the zero value the
+// hash of the empty set.
+//
+// The integers are never modified once they are part of a hash, so copies of a
+// hash can be updated independently.
+type utxoSetHash struct {
+	numerator   *big.Int
+	denominator *big.Int
+}
+
+// mulModSetHashPrime returns the product of the passed integers modulo the
+// utxo set hash prime, where nil integers are one.
+func mulModSetHashPrime(a, b *big.Int) *big.Int {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	product := new(big.Int).Mul(a, b)
+	return product.Mod(product, utxoSetHashPrime)
+}
+
+// utxoSetElement returns the element of the group the utxo set hash is
+// calculated in for the passed output hash.  The output hash is expanded to the
+// size of the prime by hashing it along with a counter.  The result is zero
+// modulo the prime with negligible probability.
+func utxoSetElement(outputHash *chainhash.Hash) *big.Int {
+	const numHashes = utxoSetHashBits / 8 / chainhash.HashSize
+	var expanded [numHashes * chainhash.HashSize]byte
+	var preimage [chainhash.HashSize + 1]byte
+	copy(preimage[:], outputHash[:])
+	for i := 0; i < numHashes; i++ {
+		preimage[chainhash.HashSize] = byte(i)
+		copy(expanded[i*chainhash.HashSize:], chainhash.HashB(preimage[:]))
+	}
+	element := new(big.Int).SetBytes(expanded[:])
+	return element.Mod(element, utxoSetHashPrime)
+}
+
+// utxoOutputHash returns the hash of the unspent output with the passed
+// outpoint and details that is accumulated into the hash of a utxo set.  The
+// tree of the outpoint is not committed to since the utxo set does not store
+// it.
+func utxoOutputHash(txHash *chainhash.Hash, outputIndex uint32, amount int64,
+	scriptVersion uint16, pkScript []byte) chainhash.Hash {
+
+	serialized := make([]byte, chainhash.HashSize+14+len(pkScript))
+	copy(serialized, txHash[:])
+	offset := chainhash.HashSize
+	binary.LittleEndian.PutUint32(serialized[offset:], outputIndex)
+	binary.LittleEndian.PutUint64(serialized[offset+4:], uint64(amount))
+	binary.LittleEndian.PutUint16(serialized[offset+12:], scriptVersion)
+	copy(serialized[offset+14:], pkScript)
+	return chainhash.HashH(serialized)
+}
+
+// add adds the passed output hash to the utxo set hash.
+func (h *utxoSetHash) add(outputHash *chainhash.Hash) {
+	h.numerator = mulModSetHashPrime(h.numerator, utxoSetElement(outputHash))
+}
+
+// sub removes the passed output hash from the utxo set hash.
+func (h *utxoSetHash) sub(outputHash *chainhash.Hash) {
+	h.denominator = mulModSetHashPrime(h.denominator,
+		utxoSetElement(outputHash))
+}
+
+// value returns the element of the group the utxo set hash represents.
+func (h *utxoSetHash) value() *big.Int {
+	if h.denominator == nil {
+		if h.numerator == nil {
+			return big.NewInt(1)
+		}
+		return h.numerator
+	}
+	inverse := new(big.Int).ModInverse(h.denominator, utxoSetHashPrime)
+	return mulModSetHashPrime(h.numerator, inverse)
+}
+
+// equal returns whether the passed utxo set hash is the hash of the same utxo
+// set.
+func (h *utxoSetHash) equal(other *utxoSetHash) bool {
+	return h.value().Cmp(other.value()) == 0
+}
+
+// commitment returns the commitment to the utxo set the hash is for.
+func (h *utxoSetHash) commitment() [UtxoCommitmentSize]byte {
+	var serialized [utxoSetHashBits / 8]byte
+	value := h.value().Bytes()
+	copy(serialized[len(serialized)-len(value):], value)
+	var commitment [UtxoCommitmentSize]byte
+	copy(commitment[:], chainhash.HashB(serialized[:]))
+	return commitment
+}
+
+// addTxOuts adds the hashes of all outputs of the passed transaction which are
+// not provably unspendable, which are the outputs added to the utxo set, to the
+// utxo set hash.
+func (h *utxoSetHash) addTxOuts(tx *wire.MsgTx) {
+	txHash := tx.TxHash()
+	for txOutIdx, txOut := range tx.TxOut {
+		if txscript.IsUnspendable(txOut.Value, txOut.PkScript) {
+			continue
+		}
+		outputHash := utxoOutputHash(&txHash, uint32(txOutIdx),
+			txOut.Value, txOut.Version, txOut.PkScript)
+		h.add(&outputHash)
+	}
+}
+
+// applyBlock updates the utxo set hash for connecting the passed block, or for
+// disconnecting it when the disconnect flag is set, by accounting for the
+// outputs it creates and the passed spent outputs.  The transactions are
+// visited in the same order they are connected in, which is the order of the
+// spent outputs.
+func (h *utxoSetHash) applyBlock(block, parent *dcrutil.Block, stxos []spentTxOut, disconnect bool) error {
+	if len(stxos) != countSpentOutputs(block, parent) {
+		return AssertError(fmt.Sprintf("unable to hash the utxo set of "+
+			"block %v with %d stxos, yet counted %d spent utxos",
+			block.Hash(), len(stxos), countSpentOutputs(block, parent)))
+	}
+
+	// Accumulate the created and spent outputs separately so they can be
+	// applied in either direction.
+	var created, spent utxoSetHash
+	var txns []*wire.MsgTx
+	if dcrutil.IsFlagSet16(block.MsgBlock().Header.VoteBits,
+		dcrutil.BlockValid) {
+
+		txns = append(txns, parent.MsgBlock().Transactions...)
+	}
+	txns = append(txns, block.MsgBlock().STransactions...)
+	var stxoIdx int
+	for _, tx := range txns {
+		created.addTxOuts(tx)
+		if IsCoinBaseTx(tx) {
+			continue
+		}
+
+		isVote := stake.DetermineTxType(tx) == stake.TxTypeSSGen
+		for txInIdx, txIn := range tx.TxIn {
+			// The stakebase input of votes does not spend anything.
+			if txInIdx == 0 && isVote {
+				continue
+			}
+
+			stxo := &stxos[stxoIdx]
+			stxoIdx++
+			pkScript := stxo.pkScript
+			if stxo.compressed {
+				pkScript = decompressScript(pkScript,
+					currentCompressionVersion)
+			}
+			prevOut := &txIn.PreviousOutPoint
+			outputHash := utxoOutputHash(&prevOut.Hash, prevOut.Index,
+				stxo.amount, stxo.scriptVersion, pkScript)
+			spent.add(&outputHash)
+		}
+	}
+
+	if disconnect {
+		created, spent = spent, created
+	}
+	h.addSetHash(&created)
+	h.subSetHash(&spent)
+	return nil
+}
+
+// addSetHash adds the outputs hashed by the passed utxo set hash to the utxo
+// set hash.
+func (h *utxoSetHash) addSetHash(other *utxoSetHash) {
+	h.numerator = mulModSetHashPrime(h.numerator, other.numerator)
+	h.denominator = mulModSetHashPrime(h.denominator, other.denominator)
+}
+
+// subSetHash removes the outputs hashed by the passed utxo set hash from the
+// utxo set hash.
+func (h *utxoSetHash) subSetHash(other *utxoSetHash) {
+	h.numerator = mulModSetHashPrime(h.numerator, other.denominator)
+	h.denominator = mulModSetHashPrime(h.denominator, other.numerator)
+}
+
+// utxoSetState houses the hash of the utxo set which results from connecting a
+// block.
+type utxoSetState struct {
+	height int64
+	hash   utxoSetHash
+}
+
+// connectUtxoCommitment records the hash of the utxo set which results from
+// connecting the passed block, which spends the passed outputs, on top of its
+// parent.  Nothing is recorded when utxo commitments are disabled or the hash
+// for the parent is not known.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) connectUtxoCommitment(node *blockNode, block, parent *dcrutil.Block, stxos []spentTxOut) error {
+	if b.utxoCommitments == nil {
+		return nil
+	}
+	parentState, ok := b.utxoCommitments[node.header.PrevBlock]
+	if !ok {
+		return nil
+	}
+
+	setHash := parentState.hash
+	if err := setHash.applyBlock(block, parent, stxos, false); err != nil {
+		return err
+	}
+	b.utxoCommitments[node.hash] = &utxoSetState{
+		height: node.height,
+		hash:   setHash,
+	}
+
+	// Remove the hashes which are too deep to be needed again.
+	for hash, state := range b.utxoCommitments {
+		if state.height < node.height-utxoCommitmentsDepth {
+			delete(b.utxoCommitments, hash)
+		}
+	}
+	return nil
+}
+
+// disconnectUtxoCommitment records the hash of the utxo set which results from
+// the parent of the passed block by disconnecting the block, which spends the
+// passed outputs, from the utxo set hash which resulted from it.  This allows
+// the commitments of blocks that are attached on top of the parent during a
+// reorganization to be validated once the hash for the parent is no longer
+// retained.  Nothing is recorded when utxo commitments are disabled or the hash
+// for the block is not known.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) disconnectUtxoCommitment(node *blockNode, block, parent *dcrutil.Block, stxos []spentTxOut) error {
+	if b.utxoCommitments == nil {
+		return nil
+	}
+	if _, ok := b.utxoCommitments[node.header.PrevBlock]; ok {
+		return nil
+	}
+	state, ok := b.utxoCommitments[node.hash]
+	if !ok {
+		return nil
+	}
+
+	setHash := state.hash
+	if err := setHash.applyBlock(block, parent, stxos, true); err != nil {
+		return err
+	}
+	b.utxoCommitments[node.header.PrevBlock] = &utxoSetState{
+		height: node.height - 1,
+		hash:   setHash,
+	}
+	return nil
+}
+
+// checkUtxoCommitment ensures the extra data of the passed block header commits
+// to the utxo set which results from its parent when utxo commitments are
+// enabled.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) checkUtxoCommitment(header *wire.BlockHeader) error {
+	if b.utxoCommitments == nil {
+		return nil
+	}
+	parentState, ok := b.utxoCommitments[header.PrevBlock]
+	if !ok {
+		return AssertError(fmt.Sprintf("the utxo set hash of block %v "+
+			"is not known", header.PrevBlock))
+	}
+
+	var commitment [UtxoCommitmentSize]byte
+	copy(commitment[:], header.ExtraData[UtxoCommitmentOffset:])
+	if want := parentState.hash.commitment(); commitment != want {
+		str := fmt.Sprintf("block commits to utxo set %x instead of "+
+			"the expected utxo set %x", commitment, want)
+		return ruleError(ErrBadUtxoCommitment, str)
+	}
+	return nil
+}
+
+// initUtxoCommitments calculates the hash of the utxo set which results from
+// the end of the main chain from the utxo set in the database when utxo
+// commitments are enabled.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) initUtxoCommitments() error {
+	if b.utxoCommitments == nil {
+		return nil
+	}
+
+	// The utxo set is read directly from the database, so write the
+	// modifications which are held by the utxo cache first.
+	best := b.bestNode
+	err := b.db.Update(func(dbTx database.Tx) error {
+		return b.utxoCache.dbFlush(dbTx, nil, best)
+	})
+	if err != nil {
+		return err
+	}
+	b.utxoCache.markFlushed(best)
+
+	log.Infof("Calculating the utxo set hash of the main chain")
+	var setHash utxoSetHash
+	var numOutputs uint64
+	addOutput := func(txHash *chainhash.Hash, outputIndex uint32, out *utxoOutput) {
+		out.maybeDecompress(currentCompressionVersion)
+		outputHash := utxoOutputHash(txHash, outputIndex, out.amount,
+			out.scriptVersion, out.pkScript)
+		setHash.add(&outputHash)
+		numOutputs++
+	}
+	err = b.db.View(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if utxoBucket := meta.Bucket(dbnamespace.UtxoSetV2BucketName); utxoBucket != nil {
+			cursor := utxoBucket.Cursor()
+			for ok := cursor.First(); ok; ok = cursor.Next() {
+				key := cursor.Key()
+				outputIndex, err := decodeOutpointKey(key)
+				if err != nil {
+					return err
+				}
+				_, out, err := deserializeUtxoOutput(cursor.Value())
+				if err != nil {
+					return err
+				}
+				var txHash chainhash.Hash
+				copy(txHash[:], key[:chainhash.HashSize])
+				addOutput(&txHash, outputIndex, out)
+			}
+		}
+
+		// Include the entries which have not been migrated to the
+		// version 2 utxo set yet.
+		legacyBucket := meta.Bucket(dbnamespace.UtxoSetBucketName)
+		if legacyBucket == nil {
+			return nil
+		}
+		return legacyBucket.ForEach(func(k, v []byte) error {
+			entry, err := deserializeUtxoEntry(v)
+			if err != nil {
+				return err
+			}
+			var txHash chainhash.Hash
+			copy(txHash[:], k)
+			for outputIndex, out := range entry.sparseOutputs {
+				if !out.spent {
+					addOutput(&txHash, outputIndex, out)
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	b.utxoCommitments[best.hash] = &utxoSetState{
+		height: best.height,
+		hash:   setHash,
+	}
+	log.Infof("Utxo set hash of block %v (height %d) calculated from %d "+
+		"unspent outputs", best.hash, best.height, numOutputs)
+	return nil
+}
+
+// UtxoCommitment returns the commitment to the utxo set which results from the
+// block with the passed hash that must be included in the extra data of the
+// header of its children, starting at UtxoCommitmentOffset, when utxo
+// commitments are enabled.  An error is returned when they are disabled or the
+// utxo set of the block is not known, which is the case for blocks that are not
+// in the main chain or a side chain that was recently validated.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UtxoCommitment(hash *chainhash.Hash) ([UtxoCommitmentSize]byte, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if b.utxoCommitments == nil {
+		return [UtxoCommitmentSize]byte{}, fmt.Errorf("utxo commitments " +
+			"are not enabled")
+	}
+	state, ok := b.utxoCommitments[*hash]
+	if !ok {
+		return [UtxoCommitmentSize]byte{}, fmt.Errorf("the utxo set of "+
+			"block %v is not known", hash)
+	}
+	return state.hash.commitment(), nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// TestUtxoSetHashArithmetic ensures the utxo set hash does not depend on the
+// order outputs are added in, removing an output undoes adding it, copies of a
+// hash are independent, and the empty set hash is one.
+func TestUtxoSetHashArithmetic(t *testing.T) {
+	var txHash chainhash.Hash
+	out1 := utxoOutputHash(&txHash, 0, 1e8, 0, []byte{0x51})
+	out2 := utxoOutputHash(&txHash, 1, 2e8, 0, []byte{0x52})
+
+	var h1, h2 utxoSetHash
+	h1.add(&out1)
+	h1.add(&out2)
+	h2.add(&out2)
+	h2.add(&out1)
+	if !h1.equal(&h2) || h1.commitment() != h2.commitment() {
+		t.Fatalf("utxo set hash depends on order - got %x, want %x",
+			h2.commitment(), h1.commitment())
+	}
+
+	// Ensure updating a copy does not modify the original.
+	h3 := h1
+	h3.sub(&out2)
+	if h1.equal(&h3) || !h1.equal(&h2) {
+		t.Fatal("updating a copy modified the original utxo set hash")
+	}
+
+	h1.sub(&out1)
+	h1.sub(&out2)
+	var empty utxoSetHash
+	if !h1.equal(&empty) || h1.value().Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("removing all outputs did not result in an empty "+
+			"utxo set hash - got %x", h1.value())
+	}
+
+	// Ensure set hashes combine the same way as their outputs.
+	var sum utxoSetHash
+	sum.addSetHash(&h2)
+	sum.subSetHash(&h3)
+	var want utxoSetHash
+	want.add(&out2)
+	if !sum.equal(&want) {
+		t.Fatalf("combined utxo set hash %x, want %x", sum.value(),
+			want.value())
+	}
+
+	// Ensure the hash is calculated modulo a 3072-bit safe prime.
+	p := utxoSetHashPrime
+	q := new(big.Int).Rsh(p, 1)
+	if p.BitLen() != utxoSetHashBits || !p.ProbablyPrime(20) ||
+		!q.ProbablyPrime(20) {
+
+		t.Fatalf("utxo set hash modulus %x is not a %d-bit safe prime", p,
+			utxoSetHashBits)
+	}
+}
+
+// TestUtxoSetHashApplyBlock ensures connecting a block updates the utxo set
+// hash to the hash of the resulting utxo set and disconnecting it again
+// restores the original hash.
+func TestUtxoSetHashApplyBlock(t *testing.T) {
+	// The utxo set initially only contains an output of a previous
+	// transaction.
+	prevOut := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 2}
+	prevPkScript := []byte{0x76, 0xa9}
+	prevOutHash := utxoOutputHash(&prevOut.Hash, prevOut.Index, 5e8, 0,
+		prevPkScript)
+	var setHash utxoSetHash
+	setHash.add(&prevOutHash)
+	original := setHash
+
+	// The parent contains a coinbase and a transaction which spends the
+	// previous output and creates a spendable and an unspendable output.
+	coinbase := wire.NewMsgTx()
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		math.MaxUint32, wire.TxTreeRegular), nil))
+	coinbase.AddTxOut(wire.NewTxOut(3e8, []byte{0x51}))
+	spend := wire.NewMsgTx()
+	spend.AddTxIn(wire.NewTxIn(&prevOut, nil))
+	spend.AddTxOut(wire.NewTxOut(4e8, []byte{0x52}))
+	spend.AddTxOut(wire.NewTxOut(0, []byte{0x6a}))
+	parent := dcrutil.NewBlock(&wire.MsgBlock{
+		Transactions: []*wire.MsgTx{coinbase, spend},
+	})
+	block := dcrutil.NewBlock(&wire.MsgBlock{
+		Header: wire.BlockHeader{VoteBits: dcrutil.BlockValid},
+	})
+	stxos := []spentTxOut{{
+		amount:   5e8,
+		pkScript: prevPkScript,
+	}}
+
+	err := setHash.applyBlock(block, parent, stxos, false)
+	if err != nil {
+		t.Fatalf("applyBlock: unexpected error connecting block: %v", err)
+	}
+	var want utxoSetHash
+	coinbaseHash, spendHash := coinbase.TxHash(), spend.TxHash()
+	coinbaseOut := utxoOutputHash(&coinbaseHash, 0, 3e8, 0, []byte{0x51})
+	spendOut := utxoOutputHash(&spendHash, 0, 4e8, 0, []byte{0x52})
+	want.add(&coinbaseOut)
+	want.add(&spendOut)
+	if !setHash.equal(&want) {
+		t.Fatalf("applyBlock: unexpected utxo set hash after connecting "+
+			"block - got %x, want %x", setHash.commitment(),
+			want.commitment())
+	}
+	if setHash.commitment() == original.commitment() {
+		t.Fatal("commitment did not change after connecting block")
+	}
+
+	err = setHash.applyBlock(block, parent, stxos, true)
+	if err != nil {
+		t.Fatalf("applyBlock: unexpected error disconnecting block: %v",
+			err)
+	}
+	if !setHash.equal(&original) {
+		t.Fatalf("applyBlock: unexpected utxo set hash after "+
+			"disconnecting block - got %x, want %x",
+			setHash.commitment(), original.commitment())
+	}
+
+	// Ensure the wrong number of spent outputs is rejected.
+	if err := setHash.applyBlock(block, parent, nil, false); err == nil {
+		t.Fatal("applyBlock: accepted missing spent outputs")
+	}
+}
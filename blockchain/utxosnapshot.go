@@ -0,0 +1,623 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/decred/dcrd/blockchain/internal/dbnamespace"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/wire"
+)
+
+// -----------------------------------------------------------------------------
+// A utxo set snapshot houses the utxo set and the live ticket pool which result
+// from a block of the main chain.  The outputs are ordered by their key in the
+// version 2 utxo set, so snapshots of the same block are identical regardless
+// of the node they were created by.
+//
+// The serialized format is:
+//
+//   <magic><version><network><block hash><block height><outputs><tickets>
+//   <num outputs><checksum>
+//
+//   Field            Type              Size
+//   magic            [4]byte           4
+//   version          uint32            4
+//   network          wire.CurrencyNet  4
+//   block hash       chainhash.Hash    chainhash.HashSize
+//   block height     uint32            4
+//   outputs          []output          variable
+//   end of outputs   uint32            4 (always zero)
+//   num tickets      uint32            4
+//   tickets          []chainhash.Hash  num tickets * chainhash.HashSize
+//   num outputs      uint64            8
+//   checksum         [32]byte          32 (sha256 of all preceding bytes)
+//
+// Each output is serialized as:
+//
+//   <size><outpoint key><output>
+//
+//   Field            Type              Size
+//   size             uint32            4
+//   outpoint key     []byte            variable
+//   output           []byte            size - len(outpoint key)
+//
+// The outpoint key and output use the version 2 utxo set format described in
+// chainio.go.  All integers are little endian.
+//
+// Snapshots are only meant for exporting the utxo set and verifying it against
+// the main chain of a node which already has the blocks.  They are not signed,
+// and the checksum only protects against corruption, so a snapshot must never
+// be trusted without verifying it.  Bootstrapping a node from a snapshot along
+// with validating the historical chain in the background is not supported
+// since the block index and ticket database are built from the stored blocks.
+// -----------------------------------------------------------------------------
+
+const (
+	// utxoSnapshotVersion is the current version of the utxo set snapshot
+	// format.
+	utxoSnapshotVersion = 1
+
+	// maxUtxoSnapshotRecord is the maximum size of a serialized output in
+	// a utxo set snapshot that is accepted.
+	maxUtxoSnapshotRecord = 1 << 20
+)
+
+// utxoSnapshotMagic identifies a utxo set snapshot file.
+var utxoSnapshotMagic = [4]byte{'d', 'u', 't', 'x'}
+
+// errLegacyUtxoSet is returned when attempting to create a utxo set snapshot
+// while the utxo set is still being migrated to the version 2 format.
+var errLegacyUtxoSet = errors.New("the utxo set must be fully migrated " +
+	"before a snapshot of it can be created")
+
+// UtxoSnapshotInfo describes a utxo set snapshot.
+type UtxoSnapshotInfo struct {
+	// Network is the network the snapshot is for.
+	Network wire.CurrencyNet
+
+	// Hash and Height identify the block the utxo set results from.
+	Hash   chainhash.Hash
+	Height int64
+
+	// NumOutputs and NumTickets are the number of unspent outputs and live
+	// tickets in the snapshot.
+	NumOutputs uint64
+	NumTickets uint32
+
+	// UtxoSetHash is the commitment to the utxo set in the same form as the
+	// experimental utxo commitments.  See UtxoCommitment.
+	UtxoSetHash [UtxoCommitmentSize]byte
+
+	// Checksum is the sha256 hash of the contents of the snapshot.  Since
+	// the contents are deterministic, it identifies the snapshot.
+	Checksum [sha256.Size]byte
+}
+
+// keySorter implements sort.Interface to allow a slice of database keys to be
+// sorted in the order the database iterates them.
+type keySorter [][]byte
+
+// Len returns the number of keys in the slice.  It is part of the
+// sort.Interface implementation.
+func (s keySorter) Len() int {
+	return len(s)
+}
+
+// Swap swaps the keys at the passed indices.  It is part of the sort.Interface
+// implementation.
+func (s keySorter) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Less returns whether the key with index i sorts before the key with index j.
+// It is part of the sort.Interface implementation.
+func (s keySorter) Less(i, j int) bool {
+	return bytes.Compare(s[i], s[j]) < 0
+}
+
+// utxoSnapshotWriter serializes a utxo set snapshot while calculating its
+// checksum and utxo set hash.  Writing stops at the first error, which is
+// returned by finish.
+type utxoSnapshotWriter struct {
+	w          io.Writer
+	buf        *bufio.Writer
+	checksum   hash.Hash
+	setHash    utxoSetHash
+	numOutputs uint64
+	err        error
+}
+
+// newUtxoSnapshotWriter returns a utxo set snapshot writer which writes to the
+// passed writer.
+func newUtxoSnapshotWriter(w io.Writer) *utxoSnapshotWriter {
+	checksum := sha256.New()
+	return &utxoSnapshotWriter{
+		w:        w,
+		buf:      bufio.NewWriter(io.MultiWriter(w, checksum)),
+		checksum: checksum,
+	}
+}
+
+// write writes the passed bytes unless a previous write failed.
+func (sw *utxoSnapshotWriter) write(b []byte) {
+	if sw.err == nil {
+		_, sw.err = sw.buf.Write(b)
+	}
+}
+
+// writeUint32 writes the passed value.
+func (sw *utxoSnapshotWriter) writeUint32(v uint32) {
+	var serialized [4]byte
+	binary.LittleEndian.PutUint32(serialized[:], v)
+	sw.write(serialized[:])
+}
+
+// writeHeader writes the fields which identify the snapshot.
+func (sw *utxoSnapshotWriter) writeHeader(net wire.CurrencyNet, hash *chainhash.Hash, height int64) {
+	sw.write(utxoSnapshotMagic[:])
+	sw.writeUint32(utxoSnapshotVersion)
+	sw.writeUint32(uint32(net))
+	sw.write(hash[:])
+	sw.writeUint32(uint32(height))
+}
+
+// writeOutput writes the passed unspent output of the passed entry of the
+// transaction with the passed hash.
+func (sw *utxoSnapshotWriter) writeOutput(txHash *chainhash.Hash, outputIndex uint32, entry *UtxoEntry, out *utxoOutput) {
+	key := outpointKey(txHash, outputIndex)
+	serialized := serializeUtxoOutput(entry, out)
+	sw.writeUint32(uint32(len(key) + len(serialized)))
+	sw.write(key)
+	sw.write(serialized)
+
+	out.maybeDecompress(currentCompressionVersion)
+	outputHash := utxoOutputHash(txHash, outputIndex, out.amount,
+		out.scriptVersion, out.pkScript)
+	sw.setHash.add(&outputHash)
+	sw.numOutputs++
+}
+
+// writeTickets ends the outputs and writes the passed live tickets.
+func (sw *utxoSnapshotWriter) writeTickets(tickets []chainhash.Hash) {
+	sw.writeUint32(0)
+	sw.writeUint32(uint32(len(tickets)))
+	for i := range tickets {
+		sw.write(tickets[i][:])
+	}
+}
+
+// finish writes the number of outputs and the checksum and returns the
+// resulting checksum and utxo set hash.
+func (sw *utxoSnapshotWriter) finish() ([sha256.Size]byte, error) {
+	var checksum [sha256.Size]byte
+	var numOutputs [8]byte
+	binary.LittleEndian.PutUint64(numOutputs[:], sw.numOutputs)
+	sw.write(numOutputs[:])
+	if sw.err == nil {
+		sw.err = sw.buf.Flush()
+	}
+	if sw.err != nil {
+		return checksum, sw.err
+	}
+
+	copy(checksum[:], sw.checksum.Sum(nil))
+	_, err := sw.w.Write(checksum[:])
+	return checksum, err
+}
+
+// utxoSnapshotOutputKeys returns the keys of the unspent outputs of the passed
+// entry of the transaction with the passed hash in the order the database
+// iterates them along with the output indexes by key.
+func utxoSnapshotOutputKeys(txHash *chainhash.Hash, entry *UtxoEntry) ([][]byte, map[string]uint32) {
+	keys := make([][]byte, 0, len(entry.sparseOutputs))
+	indexes := make(map[string]uint32, len(entry.sparseOutputs))
+	for outputIndex, out := range entry.sparseOutputs {
+		if out.spent {
+			continue
+		}
+		key := outpointKey(txHash, outputIndex)
+		keys = append(keys, key)
+		indexes[string(key)] = outputIndex
+	}
+	sort.Sort(keySorter(keys))
+	return keys, indexes
+}
+
+// utxoSnapshotSource houses the state a utxo set snapshot is written from.  It
+// is gathered while the chain state lock is held and then allows the snapshot
+// to be written without holding the lock.
+type utxoSnapshotSource struct {
+	node *blockNode
+
+	// view houses the modifications needed to roll the utxo set in dbTx
+	// back to node, and viewHashes houses the hashes of its entries in the
+	// order the database iterates them.
+	view       *UtxoViewpoint
+	viewHashes [][]byte
+
+	// dbTx is a read only database transaction, which provides a
+	// consistent snapshot of the utxo set regardless of any blocks which
+	// are connected while the snapshot is written.
+	dbTx    database.Tx
+	tickets []chainhash.Hash
+}
+
+// prepareUtxoSnapshot gathers the state needed to write a snapshot of the utxo
+// set and live ticket pool which result from the main chain block at the
+// passed height.  The utxo set is rolled back from the end of the main chain by
+// disconnecting the blocks after the block in a view, so the blocks and spend
+// journal entries of those blocks must be available.
+//
+// The database transaction of the returned source must be rolled back by the
+// caller when writeUtxoSnapshot is not called.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) prepareUtxoSnapshot(height int64) (*utxoSnapshotSource, error) {
+	best := b.bestNode
+	node, err := b.ancestorNode(best, height)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, fmt.Errorf("no block at height %d exists in the main "+
+			"chain", height)
+	}
+
+	// The utxo set is read directly from the database, so write the
+	// modifications which are held by the utxo cache first.
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return b.utxoCache.dbFlush(dbTx, nil, best)
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.utxoCache.markFlushed(best)
+
+	// Roll the utxo set back to the requested block by disconnecting the
+	// blocks after it from a view of the utxo set.
+	view := NewUtxoViewpoint()
+	view.SetBestHash(&best.hash)
+	view.SetStakeViewpoint(ViewpointPrevValidInitial)
+	for n := best; n.height > node.height; {
+		block, err := b.fetchBlockFromHash(&n.hash)
+		if err != nil {
+			return nil, err
+		}
+		parent, err := b.fetchBlockFromHash(&n.header.PrevBlock)
+		if err != nil {
+			return nil, err
+		}
+		var stxos []spentTxOut
+		err = b.db.View(func(dbTx database.Tx) error {
+			stxos, err = dbFetchSpendJournalEntry(dbTx, block, parent)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = b.disconnectTransactions(view, block, parent, stxos)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err = b.getPrevNodeFromNode(n)
+		if err != nil {
+			return nil, err
+		}
+	}
+	stakeNode, err := b.fetchStakeNode(node)
+	if err != nil {
+		return nil, err
+	}
+
+	viewHashes := make([][]byte, 0, len(view.entries))
+	for txHash := range view.entries {
+		viewHashes = append(viewHashes, append([]byte(nil), txHash[:]...))
+	}
+	sort.Sort(keySorter(viewHashes))
+
+	// Start the database transaction the outputs are read from while the
+	// utxo set in the database still results from the current best block.
+	dbTx, err := b.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	if dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName) != nil {
+		dbTx.Rollback()
+		return nil, errLegacyUtxoSet
+	}
+
+	return &utxoSnapshotSource{
+		node:       node,
+		view:       view,
+		viewHashes: viewHashes,
+		dbTx:       dbTx,
+		tickets:    stakeNode.LiveTickets(),
+	}, nil
+}
+
+// writeUtxoSnapshot writes the snapshot described by the passed source to the
+// passed writer and rolls back the database transaction of the source.
+//
+// This function is safe for concurrent access since it only reads from the
+// database transaction and the state which was gathered by
+// prepareUtxoSnapshot.
+func (b *BlockChain) writeUtxoSnapshot(w io.Writer, src *utxoSnapshotSource) (*UtxoSnapshotInfo, error) {
+	defer src.dbTx.Rollback()
+
+	// Write the outputs in the order of their keys.  The entries in the
+	// view replace all outputs of their transaction in the database, so
+	// they are merged into the outputs from the database by hash.
+	view, viewHashes := src.view, src.viewHashes
+	sw := newUtxoSnapshotWriter(w)
+	sw.writeHeader(b.chainParams.Net, &src.node.hash, src.node.height)
+	writeViewEntry := func(hashBytes []byte) {
+		var txHash chainhash.Hash
+		copy(txHash[:], hashBytes)
+		entry := view.entries[txHash]
+		if entry == nil {
+			return
+		}
+		keys, indexes := utxoSnapshotOutputKeys(&txHash, entry)
+		for _, key := range keys {
+			outputIndex := indexes[string(key)]
+			sw.writeOutput(&txHash, outputIndex, entry,
+				entry.sparseOutputs[outputIndex])
+		}
+	}
+
+	var next int
+	meta := src.dbTx.Metadata()
+	cursor := meta.Bucket(dbnamespace.UtxoSetV2BucketName).Cursor()
+	for ok := cursor.First(); ok && sw.err == nil; ok = cursor.Next() {
+		key := cursor.Key()
+		for next < len(viewHashes) && bytes.Compare(viewHashes[next],
+			key[:chainhash.HashSize]) < 0 {
+
+			writeViewEntry(viewHashes[next])
+			next++
+		}
+
+		var txHash chainhash.Hash
+		copy(txHash[:], key[:chainhash.HashSize])
+		if _, ok := view.entries[txHash]; ok {
+			continue
+		}
+		outputIndex, err := decodeOutpointKey(key)
+		if err != nil {
+			return nil, err
+		}
+		entry, out, err := deserializeUtxoOutput(cursor.Value())
+		if err != nil {
+			return nil, err
+		}
+		sw.writeOutput(&txHash, outputIndex, entry, out)
+	}
+	for ; next < len(viewHashes); next++ {
+		writeViewEntry(viewHashes[next])
+	}
+	sw.writeTickets(src.tickets)
+	checksum, err := sw.finish()
+	if err != nil {
+		return nil, err
+	}
+
+	return &UtxoSnapshotInfo{
+		Network:     b.chainParams.Net,
+		Hash:        src.node.hash,
+		Height:      src.node.height,
+		NumOutputs:  sw.numOutputs,
+		NumTickets:  uint32(len(src.tickets)),
+		UtxoSetHash: sw.setHash.commitment(),
+		Checksum:    checksum,
+	}, nil
+}
+
+// DumpUtxoSnapshot writes a snapshot of the utxo set and live ticket pool which
+// result from the main chain block at the passed height to the passed writer
+// and returns a description of it.  Blocks are only held back while the utxo
+// set is rolled back to the block.  The outputs are then read from a consistent
+// snapshot of the database while blocks continue to be processed.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) DumpUtxoSnapshot(w io.Writer, height int64) (*UtxoSnapshotInfo, error) {
+	b.chainLock.Lock()
+	src, err := b.prepareUtxoSnapshot(height)
+	b.chainLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.writeUtxoSnapshot(w, src)
+}
+
+// VerifyUtxoSnapshot ensures the described snapshot matches the utxo set and
+// live ticket pool which result from the block it is for, which must be in the
+// main chain.  The snapshot the chain results in is created again for the
+// comparison, so this is as expensive as DumpUtxoSnapshot, and blocks are held
+// back for the same duration.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) VerifyUtxoSnapshot(info *UtxoSnapshotInfo) error {
+	if info.Network != b.chainParams.Net {
+		return fmt.Errorf("snapshot is for network %v instead of %v",
+			info.Network, b.chainParams.Net)
+	}
+
+	b.chainLock.Lock()
+	node, err := b.ancestorNode(b.bestNode, info.Height)
+	if err != nil {
+		b.chainLock.Unlock()
+		return err
+	}
+	if node == nil || node.hash != info.Hash {
+		b.chainLock.Unlock()
+		return fmt.Errorf("snapshot block %v (height %d) is not in the "+
+			"main chain", info.Hash, info.Height)
+	}
+	src, err := b.prepareUtxoSnapshot(info.Height)
+	b.chainLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	want, err := b.writeUtxoSnapshot(ioutil.Discard, src)
+	if err != nil {
+		return err
+	}
+	if *want != *info {
+		return fmt.Errorf("snapshot with checksum %x does not match the "+
+			"expected snapshot with checksum %x", info.Checksum,
+			want.Checksum)
+	}
+	return nil
+}
+
+// utxoSnapshotReader deserializes a utxo set snapshot while calculating its
+// checksum.
+type utxoSnapshotReader struct {
+	r        *bufio.Reader
+	checksum hash.Hash
+}
+
+// read reads exactly the length of the passed slice into it.
+func (sr *utxoSnapshotReader) read(b []byte) error {
+	if _, err := io.ReadFull(sr.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	sr.checksum.Write(b)
+	return nil
+}
+
+// readUint32 reads a value written by writeUint32.
+func (sr *utxoSnapshotReader) readUint32() (uint32, error) {
+	var serialized [4]byte
+	if err := sr.read(serialized[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(serialized[:]), nil
+}
+
+// ReadUtxoSnapshot reads a utxo set snapshot from the passed reader and returns
+// a description of it.  The structure and checksum of the snapshot are
+// verified, while VerifyUtxoSnapshot verifies its contents.
+func ReadUtxoSnapshot(r io.Reader) (*UtxoSnapshotInfo, error) {
+	sr := utxoSnapshotReader{r: bufio.NewReader(r), checksum: sha256.New()}
+	var magic [4]byte
+	if err := sr.read(magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != utxoSnapshotMagic {
+		return nil, errors.New("not a utxo set snapshot")
+	}
+	version, err := sr.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	if version != utxoSnapshotVersion {
+		return nil, fmt.Errorf("unsupported utxo set snapshot version %d",
+			version)
+	}
+	net, err := sr.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	info := UtxoSnapshotInfo{Network: wire.CurrencyNet(net)}
+	if err := sr.read(info.Hash[:]); err != nil {
+		return nil, err
+	}
+	height, err := sr.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	info.Height = int64(height)
+
+	var setHash utxoSetHash
+	var record []byte
+	for {
+		size, err := sr.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			break
+		}
+		if size <= chainhash.HashSize || size > maxUtxoSnapshotRecord {
+			return nil, fmt.Errorf("invalid utxo set snapshot output "+
+				"size %d", size)
+		}
+		if uint32(cap(record)) < size {
+			record = make([]byte, size)
+		}
+		record = record[:size]
+		if err := sr.read(record); err != nil {
+			return nil, err
+		}
+
+		var txHash chainhash.Hash
+		copy(txHash[:], record)
+		outputIndex, bytesRead := deserializeVLQ(record[chainhash.HashSize:])
+		offset := chainhash.HashSize + bytesRead
+		if offset >= len(record) {
+			return nil, errors.New("malformed utxo set snapshot output")
+		}
+		_, out, err := deserializeUtxoOutput(record[offset:])
+		if err != nil {
+			return nil, err
+		}
+		out.maybeDecompress(currentCompressionVersion)
+		outputHash := utxoOutputHash(&txHash, uint32(outputIndex),
+			out.amount, out.scriptVersion, out.pkScript)
+		setHash.add(&outputHash)
+		info.NumOutputs++
+	}
+
+	info.NumTickets, err = sr.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	var ticket chainhash.Hash
+	for i := uint32(0); i < info.NumTickets; i++ {
+		if err := sr.read(ticket[:]); err != nil {
+			return nil, err
+		}
+	}
+	var numOutputs [8]byte
+	if err := sr.read(numOutputs[:]); err != nil {
+		return nil, err
+	}
+	if n := binary.LittleEndian.Uint64(numOutputs[:]); n != info.NumOutputs {
+		return nil, fmt.Errorf("utxo set snapshot contains %d outputs "+
+			"instead of the recorded %d", info.NumOutputs, n)
+	}
+
+	// The checksum itself is not part of the checksum.
+	var checksum [sha256.Size]byte
+	copy(checksum[:], sr.checksum.Sum(nil))
+	if _, err := io.ReadFull(sr.r, info.Checksum[:]); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if info.Checksum != checksum {
+		return nil, fmt.Errorf("utxo set snapshot checksum %x does not "+
+			"match its contents", info.Checksum)
+	}
+	info.UtxoSetHash = setHash.commitment()
+	return &info, nil
+}
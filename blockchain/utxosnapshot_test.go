@@ -0,0 +1,75 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestUtxoSnapshotRoundTrip ensures utxo set snapshots are read back with the
+// same description they were written with and corrupted snapshots are
+// rejected.
+func TestUtxoSnapshotRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	sw := newUtxoSnapshotWriter(&buf)
+	blockHash := chainhash.Hash{0x0b}
+	sw.writeHeader(wire.SimNet, &blockHash, 1234)
+	entry := newUtxoEntry(1, 1000, 2, false, false, stake.TxTypeRegular)
+	for i, txHash := range []chainhash.Hash{{0x01}, {0x02}} {
+		out := &utxoOutput{
+			amount:   int64(i+1) * 1e8,
+			pkScript: []byte{0x76, 0xa9, 0x14, byte(i)},
+		}
+		sw.writeOutput(&txHash, uint32(i), entry, out)
+	}
+	sw.writeTickets([]chainhash.Hash{{0x03}})
+	checksum, err := sw.finish()
+	if err != nil {
+		t.Fatalf("finish: unexpected error: %v", err)
+	}
+	serialized := buf.Bytes()
+
+	info, err := ReadUtxoSnapshot(bytes.NewReader(serialized))
+	if err != nil {
+		t.Fatalf("ReadUtxoSnapshot: unexpected error: %v", err)
+	}
+	want := UtxoSnapshotInfo{
+		Network:     wire.SimNet,
+		Hash:        blockHash,
+		Height:      1234,
+		NumOutputs:  2,
+		NumTickets:  1,
+		UtxoSetHash: sw.setHash.commitment(),
+		Checksum:    checksum,
+	}
+	if *info != want {
+		t.Fatalf("ReadUtxoSnapshot: unexpected info - got %+v, want %+v",
+			info, want)
+	}
+
+	// Ensure corrupting any part of the snapshot is detected.
+	for _, offset := range []int{0, 20, len(serialized) / 2,
+		len(serialized) - 1} {
+
+		corrupted := append([]byte(nil), serialized...)
+		corrupted[offset] ^= 0x01
+		_, err := ReadUtxoSnapshot(bytes.NewReader(corrupted))
+		if err == nil {
+			t.Errorf("ReadUtxoSnapshot: accepted snapshot corrupted "+
+				"at offset %d", offset)
+		}
+	}
+
+	// Ensure truncated snapshots are rejected.
+	_, err = ReadUtxoSnapshot(bytes.NewReader(serialized[:len(serialized)-1]))
+	if err == nil {
+		t.Error("ReadUtxoSnapshot: accepted truncated snapshot")
+	}
+}
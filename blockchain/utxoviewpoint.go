@@ -68,6 +68,7 @@ type utxoOutput struct {
 	scriptVersion uint16 // The script version
 	compressed    bool   // The public key script is compressed.
 	spent         bool   // Output is spent.
+	modified      bool   // Output changed since load.
 }
 
 // maybeDecompress decompresses the amount and public key script fields of the
@@ -168,6 +169,7 @@ func (entry *UtxoEntry) SpendOutput(outputIndex uint32) {
 
 	entry.modified = true
 	output.spent = true
+	output.modified = true
 	return
 }
 
@@ -337,6 +339,7 @@ func (view *UtxoViewpoint) AddTxOuts(tx *dcrutil.Tx, blockHeight int64,
 			output.scriptVersion = txOut.Version
 			output.pkScript = txOut.PkScript
 			output.compressed = false
+			output.modified = true
 			continue
 		}
 
@@ -347,6 +350,7 @@ func (view *UtxoViewpoint) AddTxOuts(tx *dcrutil.Tx, blockHeight int64,
 			scriptVersion: txOut.Version,
 			pkScript:      txOut.PkScript,
 			compressed:    false,
+			modified:      true,
 		}
 	}
 	return
@@ -442,7 +446,7 @@ func (b *BlockChain) connectTransactions(view *UtxoViewpoint, block *dcrutil.Blo
 
 	if parent != nil && block.Height() != 0 {
 		view.SetStakeViewpoint(ViewpointPrevValidInitial)
-		err := view.fetchInputUtxos(b.db, block, parent)
+		err := view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return err
 		}
@@ -462,7 +466,7 @@ func (b *BlockChain) connectTransactions(view *UtxoViewpoint, block *dcrutil.Blo
 
 	for i, stx := range block.STransactions() {
 		view.SetStakeViewpoint(thisNodeStakeViewpoint)
-		err := view.fetchInputUtxos(b.db, block, parent)
+		err := view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return err
 		}
@@ -505,7 +509,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 		thisNodeStakeViewpoint = ViewpointPrevValidStake
 	}
 	view.SetStakeViewpoint(thisNodeStakeViewpoint)
-	err := view.fetchInputUtxos(b.db, block, parent)
+	err := view.fetchInputUtxos(b.utxoCache, block, parent)
 	if err != nil {
 		return err
 	}
@@ -587,6 +591,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 					amount:        txIn.ValueIn,
 					scriptVersion: stxo.scriptVersion,
 					pkScript:      stxo.pkScript,
+					modified:      true,
 				}
 				continue
 			}
@@ -594,6 +599,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 			// Mark the existing referenced transaction output as
 			// unspent.
 			output.spent = false
+			output.modified = true
 		}
 	}
 
@@ -605,7 +611,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 		// history in the first place.
 		if regularTxTreeValid {
 			view.SetStakeViewpoint(ViewpointPrevValidInitial)
-			err = view.fetchInputUtxos(b.db, block, parent)
+			err = view.fetchInputUtxos(b.utxoCache, block, parent)
 			if err != nil {
 				return err
 			}
@@ -680,6 +686,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 							amount:        txIn.ValueIn,
 							scriptVersion: stxo.scriptVersion,
 							pkScript:      stxo.pkScript,
+							modified:      true,
 						}
 						continue
 					}
@@ -687,6 +694,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 					// Mark the existing referenced transaction output as
 					// unspent.
 					output.spent = false
+					output.modified = true
 				}
 			}
 		}
@@ -789,6 +797,7 @@ func (view *UtxoViewpoint) disconnectTransactionSlice(transactions []*dcrutil.Tx
 					amount:        txIn.ValueIn,
 					scriptVersion: stxo.scriptVersion,
 					pkScript:      stxo.pkScript,
+					modified:      true,
 				}
 				continue
 			}
@@ -796,6 +805,7 @@ func (view *UtxoViewpoint) disconnectTransactionSlice(transactions []*dcrutil.Tx
 			// Mark the existing referenced transaction output as
 			// unspent.
 			output.spent = false
+			output.modified = true
 		}
 	}
 
@@ -817,43 +827,62 @@ func (view *UtxoViewpoint) commit() {
 		}
 
 		entry.modified = false
+		for _, output := range entry.sparseOutputs {
+			output.modified = false
+		}
 	}
 }
 
 // fetchUtxosMain fetches unspent transaction output data about the provided
 // set of transactions from the point of view of the end of the main chain at
-// the time of the call.
+// the time of the call.  The data is loaded from the utxo cache, and only the
+// entries which are not cached are loaded from the database.
 //
 // Upon completion of this function, the view will contain an entry for each
 // requested transaction.  Fully spent transactions, or those which otherwise
 // don't exist, will result in a nil entry in the view.
-func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, txSet map[chainhash.Hash]struct{}) error {
+func (view *UtxoViewpoint) fetchUtxosMain(cache *utxoCache, txSet map[chainhash.Hash]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(txSet) == 0 {
 		return nil
 	}
 
-	// Load the unspent transaction output information for the requested set
-	// of transactions from the point of view of the end of the main chain.
+	// Load the entries which are already cached without accessing the
+	// database.
+	var missing []chainhash.Hash
+	for hash := range txSet {
+		// If the UTX already exists in the view, skip adding it.
+		if _, ok := view.entries[hash]; ok {
+			continue
+		}
+		hashCopy := hash
+		if entry, ok := cache.lookupEntry(&hashCopy); ok {
+			view.entries[hash] = entry
+			continue
+		}
+		missing = append(missing, hash)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// Load the unspent transaction output information for the remaining
+	// transactions from the point of view of the end of the main chain.
 	//
 	// NOTE: Missing entries are not considered an error here and instead
 	// will result in nil entries in the view.  This is intentionally done
 	// since other code uses the presence of an entry in the store as a way
 	// to optimize spend and unspend updates to apply only to the specific
 	// utxos that the caller needs access to.
-	return db.View(func(dbTx database.Tx) error {
-		for hash := range txSet {
-			hashCopy := hash
-			// If the UTX already exists in the view, skip adding it.
-			if _, ok := view.entries[hashCopy]; ok {
-				continue
-			}
-			entry, err := dbFetchUtxoEntry(dbTx, &hashCopy)
+	return cache.db.View(func(dbTx database.Tx) error {
+		for i := range missing {
+			hash := &missing[i]
+			entry, err := cache.fetchEntry(dbTx, hash)
 			if err != nil {
 				return err
 			}
 
-			view.entries[hash] = entry
+			view.entries[*hash] = entry
 		}
 
 		return nil
@@ -863,7 +892,7 @@ func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, txSet map[chainhash.Ha
 // fetchUtxos loads utxo details about provided set of transaction hashes into
 // the view from the database as needed unless they already exist in the view in
 // which case they are ignored.
-func (view *UtxoViewpoint) fetchUtxos(db database.DB, txSet map[chainhash.Hash]struct{}) error {
+func (view *UtxoViewpoint) fetchUtxos(cache *utxoCache, txSet map[chainhash.Hash]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(txSet) == 0 {
 		return nil
@@ -881,14 +910,14 @@ func (view *UtxoViewpoint) fetchUtxos(db database.DB, txSet map[chainhash.Hash]s
 	}
 
 	// Request the input utxos from the database.
-	return view.fetchUtxosMain(db, txNeededSet)
+	return view.fetchUtxosMain(cache, txNeededSet)
 }
 
 // fetchInputUtxos loads utxo details about the input transactions referenced
 // by the transactions in the given block into the view from the database as
 // needed.  In particular, referenced entries that are earlier in the block are
 // added to the view and entries that are already in the view are not modified.
-func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
+func (view *UtxoViewpoint) fetchInputUtxos(cache *utxoCache,
 	block, parent *dcrutil.Block) error {
 	viewpoint := view.StakeViewpoint()
 
@@ -943,7 +972,7 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
 		}
 
 		// Request the input utxos from the database.
-		return view.fetchUtxosMain(db, txNeededSet)
+		return view.fetchUtxosMain(cache, txNeededSet)
 	}
 
 	// Case 2+3: ViewpointPrevValidStake and ViewpointPrevValidStake.
@@ -983,7 +1012,7 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
 		}
 
 		// Request the input utxos from the database.
-		return view.fetchUtxosMain(db, txNeededSet)
+		return view.fetchUtxosMain(cache, txNeededSet)
 	}
 
 	// Case 4+5: ViewpointPrevValidRegular and
@@ -1040,7 +1069,7 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
 		}
 
 		// Request the input utxos from the database.
-		return view.fetchUtxosMain(db, txNeededSet)
+		return view.fetchUtxosMain(cache, txNeededSet)
 	}
 
 	// TODO actual blockchain error
@@ -1062,8 +1091,8 @@ func NewUtxoViewpoint() *UtxoViewpoint {
 // This function is safe for concurrent access however the returned view is NOT.
 func (b *BlockChain) FetchUtxoView(tx *dcrutil.Tx, treeValid bool) (*UtxoViewpoint,
 	error) {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
+	b.utxoLock.RLock()
+	defer b.utxoLock.RUnlock()
 
 	// Request the utxos from the point of view of the end of the main
 	// chain.
@@ -1078,7 +1107,7 @@ func (b *BlockChain) FetchUtxoView(tx *dcrutil.Tx, treeValid bool) (*UtxoViewpoi
 		if err != nil {
 			return nil, err
 		}
-		err = view.fetchInputUtxos(b.db, block, parent)
+		err = view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return nil, err
 		}
@@ -1109,7 +1138,7 @@ func (b *BlockChain) FetchUtxoView(tx *dcrutil.Tx, treeValid bool) (*UtxoViewpoi
 		}
 	}
 
-	err := view.fetchUtxosMain(b.db, txNeededSet)
+	err := view.fetchUtxosMain(b.utxoCache, txNeededSet)
 
 	return view, err
 }
@@ -1125,13 +1154,13 @@ func (b *BlockChain) FetchUtxoView(tx *dcrutil.Tx, treeValid bool) (*UtxoViewpoi
 // This function is safe for concurrent access however the returned entry (if
 // any) is NOT.
 func (b *BlockChain) FetchUtxoEntry(txHash *chainhash.Hash) (*UtxoEntry, error) {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
+	b.utxoLock.RLock()
+	defer b.utxoLock.RUnlock()
 
 	var entry *UtxoEntry
 	err := b.db.View(func(dbTx database.Tx) error {
 		var err error
-		entry, err = dbFetchUtxoEntry(dbTx, txHash)
+		entry, err = b.utxoCache.fetchEntry(dbTx, txHash)
 		return err
 	})
 	if err != nil {
@@ -350,7 +350,7 @@ func CheckProofOfWork(block *dcrutil.Block, powLimit *big.Int) error {
 // The flags do not modify the behavior of this function directly, however they
 // are needed to pass along to checkProofOfWork.
 func checkBlockHeaderSanity(block *dcrutil.Block, timeSource MedianTimeSource,
-	flags BehaviorFlags, chainParams *chaincfg.Params) error {
+	clock Clock, flags BehaviorFlags, chainParams *chaincfg.Params) error {
 	powLimit := chainParams.PowLimit
 	posLimit := chainParams.MinimumStakeDiff
 	header := &block.MsgBlock().Header
@@ -382,7 +382,7 @@ func checkBlockHeaderSanity(block *dcrutil.Block, timeSource MedianTimeSource,
 	}
 
 	// Ensure the block time is not too far in the future.
-	maxTimestamp := time.Now().Add(time.Second * MaxTimeOffsetSeconds)
+	maxTimestamp := clock.Now().Add(time.Second * MaxTimeOffsetSeconds)
 	if header.Timestamp.After(maxTimestamp) {
 		str := fmt.Sprintf("block timestamp of %v is too far in the "+
 			"future", header.Timestamp)
@@ -398,11 +398,12 @@ func checkBlockHeaderSanity(block *dcrutil.Block, timeSource MedianTimeSource,
 // The flags do not modify the behavior of this function directly, however they
 // are needed to pass along to checkBlockHeaderSanity.
 func checkBlockSanity(block *dcrutil.Block, timeSource MedianTimeSource,
-	flags BehaviorFlags, chainParams *chaincfg.Params) error {
+	clock Clock, flags BehaviorFlags, chainParams *chaincfg.Params) error {
 
 	msgBlock := block.MsgBlock()
 	header := &msgBlock.Header
-	err := checkBlockHeaderSanity(block, timeSource, flags, chainParams)
+	err := checkBlockHeaderSanity(block, timeSource, clock, flags,
+		chainParams)
 	if err != nil {
 		return err
 	}
@@ -455,32 +456,37 @@ func checkBlockSanity(block *dcrutil.Block, timeSource MedianTimeSource,
 
 	// Do some preliminary checks on each transaction to ensure they are
 	// sane before continuing.
-	for _, tx := range transactions {
+	height := int64(header.Height)
+	for i, tx := range transactions {
 		msgTx := tx.MsgTx()
 		txType := stake.DetermineTxType(msgTx)
 		if txType != stake.TxTypeRegular {
 			errStr := fmt.Sprintf("found stake tx in regular tx tree")
-			return ruleError(ErrStakeTxInRegularTree, errStr)
+			return addRuleErrorContext(ruleError(ErrStakeTxInRegularTree,
+				errStr), height, wire.TxTreeRegular, i)
 		}
 		err := CheckTransactionSanity(msgTx, chainParams)
 		if err != nil {
-			return err
+			return addRuleErrorContext(err, height,
+				wire.TxTreeRegular, i)
 		}
 	}
 
 	totalTickets := 0
 	totalVotes := 0
 	totalRevocations := 0
-	for _, stx := range block.MsgBlock().STransactions {
+	for i, stx := range block.MsgBlock().STransactions {
 		err := CheckTransactionSanity(stx, chainParams)
 		if err != nil {
-			return err
+			return addRuleErrorContext(err, height, wire.TxTreeStake,
+				i)
 		}
 
 		txType := stake.DetermineTxType(stx)
 		if txType == stake.TxTypeRegular {
 			errStr := fmt.Sprintf("found regular tx in stake tx tree")
-			return ruleError(ErrRegTxInStakeTree, errStr)
+			return addRuleErrorContext(ruleError(ErrRegTxInStakeTree,
+				errStr), height, wire.TxTreeStake, i)
 		}
 
 		switch txType {
@@ -612,7 +618,8 @@ func checkBlockSanity(block *dcrutil.Block, timeSource MedianTimeSource,
 // sane before continuing with block processing.  These checks are context free.
 func CheckBlockSanity(block *dcrutil.Block, timeSource MedianTimeSource,
 	chainParams *chaincfg.Params) error {
-	return checkBlockSanity(block, timeSource, BFNone, chainParams)
+	return checkBlockSanity(block, timeSource, localClock(timeSource),
+		BFNone, chainParams)
 }
 
 // CheckWorklessBlockSanity performs some preliminary checks on a block to
@@ -620,7 +627,8 @@ func CheckBlockSanity(block *dcrutil.Block, timeSource MedianTimeSource,
 // context free.
 func CheckWorklessBlockSanity(block *dcrutil.Block, timeSource MedianTimeSource,
 	chainParams *chaincfg.Params) error {
-	return checkBlockSanity(block, timeSource, BFNoPoWCheck, chainParams)
+	return checkBlockSanity(block, timeSource, localClock(timeSource),
+		BFNoPoWCheck, chainParams)
 }
 
 // checkBlockHeaderContext peforms several validation checks on the block header
@@ -680,21 +688,37 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 		return ruleError(ErrBadCheckpoint, str)
 	}
 
+	// Ensure chain matches the assumed valid block since the scripts of its
+	// ancestors are not validated.
+	if !b.verifyAssumeValid(blockHeight, &blockHash) {
+		str := fmt.Sprintf("block at height %d does not match the "+
+			"assumed valid block %v", blockHeight, b.assumeValid.Hash)
+		return ruleError(ErrBadAssumeValid, str)
+	}
+
 	// Find the previous checkpoint and prevent blocks which fork the main
 	// chain before it.  This prevents storage of new, otherwise valid,
 	// blocks which build off of old blocks that are likely at a much easier
 	// difficulty and therefore could be used to waste cache and disk space.
-	checkpointBlock, err := b.findPreviousCheckpoint()
+	checkpointHeader, err := b.findPreviousCheckpoint()
 	if err != nil {
 		return err
 	}
-	if checkpointBlock != nil && blockHeight < checkpointBlock.Height() {
+	if checkpointHeader != nil &&
+		blockHeight < int64(checkpointHeader.Height) {
+
 		str := fmt.Sprintf("block at height %d forks the main chain "+
 			"before the previous checkpoint at height %d",
-			blockHeight, checkpointBlock.Height())
+			blockHeight, checkpointHeader.Height)
 		return ruleError(ErrForkTooOld, str)
 	}
 
+	// Prevent blocks which fork the main chain at or before the most recent
+	// final block since they may never become part of the main chain.
+	if err := b.checkForkFinality(blockHeight); err != nil {
+		return err
+	}
+
 	if !fastAdd {
 		// Reject version 3 blocks for networks other than the main
 		// network once a majority of the network has upgraded.
@@ -768,7 +792,7 @@ func (b *BlockChain) checkDupTxs(txSet []*dcrutil.Tx,
 	for _, tx := range txSet {
 		fetchSet[*tx.Hash()] = struct{}{}
 	}
-	err := view.fetchUtxos(b.db, fetchSet)
+	err := view.fetchUtxos(b.utxoCache, fetchSet)
 	if err != nil {
 		return err
 	}
@@ -1215,7 +1239,7 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 			if !exists || utxoEntry == nil {
 				str := fmt.Sprintf("unable to find input transaction "+
 					"%v for transaction %v", txInHash, txHash)
-				return 0, ruleError(ErrMissingTx, str)
+				return 0, inputRuleError(ErrMissingTx, str, idx)
 			}
 
 			// Ensure the transaction is not double spending coins.
@@ -1224,7 +1248,8 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 				str := fmt.Sprintf("transaction %s:%d tried to double "+
 					"spend output %v", txHash, idx,
 					txIn.PreviousOutPoint)
-				return 0, ruleError(ErrDoubleSpend, str)
+				return 0, inputRuleError(ErrDoubleSpend, str,
+					idx)
 			}
 
 			// Check and make sure that the input is P2PKH or P2SH.
@@ -1241,7 +1266,8 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 					"referenced a txout that was not a PubKeyHashTy or "+
 					"ScriptHashTy pkScript (class: %v, version %v, script %x)",
 					txInHash, originTxIndex, class, thisPkVersion, thisPkScript)
-				return 0, ruleError(ErrSStxInScrType, errStr)
+				return 0, inputRuleError(ErrSStxInScrType, errStr,
+					idx)
 			}
 
 			// Get the value of the input.
@@ -1558,7 +1584,7 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 		if !exists || utxoEntry == nil {
 			str := fmt.Sprintf("unable to find input transaction "+
 				"%v for transaction %v", txInHash, txHash)
-			return 0, ruleError(ErrMissingTx, str)
+			return 0, inputRuleError(ErrMissingTx, str, idx)
 		}
 
 		// Check fraud proof witness data.
@@ -1568,7 +1594,8 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 		if utxoEntry.AmountByIndex(originTxIndex) == 0 {
 			str := fmt.Sprintf("tried to spend zero value output from input %v,"+
 				" idx %v", txInHash, originTxIndex)
-			return 0, ruleError(ErrZeroValueOutputSpend, str)
+			return 0, inputRuleError(ErrZeroValueOutputSpend, str,
+				idx)
 		}
 
 		if checkFraudProof {
@@ -1576,21 +1603,24 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 				str := fmt.Sprintf("bad fraud check value in (expected %v, "+
 					"given %v) for txIn %v",
 					utxoEntry.AmountByIndex(originTxIndex), txIn.ValueIn, idx)
-				return 0, ruleError(ErrFraudAmountIn, str)
+				return 0, inputRuleError(ErrFraudAmountIn, str,
+					idx)
 			}
 
 			if int64(txIn.BlockHeight) != utxoEntry.BlockHeight() {
 				str := fmt.Sprintf("bad fraud check block height (expected %v, "+
 					"given %v) for txIn %v %v", utxoEntry.BlockHeight(),
 					txIn.BlockHeight, idx, DebugMsgTxString(tx.MsgTx()))
-				return 0, ruleError(ErrFraudBlockHeight, str)
+				return 0, inputRuleError(ErrFraudBlockHeight, str,
+					idx)
 			}
 
 			if txIn.BlockIndex != utxoEntry.BlockIndex() {
 				str := fmt.Sprintf("bad fraud check block index (expected %v, "+
 					"given %v) for txIn %v", utxoEntry.BlockIndex(),
 					txIn.BlockIndex, idx)
-				return 0, ruleError(ErrFraudBlockIndex, str)
+				return 0, inputRuleError(ErrFraudBlockIndex, str,
+					idx)
 			}
 		}
 
@@ -1606,7 +1636,8 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 					"height %v before required maturity "+
 					"of %v blocks", txHash, txInHash, originHeight,
 					txHeight, coinbaseMaturity)
-				return 0, ruleError(ErrImmatureSpend, str)
+				return 0, inputRuleError(ErrImmatureSpend, str,
+					idx)
 			}
 		}
 
@@ -1623,7 +1654,8 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 					"required maturity of %v blocks",
 					txHash, txInHash, originHeight,
 					txHeight, coinbaseMaturity)
-				return 0, ruleError(ErrExpiryTxSpentEarly, str)
+				return 0, inputRuleError(ErrExpiryTxSpentEarly, str,
+					idx)
 			}
 		}
 
@@ -1632,7 +1664,7 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 			str := fmt.Sprintf("transaction %s:%d tried to double "+
 				"spend output %v", txHash, originTxIndex,
 				txIn.PreviousOutPoint)
-			return 0, ruleError(ErrDoubleSpend, str)
+			return 0, inputRuleError(ErrDoubleSpend, str, idx)
 		}
 
 		// Ensure that the outpoint's tx tree makes sense.
@@ -1650,7 +1682,8 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 				indicatedTree,
 				txIn.PreviousOutPoint.Hash,
 				originTxOPTree)
-			return 0, ruleError(ErrDiscordantTxTree, errStr)
+			return 0, inputRuleError(ErrDiscordantTxTree, errStr,
+				idx)
 		}
 
 		// The only transaction types that are allowed to spend from OP_SSTX
@@ -1670,7 +1703,8 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 					txHash,
 					errIsSSGen.Error(),
 					errIsSSRtx.Error())
-				return 0, ruleError(ErrTxSStxOutSpend, errStr)
+				return 0, inputRuleError(ErrTxSStxOutSpend, errStr,
+					idx)
 			}
 		}
 
@@ -1689,7 +1723,8 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 					"height %v before required maturity "+
 					"of %v blocks", txInHash, originHeight,
 					txHeight, coinbaseMaturity)
-				return 0, ruleError(ErrImmatureSpend, str)
+				return 0, inputRuleError(ErrImmatureSpend, str,
+					idx)
 			}
 		}
 
@@ -1704,7 +1739,8 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 					"height %v before required maturity "+
 					"of %v blocks", txInHash, originHeight,
 					txHeight, chainParams.SStxChangeMaturity)
-				return 0, ruleError(ErrImmatureSpend, str)
+				return 0, inputRuleError(ErrImmatureSpend, str,
+					idx)
 			}
 		}
 
@@ -1718,13 +1754,13 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 		if originTxAtom < 0 {
 			str := fmt.Sprintf("transaction output has negative "+
 				"value of %v", originTxAtom)
-			return 0, ruleError(ErrBadTxOutValue, str)
+			return 0, inputRuleError(ErrBadTxOutValue, str, idx)
 		}
 		if originTxAtom > dcrutil.MaxAmount {
 			str := fmt.Sprintf("transaction output value of %v is "+
 				"higher than max allowed value of %v",
 				originTxAtom, dcrutil.MaxAmount)
-			return 0, ruleError(ErrBadTxOutValue, str)
+			return 0, inputRuleError(ErrBadTxOutValue, str, idx)
 		}
 
 		// The total of all outputs must not be more than the max
@@ -1738,7 +1774,7 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 				"inputs is %v which is higher than max "+
 				"allowed value of %v", totalAtomIn,
 				dcrutil.MaxAmount)
-			return 0, ruleError(ErrBadTxOutValue, str)
+			return 0, inputRuleError(ErrBadTxOutValue, str, idx)
 		}
 	}
 
@@ -1799,33 +1835,7 @@ func CheckTransactionInputs(subsidyCache *SubsidyCache, tx *dcrutil.Tx,
 // quicker, but imprecise, signature operation counting mechanism from
 // txscript.
 func CountSigOps(tx *dcrutil.Tx, isCoinBaseTx bool, isSSGen bool) int {
-	msgTx := tx.MsgTx()
-
-	// Accumulate the number of signature operations in all transaction
-	// inputs.
-	totalSigOps := 0
-	for i, txIn := range msgTx.TxIn {
-		// Skip coinbase inputs.
-		if isCoinBaseTx {
-			continue
-		}
-		// Skip stakebase inputs.
-		if isSSGen && i == 0 {
-			continue
-		}
-
-		numSigOps := txscript.GetSigOpCount(txIn.SignatureScript)
-		totalSigOps += numSigOps
-	}
-
-	// Accumulate the number of signature operations in all transaction
-	// outputs.
-	for _, txOut := range msgTx.TxOut {
-		numSigOps := txscript.GetSigOpCount(txOut.PkScript)
-		totalSigOps += numSigOps
-	}
-
-	return totalSigOps
+	return countSigOpCost(tx, isCoinBaseTx, isSSGen).SigOps
 }
 
 // CountP2SHSigOps returns the number of signature operations for all input
@@ -1834,68 +1844,9 @@ func CountSigOps(tx *dcrutil.Tx, isCoinBaseTx bool, isSSGen bool) int {
 // requires access to the input transaction scripts.
 func CountP2SHSigOps(tx *dcrutil.Tx, isCoinBaseTx bool, isStakeBaseTx bool,
 	utxoView *UtxoViewpoint) (int, error) {
-	// Coinbase transactions have no interesting inputs.
-	if isCoinBaseTx {
-		return 0, nil
-	}
-
-	// Stakebase (SSGen) transactions have no P2SH inputs.  Same with SSRtx,
-	// but they will still pass the checks below.
-	if isStakeBaseTx {
-		return 0, nil
-	}
-
-	// Accumulate the number of signature operations in all transaction
-	// inputs.
-	msgTx := tx.MsgTx()
-	totalSigOps := 0
-	for txInIndex, txIn := range msgTx.TxIn {
-		// Ensure the referenced input transaction is available.
-		originTxHash := &txIn.PreviousOutPoint.Hash
-		originTxIndex := txIn.PreviousOutPoint.Index
-		utxoEntry, ok := utxoView.entries[*originTxHash]
-		if !ok || utxoEntry == nil {
-			str := fmt.Sprintf("unable to find unspent transaction "+
-				"%v referenced from transaction %s:%d during "+
-				"CountP2SHSigOps: output missing",
-				txIn.PreviousOutPoint.Hash, tx.Hash(), txInIndex)
-			return 0, ruleError(ErrMissingTx, str)
-		}
-
-		if utxoEntry.IsOutputSpent(originTxIndex) {
-			str := fmt.Sprintf("unable to find unspent output "+
-				"%v referenced from transaction %s:%d during "+
-				"CountP2SHSigOps: output spent",
-				txIn.PreviousOutPoint, tx.Hash(), txInIndex)
-			return 0, ruleError(ErrMissingTx, str)
-		}
-
-		// We're only interested in pay-to-script-hash types, so skip
-		// this input if it's not one.
-		pkScript := utxoEntry.PkScriptByIndex(originTxIndex)
-		if !txscript.IsPayToScriptHash(pkScript) {
-			continue
-		}
-
-		// Count the precise number of signature operations in the
-		// referenced public key script.
-		sigScript := txIn.SignatureScript
-		numSigOps := txscript.GetPreciseSigOpCount(sigScript, pkScript,
-			true)
-
-		// We could potentially overflow the accumulator so check for
-		// overflow.
-		lastSigOps := totalSigOps
-		totalSigOps += numSigOps
-		if totalSigOps < lastSigOps {
-			str := fmt.Sprintf("the public key script from output "+
-				"%v contains too many signature operations - "+
-				"overflow", txIn.PreviousOutPoint)
-			return 0, ruleError(ErrTooManySigOps, str)
-		}
-	}
-
-	return totalSigOps, nil
+	cost, err := countP2SHSigOpCost(tx, isCoinBaseTx, isStakeBaseTx,
+		utxoView)
+	return cost.SigOps, err
 }
 
 // checkNumSigOps Checks the number of P2SH signature operations to make
@@ -1904,27 +1855,21 @@ func CountP2SHSigOps(tx *dcrutil.Tx, isCoinBaseTx bool, isStakeBaseTx bool,
 // TxTree true == Regular, false == Stake
 func checkNumSigOps(tx *dcrutil.Tx, utxoView *UtxoViewpoint, index int,
 	txTree bool, cumulativeSigOps int) (int, error) {
-	msgTx := tx.MsgTx()
-	isSSGen, _ := stake.IsSSGen(msgTx)
-	numsigOps := CountSigOps(tx, (index == 0) && txTree, isSSGen)
-
 	// Since the first (and only the first) transaction has
 	// already been verified to be a coinbase transaction,
 	// use (i == 0) && TxTree as an optimization for the
-	// flag to countP2SHSigOps for whether or not the
+	// flag to CalcTxCost for whether or not the
 	// transaction is a coinbase transaction rather than
 	// having to do a full coinbase check again.
-	numP2SHSigOps, err := CountP2SHSigOps(tx, (index == 0) && txTree, isSSGen,
-		utxoView)
+	cost, err := CalcTxCost(tx, (index == 0) && txTree, utxoView)
 	if err != nil {
-		log.Tracef("CountP2SHSigOps failed; error "+
+		log.Tracef("CalcTxCost failed; error "+
 			"returned %v", err.Error())
 		return 0, err
 	}
 
 	startCumSigOps := cumulativeSigOps
-	cumulativeSigOps += numsigOps
-	cumulativeSigOps += numP2SHSigOps
+	cumulativeSigOps += cost.TotalSigOps()
 
 	// Check for overflow or going over the limits.  We have to do
 	// this on every loop iteration to avoid overflow.
@@ -2084,6 +2029,14 @@ func (b *BlockChain) checkTransactionsAndConnect(subsidyCache *SubsidyCache,
 	// bounds.
 	totalFees := int64(inputFees) // Stake tx tree carry forward
 	var cumulativeSigOps int
+	tree := wire.TxTreeRegular
+	if !txTree {
+		tree = wire.TxTreeStake
+	}
+	treasuryActive, err := b.isTreasuryAgendaActive(node.parent)
+	if err != nil {
+		return err
+	}
 	for idx, tx := range txs {
 		// Ensure that the number of signature operations is not
 		// beyond the consensus limit.
@@ -2091,7 +2044,15 @@ func (b *BlockChain) checkTransactionsAndConnect(subsidyCache *SubsidyCache,
 		cumulativeSigOps, err = checkNumSigOps(tx, utxoView, idx, txTree,
 			cumulativeSigOps)
 		if err != nil {
-			return err
+			return addRuleErrorContext(err, node.height, tree, idx)
+		}
+
+		// Ensure any spends from the treasury are authorized.
+		if treasuryActive {
+			err = CheckTreasurySpends(tx, utxoView, b.chainParams)
+			if err != nil {
+				return addRuleErrorContext(err, node.height, tree, idx)
+			}
 		}
 
 		// This step modifies the txStore and marks the tx outs used
@@ -2105,7 +2066,7 @@ func (b *BlockChain) checkTransactionsAndConnect(subsidyCache *SubsidyCache,
 		if err != nil {
 			log.Tracef("CheckTransactionInputs failed; error "+
 				"returned: %v", err)
-			return err
+			return addRuleErrorContext(err, node.height, tree, idx)
 		}
 
 		// Sum the total fees and ensure we don't overflow the
@@ -2121,7 +2082,7 @@ func (b *BlockChain) checkTransactionsAndConnect(subsidyCache *SubsidyCache,
 		// in flight transactions may correctly validate.
 		err = utxoView.connectTransaction(tx, node.height, uint32(idx), stxos)
 		if err != nil {
-			return err
+			return addRuleErrorContext(err, node.height, tree, idx)
 		}
 	}
 
@@ -2254,9 +2215,21 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 			"of expected %v", utxoView.BestHash(), node.header.PrevBlock))
 	}
 
-	// Check that the coinbase pays the tax, if applicable.
-	err = CoinbasePaysTax(b.subsidyCache, block.Transactions()[0],
-		node.header.Height, node.header.Voters, b.chainParams)
+	// Ensure the block commits to the utxo set which results from its
+	// parent when the experimental utxo commitments are enabled.
+	if err := b.checkUtxoCommitment(&node.header); err != nil {
+		return err
+	}
+
+	// Check that the coinbase pays the tax, if applicable.  The tax is paid
+	// to the treasury once the treasury agenda is active.
+	taxScriptVersion, taxScript, err := b.coinbaseTaxScript(node.parent)
+	if err != nil {
+		return err
+	}
+	err = coinbasePaysTaxTo(b.subsidyCache, block.Transactions()[0],
+		node.header.Height, node.header.Voters, b.chainParams,
+		taxScriptVersion, taxScript)
 	if err != nil {
 		return err
 	}
@@ -2274,22 +2247,34 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 	// transactions are included in the merkle root hash and any changes
 	// will therefore be detected by the next checkpoint).  This is a huge
 	// optimization because running the scripts is the most time consuming
-	// portion of block handling.
+	// portion of block handling.  Advisory checkpoints do not guarantee
+	// anything, so the scripts are always run in that case.
 	checkpoint := b.latestCheckpoint()
 	runScripts := !b.noVerify
-	if checkpoint != nil && node.height <= checkpoint.Height {
+	if checkpoint != nil && b.checkpointMode == CheckpointModeEnforce &&
+		node.height <= checkpoint.Height {
+		runScripts = false
+	}
+
+	// Likewise, don't run scripts for the assumed valid block and its
+	// ancestors.  The stake rules are still enforced and the utxo set is
+	// still updated for them.
+	if b.isAssumedValid(node) {
 		runScripts = false
 	}
 	var scriptFlags txscript.ScriptFlags
 	if runScripts {
-		scriptFlags |= txscript.ScriptBip16
-		scriptFlags |= txscript.ScriptVerifyDERSignatures
-		scriptFlags |= txscript.ScriptVerifyStrictEncoding
-		scriptFlags |= txscript.ScriptVerifyMinimalData
-		scriptFlags |= txscript.ScriptVerifyCleanStack
-		scriptFlags |= txscript.ScriptVerifyCheckLockTimeVerify
+		scriptFlags, err = b.consensusScriptFlags(node.parent)
+		if err != nil {
+			return err
+		}
 	}
 
+	// Keep track of the time spent fetching the referenced inputs and
+	// validating the scripts of both transaction trees so it can be
+	// recorded once the block passed all of the checks.
+	var fetchElapsed, scriptsElapsed time.Duration
+
 	// The number of signature operations must be less than the maximum
 	// allowed per block.  Note that the preliminary sanity checks on a
 	// block also include a check similar to this one, but this check
@@ -2306,10 +2291,12 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 		thisNodeRegularViewpoint = ViewpointPrevValidRegular
 
 		utxoView.SetStakeViewpoint(ViewpointPrevValidInitial)
-		err = utxoView.fetchInputUtxos(b.db, block, parentBlock)
+		fetchStart := time.Now()
+		err = utxoView.fetchInputUtxos(b.utxoCache, block, parentBlock)
 		if err != nil {
 			return err
 		}
+		fetchElapsed += time.Since(fetchStart)
 
 		for i, tx := range parentBlock.Transactions() {
 			err := utxoView.connectTransaction(tx, node.parent.height, uint32(i),
@@ -2328,10 +2315,12 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 		return err
 	}
 
-	err = utxoView.fetchInputUtxos(b.db, block, parentBlock)
+	fetchStart := time.Now()
+	err = utxoView.fetchInputUtxos(b.utxoCache, block, parentBlock)
 	if err != nil {
 		return err
 	}
+	fetchElapsed += time.Since(fetchStart)
 
 	err = b.checkTransactionsAndConnect(b.subsidyCache, 0, node,
 		block.STransactions(), utxoView, stxos, false)
@@ -2350,6 +2339,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 	}
 
 	if runScripts {
+		scriptsStart := time.Now()
 		err = checkBlockScripts(block, utxoView, false,
 			scriptFlags, b.sigCache)
 		if err != nil {
@@ -2357,6 +2347,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 				"returned on txtreestake of cur block: %v", err.Error())
 			return err
 		}
+		scriptsElapsed += time.Since(scriptsStart)
 	}
 
 	// TxTreeRegular of current block. At this point, the stake transactions
@@ -2369,10 +2360,12 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 		return err
 	}
 
-	err = utxoView.fetchInputUtxos(b.db, block, parentBlock)
+	fetchStart = time.Now()
+	err = utxoView.fetchInputUtxos(b.utxoCache, block, parentBlock)
 	if err != nil {
 		return err
 	}
+	fetchElapsed += time.Since(fetchStart)
 
 	err = b.checkTransactionsAndConnect(b.subsidyCache, stakeTreeFees, node,
 		block.Transactions(), utxoView, stxos, true)
@@ -2383,6 +2376,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 	}
 
 	if runScripts {
+		scriptsStart := time.Now()
 		err = checkBlockScripts(block, utxoView, true,
 			scriptFlags, b.sigCache)
 		if err != nil {
@@ -2390,6 +2384,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 				"returned on txtreeregular of cur block: %v", err.Error())
 			return err
 		}
+		scriptsElapsed += time.Since(scriptsStart)
 	}
 
 	// Rollback the final tx tree regular so that we don't write it to
@@ -2417,6 +2412,11 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *dcrutil.Block,
 	// transactions have been connected.
 	utxoView.SetBestHash(&node.hash)
 
+	b.validationStats.Record(StageFetchInputs, fetchElapsed)
+	if runScripts {
+		b.validationStats.Record(StageScripts, scriptsElapsed)
+	}
+
 	return nil
 }
 
@@ -2499,6 +2499,10 @@ func (b *BlockChain) CheckConnectBlock(block *dcrutil.Block) error {
 		if err != nil {
 			return err
 		}
+		err = b.disconnectUtxoCommitment(n, block, parent, stxos)
+		if err != nil {
+			return err
+		}
 	}
 
 	// The UTXO viewpoint is now accurate to either the node where the
@@ -2528,10 +2532,15 @@ func (b *BlockChain) CheckConnectBlock(block *dcrutil.Block) error {
 			return err
 		}
 
+		start := len(stxos)
 		err = b.connectTransactions(view, block, parent, &stxos)
 		if err != nil {
 			return err
 		}
+		err = b.connectUtxoCommitment(n, block, parent, stxos[start:])
+		if err != nil {
+			return err
+		}
 	}
 
 	view.SetBestHash(&parentHash)
@@ -0,0 +1,125 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ValidationStage identifies a stage of validating a transaction or block that
+// is timed in order to diagnose performance regressions.
+type ValidationStage int
+
+// These constants define the timed stages of validation.
+const (
+	// StageChecks is the stage which performs the checks that do not
+	// involve the referenced inputs, which are the context-free sanity
+	// checks of blocks and the sanity and policy checks of transactions.
+	StageChecks ValidationStage = iota
+
+	// StageFetchInputs is the stage which fetches the unspent transaction
+	// outputs referenced by the inputs.
+	StageFetchInputs
+
+	// StageScripts is the stage which validates the scripts of the inputs.
+	StageScripts
+
+	// StageUpdate is the stage which stores the result, which is the
+	// database update when connecting a block and the addition to the
+	// memory pool for a transaction.
+	StageUpdate
+
+	// StageTotal is the time from the start of the validation to its
+	// successful completion.  It is only recorded for transactions and
+	// blocks which are accepted.
+	StageTotal
+
+	// numValidationStages is the maximum validation stage.  It is NOT a
+	// valid stage and is used for bounds checking.
+	numValidationStages
+)
+
+// Map of ValidationStage values back to their constant names for pretty
+// printing.
+var validationStageStrings = map[ValidationStage]string{
+	StageChecks:      "checks",
+	StageFetchInputs: "fetchinputs",
+	StageScripts:     "scripts",
+	StageUpdate:      "update",
+	StageTotal:       "total",
+}
+
+// String returns the ValidationStage as a human-readable name.
+func (s ValidationStage) String() string {
+	if str := validationStageStrings[s]; str != "" {
+		return str
+	}
+	return fmt.Sprintf("Unknown ValidationStage (%d)", int(s))
+}
+
+// StageTiming houses the accumulated timing of a validation stage.
+type StageTiming struct {
+	Stage ValidationStage
+	Count uint64
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Average returns the average time the stage took.
+func (t *StageTiming) Average() time.Duration {
+	if t.Count == 0 {
+		return 0
+	}
+	return t.Total / time.Duration(t.Count)
+}
+
+// ValidationStats accumulates the time each stage of validating transactions or
+// blocks takes.  The zero value is ready to use.
+//
+// The stats are safe for concurrent access.
+type ValidationStats struct {
+	mtx    sync.Mutex
+	stages [numValidationStages]StageTiming
+}
+
+// Record adds the passed elapsed time of a single run of the passed stage to
+// the stats.
+func (s *ValidationStats) Record(stage ValidationStage, elapsed time.Duration) {
+	if stage < 0 || stage >= numValidationStages {
+		return
+	}
+
+	s.mtx.Lock()
+	timing := &s.stages[stage]
+	timing.Count++
+	timing.Total += elapsed
+	if elapsed > timing.Max {
+		timing.Max = elapsed
+	}
+	s.mtx.Unlock()
+}
+
+// Snapshot returns the accumulated timing of all stages ordered by stage.
+func (s *ValidationStats) Snapshot() []StageTiming {
+	s.mtx.Lock()
+	timings := make([]StageTiming, 0, len(s.stages))
+	for i := range s.stages {
+		timing := s.stages[i]
+		timing.Stage = ValidationStage(i)
+		timings = append(timings, timing)
+	}
+	s.mtx.Unlock()
+	return timings
+}
+
+// ValidationStats returns the accumulated timing of each stage of validating
+// blocks ordered by stage.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ValidationStats() []StageTiming {
+	return b.validationStats.Snapshot()
+}
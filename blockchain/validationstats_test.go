@@ -0,0 +1,89 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestValidationStats ensures the timing of validation stages is accumulated
+// per stage and invalid stages are ignored.
+func TestValidationStats(t *testing.T) {
+	var stats ValidationStats
+	stats.Record(StageScripts, 3*time.Millisecond)
+	stats.Record(StageScripts, time.Millisecond)
+	stats.Record(StageTotal, 5*time.Millisecond)
+	stats.Record(numValidationStages, time.Second)
+	stats.Record(-1, time.Second)
+
+	timings := stats.Snapshot()
+	if len(timings) != int(numValidationStages) {
+		t.Fatalf("Snapshot: unexpected number of stages - got %d, want %d",
+			len(timings), numValidationStages)
+	}
+	want := map[ValidationStage]StageTiming{
+		StageScripts: {
+			Stage: StageScripts,
+			Count: 2,
+			Total: 4 * time.Millisecond,
+			Max:   3 * time.Millisecond,
+		},
+		StageTotal: {
+			Stage: StageTotal,
+			Count: 1,
+			Total: 5 * time.Millisecond,
+			Max:   5 * time.Millisecond,
+		},
+	}
+	for i, timing := range timings {
+		wantTiming, ok := want[ValidationStage(i)]
+		if !ok {
+			wantTiming = StageTiming{Stage: ValidationStage(i)}
+		}
+		if timing != wantTiming {
+			t.Errorf("Snapshot: unexpected timing for stage %v - got "+
+				"%+v, want %+v", ValidationStage(i), timing, wantTiming)
+		}
+	}
+	if avg := timings[StageScripts].Average(); avg != 2*time.Millisecond {
+		t.Errorf("Average: unexpected average - got %v, want %v", avg,
+			2*time.Millisecond)
+	}
+	if avg := timings[StageChecks].Average(); avg != 0 {
+		t.Errorf("Average: unexpected average for unrecorded stage - "+
+			"got %v, want 0", avg)
+	}
+}
+
+// TestValidationStageStringer tests the stringized output for the
+// ValidationStage type.
+func TestValidationStageStringer(t *testing.T) {
+	tests := []struct {
+		in   ValidationStage
+		want string
+	}{
+		{StageChecks, "checks"},
+		{StageFetchInputs, "fetchinputs"},
+		{StageScripts, "scripts"},
+		{StageUpdate, "update"},
+		{StageTotal, "total"},
+		{0xffff, "Unknown ValidationStage (65535)"},
+	}
+
+	// Detect additional stages that don't have the stringer added.
+	if len(tests)-1 != int(numValidationStages) {
+		t.Errorf("It appears a validation stage was added without " +
+			"adding an associated stringer test")
+	}
+
+	for i, test := range tests {
+		result := test.in.String()
+		if result != test.want {
+			t.Errorf("String #%d\n got: %s want: %s", i, result,
+				test.want)
+		}
+	}
+}
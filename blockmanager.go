@@ -21,6 +21,7 @@ import (
 	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/dcrjson"
 	"github.com/decred/dcrd/mempool"
 	"github.com/decred/dcrd/wire"
 	"github.com/decred/dcrutil"
@@ -43,10 +44,6 @@ const (
 	// block or transaction before it is dropped.
 	maxResendLimit = 3
 
-	// maxRejectedTxns is the maximum number of rejected transactions
-	// hashes to store in memory.
-	maxRejectedTxns = 1000
-
 	// maxRequestedBlocks is the maximum number of requested block
 	// hashes to store in memory.
 	maxRequestedBlocks = wire.MaxInvPerMsg
@@ -78,6 +75,11 @@ type newPeerMsg struct {
 type blockMsg struct {
 	block *dcrutil.Block
 	peer  *serverPeer
+
+	// held is set when the block was held by the sync scheduler until the
+	// blocks preceding it were processed.  It was already verified to have
+	// been requested when it was received.
+	held bool
 }
 
 // invMsg packages a decred inv message and the peer it came from together
@@ -87,6 +89,13 @@ type invMsg struct {
 	peer *serverPeer
 }
 
+// notFoundMsg packages a decred notfound message and the peer it came from
+// together so the block handler has access to that information.
+type notFoundMsg struct {
+	notFound *wire.MsgNotFound
+	peer     *serverPeer
+}
+
 // headersMsg packages a decred headers message and the peer it came from
 // together so the block handler has access to that information.
 type headersMsg struct {
@@ -106,12 +115,39 @@ type txMsg struct {
 	peer *serverPeer
 }
 
+// packageMsg packages a decred package message and the peer it came from
+// together so the block handler has access to that information.
+type packageMsg struct {
+	txns []*dcrutil.Tx
+	peer *serverPeer
+}
+
+// cmpctBlockMsg packages a decred cmpctblock message and the peer it came from
+// together so the block handler has access to that information.
+type cmpctBlockMsg struct {
+	cmpctBlock *wire.MsgCmpctBlock
+	peer       *serverPeer
+}
+
+// blockTxnMsg packages a decred blocktxn message and the peer it came from
+// together so the block handler has access to that information.
+type blockTxnMsg struct {
+	blockTxn *wire.MsgBlockTxn
+	peer     *serverPeer
+}
+
 // getSyncPeerMsg is a message type to be sent across the message channel for
 // retrieving the current sync peer.
 type getSyncPeerMsg struct {
 	reply chan *serverPeer
 }
 
+// getSyncStatusMsg is a message type to be sent across the message channel for
+// retrieving the state of the download of blocks from the sync peers.
+type getSyncStatusMsg struct {
+	reply chan *dcrjson.GetSyncStatusResult
+}
+
 // requestFromPeerMsg is a message type to be sent across the message channel
 // for requesting either blocks or transactions from a given peer. It routes
 // this through the block manager so the block manager doesn't ban the peer
@@ -239,6 +275,21 @@ type forceReorganizationMsg struct {
 	reply      chan forceReorganizationResponse
 }
 
+// invalidateBlockResponse is a response sent to the reply channel of an
+// invalidateBlockMsg query.
+type invalidateBlockResponse struct {
+	err error
+}
+
+// invalidateBlockMsg is a message type to be sent across the message channel
+// for requesting that a block be manually invalidated, or reconsidered when the
+// reconsider flag is set.
+type invalidateBlockMsg struct {
+	hash       chainhash.Hash
+	reconsider bool
+	reply      chan invalidateBlockResponse
+}
+
 // getTopBlockResponse is a response to the request for the block at HEAD of the
 // blockchain. We need to be able to obtain this from blockChain for mining
 // purposes.
@@ -369,6 +420,7 @@ type chainState struct {
 	sync.Mutex
 	newestHash          *chainhash.Hash
 	newestHeight        int64
+	newestTime          time.Time
 	nextFinalState      [6]byte
 	nextPoolSize        uint32
 	nextStakeDifficulty int64
@@ -409,6 +461,17 @@ func (c *chainState) NextPoolSize() uint32 {
 	return c.nextPoolSize
 }
 
+// BestTime returns the time at which the current tip of the best known chain
+// became the tip.
+//
+// This function is safe for concurrent access.
+func (c *chainState) BestTime() time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.newestTime
+}
+
 // NextWinners returns the eligible SStx hashes to vote on the
 // next block as inputs for SSGen.
 //
@@ -448,11 +511,14 @@ type blockManager struct {
 	started             int32
 	shutdown            int32
 	chain               *blockchain.BlockChain
-	rejectedTxns        map[chainhash.Hash]struct{}
 	requestedTxns       map[chainhash.Hash]struct{}
 	requestedEverTxns   map[chainhash.Hash]uint8
+	txRequests          *txRequestTracker
+	relayHistory        *relayHistory
 	requestedBlocks     map[chainhash.Hash]struct{}
 	requestedEverBlocks map[chainhash.Hash]uint8
+	partialBlocks       map[chainhash.Hash]*partialBlock
+	cmpctPeers          []*serverPeer
 	progressLogger      *blockProgressLogger
 	receivedLogBlocks   int64
 	receivedLogTx       int64
@@ -469,6 +535,8 @@ type blockManager struct {
 	headerList       *list.List
 	startHeader      *list.Element
 	nextCheckpoint   *chaincfg.Checkpoint
+	syncScheduler    *blockSyncScheduler
+	headerSync       *headerSyncScheduler
 
 	// lotteryDataBroadcastMutex is a mutex protecting the map
 	// that checks if block lottery data has been broadcasted
@@ -488,14 +556,20 @@ func (b *blockManager) resetHeaderState(newestHash *chainhash.Hash, newestHeight
 	b.headersFirstMode = false
 	b.headerList.Init()
 	b.startHeader = nil
+	b.syncScheduler.Reset()
 
-	// When there is a next checkpoint, add an entry for the latest known
-	// block into the header pool.  This allows the next downloaded header
-	// to prove it links to the chain properly.
-	if b.nextCheckpoint != nil {
-		node := headerNode{height: newestHeight, hash: newestHash}
-		b.headerList.PushBack(&node)
+	// Divide the headers up to the final checkpoint into segments ending at
+	// each of the remaining checkpoints so they can be downloaded from the
+	// sync peers in parallel.  The first segment starts after the latest
+	// known block, which allows its first header to prove it links to the
+	// chain properly.
+	var checkpoints []*chaincfg.Checkpoint
+	checkpoint := b.findNextHeaderCheckpoint(newestHeight)
+	for checkpoint != nil {
+		checkpoints = append(checkpoints, checkpoint)
+		checkpoint = b.findNextHeaderCheckpoint(checkpoint.Height)
 	}
+	b.headerSync.Reset(newestHash, newestHeight, checkpoints)
 }
 
 // updateChainState updates the chain state associated with the block manager.
@@ -510,6 +584,13 @@ func (b *blockManager) updateChainState(newestHash *chainhash.Hash,
 	b.chainState.Lock()
 	defer b.chainState.Unlock()
 
+	// Track when the tip of the best chain changed so block template
+	// generation is able to determine how long it has been waiting for the
+	// votes on it.
+	if b.chainState.newestHash == nil ||
+		*b.chainState.newestHash != *newestHash {
+		b.chainState.newestTime = time.Now()
+	}
 	b.chainState.newestHash = newestHash
 	b.chainState.newestHeight = newestHeight
 	medianTime, err := b.chain.CalcPastMedianTime()
@@ -529,15 +610,16 @@ func (b *blockManager) updateChainState(newestHash *chainhash.Hash,
 
 // findNextHeaderCheckpoint returns the next checkpoint after the passed height.
 // It returns nil when there is not one either because the height is already
-// later than the final checkpoint or some other reason such as disabled
-// checkpoints.
+// later than the final checkpoint or some other reason such as checkpoints
+// which are not enforced.
 func (b *blockManager) findNextHeaderCheckpoint(height int64) *chaincfg.Checkpoint {
-	// There is no next checkpoint if checkpoints are disabled or there are
-	// none for this current network.
-	if cfg.DisableCheckpoints {
+	// There is no next checkpoint if checkpoints are not enforced or there
+	// are none for this current network.  Blocks are only validated with
+	// less scrutiny up to a checkpoint when it is enforced.
+	if cfg.checkpointMode != blockchain.CheckpointModeEnforce {
 		return nil
 	}
-	checkpoints := b.server.chainParams.Checkpoints
+	checkpoints := b.chain.Checkpoints()
 	if len(checkpoints) == 0 {
 		return nil
 	}
@@ -588,13 +670,33 @@ func (b *blockManager) startSync(peers *list.List) {
 			continue
 		}
 
-		// TODO(davec): Use a better algorithm to choose the best peer.
-		// For now, just pick the first available candidate.
+		// Choose the candidate which was the first to relay the most
+		// blocks and transactions, but only choose a peer deprioritized
+		// by the peer filter rules when there are no others.
+		if bestPeer != nil && sp.deprioritized != bestPeer.deprioritized {
+			if sp.deprioritized {
+				continue
+			}
+		} else if bestPeer != nil && sp.relayScore() <= bestPeer.relayScore() {
+			continue
+		}
 		bestPeer = sp
 	}
 
 	// Start syncing from the best peer if one was selected.
 	if bestPeer != nil {
+		// Continue downloading the headers and blocks from the sync
+		// peers when in headers-first mode since the downloaded headers
+		// are verified against the checkpoints regardless of the peer
+		// they were downloaded from.
+		if b.headersFirstMode {
+			bmgrLog.Infof("Continuing to sync to block height %d with "+
+				"sync peer %v", bestPeer.LastBlock(), bestPeer.Addr())
+			b.syncPeer = bestPeer
+			b.fetchHeadersFirstData()
+			return
+		}
+
 		// Clear the requestedBlocks if the sync peer changes, otherwise
 		// we may ignore blocks we need that the last sync peer failed
 		// to send.
@@ -623,31 +725,35 @@ func (b *blockManager) startSync(peers *list.List) {
 		// full block hasn't been tampered with.
 		//
 		// Once we have passed the final checkpoint, or checkpoints are
-		// disabled, use standard inv messages learn about the blocks
+		// not enforced, use standard inv messages learn about the blocks
 		// and fully validate them.  Finally, regression test mode does
 		// not support the headers-first approach so do normal block
 		// downloads when in regression test mode.
+		//
+		// The headers are downloaded from all of the sync peers in
+		// parallel in segments between the checkpoints and the blocks
+		// are downloaded from them in parallel as soon as the headers
+		// preceding them are verified.
 		if b.nextCheckpoint != nil &&
 			best.Height < b.nextCheckpoint.Height &&
-			!cfg.DisableCheckpoints {
+			cfg.checkpointMode == blockchain.CheckpointModeEnforce {
 
-			err := bestPeer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
-			if err != nil {
-				bmgrLog.Errorf("Failed to push getheadermsg for the "+
-					"latest blocks: %v", err)
-				return
-			}
+			b.resetHeaderState(best.Hash, best.Height)
 			b.headersFirstMode = true
-			bmgrLog.Infof("Downloading headers for blocks %d to "+
-				"%d from peer %s", best.Height+1,
-				b.nextCheckpoint.Height, bestPeer.Addr())
-		} else {
-			err := bestPeer.PushGetBlocksMsg(locator, &zeroHash)
-			if err != nil {
-				bmgrLog.Errorf("Failed to push getblocksmsg for the "+
-					"latest blocks: %v", err)
-				return
-			}
+			b.syncPeer = bestPeer
+			bmgrLog.Infof("Downloading headers for blocks %d to %d "+
+				"in %d segment(s) from the sync peers",
+				best.Height+1, b.finalCheckpointHeight(),
+				b.headerSync.NumPending())
+			b.fetchHeaderSegments()
+			return
+		}
+
+		err = bestPeer.PushGetBlocksMsg(locator, &zeroHash)
+		if err != nil {
+			bmgrLog.Errorf("Failed to push getblocksmsg for the "+
+				"latest blocks: %v", err)
+			return
 		}
 		b.syncPeer = bestPeer
 	} else {
@@ -697,10 +803,17 @@ func (b *blockManager) handleNewPeerMsg(peers *list.List, sp *serverPeer) {
 		return
 	}
 
-	// Add the peer as a candidate to sync from.
+	// Add the peer as a candidate to sync from and download blocks from
+	// during headers-first mode.
 	peers.PushBack(sp)
+	b.syncScheduler.AddPeer(sp)
 
-	// Start syncing by choosing the best candidate if needed.
+	// Start syncing by choosing the best candidate if needed.  Otherwise,
+	// download headers and blocks from the new peer as well when in
+	// headers-first mode.
+	if b.syncPeer != nil && b.headersFirstMode {
+		b.fetchHeadersFirstData()
+	}
 	b.startSync(peers)
 
 	// Grab the mining state from this peer after we're synced.
@@ -730,6 +843,11 @@ func (b *blockManager) handleDonePeerMsg(peers *list.List, sp *serverPeer) {
 		delete(b.requestedTxns, k)
 	}
 
+	// Request the transactions that were requested from the peer from the
+	// other peers that announced them.
+	b.txRequests.RemovePeer(sp)
+	b.requestPendingTxns()
+
 	// Remove requested blocks from the global map so that they will be
 	// fetched from elsewhere next time we get an inv.
 	// TODO(oga) we could possibly here check which peers have these blocks
@@ -738,16 +856,35 @@ func (b *blockManager) handleDonePeerMsg(peers *list.List, sp *serverPeer) {
 		delete(b.requestedBlocks, k)
 	}
 
+	// Forget the compact blocks which are waiting on transactions from the
+	// peer and stop considering it for compact block announcements.
+	for hash, pb := range b.partialBlocks {
+		if pb.peer == sp {
+			delete(b.partialBlocks, hash)
+		}
+	}
+	for i, cmpctPeer := range b.cmpctPeers {
+		if cmpctPeer == sp {
+			b.cmpctPeers = append(b.cmpctPeers[:i], b.cmpctPeers[i+1:]...)
+			break
+		}
+	}
+
+	// Reassign the headers and blocks assigned to the peer during
+	// headers-first mode to the remaining peers.
+	b.syncScheduler.RemovePeer(sp)
+	b.headerSync.RemovePeer(sp)
+
 	// Attempt to find a new peer to sync from if the quitting peer is the
-	// sync peer.  Also, reset the headers-first state if in headers-first
-	// mode so
+	// sync peer.  The headers-first state is retained since the headers
+	// which were already downloaded are verified against the checkpoints.
 	if b.syncPeer != nil && b.syncPeer == sp {
 		b.syncPeer = nil
-		if b.headersFirstMode {
-			best := b.chain.BestSnapshot()
-			b.resetHeaderState(best.Hash, best.Height)
-		}
 		b.startSync(peers)
+		return
+	}
+	if b.headersFirstMode {
+		b.fetchHeadersFirstData()
 	}
 }
 
@@ -798,13 +935,16 @@ func (b *blockManager) handleTxMsg(tmsg *txMsg) {
 	// to disconnect peers for sending unsolicited transactions to provide
 	// interoperability.
 	txHash := tmsg.tx.Hash()
+	iv := wire.NewInvVect(wire.InvTypeTx, txHash)
+	b.relayHistory.Received(iv, tmsg.peer, time.Now())
 
 	// Ignore transactions that we have already rejected.  Do not
 	// send a reject message here because if the transaction was already
 	// rejected, the transaction was unsolicited.
-	if _, exists := b.rejectedTxns[*txHash]; exists {
+	if rejected := b.server.txMemPool.HaveRejected(tmsg.tx); rejected != nil {
 		bmgrLog.Debugf("Ignoring unsolicited previously rejected "+
-			"transaction %v from %s", txHash, tmsg.peer)
+			"transaction %v from %s (%v: %s)", txHash, tmsg.peer,
+			rejected.RejectCode, rejected.Reason)
 		return
 	}
 
@@ -820,12 +960,14 @@ func (b *blockManager) handleTxMsg(tmsg *txMsg) {
 	// we'll retry next time we get an inv.
 	delete(tmsg.peer.requestedTxns, *txHash)
 	delete(b.requestedTxns, *txHash)
+	if sp := b.txRequests.Received(txHash); sp != nil {
+		delete(sp.requestedTxns, *txHash)
+	}
 
 	if err != nil {
-		// Do not request this transaction again until a new block
-		// has been processed.
-		b.rejectedTxns[*txHash] = struct{}{}
-		b.limitMap(b.rejectedTxns, maxRejectedTxns)
+		// Do not request this transaction again until the rejection
+		// expires or a new block has been processed.
+		b.server.txMemPool.RecordRejected(tmsg.tx, err)
 
 		// When the error is a rule error, it means the transaction was
 		// simply rejected as opposed to something actually going wrong,
@@ -844,9 +986,100 @@ func (b *blockManager) handleTxMsg(tmsg *txMsg) {
 		code, reason := mempool.ErrToRejectErr(err)
 		tmsg.peer.PushRejectMsg(wire.CmdTx, code, reason, txHash,
 			false)
+
+		// Warn other peers and websocket clients when the transaction
+		// double spends a transaction in the memory pool.
+		b.server.announceDoubleSpend(tmsg.tx, err)
 		return
 	}
 
+	// Credit the peer the transaction originated from for being the first
+	// to relay it.
+	if sp := b.relayHistory.Credit(txHash); sp != nil {
+		sp.creditFirstRelay(false)
+	}
+
+	// Request the transaction along with its unconfirmed ancestors as a
+	// package when it is an orphan and the peer supports package relay
+	// since its parents might not be accepted on their own, which is often
+	// the case for the split transactions funding tickets.
+	if len(acceptedTxs) == 0 &&
+		tmsg.peer.ProtocolVersion() >= wire.PackageRelayVersion {
+
+		tmsg.peer.requestedPackages[*txHash] = struct{}{}
+		tmsg.peer.QueueMessage(wire.NewMsgGetPackage(txHash), nil)
+	}
+
+	b.server.AnnounceNewTransactions(acceptedTxs)
+}
+
+// handlePackageMsg handles package messages from all peers.  The transactions
+// of the package are accepted to the memory pool as a unit, so parents which do
+// not pay enough fees on their own are accepted along with the children which
+// pay for them.
+func (b *blockManager) handlePackageMsg(pmsg *packageMsg) {
+	// The final transaction of the package is the one it was requested
+	// for.  Peers which send packages that were not requested are
+	// misbehaving.
+	var txHash *chainhash.Hash
+	if len(pmsg.txns) > 0 {
+		txHash = pmsg.txns[len(pmsg.txns)-1].Hash()
+	}
+	if txHash == nil {
+		pmsg.peer.addBanScore(100, 0, "empty package")
+		return
+	}
+	if _, ok := pmsg.peer.requestedPackages[*txHash]; !ok {
+		bmgrLog.Warnf("Got unrequested package for transaction %v from "+
+			"%s -- disconnecting", txHash, pmsg.peer)
+		pmsg.peer.addBanScore(100, 0, "unrequested package")
+		pmsg.peer.Disconnect()
+		return
+	}
+	delete(pmsg.peer.requestedPackages, *txHash)
+
+	// Remove the transactions from the request maps since they were
+	// delivered as part of the package.
+	for _, tx := range pmsg.txns {
+		txHash := tx.Hash()
+		iv := wire.NewInvVect(wire.InvTypeTx, txHash)
+		b.relayHistory.Received(iv, pmsg.peer, time.Now())
+		delete(pmsg.peer.requestedTxns, *txHash)
+		delete(b.requestedTxns, *txHash)
+		if sp := b.txRequests.Received(txHash); sp != nil {
+			delete(sp.requestedTxns, *txHash)
+		}
+	}
+
+	acceptedTxs, err := b.server.txMemPool.AcceptPackage(pmsg.txns, true,
+		true)
+	if err != nil {
+		if _, ok := err.(mempool.RuleError); ok {
+			bmgrLog.Debugf("Rejected package for transaction %v "+
+				"from %s: %v", txHash, pmsg.peer, err)
+		} else {
+			bmgrLog.Errorf("Failed to process package for "+
+				"transaction %v: %v", txHash, err)
+		}
+
+		// Ban peers which send malformed packages or packages with
+		// transactions which violate the rules, as opposed to packages
+		// which are only rejected due to the state of the memory pool
+		// such as paying too little fees for it.
+		code, reason := mempool.ErrToRejectErr(err)
+		if code == wire.RejectInvalid || code == wire.RejectMalformed {
+			pmsg.peer.addBanScore(100, 0, "invalid package")
+		}
+		pmsg.peer.PushRejectMsg(wire.CmdPackage, code, reason, txHash,
+			false)
+		return
+	}
+
+	for _, tx := range acceptedTxs {
+		if sp := b.relayHistory.Credit(tx.Hash()); sp != nil {
+			sp.creditFirstRelay(false)
+		}
+	}
 	b.server.AnnounceNewTransactions(acceptedTxs)
 }
 
@@ -1016,9 +1249,12 @@ func (b *blockManager) checkBlockForHiddenVotes(block *dcrutil.Block) {
 			"block with extra found voters")
 		return
 	}
+	// The tax output of the existing coinbase already pays to the required
+	// tax script.
 	coinbase, err := createCoinbaseTx(b.chain.FetchSubsidyCache(),
 		template.Block.Transactions[0].TxIn[0].SignatureScript,
 		opReturnPkScript,
+		template.Block.Transactions[0].TxOut[0].PkScript,
 		int64(template.Block.Header.Height),
 		cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))],
 		uint16(votesTotal),
@@ -1052,11 +1288,36 @@ func (b *blockManager) checkBlockForHiddenVotes(block *dcrutil.Block) {
 	return
 }
 
+// annotateBlock attaches the time the passed block was first seen and the
+// address of the peer which relayed it to the block.  Errors are only logged
+// since the annotations are purely informational.
+func (b *blockManager) annotateBlock(hash *chainhash.Hash, firstSeen time.Time, sp *serverPeer) {
+	annotations := []blockchain.BlockAnnotation{
+		{
+			Key:   blockchain.AnnotationFirstSeen,
+			Value: firstSeen.UTC().Format(time.RFC3339Nano),
+		},
+		{
+			Key:   blockchain.AnnotationRelayPeer,
+			Value: sp.Addr(),
+		},
+	}
+	for _, annotation := range annotations {
+		err := b.chain.SetBlockAnnotation(hash, annotation.Key,
+			annotation.Value)
+		if err != nil {
+			bmgrLog.Warnf("Failed to annotate block %v: %v", hash, err)
+			return
+		}
+	}
+}
+
 // handleBlockMsg handles block messages from all peers.
 func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 	// If we didn't ask for this block then the peer is misbehaving.
 	blockHash := bmsg.block.Hash()
-	if _, exists := bmsg.peer.requestedBlocks[*blockHash]; !exists {
+	_, exists := bmsg.peer.requestedBlocks[*blockHash]
+	if !exists && !bmsg.held {
 		// Check to see if we ever requested this block, since it may
 		// have been accidentally sent in duplicate. If it was,
 		// increment the counter in the ever requested map and make
@@ -1079,13 +1340,41 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		}
 	}
 
+	// Record the delivery of the block in the relay history.  Held blocks
+	// were already recorded when they were first received.
+	if !bmsg.held {
+		iv := wire.NewInvVect(wire.InvTypeBlock, blockHash)
+		b.relayHistory.Received(iv, bmsg.peer, time.Now())
+	}
+
+	// When in headers-first mode, update the measured throughput of the
+	// peer when the block was assigned to it by the sync scheduler and hold
+	// the block when it was received before the blocks preceding it since
+	// the blocks must be processed in order.
+	if b.headersFirstMode && !bmsg.held {
+		size := bmsg.block.MsgBlock().SerializeSize()
+		assigned := b.syncScheduler.Received(bmsg.peer, blockHash, size,
+			time.Now())
+		firstNodeEl := b.headerList.Front()
+		if assigned && firstNodeEl != nil &&
+			!blockHash.IsEqual(firstNodeEl.Value.(*headerNode).hash) {
+
+			delete(bmsg.peer.requestedBlocks, *blockHash)
+			delete(b.requestedBlocks, *blockHash)
+			b.syncScheduler.Hold(bmsg)
+			if b.startHeader != nil || b.syncScheduler.HasRetries() {
+				b.fetchHeaderBlocks()
+			}
+			return
+		}
+	}
+
 	// When in headers-first mode, if the block matches the hash of the
 	// first header in the list of headers that are being fetched, it's
 	// eligible for less validation since the headers have already been
 	// verified to link together and are valid up to the next checkpoint.
-	// Also, remove the list entry for all blocks except the checkpoint
-	// since it is needed to verify the next round of headers links
-	// properly.
+	// Also, remove the list entry for the block since the headers of the
+	// later segments were verified to link to the checkpoints on their own.
 	isCheckpointBlock := false
 	behaviorFlags := blockchain.BFNone
 	if b.headersFirstMode {
@@ -1096,9 +1385,8 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 				behaviorFlags |= blockchain.BFFastAdd
 				if firstNode.hash.IsEqual(b.nextCheckpoint.Hash) {
 					isCheckpointBlock = true
-				} else {
-					b.headerList.Remove(firstNodeEl)
 				}
+				b.headerList.Remove(firstNodeEl)
 			}
 		}
 	}
@@ -1111,6 +1399,7 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 
 	// Process the block to include validation, best chain selection, orphan
 	// handling, etc.
+	firstSeen := time.Now()
 	onMainChain, isOrphan, err := b.chain.ProcessBlock(bmsg.block,
 		behaviorFlags)
 	if err != nil {
@@ -1178,6 +1467,15 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		// When the block is not an orphan, log information about it and
 		// update the chain state.
 		b.progressLogger.logBlockHeight(bmsg.block)
+		if sp := b.relayHistory.Credit(blockHash); sp != nil {
+			sp.creditFirstRelay(true)
+			if onMainChain && b.current() && !cfg.BlocksOnly {
+				b.selectCmpctPeer(sp)
+			}
+		}
+		if cfg.AnnotateBlocks {
+			b.annotateBlock(blockHash, firstSeen, bmsg.peer)
+		}
 		r := b.server.rpcServer
 
 		// Determine if this block is recent enough that we need to calculate
@@ -1261,6 +1559,7 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 						*best.Hash,
 						best.Height,
 						nextStakeDiff,
+						curBlockHeader.SBits,
 					})
 				b.server.txMemPool.PruneStakeTx(nextStakeDiff,
 					best.Height)
@@ -1284,14 +1583,15 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 			blkHashUpdate = best.Hash
 
 			// Clear the rejected transactions.
-			b.rejectedTxns = make(map[chainhash.Hash]struct{})
+			b.server.txMemPool.ClearRejected()
 
 			// Allow any clients performing long polling via the
-			// getblocktemplate RPC to be notified when the new block causes
-			// their old block template to become stale.
+			// getblocktemplate RPC or registered for work notifications
+			// to be notified when the new block causes their old work to
+			// become stale.
 			rpcServer := b.server.rpcServer
 			if rpcServer != nil {
-				rpcServer.gbtWorkState.NotifyBlockConnected(blockHash)
+				rpcServer.NotifyNewTip(best.Hash, best.Height)
 			}
 		}
 	}
@@ -1317,31 +1617,20 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 	// request more blocks using the header list when the request queue is
 	// getting short.
 	if !isCheckpointBlock {
-		if b.startHeader != nil &&
-			len(bmsg.peer.requestedBlocks) < minInFlightBlocks {
+		if b.startHeader != nil || b.syncScheduler.HasRetries() {
 			b.fetchHeaderBlocks()
 		}
 		return
 	}
 
 	// This is headers-first mode and the block is a checkpoint.  When
-	// there is a next checkpoint, get the next round of headers by asking
-	// for headers starting from the block after this one up to the next
-	// checkpoint.
-	prevHeight := b.nextCheckpoint.Height
-	prevHash := b.nextCheckpoint.Hash
-	b.nextCheckpoint = b.findNextHeaderCheckpoint(prevHeight)
+	// there is a next checkpoint, continue fetching the blocks for the
+	// headers up to it, which are downloaded in parallel with the blocks.
+	b.nextCheckpoint = b.findNextHeaderCheckpoint(b.nextCheckpoint.Height)
 	if b.nextCheckpoint != nil {
-		locator := blockchain.BlockLocator([]*chainhash.Hash{prevHash})
-		err := bmsg.peer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
-		if err != nil {
-			bmgrLog.Warnf("Failed to send getheaders message to "+
-				"peer %s: %v", bmsg.peer.Addr(), err)
-			return
+		if b.startHeader != nil || b.syncScheduler.HasRetries() {
+			b.fetchHeaderBlocks()
 		}
-		bmgrLog.Infof("Downloading headers for blocks %d to %d from "+
-			"peer %s", prevHeight+1, b.nextCheckpoint.Height,
-			b.syncPeer.Addr())
 		return
 	}
 
@@ -1350,35 +1639,51 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 	// from the block after this one up to the end of the chain (zero hash).
 	b.headersFirstMode = false
 	b.headerList.Init()
+	b.startHeader = nil
 	bmgrLog.Infof("Reached the final checkpoint -- switching to normal mode")
+	if b.syncPeer == nil {
+		return
+	}
 	locator := blockchain.BlockLocator([]*chainhash.Hash{blockHash})
-	err = bmsg.peer.PushGetBlocksMsg(locator, &zeroHash)
+	err = b.syncPeer.PushGetBlocksMsg(locator, &zeroHash)
 	if err != nil {
 		bmgrLog.Warnf("Failed to send getblocks message to peer %s: %v",
-			bmsg.peer.Addr(), err)
+			b.syncPeer.Addr(), err)
 		return
 	}
 }
 
-// fetchHeaderBlocks creates and sends a request to the syncPeer for the next
-// list of blocks to be downloaded based on the current list of headers.
+// fetchHeaderBlocks creates and sends requests to the sync peers for the next
+// blocks to be downloaded based on the current list of headers.  The blocks are
+// assigned to the peers by the sync scheduler in contiguous ranges with each
+// peer being assigned a share of the blocks proportional to its measured
+// throughput.  Blocks which must be reassigned, such as those assigned to peers
+// that disconnected, are requested before any new blocks.
 func (b *blockManager) fetchHeaderBlocks() {
-	// Nothing to do if there is no start header.
-	if b.startHeader == nil {
+	// Nothing to do if there are no blocks left to request.
+	if b.startHeader == nil && !b.syncScheduler.HasRetries() {
 		bmgrLog.Warnf("fetchHeaderBlocks called with no start header")
 		return
 	}
 
-	// Build up a getdata request for the list of blocks the headers
-	// describe.  The size hint will be limited to wire.MaxInvPerMsg by
-	// the function, so no need to double check it here.
-	gdmsg := wire.NewMsgGetDataSizeHint(uint(b.headerList.Len()))
-	numRequested := 0
-	for e := b.startHeader; e != nil; e = e.Next() {
-		node, ok := e.Value.(*headerNode)
-		if !ok {
-			bmgrLog.Warn("Header list node type is not a headerNode")
-			continue
+	now := time.Now()
+	free, remaining := b.syncScheduler.FreeSlots(now)
+	requests := make(map[*serverPeer]*wire.MsgGetData)
+	var sp *serverPeer
+	for remaining > 0 {
+		node, isRetry := b.syncScheduler.PeekRetry(), true
+		if node == nil {
+			if b.startHeader == nil {
+				break
+			}
+			var ok bool
+			node, ok = b.startHeader.Value.(*headerNode)
+			if !ok {
+				bmgrLog.Warn("Header list node type is not a headerNode")
+				b.startHeader = b.startHeader.Next()
+				continue
+			}
+			isRetry = false
 		}
 
 		iv := wire.NewInvVect(wire.InvTypeBlock, node.hash)
@@ -1387,27 +1692,294 @@ func (b *blockManager) fetchHeaderBlocks() {
 			bmgrLog.Warnf("Unexpected failure when checking for "+
 				"existing inventory during header block "+
 				"fetch: %v", err)
-			continue
 		}
-		if !haveInv {
+		if err == nil && !haveInv {
+			// Continue the range of blocks assigned to the current
+			// peer while it may be assigned more and has the block.
+			// Otherwise, start a new range with the peer that may be
+			// assigned the most blocks.
+			if sp == nil || free[sp] == 0 || !b.hasSyncBlock(sp, node) {
+				sp = b.selectSyncBlockPeer(free, node)
+				if sp == nil {
+					break
+				}
+			}
+
 			b.requestedBlocks[*node.hash] = struct{}{}
 			b.requestedEverBlocks[*node.hash] = 0
-			b.syncPeer.requestedBlocks[*node.hash] = struct{}{}
+			sp.requestedBlocks[*node.hash] = struct{}{}
+			b.syncScheduler.Assign(sp, node, now)
+			gdmsg, ok := requests[sp]
+			if !ok {
+				gdmsg = wire.NewMsgGetData()
+				requests[sp] = gdmsg
+			}
 			err = gdmsg.AddInvVect(iv)
 			if err != nil {
 				bmgrLog.Warnf("Failed to add invvect while fetching "+
 					"block headers: %v", err)
 			}
-			numRequested++
+			free[sp]--
+			remaining--
 		}
-		b.startHeader = e.Next()
-		if numRequested >= wire.MaxInvPerMsg {
-			break
+
+		if isRetry {
+			b.syncScheduler.PopRetry()
+		} else {
+			b.startHeader = b.startHeader.Next()
 		}
 	}
-	if len(gdmsg.InvList) > 0 {
-		b.syncPeer.QueueMessage(gdmsg, nil)
+	for sp, gdmsg := range requests {
+		sp.QueueMessage(gdmsg, nil)
+	}
+}
+
+// hasSyncBlock returns whether or not the passed peer is expected to have the
+// block for the passed header.  The sync peer the headers are downloaded from
+// is expected to have the blocks unless it responded that it does not.
+func (b *blockManager) hasSyncBlock(sp *serverPeer, node *headerNode) bool {
+	if !b.syncScheduler.MayHave(sp, node.height) {
+		return false
+	}
+	return sp == b.syncPeer || sp.LastBlock() >= node.height
+}
+
+// selectSyncBlockPeer returns the peer which may be assigned the most
+// additional blocks according to the passed free slots and is expected to have
+// the block for the passed header.  It returns nil when there is no such peer.
+func (b *blockManager) selectSyncBlockPeer(free map[*serverPeer]int, node *headerNode) *serverPeer {
+	var bestPeer *serverPeer
+	for sp, n := range free {
+		if n <= 0 || !b.hasSyncBlock(sp, node) {
+			continue
+		}
+		if bestPeer == nil || n > free[bestPeer] {
+			bestPeer = sp
+		}
+	}
+	return bestPeer
+}
+
+// processHeldBlocks processes the blocks held by the sync scheduler during
+// headers-first mode which are next in the list of headers now that the blocks
+// preceding them were processed.
+func (b *blockManager) processHeldBlocks() {
+	for b.headersFirstMode {
+		firstNodeEl := b.headerList.Front()
+		if firstNodeEl == nil {
+			return
+		}
+		bmsg := b.syncScheduler.TakeHeld(firstNodeEl.Value.(*headerNode).hash)
+		if bmsg == nil {
+			return
+		}
+		b.handleBlockMsg(bmsg)
+	}
+}
+
+// syncStatus returns the state of the download of blocks from the sync peers.
+func (b *blockManager) syncStatus() *dcrjson.GetSyncStatusResult {
+	best := b.chain.BestSnapshot()
+	result := &dcrjson.GetSyncStatusResult{
+		Height:       best.Height,
+		HeadersFirst: b.headersFirstMode,
+		HeldBlocks:   b.syncScheduler.NumHeld(),
+		Peers:        b.syncScheduler.Status(time.Now()),
+	}
+	if b.syncPeer != nil {
+		result.SyncPeer = b.syncPeer.Addr()
+		result.SyncHeight = b.syncPeer.LastBlock()
+	}
+	if back := b.headerList.Back(); b.headersFirstMode && back != nil {
+		result.HeadersHeight = back.Value.(*headerNode).height
+	}
+	return result
+}
+
+// requestFullBlock requests the block with the passed hash in full from the
+// passed peer.  It is used when a block can't be reconstructed from a compact
+// block.  The block must already be marked as requested from the peer.
+func (b *blockManager) requestFullBlock(sp *serverPeer, hash *chainhash.Hash) {
+	gdmsg := wire.NewMsgGetData()
+	gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, hash))
+	sp.QueueMessage(gdmsg, nil)
+}
+
+// handleCmpctBlockMsg handles cmpctblock messages from all peers.  The block is
+// reconstructed from the transactions of the compact block and the memory pool
+// and processed like any other block once all of its transactions are known.
+// Missing transactions are requested from the peer with a getblocktxn message,
+// and the full block is requested instead when the block can't be
+// reconstructed.
+func (b *blockManager) handleCmpctBlockMsg(cmsg *cmpctBlockMsg) {
+	sp := cmsg.peer
+	header := &cmsg.cmpctBlock.Header
+	blockHash := header.BlockHash()
+
+	// Blocks are downloaded in full while syncing in headers-first mode and
+	// there is nothing to do for blocks which are already known.
+	if b.headersFirstMode {
+		return
+	}
+	if _, exists := b.partialBlocks[blockHash]; exists {
+		return
+	}
+	if haveBlock, err := b.chain.HaveBlock(&blockHash); err != nil ||
+		haveBlock {
+
+		delete(sp.requestedBlocks, blockHash)
+		return
+	}
+	if b.chain.IsKnownInvalidBlock(&blockHash) {
+		bmgrLog.Infof("Ignoring known invalid compact block %v from %s",
+			blockHash, sp)
+		delete(sp.requestedBlocks, blockHash)
+		return
+	}
+
+	// Compact blocks are only accepted unsolicited from the peers which
+	// were asked to announce new blocks with them.
+	iv := wire.NewInvVect(wire.InvTypeBlock, &blockHash)
+	if _, exists := sp.requestedBlocks[blockHash]; !exists {
+		announced := false
+		for _, cmpctPeer := range b.cmpctPeers {
+			if cmpctPeer == sp {
+				announced = true
+				break
+			}
+		}
+		if !announced {
+			bmgrLog.Debugf("Ignoring unrequested compact block %v "+
+				"from %s", blockHash, sp)
+			return
+		}
+		b.relayHistory.Announced(iv, sp, time.Now())
+	}
+
+	// Ensure the header has enough proof of work before spending any effort
+	// on the block since creating compact blocks costs nothing otherwise.
+	err := blockchain.CheckProofOfWork(dcrutil.NewBlock(&wire.MsgBlock{
+		Header: *header,
+	}), b.server.chainParams.PowLimit)
+	if err != nil {
+		bmgrLog.Warnf("Got compact block %v with invalid proof of work "+
+			"from %s -- disconnecting: %v", blockHash, sp, err)
+		sp.Disconnect()
+		return
+	}
+
+	// The block is requested from the peer from here on, either through
+	// its missing transactions or in full.
+	if _, exists := b.requestedBlocks[blockHash]; !exists {
+		b.requestedBlocks[blockHash] = struct{}{}
+		b.requestedEverBlocks[blockHash] = 0
+		b.limitMap(b.requestedBlocks, maxRequestedBlocks)
+	}
+	sp.requestedBlocks[blockHash] = struct{}{}
+
+	// Request the full block when its parent is unknown since it needs to
+	// go through the orphan handling.
+	haveParent, err := b.chain.HaveBlock(&header.PrevBlock)
+	if err != nil || !haveParent {
+		b.requestFullBlock(sp, &blockHash)
+		return
+	}
+
+	var poolTxns []*dcrutil.Tx
+	for _, desc := range b.server.txMemPool.TxDescs() {
+		poolTxns = append(poolTxns, desc.Tx)
+	}
+	pb, err := newPartialBlock(cmsg.cmpctBlock, poolTxns)
+	if err != nil {
+		bmgrLog.Debugf("Failed to reconstruct compact block %v from "+
+			"%s: %v", blockHash, sp, err)
+		b.requestFullBlock(sp, &blockHash)
+		return
+	}
+	pb.peer = sp
+	if pb.complete() {
+		b.processPartialBlock(pb)
+		return
+	}
+
+	// Request the missing transactions unless too many blocks are already
+	// waiting on transactions.
+	if len(b.partialBlocks) >= maxPartialBlocks {
+		b.requestFullBlock(sp, &blockHash)
+		return
+	}
+	b.partialBlocks[blockHash] = pb
+	bmgrLog.Debugf("Requesting %d regular and %d stake transactions of "+
+		"compact block %v from %s", len(pb.regular.missing),
+		len(pb.stake.missing), blockHash, sp)
+	sp.QueueMessage(wire.NewMsgGetBlockTxn(&blockHash, pb.regular.missing,
+		pb.stake.missing), nil)
+}
+
+// handleBlockTxnMsg handles blocktxn messages from all peers.  The transactions
+// complete the compact block they were requested for, which is then processed.
+func (b *blockManager) handleBlockTxnMsg(bmsg *blockTxnMsg) {
+	sp := bmsg.peer
+	blockHash := bmsg.blockTxn.BlockHash
+	pb, exists := b.partialBlocks[blockHash]
+	if !exists || pb.peer != sp {
+		bmgrLog.Debugf("Ignoring unrequested transactions of block %v "+
+			"from %s", blockHash, sp)
+		return
+	}
+	delete(b.partialBlocks, blockHash)
+
+	if err := pb.fill(bmsg.blockTxn); err != nil {
+		bmgrLog.Warnf("Got invalid transactions of block %v from %s "+
+			"-- disconnecting: %v", blockHash, sp, err)
+		sp.Disconnect()
+		return
+	}
+	b.processPartialBlock(pb)
+}
+
+// processPartialBlock processes the passed block which was reconstructed from a
+// compact block as if it was received in full from the peer that sent the
+// compact block.  The full block is requested instead when the reconstructed
+// block does not match its header.
+func (b *blockManager) processPartialBlock(pb *partialBlock) {
+	block, err := pb.block()
+	if err != nil {
+		blockHash := pb.header.BlockHash()
+		bmgrLog.Debugf("Failed to reconstruct compact block %v from "+
+			"%s: %v", blockHash, pb.peer, err)
+		b.requestFullBlock(pb.peer, &blockHash)
+		return
+	}
+	b.handleBlockMsg(&blockMsg{block: block, peer: pb.peer})
+}
+
+// selectCmpctPeer asks the passed peer, which was the first to relay a new
+// block, to announce new blocks with compact blocks.  Only the most recent
+// maxCmpctPeers peers to do so are asked, so the oldest one is asked to stop
+// when the limit is exceeded.
+func (b *blockManager) selectCmpctPeer(sp *serverPeer) {
+	if !sp.SupportsCmpctBlocks() {
+		return
+	}
+	for i, cmpctPeer := range b.cmpctPeers {
+		if cmpctPeer == sp {
+			// Move the peer to the end as the most recent one.
+			copy(b.cmpctPeers[i:], b.cmpctPeers[i+1:])
+			b.cmpctPeers[len(b.cmpctPeers)-1] = sp
+			return
+		}
+	}
+
+	if len(b.cmpctPeers) >= maxCmpctPeers {
+		oldest := b.cmpctPeers[0]
+		b.cmpctPeers = b.cmpctPeers[1:]
+		oldest.QueueMessage(wire.NewMsgSendCmpct(false,
+			wire.CmpctBlockEncodingVersion), nil)
 	}
+	b.cmpctPeers = append(b.cmpctPeers, sp)
+	sp.QueueMessage(wire.NewMsgSendCmpct(true,
+		wire.CmpctBlockEncodingVersion), nil)
 }
 
 // handleHeadersMsg handles headers messages from all peers.
@@ -1422,92 +1994,167 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		return
 	}
 
-	// Nothing to do for an empty headers message.
+	// Ignore headers from peers which are not assigned a segment of
+	// headers, such as late responses from peers whose segment was
+	// reassigned after they stalled.
+	seg := b.headerSync.Assigned(hmsg.peer)
+	if seg == nil {
+		bmgrLog.Debugf("Ignoring %d headers from %s which is not "+
+			"assigned any headers", numHeaders, hmsg.peer)
+		return
+	}
+
+	// Reassign the segment to another peer when the peer does not have the
+	// requested headers.
 	if numHeaders == 0 {
+		_, tipHeight := seg.tip()
+		bmgrLog.Debugf("Peer %s does not have the headers for blocks %d "+
+			"to %d", hmsg.peer, tipHeight+1, seg.checkpoint.Height)
+		b.headerSync.Unavailable(hmsg.peer)
+		b.fetchHeaderSegments()
 		return
 	}
 
-	// Process all of the received headers ensuring each one connects to the
-	// previous and that checkpoints match.
-	receivedCheckpoint := false
-	var finalHash *chainhash.Hash
-	for _, blockHeader := range msg.Headers {
-		blockHash := blockHeader.BlockHash()
-		finalHash = &blockHash
-
-		// Ensure there is a previous header to compare against.
-		prevNodeEl := b.headerList.Back()
-		if prevNodeEl == nil {
-			bmgrLog.Warnf("Header list does not contain a previous" +
-				"element as expected -- disconnecting peer")
-			hmsg.peer.Disconnect()
-			return
-		}
+	// Add the received headers to the segment ensuring each one connects
+	// to the previous and that the checkpoint at the end of the segment
+	// matches.
+	complete, err := b.headerSync.AddHeaders(hmsg.peer, msg.Headers,
+		time.Now())
+	if err != nil {
+		bmgrLog.Warnf("Received invalid block headers from peer %s: %v "+
+			"-- disconnecting", hmsg.peer.Addr(), err)
+		hmsg.peer.Disconnect()
+		return
+	}
 
-		// Ensure the header properly connects to the previous one and
-		// add it to the list of headers.
-		node := headerNode{hash: &blockHash}
-		prevNode := prevNodeEl.Value.(*headerNode)
-		if prevNode.hash.IsEqual(&blockHeader.PrevBlock) {
-			node.height = prevNode.height + 1
-			e := b.headerList.PushBack(&node)
-			if b.startHeader == nil {
-				b.startHeader = e
-			}
-		} else {
-			bmgrLog.Warnf("Received block header that does not "+
-				"properly connect to the chain from peer %s "+
-				"-- disconnecting", hmsg.peer.Addr())
-			hmsg.peer.Disconnect()
-			return
-		}
+	// Request the next batch of headers of the segment starting from the
+	// latest received header when the checkpoint was not reached yet.
+	// Otherwise, assign another segment to the peer.
+	if complete {
+		bmgrLog.Infof("Verified downloaded block header against "+
+			"checkpoint at height %d/hash %s", seg.checkpoint.Height,
+			seg.checkpoint.Hash)
+	} else {
+		b.requestHeaderSegment(hmsg.peer, seg)
+	}
 
-		// Verify the header at the next checkpoint height matches.
-		if node.height == b.nextCheckpoint.Height {
-			if node.hash.IsEqual(b.nextCheckpoint.Hash) {
-				receivedCheckpoint = true
-				bmgrLog.Infof("Verified downloaded block "+
-					"header against checkpoint at height "+
-					"%d/hash %s", node.height, node.hash)
-			} else {
-				bmgrLog.Warnf("Block header at height %d/hash "+
-					"%s from peer %s does NOT match "+
-					"expected checkpoint hash of %s -- "+
-					"disconnecting", node.height,
-					node.hash, hmsg.peer.Addr(),
-					b.nextCheckpoint.Hash)
-				hmsg.peer.Disconnect()
-				return
-			}
-			break
+	// Fetch the blocks for the headers of the segments which are complete
+	// and follow all of the headers that were already added to the list of
+	// headers.
+	nodes := b.headerSync.TakeComplete()
+	for _, node := range nodes {
+		e := b.headerList.PushBack(node)
+		if b.startHeader == nil {
+			b.startHeader = e
 		}
 	}
-
-	// When this header is a checkpoint, switch to fetching the blocks for
-	// all of the headers since the last checkpoint.
-	if receivedCheckpoint {
-		// Since the first entry of the list is always the final block
-		// that is already in the database and is only used to ensure
-		// the next header links properly, it must be removed before
-		// fetching the blocks.
-		b.headerList.Remove(b.headerList.Front())
+	if len(nodes) > 0 {
 		bmgrLog.Infof("Received %v block headers: Fetching blocks",
-			b.headerList.Len())
+			len(nodes))
 		b.progressLogger.SetLastLogTime(time.Now())
+	}
+	b.fetchHeadersFirstData()
+}
+
+// finalCheckpointHeight returns the height of the final checkpoint the headers
+// are downloaded up to during headers-first mode.
+func (b *blockManager) finalCheckpointHeight() int64 {
+	checkpoints := b.chain.Checkpoints()
+	if len(checkpoints) == 0 {
+		return 0
+	}
+	return checkpoints[len(checkpoints)-1].Height
+}
+
+// fetchHeadersFirstData requests the segments of headers which are not
+// assigned to any peer along with the next blocks for the downloaded headers
+// from the sync peers during headers-first mode.
+func (b *blockManager) fetchHeadersFirstData() {
+	b.fetchHeaderSegments()
+	if b.startHeader != nil || b.syncScheduler.HasRetries() {
 		b.fetchHeaderBlocks()
-		return
 	}
+}
+
+// fetchHeaderSegments assigns the segments of headers which are not assigned
+// to any peer to the sync peers which are not assigned one and requests the
+// headers of each segment from the peer it is assigned to.  A segment is only
+// assigned to the sync peer or a peer which is expected to have the headers up
+// to the checkpoint at its end, and peers deprioritized by the peer filter
+// rules are only chosen when there are no others.
+func (b *blockManager) fetchHeaderSegments() {
+	now := time.Now()
+	peers := b.syncScheduler.Peers()
+	for _, seg := range b.headerSync.Unassigned() {
+		var bestPeer *serverPeer
+		for _, sp := range peers {
+			if !b.headerSync.MayAssign(sp) {
+				continue
+			}
+			if sp != b.syncPeer && sp.LastBlock() < seg.checkpoint.Height {
+				continue
+			}
+			if bestPeer == nil || (bestPeer.deprioritized &&
+				!sp.deprioritized) {
+				bestPeer = sp
+			}
+		}
+		if bestPeer == nil {
+			continue
+		}
+
+		b.headerSync.Assign(seg, bestPeer, now)
+		b.requestHeaderSegment(bestPeer, seg)
+	}
+}
 
-	// This header is not a checkpoint, so request the next batch of
-	// headers starting from the latest known header and ending with the
-	// next checkpoint.
-	locator := blockchain.BlockLocator([]*chainhash.Hash{finalHash})
-	err := hmsg.peer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
+// requestHeaderSegment requests the next batch of headers of the passed segment
+// starting from the latest downloaded header up to the checkpoint at its end
+// from the passed peer.
+func (b *blockManager) requestHeaderSegment(sp *serverPeer, seg *headerSegment) {
+	tipHash, tipHeight := seg.tip()
+	locator := blockchain.BlockLocator([]*chainhash.Hash{tipHash})
+	err := sp.PushGetHeadersMsg(locator, seg.checkpoint.Hash)
 	if err != nil {
-		bmgrLog.Warnf("Failed to send getheaders message to "+
-			"peer %s: %v", hmsg.peer.Addr(), err)
+		bmgrLog.Warnf("Failed to send getheaders message to peer %s: %v",
+			sp.Addr(), err)
 		return
 	}
+	bmgrLog.Debugf("Downloading headers for blocks %d to %d from peer %s",
+		tipHeight+1, seg.checkpoint.Height, sp.Addr())
+}
+
+// handleSyncStalls detects the sync peers which stalled the download of the
+// headers or blocks assigned to them during headers-first mode.  The headers
+// of stalled peers are reassigned to the other peers and stalled peers are
+// disconnected when there are other sync peers so their blocks are reassigned
+// as well.
+func (b *blockManager) handleSyncStalls() {
+	if !b.headersFirstMode {
+		return
+	}
+
+	now := time.Now()
+	numPeers := len(b.syncScheduler.Peers())
+	stalled := make(map[*serverPeer]struct{})
+	for _, sp := range b.headerSync.Stalled(now) {
+		bmgrLog.Debugf("Peer %s did not deliver the requested headers "+
+			"before the stall timeout", sp)
+		stalled[sp] = struct{}{}
+	}
+	for _, sp := range b.syncScheduler.Stalled(now) {
+		bmgrLog.Debugf("Peer %s did not deliver any requested blocks "+
+			"before the stall timeout", sp)
+		stalled[sp] = struct{}{}
+	}
+	if numPeers > 1 {
+		for sp := range stalled {
+			bmgrLog.Infof("Peer %s stalled the sync -- disconnecting",
+				sp.Addr())
+			sp.Disconnect()
+		}
+	}
+	b.fetchHeaderSegments()
 }
 
 // haveInventory returns whether or not the inventory represented by the passed
@@ -1613,12 +2260,24 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 			if iv.Type == wire.InvTypeTx {
 				// Skip the transaction if it has already been
 				// rejected.
-				if _, exists := b.rejectedTxns[iv.Hash]; exists {
+				if b.server.txMemPool.HaveRejectedHash(&iv.Hash) {
+					continue
+				}
+			}
+			if iv.Type == wire.InvTypeBlock {
+				// Skip the block if it is already known to be
+				// invalid.
+				if b.chain.IsKnownInvalidBlock(&iv.Hash) {
+					bmgrLog.Debugf("Ignoring announcement of "+
+						"known invalid block %v from %s",
+						iv.Hash, imsg.peer)
 					continue
 				}
 			}
 
-			// Add it to the request queue.
+			// Record the announcement in the relay history and
+			// add it to the request queue.
+			b.relayHistory.Announced(iv, imsg.peer, time.Now())
 			imsg.peer.requestQueue = append(imsg.peer.requestQueue, iv)
 			continue
 		}
@@ -1685,20 +2344,43 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 		switch iv.Type {
 		case wire.InvTypeBlock:
 			// Request the block if there is not already a pending
-			// request.
+			// request.  New blocks are requested as compact blocks
+			// from peers which support them once the chain is
+			// current since their transactions are most likely in
+			// the memory pool already.
 			if _, exists := b.requestedBlocks[iv.Hash]; !exists {
 				b.requestedBlocks[iv.Hash] = struct{}{}
 				b.requestedEverBlocks[iv.Hash] = 0
 				b.limitMap(b.requestedBlocks, maxRequestedBlocks)
 				imsg.peer.requestedBlocks[iv.Hash] = struct{}{}
+				if b.current() && !cfg.BlocksOnly &&
+					imsg.peer.SupportsCmpctBlocks() {
+
+					iv = wire.NewInvVect(wire.InvTypeCmpctBlock,
+						&iv.Hash)
+				}
 				gdmsg.AddInvVect(iv)
 				numRequested++
 			}
 
 		case wire.InvTypeTx:
 			// Request the transaction if there is not already a
-			// pending request.
-			if _, exists := b.requestedTxns[iv.Hash]; !exists {
+			// pending request from another peer and the peer has
+			// not reached its limits on requests.  The peer is
+			// otherwise remembered as a fallback in case the
+			// transaction is not delivered.  The request of the
+			// transaction which is no longer tracked to make room
+			// for it, if any, is forgotten.
+			request, evicted := b.txRequests.Announce(&iv.Hash,
+				imsg.peer, time.Now())
+			if evicted != nil {
+				delete(b.requestedTxns, evicted.hash)
+				if evicted.peer != nil {
+					delete(evicted.peer.requestedTxns,
+						evicted.hash)
+				}
+			}
+			if request {
 				b.requestedTxns[iv.Hash] = struct{}{}
 				b.requestedEverTxns[iv.Hash] = 0
 				b.limitMap(b.requestedTxns, maxRequestedTxns)
@@ -1718,6 +2400,86 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 	}
 }
 
+// handleNotFoundMsg handles notfound messages from all peers.  Transactions
+// which were requested from the peer are requested from the other peers that
+// announced them.
+func (b *blockManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
+	var numNotFound, numBlocksNotFound int
+	for _, iv := range nfmsg.notFound.InvList {
+		// Reassign blocks the peer does not have which were assigned to
+		// it during headers-first mode to the other peers.
+		if iv.Type == wire.InvTypeBlock {
+			if b.syncScheduler.NotFound(nfmsg.peer, &iv.Hash) {
+				delete(nfmsg.peer.requestedBlocks, iv.Hash)
+				delete(b.requestedBlocks, iv.Hash)
+				numBlocksNotFound++
+			}
+			continue
+		}
+		if iv.Type != wire.InvTypeTx {
+			continue
+		}
+
+		delete(nfmsg.peer.requestedPackages, iv.Hash)
+		if b.txRequests.NotFound(&iv.Hash, nfmsg.peer) {
+			delete(nfmsg.peer.requestedTxns, iv.Hash)
+			delete(b.requestedTxns, iv.Hash)
+			numNotFound++
+		}
+	}
+
+	if numNotFound > 0 {
+		bmgrLog.Debugf("Peer %s does not have %d requested "+
+			"transaction(s)", nfmsg.peer, numNotFound)
+		b.requestPendingTxns()
+	}
+	if numBlocksNotFound > 0 {
+		bmgrLog.Debugf("Peer %s does not have %d requested block(s)",
+			nfmsg.peer, numBlocksNotFound)
+		if b.headersFirstMode {
+			b.fetchHeaderBlocks()
+		}
+	}
+}
+
+// handleTxRequestTimeouts requests the transactions which were not delivered
+// before their requests timed out from the other peers that announced them.
+func (b *blockManager) handleTxRequestTimeouts() {
+	for sp, hashes := range b.txRequests.Expire(time.Now()) {
+		bmgrLog.Debugf("Peer %s did not deliver %d requested "+
+			"transaction(s) before the request timeout", sp,
+			len(hashes))
+		for i := range hashes {
+			delete(sp.requestedTxns, hashes[i])
+			delete(b.requestedTxns, hashes[i])
+		}
+	}
+	b.requestPendingTxns()
+}
+
+// requestPendingTxns requests the transactions which are not currently
+// requested from any peer from the next peer that announced them.  The
+// transactions which no remaining peers announced are forgotten so they will be
+// requested again when they are next announced.
+func (b *blockManager) requestPendingTxns() {
+	assigned, dropped := b.txRequests.Reassign(time.Now())
+	for i := range dropped {
+		delete(b.requestedTxns, dropped[i])
+	}
+
+	for sp, hashes := range assigned {
+		gdmsg := wire.NewMsgGetData()
+		for i := range hashes {
+			hash := &hashes[i]
+			b.requestedTxns[*hash] = struct{}{}
+			b.limitMap(b.requestedTxns, maxRequestedTxns)
+			sp.requestedTxns[*hash] = struct{}{}
+			gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeTx, hash))
+		}
+		sp.QueueMessage(gdmsg, nil)
+	}
+}
+
 // limitMap is a helper function for maps that require a maximum limit by
 // evicting a random transaction if adding a new value would cause it to
 // overflow the maximum allowed.
@@ -1736,6 +2498,63 @@ func (b *blockManager) limitMap(m map[chainhash.Hash]struct{}, limit int) {
 	}
 }
 
+// reorganizedChainState updates the chain state after the main chain was
+// reorganized outside of the normal processing of blocks, such as when it is
+// forced to reorganize or blocks are manually invalidated.
+//
+// This function MUST be called from the block handler goroutine.
+func (b *blockManager) reorganizedChainState() {
+	// Query the db for the latest best block since the chain was
+	// reorganized.
+	best := b.chain.BestSnapshot()
+
+	// Fetch the required lottery data.
+	winningTickets, poolSize, finalState, err :=
+		b.chain.LotteryDataForBlock(best.Hash)
+
+	// Update registered websocket clients on the
+	// current stake difficulty.
+	nextStakeDiff, errSDiff :=
+		b.chain.CalcNextRequiredStakeDifficulty()
+	if err != nil {
+		bmgrLog.Warnf("Failed to get next stake difficulty "+
+			"calculation: %v", err)
+	}
+	r := b.server.rpcServer
+	if r != nil && errSDiff == nil {
+		r.ntfnMgr.NotifyStakeDifficulty(
+			&StakeDifficultyNtfnData{
+				*best.Hash,
+				best.Height,
+				nextStakeDiff,
+				b.chain.BestBlockHeader().SBits,
+			})
+		b.server.txMemPool.PruneStakeTx(nextStakeDiff,
+			best.Height)
+		b.server.txMemPool.PruneExpiredTx(best.Height)
+	}
+
+	missedTickets, err := b.chain.MissedTickets()
+	if err != nil {
+		bmgrLog.Warnf("Failed to get missed tickets"+
+			": %v", err)
+	}
+
+	// The blockchain should be updated, so fetch the
+	// latest snapshot.
+	best = b.chain.BestSnapshot()
+	curBlockHeader := b.chain.BestBlockHeader()
+
+	b.updateChainState(best.Hash,
+		best.Height,
+		finalState,
+		uint32(poolSize),
+		nextStakeDiff,
+		winningTickets,
+		missedTickets,
+		*curBlockHeader)
+}
+
 // blockHandler is the main handler for the block manager.  It must be run
 // as a goroutine.  It processes block and inv messages in a separate goroutine
 // from the peer handlers so the block (MsgBlock) messages are handled by a
@@ -1743,7 +2562,13 @@ func (b *blockManager) limitMap(m map[chainhash.Hash]struct{}, limit int) {
 // important because the block manager controls which blocks are needed and how
 // the fetching should proceed.
 func (b *blockManager) blockHandler() {
+	defer recoverCrash("BMGR", b.chain)
+
 	candidatePeers := list.New()
+	txRequestTicker := time.NewTicker(txRequestCheckInterval)
+	defer txRequestTicker.Stop()
+	syncStallTicker := time.NewTicker(syncStallCheckInterval)
+	defer syncStallTicker.Stop()
 out:
 	for {
 		select {
@@ -1756,8 +2581,24 @@ out:
 				b.handleTxMsg(msg)
 				msg.peer.txProcessed <- struct{}{}
 
+			case *packageMsg:
+				b.handlePackageMsg(msg)
+				msg.peer.txProcessed <- struct{}{}
+
+			case *notFoundMsg:
+				b.handleNotFoundMsg(msg)
+
 			case *blockMsg:
 				b.handleBlockMsg(msg)
+				b.processHeldBlocks()
+				msg.peer.blockProcessed <- struct{}{}
+
+			case *cmpctBlockMsg:
+				b.handleCmpctBlockMsg(msg)
+				msg.peer.blockProcessed <- struct{}{}
+
+			case *blockTxnMsg:
+				b.handleBlockTxnMsg(msg)
 				msg.peer.blockProcessed <- struct{}{}
 
 			case *invMsg:
@@ -1772,6 +2613,9 @@ out:
 			case getSyncPeerMsg:
 				msg.reply <- b.syncPeer
 
+			case getSyncStatusMsg:
+				msg.reply <- b.syncStatus()
+
 			case requestFromPeerMsg:
 				err := b.requestFromPeer(msg.peer, msg.blocks, msg.txs)
 				msg.reply <- requestFromPeerResponse{
@@ -1814,58 +2658,27 @@ out:
 				// Reorganizing has succeeded, so we need to
 				// update the chain state.
 				if err == nil {
-					// Query the db for the latest best block since
-					// the block that was processed could be on a
-					// side chain or have caused a reorg.
-					best := b.chain.BestSnapshot()
-
-					// Fetch the required lottery data.
-					winningTickets, poolSize, finalState, err :=
-						b.chain.LotteryDataForBlock(best.Hash)
-
-					// Update registered websocket clients on the
-					// current stake difficulty.
-					nextStakeDiff, errSDiff :=
-						b.chain.CalcNextRequiredStakeDifficulty()
-					if err != nil {
-						bmgrLog.Warnf("Failed to get next stake difficulty "+
-							"calculation: %v", err)
-					}
-					r := b.server.rpcServer
-					if r != nil && errSDiff == nil {
-						r.ntfnMgr.NotifyStakeDifficulty(
-							&StakeDifficultyNtfnData{
-								*best.Hash,
-								best.Height,
-								nextStakeDiff,
-							})
-						b.server.txMemPool.PruneStakeTx(nextStakeDiff,
-							best.Height)
-						b.server.txMemPool.PruneExpiredTx(best.Height)
-					}
-
-					missedTickets, err := b.chain.MissedTickets()
-					if err != nil {
-						bmgrLog.Warnf("Failed to get missed tickets"+
-							": %v", err)
-					}
+					b.reorganizedChainState()
+				}
 
-					// The blockchain should be updated, so fetch the
-					// latest snapshot.
-					best = b.chain.BestSnapshot()
-					curBlockHeader := b.chain.BestBlockHeader()
+				msg.reply <- forceReorganizationResponse{
+					err: err,
+				}
 
-					b.updateChainState(best.Hash,
-						best.Height,
-						finalState,
-						uint32(poolSize),
-						nextStakeDiff,
-						winningTickets,
-						missedTickets,
-						*curBlockHeader)
+			case invalidateBlockMsg:
+				var err error
+				if msg.reconsider {
+					err = b.chain.ReconsiderBlock(&msg.hash)
+				} else {
+					err = b.chain.InvalidateBlock(&msg.hash)
 				}
 
-				msg.reply <- forceReorganizationResponse{
+				// The chain may have been reorganized even when
+				// an error is returned, so always update the
+				// chain state.
+				b.reorganizedChainState()
+
+				msg.reply <- invalidateBlockResponse{
 					err: err,
 				}
 
@@ -1971,6 +2784,7 @@ out:
 									*best.Hash,
 									best.Height,
 									nextStakeDiff,
+									b.chain.BestBlockHeader().SBits,
 								})
 						}
 					}
@@ -2003,14 +2817,15 @@ out:
 						winningTickets,
 						missedTickets,
 						*curBlockHeader)
-				}
 
-				// Allow any clients performing long polling via the
-				// getblocktemplate RPC to be notified when the new block causes
-				// their old block template to become stale.
-				rpcServer := b.server.rpcServer
-				if rpcServer != nil {
-					rpcServer.gbtWorkState.NotifyBlockConnected(msg.block.Hash())
+					// Allow any clients performing long polling via
+					// the getblocktemplate RPC or registered for work
+					// notifications to be notified when the new tip
+					// causes their old work to become stale.
+					rpcServer := b.server.rpcServer
+					if rpcServer != nil {
+						rpcServer.NotifyNewTip(best.Hash, best.Height)
+					}
 				}
 
 				msg.reply <- processBlockResponse{
@@ -2058,6 +2873,12 @@ out:
 					"handler: %T", msg)
 			}
 
+		case <-txRequestTicker.C:
+			b.handleTxRequestTimeouts()
+
+		case <-syncStallTicker.C:
+			b.handleSyncStalls()
+
 		case <-b.quit:
 			break out
 		}
@@ -2067,6 +2888,17 @@ out:
 	bmgrLog.Trace("Block handler done")
 }
 
+// notifyConflictRemovals notifies websocket clients of the transactions which
+// were removed from the memory pool because they conflict with transactions in
+// a block.
+func (b *blockManager) notifyConflictRemovals(removals []*mempool.ConflictRemoval) {
+	if r := b.server.rpcServer; r != nil {
+		for _, removal := range removals {
+			r.ntfnMgr.NotifyTxConflict(removal)
+		}
+	}
+}
+
 // handleNotifyMsg handles notifications from blockchain.  It does things such
 // as request orphan block parents and relay accepted blocks to connected peers.
 func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
@@ -2134,9 +2966,15 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			}
 		}
 
+		// Notify registered websocket clients of blocks which extend a
+		// side chain so forks can be monitored as they are mined.
+		if !band.OnMainChain && r != nil {
+			r.ntfnMgr.NotifySideChainBlockConnected(band)
+		}
+
 		// Generate the inventory vector and relay it.
 		iv := wire.NewInvVect(wire.InvTypeBlock, block.Hash())
-		b.server.RelayInventory(iv, block.MsgBlock().Header)
+		b.server.RelayInventory(iv, block)
 
 	// A block has been connected to the main block chain.
 	case blockchain.NTBlockConnected:
@@ -2154,6 +2992,11 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		block := blockSlice[0]
 		parentBlock := blockSlice[1]
 
+		// Record the confirmation times of the transactions of the
+		// block for fee estimation before they are removed from the
+		// memory pool.
+		b.server.processFeeEstimationBlock(block)
+
 		// Check and see if the regular tx tree of the previous block was
 		// invalid or not. If it wasn't, then we need to restore all the tx
 		// from this block into the mempool. They may end up being spent in
@@ -2188,7 +3031,8 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		// tree coinbase transactions in it.
 		for _, tx := range parentBlock.Transactions()[1:] {
 			b.server.txMemPool.RemoveTransaction(tx, false)
-			b.server.txMemPool.RemoveDoubleSpends(tx)
+			removals := b.server.txMemPool.RemoveDoubleSpends(tx)
+			b.notifyConflictRemovals(removals)
 			b.server.txMemPool.RemoveOrphan(tx.Hash())
 			acceptedTxs := b.server.txMemPool.ProcessOrphans(tx.Hash())
 			b.server.AnnounceNewTransactions(acceptedTxs)
@@ -2196,7 +3040,8 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 
 		for _, stx := range block.STransactions()[0:] {
 			b.server.txMemPool.RemoveTransaction(stx, false)
-			b.server.txMemPool.RemoveDoubleSpends(stx)
+			removals := b.server.txMemPool.RemoveDoubleSpends(stx)
+			b.notifyConflictRemovals(removals)
 			b.server.txMemPool.RemoveOrphan(stx.Hash())
 			acceptedTxs := b.server.txMemPool.ProcessOrphans(stx.Hash())
 			b.server.AnnounceNewTransactions(acceptedTxs)
@@ -2231,6 +3076,19 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		}
 
 		if r := b.server.rpcServer; r != nil {
+			// Record the likely reason for any missed tickets when
+			// enabled.
+			if r.missedTickets != nil && len(tnd.TicketsMissed) > 0 {
+				block, err := b.chain.BlockByHash(&tnd.Hash)
+				if err != nil {
+					bmgrLog.Warnf("Unable to record missed tickets "+
+						"for block %v: %v", tnd.Hash, err)
+				} else {
+					prevHash := &block.MsgBlock().Header.PrevBlock
+					r.missedTickets.RecordMissedTickets(tnd, prevHash)
+				}
+			}
+
 			r.ntfnMgr.NotifySpentAndMissedTickets(tnd)
 		}
 
@@ -2247,6 +3105,19 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			r.ntfnMgr.NotifySpentAndMissedTickets(tnd)
 		}
 
+	// A block connected to the main chain crossed a stake boundary.
+	case blockchain.NTStakeBoundary:
+		sbnd, ok := notification.Data.(*blockchain.StakeBoundaryNtfnsData)
+		if !ok {
+			bmgrLog.Warnf("Stake boundary notification is not " +
+				"StakeBoundaryNtfnsData")
+			break
+		}
+
+		if r := b.server.rpcServer; r != nil {
+			r.ntfnMgr.NotifyStakeBoundary(sbnd)
+		}
+
 	// A block has been disconnected from the main block chain.
 	case blockchain.NTBlockDisconnected:
 		blockSlice, ok := notification.Data.([]*dcrutil.Block)
@@ -2272,7 +3143,8 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		if !txTreeRegularValid {
 			for _, tx := range parentBlock.Transactions()[1:] {
 				b.server.txMemPool.RemoveTransaction(tx, false)
-				b.server.txMemPool.RemoveDoubleSpends(tx)
+				removals := b.server.txMemPool.RemoveDoubleSpends(tx)
+				b.notifyConflictRemovals(removals)
 				b.server.txMemPool.RemoveOrphan(tx.Hash())
 				b.server.txMemPool.ProcessOrphans(tx.Hash())
 			}
@@ -2345,6 +3217,18 @@ func (b *blockManager) QueueTx(tx *dcrutil.Tx, sp *serverPeer) {
 	b.msgChan <- &txMsg{tx: tx, peer: sp}
 }
 
+// QueuePackage adds the passed package of transactions and peer to the block
+// handling queue.
+func (b *blockManager) QueuePackage(txns []*dcrutil.Tx, sp *serverPeer) {
+	// Don't accept more transactions if we're shutting down.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		sp.txProcessed <- struct{}{}
+		return
+	}
+
+	b.msgChan <- &packageMsg{txns: txns, peer: sp}
+}
+
 // QueueBlock adds the passed block message and peer to the block handling queue.
 func (b *blockManager) QueueBlock(block *dcrutil.Block, sp *serverPeer) {
 	// Don't accept more blocks if we're shutting down.
@@ -2356,6 +3240,30 @@ func (b *blockManager) QueueBlock(block *dcrutil.Block, sp *serverPeer) {
 	b.msgChan <- &blockMsg{block: block, peer: sp}
 }
 
+// QueueCmpctBlock adds the passed cmpctblock message and peer to the block
+// handling queue.
+func (b *blockManager) QueueCmpctBlock(msg *wire.MsgCmpctBlock, sp *serverPeer) {
+	// Don't accept more blocks if we're shutting down.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		sp.blockProcessed <- struct{}{}
+		return
+	}
+
+	b.msgChan <- &cmpctBlockMsg{cmpctBlock: msg, peer: sp}
+}
+
+// QueueBlockTxn adds the passed blocktxn message and peer to the block
+// handling queue.
+func (b *blockManager) QueueBlockTxn(msg *wire.MsgBlockTxn, sp *serverPeer) {
+	// Don't accept more blocks if we're shutting down.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		sp.blockProcessed <- struct{}{}
+		return
+	}
+
+	b.msgChan <- &blockTxnMsg{blockTxn: msg, peer: sp}
+}
+
 // QueueInv adds the passed inv message and peer to the block handling queue.
 func (b *blockManager) QueueInv(inv *wire.MsgInv, sp *serverPeer) {
 	// No channel handling here because peers do not need to block on inv
@@ -2367,6 +3275,18 @@ func (b *blockManager) QueueInv(inv *wire.MsgInv, sp *serverPeer) {
 	b.msgChan <- &invMsg{inv: inv, peer: sp}
 }
 
+// QueueNotFound adds the passed notfound message and peer to the block handling
+// queue.
+func (b *blockManager) QueueNotFound(notFound *wire.MsgNotFound, sp *serverPeer) {
+	// No channel handling here because peers do not need to block on
+	// notfound messages.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		return
+	}
+
+	b.msgChan <- &notFoundMsg{notFound: notFound, peer: sp}
+}
+
 // QueueHeaders adds the passed headers message and peer to the block handling
 // queue.
 func (b *blockManager) QueueHeaders(headers *wire.MsgHeaders, sp *serverPeer) {
@@ -2397,8 +3317,21 @@ func (b *blockManager) Start() {
 	}
 
 	bmgrLog.Trace("Starting block manager")
-	b.wg.Add(1)
+	b.wg.Add(2)
 	go b.blockHandler()
+	go b.migrationHandler()
+}
+
+// migrationHandler runs any pending background database migrations until they
+// are complete or the block manager is shutting down, in which case they are
+// resumed the next time the node is started.  It must be run as a goroutine.
+func (b *blockManager) migrationHandler() {
+	defer recoverCrash("CHAN", b.chain)
+
+	if err := b.chain.RunMigrations(b.quit); err != nil {
+		bmgrLog.Errorf("Background database migration failed: %v", err)
+	}
+	b.wg.Done()
 }
 
 // Stop gracefully shuts down the block manager by stopping all asynchronous
@@ -2413,6 +3346,20 @@ func (b *blockManager) Stop() error {
 	bmgrLog.Infof("Block manager shutting down")
 	close(b.quit)
 	b.wg.Wait()
+
+	// Write the modifications to the utxo set which are still held in
+	// memory to the database now that no more blocks will be processed.
+	if err := b.chain.FlushUtxoCache(); err != nil {
+		bmgrLog.Errorf("Unable to flush the utxo cache: %v", err)
+		return err
+	}
+
+	// Snapshot the ticket treaps of the best node so they are restored
+	// quickly on the next start.  Failing to do so only slows down the next
+	// start, so it is not treated as an error.
+	if err := b.chain.WriteTicketSnapshot(); err != nil {
+		bmgrLog.Warnf("Unable to write the ticket snapshot: %v", err)
+	}
 	return nil
 }
 
@@ -2423,6 +3370,14 @@ func (b *blockManager) SyncPeer() *serverPeer {
 	return <-reply
 }
 
+// SyncStatus returns the state of the download of blocks from the sync peers
+// including the blocks assigned to each peer and its measured throughput.
+func (b *blockManager) SyncStatus() *dcrjson.GetSyncStatusResult {
+	reply := make(chan *dcrjson.GetSyncStatusResult)
+	b.msgChan <- getSyncStatusMsg{reply: reply}
+	return <-reply
+}
+
 // RequestFromPeer allows an outside caller to request blocks or transactions
 // from a peer. The requests are logged in the blockmanager's internal map of
 // requests so they do not later ban the peer for sending the respective data.
@@ -2506,6 +3461,7 @@ func (b *blockManager) requestFromPeer(p *serverPeer, blocks, txs []*chainhash.H
 		p.requestedTxns[*vh] = struct{}{}
 		b.requestedTxns[*vh] = struct{}{}
 		b.requestedEverTxns[*vh] = 0
+		b.txRequests.Requested(vh, p, time.Now())
 	}
 
 	if len(msgResp.InvList) > 0 {
@@ -2594,6 +3550,27 @@ func (b *blockManager) ForceReorganization(formerBest, newBest chainhash.Hash) e
 	return response.err
 }
 
+// InvalidateBlock manually invalidates the passed block and reorganizes the
+// chain away from it as needed.  It is funneled through the block manager
+// since blockchain is not safe for concurrent access.
+func (b *blockManager) InvalidateBlock(hash *chainhash.Hash) error {
+	reply := make(chan invalidateBlockResponse)
+	b.msgChan <- invalidateBlockMsg{hash: *hash, reply: reply}
+	response := <-reply
+	return response.err
+}
+
+// ReconsiderBlock removes the invalidity of the passed block and reorganizes the
+// chain to the chain with the most cumulative work as needed.  It is funneled
+// through the block manager since blockchain is not safe for concurrent access.
+func (b *blockManager) ReconsiderBlock(hash *chainhash.Hash) error {
+	reply := make(chan invalidateBlockResponse)
+	b.msgChan <- invalidateBlockMsg{hash: *hash, reconsider: true,
+		reply: reply}
+	response := <-reply
+	return response.err
+}
+
 // GetGeneration returns the hashes of all the children of a parent for the
 // block hash that is passed to the function. It is funneled through the block
 // manager since blockchain is not safe for concurrent access.
@@ -2706,15 +3683,19 @@ func (b *blockManager) SetParentTemplate(bt *BlockTemplate) {
 func newBlockManager(s *server, indexManager blockchain.IndexManager) (*blockManager, error) {
 	bm := blockManager{
 		server:              s,
-		rejectedTxns:        make(map[chainhash.Hash]struct{}),
 		requestedTxns:       make(map[chainhash.Hash]struct{}),
 		requestedEverTxns:   make(map[chainhash.Hash]uint8),
+		txRequests:          newTxRequestTracker(),
+		relayHistory:        newRelayHistory(),
 		requestedBlocks:     make(map[chainhash.Hash]struct{}),
 		requestedEverBlocks: make(map[chainhash.Hash]uint8),
+		partialBlocks:       make(map[chainhash.Hash]*partialBlock),
 		progressLogger:      newBlockProgressLogger("Processed", bmgrLog),
 		lastBlockLogTime:    time.Now(),
 		msgChan:             make(chan interface{}, cfg.MaxPeers*3),
 		headerList:          list.New(),
+		syncScheduler:       newBlockSyncScheduler(),
+		headerSync:          newHeaderSyncScheduler(s.chainParams.PowLimit),
 		AggressiveMining:    !cfg.NonAggressive,
 		quit:                make(chan struct{}),
 	}
@@ -2722,27 +3703,51 @@ func newBlockManager(s *server, indexManager blockchain.IndexManager) (*blockMan
 	// Create a new block chain instance with the appropriate configuration.
 	var err error
 	bm.chain, err = blockchain.New(&blockchain.Config{
-		DB:            s.db,
-		ChainParams:   s.chainParams,
-		TimeSource:    s.timeSource,
-		Notifications: bm.handleNotifyMsg,
-		SigCache:      s.sigCache,
-		IndexManager:  indexManager,
+		DB:               s.db,
+		ChainParams:      s.chainParams,
+		TimeSource:       s.timeSource,
+		Notifications:    bm.handleNotifyMsg,
+		SigCache:         s.sigCache,
+		IndexManager:     indexManager,
+		Checkpoints:      cfg.checkpoints,
+		AssumeValid:      cfg.assumeValid,
+		UtxoCacheMaxSize: uint64(cfg.UtxoCacheMaxSize) * 1024 * 1024,
+		PruneTarget:      uint64(cfg.Prune) * 1024 * 1024,
+		UtxoCommitments:  cfg.UtxoCommitments,
+
+		ScriptFlagsAdd:    cfg.AddScriptFlags,
+		ScriptFlagsRemove: cfg.RemoveScriptFlags,
+		ScriptFlagsHeight: cfg.ScriptFlagsHeight,
 	})
 	if err != nil {
 		return nil, err
 	}
 	best := bm.chain.BestSnapshot()
-	bm.chain.DisableCheckpoints(cfg.DisableCheckpoints)
-	if !cfg.DisableCheckpoints {
+	bm.chain.SetCheckpointMode(cfg.checkpointMode)
+	switch cfg.checkpointMode {
+	case blockchain.CheckpointModeEnforce:
 		// Initialize the next checkpoint based on the current height.
 		bm.nextCheckpoint = bm.findNextHeaderCheckpoint(best.Height)
 		if bm.nextCheckpoint != nil {
 			bm.resetHeaderState(best.Hash, best.Height)
 		}
-	} else {
+	case blockchain.CheckpointModeAdvisory:
+		bmgrLog.Info("Checkpoints are advisory")
+	default:
 		bmgrLog.Info("Checkpoints are disabled")
 	}
+	if len(cfg.checkpoints) > 0 {
+		bmgrLog.Infof("Loaded %d additional checkpoints from %s",
+			len(cfg.checkpoints), cfg.CheckpointFile)
+	}
+	if cfg.assumeValid != nil {
+		bmgrLog.Infof("Assuming the scripts of block %v at height %d and "+
+			"its ancestors are valid", cfg.assumeValid.Hash,
+			cfg.assumeValid.Height)
+	}
+	if cfg.UtxoCommitments {
+		bmgrLog.Warn("Experimental utxo commitments are enabled")
+	}
 
 	// Dump the blockchain here if asked for it, and quit.
 	if cfg.DumpBlockchain != "" {
@@ -2,4 +2,8 @@
 //
 // This package provides a generic hash type and associated functions that
 // allows the specific hash algorithm to be abstracted.
+//
+// It also provides keyed 64-bit short hashes of hashes, along with map and set
+// types which allow looking hashes up by their short hashes while retaining
+// every hash when short hashes collide.
 package chainhash
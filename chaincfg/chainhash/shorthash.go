@@ -0,0 +1,326 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chainhash
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+)
+
+// ShortHashSize is the maximum size in bytes of a short hash.  Short hashes
+// can be truncated to fewer bytes, such as the 6-byte short transaction ids of
+// compact blocks, to save space at the cost of more frequent collisions.
+const ShortHashSize = 8
+
+// ShortHashKeySize is the size in bytes of a short hash key.
+const ShortHashKeySize = 16
+
+// ShortHashKey is the 128-bit SipHash-2-4 key short hashes are calculated
+// with.  Short hashes are keyed so that others can't craft transactions whose
+// short hashes collide, so the key must either be random or be derived from
+// data nobody can choose freely, such as a block hash along with a nonce.
+type ShortHashKey struct {
+	k0, k1 uint64
+}
+
+// NewShortHashKey returns the short hash key with the passed bytes.  An error
+// is returned if the number of bytes passed in is not ShortHashKeySize.
+func NewShortHashKey(b []byte) (ShortHashKey, error) {
+	if len(b) != ShortHashKeySize {
+		return ShortHashKey{}, fmt.Errorf("invalid short hash key "+
+			"length of %v, want %v", len(b), ShortHashKeySize)
+	}
+	return ShortHashKey{
+		k0: binary.LittleEndian.Uint64(b[0:8]),
+		k1: binary.LittleEndian.Uint64(b[8:16]),
+	}, nil
+}
+
+// ShortHashKeyFromHash returns the short hash key with the first
+// ShortHashKeySize bytes of the passed hash.
+func ShortHashKeyFromHash(hash *Hash) ShortHashKey {
+	key, _ := NewShortHashKey(hash[:ShortHashKeySize])
+	return key
+}
+
+// RandomShortHashKey returns a short hash key read from the cryptographically
+// secure random number generator, which suits short hashes that are only used
+// locally.
+func RandomShortHashKey() (ShortHashKey, error) {
+	var b [ShortHashKeySize]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ShortHashKey{}, err
+	}
+	return NewShortHashKey(b[:])
+}
+
+// Bytes returns the bytes which represent the short hash key.
+func (k ShortHashKey) Bytes() []byte {
+	b := make([]byte, ShortHashKeySize)
+	binary.LittleEndian.PutUint64(b[0:8], k.k0)
+	binary.LittleEndian.PutUint64(b[8:16], k.k1)
+	return b
+}
+
+// ShortHashB returns the short hash of the passed bytes.
+func (k ShortHashKey) ShortHashB(b []byte) uint64 {
+	return sipHash24(k.k0, k.k1, b)
+}
+
+// ShortHash returns the short hash of the passed hash.
+func (k ShortHashKey) ShortHash(hash *Hash) uint64 {
+	return sipHash24(k.k0, k.k1, hash[:])
+}
+
+// TruncatedShortHash returns the short hash of the passed hash truncated to its
+// least significant size bytes, which is the encoding of its first size bytes
+// in little endian.  The size must be between 1 and ShortHashSize.
+func (k ShortHashKey) TruncatedShortHash(hash *Hash, size int) uint64 {
+	return truncateShortHash(k.ShortHash(hash), size)
+}
+
+// truncateShortHash returns the least significant size bytes of the passed
+// short hash.  It panics when the size is not between 1 and ShortHashSize since
+// that is a programming error.
+func truncateShortHash(shortHash uint64, size int) uint64 {
+	if size < 1 || size > ShortHashSize {
+		panic(fmt.Sprintf("invalid short hash size of %d", size))
+	}
+	if size == ShortHashSize {
+		return shortHash
+	}
+	return shortHash & (1<<(8*uint(size)) - 1)
+}
+
+// shortHashEntry houses a hash in a ShortHashMap along with its value.
+type shortHashEntry struct {
+	hash  Hash
+	value interface{}
+}
+
+// ShortHashMap maps hashes to values and allows looking them up by their short
+// hashes as well, as needed to resolve the short transaction ids of compact
+// blocks.  All hashes whose short hashes collide are retained, and looking up
+// a short hash which collides reports the collision rather than returning one
+// of the hashes arbitrarily.
+//
+// NOTE: The map is not safe for concurrent access.
+type ShortHashMap struct {
+	key     ShortHashKey
+	size    int
+	entries map[uint64]shortHashEntry
+
+	// shortHash calculates the short hashes of the hashes in the map.  It
+	// is only replaced by tests which need to force collisions.
+	shortHash func(hash *Hash) uint64
+
+	// collisions houses the entries of all short hashes which are shared
+	// by multiple hashes.  Those entries are not in entries, so the
+	// uncommon collisions don't cost the map any memory otherwise.
+	collisions map[uint64][]shortHashEntry
+	numEntries int
+}
+
+// NewShortHashMap returns a new empty map which calculates the short hashes of
+// its hashes with the passed key truncated to the passed size in bytes, which
+// must be between 1 and ShortHashSize.  The size hint is the number of hashes
+// the map is expected to hold.
+func NewShortHashMap(key ShortHashKey, size int, sizeHint int) *ShortHashMap {
+	// Ensure the size is valid up front rather than on the first use.
+	truncateShortHash(0, size)
+
+	return &ShortHashMap{
+		key:     key,
+		size:    size,
+		entries: make(map[uint64]shortHashEntry, sizeHint),
+		shortHash: func(hash *Hash) uint64 {
+			return key.TruncatedShortHash(hash, size)
+		},
+		collisions: make(map[uint64][]shortHashEntry),
+	}
+}
+
+// Key returns the key the map calculates short hashes with.
+func (m *ShortHashMap) Key() ShortHashKey {
+	return m.key
+}
+
+// Size returns the size in bytes the map truncates short hashes to.
+func (m *ShortHashMap) Size() int {
+	return m.size
+}
+
+// Len returns the number of hashes in the map.
+func (m *ShortHashMap) Len() int {
+	return m.numEntries
+}
+
+// Put maps the passed hash to the passed value, replacing its previous value
+// if the hash is already in the map.
+func (m *ShortHashMap) Put(hash *Hash, value interface{}) {
+	shortHash := m.shortHash(hash)
+	if colliding, ok := m.collisions[shortHash]; ok {
+		for i := range colliding {
+			if colliding[i].hash == *hash {
+				colliding[i].value = value
+				return
+			}
+		}
+		m.collisions[shortHash] = append(colliding,
+			shortHashEntry{*hash, value})
+		m.numEntries++
+		return
+	}
+
+	entry, ok := m.entries[shortHash]
+	switch {
+	case !ok:
+		m.entries[shortHash] = shortHashEntry{*hash, value}
+	case entry.hash == *hash:
+		m.entries[shortHash] = shortHashEntry{*hash, value}
+		return
+	default:
+		delete(m.entries, shortHash)
+		m.collisions[shortHash] = []shortHashEntry{entry,
+			{*hash, value}}
+	}
+	m.numEntries++
+}
+
+// Get returns the value the passed hash maps to and whether the hash is in the
+// map.
+func (m *ShortHashMap) Get(hash *Hash) (interface{}, bool) {
+	shortHash := m.shortHash(hash)
+	if entry, ok := m.entries[shortHash]; ok {
+		if entry.hash == *hash {
+			return entry.value, true
+		}
+		return nil, false
+	}
+	for _, entry := range m.collisions[shortHash] {
+		if entry.hash == *hash {
+			return entry.value, true
+		}
+	}
+	return nil, false
+}
+
+// Has returns whether the passed hash is in the map.
+func (m *ShortHashMap) Has(hash *Hash) bool {
+	_, ok := m.Get(hash)
+	return ok
+}
+
+// Delete removes the passed hash from the map.  It has no effect if the hash is
+// not in the map.
+func (m *ShortHashMap) Delete(hash *Hash) {
+	shortHash := m.shortHash(hash)
+	if entry, ok := m.entries[shortHash]; ok {
+		if entry.hash == *hash {
+			delete(m.entries, shortHash)
+			m.numEntries--
+		}
+		return
+	}
+
+	colliding := m.collisions[shortHash]
+	for i := range colliding {
+		if colliding[i].hash != *hash {
+			continue
+		}
+
+		// The short hash no longer collides once a single hash remains.
+		m.numEntries--
+		if len(colliding) == 2 {
+			delete(m.collisions, shortHash)
+			m.entries[shortHash] = colliding[1-i]
+			return
+		}
+		colliding[i] = colliding[len(colliding)-1]
+		m.collisions[shortHash] = colliding[:len(colliding)-1]
+		return
+	}
+}
+
+// LookupShort returns the hash with the passed short hash along with its value
+// and the number of hashes in the map which have the short hash.  The hash and
+// value are only returned when that number is exactly one, since it is
+// ambiguous which hash is meant otherwise.
+func (m *ShortHashMap) LookupShort(shortHash uint64) (*Hash, interface{}, int) {
+	if entry, ok := m.entries[shortHash]; ok {
+		return &entry.hash, entry.value, 1
+	}
+	return nil, nil, len(m.collisions[shortHash])
+}
+
+// ForEach calls the passed function with every hash in the map along with its
+// value.  The order is unspecified, and the map must not be modified by the
+// function.
+func (m *ShortHashMap) ForEach(fn func(hash *Hash, value interface{})) {
+	for _, entry := range m.entries {
+		fn(&entry.hash, entry.value)
+	}
+	for _, colliding := range m.collisions {
+		for _, entry := range colliding {
+			fn(&entry.hash, entry.value)
+		}
+	}
+}
+
+// ShortHashSet is a set of hashes which allows looking them up by their short
+// hashes as well.  It provides the same collision handling as ShortHashMap.
+//
+// NOTE: The set is not safe for concurrent access.
+type ShortHashSet struct {
+	m *ShortHashMap
+}
+
+// NewShortHashSet returns a new empty set which calculates the short hashes of
+// its hashes with the passed key truncated to the passed size in bytes, which
+// must be between 1 and ShortHashSize.  The size hint is the number of hashes
+// the set is expected to hold.
+func NewShortHashSet(key ShortHashKey, size int, sizeHint int) *ShortHashSet {
+	return &ShortHashSet{m: NewShortHashMap(key, size, sizeHint)}
+}
+
+// Key returns the key the set calculates short hashes with.
+func (s *ShortHashSet) Key() ShortHashKey {
+	return s.m.Key()
+}
+
+// Size returns the size in bytes the set truncates short hashes to.
+func (s *ShortHashSet) Size() int {
+	return s.m.Size()
+}
+
+// Len returns the number of hashes in the set.
+func (s *ShortHashSet) Len() int {
+	return s.m.Len()
+}
+
+// Add adds the passed hash to the set.
+func (s *ShortHashSet) Add(hash *Hash) {
+	s.m.Put(hash, nil)
+}
+
+// Contains returns whether the passed hash is in the set.
+func (s *ShortHashSet) Contains(hash *Hash) bool {
+	return s.m.Has(hash)
+}
+
+// Remove removes the passed hash from the set.  It has no effect if the hash
+// is not in the set.
+func (s *ShortHashSet) Remove(hash *Hash) {
+	s.m.Delete(hash)
+}
+
+// LookupShort returns the hash with the passed short hash along with the
+// number of hashes in the set which have the short hash.  The hash is only
+// returned when that number is exactly one.
+func (s *ShortHashSet) LookupShort(shortHash uint64) (*Hash, int) {
+	hash, _, n := s.m.LookupShort(shortHash)
+	return hash, n
+}
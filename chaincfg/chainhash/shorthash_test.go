@@ -0,0 +1,199 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chainhash
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestShortHash ensures short hashes are calculated with SipHash-2-4 as
+// specified by the reference test vectors, which use the key 000102...0f and
+// the message 000102...(n-1) of length n.
+func TestShortHash(t *testing.T) {
+	tests := []struct {
+		n    int
+		want uint64
+	}{
+		{0, 0x726fdb47dd0e0e31},
+		{1, 0x74f839c593dc67fd},
+		{7, 0xab0200f58b01d137},
+		{8, 0x93f5f5799a932462},
+		{15, 0xa129ca6149be45e5},
+		{16, 0x3f2acc7f57c29bdb},
+		{31, 0x32d892fad841c342},
+		{32, 0x7127512f72f27cce},
+		{63, 0x958a324ceb064572},
+	}
+
+	keyBytes := make([]byte, ShortHashKeySize)
+	for i := range keyBytes {
+		keyBytes[i] = byte(i)
+	}
+	key, err := NewShortHashKey(keyBytes)
+	if err != nil {
+		t.Fatalf("NewShortHashKey: unexpected error: %v", err)
+	}
+	if !bytes.Equal(key.Bytes(), keyBytes) {
+		t.Fatalf("Bytes: unexpected key bytes - got %x, want %x",
+			key.Bytes(), keyBytes)
+	}
+	if _, err := NewShortHashKey(keyBytes[1:]); err == nil {
+		t.Fatalf("NewShortHashKey: no error for short key")
+	}
+
+	msg := make([]byte, 64)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	for _, test := range tests {
+		got := key.ShortHashB(msg[:test.n])
+		if got != test.want {
+			t.Errorf("ShortHashB #%d: unexpected short hash - got %016x, "+
+				"want %016x", test.n, got, test.want)
+		}
+	}
+
+	// The short hash of a hash is the short hash of its bytes, and a key
+	// derived from a hash uses its first bytes.
+	var hash Hash
+	copy(hash[:], msg)
+	if got, want := key.ShortHash(&hash), key.ShortHashB(hash[:]); got != want {
+		t.Errorf("ShortHash: unexpected short hash - got %016x, want "+
+			"%016x", got, want)
+	}
+	if got := ShortHashKeyFromHash(&hash); got != key {
+		t.Errorf("ShortHashKeyFromHash: unexpected key - got %x, want %x",
+			got.Bytes(), keyBytes)
+	}
+
+	// Truncated short hashes keep the least significant bytes.
+	full := key.ShortHash(&hash)
+	truncated := []struct {
+		size int
+		want uint64
+	}{
+		{1, full & 0xff},
+		{6, full & 0xffffffffffff},
+		{ShortHashSize, full},
+	}
+	for _, test := range truncated {
+		got := key.TruncatedShortHash(&hash, test.size)
+		if got != test.want {
+			t.Errorf("TruncatedShortHash #%d: unexpected short hash - "+
+				"got %016x, want %016x", test.size, got, test.want)
+		}
+	}
+	for _, size := range []int{0, ShortHashSize + 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TruncatedShortHash #%d: no panic for "+
+						"invalid size", size)
+				}
+			}()
+			key.TruncatedShortHash(&hash, size)
+		}()
+	}
+}
+
+// TestShortHashMap ensures hashes can be looked up by their hashes and short
+// hashes, and that colliding short hashes retain every hash and are reported
+// by lookups until a single hash remains.
+func TestShortHashMap(t *testing.T) {
+	key, err := RandomShortHashKey()
+	if err != nil {
+		t.Fatalf("RandomShortHashKey: unexpected error: %v", err)
+	}
+	m := NewShortHashMap(key, ShortHashSize, 0)
+
+	// Force the first two hashes to collide.
+	hashes := []Hash{{0x01}, {0x02}, {0x03}, {0x04}}
+	m.shortHash = func(hash *Hash) uint64 {
+		if hash[0] <= 0x02 {
+			return 0
+		}
+		return uint64(hash[0])
+	}
+	for i := range hashes {
+		m.Put(&hashes[i], i)
+	}
+	m.Put(&hashes[3], 3)
+	if m.Len() != len(hashes) {
+		t.Fatalf("Len: unexpected length - got %d, want %d", m.Len(),
+			len(hashes))
+	}
+	for i := range hashes {
+		value, ok := m.Get(&hashes[i])
+		if !ok || value != i {
+			t.Fatalf("Get: unexpected value for hash %d - got %v, %v",
+				i, value, ok)
+		}
+	}
+	if _, ok := m.Get(&Hash{0x05}); ok {
+		t.Fatalf("Get: found hash which is not in the map")
+	}
+	if hash, _, n := m.LookupShort(0); hash != nil || n != 2 {
+		t.Fatalf("LookupShort: unexpected result for colliding short "+
+			"hash - got %v, %d", hash, n)
+	}
+	hash, value, n := m.LookupShort(0x03)
+	if hash == nil || *hash != hashes[2] || value != 2 || n != 1 {
+		t.Fatalf("LookupShort: unexpected result - got %v, %v, %d",
+			hash, value, n)
+	}
+	var numIterated int
+	m.ForEach(func(hash *Hash, value interface{}) {
+		if *hash != hashes[value.(int)] {
+			t.Fatalf("ForEach: unexpected value %v for hash %v",
+				value, hash)
+		}
+		numIterated++
+	})
+	if numIterated != len(hashes) {
+		t.Fatalf("ForEach: unexpected number of hashes - got %d, want "+
+			"%d", numIterated, len(hashes))
+	}
+
+	// Removing one of the colliding hashes resolves the collision.
+	m.Put(&hashes[1], 10)
+	m.Delete(&hashes[0])
+	m.Delete(&hashes[0])
+	if m.Len() != len(hashes)-1 || m.Has(&hashes[0]) {
+		t.Fatalf("Delete: hash not removed")
+	}
+	hash, value, n = m.LookupShort(0)
+	if hash == nil || *hash != hashes[1] || value != 10 || n != 1 {
+		t.Fatalf("LookupShort: unexpected result after collision was "+
+			"resolved - got %v, %v, %d", hash, value, n)
+	}
+
+	// A set behaves the same, and truncates the short hashes to its size.
+	const size = 6
+	s := NewShortHashSet(key, size, 0)
+	if s.Size() != size {
+		t.Fatalf("Size: unexpected size - got %d, want %d", s.Size(), size)
+	}
+	s.Add(&hashes[0])
+	if !s.Contains(&hashes[0]) || s.Len() != 1 {
+		t.Fatalf("Add: hash not added to set")
+	}
+	if hash, n := s.LookupShort(key.ShortHash(&hashes[0])); hash != nil ||
+		n != 0 {
+
+		t.Fatalf("LookupShort: found hash by untruncated short hash")
+	}
+	shortHash := key.TruncatedShortHash(&hashes[0], size)
+	if hash, n := s.LookupShort(shortHash); hash == nil ||
+		*hash != hashes[0] || n != 1 {
+
+		t.Fatalf("LookupShort: unexpected set result - got %v, %d",
+			hash, n)
+	}
+	s.Remove(&hashes[0])
+	if s.Contains(&hashes[0]) || s.Len() != 0 {
+		t.Fatalf("Remove: hash not removed from set")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chaincfg
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnknownAddrID describes an error where the provided id which is
+	// intended to identify the network of an address is not registered.
+	ErrUnknownAddrID = errors.New("unknown address id")
+
+	// ErrUnknownPrivateKeyID describes an error where the provided id which
+	// is intended to identify the network of a WIF private key is not
+	// registered.
+	ErrUnknownPrivateKeyID = errors.New("unknown private key id")
+
+	// ErrUnknownHDID describes an error where the provided id which is
+	// intended to identify the network of a hierarchical deterministic
+	// private or public extended key is not registered.
+	ErrUnknownHDID = errors.New("unknown hd extended key id")
+)
+
+// WrongNetError describes an error where an address, WIF private key, or
+// extended key which belongs to one network is used on another network.
+type WrongNetError struct {
+	// Kind describes the kind of the serialized data such as "address".
+	Kind string
+
+	// Net is the network the serialized data belongs to.
+	Net *Params
+
+	// Expected is the network the serialized data was used on.
+	Expected *Params
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e WrongNetError) Error() string {
+	return fmt.Sprintf("%s is for %s instead of %s", e.Kind, e.Net.Name,
+		e.Expected.Name)
+}
+
+var (
+	addrIDNets       = make(map[[2]byte]*Params)
+	privateKeyIDNets = make(map[[2]byte]*Params)
+	hdIDNets         = make(map[[4]byte]*Params)
+)
+
+// registerNetIDs registers the passed network as the network of its address,
+// WIF private key, and extended key ids.  Ids which are already registered
+// keep identifying the network they were first registered for.
+func registerNetIDs(params *Params) {
+	addrIDs := [][2]byte{params.PubKeyAddrID, params.PubKeyHashAddrID,
+		params.PKHEdwardsAddrID, params.PKHSchnorrAddrID,
+		params.ScriptHashAddrID}
+	for _, id := range addrIDs {
+		if _, ok := addrIDNets[id]; !ok {
+			addrIDNets[id] = params
+		}
+	}
+	if _, ok := privateKeyIDNets[params.PrivateKeyID]; !ok {
+		privateKeyIDNets[params.PrivateKeyID] = params
+	}
+	hdIDs := [][4]byte{params.HDPrivateKeyID, params.HDPublicKeyID}
+	for _, id := range hdIDs {
+		if _, ok := hdIDNets[id]; !ok {
+			hdIDNets[id] = params
+		}
+	}
+}
+
+// AddrIDNet returns the parameters of the default or registered network the
+// passed id, which makes up the first 2 bytes of an address of any type,
+// identifies.  When the id is not registered, the ErrUnknownAddrID error will
+// be returned.
+func AddrIDNet(id [2]byte) (*Params, error) {
+	params, ok := addrIDNets[id]
+	if !ok {
+		return nil, ErrUnknownAddrID
+	}
+	return params, nil
+}
+
+// PrivateKeyIDNet returns the parameters of the default or registered network
+// the passed id, which makes up the first 2 bytes of a WIF private key,
+// identifies.  When the id is not registered, the ErrUnknownPrivateKeyID error
+// will be returned.
+func PrivateKeyIDNet(id [2]byte) (*Params, error) {
+	params, ok := privateKeyIDNets[id]
+	if !ok {
+		return nil, ErrUnknownPrivateKeyID
+	}
+	return params, nil
+}
+
+// HDIDNet returns the parameters of the default or registered network the
+// passed id, which makes up the first 4 bytes of a private or public
+// hierarchical deterministic extended key, identifies.  When the id is not
+// registered, the ErrUnknownHDID error will be returned.
+func HDIDNet(id [4]byte) (*Params, error) {
+	params, ok := hdIDNets[id]
+	if !ok {
+		return nil, ErrUnknownHDID
+	}
+	return params, nil
+}
+
+// checkNet returns a WrongNetError for the passed kind of serialized data when
+// the network it belongs to is not the expected one.
+func checkNet(kind string, net *Params, err error, expected *Params) error {
+	if err != nil {
+		return err
+	}
+	if net.Net != expected.Net {
+		return WrongNetError{Kind: kind, Net: net, Expected: expected}
+	}
+	return nil
+}
+
+// CheckAddrID ensures the passed id, which makes up the first 2 bytes of an
+// address of any type, identifies the network of the parameters.  A
+// WrongNetError is returned when it identifies another network.
+func (p *Params) CheckAddrID(id [2]byte) error {
+	net, err := AddrIDNet(id)
+	return checkNet("address", net, err, p)
+}
+
+// CheckPrivateKeyID ensures the passed id, which makes up the first 2 bytes of
+// a WIF private key, identifies the network of the parameters.  A WrongNetError
+// is returned when it identifies another network.
+func (p *Params) CheckPrivateKeyID(id [2]byte) error {
+	net, err := PrivateKeyIDNet(id)
+	return checkNet("private key", net, err, p)
+}
+
+// CheckHDID ensures the passed id, which makes up the first 4 bytes of a
+// private or public hierarchical deterministic extended key, identifies the
+// network of the parameters.  A WrongNetError is returned when it identifies
+// another network.
+func (p *Params) CheckHDID(id [4]byte) error {
+	net, err := HDIDNet(id)
+	return checkNet("extended key", net, err, p)
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chaincfg_test
+
+import (
+	"testing"
+
+	. "github.com/decred/dcrd/chaincfg"
+)
+
+// TestNetIDs ensures the networks of address, WIF private key, and extended
+// key ids are identified and used on the expected networks.
+func TestNetIDs(t *testing.T) {
+	nets := []*Params{&MainNetParams, &TestNet2Params, &SimNetParams}
+	for _, params := range nets {
+		addrIDs := [][2]byte{params.PubKeyAddrID, params.PubKeyHashAddrID,
+			params.PKHEdwardsAddrID, params.PKHSchnorrAddrID,
+			params.ScriptHashAddrID}
+		for _, id := range addrIDs {
+			net, err := AddrIDNet(id)
+			if err != nil || net != params {
+				t.Errorf("AddrIDNet(%x): got %v (%v), want %s", id,
+					net, err, params.Name)
+			}
+			if err := params.CheckAddrID(id); err != nil {
+				t.Errorf("%s: CheckAddrID(%x): unexpected error: %v",
+					params.Name, id, err)
+			}
+		}
+
+		net, err := PrivateKeyIDNet(params.PrivateKeyID)
+		if err != nil || net != params {
+			t.Errorf("PrivateKeyIDNet(%x): got %v (%v), want %s",
+				params.PrivateKeyID, net, err, params.Name)
+		}
+
+		for _, id := range [][4]byte{params.HDPrivateKeyID,
+			params.HDPublicKeyID} {
+
+			net, err := HDIDNet(id)
+			if err != nil || net != params {
+				t.Errorf("HDIDNet(%x): got %v (%v), want %s", id, net,
+					err, params.Name)
+			}
+			if err := params.CheckHDID(id); err != nil {
+				t.Errorf("%s: CheckHDID(%x): unexpected error: %v",
+					params.Name, id, err)
+			}
+		}
+	}
+
+	// Ensure unknown ids are rejected.
+	if _, err := AddrIDNet([2]byte{0xff, 0xff}); err != ErrUnknownAddrID {
+		t.Errorf("AddrIDNet: unexpected error for unknown id: %v", err)
+	}
+	_, err := PrivateKeyIDNet([2]byte{0xff, 0xff})
+	if err != ErrUnknownPrivateKeyID {
+		t.Errorf("PrivateKeyIDNet: unexpected error for unknown id: %v",
+			err)
+	}
+	_, err = HDIDNet([4]byte{0xff, 0xff, 0xff, 0xff})
+	if err != ErrUnknownHDID {
+		t.Errorf("HDIDNet: unexpected error for unknown id: %v", err)
+	}
+
+	// Ensure ids of another network are reported as such.
+	err = MainNetParams.CheckAddrID(TestNet2Params.PubKeyHashAddrID)
+	wantErr := WrongNetError{Kind: "address", Net: &TestNet2Params,
+		Expected: &MainNetParams}
+	if err != wantErr {
+		t.Errorf("CheckAddrID: unexpected error - got %v, want %v", err,
+			wantErr)
+	}
+	err = SimNetParams.CheckPrivateKeyID(MainNetParams.PrivateKeyID)
+	if _, ok := err.(WrongNetError); !ok {
+		t.Errorf("CheckPrivateKeyID: unexpected error - got %v, want "+
+			"WrongNetError", err)
+	}
+}
@@ -48,6 +48,11 @@ var (
 // If you believe that any MsgTxs in your daemon will be used mutably, do NOT
 // turn on this feature. It is disabled by default.
 // This feature is considered EXPERIMENTAL, enable at your own risk!
+//
+// The option only governs reusing the cached hash of a transaction and the
+// prefix hashes passed to txscript.CalcSignatureHash.  The signature hashes
+// passed to txscript.NewEngineWithSigHashes are always used since they are
+// calculated for the transaction being validated.
 var SigHashOptimization = false
 
 // CheckForDuplicateHashes checks for duplicate hashes when validating blocks.
@@ -160,6 +165,10 @@ const (
 	// VoteIDMaxBlockSize is the vote ID for the the maximum block size
 	// increase agenda used for the hard fork demo.
 	VoteIDMaxBlockSize = "maxblocksize"
+
+	// VoteIDTreasury is the vote ID for the agenda which redirects the
+	// block tax to a treasury that is controlled by the consensus rules.
+	VoteIDTreasury = "treasury"
 )
 
 // ConsensusDeployment defines details related to a specific consensus rule
@@ -310,6 +319,13 @@ type Params struct {
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints []Checkpoint
 
+	// AssumeValid is the block which, along with all of its ancestors, is
+	// assumed to have valid scripts, so the scripts of those blocks are not
+	// validated.  All other consensus rules are still enforced for them.
+	// It must be buried deeply enough in the main chain to never be
+	// reorganized.  A nil hash disables the assumption.
+	AssumeValid Checkpoint
+
 	// These fields are related to voting on consensus rule changes as
 	// defined by BIP0009.
 	//
@@ -438,6 +454,12 @@ type Params struct {
 	OrganizationPkScript        []byte
 	OrganizationPkScriptVersion uint16
 
+	// TreasuryPubKeys are the serialized public keys which are authorized
+	// to sign transactions that spend from the treasury once the treasury
+	// agenda is active.  The treasury may not be spent from when there are
+	// no authorized keys.
+	TreasuryPubKeys [][]byte
+
 	// BlockOneLedger specifies the list of payouts in the coinbase of
 	// block height 1. If there are no payouts to be given, set this
 	// to an empty slice.
@@ -495,6 +517,9 @@ var MainNetParams = Params{
 		{99880, newHashFromStr("0000000000000cb2a9a9ded647b9f78aae51ace32dd8913701d420ead272913c")},
 	},
 
+	// The assumed valid block is advanced along with the checkpoints.
+	AssumeValid: Checkpoint{99880, newHashFromStr("0000000000000cb2a9a9ded647b9f78aae51ace32dd8913701d420ead272913c")},
+
 	// The miner confirmation window is defined as:
 	//   target proof of work timespan / target proof of work spacing
 	RuleChangeActivationQuorum:     4032, // 10 % of RuleChangeActivationInterval * TicketsPerBlock
@@ -874,6 +899,7 @@ func Register(params *Params) error {
 	pubKeyHashAddrIDs[params.PubKeyHashAddrID] = struct{}{}
 	scriptHashAddrIDs[params.ScriptHashAddrID] = struct{}{}
 	hdPrivToPubKeyIDs[params.HDPrivateKeyID] = params.HDPublicKeyID[:]
+	registerNetIDs(params)
 	return nil
 }
 
@@ -12,6 +12,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/decred/dcrd/dcrjson"
@@ -109,6 +111,47 @@ type config struct {
 	SimNet        bool   `long:"simnet" description:"Connect to the simulation test network"`
 	TLSSkipVerify bool   `long:"skipverify" description:"Do not verify tls certificates (not recommended!)"`
 	Wallet        bool   `long:"wallet" description:"Connect to wallet"`
+
+	RPCFailover []string `long:"rpcfailover" description:"Add an RPC server to fail over to when higher priority servers can't be connected to, optionally followed by a comma and its priority where lower values are preferred (default priority: 1, --rpcserver always has priority 0)"`
+	HealthCheck bool     `long:"healthcheck" description:"Skip RPC servers that do not report themselves as ready via the /ready endpoint"`
+	ShowServer  bool     `long:"showserver" description:"Write the address of the RPC server that served the request to stderr"`
+
+	rpcEndpoints []rpcEndpoint
+}
+
+// rpcEndpoint describes an RPC server along with the priority it is tried in
+// relative to the other configured servers.
+type rpcEndpoint struct {
+	addr     string
+	priority int
+}
+
+// rpcEndpointsByPriority provides sorting of RPC endpoints by priority while
+// retaining the order they were specified in for equal priorities when used
+// with sort.Stable.
+type rpcEndpointsByPriority []rpcEndpoint
+
+func (s rpcEndpointsByPriority) Len() int           { return len(s) }
+func (s rpcEndpointsByPriority) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s rpcEndpointsByPriority) Less(i, j int) bool { return s[i].priority < s[j].priority }
+
+// parseRPCEndpoint parses an RPC failover server of the form
+// host[:port][,priority] while adding the default port as needed.
+func parseRPCEndpoint(failover string, cfg *config) (rpcEndpoint, error) {
+	endpoint := rpcEndpoint{addr: failover, priority: 1}
+	if idx := strings.LastIndex(failover, ","); idx != -1 {
+		priority, err := strconv.Atoi(failover[idx+1:])
+		if err != nil || priority < 0 {
+			return endpoint, fmt.Errorf("invalid priority for RPC "+
+				"failover server %q -- it must be a non-negative "+
+				"integer", failover)
+		}
+		endpoint.addr = failover[:idx]
+		endpoint.priority = priority
+	}
+	endpoint.addr = normalizeAddress(endpoint.addr, cfg.TestNet,
+		cfg.SimNet, cfg.Wallet)
+	return endpoint, nil
 }
 
 // normalizeAddress returns addr with the passed default port appended if
@@ -286,6 +329,20 @@ func loadConfig() (*config, []string, error) {
 	cfg.RPCServer = normalizeAddress(cfg.RPCServer, cfg.TestNet,
 		cfg.SimNet, cfg.Wallet)
 
+	// Build the list of RPC servers to try in order of priority.  The
+	// server specified by --rpcserver is always tried first.
+	cfg.rpcEndpoints = []rpcEndpoint{{addr: cfg.RPCServer}}
+	for _, failover := range cfg.RPCFailover {
+		endpoint, err := parseRPCEndpoint(failover, &cfg)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", "loadConfig", err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		cfg.rpcEndpoints = append(cfg.rpcEndpoints, endpoint)
+	}
+	sort.Stable(rpcEndpointsByPriority(cfg.rpcEndpoints))
+
 	return &cfg, remainingArgs, nil
 }
 
@@ -136,7 +136,10 @@ func main() {
 
 	// Send the JSON-RPC request to the server using the user-specified
 	// connection configuration.
-	result, err := sendPostRequest(marshalledJSON, cfg)
+	result, server, err := sendPostRequest(marshalledJSON, cfg)
+	if cfg.ShowServer && server != "" {
+		fmt.Fprintf(os.Stderr, "Served by %s\n", server)
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
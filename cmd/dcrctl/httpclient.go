@@ -5,10 +5,13 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/decred/dcrd/dcrjson"
 
@@ -65,21 +68,84 @@ func newHTTPClient(cfg *config) (*http.Client, error) {
 	return &client, nil
 }
 
+// checkReady queries the /ready endpoint of the passed RPC server and returns
+// an error when the server can't be reached or does not report itself as
+// ready.
+func checkReady(httpClient *http.Client, protocol, addr string) error {
+	httpResponse, err := httpClient.Get(protocol + "://" + addr + "/ready")
+	if err != nil {
+		return err
+	}
+	httpResponse.Body.Close()
+	if httpResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("server is not ready (%d %s)",
+			httpResponse.StatusCode,
+			http.StatusText(httpResponse.StatusCode))
+	}
+	return nil
+}
+
 // sendPostRequest sends the marshalled JSON-RPC command using HTTP-POST mode
-// to the server described in the passed config struct.  It also attempts to
-// unmarshal the response as a JSON-RPC response and returns either the result
-// field or the error field depending on whether or not there is an error.
-func sendPostRequest(marshalledJSON []byte, cfg *config) ([]byte, error) {
-	// Generate a request to the configured RPC server.
+// to the servers described in the passed config struct.  The servers are tried
+// in order of priority and the next one is only attempted when the previous
+// one could not be connected to or, when health checks are enabled, does not
+// report itself as ready.  Requests which fail after connecting are not sent to
+// another server since the command might already have been executed.  It also
+// attempts to unmarshal the response as a JSON-RPC response and returns either
+// the result field or the error field depending on whether or not there is an
+// error along with the address of the server which served the request.
+func sendPostRequest(marshalledJSON []byte, cfg *config) ([]byte, string, error) {
 	protocol := "http"
 	if !cfg.NoTLS {
 		protocol = "https"
 	}
-	url := protocol + "://" + cfg.RPCServer
+
+	// Create the new HTTP client that is configured according to the user-
+	// specified options.
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var errs []string
+	for _, endpoint := range cfg.rpcEndpoints {
+		if cfg.HealthCheck {
+			err := checkReady(httpClient, protocol, endpoint.addr)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v",
+					endpoint.addr, err))
+				continue
+			}
+		}
+
+		result, reached, err := sendPostRequestTo(httpClient, protocol,
+			endpoint.addr, marshalledJSON, cfg)
+		if !reached {
+			errs = append(errs, fmt.Sprintf("%s: %v", endpoint.addr,
+				err))
+			continue
+		}
+		return result, endpoint.addr, err
+	}
+
+	if len(errs) == 1 {
+		return nil, "", errors.New(errs[0])
+	}
+	return nil, "", fmt.Errorf("unable to reach any RPC server:\n  %s",
+		strings.Join(errs, "\n  "))
+}
+
+// sendPostRequestTo sends the marshalled JSON-RPC command using HTTP-POST mode
+// to the passed server address.  The reached flag indicates whether or not the
+// server might have received the request so the caller can determine if it is
+// safe to try another server.
+func sendPostRequestTo(httpClient *http.Client, protocol, addr string, marshalledJSON []byte, cfg *config) (result []byte, reached bool, err error) {
+	// Generate a request to the RPC server.
+	url := protocol + "://" + addr
 	bodyReader := bytes.NewReader(marshalledJSON)
 	httpRequest, err := http.NewRequest("POST", url, bodyReader)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	httpRequest.Close = true
 	httpRequest.Header.Set("Content-Type", "application/json")
@@ -87,15 +153,10 @@ func sendPostRequest(marshalledJSON []byte, cfg *config) ([]byte, error) {
 	// Configure basic access authorization.
 	httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPassword)
 
-	// Create the new HTTP client that is configured according to the user-
-	// specified options and submit the request.
-	httpClient, err := newHTTPClient(cfg)
-	if err != nil {
-		return nil, err
-	}
+	// Submit the request.
 	httpResponse, err := httpClient.Do(httpRequest)
 	if err != nil {
-		return nil, err
+		return nil, !isDialError(err), err
 	}
 
 	// Read the raw bytes and close the response.
@@ -103,7 +164,7 @@ func sendPostRequest(marshalledJSON []byte, cfg *config) ([]byte, error) {
 	httpResponse.Body.Close()
 	if err != nil {
 		err = fmt.Errorf("error reading json reply: %v", err)
-		return nil, err
+		return nil, true, err
 	}
 
 	// Handle unsuccessful HTTP responses
@@ -113,20 +174,32 @@ func sendPostRequest(marshalledJSON []byte, cfg *config) ([]byte, error) {
 		// than showing nothing in case the target server has a poor
 		// implementation.
 		if len(respBytes) == 0 {
-			return nil, fmt.Errorf("%d %s", httpResponse.StatusCode,
+			return nil, true, fmt.Errorf("%d %s",
+				httpResponse.StatusCode,
 				http.StatusText(httpResponse.StatusCode))
 		}
-		return nil, fmt.Errorf("%s", respBytes)
+		return nil, true, fmt.Errorf("%s", respBytes)
 	}
 
 	// Unmarshal the response.
 	var resp dcrjson.Response
 	if err := json.Unmarshal(respBytes, &resp); err != nil {
-		return nil, err
+		return nil, true, err
 	}
 
 	if resp.Error != nil {
-		return nil, resp.Error
+		return nil, true, resp.Error
+	}
+	return resp.Result, true, nil
+}
+
+// isDialError returns whether or not the passed error returned by an HTTP
+// client is the result of failing to connect to the server.  Any other error
+// might have occurred after the request was sent.
+func isDialError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
 	}
-	return resp.Result, nil
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
 }
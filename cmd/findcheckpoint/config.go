@@ -7,8 +7,10 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 
 	flags "github.com/btcsuite/go-flags"
 	"github.com/decred/dcrd/chaincfg"
@@ -23,13 +25,15 @@ const (
 	maxCandidates        = 20
 	defaultNumCandidates = 5
 	defaultDbType        = "ffldb"
+	defaultRPCServer     = "localhost"
 )
 
 var (
-	dcrdHomeDir     = dcrutil.AppDataDir("dcrd", false)
-	defaultDataDir  = filepath.Join(dcrdHomeDir, "data")
-	knownDbTypes    = database.SupportedDrivers()
-	activeNetParams = &chaincfg.MainNetParams
+	dcrdHomeDir        = dcrutil.AppDataDir("dcrd", false)
+	defaultDataDir     = filepath.Join(dcrdHomeDir, "data")
+	defaultRPCCertFile = filepath.Join(dcrdHomeDir, "rpc.cert")
+	knownDbTypes       = database.SupportedDrivers()
+	activeNetParams    = &chaincfg.MainNetParams
 )
 
 // config defines the configuration options for findcheckpoint.
@@ -42,6 +46,12 @@ type config struct {
 	SimNet        bool   `long:"simnet" description:"Use the simulation test network"`
 	NumCandidates int    `short:"n" long:"numcandidates" description:"Max num of checkpoint candidates to show {1-20}"`
 	UseGoOutput   bool   `short:"g" long:"gooutput" description:"Display the candidates using Go syntax that is ready to insert into the dcrchain checkpoint list"`
+	UseRPC        bool   `long:"rpc" description:"Query a running dcrd instance via its RPC server instead of opening the block database"`
+	RPCUser       string `short:"u" long:"rpcuser" description:"RPC username"`
+	RPCPassword   string `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
+	RPCServer     string `short:"s" long:"rpcserver" description:"RPC server to connect to"`
+	RPCCert       string `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
+	NoTLS         bool   `long:"notls" description:"Disable TLS for the RPC connection"`
 }
 
 // validDbType returns whether or not dbType is a supported database type.
@@ -55,6 +65,40 @@ func validDbType(dbType string) bool {
 	return false
 }
 
+// normalizeAddress returns addr with the default RPC port of the active network
+// appended if there is not already a port specified.
+func normalizeAddress(addr string, useTestNet, useSimNet bool) string {
+	_, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		var defaultPort string
+		switch {
+		case useTestNet:
+			defaultPort = "19109"
+		case useSimNet:
+			defaultPort = "19556"
+		default:
+			defaultPort = "9109"
+		}
+
+		return net.JoinHostPort(addr, defaultPort)
+	}
+	return addr
+}
+
+// cleanAndExpandPath expands environment variables and leading ~ in the
+// passed path, cleans the result, and returns it.
+func cleanAndExpandPath(path string) string {
+	// Expand initial ~ to OS specific home directory.
+	if strings.HasPrefix(path, "~") {
+		homeDir := filepath.Dir(dcrdHomeDir)
+		path = strings.Replace(path, "~", homeDir, 1)
+	}
+
+	// NOTE: The os.ExpandEnv doesn't work with Windows-style %VARIABLE%,
+	// but they variables can still be expanded via POSIX-style $VARIABLE.
+	return filepath.Clean(os.ExpandEnv(path))
+}
+
 // netName returns the name used when referring to a decred network.  At the
 // time of writing, dcrd currently places blocks for testnet version 2 in the
 // data and log directory "testnet2", which does not match the Name field of the
@@ -80,6 +124,8 @@ func loadConfig() (*config, []string, error) {
 		DataDir:       defaultDataDir,
 		DbType:        defaultDbType,
 		NumCandidates: defaultNumCandidates,
+		RPCServer:     defaultRPCServer,
+		RPCCert:       defaultRPCCertFile,
 	}
 
 	// Parse command line options.
@@ -142,5 +188,10 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Handle environment variable expansion in the RPC certificate path
+	// and add the default port to the RPC server if needed.
+	cfg.RPCCert = cleanAndExpandPath(cfg.RPCCert)
+	cfg.RPCServer = normalizeAddress(cfg.RPCServer, cfg.TestNet, cfg.SimNet)
+
 	return &cfg, remainingArgs, nil
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrutil"
 )
 
 const blockDbNamePrefix = "blocks"
@@ -35,12 +36,29 @@ func loadBlockDB() (database.DB, error) {
 	return db, nil
 }
 
+// chainSource provides access to the chain data needed to find checkpoint
+// candidates.  It is implemented by a chain instance backed by the block
+// database as well as by a connection to the RPC server of a running dcrd
+// instance.
+type chainSource interface {
+	// BlockByHash returns the block with the given hash.
+	BlockByHash(hash *chainhash.Hash) (*dcrutil.Block, error)
+
+	// LatestCheckpoint returns the most recent checkpoint or nil when
+	// there are none.
+	LatestCheckpoint() *chaincfg.Checkpoint
+
+	// IsCheckpointCandidate returns whether or not the passed block is a
+	// good checkpoint candidate.
+	IsCheckpointCandidate(block *dcrutil.Block) (bool, error)
+}
+
 // findCandidates searches the chain backwards for checkpoint candidates and
 // returns a slice of found candidates, if any.  It also stops searching for
 // candidates at the last checkpoint that is already hard coded into chain
 // since there is no point in finding candidates before already existing
 // checkpoints.
-func findCandidates(chain *blockchain.BlockChain, latestHash *chainhash.Hash) ([]*chaincfg.Checkpoint, error) {
+func findCandidates(chain chainSource, latestHash *chainhash.Hash) ([]*chaincfg.Checkpoint, error) {
 	// Start with the latest block of the main chain.
 	block, err := chain.BlockByHash(latestHash)
 	if err != nil {
@@ -63,7 +81,7 @@ func findCandidates(chain *blockchain.BlockChain, latestHash *chainhash.Hash) ([
 	checkpointConfirmations := int64(blockchain.CheckpointConfirmations)
 	requiredHeight := latestCheckpoint.Height + checkpointConfirmations
 	if block.Height() < requiredHeight {
-		return nil, fmt.Errorf("the block database is only at height "+
+		return nil, fmt.Errorf("the block chain is only at height "+
 			"%d which is less than the latest checkpoint height "+
 			"of %d plus required confirmations of %d",
 			block.Height(), latestCheckpoint.Height,
@@ -141,32 +159,61 @@ func main() {
 	}
 	cfg = tcfg
 
-	// Load the block database.
-	db, err := loadBlockDB()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "failed to load database:", err)
-		return
-	}
-	defer db.Close()
-
-	// Setup chain.  Ignore notifications since they aren't needed for this
-	// util.
-	chain, err := blockchain.New(&blockchain.Config{
-		DB:          db,
-		ChainParams: activeNetParams,
-	})
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to initialize chain: %v\n", err)
-		return
-	}
+	var chain chainSource
+	var bestHash *chainhash.Hash
+	if cfg.UseRPC {
+		// Query the chain from the RPC server of a running dcrd
+		// instance which avoids the need for exclusive access to the
+		// block database.
+		rpcChain, err := newRPCChain()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to connect to RPC server:",
+				err)
+			return
+		}
+		defer rpcChain.client.Shutdown()
 
-	// Get the latest block hash and height from the database and report
-	// status.
-	best := chain.BestSnapshot()
-	fmt.Printf("Block database loaded with block height %d\n", best.Height)
+		var bestHeight int64
+		bestHash, bestHeight, err = rpcChain.bestBlock()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to query best block:", err)
+			return
+		}
+		fmt.Printf("Connected to RPC server %s with block height %d\n",
+			cfg.RPCServer, bestHeight)
+		chain = rpcChain
+	} else {
+		// Load the block database.
+		db, err := loadBlockDB()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to load database:", err)
+			return
+		}
+		defer db.Close()
+
+		// Setup chain.  Ignore notifications since they aren't needed
+		// for this util.
+		dbChain, err := blockchain.New(&blockchain.Config{
+			DB:          db,
+			ChainParams: activeNetParams,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to initialize chain: %v\n",
+				err)
+			return
+		}
+
+		// Get the latest block hash and height from the database and
+		// report status.
+		best := dbChain.BestSnapshot()
+		fmt.Printf("Block database loaded with block height %d\n",
+			best.Height)
+		bestHash = best.Hash
+		chain = dbChain
+	}
 
 	// Find checkpoint candidates.
-	candidates, err := findCandidates(chain, best.Hash)
+	candidates, err := findCandidates(chain, bestHash)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Unable to identify candidates:", err)
 		return
@@ -0,0 +1,192 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrrpcclient"
+	"github.com/decred/dcrutil"
+)
+
+// rpcChain provides the chain data needed to find checkpoint candidates by
+// querying the RPC server of a running dcrd instance.  It implements the
+// chainSource interface.
+type rpcChain struct {
+	client     *dcrrpcclient.Client
+	bestHeight int64
+}
+
+// Ensure rpcChain implements the chainSource interface.
+var _ chainSource = (*rpcChain)(nil)
+
+// newRPCChain connects to the RPC server specified by the configuration and
+// returns a chain source which queries it.
+func newRPCChain() (*rpcChain, error) {
+	var certs []byte
+	if !cfg.NoTLS {
+		var err error
+		certs, err = ioutil.ReadFile(cfg.RPCCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read RPC server TLS "+
+				"cert: %v", err)
+		}
+	}
+	connCfg := &dcrrpcclient.ConnConfig{
+		Host:         cfg.RPCServer,
+		User:         cfg.RPCUser,
+		Pass:         cfg.RPCPassword,
+		Certificates: certs,
+		DisableTLS:   cfg.NoTLS,
+		HTTPPostMode: true,
+	}
+	client, err := dcrrpcclient.New(connCfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcChain{client: client}, nil
+}
+
+// bestBlock returns the hash and height of the main chain tip and remembers
+// the height for determining whether blocks have enough confirmations to be
+// checkpoint candidates.
+func (c *rpcChain) bestBlock() (*chainhash.Hash, int64, error) {
+	height, err := c.client.GetBlockCount()
+	if err != nil {
+		return nil, 0, err
+	}
+	hash, err := c.client.GetBlockHash(height)
+	if err != nil {
+		return nil, 0, err
+	}
+	c.bestHeight = height
+	return hash, height, nil
+}
+
+// rawRequest issues the given RPC method with the passed parameters and
+// returns the result, which must be a hex-encoded string, decoded to bytes.
+func (c *rpcChain) rawRequest(method string, params ...interface{}) ([]byte, error) {
+	rawParams := make([]json.RawMessage, 0, len(params))
+	for _, param := range params {
+		rawParam, err := json.Marshal(param)
+		if err != nil {
+			return nil, err
+		}
+		rawParams = append(rawParams, rawParam)
+	}
+	result, err := c.client.RawRequest(method, rawParams)
+	if err != nil {
+		return nil, err
+	}
+	var hexStr string
+	if err := json.Unmarshal(result, &hexStr); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(hexStr)
+}
+
+// header returns the header of the block with the given hash.
+func (c *rpcChain) header(hash *chainhash.Hash) (*wire.BlockHeader, error) {
+	serialized, err := c.rawRequest("getblockheader", hash.String(), false)
+	if err != nil {
+		return nil, err
+	}
+	var header wire.BlockHeader
+	if err := header.FromBytes(serialized); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// BlockByHash returns the block with the given hash.
+//
+// This is part of the chainSource interface.
+func (c *rpcChain) BlockByHash(hash *chainhash.Hash) (*dcrutil.Block, error) {
+	serialized, err := c.rawRequest("getblock", hash.String(), false)
+	if err != nil {
+		return nil, err
+	}
+	return dcrutil.NewBlockFromBytes(serialized)
+}
+
+// LatestCheckpoint returns the most recent checkpoint of the active network or
+// nil when there are none.  The RPC server does not expose its checkpoints, so
+// additional checkpoints the node was configured with are not known.
+//
+// This is part of the chainSource interface.
+func (c *rpcChain) LatestCheckpoint() *chaincfg.Checkpoint {
+	checkpoints := activeNetParams.Checkpoints
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	return &checkpoints[len(checkpoints)-1]
+}
+
+// IsCheckpointCandidate returns whether or not the passed block is a good
+// checkpoint candidate.  It applies the same rules as the chain does for
+// blocks in the block database.
+//
+// This is part of the chainSource interface.
+func (c *rpcChain) IsCheckpointCandidate(block *dcrutil.Block) (bool, error) {
+	// A checkpoint must be in the main chain.
+	blockHeight := block.Height()
+	mainChainHash, err := c.client.GetBlockHash(blockHeight)
+	if err != nil {
+		return false, err
+	}
+	if !mainChainHash.IsEqual(block.Hash()) {
+		return false, nil
+	}
+
+	// A checkpoint must be at least CheckpointConfirmations blocks before
+	// the end of the main chain.
+	if blockHeight > c.bestHeight-blockchain.CheckpointConfirmations {
+		return false, nil
+	}
+
+	// A checkpoint must have timestamps for the block and the blocks on
+	// either side of it in order (due to the median time allowance this is
+	// not always the case).
+	prevHeader, err := c.header(&block.MsgBlock().Header.PrevBlock)
+	if err != nil {
+		return false, err
+	}
+	nextHash, err := c.client.GetBlockHash(blockHeight + 1)
+	if err != nil {
+		return false, err
+	}
+	nextHeader, err := c.header(nextHash)
+	if err != nil {
+		return false, err
+	}
+	prevTime := prevHeader.Timestamp
+	curTime := block.MsgBlock().Header.Timestamp
+	nextTime := nextHeader.Timestamp
+	if prevTime.After(curTime) || nextTime.Before(curTime) {
+		return false, nil
+	}
+
+	// A checkpoint must have transactions that only contain standard
+	// scripts.
+	for _, tx := range block.Transactions() {
+		for _, txOut := range tx.MsgTx().TxOut {
+			class := txscript.GetScriptClass(txOut.Version,
+				txOut.PkScript)
+			if class == txscript.NonStandardTy {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
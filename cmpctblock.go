@@ -0,0 +1,188 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// maxCmpctPeers is the maximum number of peers which are asked to
+	// announce new blocks with compact blocks (high bandwidth mode).  The
+	// peers which most recently were the first to relay a new block are
+	// chosen since they are the most likely to do so again.
+	maxCmpctPeers = 3
+
+	// maxCmpctBlockDepth is the maximum depth below the best chain tip of
+	// the blocks which are served as compact blocks and whose transactions
+	// are served individually.  Older blocks are served in full since peers
+	// can't be expected to have their transactions in their memory pools.
+	maxCmpctBlockDepth = 10
+
+	// maxPartialBlocks is the maximum number of compact blocks which are
+	// held while their missing transactions are requested.  The full block
+	// is requested instead of the missing transactions once it is reached.
+	maxPartialBlocks = 16
+)
+
+// errShortIDCollision is returned when a compact block has multiple
+// transactions with the same short id, in which case the block can't be
+// reconstructed from it.
+var errShortIDCollision = errors.New("compact block has duplicate short " +
+	"transaction ids")
+
+// partialTxTree houses one of the transaction trees of a block which is being
+// reconstructed from a compact block along with the indexes of the
+// transactions which are missing from it.
+type partialTxTree struct {
+	txns    []*wire.MsgTx
+	missing []uint32
+}
+
+// fill sets the missing transactions of the tree to the passed transactions in
+// order.  The number of transactions must match the number of missing ones.
+func (t *partialTxTree) fill(txns []*wire.MsgTx) error {
+	if len(txns) != len(t.missing) {
+		return fmt.Errorf("got %d transactions, but %d are missing",
+			len(txns), len(t.missing))
+	}
+	for i, index := range t.missing {
+		t.txns[index] = txns[i]
+	}
+	t.missing = nil
+	return nil
+}
+
+// partialBlock houses a block which is being reconstructed from a compact block
+// along with the peer it was received from.
+type partialBlock struct {
+	header  wire.BlockHeader
+	peer    *serverPeer
+	regular partialTxTree
+	stake   partialTxTree
+}
+
+// newPartialBlock returns a partial block for the passed compact block with
+// the prefilled transactions and the passed pool transactions whose short ids
+// match those of the compact block in place.  Pool transactions are only
+// matched against the tree they belong in, and short ids which match multiple
+// pool transactions are treated as missing since the correct one is unknown.
+//
+// errShortIDCollision is returned when the compact block itself has duplicate
+// short ids, in which case the full block needs to be requested instead.
+func newPartialBlock(msg *wire.MsgCmpctBlock, poolTxns []*dcrutil.Tx) (*partialBlock, error) {
+	// Map the pool transactions of each tree by their short ids.
+	key := msg.ShortTxIDKey()
+	regularPool := chainhash.NewShortHashMap(key, wire.ShortTxIDSize,
+		len(poolTxns))
+	stakePool := chainhash.NewShortHashMap(key, wire.ShortTxIDSize, 0)
+	for _, tx := range poolTxns {
+		pool := regularPool
+		if tx.Tree() == wire.TxTreeStake {
+			pool = stakePool
+		}
+		pool.Put(tx.Hash(), tx.MsgTx())
+	}
+
+	pb := &partialBlock{header: msg.Header}
+	trees := []struct {
+		cmpct   *wire.CmpctTxTree
+		partial *partialTxTree
+		pool    *chainhash.ShortHashMap
+	}{
+		{cmpct: &msg.Regular, partial: &pb.regular, pool: regularPool},
+		{cmpct: &msg.Stake, partial: &pb.stake, pool: stakePool},
+	}
+
+	// Place the prefilled transactions and fill the remaining indexes of
+	// each tree in order with the pool transactions which match their short
+	// ids, or determine they are missing when no single pool transaction
+	// matches.
+	for i := range trees {
+		tree := &trees[i]
+		txns := make([]*wire.MsgTx, tree.cmpct.NumTxns())
+		for _, prefilled := range tree.cmpct.PrefilledTxs {
+			txns[prefilled.Index] = prefilled.Tx
+		}
+
+		shortIDs := tree.cmpct.ShortIDs
+		seen := make(map[uint64]struct{}, len(shortIDs))
+		for index := range txns {
+			if txns[index] != nil {
+				continue
+			}
+			shortID := shortIDs[0]
+			shortIDs = shortIDs[1:]
+			if _, exists := seen[shortID]; exists {
+				return nil, errShortIDCollision
+			}
+			seen[shortID] = struct{}{}
+
+			_, tx, n := tree.pool.LookupShort(shortID)
+			if n != 1 {
+				tree.partial.missing = append(tree.partial.missing,
+					uint32(index))
+				continue
+			}
+			txns[index] = tx.(*wire.MsgTx)
+		}
+		tree.partial.txns = txns
+	}
+
+	return pb, nil
+}
+
+// complete returns whether all transactions of the block are known.
+func (pb *partialBlock) complete() bool {
+	return len(pb.regular.missing) == 0 && len(pb.stake.missing) == 0
+}
+
+// fill sets the missing transactions of the block to the transactions of the
+// passed blocktxn message, which must provide exactly the missing transactions
+// in order.
+func (pb *partialBlock) fill(msg *wire.MsgBlockTxn) error {
+	if err := pb.regular.fill(msg.Transactions); err != nil {
+		return fmt.Errorf("regular tree: %v", err)
+	}
+	if err := pb.stake.fill(msg.STransactions); err != nil {
+		return fmt.Errorf("stake tree: %v", err)
+	}
+	return nil
+}
+
+// block returns the reconstructed block once all of its transactions are known.
+// An error is returned when the merkle roots of the transactions do not match
+// the header, which happens when the short id of a pool transaction collides
+// with the short id of a different transaction of the block or the signature
+// scripts of a pool transaction differ from those of the block, in which case
+// the full block needs to be requested instead.
+func (pb *partialBlock) block() (*dcrutil.Block, error) {
+	if !pb.complete() {
+		return nil, errors.New("block has missing transactions")
+	}
+
+	block := dcrutil.NewBlock(&wire.MsgBlock{
+		Header:        pb.header,
+		Transactions:  pb.regular.txns,
+		STransactions: pb.stake.txns,
+	})
+	merkles := blockchain.BuildMerkleTreeStore(block.Transactions())
+	if !pb.header.MerkleRoot.IsEqual(merkles[len(merkles)-1]) {
+		return nil, errors.New("reconstructed block merkle root does " +
+			"not match the header")
+	}
+	merkles = blockchain.BuildMerkleTreeStore(block.STransactions())
+	if !pb.header.StakeRoot.IsEqual(merkles[len(merkles)-1]) {
+		return nil, errors.New("reconstructed block stake root does " +
+			"not match the header")
+	}
+	return block, nil
+}
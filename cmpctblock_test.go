@@ -0,0 +1,153 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// cmpctBlockTestTx returns a transaction which is unique to the passed number.
+func cmpctBlockTestTx(n int64) *wire.MsgTx {
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&zeroHash, 0,
+		wire.TxTreeRegular), nil))
+	tx.AddTxOut(wire.NewTxOut(n, nil))
+	return tx
+}
+
+// cmpctBlockTestBlock returns a block with the passed number of regular and
+// stake transactions and valid merkle roots.
+func cmpctBlockTestBlock(numRegular, numStake int) *wire.MsgBlock {
+	block := &wire.MsgBlock{}
+	for i := 0; i < numRegular; i++ {
+		block.AddTransaction(cmpctBlockTestTx(int64(i)))
+	}
+	for i := 0; i < numStake; i++ {
+		block.AddSTransaction(cmpctBlockTestTx(int64(1000 + i)))
+	}
+	utilBlock := dcrutil.NewBlock(block)
+	merkles := blockchain.BuildMerkleTreeStore(utilBlock.Transactions())
+	block.Header.MerkleRoot = *merkles[len(merkles)-1]
+	merkles = blockchain.BuildMerkleTreeStore(utilBlock.STransactions())
+	block.Header.StakeRoot = *merkles[len(merkles)-1]
+	return block
+}
+
+// cmpctBlockTestPoolTx returns the passed transaction as a pool transaction of
+// the passed tree.
+func cmpctBlockTestPoolTx(tx *wire.MsgTx, tree int8) *dcrutil.Tx {
+	utilTx := dcrutil.NewTx(tx)
+	utilTx.SetTree(tree)
+	return utilTx
+}
+
+// TestPartialBlockReconstruction ensures blocks are reconstructed from compact
+// blocks and pool transactions, the missing transactions are reported per tree,
+// and filling them in yields the original block.
+func TestPartialBlockReconstruction(t *testing.T) {
+	block := cmpctBlockTestBlock(5, 3)
+	msg := wire.NewMsgCmpctBlockFromBlock(block, 42)
+
+	// Provide all but the third regular and the second stake transaction in
+	// the pool, along with a transaction which is not in the block and a
+	// stake transaction offered as a regular one.
+	pool := []*dcrutil.Tx{
+		cmpctBlockTestPoolTx(block.Transactions[1], wire.TxTreeRegular),
+		cmpctBlockTestPoolTx(block.Transactions[3], wire.TxTreeRegular),
+		cmpctBlockTestPoolTx(block.Transactions[4], wire.TxTreeRegular),
+		cmpctBlockTestPoolTx(block.STransactions[0], wire.TxTreeStake),
+		cmpctBlockTestPoolTx(block.STransactions[1], wire.TxTreeRegular),
+		cmpctBlockTestPoolTx(block.STransactions[2], wire.TxTreeStake),
+		cmpctBlockTestPoolTx(cmpctBlockTestTx(9999), wire.TxTreeRegular),
+	}
+	pb, err := newPartialBlock(msg, pool)
+	if err != nil {
+		t.Fatalf("newPartialBlock: unexpected error: %v", err)
+	}
+	if pb.complete() {
+		t.Fatal("complete: partial block unexpectedly complete")
+	}
+	if len(pb.regular.missing) != 1 || pb.regular.missing[0] != 2 {
+		t.Fatalf("unexpected missing regular transactions: %v",
+			pb.regular.missing)
+	}
+	if len(pb.stake.missing) != 1 || pb.stake.missing[0] != 1 {
+		t.Fatalf("unexpected missing stake transactions: %v",
+			pb.stake.missing)
+	}
+	if _, err := pb.block(); err == nil {
+		t.Fatal("block: no error for incomplete block")
+	}
+
+	// Ensure the wrong number of transactions is rejected.
+	blockHash := block.BlockHash()
+	blockTxn := wire.NewMsgBlockTxn(&blockHash)
+	if err := pb.fill(blockTxn); err == nil {
+		t.Fatal("fill: no error for missing transactions")
+	}
+
+	blockTxn.Transactions = []*wire.MsgTx{block.Transactions[2]}
+	blockTxn.STransactions = []*wire.MsgTx{block.STransactions[1]}
+	if err := pb.fill(blockTxn); err != nil {
+		t.Fatalf("fill: unexpected error: %v", err)
+	}
+	utilBlock, err := pb.block()
+	if err != nil {
+		t.Fatalf("block: unexpected error: %v", err)
+	}
+	if got := utilBlock.MsgBlock().BlockHash(); got != blockHash {
+		t.Fatalf("block: unexpected block hash - got %v, want %v", got,
+			blockHash)
+	}
+}
+
+// TestPartialBlockCollisions ensures duplicate short ids in a compact block are
+// rejected, pool transactions provided more than once are still matched, and a
+// wrong transaction is detected by its merkle root.
+func TestPartialBlockCollisions(t *testing.T) {
+	block := cmpctBlockTestBlock(3, 0)
+	msg := wire.NewMsgCmpctBlockFromBlock(block, 7)
+
+	// Ensure duplicate short ids in the compact block are rejected.
+	dup := *msg
+	dup.Regular.ShortIDs = []uint64{msg.Regular.ShortIDs[0],
+		msg.Regular.ShortIDs[0]}
+	if _, err := newPartialBlock(&dup, nil); err != errShortIDCollision {
+		t.Fatalf("newPartialBlock: unexpected error - got %v, want %v",
+			err, errShortIDCollision)
+	}
+
+	// Ensure a pool transaction which is provided more than once is still
+	// matched since it only has a single hash.  Short ids which are matched
+	// by pool transactions with different hashes are reported as ambiguous
+	// by the short hash map and thus treated as missing.
+	pool := []*dcrutil.Tx{
+		cmpctBlockTestPoolTx(block.Transactions[2], wire.TxTreeRegular),
+		cmpctBlockTestPoolTx(block.Transactions[2], wire.TxTreeRegular),
+	}
+	pb, err := newPartialBlock(msg, pool)
+	if err != nil {
+		t.Fatalf("newPartialBlock: unexpected error: %v", err)
+	}
+	if len(pb.regular.missing) != 1 || pb.regular.missing[0] != 1 {
+		t.Fatalf("unexpected missing regular transactions: %v",
+			pb.regular.missing)
+	}
+
+	// Ensure filling in the wrong transaction is detected.
+	blockHash := block.BlockHash()
+	blockTxn := wire.NewMsgBlockTxn(&blockHash)
+	blockTxn.Transactions = []*wire.MsgTx{cmpctBlockTestTx(9999)}
+	if err := pb.fill(blockTxn); err != nil {
+		t.Fatalf("fill: unexpected error: %v", err)
+	}
+	if _, err := pb.block(); err == nil {
+		t.Fatal("block: no error for mismatched merkle root")
+	}
+}
@@ -23,6 +23,9 @@ import (
 
 	flags "github.com/btcsuite/go-flags"
 	"github.com/btcsuite/go-socks/socks"
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/connmgr"
 	"github.com/decred/dcrd/database"
 	_ "github.com/decred/dcrd/database/ffldb"
@@ -42,6 +45,10 @@ const (
 	defaultBanThreshold          = 100
 	defaultMaxRPCClients         = 10
 	defaultMaxRPCWebsockets      = 25
+	defaultRPCHealthMaxLag       = 6
+	defaultRPCHealthMinPeers     = 1
+	defaultRPCAuditMaxSize       = 10
+	defaultRPCAuditMaxRolls      = 3
 	defaultVerifyEnabled         = false
 	defaultDbType                = "ffldb"
 	defaultFreeTxRelayLimit      = 15.0
@@ -55,8 +62,11 @@ const (
 	defaultNoMiningStateSync     = false
 	defaultAllowOldVotes         = false
 	defaultMaxOrphanTransactions = 1000
+	defaultMaxReplacedTxs        = mempool.DefaultMaxReplacedTxs
+	defaultMaxMempoolSize        = mempool.DefaultMaxPoolSize / 1024 / 1024
 	defaultMaxOrphanTxSize       = 5000
 	defaultSigCacheMaxSize       = 100000
+	defaultUtxoCacheMaxSize      = blockchain.DefaultUtxoCacheMaxSize / 1024 / 1024
 	sampleConfigFilename         = "sample-dcrd.conf"
 	defaultTxIndex               = false
 	defaultNoExistsAddrIndex     = false
@@ -70,6 +80,9 @@ var (
 	defaultRPCKeyFile  = filepath.Join(defaultHomeDir, "rpc.key")
 	defaultRPCCertFile = filepath.Join(defaultHomeDir, "rpc.cert")
 	defaultLogDir      = filepath.Join(defaultHomeDir, defaultLogDirname)
+
+	defaultTLSPeerKeyFile  = filepath.Join(defaultHomeDir, "p2p.key")
+	defaultTLSPeerCertFile = filepath.Join(defaultHomeDir, "p2p.cert")
 )
 
 // runServiceCommand is only set to a real function on Windows.  It is used
@@ -102,6 +115,12 @@ type config struct {
 	DisableBanning      bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
 	BanDuration         time.Duration `long:"banduration" description:"How long to ban misbehaving peers.  Valid time units are {s, m, h}.  Minimum 1 second"`
 	BanThreshold        uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
+	TLSPeers            []string      `long:"tlspeer" description:"Add a trusted peer to connect with at startup over mutual TLS -- The host must be covered by the certificate the peer presents"`
+	TLSPeerListeners    []string      `long:"tlspeerlisten" description:"Add an interface/port to listen for mutual TLS connections from trusted peers -- NOTE: These listeners are not affected by --nolisten and are not advertised"`
+	TLSPeerCert         string        `long:"tlspeercert" description:"File containing the certificate presented to trusted TLS peers"`
+	TLSPeerKey          string        `long:"tlspeerkey" description:"File containing the key of the certificate presented to trusted TLS peers"`
+	TLSPeerCAFile       string        `long:"tlspeercafile" description:"File containing the certificates of the trusted TLS peers or of the authorities which issued them"`
+	PeerFilters         []string      `long:"peerfilter" description:"Add a rule in the form <action>:<kind>:<value> to refuse or deprioritize peers -- Actions are {refuse, deprioritize} and kinds are {useragent, version, services} which match peers by user agent pattern (* and ? wildcards), protocol version or <min>-<max> range, and missing service bits respectively"`
 	RPCUser             string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
 	RPCPass             string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
 	RPCLimitUser        string        `long:"rpclimituser" description:"Username for limited RPC connections"`
@@ -111,10 +130,17 @@ type config struct {
 	RPCKey              string        `long:"rpckey" description:"File containing the certificate key"`
 	RPCMaxClients       int           `long:"rpcmaxclients" description:"Max number of RPC clients for standard connections"`
 	RPCMaxWebsockets    int           `long:"rpcmaxwebsockets" description:"Max number of RPC websocket connections"`
+	RPCHealthMaxLag     uint32        `long:"rpchealthmaxlag" description:"Max number of blocks the chain may be behind the median height of the connected peers for the /ready endpoint to report the node as ready"`
+	RPCHealthMinPeers   int           `long:"rpchealthminpeers" description:"Min number of connected peers for the /ready endpoint to report the node as ready"`
+	RPCAuditLog         string        `long:"rpcauditlog" description:"Write a JSON entry with the method, parameters hash, user, latency, reply size, and error code of each RPC request to the specified file -- NOTE: The parameters of commands which carry credentials or private keys are not hashed"`
+	RPCAuditMaxSize     int           `long:"rpcauditmaxsize" description:"Size in MiB at which the RPC audit log is rotated"`
+	RPCAuditMaxRolls    int           `long:"rpcauditmaxrolls" description:"Number of rotated RPC audit log files to keep"`
+	TrackMissedTickets  bool          `long:"trackmissedtickets" description:"Record diagnostic details about the likely reason tickets miss their votes for the getmissedticketdetails RPC"`
 	DisableRPC          bool          `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass or rpclimituser/rpclimitpass is specified"`
 	DisableTLS          bool          `long:"notls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
 	DisableDNSSeed      bool          `long:"nodnsseed" description:"Disable DNS seeding for peers"`
 	ExternalIPs         []string      `long:"externalip" description:"Add an ip to the list of local addresses we claim to listen on to peers"`
+	DiscoverIP          bool          `long:"discoverip" description:"Advertise the external ip most outbound peers report seeing us as, together with the default port -- NOTE: This option has no effect if external ips are specified or a proxy is used"`
 	Proxy               string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
 	ProxyUser           string        `long:"proxyuser" description:"Username for proxy server"`
 	ProxyPass           string        `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
@@ -123,9 +149,13 @@ type config struct {
 	OnionProxyPass      string        `long:"onionpass" default-mask:"-" description:"Password for onion proxy server"`
 	NoOnion             bool          `long:"noonion" description:"Disable connecting to tor hidden services"`
 	TorIsolation        bool          `long:"torisolation" description:"Enable Tor stream isolation by randomizing user credentials for each connection."`
+	DialRoutes          []string      `long:"dialroute" description:"Add a rule of the form <destination>=<proxy>[,<proxy>...] to connect to matching peers via the listed SOCKS5 proxies, which are tried in order, or directly with the proxy direct -- The destination is a network in CIDR notation (eg. 10.0.0.0/8), a host name suffix (eg. .i2p), or lan for private and link-local addresses, and the first matching rule applies"`
 	TestNet             bool          `long:"testnet" description:"Use the test network"`
 	SimNet              bool          `long:"simnet" description:"Use the simulation test network"`
 	DisableCheckpoints  bool          `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing."`
+	CheckpointMode      string        `long:"checkpointmode" description:"How to treat checkpoints {enforce, advisory, disabled} -- advisory only warns about blocks which do not match the checkpoints and fully validates the entire chain (default: enforce)"`
+	CheckpointFile      string        `long:"checkpointfile" description:"Path to a file with additional checkpoints, one <height>:<hash> per line"`
+	AssumeValid         string        `long:"assumevalid" description:"Skip script validation for the block identified by <height>:<hash> and all of its ancestors while still enforcing the other consensus rules, or 0 to validate the scripts of all blocks (default: the assumed valid block of the network)"`
 	DbType              string        `long:"dbtype" description:"Database backend to use for the Block Chain"`
 	Profile             string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	CPUProfile          string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
@@ -134,28 +164,47 @@ type config struct {
 	MiningTimeOffset    int           `long:"miningtimeoffset" description:"Offset the mining timestamp of a block by this many seconds (positive values are in the past)"`
 	DebugLevel          string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
 	Upnp                bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
+	LANPeers            bool          `long:"lanpeers" description:"Discover and connect to peers on the local network via multicast announcements -- Only available on testnet and simnet"`
 	MinRelayTxFee       float64       `long:"minrelaytxfee" description:"The minimum transaction fee in DCR/kB to be considered a non-zero fee."`
 	FreeTxRelayLimit    float64       `long:"limitfreerelay" description:"Limit relay of transactions with no transaction fee to the given amount in thousands of bytes per minute"`
 	NoRelayPriority     bool          `long:"norelaypriority" description:"Do not require free or low-fee transactions to have high priority for relaying"`
 	MaxOrphanTxs        int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
+	NoReplacement       bool          `long:"noreplacement" description:"Reject transactions which spend outputs already spent by transactions in the mempool instead of accepting them as replacements when the spent transactions opt in to replacement"`
+	IncrRelayFee        float64       `long:"incrementalrelayfee" description:"The fee rate in DCR/kB by which a replacement transaction must pay more than the fees of all of the transactions it replaces, and by which the minimum fee rate of the full mempool is raised above the fee rates of evicted transactions"`
+	MaxReplacedTxs      int           `long:"maxreplacedtxs" description:"Max number of transactions a replacement transaction may remove from the mempool, including all of the transactions which depend on the replaced ones"`
+	MaxMempoolSize      uint          `long:"maxmempool" description:"The maximum size in MiB of all transactions in the mempool -- The regular transactions with the lowest fee rates are evicted once it is exceeded, which raises the minimum fee rate for accepting transactions until it decays"`
 	Generate            bool          `long:"generate" description:"Generate (mine) coins using the CPU"`
 	MiningAddrs         []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
 	BlockMinSize        uint32        `long:"blockminsize" description:"Mininum block size in bytes to be used when creating a block"`
 	BlockMaxSize        uint32        `long:"blockmaxsize" description:"Maximum block size in bytes to be used when creating a block"`
 	BlockPrioritySize   uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
+	MiningVoteWait      time.Duration `long:"miningvotewait" description:"Maximum time to wait for all of the votes on a new block before creating block templates that include fewer votes and therefore pay a reduced reward.  Valid time units are {s, m, h}"`
 	GetWorkKeys         []string      `long:"getworkkey" description:"DEPRECATED -- Use the --miningaddr option instead"`
 	NoPeerBloomFilters  bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
 	SigCacheMaxSize     uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
+	UtxoCacheMaxSize    uint          `long:"utxocachesize" description:"The maximum size in MiB of the cache which holds modifications to the utxo set in memory before they are written to the database"`
+	Prune               uint          `long:"prune" description:"Reduce storage requirements by removing the data of old blocks to keep the stored block data below the specified target size in MiB -- The minimum target is 1024 and 0 disables pruning"`
+	UtxoCommitments     bool          `long:"utxocommitments" description:"Commit to the utxo set in the extra data of generated blocks and reject blocks which do not commit to it -- EXPERIMENTAL and only available on simnet"`
+	AddScriptFlags      []string      `long:"addscriptflag" description:"Enforce the named script verification flag regardless of the state of the agendas, as reported by getblockchaininfo -- Only available on testnet and simnet"`
+	RemoveScriptFlags   []string      `long:"removescriptflag" description:"Do not enforce the named script verification flag regardless of the state of the agendas, as reported by getblockchaininfo -- Only available on testnet and simnet"`
+	ScriptFlagsHeight   int64         `long:"scriptflagsheight" description:"The height of the first block the --addscriptflag and --removescriptflag overrides apply to, which keeps the rules of earlier blocks unchanged -- 0 applies them to all blocks"`
 	NonAggressive       bool          `long:"nonaggressive" description:"Disable mining off of the parent block of the blockchain if there aren't enough voters"`
 	NoMiningStateSync   bool          `long:"nominingstatesync" description:"Disable synchronizing the mining state with other nodes"`
 	AllowOldVotes       bool          `long:"allowoldvotes" description:"Enable the addition of very old votes to the mempool"`
 	BlocksOnly          bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
+	AnnotateBlocks      bool          `long:"annotateblocks" description:"Annotate blocks received from remote peers with the time they were first seen and the address of the peer which relayed them"`
 	TxIndex             bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
 	DropTxIndex         bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
 	AddrIndex           bool          `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
 	DropAddrIndex       bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
 	NoExistsAddrIndex   bool          `long:"noexistsaddrindex" description:"Disable the exists address index, which tracks whether or not an address has even been used."`
 	DropExistsAddrIndex bool          `long:"dropexistsaddrindex" description:"Deletes the exists address index from the database on start up and then exits."`
+	WindowAggIndex      bool          `long:"windowaggindex" description:"Maintain an index of aggregate difficulty, ticket price, fee, and subsidy information for each stake difficulty window which makes the getwindowaggregates RPC available"`
+	DropWindowAggIndex  bool          `long:"dropwindowaggindex" description:"Deletes the window aggregate index from the database on start up and then exits."`
+	SpendIndex          bool          `long:"spendindex" description:"Maintain an index of the transaction inputs which spend the outputs of the main chain which makes the gettxspendinginfo RPC available"`
+	DropSpendIndex      bool          `long:"dropspendindex" description:"Deletes the spend index from the database on start up and then exits."`
+	BalanceIndex        bool          `long:"balanceindex" description:"Maintain an index of the confirmed balance and unspent outputs of each script which makes the getaddressbalance and getaddressutxos RPCs available"`
+	DropBalanceIndex    bool          `long:"dropbalanceindex" description:"Deletes the balance index from the database on start up and then exits."`
 	PipeRx              uint          `long:"piperx" description:"File descriptor of read end pipe to enable parent -> child process communication"`
 	PipeTx              uint          `long:"pipetx" description:"File descriptor of write end pipe to enable parent <- child process communication"`
 	LifetimeEvents      bool          `long:"lifetimeevents" description:"Send lifetime notifications over the TX pipe"`
@@ -163,8 +212,14 @@ type config struct {
 	lookup              func(string) ([]net.IP, error)
 	oniondial           func(string, string) (net.Conn, error)
 	dial                func(string, string) (net.Conn, error)
+	dialRouter          *connmgr.DialRouter
 	miningAddrs         []dcrutil.Address
 	minRelayTxFee       dcrutil.Amount
+	incrRelayFee        dcrutil.Amount
+	checkpointMode      blockchain.CheckpointMode
+	checkpoints         []chaincfg.Checkpoint
+	assumeValid         *chaincfg.Checkpoint
+	peerFilterRules     []*peerFilterRule
 }
 
 // serviceOptions defines the configuration options for the daemon as a service on
@@ -282,6 +337,79 @@ func validDbType(dbType string) bool {
 	return false
 }
 
+// parseCheckpointMode returns the checkpoint mode identified by the passed name.
+func parseCheckpointMode(name string) (blockchain.CheckpointMode, error) {
+	modes := []blockchain.CheckpointMode{
+		blockchain.CheckpointModeEnforce,
+		blockchain.CheckpointModeAdvisory,
+		blockchain.CheckpointModeDisabled,
+	}
+	for _, mode := range modes {
+		if name == mode.String() {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("the checkpoint mode [%v] is invalid -- "+
+		"supported modes %v", name, modes)
+}
+
+// parseCheckpoint returns the checkpoint described by the passed string in the
+// form <height>:<hash>.
+func parseCheckpoint(s string) (chaincfg.Checkpoint, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return chaincfg.Checkpoint{}, fmt.Errorf("checkpoint %q is not "+
+			"in the form <height>:<hash>", s)
+	}
+	height, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil || height < 0 {
+		return chaincfg.Checkpoint{}, fmt.Errorf("invalid checkpoint "+
+			"height %q", parts[0])
+	}
+	hashStr := strings.TrimSpace(parts[1])
+	if len(hashStr) != chainhash.MaxHashStringSize {
+		return chaincfg.Checkpoint{}, fmt.Errorf("invalid checkpoint "+
+			"hash %q", hashStr)
+	}
+	hash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return chaincfg.Checkpoint{}, fmt.Errorf("invalid checkpoint "+
+			"hash %q: %v", hashStr, err)
+	}
+	return chaincfg.Checkpoint{Height: height, Hash: hash}, nil
+}
+
+// loadCheckpointFile returns the checkpoints in the passed file.  Each line
+// which is not empty or a comment starting with '#' must contain a checkpoint
+// in the form <height>:<hash>.
+func loadCheckpointFile(path string) ([]chaincfg.Checkpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var checkpoints []chaincfg.Checkpoint
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		checkpoint, err := parseCheckpoint(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, lineNum, err)
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return checkpoints, nil
+}
+
 // removeDuplicateAddresses returns a new slice with all duplicate entries in
 // addrs removed.
 func removeDuplicateAddresses(addrs []string) []string {
@@ -358,18 +486,28 @@ func loadConfig() (*config, []string, error) {
 		BanThreshold:      defaultBanThreshold,
 		RPCMaxClients:     defaultMaxRPCClients,
 		RPCMaxWebsockets:  defaultMaxRPCWebsockets,
+		RPCHealthMaxLag:   defaultRPCHealthMaxLag,
+		RPCHealthMinPeers: defaultRPCHealthMinPeers,
+		RPCAuditMaxSize:   defaultRPCAuditMaxSize,
+		RPCAuditMaxRolls:  defaultRPCAuditMaxRolls,
 		DataDir:           defaultDataDir,
 		LogDir:            defaultLogDir,
 		DbType:            defaultDbType,
 		RPCKey:            defaultRPCKeyFile,
 		RPCCert:           defaultRPCCertFile,
+		TLSPeerKey:        defaultTLSPeerKeyFile,
+		TLSPeerCert:       defaultTLSPeerCertFile,
 		MinRelayTxFee:     mempool.DefaultMinRelayTxFee.ToCoin(),
 		FreeTxRelayLimit:  defaultFreeTxRelayLimit,
 		BlockMinSize:      defaultBlockMinSize,
 		BlockMaxSize:      defaultBlockMaxSize,
 		BlockPrioritySize: mempool.DefaultBlockPrioritySize,
 		MaxOrphanTxs:      defaultMaxOrphanTransactions,
+		IncrRelayFee:      mempool.DefaultIncrementalRelayFee.ToCoin(),
+		MaxReplacedTxs:    defaultMaxReplacedTxs,
+		MaxMempoolSize:    defaultMaxMempoolSize,
 		SigCacheMaxSize:   defaultSigCacheMaxSize,
+		UtxoCacheMaxSize:  defaultUtxoCacheMaxSize,
 		Generate:          defaultGenerate,
 		NoMiningStateSync: defaultNoMiningStateSync,
 		TxIndex:           defaultTxIndex,
@@ -444,6 +582,16 @@ func loadConfig() (*config, []string, error) {
 		} else {
 			cfg.RPCCert = preCfg.RPCCert
 		}
+		if preCfg.TLSPeerKey == defaultTLSPeerKeyFile {
+			cfg.TLSPeerKey = filepath.Join(cfg.HomeDir, "p2p.key")
+		} else {
+			cfg.TLSPeerKey = preCfg.TLSPeerKey
+		}
+		if preCfg.TLSPeerCert == defaultTLSPeerCertFile {
+			cfg.TLSPeerCert = filepath.Join(cfg.HomeDir, "p2p.cert")
+		} else {
+			cfg.TLSPeerCert = preCfg.TLSPeerCert
+		}
 		if preCfg.LogDir == defaultLogDir {
 			cfg.LogDir = filepath.Join(cfg.HomeDir, defaultLogDirname)
 		} else {
@@ -576,6 +724,94 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Validate the checkpoint mode.  The --nocheckpoints option is
+	// equivalent to the disabled mode.
+	cfg.checkpointMode = blockchain.CheckpointModeEnforce
+	if cfg.CheckpointMode != "" {
+		mode, err := parseCheckpointMode(cfg.CheckpointMode)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.checkpointMode = mode
+	}
+	if cfg.DisableCheckpoints {
+		if cfg.checkpointMode != blockchain.CheckpointModeDisabled &&
+			cfg.CheckpointMode != "" {
+
+			str := "%s: the --nocheckpoints and --checkpointmode=%v " +
+				"options may not be used at the same time"
+			err := fmt.Errorf(str, funcName, cfg.CheckpointMode)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.checkpointMode = blockchain.CheckpointModeDisabled
+	}
+
+	// Load any additional checkpoints supplied by the operator.
+	if cfg.CheckpointFile != "" {
+		cfg.CheckpointFile = cleanAndExpandPath(cfg.CheckpointFile)
+		cfg.checkpoints, err = loadCheckpointFile(cfg.CheckpointFile)
+		if err != nil {
+			err := fmt.Errorf("%s: failed to load checkpoint file: %v",
+				funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// Determine the assumed valid block, which defaults to the one of the
+	// network and is disabled with 0.
+	if activeNetParams.AssumeValid.Hash != nil {
+		assumeValid := activeNetParams.AssumeValid
+		cfg.assumeValid = &assumeValid
+	}
+	switch cfg.AssumeValid {
+	case "":
+	case "0":
+		cfg.assumeValid = nil
+	default:
+		assumeValid, err := parseCheckpoint(cfg.AssumeValid)
+		if err != nil {
+			err := fmt.Errorf("%s: invalid --assumevalid: %v",
+				funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.assumeValid = &assumeValid
+	}
+
+	// Parse the peer filter rules.
+	for _, rule := range cfg.PeerFilters {
+		r, err := parsePeerFilterRule(rule)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.peerFilterRules = append(cfg.peerFilterRules, r)
+	}
+
+	// Validate the RPC audit log options.
+	if cfg.RPCAuditLog != "" {
+		cfg.RPCAuditLog = cleanAndExpandPath(cfg.RPCAuditLog)
+		if cfg.RPCAuditMaxSize < 1 || cfg.RPCAuditMaxRolls < 1 {
+			str := "%s: The rpcauditmaxsize and rpcauditmaxrolls " +
+				"options must be at least 1 -- parsed [%d, %d]"
+			err := fmt.Errorf(str, funcName, cfg.RPCAuditMaxSize,
+				cfg.RPCAuditMaxRolls)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Validate profile port number
 	if cfg.Profile != "" {
 		profilePort, err := strconv.Atoi(cfg.Profile)
@@ -597,6 +833,59 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Don't allow negative vote wait durations.
+	if cfg.MiningVoteWait < 0 {
+		str := "%s: The miningvotewait option may not be negative -- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.MiningVoteWait)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Local network peer discovery is only intended for test setups.
+	if cfg.LANPeers && !(cfg.TestNet || cfg.SimNet) {
+		str := "%s: the --lanpeers option is only available on the " +
+			"test and simulation networks"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The utxo commitments are an experimental consensus change, so they
+	// are only available on the simulation network.
+	if cfg.UtxoCommitments && !cfg.SimNet {
+		str := "%s: the --utxocommitments option is only available on " +
+			"the simulation network"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Overriding the script verification flags is only intended for testing
+	// script changes before the agendas which enable them are active.
+	// The overrides are passed to the chain rather than set on the network
+	// parameters so they only apply from the configured height on.
+	if (len(cfg.AddScriptFlags) > 0 || len(cfg.RemoveScriptFlags) > 0 ||
+		cfg.ScriptFlagsHeight != 0) && !(cfg.TestNet || cfg.SimNet) {
+
+		str := "%s: the --addscriptflag, --removescriptflag, and " +
+			"--scriptflagsheight options are only available on the " +
+			"test and simulation networks"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.ScriptFlagsHeight < 0 {
+		str := "%s: the --scriptflagsheight option may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// --addPeer and --connect do not mix.
 	if len(cfg.AddPeers) > 0 && len(cfg.ConnectPeers) > 0 {
 		str := "%s: the --addpeer and --connect options can not be " +
@@ -607,6 +896,23 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Links with trusted TLS peers require the trusted certificates.
+	if (len(cfg.TLSPeers) > 0 || len(cfg.TLSPeerListeners) > 0) &&
+		cfg.TLSPeerCAFile == "" {
+		str := "%s: the --tlspeer and --tlspeerlisten options require " +
+			"the trusted certificates to be specified via " +
+			"--tlspeercafile"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.TLSPeerCAFile != "" {
+		cfg.TLSPeerCert = cleanAndExpandPath(cfg.TLSPeerCert)
+		cfg.TLSPeerKey = cleanAndExpandPath(cfg.TLSPeerKey)
+		cfg.TLSPeerCAFile = cleanAndExpandPath(cfg.TLSPeerCAFile)
+	}
+
 	// --proxy or --connect without --listen disables listening.
 	if (cfg.Proxy != "" || len(cfg.ConnectPeers) > 0) &&
 		len(cfg.Listeners) == 0 {
@@ -676,6 +982,16 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Validate the incrementalrelayfee.
+	cfg.incrRelayFee, err = dcrutil.NewAmount(cfg.IncrRelayFee)
+	if err != nil {
+		str := "%s: invalid incrementalrelayfee: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Limit the max block size to a sane value.
 	if cfg.BlockMaxSize < blockMaxSizeMin || cfg.BlockMaxSize >
 		blockMaxSizeMax {
@@ -699,6 +1015,27 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// The number of transactions a replacement may remove must include at
+	// least the transaction it replaces.
+	if cfg.MaxReplacedTxs < 1 {
+		str := "%s: The maxreplacedtxs option may not be less than 1 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.MaxReplacedTxs)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The mempool must be able to hold at least some transactions.
+	if cfg.MaxMempoolSize < 1 {
+		str := "%s: The maxmempool option may not be less than 1 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.MaxMempoolSize)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Limit the block priority and minimum block sizes to max block size.
 	cfg.BlockPrioritySize = minUint32(cfg.BlockPrioritySize, cfg.BlockMaxSize)
 	cfg.BlockMinSize = minUint32(cfg.BlockMinSize, cfg.BlockMaxSize)
@@ -735,6 +1072,41 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --balanceindex and --droptxindex do not mix.
+	if cfg.BalanceIndex && cfg.DropTxIndex {
+		err := fmt.Errorf("%s: the --balanceindex and --droptxindex "+
+			"options may not be activated at the same time "+
+			"because the balance index relies on the transaction "+
+			"index", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --prune does not mix with --txindex or the indexes which rely on it
+	// since they serve transactions from the stored blocks.
+	if cfg.Prune != 0 && (cfg.TxIndex || cfg.AddrIndex || cfg.BalanceIndex) {
+		err := fmt.Errorf("%s: the --prune option may not be "+
+			"activated along with the --txindex, --addrindex, or "+
+			"--balanceindex options because the indexes rely on the data of all "+
+			"blocks", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Ensure the prune target leaves room for the blocks which are never
+	// pruned.
+	minPruneTarget := uint(blockchain.MinPruneTarget / 1024 / 1024)
+	if cfg.Prune != 0 && cfg.Prune < minPruneTarget {
+		err := fmt.Errorf("%s: the --prune option must be at least "+
+			"%d MiB -- parsed [%d]", funcName, minPruneTarget,
+			cfg.Prune)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// !--noexistsaddrindex and --dropexistsaddrindex do not mix.
 	if !cfg.NoExistsAddrIndex && cfg.DropExistsAddrIndex {
 		err := fmt.Errorf("dropexistsaddrindex cannot be activated when " +
@@ -744,6 +1116,35 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --windowaggindex and --dropwindowaggindex do not mix.
+	if cfg.WindowAggIndex && cfg.DropWindowAggIndex {
+		err := fmt.Errorf("%s: the --windowaggindex and "+
+			"--dropwindowaggindex options may not be activated at "+
+			"the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --spendindex and --dropspendindex do not mix.
+	if cfg.SpendIndex && cfg.DropSpendIndex {
+		err := fmt.Errorf("%s: the --spendindex and --dropspendindex "+
+			"options may not be activated at the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --balanceindex and --dropbalanceindex do not mix.
+	if cfg.BalanceIndex && cfg.DropBalanceIndex {
+		err := fmt.Errorf("%s: the --balanceindex and "+
+			"--dropbalanceindex options may not be activated at the "+
+			"same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Check getwork keys are valid and saved parsed versions.
 	cfg.miningAddrs = make([]dcrutil.Address, 0, len(cfg.GetWorkKeys)+
 		len(cfg.MiningAddrs))
@@ -845,6 +1246,13 @@ func loadConfig() (*config, []string, error) {
 	cfg.ConnectPeers = normalizeAddresses(cfg.ConnectPeers,
 		activeNetParams.DefaultPort)
 
+	// Add default port to all trusted TLS peer addresses and listener
+	// addresses if needed and remove duplicate addresses.
+	cfg.TLSPeers = normalizeAddresses(cfg.TLSPeers,
+		activeNetParams.DefaultPort)
+	cfg.TLSPeerListeners = normalizeAddresses(cfg.TLSPeerListeners,
+		activeNetParams.DefaultPort)
+
 	// Tor stream isolation requires either proxy or onion proxy to be set.
 	if cfg.TorIsolation && cfg.Proxy == "" && cfg.OnionProxy == "" {
 		str := "%s: Tor stream isolation requires either proxy or " +
@@ -946,6 +1354,26 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Setup the router which selects the dial function for each address.
+	// The configured dial routes take precedence over the onion route, and
+	// all other addresses are dialed with the normal dial function.
+	cfg.dialRouter = connmgr.NewDialRouter(cfg.dial)
+	for _, rule := range cfg.DialRoutes {
+		destination, dialers, err := parseDialRoute(rule,
+			cfg.TorIsolation)
+		if err == nil {
+			err = cfg.dialRouter.AddRoute(destination, dialers...)
+		}
+		if err != nil {
+			str := "%s: invalid dial route %q: %v"
+			err := fmt.Errorf(str, funcName, rule, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+	cfg.dialRouter.AddRoute(".onion", cfg.oniondial)
+
 	// Warn if old testnet directory is present.
 	for _, oldDir := range oldTestNets {
 		if fileExists(oldDir) {
@@ -1032,16 +1460,45 @@ func createDefaultConfigFile(destinationPath string) error {
 	return nil
 }
 
+// parseDialRoute parses a dial route rule of the form
+// <destination>=<proxy>[,<proxy>...] into the destination and the dial
+// functions for the listed proxies.  The proxy direct connects without a
+// proxy.
+func parseDialRoute(rule string, torIsolation bool) (string, []connmgr.DialFunc, error) {
+	parts := strings.SplitN(rule, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", nil, errors.New("no proxies specified")
+	}
+
+	proxies := strings.Split(parts[1], ",")
+	dialers := make([]connmgr.DialFunc, 0, len(proxies))
+	for _, proxyAddr := range proxies {
+		if proxyAddr == "direct" {
+			dialers = append(dialers, net.Dial)
+			continue
+		}
+		if _, _, err := net.SplitHostPort(proxyAddr); err != nil {
+			return "", nil, fmt.Errorf("invalid proxy %q: %v",
+				proxyAddr, err)
+		}
+		proxy := &socks.Proxy{
+			Addr:         proxyAddr,
+			TorIsolation: torIsolation,
+		}
+		dialers = append(dialers, proxy.Dial)
+	}
+
+	return parts[0], dialers, nil
+}
+
 // dcrdDial connects to the address on the named network using the appropriate
 // dial function depending on the address and configuration options.  For
-// example, .onion addresses will be dialed using the onion specific proxy if
-// one was specified, but will otherwise use the normal dial function (which
-// could itself use a proxy or not).
+// example, addresses which match a dial route will be dialed using the proxies
+// of the route, and .onion addresses will be dialed using the onion specific
+// proxy if one was specified, but will otherwise use the normal dial function
+// (which could itself use a proxy or not).
 func dcrdDial(addr net.Addr) (net.Conn, error) {
-	if strings.Contains(addr.String(), ".onion:") {
-		return cfg.oniondial(addr.Network(), addr.String())
-	}
-	return cfg.dial(addr.Network(), addr.String())
+	return cfg.dialRouter.Dial(addr.Network(), addr.String())
 }
 
 // dcrdLookup returns the correct DNS lookup function to use depending on the
@@ -0,0 +1,132 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/mempool"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// confirmEstWindow is the number of recent main chain blocks examined
+	// to determine the space available to regular transactions in future
+	// blocks.
+	confirmEstWindow = 24
+
+	// confirmEstFullFillRate is the fraction of the space available to
+	// regular transactions which must be used for a block to be considered
+	// full.  The regular transactions in full blocks are limited by the
+	// space available to them rather than by the transactions on hand.
+	confirmEstFullFillRate = 0.95
+
+	// defaultConfirmEstBlocks is the default number of blocks to estimate
+	// the confirmation probability for.
+	defaultConfirmEstBlocks = 6
+
+	// maxConfirmEstBlocks is the maximum number of blocks to estimate the
+	// confirmation probability for.
+	maxConfirmEstBlocks = 144
+)
+
+// blockSpaceSample describes the space used by and available to regular
+// transactions in a block.
+type blockSpaceSample struct {
+	// capacity is the number of bytes of regular transactions the block
+	// could have held.  It is the number of bytes actually used when the
+	// block is full.
+	capacity float64
+
+	// fillRate is the fraction of the space available to regular
+	// transactions that was used.
+	fillRate float64
+}
+
+// newBlockSpaceSample returns the space sample for a block with the passed
+// serialized size and total size of the regular transactions other than the
+// coinbase.  The space available to regular transactions is what remains of
+// the maximum block size after the rest of the block, which is dominated by the
+// stake transactions.
+func newBlockSpaceSample(blockSize, regularSize, maxBlockSize int64) blockSpaceSample {
+	available := maxBlockSize - (blockSize - regularSize)
+	if available < regularSize {
+		// The block was created with a larger maximum size.
+		available = regularSize
+	}
+	if available <= 0 {
+		return blockSpaceSample{fillRate: 1}
+	}
+
+	fillRate := float64(regularSize) / float64(available)
+	capacity := float64(available)
+	if fillRate >= confirmEstFullFillRate {
+		capacity = float64(regularSize)
+	}
+	return blockSpaceSample{capacity: capacity, fillRate: fillRate}
+}
+
+// confirmProbabilities returns the probability that a transaction is confirmed
+// within each of the next numBlocks blocks given the number of bytes of
+// transactions ahead of it, including the transaction itself, and samples of
+// the space available to regular transactions in recent blocks.  The space in
+// future blocks is modeled as independent draws from the samples, and the total
+// space of several blocks is approximated by a normal distribution.  The
+// probabilities are cumulative, so the final one is the probability that the
+// transaction is confirmed at all within numBlocks blocks.  Transactions which
+// arrive later and pay a higher fee rate are not accounted for.
+func confirmProbabilities(bytesAhead int64, samples []blockSpaceSample, numBlocks int) []float64 {
+	probabilities := make([]float64, numBlocks)
+	if len(samples) == 0 {
+		return probabilities
+	}
+
+	var sum, sumSq float64
+	for _, sample := range samples {
+		sum += sample.capacity
+		sumSq += sample.capacity * sample.capacity
+	}
+	n := float64(len(samples))
+	mean := sum / n
+	variance := sumSq/n - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+
+	need := float64(bytesAhead)
+	for i := range probabilities {
+		blocks := float64(i + 1)
+		expected := mean * blocks
+		stdDev := math.Sqrt(variance * blocks)
+		if stdDev == 0 {
+			if expected >= need {
+				probabilities[i] = 1
+			}
+			continue
+		}
+		z := (need - expected) / stdDev
+		probabilities[i] = 0.5 * math.Erfc(z/math.Sqrt2)
+	}
+	return probabilities
+}
+
+// mempoolBytesAhead returns the total size of the regular transactions in the
+// memory pool which pay at least the passed fee rate in atoms per kilobyte.
+// Block templates include regular transactions in fee rate order, so these
+// transactions are mined before a transaction paying the fee rate.
+func mempoolBytesAhead(txDescs []*mempool.TxDesc, feeRate dcrutil.Amount) int64 {
+	var bytesAhead int64
+	for _, txD := range txDescs {
+		if txD.Type != stake.TxTypeRegular {
+			continue
+		}
+		txSize := int64(txD.Tx.MsgTx().SerializeSize())
+		if dcrutil.Amount(txD.Fee*1000/txSize) >= feeRate {
+			bytesAhead += txSize
+		}
+	}
+	return bytesAhead
+}
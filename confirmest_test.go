@@ -0,0 +1,70 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestConfirmProbabilities ensures the space available to regular transactions
+// is determined from recent blocks as expected and that the confirmation
+// probabilities reflect the transactions ahead of a transaction.
+func TestConfirmProbabilities(t *testing.T) {
+	const maxBlockSize = 1000
+
+	// A block which is not full could have held all of the space left by
+	// the rest of the block, while a full block could only hold what it
+	// did.
+	sample := newBlockSpaceSample(600, 200, maxBlockSize)
+	if sample.capacity != 600 || sample.fillRate != 200.0/600 {
+		t.Fatalf("newBlockSpaceSample: unexpected sample for block "+
+			"which is not full: %+v", sample)
+	}
+	sample = newBlockSpaceSample(990, 590, maxBlockSize)
+	if sample.capacity != 590 {
+		t.Fatalf("newBlockSpaceSample: unexpected sample for full "+
+			"block: %+v", sample)
+	}
+	sample = newBlockSpaceSample(1200, 1000, maxBlockSize)
+	if sample.capacity != 1000 || sample.fillRate != 1 {
+		t.Fatalf("newBlockSpaceSample: unexpected sample for block "+
+			"larger than the maximum size: %+v", sample)
+	}
+
+	// With a constant capacity the probabilities are certain.
+	constant := []blockSpaceSample{{capacity: 500}, {capacity: 500}}
+	got := confirmProbabilities(1200, constant, 4)
+	want := []float64{0, 0, 1, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("confirmProbabilities: got %v, want %v", got, want)
+		}
+	}
+
+	// With a varying capacity the probabilities increase with the number
+	// of blocks, and the probability of the expected capacity of a block
+	// being enough is one half.
+	varying := []blockSpaceSample{{capacity: 400}, {capacity: 600}}
+	got = confirmProbabilities(500, varying, 3)
+	if math.Abs(got[0]-0.5) > 1e-9 {
+		t.Fatalf("confirmProbabilities: got %v for the expected "+
+			"capacity, want 0.5", got[0])
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Fatalf("confirmProbabilities: probabilities %v do not "+
+				"increase", got)
+		}
+	}
+
+	// Nothing can be estimated without any recent blocks.
+	for _, probability := range confirmProbabilities(1, nil, 2) {
+		if probability != 0 {
+			t.Fatalf("confirmProbabilities: got probability %v "+
+				"without samples", probability)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DialFunc connects to the address on the named network.
+type DialFunc func(network, address string) (net.Conn, error)
+
+// lanNets houses the networks of the addresses which are not reachable from
+// the public internet and therefore matched by the LAN destination.
+var lanNets = func() []*net.IPNet {
+	cidrs := []string{
+		"10.0.0.0/8",     // RFC1918
+		"172.16.0.0/12",  // RFC1918
+		"192.168.0.0/16", // RFC1918
+		"127.0.0.0/8",    // RFC1122 loopback
+		"169.254.0.0/16", // RFC3927 link-local
+		"::1/128",        // RFC4291 loopback
+		"fc00::/7",       // RFC4193 unique local
+		"fe80::/10",      // RFC4862 link-local
+	}
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}()
+
+// dialRoute associates the destinations it matches with the dial functions
+// used to connect to them.
+type dialRoute struct {
+	nets    []*net.IPNet
+	suffix  string
+	dialers []DialFunc
+}
+
+// matches returns whether the passed host, which is either an IP address or a
+// host name, is a destination of the route.
+func (r *dialRoute) matches(host string) bool {
+	if r.suffix != "" {
+		return strings.HasSuffix(strings.ToLower(host), r.suffix)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range r.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// DialRouter connects to addresses with the dial functions of the first route
+// which matches the destination, and with a default dial function when none do.
+// A route may have several dial functions, such as multiple proxies, which are
+// tried in order until one of them connects.
+type DialRouter struct {
+	routes      []dialRoute
+	defaultDial DialFunc
+}
+
+// NewDialRouter returns a new dial router without any routes which connects to
+// all addresses with the passed default dial function.
+func NewDialRouter(defaultDial DialFunc) *DialRouter {
+	return &DialRouter{defaultDial: defaultDial}
+}
+
+// AddRoute adds a route for the passed destination which connects with the
+// passed dial functions in order.  Routes are matched in the order they were
+// added.
+//
+// The destination is either a network in CIDR notation, such as 10.0.0.0/8, a
+// host name suffix which starts with a period, such as .onion, or lan, which
+// matches private, loopback, and link-local addresses.
+func (r *DialRouter) AddRoute(destination string, dialers ...DialFunc) error {
+	if len(dialers) == 0 {
+		return errors.New("a route requires at least one dial function")
+	}
+
+	route := dialRoute{dialers: dialers}
+	switch {
+	case destination == "lan":
+		route.nets = lanNets
+	case strings.HasPrefix(destination, "."):
+		if len(destination) == 1 {
+			return fmt.Errorf("invalid route destination %q", destination)
+		}
+		route.suffix = strings.ToLower(destination)
+	default:
+		_, ipNet, err := net.ParseCIDR(destination)
+		if err != nil {
+			return fmt.Errorf("invalid route destination %q: %v",
+				destination, err)
+		}
+		route.nets = []*net.IPNet{ipNet}
+	}
+	r.routes = append(r.routes, route)
+	return nil
+}
+
+// Dial connects to the address on the named network with the dial functions of
+// the first route which matches the host of the address.  The error of the last
+// dial function is returned when none of them connect.
+//
+// This function is safe for concurrent access once all routes are added.
+func (r *DialRouter) Dial(network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range r.routes {
+		route := &r.routes[i]
+		if !route.matches(host) {
+			continue
+		}
+
+		for _, dial := range route.dialers {
+			var conn net.Conn
+			conn, err = dial(network, address)
+			if err == nil {
+				return conn, nil
+			}
+			log.Debugf("Failed to dial %s: %v", address, err)
+		}
+		return nil, err
+	}
+
+	return r.defaultDial(network, address)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// TestDialRouter ensures the dial router connects to destinations with the
+// dial functions of the first matching route, fails over between them, and
+// falls back to the default dial function.
+func TestDialRouter(t *testing.T) {
+	// namedDialer returns a dial function which records its name as the
+	// most recently used dialer and fails when requested.
+	var used string
+	namedDialer := func(name string, fail bool) DialFunc {
+		return func(network, address string) (net.Conn, error) {
+			used = name
+			if fail {
+				return nil, errors.New(name + " failed")
+			}
+			return mockDialer(&mockAddr{network, address})
+		}
+	}
+
+	router := NewDialRouter(namedDialer("default", false))
+	routes := []struct {
+		destination string
+		dialers     []DialFunc
+	}{
+		{".onion", []DialFunc{namedDialer("tor", false)}},
+		{".i2p", []DialFunc{namedDialer("i2p1", true),
+			namedDialer("i2p2", false)}},
+		{"lan", []DialFunc{namedDialer("direct", false)}},
+		{"203.0.113.0/24", []DialFunc{namedDialer("down1", true),
+			namedDialer("down2", true)}},
+	}
+	for _, route := range routes {
+		err := router.AddRoute(route.destination, route.dialers...)
+		if err != nil {
+			t.Fatalf("AddRoute(%s): unexpected error: %v",
+				route.destination, err)
+		}
+	}
+
+	tests := []struct {
+		address string
+		dialer  string
+		fail    bool
+	}{
+		{"abcdefghijklmnop.onion:9108", "tor", false},
+		{"ABCDEFGHIJKLMNOP.ONION:9108", "tor", false},
+		{"peer.i2p:9108", "i2p2", false},
+		{"192.168.1.10:9108", "direct", false},
+		{"[fe80::1]:9108", "direct", false},
+		{"203.0.113.7:9108", "down2", true},
+		{"198.51.100.1:9108", "default", false},
+		{"seed.example.com:9108", "default", false},
+	}
+	for _, test := range tests {
+		used = ""
+		conn, err := router.Dial("tcp", test.address)
+		if test.fail != (err != nil) {
+			t.Errorf("Dial(%s): unexpected error: %v", test.address, err)
+			continue
+		}
+		if conn != nil {
+			conn.Close()
+		}
+		if used != test.dialer {
+			t.Errorf("Dial(%s): used dialer %q, want %q", test.address,
+				used, test.dialer)
+		}
+	}
+
+	// Ensure invalid routes are rejected.
+	invalid := []string{"", ".", "10.0.0.0", "example.com"}
+	for _, destination := range invalid {
+		err := router.AddRoute(destination, namedDialer("x", false))
+		if err == nil {
+			t.Errorf("AddRoute(%q): did not receive expected error",
+				destination)
+		}
+	}
+	if err := router.AddRoute(".onion"); err == nil {
+		t.Error("AddRoute: did not receive expected error for a route " +
+			"without dial functions")
+	}
+}
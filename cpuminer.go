@@ -338,7 +338,9 @@ out:
 		// Create a new block template using the available transactions
 		// in the memory pool as a source of transactions to potentially
 		// include in the block.
-		template, err := NewBlockTemplate(m.policy, m.server, payToAddr)
+		minVotes, _ := templateVoteRequirement(m.server, 0)
+		template, err := NewBlockTemplate(m.policy, m.server, payToAddr,
+			minVotes)
 		m.submitBlockLock.Unlock()
 		if err != nil {
 			errStr := fmt.Sprintf("Failed to create new block "+
@@ -616,8 +618,11 @@ func (m *CPUMiner) GenerateNBlocks(n uint32) ([]*chainhash.Hash, error) {
 
 		// Create a new block template using the available transactions
 		// in the memory pool as a source of transactions to potentially
-		// include in the block.
-		template, err := NewBlockTemplate(m.policy, m.server, payToAddr)
+		// include in the block.  The vote wait is not applied here
+		// since the requested blocks are expected to be generated
+		// immediately.
+		template, err := NewBlockTemplate(m.policy, m.server, payToAddr,
+			minVotesRequired(m.server.chainParams))
 		m.submitBlockLock.Unlock()
 		if err != nil {
 			errStr := fmt.Sprintf("Failed to create new block "+
@@ -0,0 +1,251 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/blockchain"
+)
+
+const (
+	// crashLogTailSize is the maximum number of bytes from the end of the
+	// log file which are included in a crash diagnostics bundle.
+	crashLogTailSize = 1024 * 1024
+
+	// crashTipStateTimeout is how long to wait for the state of the best
+	// chain when writing a crash diagnostics bundle.  The panic may have
+	// happened while the chain state lock was held, in which case the
+	// state is not available.
+	crashTipStateTimeout = 5 * time.Second
+
+	// crashRedacted replaces the values of configuration options which
+	// carry credentials in a crash diagnostics bundle.
+	crashRedacted = "<redacted>"
+)
+
+// crashReport describes a panic which was recovered from a goroutine of a
+// subsystem.
+type crashReport struct {
+	subsystem string
+	value     interface{}
+	stack     []byte
+	time      time.Time
+}
+
+// recoverCrash recovers from a panic in the goroutine of the passed subsystem,
+// writes a crash diagnostics bundle to the data directory, and exits.  The
+// bundle houses the panic along with the stack of the goroutine which panicked,
+// the stacks of all goroutines, the state of the best chain, the end of the
+// log file, and the configuration with all credentials redacted, which makes
+// crash reports from the field actionable.
+//
+// It must be deferred directly by the goroutine since a panic can only be
+// recovered from a deferred function.  The chain may be nil when the
+// subsystem does not have access to it.
+func recoverCrash(subsystem string, chain *blockchain.BlockChain) {
+	if r := recover(); r != nil {
+		handleCrash(subsystem, r, chain)
+	}
+}
+
+// handleCrash writes a crash diagnostics bundle for the passed value of a panic
+// recovered from a goroutine of the passed subsystem and exits.  It is used by
+// subsystems which recover panics themselves and must be called on the
+// goroutine which panicked so the bundle houses its stack.
+func handleCrash(subsystem string, r interface{}, chain *blockchain.BlockChain) {
+	report := &crashReport{
+		subsystem: subsystem,
+		value:     r,
+		stack:     debug.Stack(),
+		time:      time.Now(),
+	}
+
+	dcrdLog.Criticalf("Unrecovered panic in %s: %v\n%s", subsystem, r,
+		report.stack)
+	logFile := filepath.Join(cfg.LogDir, defaultLogFilename)
+	bundle, err := writeCrashBundle(cfg.DataDir, logFile, report, chain)
+	if err != nil {
+		dcrdLog.Criticalf("Unable to write crash diagnostics: %v", err)
+	} else {
+		dcrdLog.Criticalf("Crash diagnostics written to %s", bundle)
+	}
+	backendLog.Flush()
+	os.Exit(1)
+}
+
+// writeCrashBundle writes a crash diagnostics bundle for the passed report to a
+// zip archive in the passed directory and returns its path.  The log is flushed
+// first so the end of the passed log file includes the messages leading up to
+// the panic.
+func writeCrashBundle(dir, logFile string, report *crashReport, chain *blockchain.BlockChain) (string, error) {
+	backendLog.Flush()
+
+	fileName := fmt.Sprintf("crash-%s-%s.zip", strings.ToLower(
+		report.subsystem), report.time.UTC().Format("20060102-150405"))
+	bundlePath := filepath.Join(dir, fileName)
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		return "", err
+	}
+
+	var panicInfo bytes.Buffer
+	fmt.Fprintf(&panicInfo, "Subsystem: %s\n", report.subsystem)
+	fmt.Fprintf(&panicInfo, "Time: %s\n", report.time.UTC().Format(
+		time.RFC3339))
+	fmt.Fprintf(&panicInfo, "Version: %s\n", version())
+	fmt.Fprintf(&panicInfo, "Go version: %s %s/%s\n", runtime.Version(),
+		runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&panicInfo, "Panic: %v\n\n%s", report.value, report.stack)
+
+	var goroutines bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&goroutines, 2)
+
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{"panic.txt", panicInfo.Bytes()},
+		{"goroutines.txt", goroutines.Bytes()},
+		{"tip.txt", crashTipState(chain)},
+		{"config.txt", crashConfigSnapshot(cfg)},
+		{"log.txt", crashLogTail(logFile)},
+	}
+	zw := zip.NewWriter(f)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.name)
+		if err == nil {
+			_, err = w.Write(entry.data)
+		}
+		if err != nil {
+			f.Close()
+			return "", err
+		}
+	}
+	err = zw.Close()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return bundlePath, nil
+}
+
+// crashTipState returns a description of the state of the passed best chain.
+// A note is returned instead when the chain is nil or its state is not
+// available in time.
+func crashTipState(chain *blockchain.BlockChain) []byte {
+	if chain == nil {
+		return []byte("The state of the chain is not available to the " +
+			"subsystem.\n")
+	}
+
+	snapshots := make(chan *blockchain.BestState, 1)
+	go func() {
+		snapshots <- chain.BestSnapshot()
+	}()
+	var best *blockchain.BestState
+	select {
+	case best = <-snapshots:
+	case <-time.After(crashTipStateTimeout):
+		return []byte("Timed out waiting for the state of the chain, " +
+			"which is likely locked by the goroutine which panicked.\n")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Hash: %v\n", best.Hash)
+	fmt.Fprintf(&buf, "Height: %d\n", best.Height)
+	fmt.Fprintf(&buf, "Bits: %08x\n", best.Bits)
+	fmt.Fprintf(&buf, "Block size: %d\n", best.BlockSize)
+	fmt.Fprintf(&buf, "Transactions: %d\n", best.NumTxns)
+	fmt.Fprintf(&buf, "Total transactions: %d\n", best.TotalTxns)
+	fmt.Fprintf(&buf, "Total subsidy: %d\n", best.TotalSubsidy)
+	fmt.Fprintf(&buf, "Work sum: %v\n", best.WorkSum)
+	return buf.Bytes()
+}
+
+// isCrashRedactedOption returns whether or not the value of the configuration
+// option with the passed field tag carries credentials and must be redacted.
+// Passwords are masked from the help output already, and the user names which
+// accompany them are redacted as well.
+func isCrashRedactedOption(tag reflect.StructTag) bool {
+	if _, ok := tag.Lookup("default-mask"); ok {
+		return true
+	}
+	return strings.HasSuffix(tag.Get("long"), "user")
+}
+
+// crashConfigSnapshot returns the values of all options of the passed
+// configuration, one option per line in the format of the configuration file,
+// with the values of the options which carry credentials redacted.
+func crashConfigSnapshot(c *config) []byte {
+	if c == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("long")
+		if field.PkgPath != "" || name == "" {
+			continue
+		}
+		value := v.Field(i)
+		redact := isCrashRedactedOption(field.Tag)
+		write := func(value reflect.Value) {
+			s := fmt.Sprint(value.Interface())
+			if redact && s != "" {
+				s = crashRedacted
+			}
+			fmt.Fprintf(&buf, "%s=%s\n", name, s)
+		}
+		if value.Kind() == reflect.Slice {
+			for j := 0; j < value.Len(); j++ {
+				write(value.Index(j))
+			}
+			continue
+		}
+		write(value)
+	}
+	return buf.Bytes()
+}
+
+// crashLogTail returns at most the final crashLogTailSize bytes of the passed
+// log file, or a note when it can not be read.
+func crashLogTail(logFile string) []byte {
+	f, err := os.Open(logFile)
+	if err != nil {
+		return []byte(fmt.Sprintf("Unable to read the log: %v\n", err))
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return []byte(fmt.Sprintf("Unable to read the log: %v\n", err))
+	}
+	if offset := fi.Size() - crashLogTailSize; offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return []byte(fmt.Sprintf("Unable to read the log: %v\n",
+				err))
+		}
+	}
+	var buf bytes.Buffer
+	buf.ReadFrom(io.LimitReader(f, crashLogTailSize))
+	return buf.Bytes()
+}
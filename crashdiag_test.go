@@ -0,0 +1,110 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCrashBundle ensures crash diagnostics bundles include all diagnostics
+// along with the end of the log file, and redact the values of configuration
+// options which carry credentials.
+func TestCrashBundle(t *testing.T) {
+	c := &config{
+		RPCUser:      "rpcuser",
+		RPCPass:      "rpcsecret",
+		RPCLimitPass: "",
+		ProxyPass:    "proxysecret",
+		MaxPeers:     8,
+		AddPeers:     []string{"127.0.0.1:9108", "127.0.0.2:9108"},
+	}
+	snapshot := string(crashConfigSnapshot(c))
+	for _, want := range []string{"rpcuser=<redacted>\n",
+		"rpcpass=<redacted>\n", "rpclimitpass=\n", "proxypass=<redacted>\n",
+		"maxpeers=8\n", "addpeer=127.0.0.1:9108\n",
+		"addpeer=127.0.0.2:9108\n"} {
+
+		if !strings.Contains(snapshot, want) {
+			t.Errorf("crashConfigSnapshot: missing %q in snapshot:\n%s",
+				want, snapshot)
+		}
+	}
+	for _, secret := range []string{"rpcsecret", "proxysecret"} {
+		if strings.Contains(snapshot, secret) {
+			t.Errorf("crashConfigSnapshot: secret %q not redacted", secret)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "crashdiag")
+	if err != nil {
+		t.Fatalf("TempDir: unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Only the end of the log file must be included.
+	logFile := filepath.Join(dir, "dcrd.log")
+	logData := strings.Repeat("x", crashLogTailSize) + "last message\n"
+	if err := ioutil.WriteFile(logFile, []byte("first message\n"+logData),
+		0600); err != nil {
+
+		t.Fatalf("WriteFile: unexpected error: %v", err)
+	}
+
+	report := &crashReport{
+		subsystem: "BMGR",
+		value:     "test panic",
+		stack:     []byte("test stack"),
+		time:      time.Date(2017, 6, 1, 12, 30, 0, 0, time.UTC),
+	}
+	bundle, err := writeCrashBundle(dir, logFile, report, nil)
+	if err != nil {
+		t.Fatalf("writeCrashBundle: unexpected error: %v", err)
+	}
+	if want := filepath.Join(dir, "crash-bmgr-20170601-123000.zip"); bundle != want {
+		t.Fatalf("writeCrashBundle: unexpected bundle path - got %s, "+
+			"want %s", bundle, want)
+	}
+
+	zr, err := zip.OpenReader(bundle)
+	if err != nil {
+		t.Fatalf("OpenReader: unexpected error: %v", err)
+	}
+	defer zr.Close()
+	entries := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open %s: unexpected error: %v", f.Name, err)
+		}
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("ReadAll %s: unexpected error: %v", f.Name, err)
+		}
+		entries[f.Name] = string(data)
+	}
+	for _, name := range []string{"panic.txt", "goroutines.txt", "tip.txt",
+		"config.txt", "log.txt"} {
+
+		if _, ok := entries[name]; !ok {
+			t.Errorf("writeCrashBundle: missing entry %s", name)
+		}
+	}
+	if !strings.Contains(entries["panic.txt"], "Panic: test panic") ||
+		!strings.Contains(entries["panic.txt"], "test stack") {
+
+		t.Errorf("writeCrashBundle: unexpected panic entry:\n%s",
+			entries["panic.txt"])
+	}
+	if entries["log.txt"] != logData[len(logData)-crashLogTailSize:] {
+		t.Errorf("writeCrashBundle: log entry is not the end of the log")
+	}
+}
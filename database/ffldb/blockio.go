@@ -16,6 +16,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
@@ -168,6 +170,11 @@ type blockStore struct {
 	fileNumToLRUElem map[uint32]*list.Element
 	openBlockFiles   map[uint32]*lockableFile
 
+	// firstFileNum is the number of the oldest block file which has not
+	// been removed by pruning the stored blocks.  It is protected by the
+	// overall files mutex (obfMutex).
+	firstFileNum uint32
+
 	// writeCursor houses the state for the current file and location that
 	// new blocks are written to.
 	writeCursor *writeCursor
@@ -333,8 +340,15 @@ func (s *blockStore) blockFile(fileNum uint32) (*lockableFile, error) {
 	}
 	wc.RUnlock()
 
-	// Try to return an open file under the overall files read lock.
+	// Try to return an open file under the overall files read lock.  The
+	// blocks in files older than the oldest file which remains have been
+	// pruned.
 	s.obfMutex.RLock()
+	if fileNum < s.firstFileNum {
+		s.obfMutex.RUnlock()
+		str := fmt.Sprintf("block file %d has been pruned", fileNum)
+		return nil, makeDbErr(database.ErrBlockPruned, str, nil)
+	}
 	if obf, ok := s.openBlockFiles[fileNum]; ok {
 		s.lruMutex.Lock()
 		s.openBlocksLRU.MoveToFront(s.fileNumToLRUElem[fileNum])
@@ -528,6 +542,17 @@ func (s *blockStore) readBlock(hash *chainhash.Hash, loc blockLocation) ([]byte,
 		return nil, makeDbErr(database.ErrDriverSpecific, str, err)
 	}
 
+	return s.verifyBlockRecord(hash, serializedData[:n])
+}
+
+// verifyBlockRecord ensures the checksum and network of the passed block record,
+// as stored in the flat files, are valid and returns the raw block it contains.
+//
+// Returns ErrCorruption if the checksum does not match and ErrDriverSpecific if
+// the block is for a different network.
+func (s *blockStore) verifyBlockRecord(hash *chainhash.Hash, serializedData []byte) ([]byte, error) {
+	n := len(serializedData)
+
 	// Calculate the checksum of the read data and ensure it matches the
 	// serialized checksum.  This will detect any data corruption in the
 	// flat file without having to do much more expensive merkle root
@@ -621,6 +646,80 @@ func (s *blockStore) syncBlocks() error {
 	return nil
 }
 
+// storedFileSizes returns the number of the oldest flat block file which has
+// not been pruned along with the sizes of it and all newer files through the
+// current write file.  The size of the current write file is taken from the
+// write cursor.
+//
+// This function MUST be called with the database write lock held so the write
+// cursor does not change.
+func (s *blockStore) storedFileSizes() (uint32, []uint64, error) {
+	s.obfMutex.RLock()
+	firstFileNum := s.firstFileNum
+	s.obfMutex.RUnlock()
+
+	wc := s.writeCursor
+	wc.RLock()
+	curFileNum, curOffset := wc.curFileNum, wc.curOffset
+	wc.RUnlock()
+
+	sizes := make([]uint64, 0, curFileNum-firstFileNum+1)
+	for fileNum := firstFileNum; fileNum < curFileNum; fileNum++ {
+		st, err := os.Stat(blockFilePath(s.basePath, fileNum))
+		if err != nil {
+			return 0, nil, makeDbErr(database.ErrDriverSpecific,
+				err.Error(), err)
+		}
+		sizes = append(sizes, uint64(st.Size()))
+	}
+	sizes = append(sizes, uint64(curOffset))
+
+	return firstFileNum, sizes, nil
+}
+
+// pruneFiles removes all flat block files older than the passed file number,
+// which MUST NOT be newer than the current write file.  Any open handles to the
+// files are closed, and reading blocks which were stored in them results in
+// ErrBlockPruned afterwards.
+//
+// This function MUST be called with the database write lock held.
+func (s *blockStore) pruneFiles(firstFileNum uint32) error {
+	// Mark the files pruned and close any open handles to them under the
+	// overall files write lock so readers are not able to open them again.
+	s.obfMutex.Lock()
+	oldFirstFileNum := s.firstFileNum
+	s.firstFileNum = firstFileNum
+	s.lruMutex.Lock()
+	for fileNum := oldFirstFileNum; fileNum < firstFileNum; fileNum++ {
+		blockFile, ok := s.openBlockFiles[fileNum]
+		if !ok {
+			continue
+		}
+
+		// Close the file under the write lock for the file in case any
+		// readers are currently reading from it so it's not closed out
+		// from under them.
+		blockFile.Lock()
+		_ = blockFile.file.Close()
+		blockFile.Unlock()
+
+		s.openBlocksLRU.Remove(s.fileNumToLRUElem[fileNum])
+		delete(s.openBlockFiles, fileNum)
+		delete(s.fileNumToLRUElem, fileNum)
+	}
+	s.lruMutex.Unlock()
+	s.obfMutex.Unlock()
+
+	for fileNum := oldFirstFileNum; fileNum < firstFileNum; fileNum++ {
+		log.Debugf("Removing pruned block file %d", fileNum)
+		if err := s.deleteFileFunc(fileNum); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // handleRollback rolls the block files on disk back to the provided file number
 // and offset.  This involves potentially deleting and truncating the files that
 // were partially written.
@@ -718,14 +817,28 @@ func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
 }
 
 // scanBlockFiles searches the database directory for all flat block files to
-// find the end of the most recent file.  This position is considered the
-// current write cursor which is also stored in the metadata.  Thus, it is used
-// to detect unexpected shutdowns in the middle of writes so the block files
-// can be reconciled.
-func scanBlockFiles(dbPath string) (int, uint32) {
+// find the oldest file and the end of the most recent file.  This position is
+// considered the current write cursor which is also stored in the metadata.
+// Thus, it is used to detect unexpected shutdowns in the middle of writes so the
+// block files can be reconciled.
+func scanBlockFiles(dbPath string) (int, int, uint32) {
+	// The oldest files are removed when the stored blocks are pruned, so
+	// start the scan at the oldest file which remains.  The file names are
+	// zero padded, so the matches are sorted by file number.
+	firstFile := 0
+	matches, _ := filepath.Glob(filepath.Join(dbPath, "*.fdb"))
+	for _, match := range matches {
+		fileNum, err := strconv.ParseUint(strings.TrimSuffix(
+			filepath.Base(match), ".fdb"), 10, 32)
+		if err == nil {
+			firstFile = int(fileNum)
+			break
+		}
+	}
+
 	lastFile := -1
 	fileLen := uint32(0)
-	for i := 0; ; i++ {
+	for i := firstFile; ; i++ {
 		filePath := blockFilePath(dbPath, uint32(i))
 		st, err := os.Stat(filePath)
 		if err != nil {
@@ -736,9 +849,9 @@ func scanBlockFiles(dbPath string) (int, uint32) {
 		fileLen = uint32(st.Size())
 	}
 
-	log.Tracef("Scan found latest block file #%d with length %d", lastFile,
-		fileLen)
-	return lastFile, fileLen
+	log.Tracef("Scan found oldest block file #%d and latest block file #%d "+
+		"with length %d", firstFile, lastFile, fileLen)
+	return firstFile, lastFile, fileLen
 }
 
 // newBlockStore returns a new block store with the current block file number
@@ -747,8 +860,9 @@ func newBlockStore(basePath string, network wire.CurrencyNet) *blockStore {
 	// Look for the end of the latest block to file to determine what the
 	// write cursor position is from the viewpoing of the block files on
 	// disk.
-	fileNum, fileOff := scanBlockFiles(basePath)
+	firstFileNum, fileNum, fileOff := scanBlockFiles(basePath)
 	if fileNum == -1 {
+		firstFileNum = 0
 		fileNum = 0
 		fileOff = 0
 	}
@@ -760,6 +874,7 @@ func newBlockStore(basePath string, network wire.CurrencyNet) *blockStore {
 		openBlockFiles:   make(map[uint32]*lockableFile),
 		openBlocksLRU:    list.New(),
 		fileNumToLRUElem: make(map[uint32]*list.Element),
+		firstFileNum:     uint32(firstFileNum),
 
 		writeCursor: &writeCursor{
 			curFile:    &lockableFile{},
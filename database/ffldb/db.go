@@ -981,6 +981,9 @@ type transaction struct {
 // Enforce transaction implements the database.Tx interface.
 var _ database.Tx = (*transaction)(nil)
 
+// Enforce transaction implements the database.MappedBlockFetcher interface.
+var _ database.MappedBlockFetcher = (*transaction)(nil)
+
 // removeActiveIter removes the passed iterator from the list of active
 // iterators against the pending keys treap.
 func (tx *transaction) removeActiveIter(iter *treap.Iterator) {
@@ -1379,6 +1382,46 @@ func (tx *transaction) FetchBlock(hash *chainhash.Hash) ([]byte, error) {
 	return blockBytes, nil
 }
 
+// FetchMappedBlock returns the raw serialized bytes for the block identified by
+// the given hash directly from a read-only memory mapping of the block file
+// which houses it along with a function which releases the mapping.  The raw
+// bytes are in the format returned by Serialize on a wire.MsgBlock.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockNotFound if the requested block hash does not exist
+//   - ErrTxClosed if the transaction has already been closed
+//   - ErrCorruption if the database has somehow become corrupted
+//
+// In addition, returns ErrDriverSpecific if the block is pending to be written
+// on commit or the block files can't be memory mapped.
+//
+// This function is part of the database.MappedBlockFetcher interface
+// implementation.
+func (tx *transaction) FetchMappedBlock(hash *chainhash.Hash) ([]byte, func() error, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, nil, err
+	}
+
+	// Blocks which are pending to be written on commit are not in the
+	// block files yet.
+	if _, exists := tx.pendingBlocks[*hash]; exists {
+		str := fmt.Sprintf("block %s has not been written yet", hash)
+		return nil, nil, makeDbErr(database.ErrDriverSpecific, str, nil)
+	}
+
+	// Lookup the location of the block in the files from the block index.
+	blockRow, err := tx.fetchBlockRow(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	location := deserializeBlockLoc(blockRow)
+
+	// Map the block from the appropriate location.  The function also
+	// performs a checksum over the data to detect data corruption.
+	return tx.db.store.mapBlock(hash, location)
+}
+
 // FetchBlocks returns the raw serialized bytes for the blocks identified by the
 // given hashes.  The raw bytes are in the format returned by Serialize on a
 // wire.MsgBlock.
@@ -1957,6 +2000,100 @@ func (db *db) Update(fn func(database.Tx) error) error {
 	return tx.Commit()
 }
 
+// pruneBlocks removes the flat block files which house the oldest stored blocks
+// until the total size of the block files does not exceed the passed target
+// size in bytes.  See PruneBlocks for details.
+//
+// This function MUST be called with a writable transaction so no blocks are
+// written while the files are pruned.
+func (tx *transaction) pruneBlocks(targetSize uint64, pruneHeight uint32) (uint64, error) {
+	store := tx.db.store
+	firstFileNum, sizes, err := store.storedFileSizes()
+	if err != nil {
+		return 0, err
+	}
+	var remaining uint64
+	for _, size := range sizes {
+		remaining += size
+	}
+	if remaining <= targetSize {
+		return remaining, nil
+	}
+
+	// Determine the maximum height of the blocks housed by each file that
+	// is a candidate for removal.  The current write file is never
+	// removed.
+	curFileNum := firstFileNum + uint32(len(sizes)) - 1
+	maxHeights := make(map[uint32]uint32)
+	err = tx.blockIdxBucket.ForEach(func(k, v []byte) error {
+		loc := deserializeBlockLoc(v)
+		if loc.blockFileNum < firstFileNum || loc.blockFileNum >= curFileNum {
+			return nil
+		}
+
+		var header wire.BlockHeader
+		err := header.Deserialize(bytes.NewReader(v[blockHdrOffset:]))
+		if err != nil {
+			str := fmt.Sprintf("failed to deserialize header for block "+
+				"%x: %v", k, err)
+			return makeDbErr(database.ErrCorruption, str, err)
+		}
+		if header.Height > maxHeights[loc.blockFileNum] {
+			maxHeights[loc.blockFileNum] = header.Height
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Remove the oldest files while the target size is exceeded and the
+	// files only house blocks below the prune height.
+	newFirstFileNum := firstFileNum
+	for remaining > targetSize && newFirstFileNum < curFileNum {
+		if maxHeights[newFirstFileNum] >= pruneHeight {
+			break
+		}
+		remaining -= sizes[newFirstFileNum-firstFileNum]
+		newFirstFileNum++
+	}
+	if newFirstFileNum == firstFileNum {
+		return remaining, nil
+	}
+
+	log.Infof("Pruning block files %d through %d", firstFileNum,
+		newFirstFileNum-1)
+	if err := store.pruneFiles(newFirstFileNum); err != nil {
+		return 0, err
+	}
+
+	return remaining, nil
+}
+
+// PruneBlocks removes the flat block files which house the oldest stored blocks
+// until the total size of the block files does not exceed the passed target
+// size in bytes.  A file is only removed when all of the blocks it houses have
+// a height less than the passed prune height, and the current write file is
+// never removed.  The block index retains the headers of the blocks which were
+// stored in removed files.  It returns the total size of the block files which
+// remain.
+//
+// This function is part of the database.DB interface implementation.
+func (db *db) PruneBlocks(targetSize uint64, pruneHeight uint32) (uint64, error) {
+	// Start a read-write transaction to prevent any blocks from being
+	// written while the files are pruned.  The metadata is not modified,
+	// so the transaction is always rolled back.
+	tx, err := db.begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer rollbackOnPanic(tx)
+
+	remaining, err := tx.pruneBlocks(targetSize, pruneHeight)
+	_ = tx.Rollback()
+	return remaining, err
+}
+
 // Close cleanly shuts down the database and syncs all data.  It will block
 // until all database transactions have been finalized (rolled back or
 // committed).
@@ -0,0 +1,57 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ffldb
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database"
+)
+
+// mapBlock maps the block record at the provided location read-only into
+// memory and returns the raw block it contains along with a function which
+// releases the mapping.  The record is verified the same way as readBlock,
+// however, the block data is never copied, so it remains valid until the
+// release function is called regardless of any database transactions.
+//
+// Returns ErrDriverSpecific if the block file can't be memory mapped.
+func (s *blockStore) mapBlock(hash *chainhash.Hash, loc blockLocation) ([]byte, func() error, error) {
+	// Get the referenced block file handle opening the file as needed.  The
+	// mapping remains valid after the file is closed, so the file may be
+	// closed again to stay within the max allowed open files at any time.
+	blockFile, err := s.blockFile(loc.blockFileNum)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, ok := blockFile.file.(*os.File)
+	if !ok {
+		blockFile.RUnlock()
+		str := fmt.Sprintf("block file %d can't be memory mapped",
+			loc.blockFileNum)
+		return nil, nil, makeDbErr(database.ErrDriverSpecific, str, nil)
+	}
+
+	serializedData, mapping, err := mmapRegion(file, int64(loc.fileOffset),
+		int(loc.blockLen))
+	blockFile.RUnlock()
+	if err != nil {
+		str := fmt.Sprintf("failed to map block %s from file %d, "+
+			"offset %d: %v", hash, loc.blockFileNum, loc.fileOffset,
+			err)
+		return nil, nil, makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	release := func() error {
+		return munmapRegion(mapping)
+	}
+
+	blockBytes, err := s.verifyBlockRecord(hash, serializedData)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return blockBytes, release, nil
+}
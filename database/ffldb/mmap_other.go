@@ -0,0 +1,29 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package ffldb
+
+import (
+	"errors"
+	"os"
+)
+
+// errMmapUnsupported is returned when attempting to memory map a block file on
+// operating systems which are not supported.
+var errMmapUnsupported = errors.New("memory mapped files are not supported " +
+	"on this operating system")
+
+// mmapRegion always returns an error since memory mapped files are not
+// supported on this operating system.
+func mmapRegion(file *os.File, offset int64, length int) ([]byte, []byte, error) {
+	return nil, nil, errMmapUnsupported
+}
+
+// munmapRegion has no effect since memory mapped files are not supported on
+// this operating system.
+func munmapRegion(mapping []byte) error {
+	return nil
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package ffldb
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapRegion maps the passed number of bytes of the file starting at the
+// provided offset read-only into memory.  Mappings must start on a page
+// boundary, so the entire mapping, which must be passed to munmapRegion once
+// the region is no longer used, is returned along with the requested region.
+func mmapRegion(file *os.File, offset int64, length int) ([]byte, []byte, error) {
+	pageOffset := offset % int64(os.Getpagesize())
+	mapping, err := syscall.Mmap(int(file.Fd()), offset-pageOffset,
+		int(pageOffset)+length, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mapping[pageOffset:], mapping, nil
+}
+
+// munmapRegion releases a mapping returned by mmapRegion.
+func munmapRegion(mapping []byte) error {
+	return syscall.Munmap(mapping)
+}
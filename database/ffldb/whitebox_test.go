@@ -682,3 +682,167 @@ func TestFailureScenarios(t *testing.T) {
 	// Test various corruption scenarios.
 	testCorruption(tc)
 }
+
+// TestPruneBlocks ensures pruning the stored blocks removes the oldest block
+// files only while the target size is exceeded and all blocks they house are
+// below the prune height, and that the headers of the pruned blocks remain
+// available.
+func TestPruneBlocks(t *testing.T) {
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-pruneblocks")
+	_ = os.RemoveAll(dbPath)
+	idb, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer func() {
+		idb.Close()
+	}()
+
+	// Change the maximum file size to a small value to force multiple flat
+	// files with the test data set.
+	idb.(*db).store.maxBlockFileSize = 8192 // 8KiB
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("loadBlocks: Unexpected error: %v", err)
+	}
+	for _, block := range blocks {
+		err := idb.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			t.Fatalf("StoreBlock: unexpected error: %v", err)
+		}
+	}
+
+	// Ensure nothing is pruned when the target size is not exceeded or
+	// there are no blocks below the prune height.
+	totalSize, err := idb.PruneBlocks(^uint64(0), uint32(len(blocks)))
+	if err != nil {
+		t.Fatalf("PruneBlocks: unexpected error: %v", err)
+	}
+	remaining, err := idb.PruneBlocks(0, 0)
+	if err != nil {
+		t.Fatalf("PruneBlocks: unexpected error: %v", err)
+	}
+	if remaining != totalSize {
+		t.Fatalf("PruneBlocks: unexpected remaining size -- got %d, "+
+			"want %d", remaining, totalSize)
+	}
+
+	// Prune as many blocks below the prune height as possible and ensure
+	// the oldest blocks were pruned while the newer ones remain.
+	const pruneHeight = 100
+	remaining, err = idb.PruneBlocks(0, pruneHeight)
+	if err != nil {
+		t.Fatalf("PruneBlocks: unexpected error: %v", err)
+	}
+	if remaining >= totalSize {
+		t.Fatalf("PruneBlocks: no blocks were pruned")
+	}
+
+	// checkBlocks ensures the pruned blocks are reported as such while the
+	// headers of all blocks remain available.
+	checkBlocks := func(idb database.DB) {
+		err := idb.View(func(tx database.Tx) error {
+			for height, block := range blocks {
+				if _, err := tx.FetchBlockHeader(block.Hash()); err != nil {
+					t.Errorf("FetchBlockHeader #%d: unexpected "+
+						"error: %v", height, err)
+				}
+				_, err := tx.FetchBlock(block.Hash())
+				switch {
+				case height == 0:
+					testName := "FetchBlock genesis"
+					checkDbError(t, testName, err,
+						database.ErrBlockPruned)
+				case height >= pruneHeight && err != nil:
+					t.Errorf("FetchBlock #%d: unexpected error: %v",
+						height, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("View: unexpected error: %v", err)
+		}
+	}
+	checkBlocks(idb)
+
+	// Ensure the pruned blocks are still reported as such after reopening
+	// the database.
+	if err := idb.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	idb, err = database.Open(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to open test database (%s) %v", dbType, err)
+	}
+	checkBlocks(idb)
+}
+
+// TestFetchMappedBlock ensures the blocks fetched from memory mappings of the
+// block files match the blocks read from them, remain valid after the
+// transaction has ended, and blocks pending to be written are rejected.
+func TestFetchMappedBlock(t *testing.T) {
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-fetchmappedblock")
+	_ = os.RemoveAll(dbPath)
+	idb, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer idb.Close()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("loadBlocks: Unexpected error: %v", err)
+	}
+	for _, block := range blocks {
+		err := idb.Update(func(tx database.Tx) error {
+			if err := tx.StoreBlock(block); err != nil {
+				return err
+			}
+
+			// Ensure the block can't be mapped before it is written.
+			_, _, err := tx.(database.MappedBlockFetcher).
+				FetchMappedBlock(block.Hash())
+			checkDbError(t, "FetchMappedBlock pending", err,
+				database.ErrDriverSpecific)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("StoreBlock: unexpected error: %v", err)
+		}
+	}
+
+	for height, block := range blocks {
+		var blockBytes, mappedBytes []byte
+		var release func() error
+		err := idb.View(func(tx database.Tx) error {
+			var err error
+			blockBytes, err = tx.FetchBlock(block.Hash())
+			if err != nil {
+				return err
+			}
+			blockBytes = append([]byte(nil), blockBytes...)
+
+			mappedBytes, release, err = tx.(database.MappedBlockFetcher).
+				FetchMappedBlock(block.Hash())
+			return err
+		})
+		if err != nil {
+			t.Fatalf("View #%d: unexpected error: %v", height, err)
+		}
+		if !bytes.Equal(mappedBytes, blockBytes) {
+			t.Fatalf("FetchMappedBlock #%d: mismatched block bytes",
+				height)
+		}
+		if err := release(); err != nil {
+			t.Fatalf("release #%d: unexpected error: %v", height, err)
+		}
+	}
+}
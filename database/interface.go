@@ -307,6 +307,7 @@ type Tx interface {
 	// The interface contract guarantees at least the following errors will
 	// be returned (other implementation-specific errors are possible):
 	//   - ErrBlockNotFound if the requested block hash does not exist
+	//   - ErrBlockPruned if the data of the requested block was pruned
 	//   - ErrTxClosed if the transaction has already been closed
 	//   - ErrCorruption if the database has somehow become corrupted
 	//
@@ -325,6 +326,8 @@ type Tx interface {
 	// be returned (other implementation-specific errors are possible):
 	//   - ErrBlockNotFound if the any of the requested block hashes do not
 	//     exist
+	//   - ErrBlockPruned if the data of any of the requested blocks was
+	//     pruned
 	//   - ErrTxClosed if the transaction has already been closed
 	//   - ErrCorruption if the database has somehow become corrupted
 	//
@@ -352,6 +355,7 @@ type Tx interface {
 	//   - ErrBlockNotFound if the requested block hash does not exist
 	//   - ErrBlockRegionInvalid if the region exceeds the bounds of the
 	//     associated block
+	//   - ErrBlockPruned if the data of the associated block was pruned
 	//   - ErrTxClosed if the transaction has already been closed
 	//   - ErrCorruption if the database has somehow become corrupted
 	//
@@ -380,6 +384,8 @@ type Tx interface {
 	//     exist
 	//   - ErrBlockRegionInvalid if one or more region exceed the bounds of
 	//     the associated block
+	//   - ErrBlockPruned if the data of any of the associated blocks was
+	//     pruned
 	//   - ErrTxClosed if the transaction has already been closed
 	//   - ErrCorruption if the database has somehow become corrupted
 	//
@@ -409,6 +415,30 @@ type Tx interface {
 	Rollback() error
 }
 
+// MappedBlockFetcher is an optional interface which may be implemented by the
+// transactions of backends which store blocks in files that can be memory
+// mapped.  It allows serving block data without copying it into memory first.
+type MappedBlockFetcher interface {
+	// FetchMappedBlock returns the raw serialized bytes for the block
+	// identified by the given hash along with a function which must be
+	// called to release them once they are no longer needed.  The raw
+	// bytes are in the format returned by Serialize on a wire.MsgBlock.
+	//
+	// The following errors are required to be returned:
+	//   - ErrBlockNotFound if the requested block hash does not exist
+	//   - ErrTxClosed if the transaction has already been closed
+	//   - ErrCorruption if the database has somehow become corrupted
+	//
+	// Other errors are possible depending on the implementation, in
+	// which case callers are expected to fall back to FetchBlock.
+	//
+	// NOTE: Unlike the data returned by FetchBlock, the data returned by
+	// this function remains valid after the transaction has ended until
+	// the release function is called.  Attempting to access it afterwards
+	// results in undefined behavior.
+	FetchMappedBlock(hash *chainhash.Hash) ([]byte, func() error, error)
+}
+
 // DB provides a generic interface that is used to store blocks and related
 // metadata.  This interface is intended to be agnostic to the actual mechanism
 // used for backend data storage.  The RegisterDriver function can be used to
@@ -460,6 +490,19 @@ type DB interface {
 	// user-supplied function will result in a panic.
 	Update(fn func(tx Tx) error) error
 
+	// PruneBlocks removes the data of the oldest stored blocks until the
+	// total size of the stored block data does not exceed the passed
+	// target size in bytes.  Only the data of blocks with a height less
+	// than the passed prune height is removed, and the headers of removed
+	// blocks remain available.  Depending on the backend implementation,
+	// block data might only be removable in larger units than a single
+	// block.  It returns the total size of the block data which remains
+	// stored.
+	//
+	// Attempting to fetch the data of a removed block afterwards results
+	// in ErrBlockPruned.
+	PruneBlocks(targetSize uint64, pruneHeight uint32) (uint64, error)
+
 	// Close cleanly shuts down the database and syncs all data.  It will
 	// block until all database transactions have been finalized (rolled
 	// back or committed).
@@ -229,6 +229,12 @@ type TemplateRequest struct {
 	// "proposal".
 	Data   string `json:"data,omitempty"`
 	WorkID string `json:"workid,omitempty"`
+
+	// Optional minimum number of votes the previous block must have in
+	// order for the template to build on it.  This allows requesting a
+	// template with fewer votes, and therefore a reduced reward, without
+	// waiting for the remaining votes.
+	MinVotes uint16 `json:"minvotes,omitempty"`
 }
 
 // convertTemplateRequestField potentially converts the provided value as
@@ -401,8 +407,9 @@ func NewGetNetTotalsCmd() *GetNetTotalsCmd {
 
 // GetNetworkHashPSCmd defines the getnetworkhashps JSON-RPC command.
 type GetNetworkHashPSCmd struct {
-	Blocks *int `jsonrpcdefault:"120"`
-	Height *int `jsonrpcdefault:"-1"`
+	Blocks  *int  `jsonrpcdefault:"120"`
+	Height  *int  `jsonrpcdefault:"-1"`
+	Verbose *bool `jsonrpcdefault:"false"`
 }
 
 // NewGetNetworkHashPSCmd returns a new instance which can be used to issue a
@@ -410,10 +417,11 @@ type GetNetworkHashPSCmd struct {
 //
 // The parameters which are pointers indicate they are optional.  Passing nil
 // for optional parameters will use the default value.
-func NewGetNetworkHashPSCmd(numBlocks, height *int) *GetNetworkHashPSCmd {
+func NewGetNetworkHashPSCmd(numBlocks, height *int, verbose *bool) *GetNetworkHashPSCmd {
 	return &GetNetworkHashPSCmd{
-		Blocks: numBlocks,
-		Height: height,
+		Blocks:  numBlocks,
+		Height:  height,
+		Verbose: verbose,
 	}
 }
 
@@ -447,10 +455,16 @@ const (
 	GRMRevocations GetRawMempoolTxTypeCmd = "revocations"
 )
 
-// GetRawMempoolCmd defines the getmempool JSON-RPC command.
+// GetRawMempoolCmd defines the getmempool JSON-RPC command.  MinFeeRate is
+// in DCR/kB and MinAge and MaxAge are the minimum and maximum number of seconds
+// transactions have been in the memory pool.
 type GetRawMempoolCmd struct {
-	Verbose *bool `jsonrpcdefault:"false"`
-	TxType  *string
+	Verbose    *bool `jsonrpcdefault:"false"`
+	TxType     *string
+	MinFeeRate *float64
+	MinAge     *int64
+	MaxAge     *int64
+	Summary    *bool
 }
 
 // NewGetRawMempoolCmd returns a new instance which can be used to issue a
@@ -458,10 +472,16 @@ type GetRawMempoolCmd struct {
 //
 // The parameters which are pointers indicate they are optional.  Passing nil
 // for optional parameters will use the default value.
-func NewGetRawMempoolCmd(verbose *bool, txType *string) *GetRawMempoolCmd {
+func NewGetRawMempoolCmd(verbose *bool, txType *string, minFeeRate *float64,
+	minAge, maxAge *int64, summary *bool) *GetRawMempoolCmd {
+
 	return &GetRawMempoolCmd{
-		Verbose: verbose,
-		TxType:  txType,
+		Verbose:    verbose,
+		TxType:     txType,
+		MinFeeRate: minFeeRate,
+		MinAge:     minAge,
+		MaxAge:     maxAge,
+		Summary:    summary,
 	}
 }
 
@@ -629,9 +649,13 @@ func NewSearchRawTransactionsCmd(address string, verbose, skip, count *int, vinE
 }
 
 // SendRawTransactionCmd defines the sendrawtransaction JSON-RPC command.
+//
+// MaxFeeRate is the maximum fee rate in DCR/kB the transaction may pay.  A
+// value of zero disables the check, as does setting AllowHighFees.
 type SendRawTransactionCmd struct {
 	HexTx         string
-	AllowHighFees *bool `jsonrpcdefault:"false"`
+	AllowHighFees *bool    `jsonrpcdefault:"false"`
+	MaxFeeRate    *float64 `jsonrpcdefault:"0.1"`
 }
 
 // NewSendRawTransactionCmd returns a new instance which can be used to issue a
@@ -639,10 +663,11 @@ type SendRawTransactionCmd struct {
 //
 // The parameters which are pointers indicate they are optional.  Passing nil
 // for optional parameters will use the default value.
-func NewSendRawTransactionCmd(hexTx string, allowHighFees *bool) *SendRawTransactionCmd {
+func NewSendRawTransactionCmd(hexTx string, allowHighFees *bool, maxFeeRate *float64) *SendRawTransactionCmd {
 	return &SendRawTransactionCmd{
 		HexTx:         hexTx,
 		AllowHighFees: allowHighFees,
+		MaxFeeRate:    maxFeeRate,
 	}
 }
 
@@ -678,6 +703,10 @@ func NewStopCmd() *StopCmd {
 type SubmitBlockOptions struct {
 	// must be provided if server provided a workid with template.
 	WorkID string `json:"workid,omitempty"`
+
+	// RejectDetails requests that rejections are returned as a
+	// SubmitBlockRejectResult instead of a string.
+	RejectDetails bool `json:"rejectdetails,omitempty"`
 }
 
 // SubmitBlockCmd defines the submitblock JSON-RPC command.
@@ -715,6 +744,7 @@ func NewValidateAddressCmd(address string) *ValidateAddressCmd {
 type VerifyChainCmd struct {
 	CheckLevel *int64 `jsonrpcdefault:"3"`
 	CheckDepth *int64 `jsonrpcdefault:"288"` // 0 = all
+	Async      *bool  `jsonrpcdefault:"false"`
 }
 
 // NewVerifyChainCmd returns a new instance which can be used to issue a
@@ -722,10 +752,11 @@ type VerifyChainCmd struct {
 //
 // The parameters which are pointers indicate they are optional.  Passing nil
 // for optional parameters will use the default value.
-func NewVerifyChainCmd(checkLevel, checkDepth *int64) *VerifyChainCmd {
+func NewVerifyChainCmd(checkLevel, checkDepth *int64, async *bool) *VerifyChainCmd {
 	return &VerifyChainCmd{
 		CheckLevel: checkLevel,
 		CheckDepth: checkDepth,
+		Async:      async,
 	}
 }
 
@@ -317,6 +317,28 @@ func TestChainSvrCmds(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "getblocktemplate optional - template request with minvotes",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getblocktemplate", `{"mode":"template","capabilities":["coinbasevalue"],"minvotes":3}`)
+			},
+			staticCmd: func() interface{} {
+				template := dcrjson.TemplateRequest{
+					Mode:         "template",
+					Capabilities: []string{"coinbasevalue"},
+					MinVotes:     3,
+				}
+				return dcrjson.NewGetBlockTemplateCmd(&template)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblocktemplate","params":[{"mode":"template","capabilities":["coinbasevalue"],"minvotes":3}],"id":1}`,
+			unmarshalled: &dcrjson.GetBlockTemplateCmd{
+				Request: &dcrjson.TemplateRequest{
+					Mode:         "template",
+					Capabilities: []string{"coinbasevalue"},
+					MinVotes:     3,
+				},
+			},
+		},
 		{
 			name: "getchaintips",
 			newCmd: func() (interface{}, error) {
@@ -372,6 +394,20 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"gethashespersec","params":[],"id":1}`,
 			unmarshalled: &dcrjson.GetHashesPerSecCmd{},
 		},
+		{
+			name: "getheaders",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getheaders", "298e5cc3d985bfe7f81dc135f360abe089edd4396b86d2de66b0cef42b21d980", "000000000000000000000000000000000000000000000000000000000000dead")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetHeadersCmd("298e5cc3d985bfe7f81dc135f360abe089edd4396b86d2de66b0cef42b21d980", "000000000000000000000000000000000000000000000000000000000000dead")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getheaders","params":["298e5cc3d985bfe7f81dc135f360abe089edd4396b86d2de66b0cef42b21d980","000000000000000000000000000000000000000000000000000000000000dead"],"id":1}`,
+			unmarshalled: &dcrjson.GetHeadersCmd{
+				BlockLocators: "298e5cc3d985bfe7f81dc135f360abe089edd4396b86d2de66b0cef42b21d980",
+				HashStop:      "000000000000000000000000000000000000000000000000000000000000dead",
+			},
+		},
 		{
 			name: "getinfo",
 			newCmd: func() (interface{}, error) {
@@ -433,12 +469,13 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("getnetworkhashps")
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewGetNetworkHashPSCmd(nil, nil)
+				return dcrjson.NewGetNetworkHashPSCmd(nil, nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"getnetworkhashps","params":[],"id":1}`,
 			unmarshalled: &dcrjson.GetNetworkHashPSCmd{
-				Blocks: dcrjson.Int(120),
-				Height: dcrjson.Int(-1),
+				Blocks:  dcrjson.Int(120),
+				Height:  dcrjson.Int(-1),
+				Verbose: dcrjson.Bool(false),
 			},
 		},
 		{
@@ -447,12 +484,13 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("getnetworkhashps", 200)
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewGetNetworkHashPSCmd(dcrjson.Int(200), nil)
+				return dcrjson.NewGetNetworkHashPSCmd(dcrjson.Int(200), nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"getnetworkhashps","params":[200],"id":1}`,
 			unmarshalled: &dcrjson.GetNetworkHashPSCmd{
-				Blocks: dcrjson.Int(200),
-				Height: dcrjson.Int(-1),
+				Blocks:  dcrjson.Int(200),
+				Height:  dcrjson.Int(-1),
+				Verbose: dcrjson.Bool(false),
 			},
 		},
 		{
@@ -461,12 +499,28 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("getnetworkhashps", 200, 123)
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewGetNetworkHashPSCmd(dcrjson.Int(200), dcrjson.Int(123))
+				return dcrjson.NewGetNetworkHashPSCmd(dcrjson.Int(200), dcrjson.Int(123), nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"getnetworkhashps","params":[200,123],"id":1}`,
 			unmarshalled: &dcrjson.GetNetworkHashPSCmd{
-				Blocks: dcrjson.Int(200),
-				Height: dcrjson.Int(123),
+				Blocks:  dcrjson.Int(200),
+				Height:  dcrjson.Int(123),
+				Verbose: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "getnetworkhashps optional3",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getnetworkhashps", 200, 123, true)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetNetworkHashPSCmd(dcrjson.Int(200), dcrjson.Int(123), dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getnetworkhashps","params":[200,123,true],"id":1}`,
+			unmarshalled: &dcrjson.GetNetworkHashPSCmd{
+				Blocks:  dcrjson.Int(200),
+				Height:  dcrjson.Int(123),
+				Verbose: dcrjson.Bool(true),
 			},
 		},
 		{
@@ -486,7 +540,7 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("getrawmempool")
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewGetRawMempoolCmd(nil, nil)
+				return dcrjson.NewGetRawMempoolCmd(nil, nil, nil, nil, nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"getrawmempool","params":[],"id":1}`,
 			unmarshalled: &dcrjson.GetRawMempoolCmd{
@@ -499,7 +553,7 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("getrawmempool", false)
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewGetRawMempoolCmd(dcrjson.Bool(false), nil)
+				return dcrjson.NewGetRawMempoolCmd(dcrjson.Bool(false), nil, nil, nil, nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"getrawmempool","params":[false],"id":1}`,
 			unmarshalled: &dcrjson.GetRawMempoolCmd{
@@ -512,7 +566,7 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("getrawmempool", false, "all")
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewGetRawMempoolCmd(dcrjson.Bool(false), dcrjson.String("all"))
+				return dcrjson.NewGetRawMempoolCmd(dcrjson.Bool(false), dcrjson.String("all"), nil, nil, nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"getrawmempool","params":[false,"all"],"id":1}`,
 			unmarshalled: &dcrjson.GetRawMempoolCmd{
@@ -520,6 +574,28 @@ func TestChainSvrCmds(t *testing.T) {
 				TxType:  dcrjson.String("all"),
 			},
 		},
+		{
+			name: "getrawmempool optional 3",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getrawmempool", false, "regular",
+					0.001, 60, 3600, true)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetRawMempoolCmd(dcrjson.Bool(false),
+					dcrjson.String("regular"), dcrjson.Float64(0.001),
+					dcrjson.Int64(60), dcrjson.Int64(3600),
+					dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getrawmempool","params":[false,"regular",0.001,60,3600,true],"id":1}`,
+			unmarshalled: &dcrjson.GetRawMempoolCmd{
+				Verbose:    dcrjson.Bool(false),
+				TxType:     dcrjson.String("regular"),
+				MinFeeRate: dcrjson.Float64(0.001),
+				MinAge:     dcrjson.Int64(60),
+				MaxAge:     dcrjson.Int64(3600),
+				Summary:    dcrjson.Bool(true),
+			},
+		},
 		{
 			name: "getrawtransaction",
 			newCmd: func() (interface{}, error) {
@@ -855,12 +931,13 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("sendrawtransaction", "1122")
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewSendRawTransactionCmd("1122", nil)
+				return dcrjson.NewSendRawTransactionCmd("1122", nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"sendrawtransaction","params":["1122"],"id":1}`,
 			unmarshalled: &dcrjson.SendRawTransactionCmd{
 				HexTx:         "1122",
 				AllowHighFees: dcrjson.Bool(false),
+				MaxFeeRate:    dcrjson.Float64(0.1),
 			},
 		},
 		{
@@ -869,12 +946,29 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("sendrawtransaction", "1122", false)
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewSendRawTransactionCmd("1122", dcrjson.Bool(false))
+				return dcrjson.NewSendRawTransactionCmd("1122", dcrjson.Bool(false), nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"sendrawtransaction","params":["1122",false],"id":1}`,
 			unmarshalled: &dcrjson.SendRawTransactionCmd{
 				HexTx:         "1122",
 				AllowHighFees: dcrjson.Bool(false),
+				MaxFeeRate:    dcrjson.Float64(0.1),
+			},
+		},
+		{
+			name: "sendrawtransaction optional2",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("sendrawtransaction", "1122", false, 0.5)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewSendRawTransactionCmd("1122", dcrjson.Bool(false),
+					dcrjson.Float64(0.5))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"sendrawtransaction","params":["1122",false,0.5],"id":1}`,
+			unmarshalled: &dcrjson.SendRawTransactionCmd{
+				HexTx:         "1122",
+				AllowHighFees: dcrjson.Bool(false),
+				MaxFeeRate:    dcrjson.Float64(0.5),
 			},
 		},
 		{
@@ -949,6 +1043,25 @@ func TestChainSvrCmds(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "submitblock optional rejectdetails",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("submitblock", "112233", `{"rejectdetails":true}`)
+			},
+			staticCmd: func() interface{} {
+				options := dcrjson.SubmitBlockOptions{
+					RejectDetails: true,
+				}
+				return dcrjson.NewSubmitBlockCmd("112233", &options)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"submitblock","params":["112233",{"rejectdetails":true}],"id":1}`,
+			unmarshalled: &dcrjson.SubmitBlockCmd{
+				HexBlock: "112233",
+				Options: &dcrjson.SubmitBlockOptions{
+					RejectDetails: true,
+				},
+			},
+		},
 		{
 			name: "validateaddress",
 			newCmd: func() (interface{}, error) {
@@ -968,12 +1081,13 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("verifychain")
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewVerifyChainCmd(nil, nil)
+				return dcrjson.NewVerifyChainCmd(nil, nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"verifychain","params":[],"id":1}`,
 			unmarshalled: &dcrjson.VerifyChainCmd{
 				CheckLevel: dcrjson.Int64(3),
 				CheckDepth: dcrjson.Int64(288),
+				Async:      dcrjson.Bool(false),
 			},
 		},
 		{
@@ -982,12 +1096,13 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("verifychain", 2)
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewVerifyChainCmd(dcrjson.Int64(2), nil)
+				return dcrjson.NewVerifyChainCmd(dcrjson.Int64(2), nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"verifychain","params":[2],"id":1}`,
 			unmarshalled: &dcrjson.VerifyChainCmd{
 				CheckLevel: dcrjson.Int64(2),
 				CheckDepth: dcrjson.Int64(288),
+				Async:      dcrjson.Bool(false),
 			},
 		},
 		{
@@ -996,12 +1111,28 @@ func TestChainSvrCmds(t *testing.T) {
 				return dcrjson.NewCmd("verifychain", 2, 500)
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewVerifyChainCmd(dcrjson.Int64(2), dcrjson.Int64(500))
+				return dcrjson.NewVerifyChainCmd(dcrjson.Int64(2), dcrjson.Int64(500), nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"verifychain","params":[2,500],"id":1}`,
 			unmarshalled: &dcrjson.VerifyChainCmd{
 				CheckLevel: dcrjson.Int64(2),
 				CheckDepth: dcrjson.Int64(500),
+				Async:      dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "verifychain optional3",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("verifychain", 2, 500, true)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewVerifyChainCmd(dcrjson.Int64(2), dcrjson.Int64(500), dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"verifychain","params":[2,500,true],"id":1}`,
+			unmarshalled: &dcrjson.VerifyChainCmd{
+				CheckLevel: dcrjson.Int64(2),
+				CheckDepth: dcrjson.Int64(500),
+				Async:      dcrjson.Bool(true),
 			},
 		},
 		{
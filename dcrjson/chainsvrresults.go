@@ -28,9 +28,11 @@ type GetBlockHeaderVerboseResult struct {
 	Height        uint32  `json:"height"`
 	Size          uint32  `json:"size"`
 	Time          int64   `json:"time"`
+	MedianTime    int64   `json:"mediantime"`
 	Nonce         uint32  `json:"nonce"`
 	StakeVersion  uint32  `json:"stakeversion"`
 	Difficulty    float64 `json:"difficulty"`
+	ChainWork     string  `json:"chainwork"`
 	NextHash      string  `json:"nextblockhash,omitempty"`
 }
 
@@ -50,6 +52,7 @@ type GetBlockVerboseResult struct {
 	STx           []string      `json:"stx,omitempty"`
 	RawSTx        []TxRawResult `json:"rawstx,omitempty"`
 	Time          int64         `json:"time"`
+	MedianTime    int64         `json:"mediantime"`
 	Nonce         uint32        `json:"nonce"`
 	VoteBits      uint16        `json:"votebits"`
 	FinalState    string        `json:"finalstate"`
@@ -60,6 +63,7 @@ type GetBlockVerboseResult struct {
 	Bits          string        `json:"bits"`
 	SBits         float64       `json:"sbits"`
 	Difficulty    float64       `json:"difficulty"`
+	ChainWork     string        `json:"chainwork"`
 	ExtraData     string        `json:"extradata"`
 	StakeVersion  uint32        `json:"stakeversion"`
 	PreviousHash  string        `json:"previousblockhash"`
@@ -99,13 +103,15 @@ type GetAddedNodeInfoResult struct {
 // GetBlockChainInfoResult models the data returned from the getblockchaininfo
 // command.
 type GetBlockChainInfoResult struct {
-	Chain                string  `json:"chain"`
-	Blocks               int32   `json:"blocks"`
-	Headers              int32   `json:"headers"`
-	BestBlockHash        string  `json:"bestblockhash"`
-	Difficulty           float64 `json:"difficulty"`
-	VerificationProgress float64 `json:"verificationprogress"`
-	ChainWork            string  `json:"chainwork"`
+	Chain                string   `json:"chain"`
+	Blocks               int32    `json:"blocks"`
+	Headers              int32    `json:"headers"`
+	BestBlockHash        string   `json:"bestblockhash"`
+	Difficulty           float64  `json:"difficulty"`
+	VerificationProgress float64  `json:"verificationprogress"`
+	ChainWork            string   `json:"chainwork"`
+	ConsensusScriptFlags []string `json:"consensusscriptflags"`
+	StandardScriptFlags  []string `json:"standardscriptflags"`
 }
 
 // GetBlockTemplateResultTx models the transactions field of the
@@ -161,13 +167,21 @@ type GetBlockTemplateResult struct {
 	// Block proposal from BIP 0023.
 	Capabilities  []string `json:"capabilities,omitempty"`
 	RejectReasion string   `json:"reject-reason,omitempty"`
+
+	// Vote details.  VoteWaitExpires is only set while the template is
+	// waiting for all of the votes on the previous block.
+	Voters          uint16 `json:"voters,omitempty"`
+	MinVotes        uint16 `json:"minvotes,omitempty"`
+	VoteWaitExpires int64  `json:"votewaitexpires,omitempty"`
 }
 
 // GetMempoolInfoResult models the data returned from the getmempoolinfo
 // command.
 type GetMempoolInfoResult struct {
-	Size  int64 `json:"size"`
-	Bytes int64 `json:"bytes"`
+	Size          int64   `json:"size"`
+	Bytes         int64   `json:"bytes"`
+	MaxMempool    int64   `json:"maxmempool"`
+	MempoolMinFee float64 `json:"mempoolminfee"`
 }
 
 // GetNetworkInfoResult models the data returned from the getnetworkinfo
@@ -203,6 +217,11 @@ type GetPeerInfoResult struct {
 	CurrentHeight  int64   `json:"currentheight,omitempty"`
 	BanScore       int32   `json:"banscore"`
 	SyncNode       bool    `json:"syncnode"`
+	FirstBlocks    uint64  `json:"firstblocks"`
+	FirstTxns      uint64  `json:"firsttxns"`
+
+	BytesSentPerMsg map[string]uint64 `json:"bytessentpermsg,omitempty"`
+	BytesRecvPerMsg map[string]uint64 `json:"bytesrecvpermsg,omitempty"`
 }
 
 // GetRawMempoolVerboseResult models the data returned from the getrawmempool
@@ -218,6 +237,22 @@ type GetRawMempoolVerboseResult struct {
 	Depends          []string `json:"depends"`
 }
 
+// GetRawMempoolSummaryResult models the data returned from the getrawmempool
+// command when the summary flag is set.  The fees are in DCR and the fee rates
+// are in DCR/kB.
+type GetRawMempoolSummaryResult struct {
+	Count       int64   `json:"count"`
+	Size        int64   `json:"size"`
+	Fees        float64 `json:"fees"`
+	MinFeeRate  float64 `json:"minfeerate"`
+	MaxFeeRate  float64 `json:"maxfeerate"`
+	OldestTime  int64   `json:"oldesttime"`
+	Regular     int64   `json:"regular"`
+	Tickets     int64   `json:"tickets"`
+	Votes       int64   `json:"votes"`
+	Revocations int64   `json:"revocations"`
+}
+
 // ScriptPubKeyResult models the scriptPubKey data of a tx script.  It is
 // defined separately since it is used by multiple commands.
 type ScriptPubKeyResult struct {
@@ -239,6 +274,20 @@ type GetTxOutResult struct {
 	Coinbase      bool               `json:"coinbase"`
 }
 
+// GetNetworkHashPSVerboseResult models the data returned from the
+// getnetworkhashps command when the verbose flag is set.  The average estimate
+// is the total work divided by the time between the first and last blocks of
+// the window, while the work-weighted estimate is the average of the estimates
+// for each run of blocks with the same difficulty weighted by their work.
+type GetNetworkHashPSVerboseResult struct {
+	StartHeight     int64 `json:"startheight"`
+	EndHeight       int64 `json:"endheight"`
+	TimeSpan        int64 `json:"timespan"`
+	DifficultyRuns  int64 `json:"difficultyruns"`
+	AverageHashesPS int64 `json:"averagehashesps"`
+	WorkWeightedPS  int64 `json:"workweightedps"`
+}
+
 // GetNetTotalsResult models the data returned from the getnettotals command.
 type GetNetTotalsResult struct {
 	TotalBytesRecv uint64 `json:"totalbytesrecv"`
@@ -495,3 +544,36 @@ type ValidateAddressChainResult struct {
 type GetHeadersResult struct {
 	Headers []string `json:"headers"`
 }
+
+// RuleErrorDetails describes the consensus rule violation which caused a block
+// or transaction to be rejected.  Code is the stable numeric value of the
+// blockchain.ErrorCode of the violation and Name is its name.  The location of
+// the violation is omitted when it is not known.
+type RuleErrorDetails struct {
+	Code       int    `json:"code"`
+	Name       string `json:"name"`
+	Height     *int64 `json:"height,omitempty"`
+	TxTree     *int8  `json:"txtree,omitempty"`
+	TxIndex    *int   `json:"txindex,omitempty"`
+	InputIndex *int   `json:"inputindex,omitempty"`
+}
+
+// SubmitBlockRejectResult models the data returned by the submitblock command
+// when a block is rejected and the reject details were requested.
+type SubmitBlockRejectResult struct {
+	Reason  string            `json:"reason"`
+	Details *RuleErrorDetails `json:"details,omitempty"`
+}
+
+// TxConflict describes an outpoint which is spent by conflicting transactions
+// along with the hash of the transaction which spends it.
+type TxConflict struct {
+	OutPoint OutPoint `json:"outpoint"`
+	TxID     string   `json:"txid"`
+}
+
+// TxConflictDetails describes the outpoints which caused a transaction to be
+// rejected because they are already spent by transactions in the mempool.
+type TxConflictDetails struct {
+	Conflicts []TxConflict `json:"conflicts"`
+}
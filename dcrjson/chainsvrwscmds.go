@@ -67,6 +67,15 @@ func NewStopNotifyBlocksCmd() *StopNotifyBlocksCmd {
 	return &StopNotifyBlocksCmd{}
 }
 
+// NotifyJobsCmd defines the notifyjobs JSON-RPC command.
+type NotifyJobsCmd struct{}
+
+// NewNotifyJobsCmd returns a new instance which can be used to issue a
+// notifyjobs JSON-RPC command.
+func NewNotifyJobsCmd() *NotifyJobsCmd {
+	return &NotifyJobsCmd{}
+}
+
 // NotifyNewTransactionsCmd defines the notifynewtransactions JSON-RPC command.
 type NotifyNewTransactionsCmd struct {
 	Verbose *bool `jsonrpcdefault:"false"`
@@ -83,6 +92,33 @@ func NewNotifyNewTransactionsCmd(verbose *bool) *NotifyNewTransactionsCmd {
 	}
 }
 
+// NotifySideChainBlocksCmd defines the notifysidechainblocks JSON-RPC command.
+type NotifySideChainBlocksCmd struct{}
+
+// NewNotifySideChainBlocksCmd returns a new instance which can be used to issue
+// a notifysidechainblocks JSON-RPC command.
+func NewNotifySideChainBlocksCmd() *NotifySideChainBlocksCmd {
+	return &NotifySideChainBlocksCmd{}
+}
+
+// NotifyStakeBoundariesCmd defines the notifystakeboundaries JSON-RPC command.
+type NotifyStakeBoundariesCmd struct{}
+
+// NewNotifyStakeBoundariesCmd returns a new instance which can be used to issue
+// a notifystakeboundaries JSON-RPC command.
+func NewNotifyStakeBoundariesCmd() *NotifyStakeBoundariesCmd {
+	return &NotifyStakeBoundariesCmd{}
+}
+
+// NotifyWorkCmd defines the notifywork JSON-RPC command.
+type NotifyWorkCmd struct{}
+
+// NewNotifyWorkCmd returns a new instance which can be used to issue a
+// notifywork JSON-RPC command.
+func NewNotifyWorkCmd() *NotifyWorkCmd {
+	return &NotifyWorkCmd{}
+}
+
 // SessionCmd defines the session JSON-RPC command.
 type SessionCmd struct{}
 
@@ -92,6 +128,15 @@ func NewSessionCmd() *SessionCmd {
 	return &SessionCmd{}
 }
 
+// StopNotifyJobsCmd defines the stopnotifyjobs JSON-RPC command.
+type StopNotifyJobsCmd struct{}
+
+// NewStopNotifyJobsCmd returns a new instance which can be used to issue a
+// stopnotifyjobs JSON-RPC command.
+func NewStopNotifyJobsCmd() *StopNotifyJobsCmd {
+	return &StopNotifyJobsCmd{}
+}
+
 // StopNotifyNewTransactionsCmd defines the stopnotifynewtransactions JSON-RPC command.
 type StopNotifyNewTransactionsCmd struct{}
 
@@ -104,17 +149,53 @@ func NewStopNotifyNewTransactionsCmd() *StopNotifyNewTransactionsCmd {
 	return &StopNotifyNewTransactionsCmd{}
 }
 
+// StopNotifySideChainBlocksCmd defines the stopnotifysidechainblocks JSON-RPC
+// command.
+type StopNotifySideChainBlocksCmd struct{}
+
+// NewStopNotifySideChainBlocksCmd returns a new instance which can be used to
+// issue a stopnotifysidechainblocks JSON-RPC command.
+func NewStopNotifySideChainBlocksCmd() *StopNotifySideChainBlocksCmd {
+	return &StopNotifySideChainBlocksCmd{}
+}
+
+// StopNotifyStakeBoundariesCmd defines the stopnotifystakeboundaries JSON-RPC
+// command.
+type StopNotifyStakeBoundariesCmd struct{}
+
+// NewStopNotifyStakeBoundariesCmd returns a new instance which can be used to
+// issue a stopnotifystakeboundaries JSON-RPC command.
+func NewStopNotifyStakeBoundariesCmd() *StopNotifyStakeBoundariesCmd {
+	return &StopNotifyStakeBoundariesCmd{}
+}
+
+// StopNotifyWorkCmd defines the stopnotifywork JSON-RPC command.
+type StopNotifyWorkCmd struct{}
+
+// NewStopNotifyWorkCmd returns a new instance which can be used to issue a
+// stopnotifywork JSON-RPC command.
+func NewStopNotifyWorkCmd() *StopNotifyWorkCmd {
+	return &StopNotifyWorkCmd{}
+}
+
 // RescanCmd defines the rescan JSON-RPC command.
 type RescanCmd struct {
 	// Concatenated block hashes in non-byte-reversed hex encoding.  Must
 	// have length evenly divisible by 2*chainhash.HashSize.
 	BlockHashes string
+
+	// Async requests the rescan to be run as a job which is queried with
+	// getjob instead of blocking until it is finished.
+	Async *bool `jsonrpcdefault:"false"`
 }
 
 // NewRescanCmd returns a new instance which can be used to issue a rescan
 // JSON-RPC command.
-func NewRescanCmd(blockHashes string) *RescanCmd {
-	return &RescanCmd{BlockHashes: blockHashes}
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewRescanCmd(blockHashes string, async *bool) *RescanCmd {
+	return &RescanCmd{BlockHashes: blockHashes, Async: async}
 }
 
 func init() {
@@ -124,9 +205,17 @@ func init() {
 	MustRegisterCmd("authenticate", (*AuthenticateCmd)(nil), flags)
 	MustRegisterCmd("loadtxfilter", (*LoadTxFilterCmd)(nil), flags)
 	MustRegisterCmd("notifyblocks", (*NotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("notifyjobs", (*NotifyJobsCmd)(nil), flags)
 	MustRegisterCmd("notifynewtransactions", (*NotifyNewTransactionsCmd)(nil), flags)
+	MustRegisterCmd("notifysidechainblocks", (*NotifySideChainBlocksCmd)(nil), flags)
+	MustRegisterCmd("notifystakeboundaries", (*NotifyStakeBoundariesCmd)(nil), flags)
+	MustRegisterCmd("notifywork", (*NotifyWorkCmd)(nil), flags)
 	MustRegisterCmd("session", (*SessionCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyblocks", (*StopNotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("stopnotifyjobs", (*StopNotifyJobsCmd)(nil), flags)
 	MustRegisterCmd("stopnotifynewtransactions", (*StopNotifyNewTransactionsCmd)(nil), flags)
+	MustRegisterCmd("stopnotifysidechainblocks", (*StopNotifySideChainBlocksCmd)(nil), flags)
+	MustRegisterCmd("stopnotifystakeboundaries", (*StopNotifyStakeBoundariesCmd)(nil), flags)
+	MustRegisterCmd("stopnotifywork", (*StopNotifyWorkCmd)(nil), flags)
 	MustRegisterCmd("rescan", (*RescanCmd)(nil), flags)
 }
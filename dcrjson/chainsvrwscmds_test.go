@@ -100,17 +100,120 @@ func TestChainSvrWsCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifynewtransactions","params":[],"id":1}`,
 			unmarshalled: &dcrjson.StopNotifyNewTransactionsCmd{},
 		},
+		{
+			name: "notifyjobs",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("notifyjobs")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewNotifyJobsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"notifyjobs","params":[],"id":1}`,
+			unmarshalled: &dcrjson.NotifyJobsCmd{},
+		},
+		{
+			name: "stopnotifyjobs",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("stopnotifyjobs")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewStopNotifyJobsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifyjobs","params":[],"id":1}`,
+			unmarshalled: &dcrjson.StopNotifyJobsCmd{},
+		},
+		{
+			name: "notifystakeboundaries",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("notifystakeboundaries")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewNotifyStakeBoundariesCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"notifystakeboundaries","params":[],"id":1}`,
+			unmarshalled: &dcrjson.NotifyStakeBoundariesCmd{},
+		},
+		{
+			name: "stopnotifystakeboundaries",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("stopnotifystakeboundaries")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewStopNotifyStakeBoundariesCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifystakeboundaries","params":[],"id":1}`,
+			unmarshalled: &dcrjson.StopNotifyStakeBoundariesCmd{},
+		},
+		{
+			name: "notifysidechainblocks",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("notifysidechainblocks")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewNotifySideChainBlocksCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"notifysidechainblocks","params":[],"id":1}`,
+			unmarshalled: &dcrjson.NotifySideChainBlocksCmd{},
+		},
+		{
+			name: "stopnotifysidechainblocks",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("stopnotifysidechainblocks")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewStopNotifySideChainBlocksCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifysidechainblocks","params":[],"id":1}`,
+			unmarshalled: &dcrjson.StopNotifySideChainBlocksCmd{},
+		},
+		{
+			name: "notifywork",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("notifywork")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewNotifyWorkCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"notifywork","params":[],"id":1}`,
+			unmarshalled: &dcrjson.NotifyWorkCmd{},
+		},
+		{
+			name: "stopnotifywork",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("stopnotifywork")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewStopNotifyWorkCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifywork","params":[],"id":1}`,
+			unmarshalled: &dcrjson.StopNotifyWorkCmd{},
+		},
 		{
 			name: "rescan",
 			newCmd: func() (interface{}, error) {
 				return dcrjson.NewCmd("rescan", "0000000000000000000000000000000000000000000000000000000000000123")
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewRescanCmd("0000000000000000000000000000000000000000000000000000000000000123")
+				return dcrjson.NewRescanCmd("0000000000000000000000000000000000000000000000000000000000000123", nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"rescan","params":["0000000000000000000000000000000000000000000000000000000000000123"],"id":1}`,
 			unmarshalled: &dcrjson.RescanCmd{
 				BlockHashes: "0000000000000000000000000000000000000000000000000000000000000123",
+				Async:       dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "rescan async",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("rescan", "0000000000000000000000000000000000000000000000000000000000000123", true)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewRescanCmd("0000000000000000000000000000000000000000000000000000000000000123", dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"rescan","params":["0000000000000000000000000000000000000000000000000000000000000123",true],"id":1}`,
+			unmarshalled: &dcrjson.RescanCmd{
+				BlockHashes: "0000000000000000000000000000000000000000000000000000000000000123",
+				Async:       dcrjson.Bool(true),
 			},
 		},
 	}
@@ -35,6 +35,61 @@ const (
 	// from the chain server that inform a client that a relevant
 	// transaction was accepted by the mempool.
 	RelevantTxAcceptedNtfnMethod = "relevanttxaccepted"
+
+	// WorkExpiredNtfnMethod is the method used for notifications from the
+	// chain server that work previously handed out to miners is stale.
+	WorkExpiredNtfnMethod = "workexpired"
+
+	// TxConflictNtfnMethod is the method used for notifications from the
+	// chain server that a transaction was removed from the mempool because
+	// it conflicts with another transaction.
+	TxConflictNtfnMethod = "txconflict"
+
+	// DoubleSpendProofNtfnMethod is the method used for notifications from
+	// the chain server that proof of two transactions spending the same
+	// outpoint was observed.
+	DoubleSpendProofNtfnMethod = "doublespendproof"
+
+	// SideChainBlockConnectedNtfnMethod is the method used for
+	// notifications from the chain server that a block which extends a
+	// chain other than the main chain has been accepted.
+	SideChainBlockConnectedNtfnMethod = "sidechainblockconnected"
+
+	// JobCompletedNtfnMethod is the method used for notifications from the
+	// chain server that a job started by a long-running command finished.
+	JobCompletedNtfnMethod = "jobcompleted"
+
+	// StakeBoundaryNtfnMethod is the method used for notifications from the
+	// chain server that a block connected to the main chain crossed a stake
+	// boundary.
+	StakeBoundaryNtfnMethod = "stakeboundary"
+)
+
+// These constants define the reasons included in workexpired notifications.
+const (
+	// WorkExpiredNewTip indicates the work is stale because a new block
+	// was connected to the main chain.
+	WorkExpiredNewTip = "newtip"
+
+	// WorkExpiredVotes indicates the work is stale because a new vote on
+	// the tip of the main chain is available.
+	WorkExpiredVotes = "votes"
+)
+
+// These constants define the boundaries included in stakeboundary
+// notifications.
+const (
+	// StakeBoundaryTicketPriceInterval indicates the block is the first
+	// block of a new ticket price interval.
+	StakeBoundaryTicketPriceInterval = "ticketpriceinterval"
+
+	// StakeBoundaryTicketsMatured indicates tickets matured and were added
+	// to the live ticket pool by the block.
+	StakeBoundaryTicketsMatured = "ticketsmatured"
+
+	// StakeBoundaryTicketsExpired indicates tickets expired and were
+	// removed from the live ticket pool by the block.
+	StakeBoundaryTicketsExpired = "ticketsexpired"
 )
 
 // BlockConnectedNtfn defines the blockconnected JSON-RPC notification.
@@ -125,6 +180,143 @@ func NewRelevantTxAcceptedNtfn(txHex string) *RelevantTxAcceptedNtfn {
 	return &RelevantTxAcceptedNtfn{Transaction: txHex}
 }
 
+// WorkExpiredNtfn defines the workexpired JSON-RPC notification.  Work handed
+// out prior to the notification that does not build on the block identified
+// by Hash with the given number of votes is stale.
+type WorkExpiredNtfn struct {
+	Reason string `json:"reason"`
+	Hash   string `json:"hash"`
+	Height int64  `json:"height"`
+	Voters uint16 `json:"voters"`
+}
+
+// NewWorkExpiredNtfn returns a new instance which can be used to issue a
+// workexpired JSON-RPC notification.
+func NewWorkExpiredNtfn(reason string, hash string, height int64, voters uint16) *WorkExpiredNtfn {
+	return &WorkExpiredNtfn{
+		Reason: reason,
+		Hash:   hash,
+		Height: height,
+		Voters: voters,
+	}
+}
+
+// TxConflictNtfn defines the txconflict JSON-RPC notification.  The
+// transaction identified by TxID was removed from the mempool because the
+// outpoints in Conflicts are spent by other transactions, such as ones in a
+// newly connected block.
+type TxConflictNtfn struct {
+	TxID      string       `json:"txid"`
+	Conflicts []TxConflict `json:"conflicts"`
+}
+
+// NewTxConflictNtfn returns a new instance which can be used to issue a
+// txconflict JSON-RPC notification.
+func NewTxConflictNtfn(txHash string, conflicts []TxConflict) *TxConflictNtfn {
+	return &TxConflictNtfn{
+		TxID:      txHash,
+		Conflicts: conflicts,
+	}
+}
+
+// DoubleSpendProofNtfn defines the doublespendproof JSON-RPC notification.
+// The transactions identified by TxIDs both spend OutPoint, and Proof is the
+// hex-encoded dsproof wire message which proves it.
+type DoubleSpendProofNtfn struct {
+	OutPoint OutPoint `json:"outpoint"`
+	TxIDs    []string `json:"txids"`
+	Proof    string   `json:"proof"`
+}
+
+// NewDoubleSpendProofNtfn returns a new instance which can be used to issue a
+// doublespendproof JSON-RPC notification.
+func NewDoubleSpendProofNtfn(outPoint OutPoint, txIDs []string, proof string) *DoubleSpendProofNtfn {
+	return &DoubleSpendProofNtfn{
+		OutPoint: outPoint,
+		TxIDs:    txIDs,
+		Proof:    proof,
+	}
+}
+
+// SideChainBlockConnectedNtfn defines the sidechainblockconnected JSON-RPC
+// notification.  ChainWork is the total work of the side chain ending with the
+// block and WorkBehindTip is how much less work it has than the main chain,
+// both encoded as hex.
+type SideChainBlockConnectedNtfn struct {
+	Header        string `json:"header"`
+	ChainWork     string `json:"chainwork"`
+	WorkBehindTip string `json:"workbehindtip"`
+}
+
+// NewSideChainBlockConnectedNtfn returns a new instance which can be used to
+// issue a sidechainblockconnected JSON-RPC notification.
+func NewSideChainBlockConnectedNtfn(header string, chainWork string, workBehindTip string) *SideChainBlockConnectedNtfn {
+	return &SideChainBlockConnectedNtfn{
+		Header:        header,
+		ChainWork:     chainWork,
+		WorkBehindTip: workBehindTip,
+	}
+}
+
+// JobCompletedNtfn defines the jobcompleted JSON-RPC notification.  The job
+// identified by JobID which was started by Method finished in State, which is
+// one of the JobState* states other than JobStateRunning.  Error describes why
+// the job failed, if it did.  The result of the job is queried with getjob.
+type JobCompletedNtfn struct {
+	JobID  uint64 `json:"jobid"`
+	Method string `json:"method"`
+	State  string `json:"state"`
+	Error  string `json:"error"`
+}
+
+// NewJobCompletedNtfn returns a new instance which can be used to issue a
+// jobcompleted JSON-RPC notification.
+func NewJobCompletedNtfn(jobID uint64, method string, state string, errStr string) *JobCompletedNtfn {
+	return &JobCompletedNtfn{
+		JobID:  jobID,
+		Method: method,
+		State:  state,
+		Error:  errStr,
+	}
+}
+
+// StakeBoundaryNtfn defines the stakeboundary JSON-RPC notification.  The block
+// identified by Hash crossed the stake boundary given by Boundary, which is one
+// of the StakeBoundary* constants.  StakeDifficulty is the ticket price in
+// effect for the block and PoolSize is the size of the live ticket pool once
+// the block was connected.
+//
+// PrevStakeDifficulty and IntervalEndHeight are only set for new ticket price
+// intervals, while Tickets are the tickets which matured or expired.
+type StakeBoundaryNtfn struct {
+	Boundary            string   `json:"boundary"`
+	Hash                string   `json:"hash"`
+	Height              int64    `json:"height"`
+	StakeDifficulty     int64    `json:"stakedifficulty"`
+	PoolSize            int      `json:"poolsize"`
+	PrevStakeDifficulty int64    `json:"prevstakedifficulty,omitempty"`
+	IntervalEndHeight   int64    `json:"intervalendheight,omitempty"`
+	Tickets             []string `json:"tickets,omitempty"`
+}
+
+// NewStakeBoundaryNtfn returns a new instance which can be used to issue a
+// stakeboundary JSON-RPC notification.
+func NewStakeBoundaryNtfn(boundary string, hash string, height int64,
+	stakeDifficulty int64, poolSize int, prevStakeDifficulty int64,
+	intervalEndHeight int64, tickets []string) *StakeBoundaryNtfn {
+
+	return &StakeBoundaryNtfn{
+		Boundary:            boundary,
+		Hash:                hash,
+		Height:              height,
+		StakeDifficulty:     stakeDifficulty,
+		PoolSize:            poolSize,
+		PrevStakeDifficulty: prevStakeDifficulty,
+		IntervalEndHeight:   intervalEndHeight,
+		Tickets:             tickets,
+	}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets and are
 	// notifications.
@@ -136,4 +328,10 @@ func init() {
 	MustRegisterCmd(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
 	MustRegisterCmd(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
+	MustRegisterCmd(WorkExpiredNtfnMethod, (*WorkExpiredNtfn)(nil), flags)
+	MustRegisterCmd(TxConflictNtfnMethod, (*TxConflictNtfn)(nil), flags)
+	MustRegisterCmd(DoubleSpendProofNtfnMethod, (*DoubleSpendProofNtfn)(nil), flags)
+	MustRegisterCmd(SideChainBlockConnectedNtfnMethod, (*SideChainBlockConnectedNtfn)(nil), flags)
+	MustRegisterCmd(JobCompletedNtfnMethod, (*JobCompletedNtfn)(nil), flags)
+	MustRegisterCmd(StakeBoundaryNtfnMethod, (*StakeBoundaryNtfn)(nil), flags)
 }
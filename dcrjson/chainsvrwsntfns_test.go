@@ -113,6 +113,109 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "workexpired",
+			newNtfn: func() (interface{}, error) {
+				return dcrjson.NewCmd("workexpired", "newtip", "123", 100000, 4)
+			},
+			staticNtfn: func() interface{} {
+				return dcrjson.NewWorkExpiredNtfn("newtip", "123", 100000, 4)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"workexpired","params":["newtip","123",100000,4],"id":null}`,
+			unmarshalled: &dcrjson.WorkExpiredNtfn{
+				Reason: "newtip",
+				Hash:   "123",
+				Height: 100000,
+				Voters: 4,
+			},
+		},
+		{
+			name: "txconflict",
+			newNtfn: func() (interface{}, error) {
+				return dcrjson.NewCmd("txconflict", "123", `[{"outpoint":{"hash":"456","tree":0,"index":1},"txid":"789"}]`)
+			},
+			staticNtfn: func() interface{} {
+				conflicts := []dcrjson.TxConflict{{
+					OutPoint: dcrjson.OutPoint{Hash: "456", Tree: 0, Index: 1},
+					TxID:     "789",
+				}}
+				return dcrjson.NewTxConflictNtfn("123", conflicts)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"txconflict","params":["123",[{"outpoint":{"hash":"456","tree":0,"index":1},"txid":"789"}]],"id":null}`,
+			unmarshalled: &dcrjson.TxConflictNtfn{
+				TxID: "123",
+				Conflicts: []dcrjson.TxConflict{{
+					OutPoint: dcrjson.OutPoint{Hash: "456", Tree: 0, Index: 1},
+					TxID:     "789",
+				}},
+			},
+		},
+		{
+			name: "doublespendproof",
+			newNtfn: func() (interface{}, error) {
+				return dcrjson.NewCmd("doublespendproof", `{"hash":"123","tree":0,"index":1}`, `["456","789"]`, "00")
+			},
+			staticNtfn: func() interface{} {
+				outPoint := dcrjson.OutPoint{Hash: "123", Tree: 0, Index: 1}
+				return dcrjson.NewDoubleSpendProofNtfn(outPoint,
+					[]string{"456", "789"}, "00")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"doublespendproof","params":[{"hash":"123","tree":0,"index":1},["456","789"],"00"],"id":null}`,
+			unmarshalled: &dcrjson.DoubleSpendProofNtfn{
+				OutPoint: dcrjson.OutPoint{Hash: "123", Tree: 0, Index: 1},
+				TxIDs:    []string{"456", "789"},
+				Proof:    "00",
+			},
+		},
+		{
+			name: "sidechainblockconnected",
+			newNtfn: func() (interface{}, error) {
+				return dcrjson.NewCmd("sidechainblockconnected", "header", "0a", "01")
+			},
+			staticNtfn: func() interface{} {
+				return dcrjson.NewSideChainBlockConnectedNtfn("header", "0a", "01")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"sidechainblockconnected","params":["header","0a","01"],"id":null}`,
+			unmarshalled: &dcrjson.SideChainBlockConnectedNtfn{
+				Header:        "header",
+				ChainWork:     "0a",
+				WorkBehindTip: "01",
+			},
+		},
+		{
+			name: "jobcompleted",
+			newNtfn: func() (interface{}, error) {
+				return dcrjson.NewCmd("jobcompleted", 3, "verifychain", "failed", "bad block")
+			},
+			staticNtfn: func() interface{} {
+				return dcrjson.NewJobCompletedNtfn(3, "verifychain", "failed", "bad block")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"jobcompleted","params":[3,"verifychain","failed","bad block"],"id":null}`,
+			unmarshalled: &dcrjson.JobCompletedNtfn{
+				JobID:  3,
+				Method: "verifychain",
+				State:  "failed",
+				Error:  "bad block",
+			},
+		},
+		{
+			name: "stakeboundary",
+			newNtfn: func() (interface{}, error) {
+				return dcrjson.NewCmd("stakeboundary", "ticketsmatured", "123", 100000, 150000000, 40960, 0, 0, []string{"456"})
+			},
+			staticNtfn: func() interface{} {
+				return dcrjson.NewStakeBoundaryNtfn("ticketsmatured", "123", 100000, 150000000, 40960, 0, 0, []string{"456"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"stakeboundary","params":["ticketsmatured","123",100000,150000000,40960,0,0,["456"]],"id":null}`,
+			unmarshalled: &dcrjson.StakeBoundaryNtfn{
+				Boundary:        "ticketsmatured",
+				Hash:            "123",
+				Height:          100000,
+				StakeDifficulty: 150000000,
+				PoolSize:        40960,
+				Tickets:         []string{"456"},
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
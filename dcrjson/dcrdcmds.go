@@ -7,6 +7,138 @@
 
 package dcrjson
 
+// AddrManAddress describes a known address in the portable address manager
+// format used by the dumpaddrman and importaddrman JSON-RPC commands.
+type AddrManAddress struct {
+	Addr        string `json:"addr"`
+	Src         string `json:"src"`
+	Services    uint64 `json:"services"`
+	Attempts    int    `json:"attempts"`
+	TimeStamp   int64  `json:"timestamp"`
+	LastAttempt int64  `json:"lastattempt"`
+	LastSuccess int64  `json:"lastsuccess"`
+	Tried       bool   `json:"tried"`
+}
+
+// AddrManDump describes the addresses known to an address manager in the
+// portable address manager format.  It is returned by the dumpaddrman JSON-RPC
+// command and accepted by the importaddrman JSON-RPC command, so the result of
+// the former may be saved to a file and used to seed other nodes.
+type AddrManDump struct {
+	Version   int              `json:"version"`
+	Addresses []AddrManAddress `json:"addresses"`
+}
+
+// AdvanceChainCmd defines the advancechain JSON-RPC command.
+type AdvanceChainCmd struct {
+	NumBlocks uint32
+}
+
+// NewAdvanceChainCmd returns a new instance which can be used to issue an
+// advancechain JSON-RPC command.
+func NewAdvanceChainCmd(numBlocks uint32) *AdvanceChainCmd {
+	return &AdvanceChainCmd{
+		NumBlocks: numBlocks,
+	}
+}
+
+// CancelJobCmd defines the canceljob JSON-RPC command.
+type CancelJobCmd struct {
+	JobID uint64
+}
+
+// NewCancelJobCmd returns a new instance which can be used to issue a
+// canceljob JSON-RPC command.
+func NewCancelJobCmd(jobID uint64) *CancelJobCmd {
+	return &CancelJobCmd{
+		JobID: jobID,
+	}
+}
+
+// CompareChainWorkCmd defines the comparechainwork JSON-RPC command.
+type CompareChainWorkCmd struct {
+	Hash1 string
+	Hash2 string
+}
+
+// NewCompareChainWorkCmd returns a new instance which can be used to issue a
+// comparechainwork JSON-RPC command.
+func NewCompareChainWorkCmd(hash1, hash2 string) *CompareChainWorkCmd {
+	return &CompareChainWorkCmd{
+		Hash1: hash1,
+		Hash2: hash2,
+	}
+}
+
+// CreditOutputCmd defines the creditoutput JSON-RPC command.
+type CreditOutputCmd struct {
+	Address string
+	Amount  float64
+}
+
+// NewCreditOutputCmd returns a new instance which can be used to issue a
+// creditoutput JSON-RPC command.
+func NewCreditOutputCmd(address string, amount float64) *CreditOutputCmd {
+	return &CreditOutputCmd{
+		Address: address,
+		Amount:  amount,
+	}
+}
+
+// DropIndexCmd defines the dropindex JSON-RPC command.  Index is the
+// name of the option which enables the index, such as txindex.
+type DropIndexCmd struct {
+	Index string
+}
+
+// NewDropIndexCmd returns a new instance which can be used to issue a
+// dropindex JSON-RPC command.
+func NewDropIndexCmd(index string) *DropIndexCmd {
+	return &DropIndexCmd{
+		Index: index,
+	}
+}
+
+// DumpAddrManCmd defines the dumpaddrman JSON-RPC command.
+type DumpAddrManCmd struct{}
+
+// NewDumpAddrManCmd returns a new instance which can be used to issue a
+// dumpaddrman JSON-RPC command.
+func NewDumpAddrManCmd() *DumpAddrManCmd {
+	return &DumpAddrManCmd{}
+}
+
+// DumpUtxoSetCmd defines the dumputxoset JSON-RPC command.
+type DumpUtxoSetCmd struct {
+	Height *int64
+	Async  *bool `jsonrpcdefault:"false"`
+}
+
+// NewDumpUtxoSetCmd returns a new instance which can be used to issue a
+// dumputxoset JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewDumpUtxoSetCmd(height *int64, async *bool) *DumpUtxoSetCmd {
+	return &DumpUtxoSetCmd{
+		Height: height,
+		Async:  async,
+	}
+}
+
+// EstimateSmartFeeCmd defines the estimatesmartfee JSON-RPC command.
+type EstimateSmartFeeCmd struct {
+	Confirmations int64
+}
+
+// NewEstimateSmartFeeCmd returns a new instance which can be used to issue an
+// estimatesmartfee JSON-RPC command.
+func NewEstimateSmartFeeCmd(confirmations int64) *EstimateSmartFeeCmd {
+	return &EstimateSmartFeeCmd{
+		Confirmations: confirmations,
+	}
+}
+
 // EstimateStakeDiffCmd defines the eststakedifficulty JSON-RPC command.
 type EstimateStakeDiffCmd struct {
 	Tickets *uint32
@@ -19,6 +151,36 @@ func NewEstimateStakeDiffCmd(tickets *uint32) *EstimateStakeDiffCmd {
 	}
 }
 
+// EstimateTicketFeeCmd defines the estimateticketfee JSON-RPC command.
+type EstimateTicketFeeCmd struct {
+	Confirmations int64
+}
+
+// NewEstimateTicketFeeCmd returns a new instance which can be used to issue an
+// estimateticketfee JSON-RPC command.
+func NewEstimateTicketFeeCmd(confirmations int64) *EstimateTicketFeeCmd {
+	return &EstimateTicketFeeCmd{
+		Confirmations: confirmations,
+	}
+}
+
+// EstimateTimeToConfirmCmd defines the estimatetimetoconfirm JSON-RPC command.
+type EstimateTimeToConfirmCmd struct {
+	FeeRate   float64
+	Size      uint32
+	NumBlocks *uint32 `jsonrpcdefault:"6"`
+}
+
+// NewEstimateTimeToConfirmCmd returns a new instance which can be used to
+// issue an estimatetimetoconfirm JSON-RPC command.
+func NewEstimateTimeToConfirmCmd(feeRate float64, size uint32, numBlocks *uint32) *EstimateTimeToConfirmCmd {
+	return &EstimateTimeToConfirmCmd{
+		FeeRate:   feeRate,
+		Size:      size,
+		NumBlocks: numBlocks,
+	}
+}
+
 // ExistsAddressCmd defines the existsaddress JSON-RPC command.
 type ExistsAddressCmd struct {
 	Address string
@@ -97,6 +259,84 @@ func NewExistsMempoolTxsCmd(txHashBlob string) *ExistsMempoolTxsCmd {
 	}
 }
 
+// ForceStakeDifficultyCmd defines the forcestakedifficulty JSON-RPC command.
+type ForceStakeDifficultyCmd struct {
+	StakeDifficulty float64
+}
+
+// NewForceStakeDifficultyCmd returns a new instance which can be used to issue
+// a forcestakedifficulty JSON-RPC command.
+func NewForceStakeDifficultyCmd(stakeDifficulty float64) *ForceStakeDifficultyCmd {
+	return &ForceStakeDifficultyCmd{
+		StakeDifficulty: stakeDifficulty,
+	}
+}
+
+// GetAddressBalanceCmd defines the getaddressbalance JSON-RPC command.
+type GetAddressBalanceCmd struct {
+	Address string
+}
+
+// NewGetAddressBalanceCmd returns a new instance which can be used to issue a
+// getaddressbalance JSON-RPC command.
+func NewGetAddressBalanceCmd(address string) *GetAddressBalanceCmd {
+	return &GetAddressBalanceCmd{
+		Address: address,
+	}
+}
+
+// GetAddressUtxosCmd defines the getaddressutxos JSON-RPC command.
+type GetAddressUtxosCmd struct {
+	Address string
+}
+
+// NewGetAddressUtxosCmd returns a new instance which can be used to issue a
+// getaddressutxos JSON-RPC command.
+func NewGetAddressUtxosCmd(address string) *GetAddressUtxosCmd {
+	return &GetAddressUtxosCmd{
+		Address: address,
+	}
+}
+
+// GetBlockAnnotationsCmd defines the getblockannotations JSON-RPC command.
+type GetBlockAnnotationsCmd struct {
+	Hash string
+}
+
+// NewGetBlockAnnotationsCmd returns a new instance which can be used to issue a
+// getblockannotations JSON-RPC command.
+func NewGetBlockAnnotationsCmd(hash string) *GetBlockAnnotationsCmd {
+	return &GetBlockAnnotationsCmd{
+		Hash: hash,
+	}
+}
+
+// GetBlockByMedianTimeCmd defines the getblockbymediantime JSON-RPC command.
+type GetBlockByMedianTimeCmd struct {
+	Time int64
+}
+
+// NewGetBlockByMedianTimeCmd returns a new instance which can be used to issue
+// a getblockbymediantime JSON-RPC command.
+func NewGetBlockByMedianTimeCmd(time int64) *GetBlockByMedianTimeCmd {
+	return &GetBlockByMedianTimeCmd{
+		Time: time,
+	}
+}
+
+// GetBlockHashByTimeCmd defines the getblockhashbytime JSON-RPC command.
+type GetBlockHashByTimeCmd struct {
+	Time int64
+}
+
+// NewGetBlockHashByTimeCmd returns a new instance which can be used to issue a
+// getblockhashbytime JSON-RPC command.
+func NewGetBlockHashByTimeCmd(time int64) *GetBlockHashByTimeCmd {
+	return &GetBlockHashByTimeCmd{
+		Time: time,
+	}
+}
+
 // GetCoinSupplyCmd defines the getcoinsupply JSON-RPC command.
 type GetCoinSupplyCmd struct{}
 
@@ -106,6 +346,223 @@ func NewGetCoinSupplyCmd() *GetCoinSupplyCmd {
 	return &GetCoinSupplyCmd{}
 }
 
+// GetCoinSupplyBreakdownCmd defines the getcoinsupplybreakdown JSON-RPC
+// command.
+type GetCoinSupplyBreakdownCmd struct{}
+
+// NewGetCoinSupplyBreakdownCmd returns a new instance which can be used to
+// issue a getcoinsupplybreakdown JSON-RPC command.
+func NewGetCoinSupplyBreakdownCmd() *GetCoinSupplyBreakdownCmd {
+	return &GetCoinSupplyBreakdownCmd{}
+}
+
+// GetFinalityCmd defines the getfinality JSON-RPC command.
+type GetFinalityCmd struct{}
+
+// NewGetFinalityCmd returns a new instance which can be used to issue a
+// getfinality JSON-RPC command.
+func NewGetFinalityCmd() *GetFinalityCmd {
+	return &GetFinalityCmd{}
+}
+
+// GetIndexInfoCmd defines the getindexinfo JSON-RPC command.
+type GetIndexInfoCmd struct{}
+
+// NewGetIndexInfoCmd returns a new instance which can be used to issue a
+// getindexinfo JSON-RPC command.
+func NewGetIndexInfoCmd() *GetIndexInfoCmd {
+	return &GetIndexInfoCmd{}
+}
+
+// GetJobCmd defines the getjob JSON-RPC command.
+type GetJobCmd struct {
+	JobID uint64
+}
+
+// NewGetJobCmd returns a new instance which can be used to issue a getjob
+// JSON-RPC command.
+func NewGetJobCmd(jobID uint64) *GetJobCmd {
+	return &GetJobCmd{
+		JobID: jobID,
+	}
+}
+
+// GetMempoolAncestorsCmd defines the getmempoolancestors JSON-RPC command.
+type GetMempoolAncestorsCmd struct {
+	TxID    string
+	Verbose *bool `jsonrpcdefault:"false"`
+}
+
+// NewGetMempoolAncestorsCmd returns a new instance which can be used to issue a
+// getmempoolancestors JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetMempoolAncestorsCmd(txID string, verbose *bool) *GetMempoolAncestorsCmd {
+	return &GetMempoolAncestorsCmd{
+		TxID:    txID,
+		Verbose: verbose,
+	}
+}
+
+// GetMempoolDescendantsCmd defines the getmempooldescendants JSON-RPC command.
+type GetMempoolDescendantsCmd struct {
+	TxID    string
+	Verbose *bool `jsonrpcdefault:"false"`
+}
+
+// NewGetMempoolDescendantsCmd returns a new instance which can be used to issue
+// a getmempooldescendants JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetMempoolDescendantsCmd(txID string, verbose *bool) *GetMempoolDescendantsCmd {
+	return &GetMempoolDescendantsCmd{
+		TxID:    txID,
+		Verbose: verbose,
+	}
+}
+
+// GetMempoolEntryCmd defines the getmempoolentry JSON-RPC command.
+type GetMempoolEntryCmd struct {
+	TxID string
+}
+
+// NewGetMempoolEntryCmd returns a new instance which can be used to issue a
+// getmempoolentry JSON-RPC command.
+func NewGetMempoolEntryCmd(txID string) *GetMempoolEntryCmd {
+	return &GetMempoolEntryCmd{
+		TxID: txID,
+	}
+}
+
+// GetMempoolGraphCmd defines the getmempoolgraph JSON-RPC command.
+type GetMempoolGraphCmd struct {
+	TxID string
+}
+
+// NewGetMempoolGraphCmd returns a new instance which can be used to issue a
+// getmempoolgraph JSON-RPC command.
+func NewGetMempoolGraphCmd(txID string) *GetMempoolGraphCmd {
+	return &GetMempoolGraphCmd{
+		TxID: txID,
+	}
+}
+
+// GetMissedTicketDetailsCmd defines the getmissedticketdetails JSON-RPC
+// command.
+type GetMissedTicketDetailsCmd struct {
+	Tickets *[]string
+}
+
+// NewGetMissedTicketDetailsCmd returns a new instance which can be used to
+// issue a getmissedticketdetails JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetMissedTicketDetailsCmd(tickets *[]string) *GetMissedTicketDetailsCmd {
+	return &GetMissedTicketDetailsCmd{
+		Tickets: tickets,
+	}
+}
+
+// GetPeerFilterStatsCmd defines the getpeerfilterstats JSON-RPC command.
+type GetPeerFilterStatsCmd struct{}
+
+// NewGetPeerFilterStatsCmd returns a new instance which can be used to issue a
+// getpeerfilterstats JSON-RPC command.
+func NewGetPeerFilterStatsCmd() *GetPeerFilterStatsCmd {
+	return &GetPeerFilterStatsCmd{}
+}
+
+// GetRejectedTransactionsCmd defines the getrejectedtransactions JSON-RPC
+// command.
+type GetRejectedTransactionsCmd struct{}
+
+// NewGetRejectedTransactionsCmd returns a new instance which can be used to
+// issue a getrejectedtransactions JSON-RPC command.
+func NewGetRejectedTransactionsCmd() *GetRejectedTransactionsCmd {
+	return &GetRejectedTransactionsCmd{}
+}
+
+// GetRelayHistoryCmd defines the getrelayhistory JSON-RPC command.  Type is
+// either "block" or "tx" to only return the relay history of blocks or
+// transactions, and both are returned when it is omitted.
+type GetRelayHistoryCmd struct {
+	Type  *string
+	Count *uint32 `jsonrpcdefault:"100"`
+}
+
+// NewGetRelayHistoryCmd returns a new instance which can be used to issue a
+// getrelayhistory JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetRelayHistoryCmd(invType *string, count *uint32) *GetRelayHistoryCmd {
+	return &GetRelayHistoryCmd{
+		Type:  invType,
+		Count: count,
+	}
+}
+
+// GetTxCostCmd defines the gettxcost JSON-RPC command.
+type GetTxCostCmd struct {
+	HexTx string
+}
+
+// NewGetTxCostCmd returns a new instance which can be used to issue a
+// gettxcost JSON-RPC command.
+func NewGetTxCostCmd(hexTx string) *GetTxCostCmd {
+	return &GetTxCostCmd{
+		HexTx: hexTx,
+	}
+}
+
+// GetTxRelayStatusCmd defines the gettxrelaystatus JSON-RPC command.
+type GetTxRelayStatusCmd struct {
+	TxID *string
+}
+
+// NewGetTxRelayStatusCmd returns a new instance which can be used to issue a
+// gettxrelaystatus JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetTxRelayStatusCmd(txID *string) *GetTxRelayStatusCmd {
+	return &GetTxRelayStatusCmd{
+		TxID: txID,
+	}
+}
+
+// GetTxSpendingInfoCmd defines the gettxspendinginfo JSON-RPC command.
+type GetTxSpendingInfoCmd struct {
+	Txid           string
+	Vout           uint32
+	IncludeMempool *bool `jsonrpcdefault:"true"`
+}
+
+// NewGetTxSpendingInfoCmd returns a new instance which can be used to issue a
+// gettxspendinginfo JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetTxSpendingInfoCmd(txHash string, index uint32, includeMempool *bool) *GetTxSpendingInfoCmd {
+	return &GetTxSpendingInfoCmd{
+		Txid:           txHash,
+		Vout:           index,
+		IncludeMempool: includeMempool,
+	}
+}
+
+// GetValidationStatsCmd defines the getvalidationstats JSON-RPC command.
+type GetValidationStatsCmd struct{}
+
+// NewGetValidationStatsCmd returns a new instance which can be used to issue a
+// getvalidationstats JSON-RPC command.
+func NewGetValidationStatsCmd() *GetValidationStatsCmd {
+	return &GetValidationStatsCmd{}
+}
+
 // GetStakeDifficultyCmd is a type handling custom marshaling and
 // unmarshaling of getstakedifficulty JSON RPC commands.
 type GetStakeDifficultyCmd struct{}
@@ -146,6 +603,15 @@ func NewGetStakeVersionsCmd(hash string, count int32) *GetStakeVersionsCmd {
 	}
 }
 
+// GetSyncStatusCmd defines the getsyncstatus JSON-RPC command.
+type GetSyncStatusCmd struct{}
+
+// NewGetSyncStatusCmd returns a new instance which can be used to issue a
+// getsyncstatus JSON-RPC command.
+func NewGetSyncStatusCmd() *GetSyncStatusCmd {
+	return &GetSyncStatusCmd{}
+}
+
 // GetTicketPoolValueCmd defines the getticketpoolvalue JSON-RPC command.
 type GetTicketPoolValueCmd struct{}
 
@@ -155,6 +621,28 @@ func NewGetTicketPoolValueCmd() *GetTicketPoolValueCmd {
 	return &GetTicketPoolValueCmd{}
 }
 
+// GetTreasuryBalanceCmd defines the gettreasurybalance JSON-RPC command.
+type GetTreasuryBalanceCmd struct{}
+
+// NewGetTreasuryBalanceCmd returns a new instance which can be used to issue a
+// gettreasurybalance JSON-RPC command.
+func NewGetTreasuryBalanceCmd() *GetTreasuryBalanceCmd {
+	return &GetTreasuryBalanceCmd{}
+}
+
+// GetTreasurySpendsCmd defines the gettreasuryspends JSON-RPC command.
+type GetTreasurySpendsCmd struct {
+	NumBlocks *uint32 `jsonrpcdefault:"1"`
+}
+
+// NewGetTreasurySpendsCmd returns a new instance which can be used to issue a
+// gettreasuryspends JSON-RPC command.
+func NewGetTreasurySpendsCmd(numBlocks *uint32) *GetTreasurySpendsCmd {
+	return &GetTreasurySpendsCmd{
+		NumBlocks: numBlocks,
+	}
+}
+
 // GetVoteInfoCmd returns voting results over a range of blocks.  Count
 // indicates how many blocks are walked backwards.
 type GetVoteInfoCmd struct {
@@ -169,6 +657,68 @@ func NewGetVoteInfoCmd(version uint32) *GetVoteInfoCmd {
 	}
 }
 
+// GetVotingWalletStatsCmd defines the getvotingwalletstats JSON-RPC command.
+type GetVotingWalletStatsCmd struct{}
+
+// NewGetVotingWalletStatsCmd returns a new instance which can be used to issue
+// a getvotingwalletstats JSON-RPC command.
+func NewGetVotingWalletStatsCmd() *GetVotingWalletStatsCmd {
+	return &GetVotingWalletStatsCmd{}
+}
+
+// GetWindowAggregatesCmd defines the getwindowaggregates JSON-RPC command.
+type GetWindowAggregatesCmd struct {
+	Windows *uint32 `jsonrpcdefault:"1"`
+}
+
+// NewGetWindowAggregatesCmd returns a new instance which can be used to issue
+// a getwindowaggregates JSON-RPC command.
+func NewGetWindowAggregatesCmd(windows *uint32) *GetWindowAggregatesCmd {
+	return &GetWindowAggregatesCmd{
+		Windows: windows,
+	}
+}
+
+// ImportAddrManCmd defines the importaddrman JSON-RPC command.
+type ImportAddrManCmd struct {
+	AddrMan AddrManDump
+}
+
+// NewImportAddrManCmd returns a new instance which can be used to issue an
+// importaddrman JSON-RPC command.
+func NewImportAddrManCmd(addrMan AddrManDump) *ImportAddrManCmd {
+	return &ImportAddrManCmd{
+		AddrMan: addrMan,
+	}
+}
+
+// ListJobsCmd defines the listjobs JSON-RPC command.
+type ListJobsCmd struct{}
+
+// NewListJobsCmd returns a new instance which can be used to issue a listjobs
+// JSON-RPC command.
+func NewListJobsCmd() *ListJobsCmd {
+	return &ListJobsCmd{}
+}
+
+// LoadUtxoSetCmd defines the loadutxoset JSON-RPC command.
+type LoadUtxoSetCmd struct {
+	File  string
+	Async *bool `jsonrpcdefault:"false"`
+}
+
+// NewLoadUtxoSetCmd returns a new instance which can be used to issue a
+// loadutxoset JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewLoadUtxoSetCmd(file string, async *bool) *LoadUtxoSetCmd {
+	return &LoadUtxoSetCmd{
+		File:  file,
+		Async: async,
+	}
+}
+
 // LiveTicketsCmd is a type handling custom marshaling and
 // unmarshaling of livetickets JSON RPC commands.
 type LiveTicketsCmd struct{}
@@ -209,6 +759,53 @@ func NewRebroadcastWinnersCmd() *RebroadcastWinnersCmd {
 	return &RebroadcastWinnersCmd{}
 }
 
+// RebuildIndexCmd defines the rebuildindex JSON-RPC command.  Index is the
+// name of the option which enables the index, such as txindex.
+type RebuildIndexCmd struct {
+	Index string
+}
+
+// NewRebuildIndexCmd returns a new instance which can be used to issue a
+// rebuildindex JSON-RPC command.
+func NewRebuildIndexCmd(index string) *RebuildIndexCmd {
+	return &RebuildIndexCmd{
+		Index: index,
+	}
+}
+
+// SetBlockAnnotationCmd defines the setblockannotation JSON-RPC command.  The
+// annotation with the key is removed when the value is omitted.
+type SetBlockAnnotationCmd struct {
+	Hash  string
+	Key   string
+	Value *string
+}
+
+// NewSetBlockAnnotationCmd returns a new instance which can be used to issue a
+// setblockannotation JSON-RPC command.
+func NewSetBlockAnnotationCmd(hash, key string, value *string) *SetBlockAnnotationCmd {
+	return &SetBlockAnnotationCmd{
+		Hash:  hash,
+		Key:   key,
+		Value: value,
+	}
+}
+
+// StartProfileCmd defines the startprofile JSON-RPC command.
+type StartProfileCmd struct {
+	ProfileType string
+	Duration    *uint32 `jsonrpcdefault:"30"`
+}
+
+// NewStartProfileCmd returns a new instance which can be used to issue a
+// startprofile JSON-RPC command.
+func NewStartProfileCmd(profileType string, duration *uint32) *StartProfileCmd {
+	return &StartProfileCmd{
+		ProfileType: profileType,
+		Duration:    duration,
+	}
+}
+
 // TicketFeeInfoCmd defines the ticketsfeeinfo JSON-RPC command.
 type TicketFeeInfoCmd struct {
 	Blocks  *uint32
@@ -267,6 +864,20 @@ func NewTxFeeInfoCmd(blocks *uint32, start *uint32, end *uint32) *TxFeeInfoCmd {
 	}
 }
 
+// VerifyIndexCmd defines the verifyindex JSON-RPC command.  Index is the
+// name of the option which enables the index, such as txindex.
+type VerifyIndexCmd struct {
+	Index string
+}
+
+// NewVerifyIndexCmd returns a new instance which can be used to issue a
+// verifyindex JSON-RPC command.
+func NewVerifyIndexCmd(index string) *VerifyIndexCmd {
+	return &VerifyIndexCmd{
+		Index: index,
+	}
+}
+
 // VersionCmd defines the version JSON-RPC command.
 type VersionCmd struct{}
 
@@ -274,30 +885,88 @@ type VersionCmd struct{}
 // version command.
 func NewVersionCmd() *VersionCmd { return new(VersionCmd) }
 
+// WarpClockCmd defines the warpclock JSON-RPC command.
+type WarpClockCmd struct {
+	Seconds int64
+}
+
+// NewWarpClockCmd returns a new instance which can be used to issue a
+// warpclock JSON-RPC command.
+func NewWarpClockCmd(seconds int64) *WarpClockCmd {
+	return &WarpClockCmd{
+		Seconds: seconds,
+	}
+}
+
 func init() {
 	// No special flags for commands in this file.
 	flags := UsageFlag(0)
 
+	MustRegisterCmd("advancechain", (*AdvanceChainCmd)(nil), flags)
+	MustRegisterCmd("canceljob", (*CancelJobCmd)(nil), flags)
+	MustRegisterCmd("comparechainwork", (*CompareChainWorkCmd)(nil), flags)
+	MustRegisterCmd("creditoutput", (*CreditOutputCmd)(nil), flags)
+	MustRegisterCmd("dropindex", (*DropIndexCmd)(nil), flags)
+	MustRegisterCmd("dumpaddrman", (*DumpAddrManCmd)(nil), flags)
+	MustRegisterCmd("dumputxoset", (*DumpUtxoSetCmd)(nil), flags)
+	MustRegisterCmd("estimatesmartfee", (*EstimateSmartFeeCmd)(nil), flags)
 	MustRegisterCmd("estimatestakediff", (*EstimateStakeDiffCmd)(nil), flags)
+	MustRegisterCmd("estimateticketfee", (*EstimateTicketFeeCmd)(nil), flags)
+	MustRegisterCmd("estimatetimetoconfirm", (*EstimateTimeToConfirmCmd)(nil), flags)
 	MustRegisterCmd("existsaddress", (*ExistsAddressCmd)(nil), flags)
 	MustRegisterCmd("existsaddresses", (*ExistsAddressesCmd)(nil), flags)
 	MustRegisterCmd("existsexpiredtickets", (*ExistsExpiredTicketsCmd)(nil), flags)
 	MustRegisterCmd("existsliveticket", (*ExistsLiveTicketCmd)(nil), flags)
 	MustRegisterCmd("existslivetickets", (*ExistsLiveTicketsCmd)(nil), flags)
 	MustRegisterCmd("existsmempooltxs", (*ExistsMempoolTxsCmd)(nil), flags)
+	MustRegisterCmd("forcestakedifficulty", (*ForceStakeDifficultyCmd)(nil), flags)
+	MustRegisterCmd("getaddressbalance", (*GetAddressBalanceCmd)(nil), flags)
+	MustRegisterCmd("getaddressutxos", (*GetAddressUtxosCmd)(nil), flags)
+	MustRegisterCmd("getblockannotations", (*GetBlockAnnotationsCmd)(nil), flags)
+	MustRegisterCmd("getblockbymediantime", (*GetBlockByMedianTimeCmd)(nil), flags)
+	MustRegisterCmd("getblockhashbytime", (*GetBlockHashByTimeCmd)(nil), flags)
 	MustRegisterCmd("getcoinsupply", (*GetCoinSupplyCmd)(nil), flags)
+	MustRegisterCmd("getcoinsupplybreakdown", (*GetCoinSupplyBreakdownCmd)(nil), flags)
+	MustRegisterCmd("getfinality", (*GetFinalityCmd)(nil), flags)
+	MustRegisterCmd("getindexinfo", (*GetIndexInfoCmd)(nil), flags)
+	MustRegisterCmd("getjob", (*GetJobCmd)(nil), flags)
+	MustRegisterCmd("getmempoolancestors", (*GetMempoolAncestorsCmd)(nil), flags)
+	MustRegisterCmd("getmempooldescendants", (*GetMempoolDescendantsCmd)(nil), flags)
+	MustRegisterCmd("getmempoolentry", (*GetMempoolEntryCmd)(nil), flags)
+	MustRegisterCmd("getmempoolgraph", (*GetMempoolGraphCmd)(nil), flags)
+	MustRegisterCmd("getmissedticketdetails", (*GetMissedTicketDetailsCmd)(nil), flags)
+	MustRegisterCmd("getpeerfilterstats", (*GetPeerFilterStatsCmd)(nil), flags)
+	MustRegisterCmd("getrejectedtransactions", (*GetRejectedTransactionsCmd)(nil), flags)
+	MustRegisterCmd("getrelayhistory", (*GetRelayHistoryCmd)(nil), flags)
 	MustRegisterCmd("getstakedifficulty", (*GetStakeDifficultyCmd)(nil), flags)
 	MustRegisterCmd("getstakeversioninfo", (*GetStakeVersionInfoCmd)(nil), flags)
 	MustRegisterCmd("getstakeversions", (*GetStakeVersionsCmd)(nil), flags)
+	MustRegisterCmd("getsyncstatus", (*GetSyncStatusCmd)(nil), flags)
 	MustRegisterCmd("getticketpoolvalue", (*GetTicketPoolValueCmd)(nil), flags)
+	MustRegisterCmd("gettreasurybalance", (*GetTreasuryBalanceCmd)(nil), flags)
+	MustRegisterCmd("gettreasuryspends", (*GetTreasurySpendsCmd)(nil), flags)
+	MustRegisterCmd("gettxcost", (*GetTxCostCmd)(nil), flags)
+	MustRegisterCmd("gettxrelaystatus", (*GetTxRelayStatusCmd)(nil), flags)
+	MustRegisterCmd("gettxspendinginfo", (*GetTxSpendingInfoCmd)(nil), flags)
+	MustRegisterCmd("getvalidationstats", (*GetValidationStatsCmd)(nil), flags)
 	MustRegisterCmd("getvoteinfo", (*GetVoteInfoCmd)(nil), flags)
+	MustRegisterCmd("getvotingwalletstats", (*GetVotingWalletStatsCmd)(nil), flags)
+	MustRegisterCmd("getwindowaggregates", (*GetWindowAggregatesCmd)(nil), flags)
+	MustRegisterCmd("importaddrman", (*ImportAddrManCmd)(nil), flags)
+	MustRegisterCmd("listjobs", (*ListJobsCmd)(nil), flags)
 	MustRegisterCmd("livetickets", (*LiveTicketsCmd)(nil), flags)
+	MustRegisterCmd("loadutxoset", (*LoadUtxoSetCmd)(nil), flags)
 	MustRegisterCmd("missedtickets", (*MissedTicketsCmd)(nil), flags)
 	MustRegisterCmd("rebroadcastmissed", (*RebroadcastMissedCmd)(nil), flags)
 	MustRegisterCmd("rebroadcastwinners", (*RebroadcastWinnersCmd)(nil), flags)
+	MustRegisterCmd("rebuildindex", (*RebuildIndexCmd)(nil), flags)
+	MustRegisterCmd("setblockannotation", (*SetBlockAnnotationCmd)(nil), flags)
+	MustRegisterCmd("startprofile", (*StartProfileCmd)(nil), flags)
 	MustRegisterCmd("ticketfeeinfo", (*TicketFeeInfoCmd)(nil), flags)
 	MustRegisterCmd("ticketsforaddress", (*TicketsForAddressCmd)(nil), flags)
 	MustRegisterCmd("ticketvwap", (*TicketVWAPCmd)(nil), flags)
 	MustRegisterCmd("txfeeinfo", (*TxFeeInfoCmd)(nil), flags)
+	MustRegisterCmd("verifyindex", (*VerifyIndexCmd)(nil), flags)
 	MustRegisterCmd("version", (*VersionCmd)(nil), flags)
+	MustRegisterCmd("warpclock", (*WarpClockCmd)(nil), flags)
 }
@@ -42,6 +42,234 @@ func TestDcrdCmds(t *testing.T) {
 				LevelSpec: "trace",
 			},
 		},
+		{
+			name: "advancechain",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("advancechain", 10)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewAdvanceChainCmd(10)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"advancechain","params":[10],"id":1}`,
+			unmarshalled: &dcrjson.AdvanceChainCmd{
+				NumBlocks: 10,
+			},
+		},
+		{
+			name: "canceljob",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("canceljob", 3)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewCancelJobCmd(3)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"canceljob","params":[3],"id":1}`,
+			unmarshalled: &dcrjson.CancelJobCmd{
+				JobID: 3,
+			},
+		},
+		{
+			name: "comparechainwork",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("comparechainwork", "123", "456")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewCompareChainWorkCmd("123", "456")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"comparechainwork","params":["123","456"],"id":1}`,
+			unmarshalled: &dcrjson.CompareChainWorkCmd{
+				Hash1: "123",
+				Hash2: "456",
+			},
+		},
+		{
+			name: "creditoutput",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("creditoutput", "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc", 1.5)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewCreditOutputCmd("SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc", 1.5)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"creditoutput","params":["SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc",1.5],"id":1}`,
+			unmarshalled: &dcrjson.CreditOutputCmd{
+				Address: "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc",
+				Amount:  1.5,
+			},
+		},
+		{
+			name: "dropindex",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("dropindex", "txindex")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewDropIndexCmd("txindex")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"dropindex","params":["txindex"],"id":1}`,
+			unmarshalled: &dcrjson.DropIndexCmd{
+				Index: "txindex",
+			},
+		},
+		{
+			name: "dumpaddrman",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("dumpaddrman")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewDumpAddrManCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"dumpaddrman","params":[],"id":1}`,
+			unmarshalled: &dcrjson.DumpAddrManCmd{},
+		},
+		{
+			name: "dumputxoset",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("dumputxoset")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewDumpUtxoSetCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"dumputxoset","params":[],"id":1}`,
+			unmarshalled: &dcrjson.DumpUtxoSetCmd{
+				Height: nil,
+				Async:  dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "dumputxoset optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("dumputxoset", 1000)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewDumpUtxoSetCmd(dcrjson.Int64(1000), nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"dumputxoset","params":[1000],"id":1}`,
+			unmarshalled: &dcrjson.DumpUtxoSetCmd{
+				Height: dcrjson.Int64(1000),
+				Async:  dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "dumputxoset async",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("dumputxoset", 1000, true)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewDumpUtxoSetCmd(dcrjson.Int64(1000),
+					dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"dumputxoset","params":[1000,true],"id":1}`,
+			unmarshalled: &dcrjson.DumpUtxoSetCmd{
+				Height: dcrjson.Int64(1000),
+				Async:  dcrjson.Bool(true),
+			},
+		},
+		{
+			name: "estimatesmartfee",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("estimatesmartfee", 6)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewEstimateSmartFeeCmd(6)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"estimatesmartfee","params":[6],"id":1}`,
+			unmarshalled: &dcrjson.EstimateSmartFeeCmd{
+				Confirmations: 6,
+			},
+		},
+		{
+			name: "estimateticketfee",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("estimateticketfee", 6)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewEstimateTicketFeeCmd(6)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"estimateticketfee","params":[6],"id":1}`,
+			unmarshalled: &dcrjson.EstimateTicketFeeCmd{
+				Confirmations: 6,
+			},
+		},
+		{
+			name: "estimatetimetoconfirm",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("estimatetimetoconfirm", 0.001, 250)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewEstimateTimeToConfirmCmd(0.001, 250, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"estimatetimetoconfirm","params":[0.001,250],"id":1}`,
+			unmarshalled: &dcrjson.EstimateTimeToConfirmCmd{
+				FeeRate:   0.001,
+				Size:      250,
+				NumBlocks: dcrjson.Uint32(6),
+			},
+		},
+		{
+			name: "estimatetimetoconfirm optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("estimatetimetoconfirm", 0.001, 250, 12)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewEstimateTimeToConfirmCmd(0.001, 250,
+					dcrjson.Uint32(12))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"estimatetimetoconfirm","params":[0.001,250,12],"id":1}`,
+			unmarshalled: &dcrjson.EstimateTimeToConfirmCmd{
+				FeeRate:   0.001,
+				Size:      250,
+				NumBlocks: dcrjson.Uint32(12),
+			},
+		},
+		{
+			name: "forcestakedifficulty",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("forcestakedifficulty", 2.5)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewForceStakeDifficultyCmd(2.5)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"forcestakedifficulty","params":[2.5],"id":1}`,
+			unmarshalled: &dcrjson.ForceStakeDifficultyCmd{
+				StakeDifficulty: 2.5,
+			},
+		},
+		{
+			name: "importaddrman",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("importaddrman", `{"version":1,"addresses":[{"addr":"1.2.3.4:9108","src":"5.6.7.8:9108","services":1,"attempts":2,"timestamp":1500000000,"lastattempt":1500000001,"lastsuccess":1500000002,"tried":true}]}`)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewImportAddrManCmd(dcrjson.AddrManDump{
+					Version: 1,
+					Addresses: []dcrjson.AddrManAddress{{
+						Addr:        "1.2.3.4:9108",
+						Src:         "5.6.7.8:9108",
+						Services:    1,
+						Attempts:    2,
+						TimeStamp:   1500000000,
+						LastAttempt: 1500000001,
+						LastSuccess: 1500000002,
+						Tried:       true,
+					}},
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"importaddrman","params":[{"version":1,"addresses":[{"addr":"1.2.3.4:9108","src":"5.6.7.8:9108","services":1,"attempts":2,"timestamp":1500000000,"lastattempt":1500000001,"lastsuccess":1500000002,"tried":true}]}],"id":1}`,
+			unmarshalled: &dcrjson.ImportAddrManCmd{
+				AddrMan: dcrjson.AddrManDump{
+					Version: 1,
+					Addresses: []dcrjson.AddrManAddress{{
+						Addr:        "1.2.3.4:9108",
+						Src:         "5.6.7.8:9108",
+						Services:    1,
+						Attempts:    2,
+						TimeStamp:   1500000000,
+						LastAttempt: 1500000001,
+						LastSuccess: 1500000002,
+						Tried:       true,
+					}},
+				},
+			},
+		},
 		{
 			name: "getstakeversions",
 			newCmd: func() (interface{}, error) {
@@ -56,6 +284,407 @@ func TestDcrdCmds(t *testing.T) {
 				Count: 1,
 			},
 		},
+		{
+			name: "getsyncstatus",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getsyncstatus")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetSyncStatusCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getsyncstatus","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetSyncStatusCmd{},
+		},
+		{
+			name: "getaddressbalance",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getaddressbalance", "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetAddressBalanceCmd("SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressbalance","params":["SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc"],"id":1}`,
+			unmarshalled: &dcrjson.GetAddressBalanceCmd{
+				Address: "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc",
+			},
+		},
+		{
+			name: "getaddressutxos",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getaddressutxos", "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetAddressUtxosCmd("SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressutxos","params":["SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc"],"id":1}`,
+			unmarshalled: &dcrjson.GetAddressUtxosCmd{
+				Address: "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc",
+			},
+		},
+		{
+			name: "getblockannotations",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getblockannotations", "123")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetBlockAnnotationsCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockannotations","params":["123"],"id":1}`,
+			unmarshalled: &dcrjson.GetBlockAnnotationsCmd{
+				Hash: "123",
+			},
+		},
+		{
+			name: "getblockbymediantime",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getblockbymediantime", 1500000000)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetBlockByMedianTimeCmd(1500000000)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockbymediantime","params":[1500000000],"id":1}`,
+			unmarshalled: &dcrjson.GetBlockByMedianTimeCmd{
+				Time: 1500000000,
+			},
+		},
+		{
+			name: "getblockhashbytime",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getblockhashbytime", 1500000000)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetBlockHashByTimeCmd(1500000000)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockhashbytime","params":[1500000000],"id":1}`,
+			unmarshalled: &dcrjson.GetBlockHashByTimeCmd{
+				Time: 1500000000,
+			},
+		},
+		{
+			name: "getcoinsupplybreakdown",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getcoinsupplybreakdown")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetCoinSupplyBreakdownCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getcoinsupplybreakdown","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetCoinSupplyBreakdownCmd{},
+		},
+		{
+			name: "getfinality",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getfinality")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetFinalityCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getfinality","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetFinalityCmd{},
+		},
+		{
+			name: "getindexinfo",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getindexinfo")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetIndexInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getindexinfo","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetIndexInfoCmd{},
+		},
+		{
+			name: "getjob",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getjob", 3)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetJobCmd(3)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getjob","params":[3],"id":1}`,
+			unmarshalled: &dcrjson.GetJobCmd{
+				JobID: 3,
+			},
+		},
+		{
+			name: "getmempoolancestors",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getmempoolancestors", "123")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetMempoolAncestorsCmd("123", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolancestors","params":["123"],"id":1}`,
+			unmarshalled: &dcrjson.GetMempoolAncestorsCmd{
+				TxID:    "123",
+				Verbose: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "getmempoolancestors verbose",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getmempoolancestors", "123", true)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetMempoolAncestorsCmd("123",
+					dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolancestors","params":["123",true],"id":1}`,
+			unmarshalled: &dcrjson.GetMempoolAncestorsCmd{
+				TxID:    "123",
+				Verbose: dcrjson.Bool(true),
+			},
+		},
+		{
+			name: "getmempooldescendants",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getmempooldescendants", "123")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetMempoolDescendantsCmd("123", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempooldescendants","params":["123"],"id":1}`,
+			unmarshalled: &dcrjson.GetMempoolDescendantsCmd{
+				TxID:    "123",
+				Verbose: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "getmempooldescendants verbose",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getmempooldescendants", "123", true)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetMempoolDescendantsCmd("123",
+					dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempooldescendants","params":["123",true],"id":1}`,
+			unmarshalled: &dcrjson.GetMempoolDescendantsCmd{
+				TxID:    "123",
+				Verbose: dcrjson.Bool(true),
+			},
+		},
+		{
+			name: "getmempoolentry",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getmempoolentry", "123")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetMempoolEntryCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolentry","params":["123"],"id":1}`,
+			unmarshalled: &dcrjson.GetMempoolEntryCmd{
+				TxID: "123",
+			},
+		},
+		{
+			name: "getmempoolgraph",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getmempoolgraph", "123")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetMempoolGraphCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolgraph","params":["123"],"id":1}`,
+			unmarshalled: &dcrjson.GetMempoolGraphCmd{
+				TxID: "123",
+			},
+		},
+		{
+			name: "getmissedticketdetails",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getmissedticketdetails")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetMissedTicketDetailsCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmissedticketdetails","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetMissedTicketDetailsCmd{
+				Tickets: nil,
+			},
+		},
+		{
+			name: "getmissedticketdetails optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getmissedticketdetails", []string{"123"})
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetMissedTicketDetailsCmd(&[]string{"123"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmissedticketdetails","params":[["123"]],"id":1}`,
+			unmarshalled: &dcrjson.GetMissedTicketDetailsCmd{
+				Tickets: &[]string{"123"},
+			},
+		},
+		{
+			name: "getpeerfilterstats",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getpeerfilterstats")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetPeerFilterStatsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getpeerfilterstats","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetPeerFilterStatsCmd{},
+		},
+		{
+			name: "getrejectedtransactions",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getrejectedtransactions")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetRejectedTransactionsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getrejectedtransactions","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetRejectedTransactionsCmd{},
+		},
+		{
+			name: "getrelayhistory",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getrelayhistory")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetRelayHistoryCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getrelayhistory","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetRelayHistoryCmd{
+				Type:  nil,
+				Count: dcrjson.Uint32(100),
+			},
+		},
+		{
+			name: "getrelayhistory optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getrelayhistory", "block", 10)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetRelayHistoryCmd(dcrjson.String("block"),
+					dcrjson.Uint32(10))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getrelayhistory","params":["block",10],"id":1}`,
+			unmarshalled: &dcrjson.GetRelayHistoryCmd{
+				Type:  dcrjson.String("block"),
+				Count: dcrjson.Uint32(10),
+			},
+		},
+		{
+			name: "gettreasurybalance",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("gettreasurybalance")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetTreasuryBalanceCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"gettreasurybalance","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetTreasuryBalanceCmd{},
+		},
+		{
+			name: "gettreasuryspends",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("gettreasuryspends")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetTreasurySpendsCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettreasuryspends","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetTreasurySpendsCmd{
+				NumBlocks: dcrjson.Uint32(1),
+			},
+		},
+		{
+			name: "gettreasuryspends optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("gettreasuryspends", 144)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetTreasurySpendsCmd(dcrjson.Uint32(144))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettreasuryspends","params":[144],"id":1}`,
+			unmarshalled: &dcrjson.GetTreasurySpendsCmd{
+				NumBlocks: dcrjson.Uint32(144),
+			},
+		},
+		{
+			name: "gettxcost",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("gettxcost", "0100")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetTxCostCmd("0100")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxcost","params":["0100"],"id":1}`,
+			unmarshalled: &dcrjson.GetTxCostCmd{
+				HexTx: "0100",
+			},
+		},
+		{
+			name: "gettxrelaystatus",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("gettxrelaystatus")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetTxRelayStatusCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxrelaystatus","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetTxRelayStatusCmd{
+				TxID: nil,
+			},
+		},
+		{
+			name: "gettxrelaystatus optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("gettxrelaystatus", "123")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetTxRelayStatusCmd(dcrjson.String("123"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxrelaystatus","params":["123"],"id":1}`,
+			unmarshalled: &dcrjson.GetTxRelayStatusCmd{
+				TxID: dcrjson.String("123"),
+			},
+		},
+		{
+			name: "gettxspendinginfo",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("gettxspendinginfo", "123", 1)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetTxSpendingInfoCmd("123", 1, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxspendinginfo","params":["123",1],"id":1}`,
+			unmarshalled: &dcrjson.GetTxSpendingInfoCmd{
+				Txid:           "123",
+				Vout:           1,
+				IncludeMempool: dcrjson.Bool(true),
+			},
+		},
+		{
+			name: "gettxspendinginfo optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("gettxspendinginfo", "123", 1, false)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetTxSpendingInfoCmd("123", 1,
+					dcrjson.Bool(false))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxspendinginfo","params":["123",1,false],"id":1}`,
+			unmarshalled: &dcrjson.GetTxSpendingInfoCmd{
+				Txid:           "123",
+				Vout:           1,
+				IncludeMempool: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "getvalidationstats",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getvalidationstats")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetValidationStatsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getvalidationstats","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetValidationStatsCmd{},
+		},
 		{
 			name: "getvoteinfo",
 			newCmd: func() (interface{}, error) {
@@ -69,6 +698,180 @@ func TestDcrdCmds(t *testing.T) {
 				Version: 1,
 			},
 		},
+		{
+			name: "getvotingwalletstats",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getvotingwalletstats")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetVotingWalletStatsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getvotingwalletstats","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetVotingWalletStatsCmd{},
+		},
+		{
+			name: "getwindowaggregates",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getwindowaggregates")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetWindowAggregatesCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getwindowaggregates","params":[],"id":1}`,
+			unmarshalled: &dcrjson.GetWindowAggregatesCmd{
+				Windows: dcrjson.Uint32(1),
+			},
+		},
+		{
+			name: "getwindowaggregates optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("getwindowaggregates", 5)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewGetWindowAggregatesCmd(dcrjson.Uint32(5))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getwindowaggregates","params":[5],"id":1}`,
+			unmarshalled: &dcrjson.GetWindowAggregatesCmd{
+				Windows: dcrjson.Uint32(5),
+			},
+		},
+		{
+			name: "listjobs",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("listjobs")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewListJobsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"listjobs","params":[],"id":1}`,
+			unmarshalled: &dcrjson.ListJobsCmd{},
+		},
+		{
+			name: "loadutxoset",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("loadutxoset", "utxoset.dat")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewLoadUtxoSetCmd("utxoset.dat", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"loadutxoset","params":["utxoset.dat"],"id":1}`,
+			unmarshalled: &dcrjson.LoadUtxoSetCmd{
+				File:  "utxoset.dat",
+				Async: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "loadutxoset async",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("loadutxoset", "utxoset.dat", true)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewLoadUtxoSetCmd("utxoset.dat",
+					dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"loadutxoset","params":["utxoset.dat",true],"id":1}`,
+			unmarshalled: &dcrjson.LoadUtxoSetCmd{
+				File:  "utxoset.dat",
+				Async: dcrjson.Bool(true),
+			},
+		},
+		{
+			name: "rebuildindex",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("rebuildindex", "txindex")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewRebuildIndexCmd("txindex")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"rebuildindex","params":["txindex"],"id":1}`,
+			unmarshalled: &dcrjson.RebuildIndexCmd{
+				Index: "txindex",
+			},
+		},
+		{
+			name: "setblockannotation",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("setblockannotation", "123", "tag")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewSetBlockAnnotationCmd("123", "tag", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setblockannotation","params":["123","tag"],"id":1}`,
+			unmarshalled: &dcrjson.SetBlockAnnotationCmd{
+				Hash: "123",
+				Key:  "tag",
+			},
+		},
+		{
+			name: "setblockannotation optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("setblockannotation", "123", "tag", "value")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewSetBlockAnnotationCmd("123", "tag",
+					dcrjson.String("value"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setblockannotation","params":["123","tag","value"],"id":1}`,
+			unmarshalled: &dcrjson.SetBlockAnnotationCmd{
+				Hash:  "123",
+				Key:   "tag",
+				Value: dcrjson.String("value"),
+			},
+		},
+		{
+			name: "startprofile",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("startprofile", "cpu")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewStartProfileCmd("cpu", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"startprofile","params":["cpu"],"id":1}`,
+			unmarshalled: &dcrjson.StartProfileCmd{
+				ProfileType: "cpu",
+				Duration:    dcrjson.Uint32(30),
+			},
+		},
+		{
+			name: "startprofile optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("startprofile", "trace", 10)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewStartProfileCmd("trace", dcrjson.Uint32(10))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"startprofile","params":["trace",10],"id":1}`,
+			unmarshalled: &dcrjson.StartProfileCmd{
+				ProfileType: "trace",
+				Duration:    dcrjson.Uint32(10),
+			},
+		},
+		{
+			name: "verifyindex",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("verifyindex", "txindex")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewVerifyIndexCmd("txindex")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"verifyindex","params":["txindex"],"id":1}`,
+			unmarshalled: &dcrjson.VerifyIndexCmd{
+				Index: "txindex",
+			},
+		},
+		{
+			name: "warpclock",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("warpclock", -3600)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewWarpClockCmd(-3600)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"warpclock","params":[-3600],"id":1}`,
+			unmarshalled: &dcrjson.WarpClockCmd{
+				Seconds: -3600,
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
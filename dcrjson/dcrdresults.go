@@ -5,6 +5,147 @@
 
 package dcrjson
 
+// UtxoSnapshotResult models the data returned from the dumputxoset and
+// loadutxoset commands.
+type UtxoSnapshotResult struct {
+	File        string `json:"file"`
+	Hash        string `json:"hash"`
+	Height      int64  `json:"height"`
+	NumUtxos    uint64 `json:"numutxos"`
+	NumTickets  uint32 `json:"numtickets"`
+	UtxoSetHash string `json:"utxosethash"`
+	Checksum    string `json:"checksum"`
+}
+
+// ImportAddrManResult models the data returned from the importaddrman command.
+type ImportAddrManResult struct {
+	Added   int `json:"added"`
+	Skipped int `json:"skipped"`
+}
+
+// CompareChainWorkResult models the data returned from the comparechainwork
+// command.  The chain work values are hex-encoded and Difference is the chain
+// work of the first block less that of the second, which is prefixed with a
+// minus sign when negative.  Comparison is -1, 0, or 1 when the chain work of
+// the first block is less than, equal to, or greater than that of the second.
+type CompareChainWorkResult struct {
+	ChainWork1 string `json:"chainwork1"`
+	ChainWork2 string `json:"chainwork2"`
+	Difference string `json:"difference"`
+	Comparison int    `json:"comparison"`
+}
+
+// CreditOutputResult models the data returned from the creditoutput command.
+type CreditOutputResult struct {
+	TxID        string `json:"txid"`
+	BlockHeight int64  `json:"blockheight"`
+	BlockIndex  uint32 `json:"blockindex"`
+}
+
+// BlockAnnotationResult models an annotation of a block returned from the
+// getblockannotations command.
+type BlockAnnotationResult struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// GetBlockByMedianTimeResult models the data returned from the
+// getblockbymediantime command.  Time and MedianTime are unix timestamps.
+type GetBlockByMedianTimeResult struct {
+	Hash       string `json:"hash"`
+	Height     int64  `json:"height"`
+	Time       int64  `json:"time"`
+	MedianTime int64  `json:"mediantime"`
+}
+
+// GetCoinSupplyBreakdownResult models the data returned from the
+// getcoinsupplybreakdown command.  The amounts are in atoms and Total is the sum
+// of the amounts mined from each subsidy source.
+type GetCoinSupplyBreakdownResult struct {
+	Hash       string `json:"hash"`
+	Height     int64  `json:"height"`
+	Total      int64  `json:"total"`
+	Premine    int64  `json:"premine"`
+	PoW        int64  `json:"pow"`
+	PoS        int64  `json:"pos"`
+	DevSubsidy int64  `json:"devsubsidy"`
+}
+
+// GetFinalityResult models the data returned from the getfinality command.
+// Height and Hash identify the most recent block which may no longer be
+// disconnected by a reorganize, which is the genesis block when no finality
+// depth is enforced.
+type GetFinalityResult struct {
+	Active bool   `json:"active"`
+	Depth  int64  `json:"depth"`
+	Height int64  `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// GetMempoolEntryResult models the data returned from the getmempoolentry
+// command and the verbose getmempoolancestors and getmempooldescendants
+// commands.  The ancestor and descendant stats include the transaction itself.
+// Depends lists the transactions of the memory pool which the transaction
+// spends and SpentBy those which spend it.  The fees are in DCR.
+type GetMempoolEntryResult struct {
+	TxID            string   `json:"txid"`
+	Size            int32    `json:"size"`
+	Fee             float64  `json:"fee"`
+	Time            int64    `json:"time"`
+	Height          int64    `json:"height"`
+	AncestorCount   int64    `json:"ancestorcount"`
+	AncestorSize    int64    `json:"ancestorsize"`
+	AncestorFees    float64  `json:"ancestorfees"`
+	DescendantCount int64    `json:"descendantcount"`
+	DescendantSize  int64    `json:"descendantsize"`
+	DescendantFees  float64  `json:"descendantfees"`
+	Depends         []string `json:"depends"`
+	SpentBy         []string `json:"spentby"`
+}
+
+// MempoolGraphEntry models a transaction of the memory pool dependency graph
+// returned from the getmempoolgraph command.  Depends lists the transactions of
+// the memory pool which the transaction spends and SpentBy those which spend
+// it.  The fee is in DCR.
+type MempoolGraphEntry struct {
+	TxID    string   `json:"txid"`
+	Size    int32    `json:"size"`
+	Fee     float64  `json:"fee"`
+	Time    int64    `json:"time"`
+	Height  int64    `json:"height"`
+	Depends []string `json:"depends"`
+	SpentBy []string `json:"spentby"`
+}
+
+// GetMempoolGraphResult models the data returned from the getmempoolgraph
+// command.  The transactions are ordered so that every transaction follows all
+// of its ancestors among them.
+type GetMempoolGraphResult struct {
+	TxID         string              `json:"txid"`
+	Transactions []MempoolGraphEntry `json:"transactions"`
+}
+
+// GetTreasuryBalanceResult models the data returned from the gettreasurybalance
+// command.  The amounts are in atoms and Added and Spent are the changes which
+// were applied to the treasury by the block.
+type GetTreasuryBalanceResult struct {
+	Active  bool   `json:"active"`
+	Hash    string `json:"hash"`
+	Height  int64  `json:"height"`
+	Balance int64  `json:"balance"`
+	Added   int64  `json:"added"`
+	Spent   int64  `json:"spent"`
+}
+
+// TreasurySpendResult models the data of a transaction which spent from the
+// treasury that is returned from the gettreasuryspends command.
+type TreasurySpendResult struct {
+	TxID        string `json:"txid"`
+	BlockHash   string `json:"blockhash"`
+	BlockHeight int64  `json:"blockheight"`
+	Amount      int64  `json:"amount"`
+}
+
 // GetStakeDifficultyResult models the data returned from the
 // getstakedifficulty command.
 type GetStakeDifficultyResult struct {
@@ -26,6 +167,65 @@ type VersionInterval struct {
 	VoteVersions []VersionCount `json:"voteversions"`
 }
 
+// MissedTicketDetailsResult models the data returned for each ticket from the
+// getmissedticketdetails command.  The times are unix timestamps and are zero
+// when unknown.
+type MissedTicketDetailsResult struct {
+	Ticket           string `json:"ticket"`
+	BlockHash        string `json:"blockhash"`
+	BlockHeight      int64  `json:"blockheight"`
+	VotedBlockHash   string `json:"votedblockhash"`
+	VotedBlockHeight int64  `json:"votedblockheight"`
+	Reason           string `json:"reason"`
+	LotteryTime      int64  `json:"lotterytime"`
+	VoteSeenTime     int64  `json:"voteseentime"`
+	BlockSeenTime    int64  `json:"blockseentime"`
+	VotingWallets    int64  `json:"votingwallets"`
+	Acknowledged     bool   `json:"acknowledged"`
+}
+
+// PeerFilterRuleStats models the data returned for each peer filter rule from
+// the getpeerfilterstats command.
+type PeerFilterRuleStats struct {
+	Rule    string `json:"rule"`
+	Action  string `json:"action"`
+	Matches uint64 `json:"matches"`
+}
+
+// GetPeerFilterStatsResult models the data returned from the getpeerfilterstats
+// command.
+type GetPeerFilterStatsResult struct {
+	Refused       uint64                `json:"refused"`
+	Deprioritized uint64                `json:"deprioritized"`
+	Rules         []PeerFilterRuleStats `json:"rules"`
+}
+
+// RejectedTransactionResult models the data returned for each transaction from
+// the getrejectedtransactions command.
+type RejectedTransactionResult struct {
+	TxID       string `json:"txid"`
+	FullHash   string `json:"fullhash"`
+	RejectCode string `json:"rejectcode"`
+	Reason     string `json:"reason"`
+	Time       int64  `json:"time"`
+	Expires    int64  `json:"expires"`
+	Seen       uint32 `json:"seen"`
+}
+
+// RelayHistoryResult models the data returned for each block or transaction
+// from the getrelayhistory command.  The announcement fields are omitted when
+// the inventory was delivered without being announced first and the delivery
+// fields are omitted when it was not delivered yet.  The times are in
+// milliseconds since 1 Jan 1970 GMT.
+type RelayHistoryResult struct {
+	Hash          string `json:"hash"`
+	Type          string `json:"type"`
+	AnnouncedBy   string `json:"announcedby,omitempty"`
+	AnnouncedTime int64  `json:"announcedtime,omitempty"`
+	ReceivedFrom  string `json:"receivedfrom,omitempty"`
+	ReceivedTime  int64  `json:"receivedtime,omitempty"`
+}
+
 // GetStakeVersionInfoResult models the resulting data for getstakeversioninfo
 // command.
 type GetStakeVersionInfoResult struct {
@@ -34,6 +234,37 @@ type GetStakeVersionInfoResult struct {
 	Intervals     []VersionInterval `json:"intervals"`
 }
 
+// SyncPeerStatusResult models the data returned for each sync peer from the
+// getsyncstatus command.  AssignedFrom and AssignedTo are the lowest and
+// highest heights of the blocks requested from the peer which were not yet
+// received, and are zero when there are none.  Throughput is in bytes per
+// second.
+type SyncPeerStatusResult struct {
+	ID             int32   `json:"id"`
+	Addr           string  `json:"addr"`
+	LastBlock      int64   `json:"lastblock"`
+	InFlight       int     `json:"inflight"`
+	Quota          int     `json:"quota"`
+	AssignedFrom   int64   `json:"assignedfrom"`
+	AssignedTo     int64   `json:"assignedto"`
+	BlocksReceived uint64  `json:"blocksreceived"`
+	BytesReceived  uint64  `json:"bytesreceived"`
+	Throughput     float64 `json:"throughput"`
+}
+
+// GetSyncStatusResult models the data returned from the getsyncstatus command.
+// HeadersHeight is the height of the last downloaded header and is only set in
+// headers-first mode.
+type GetSyncStatusResult struct {
+	Height        int64                  `json:"height"`
+	SyncPeer      string                 `json:"syncpeer,omitempty"`
+	SyncHeight    int64                  `json:"syncheight,omitempty"`
+	HeadersFirst  bool                   `json:"headersfirst"`
+	HeadersHeight int64                  `json:"headersheight,omitempty"`
+	HeldBlocks    int                    `json:"heldblocks"`
+	Peers         []SyncPeerStatusResult `json:"peers"`
+}
+
 // VersionBits models a generic version:bits tuple.
 type VersionBits struct {
 	Version uint32 `json:"version"`
@@ -78,6 +309,23 @@ type Agenda struct {
 	Choices        []Choice `json:"choices"`
 }
 
+// ValidationStageResult models the accumulated timing of a stage of validating
+// transactions or blocks.  The times are in microseconds.
+type ValidationStageResult struct {
+	Stage     string `json:"stage"`
+	Count     uint64 `json:"count"`
+	TotalTime int64  `json:"totaltime"`
+	AvgTime   int64  `json:"avgtime"`
+	MaxTime   int64  `json:"maxtime"`
+}
+
+// GetValidationStatsResult models the data returned from the getvalidationstats
+// command.
+type GetValidationStatsResult struct {
+	Transactions []ValidationStageResult `json:"transactions"`
+	Blocks       []ValidationStageResult `json:"blocks"`
+}
+
 // GetVoteInfoResult models the data returned from the getvoteinfo command.
 type GetVoteInfoResult struct {
 	CurrentHeight int64    `json:"currentheight"`
@@ -90,6 +338,57 @@ type GetVoteInfoResult struct {
 	Agendas       []Agenda `json:"agendas,omitempty"`
 }
 
+// GetVotingWalletStatsResult models the data returned from the
+// getvotingwalletstats command.
+type GetVotingWalletStatsResult struct {
+	Clients         int64  `json:"clients"`
+	Pending         int64  `json:"pending"`
+	Delivered       uint64 `json:"delivered"`
+	Retransmissions uint64 `json:"retransmissions"`
+	Expired         uint64 `json:"expired"`
+	AvgLatency      int64  `json:"avglatency"`
+	MaxLatency      int64  `json:"maxlatency"`
+}
+
+// WindowAggregate models the aggregate information about a stake difficulty
+// adjustment window.
+type WindowAggregate struct {
+	Window          uint32  `json:"window"`
+	StartHeight     int64   `json:"startheight"`
+	EndHeight       int64   `json:"endheight"`
+	Blocks          uint32  `json:"blocks"`
+	Difficulty      float64 `json:"difficulty"`
+	StakeDifficulty float64 `json:"stakedifficulty"`
+	TotalFees       float64 `json:"totalfees"`
+	WorkSubsidy     float64 `json:"worksubsidy"`
+	StakeSubsidy    float64 `json:"stakesubsidy"`
+	TaxSubsidy      float64 `json:"taxsubsidy"`
+	Votes           uint32  `json:"votes"`
+	Tickets         uint32  `json:"tickets"`
+	Revocations     uint32  `json:"revocations"`
+}
+
+// GetWindowAggregatesResult models the data returned from the
+// getwindowaggregates command.
+type GetWindowAggregatesResult struct {
+	WindowSize int64             `json:"windowsize"`
+	Windows    []WindowAggregate `json:"windows"`
+}
+
+// StartProfileResult models the data returned from the startprofile command.
+type StartProfileResult struct {
+	File     string `json:"file"`
+	Duration uint32 `json:"duration"`
+}
+
+// EstimateSmartFeeResult models the data returned from the estimatesmartfee and
+// estimateticketfee commands.  The fee rate is in DCR/kB and Blocks is the
+// number of blocks the estimate is for.
+type EstimateSmartFeeResult struct {
+	FeeRate float64 `json:"feerate"`
+	Blocks  int64   `json:"blocks"`
+}
+
 // EstimateStakeDiffResult models the data returned from the estimatestakediff
 // command.
 type EstimateStakeDiffResult struct {
@@ -99,6 +398,23 @@ type EstimateStakeDiffResult struct {
 	User     *float64 `json:"user,omitempty"`
 }
 
+// ConfirmProbability models the probability that a transaction is confirmed
+// within a number of blocks.
+type ConfirmProbability struct {
+	Blocks      uint32  `json:"blocks"`
+	Probability float64 `json:"probability"`
+}
+
+// EstimateTimeToConfirmResult models the data returned from the
+// estimatetimetoconfirm command.  BytesAhead includes the size of the
+// transaction being estimated.
+type EstimateTimeToConfirmResult struct {
+	BytesAhead    int64                `json:"bytesahead"`
+	BlockCapacity float64              `json:"blockcapacity"`
+	FillRate      float64              `json:"fillrate"`
+	Probabilities []ConfirmProbability `json:"probabilities"`
+}
+
 // LiveTicketsResult models the data returned from the livetickets
 // command.
 type LiveTicketsResult struct {
@@ -174,6 +490,109 @@ type TicketsForAddressResult struct {
 	Tickets []string `json:"tickets"`
 }
 
+// IndexInfoResult models the data returned for each enabled index from the
+// getindexinfo command.  Operation is the most recent maintenance operation of
+// the index, and the heights describe the progress of a rebuild or
+// verification.
+type IndexInfoResult struct {
+	Name         string `json:"name"`
+	Active       bool   `json:"active"`
+	Operation    string `json:"operation,omitempty"`
+	Running      bool   `json:"running"`
+	Height       int64  `json:"height"`
+	TargetHeight int64  `json:"targetheight"`
+	DeletedKeys  uint64 `json:"deletedkeys"`
+	Error        string `json:"error,omitempty"`
+}
+
+// These constants define the states of the jobs reported by JobResult.
+const (
+	// JobStateRunning indicates the job is still in progress.
+	JobStateRunning = "running"
+
+	// JobStateCompleted indicates the job finished successfully.
+	JobStateCompleted = "completed"
+
+	// JobStateFailed indicates the job finished with an error.
+	JobStateFailed = "failed"
+
+	// JobStateCancelled indicates the job was interrupted by canceljob or
+	// the shutdown of the server.
+	JobStateCancelled = "cancelled"
+)
+
+// JobResult models the data returned from the getjob and listjobs commands and
+// the commands which start jobs.  Progress and Total describe how much of the
+// work of the job is done in units which depend on the command, such as
+// blocks, and are zero when the command does not report progress.  Result is
+// the result the command would have returned without running as a job and is
+// only set once the job completed.
+type JobResult struct {
+	JobID       uint64      `json:"jobid"`
+	Method      string      `json:"method"`
+	State       string      `json:"state"`
+	Cancellable bool        `json:"cancellable"`
+	Progress    int64       `json:"progress"`
+	Total       int64       `json:"total"`
+	StartTime   int64       `json:"starttime"`
+	EndTime     int64       `json:"endtime,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	Result      interface{} `json:"result,omitempty"`
+}
+
+// GetTxCostResult models the data returned from the gettxcost command.
+type GetTxCostResult struct {
+	TxID        string `json:"txid"`
+	Size        int    `json:"size"`
+	SigOps      int    `json:"sigops"`
+	P2SHSigOps  int    `json:"p2shsigops"`
+	AltSigOps   int    `json:"altsigops"`
+	TotalSigOps int    `json:"totalsigops"`
+}
+
+// AddressBalanceResult models the data returned from the getaddressbalance
+// command.
+type AddressBalanceResult struct {
+	Balance  float64 `json:"balance"`
+	NumUtxos uint32  `json:"numutxos"`
+}
+
+// AddressUtxoResult models the data of an unspent output returned from the
+// getaddressutxos command.
+type AddressUtxoResult struct {
+	TxID          string  `json:"txid"`
+	Vout          uint32  `json:"vout"`
+	Tree          int8    `json:"tree"`
+	Amount        float64 `json:"amount"`
+	ScriptPubKey  string  `json:"scriptpubkey"`
+	Height        int64   `json:"height"`
+	Confirmations int64   `json:"confirmations"`
+}
+
+// TxSpendingInfoResult models the data returned from the gettxspendinginfo
+// command.  The spending transaction fields are omitted when the output is not
+// spent, and the block fields are omitted when it is only spent by a memory
+// pool transaction.
+type TxSpendingInfoResult struct {
+	Spent         bool   `json:"spent"`
+	SpendingTxID  string `json:"spendingtxid,omitempty"`
+	Vin           uint32 `json:"vin"`
+	BlockHash     string `json:"blockhash,omitempty"`
+	BlockHeight   int64  `json:"blockheight,omitempty"`
+	Confirmations int64  `json:"confirmations"`
+}
+
+// TxRelayStatusResult models the data returned from the gettxrelaystatus
+// command.  Added and LastOffered are unix timestamps, where LastOffered is zero
+// when the transaction has not been offered to any peers yet.
+type TxRelayStatusResult struct {
+	TxID        string `json:"txid"`
+	Added       int64  `json:"added"`
+	LastOffered int64  `json:"lastoffered"`
+	Offered     int    `json:"offered"`
+	Requested   int    `json:"requested"`
+}
+
 // TxFeeInfoResult models the data returned from the ticketfeeinfo command.
 // command.
 type TxFeeInfoResult struct {
@@ -184,11 +603,23 @@ type TxFeeInfoResult struct {
 
 // VersionResult models objects included in the version response.  In the actual
 // result, these objects are keyed by the program or API name.
+//
+// The notifications, indexes, and features fields advertise the capabilities
+// of the server and are only set for the JSON-RPC API object.
 type VersionResult struct {
-	VersionString string `json:"versionstring"`
-	Major         uint32 `json:"major"`
-	Minor         uint32 `json:"minor"`
-	Patch         uint32 `json:"patch"`
-	Prerelease    string `json:"prerelease"`
-	BuildMetadata string `json:"buildmetadata"`
+	VersionString string   `json:"versionstring"`
+	Major         uint32   `json:"major"`
+	Minor         uint32   `json:"minor"`
+	Patch         uint32   `json:"patch"`
+	Prerelease    string   `json:"prerelease"`
+	BuildMetadata string   `json:"buildmetadata"`
+	Notifications []string `json:"notifications,omitempty"`
+	Indexes       []string `json:"indexes,omitempty"`
+	Features      []string `json:"features,omitempty"`
+}
+
+// WarpClockResult models the data returned from the warpclock command.
+type WarpClockResult struct {
+	Offset int64 `json:"offset"`
+	Time   int64 `json:"time"`
 }
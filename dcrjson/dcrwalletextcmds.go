@@ -307,11 +307,42 @@ func NewListScriptsCmd() *ListScriptsCmd {
 // unmarshaling of notifywinningtickets JSON websocket extension
 // commands.
 type NotifyWinningTicketsCmd struct {
+	Verbose *bool `jsonrpcdefault:"false"`
 }
 
 // NewNotifyWinningTicketsCmd creates a new NotifyWinningTicketsCmd.
-func NewNotifyWinningTicketsCmd() *NotifyWinningTicketsCmd {
-	return &NotifyWinningTicketsCmd{}
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewNotifyWinningTicketsCmd(verbose *bool) *NotifyWinningTicketsCmd {
+	return &NotifyWinningTicketsCmd{
+		Verbose: verbose,
+	}
+}
+
+// RegisterVotingWalletCmd is a type handling custom marshaling and
+// unmarshaling of registervotingwallet JSON websocket extension commands.
+type RegisterVotingWalletCmd struct {
+}
+
+// NewRegisterVotingWalletCmd creates a new RegisterVotingWalletCmd.
+func NewRegisterVotingWalletCmd() *RegisterVotingWalletCmd {
+	return &RegisterVotingWalletCmd{}
+}
+
+// AckNotificationCmd is a type handling custom marshaling and unmarshaling of
+// acknotification JSON websocket extension commands.
+type AckNotificationCmd struct {
+	Method    string
+	BlockHash string
+}
+
+// NewAckNotificationCmd creates a new AckNotificationCmd.
+func NewAckNotificationCmd(method, blockHash string) *AckNotificationCmd {
+	return &AckNotificationCmd{
+		Method:    method,
+		BlockHash: blockHash,
+	}
 }
 
 // NotifySpentAndMissedTicketsCmd is a type handling custom marshaling and
@@ -347,6 +378,18 @@ func NewNotifyStakeDifficultyCmd() *NotifyStakeDifficultyCmd {
 	return &NotifyStakeDifficultyCmd{}
 }
 
+// NotifyStakeDifficultyChangedCmd is a type handling custom marshaling and
+// unmarshaling of notifystakedifficultychanged JSON websocket extension
+// commands.
+type NotifyStakeDifficultyChangedCmd struct {
+}
+
+// NewNotifyStakeDifficultyChangedCmd creates a new
+// NotifyStakeDifficultyChangedCmd.
+func NewNotifyStakeDifficultyChangedCmd() *NotifyStakeDifficultyChangedCmd {
+	return &NotifyStakeDifficultyChangedCmd{}
+}
+
 // PurchaseTicketCmd is a type handling custom marshaling and
 // unmarshaling of purchaseticket JSON RPC commands.
 type PurchaseTicketCmd struct {
@@ -635,6 +678,7 @@ func init() {
 	flags := UFWalletOnly
 
 	MustRegisterCmd("accountaddressindex", (*AccountAddressIndexCmd)(nil), flags)
+	MustRegisterCmd("acknotification", (*AckNotificationCmd)(nil), flags)
 	MustRegisterCmd("accountfetchaddresses", (*AccountFetchAddressesCmd)(nil), flags)
 	MustRegisterCmd("accountsyncaddressindex", (*AccountSyncAddressIndexCmd)(nil), flags)
 	MustRegisterCmd("addticket", (*AddTicketCmd)(nil), flags)
@@ -659,11 +703,15 @@ func init() {
 		(*NotifySpentAndMissedTicketsCmd)(nil), flags)
 	MustRegisterCmd("notifystakedifficulty",
 		(*NotifyStakeDifficultyCmd)(nil), flags)
+	MustRegisterCmd("notifystakedifficultychanged",
+		(*NotifyStakeDifficultyChangedCmd)(nil), flags)
 	MustRegisterCmd("notifywinningtickets",
 		(*NotifyWinningTicketsCmd)(nil), flags)
 	MustRegisterCmd("purchaseticket", (*PurchaseTicketCmd)(nil), flags)
 	MustRegisterCmd("redeemmultisigout", (*RedeemMultiSigOutCmd)(nil), flags)
 	MustRegisterCmd("redeemmultisigouts", (*RedeemMultiSigOutsCmd)(nil), flags)
+	MustRegisterCmd("registervotingwallet",
+		(*RegisterVotingWalletCmd)(nil), flags)
 	MustRegisterCmd("rescanwallet", (*RescanWalletCmd)(nil), flags)
 	MustRegisterCmd("sendtomultisig", (*SendToMultiSigCmd)(nil), flags)
 	MustRegisterCmd("sendtosstx", (*SendToSStxCmd)(nil), flags)
@@ -35,10 +35,50 @@ func TestDcrWalletExtCmds(t *testing.T) {
 				return dcrjson.NewCmd("notifywinningtickets")
 			},
 			staticCmd: func() interface{} {
-				return dcrjson.NewNotifyWinningTicketsCmd()
+				return dcrjson.NewNotifyWinningTicketsCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifywinningtickets","params":[],"id":1}`,
+			unmarshalled: &dcrjson.NotifyWinningTicketsCmd{
+				Verbose: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "notifywinningtickets optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("notifywinningtickets", true)
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewNotifyWinningTicketsCmd(dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifywinningtickets","params":[true],"id":1}`,
+			unmarshalled: &dcrjson.NotifyWinningTicketsCmd{
+				Verbose: dcrjson.Bool(true),
+			},
+		},
+		{
+			name: "registervotingwallet",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("registervotingwallet")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewRegisterVotingWalletCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"registervotingwallet","params":[],"id":1}`,
+			unmarshalled: &dcrjson.RegisterVotingWalletCmd{},
+		},
+		{
+			name: "acknotification",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("acknotification", "winningtickets", "123")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewAckNotificationCmd("winningtickets", "123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"acknotification","params":["winningtickets","123"],"id":1}`,
+			unmarshalled: &dcrjson.AckNotificationCmd{
+				Method:    "winningtickets",
+				BlockHash: "123",
 			},
-			marshalled:   `{"jsonrpc":"1.0","method":"notifywinningtickets","params":[],"id":1}`,
-			unmarshalled: &dcrjson.NotifyWinningTicketsCmd{},
 		},
 		{
 			name: "notifyspentandmissedtickets",
@@ -73,6 +113,17 @@ func TestDcrWalletExtCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"notifystakedifficulty","params":[],"id":1}`,
 			unmarshalled: &dcrjson.NotifyStakeDifficultyCmd{},
 		},
+		{
+			name: "notifystakedifficultychanged",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd("notifystakedifficultychanged")
+			},
+			staticCmd: func() interface{} {
+				return dcrjson.NewNotifyStakeDifficultyChangedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"notifystakedifficultychanged","params":[],"id":1}`,
+			unmarshalled: &dcrjson.NotifyStakeDifficultyChangedCmd{},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
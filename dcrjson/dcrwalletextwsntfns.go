@@ -35,6 +35,10 @@ const (
 	// StakeDifficultyNtfnMethod is the method of the daemon
 	// stakedifficulty notification.
 	StakeDifficultyNtfnMethod = "stakedifficulty"
+
+	// StakeDifficultyChangedNtfnMethod is the method of the daemon
+	// stakedifficultychanged notification.
+	StakeDifficultyChangedNtfnMethod = "stakedifficultychanged"
 )
 
 // TicketPurchasedNtfn is a type handling custom marshaling and
@@ -90,18 +94,30 @@ func NewRevocationCreatedNtfn(txHash string, sstxIn string) *RevocationCreatedNt
 
 // WinningTicketsNtfn is a type handling custom marshaling and
 // unmarshaling of blockconnected JSON websocket notifications.
+//
+// The optional VoteDeadline and Commitments fields are only populated for
+// clients that registered for verbose notifications.  VoteDeadline is the
+// height of the block which must include the votes and Commitments maps each
+// winning ticket hash to the addresses committed to by the ticket.
 type WinningTicketsNtfn struct {
-	BlockHash   string
-	BlockHeight int32
-	Tickets     map[string]string
+	BlockHash    string
+	BlockHeight  int32
+	Tickets      map[string]string
+	VoteDeadline *int32
+	Commitments  *map[string][]string
 }
 
 // NewWinningTicketsNtfn creates a new WinningTicketsNtfn.
-func NewWinningTicketsNtfn(hash string, height int32, tickets map[string]string) *WinningTicketsNtfn {
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will omit them from the notification.
+func NewWinningTicketsNtfn(hash string, height int32, tickets map[string]string, voteDeadline *int32, commitments *map[string][]string) *WinningTicketsNtfn {
 	return &WinningTicketsNtfn{
-		BlockHash:   hash,
-		BlockHeight: height,
-		Tickets:     tickets,
+		BlockHash:    hash,
+		BlockHeight:  height,
+		Tickets:      tickets,
+		VoteDeadline: voteDeadline,
+		Commitments:  commitments,
 	}
 }
 
@@ -160,6 +176,27 @@ func NewStakeDifficultyNtfn(hash string, height int32, stakeDiff int64) *StakeDi
 	}
 }
 
+// StakeDifficultyChangedNtfn is a type handling custom marshaling and
+// unmarshaling of stakedifficultychanged JSON websocket notifications.
+type StakeDifficultyChangedNtfn struct {
+	BlockHash    string
+	BlockHeight  int32
+	OldStakeDiff int64
+	NewStakeDiff int64
+	FirstHeight  int32
+}
+
+// NewStakeDifficultyChangedNtfn creates a new StakeDifficultyChangedNtfn.
+func NewStakeDifficultyChangedNtfn(hash string, height int32, oldStakeDiff, newStakeDiff int64, firstHeight int32) *StakeDifficultyChangedNtfn {
+	return &StakeDifficultyChangedNtfn{
+		BlockHash:    hash,
+		BlockHeight:  height,
+		OldStakeDiff: oldStakeDiff,
+		NewStakeDiff: newStakeDiff,
+		FirstHeight:  firstHeight,
+	}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets and are
 	// notifications.
@@ -172,4 +209,5 @@ func init() {
 	MustRegisterCmd(SpentAndMissedTicketsNtfnMethod, (*SpentAndMissedTicketsNtfn)(nil), flags)
 	MustRegisterCmd(NewTicketsNtfnMethod, (*NewTicketsNtfn)(nil), flags)
 	MustRegisterCmd(StakeDifficultyNtfnMethod, (*StakeDifficultyNtfn)(nil), flags)
+	MustRegisterCmd(StakeDifficultyChangedNtfnMethod, (*StakeDifficultyChangedNtfn)(nil), flags)
 }
@@ -79,7 +79,7 @@ func TestDcrwalletChainSvrWsNtfns(t *testing.T) {
 				return dcrjson.NewCmd("winningtickets", "123", 100, map[string]string{"a": "b"})
 			},
 			staticNtfn: func() interface{} {
-				return dcrjson.NewWinningTicketsNtfn("123", 100, map[string]string{"a": "b"})
+				return dcrjson.NewWinningTicketsNtfn("123", 100, map[string]string{"a": "b"}, nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"winningtickets","params":["123",100,{"a":"b"}],"id":null}`,
 			unmarshalled: &dcrjson.WinningTicketsNtfn{
@@ -88,6 +88,25 @@ func TestDcrwalletChainSvrWsNtfns(t *testing.T) {
 				Tickets:     map[string]string{"a": "b"},
 			},
 		},
+		{
+			name: "winningtickets verbose",
+			newNtfn: func() (interface{}, error) {
+				return dcrjson.NewCmd("winningtickets", "123", 100, map[string]string{"a": "b"}, 101, map[string][]string{"b": {"c", "d"}})
+			},
+			staticNtfn: func() interface{} {
+				deadline := int32(101)
+				commitments := map[string][]string{"b": {"c", "d"}}
+				return dcrjson.NewWinningTicketsNtfn("123", 100, map[string]string{"a": "b"}, &deadline, &commitments)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"winningtickets","params":["123",100,{"a":"b"},101,{"b":["c","d"]}],"id":null}`,
+			unmarshalled: &dcrjson.WinningTicketsNtfn{
+				BlockHash:    "123",
+				BlockHeight:  100,
+				Tickets:      map[string]string{"a": "b"},
+				VoteDeadline: dcrjson.Int32(101),
+				Commitments:  &map[string][]string{"b": {"c", "d"}},
+			},
+		},
 		{
 			name: "spentandmissedtickets",
 			newNtfn: func() (interface{}, error) {
@@ -120,6 +139,23 @@ func TestDcrwalletChainSvrWsNtfns(t *testing.T) {
 				Tickets:   []string{"a", "b"},
 			},
 		},
+		{
+			name: "stakedifficultychanged",
+			newNtfn: func() (interface{}, error) {
+				return dcrjson.NewCmd("stakedifficultychanged", "123", 143, 2, 3, 144)
+			},
+			staticNtfn: func() interface{} {
+				return dcrjson.NewStakeDifficultyChangedNtfn("123", 143, 2, 3, 144)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"stakedifficultychanged","params":["123",143,2,3,144],"id":null}`,
+			unmarshalled: &dcrjson.StakeDifficultyChangedNtfn{
+				BlockHash:    "123",
+				BlockHeight:  143,
+				OldStakeDiff: 2,
+				NewStakeDiff: 3,
+				FirstHeight:  144,
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
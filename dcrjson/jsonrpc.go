@@ -17,10 +17,12 @@ import (
 type RPCErrorCode int
 
 // RPCError represents an error that is used as a part of a JSON-RPC Response
-// object.
+// object.  The optional Data field provides additional structured information
+// about the error, such as the RuleErrorDetails of a rejected transaction.
 type RPCError struct {
 	Code    RPCErrorCode `json:"code,omitempty"`
 	Message string       `json:"message,omitempty"`
+	Data    interface{}  `json:"data,omitempty"`
 }
 
 // Guarantee RPCError satisifies the builtin error interface.
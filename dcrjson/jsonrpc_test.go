@@ -79,6 +79,21 @@ func TestMarshalResponse(t *testing.T) {
 			}(),
 			expected: []byte(`{"result":null,"error":{"code":-5,"message":"123 not found"},"id":1}`),
 		},
+		{
+			name:   "result with error data",
+			result: nil,
+			jsonErr: func() *dcrjson.RPCError {
+				inputIndex := 2
+				jsonErr := dcrjson.NewRPCError(dcrjson.ErrRPCDeserialization, "TX rejected")
+				jsonErr.Data = &dcrjson.RuleErrorDetails{
+					Code:       29,
+					Name:       "ErrDoubleSpend",
+					InputIndex: &inputIndex,
+				}
+				return jsonErr
+			}(),
+			expected: []byte(`{"result":null,"error":{"code":-22,"message":"TX rejected","data":{"code":29,"name":"ErrDoubleSpend","inputindex":2}},"id":1}`),
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -40,6 +40,25 @@ Application Options:
                             banning misbehaving peers.
       --banduration=        How long to ban misbehaving peers.  Valid time units
                             are {s, m, h}.  Minimum 1 second (24h0m0s)
+      --tlspeer=            Add a trusted peer to connect with at startup over
+                            mutual TLS -- The host must be covered by the
+                            certificate the peer presents
+      --tlspeerlisten=      Add an interface/port to listen for mutual TLS
+                            connections from trusted peers -- NOTE: These
+                            listeners are not affected by --nolisten and are
+                            not advertised
+      --tlspeercert=        File containing the certificate presented to
+                            trusted TLS peers (~/.dcrd/p2p.cert)
+      --tlspeerkey=         File containing the key of the certificate
+                            presented to trusted TLS peers (~/.dcrd/p2p.key)
+      --tlspeercafile=      File containing the certificates of the trusted TLS
+                            peers or of the authorities which issued them
+      --peerfilter=         Add a rule in the form <action>:<kind>:<value> to
+                            refuse or deprioritize peers -- Actions are {refuse,
+                            deprioritize} and kinds are {useragent, version,
+                            services} which match peers by user agent pattern
+                            (* and ? wildcards), protocol version or <min>-<max>
+                            range, and missing service bits respectively
   -u, --rpcuser=            Username for RPC connections
   -P, --rpcpass=            Password for RPC connections
       --rpclimituser=       Username for limited RPC connections
@@ -51,6 +70,14 @@ Application Options:
       --rpcmaxclients=      Max number of RPC clients for standard connections
                             (10)
       --rpcmaxwebsockets=   Max number of RPC websocket connections (25)
+      --rpcauditlog=        Write a JSON entry with the method, parameters hash,
+                            user, latency, reply size, and error code of each
+                            RPC request to the specified file -- NOTE: The
+                            parameters of commands which carry credentials or
+                            private keys are not hashed
+      --rpcauditmaxsize=    Size in MiB at which the RPC audit log is rotated
+                            (10)
+      --rpcauditmaxrolls=   Number of rotated RPC audit log files to keep (3)
       --norpc               Disable built-in RPC server -- NOTE: The RPC server
                             is disabled by default if no rpcuser/rpcpass or
                             rpclimituser/rpclimitpass is specified
@@ -69,10 +96,29 @@ Application Options:
       --noonion             Disable connecting to tor hidden services
       --torisolation        Enable Tor stream isolation by randomizing user
                             credentials for each connection.
+      --dialroute=          Add a rule of the form
+                            <destination>=<proxy>[,<proxy>...] to connect to
+                            matching peers via the listed SOCKS5 proxies,
+                            which are tried in order, or directly with the
+                            proxy direct -- The destination is a network in
+                            CIDR notation (eg. 10.0.0.0/8), a host name suffix
+                            (eg. .i2p), or lan for private and link-local
+                            addresses, and the first matching rule applies
       --testnet             Use the test network
       --simnet              Use the simulation test network
       --nocheckpoints       Disable built-in checkpoints.  Don't do this unless
                             you know what you're doing.
+      --checkpointmode=     How to treat checkpoints {enforce, advisory,
+                            disabled} -- advisory only warns about blocks which
+                            do not match the checkpoints and fully validates
+                            the entire chain (default: enforce)
+      --checkpointfile=     Path to a file with additional checkpoints, one
+                            <height>:<hash> per line
+      --assumevalid=        Skip script validation for the block identified
+                            by <height>:<hash> and all of its ancestors while
+                            still enforcing the other consensus rules, or 0 to
+                            validate the scripts of all blocks (default: the
+                            assumed valid block of the network)
       --dbtype=             Database backend to use for the Block Chain (ffldb)
       --profile=            Enable HTTP profiling on given port -- NOTE port
                             must be between 1024 and 65536
@@ -97,6 +143,23 @@ Application Options:
                             high priority for relaying
       --maxorphantx=        Max number of orphan transactions to keep in memory
                             (1000)
+      --noreplacement       Reject transactions which spend outputs already spent
+                            by transactions in the mempool instead of accepting
+                            them as replacements when the spent transactions
+                            opt in to replacement
+      --incrementalrelayfee= The fee rate in DCR/kB by which a replacement
+                            transaction must pay more than the fees of all of
+                            the transactions it replaces, and by which the
+                            minimum fee rate of the full mempool is raised
+                            above the fee rates of evicted transactions (0.01)
+      --maxreplacedtxs=     Max number of transactions a replacement transaction
+                            may remove from the mempool, including all of the
+                            transactions which depend on the replaced ones (100)
+      --maxmempool=         The maximum size in MiB of all transactions in the
+                            mempool -- The regular transactions with the lowest
+                            fee rates are evicted once it is exceeded, which
+                            raises the minimum fee rate for accepting
+                            transactions until it decays (300)
       --generate            Generate (mine) bitcoins using the CPU
       --miningaddr=         Add the specified payment address to the list of
                             addresses to use for generated blocks -- At least
@@ -117,7 +180,33 @@ Application Options:
       --nopeerbloomfilters  Disable bloom filtering support.
       --sigcachemaxsize=    The maximum number of entries in the signature
                             verification cache.
+      --utxocachesize=      The maximum size in MiB of the cache which holds
+                            modifications to the utxo set in memory before they
+                            are written to the database (150).
+      --prune=              Reduce storage requirements by removing the data of
+                            old blocks to keep the stored block data below the
+                            specified target size in MiB -- The minimum target
+                            is 1024 and 0 disables pruning
+      --utxocommitments     Commit to the utxo set in the extra data of
+                            generated blocks and reject blocks which do not
+                            commit to it -- EXPERIMENTAL and only available on
+                            simnet
+      --addscriptflag=      Enforce the named script verification flag
+                            regardless of the state of the agendas, as reported
+                            by getblockchaininfo -- Only available on testnet
+                            and simnet
+      --removescriptflag=   Do not enforce the named script verification flag
+                            regardless of the state of the agendas, as reported
+                            by getblockchaininfo -- Only available on testnet
+                            and simnet
+      --scriptflagsheight=  The height of the first block the --addscriptflag
+                            and --removescriptflag overrides apply to, which
+                            keeps the rules of earlier blocks unchanged -- 0
+                            applies them to all blocks
       --blocksonly          Do not accept transactions from remote peers.
+      --annotateblocks      Annotate blocks received from remote peers with the
+                            time they were first seen and the address of the
+                            peer which relayed them
 
 Help Options:
   -h, --help           Show this help message
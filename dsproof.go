@@ -0,0 +1,206 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/mempool"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// dsProofExpiry is how long the outpoint of a processed double spend
+	// proof is remembered in order to avoid processing and relaying
+	// another proof for it.
+	dsProofExpiry = time.Hour
+
+	// maxRecentDSProofs is the maximum number of outpoints of processed
+	// double spend proofs to remember.
+	maxRecentDSProofs = 10000
+)
+
+// recentDSProofs tracks the outpoints of recently processed double spend
+// proofs.  Only a single proof is relayed for each outpoint since a proof is
+// all that is needed to warn of the double spend.
+type recentDSProofs struct {
+	mtx       sync.Mutex
+	outPoints map[wire.OutPoint]time.Time
+}
+
+// newRecentDSProofs returns a new empty recent double spend proof tracker.
+func newRecentDSProofs() *recentDSProofs {
+	return &recentDSProofs{
+		outPoints: make(map[wire.OutPoint]time.Time),
+	}
+}
+
+// Exists returns whether or not a double spend proof for the passed outpoint
+// was recently processed.
+//
+// This function is safe for concurrent access.
+func (r *recentDSProofs) Exists(outPoint *wire.OutPoint) bool {
+	r.mtx.Lock()
+	added, ok := r.outPoints[*outPoint]
+	r.mtx.Unlock()
+	return ok && time.Since(added) < dsProofExpiry
+}
+
+// Add records that a double spend proof for the passed outpoint was processed.
+// It returns false when a proof for the outpoint was already recorded.  Expired
+// outpoints are removed when the maximum number of tracked outpoints is
+// reached, and an arbitrary outpoint is evicted when none have expired.
+//
+// This function is safe for concurrent access.
+func (r *recentDSProofs) Add(outPoint *wire.OutPoint) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+	if added, ok := r.outPoints[*outPoint]; ok && now.Sub(added) < dsProofExpiry {
+		return false
+	}
+
+	if len(r.outPoints) >= maxRecentDSProofs {
+		for op, added := range r.outPoints {
+			if now.Sub(added) >= dsProofExpiry {
+				delete(r.outPoints, op)
+			}
+		}
+		for op := range r.outPoints {
+			if len(r.outPoints) < maxRecentDSProofs {
+				break
+			}
+			delete(r.outPoints, op)
+		}
+	}
+	r.outPoints[*outPoint] = now
+	return true
+}
+
+// fetchOutPointScript returns the public key script and script version of the
+// output referenced by the passed outpoint.  The output may be created by a
+// transaction in the memory pool or be an unspent output in the main chain.
+func (s *server) fetchOutPointScript(outPoint *wire.OutPoint) ([]byte, uint16, error) {
+	tx, err := s.txMemPool.FetchTransaction(&outPoint.Hash, false)
+	if err == nil {
+		msgTx := tx.MsgTx()
+		if outPoint.Index >= uint32(len(msgTx.TxOut)) {
+			return nil, 0, fmt.Errorf("output %v does not exist",
+				outPoint)
+		}
+		txOut := msgTx.TxOut[outPoint.Index]
+		return txOut.PkScript, txOut.Version, nil
+	}
+
+	entry, err := s.blockManager.chain.FetchUtxoEntry(&outPoint.Hash)
+	if err != nil {
+		return nil, 0, err
+	}
+	if entry == nil {
+		return nil, 0, fmt.Errorf("output %v does not exist or is "+
+			"spent", outPoint)
+	}
+	pkScript := entry.PkScriptByIndex(outPoint.Index)
+	if pkScript == nil {
+		return nil, 0, fmt.Errorf("output %v does not exist or is "+
+			"spent", outPoint)
+	}
+	return pkScript, entry.ScriptVersionByIndex(outPoint.Index), nil
+}
+
+// processDoubleSpendProof checks the passed double spend proof and, when it is
+// valid and no proof for the outpoint was recently processed, relays it to all
+// peers other than the one it was received from and notifies websocket clients.
+// The peer is nil for proofs created by this node.
+//
+// This function is safe for concurrent access.
+func (s *server) processDoubleSpendProof(proof *wire.MsgDoubleSpendProof, sp *serverPeer) error {
+	if s.dsProofs.Exists(&proof.OutPoint) {
+		return nil
+	}
+
+	pkScript, scriptVersion, err := s.fetchOutPointScript(&proof.OutPoint)
+	if err != nil {
+		return err
+	}
+	flags, err := s.blockManager.chain.StandardScriptFlags()
+	if err != nil {
+		return err
+	}
+	err = blockchain.CheckDoubleSpendProof(proof, pkScript, scriptVersion,
+		flags, s.sigCache)
+	if err != nil {
+		return err
+	}
+
+	// Another proof for the outpoint may have been processed concurrently.
+	if !s.dsProofs.Add(&proof.OutPoint) {
+		return nil
+	}
+
+	srvrLog.Infof("Detected double spend of %v by transactions %v and %v",
+		proof.OutPoint, proof.Spends[0].Tx.TxHash(),
+		proof.Spends[1].Tx.TxHash())
+
+	if sp != nil {
+		s.BroadcastMessage(proof, sp)
+	} else {
+		s.BroadcastMessage(proof)
+	}
+	if s.rpcServer != nil {
+		s.rpcServer.ntfnMgr.NotifyDoubleSpendProof(proof)
+	}
+	return nil
+}
+
+// announceDoubleSpend creates and processes a double spend proof when the
+// passed error indicates the transaction was rejected by the memory pool
+// because it spends an outpoint which is already spent by a transaction in the
+// pool.  Nothing is done for any other errors.
+func (s *server) announceDoubleSpend(tx *dcrutil.Tx, err error) {
+	merr, ok := err.(mempool.RuleError)
+	if !ok {
+		return
+	}
+	terr, ok := merr.Err.(mempool.TxRuleError)
+	if !ok {
+		return
+	}
+
+	// A single proof is enough to show the transaction is a double spend,
+	// so only the first conflict which has not already been proven is
+	// used.
+	for i := range terr.Conflicts {
+		conflict := &terr.Conflicts[i]
+		if s.dsProofs.Exists(&conflict.OutPoint) {
+			return
+		}
+		poolTx, err := s.txMemPool.FetchTransaction(&conflict.TxHash,
+			false)
+		if err != nil {
+			continue
+		}
+
+		proof, err := blockchain.NewDoubleSpendProof(&conflict.OutPoint,
+			poolTx.MsgTx(), tx.MsgTx())
+		if err != nil {
+			srvrLog.Debugf("Unable to create double spend proof for "+
+				"%v: %v", conflict.OutPoint, err)
+			continue
+		}
+		err = s.processDoubleSpendProof(proof, nil)
+		if err != nil {
+			srvrLog.Debugf("Rejected double spend proof for %v: %v",
+				conflict.OutPoint, err)
+			continue
+		}
+		return
+	}
+}
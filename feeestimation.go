@@ -0,0 +1,143 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/fees"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// feeEstimatesFileName and ticketFeeEstimatesFileName are the names of
+	// the files in the data directory the state of the fee estimators for
+	// regular transactions and ticket purchases is saved to on shutdown.
+	feeEstimatesFileName       = "feeestimates.json"
+	ticketFeeEstimatesFileName = "ticketfeeestimates.json"
+
+	// minFeeEstimateBucketFee and maxFeeEstimateBucketFee are the lowest
+	// and highest fee rates in atoms/kB tracked for regular transactions.
+	// They are independent of the configured minimum relay fee so that the
+	// saved state remains usable when it changes.
+	minFeeEstimateBucketFee = 1e4
+	maxFeeEstimateBucketFee = 1e8
+
+	// minTicketFeeEstimateBucketFee and maxTicketFeeEstimateBucketFee are
+	// the lowest and highest fee rates in atoms/kB tracked for ticket
+	// purchases, which pay considerably higher fees than regular
+	// transactions while competing for the limited ticket slots of blocks.
+	minTicketFeeEstimateBucketFee = 1e6
+	maxTicketFeeEstimateBucketFee = 1e9
+)
+
+// newFeeEstimator returns a new fee estimator which tracks the passed range of
+// fee rates and restores the state saved to the passed file in the data
+// directory, if any.  Saved state which can not be restored is discarded.
+func newFeeEstimator(fileName string, minBucketFee, maxBucketFee, bestHeight int64) (*fees.Estimator, error) {
+	estimator, err := fees.NewEstimator(&fees.EstimatorConfig{
+		MinBucketFee: minBucketFee,
+		MaxBucketFee: maxBucketFee,
+		FeeRateStep:  fees.DefaultFeeRateStep,
+		MaxConfirms:  fees.DefaultMaxConfirms,
+		BestHeight:   bestHeight,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	filePath := filepath.Join(cfg.DataDir, fileName)
+	f, err := os.Open(filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			srvrLog.Warnf("Unable to open saved fee estimates: %v", err)
+		}
+		return estimator, nil
+	}
+	defer f.Close()
+	if err := estimator.Restore(f); err != nil {
+		srvrLog.Warnf("Discarding saved fee estimates in %s: %v",
+			filePath, err)
+	}
+	return estimator, nil
+}
+
+// saveFeeEstimator writes the state of the passed fee estimator to the passed
+// file in the data directory.  The state is written to a temporary file first
+// and renamed once it is complete so a partially written state never replaces
+// the previous one.
+func saveFeeEstimator(estimator *fees.Estimator, fileName string) error {
+	f, err := ioutil.TempFile(cfg.DataDir, fileName+".")
+	if err != nil {
+		return err
+	}
+	err = estimator.Save(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), filepath.Join(cfg.DataDir, fileName)); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// saveFeeEstimates writes the state of the fee estimators of the server to the
+// data directory.  It must only be called once no more blocks are processed.
+func (s *server) saveFeeEstimates() {
+	if err := saveFeeEstimator(s.feeEstimator, feeEstimatesFileName); err != nil {
+		srvrLog.Errorf("Unable to save fee estimates: %v", err)
+	}
+	err := saveFeeEstimator(s.ticketFeeEstimator, ticketFeeEstimatesFileName)
+	if err != nil {
+		srvrLog.Errorf("Unable to save ticket fee estimates: %v", err)
+	}
+}
+
+// addTxToFeeEstimation starts tracking the passed transaction which was added
+// to the memory pool with the fee estimator for its type.  Transactions other
+// than regular transactions and ticket purchases are not tracked.
+func (s *server) addTxToFeeEstimation(txHash *chainhash.Hash, txType stake.TxType, fee, size int64) {
+	switch txType {
+	case stake.TxTypeRegular:
+		s.feeEstimator.AddMemPoolTransaction(txHash, fee, size)
+	case stake.TxTypeSStx:
+		s.ticketFeeEstimator.AddMemPoolTransaction(txHash, fee, size)
+	}
+}
+
+// removeTxFromFeeEstimation stops tracking the passed transaction which was
+// removed from the memory pool.
+func (s *server) removeTxFromFeeEstimation(txHash *chainhash.Hash) {
+	s.feeEstimator.RemoveMemPoolTransaction(txHash)
+	s.ticketFeeEstimator.RemoveMemPoolTransaction(txHash)
+}
+
+// processFeeEstimationBlock records the confirmation times of the transactions
+// mined in the passed block which was connected to the main chain.  It must be
+// called before the transactions of the block are removed from the memory pool.
+func (s *server) processFeeEstimationBlock(block *dcrutil.Block) {
+	txns := block.Transactions()[1:]
+	txHashes := make([]*chainhash.Hash, 0, len(txns))
+	for _, tx := range txns {
+		txHashes = append(txHashes, tx.Hash())
+	}
+	s.feeEstimator.ProcessBlock(block.Height(), txHashes)
+
+	stxns := block.STransactions()
+	ticketHashes := make([]*chainhash.Hash, 0, len(stxns))
+	for _, stx := range stxns {
+		ticketHashes = append(ticketHashes, stx.Hash())
+	}
+	s.ticketFeeEstimator.ProcessBlock(block.Height(), ticketHashes)
+}
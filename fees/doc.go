@@ -0,0 +1,26 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package fees provides a fee estimator which derives the fee rate a transaction
+needs to pay in order to be confirmed within a number of blocks from the
+confirmation times of the transactions seen in the memory pool.
+
+Overview
+
+The estimator groups the fee rates of transactions into exponentially spaced
+buckets.  Whenever a transaction which entered the memory pool is mined, the
+number of blocks it took to be confirmed is recorded in the bucket of its fee
+rate.  The recorded data decays with every block so the estimates follow the
+recent state of the network.  Transactions which remain in the memory pool for
+longer than a target are counted as failing to confirm within it.
+
+An estimate for a target number of confirmations is the average fee rate of the
+lowest range of buckets whose transactions confirmed within the target with a
+high enough success rate while all ranges above also succeeded.
+
+The state of an estimator may be saved and restored so the data gathered is
+retained across restarts.
+*/
+package fees
@@ -0,0 +1,374 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fees
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+const (
+	// DefaultMaxConfirms is the default maximum number of confirmations
+	// an estimator tracks and provides estimates for.
+	DefaultMaxConfirms = 32
+
+	// DefaultFeeRateStep is the default ratio between the fee rates of
+	// consecutive buckets.
+	DefaultFeeRateStep = 1.1
+
+	// decay is the factor the recorded data is multiplied with for every
+	// connected block.  It gives the data of a block a half life of about
+	// 350 blocks, which is a little over a day.
+	decay = 0.998
+
+	// successThreshold is the minimum fraction of the transactions of a
+	// range of buckets which must have been confirmed within a target for
+	// the range to be considered sufficient for it.
+	successThreshold = 0.95
+
+	// sufficientDataPoints is the minimum decayed number of transactions a
+	// range of buckets must hold before its success rate is evaluated.
+	sufficientDataPoints = 10
+
+	// stateVersion is the version of the serialized state of an estimator.
+	stateVersion = 1
+)
+
+var (
+	// ErrNoEstimate is returned by EstimateFee when not enough transactions
+	// were seen to estimate the fee rate for the requested target.
+	ErrNoEstimate = errors.New("insufficient data to estimate the fee rate")
+
+	// ErrIncompatibleState is returned by Restore when the saved state was
+	// created by an estimator with a different version or configuration.
+	ErrIncompatibleState = errors.New("saved fee estimator state is " +
+		"incompatible with the estimator")
+)
+
+// EstimatorConfig houses the configuration of an estimator.
+type EstimatorConfig struct {
+	// MinBucketFee and MaxBucketFee are the fee rates in atoms/kB of the
+	// lowest and highest buckets.  Transactions paying less than the
+	// lowest or more than the highest fee rate are recorded in the lowest
+	// or highest bucket respectively.
+	MinBucketFee int64
+	MaxBucketFee int64
+
+	// FeeRateStep is the ratio between the fee rates of consecutive
+	// buckets.  It must be greater than one.
+	FeeRateStep float64
+
+	// MaxConfirms is the maximum number of confirmations tracked and the
+	// highest target estimates are provided for.
+	MaxConfirms int
+
+	// BestHeight is the height of the current best chain at the time the
+	// estimator is created.
+	BestHeight int64
+}
+
+// memPoolTx describes a transaction in the memory pool tracked by an estimator.
+type memPoolTx struct {
+	bucket  int
+	height  int64
+	feeRate int64
+}
+
+// Estimator estimates the fee rate transactions need to pay in order to be
+// confirmed within a number of blocks from the confirmation times of the
+// transactions seen in the memory pool.  See the package documentation for
+// details.
+type Estimator struct {
+	mtx sync.Mutex
+
+	// feeRates houses the lowest fee rate of every bucket in atoms/kB in
+	// ascending order.
+	feeRates    []int64
+	maxConfirms int
+	bestHeight  int64
+
+	// confirmed houses the decayed number of transactions of every bucket
+	// which were confirmed within the number of blocks identified by the
+	// first index plus one.  txCounts and feeSums house the decayed number
+	// of confirmed transactions and the sum of their fee rates per bucket.
+	confirmed [][]float64
+	txCounts  []float64
+	feeSums   []float64
+
+	// memPool houses the transactions of the memory pool which entered it
+	// while the estimator was tracking them.
+	memPool map[chainhash.Hash]memPoolTx
+}
+
+// NewEstimator returns a new estimator with no recorded data for the passed
+// configuration.
+func NewEstimator(cfg *EstimatorConfig) (*Estimator, error) {
+	if cfg.MinBucketFee <= 0 || cfg.MaxBucketFee < cfg.MinBucketFee {
+		return nil, fmt.Errorf("invalid bucket fee rates %d to %d",
+			cfg.MinBucketFee, cfg.MaxBucketFee)
+	}
+	if cfg.FeeRateStep <= 1 {
+		return nil, fmt.Errorf("fee rate step %v must be greater than 1",
+			cfg.FeeRateStep)
+	}
+	if cfg.MaxConfirms < 1 {
+		return nil, fmt.Errorf("maximum number of confirmations %d must "+
+			"be positive", cfg.MaxConfirms)
+	}
+
+	var feeRates []int64
+	for feeRate := float64(cfg.MinBucketFee); feeRate <=
+		float64(cfg.MaxBucketFee); feeRate *= cfg.FeeRateStep {
+
+		feeRates = append(feeRates, int64(feeRate))
+	}
+
+	e := &Estimator{
+		feeRates:    feeRates,
+		maxConfirms: cfg.MaxConfirms,
+		bestHeight:  cfg.BestHeight,
+		confirmed:   make([][]float64, cfg.MaxConfirms),
+		txCounts:    make([]float64, len(feeRates)),
+		feeSums:     make([]float64, len(feeRates)),
+		memPool:     make(map[chainhash.Hash]memPoolTx),
+	}
+	for i := range e.confirmed {
+		e.confirmed[i] = make([]float64, len(feeRates))
+	}
+	return e, nil
+}
+
+// bucketForFeeRate returns the index of the bucket the passed fee rate is
+// recorded in, which is the bucket with the highest fee rate that does not
+// exceed it.
+func (e *Estimator) bucketForFeeRate(feeRate int64) int {
+	bucket := 0
+	for i, bucketFeeRate := range e.feeRates {
+		if feeRate < bucketFeeRate {
+			break
+		}
+		bucket = i
+	}
+	return bucket
+}
+
+// AddMemPoolTransaction starts tracking the passed transaction which entered
+// the memory pool with the passed fee in atoms and serialized size in bytes.
+//
+// This function is safe for concurrent access.
+func (e *Estimator) AddMemPoolTransaction(txHash *chainhash.Hash, fee, size int64) {
+	if size <= 0 {
+		return
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if _, exists := e.memPool[*txHash]; exists {
+		return
+	}
+	feeRate := fee * 1000 / size
+	e.memPool[*txHash] = memPoolTx{
+		bucket:  e.bucketForFeeRate(feeRate),
+		height:  e.bestHeight,
+		feeRate: feeRate,
+	}
+}
+
+// RemoveMemPoolTransaction stops tracking the passed transaction, which must be
+// called when a transaction leaves the memory pool without being mined.  It is
+// a no-op for transactions confirmed by a block passed to ProcessBlock.
+//
+// This function is safe for concurrent access.
+func (e *Estimator) RemoveMemPoolTransaction(txHash *chainhash.Hash) {
+	e.mtx.Lock()
+	delete(e.memPool, *txHash)
+	e.mtx.Unlock()
+}
+
+// ProcessBlock decays the recorded data and records the confirmation times of
+// the tracked transactions mined in the block at the passed height with the
+// passed transaction hashes.  It must be called for every block connected to
+// the main chain before the transactions of the block are removed from the
+// memory pool.
+//
+// Blocks which do not extend the highest block processed so far, such as the
+// blocks which replace others during a reorganization, are ignored to avoid
+// recording the same transactions multiple times.
+//
+// This function is safe for concurrent access.
+func (e *Estimator) ProcessBlock(height int64, txHashes []*chainhash.Hash) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if height <= e.bestHeight {
+		return
+	}
+	e.bestHeight = height
+
+	for _, confirmed := range e.confirmed {
+		for i := range confirmed {
+			confirmed[i] *= decay
+		}
+	}
+	for i := range e.txCounts {
+		e.txCounts[i] *= decay
+		e.feeSums[i] *= decay
+	}
+
+	for _, txHash := range txHashes {
+		tx, exists := e.memPool[*txHash]
+		if !exists {
+			continue
+		}
+		delete(e.memPool, *txHash)
+
+		// Record the transaction as confirmed within its number of
+		// confirmations and every higher target.
+		confirms := int(height - tx.height)
+		if confirms < 1 {
+			confirms = 1
+		}
+		for i := confirms - 1; i < e.maxConfirms; i++ {
+			e.confirmed[i][tx.bucket]++
+		}
+		e.txCounts[tx.bucket]++
+		e.feeSums[tx.bucket] += float64(tx.feeRate)
+	}
+}
+
+// EstimateFee returns the estimated fee rate in atoms/kB a transaction needs
+// to pay in order to be confirmed within the passed number of blocks.
+// ErrNoEstimate is returned when not enough transactions were seen to provide
+// an estimate.
+//
+// This function is safe for concurrent access.
+func (e *Estimator) EstimateFee(targetConfs int) (int64, error) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if targetConfs < 1 || targetConfs > e.maxConfirms {
+		return 0, fmt.Errorf("target confirmations %d must be between 1 "+
+			"and %d", targetConfs, e.maxConfirms)
+	}
+
+	// Count the transactions of every bucket which are still in the memory
+	// pool after more blocks than the target as failing to confirm within
+	// it.
+	unconfirmed := make([]float64, len(e.feeRates))
+	for _, tx := range e.memPool {
+		if e.bestHeight-tx.height >= int64(targetConfs) {
+			unconfirmed[tx.bucket]++
+		}
+	}
+
+	// Combine the buckets into ranges from the highest fee rate down, each
+	// holding enough transactions to evaluate its success rate.  The
+	// estimate is the average fee rate of the lowest range which succeeds
+	// while all ranges above it succeeded as well.
+	confirmed := e.confirmed[targetConfs-1]
+	var numConfirmed, numTotal, numTxns, feeSum float64
+	estimate := -1.0
+	for i := len(e.feeRates) - 1; i >= 0; i-- {
+		numConfirmed += confirmed[i]
+		numTotal += e.txCounts[i] + unconfirmed[i]
+		numTxns += e.txCounts[i]
+		feeSum += e.feeSums[i]
+		if numTotal < sufficientDataPoints {
+			continue
+		}
+		if numConfirmed/numTotal < successThreshold {
+			break
+		}
+		estimate = feeSum / numTxns
+		numConfirmed, numTotal, numTxns, feeSum = 0, 0, 0, 0
+	}
+	if estimate < 0 {
+		return 0, ErrNoEstimate
+	}
+	return int64(estimate + 0.5), nil
+}
+
+// MaxConfirms returns the highest target the estimator provides estimates for.
+func (e *Estimator) MaxConfirms() int {
+	return e.maxConfirms
+}
+
+// estimatorState is the serialized state of an estimator.
+type estimatorState struct {
+	Version     int         `json:"version"`
+	BestHeight  int64       `json:"bestheight"`
+	FeeRates    []int64     `json:"feerates"`
+	MaxConfirms int         `json:"maxconfirms"`
+	Confirmed   [][]float64 `json:"confirmed"`
+	TxCounts    []float64   `json:"txcounts"`
+	FeeSums     []float64   `json:"feesums"`
+}
+
+// Save writes the recorded data of the estimator to the passed writer so it
+// can be restored with Restore.  The tracked memory pool transactions are not
+// saved.
+//
+// This function is safe for concurrent access.
+func (e *Estimator) Save(w io.Writer) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	return json.NewEncoder(w).Encode(&estimatorState{
+		Version:     stateVersion,
+		BestHeight:  e.bestHeight,
+		FeeRates:    e.feeRates,
+		MaxConfirms: e.maxConfirms,
+		Confirmed:   e.confirmed,
+		TxCounts:    e.txCounts,
+		FeeSums:     e.feeSums,
+	})
+}
+
+// Restore replaces the recorded data of the estimator with the data saved by
+// Save.  ErrIncompatibleState is returned when the data was saved by an
+// estimator with a different configuration.  The best height the estimator was
+// created with is retained since the main chain may have changed since the
+// data was saved.
+//
+// This function is safe for concurrent access.
+func (e *Estimator) Restore(r io.Reader) error {
+	var state estimatorState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return err
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if state.Version != stateVersion || state.MaxConfirms != e.maxConfirms ||
+		len(state.FeeRates) != len(e.feeRates) ||
+		len(state.Confirmed) != e.maxConfirms ||
+		len(state.TxCounts) != len(e.feeRates) ||
+		len(state.FeeSums) != len(e.feeRates) {
+
+		return ErrIncompatibleState
+	}
+	for i, feeRate := range state.FeeRates {
+		if feeRate != e.feeRates[i] {
+			return ErrIncompatibleState
+		}
+	}
+	for _, confirmed := range state.Confirmed {
+		if len(confirmed) != len(e.feeRates) {
+			return ErrIncompatibleState
+		}
+	}
+
+	e.confirmed = state.Confirmed
+	e.txCounts = state.TxCounts
+	e.feeSums = state.FeeSums
+	return nil
+}
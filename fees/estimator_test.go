@@ -0,0 +1,158 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fees
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// testEstimator returns an estimator with buckets from 0.01 to 1 DCR/kB which
+// tracks up to 8 confirmations.
+func testEstimator(t *testing.T) *Estimator {
+	e, err := NewEstimator(&EstimatorConfig{
+		MinBucketFee: 1e6,
+		MaxBucketFee: 1e8,
+		FeeRateStep:  DefaultFeeRateStep,
+		MaxConfirms:  8,
+	})
+	if err != nil {
+		t.Fatalf("NewEstimator: unexpected error: %v", err)
+	}
+	return e
+}
+
+// testTxHash returns a transaction hash which is unique to the passed number.
+func testTxHash(n int) *chainhash.Hash {
+	return &chainhash.Hash{byte(n), byte(n >> 8), byte(n >> 16)}
+}
+
+// TestEstimateFee ensures fee rates are estimated from the confirmation times
+// of the transactions seen in the memory pool, transactions which linger in the
+// memory pool count against their fee rates, and no estimate is provided
+// without enough data.
+func TestEstimateFee(t *testing.T) {
+	t.Parallel()
+
+	e := testEstimator(t)
+	if _, err := e.EstimateFee(1); err != ErrNoEstimate {
+		t.Fatalf("EstimateFee: unexpected error -- got %v, want %v", err,
+			ErrNoEstimate)
+	}
+	for _, target := range []int{0, 9} {
+		if _, err := e.EstimateFee(target); err == nil {
+			t.Fatalf("EstimateFee: no error for target %d", target)
+		}
+	}
+
+	// Mine transactions paying 0.1 DCR/kB in the next block and those
+	// paying 0.02 DCR/kB after five blocks.
+	n := 0
+	var lowFeeTxns []*chainhash.Hash
+	for height := int64(1); height <= 40; height++ {
+		var mined []*chainhash.Hash
+		for i := 0; i < 2; i++ {
+			n++
+			e.AddMemPoolTransaction(testTxHash(n), 1e7, 1000)
+			mined = append(mined, testTxHash(n))
+			n++
+			e.AddMemPoolTransaction(testTxHash(n), 2e6, 1000)
+			lowFeeTxns = append(lowFeeTxns, testTxHash(n))
+		}
+		if len(lowFeeTxns) > 8 {
+			mined = append(mined, lowFeeTxns[:len(lowFeeTxns)-8]...)
+			lowFeeTxns = lowFeeTxns[len(lowFeeTxns)-8:]
+		}
+		e.ProcessBlock(height, mined)
+	}
+
+	feeRate, err := e.EstimateFee(1)
+	if err != nil {
+		t.Fatalf("EstimateFee: unexpected error: %v", err)
+	}
+	if feeRate != 1e7 {
+		t.Fatalf("EstimateFee: unexpected fee rate for the next block "+
+			"-- got %d, want %d", feeRate, int64(1e7))
+	}
+	feeRate, err = e.EstimateFee(6)
+	if err != nil {
+		t.Fatalf("EstimateFee: unexpected error: %v", err)
+	}
+	if feeRate != 2e6 {
+		t.Fatalf("EstimateFee: unexpected fee rate for six blocks -- "+
+			"got %d, want %d", feeRate, int64(2e6))
+	}
+
+	// Ensure blocks which do not extend the best height are ignored.
+	e.ProcessBlock(40, lowFeeTxns)
+	if _, exists := e.memPool[*lowFeeTxns[0]]; !exists {
+		t.Fatal("ProcessBlock: transaction mined in an ignored block")
+	}
+
+	// Ensure transactions removed from the memory pool are no longer
+	// tracked.
+	e.RemoveMemPoolTransaction(lowFeeTxns[0])
+	if _, exists := e.memPool[*lowFeeTxns[0]]; exists {
+		t.Fatal("RemoveMemPoolTransaction: transaction still tracked")
+	}
+}
+
+// TestEstimatorSaveRestore ensures the recorded data of an estimator survives
+// saving and restoring it and the data is only restored into estimators with
+// the same configuration.
+func TestEstimatorSaveRestore(t *testing.T) {
+	t.Parallel()
+
+	e := testEstimator(t)
+	for height := int64(1); height <= 20; height++ {
+		var mined []*chainhash.Hash
+		for i := 0; i < 2; i++ {
+			txHash := testTxHash(int(height)*2 + i)
+			e.AddMemPoolTransaction(txHash, 5e6, 1000)
+			mined = append(mined, txHash)
+		}
+		e.ProcessBlock(height, mined)
+	}
+	want, err := e.EstimateFee(2)
+	if err != nil {
+		t.Fatalf("EstimateFee: unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := e.Save(&buf); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	saved := buf.Bytes()
+
+	restored := testEstimator(t)
+	if err := restored.Restore(bytes.NewReader(saved)); err != nil {
+		t.Fatalf("Restore: unexpected error: %v", err)
+	}
+	got, err := restored.EstimateFee(2)
+	if err != nil {
+		t.Fatalf("EstimateFee: unexpected error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("EstimateFee: unexpected restored estimate -- got %d, "+
+			"want %d", got, want)
+	}
+
+	other, err := NewEstimator(&EstimatorConfig{
+		MinBucketFee: 1e6,
+		MaxBucketFee: 1e9,
+		FeeRateStep:  DefaultFeeRateStep,
+		MaxConfirms:  8,
+	})
+	if err != nil {
+		t.Fatalf("NewEstimator: unexpected error: %v", err)
+	}
+	err = other.Restore(bytes.NewReader(saved))
+	if err != ErrIncompatibleState {
+		t.Fatalf("Restore: unexpected error -- got %v, want %v", err,
+			ErrIncompatibleState)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// headerSyncStallTimeout is the amount of time a peer which is assigned a
+// segment of headers may take to respond to a request for the headers before
+// the segment is reassigned to another peer.
+const headerSyncStallTimeout = time.Second * 30
+
+// headerSegment houses the headers downloaded during headers-first mode for
+// the blocks after a checkpoint, or after the latest known block for the first
+// segment, up to and including the block at the next checkpoint.  Since both
+// ends of a segment are known in advance, the headers of each segment are
+// verified to link together independently of the other segments.
+//
+// The headers of a segment are only verified against the checkpoint once it is
+// complete, so all of them are discarded when the peer they are requested from
+// stops serving them before that.  Otherwise, a peer could send headers which
+// link together but do not lead to the checkpoint and then stall, and the
+// segment would be requested from the other peers starting from a header they
+// do not know.
+type headerSegment struct {
+	prevHash   *chainhash.Hash
+	prevHeight int64
+	checkpoint *chaincfg.Checkpoint
+	nodes      []*headerNode
+
+	// peer is the peer the headers are requested from or nil when the
+	// segment is not assigned to a peer.
+	peer        *serverPeer
+	requestedAt time.Time
+}
+
+// tip returns the hash and height of the latest downloaded header of the
+// segment, which is the block preceding the segment when no headers were
+// downloaded yet.
+func (seg *headerSegment) tip() (*chainhash.Hash, int64) {
+	if len(seg.nodes) == 0 {
+		return seg.prevHash, seg.prevHeight
+	}
+	node := seg.nodes[len(seg.nodes)-1]
+	return node.hash, node.height
+}
+
+// complete returns whether or not all of the headers of the segment up to the
+// checkpoint have been downloaded.
+func (seg *headerSegment) complete() bool {
+	_, height := seg.tip()
+	return height == seg.checkpoint.Height
+}
+
+// headerSyncScheduler schedules the headers which are downloaded during
+// headers-first mode across all of the sync peers.  The headers up to the
+// final checkpoint are divided into segments between consecutive checkpoints
+// and each peer is assigned one segment at a time, so the headers of several
+// segments are downloaded in parallel.  The headers of the segments are handed
+// out in order once they are complete so the blocks for them can be
+// downloaded while the headers of the later segments are still being
+// downloaded.
+//
+// The scheduler is not safe for concurrent access.  It is only accessed from
+// the block handler goroutine.
+type headerSyncScheduler struct {
+	powLimit *big.Int
+	segments []*headerSegment
+	assigned map[*serverPeer]*headerSegment
+
+	// unavailable houses the peers which responded that they do not have
+	// the headers of the segment they were assigned.  They are no longer
+	// assigned segments.
+	unavailable map[*serverPeer]struct{}
+}
+
+// newHeaderSyncScheduler returns a new header sync scheduler without any
+// segments which requires the headers to have proof of work up to the passed
+// limit.
+func newHeaderSyncScheduler(powLimit *big.Int) *headerSyncScheduler {
+	return &headerSyncScheduler{
+		powLimit:    powLimit,
+		assigned:    make(map[*serverPeer]*headerSegment),
+		unavailable: make(map[*serverPeer]struct{}),
+	}
+}
+
+// Reset discards all segments, along with the peers which do not have their
+// headers, and divides the headers after the passed block up to the final
+// passed checkpoint into new unassigned segments ending at each of the passed
+// checkpoints.  The checkpoints must be ordered by height and be after the
+// passed block.
+func (s *headerSyncScheduler) Reset(prevHash *chainhash.Hash, prevHeight int64, checkpoints []*chaincfg.Checkpoint) {
+	s.segments = make([]*headerSegment, 0, len(checkpoints))
+	s.assigned = make(map[*serverPeer]*headerSegment)
+	s.unavailable = make(map[*serverPeer]struct{})
+	for _, checkpoint := range checkpoints {
+		s.segments = append(s.segments, &headerSegment{
+			prevHash:   prevHash,
+			prevHeight: prevHeight,
+			checkpoint: checkpoint,
+		})
+		prevHash, prevHeight = checkpoint.Hash, checkpoint.Height
+	}
+}
+
+// Unassigned returns the segments which are neither complete nor assigned to a
+// peer ordered by height.
+func (s *headerSyncScheduler) Unassigned() []*headerSegment {
+	var unassigned []*headerSegment
+	for _, seg := range s.segments {
+		if seg.peer == nil && !seg.complete() {
+			unassigned = append(unassigned, seg)
+		}
+	}
+	return unassigned
+}
+
+// MayAssign returns whether or not the passed peer may be assigned a segment
+// since it is neither assigned one already nor responded that it does not have
+// the headers of a segment.
+func (s *headerSyncScheduler) MayAssign(sp *serverPeer) bool {
+	if _, ok := s.assigned[sp]; ok {
+		return false
+	}
+	_, ok := s.unavailable[sp]
+	return !ok
+}
+
+// Assign records that the headers of the passed segment are requested from the
+// passed peer.
+func (s *headerSyncScheduler) Assign(seg *headerSegment, sp *serverPeer, now time.Time) {
+	seg.peer = sp
+	seg.requestedAt = now
+	s.assigned[sp] = seg
+}
+
+// Assigned returns the segment which is assigned to the passed peer or nil when
+// it is not assigned one.
+func (s *headerSyncScheduler) Assigned(sp *serverPeer) *headerSegment {
+	return s.assigned[sp]
+}
+
+// unassign removes the segment assigned to the passed peer, if any, from the
+// peer.  The headers which were downloaded for the segment are discarded unless
+// it is complete, so the segment is downloaded again starting from the block
+// preceding it.
+func (s *headerSyncScheduler) unassign(sp *serverPeer) {
+	seg, ok := s.assigned[sp]
+	if !ok {
+		return
+	}
+	seg.peer = nil
+	if !seg.complete() {
+		seg.nodes = nil
+	}
+	delete(s.assigned, sp)
+}
+
+// AddHeaders adds the passed headers received from the passed peer to the
+// segment assigned to it and returns whether or not the segment is complete.
+// Each header must connect to the previous one and have valid proof of work,
+// and the header at the height of the checkpoint at the end of the segment must
+// match the checkpoint.  Headers
+// after the checkpoint are ignored.  An error is returned without adding any
+// of the headers when they are invalid.
+//
+// Complete segments are no longer assigned to the peer.
+func (s *headerSyncScheduler) AddHeaders(sp *serverPeer, headers []*wire.BlockHeader, now time.Time) (bool, error) {
+	seg, ok := s.assigned[sp]
+	if !ok {
+		return false, fmt.Errorf("peer is not assigned any headers")
+	}
+
+	prevHash, prevHeight := seg.tip()
+	nodes := make([]*headerNode, 0, len(headers))
+	for _, header := range headers {
+		if prevHeight == seg.checkpoint.Height {
+			break
+		}
+		if header.PrevBlock != *prevHash {
+			return false, fmt.Errorf("header for block %v does not "+
+				"connect to block %v at height %d",
+				header.BlockHash(), prevHash, prevHeight)
+		}
+
+		hash := header.BlockHash()
+		err := blockchain.CheckProofOfWork(dcrutil.NewBlock(&wire.MsgBlock{
+			Header: *header,
+		}), s.powLimit)
+		if err != nil {
+			return false, fmt.Errorf("header for block %v has invalid "+
+				"proof of work: %v", hash, err)
+		}
+		if header.Height != uint32(prevHeight+1) {
+			return false, fmt.Errorf("header for block %v has height "+
+				"%d instead of %d", hash, header.Height,
+				prevHeight+1)
+		}
+
+		node := &headerNode{hash: &hash, height: prevHeight + 1}
+		if node.height == seg.checkpoint.Height &&
+			!node.hash.IsEqual(seg.checkpoint.Hash) {
+
+			return false, fmt.Errorf("header at height %d/hash %s "+
+				"does NOT match expected checkpoint hash of %s",
+				node.height, node.hash, seg.checkpoint.Hash)
+		}
+		nodes = append(nodes, node)
+		prevHash, prevHeight = node.hash, node.height
+	}
+	seg.nodes = append(seg.nodes, nodes...)
+	seg.requestedAt = now
+
+	if !seg.complete() {
+		return false, nil
+	}
+	s.unassign(sp)
+	return true, nil
+}
+
+// Unavailable records that the passed peer does not have the headers of the
+// segment assigned to it so the segment is reassigned to another peer without
+// the headers the peer already sent.  The peer is no longer assigned segments.
+func (s *headerSyncScheduler) Unavailable(sp *serverPeer) {
+	s.unassign(sp)
+	s.unavailable[sp] = struct{}{}
+}
+
+// RemovePeer removes the passed peer from the peers segments may be assigned
+// to.  The segment which was assigned to it is reassigned to another peer
+// without the headers the peer already sent.
+func (s *headerSyncScheduler) RemovePeer(sp *serverPeer) {
+	s.unassign(sp)
+	delete(s.unavailable, sp)
+}
+
+// Stalled returns the peers which did not respond to the latest request for
+// the headers of the segment assigned to them within the stall timeout.  The
+// segments are no longer assigned to the returned peers, and the headers they
+// already sent are discarded.
+func (s *headerSyncScheduler) Stalled(now time.Time) []*serverPeer {
+	var stalled []*serverPeer
+	for sp, seg := range s.assigned {
+		if now.Sub(seg.requestedAt) > headerSyncStallTimeout {
+			stalled = append(stalled, sp)
+		}
+	}
+	for _, sp := range stalled {
+		s.unassign(sp)
+	}
+	return stalled
+}
+
+// TakeComplete returns the headers of the leading segments which are complete
+// ordered by height and removes those segments.  The headers of a complete
+// segment are only returned once all of the segments before it were returned.
+func (s *headerSyncScheduler) TakeComplete() []*headerNode {
+	var nodes []*headerNode
+	for len(s.segments) > 0 && s.segments[0].complete() {
+		nodes = append(nodes, s.segments[0].nodes...)
+		s.segments[0] = nil
+		s.segments = s.segments[1:]
+	}
+	return nodes
+}
+
+// NumPending returns the number of segments whose headers were not returned
+// yet.
+func (s *headerSyncScheduler) NumPending() int {
+	return len(s.segments)
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// headerSyncTestPowLimit is the proof of work limit of the test headers.
+var headerSyncTestPowLimit = chaincfg.SimNetParams.PowLimit
+
+// solveHeaderSyncTestHeader sets the bits of the passed header to the proof of
+// work limit of the test headers and searches for a nonce which satisfies it.
+func solveHeaderSyncTestHeader(header *wire.BlockHeader) {
+	header.Bits = chaincfg.SimNetParams.PowLimitBits
+	for header.Nonce = 0; ; header.Nonce++ {
+		hash := header.BlockHash()
+		if blockchain.HashToBig(&hash).Cmp(headerSyncTestPowLimit) <= 0 {
+			return
+		}
+	}
+}
+
+// headerSyncTestChain returns a chain of headers with valid proof of work which
+// link together for the heights from zero through the passed height.  The
+// passed stake version allows creating distinct chains.
+func headerSyncTestChain(tipHeight int64, stakeVersion uint32) []*wire.BlockHeader {
+	headers := make([]*wire.BlockHeader, 0, tipHeight+1)
+	var prevHash chainhash.Hash
+	for height := int64(0); height <= tipHeight; height++ {
+		header := &wire.BlockHeader{
+			PrevBlock:    prevHash,
+			Height:       uint32(height),
+			StakeVersion: stakeVersion,
+		}
+		solveHeaderSyncTestHeader(header)
+		headers = append(headers, header)
+		prevHash = header.BlockHash()
+	}
+	return headers
+}
+
+// headerSyncTestCheckpoint returns a checkpoint for the header at the passed
+// height of the passed chain.
+func headerSyncTestCheckpoint(headers []*wire.BlockHeader, height int64) *chaincfg.Checkpoint {
+	hash := headers[height].BlockHash()
+	return &chaincfg.Checkpoint{Height: height, Hash: &hash}
+}
+
+// TestHeaderSyncSchedulerSegments ensures the headers between checkpoints are
+// downloaded in segments from different peers in parallel, verified against the
+// checkpoints, and handed out in order of height.
+func TestHeaderSyncSchedulerSegments(t *testing.T) {
+	headers := headerSyncTestChain(12, 0)
+	genesisHash := headers[0].BlockHash()
+	checkpoints := []*chaincfg.Checkpoint{
+		headerSyncTestCheckpoint(headers, 4),
+		headerSyncTestCheckpoint(headers, 8),
+		headerSyncTestCheckpoint(headers, 12),
+	}
+	s := newHeaderSyncScheduler(headerSyncTestPowLimit)
+	s.Reset(&genesisHash, 0, checkpoints)
+	if s.NumPending() != len(checkpoints) {
+		t.Fatalf("unexpected pending segments - got %d, want %d",
+			s.NumPending(), len(checkpoints))
+	}
+
+	// Assign the first two segments to different peers.
+	now := time.Now()
+	sp1, sp2 := &serverPeer{}, &serverPeer{}
+	unassigned := s.Unassigned()
+	if len(unassigned) != len(checkpoints) {
+		t.Fatalf("unexpected unassigned segments - got %d, want %d",
+			len(unassigned), len(checkpoints))
+	}
+	s.Assign(unassigned[0], sp1, now)
+	s.Assign(unassigned[1], sp2, now)
+	if s.MayAssign(sp1) || s.MayAssign(sp2) {
+		t.Fatal("MayAssign: peer with assigned segment may be assigned")
+	}
+	if len(s.Unassigned()) != 1 {
+		t.Fatalf("unexpected unassigned segments - got %d, want 1",
+			len(s.Unassigned()))
+	}
+
+	// Ensure headers which do not connect to the segment are rejected
+	// without adding any of them.
+	if _, err := s.AddHeaders(sp2, headers[1:5], now); err == nil {
+		t.Fatal("AddHeaders: accepted headers which do not connect")
+	}
+	if _, height := s.Assigned(sp2).tip(); height != 4 {
+		t.Fatalf("unexpected segment tip - got %d, want 4", height)
+	}
+
+	// Ensure the later segment is complete before the earlier one, while
+	// its headers are not handed out until the earlier segment completes.
+	complete, err := s.AddHeaders(sp2, headers[5:], now)
+	if err != nil {
+		t.Fatalf("AddHeaders: unexpected error: %v", err)
+	}
+	if !complete {
+		t.Fatal("AddHeaders: segment up to the checkpoint is not complete")
+	}
+	if s.Assigned(sp2) != nil || !s.MayAssign(sp2) {
+		t.Fatal("complete segment is still assigned to the peer")
+	}
+	if nodes := s.TakeComplete(); len(nodes) != 0 {
+		t.Fatalf("TakeComplete: unexpected %d headers before the "+
+			"earlier segment completed", len(nodes))
+	}
+
+	// Complete the first segment in two batches and ensure the headers of
+	// both segments are handed out in order.
+	complete, err = s.AddHeaders(sp1, headers[1:3], now)
+	if err != nil || complete {
+		t.Fatalf("AddHeaders: unexpected result for partial segment - "+
+			"complete %v, err %v", complete, err)
+	}
+	complete, err = s.AddHeaders(sp1, headers[3:5], now)
+	if err != nil || !complete {
+		t.Fatalf("AddHeaders: unexpected result for final batch - "+
+			"complete %v, err %v", complete, err)
+	}
+	nodes := s.TakeComplete()
+	if len(nodes) != 8 {
+		t.Fatalf("TakeComplete: unexpected number of headers - got %d, "+
+			"want 8", len(nodes))
+	}
+	for i, node := range nodes {
+		wantHash := headers[i+1].BlockHash()
+		if node.height != int64(i+1) || !node.hash.IsEqual(&wantHash) {
+			t.Fatalf("TakeComplete: unexpected header %d - got height "+
+				"%d hash %v", i, node.height, node.hash)
+		}
+	}
+	if s.NumPending() != 1 {
+		t.Fatalf("unexpected pending segments - got %d, want 1",
+			s.NumPending())
+	}
+}
+
+// TestHeaderSyncSchedulerCheckpoint ensures headers which do not match the
+// checkpoint at the end of a segment or lack proof of work are rejected.
+func TestHeaderSyncSchedulerCheckpoint(t *testing.T) {
+	headers := headerSyncTestChain(4, 0)
+	genesisHash := headers[0].BlockHash()
+	otherHeaders := headerSyncTestChain(4, 0)
+	otherHeaders[4].StakeVersion = 1
+	solveHeaderSyncTestHeader(otherHeaders[4])
+	s := newHeaderSyncScheduler(headerSyncTestPowLimit)
+	s.Reset(&genesisHash, 0, []*chaincfg.Checkpoint{
+		headerSyncTestCheckpoint(otherHeaders, 4),
+	})
+
+	sp := &serverPeer{}
+	now := time.Now()
+	s.Assign(s.Unassigned()[0], sp, now)
+	if _, err := s.AddHeaders(sp, headers[1:], now); err == nil {
+		t.Fatal("AddHeaders: accepted header which does not match the " +
+			"checkpoint")
+	}
+
+	// Ensure headers with bits above the proof of work limit or a hash
+	// above their target are rejected.
+	noWork := *otherHeaders[1]
+	noWork.Bits = 0x2100ffff
+	if _, err := s.AddHeaders(sp, []*wire.BlockHeader{&noWork}, now); err == nil {
+		t.Fatal("AddHeaders: accepted header with bits above the limit")
+	}
+	noWork.Bits = 0x03000001
+	if _, err := s.AddHeaders(sp, []*wire.BlockHeader{&noWork}, now); err == nil {
+		t.Fatal("AddHeaders: accepted header with a hash above its target")
+	}
+	complete, err := s.AddHeaders(sp, otherHeaders[1:], now)
+	if err != nil || !complete {
+		t.Fatalf("AddHeaders: unexpected result for matching headers - "+
+			"complete %v, err %v", complete, err)
+	}
+}
+
+// TestHeaderSyncSchedulerReassign ensures the segments of peers which stalled,
+// do not have the headers, or were removed are reassigned without the headers
+// those peers already sent.
+func TestHeaderSyncSchedulerReassign(t *testing.T) {
+	headers := headerSyncTestChain(8, 0)
+	genesisHash := headers[0].BlockHash()
+	s := newHeaderSyncScheduler(headerSyncTestPowLimit)
+	s.Reset(&genesisHash, 0, []*chaincfg.Checkpoint{
+		headerSyncTestCheckpoint(headers, 4),
+		headerSyncTestCheckpoint(headers, 8),
+	})
+
+	now := time.Now()
+	sp1, sp2, sp3 := &serverPeer{}, &serverPeer{}, &serverPeer{}
+	unassigned := s.Unassigned()
+	s.Assign(unassigned[0], sp1, now)
+	s.Assign(unassigned[1], sp2, now)
+	if _, err := s.AddHeaders(sp1, headers[1:3], now); err != nil {
+		t.Fatalf("AddHeaders: unexpected error: %v", err)
+	}
+
+	// Ensure a peer which responded within the stall timeout is not
+	// considered stalled while the other one is.
+	later := now.Add(headerSyncStallTimeout + time.Second)
+	if _, err := s.AddHeaders(sp1, headers[3:4], later); err != nil {
+		t.Fatalf("AddHeaders: unexpected error: %v", err)
+	}
+	stalled := s.Stalled(later)
+	if len(stalled) != 1 || stalled[0] != sp2 {
+		t.Fatalf("Stalled: unexpected stalled peers %v", stalled)
+	}
+	if s.Assigned(sp2) != nil || !s.MayAssign(sp2) {
+		t.Fatal("segment of stalled peer is still assigned")
+	}
+
+	// Ensure a peer which does not have the headers is no longer assigned
+	// segments and the segment is reassigned from its start.
+	s.Unavailable(sp1)
+	if s.MayAssign(sp1) {
+		t.Fatal("MayAssign: peer without the headers may be assigned")
+	}
+	unassigned = s.Unassigned()
+	if len(unassigned) != 2 {
+		t.Fatalf("unexpected unassigned segments - got %d, want 2",
+			len(unassigned))
+	}
+	if _, height := unassigned[0].tip(); height != 0 {
+		t.Fatalf("unexpected tip of reassigned segment - got %d, want 0",
+			height)
+	}
+	s.Assign(unassigned[0], sp3, later)
+	complete, err := s.AddHeaders(sp3, headers[1:5], later)
+	if err != nil || !complete {
+		t.Fatalf("AddHeaders: unexpected result for reassigned segment "+
+			"- complete %v, err %v", complete, err)
+	}
+
+	// Ensure a removed peer may be assigned segments again once it
+	// reconnects and the segment of a removed peer is reassigned.
+	s.RemovePeer(sp1)
+	if !s.MayAssign(sp1) {
+		t.Fatal("MayAssign: removed peer may not be assigned")
+	}
+	s.Assign(s.Unassigned()[0], sp2, later)
+	s.RemovePeer(sp2)
+	if len(s.Unassigned()) != 1 {
+		t.Fatalf("unexpected unassigned segments after removing peer - "+
+			"got %d, want 1", len(s.Unassigned()))
+	}
+	if _, err := s.AddHeaders(sp2, headers[5:], later); err == nil {
+		t.Fatal("AddHeaders: accepted headers from removed peer")
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// lanPeersGroupAddr is the multicast group address and port used to
+	// announce and discover peers on the local network.
+	lanPeersGroupAddr = "239.255.84.108:19180"
+
+	// lanPeersAnnounceInterval is the interval at which the local node is
+	// announced to the local network.
+	lanPeersAnnounceInterval = time.Second * 30
+
+	// lanPeersRetryInterval is the minimum amount of time between
+	// connection attempts to the same peer discovered on the local network.
+	lanPeersRetryInterval = time.Minute * 5
+
+	// lanBeaconSize is the size of a serialized local network announcement.
+	lanBeaconSize = 4 + 4 + 2 + 8
+)
+
+// lanBeaconMagic identifies local network announcements made by dcrd.
+var lanBeaconMagic = [4]byte{'d', 'c', 'r', 'l'}
+
+// lanBeacon describes an announcement of a node on the local network.
+//
+// The serialized format is:
+//
+//   <magic><net><port><nonce>
+//
+//   Field           Type              Size
+//   magic           [4]byte           4 bytes
+//   net             wire.CurrencyNet  4 bytes
+//   port            uint16            2 bytes
+//   nonce           uint64            8 bytes
+type lanBeacon struct {
+	net   wire.CurrencyNet
+	port  uint16
+	nonce uint64
+}
+
+// serialize returns the serialization of the announcement according to the
+// format described above.
+func (b *lanBeacon) serialize() []byte {
+	serialized := make([]byte, lanBeaconSize)
+	copy(serialized[0:4], lanBeaconMagic[:])
+	binary.LittleEndian.PutUint32(serialized[4:8], uint32(b.net))
+	binary.LittleEndian.PutUint16(serialized[8:10], b.port)
+	binary.LittleEndian.PutUint64(serialized[10:18], b.nonce)
+	return serialized
+}
+
+// deserializeLANBeacon decodes the passed serialized local network
+// announcement.
+func deserializeLANBeacon(serialized []byte) (*lanBeacon, error) {
+	if len(serialized) != lanBeaconSize {
+		return nil, errors.New("unexpected local network announcement " +
+			"size")
+	}
+	if !bytes.Equal(serialized[0:4], lanBeaconMagic[:]) {
+		return nil, errors.New("unexpected local network announcement " +
+			"magic")
+	}
+
+	return &lanBeacon{
+		net:   wire.CurrencyNet(binary.LittleEndian.Uint32(serialized[4:8])),
+		port:  binary.LittleEndian.Uint16(serialized[8:10]),
+		nonce: binary.LittleEndian.Uint64(serialized[10:18]),
+	}, nil
+}
+
+// lanListenPort returns the port to announce to the local network.  It is the
+// port of the first configured listener or the default port of the active
+// network when it can't be determined.
+func lanListenPort() uint16 {
+	if len(cfg.Listeners) > 0 {
+		_, portStr, err := net.SplitHostPort(cfg.Listeners[0])
+		if err == nil {
+			port, err := strconv.ParseUint(portStr, 10, 16)
+			if err == nil {
+				return uint16(port)
+			}
+		}
+	}
+
+	port, _ := strconv.ParseUint(activeNetParams.DefaultPort, 10, 16)
+	return uint16(port)
+}
+
+// lanPeerMsg is used to request a connection to a peer that was discovered on
+// the local network.
+type lanPeerMsg struct {
+	addr string
+}
+
+// lanPeerHandler periodically announces the local node to the local network
+// via multicast and connects to other nodes on the same network that announce
+// themselves.  The simulation and test networks are typically run with several
+// nodes on the same LAN or container network, so this removes the need to
+// manually wire them together.
+//
+// It must be run as a goroutine.
+func (s *server) lanPeerHandler() {
+	defer s.wg.Done()
+
+	groupAddr, err := net.ResolveUDPAddr("udp4", lanPeersGroupAddr)
+	if err != nil {
+		srvrLog.Warnf("Unable to resolve local peer discovery address: %v",
+			err)
+		return
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, groupAddr)
+	if err != nil {
+		srvrLog.Warnf("Unable to start local peer discovery: %v", err)
+		return
+	}
+
+	// The nonce is used to detect announcements made by this node and to
+	// ensure only one of each pair of nodes initiates the connection.
+	nonce, err := wire.RandomUint64()
+	if err != nil {
+		srvrLog.Warnf("Unable to start local peer discovery: %v", err)
+		conn.Close()
+		return
+	}
+	beacon := lanBeacon{
+		net:   activeNetParams.Net,
+		port:  lanListenPort(),
+		nonce: nonce,
+	}
+	serializedBeacon := beacon.serialize()
+	announce := func() {
+		// Nodes that are not listening can't be connected to, so there
+		// is no point in announcing them.
+		if cfg.DisableListen {
+			return
+		}
+		_, err := conn.WriteToUDP(serializedBeacon, groupAddr)
+		if err != nil {
+			srvrLog.Debugf("Unable to announce to local network: %v",
+				err)
+		}
+	}
+
+	type receivedBeacon struct {
+		beacon *lanBeacon
+		ip     net.IP
+	}
+	received := make(chan receivedBeacon)
+	go func() {
+		buf := make([]byte, lanBeaconSize+1)
+		for {
+			n, src, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				// The connection is closed on shutdown.
+				return
+			}
+			b, err := deserializeLANBeacon(buf[:n])
+			if err != nil {
+				continue
+			}
+			select {
+			case received <- receivedBeacon{beacon: b, ip: src.IP}:
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+
+	srvrLog.Infof("Local peer discovery enabled on %s", lanPeersGroupAddr)
+	announce()
+	ticker := time.NewTicker(lanPeersAnnounceInterval)
+	lastAttempts := make(map[string]time.Time)
+out:
+	for {
+		select {
+		case <-ticker.C:
+			announce()
+
+		case r := <-received:
+			// Ignore announcements made by this node, for other
+			// networks, or from nodes that will initiate the
+			// connection themselves.
+			b := r.beacon
+			if b.nonce == nonce || b.net != activeNetParams.Net ||
+				b.nonce < nonce {
+				continue
+			}
+
+			addr := net.JoinHostPort(r.ip.String(),
+				strconv.Itoa(int(b.port)))
+			if time.Since(lastAttempts[addr]) < lanPeersRetryInterval {
+				continue
+			}
+			lastAttempts[addr] = time.Now()
+
+			srvrLog.Debugf("Discovered peer %s on local network", addr)
+			select {
+			case s.query <- lanPeerMsg{addr: addr}:
+			case <-s.quit:
+				break out
+			}
+
+		case <-s.quit:
+			break out
+		}
+	}
+
+	ticker.Stop()
+	conn.Close()
+	srvrLog.Tracef("Local peer discovery handler done")
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// TestLANBeaconSerialization ensures local network announcements serialize
+// and deserialize as expected and malformed announcements are rejected.
+func TestLANBeaconSerialization(t *testing.T) {
+	beacon := lanBeacon{
+		net:   wire.SimNet,
+		port:  18555,
+		nonce: 0x0102030405060708,
+	}
+	serialized := beacon.serialize()
+	if len(serialized) != lanBeaconSize {
+		t.Fatalf("unexpected serialized size - got %d, want %d",
+			len(serialized), lanBeaconSize)
+	}
+
+	decoded, err := deserializeLANBeacon(serialized)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(*decoded, beacon) {
+		t.Fatalf("mismatched beacon - got %+v, want %+v", *decoded,
+			beacon)
+	}
+
+	// Ensure truncated announcements are rejected.
+	if _, err := deserializeLANBeacon(serialized[1:]); err == nil {
+		t.Fatal("expected error for truncated announcement")
+	}
+
+	// Ensure announcements with the wrong magic are rejected.
+	serialized[0] ^= 0xff
+	if _, err := deserializeLANBeacon(serialized); err == nil {
+		t.Fatal("expected error for announcement with bad magic")
+	}
+}
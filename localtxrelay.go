@@ -0,0 +1,191 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// localTxRelayState houses the relay state of a single locally submitted
+// transaction.
+type localTxRelayState struct {
+	tx          *dcrutil.Tx
+	added       time.Time
+	lastOffered time.Time
+	offered     map[int32]struct{}
+	requested   map[int32]struct{}
+}
+
+// localTxRelay tracks the transactions which were submitted locally via the
+// RPC server until they are included in a block.  Unlike transactions relayed
+// on behalf of other peers, which are trickled to peers in batches and only
+// announced once, locally submitted transactions are announced immediately to
+// every peer each time they are relayed as well as to every new peer once it
+// completes its handshake.  The peers the transactions were offered to and
+// requested by are recorded so the relay status may be queried via RPC.
+type localTxRelay struct {
+	mtx  sync.Mutex
+	txns map[chainhash.Hash]*localTxRelayState
+}
+
+// newLocalTxRelay returns a new empty local transaction relay tracker.
+func newLocalTxRelay() *localTxRelay {
+	return &localTxRelay{
+		txns: make(map[chainhash.Hash]*localTxRelayState),
+	}
+}
+
+// Add starts tracking the passed locally submitted transaction.  Adding a
+// transaction which is already tracked has no effect.
+//
+// This function is safe for concurrent access.
+func (r *localTxRelay) Add(tx *dcrutil.Tx) {
+	r.mtx.Lock()
+	if _, ok := r.txns[*tx.Hash()]; !ok {
+		r.txns[*tx.Hash()] = &localTxRelayState{
+			tx:        tx,
+			added:     time.Now(),
+			offered:   make(map[int32]struct{}),
+			requested: make(map[int32]struct{}),
+		}
+	}
+	r.mtx.Unlock()
+}
+
+// Remove stops tracking the transaction with the passed hash.
+//
+// This function is safe for concurrent access.
+func (r *localTxRelay) Remove(hash *chainhash.Hash) {
+	r.mtx.Lock()
+	delete(r.txns, *hash)
+	r.mtx.Unlock()
+}
+
+// IsLocal returns whether or not the transaction with the passed hash is a
+// tracked locally submitted transaction.
+//
+// This function is safe for concurrent access.
+func (r *localTxRelay) IsLocal(hash *chainhash.Hash) bool {
+	r.mtx.Lock()
+	_, ok := r.txns[*hash]
+	r.mtx.Unlock()
+	return ok
+}
+
+// Txns returns all of the tracked transactions.
+//
+// This function is safe for concurrent access.
+func (r *localTxRelay) Txns() []*dcrutil.Tx {
+	r.mtx.Lock()
+	txns := make([]*dcrutil.Tx, 0, len(r.txns))
+	for _, state := range r.txns {
+		txns = append(txns, state.tx)
+	}
+	r.mtx.Unlock()
+	return txns
+}
+
+// RecordOffered records that the transaction with the passed hash was
+// announced to the peer with the passed id.  Untracked transactions are
+// ignored.
+//
+// This function is safe for concurrent access.
+func (r *localTxRelay) RecordOffered(hash *chainhash.Hash, peerID int32) {
+	r.mtx.Lock()
+	if state, ok := r.txns[*hash]; ok {
+		state.offered[peerID] = struct{}{}
+		state.lastOffered = time.Now()
+	}
+	r.mtx.Unlock()
+}
+
+// RecordRequested records that the transaction with the passed hash was
+// requested by the peer with the passed id.  Untracked transactions are
+// ignored.
+//
+// This function is safe for concurrent access.
+func (r *localTxRelay) RecordRequested(hash *chainhash.Hash, peerID int32) {
+	r.mtx.Lock()
+	if state, ok := r.txns[*hash]; ok {
+		state.requested[peerID] = struct{}{}
+	}
+	r.mtx.Unlock()
+}
+
+// relayStatusByAdded provides sorting of relay status results from the oldest
+// to the most recently submitted transaction.
+type relayStatusByAdded []dcrjson.TxRelayStatusResult
+
+func (s relayStatusByAdded) Len() int           { return len(s) }
+func (s relayStatusByAdded) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s relayStatusByAdded) Less(i, j int) bool { return s[i].Added < s[j].Added }
+
+// RelayStatus returns the relay status of the transaction with the passed hash,
+// or of all of the tracked transactions ordered from the oldest to the most
+// recently submitted when the hash is nil.
+//
+// This function is safe for concurrent access.
+func (r *localTxRelay) RelayStatus(hash *chainhash.Hash) []dcrjson.TxRelayStatusResult {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	status := func(state *localTxRelayState) dcrjson.TxRelayStatusResult {
+		var lastOffered int64
+		if !state.lastOffered.IsZero() {
+			lastOffered = state.lastOffered.Unix()
+		}
+		return dcrjson.TxRelayStatusResult{
+			TxID:        state.tx.Hash().String(),
+			Added:       state.added.Unix(),
+			LastOffered: lastOffered,
+			Offered:     len(state.offered),
+			Requested:   len(state.requested),
+		}
+	}
+
+	if hash != nil {
+		state, ok := r.txns[*hash]
+		if !ok {
+			return nil
+		}
+		return []dcrjson.TxRelayStatusResult{status(state)}
+	}
+
+	result := make([]dcrjson.TxRelayStatusResult, 0, len(r.txns))
+	for _, state := range r.txns {
+		result = append(result, status(state))
+	}
+	sort.Sort(relayStatusByAdded(result))
+	return result
+}
+
+// pushLocalTxInv immediately sends an inventory message to the passed peer
+// which announces the passed locally submitted transactions, ignoring those
+// the peer does not want relayed, and records that they were offered to it.
+func (s *server) pushLocalTxInv(sp *serverPeer, txns []*dcrutil.Tx) {
+	invMsg := wire.NewMsgInvSizeHint(uint(len(txns)))
+	for _, tx := range txns {
+		if !sp.wantsTxRelay(tx) {
+			continue
+		}
+
+		iv := wire.NewInvVect(wire.InvTypeTx, tx.Hash())
+		if err := invMsg.AddInvVect(iv); err != nil {
+			break
+		}
+		sp.AddKnownInventory(iv)
+		s.localTxs.RecordOffered(tx.Hash(), sp.ID())
+	}
+	if len(invMsg.InvList) > 0 {
+		sp.QueueMessage(invMsg, nil)
+	}
+}
@@ -7,6 +7,7 @@ package mempool
 
 import (
 	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/wire"
 )
 
@@ -36,6 +37,17 @@ func (e RuleError) Error() string {
 type TxRuleError struct {
 	RejectCode  wire.RejectCode // The code to send with reject messages
 	Description string          // Human readable description of the issue
+	Conflicts   []Conflict      // Outpoints already spent by the pool, if any
+}
+
+// Conflict describes an outpoint which is spent by more than one transaction.
+type Conflict struct {
+	// OutPoint is the outpoint spent by the conflicting transactions.
+	OutPoint wire.OutPoint
+
+	// TxHash is the hash of the transaction which already spends the
+	// outpoint.
+	TxHash chainhash.Hash
 }
 
 // Error satisfies the error interface and prints human-readable errors.
@@ -51,6 +63,19 @@ func txRuleError(c wire.RejectCode, desc string) RuleError {
 	}
 }
 
+// txConflictError creates an underlying TxRuleError for a transaction which
+// conflicts with transactions in the pool and returns a RuleError that
+// encapsulates it.
+func txConflictError(desc string, conflicts []Conflict) RuleError {
+	return RuleError{
+		Err: TxRuleError{
+			RejectCode:  wire.RejectDuplicate,
+			Description: desc,
+			Conflicts:   conflicts,
+		},
+	}
+}
+
 // chainRuleError returns a RuleError that encapsulates the given
 // blockchain.RuleError.
 func chainRuleError(chainErr blockchain.RuleError) RuleError {
@@ -83,7 +108,17 @@ func extractRejectCode(err error) (wire.RejectCode, bool) {
 		case blockchain.ErrBlockVersionTooOld:
 			code = wire.RejectObsolete
 
-		// Rejected due to checkpoint.
+		// Rejected due to using versions which are not active yet.
+		case blockchain.ErrInactiveTxVersion:
+			fallthrough
+		case blockchain.ErrInactiveScriptVersion:
+			code = wire.RejectNonstandard
+
+		// Rejected due to checkpoint or finality.
+		case blockchain.ErrForkBeforeFinalized:
+			fallthrough
+		case blockchain.ErrReorgBeyondFinality:
+			fallthrough
 		case blockchain.ErrCheckpointTimeTooOld:
 			fallthrough
 		case blockchain.ErrDifficultyTooLow:
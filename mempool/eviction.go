@@ -0,0 +1,254 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"container/heap"
+	"math"
+	"time"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// DefaultMaxPoolSize is the default maximum size in bytes of all of the
+	// transactions in the pool combined.
+	DefaultMaxPoolSize = 300 * 1024 * 1024
+
+	// rollingMinFeeHalfLife is the time it takes the rolling minimum fee
+	// rate, which is raised whenever transactions are evicted from the
+	// full pool, to decay to half of its value.
+	rollingMinFeeHalfLife = 12 * time.Hour
+)
+
+// evictionCandidate describes a regular transaction in the pool which may be
+// evicted along with all of its descendants when the pool is full.
+type evictionCandidate struct {
+	txDesc *TxDesc
+
+	// feeRate is the fee rate in atoms/kB of the transaction or the fee
+	// rate of the transaction together with its descendants, whichever is
+	// higher, so transactions whose children pay for them are evicted
+	// last.
+	feeRate int64
+}
+
+// evictionHeap implements heap.Interface to allow eviction candidates to be
+// popped in ascending order of their fee rates, so only the candidates which
+// are actually evicted have to be ordered.
+type evictionHeap []evictionCandidate
+
+// Len returns the number of candidates in the heap.  It is part of the
+// heap.Interface implementation.
+func (h evictionHeap) Len() int { return len(h) }
+
+// Swap swaps the candidates at the passed indices.  It is part of the
+// heap.Interface implementation.
+func (h evictionHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+// Less returns whether the candidate with index i has a lower fee rate than the
+// candidate with index j.  It is part of the heap.Interface implementation.
+func (h evictionHeap) Less(i, j int) bool {
+	return h[i].feeRate < h[j].feeRate
+}
+
+// Push pushes the passed candidate onto the heap.  It is part of the
+// heap.Interface implementation.
+func (h *evictionHeap) Push(x interface{}) {
+	*h = append(*h, x.(evictionCandidate))
+}
+
+// Pop removes the candidate with the lowest fee rate from the heap and returns
+// it.  It is part of the heap.Interface implementation.
+func (h *evictionHeap) Pop() interface{} {
+	n := len(*h)
+	candidate := (*h)[n-1]
+	(*h)[n-1] = evictionCandidate{}
+	*h = (*h)[:n-1]
+	return candidate
+}
+
+// evictionPlan houses the transactions which must be evicted for the size of
+// the pool to no longer exceed the configured maximum.
+type evictionPlan struct {
+	// roots houses the candidates which are evicted along with all of
+	// their descendants in the order they are evicted.
+	roots []evictionCandidate
+
+	// evicted houses the hashes of all transactions which are evicted,
+	// including the descendants of the roots.
+	evicted map[chainhash.Hash]struct{}
+}
+
+// evicts returns whether the plan evicts the transaction with the passed hash.
+// A nil plan does not evict any transactions.
+func (p *evictionPlan) evicts(hash *chainhash.Hash) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.evicted[*hash]
+	return ok
+}
+
+// rollingMinFeeRate returns the current rolling minimum fee rate in atoms/kB,
+// which decays over time since it was last raised by evicting transactions.
+// It is zero once it decayed below half of the incremental relay fee.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) rollingMinFeeRate() dcrutil.Amount {
+	if mp.rollingFeeRate == 0 {
+		return 0
+	}
+
+	elapsed := mp.cfg.Clock.Now().Sub(mp.rollingFeeUpdated)
+	rate := float64(mp.rollingFeeRate) * math.Pow(0.5,
+		elapsed.Seconds()/rollingMinFeeHalfLife.Seconds())
+	if rate < float64(mp.cfg.Policy.IncrementalRelayFee)/2 {
+		return 0
+	}
+	return dcrutil.Amount(rate)
+}
+
+// raiseRollingMinFeeRate raises the rolling minimum fee rate above the passed
+// fee rate in atoms/kB of a transaction which was evicted from the full pool,
+// so transactions which would be evicted right away are not accepted.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) raiseRollingMinFeeRate(evictedFeeRate int64) {
+	rate := dcrutil.Amount(evictedFeeRate) +
+		mp.cfg.Policy.IncrementalRelayFee
+	if current := mp.rollingMinFeeRate(); rate < current {
+		rate = current
+	}
+	mp.rollingFeeRate = rate
+	mp.rollingFeeUpdated = mp.cfg.Clock.Now()
+}
+
+// minPoolFeeRate returns the minimum fee rate in atoms/kB regular transactions
+// must pay to be accepted, which is the minimum relay fee unless the rolling
+// minimum fee rate is higher.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) minPoolFeeRate() dcrutil.Amount {
+	rate := mp.rollingMinFeeRate()
+	if rate < mp.cfg.Policy.MinRelayTxFee {
+		return mp.cfg.Policy.MinRelayTxFee
+	}
+	return rate
+}
+
+// MinPoolFeeRate returns the minimum fee rate in atoms/kB regular transactions
+// must currently pay to be accepted.  It exceeds the minimum relay fee while
+// the pool is full or was full recently.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MinPoolFeeRate() dcrutil.Amount {
+	mp.RLock()
+	defer mp.RUnlock()
+
+	return mp.minPoolFeeRate()
+}
+
+// planEviction determines the regular transactions with the lowest fee rates
+// which must be evicted along with all of their descendants for the size of the
+// pool to no longer exceed the configured maximum without evicting them.  Stake
+// transactions are never evicted, so neither are regular transactions they
+// depend on.  It returns nil when the pool does not exceed the maximum.
+//
+// The fee rates are determined with the tracked stats of the descendants of
+// every transaction, so only the descendants of the transactions which are
+// actually evicted are walked.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) planEviction() *evictionPlan {
+	maxSize := mp.cfg.Policy.MaxPoolSize
+	if maxSize <= 0 || mp.poolSize <= maxSize {
+		return nil
+	}
+
+	candidates := make(evictionHeap, 0, len(mp.pool))
+	for _, txDesc := range mp.pool {
+		if txDesc.Type != stake.TxTypeRegular {
+			continue
+		}
+
+		size := int64(txDesc.Tx.MsgTx().SerializeSize())
+		descendants := mp.relativeStatsFor(txDesc).descendants
+		rate := feeRate(txDesc.Fee, size)
+		totalRate := feeRate(txDesc.Fee+descendants.Fees,
+			size+descendants.Size)
+		if totalRate > rate {
+			rate = totalRate
+		}
+		candidates = append(candidates, evictionCandidate{txDesc, rate})
+	}
+	heap.Init(&candidates)
+
+	plan := &evictionPlan{evicted: make(map[chainhash.Hash]struct{})}
+	poolSize := mp.poolSize
+nextCandidate:
+	for poolSize > maxSize && candidates.Len() > 0 {
+		// Skip transactions which are already evicted as the descendant
+		// of another transaction.
+		candidate := heap.Pop(&candidates).(evictionCandidate)
+		tx := candidate.txDesc.Tx
+		if plan.evicts(tx.Hash()) {
+			continue
+		}
+		descendants := mp.descendants(tx)
+		for _, descendant := range descendants {
+			if descendant.Type != stake.TxTypeRegular {
+				continue nextCandidate
+			}
+		}
+
+		plan.roots = append(plan.roots, candidate)
+		plan.evicted[*tx.Hash()] = struct{}{}
+		poolSize -= int64(tx.MsgTx().SerializeSize())
+		for _, descendant := range descendants {
+			hash := descendant.Tx.Hash()
+			if plan.evicts(hash) {
+				continue
+			}
+			plan.evicted[*hash] = struct{}{}
+			poolSize -= int64(descendant.Tx.MsgTx().SerializeSize())
+		}
+	}
+	return plan
+}
+
+// evict evicts the transactions of the passed plan from the pool and raises
+// the rolling minimum fee rate above the fee rate of every evicted transaction.
+// A nil plan does not evict any transactions.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) evict(plan *evictionPlan) {
+	if plan == nil {
+		return
+	}
+
+	for _, candidate := range plan.roots {
+		tx := candidate.txDesc.Tx
+		log.Tracef("Evicting transaction %v with a fee rate of %d "+
+			"atoms/kB from the full pool", tx.Hash(), candidate.feeRate)
+		mp.removeTransaction(tx, true)
+		mp.raiseRollingMinFeeRate(candidate.feeRate)
+	}
+	log.Debugf("Evicted %d transactions from the full pool, which raised "+
+		"the minimum fee rate to %v/kB", len(plan.evicted),
+		mp.minPoolFeeRate())
+}
+
+// limitPoolSize evicts the regular transactions with the lowest fee rates
+// along with all of their descendants until the size of the pool no longer
+// exceeds the configured maximum, and raises the rolling minimum fee rate above
+// the fee rate of every evicted transaction.  See planEviction for details.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) limitPoolSize() {
+	mp.evict(mp.planEviction())
+}
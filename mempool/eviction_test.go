@@ -0,0 +1,136 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/mining"
+	"github.com/decred/dcrutil"
+)
+
+// fixedClock provides an implementation of the blockchain.Clock interface which
+// always reports the same time.
+type fixedClock time.Time
+
+// Now returns the time of the clock.
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// TestLimitPoolSize ensures the regular transactions with the lowest fee rates
+// are evicted from the full pool unless their descendants pay for them or
+// include stake transactions, and that the rolling minimum fee rate is raised
+// above the fee rates of the evicted transactions and decays afterwards.
+func TestLimitPoolSize(t *testing.T) {
+	t.Parallel()
+
+	clock := blockchain.NewWarpClock(fixedClock(time.Unix(1500000000, 0)))
+	mp := newTestPool(clock, Policy{
+		MinRelayTxFee:       1e4,
+		IncrementalRelayFee: 1e4,
+	})
+	addTx := func(tx *dcrutil.Tx, txType stake.TxType, fee int64) {
+		txDesc := &TxDesc{
+			TxDesc: mining.TxDesc{Tx: tx, Type: txType, Fee: fee},
+		}
+		mp.pool[*tx.Hash()] = txDesc
+		for _, txIn := range tx.MsgTx().TxIn {
+			mp.outpoints.Add(&txIn.PreviousOutPoint, tx)
+		}
+		mp.poolSize += int64(tx.MsgTx().SerializeSize())
+		mp.addRelatives(txDesc)
+	}
+	txFeeRate := func(tx *dcrutil.Tx) int64 {
+		txDesc := mp.pool[*tx.Hash()]
+		return feeRate(txDesc.Fee, int64(tx.MsgTx().SerializeSize()))
+	}
+
+	// Create a transaction with the lowest fee rate of the regular
+	// transactions which may be evicted, a transaction with a low fee rate
+	// whose child pays for it, a transaction with a higher fee rate, and a
+	// transaction with an even lower fee rate which funds a ticket.
+	lowest := newTestTx(1)
+	parent := newTestTx(2)
+	child := newTestTx(3, testOutPoint(parent, 0))
+	higher := newTestTx(4)
+	ticketParent := newTestTx(5)
+	ticket := newTestTx(6, testOutPoint(ticketParent, 0))
+	addTx(lowest, stake.TxTypeRegular, 100)
+	addTx(parent, stake.TxTypeRegular, 200)
+	addTx(child, stake.TxTypeRegular, 100000)
+	addTx(higher, stake.TxTypeRegular, 300)
+	addTx(ticketParent, stake.TxTypeRegular, 50)
+	addTx(ticket, stake.TxTypeSStx, 100000)
+
+	// The pool size is not limited without a maximum size.
+	mp.limitPoolSize()
+	if len(mp.pool) != 6 || mp.rollingMinFeeRate() != 0 {
+		t.Fatalf("limitPoolSize: evicted transactions without a limit")
+	}
+	if rate := mp.MinPoolFeeRate(); rate != mp.cfg.Policy.MinRelayTxFee {
+		t.Fatalf("MinPoolFeeRate: unexpected rate - got %v, want %v",
+			rate, mp.cfg.Policy.MinRelayTxFee)
+	}
+
+	tests := []struct {
+		name    string
+		evicted *dcrutil.Tx
+		poolLen int
+	}{
+		{"lowest fee rate", lowest, 5},
+		{"higher fee rate", higher, 4},
+	}
+	for _, test := range tests {
+		wantRate := dcrutil.Amount(txFeeRate(test.evicted)) +
+			mp.cfg.Policy.IncrementalRelayFee
+		mp.cfg.Policy.MaxPoolSize = mp.poolSize - 1
+		numTxns := len(mp.pool)
+		plan := mp.planEviction()
+		if !plan.evicts(test.evicted.Hash()) || len(mp.pool) != numTxns {
+			t.Fatalf("%s: planEviction does not evict transaction or "+
+				"modified the pool", test.name)
+		}
+		mp.limitPoolSize()
+		if mp.isTransactionInPool(test.evicted.Hash()) {
+			t.Fatalf("%s: transaction not evicted", test.name)
+		}
+		if len(mp.pool) != test.poolLen {
+			t.Fatalf("%s: unexpected number of transactions in the "+
+				"pool - got %d, want %d", test.name, len(mp.pool),
+				test.poolLen)
+		}
+		if rate := mp.MinPoolFeeRate(); rate != wantRate {
+			t.Fatalf("%s: unexpected minimum fee rate - got %v, want "+
+				"%v", test.name, rate, wantRate)
+		}
+	}
+	for _, tx := range []*dcrutil.Tx{parent, child, ticketParent, ticket} {
+		if !mp.isTransactionInPool(tx.Hash()) {
+			t.Fatalf("limitPoolSize: transaction %v evicted", tx.Hash())
+		}
+	}
+
+	// Ensure the rolling minimum fee rate halves every half life and is
+	// reset once it decays below half of the incremental relay fee.
+	wantRate := mp.rollingMinFeeRate() / 2
+	clock.Warp(rollingMinFeeHalfLife)
+	if rate := mp.rollingMinFeeRate(); rate != wantRate {
+		t.Fatalf("rollingMinFeeRate: unexpected rate after one half life "+
+			"- got %v, want %v", rate, wantRate)
+	}
+	clock.Warp(rollingMinFeeHalfLife)
+	if rate := mp.rollingMinFeeRate(); rate != 0 {
+		t.Fatalf("rollingMinFeeRate: unexpected rate after two half "+
+			"lives - got %v, want 0", rate)
+	}
+	if rate := mp.MinPoolFeeRate(); rate != mp.cfg.Policy.MinRelayTxFee {
+		t.Fatalf("MinPoolFeeRate: unexpected rate - got %v, want %v",
+			rate, mp.cfg.Policy.MinRelayTxFee)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// txParents returns the descriptors of the transactions in the pool whose
+// outputs are spent by the passed transaction.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txParents(tx *dcrutil.Tx) []*TxDesc {
+	var parents []*TxDesc
+	for _, txIn := range tx.MsgTx().TxIn {
+		parent, exists := mp.pool[txIn.PreviousOutPoint.Hash]
+		if !exists || containsTxDesc(parents, parent) {
+			continue
+		}
+		parents = append(parents, parent)
+	}
+	return parents
+}
+
+// txChildren returns the descriptors of the transactions in the pool which
+// spend outputs of the passed transaction.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txChildren(tx *dcrutil.Tx) []*TxDesc {
+	msgTx := tx.MsgTx()
+	tree := wire.TxTreeRegular
+	if stake.DetermineTxType(msgTx) != stake.TxTypeRegular {
+		tree = wire.TxTreeStake
+	}
+
+	var children []*TxDesc
+	for i := uint32(0); i < uint32(len(msgTx.TxOut)); i++ {
+		outpoint := wire.NewOutPoint(tx.Hash(), i, tree)
+		spender, exists := mp.outpoints.Spender(outpoint)
+		if !exists {
+			continue
+		}
+		child, exists := mp.pool[*spender.Hash()]
+		if !exists || containsTxDesc(children, child) {
+			continue
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+// txDependencies returns the hashes of the transactions in the pool which the
+// passed transaction spends and of those which spend it as strings.  Both are
+// empty rather than nil when there are no such transactions.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txDependencies(tx *dcrutil.Tx) (depends, spentBy []string) {
+	depends = make([]string, 0)
+	for _, parent := range mp.txParents(tx) {
+		depends = append(depends, parent.Tx.Hash().String())
+	}
+	spentBy = make([]string, 0)
+	for _, child := range mp.txChildren(tx) {
+		spentBy = append(spentBy, child.Tx.Hash().String())
+	}
+	return depends, spentBy
+}
+
+// containsTxDesc returns whether or not the passed descriptor is in the passed
+// slice.  Transactions rarely spend more than a few transactions of the pool,
+// so a linear search is cheaper than a map.
+func containsTxDesc(descs []*TxDesc, desc *TxDesc) bool {
+	for _, d := range descs {
+		if d == desc {
+			return true
+		}
+	}
+	return false
+}
+
+// ancestors returns the descriptors of all transactions in the pool which the
+// passed transaction depends on, either directly or through other transactions
+// in the pool, ordered so that every transaction follows all of its ancestors.
+// The passed transaction itself is not included.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) ancestors(tx *dcrutil.Tx) []*TxDesc {
+	// Visit the parents depth first, adding each transaction after all of
+	// the transactions it spends.
+	var ancestors []*TxDesc
+	visited := map[chainhash.Hash]struct{}{*tx.Hash(): {}}
+	var visit func(tx *dcrutil.Tx)
+	visit = func(tx *dcrutil.Tx) {
+		for _, parent := range mp.txParents(tx) {
+			if _, ok := visited[*parent.Tx.Hash()]; ok {
+				continue
+			}
+			visited[*parent.Tx.Hash()] = struct{}{}
+			visit(parent.Tx)
+			ancestors = append(ancestors, parent)
+		}
+	}
+	visit(tx)
+	return ancestors
+}
+
+// descendants returns the descriptors of all transactions in the pool which
+// depend on the passed transaction, either directly or through other
+// transactions in the pool, ordered so that every transaction follows all of
+// its ancestors among them.  The passed transaction itself is not included.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) descendants(tx *dcrutil.Tx) []*TxDesc {
+	// Visit the children depth first, adding each transaction after all of
+	// the transactions which spend it, and reverse the result afterwards.
+	var descendants []*TxDesc
+	visited := map[chainhash.Hash]struct{}{*tx.Hash(): {}}
+	var visit func(tx *dcrutil.Tx)
+	visit = func(tx *dcrutil.Tx) {
+		for _, child := range mp.txChildren(tx) {
+			if _, ok := visited[*child.Tx.Hash()]; ok {
+				continue
+			}
+			visited[*child.Tx.Hash()] = struct{}{}
+			visit(child.Tx)
+			descendants = append(descendants, child)
+		}
+	}
+	visit(tx)
+	for i, j := 0, len(descendants)-1; i < j; i, j = i+1, j-1 {
+		descendants[i], descendants[j] = descendants[j], descendants[i]
+	}
+	return descendants
+}
+
+// Ancestors returns the descriptors of all transactions in the pool which the
+// transaction with the passed hash depends on, either directly or through other
+// transactions in the pool, ordered so that every transaction follows all of
+// its ancestors.  An error is returned when the transaction is not in the
+// pool.  The descriptors are to be treated as read only.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Ancestors(hash *chainhash.Hash) ([]*TxDesc, error) {
+	mp.RLock()
+	defer mp.RUnlock()
+
+	txDesc, exists := mp.pool[*hash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool", hash)
+	}
+	return mp.ancestors(txDesc.Tx), nil
+}
+
+// Descendants returns the descriptors of all transactions in the pool which
+// depend on the transaction with the passed hash, either directly or through
+// other transactions in the pool, ordered so that every transaction follows
+// all of its ancestors among them.  An error is returned when the transaction
+// is not in the pool.  The descriptors are to be treated as read only.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Descendants(hash *chainhash.Hash) ([]*TxDesc, error) {
+	mp.RLock()
+	defer mp.RUnlock()
+
+	txDesc, exists := mp.pool[*hash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool", hash)
+	}
+	return mp.descendants(txDesc.Tx), nil
+}
+
+// DependencyGraph returns the transaction with the passed hash along with all
+// of its ancestors and descendants in the pool as fully populated JSON results,
+// ordered so that every transaction follows all of its ancestors among them.
+// Every entry lists the transactions of the pool it spends and which spend it,
+// which may include transactions outside of the graph since the other
+// ancestors of the descendants are not part of it.  An error is returned when
+// the transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) DependencyGraph(hash *chainhash.Hash) ([]dcrjson.MempoolGraphEntry, error) {
+	mp.RLock()
+	defer mp.RUnlock()
+
+	txDesc, exists := mp.pool[*hash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool", hash)
+	}
+
+	descs := mp.ancestors(txDesc.Tx)
+	descs = append(descs, txDesc)
+	descs = append(descs, mp.descendants(txDesc.Tx)...)
+	entries := make([]dcrjson.MempoolGraphEntry, 0, len(descs))
+	for _, desc := range descs {
+		depends, spentBy := mp.txDependencies(desc.Tx)
+		entry := dcrjson.MempoolGraphEntry{
+			TxID:    desc.Tx.Hash().String(),
+			Size:    int32(desc.Tx.MsgTx().SerializeSize()),
+			Fee:     dcrutil.Amount(desc.Fee).ToCoin(),
+			Time:    desc.Added.Unix(),
+			Height:  desc.Height,
+			Depends: depends,
+			SpentBy: spentBy,
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
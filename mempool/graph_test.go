@@ -0,0 +1,99 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/mining"
+	"github.com/decred/dcrutil"
+)
+
+// TestDependencyGraph ensures the ancestors and descendants of a transaction
+// are found through the pool, ordered so that every transaction follows its
+// ancestors, and the dependency graph lists the edges of every transaction.
+func TestDependencyGraph(t *testing.T) {
+	t.Parallel()
+
+	mp := newTestPool(nil, Policy{})
+	addTx := func(tx *dcrutil.Tx) {
+		mp.pool[*tx.Hash()] = &TxDesc{
+			TxDesc: mining.TxDesc{Tx: tx, Type: stake.TxTypeRegular},
+		}
+		for _, txIn := range tx.MsgTx().TxIn {
+			mp.outpoints.Add(&txIn.PreviousOutPoint, tx)
+		}
+	}
+
+	// Create a diamond of transactions where the final transaction spends
+	// two transactions which both spend the same parent, along with a
+	// transaction which spends the final one.
+	parent := newTestTx(1)
+	left := newTestTx(2, testOutPoint(parent, 0))
+	right := newTestTx(3, testOutPoint(parent, 0))
+	child := newTestTx(4, testOutPoint(left, 0), testOutPoint(right, 0))
+	grandchild := newTestTx(5, testOutPoint(child, 0))
+	for _, tx := range []*dcrutil.Tx{parent, left, right, child, grandchild} {
+		addTx(tx)
+	}
+
+	// indexOf returns the position of the transaction in the passed
+	// descriptors or -1 when it is not one of them.
+	indexOf := func(descs []*TxDesc, tx *dcrutil.Tx) int {
+		for i, desc := range descs {
+			if desc.Tx == tx {
+				return i
+			}
+		}
+		return -1
+	}
+
+	ancestors, err := mp.Ancestors(child.Hash())
+	if err != nil {
+		t.Fatalf("Ancestors: unexpected error: %v", err)
+	}
+	if len(ancestors) != 3 || indexOf(ancestors, parent) != 0 ||
+		indexOf(ancestors, left) == -1 || indexOf(ancestors, right) == -1 {
+		t.Fatalf("Ancestors: unexpected ancestors %v", ancestors)
+	}
+
+	descendants, err := mp.Descendants(parent.Hash())
+	if err != nil {
+		t.Fatalf("Descendants: unexpected error: %v", err)
+	}
+	if len(descendants) != 4 || indexOf(descendants, child) != 2 ||
+		indexOf(descendants, grandchild) != 3 {
+		t.Fatalf("Descendants: unexpected descendants %v", descendants)
+	}
+
+	graph, err := mp.DependencyGraph(left.Hash())
+	if err != nil {
+		t.Fatalf("DependencyGraph: unexpected error: %v", err)
+	}
+	wantTxIDs := []string{parent.Hash().String(), left.Hash().String(),
+		child.Hash().String(), grandchild.Hash().String()}
+	if len(graph) != len(wantTxIDs) {
+		t.Fatalf("DependencyGraph: unexpected number of entries -- got "+
+			"%d, want %d", len(graph), len(wantTxIDs))
+	}
+	for i, entry := range graph {
+		if entry.TxID != wantTxIDs[i] {
+			t.Fatalf("DependencyGraph: unexpected entry %d -- got %v, "+
+				"want %v", i, entry.TxID, wantTxIDs[i])
+		}
+	}
+	if len(graph[0].SpentBy) != 2 || len(graph[2].Depends) != 2 {
+		t.Fatalf("DependencyGraph: unexpected edges %v", graph)
+	}
+
+	if _, err := mp.Ancestors(&chainhash.Hash{0x02}); err == nil {
+		t.Fatal("Ancestors: no error for transaction not in the pool")
+	}
+	if _, err := mp.Descendants(&chainhash.Hash{0x02}); err == nil {
+		t.Fatal("Descendants: no error for transaction not in the pool")
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// numIndexShards is the number of shards the outpoint and orphan indexes are
+// split into.  Each shard is protected by its own lock, so queries which touch
+// different shards do not contend with each other or with the main pool lock.
+const numIndexShards = 32
+
+// shardForHash returns the shard for the passed hash.  Transaction hashes are
+// uniformly distributed, so the first byte is enough to spread the entries
+// evenly across the shards.
+func shardForHash(hash *chainhash.Hash) int {
+	return int(hash[0]) % numIndexShards
+}
+
+// outPointShard is a shard of the outpoint index.
+type outPointShard struct {
+	sync.RWMutex
+	spenders map[wire.OutPoint]*dcrutil.Tx
+}
+
+// outPointIndex maps the outpoints spent by transactions in the main pool to
+// the transactions which spend them.  It is split into shards by the hash of
+// the outpoint.
+//
+// The index is modified with the mempool lock held (for writes), so it is
+// always consistent with the main pool for code which holds the mempool lock.
+// The shard locks additionally make it safe to query the index concurrently
+// without the mempool lock.
+type outPointIndex struct {
+	shards [numIndexShards]outPointShard
+}
+
+// newOutPointIndex returns a new empty outpoint index.
+func newOutPointIndex() *outPointIndex {
+	idx := new(outPointIndex)
+	for i := range idx.shards {
+		idx.shards[i].spenders = make(map[wire.OutPoint]*dcrutil.Tx)
+	}
+	return idx
+}
+
+// shard returns the shard of the index for the passed outpoint.
+func (idx *outPointIndex) shard(outPoint *wire.OutPoint) *outPointShard {
+	return &idx.shards[shardForHash(&outPoint.Hash)]
+}
+
+// Spender returns the transaction which spends the passed outpoint and whether
+// or not the outpoint is spent.
+//
+// This function is safe for concurrent access.
+func (idx *outPointIndex) Spender(outPoint *wire.OutPoint) (*dcrutil.Tx, bool) {
+	shard := idx.shard(outPoint)
+	shard.RLock()
+	tx, exists := shard.spenders[*outPoint]
+	shard.RUnlock()
+	return tx, exists
+}
+
+// Add records that the passed outpoint is spent by the passed transaction.
+//
+// This function is safe for concurrent access.
+func (idx *outPointIndex) Add(outPoint *wire.OutPoint, tx *dcrutil.Tx) {
+	shard := idx.shard(outPoint)
+	shard.Lock()
+	shard.spenders[*outPoint] = tx
+	shard.Unlock()
+}
+
+// Remove removes the passed outpoint from the index.
+//
+// This function is safe for concurrent access.
+func (idx *outPointIndex) Remove(outPoint *wire.OutPoint) {
+	shard := idx.shard(outPoint)
+	shard.Lock()
+	delete(shard.spenders, *outPoint)
+	shard.Unlock()
+}
+
+// orphanShard is a shard of the orphan index.  The orphans are split into
+// shards by their hashes while the orphans which spend the outputs of a
+// transaction are split into shards by the hash of that transaction.
+type orphanShard struct {
+	sync.RWMutex
+	orphans       map[chainhash.Hash]*dcrutil.Tx
+	orphansByPrev map[chainhash.Hash]map[chainhash.Hash]*dcrutil.Tx
+}
+
+// orphanIndex houses the orphan transactions along with an index of the
+// orphans by the transactions whose outputs they spend.  It is split into
+// shards.
+//
+// The index is modified with the mempool lock held (for writes), so it is
+// always consistent for code which holds the mempool lock.  The shard locks
+// additionally make it safe to query the index concurrently without the
+// mempool lock.  Since adding or removing an orphan touches several shards,
+// such queries may observe an orphan which is only partially added or removed.
+type orphanIndex struct {
+	count  int64 // must only be used atomically
+	shards [numIndexShards]orphanShard
+}
+
+// newOrphanIndex returns a new empty orphan index.
+func newOrphanIndex() *orphanIndex {
+	idx := new(orphanIndex)
+	for i := range idx.shards {
+		shard := &idx.shards[i]
+		shard.orphans = make(map[chainhash.Hash]*dcrutil.Tx)
+		shard.orphansByPrev = make(map[chainhash.Hash]map[chainhash.Hash]*dcrutil.Tx)
+	}
+	return idx
+}
+
+// Count returns the number of orphans in the index.
+//
+// This function is safe for concurrent access.
+func (idx *orphanIndex) Count() int {
+	return int(atomic.LoadInt64(&idx.count))
+}
+
+// Orphan returns the orphan with the passed hash and whether or not it exists.
+//
+// This function is safe for concurrent access.
+func (idx *orphanIndex) Orphan(hash *chainhash.Hash) (*dcrutil.Tx, bool) {
+	shard := &idx.shards[shardForHash(hash)]
+	shard.RLock()
+	tx, exists := shard.orphans[*hash]
+	shard.RUnlock()
+	return tx, exists
+}
+
+// OrphansByPrev returns the orphans which spend outputs of the transaction with
+// the passed hash.
+//
+// This function is safe for concurrent access.
+func (idx *orphanIndex) OrphansByPrev(hash *chainhash.Hash) []*dcrutil.Tx {
+	shard := &idx.shards[shardForHash(hash)]
+	shard.RLock()
+	orphans := make([]*dcrutil.Tx, 0, len(shard.orphansByPrev[*hash]))
+	for _, tx := range shard.orphansByPrev[*hash] {
+		orphans = append(orphans, tx)
+	}
+	shard.RUnlock()
+	return orphans
+}
+
+// ForEach calls the passed function with the hash of each orphan until it
+// returns false.  The function must not modify the index.
+//
+// This function is safe for concurrent access.
+func (idx *orphanIndex) ForEach(f func(hash *chainhash.Hash) bool) {
+	for i := range idx.shards {
+		shard := &idx.shards[i]
+		shard.RLock()
+		for hash := range shard.orphans {
+			hash := hash
+			if !f(&hash) {
+				shard.RUnlock()
+				return
+			}
+		}
+		shard.RUnlock()
+	}
+}
+
+// Add adds the passed orphan to the index.
+//
+// This function is safe for concurrent access.
+func (idx *orphanIndex) Add(tx *dcrutil.Tx) {
+	txHash := tx.Hash()
+	shard := &idx.shards[shardForHash(txHash)]
+	shard.Lock()
+	if _, exists := shard.orphans[*txHash]; exists {
+		shard.Unlock()
+		return
+	}
+	shard.orphans[*txHash] = tx
+	shard.Unlock()
+	atomic.AddInt64(&idx.count, 1)
+
+	for _, txIn := range tx.MsgTx().TxIn {
+		originTxHash := &txIn.PreviousOutPoint.Hash
+		shard := &idx.shards[shardForHash(originTxHash)]
+		shard.Lock()
+		orphans, exists := shard.orphansByPrev[*originTxHash]
+		if !exists {
+			orphans = make(map[chainhash.Hash]*dcrutil.Tx)
+			shard.orphansByPrev[*originTxHash] = orphans
+		}
+		orphans[*txHash] = tx
+		shard.Unlock()
+	}
+}
+
+// Remove removes the orphan with the passed hash from the index and returns
+// whether or not it existed.
+//
+// This function is safe for concurrent access.
+func (idx *orphanIndex) Remove(txHash *chainhash.Hash) bool {
+	shard := &idx.shards[shardForHash(txHash)]
+	shard.Lock()
+	tx, exists := shard.orphans[*txHash]
+	if !exists {
+		shard.Unlock()
+		return false
+	}
+	delete(shard.orphans, *txHash)
+	shard.Unlock()
+	atomic.AddInt64(&idx.count, -1)
+
+	// Remove the reference from the previous orphan index.
+	for _, txIn := range tx.MsgTx().TxIn {
+		originTxHash := &txIn.PreviousOutPoint.Hash
+		shard := &idx.shards[shardForHash(originTxHash)]
+		shard.Lock()
+		if orphans, exists := shard.orphansByPrev[*originTxHash]; exists {
+			delete(orphans, *txHash)
+
+			// Remove the map entry altogether if there are no
+			// longer any orphans which depend on it.
+			if len(orphans) == 0 {
+				delete(shard.orphansByPrev, *originTxHash)
+			}
+		}
+		shard.Unlock()
+	}
+	return true
+}
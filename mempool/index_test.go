@@ -0,0 +1,127 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// TestOutPointIndex ensures the outpoint index tracks the spenders of outpoints
+// as expected, including under concurrent access.
+func TestOutPointIndex(t *testing.T) {
+	t.Parallel()
+
+	idx := newOutPointIndex()
+	tx := dcrutil.NewTx(wire.NewMsgTx())
+	var outPoints []wire.OutPoint
+	for i := 0; i < 256; i++ {
+		outPoints = append(outPoints, wire.OutPoint{
+			Hash:  chainhash.Hash{byte(i)},
+			Index: uint32(i),
+		})
+	}
+
+	var wg sync.WaitGroup
+	for i := range outPoints {
+		wg.Add(1)
+		go func(outPoint *wire.OutPoint) {
+			defer wg.Done()
+			idx.Add(outPoint, tx)
+			idx.Spender(outPoint)
+		}(&outPoints[i])
+	}
+	wg.Wait()
+
+	for i := range outPoints {
+		spender, exists := idx.Spender(&outPoints[i])
+		if !exists || spender != tx {
+			t.Fatalf("Spender: missing spender for outpoint %v",
+				outPoints[i])
+		}
+	}
+
+	idx.Remove(&outPoints[0])
+	if _, exists := idx.Spender(&outPoints[0]); exists {
+		t.Fatalf("Spender: outpoint %v still spent after removal",
+			outPoints[0])
+	}
+}
+
+// TestOrphanIndex ensures the orphan index tracks orphans and the orphans which
+// depend on other transactions as expected, including under concurrent access.
+func TestOrphanIndex(t *testing.T) {
+	t.Parallel()
+
+	idx := newOrphanIndex()
+	parentHash := chainhash.Hash{0xff}
+	var orphans []*dcrutil.Tx
+	for i := 0; i < 64; i++ {
+		msgTx := wire.NewMsgTx()
+		msgTx.LockTime = uint32(i)
+		prevOut := wire.NewOutPoint(&parentHash, uint32(i),
+			wire.TxTreeRegular)
+		msgTx.AddTxIn(wire.NewTxIn(prevOut, nil))
+		orphans = append(orphans, dcrutil.NewTx(msgTx))
+	}
+
+	var wg sync.WaitGroup
+	for _, tx := range orphans {
+		wg.Add(1)
+		go func(tx *dcrutil.Tx) {
+			defer wg.Done()
+			idx.Add(tx)
+			idx.Orphan(tx.Hash())
+			idx.OrphansByPrev(&parentHash)
+		}(tx)
+	}
+	wg.Wait()
+
+	// Adding an orphan which already exists must not change the count.
+	idx.Add(orphans[0])
+	if idx.Count() != len(orphans) {
+		t.Fatalf("Count: got %d, want %d", idx.Count(), len(orphans))
+	}
+	for _, tx := range orphans {
+		if orphan, exists := idx.Orphan(tx.Hash()); !exists || orphan != tx {
+			t.Fatalf("Orphan: missing orphan %v", tx.Hash())
+		}
+	}
+	if n := len(idx.OrphansByPrev(&parentHash)); n != len(orphans) {
+		t.Fatalf("OrphansByPrev: got %d orphans, want %d", n,
+			len(orphans))
+	}
+	var numIterated int
+	idx.ForEach(func(*chainhash.Hash) bool {
+		numIterated++
+		return true
+	})
+	if numIterated != len(orphans) {
+		t.Fatalf("ForEach: iterated %d orphans, want %d", numIterated,
+			len(orphans))
+	}
+
+	// Remove all of the orphans and ensure the previous orphan index no
+	// longer references them.
+	for _, tx := range orphans {
+		if !idx.Remove(tx.Hash()) {
+			t.Fatalf("Remove: orphan %v did not exist", tx.Hash())
+		}
+	}
+	if idx.Remove(orphans[0].Hash()) {
+		t.Fatal("Remove: removed orphan which does not exist")
+	}
+	if idx.Count() != 0 {
+		t.Fatalf("Count: got %d after removing all orphans", idx.Count())
+	}
+	shard := &idx.shards[shardForHash(&parentHash)]
+	if _, exists := shard.orphansByPrev[parentHash]; exists {
+		t.Fatal("Remove: previous orphan index entry not removed")
+	}
+}
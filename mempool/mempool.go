@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -112,6 +113,14 @@ type Config struct {
 	// TimeSource defines the timesource to use.
 	TimeSource blockchain.MedianTimeSource
 
+	// Clock defines the local clock to use for recording when transactions
+	// are added and rejected, expiring rejected transactions, and rate
+	// limiting free transactions.  It should be the same clock the time
+	// source uses as its local clock.
+	//
+	// This field can be nil in which case the system clock will be used.
+	Clock blockchain.Clock
+
 	// AddrIndex defines the optional address index instance to use for
 	// indexing the unconfirmed transactions in the memory pool.
 	// This can be nil if the address index is not enabled.
@@ -121,6 +130,24 @@ type Config struct {
 	// to use for indexing the unconfirmed transactions in the memory pool.
 	// This can be nil if the address index is not enabled.
 	ExistsAddrIndex *indexers.ExistsAddrIndex
+
+	// AddTxToFeeEstimation defines an optional function to be called
+	// whenever a transaction is added to the main pool with its fee in
+	// atoms and serialized size, which is used to track the confirmation
+	// times of transactions for fee estimation.
+	AddTxToFeeEstimation func(txHash *chainhash.Hash, txType stake.TxType, fee, size int64)
+
+	// RemoveTxFromFeeEstimation defines an optional function to be called
+	// whenever a transaction is removed from the main pool.
+	RemoveTxFromFeeEstimation func(txHash *chainhash.Hash)
+
+	// TxReplaced defines an optional function to be called whenever a
+	// transaction is removed from the main pool because it was replaced by
+	// a transaction which spends the same outputs and pays higher fees.
+	// The transactions which depend on the replaced transaction are removed
+	// as well without being reported.  It is called with the mempool lock
+	// held, so it must not call back into the mempool.
+	TxReplaced func(removal *ConflictRemoval)
 }
 
 // Policy houses the policy (configuration parameters) which is used to
@@ -155,6 +182,30 @@ type Policy struct {
 	// AllowOldVotes defines whether or not votes on old blocks will be
 	// admitted and relayed.
 	AllowOldVotes bool
+
+	// AcceptReplacement defines whether or not regular transactions which
+	// spend outputs already spent by transactions in the pool that signal
+	// replaceability are accepted as their replacements.
+	AcceptReplacement bool
+
+	// IncrementalRelayFee defines the fee rate in atoms/kB by which a
+	// replacement transaction must pay more than the fees of all of the
+	// transactions it replaces combined.  It is also the amount by which
+	// the minimum fee rate is raised above the fee rate of transactions
+	// evicted from the full pool.
+	IncrementalRelayFee dcrutil.Amount
+
+	// MaxReplacedTxs defines the maximum number of transactions which may
+	// be removed from the pool by a replacement, including all of the
+	// transactions which depend on the replaced transactions.
+	MaxReplacedTxs int
+
+	// MaxPoolSize defines the maximum size in bytes of all transactions in
+	// the pool combined.  Once it is exceeded, the regular transactions
+	// with the lowest fee rates are evicted and the minimum fee rate for
+	// accepting regular transactions is raised until the pool has not been
+	// full for a while.  The pool size is not limited when it is zero.
+	MaxPoolSize int64
 }
 
 // TxDesc is a descriptor containing a transaction in the mempool along with
@@ -175,22 +226,50 @@ type TxPool struct {
 	lastUpdated int64 // last time pool was updated.
 
 	sync.RWMutex
-	cfg           Config
-	pool          map[chainhash.Hash]*TxDesc
-	orphans       map[chainhash.Hash]*dcrutil.Tx
-	orphansByPrev map[chainhash.Hash]map[chainhash.Hash]*dcrutil.Tx
-	addrindex     map[string]map[chainhash.Hash]struct{} // maps address to txs
-	outpoints     map[wire.OutPoint]*dcrutil.Tx
+	cfg       Config
+	pool      map[chainhash.Hash]*TxDesc
+	poolSize  int64                                  // serialized size of all txs
+	addrindex map[string]map[chainhash.Hash]struct{} // maps address to txs
+
+	// The orphan and outpoint indexes are sharded with their own locks so
+	// they may be queried without the mempool lock.  They are only
+	// modified with the mempool lock held (for writes).  The shard locks
+	// are always acquired after the mempool lock, so the mempool lock must
+	// never be acquired while a shard lock is held.
+	orphans   *orphanIndex
+	outpoints *outPointIndex
+
+	// The stats of the ancestors and descendants of every transaction in
+	// the pool are tracked as transactions are added and removed.
+	relatives map[chainhash.Hash]*relativeStats
 
 	// Votes on blocks.
 	votesMtx sync.Mutex
 	votes    map[chainhash.Hash][]*VoteTx
 
+	// Recently rejected transactions.
+	rejectedMtx sync.Mutex
+	rejected    map[chainhash.Hash]*RejectedTx
+
 	// A declared subsidy cache as passed from the blockchain.
 	subsidyCache *blockchain.SubsidyCache
 
+	// validationStats accumulates the time each stage of accepting
+	// transactions takes.
+	validationStats blockchain.ValidationStats
+
 	pennyTotal    float64 // exponentially decaying total for penny spends.
 	lastPennyUnix int64   // unix time of last ``penny spend''
+
+	// The rolling minimum fee rate is raised whenever transactions are
+	// evicted from the full pool and decays from the time it was raised.
+	rollingFeeRate    dcrutil.Amount
+	rollingFeeUpdated time.Time
+
+	// acceptPackageTx accepts the transactions of packages in place of
+	// maybeAcceptTransaction when it is set.  It is only set by tests which
+	// exercise accepting packages without a chain to validate them against.
+	acceptPackageTx func(tx *dcrutil.Tx, rateLimit, allowHighFees bool) ([]*chainhash.Hash, error)
 }
 
 // insertVote inserts a vote into the map of block votes.
@@ -287,28 +366,9 @@ var _ mining.TxSource = (*TxPool)(nil)
 func (mp *TxPool) removeOrphan(txHash *chainhash.Hash) {
 	log.Tracef("Removing orphan transaction %v", txHash)
 
-	// Nothing to do if passed tx is not an orphan.
-	tx, exists := mp.orphans[*txHash]
-	if !exists {
-		return
-	}
-
-	// Remove the reference from the previous orphan index.
-	for _, txIn := range tx.MsgTx().TxIn {
-		originTxHash := txIn.PreviousOutPoint.Hash
-		if orphans, exists := mp.orphansByPrev[originTxHash]; exists {
-			delete(orphans, *tx.Hash())
-
-			// Remove the map entry altogether if there are no
-			// longer any orphans which depend on it.
-			if len(orphans) == 0 {
-				delete(mp.orphansByPrev, originTxHash)
-			}
-		}
-	}
-
-	// Remove the transaction from the orphan pool.
-	delete(mp.orphans, *txHash)
+	// Remove the transaction from the orphan pool and previous orphan
+	// index.  Nothing is done if the passed tx is not an orphan.
+	mp.orphans.Remove(txHash)
 }
 
 // RemoveOrphan removes the passed orphan transaction from the orphan pool and
@@ -326,7 +386,7 @@ func (mp *TxPool) RemoveOrphan(txHash *chainhash.Hash) {
 //
 // This function MUST be called with the mempool lock held (for writes).
 func (mp *TxPool) limitNumOrphans() error {
-	if len(mp.orphans)+1 > mp.cfg.Policy.MaxOrphanTxs &&
+	if mp.orphans.Count()+1 > mp.cfg.Policy.MaxOrphanTxs &&
 		mp.cfg.Policy.MaxOrphanTxs > 0 {
 
 		// Generate a cryptographically random hash.
@@ -343,18 +403,21 @@ func (mp *TxPool) limitNumOrphans() error {
 		// the hashes in the orphan pool are larger than the random
 		// hash.
 		var foundHash *chainhash.Hash
-		for txHash := range mp.orphans {
+		mp.orphans.ForEach(func(txHash *chainhash.Hash) bool {
 			if foundHash == nil {
-				foundHash = &txHash
+				foundHash = txHash
 			}
-			txHashNum := blockchain.HashToBig(&txHash)
+			txHashNum := blockchain.HashToBig(txHash)
 			if txHashNum.Cmp(randHashNum) > 0 {
-				foundHash = &txHash
-				break
+				foundHash = txHash
+				return false
 			}
-		}
+			return true
+		})
 
-		mp.removeOrphan(foundHash)
+		if foundHash != nil {
+			mp.removeOrphan(foundHash)
+		}
 	}
 
 	return nil
@@ -368,18 +431,10 @@ func (mp *TxPool) addOrphan(tx *dcrutil.Tx) {
 	// random orphan is evicted to make room if needed.
 	mp.limitNumOrphans()
 
-	mp.orphans[*tx.Hash()] = tx
-	for _, txIn := range tx.MsgTx().TxIn {
-		originTxHash := txIn.PreviousOutPoint.Hash
-		if _, exists := mp.orphansByPrev[originTxHash]; !exists {
-			mp.orphansByPrev[originTxHash] =
-				make(map[chainhash.Hash]*dcrutil.Tx)
-		}
-		mp.orphansByPrev[originTxHash][*tx.Hash()] = tx
-	}
+	mp.orphans.Add(tx)
 
 	log.Debugf("Stored orphan transaction %v (total: %d)", tx.Hash(),
-		len(mp.orphans))
+		mp.orphans.Count())
 }
 
 // maybeAddOrphan potentially adds an orphan to the orphan pool.
@@ -437,13 +492,11 @@ func (mp *TxPool) IsTransactionInPool(hash *chainhash.Hash) bool {
 // isOrphanInPool returns whether or not the passed transaction already exists
 // in the orphan pool.
 //
-// This function MUST be called with the mempool lock held (for reads).
+// This function is safe for concurrent access since the orphan index has its
+// own locks.
 func (mp *TxPool) isOrphanInPool(hash *chainhash.Hash) bool {
-	if _, exists := mp.orphans[*hash]; exists {
-		return true
-	}
-
-	return false
+	_, exists := mp.orphans.Orphan(hash)
+	return exists
 }
 
 // IsOrphanInPool returns whether or not the passed transaction already exists
@@ -451,10 +504,6 @@ func (mp *TxPool) isOrphanInPool(hash *chainhash.Hash) bool {
 //
 // This function is safe for concurrent access.
 func (mp *TxPool) IsOrphanInPool(hash *chainhash.Hash) bool {
-	// Protect concurrent access.
-	mp.RLock()
-	defer mp.RUnlock()
-
 	return mp.isOrphanInPool(hash)
 }
 
@@ -471,11 +520,26 @@ func (mp *TxPool) haveTransaction(hash *chainhash.Hash) bool {
 //
 // This function is safe for concurrent access.
 func (mp *TxPool) HaveTransaction(hash *chainhash.Hash) bool {
+	// The orphan pool does not require the mempool lock, so check it first
+	// to avoid waiting on the lock when possible.
+	if mp.isOrphanInPool(hash) {
+		return true
+	}
+
 	// Protect concurrent access.
 	mp.RLock()
 	defer mp.RUnlock()
 
-	return mp.haveTransaction(hash)
+	return mp.isTransactionInPool(hash)
+}
+
+// FetchSpender returns the transaction in the main pool which spends the passed
+// outpoint, or nil when it is not spent by any transaction in the main pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) FetchSpender(outPoint *wire.OutPoint) *dcrutil.Tx {
+	spender, _ := mp.outpoints.Spender(outPoint)
+	return spender
 }
 
 // haveTransactions returns whether or not the passed transactions already exist
@@ -521,7 +585,7 @@ func (mp *TxPool) removeTransaction(tx *dcrutil.Tx, removeRedeemers bool) {
 		}
 		for i := uint32(0); i < uint32(len(msgTx.TxOut)); i++ {
 			outpoint := wire.NewOutPoint(txHash, i, tree)
-			if txRedeemer, exists := mp.outpoints[*outpoint]; exists {
+			if txRedeemer, exists := mp.outpoints.Spender(outpoint); exists {
 				mp.removeTransaction(txRedeemer, true)
 			}
 		}
@@ -535,13 +599,24 @@ func (mp *TxPool) removeTransaction(tx *dcrutil.Tx, removeRedeemers bool) {
 			mp.cfg.AddrIndex.RemoveUnconfirmedTx(txHash)
 		}
 
+		// Find the relatives of the transaction while it still links
+		// them since their tracked stats include it.
+		ancestors := mp.ancestors(tx)
+		descendants := mp.descendants(tx)
+
 		// Mark the referenced outpoints as unspent by the pool.
 
 		for _, txIn := range txDesc.Tx.MsgTx().TxIn {
-			delete(mp.outpoints, txIn.PreviousOutPoint)
+			mp.outpoints.Remove(&txIn.PreviousOutPoint)
 		}
 		delete(mp.pool, *txHash)
-		atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
+		mp.poolSize -= int64(txDesc.Tx.MsgTx().SerializeSize())
+		mp.removeRelatives(txDesc, ancestors, descendants)
+		atomic.StoreInt64(&mp.lastUpdated, mp.cfg.Clock.Now().Unix())
+
+		if mp.cfg.RemoveTxFromFeeEstimation != nil {
+			mp.cfg.RemoveTxFromFeeEstimation(txHash)
+		}
 	}
 }
 
@@ -559,25 +634,64 @@ func (mp *TxPool) RemoveTransaction(tx *dcrutil.Tx, removeRedeemers bool) {
 	mp.removeTransaction(tx, removeRedeemers)
 }
 
+// ConflictRemoval describes a transaction which was removed from the memory
+// pool because it spends outputs which are also spent by another transaction,
+// such as one in a newly connected block.
+type ConflictRemoval struct {
+	// Tx is the transaction which was removed.
+	Tx *dcrutil.Tx
+
+	// Conflicts houses the outpoints spent by the removed transaction
+	// which are also spent by the other transaction.
+	Conflicts []Conflict
+}
+
 // RemoveDoubleSpends removes all transactions which spend outputs spent by the
 // passed transaction from the memory pool.  Removing those transactions then
 // leads to removing all transactions which rely on them, recursively.  This is
 // necessary when a block is connected to the main chain because the block may
 // contain transactions which were previously unknown to the memory pool.
 //
+// The transactions which directly conflict with the passed transaction are
+// returned along with the conflicting outpoints so the owners of the inputs
+// may be notified.
+//
 // This function is safe for concurrent access.
-func (mp *TxPool) RemoveDoubleSpends(tx *dcrutil.Tx) {
+func (mp *TxPool) RemoveDoubleSpends(tx *dcrutil.Tx) []*ConflictRemoval {
 	// Protect concurrent access.
 	mp.Lock()
 	defer mp.Unlock()
 
+	var removals []*ConflictRemoval
 	for _, txIn := range tx.MsgTx().TxIn {
-		if txRedeemer, ok := mp.outpoints[txIn.PreviousOutPoint]; ok {
-			if !txRedeemer.Hash().IsEqual(tx.Hash()) {
-				mp.removeTransaction(txRedeemer, true)
+		txRedeemer, ok := mp.outpoints.Spender(&txIn.PreviousOutPoint)
+		if !ok || txRedeemer.Hash().IsEqual(tx.Hash()) {
+			continue
+		}
+
+		// Include all of the outpoints spent by both transactions since
+		// the redeemer is removed along with its other inputs.
+		removal := &ConflictRemoval{Tx: txRedeemer}
+		for _, redeemerIn := range txRedeemer.MsgTx().TxIn {
+			for _, in := range tx.MsgTx().TxIn {
+				if in.PreviousOutPoint == redeemerIn.PreviousOutPoint {
+					removal.Conflicts = append(removal.Conflicts,
+						Conflict{
+							OutPoint: in.PreviousOutPoint,
+							TxHash:   *tx.Hash(),
+						})
+					break
+				}
 			}
 		}
+		log.Debugf("Removing transaction %v since it double spends "+
+			"outputs spent by transaction %v", txRedeemer.Hash(),
+			tx.Hash())
+		mp.removeTransaction(txRedeemer, true)
+		removals = append(removals, removal)
 	}
+
+	return removals
 }
 
 // addTransaction adds the passed transaction to the memory pool.  It should
@@ -591,20 +705,23 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint,
 	// Add the transaction to the pool and mark the referenced outpoints
 	// as spent by the pool.
 	msgTx := tx.MsgTx()
-	mp.pool[*tx.Hash()] = &TxDesc{
+	txDesc := &TxDesc{
 		TxDesc: mining.TxDesc{
 			Tx:     tx,
 			Type:   txType,
-			Added:  time.Now(),
+			Added:  mp.cfg.Clock.Now(),
 			Height: height,
 			Fee:    fee,
 		},
 		StartingPriority: CalcPriority(msgTx, utxoView, height),
 	}
+	mp.pool[*tx.Hash()] = txDesc
 	for _, txIn := range msgTx.TxIn {
-		mp.outpoints[txIn.PreviousOutPoint] = tx
+		mp.outpoints.Add(&txIn.PreviousOutPoint, tx)
 	}
-	atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
+	mp.poolSize += int64(msgTx.SerializeSize())
+	mp.addRelatives(txDesc)
+	atomic.StoreInt64(&mp.lastUpdated, mp.cfg.Clock.Now().Unix())
 
 	// Add unconfirmed address index entries associated with the transaction
 	// if enabled.
@@ -614,15 +731,22 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint,
 	if mp.cfg.ExistsAddrIndex != nil {
 		mp.cfg.ExistsAddrIndex.AddUnconfirmedTx(msgTx)
 	}
+
+	if mp.cfg.AddTxToFeeEstimation != nil {
+		mp.cfg.AddTxToFeeEstimation(tx.Hash(), txType, fee,
+			int64(msgTx.SerializeSize()))
+	}
 }
 
 // checkPoolDoubleSpend checks whether or not the passed transaction is
 // attempting to spend coins already spent by other transactions in the pool.
 // Note it does not check for double spends against transactions already in the
-// main chain.
+// main chain.  The returned error identifies all of the conflicting outpoints
+// along with the transactions which already spend them.
 //
 // This function MUST be called with the mempool lock held (for reads).
 func (mp *TxPool) checkPoolDoubleSpend(tx *dcrutil.Tx, txType stake.TxType) error {
+	var conflicts []Conflict
 	for i, txIn := range tx.MsgTx().TxIn {
 		// We don't care about double spends of stake bases.
 		if (txType == stake.TxTypeSSGen || txType == stake.TxTypeSSRtx) &&
@@ -630,28 +754,54 @@ func (mp *TxPool) checkPoolDoubleSpend(tx *dcrutil.Tx, txType stake.TxType) erro
 			continue
 		}
 
-		if txR, exists := mp.outpoints[txIn.PreviousOutPoint]; exists {
-			str := fmt.Sprintf("transaction %v in the pool "+
-				"already spends the same coins", txR.Hash())
-			return txRuleError(wire.RejectDuplicate, str)
+		if txR, exists := mp.outpoints.Spender(&txIn.PreviousOutPoint); exists {
+			conflicts = append(conflicts, Conflict{
+				OutPoint: txIn.PreviousOutPoint,
+				TxHash:   *txR.Hash(),
+			})
 		}
 	}
+	if len(conflicts) == 0 {
+		return nil
+	}
 
-	return nil
+	// Report every conflicting transaction so the caller can identify all
+	// of the inputs which are double spent.
+	var hashes []string
+	seen := make(map[chainhash.Hash]struct{})
+	for _, conflict := range conflicts {
+		if _, ok := seen[conflict.TxHash]; ok {
+			continue
+		}
+		seen[conflict.TxHash] = struct{}{}
+		hashes = append(hashes, conflict.TxHash.String())
+	}
+	str := fmt.Sprintf("transaction %v in the pool already spends the "+
+		"same coins", strings.Join(hashes, ", "))
+	if len(hashes) > 1 {
+		str = fmt.Sprintf("transactions %v in the pool already spend "+
+			"the same coins", strings.Join(hashes, ", "))
+	}
+	return txConflictError(str, conflicts)
 }
 
 // isTxTreeValid checks the map of votes for a block to see if the tx
 // tree regular for the block at HEAD is valid.
 func (mp *TxPool) isTxTreeValid(newestHash *chainhash.Hash) bool {
+	return txTreeValid(mp.votes[*newestHash], mp.cfg.ChainParams.TicketsPerBlock)
+}
+
+// txTreeValid tallies the passed votes on a block to determine if its regular
+// transaction tree is valid.
+func txTreeValid(vts []*VoteTx, ticketsPerBlock uint16) bool {
 	// There are no votes on the block currently; assume it's valid.
-	vts := mp.votes[*newestHash]
 	if len(vts) == 0 {
 		return true
 	}
 
 	// There are not possibly enough votes to tell if the txTree is valid;
 	// assume it's valid.
-	if len(vts) <= int(mp.cfg.ChainParams.TicketsPerBlock/2) {
+	if len(vts) <= int(ticketsPerBlock/2) {
 		return true
 	}
 
@@ -707,6 +857,19 @@ func (mp *TxPool) fetchInputUtxos(tx *dcrutil.Tx) (*blockchain.UtxoViewpoint, er
 	return utxoView, nil
 }
 
+// FetchInputUtxos loads utxo details about the input transactions referenced by
+// the passed transaction from the viewpoint of the main chain and the contents
+// of the transaction pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) FetchInputUtxos(tx *dcrutil.Tx) (*blockchain.UtxoViewpoint, error) {
+	// Protect concurrent access.
+	mp.RLock()
+	defer mp.RUnlock()
+
+	return mp.fetchInputUtxos(tx)
+}
+
 // FetchTransaction returns the requested transaction from the transaction pool.
 // This only fetches from the main transaction pool and does not include
 // orphans.
@@ -743,7 +906,9 @@ func (mp *TxPool) FetchTransaction(txHash *chainhash.Hash, includeRecentBlock bo
 
 // maybeAcceptTransaction is the internal function which implements the public
 // MaybeAcceptTransaction.  See the comment for MaybeAcceptTransaction for
-// more details.
+// more details.  When inPackage is set, the transaction is part of a package
+// whose fees are checked as a whole by the caller, so the checks of the fees
+// and priority of the transaction on its own are skipped.
 //
 // This function MUST be called with the mempool lock held (for writes).
 // DECRED - TODO
@@ -751,9 +916,12 @@ func (mp *TxPool) FetchTransaction(txHash *chainhash.Hash, includeRecentBlock bo
 // so that we can easily pick different stake tx types from the mempool later.
 // This should probably be done at the bottom using "IsSStx" etc functions.
 // It should also set the dcrutil tree type for the tx as well.
-func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allowHighFees bool) ([]*chainhash.Hash, error) {
+func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allowHighFees, inPackage bool) ([]*chainhash.Hash, error) {
 	msgTx := tx.MsgTx()
 	txHash := tx.Hash()
+	acceptStart := time.Now()
+	var conflicts []Conflict
+
 	// Don't accept the transaction if it already exists in the pool.  This
 	// applies to orphan transactions as well.  This check is intended to
 	// be a quick check to weed out duplicates.
@@ -809,8 +977,12 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 	// Don't allow non-standard transactions if the network parameters
 	// forbid their relaying.
 	if !mp.cfg.ChainParams.RelayNonStdTxs {
-		err := checkTransactionStandard(tx, txType, nextBlockHeight,
-			mp.cfg.TimeSource, mp.cfg.Policy.MinRelayTxFee)
+		maxTxVersion, err := mp.cfg.Chain.MaxTxVersion()
+		if err != nil {
+			return nil, err
+		}
+		err = checkTransactionStandard(tx, txType, nextBlockHeight,
+			mp.cfg.TimeSource, mp.cfg.Policy.MinRelayTxFee, maxTxVersion)
 		if err != nil {
 			// Attempt to extract a reject code from the error so
 			// it can be retained.  When not possible, fall back to
@@ -825,6 +997,16 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 		}
 	}
 
+	// Don't allow transactions which use transaction or output script
+	// versions that are not valid until agendas which are not yet active.
+	err = mp.cfg.Chain.CheckTransactionVersions(msgTx)
+	if err != nil {
+		if cerr, ok := err.(blockchain.RuleError); ok {
+			return nil, chainRuleError(cerr)
+		}
+		return nil, err
+	}
+
 	// If the transaction is a ticket, ensure that it meets the next
 	// stake difficulty.
 	if txType == stake.TxTypeSStx {
@@ -886,9 +1068,18 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 		// at this point.  There is a more in-depth check that happens later
 		// after fetching the referenced transaction inputs from the main chain
 		// which examines the actual spend data and prevents double spends.
+		//
+		// Regular transactions may replace the conflicting transactions
+		// instead when replacement is enabled, which is decided once the
+		// fee of the transaction is known.
 		err = mp.checkPoolDoubleSpend(tx, txType)
 		if err != nil {
-			return nil, err
+			if !mp.cfg.Policy.AcceptReplacement ||
+				txType != stake.TxTypeRegular || inPackage {
+
+				return nil, err
+			}
+			conflicts = err.(RuleError).Err.(TxRuleError).Conflicts
 		}
 	}
 
@@ -913,6 +1104,8 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 	// to this transaction.  This function also attempts to fetch the
 	// transaction itself to be used for detecting a duplicate transaction
 	// without needing to do a separate lookup.
+	checksElapsed := time.Since(acceptStart)
+	fetchStart := time.Now()
 	utxoView, err := mp.fetchInputUtxos(tx)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
@@ -920,6 +1113,9 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 		}
 		return nil, err
 	}
+	checksStart := time.Now()
+	mp.validationStats.Record(blockchain.StageFetchInputs,
+		checksStart.Sub(fetchStart))
 
 	// Don't allow the transaction if it exists in the main chain and is not
 	// not already fully spent.
@@ -1007,8 +1203,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 	// the coinbase address itself can contain signature operations, the
 	// maximum allowed signature operations per transaction is less than
 	// the maximum allowed signature operations per block.
-	numSigOps, err := blockchain.CountP2SHSigOps(tx, false,
-		(txType == stake.TxTypeSSGen), utxoView)
+	cost, err := blockchain.CalcTxCost(tx, false, utxoView)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
 			return nil, chainRuleError(cerr)
@@ -1016,7 +1211,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 		return nil, err
 	}
 
-	numSigOps += blockchain.CountSigOps(tx, false, (txType == stake.TxTypeSSGen))
+	numSigOps := cost.TotalSigOps()
 	if numSigOps > mp.cfg.Policy.MaxSigOpsPerTx {
 		str := fmt.Sprintf("transaction %v has too many sigops: %d > %d",
 			txHash, numSigOps, mp.cfg.Policy.MaxSigOpsPerTx)
@@ -1038,7 +1233,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 	serializedSize := int64(msgTx.SerializeSize())
 	minFee := calcMinRequiredTxRelayFee(serializedSize,
 		mp.cfg.Policy.MinRelayTxFee)
-	if txType == stake.TxTypeRegular { // Non-stake only
+	if txType == stake.TxTypeRegular && !inPackage { // Non-stake only
 		if serializedSize >= (DefaultBlockPrioritySize-1000) &&
 			txFee < minFee {
 
@@ -1056,7 +1251,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 	//
 	// This applies to non-stake transactions only.
 	if isNew && !mp.cfg.Policy.DisableRelayPriority && txFee < minFee &&
-		txType == stake.TxTypeRegular {
+		txType == stake.TxTypeRegular && !inPackage {
 
 		currentPriority := CalcPriority(msgTx, utxoView,
 			nextBlockHeight)
@@ -1068,11 +1263,29 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 		}
 	}
 
+	// Don't allow regular transactions with fees too low for the pool while
+	// it is full or was full recently, regardless of their priority, since
+	// they would be the first to be evicted.
+	if txType == stake.TxTypeRegular && !inPackage &&
+		mp.rollingMinFeeRate() > 0 {
+
+		poolMinFee := calcMinRequiredTxRelayFee(serializedSize,
+			mp.minPoolFeeRate())
+		if txFee < poolMinFee {
+			str := fmt.Sprintf("transaction %v has %v fees which "+
+				"is under the required amount of %v for the "+
+				"full memory pool", txHash, txFee, poolMinFee)
+			return nil, txRuleError(wire.RejectInsufficientFee, str)
+		}
+	}
+
 	// Free-to-relay transactions are rate limited here to prevent
 	// penny-flooding with tiny transactions as a form of attack.
 	// This applies to non-stake transactions only.
-	if rateLimit && txFee < minFee && txType == stake.TxTypeRegular {
-		nowUnix := time.Now().Unix()
+	if rateLimit && txFee < minFee && txType == stake.TxTypeRegular &&
+		!inPackage {
+
+		nowUnix := mp.cfg.Clock.Now().Unix()
 		// Decay passed data with an exponentially decaying ~10 minute
 		// window.
 		mp.pennyTotal *= math.Pow(1.0-1.0/600.0,
@@ -1099,7 +1312,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 	//
 	// This applies to tickets transactions only.
 	minTicketFee := calcMinRequiredTxRelayFee(serializedSize, minTicketFee)
-	if (txFee < minTicketFee) && txType == stake.TxTypeSStx {
+	if (txFee < minTicketFee) && txType == stake.TxTypeSStx && !inPackage {
 		str := fmt.Sprintf("transaction %v has a %v fee which "+
 			"is under the required threshold amount of %d", txHash, txFee,
 			minTicketFee)
@@ -1121,16 +1334,61 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 		}
 	}
 
+	// Ensure the transaction pays enough to replace the transactions it
+	// conflicts with along with everything which depends on them.
+	var replaced []*TxDesc
+	if len(conflicts) > 0 {
+		replaced, err = mp.checkReplacement(tx, txFee, conflicts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Ensure the transaction does not exceed the limits on its ancestors
+	// and the descendants of its ancestors in the pool.
+	if err := mp.checkRelativeLimits(tx); err != nil {
+		return nil, err
+	}
+
 	// Verify crypto signatures for each input and reject the transaction if
 	// any don't verify.
-	err = blockchain.ValidateTransactionScripts(tx, utxoView,
-		txscript.StandardVerifyFlags, mp.cfg.SigCache)
+	scriptFlags, err := mp.cfg.Chain.StandardScriptFlags()
+	if err != nil {
+		return nil, err
+	}
+
+	// Spends from the treasury must be authorized by one of the treasury
+	// keys of the network once the treasury agenda is active, which is the
+	// case when the script flags require the signatures to be verified.
+	if scriptFlags&txscript.ScriptVerifyTreasury != 0 {
+		err := blockchain.CheckTreasurySpends(tx, utxoView,
+			mp.cfg.ChainParams)
+		if err != nil {
+			if cerr, ok := err.(blockchain.RuleError); ok {
+				return nil, chainRuleError(cerr)
+			}
+			return nil, err
+		}
+	}
+
+	scriptsStart := time.Now()
+	checksElapsed += scriptsStart.Sub(checksStart)
+	mp.validationStats.Record(blockchain.StageChecks, checksElapsed)
+	err = blockchain.ValidateTransactionScripts(tx, utxoView, scriptFlags,
+		mp.cfg.SigCache)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
 			return nil, chainRuleError(cerr)
 		}
 		return nil, err
 	}
+	updateStart := time.Now()
+	mp.validationStats.Record(blockchain.StageScripts,
+		updateStart.Sub(scriptsStart))
+
+	// Remove the replaced transactions before adding the replacement since
+	// they spend the same outputs.
+	mp.replaceTransactions(tx, replaced)
 
 	// Add to transaction pool.
 	mp.addTransaction(utxoView, tx, txType, best.Height, txFee)
@@ -1146,6 +1404,22 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit, allow
 		}
 	}
 
+	// Evict the transactions with the lowest fee rates when the pool
+	// exceeds its maximum size, which may include the transaction itself.
+	// The size of the pool is limited once all transactions of a package
+	// were added instead.
+	if !inPackage {
+		mp.limitPoolSize()
+		if !mp.isTransactionInPool(txHash) {
+			str := fmt.Sprintf("transaction %v has a fee rate too "+
+				"low to remain in the full memory pool", txHash)
+			return nil, txRuleError(wire.RejectInsufficientFee, str)
+		}
+	}
+
+	mp.validationStats.Record(blockchain.StageUpdate, time.Since(updateStart))
+	mp.validationStats.Record(blockchain.StageTotal, time.Since(acceptStart))
+
 	log.Debugf("Accepted transaction %v (pool size: %v)", txHash,
 		len(mp.pool))
 
@@ -1165,7 +1439,7 @@ func (mp *TxPool) MaybeAcceptTransaction(tx *dcrutil.Tx, isNew, rateLimit bool)
 	mp.Lock()
 	defer mp.Unlock()
 
-	return mp.maybeAcceptTransaction(tx, isNew, rateLimit, true)
+	return mp.maybeAcceptTransaction(tx, isNew, rateLimit, true, false)
 }
 
 // processOrphans is the internal function which implements the public
@@ -1188,8 +1462,8 @@ func (mp *TxPool) processOrphans(hash *chainhash.Hash) []*dcrutil.Tx {
 		// be multiple if the referenced transaction contains multiple
 		// outputs.  Skip to the next item on the list of hashes to
 		// process if there are none.
-		orphans, exists := mp.orphansByPrev[*processHash]
-		if !exists || orphans == nil {
+		orphans := mp.orphans.OrphansByPrev(processHash)
+		if len(orphans) == 0 {
 			continue
 		}
 
@@ -1213,7 +1487,7 @@ func (mp *TxPool) processOrphans(hash *chainhash.Hash) []*dcrutil.Tx {
 			// Potentially accept the transaction into the
 			// transaction pool.
 			missingParents, err := mp.maybeAcceptTransaction(tx,
-				true, true, true)
+				true, true, true, false)
 			if err != nil {
 				// TODO: Remove orphans that depend on this
 				// failed transaction.
@@ -1353,7 +1627,7 @@ func (mp *TxPool) ProcessTransaction(tx *dcrutil.Tx, allowOrphan, rateLimit, all
 	// Potentially accept the transaction to the memory pool.
 	var missingParents []*chainhash.Hash
 	missingParents, err = mp.maybeAcceptTransaction(tx, true, rateLimit,
-		allowHighFees)
+		allowHighFees, false)
 	if err != nil {
 		return nil, err
 	}
@@ -1465,12 +1739,12 @@ func (mp *TxPool) MiningDescs() []*mining.TxDesc {
 	return descs
 }
 
-// RawMempoolVerbose returns all of the entries in the mempool filtered by the
-// provided stake type as a fully populated JSON result.  The filter type can be
-// nil in which case all transactions will be returned.
+// RawMempoolVerbose returns all of the entries in the mempool for which the
+// provided filter function returns true as a fully populated JSON result.  The
+// filter can be nil in which case all transactions will be returned.
 //
 // This function is safe for concurrent access.
-func (mp *TxPool) RawMempoolVerbose(filterType *stake.TxType) map[string]*dcrjson.GetRawMempoolVerboseResult {
+func (mp *TxPool) RawMempoolVerbose(filter func(*TxDesc) bool) map[string]*dcrjson.GetRawMempoolVerboseResult {
 	mp.RLock()
 	defer mp.RUnlock()
 
@@ -1479,9 +1753,9 @@ func (mp *TxPool) RawMempoolVerbose(filterType *stake.TxType) map[string]*dcrjso
 	best := mp.cfg.Chain.BestSnapshot()
 
 	for _, desc := range mp.pool {
-		// Skip entries that don't match the requested stake type if
+		// Skip entries that don't match the requested filter if
 		// specified.
-		if filterType != nil && desc.Type != *filterType {
+		if filter != nil && !filter(desc) {
 			continue
 		}
 
@@ -1527,6 +1801,14 @@ func (mp *TxPool) LastUpdated() time.Time {
 	return time.Unix(atomic.LoadInt64(&mp.lastUpdated), 0)
 }
 
+// ValidationStats returns the accumulated timing of each stage of accepting
+// transactions into the pool ordered by stage.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) ValidationStats() []blockchain.StageTiming {
+	return mp.validationStats.Snapshot()
+}
+
 // CheckIfTxsExist checks a list of transaction hashes against the mempool
 // and returns true if they all exist in the mempool, otherwise false.
 //
@@ -1549,13 +1831,18 @@ func (mp *TxPool) CheckIfTxsExist(hashes []chainhash.Hash) bool {
 // New returns a new memory pool for validating and storing standalone
 // transactions until they are mined into a block.
 func New(cfg *Config) *TxPool {
-	return &TxPool{
-		cfg:           *cfg,
-		pool:          make(map[chainhash.Hash]*TxDesc),
-		orphans:       make(map[chainhash.Hash]*dcrutil.Tx),
-		orphansByPrev: make(map[chainhash.Hash]map[chainhash.Hash]*dcrutil.Tx),
-		outpoints:     make(map[wire.OutPoint]*dcrutil.Tx),
-		votes:         make(map[chainhash.Hash][]*VoteTx),
-		subsidyCache:  cfg.Chain.FetchSubsidyCache(),
-	}
+	pool := &TxPool{
+		cfg:          *cfg,
+		pool:         make(map[chainhash.Hash]*TxDesc),
+		orphans:      newOrphanIndex(),
+		outpoints:    newOutPointIndex(),
+		relatives:    make(map[chainhash.Hash]*relativeStats),
+		votes:        make(map[chainhash.Hash][]*VoteTx),
+		rejected:     make(map[chainhash.Hash]*RejectedTx),
+		subsidyCache: cfg.Chain.FetchSubsidyCache(),
+	}
+	if pool.cfg.Clock == nil {
+		pool.cfg.Clock = blockchain.SystemClock()
+	}
+	return pool
 }
@@ -0,0 +1,60 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// newTestPool returns a simnet pool with the passed clock and policy which
+// does not have a chain.  This allows the tests to populate the pool directly
+// and exercise everything which does not need to look up transactions in the
+// chain.  The system clock is used when the passed clock is nil.
+func newTestPool(clock blockchain.Clock, policy Policy) *TxPool {
+	if clock == nil {
+		clock = blockchain.SystemClock()
+	}
+	return &TxPool{
+		cfg: Config{
+			ChainParams: &chaincfg.SimNetParams,
+			Clock:       clock,
+			Policy:      policy,
+		},
+		pool:      make(map[chainhash.Hash]*TxDesc),
+		orphans:   newOrphanIndex(),
+		outpoints: newOutPointIndex(),
+		relatives: make(map[chainhash.Hash]*relativeStats),
+		votes:     make(map[chainhash.Hash][]*VoteTx),
+		rejected:  make(map[chainhash.Hash]*RejectedTx),
+	}
+}
+
+// newTestTx returns a regular transaction with two outputs which spends the
+// passed outputs, or a unique outpoint outside of the pool when there are
+// none.  The transaction is unique to the passed number.
+func newTestTx(n int64, prevOuts ...*wire.OutPoint) *dcrutil.Tx {
+	msgTx := wire.NewMsgTx()
+	for _, prevOut := range prevOuts {
+		msgTx.AddTxIn(wire.NewTxIn(prevOut, nil))
+	}
+	if len(prevOuts) == 0 {
+		prevOut := wire.NewOutPoint(&chainhash.Hash{0x01}, uint32(n),
+			wire.TxTreeRegular)
+		msgTx.AddTxIn(wire.NewTxIn(prevOut, nil))
+	}
+	msgTx.AddTxOut(wire.NewTxOut(n, []byte{0x51}))
+	msgTx.AddTxOut(wire.NewTxOut(n, []byte{0x51}))
+	return dcrutil.NewTx(msgTx)
+}
+
+// testOutPoint returns the regular tree outpoint of the passed output of the
+// passed transaction.
+func testOutPoint(tx *dcrutil.Tx, index uint32) *wire.OutPoint {
+	return wire.NewOutPoint(tx.Hash(), index, wire.TxTreeRegular)
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// checkPackageSanity performs checks on the structure of a package which do not
+// depend on the contents of the pool.  A package must have at least two and at
+// most wire.MaxPackageTxns unique transactions which are either regular
+// transactions or tickets, and every transaction must follow the transactions
+// of the package it spends.
+func checkPackageSanity(txns []*dcrutil.Tx) error {
+	if len(txns) < 2 || len(txns) > wire.MaxPackageTxns {
+		str := fmt.Sprintf("package has %d transactions, but must have "+
+			"between 2 and %d", len(txns), wire.MaxPackageTxns)
+		return txRuleError(wire.RejectInvalid, str)
+	}
+
+	positions := make(map[chainhash.Hash]int, len(txns))
+	for i, tx := range txns {
+		if _, exists := positions[*tx.Hash()]; exists {
+			str := fmt.Sprintf("package has duplicate transaction %v",
+				tx.Hash())
+			return txRuleError(wire.RejectInvalid, str)
+		}
+		positions[*tx.Hash()] = i
+	}
+
+	for i, tx := range txns {
+		txType := stake.DetermineTxType(tx.MsgTx())
+		if txType != stake.TxTypeRegular && txType != stake.TxTypeSStx {
+			str := fmt.Sprintf("package transaction %v is a %v, but "+
+				"only regular transactions and tickets are "+
+				"allowed", tx.Hash(), txType)
+			return txRuleError(wire.RejectInvalid, str)
+		}
+
+		for _, txIn := range tx.MsgTx().TxIn {
+			pos, ok := positions[txIn.PreviousOutPoint.Hash]
+			if ok && pos >= i {
+				str := fmt.Sprintf("package transaction %v spends "+
+					"transaction %v which does not precede it",
+					tx.Hash(), txIn.PreviousOutPoint.Hash)
+				return txRuleError(wire.RejectInvalid, str)
+			}
+		}
+	}
+
+	return nil
+}
+
+// txMinFee returns the minimum fee a transaction of the passed type and
+// size is required to pay on its own to be relayed, which a package must pay
+// for all of its transactions combined.  Regular transactions must pay the
+// raised minimum fee rate while the pool is full or was full recently.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txMinFee(txType stake.TxType, serializedSize int64) int64 {
+	if txType == stake.TxTypeSStx {
+		return calcMinRequiredTxRelayFee(serializedSize, minTicketFee)
+	}
+	return calcMinRequiredTxRelayFee(serializedSize, mp.minPoolFeeRate())
+}
+
+// AcceptPackage validates the passed package of transactions and adds all of
+// them to the memory pool, or none of them when any transaction is invalid.  It
+// allows parents which pay too little in fees on their own, such as the split
+// transactions funding tickets, to be accepted along with children which pay
+// for them.
+//
+// The transactions must be ordered so that every transaction follows the
+// transactions of the package it spends, and all other inputs must be
+// available from the main chain or the memory pool.  Transactions of the
+// package which are already in the memory pool are skipped.  The fees of the
+// remaining transactions are evaluated as a whole, so the package must pay at
+// least the sum of the minimum fees its transactions would have to pay on their
+// own, while each transaction must otherwise meet all of the usual rules.
+//
+// It returns a slice of transactions added to the mempool.  When the error is
+// nil, the list will include the newly accepted transactions of the package in
+// order along with any orphan transactions that were added as a result.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) AcceptPackage(txns []*dcrutil.Tx, rateLimit, allowHighFees bool) ([]*dcrutil.Tx, error) {
+	if err := checkPackageSanity(txns); err != nil {
+		return nil, err
+	}
+
+	// Protect concurrent access.  Since the lock is held until all of the
+	// transactions are either accepted or removed again, the package is
+	// never partially visible.
+	mp.Lock()
+	defer mp.Unlock()
+
+	// Remove the transactions of the package from the orphan pool since an
+	// orphan is otherwise rejected as a duplicate.  They are added back
+	// when the package is rejected.
+	var orphans []*dcrutil.Tx
+	for _, tx := range txns {
+		if mp.isOrphanInPool(tx.Hash()) {
+			mp.removeOrphan(tx.Hash())
+			orphans = append(orphans, tx)
+		}
+	}
+
+	accepted := make([]*dcrutil.Tx, 0, len(txns))
+	reject := func(err error) ([]*dcrutil.Tx, error) {
+		for i := len(accepted) - 1; i >= 0; i-- {
+			mp.removeTransaction(accepted[i], false)
+		}
+		for _, tx := range orphans {
+			mp.addOrphan(tx)
+		}
+		return nil, err
+	}
+
+	acceptTx := mp.acceptPackageTx
+	if acceptTx == nil {
+		acceptTx = func(tx *dcrutil.Tx, rateLimit, allowHighFees bool) ([]*chainhash.Hash, error) {
+			return mp.maybeAcceptTransaction(tx, true, rateLimit,
+				allowHighFees, true)
+		}
+	}
+
+	var packageFee, packageMinFee int64
+	for _, tx := range txns {
+		if mp.isTransactionInPool(tx.Hash()) {
+			continue
+		}
+
+		missingParents, err := acceptTx(tx, rateLimit, allowHighFees)
+		if err != nil {
+			return reject(err)
+		}
+		if len(missingParents) > 0 {
+			str := fmt.Sprintf("package transaction %v references "+
+				"outputs of unknown or fully-spent transaction %v",
+				tx.Hash(), missingParents[0])
+			return reject(txRuleError(wire.RejectDuplicate, str))
+		}
+		accepted = append(accepted, tx)
+
+		txDesc := mp.pool[*tx.Hash()]
+		packageFee += txDesc.Fee
+		packageMinFee += mp.txMinFee(txDesc.Type,
+			int64(tx.MsgTx().SerializeSize()))
+	}
+
+	if packageFee < packageMinFee {
+		str := fmt.Sprintf("package has %v fees which is under the "+
+			"required amount of %v", packageFee, packageMinFee)
+		return reject(txRuleError(wire.RejectInsufficientFee, str))
+	}
+
+	// Evict the transactions with the lowest fee rates now that the whole
+	// package was added when the pool exceeds its maximum size.  The
+	// package is rejected without evicting anything when that would evict
+	// any of its transactions, since the evicted transactions could not be
+	// restored otherwise.
+	plan := mp.planEviction()
+	for _, tx := range accepted {
+		if plan.evicts(tx.Hash()) {
+			str := fmt.Sprintf("package transaction %v has a fee "+
+				"rate too low to remain in the full memory pool",
+				tx.Hash())
+			return reject(txRuleError(wire.RejectInsufficientFee, str))
+		}
+	}
+	mp.evict(plan)
+
+	log.Debugf("Accepted package of %d transactions with %v fees",
+		len(accepted), packageFee)
+
+	// Accept any orphan transactions that depend on the transactions of
+	// the package.
+	var orphansAccepted []*dcrutil.Tx
+	for _, tx := range accepted {
+		orphansAccepted = append(orphansAccepted,
+			mp.processOrphans(tx.Hash())...)
+	}
+	return append(accepted, orphansAccepted...), nil
+}
+
+// PackageTxns returns the transaction with the passed hash along with its
+// ancestors in the memory pool, ordered so that every transaction follows all
+// of its ancestors, for relaying them as a package.  An error is returned when
+// the transaction is not in the memory pool or it has more ancestors than fit
+// into a package.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) PackageTxns(hash *chainhash.Hash) ([]*dcrutil.Tx, error) {
+	mp.RLock()
+	defer mp.RUnlock()
+
+	txDesc, exists := mp.pool[*hash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool", hash)
+	}
+
+	ancestors := mp.ancestors(txDesc.Tx)
+	if len(ancestors)+1 > wire.MaxPackageTxns {
+		return nil, fmt.Errorf("transaction %v has too many ancestors "+
+			"in the pool to relay as a package", hash)
+	}
+	txns := make([]*dcrutil.Tx, 0, len(ancestors)+1)
+	for _, ancestor := range ancestors {
+		txns = append(txns, ancestor.Tx)
+	}
+	txns = append(txns, txDesc.Tx)
+	return txns, nil
+}
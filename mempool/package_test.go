@@ -0,0 +1,230 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/mining"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// TestCheckPackageSanity ensures packages with an invalid number of
+// transactions, duplicate transactions, or transactions which precede the
+// transactions they spend are rejected.
+func TestCheckPackageSanity(t *testing.T) {
+	t.Parallel()
+
+	parent := newTestTx(1)
+	child := newTestTx(2, testOutPoint(parent, 0))
+	grandchild := newTestTx(3, testOutPoint(child, 0),
+		testOutPoint(parent, 0))
+	tooMany := []*dcrutil.Tx{parent}
+	for i := 1; i <= wire.MaxPackageTxns; i++ {
+		tx := newTestTx(int64(10+i), testOutPoint(parent, 0))
+		tooMany = append(tooMany, tx)
+	}
+
+	tests := []struct {
+		name  string
+		txns  []*dcrutil.Tx
+		valid bool
+	}{
+		{"parent and child", []*dcrutil.Tx{parent, child}, true},
+		{"three generations", []*dcrutil.Tx{parent, child, grandchild},
+			true},
+		{"single transaction", []*dcrutil.Tx{parent}, false},
+		{"too many transactions", tooMany, false},
+		{"duplicate transaction", []*dcrutil.Tx{parent, child, child},
+			false},
+		{"child first", []*dcrutil.Tx{child, parent}, false},
+	}
+	for _, test := range tests {
+		err := checkPackageSanity(test.txns)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !test.valid {
+			if _, ok := err.(RuleError); !ok {
+				t.Errorf("%s: unexpected error - got %v, want "+
+					"RuleError", test.name, err)
+			}
+		}
+	}
+}
+
+// TestPackageTxns ensures the transactions of a package are returned with every
+// transaction following all of its ancestors in the pool, and transactions with
+// too many ancestors are refused.
+func TestPackageTxns(t *testing.T) {
+	t.Parallel()
+
+	mp := newTestPool(nil, Policy{})
+	addTx := func(tx *dcrutil.Tx) {
+		mp.pool[*tx.Hash()] = &TxDesc{
+			TxDesc: mining.TxDesc{Tx: tx, Type: stake.TxTypeRegular},
+		}
+	}
+
+	// Create a diamond of transactions where the final transaction spends
+	// two transactions which both spend the same parent.
+	parent := newTestTx(1)
+	left := newTestTx(2, testOutPoint(parent, 0))
+	right := newTestTx(3, testOutPoint(parent, 0))
+	child := newTestTx(4, testOutPoint(left, 0), testOutPoint(right, 0))
+	for _, tx := range []*dcrutil.Tx{parent, left, right, child} {
+		addTx(tx)
+	}
+
+	txns, err := mp.PackageTxns(child.Hash())
+	if err != nil {
+		t.Fatalf("PackageTxns: unexpected error: %v", err)
+	}
+	if len(txns) != 4 || txns[len(txns)-1] != child {
+		t.Fatalf("PackageTxns: unexpected transactions %v", txns)
+	}
+	if err := checkPackageSanity(txns); err != nil {
+		t.Fatalf("PackageTxns: package is not ordered: %v", err)
+	}
+
+	if _, err := mp.PackageTxns(&chainhash.Hash{0x02}); err == nil {
+		t.Fatal("PackageTxns: no error for transaction not in the pool")
+	}
+
+	// Ensure a chain of transactions longer than a package is refused.
+	tx := child
+	for i := 0; i < wire.MaxPackageTxns; i++ {
+		tx = newTestTx(int64(100+i), testOutPoint(tx, 0))
+		addTx(tx)
+	}
+	if _, err := mp.PackageTxns(tx.Hash()); err == nil {
+		t.Fatal("PackageTxns: no error for too many ancestors")
+	}
+}
+
+// TestAcceptPackage ensures packages are accepted when their transactions pay
+// the minimum fees of all of them combined, that rejected packages leave
+// neither their transactions in the pool nor their orphans removed, and that
+// transactions of a package which are already in the pool are skipped.
+func TestAcceptPackage(t *testing.T) {
+	t.Parallel()
+
+	mp := newTestPool(nil, Policy{
+		MinRelayTxFee:   1e4,
+		MaxOrphanTxs:    10,
+		MaxOrphanTxSize: 100000,
+	})
+	fees := make(map[chainhash.Hash]int64)
+	invalid := make(map[chainhash.Hash]struct{})
+	var numAccepted int
+	addTx := func(tx *dcrutil.Tx) {
+		txDesc := &TxDesc{
+			TxDesc: mining.TxDesc{
+				Tx:   tx,
+				Type: stake.TxTypeRegular,
+				Fee:  fees[*tx.Hash()],
+			},
+		}
+		mp.pool[*tx.Hash()] = txDesc
+		for _, txIn := range tx.MsgTx().TxIn {
+			mp.outpoints.Add(&txIn.PreviousOutPoint, tx)
+		}
+		mp.poolSize += int64(tx.MsgTx().SerializeSize())
+		mp.addRelatives(txDesc)
+	}
+	mp.acceptPackageTx = func(tx *dcrutil.Tx, rateLimit, allowHighFees bool) ([]*chainhash.Hash, error) {
+		numAccepted++
+		if _, ok := invalid[*tx.Hash()]; ok {
+			return nil, txRuleError(wire.RejectInvalid, "invalid")
+		}
+		addTx(tx)
+		return nil, nil
+	}
+	newTx := func(n int64, fee int64, prevOuts ...*wire.OutPoint) *dcrutil.Tx {
+		tx := newTestTx(n, prevOuts...)
+		fees[*tx.Hash()] = fee
+		return tx
+	}
+	minFee := func(txns ...*dcrutil.Tx) int64 {
+		var total int64
+		for _, tx := range txns {
+			total += mp.txMinFee(stake.TxTypeRegular,
+				int64(tx.MsgTx().SerializeSize()))
+		}
+		return total
+	}
+	checkRejected := func(desc string, txns []*dcrutil.Tx, code wire.RejectCode) {
+		accepted, err := mp.AcceptPackage(txns, false, false)
+		if gotCode, ok := extractRejectCode(err); !ok || gotCode != code {
+			t.Fatalf("%s: unexpected error - got %v, want %v", desc,
+				err, code)
+		}
+		if accepted != nil {
+			t.Fatalf("%s: unexpected accepted transactions %v", desc,
+				accepted)
+		}
+		for _, tx := range txns {
+			if mp.isTransactionInPool(tx.Hash()) {
+				t.Fatalf("%s: transaction %v of rejected package "+
+					"remains in the pool", desc, tx.Hash())
+			}
+		}
+	}
+
+	// Ensure a parent without fees is rejected along with a child which
+	// only pays for itself, and that the orphan of the package which was
+	// removed to process it is restored.
+	parent := newTx(1, 0)
+	child := newTx(2, 0, testOutPoint(parent, 0))
+	fees[*child.Hash()] = minFee(child)
+	mp.addOrphan(child)
+	checkRejected("child paying for itself", []*dcrutil.Tx{parent, child},
+		wire.RejectInsufficientFee)
+	if !mp.isOrphanInPool(child.Hash()) {
+		t.Fatal("orphan of rejected package was not restored")
+	}
+
+	// Ensure the accepted transactions are removed again when a later
+	// transaction of the package is invalid.
+	invalidChild := newTx(3, 1e6, testOutPoint(parent, 0))
+	invalid[*invalidChild.Hash()] = struct{}{}
+	checkRejected("invalid child", []*dcrutil.Tx{parent, invalidChild},
+		wire.RejectInvalid)
+
+	// Ensure the package is accepted once the child pays the minimum fees
+	// of both transactions combined.
+	fees[*child.Hash()] = minFee(parent, child)
+	accepted, err := mp.AcceptPackage([]*dcrutil.Tx{parent, child}, false,
+		false)
+	if err != nil {
+		t.Fatalf("AcceptPackage: unexpected error: %v", err)
+	}
+	if len(accepted) != 2 || accepted[0] != parent || accepted[1] != child {
+		t.Fatalf("AcceptPackage: unexpected accepted transactions %v",
+			accepted)
+	}
+	if mp.isOrphanInPool(child.Hash()) {
+		t.Fatal("AcceptPackage: accepted transaction remains an orphan")
+	}
+
+	// Ensure transactions of a package which are already in the pool are
+	// neither accepted again nor included in the fees of the package.
+	grandchild := newTx(4, 0, testOutPoint(child, 0))
+	fees[*grandchild.Hash()] = minFee(grandchild)
+	numAccepted = 0
+	accepted, err = mp.AcceptPackage([]*dcrutil.Tx{parent, child,
+		grandchild}, false, false)
+	if err != nil {
+		t.Fatalf("AcceptPackage: unexpected error: %v", err)
+	}
+	if len(accepted) != 1 || accepted[0] != grandchild || numAccepted != 1 {
+		t.Fatalf("AcceptPackage: unexpected accepted transactions %v "+
+			"(%d processed)", accepted, numAccepted)
+	}
+}
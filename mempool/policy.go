@@ -349,16 +349,23 @@ func isDust(txOut *wire.TxOut, minRelayTxFee dcrutil.Amount) bool {
 // finalized, conforming to more stringent size constraints, having scripts
 // of recognized forms, and not containing "dust" outputs (those that are
 // so small it costs more to process them than they are worth).
+//
+// The maximum transaction version is the highest transaction version which is
+// active as determined by the chain.
 func checkTransactionStandard(tx *dcrutil.Tx, txType stake.TxType, height int64,
-	timeSource blockchain.MedianTimeSource,
-	minRelayTxFee dcrutil.Amount) error {
+	timeSource blockchain.MedianTimeSource, minRelayTxFee dcrutil.Amount,
+	maxTxVersion uint16) error {
 
-	// The transaction must be a currently supported version.
+	// The transaction must be a currently supported version and use the
+	// full serialization type.
 	msgTx := tx.MsgTx()
-	if !wire.IsSupportedMsgTxVersion(msgTx) {
+	txVersion := uint16(msgTx.Version)
+	serType := wire.TxSerializeType(uint32(msgTx.Version) >> 16)
+	if serType != wire.TxSerializeFull || txVersion < 1 ||
+		txVersion > maxTxVersion {
+
 		str := fmt.Sprintf("transaction version %d is not in the "+
-			"valid range of %d-%d", msgTx.Version, 1,
-			wire.TxVersion)
+			"valid range of %d-%d", msgTx.Version, 1, maxTxVersion)
 		return txRuleError(wire.RejectNonstandard, str)
 	}
 
@@ -0,0 +1,204 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"sort"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// maxRejectedTxns is the maximum number of recently rejected
+	// transactions that are remembered.
+	maxRejectedTxns = 1000
+
+	// rejectedTxExpiry is the amount of time a rejected transaction is
+	// remembered for.  Rejected transactions are also forgotten whenever a
+	// new block is connected since that might change whether or not they
+	// are valid.
+	rejectedTxExpiry = time.Minute * 10
+)
+
+// RejectedTx describes a transaction that was recently rejected from the pool.
+type RejectedTx struct {
+	// Hash is the hash of the transaction.
+	Hash chainhash.Hash
+
+	// FullHash is the hash of the full transaction including the witness
+	// data.  Since the transaction hash does not commit to the witness
+	// data, it is used to avoid ignoring a valid transaction because a
+	// malleated version of it with an invalid witness was rejected.
+	FullHash chainhash.Hash
+
+	// RejectCode and Reason are the reject code and reason the
+	// transaction was rejected for.
+	RejectCode wire.RejectCode
+	Reason     string
+
+	// Rejected is the time the transaction was first rejected and Expires
+	// is the time it will be forgotten.
+	Rejected time.Time
+	Expires  time.Time
+
+	// Seen is the number of times the transaction was seen again after it
+	// was rejected.
+	Seen uint32
+}
+
+// RecordRejected remembers that the passed transaction, which was received from
+// a peer, was rejected for the passed error so it is not requested or processed
+// again until the rejection expires.  The oldest rejected transaction is
+// evicted when the maximum number of rejected transactions would be exceeded.
+//
+// Only transactions which violate the rules are remembered.  Errors which are
+// not rule errors and rejections of transactions which are already known do
+// not say anything about the validity of the transaction, so remembering them
+// would prevent valid transactions from being accepted.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) RecordRejected(tx *dcrutil.Tx, err error) {
+	if _, ok := err.(RuleError); !ok {
+		return
+	}
+	code, reason := ErrToRejectErr(err)
+	if code == wire.RejectDuplicate {
+		return
+	}
+	fullHash := tx.MsgTx().TxHashFull()
+	now := mp.cfg.Clock.Now()
+
+	mp.rejectedMtx.Lock()
+	defer mp.rejectedMtx.Unlock()
+
+	// Keep the original rejection when the same transaction is rejected
+	// again.
+	existing, ok := mp.rejected[*tx.Hash()]
+	if ok && existing.FullHash == fullHash {
+		return
+	}
+	if !ok && len(mp.rejected)+1 > maxRejectedTxns {
+		var oldest *RejectedTx
+		for _, rejected := range mp.rejected {
+			if oldest == nil || rejected.Rejected.Before(oldest.Rejected) {
+				oldest = rejected
+			}
+		}
+		delete(mp.rejected, oldest.Hash)
+	}
+
+	mp.rejected[*tx.Hash()] = &RejectedTx{
+		Hash:       *tx.Hash(),
+		FullHash:   fullHash,
+		RejectCode: code,
+		Reason:     reason,
+		Rejected:   now,
+		Expires:    now.Add(rejectedTxExpiry),
+	}
+}
+
+// lookupRejected returns the unexpired rejected transaction with the passed
+// hash after recording it was seen again.
+//
+// This function MUST be called with the rejected transaction lock held.
+func (mp *TxPool) lookupRejected(hash *chainhash.Hash) *RejectedTx {
+	rejected, ok := mp.rejected[*hash]
+	if !ok {
+		return nil
+	}
+	if mp.cfg.Clock.Now().After(rejected.Expires) {
+		delete(mp.rejected, *hash)
+		return nil
+	}
+	rejected.Seen++
+	return rejected
+}
+
+// HaveRejectedHash returns whether or not a transaction with the passed hash
+// was recently rejected.  It is intended to be used to avoid requesting
+// transactions that were recently rejected when they are announced.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) HaveRejectedHash(hash *chainhash.Hash) bool {
+	mp.rejectedMtx.Lock()
+	rejected := mp.lookupRejected(hash)
+	mp.rejectedMtx.Unlock()
+	return rejected != nil
+}
+
+// HaveRejected returns the details of the rejection when the exact passed
+// transaction, including its witness data, was recently rejected.  It returns
+// nil otherwise.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) HaveRejected(tx *dcrutil.Tx) *RejectedTx {
+	mp.rejectedMtx.Lock()
+	defer mp.rejectedMtx.Unlock()
+
+	rejected := mp.lookupRejected(tx.Hash())
+	if rejected == nil || rejected.FullHash != tx.MsgTx().TxHashFull() {
+		return nil
+	}
+	rejectedCopy := *rejected
+	return &rejectedCopy
+}
+
+// ClearRejected forgets all recently rejected transactions.  It is intended to
+// be called when a new block is connected since that might change whether or
+// not they are valid.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) ClearRejected() {
+	mp.rejectedMtx.Lock()
+	mp.rejected = make(map[chainhash.Hash]*RejectedTx)
+	mp.rejectedMtx.Unlock()
+}
+
+// RecentlyRejected returns the unexpired recently rejected transactions
+// ordered from the oldest to most recent rejection.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) RecentlyRejected() []RejectedTx {
+	now := mp.cfg.Clock.Now()
+	mp.rejectedMtx.Lock()
+	result := make([]RejectedTx, 0, len(mp.rejected))
+	for hash, rejected := range mp.rejected {
+		if now.After(rejected.Expires) {
+			delete(mp.rejected, hash)
+			continue
+		}
+		result = append(result, *rejected)
+	}
+	mp.rejectedMtx.Unlock()
+
+	sort.Sort(rejectedTxSorter(result))
+	return result
+}
+
+// rejectedTxSorter implements sort.Interface to allow a slice of rejected
+// transactions to be sorted by the time they were rejected.
+type rejectedTxSorter []RejectedTx
+
+// Len returns the number of rejected transactions in the slice.  It is part of
+// the sort.Interface implementation.
+func (s rejectedTxSorter) Len() int {
+	return len(s)
+}
+
+// Swap swaps the rejected transactions at the passed indices.  It is part of
+// the sort.Interface implementation.
+func (s rejectedTxSorter) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Less returns whether the rejected transaction with index i should sort
+// before the rejected transaction with index j.  It is part of the
+// sort.Interface implementation.
+func (s rejectedTxSorter) Less(i, j int) bool {
+	return s[i].Rejected.Before(s[j].Rejected)
+}
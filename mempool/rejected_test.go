@@ -0,0 +1,135 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// TestRecordRejected ensures only rule violations are remembered, that the
+// original rejection is kept when a transaction is rejected again, and that
+// rejections which have expired are forgotten.
+func TestRecordRejected(t *testing.T) {
+	t.Parallel()
+
+	clock := blockchain.NewWarpClock(blockchain.SystemClock())
+	mp := newTestPool(clock, Policy{})
+	invalid := txRuleError(wire.RejectInvalid, "invalid")
+
+	// Ensure errors which do not say anything about the validity of the
+	// transaction are not remembered.
+	tx := newTestTx(1)
+	mp.RecordRejected(tx, errors.New("database failure"))
+	mp.RecordRejected(tx, txRuleError(wire.RejectDuplicate, "duplicate"))
+	if mp.HaveRejectedHash(tx.Hash()) {
+		t.Fatal("RecordRejected: remembered transaction which does not " +
+			"violate the rules")
+	}
+
+	// Ensure rule violations are remembered along with the reject code and
+	// that rejecting the transaction again keeps the original rejection.
+	mp.RecordRejected(tx, invalid)
+	clock.Warp(time.Minute)
+	mp.RecordRejected(tx, txRuleError(wire.RejectNonstandard, "nonstandard"))
+	rejected := mp.HaveRejected(tx)
+	if rejected == nil {
+		t.Fatal("HaveRejected: rejected transaction not remembered")
+	}
+	if rejected.RejectCode != wire.RejectInvalid || rejected.Seen != 1 {
+		t.Fatalf("HaveRejected: unexpected rejection %+v", rejected)
+	}
+	if got := mp.RecentlyRejected(); len(got) != 1 || got[0].Hash != *tx.Hash() {
+		t.Fatalf("RecentlyRejected: unexpected rejections %+v", got)
+	}
+
+	// Ensure the rejection expires.
+	clock.Warp(rejectedTxExpiry)
+	if mp.HaveRejectedHash(tx.Hash()) {
+		t.Fatal("HaveRejectedHash: rejection did not expire")
+	}
+	if got := mp.RecentlyRejected(); len(got) != 0 {
+		t.Fatalf("RecentlyRejected: unexpected rejections %+v", got)
+	}
+
+	// Ensure connected blocks forget all rejections.
+	mp.RecordRejected(tx, invalid)
+	mp.ClearRejected()
+	if mp.HaveRejectedHash(tx.Hash()) {
+		t.Fatal("ClearRejected: rejection not forgotten")
+	}
+}
+
+// TestRejectedFullHash ensures a rejected transaction only blocks processing the
+// exact same transaction including its witness data, so a malleated version of
+// a valid transaction does not prevent the valid version from being accepted.
+func TestRejectedFullHash(t *testing.T) {
+	t.Parallel()
+
+	mp := newTestPool(nil, Policy{})
+	tx := newTestTx(1)
+	malleatedTx := tx.MsgTx().Copy()
+	malleatedTx.TxIn[0].SignatureScript = []byte{0x00}
+	malleated := dcrutil.NewTx(malleatedTx)
+	if *malleated.Hash() != *tx.Hash() {
+		t.Fatal("malleated transaction has a different hash")
+	}
+
+	mp.RecordRejected(malleated, txRuleError(wire.RejectInvalid, "invalid"))
+	if !mp.HaveRejectedHash(tx.Hash()) {
+		t.Fatal("HaveRejectedHash: rejected transaction not remembered")
+	}
+	if rejected := mp.HaveRejected(tx); rejected != nil {
+		t.Fatalf("HaveRejected: unmalleated transaction reported as "+
+			"rejected: %+v", rejected)
+	}
+	if rejected := mp.HaveRejected(malleated); rejected == nil {
+		t.Fatal("HaveRejected: malleated transaction not reported as " +
+			"rejected")
+	}
+
+	// Ensure rejecting the unmalleated version replaces the rejection.
+	mp.RecordRejected(tx, txRuleError(wire.RejectInvalid, "invalid"))
+	if mp.HaveRejected(tx) == nil || mp.HaveRejected(malleated) != nil {
+		t.Fatal("RecordRejected: rejection of malleated transaction not " +
+			"replaced")
+	}
+}
+
+// TestRejectedEviction ensures the oldest rejected transaction is evicted when
+// the maximum number of rejected transactions would be exceeded.
+func TestRejectedEviction(t *testing.T) {
+	t.Parallel()
+
+	clock := blockchain.NewWarpClock(blockchain.SystemClock())
+	mp := newTestPool(clock, Policy{})
+	invalid := txRuleError(wire.RejectInvalid, "invalid")
+	txns := make([]*dcrutil.Tx, 0, maxRejectedTxns+1)
+	for i := 0; i < maxRejectedTxns+1; i++ {
+		tx := newTestTx(int64(i))
+		mp.RecordRejected(tx, invalid)
+		txns = append(txns, tx)
+		clock.Warp(time.Millisecond)
+	}
+
+	if len(mp.rejected) != maxRejectedTxns {
+		t.Fatalf("RecordRejected: got %d rejected transactions, want %d",
+			len(mp.rejected), maxRejectedTxns)
+	}
+	if mp.HaveRejectedHash(txns[0].Hash()) {
+		t.Fatal("RecordRejected: oldest rejected transaction not evicted")
+	}
+	for _, tx := range txns[1:] {
+		if !mp.HaveRejectedHash(tx.Hash()) {
+			t.Fatalf("RecordRejected: rejected transaction %v evicted",
+				tx.Hash())
+		}
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// maxAncestorCount is the maximum number of transactions in the pool a
+	// transaction and all of its ancestors may consist of.
+	maxAncestorCount = 25
+
+	// maxAncestorSize is the maximum serialized size in bytes of a
+	// transaction and all of its ancestors in the pool combined.
+	maxAncestorSize = 101000
+
+	// maxDescendantCount is the maximum number of transactions in the pool
+	// a transaction and all of its descendants may consist of.
+	maxDescendantCount = 25
+
+	// maxDescendantSize is the maximum serialized size in bytes of a
+	// transaction and all of its descendants in the pool combined.
+	maxDescendantSize = 101000
+)
+
+// RelativeStats houses the number of transactions, their combined serialized
+// size, and their combined fees in atoms of either all ancestors or all
+// descendants of a transaction in the pool.
+type RelativeStats struct {
+	Count int
+	Size  int64
+	Fees  int64
+}
+
+// add includes the passed transaction in the stats.
+func (s *RelativeStats) add(txDesc *TxDesc) {
+	s.Count++
+	s.Size += int64(txDesc.Tx.MsgTx().SerializeSize())
+	s.Fees += txDesc.Fee
+}
+
+// remove excludes the passed transaction from the stats.
+func (s *RelativeStats) remove(txDesc *TxDesc) {
+	s.Count--
+	s.Size -= int64(txDesc.Tx.MsgTx().SerializeSize())
+	s.Fees -= txDesc.Fee
+}
+
+// relativeStats houses the tracked stats of the ancestors and descendants of a
+// transaction in the pool, which do not include the transaction itself.
+type relativeStats struct {
+	ancestors   RelativeStats
+	descendants RelativeStats
+}
+
+// calcRelativeStats returns the stats of the ancestors and descendants of the
+// passed transaction by walking the pool.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) calcRelativeStats(txDesc *TxDesc) *relativeStats {
+	stats := new(relativeStats)
+	for _, ancestor := range mp.ancestors(txDesc.Tx) {
+		stats.ancestors.add(ancestor)
+	}
+	for _, descendant := range mp.descendants(txDesc.Tx) {
+		stats.descendants.add(descendant)
+	}
+	return stats
+}
+
+// recalcRelativeStats replaces the tracked stats of the passed transactions
+// with stats calculated by walking the pool.  Transactions which are not
+// tracked are skipped.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) recalcRelativeStats(descs []*TxDesc) {
+	for _, desc := range descs {
+		hash := *desc.Tx.Hash()
+		if _, ok := mp.relatives[hash]; ok {
+			mp.relatives[hash] = mp.calcRelativeStats(desc)
+		}
+	}
+}
+
+// addRelatives starts tracking the stats of the ancestors and descendants of
+// the passed transaction which was just added to the pool, and includes it in
+// the tracked stats of its relatives.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) addRelatives(txDesc *TxDesc) {
+	ancestors := mp.ancestors(txDesc.Tx)
+	descendants := mp.descendants(txDesc.Tx)
+
+	// A new transaction usually has no descendants, so it only becomes a
+	// descendant of its ancestors.  Transactions added back to the pool
+	// from disconnected blocks may be spent by transactions in the pool
+	// though, which can relate ancestors and descendants of the transaction
+	// which were related through other transactions already, so all of
+	// their stats are calculated again instead.
+	stats := new(relativeStats)
+	mp.relatives[*txDesc.Tx.Hash()] = stats
+	if len(descendants) > 0 {
+		mp.recalcRelativeStats(ancestors)
+		mp.recalcRelativeStats([]*TxDesc{txDesc})
+		mp.recalcRelativeStats(descendants)
+		return
+	}
+	for _, ancestor := range ancestors {
+		stats.ancestors.add(ancestor)
+		if ancestorStats, ok := mp.relatives[*ancestor.Tx.Hash()]; ok {
+			ancestorStats.descendants.add(txDesc)
+		}
+	}
+}
+
+// removeRelatives stops tracking the stats of the passed transaction which was
+// just removed from the pool, and excludes it from the tracked stats of its
+// passed former ancestors and descendants.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) removeRelatives(txDesc *TxDesc, ancestors, descendants []*TxDesc) {
+	delete(mp.relatives, *txDesc.Tx.Hash())
+
+	// The ancestors and descendants of the transaction may remain related
+	// through other transactions, so their stats are calculated again when
+	// it had both.
+	if len(ancestors) > 0 && len(descendants) > 0 {
+		mp.recalcRelativeStats(ancestors)
+		mp.recalcRelativeStats(descendants)
+		return
+	}
+	for _, ancestor := range ancestors {
+		if stats, ok := mp.relatives[*ancestor.Tx.Hash()]; ok {
+			stats.descendants.remove(txDesc)
+		}
+	}
+	for _, descendant := range descendants {
+		if stats, ok := mp.relatives[*descendant.Tx.Hash()]; ok {
+			stats.ancestors.remove(txDesc)
+		}
+	}
+}
+
+// checkRelativeLimits ensures that adding the passed transaction, which is not
+// in the pool yet, does not exceed the limits on the number and the combined
+// size of either its own ancestors or the descendants of any of its ancestors.
+// Limiting them bounds the work needed to walk the relatives of transactions
+// in the pool, such as when evicting them or selecting them for block
+// templates.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) checkRelativeLimits(tx *dcrutil.Tx) error {
+	ancestors := mp.ancestors(tx)
+	if len(ancestors) == 0 {
+		return nil
+	}
+
+	size := int64(tx.MsgTx().SerializeSize())
+	count, totalSize := 1+len(ancestors), size
+	for _, ancestor := range ancestors {
+		totalSize += int64(ancestor.Tx.MsgTx().SerializeSize())
+	}
+	if count > maxAncestorCount || totalSize > maxAncestorSize {
+		str := fmt.Sprintf("transaction %v has %d ancestors with a "+
+			"combined size of %d bytes including itself, which "+
+			"exceeds the limit of %d ancestors or %d bytes",
+			tx.Hash(), count, totalSize, maxAncestorCount,
+			maxAncestorSize)
+		return txRuleError(wire.RejectNonstandard, str)
+	}
+
+	// The stats of the descendants of every ancestor include neither the
+	// ancestor nor the new transaction.
+	for _, ancestor := range ancestors {
+		descendants := mp.relativeStatsFor(ancestor).descendants
+		count := descendants.Count + 2
+		totalSize := descendants.Size + size +
+			int64(ancestor.Tx.MsgTx().SerializeSize())
+		if count > maxDescendantCount || totalSize > maxDescendantSize {
+			str := fmt.Sprintf("transaction %v would give its "+
+				"ancestor %v %d descendants with a combined size "+
+				"of %d bytes including itself, which exceeds the "+
+				"limit of %d descendants or %d bytes", tx.Hash(),
+				ancestor.Tx.Hash(), count, totalSize,
+				maxDescendantCount, maxDescendantSize)
+			return txRuleError(wire.RejectNonstandard, str)
+		}
+	}
+	return nil
+}
+
+// relativeStatsFor returns the tracked stats of the ancestors and descendants
+// of the passed transaction in the pool, which are calculated when it is not
+// tracked.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) relativeStatsFor(txDesc *TxDesc) *relativeStats {
+	if stats, ok := mp.relatives[*txDesc.Tx.Hash()]; ok {
+		return stats
+	}
+	return mp.calcRelativeStats(txDesc)
+}
+
+// Relatives returns the stats of all ancestors and all descendants in the pool
+// of the transaction with the passed hash, which do not include the
+// transaction itself.  An error is returned when the transaction is not in the
+// pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Relatives(hash *chainhash.Hash) (ancestors, descendants RelativeStats, err error) {
+	mp.RLock()
+	defer mp.RUnlock()
+
+	txDesc, exists := mp.pool[*hash]
+	if !exists {
+		err := fmt.Errorf("transaction %v is not in the pool", hash)
+		return RelativeStats{}, RelativeStats{}, err
+	}
+	stats := mp.relativeStatsFor(txDesc)
+	return stats.ancestors, stats.descendants, nil
+}
+
+// mempoolEntry returns the passed transaction in the pool as a fully populated
+// JSON result.  The ancestor and descendant stats include the transaction
+// itself.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) mempoolEntry(txDesc *TxDesc) dcrjson.GetMempoolEntryResult {
+	size := int64(txDesc.Tx.MsgTx().SerializeSize())
+	stats := mp.relativeStatsFor(txDesc)
+	depends, spentBy := mp.txDependencies(txDesc.Tx)
+	return dcrjson.GetMempoolEntryResult{
+		TxID:            txDesc.Tx.Hash().String(),
+		Size:            int32(size),
+		Fee:             dcrutil.Amount(txDesc.Fee).ToCoin(),
+		Time:            txDesc.Added.Unix(),
+		Height:          txDesc.Height,
+		AncestorCount:   int64(stats.ancestors.Count) + 1,
+		AncestorSize:    stats.ancestors.Size + size,
+		AncestorFees:    dcrutil.Amount(stats.ancestors.Fees + txDesc.Fee).ToCoin(),
+		DescendantCount: int64(stats.descendants.Count) + 1,
+		DescendantSize:  stats.descendants.Size + size,
+		DescendantFees:  dcrutil.Amount(stats.descendants.Fees + txDesc.Fee).ToCoin(),
+		Depends:         depends,
+		SpentBy:         spentBy,
+	}
+}
+
+// mempoolEntries returns the passed transactions in the pool as fully populated
+// JSON results in the same order.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) mempoolEntries(descs []*TxDesc) []dcrjson.GetMempoolEntryResult {
+	entries := make([]dcrjson.GetMempoolEntryResult, 0, len(descs))
+	for _, desc := range descs {
+		entries = append(entries, mp.mempoolEntry(desc))
+	}
+	return entries
+}
+
+// MempoolEntry returns the transaction with the passed hash as a fully
+// populated JSON result, including the stats of its ancestors and descendants
+// in the pool.  An error is returned when the transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MempoolEntry(hash *chainhash.Hash) (*dcrjson.GetMempoolEntryResult, error) {
+	mp.RLock()
+	defer mp.RUnlock()
+
+	txDesc, exists := mp.pool[*hash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool", hash)
+	}
+	entry := mp.mempoolEntry(txDesc)
+	return &entry, nil
+}
+
+// AncestorEntries returns all ancestors in the pool of the transaction with the
+// passed hash as fully populated JSON results, ordered so that every
+// transaction follows all of its ancestors.  An error is returned when the
+// transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) AncestorEntries(hash *chainhash.Hash) ([]dcrjson.GetMempoolEntryResult, error) {
+	mp.RLock()
+	defer mp.RUnlock()
+
+	txDesc, exists := mp.pool[*hash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool", hash)
+	}
+	return mp.mempoolEntries(mp.ancestors(txDesc.Tx)), nil
+}
+
+// DescendantEntries returns all descendants in the pool of the transaction with
+// the passed hash as fully populated JSON results, ordered so that every
+// transaction follows all of its ancestors among them.  An error is returned
+// when the transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) DescendantEntries(hash *chainhash.Hash) ([]dcrjson.GetMempoolEntryResult, error) {
+	mp.RLock()
+	defer mp.RUnlock()
+
+	txDesc, exists := mp.pool[*hash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool", hash)
+	}
+	return mp.mempoolEntries(mp.descendants(txDesc.Tx)), nil
+}
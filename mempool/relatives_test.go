@@ -0,0 +1,187 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/mining"
+	"github.com/decred/dcrutil"
+)
+
+// TestRelativeStats ensures the tracked stats of the ancestors and descendants
+// of every transaction in the pool match the stats calculated by walking the
+// pool as transactions are added, including transactions added before the
+// transactions they spend, and removed with and without their descendants.
+func TestRelativeStats(t *testing.T) {
+	t.Parallel()
+
+	mp := newTestPool(nil, Policy{})
+	addTx := func(tx *dcrutil.Tx, fee int64) {
+		txDesc := &TxDesc{
+			TxDesc: mining.TxDesc{
+				Tx:   tx,
+				Type: stake.TxTypeRegular,
+				Fee:  fee,
+			},
+		}
+		mp.pool[*tx.Hash()] = txDesc
+		for _, txIn := range tx.MsgTx().TxIn {
+			mp.outpoints.Add(&txIn.PreviousOutPoint, tx)
+		}
+		mp.addRelatives(txDesc)
+	}
+	checkStats := func(desc string) {
+		if len(mp.relatives) != len(mp.pool) {
+			t.Fatalf("%s: unexpected number of tracked transactions - "+
+				"got %d, want %d", desc, len(mp.relatives),
+				len(mp.pool))
+		}
+		for hash, txDesc := range mp.pool {
+			stats, ok := mp.relatives[hash]
+			if !ok {
+				t.Fatalf("%s: transaction %v not tracked", desc, hash)
+			}
+			want := mp.calcRelativeStats(txDesc)
+			if *stats != *want {
+				t.Fatalf("%s: unexpected stats for transaction %v - "+
+					"got %+v, want %+v", desc, hash, *stats, *want)
+			}
+		}
+	}
+
+	// Create a diamond where the two transactions spending the outputs of
+	// the root are both spent by the same transaction, along with a child
+	// of that transaction.
+	root := newTestTx(1)
+	left := newTestTx(2, testOutPoint(root, 0))
+	right := newTestTx(3, testOutPoint(root, 1))
+	joined := newTestTx(4, testOutPoint(left, 0), testOutPoint(right, 0))
+	leaf := newTestTx(5, testOutPoint(joined, 0))
+
+	// Add the leaf and the transaction joining the diamond before the rest
+	// of it as happens when the rest is added back to the pool from a
+	// disconnected block.
+	addTx(leaf, 5000)
+	checkStats("add leaf")
+	addTx(joined, 4000)
+	checkStats("add joined")
+	addTx(left, 2000)
+	checkStats("add left")
+	addTx(root, 1000)
+	checkStats("add root")
+	addTx(right, 3000)
+	checkStats("add right")
+
+	// The stats of the root must include every other transaction once.
+	ancestors, descendants, err := mp.Relatives(root.Hash())
+	if err != nil {
+		t.Fatalf("Relatives: unexpected error: %v", err)
+	}
+	if ancestors.Count != 0 || descendants.Count != 4 ||
+		descendants.Fees != 14000 {
+
+		t.Fatalf("Relatives: unexpected stats for root - got %+v, %+v",
+			ancestors, descendants)
+	}
+
+	// The entry stats include the transaction itself.
+	entry, err := mp.MempoolEntry(joined.Hash())
+	if err != nil {
+		t.Fatalf("MempoolEntry: unexpected error: %v", err)
+	}
+	size := int64(joined.MsgTx().SerializeSize())
+	if entry.AncestorCount != 4 || entry.DescendantCount != 2 ||
+		entry.DescendantSize != size+int64(leaf.MsgTx().SerializeSize()) ||
+		entry.AncestorFees != dcrutil.Amount(10000).ToCoin() {
+
+		t.Fatalf("MempoolEntry: unexpected entry for joined - got %+v",
+			entry)
+	}
+	if len(entry.Depends) != 2 || len(entry.SpentBy) != 1 {
+		t.Fatalf("MempoolEntry: unexpected dependencies for joined - "+
+			"got %v, %v", entry.Depends, entry.SpentBy)
+	}
+
+	// Remove one side of the diamond without its descendants, which keeps
+	// the root related to the rest through the other side, and then the
+	// other side along with all of its descendants.
+	mp.removeTransaction(left, false)
+	checkStats("remove left")
+	mp.removeTransaction(right, true)
+	checkStats("remove right")
+	if len(mp.pool) != 1 || !mp.isTransactionInPool(root.Hash()) {
+		t.Fatalf("removeTransaction: unexpected transactions in the pool")
+	}
+	mp.removeTransaction(root, true)
+	checkStats("remove root")
+
+	if _, err := mp.MempoolEntry(root.Hash()); err == nil {
+		t.Fatalf("MempoolEntry: no error for transaction not in the pool")
+	}
+}
+
+// TestRelativeLimits ensures transactions are rejected when adding them would
+// exceed the limits on the number of their ancestors or the number of the
+// descendants of any of their ancestors.
+func TestRelativeLimits(t *testing.T) {
+	t.Parallel()
+
+	mp := newTestPool(nil, Policy{})
+	addTx := func(tx *dcrutil.Tx) {
+		txDesc := &TxDesc{
+			TxDesc: mining.TxDesc{Tx: tx, Type: stake.TxTypeRegular},
+		}
+		mp.pool[*tx.Hash()] = txDesc
+		for _, txIn := range tx.MsgTx().TxIn {
+			mp.outpoints.Add(&txIn.PreviousOutPoint, tx)
+		}
+		mp.addRelatives(txDesc)
+	}
+
+	// Create a chain of one transaction less than the ancestor limit.
+	chain := []*dcrutil.Tx{newTestTx(1)}
+	if err := mp.checkRelativeLimits(chain[0]); err != nil {
+		t.Fatalf("checkRelativeLimits: unexpected error: %v", err)
+	}
+	addTx(chain[0])
+	for i := int64(1); i < maxAncestorCount-1; i++ {
+		tx := newTestTx(i+1, testOutPoint(chain[i-1], 0))
+		if err := mp.checkRelativeLimits(tx); err != nil {
+			t.Fatalf("checkRelativeLimits #%d: unexpected error: %v",
+				i, err)
+		}
+		addTx(tx)
+		chain = append(chain, tx)
+	}
+
+	// Ensure the transaction which reaches the ancestor limit is accepted
+	// while a transaction exceeding it is not.
+	last := chain[len(chain)-1]
+	atLimit := newTestTx(100, testOutPoint(last, 0))
+	if err := mp.checkRelativeLimits(atLimit); err != nil {
+		t.Fatalf("checkRelativeLimits: unexpected error at the ancestor "+
+			"limit: %v", err)
+	}
+	addTx(atLimit)
+	overLimit := newTestTx(101, testOutPoint(atLimit, 0))
+	if err := mp.checkRelativeLimits(overLimit); err == nil {
+		t.Fatal("checkRelativeLimits: accepted transaction exceeding the " +
+			"ancestor limit")
+	}
+
+	// Ensure a transaction which stays within the ancestor limit is still
+	// rejected when it exceeds the descendant limit of the first
+	// transaction of the chain.
+	sibling := newTestTx(102, testOutPoint(last, 1))
+	if len(mp.ancestors(sibling))+1 > maxAncestorCount {
+		t.Fatal("sibling exceeds the ancestor limit")
+	}
+	if err := mp.checkRelativeLimits(sibling); err == nil {
+		t.Fatal("checkRelativeLimits: accepted transaction exceeding the " +
+			"descendant limit")
+	}
+}
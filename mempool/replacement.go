@@ -0,0 +1,220 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// DefaultIncrementalRelayFee is the default fee rate in atoms/kB by
+	// which a replacement transaction must pay more than the transactions
+	// it replaces combined.  It covers the cost of relaying the replacement
+	// in addition to the replaced transactions.
+	DefaultIncrementalRelayFee = DefaultMinRelayTxFee
+
+	// DefaultMaxReplacedTxs is the default maximum number of transactions
+	// which are removed from the pool when a transaction is replaced,
+	// including all of the transactions which depend on it.
+	DefaultMaxReplacedTxs = 100
+
+	// maxReplaceableSequenceNum is the highest input sequence number which
+	// signals that the transaction may be replaced.  The two highest
+	// sequence numbers are reserved for transactions which opt out.
+	maxReplaceableSequenceNum = wire.MaxTxInSequenceNum - 2
+)
+
+// signalsReplacement returns whether or not the passed transaction opts in to
+// being replaced by a transaction which spends the same outputs, which is the
+// case when the sequence number of any of its inputs is low enough.
+func signalsReplacement(msgTx *wire.MsgTx) bool {
+	for _, txIn := range msgTx.TxIn {
+		if txIn.Sequence <= maxReplaceableSequenceNum {
+			return true
+		}
+	}
+	return false
+}
+
+// isReplaceable returns whether or not the passed transaction in the pool may
+// be replaced, which is the case when it or any of its ancestors in the pool
+// signal replaceability.  Only regular transactions can be replaced.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) isReplaceable(txDesc *TxDesc) bool {
+	if txDesc.Type != stake.TxTypeRegular {
+		return false
+	}
+	if signalsReplacement(txDesc.Tx.MsgTx()) {
+		return true
+	}
+	for _, ancestor := range mp.ancestors(txDesc.Tx) {
+		if signalsReplacement(ancestor.Tx.MsgTx()) {
+			return true
+		}
+	}
+	return false
+}
+
+// feeRate returns the fee rate in atoms/kB of a transaction with the passed fee
+// and serialized size.
+func feeRate(fee, serializedSize int64) int64 {
+	if serializedSize <= 0 {
+		return 0
+	}
+	return fee * 1000 / serializedSize
+}
+
+// checkReplacement checks whether or not the passed regular transaction, which
+// pays the passed fee and spends the passed outpoints already spent by other
+// transactions in the pool, may replace those transactions.  It returns the
+// directly conflicting transactions, which must be removed along with all of
+// the transactions which depend on them before the replacement is added.
+//
+// The conflicting transactions may only be replaced when:
+//   - all of them signal replaceability, either themselves or through one of
+//     their ancestors in the pool
+//   - the replacement pays a higher fee rate than each of them
+//   - no more than the configured maximum number of transactions is removed
+//     including the descendants, none of which may be a stake transaction
+//   - the replacement does not spend the outputs of any removed transaction
+//     and does not spend outputs of other transactions in the pool which the
+//     conflicting transactions do not already spend
+//   - the replacement pays at least the fees of all removed transactions plus
+//     the incremental relay fee for its own size
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) checkReplacement(tx *dcrutil.Tx, txFee int64, conflicts []Conflict) ([]*TxDesc, error) {
+	txHash := tx.Hash()
+	serializedSize := int64(tx.MsgTx().SerializeSize())
+	txFeeRate := feeRate(txFee, serializedSize)
+
+	var replaced []*TxDesc
+	for _, conflict := range conflicts {
+		txDesc, exists := mp.pool[conflict.TxHash]
+		if !exists || containsTxDesc(replaced, txDesc) {
+			continue
+		}
+		if !mp.isReplaceable(txDesc) {
+			str := fmt.Sprintf("transaction %v spends outputs already "+
+				"spent by transaction %v in the pool which does "+
+				"not signal replaceability", txHash, conflict.TxHash)
+			return nil, txConflictError(str, conflicts)
+		}
+		replacedFeeRate := feeRate(txDesc.Fee,
+			int64(txDesc.Tx.MsgTx().SerializeSize()))
+		if txFeeRate <= replacedFeeRate {
+			str := fmt.Sprintf("transaction %v has a fee rate of %d "+
+				"atoms/kB which does not exceed the fee rate of %d "+
+				"atoms/kB of transaction %v it replaces", txHash,
+				txFeeRate, replacedFeeRate, conflict.TxHash)
+			return nil, txRuleError(wire.RejectInsufficientFee, str)
+		}
+		replaced = append(replaced, txDesc)
+	}
+
+	// Gather all of the transactions which would be removed along with the
+	// replaced transactions.
+	evicted := make(map[chainhash.Hash]*TxDesc)
+	for _, txDesc := range replaced {
+		evicted[*txDesc.Tx.Hash()] = txDesc
+		for _, descendant := range mp.descendants(txDesc.Tx) {
+			evicted[*descendant.Tx.Hash()] = descendant
+		}
+	}
+	maxEvictions := mp.cfg.Policy.MaxReplacedTxs
+	if len(evicted) > maxEvictions {
+		str := fmt.Sprintf("transaction %v would replace %d transactions "+
+			"which is more than the maximum of %d", txHash,
+			len(evicted), maxEvictions)
+		return nil, txRuleError(wire.RejectNonstandard, str)
+	}
+	var evictedFee int64
+	for hash, txDesc := range evicted {
+		if txDesc.Type != stake.TxTypeRegular {
+			str := fmt.Sprintf("transaction %v would replace %v "+
+				"%v which can not be replaced", txHash,
+				txDesc.Type, hash)
+			return nil, txRuleError(wire.RejectNonstandard, str)
+		}
+		evictedFee += txDesc.Fee
+	}
+
+	// Only allow the replacement to spend outputs of the pool which are
+	// also spent by the transactions it replaces, since it could otherwise
+	// pay a higher fee rate while being less likely to be mined.
+	for _, txIn := range tx.MsgTx().TxIn {
+		prevHash := &txIn.PreviousOutPoint.Hash
+		if _, ok := evicted[*prevHash]; ok {
+			str := fmt.Sprintf("transaction %v spends outputs of "+
+				"transaction %v which it replaces", txHash, prevHash)
+			return nil, txRuleError(wire.RejectInvalid, str)
+		}
+		parent, exists := mp.pool[*prevHash]
+		if !exists {
+			continue
+		}
+		spentByReplaced := false
+		for _, txDesc := range replaced {
+			if containsTxDesc(mp.txParents(txDesc.Tx), parent) {
+				spentByReplaced = true
+				break
+			}
+		}
+		if !spentByReplaced {
+			str := fmt.Sprintf("transaction %v spends outputs of "+
+				"unconfirmed transaction %v which the transactions "+
+				"it replaces do not spend", txHash, prevHash)
+			return nil, txRuleError(wire.RejectNonstandard, str)
+		}
+	}
+
+	minFee := evictedFee + calcMinRequiredTxRelayFee(serializedSize,
+		mp.cfg.Policy.IncrementalRelayFee)
+	if txFee < minFee {
+		str := fmt.Sprintf("transaction %v has %v fees which is under "+
+			"the required amount of %v to replace %d transactions",
+			txHash, txFee, minFee, len(evicted))
+		return nil, txRuleError(wire.RejectInsufficientFee, str)
+	}
+
+	return replaced, nil
+}
+
+// replaceTransactions removes the passed transactions replaced by the passed
+// transaction from the pool along with all of the transactions which depend on
+// them, and reports every replaced transaction together with the outpoints it
+// shares with the replacement.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) replaceTransactions(tx *dcrutil.Tx, replaced []*TxDesc) {
+	for _, txDesc := range replaced {
+		removal := &ConflictRemoval{Tx: txDesc.Tx}
+		for _, replacedIn := range txDesc.Tx.MsgTx().TxIn {
+			for _, txIn := range tx.MsgTx().TxIn {
+				if txIn.PreviousOutPoint == replacedIn.PreviousOutPoint {
+					removal.Conflicts = append(removal.Conflicts,
+						Conflict{
+							OutPoint: txIn.PreviousOutPoint,
+							TxHash:   *tx.Hash(),
+						})
+					break
+				}
+			}
+		}
+
+		log.Debugf("Replacing transaction %v with transaction %v",
+			txDesc.Tx.Hash(), tx.Hash())
+		mp.removeTransaction(txDesc.Tx, true)
+		if mp.cfg.TxReplaced != nil {
+			mp.cfg.TxReplaced(removal)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/mining"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// newReplacementTestTx returns a copy of the passed transaction which pays the
+// passed value with its first output and whose inputs signal replaceability
+// when requested.  Any additional transactions have their first output spent by
+// the copy as well.
+func newReplacementTestTx(tx *dcrutil.Tx, value int64, signal bool, extra ...*dcrutil.Tx) *dcrutil.Tx {
+	msgTx := tx.MsgTx().Copy()
+	msgTx.TxOut[0].Value = value
+	for _, parent := range extra {
+		prevOut := wire.NewOutPoint(parent.Hash(), 0, wire.TxTreeRegular)
+		msgTx.AddTxIn(wire.NewTxIn(prevOut, nil))
+	}
+	if signal {
+		for _, txIn := range msgTx.TxIn {
+			txIn.Sequence = 0
+		}
+	}
+	return dcrutil.NewTx(msgTx)
+}
+
+// TestCheckReplacement ensures transactions may only replace conflicting
+// transactions which signal replaceability, either themselves or through their
+// ancestors, when they pay enough fees, do not remove too many transactions or
+// any stake transactions, and do not spend new unconfirmed outputs.
+func TestCheckReplacement(t *testing.T) {
+	t.Parallel()
+
+	mp := newTestPool(nil, Policy{
+		AcceptReplacement:   true,
+		IncrementalRelayFee: 1e4,
+		MaxReplacedTxs:      2,
+	})
+	addTx := func(tx *dcrutil.Tx, txType stake.TxType, fee int64) {
+		mp.pool[*tx.Hash()] = &TxDesc{
+			TxDesc: mining.TxDesc{Tx: tx, Type: txType, Fee: fee},
+		}
+		for _, txIn := range tx.MsgTx().TxIn {
+			mp.outpoints.Add(&txIn.PreviousOutPoint, tx)
+		}
+	}
+
+	// Create a signaling transaction with a child, a transaction which does
+	// not signal, a signaling transaction with a ticket spending it, a
+	// transaction which inherits replaceability from its parent, and an
+	// unrelated transaction.
+	original := newReplacementTestTx(newTestTx(1), 1, true)
+	child := newTestTx(2, testOutPoint(original, 0))
+	optOut := newTestTx(3)
+	ticketParent := newReplacementTestTx(newTestTx(4), 4, true)
+	ticket := newTestTx(5, testOutPoint(ticketParent, 0))
+	inheritParent := newReplacementTestTx(newTestTx(6), 6, true)
+	inherited := newTestTx(7, testOutPoint(inheritParent, 0))
+	unrelated := newTestTx(8)
+	addTx(original, stake.TxTypeRegular, 10000)
+	addTx(child, stake.TxTypeRegular, 1000)
+	addTx(optOut, stake.TxTypeRegular, 10000)
+	addTx(ticketParent, stake.TxTypeRegular, 10000)
+	addTx(ticket, stake.TxTypeSStx, 10000)
+	addTx(inheritParent, stake.TxTypeRegular, 10000)
+	addTx(inherited, stake.TxTypeRegular, 1000)
+	addTx(unrelated, stake.TxTypeRegular, 10000)
+
+	tests := []struct {
+		name     string
+		tx       *dcrutil.Tx
+		fee      int64
+		replaced *dcrutil.Tx
+	}{
+		{"replaces with descendants",
+			newReplacementTestTx(original, 2, false), 13000, original},
+		{"does not pay for descendants",
+			newReplacementTestTx(original, 2, false), 11000, nil},
+		{"lower fee rate",
+			newReplacementTestTx(original, 2, false), 9000, nil},
+		{"conflict opts out",
+			newReplacementTestTx(optOut, 2, false), 20000, nil},
+		{"ticket descendant",
+			newReplacementTestTx(ticketParent, 2, false), 40000, nil},
+		{"inherits replaceability",
+			newReplacementTestTx(inherited, 2, false), 2000, inherited},
+		{"spends replaced descendant",
+			newReplacementTestTx(original, 2, false, child), 20000, nil},
+		{"spends new unconfirmed output",
+			newReplacementTestTx(original, 2, false, unrelated), 20000,
+			nil},
+	}
+	for _, test := range tests {
+		err := mp.checkPoolDoubleSpend(test.tx, stake.TxTypeRegular)
+		if err == nil {
+			t.Errorf("%s: transaction does not conflict", test.name)
+			continue
+		}
+		conflicts := err.(RuleError).Err.(TxRuleError).Conflicts
+
+		replaced, err := mp.checkReplacement(test.tx, test.fee, conflicts)
+		if test.replaced == nil {
+			if _, ok := err.(RuleError); !ok {
+				t.Errorf("%s: unexpected error - got %v, want "+
+					"RuleError", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if len(replaced) != 1 || replaced[0].Tx != test.replaced {
+			t.Errorf("%s: unexpected replaced transactions %v",
+				test.name, replaced)
+		}
+	}
+
+	// Ensure the descendants count towards the maximum number of replaced
+	// transactions.
+	mp.cfg.Policy.MaxReplacedTxs = 1
+	replacement := newReplacementTestTx(original, 2, false)
+	err := mp.checkPoolDoubleSpend(replacement, stake.TxTypeRegular)
+	conflicts := err.(RuleError).Err.(TxRuleError).Conflicts
+	if _, err := mp.checkReplacement(replacement, 20000, conflicts); err == nil {
+		t.Fatal("checkReplacement: no error for too many replaced " +
+			"transactions")
+	}
+
+	// Ensure replacing removes the replaced transaction along with its
+	// descendants.
+	mp.replaceTransactions(replacement, []*TxDesc{mp.pool[*original.Hash()]})
+	if mp.isTransactionInPool(original.Hash()) ||
+		mp.isTransactionInPool(child.Hash()) {
+
+		t.Fatal("replaceTransactions: replaced transactions still in pool")
+	}
+}
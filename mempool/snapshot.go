@@ -0,0 +1,126 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/mining"
+)
+
+// Snapshot is an immutable view of the transactions and votes in the memory
+// pool at the time it was created.  It allows block templates to be built from
+// a consistent set of transactions without holding the pool locks, so the pool
+// continues to accept transactions and votes while a template is built.
+//
+// The transactions referenced by a snapshot are shared with the pool and must
+// be treated as read only.
+type Snapshot struct {
+	lastUpdated     time.Time
+	descs           []*mining.TxDesc
+	txns            map[chainhash.Hash]struct{}
+	orphans         map[chainhash.Hash]struct{}
+	votes           map[chainhash.Hash][]*VoteTx
+	ticketsPerBlock uint16
+}
+
+// Ensure the Snapshot type implements the mining.TxSource interface.
+var _ mining.TxSource = (*Snapshot)(nil)
+
+// Snapshot returns an immutable view of the transactions and votes currently
+// in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Snapshot() *Snapshot {
+	mp.RLock()
+	s := &Snapshot{
+		lastUpdated:     mp.LastUpdated(),
+		descs:           make([]*mining.TxDesc, 0, len(mp.pool)),
+		txns:            make(map[chainhash.Hash]struct{}, len(mp.pool)),
+		orphans:         make(map[chainhash.Hash]struct{}, mp.orphans.Count()),
+		ticketsPerBlock: mp.cfg.ChainParams.TicketsPerBlock,
+	}
+	for hash, desc := range mp.pool {
+		s.descs = append(s.descs, &desc.TxDesc)
+		s.txns[hash] = struct{}{}
+	}
+	mp.orphans.ForEach(func(hash *chainhash.Hash) bool {
+		s.orphans[*hash] = struct{}{}
+		return true
+	})
+	mp.RUnlock()
+
+	mp.votesMtx.Lock()
+	s.votes = make(map[chainhash.Hash][]*VoteTx, len(mp.votes))
+	for hash, vts := range mp.votes {
+		votesCopy := make([]*VoteTx, len(vts))
+		copy(votesCopy, vts)
+		s.votes[hash] = votesCopy
+	}
+	mp.votesMtx.Unlock()
+
+	return s
+}
+
+// LastUpdated returns the last time a transaction was added to or removed from
+// the main pool as of the time the snapshot was created.
+//
+// This is part of the mining.TxSource interface implementation.
+func (s *Snapshot) LastUpdated() time.Time {
+	return s.lastUpdated
+}
+
+// MiningDescs returns a slice of mining descriptors for all the transactions
+// in the snapshot.
+//
+// This is part of the mining.TxSource interface implementation.
+func (s *Snapshot) MiningDescs() []*mining.TxDesc {
+	descs := make([]*mining.TxDesc, len(s.descs))
+	copy(descs, s.descs)
+	return descs
+}
+
+// HaveTransaction returns whether or not the passed transaction exists in the
+// main pool or in the orphan pool of the snapshot.
+//
+// This is part of the mining.TxSource interface implementation.
+func (s *Snapshot) HaveTransaction(hash *chainhash.Hash) bool {
+	if _, exists := s.txns[*hash]; exists {
+		return true
+	}
+	_, exists := s.orphans[*hash]
+	return exists
+}
+
+// CheckIfTxsExist returns whether or not all of the passed transaction hashes
+// exist in the main pool of the snapshot.
+func (s *Snapshot) CheckIfTxsExist(hashes []chainhash.Hash) bool {
+	for _, h := range hashes {
+		if _, exists := s.txns[h]; !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// VotesForBlocks returns the vote metadata for all votes in the snapshot on the
+// provided block hashes.
+func (s *Snapshot) VotesForBlocks(hashes []chainhash.Hash) [][]*VoteTx {
+	result := make([][]*VoteTx, 0, len(hashes))
+	for _, hash := range hashes {
+		votes := s.votes[hash]
+		votesCopy := make([]*VoteTx, len(votes))
+		copy(votesCopy, votes)
+		result = append(result, votesCopy)
+	}
+	return result
+}
+
+// IsTxTreeValid returns whether or not the votes in the snapshot on the block
+// with the passed hash indicate its regular transaction tree is valid.
+func (s *Snapshot) IsTxTreeValid(hash *chainhash.Hash) bool {
+	return txTreeValid(s.votes[*hash], s.ticketsPerBlock)
+}
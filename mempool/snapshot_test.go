@@ -0,0 +1,71 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/mining"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// TestSnapshot ensures a snapshot of the pool reflects the transactions and
+// votes in the pool when it was created and is not affected by later changes
+// to the pool.
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	mp := newTestPool(nil, Policy{})
+	newTx := func(lockTime uint32) *dcrutil.Tx {
+		msgTx := wire.NewMsgTx()
+		msgTx.LockTime = lockTime
+		return dcrutil.NewTx(msgTx)
+	}
+	addTx := func(tx *dcrutil.Tx) {
+		mp.pool[*tx.Hash()] = &TxDesc{
+			TxDesc: mining.TxDesc{Tx: tx, Type: stake.TxTypeRegular},
+		}
+	}
+	tx1, tx2, orphan := newTx(1), newTx(2), newTx(3)
+	addTx(tx1)
+	mp.orphans.Add(orphan)
+	blockHash := chainhash.Hash{0x01}
+	mp.votes[blockHash] = []*VoteTx{{Vote: false}}
+
+	snapshot := mp.Snapshot()
+
+	// Modify the pool after the snapshot was created.
+	addTx(tx2)
+	delete(mp.pool, *tx1.Hash())
+	mp.orphans.Remove(orphan.Hash())
+	mp.votes[blockHash] = append(mp.votes[blockHash], &VoteTx{Vote: true})
+
+	descs := snapshot.MiningDescs()
+	if len(descs) != 1 || descs[0].Tx != tx1 {
+		t.Fatalf("MiningDescs: unexpected descriptors %v", descs)
+	}
+	if !snapshot.HaveTransaction(tx1.Hash()) {
+		t.Fatal("HaveTransaction: missing transaction from snapshot")
+	}
+	if !snapshot.HaveTransaction(orphan.Hash()) {
+		t.Fatal("HaveTransaction: missing orphan from snapshot")
+	}
+	if snapshot.HaveTransaction(tx2.Hash()) {
+		t.Fatal("HaveTransaction: transaction added after snapshot")
+	}
+	if snapshot.CheckIfTxsExist([]chainhash.Hash{*tx1.Hash(), *tx2.Hash()}) {
+		t.Fatal("CheckIfTxsExist: transaction added after snapshot")
+	}
+	if !snapshot.CheckIfTxsExist([]chainhash.Hash{*tx1.Hash()}) {
+		t.Fatal("CheckIfTxsExist: missing transaction from snapshot")
+	}
+	votes := snapshot.VotesForBlocks([]chainhash.Hash{blockHash})
+	if len(votes) != 1 || len(votes[0]) != 1 {
+		t.Fatalf("VotesForBlocks: unexpected votes %v", votes)
+	}
+}
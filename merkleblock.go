@@ -0,0 +1,161 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+	"github.com/decred/dcrutil/bloom"
+)
+
+// partialMerkleTree is used to house intermediate information needed to
+// generate the hashes and flag bits of a partial merkle tree for a single
+// transaction tree of a block.
+type partialMerkleTree struct {
+	numTx       uint32
+	allHashes   []*chainhash.Hash
+	finalHashes []*chainhash.Hash
+	matchedBits []byte
+	bits        []byte
+}
+
+// calcTreeWidth calculates and returns the number of nodes (width) of a merkle
+// tree at the given depth-first height.
+func (m *partialMerkleTree) calcTreeWidth(height uint32) uint32 {
+	return (m.numTx + (1 << height) - 1) >> height
+}
+
+// calcHash returns the hash for a sub-tree given a depth-first height and
+// node position.
+func (m *partialMerkleTree) calcHash(height, pos uint32) *chainhash.Hash {
+	if height == 0 {
+		return m.allHashes[pos]
+	}
+
+	var right *chainhash.Hash
+	left := m.calcHash(height-1, pos*2)
+	if pos*2+1 < m.calcTreeWidth(height-1) {
+		right = m.calcHash(height-1, pos*2+1)
+	} else {
+		right = left
+	}
+	return blockchain.HashMerkleBranches(left, right)
+}
+
+// traverseAndBuild builds a partial merkle tree using a recursive depth-first
+// approach.  As it calculates the hashes, it also saves whether or not each
+// node is a parent node and a list of final hashes to be included in the
+// merkle block.
+func (m *partialMerkleTree) traverseAndBuild(height, pos uint32) {
+	// Determine whether this node is a parent of a matched node.
+	var isParent byte
+	for i := pos << height; i < (pos+1)<<height && i < m.numTx; i++ {
+		isParent |= m.matchedBits[i]
+	}
+	m.bits = append(m.bits, isParent)
+
+	// When the node is a leaf node or not a parent of a matched node,
+	// append the hash to the list that will be part of the final merkle
+	// block.
+	if height == 0 || isParent == 0x00 {
+		m.finalHashes = append(m.finalHashes, m.calcHash(height, pos))
+		return
+	}
+
+	// At this point, the node is an internal node and it is the parent of
+	// an included leaf node.
+
+	// Descend into the left child and process its sub-tree.
+	m.traverseAndBuild(height-1, pos*2)
+
+	// Descend into the right child and process its sub-tree if
+	// there is one.
+	if pos*2+1 < m.calcTreeWidth(height-1) {
+		m.traverseAndBuild(height-1, pos*2+1)
+	}
+}
+
+// filterTxTree matches the passed transactions, which make up one of the
+// transaction trees of a block, against the passed filter and returns the
+// partial merkle tree of the transaction tree along with the indices of the
+// matched transactions.  The filter is updated as transactions are matched.
+func filterTxTree(txns []*dcrutil.Tx, filter *bloom.Filter) (*partialMerkleTree, []uint32) {
+	numTx := uint32(len(txns))
+	tree := partialMerkleTree{
+		numTx:       numTx,
+		allHashes:   make([]*chainhash.Hash, 0, numTx),
+		matchedBits: make([]byte, 0, numTx),
+	}
+
+	// Find and keep track of any transactions that match the filter.  The
+	// leaves of the transaction trees commit to the full transaction
+	// hashes.
+	var matchedIndices []uint32
+	for txIndex, tx := range txns {
+		if filter.MatchTxAndUpdate(tx) {
+			tree.matchedBits = append(tree.matchedBits, 0x01)
+			matchedIndices = append(matchedIndices, uint32(txIndex))
+		} else {
+			tree.matchedBits = append(tree.matchedBits, 0x00)
+		}
+		txHashFull := tx.MsgTx().TxHashFull()
+		tree.allHashes = append(tree.allHashes, &txHashFull)
+	}
+
+	// An empty transaction tree does not have any nodes.
+	if numTx == 0 {
+		return &tree, matchedIndices
+	}
+
+	// Calculate the number of merkle branches (height) in the tree.
+	height := uint32(0)
+	for tree.calcTreeWidth(height) > 1 {
+		height++
+	}
+
+	// Build the depth-first partial merkle tree.
+	tree.traverseAndBuild(height, 0)
+	return &tree, matchedIndices
+}
+
+// appendFlagBits packs the passed flag bits into bytes, least significant bit
+// first, and appends them to the passed flags.
+func appendFlagBits(flags []byte, bits []byte) []byte {
+	packed := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		packed[i/8] |= bit << (uint(i) % 8)
+	}
+	return append(flags, packed...)
+}
+
+// newFilteredMerkleBlock returns a new merkleblock message for the passed
+// block which includes partial merkle trees for both the regular and stake
+// transaction trees filtered according to the passed filter, along with the
+// indices of the regular and stake transactions that matched the filter.
+//
+// The flag bits of the regular transaction tree are followed by the flag bits
+// of the stake transaction tree, which start at the next byte boundary.
+func newFilteredMerkleBlock(block *dcrutil.Block, filter *bloom.Filter) (*wire.MsgMerkleBlock, []uint32, []uint32) {
+	regularTree, matchedTxIndices := filterTxTree(block.Transactions(),
+		filter)
+	stakeTree, matchedSTxIndices := filterTxTree(block.STransactions(),
+		filter)
+
+	msgMerkleBlock := wire.MsgMerkleBlock{
+		Header:        block.MsgBlock().Header,
+		Transactions:  regularTree.numTx,
+		Hashes:        regularTree.finalHashes,
+		STransactions: stakeTree.numTx,
+		SHashes:       stakeTree.finalHashes,
+	}
+	msgMerkleBlock.Flags = appendFlagBits(nil, regularTree.bits)
+	msgMerkleBlock.Flags = appendFlagBits(msgMerkleBlock.Flags,
+		stakeTree.bits)
+
+	return &msgMerkleBlock, matchedTxIndices, matchedSTxIndices
+}
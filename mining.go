@@ -56,7 +56,9 @@ const (
 
 // txPrioItem houses a transaction along with extra information that allows the
 // transaction to be prioritized and track dependencies on other transactions
-// which have not been mined into a block yet.
+// which have not been mined into a block yet.  The fee per kilobyte is that of
+// the package the transaction is expected to be mined with as opposed to its
+// own, so transactions paying for their ancestors are accounted for.
 type txPrioItem struct {
 	tx       *dcrutil.Tx
 	txType   stake.TxType
@@ -279,6 +281,185 @@ func newTxPriorityQueue(reserve int, lessFunc func(*txPriorityQueue, int,
 	return pq
 }
 
+// maxPackageAncestors is the maximum number of ancestors a transaction may have
+// for its fees to be attributed to them when selecting transactions for a block
+// as part of a package.  It matches the ancestor limit of the memory pool, which
+// also includes the transaction itself, and bounds the work needed to determine
+// the packages regardless of the source of the transactions.
+const maxPackageAncestors = 24
+
+// packageTx houses a transaction considered for inclusion in a block along
+// with the state needed to select it as part of a package with its ancestors in
+// the source pool.
+type packageTx struct {
+	item        *txPrioItem
+	size        int64
+	ancestors   map[*packageTx]struct{}
+	descendants []*packageTx
+	visited     bool
+	selected    bool
+
+	// packageFee and packageSize are the combined fee and size of the
+	// transaction and all of its ancestors which have not been selected as
+	// part of another package yet.
+	packageFee  int64
+	packageSize int64
+}
+
+// packageFeePerKB returns the fee per kilobyte of the transaction together with
+// all of its ancestors which have not been selected yet.
+func (ptx *packageTx) packageFeePerKB() float64 {
+	return float64(ptx.packageFee) * float64(kilobyte) /
+		float64(ptx.packageSize)
+}
+
+// packageCandidate is an entry of a packageQueue.  Entries whose fee per
+// kilobyte no longer matches the package of their transaction are stale, since
+// a new entry was queued when the package changed.
+type packageCandidate struct {
+	ptx      *packageTx
+	feePerKB float64
+}
+
+// packageQueue implements a priority queue of package candidates ordered by
+// their fees per kilobyte in descending order.
+type packageQueue []packageCandidate
+
+// Len returns the number of candidates in the queue.  It is part of the
+// heap.Interface implementation.
+func (pq packageQueue) Len() int { return len(pq) }
+
+// Less returns whether the candidate with index i has a higher fee per kilobyte
+// than the candidate with index j.  It is part of the heap.Interface
+// implementation.
+func (pq packageQueue) Less(i, j int) bool { return pq[i].feePerKB > pq[j].feePerKB }
+
+// Swap swaps the candidates at the passed indices in the queue.  It is part of
+// the heap.Interface implementation.
+func (pq packageQueue) Swap(i, j int) { pq[i], pq[j] = pq[j], pq[i] }
+
+// Push pushes the passed candidate onto the queue.  It is part of the
+// heap.Interface implementation.
+func (pq *packageQueue) Push(x interface{}) {
+	*pq = append(*pq, x.(packageCandidate))
+}
+
+// Pop removes the candidate with the highest fee per kilobyte from the queue
+// and returns it.  It is part of the heap.Interface implementation.
+func (pq *packageQueue) Pop() interface{} {
+	old := *pq
+	n := len(old)
+	candidate := old[n-1]
+	*pq = old[:n-1]
+	return candidate
+}
+
+// calcPackageFeeRates sets the fee per kilobyte of the passed items, which are
+// all transactions considered for inclusion in a block, to the fee per kilobyte
+// of the package they are expected to be mined with, so that a transaction with
+// a high fee pulls in the ancestors it depends on.
+//
+// Packages are selected the way a miner maximizing fees would: the transaction
+// whose package of itself and all of its ancestors which were not already
+// selected has the highest fee per kilobyte is selected along with that
+// package, and the packages of its descendants no longer include the selected
+// transactions.  This repeats until all transactions are selected.
+//
+// Transactions which depend on transactions that are not among the items can
+// never be included in the block, so their fees are not attributed to their
+// ancestors and their fee per kilobyte is left unchanged.  The same applies to
+// transactions with more than maxPackageAncestors ancestors.
+func calcPackageFeeRates(items []*txPrioItem) {
+	ptxs := make(map[chainhash.Hash]*packageTx, len(items))
+	for _, item := range items {
+		size := int64(item.tx.MsgTx().SerializeSize())
+		ptxs[*item.tx.Hash()] = &packageTx{item: item, size: size}
+	}
+
+	// findAncestors sets the ancestors of the passed transaction and
+	// returns whether all of them are among the items and there are no more
+	// than the maximum allowed.
+	var findAncestors func(ptx *packageTx) bool
+	findAncestors = func(ptx *packageTx) bool {
+		if ptx.visited {
+			return ptx.ancestors != nil
+		}
+		ptx.visited = true
+
+		ancestors := make(map[*packageTx]struct{})
+		for hash := range ptx.item.dependsOn {
+			parent, ok := ptxs[hash]
+			if !ok || !findAncestors(parent) {
+				return false
+			}
+			ancestors[parent] = struct{}{}
+			for ancestor := range parent.ancestors {
+				ancestors[ancestor] = struct{}{}
+			}
+			if len(ancestors) > maxPackageAncestors {
+				return false
+			}
+		}
+		ptx.ancestors = ancestors
+		return true
+	}
+
+	queue := make(packageQueue, 0, len(ptxs))
+	for _, ptx := range ptxs {
+		if !findAncestors(ptx) {
+			continue
+		}
+		ptx.packageFee = ptx.item.fee
+		ptx.packageSize = ptx.size
+		for ancestor := range ptx.ancestors {
+			ptx.packageFee += ancestor.item.fee
+			ptx.packageSize += ancestor.size
+			ancestor.descendants = append(ancestor.descendants, ptx)
+		}
+		queue = append(queue, packageCandidate{ptx, ptx.packageFeePerKB()})
+	}
+	heap.Init(&queue)
+
+	for queue.Len() > 0 {
+		candidate := heap.Pop(&queue).(packageCandidate)
+		ptx := candidate.ptx
+		if ptx.selected || candidate.feePerKB != ptx.packageFeePerKB() {
+			continue
+		}
+
+		// Select the transaction along with its ancestors which were not
+		// selected yet.
+		pkg := []*packageTx{ptx}
+		for ancestor := range ptx.ancestors {
+			if !ancestor.selected {
+				pkg = append(pkg, ancestor)
+			}
+		}
+		for _, selected := range pkg {
+			selected.selected = true
+			selected.item.feePerKB = candidate.feePerKB
+		}
+
+		// Remove the selected transactions from the packages of their
+		// descendants and queue the updated packages.
+		updated := make(map[*packageTx]struct{})
+		for _, selected := range pkg {
+			for _, descendant := range selected.descendants {
+				if descendant.selected {
+					continue
+				}
+				descendant.packageFee -= selected.item.fee
+				descendant.packageSize -= selected.size
+				updated[descendant] = struct{}{}
+			}
+		}
+		for descendant := range updated {
+			heap.Push(&queue, packageCandidate{descendant,
+				descendant.packageFeePerKB()})
+		}
+	}
+}
+
 // containsTx is a helper function that checks to see if a list of transactions
 // contains any of the TxIns of some transaction.
 func containsTxIns(txs []*dcrutil.Tx, tx *dcrutil.Tx) bool {
@@ -325,10 +506,11 @@ func (b byNumberOfVotes) Less(i, j int) bool {
 // SortParentsByVotes takes a list of block header hashes and sorts them
 // by the number of votes currently available for them in the votes map of
 // mempool.  It then returns all blocks that are eligible to be used (have
-// at least a majority number of votes) sorted by number of votes, descending.
+// at least the passed minimum number of votes) sorted by number of votes,
+// descending.
 //
 // This function is safe for concurrent access.
-func SortParentsByVotes(mp *mempool.TxPool, currentTopBlock chainhash.Hash, blocks []chainhash.Hash, params *chaincfg.Params) []chainhash.Hash {
+func SortParentsByVotes(mp *mempool.TxPool, currentTopBlock chainhash.Hash, blocks []chainhash.Hash, minVotes uint16) []chainhash.Hash {
 	// Return now when no blocks were provided.
 	lenBlocks := len(blocks)
 	if lenBlocks == 0 {
@@ -338,12 +520,11 @@ func SortParentsByVotes(mp *mempool.TxPool, currentTopBlock chainhash.Hash, bloc
 	// Fetch the vote metadata for the provided block hashes from the
 	// mempool and filter out any blocks that do not have the minimum
 	// required number of votes.
-	minVotesRequired := (params.TicketsPerBlock / 2) + 1
 	voteMetadata := mp.VotesForBlocks(blocks)
 	filtered := make([]*blockWithNumVotes, 0, lenBlocks)
 	for i := range blocks {
 		numVotes := uint16(len(voteMetadata[i]))
-		if numVotes >= minVotesRequired {
+		if numVotes >= minVotes {
 			filtered = append(filtered, &blockWithNumVotes{
 				Hash:     blocks[i],
 				NumVotes: numVotes,
@@ -397,6 +578,42 @@ func SortParentsByVotes(mp *mempool.TxPool, currentTopBlock chainhash.Hash, bloc
 	return sortedUsefulBlocks
 }
 
+// minVotesRequired returns the minimum number of votes a block must have in
+// order to be built on by the consensus rules.
+func minVotesRequired(params *chaincfg.Params) uint16 {
+	return (params.TicketsPerBlock / 2) + 1
+}
+
+// templateVoteRequirement returns the minimum number of votes the current tip of
+// the best chain must have before a new block template is built on it, along
+// with the time at which the wait for all of its votes expires.
+//
+// Since blocks which include fewer votes pay a reduced reward, block templates
+// are only built with all of the votes until the configured vote wait has
+// elapsed since the tip changed, and with the minimum required by the consensus
+// rules afterwards.  A nonzero requested number of votes overrides the wait so
+// callers are able to obtain a reduced-reward block template on demand.  It
+// must already have been checked to be within the range allowed by the
+// consensus rules.  The returned expiration time is the zero time when a
+// template is not currently waiting for votes.
+//
+// This function is safe for concurrent access.
+func templateVoteRequirement(server *server, requested uint16) (uint16, time.Time) {
+	params := server.chainParams
+	if requested != 0 {
+		return requested, time.Time{}
+	}
+	if cfg.MiningVoteWait == 0 {
+		return minVotesRequired(params), time.Time{}
+	}
+
+	expires := server.blockManager.chainState.BestTime().Add(cfg.MiningVoteWait)
+	if time.Now().Before(expires) {
+		return params.TicketsPerBlock, expires
+	}
+	return minVotesRequired(params), time.Time{}
+}
+
 // BlockTemplate houses a block that has yet to be solved along with additional
 // details about the fees and the number of signature operations for each
 // transaction in the block.
@@ -569,13 +786,16 @@ func UpdateExtraNonce(msgBlock *wire.MsgBlock, blockHeight int64,
 
 // createCoinbaseTx returns a coinbase transaction paying an appropriate subsidy
 // based on the passed block height to the provided address.  When the address
-// is nil, the coinbase transaction will instead be redeemable by anyone.
+// is nil, the coinbase transaction will instead be redeemable by anyone.  The
+// tax is paid to the passed tax script, which is the organization script of the
+// network unless the treasury agenda is active.
 //
 // See the comment for NewBlockTemplate for more information about why the nil
 // address handling is useful.
 func createCoinbaseTx(subsidyCache *blockchain.SubsidyCache,
 	coinbaseScript []byte,
 	opReturnPkScript []byte,
+	taxPkScript []byte,
 	nextBlockHeight int64,
 	addr dcrutil.Address,
 	voters uint16,
@@ -636,7 +856,7 @@ func createCoinbaseTx(subsidyCache *blockchain.SubsidyCache,
 	if params.BlockTaxProportion > 0 {
 		tx.AddTxOut(&wire.TxOut{
 			Value:    tax,
-			PkScript: params.OrganizationPkScript,
+			PkScript: taxPkScript,
 		})
 	} else {
 		// Tax disabled.
@@ -973,9 +1193,15 @@ func handleTooFewVoters(subsidyCache *blockchain.SubsidyCache,
 				if err != nil {
 					return nil, err
 				}
+				_, taxPkScript, err := bm.chain.CoinbaseTaxScript(
+					&topBlock.MsgBlock().Header.PrevBlock)
+				if err != nil {
+					return nil, err
+				}
 				coinbaseTx, err := createCoinbaseTx(subsidyCache,
 					[]byte{0x01, 0x02},
 					opReturnPkScript,
+					taxPkScript,
 					topBlock.Height(),
 					miningAddress,
 					topBlock.MsgBlock().Header.Voters,
@@ -1189,16 +1415,24 @@ func handleCreatedBlockTemplate(blockTemplate *BlockTemplate,
 //  |                                   |   |
 //   -----------------------------------  --
 //
+//  The minVotes parameter specifies the minimum number of votes a top block
+//  must have in order to be built on.  It must not be less than the minimum
+//  required by the consensus rules.
+//
 //  This function returns nil, nil if there are not enough voters on any of
-//  the current top blocks to create a new block template.
+//  the current top blocks to create a new block template.  It also returns
+//  nil, nil when the minVotes parameter exceeds the minimum required by the
+//  consensus rules and a top block has the consensus minimum but not minVotes,
+//  such as while waiting for all of the votes on the tip, since building on the
+//  parent of the tip instead would orphan it needlessly.  The parent of the tip
+//  is only built on when no top block has the consensus minimum.
 func NewBlockTemplate(policy *mining.Policy, server *server,
-	payToAddress dcrutil.Address) (*BlockTemplate, error) {
+	payToAddress dcrutil.Address, minVotes uint16) (*BlockTemplate, error) {
 
 	// TODO: The mempool should be completely separated via the TxSource
 	// interface so this function is fully decoupled.
 	mp := server.txMemPool
 
-	var txSource mining.TxSource = server.txMemPool
 	blockManager := server.blockManager
 	timeSource := server.timeSource
 	chainState := &blockManager.chainState
@@ -1259,8 +1493,17 @@ func NewBlockTemplate(policy *mining.Policy, server *server,
 		// not currently on the block that has the most votes, switch to that
 		// block.
 		eligibleParents := SortParentsByVotes(mp, *prevHash, children,
-			blockManager.server.chainParams)
+			minVotes)
 		if len(eligibleParents) == 0 {
+			consensusMinVotes := minVotesRequired(server.chainParams)
+			if minVotes > consensusMinVotes && len(SortParentsByVotes(mp,
+				*prevHash, children, consensusMinVotes)) > 0 {
+
+				minrLog.Debugf("Waiting for %d votes on a HEAD block "+
+					"before creating a new block template", minVotes)
+				return nil, nil
+			}
+
 			minrLog.Debugf("Too few voters found on any HEAD block, " +
 				"recycling a parent block to mine on")
 			return handleTooFewVoters(subsidyCache, nextBlockHeight,
@@ -1299,6 +1542,13 @@ func NewBlockTemplate(policy *mining.Policy, server *server,
 		}
 	}
 
+	// Build the template from a snapshot of the memory pool so it is built
+	// from a consistent set of transactions and votes without blocking the
+	// acceptance of new ones while it is built.  The snapshot is taken
+	// after any forced reorganization above since that modifies the pool.
+	snapshot := mp.Snapshot()
+	var txSource mining.TxSource = snapshot
+
 	// Get the current source transactions and create a priority queue to
 	// hold the transactions which are ready for inclusion into a block
 	// along with some priority related and fee metadata.  Reserve the same
@@ -1327,6 +1577,12 @@ func NewBlockTemplate(policy *mining.Policy, server *server,
 	// in the block once each transaction has been included.
 	dependers := make(map[chainhash.Hash]*list.List)
 
+	// prioItems houses all transactions which are ready for inclusion as
+	// well as the ones which depend on other transactions in the source
+	// pool, so their fees per kilobyte can be calculated from the packages
+	// they form.
+	prioItems := make([]*txPrioItem, 0, len(sourceTxns))
+
 	// Create slices to hold the fees and number of signature operations
 	// for each of the selected transactions and add an entry for the
 	// coinbase.  This allows the code below to simply append details about
@@ -1341,7 +1597,7 @@ func NewBlockTemplate(policy *mining.Policy, server *server,
 
 	minrLog.Debugf("Considering %d transactions for inclusion to new block",
 		len(sourceTxns))
-	treeValid := mp.IsTxTreeValid(prevHash)
+	treeValid := snapshot.IsTxTreeValid(prevHash)
 
 mempoolLoop:
 	for _, txDesc := range sourceTxns {
@@ -1450,6 +1706,7 @@ mempoolLoop:
 		prioItem.feePerKB = (float64(txDesc.Fee) * float64(kilobyte)) /
 			float64(txSize)
 		prioItem.fee = txDesc.Fee
+		prioItems = append(prioItems, prioItem)
 
 		// Add the transaction to the priority queue to mark it ready
 		// for inclusion in the block unless it has dependencies.
@@ -1463,6 +1720,14 @@ mempoolLoop:
 		mergeUtxoView(blockUtxos, utxos)
 	}
 
+	// Sort the transactions by the fees per kilobyte of the packages they
+	// form with their ancestors instead of their own, so that a child with
+	// a high fee pulls in the parents it depends on via child-pays-for-parent.
+	// The priority queue must be reinitialized since the sort keys of the
+	// transactions it already holds changed.
+	calcPackageFeeRates(prioItems)
+	heap.Init(priorityQueue)
+
 	minrLog.Tracef("Priority queue len %d, dependers len %d",
 		priorityQueue.Len(), len(dependers))
 
@@ -1487,6 +1752,13 @@ mempoolLoop:
 		foundWinningTickets[ticketHash] = false
 	}
 
+	// Transaction scripts are verified using the same flags as transactions
+	// accepted into the memory pool.
+	scriptFlags, err := blockManager.chain.StandardScriptFlags()
+	if err != nil {
+		return nil, err
+	}
+
 	// Choose which transactions make it into the block.
 	for priorityQueue.Len() > 0 {
 		// Grab the highest priority (or highest fee per kilobyte
@@ -1534,8 +1806,20 @@ mempoolLoop:
 			}
 		}
 
+		// Calculate the costs of the transaction the same way the
+		// consensus rules do.  This isn't very expensive, but we do this
+		// a number of times.  Consider caching this in the mempool in
+		// the future. - Decred
+		cost, err := blockchain.CalcTxCost(tx, false, blockUtxos)
+		if err != nil {
+			minrLog.Tracef("Skipping tx %s due to error in "+
+				"CalcTxCost: %v", tx.Hash(), err)
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
 		// Enforce maximum block size.  Also check for overflow.
-		txSize := uint32(tx.MsgTx().SerializeSize())
+		txSize := uint32(cost.Size)
 		blockPlusTxSize := blockSize + txSize
 		if blockPlusTxSize < blockSize || blockPlusTxSize >= policy.BlockMaxSize {
 			minrLog.Tracef("Skipping tx %s (size %v) because it "+
@@ -1548,7 +1832,7 @@ mempoolLoop:
 
 		// Enforce maximum signature operations per block.  Also check
 		// for overflow.
-		numSigOps := int64(blockchain.CountSigOps(tx, false, isSSGen))
+		numSigOps := int64(cost.TotalSigOps())
 		if blockSigOps+numSigOps < blockSigOps ||
 			blockSigOps+numSigOps > blockchain.MaxSigOpsPerBlock {
 			minrLog.Tracef("Skipping tx %s because it would "+
@@ -1557,26 +1841,6 @@ mempoolLoop:
 			continue
 		}
 
-		// This isn't very expensive, but we do this check a number of times.
-		// Consider caching this in the mempool in the future. - Decred
-		numP2SHSigOps, err := blockchain.CountP2SHSigOps(tx, false,
-			isSSGen, blockUtxos)
-		if err != nil {
-			minrLog.Tracef("Skipping tx %s due to error in "+
-				"CountP2SHSigOps: %v", tx.Hash(), err)
-			logSkippedDeps(tx, deps)
-			continue
-		}
-		numSigOps += int64(numP2SHSigOps)
-		if blockSigOps+numSigOps < blockSigOps ||
-			blockSigOps+numSigOps > blockchain.MaxSigOpsPerBlock {
-			minrLog.Tracef("Skipping tx %s because it would "+
-				"exceed the maximum sigops per block (p2sh)",
-				tx.Hash())
-			logSkippedDeps(tx, deps)
-			continue
-		}
-
 		// Check to see if the SSGen tx actually uses a ticket that is
 		// valid for the next block.
 		if isSSGen {
@@ -1654,7 +1918,7 @@ mempoolLoop:
 			continue
 		}
 		err = blockchain.ValidateTransactionScripts(tx, blockUtxos,
-			txscript.StandardVerifyFlags, server.sigCache)
+			scriptFlags, server.sigCache)
 		if err != nil {
 			minrLog.Tracef("Skipping tx %s due to error in "+
 				"ValidateTransactionScripts: %v", tx.Hash(), err)
@@ -1900,9 +2164,14 @@ mempoolLoop:
 	if err != nil {
 		return nil, err
 	}
+	_, taxPkScript, err := blockManager.chain.CoinbaseTaxScript(prevHash)
+	if err != nil {
+		return nil, err
+	}
 	coinbaseTx, err := createCoinbaseTx(subsidyCache,
 		coinbaseScript,
 		opReturnPkScript,
+		taxPkScript,
 		nextBlockHeight,
 		payToAddress,
 		uint16(voters),
@@ -1915,8 +2184,12 @@ mempoolLoop:
 	if err != nil {
 		return nil, err
 	}
-	numCoinbaseSigOps := int64(blockchain.CountSigOps(coinbaseTx, true, false))
-	blockSize += uint32(coinbaseTx.MsgTx().SerializeSize())
+	coinbaseCost, err := blockchain.CalcTxCost(coinbaseTx, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	numCoinbaseSigOps := int64(coinbaseCost.TotalSigOps())
+	blockSize += uint32(coinbaseCost.Size)
 	blockSigOps += numCoinbaseSigOps
 	txFeesMap[*coinbaseTx.Hash()] = 0
 	txSigOpCountsMap[*coinbaseTx.Hash()] = numCoinbaseSigOps
@@ -2007,10 +2280,14 @@ mempoolLoop:
 	// Return nil if we don't yet have enough voters; sometimes it takes a
 	// bit for the mempool to sync with the votes map and we end up down
 	// here despite having the relevant votes available in the votes map.
-	minimumVotesRequired :=
-		int((server.chainParams.TicketsPerBlock / 2) + 1)
-	if nextBlockHeight >= stakeValidationHeight &&
-		voters < minimumVotesRequired {
+	if nextBlockHeight >= stakeValidationHeight && voters < int(minVotes) {
+		consensusMinVotes := minVotesRequired(server.chainParams)
+		if voters >= int(consensusMinVotes) {
+			minrLog.Debugf("Waiting for %d votes on the HEAD block "+
+				"before creating a new block template", minVotes)
+			return nil, nil
+		}
+
 		minrLog.Warnf("incongruent number of voters in mempool " +
 			"vs mempool.voters; not enough voters found")
 		return handleTooFewVoters(subsidyCache, nextBlockHeight, payToAddress,
@@ -2141,6 +2418,17 @@ mempoolLoop:
 
 	msgBlock.Header.Size = uint32(msgBlock.SerializeSize())
 
+	// Commit to the utxo set which results from the parent block in the
+	// extra data when the experimental utxo commitments are enabled.
+	if cfg.UtxoCommitments {
+		commitment, err := blockManager.chain.UtxoCommitment(prevHash)
+		if err != nil {
+			return nil, err
+		}
+		copy(msgBlock.Header.ExtraData[blockchain.UtxoCommitmentOffset:],
+			commitment[:])
+	}
+
 	// Finally, perform a full check on the created block against the chain
 	// consensus rules to ensure it properly connects to the current best
 	// chain with no issues.
@@ -11,6 +11,8 @@ import (
 	"testing"
 
 	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
 	"github.com/decred/dcrutil"
 )
 
@@ -278,3 +280,101 @@ func TestStakeTxFeePrioHeap(t *testing.T) {
 		}
 	}
 }
+
+// TestCalcPackageFeeRates ensures transactions are assigned the fees per
+// kilobyte of the packages they form with their ancestors, so that children
+// with high fees pull in their parents while transactions which can't be
+// included don't.
+func TestCalcPackageFeeRates(t *testing.T) {
+	newItem := func(n uint32, fee int64, parents ...*txPrioItem) *txPrioItem {
+		msgTx := wire.NewMsgTx()
+		prevOut := wire.NewOutPoint(&chainhash.Hash{0x01}, n,
+			wire.TxTreeRegular)
+		msgTx.AddTxIn(wire.NewTxIn(prevOut, nil))
+		msgTx.AddTxOut(wire.NewTxOut(int64(n), []byte{0x51}))
+		item := &txPrioItem{tx: dcrutil.NewTx(msgTx), fee: fee}
+		for _, parent := range parents {
+			if item.dependsOn == nil {
+				item.dependsOn = make(map[chainhash.Hash]struct{})
+			}
+			item.dependsOn[*parent.tx.Hash()] = struct{}{}
+		}
+		item.feePerKB = float64(fee) * kilobyte / float64(msgTx.SerializeSize())
+		return item
+	}
+	feePerKB := func(items ...*txPrioItem) float64 {
+		var fee, size int64
+		for _, item := range items {
+			fee += item.fee
+			size += int64(item.tx.MsgTx().SerializeSize())
+		}
+		return float64(fee) * kilobyte / float64(size)
+	}
+
+	// Create a parent without fees whose child pays for both, a parent
+	// which pays more than its child, and a child whose parent is not
+	// among the items along with its other parent.
+	freeParent := newItem(1, 0)
+	payingChild := newItem(2, 100000, freeParent)
+	richParent := newItem(3, 60000)
+	poorChild := newItem(4, 1000, richParent)
+	missing := newItem(5, 0)
+	orphanParent := newItem(6, 10)
+	orphanChild := newItem(7, 1000000, missing, orphanParent)
+	independent := newItem(8, 20000)
+
+	items := []*txPrioItem{freeParent, payingChild, richParent, poorChild,
+		orphanParent, orphanChild, independent}
+	tests := []struct {
+		name string
+		item *txPrioItem
+		want float64
+	}{
+		{"free parent", freeParent, feePerKB(freeParent, payingChild)},
+		{"paying child", payingChild, feePerKB(freeParent, payingChild)},
+		{"rich parent", richParent, feePerKB(richParent)},
+		{"poor child", poorChild, feePerKB(poorChild)},
+		{"orphan parent", orphanParent, feePerKB(orphanParent)},
+		{"orphan child", orphanChild, feePerKB(orphanChild)},
+		{"independent", independent, feePerKB(independent)},
+	}
+	calcPackageFeeRates(items)
+	for _, test := range tests {
+		if test.item.feePerKB != test.want {
+			t.Errorf("%s: unexpected fee per KB - got %v, want %v",
+				test.name, test.item.feePerKB, test.want)
+		}
+	}
+
+	// Ensure the fees of a transaction with more ancestors than allowed
+	// are not attributed to them, while the fees of a transaction with the
+	// maximum allowed are.
+	chain := []*txPrioItem{newItem(100, 0)}
+	for i := 1; i <= maxPackageAncestors+1; i++ {
+		chain = append(chain, newItem(uint32(100+i), 0, chain[i-1]))
+	}
+	atLimit := chain[maxPackageAncestors]
+	atLimit.fee = 1000000
+	overLimit := chain[maxPackageAncestors+1]
+	overLimit.fee = 2000000
+	overLimit.feePerKB = feePerKB(overLimit)
+	calcPackageFeeRates(chain)
+	if want := feePerKB(chain[:maxPackageAncestors+1]...); atLimit.feePerKB != want {
+		t.Errorf("at limit: unexpected fee per KB - got %v, want %v",
+			atLimit.feePerKB, want)
+	}
+	if want := feePerKB(overLimit); overLimit.feePerKB != want {
+		t.Errorf("over limit: unexpected fee per KB - got %v, want %v",
+			overLimit.feePerKB, want)
+	}
+
+	// The parent without fees must be sorted before the independent
+	// transaction with a lower fee per kilobyte than the package.
+	priorityQueue := newTxPriorityQueue(len(items), txPQByStakeAndFee)
+	heap.Push(priorityQueue, independent)
+	heap.Push(priorityQueue, freeParent)
+	if item := heap.Pop(priorityQueue).(*txPrioItem); item != freeParent {
+		t.Errorf("txPQByStakeAndFee: parent paid for by its child not " +
+			"sorted first")
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// maxMissedTicketDetails is the maximum number of missed ticket
+	// details that are retained.  The oldest details are evicted first.
+	maxMissedTicketDetails = 8192
+
+	// maxMissedTicketLotteries is the maximum number of blocks for which
+	// the winning tickets and the votes seen for them are retained.
+	maxMissedTicketLotteries = 64
+
+	// missedTicketLateBlockThreshold is the minimum amount of time between
+	// announcing the winning tickets for a block and receiving the block
+	// which must include their votes in order for the votes to reasonably
+	// have been expected to propagate to the miner.
+	missedTicketLateBlockThreshold = time.Second * 10
+)
+
+// These constants define the reasons a ticket is determined to have missed its
+// vote.
+const (
+	// missedReasonVoteNotIncluded indicates the vote was seen before the
+	// block which should have included it, but the miner did not include
+	// it.
+	missedReasonVoteNotIncluded = "votenotincluded"
+
+	// missedReasonLateBlock indicates no vote was seen and the block which
+	// should have included it was found too soon after the winning tickets
+	// were announced for the vote to have propagated.
+	missedReasonLateBlock = "lateblock"
+
+	// missedReasonWalletDisconnected indicates no vote was seen and no
+	// voting wallet acknowledged the winning tickets notification.
+	missedReasonWalletDisconnected = "walletdisconnected"
+
+	// missedReasonNoVote indicates no vote was seen even though a voting
+	// wallet acknowledged the winning tickets notification.
+	missedReasonNoVote = "novote"
+
+	// missedReasonDataUnavailable indicates the winning tickets for the
+	// block were not observed by this node, such as when it was still
+	// syncing or was started after they were announced, so no reason can
+	// be determined.
+	missedReasonDataUnavailable = "unavailable"
+)
+
+// missedTicketLottery houses the data observed about the winning tickets for
+// a block.
+type missedTicketLottery struct {
+	height        int64
+	announced     time.Time
+	votingWallets int64
+	acknowledged  bool
+	votesSeen     map[chainhash.Hash]time.Time
+}
+
+// missedTicketTracker records the context around winning tickets, the votes
+// seen for them, and the delivery of winning ticket notifications to voting
+// wallets in order to determine the likely reason tickets miss their votes.
+// All of the data is kept in memory and is bounded, since it is only intended
+// to help diagnose recent misses.
+type missedTicketTracker struct {
+	mtx       sync.Mutex
+	stats     *votingWalletStats
+	lotteries map[chainhash.Hash]*missedTicketLottery
+	details   map[chainhash.Hash]*dcrjson.MissedTicketDetailsResult
+	order     []chainhash.Hash
+}
+
+// newMissedTicketTracker returns a new missed ticket tracker which consults the
+// passed voting wallet stats to determine how many voting wallets were
+// connected when winning tickets are announced.
+func newMissedTicketTracker(stats *votingWalletStats) *missedTicketTracker {
+	return &missedTicketTracker{
+		stats:     stats,
+		lotteries: make(map[chainhash.Hash]*missedTicketLottery),
+		details:   make(map[chainhash.Hash]*dcrjson.MissedTicketDetailsResult),
+	}
+}
+
+// pruneLotteries removes the oldest lottery data once the maximum number of
+// retained lotteries is exceeded.
+//
+// This function MUST be called with the tracker lock held.
+func (t *missedTicketTracker) pruneLotteries() {
+	for len(t.lotteries) > maxMissedTicketLotteries {
+		var oldestHash chainhash.Hash
+		oldestHeight := int64(-1)
+		for hash, lottery := range t.lotteries {
+			if oldestHeight == -1 || lottery.height < oldestHeight {
+				oldestHash, oldestHeight = hash, lottery.height
+			}
+		}
+		delete(t.lotteries, oldestHash)
+	}
+}
+
+// RecordWinningTickets records the time the winning tickets for the block with
+// the given hash and height were announced.  Announcing the same block more
+// than once, such as when rebroadcasting winners, keeps the original time.
+//
+// This function is safe for concurrent access.
+func (t *missedTicketTracker) RecordWinningTickets(hash *chainhash.Hash, height int64) {
+	t.stats.mtx.Lock()
+	votingWallets := t.stats.clients
+	t.stats.mtx.Unlock()
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if _, ok := t.lotteries[*hash]; ok {
+		return
+	}
+	t.lotteries[*hash] = &missedTicketLottery{
+		height:        height,
+		announced:     time.Now(),
+		votingWallets: votingWallets,
+		votesSeen:     make(map[chainhash.Hash]time.Time),
+	}
+	t.pruneLotteries()
+}
+
+// RecordAcknowledgement records that a voting wallet acknowledged the winning
+// tickets notification for the block with the given hash.
+//
+// This function is safe for concurrent access.
+func (t *missedTicketTracker) RecordAcknowledgement(blockHash string) {
+	hash, err := chainhash.NewHashFromStr(blockHash)
+	if err != nil {
+		return
+	}
+
+	t.mtx.Lock()
+	if lottery, ok := t.lotteries[*hash]; ok {
+		lottery.acknowledged = true
+	}
+	t.mtx.Unlock()
+}
+
+// RecordMempoolTx records the time a vote was first seen when the passed
+// transaction accepted to the memory pool is a vote.
+//
+// This function is safe for concurrent access.
+func (t *missedTicketTracker) RecordMempoolTx(tx *dcrutil.Tx) {
+	msgTx := tx.MsgTx()
+	if isVote, _ := stake.IsSSGen(msgTx); !isVote {
+		return
+	}
+	votedHash, _, err := stake.SSGenBlockVotedOn(msgTx)
+	if err != nil {
+		return
+	}
+	ticket := msgTx.TxIn[1].PreviousOutPoint.Hash
+
+	t.mtx.Lock()
+	if lottery, ok := t.lotteries[votedHash]; ok {
+		if _, seen := lottery.votesSeen[ticket]; !seen {
+			lottery.votesSeen[ticket] = time.Now()
+		}
+	}
+	t.mtx.Unlock()
+}
+
+// RecordMissedTickets determines and records the likely reason each ticket
+// missed by the connected block described by the passed data missed its vote.
+// Tickets which were previously recorded as missed are ignored.
+//
+// This function is safe for concurrent access.
+func (t *missedTicketTracker) RecordMissedTickets(tnd *blockchain.TicketNotificationsData, prevHash *chainhash.Hash) {
+	if len(tnd.TicketsMissed) == 0 {
+		return
+	}
+
+	now := time.Now()
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	lottery := t.lotteries[*prevHash]
+	for _, ticket := range tnd.TicketsMissed {
+		if _, ok := t.details[ticket]; ok {
+			continue
+		}
+
+		detail := &dcrjson.MissedTicketDetailsResult{
+			Ticket:           ticket.String(),
+			BlockHash:        tnd.Hash.String(),
+			BlockHeight:      tnd.Height,
+			VotedBlockHash:   prevHash.String(),
+			VotedBlockHeight: tnd.Height - 1,
+			BlockSeenTime:    now.Unix(),
+			Reason:           missedReasonDataUnavailable,
+		}
+		if lottery != nil {
+			voteSeen, voted := lottery.votesSeen[ticket]
+			detail.LotteryTime = lottery.announced.Unix()
+			detail.VotingWallets = lottery.votingWallets
+			detail.Acknowledged = lottery.acknowledged
+			switch {
+			case voted:
+				detail.VoteSeenTime = voteSeen.Unix()
+				detail.Reason = missedReasonVoteNotIncluded
+			case now.Sub(lottery.announced) < missedTicketLateBlockThreshold:
+				detail.Reason = missedReasonLateBlock
+			case !lottery.acknowledged:
+				detail.Reason = missedReasonWalletDisconnected
+			default:
+				detail.Reason = missedReasonNoVote
+			}
+		}
+
+		t.details[ticket] = detail
+		t.order = append(t.order, ticket)
+	}
+
+	// Evict the oldest details once the maximum is exceeded.
+	if len(t.order) > maxMissedTicketDetails {
+		numEvict := len(t.order) - maxMissedTicketDetails
+		for _, ticket := range t.order[:numEvict] {
+			delete(t.details, ticket)
+		}
+		t.order = append(t.order[:0], t.order[numEvict:]...)
+	}
+}
+
+// MissedTicketDetails returns the recorded details for the passed tickets.
+// Tickets which do not have any recorded details are skipped.  All recorded
+// details, ordered from oldest to newest, are returned when no tickets are
+// passed.
+//
+// This function is safe for concurrent access.
+func (t *missedTicketTracker) MissedTicketDetails(tickets []chainhash.Hash) []dcrjson.MissedTicketDetailsResult {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if len(tickets) == 0 {
+		tickets = t.order
+	}
+	results := make([]dcrjson.MissedTicketDetailsResult, 0, len(tickets))
+	for i := range tickets {
+		if detail, ok := t.details[tickets[i]]; ok {
+			results = append(results, *detail)
+		}
+	}
+	return results
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+)
+
+// tlsPeerHandshakeTimeout is the maximum amount of time the TLS handshake with
+// a trusted peer may take when connecting to it.
+const tlsPeerHandshakeTimeout = time.Second * 30
+
+// newTLSPeerConfig returns a TLS configuration for links with trusted peers
+// which presents the passed certificate and only accepts peers which present a
+// certificate that is either contained in or issued by one of the certificates
+// in the passed trusted certificates file.  The server name is used to verify
+// the certificate of the accepting side of the link and is empty for accepted
+// links.
+//
+// The certificate and key files are generated when neither one exists.
+func newTLSPeerConfig(certFile, keyFile, caFile, serverName string) (*tls.Config, error) {
+	if !fileExists(certFile) && !fileExists(keyFile) {
+		if err := genCertPair(certFile, keyFile); err != nil {
+			return nil, err
+		}
+	}
+	keypair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pemCerts, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	trusted := x509.NewCertPool()
+	if !trusted.AppendCertsFromPEM(pemCerts) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{keypair},
+		RootCAs:      trusted,
+		ClientCAs:    trusted,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// tlsPeerListeners returns listeners on the passed addresses which only accept
+// connections from trusted peers with the passed TLS configuration.  Unlike
+// the normal listeners, the local addresses are not advertised to other peers.
+func tlsPeerListeners(listenAddrs []string, config *tls.Config) ([]net.Listener, error) {
+	ipv4Addrs, ipv6Addrs, _, err := parseListeners(listenAddrs)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(ipv4Addrs)+len(ipv6Addrs))
+	for _, addr := range ipv4Addrs {
+		listener, err := tls.Listen("tcp4", addr, config)
+		if err != nil {
+			srvrLog.Warnf("Can't listen for trusted TLS peers on %s: %v",
+				addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	for _, addr := range ipv6Addrs {
+		listener, err := tls.Listen("tcp6", addr, config)
+		if err != nil {
+			srvrLog.Warnf("Can't listen for trusted TLS peers on %s: %v",
+				addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("no valid trusted TLS peer listen address")
+	}
+
+	return listeners, nil
+}
+
+// tlsPeerDialer dials trusted peers over TLS and all other peers as usual.
+type tlsPeerDialer struct {
+	// configs houses the TLS configuration for each trusted peer keyed by
+	// its resolved address.
+	configs map[string]*tls.Config
+}
+
+// newTLSPeerDialer returns a dialer which connects to the passed trusted peers
+// over TLS along with the resolved addresses of the peers.  The host of each
+// peer must be covered by the certificate the peer presents.
+func newTLSPeerDialer(peers []string, certFile, keyFile, caFile string) (*tlsPeerDialer, []net.Addr, error) {
+	dialer := &tlsPeerDialer{
+		configs: make(map[string]*tls.Config, len(peers)),
+	}
+	addrs := make([]net.Addr, 0, len(peers))
+	for _, peer := range peers {
+		host, _, err := net.SplitHostPort(peer)
+		if err != nil {
+			return nil, nil, err
+		}
+		addr, err := addrStringToNetAddr(peer)
+		if err != nil {
+			return nil, nil, err
+		}
+		config, err := newTLSPeerConfig(certFile, keyFile, caFile, host)
+		if err != nil {
+			return nil, nil, err
+		}
+		dialer.configs[addr.String()] = config
+		addrs = append(addrs, addr)
+	}
+
+	return dialer, addrs, nil
+}
+
+// Dial connects to the passed address and performs the TLS handshake when it
+// belongs to a trusted peer.  The connection is closed when the handshake
+// fails, so peers which do not present a trusted certificate are never
+// communicated with.
+func (d *tlsPeerDialer) Dial(addr net.Addr) (net.Conn, error) {
+	conn, err := dcrdDial(addr)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := d.configs[addr.String()]
+	if !ok {
+		return conn, nil
+	}
+
+	tlsConn := tls.Client(conn, config)
+	tlsConn.SetDeadline(time.Now().Add(tlsPeerHandshakeTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		tlsConn.Close()
+		return nil, fmt.Errorf("TLS handshake with trusted peer %s "+
+			"failed: %v", addr, err)
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
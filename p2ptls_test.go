@@ -0,0 +1,214 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/connmgr"
+)
+
+// tlsPeerTestCert generates a certificate and key named after the passed name
+// in the passed directory and returns the paths of both files.
+func tlsPeerTestCert(t *testing.T, dir, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".cert")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := genCertPair(certFile, keyFile); err != nil {
+		t.Fatalf("genCertPair: unexpected error: %v", err)
+	}
+	return certFile, keyFile
+}
+
+// TestTLSPeerConfig ensures the TLS configuration for trusted peers requires
+// verified certificates from both sides, that the certificate and key are only
+// generated when neither one exists, and that trusted certificates files which
+// can't be used are rejected.
+func TestTLSPeerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "p2ptls")
+	if err != nil {
+		t.Fatalf("TempDir: unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The missing certificate and key are generated, which allows the
+	// generated certificate to be the trusted certificates file as well.
+	certFile := filepath.Join(dir, "peer.cert")
+	keyFile := filepath.Join(dir, "peer.key")
+	config, err := newTLSPeerConfig(certFile, keyFile, certFile, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("newTLSPeerConfig: unexpected error: %v", err)
+	}
+	if !fileExists(certFile) || !fileExists(keyFile) {
+		t.Fatal("newTLSPeerConfig: certificate and key not generated")
+	}
+	if len(config.Certificates) != 1 || config.RootCAs == nil ||
+		config.ClientCAs == nil ||
+		config.ClientAuth != tls.RequireAndVerifyClientCert ||
+		config.ServerName != "127.0.0.1" ||
+		config.MinVersion != tls.VersionTLS12 {
+
+		t.Fatalf("newTLSPeerConfig: unexpected config %+v", config)
+	}
+
+	// Ensure trusted certificates files which are missing or do not
+	// contain any certificates are rejected.
+	_, err = newTLSPeerConfig(certFile, keyFile, filepath.Join(dir, "none"),
+		"")
+	if err == nil {
+		t.Fatal("newTLSPeerConfig: no error for missing trusted " +
+			"certificates file")
+	}
+	if _, err := newTLSPeerConfig(certFile, keyFile, keyFile, ""); err == nil ||
+		!strings.Contains(err.Error(), "no certificates found") {
+
+		t.Fatalf("newTLSPeerConfig: unexpected error for trusted "+
+			"certificates file without certificates: %v", err)
+	}
+
+	// Ensure a certificate is not generated when only the key exists.
+	if err := os.Remove(certFile); err != nil {
+		t.Fatalf("Remove: unexpected error: %v", err)
+	}
+	if _, err := newTLSPeerConfig(certFile, keyFile, keyFile, ""); err == nil {
+		t.Fatal("newTLSPeerConfig: no error for missing certificate")
+	}
+	if fileExists(certFile) {
+		t.Fatal("newTLSPeerConfig: certificate generated for existing key")
+	}
+}
+
+// TestTLSPeerDialer ensures connections to trusted peers are only established
+// when the TLS handshake succeeds with certificates both sides trust, that
+// connections to all other peers are not wrapped in TLS, and that trusted peer
+// listeners reject addresses they can't listen on.
+func TestTLSPeerDialer(t *testing.T) {
+	defer func(prevCfg *config) { cfg = prevCfg }(cfg)
+	cfg = &config{
+		lookup:     net.LookupIP,
+		dialRouter: connmgr.NewDialRouter(net.Dial),
+	}
+
+	dir, err := ioutil.TempDir("", "p2ptls")
+	if err != nil {
+		t.Fatalf("TempDir: unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	serverCert, serverKey := tlsPeerTestCert(t, dir, "server")
+	clientCert, clientKey := tlsPeerTestCert(t, dir, "client")
+	otherCert, otherKey := tlsPeerTestCert(t, dir, "other")
+
+	serverConfig, err := newTLSPeerConfig(serverCert, serverKey, clientCert,
+		"")
+	if err != nil {
+		t.Fatalf("newTLSPeerConfig: unexpected error: %v", err)
+	}
+	listeners, err := tlsPeerListeners([]string{"127.0.0.1:0"}, serverConfig)
+	if err != nil {
+		t.Fatalf("tlsPeerListeners: unexpected error: %v", err)
+	}
+	listener := listeners[0]
+	defer listener.Close()
+	listenAddr := listener.Addr().String()
+
+	// Ensure addresses which are not IP addresses or which can't be
+	// listened on are rejected.
+	for _, addr := range []string{"localhost:0", listenAddr} {
+		_, err := tlsPeerListeners([]string{addr}, serverConfig)
+		if err == nil {
+			t.Fatalf("tlsPeerListeners: no error for address %s", addr)
+		}
+	}
+
+	// Perform the handshake of every accepted connection and report the
+	// outcome.
+	handshakes := make(chan error)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			err = conn.(*tls.Conn).Handshake()
+			conn.Close()
+			handshakes <- err
+		}
+	}()
+
+	// Ensure the connection succeeds when both sides trust each other.
+	dialer, addrs, err := newTLSPeerDialer([]string{listenAddr}, clientCert,
+		clientKey, serverCert)
+	if err != nil {
+		t.Fatalf("newTLSPeerDialer: unexpected error: %v", err)
+	}
+	if len(addrs) != 1 || addrs[0].String() != listenAddr {
+		t.Fatalf("newTLSPeerDialer: unexpected addresses %v", addrs)
+	}
+	conn, err := dialer.Dial(addrs[0])
+	if err != nil {
+		t.Fatalf("Dial: unexpected error: %v", err)
+	}
+	if _, ok := conn.(*tls.Conn); !ok {
+		t.Fatalf("Dial: connection to trusted peer is not TLS - got %T",
+			conn)
+	}
+	conn.Close()
+	if err := <-handshakes; err != nil {
+		t.Fatalf("Handshake: unexpected error accepting trusted peer: %v",
+			err)
+	}
+
+	// Ensure peers which present a certificate that is not trusted are
+	// rejected by both sides.
+	dialer, addrs, err = newTLSPeerDialer([]string{listenAddr}, clientCert,
+		clientKey, otherCert)
+	if err != nil {
+		t.Fatalf("newTLSPeerDialer: unexpected error: %v", err)
+	}
+	if conn, err := dialer.Dial(addrs[0]); err == nil {
+		conn.Close()
+		t.Fatal("Dial: no error for untrusted server certificate")
+	}
+	<-handshakes
+	dialer, addrs, err = newTLSPeerDialer([]string{listenAddr}, otherCert,
+		otherKey, serverCert)
+	if err != nil {
+		t.Fatalf("newTLSPeerDialer: unexpected error: %v", err)
+	}
+	if conn, err := dialer.Dial(addrs[0]); err == nil {
+		conn.Close()
+	}
+	if err := <-handshakes; err == nil {
+		t.Fatal("Handshake: no error for untrusted client certificate")
+	}
+
+	// Ensure connections to peers which are not trusted peers are not
+	// wrapped in TLS.
+	plain, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: unexpected error: %v", err)
+	}
+	defer plain.Close()
+	conn, err = dialer.Dial(plain.Addr())
+	if err != nil {
+		t.Fatalf("Dial: unexpected error: %v", err)
+	}
+	if _, ok := conn.(*tls.Conn); ok {
+		t.Fatal("Dial: connection to other peer is TLS")
+	}
+	conn.Close()
+
+	// Ensure trusted peers without a port are rejected.
+	_, _, err = newTLSPeerDialer([]string{"127.0.0.1"}, clientCert,
+		clientKey, serverCert)
+	if err == nil {
+		t.Fatal("newTLSPeerDialer: no error for peer without a port")
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build gofuzz
+
+// This file contains the go-fuzz target for the message handling of peers,
+// including the version handshake and inventory handling.  The seed corpus is
+// housed in testdata/fuzz/corpus and go-fuzz stores the inputs it discovers
+// alongside it.  Run the fuzzer with:
+//
+//   go-fuzz-build github.com/decred/dcrd/peer
+//   go-fuzz -bin=peer-fuzz.zip -workdir=testdata/fuzz
+
+package peer
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/wire"
+)
+
+// fuzzCommands houses the commands of the messages the remote peer may send to
+// the peer under test.  The selector byte of each message in the input selects
+// the command by its index modulo the number of commands, so new commands must
+// only ever be appended in order to keep the existing corpus meaningful.
+var fuzzCommands = []string{
+	wire.CmdVersion,
+	wire.CmdVerAck,
+	wire.CmdGetAddr,
+	wire.CmdAddr,
+	wire.CmdGetBlocks,
+	wire.CmdBlock,
+	wire.CmdInv,
+	wire.CmdGetData,
+	wire.CmdNotFound,
+	wire.CmdTx,
+	wire.CmdPing,
+	wire.CmdPong,
+	wire.CmdGetHeaders,
+	wire.CmdHeaders,
+	wire.CmdAlert,
+	wire.CmdMemPool,
+	wire.CmdMiningState,
+	wire.CmdGetMiningState,
+	wire.CmdFilterAdd,
+	wire.CmdFilterClear,
+	wire.CmdFilterLoad,
+	wire.CmdMerkleBlock,
+	wire.CmdReject,
+	wire.CmdSendHeaders,
+	wire.CmdDoubleSpendProof,
+	wire.CmdSendCmpct,
+	wire.CmdCmpctBlock,
+	wire.CmdGetBlockTxn,
+	wire.CmdBlockTxn,
+	wire.CmdGetPackage,
+	wire.CmdPackage,
+}
+
+// fuzzMessage is a wire message with an arbitrary payload.  It allows the
+// fuzzer to send malformed payloads with a valid message header and checksum.
+type fuzzMessage struct {
+	command string
+	payload []byte
+}
+
+// BtcDecode is a no-op since fuzz messages are only ever written.  This is part
+// of the wire.Message interface implementation.
+func (m *fuzzMessage) BtcDecode(r io.Reader, pver uint32) error {
+	return nil
+}
+
+// BtcEncode writes the raw payload of the message.  This is part of the
+// wire.Message interface implementation.
+func (m *fuzzMessage) BtcEncode(w io.Writer, pver uint32) error {
+	_, err := w.Write(m.payload)
+	return err
+}
+
+// Command returns the command of the message.  This is part of the
+// wire.Message interface implementation.
+func (m *fuzzMessage) Command() string {
+	return m.command
+}
+
+// MaxPayloadLength returns the maximum length of any message payload.  This is
+// part of the wire.Message interface implementation.
+func (m *fuzzMessage) MaxPayloadLength(pver uint32) uint32 {
+	return wire.MaxMessagePayload
+}
+
+// parseFuzzMessages splits the passed input into the messages it describes.
+// Each message is encoded as a selector byte, a two byte little-endian payload
+// length, and the payload.  A truncated final payload is used as is.
+func parseFuzzMessages(data []byte) []*fuzzMessage {
+	var msgs []*fuzzMessage
+	for len(data) >= 3 {
+		command := fuzzCommands[int(data[0])%len(fuzzCommands)]
+		payloadLen := int(binary.LittleEndian.Uint16(data[1:3]))
+		data = data[3:]
+		if payloadLen > len(data) {
+			payloadLen = len(data)
+		}
+		msgs = append(msgs, &fuzzMessage{command, data[:payloadLen]})
+		data = data[payloadLen:]
+	}
+	return msgs
+}
+
+// fuzzConn wraps one end of a pipe to give it TCP addresses since inbound
+// peers require the remote address of the connection to be a valid address.
+type fuzzConn struct {
+	net.Conn
+}
+
+// LocalAddr returns the local address for the connection.
+func (c fuzzConn) LocalAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9108}
+}
+
+// RemoteAddr returns the remote address for the connection.
+func (c fuzzConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 9108}
+}
+
+// Fuzz is the go-fuzz entry point for peer message handling.  The input
+// describes a sequence of messages, as described by parseFuzzMessages, that a
+// remote peer sends to a new inbound peer with valid message headers.  The
+// remote end of the connection is closed once all of the messages are sent.
+//
+// Inputs which complete the version handshake are given priority.
+func Fuzz(data []byte) int {
+	msgs := parseFuzzMessages(data)
+	if len(msgs) == 0 {
+		return -1
+	}
+
+	params := &chaincfg.SimNetParams
+	p := NewInboundPeer(&Config{
+		UserAgentName:    "fuzz",
+		UserAgentVersion: "1.0.0",
+		ChainParams:      params,
+		Services:         wire.SFNodeNetwork,
+	})
+	local, remote := net.Pipe()
+	p.AssociateConnection(fuzzConn{local})
+
+	// Discard everything the peer under test sends.
+	go io.Copy(ioutil.Discard, remote)
+
+	for _, msg := range msgs {
+		err := wire.WriteMessage(remote, msg, wire.ProtocolVersion,
+			params.Net)
+		if err != nil {
+			break
+		}
+	}
+	remote.Close()
+	p.WaitForDisconnect()
+
+	if p.VerAckReceived() {
+		return 1
+	}
+	return 0
+}
@@ -28,7 +28,7 @@ import (
 
 const (
 	// MaxProtocolVersion is the max protocol version the peer supports.
-	MaxProtocolVersion = wire.SendHeadersVersion
+	MaxProtocolVersion = wire.PackageRelayVersion
 
 	// outputBufferSize is the number of elements the output channels use.
 	outputBufferSize = 5000
@@ -187,6 +187,32 @@ type MessageListeners struct {
 	// message.
 	OnSendHeaders func(p *Peer, msg *wire.MsgSendHeaders)
 
+	// OnDoubleSpendProof is invoked when a peer receives a dsproof wire
+	// message.
+	OnDoubleSpendProof func(p *Peer, msg *wire.MsgDoubleSpendProof)
+
+	// OnSendCmpct is invoked when a peer receives a sendcmpct wire
+	// message.
+	OnSendCmpct func(p *Peer, msg *wire.MsgSendCmpct)
+
+	// OnCmpctBlock is invoked when a peer receives a cmpctblock wire
+	// message.
+	OnCmpctBlock func(p *Peer, msg *wire.MsgCmpctBlock)
+
+	// OnGetBlockTxn is invoked when a peer receives a getblocktxn wire
+	// message.
+	OnGetBlockTxn func(p *Peer, msg *wire.MsgGetBlockTxn)
+
+	// OnBlockTxn is invoked when a peer receives a blocktxn wire message.
+	OnBlockTxn func(p *Peer, msg *wire.MsgBlockTxn)
+
+	// OnGetPackage is invoked when a peer receives a getpackage wire
+	// message.
+	OnGetPackage func(p *Peer, msg *wire.MsgGetPackage)
+
+	// OnPackage is invoked when a peer receives a package wire message.
+	OnPackage func(p *Peer, msg *wire.MsgPackage)
+
 	// OnRead is invoked when a peer receives a wire message.  It consists
 	// of the number of bytes read, the message, and whether or not an error
 	// in the read occurred.  Typically, callers will opt to use the
@@ -249,6 +275,27 @@ type Config struct {
 	// not send inv messages for transactions.
 	DisableRelayTx bool
 
+	// Clock specifies the local clock to use for the timestamp advertised
+	// in the version message and for determining the time offset of the
+	// remote peer.  This field can be omitted in which case the system
+	// clock will be used.
+	Clock blockchain.Clock
+
+	// MessageStats specifies a collector which receives the statistics of
+	// every message read from and written to the peer, such as for per-peer
+	// bandwidth accounting.  Unlike collectors registered with the wire
+	// package, it only receives the messages of this peer.  This field can
+	// be omitted in which case no per-peer statistics are collected.
+	MessageStats wire.MessageStatsCollector
+
+	// HandlePanic specifies a callback which is invoked with the value of a
+	// panic recovered from any of the goroutines of the peer, which includes
+	// the message listeners since they are run by the input handler.  The
+	// callback runs on the goroutine which panicked and is not expected to
+	// return, such as when it records the crash and exits.  This field can be
+	// omitted in which case panics are not recovered.
+	HandlePanic func(p *Peer, r interface{})
+
 	// Listeners houses callback functions to be invoked on receiving peer
 	// messages.
 	Listeners MessageListeners
@@ -420,6 +467,8 @@ type Peer struct {
 	advertisedProtoVer   uint32 // protocol version advertised by remote
 	protocolVersion      uint32 // negotiated protocol version
 	sendHeadersPreferred bool   // peer sent a sendheaders message
+	cmpctBlocksSupported bool   // peer sent a supported sendcmpct message
+	cmpctBlocksAnnounced bool   // peer wants cmpctblock announcements
 	versionSent          bool
 	verAckReceived       bool
 
@@ -493,6 +542,14 @@ func (p *Peer) AddKnownInventory(invVect *wire.InvVect) {
 	p.knownInventory.Add(invVect)
 }
 
+// HasKnownInventory returns whether the passed inventory is in the cache of
+// known inventory for the peer.
+//
+// This function is safe for concurrent access.
+func (p *Peer) HasKnownInventory(invVect *wire.InvVect) bool {
+	return p.knownInventory.Exists(invVect)
+}
+
 // StatsSnapshot returns a snapshot of the current peer flags and statistics.
 //
 // This function is safe for concurrent access.
@@ -740,6 +797,30 @@ func (p *Peer) WantsHeaders() bool {
 	return p.sendHeadersPreferred
 }
 
+// SupportsCmpctBlocks returns if the peer signaled support for the compact
+// block encoding version implemented by the wire package with a sendcmpct
+// message, which means compact blocks may be requested from it.
+//
+// This function is safe for concurrent access.
+func (p *Peer) SupportsCmpctBlocks() bool {
+	p.flagsMtx.Lock()
+	defer p.flagsMtx.Unlock()
+
+	return p.cmpctBlocksSupported
+}
+
+// WantsCmpctBlocks returns if the peer wants new blocks announced with
+// cmpctblock messages instead of inventory vectors or headers (high bandwidth
+// mode).
+//
+// This function is safe for concurrent access.
+func (p *Peer) WantsCmpctBlocks() bool {
+	p.flagsMtx.Lock()
+	defer p.flagsMtx.Unlock()
+
+	return p.cmpctBlocksSupported && p.cmpctBlocksAnnounced
+}
+
 // localVersionMsg creates a version message that can be used to send to the
 // remote peer.
 func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
@@ -789,6 +870,7 @@ func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
 
 	// Version message.
 	msg := wire.NewMsgVersion(ourNA, theirNA, nonce, int32(blockNum))
+	msg.Timestamp = time.Unix(p.cfg.Clock.Now().Unix(), 0)
 	msg.AddUserAgent(p.cfg.UserAgentName, p.cfg.UserAgentVersion)
 
 	// XXX: bitcoind appears to always enable the full node services flag
@@ -1015,7 +1097,7 @@ func (p *Peer) handleRemoteVersionMsg(msg *wire.MsgVersion) error {
 	p.startingHeight = int64(msg.LastBlock)
 
 	// Set the peer's time offset.
-	p.timeOffset = msg.Timestamp.Unix() - time.Now().Unix()
+	p.timeOffset = msg.Timestamp.Unix() - p.cfg.Clock.Now().Unix()
 	p.statsMtx.Unlock()
 
 	// Negotiate the protocol version.
@@ -1087,8 +1169,8 @@ func (p *Peer) handlePongMsg(msg *wire.MsgPong) {
 
 // readMessage reads the next wire message from the peer with logging.
 func (p *Peer) readMessage() (wire.Message, []byte, error) {
-	n, msg, buf, err := wire.ReadMessageN(p.conn, p.ProtocolVersion(),
-		p.cfg.ChainParams.Net)
+	n, msg, buf, err := wire.ReadMessageWithStats(p.conn,
+		p.ProtocolVersion(), p.cfg.ChainParams.Net, p.cfg.MessageStats)
 	atomic.AddUint64(&p.bytesReceived, uint64(n))
 	if p.cfg.Listeners.OnRead != nil {
 		p.cfg.Listeners.OnRead(p, n, msg, err)
@@ -1150,8 +1232,8 @@ func (p *Peer) writeMessage(msg wire.Message) error {
 	}))
 
 	// Write the message to the peer.
-	n, err := wire.WriteMessageN(p.conn, msg, p.ProtocolVersion(),
-		p.cfg.ChainParams.Net)
+	n, err := wire.WriteMessageWithStats(p.conn, msg, p.ProtocolVersion(),
+		p.cfg.ChainParams.Net, p.cfg.MessageStats)
 	atomic.AddUint64(&p.bytesSent, uint64(n))
 	if p.cfg.Listeners.OnWrite != nil {
 		p.cfg.Listeners.OnWrite(p, n, msg, err)
@@ -1207,11 +1289,16 @@ func (p *Peer) maybeAddDeadline(pendingResponses map[string]time.Time, msgCmd st
 		pendingResponses[wire.CmdInv] = deadline
 
 	case wire.CmdGetData:
-		// Expects a block, tx, or notfound message.
+		// Expects a block, cmpctblock, tx, or notfound message.
 		pendingResponses[wire.CmdBlock] = deadline
+		pendingResponses[wire.CmdCmpctBlock] = deadline
 		pendingResponses[wire.CmdTx] = deadline
 		pendingResponses[wire.CmdNotFound] = deadline
 
+	case wire.CmdGetBlockTxn:
+		// Expects a blocktxn message.
+		pendingResponses[wire.CmdBlockTxn] = deadline
+
 	case wire.CmdGetHeaders:
 		// Expects a headers message.  Use a longer deadline since it
 		// can take a while for the remote peer to load all of the
@@ -1224,10 +1311,25 @@ func (p *Peer) maybeAddDeadline(pendingResponses map[string]time.Time, msgCmd st
 	}
 }
 
+// recoverPanic recovers from a panic in a goroutine of the peer and passes it to
+// the configured panic handler.  Panics are not recovered when there is no
+// handler.  It must be deferred directly by the goroutine since a panic can
+// only be recovered from a deferred function.
+func (p *Peer) recoverPanic() {
+	if p.cfg.HandlePanic == nil {
+		return
+	}
+	if r := recover(); r != nil {
+		p.cfg.HandlePanic(p, r)
+	}
+}
+
 // stallHandler handles stall detection for the peer.  This entails keeping
 // track of expected responses and assigning them deadlines while accounting for
 // the time spent in callbacks.  It must be run as a goroutine.
 func (p *Peer) stallHandler() {
+	defer p.recoverPanic()
+
 	// These variables are used to adjust the deadline times forward by the
 	// time it takes callbacks to execute.  This is done because new
 	// messages aren't read until the previous one is finished processing
@@ -1268,10 +1370,13 @@ out:
 				switch msgCmd := msg.message.Command(); msgCmd {
 				case wire.CmdBlock:
 					fallthrough
+				case wire.CmdCmpctBlock:
+					fallthrough
 				case wire.CmdTx:
 					fallthrough
 				case wire.CmdNotFound:
 					delete(pendingResponses, wire.CmdBlock)
+					delete(pendingResponses, wire.CmdCmpctBlock)
 					delete(pendingResponses, wire.CmdTx)
 					delete(pendingResponses, wire.CmdNotFound)
 
@@ -1377,6 +1482,8 @@ cleanup:
 // inHandler handles all incoming messages for the peer.  It must be run as a
 // goroutine.
 func (p *Peer) inHandler() {
+	defer p.recoverPanic()
+
 	// Peers must complete the initial version negotiation within a shorter
 	// timeframe than a general idle timeout.  The timer is then reset below
 	// to idleTimeout for all future messages.
@@ -1561,6 +1668,51 @@ out:
 				p.cfg.Listeners.OnSendHeaders(p, msg)
 			}
 
+		case *wire.MsgDoubleSpendProof:
+			if p.cfg.Listeners.OnDoubleSpendProof != nil {
+				p.cfg.Listeners.OnDoubleSpendProof(p, msg)
+			}
+
+		case *wire.MsgSendCmpct:
+			// Compact blocks with other encoding versions are not
+			// understood, so they are neither requested from nor
+			// announced to the peer.
+			p.flagsMtx.Lock()
+			if msg.CmpctBlockVersion == wire.CmpctBlockEncodingVersion {
+				p.cmpctBlocksSupported = true
+				p.cmpctBlocksAnnounced = msg.AnnounceUsingCmpctBlock
+			}
+			p.flagsMtx.Unlock()
+
+			if p.cfg.Listeners.OnSendCmpct != nil {
+				p.cfg.Listeners.OnSendCmpct(p, msg)
+			}
+
+		case *wire.MsgCmpctBlock:
+			if p.cfg.Listeners.OnCmpctBlock != nil {
+				p.cfg.Listeners.OnCmpctBlock(p, msg)
+			}
+
+		case *wire.MsgGetBlockTxn:
+			if p.cfg.Listeners.OnGetBlockTxn != nil {
+				p.cfg.Listeners.OnGetBlockTxn(p, msg)
+			}
+
+		case *wire.MsgBlockTxn:
+			if p.cfg.Listeners.OnBlockTxn != nil {
+				p.cfg.Listeners.OnBlockTxn(p, msg)
+			}
+
+		case *wire.MsgGetPackage:
+			if p.cfg.Listeners.OnGetPackage != nil {
+				p.cfg.Listeners.OnGetPackage(p, msg)
+			}
+
+		case *wire.MsgPackage:
+			if p.cfg.Listeners.OnPackage != nil {
+				p.cfg.Listeners.OnPackage(p, msg)
+			}
+
 		default:
 			log.Debugf("Received unhandled message of type %v "+
 				"from %v", rmsg.Command(), p)
@@ -1586,6 +1738,8 @@ out:
 // handlers will not block on us sending a message.  That data is then passed on
 // to outHandler to be actually written.
 func (p *Peer) queueHandler() {
+	defer p.recoverPanic()
+
 	pendingMsgs := list.New()
 	invSendQueue := list.New()
 	trickleTicker := time.NewTicker(trickleTimeout)
@@ -1732,6 +1886,8 @@ func (p *Peer) shouldLogWriteError(err error) bool {
 // goroutine.  It uses a buffered channel to serialize output messages while
 // allowing the sender to continue running asynchronously.
 func (p *Peer) outHandler() {
+	defer p.recoverPanic()
+
 	// pingTicker is used to periodically send pings to the remote peer.
 	pingTicker := time.NewTicker(pingInterval)
 	defer pingTicker.Stop()
@@ -2034,6 +2190,11 @@ func newPeerBase(cfg *Config, inbound bool) *Peer {
 		cfg.ChainParams = &chaincfg.TestNet2Params
 	}
 
+	// Use the system clock if the caller did not specify a clock.
+	if cfg.Clock == nil {
+		cfg.Clock = blockchain.SystemClock()
+	}
+
 	p := Peer{
 		inbound:         inbound,
 		knownInventory:  newMruInventoryMap(maxKnownInventory),
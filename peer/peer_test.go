@@ -11,6 +11,7 @@ import (
 	"io"
 	"net"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -313,6 +314,152 @@ func TestPeerConnection(t *testing.T) {
 	}
 }
 
+// msgStatsCollector is a wire.MessageStatsCollector which counts the bytes of
+// the messages it receives the statistics of by command.
+type msgStatsCollector struct {
+	sync.Mutex
+	read    map[string]int
+	written map[string]int
+}
+
+// MessageRead counts the bytes of the read message.
+func (c *msgStatsCollector) MessageRead(stats *wire.MessageStats) {
+	c.Lock()
+	c.read[stats.Command] += stats.Bytes
+	c.Unlock()
+}
+
+// MessageWritten counts the bytes of the written message.
+func (c *msgStatsCollector) MessageWritten(stats *wire.MessageStats) {
+	c.Lock()
+	c.written[stats.Command] += stats.Bytes
+	c.Unlock()
+}
+
+// TestPeerMessageStats ensures the message statistics collector of a peer only
+// receives the statistics of the messages of that peer.
+func TestPeerMessageStats(t *testing.T) {
+	verack := make(chan struct{}, 4)
+	newPeerCfg := func(c wire.MessageStatsCollector) *peer.Config {
+		return &peer.Config{
+			Listeners: peer.MessageListeners{
+				OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+					verack <- struct{}{}
+				},
+				OnWrite: func(p *peer.Peer, bytesWritten int,
+					msg wire.Message, err error) {
+					if _, ok := msg.(*wire.MsgVerAck); ok {
+						verack <- struct{}{}
+					}
+				},
+			},
+			UserAgentName:    "peer",
+			UserAgentVersion: "1.0",
+			ChainParams:      &chaincfg.MainNetParams,
+			MessageStats:     c,
+		}
+	}
+	inStats := &msgStatsCollector{
+		read:    make(map[string]int),
+		written: make(map[string]int),
+	}
+	inConn, outConn := pipe(
+		&conn{raddr: "10.0.0.1:8333"},
+		&conn{raddr: "10.0.0.2:8333"},
+	)
+	inPeer := peer.NewInboundPeer(newPeerCfg(inStats))
+	inPeer.AssociateConnection(inConn)
+	outPeer, err := peer.NewOutboundPeer(newPeerCfg(nil), "10.0.0.2:8333")
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected error: %v", err)
+	}
+	outPeer.AssociateConnection(outConn)
+	for i := 0; i < 4; i++ {
+		select {
+		case <-verack:
+		case <-time.After(time.Second):
+			t.Fatal("verack timeout")
+		}
+	}
+
+	// The collector must have received the version and verack messages of
+	// the inbound peer in both directions, and nothing else.
+	want := map[string]int{
+		wire.CmdVersion: 134,
+		wire.CmdVerAck:  24,
+	}
+	inStats.Lock()
+	for _, counts := range []map[string]int{inStats.read, inStats.written} {
+		if len(counts) != len(want) {
+			t.Errorf("unexpected message stats %v, want %v", counts,
+				want)
+		}
+		for cmd, bytes := range want {
+			if counts[cmd] != bytes {
+				t.Errorf("unexpected bytes for %s - got %d, want %d",
+					cmd, counts[cmd], bytes)
+			}
+		}
+	}
+	inStats.Unlock()
+
+	inPeer.Disconnect()
+	outPeer.Disconnect()
+	inPeer.WaitForDisconnect()
+	outPeer.WaitForDisconnect()
+}
+
+// TestPeerHandlePanic ensures a panic in a message listener is recovered and
+// passed to the configured panic handler along with the peer which panicked.
+func TestPeerHandlePanic(t *testing.T) {
+	type recovered struct {
+		p *peer.Peer
+		r interface{}
+	}
+	panics := make(chan recovered, 1)
+	inPeer := peer.NewInboundPeer(&peer.Config{
+		Listeners: peer.MessageListeners{
+			OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+				panic("verack")
+			},
+		},
+		UserAgentName:    "peer",
+		UserAgentVersion: "1.0",
+		ChainParams:      &chaincfg.MainNetParams,
+		HandlePanic: func(p *peer.Peer, r interface{}) {
+			panics <- recovered{p, r}
+		},
+	})
+	outPeer, err := peer.NewOutboundPeer(&peer.Config{
+		UserAgentName:    "peer",
+		UserAgentVersion: "1.0",
+		ChainParams:      &chaincfg.MainNetParams,
+	}, "10.0.0.2:8333")
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected error: %v", err)
+	}
+	inConn, outConn := pipe(
+		&conn{raddr: "10.0.0.1:8333"},
+		&conn{raddr: "10.0.0.2:8333"},
+	)
+	inPeer.AssociateConnection(inConn)
+	outPeer.AssociateConnection(outConn)
+
+	select {
+	case got := <-panics:
+		if got.p != inPeer || got.r != "verack" {
+			t.Errorf("unexpected recovered panic %v from peer %v", got.r,
+				got.p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("panic handler timeout")
+	}
+
+	inPeer.Disconnect()
+	outPeer.Disconnect()
+	outPeer.WaitForDisconnect()
+}
+
 // TestPeerListeners tests that the peer listeners are called as expected.
 func TestPeerListeners(t *testing.T) {
 	verack := make(chan struct{}, 1)
@@ -385,6 +532,27 @@ func TestPeerListeners(t *testing.T) {
 			OnSendHeaders: func(p *peer.Peer, msg *wire.MsgSendHeaders) {
 				ok <- msg
 			},
+			OnDoubleSpendProof: func(p *peer.Peer, msg *wire.MsgDoubleSpendProof) {
+				ok <- msg
+			},
+			OnSendCmpct: func(p *peer.Peer, msg *wire.MsgSendCmpct) {
+				ok <- msg
+			},
+			OnCmpctBlock: func(p *peer.Peer, msg *wire.MsgCmpctBlock) {
+				ok <- msg
+			},
+			OnGetBlockTxn: func(p *peer.Peer, msg *wire.MsgGetBlockTxn) {
+				ok <- msg
+			},
+			OnBlockTxn: func(p *peer.Peer, msg *wire.MsgBlockTxn) {
+				ok <- msg
+			},
+			OnGetPackage: func(p *peer.Peer, msg *wire.MsgGetPackage) {
+				ok <- msg
+			},
+			OnPackage: func(p *peer.Peer, msg *wire.MsgPackage) {
+				ok <- msg
+			},
 		},
 		UserAgentName:    "peer",
 		UserAgentVersion: "1.0",
@@ -419,6 +587,12 @@ func TestPeerListeners(t *testing.T) {
 		}
 	}
 
+	// The spends of a double spend proof must have an input which spends
+	// the outpoint.
+	dsTx := wire.NewMsgTx()
+	dsTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil))
+	dsSpend := &wire.DoubleSpendProofSpend{Tx: *dsTx}
+
 	tests := []struct {
 		listener string
 		msg      wire.Message
@@ -512,6 +686,35 @@ func TestPeerListeners(t *testing.T) {
 			"OnSendHeaders",
 			wire.NewMsgSendHeaders(),
 		},
+		{
+			"OnDoubleSpendProof",
+			wire.NewMsgDoubleSpendProof(&wire.OutPoint{}, dsSpend,
+				dsSpend),
+		},
+		{
+			"OnSendCmpct",
+			wire.NewMsgSendCmpct(true, wire.CmpctBlockEncodingVersion),
+		},
+		{
+			"OnCmpctBlock",
+			wire.NewMsgCmpctBlock(&wire.BlockHeader{}, 0),
+		},
+		{
+			"OnGetBlockTxn",
+			wire.NewMsgGetBlockTxn(&chainhash.Hash{}, nil, nil),
+		},
+		{
+			"OnBlockTxn",
+			wire.NewMsgBlockTxn(&chainhash.Hash{}),
+		},
+		{
+			"OnGetPackage",
+			wire.NewMsgGetPackage(&chainhash.Hash{}),
+		},
+		{
+			"OnPackage",
+			wire.NewMsgPackage(),
+		},
 	}
 	t.Logf("Running %d tests", len(tests))
 	for _, test := range tests {
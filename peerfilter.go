@@ -0,0 +1,214 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/wire"
+)
+
+// peerFilterAction identifies what is done with peers matched by a peer filter
+// rule.
+type peerFilterAction int
+
+// These constants define the actions of peer filter rules.  They are ordered
+// by severity so the most severe action of all matching rules applies.
+const (
+	// pfNone indicates the peer is not matched by any rule.
+	pfNone peerFilterAction = iota
+
+	// pfDeprioritize indicates the peer is only used when no other peers
+	// are available, such as when choosing a peer to sync from.
+	pfDeprioritize
+
+	// pfRefuse indicates the peer is disconnected once it sends its version
+	// message.
+	pfRefuse
+)
+
+// peerFilterActionStrings is a map of peer filter actions back to the names
+// used to configure them.
+var peerFilterActionStrings = map[peerFilterAction]string{
+	pfNone:         "none",
+	pfDeprioritize: "deprioritize",
+	pfRefuse:       "refuse",
+}
+
+// String returns the peerFilterAction in human-readable form.
+func (a peerFilterAction) String() string {
+	if s, ok := peerFilterActionStrings[a]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown peerFilterAction (%d)", int(a))
+}
+
+// peerFilterRule describes a rule which matches peers by their user agent,
+// protocol version, or advertised services.
+type peerFilterRule struct {
+	// matches must only be used atomically.
+	matches uint64
+
+	rule   string
+	action peerFilterAction
+
+	// Only the criteria for the kind of the rule are set.
+	userAgent  *regexp.Regexp
+	minVersion uint32
+	maxVersion uint32
+	services   wire.ServiceFlag
+}
+
+// parsePeerFilterRule returns the peer filter rule described by the passed
+// string, which must be in the form <action>:<kind>:<value>.  The action is one
+// of refuse or deprioritize.  The kind is one of useragent, which matches peers
+// whose user agent matches the value where * and ? match any sequence of
+// characters and any single character, version, which matches peers whose
+// protocol version is in the range given by the value as either a single
+// version or <min>-<max>, or services, which matches peers that do not
+// advertise all of the service bits given by the value.
+func parsePeerFilterRule(rule string) (*peerFilterRule, error) {
+	parts := strings.SplitN(rule, ":", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("peer filter rule %q is not in the form "+
+			"<action>:<kind>:<value>", rule)
+	}
+
+	r := &peerFilterRule{rule: rule}
+	switch parts[0] {
+	case pfDeprioritize.String():
+		r.action = pfDeprioritize
+	case pfRefuse.String():
+		r.action = pfRefuse
+	default:
+		return nil, fmt.Errorf("peer filter rule %q has invalid action "+
+			"%q -- supported actions [%v %v]", rule, parts[0],
+			pfRefuse, pfDeprioritize)
+	}
+
+	value := parts[2]
+	switch parts[1] {
+	case "useragent":
+		if value == "" {
+			return nil, fmt.Errorf("peer filter rule %q has an empty "+
+				"user agent pattern", rule)
+		}
+		pattern := regexp.QuoteMeta(value)
+		pattern = strings.Replace(pattern, `\*`, ".*", -1)
+		pattern = strings.Replace(pattern, `\?`, ".", -1)
+		r.userAgent = regexp.MustCompile("^" + pattern + "$")
+
+	case "version":
+		bounds := strings.SplitN(value, "-", 2)
+		min, err := strconv.ParseUint(bounds[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("peer filter rule %q has invalid "+
+				"protocol version %q", rule, bounds[0])
+		}
+		max := min
+		if len(bounds) == 2 {
+			max, err = strconv.ParseUint(bounds[1], 10, 32)
+			if err != nil || max < min {
+				return nil, fmt.Errorf("peer filter rule %q has "+
+					"invalid protocol version range %q", rule,
+					value)
+			}
+		}
+		r.minVersion, r.maxVersion = uint32(min), uint32(max)
+
+	case "services":
+		services, err := strconv.ParseUint(value, 0, 64)
+		if err != nil || services == 0 {
+			return nil, fmt.Errorf("peer filter rule %q has invalid "+
+				"service bits %q", rule, value)
+		}
+		r.services = wire.ServiceFlag(services)
+
+	default:
+		return nil, fmt.Errorf("peer filter rule %q has invalid kind %q "+
+			"-- supported kinds [useragent version services]", rule,
+			parts[1])
+	}
+
+	return r, nil
+}
+
+// match returns whether or not the rule matches a peer which sent the passed
+// version message.
+func (r *peerFilterRule) match(msg *wire.MsgVersion) bool {
+	switch {
+	case r.userAgent != nil:
+		return r.userAgent.MatchString(msg.UserAgent)
+	case r.services != 0:
+		return msg.Services&r.services != r.services
+	default:
+		pver := uint32(msg.ProtocolVersion)
+		return pver >= r.minVersion && pver <= r.maxVersion
+	}
+}
+
+// peerFilter applies the configured peer filter rules to peers and tracks the
+// number of peers which were filtered.
+type peerFilter struct {
+	// The following variables must only be used atomically.
+	refused       uint64
+	deprioritized uint64
+
+	rules []*peerFilterRule
+}
+
+// newPeerFilter returns a new peer filter which applies the passed rules.
+func newPeerFilter(rules []*peerFilterRule) *peerFilter {
+	return &peerFilter{rules: rules}
+}
+
+// filter returns the action to take for a peer which sent the passed version
+// message.  The most severe action of all matching rules applies.
+//
+// This function is safe for concurrent access.
+func (f *peerFilter) filter(msg *wire.MsgVersion) peerFilterAction {
+	action := pfNone
+	for _, r := range f.rules {
+		if !r.match(msg) {
+			continue
+		}
+		atomic.AddUint64(&r.matches, 1)
+		if r.action > action {
+			action = r.action
+		}
+	}
+
+	switch action {
+	case pfDeprioritize:
+		atomic.AddUint64(&f.deprioritized, 1)
+	case pfRefuse:
+		atomic.AddUint64(&f.refused, 1)
+	}
+	return action
+}
+
+// stats returns the number of peers filtered overall and by each rule.
+//
+// This function is safe for concurrent access.
+func (f *peerFilter) stats() *dcrjson.GetPeerFilterStatsResult {
+	result := &dcrjson.GetPeerFilterStatsResult{
+		Refused:       atomic.LoadUint64(&f.refused),
+		Deprioritized: atomic.LoadUint64(&f.deprioritized),
+		Rules:         make([]dcrjson.PeerFilterRuleStats, 0, len(f.rules)),
+	}
+	for _, r := range f.rules {
+		result.Rules = append(result.Rules, dcrjson.PeerFilterRuleStats{
+			Rule:    r.rule,
+			Action:  r.action.String(),
+			Matches: atomic.LoadUint64(&r.matches),
+		})
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// TestPeerFilter ensures peer filter rules are parsed and applied to peers as
+// expected and the filtered peers are counted.
+func TestPeerFilter(t *testing.T) {
+	invalid := []string{
+		"refuse",
+		"refuse:useragent",
+		"ban:useragent:/clone:*/",
+		"refuse:address:127.0.0.1",
+		"refuse:useragent:",
+		"refuse:version:a",
+		"refuse:version:5-3",
+		"deprioritize:services:0",
+	}
+	for _, rule := range invalid {
+		if _, err := parsePeerFilterRule(rule); err == nil {
+			t.Fatalf("parsePeerFilterRule(%q): expected error", rule)
+		}
+	}
+
+	var rules []*peerFilterRule
+	for _, rule := range []string{
+		"refuse:useragent:/clone:*/",
+		"deprioritize:version:1-2",
+		"deprioritize:services:0x1",
+	} {
+		r, err := parsePeerFilterRule(rule)
+		if err != nil {
+			t.Fatalf("parsePeerFilterRule(%q): unexpected error: %v",
+				rule, err)
+		}
+		rules = append(rules, r)
+	}
+	f := newPeerFilter(rules)
+
+	tests := []struct {
+		userAgent string
+		pver      int32
+		services  wire.ServiceFlag
+		want      peerFilterAction
+	}{
+		{"/dcrwire:0.2.0/dcrd:0.8.2/", 3, wire.SFNodeNetwork, pfNone},
+		{"/clone:1.0/", 3, wire.SFNodeNetwork, pfRefuse},
+		{"/clone:1.0/", 1, 0, pfRefuse},
+		{"/dcrwire:0.2.0/clone:1.0/", 3, wire.SFNodeNetwork, pfNone},
+		{"/dcrwire:0.2.0/dcrd:0.8.2/", 2, wire.SFNodeNetwork, pfDeprioritize},
+		{"/dcrwire:0.2.0/dcrd:0.8.2/", 3, wire.SFNodeBloom, pfDeprioritize},
+	}
+	for i, test := range tests {
+		msg := &wire.MsgVersion{
+			ProtocolVersion: test.pver,
+			Services:        test.services,
+			UserAgent:       test.userAgent,
+		}
+		if got := f.filter(msg); got != test.want {
+			t.Fatalf("filter #%d: got %v, want %v", i, got, test.want)
+		}
+	}
+
+	stats := f.stats()
+	if stats.Refused != 2 || stats.Deprioritized != 2 {
+		t.Fatalf("stats: got %d refused and %d deprioritized peers, "+
+			"want 2 and 2", stats.Refused, stats.Deprioritized)
+	}
+	wantMatches := []uint64{2, 2, 2}
+	for i, r := range stats.Rules {
+		if r.Matches != wantMatches[i] {
+			t.Fatalf("stats: got %d matches for rule %q, want %d",
+				r.Matches, r.Rule, wantMatches[i])
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// maxRelayHistoryBlocks is the maximum number of the most recent blocks
+	// the relay history is kept for.
+	maxRelayHistoryBlocks = 1000
+
+	// maxRelayHistoryTxns is the maximum number of the most recent
+	// transactions the relay history is kept for.
+	maxRelayHistoryTxns = 10000
+
+	// blockRelayScoreWeight is the weight of each block a peer was the
+	// first to relay in its relay score relative to each transaction it was
+	// the first to relay.
+	blockRelayScoreWeight = 100
+)
+
+// relayRecord houses the provenance of a block or transaction, which is the
+// peer that first announced it, the peer that first delivered it, and when
+// that happened.  The announcement fields are not set for inventory that was
+// delivered without being announced first.
+type relayRecord struct {
+	hash         chainhash.Hash
+	invType      wire.InvType
+	announcedAt  time.Time
+	announcedBy  *serverPeer
+	receivedAt   time.Time
+	receivedFrom *serverPeer
+	credited     bool
+}
+
+// origin returns the peer the inventory originated from, which is the peer that
+// first announced it or the peer that delivered it when it was not announced.
+func (r *relayRecord) origin() *serverPeer {
+	if r.announcedBy != nil {
+		return r.announcedBy
+	}
+	return r.receivedFrom
+}
+
+// firstSeen returns the time the inventory was first announced or delivered.
+func (r *relayRecord) firstSeen() time.Time {
+	if r.announcedBy != nil {
+		return r.announcedAt
+	}
+	return r.receivedAt
+}
+
+// relayHistory tracks the provenance of the most recent blocks and transactions
+// received from remote peers.  Only the first announcement and the first
+// delivery of each block and transaction are recorded, and the oldest records
+// are evicted once the maximum number of records for their type is reached.
+//
+// The relay history is safe for concurrent access.
+type relayHistory struct {
+	mtx     sync.Mutex
+	records map[chainhash.Hash]*relayRecord
+
+	// blocks and txns are the hashes of the records of each type ordered
+	// from the oldest to the most recent.
+	blocks []chainhash.Hash
+	txns   []chainhash.Hash
+}
+
+// newRelayHistory returns a new empty relay history.
+func newRelayHistory() *relayHistory {
+	return &relayHistory{
+		records: make(map[chainhash.Hash]*relayRecord),
+	}
+}
+
+// record returns the record for the passed inventory, creating it and evicting
+// the oldest record of the same type as needed when it does not exist yet.
+//
+// This function MUST be called with the relay history lock held.
+func (h *relayHistory) record(iv *wire.InvVect) *relayRecord {
+	if r, ok := h.records[iv.Hash]; ok {
+		return r
+	}
+
+	r := &relayRecord{hash: iv.Hash, invType: iv.Type}
+	h.records[iv.Hash] = r
+	order, max := &h.txns, maxRelayHistoryTxns
+	if iv.Type == wire.InvTypeBlock {
+		order, max = &h.blocks, maxRelayHistoryBlocks
+	}
+	*order = append(*order, iv.Hash)
+	if len(*order) > max {
+		delete(h.records, (*order)[0])
+		*order = (*order)[1:]
+	}
+	return r
+}
+
+// Announced records that the passed peer announced the passed inventory unless
+// another peer already announced or delivered it.
+func (h *relayHistory) Announced(iv *wire.InvVect, sp *serverPeer, now time.Time) {
+	h.mtx.Lock()
+	r := h.record(iv)
+	if r.announcedBy == nil && r.receivedFrom == nil {
+		r.announcedAt = now
+		r.announcedBy = sp
+	}
+	h.mtx.Unlock()
+}
+
+// Received records that the passed peer delivered the passed inventory unless
+// another peer already delivered it.
+func (h *relayHistory) Received(iv *wire.InvVect, sp *serverPeer, now time.Time) {
+	h.mtx.Lock()
+	r := h.record(iv)
+	if r.receivedFrom == nil {
+		r.receivedAt = now
+		r.receivedFrom = sp
+	}
+	h.mtx.Unlock()
+}
+
+// Credit returns the peer the passed inventory originated from so it can be
+// credited for relaying it first.  It returns nil when the inventory is not
+// known to the relay history or its origin was already credited, which
+// ensures each block and transaction is only credited once.
+func (h *relayHistory) Credit(hash *chainhash.Hash) *serverPeer {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	r, ok := h.records[*hash]
+	if !ok || r.credited {
+		return nil
+	}
+	r.credited = true
+	return r.origin()
+}
+
+// Recent returns up to the passed number of the most recent records of blocks,
+// transactions, or both according to the passed flags ordered from the most
+// recently to the least recently first seen.
+func (h *relayHistory) Recent(includeBlocks, includeTxns bool, count int) []dcrjson.RelayHistoryResult {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	// The records of each type are ordered by the time they were first
+	// seen since they are created when they are first seen, so they are
+	// merged starting from the most recent.
+	var blocks, txns []chainhash.Hash
+	if includeBlocks {
+		blocks = h.blocks
+	}
+	if includeTxns {
+		txns = h.txns
+	}
+	if count > len(blocks)+len(txns) {
+		count = len(blocks) + len(txns)
+	}
+	result := make([]dcrjson.RelayHistoryResult, 0, count)
+	for len(result) < count && (len(blocks) > 0 || len(txns) > 0) {
+		// Take the most recent record of either type.
+		var block, tx *relayRecord
+		if len(blocks) > 0 {
+			block = h.records[blocks[len(blocks)-1]]
+		}
+		if len(txns) > 0 {
+			tx = h.records[txns[len(txns)-1]]
+		}
+		r := block
+		if block == nil || (tx != nil && tx.firstSeen().After(block.firstSeen())) {
+			r = tx
+			txns = txns[:len(txns)-1]
+		} else {
+			blocks = blocks[:len(blocks)-1]
+		}
+
+		entry := dcrjson.RelayHistoryResult{
+			Hash: r.hash.String(),
+			Type: "tx",
+		}
+		if r.invType == wire.InvTypeBlock {
+			entry.Type = "block"
+		}
+		if r.announcedBy != nil {
+			entry.AnnouncedBy = r.announcedBy.Addr()
+			entry.AnnouncedTime = unixMillis(r.announcedAt)
+		}
+		if r.receivedFrom != nil {
+			entry.ReceivedFrom = r.receivedFrom.Addr()
+			entry.ReceivedTime = unixMillis(r.receivedAt)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// unixMillis returns the passed time in milliseconds since 1 Jan 1970 GMT.
+func unixMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/peer"
+	"github.com/decred/dcrd/wire"
+)
+
+// relayHistoryTestPeer returns a server peer with the passed address.
+func relayHistoryTestPeer(t *testing.T, addr string) *serverPeer {
+	p, err := peer.NewOutboundPeer(&peer.Config{}, addr)
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected error: %v", err)
+	}
+	return &serverPeer{Peer: p}
+}
+
+// relayHistoryTestInv returns an inventory vector of the passed type whose hash
+// is derived from the passed number and type.
+func relayHistoryTestInv(invType wire.InvType, n uint32) *wire.InvVect {
+	var hash chainhash.Hash
+	hash[0], hash[1], hash[2] = byte(n), byte(n>>8), byte(n>>16)
+	hash[chainhash.HashSize-1] = byte(invType)
+	return wire.NewInvVect(invType, &hash)
+}
+
+// TestRelayHistoryProvenance ensures only the first announcement and delivery
+// of inventory are recorded and the peer it originated from is only credited
+// once.
+func TestRelayHistoryProvenance(t *testing.T) {
+	h := newRelayHistory()
+	sp1 := relayHistoryTestPeer(t, "127.0.0.1:9108")
+	sp2 := relayHistoryTestPeer(t, "127.0.0.2:9108")
+	now := time.Now()
+
+	// Ensure the first peer to announce a block is credited even when the
+	// block is delivered by another peer.
+	block := relayHistoryTestInv(wire.InvTypeBlock, 1)
+	h.Announced(block, sp1, now)
+	h.Announced(block, sp2, now.Add(time.Second))
+	h.Received(block, sp2, now.Add(2*time.Second))
+	h.Received(block, sp1, now.Add(3*time.Second))
+	if sp := h.Credit(&block.Hash); sp != sp1 {
+		t.Fatalf("Credit: unexpected peer credited for block - got %v, "+
+			"want %v", sp, sp1)
+	}
+	if sp := h.Credit(&block.Hash); sp != nil {
+		t.Fatalf("Credit: block credited again to %v", sp)
+	}
+
+	// Ensure the peer which delivered a transaction without announcing it
+	// first is credited and later announcements are ignored.
+	tx := relayHistoryTestInv(wire.InvTypeTx, 2)
+	h.Received(tx, sp2, now.Add(4*time.Second))
+	h.Announced(tx, sp1, now.Add(5*time.Second))
+	if sp := h.Credit(&tx.Hash); sp != sp2 {
+		t.Fatalf("Credit: unexpected peer credited for transaction - "+
+			"got %v, want %v", sp, sp2)
+	}
+
+	// Ensure unknown inventory is not credited.
+	unknown := relayHistoryTestInv(wire.InvTypeTx, 3)
+	if sp := h.Credit(&unknown.Hash); sp != nil {
+		t.Fatalf("Credit: unknown transaction credited to %v", sp)
+	}
+
+	// Ensure the recorded provenance is returned.
+	recent := h.Recent(true, true, 10)
+	if len(recent) != 2 {
+		t.Fatalf("Recent: unexpected number of records - got %d, want 2",
+			len(recent))
+	}
+	got := recent[1]
+	if got.Hash != block.Hash.String() || got.Type != "block" ||
+		got.AnnouncedBy != sp1.Addr() ||
+		got.AnnouncedTime != unixMillis(now) ||
+		got.ReceivedFrom != sp2.Addr() ||
+		got.ReceivedTime != unixMillis(now.Add(2*time.Second)) {
+
+		t.Fatalf("Recent: unexpected block record %+v", got)
+	}
+	got = recent[0]
+	if got.Hash != tx.Hash.String() || got.Type != "tx" ||
+		got.AnnouncedBy != "" || got.ReceivedFrom != sp2.Addr() {
+
+		t.Fatalf("Recent: unexpected transaction record %+v", got)
+	}
+}
+
+// TestRelayHistoryRecent ensures the most recent records are returned ordered
+// from the most to least recently first seen and the oldest records are evicted
+// once the maximum number of records for their type is reached.
+func TestRelayHistoryRecent(t *testing.T) {
+	h := newRelayHistory()
+	sp := relayHistoryTestPeer(t, "127.0.0.1:9108")
+	start := time.Now()
+
+	// Record an extra block beyond the maximum while interleaving a
+	// transaction after every other block.
+	var txns []*wire.InvVect
+	for i := uint32(0); i <= maxRelayHistoryBlocks; i++ {
+		now := start.Add(time.Duration(2*i) * time.Millisecond)
+		h.Announced(relayHistoryTestInv(wire.InvTypeBlock, i), sp, now)
+		if i%2 == 0 {
+			tx := relayHistoryTestInv(wire.InvTypeTx, i)
+			h.Received(tx, sp, now.Add(time.Millisecond))
+			txns = append(txns, tx)
+		}
+	}
+
+	// Ensure the oldest block was evicted while the transactions were not.
+	oldest := relayHistoryTestInv(wire.InvTypeBlock, 0)
+	if sp := h.Credit(&oldest.Hash); sp != nil {
+		t.Fatal("Credit: evicted block credited")
+	}
+	blocks := h.Recent(true, false, maxRelayHistoryBlocks+1)
+	if len(blocks) != maxRelayHistoryBlocks {
+		t.Fatalf("Recent: unexpected number of blocks - got %d, want %d",
+			len(blocks), maxRelayHistoryBlocks)
+	}
+	allTxns := h.Recent(false, true, len(txns)+1)
+	if len(allTxns) != len(txns) {
+		t.Fatalf("Recent: unexpected number of transactions - got %d, "+
+			"want %d", len(allTxns), len(txns))
+	}
+
+	// Ensure the records of both types are merged by the time they were
+	// first seen.
+	want := []*wire.InvVect{
+		txns[len(txns)-1],
+		relayHistoryTestInv(wire.InvTypeBlock, maxRelayHistoryBlocks),
+		relayHistoryTestInv(wire.InvTypeBlock, maxRelayHistoryBlocks-1),
+		txns[len(txns)-2],
+	}
+	recent := h.Recent(true, true, len(want))
+	if len(recent) != len(want) {
+		t.Fatalf("Recent: unexpected number of records - got %d, want %d",
+			len(recent), len(want))
+	}
+	for i, iv := range want {
+		if recent[i].Hash != iv.Hash.String() {
+			t.Fatalf("Recent: unexpected record %d - got %v, want %v",
+				i, recent[i].Hash, iv.Hash)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/seelog"
+
+	"github.com/decred/dcrd/dcrjson"
+)
+
+const (
+	// rpcAuditTransportHTTP and rpcAuditTransportWebsocket identify the
+	// transport a request was received over in the RPC audit log.
+	rpcAuditTransportHTTP      = "http"
+	rpcAuditTransportWebsocket = "websocket"
+)
+
+// rpcAuditRedactedMethods houses the methods which carry credentials or other
+// secrets such as private keys and passphrases in their parameters.  The
+// parameters of these methods are not hashed in the RPC audit log since the
+// secrets they carry often have too little entropy to withstand a brute force
+// search against the hash.
+var rpcAuditRedactedMethods = map[string]struct{}{
+	"authenticate":           {},
+	"createencryptedwallet":  {},
+	"encryptwallet":          {},
+	"importprivkey":          {},
+	"signrawtransaction":     {},
+	"walletpassphrase":       {},
+	"walletpassphrasechange": {},
+}
+
+// rpcAuditEntry describes a RPC request and its reply as written to the RPC
+// audit log.  Only the hash of the parameters and the size of the reply are
+// recorded so that the log never holds the data exchanged with the clients.
+type rpcAuditEntry struct {
+	Time       string               `json:"time"`
+	RemoteAddr string               `json:"remoteaddr"`
+	Transport  string               `json:"transport"`
+	User       string               `json:"user"`
+	Method     string               `json:"method"`
+	ParamsHash string               `json:"paramshash,omitempty"`
+	Redacted   bool                 `json:"redacted,omitempty"`
+	LatencyMs  float64              `json:"latencyms"`
+	ResultSize int                  `json:"resultsize"`
+	ErrorCode  dcrjson.RPCErrorCode `json:"errorcode,omitempty"`
+	AuthFailed bool                 `json:"authfailed,omitempty"`
+
+	// start is the time the request was received.
+	start time.Time
+}
+
+// newRPCAuditEntry returns an audit entry for a request for the passed method
+// and parameters which was received from the passed remote address at the
+// passed time.  The user is the configured name of the admin or limited user
+// the client authenticated as.
+func newRPCAuditEntry(start time.Time, transport, remoteAddr string, isAdmin bool, method string, params []json.RawMessage) *rpcAuditEntry {
+	entry := &rpcAuditEntry{
+		Time:       start.UTC().Format(time.RFC3339Nano),
+		RemoteAddr: remoteAddr,
+		Transport:  transport,
+		User:       cfg.RPCLimitUser,
+		Method:     method,
+		start:      start,
+	}
+	if isAdmin {
+		entry.User = cfg.RPCUser
+	}
+	if _, ok := rpcAuditRedactedMethods[method]; ok {
+		entry.Redacted = true
+	} else if params != nil {
+		// The raw parameters are marshalled again rather than hashed as
+		// received so the hash does not depend on the formatting of the
+		// request.
+		serialized, err := json.Marshal(params)
+		if err == nil {
+			hash := sha256.Sum256(serialized)
+			entry.ParamsHash = hex.EncodeToString(hash[:])
+		}
+	}
+	return entry
+}
+
+// newRPCAuthFailureEntry returns an audit entry for a failed authentication
+// attempt which was received from the passed remote address at the passed time.
+// The user is the name the client attempted to authenticate as and the method
+// is the command it attempted to authenticate with, which is empty for HTTP
+// requests since their credentials are checked before the request is read.  The
+// parameters are never recorded since they carry the attempted credentials.
+func newRPCAuthFailureEntry(start time.Time, transport, remoteAddr, user, method string) *rpcAuditEntry {
+	return &rpcAuditEntry{
+		Time:       start.UTC().Format(time.RFC3339Nano),
+		RemoteAddr: remoteAddr,
+		Transport:  transport,
+		User:       user,
+		Method:     method,
+		AuthFailed: true,
+		start:      start,
+	}
+}
+
+// rpcAuditor writes an entry to the RPC audit log for each RPC request.  The
+// log is rotated once it reaches a configured size.
+type rpcAuditor struct {
+	logger seelog.LoggerInterface
+}
+
+// newRPCAuditor returns a new RPC auditor which writes to the passed log file.
+// The file is rotated once it reaches maxSize bytes and at most maxRolls of the
+// rotated files are kept.
+func newRPCAuditor(logFile string, maxSize int64, maxRolls int) (*rpcAuditor, error) {
+	// The entries are written synchronously so that they are never lost
+	// when the process exits and they are never dropped under load.
+	config := `
+	<seelog type="sync" minlevel="info">
+		<outputs formatid="audit">
+			<rollingfile type="size" filename="%s" maxsize="%d" maxrolls="%d" />
+		</outputs>
+		<formats>
+			<format id="audit" format="%%Msg%%n" />
+		</formats>
+	</seelog>`
+	var escaped bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(logFile)); err != nil {
+		return nil, err
+	}
+	config = fmt.Sprintf(config, escaped.String(), maxSize, maxRolls)
+
+	logger, err := seelog.LoggerFromConfigAsString(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RPC audit log: %v", err)
+	}
+	return &rpcAuditor{logger: logger}, nil
+}
+
+// write completes the passed audit entry with the time taken to reply, the
+// marshalled reply, and the error the request was replied to with, if any, and
+// writes it to the log.
+//
+// This function is safe for concurrent access.
+func (a *rpcAuditor) write(entry *rpcAuditEntry, reply []byte, replyErr error) {
+	entry.LatencyMs = time.Since(entry.start).Seconds() * 1000
+	entry.ResultSize = len(reply)
+	if replyErr != nil {
+		if jErr, ok := replyErr.(*dcrjson.RPCError); ok {
+			entry.ErrorCode = jErr.Code
+		} else {
+			entry.ErrorCode = dcrjson.ErrRPCInternal.Code
+		}
+	}
+
+	serialized, err := json.Marshal(entry)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal RPC audit entry: %v", err)
+		return
+	}
+	a.logger.Info(string(serialized))
+}
+
+// Close flushes and closes the RPC audit log.
+func (a *rpcAuditor) Close() {
+	a.logger.Close()
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrjson"
+)
+
+// TestRPCAuditLog ensures RPC audit entries hash the parameters independently
+// of their formatting, redact the parameters of commands which carry secrets,
+// are written to the audit log with the details of the reply, and that failed
+// authentication attempts are recorded.
+func TestRPCAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpcaudit")
+	if err != nil {
+		t.Fatalf("TempDir: unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logFile := filepath.Join(dir, "rpcaudit.log")
+	auditor, err := newRPCAuditor(logFile, 1024*1024, 1)
+	if err != nil {
+		t.Fatalf("newRPCAuditor: unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	params := []json.RawMessage{json.RawMessage(`"abc"`),
+		json.RawMessage(`{ "a": 1 }`)}
+	reformatted := []json.RawMessage{json.RawMessage(`"abc"`),
+		json.RawMessage(`{"a":1}`)}
+	entry := newRPCAuditEntry(start, rpcAuditTransportHTTP,
+		"127.0.0.1:1234", true, "getblock", params)
+	other := newRPCAuditEntry(start, rpcAuditTransportHTTP,
+		"127.0.0.1:1234", true, "getblock", reformatted)
+	if entry.ParamsHash == "" || entry.ParamsHash != other.ParamsHash {
+		t.Fatalf("got params hashes %q and %q, want equal hashes",
+			entry.ParamsHash, other.ParamsHash)
+	}
+
+	redacted := newRPCAuditEntry(start, rpcAuditTransportWebsocket,
+		"127.0.0.1:1234", false, "walletpassphrase", params)
+	if !redacted.Redacted || redacted.ParamsHash != "" {
+		t.Fatalf("walletpassphrase entry was not redacted: %+v",
+			redacted)
+	}
+
+	auditor.write(entry, []byte("reply"), nil)
+	auditor.write(redacted, nil, dcrjson.ErrRPCInvalidParams)
+	auditor.write(other, nil, errors.New("internal"))
+	failure := newRPCAuthFailureEntry(start, rpcAuditTransportWebsocket,
+		"127.0.0.1:1234", "bob", "authenticate")
+	auditor.write(failure, nil, nil)
+	auditor.Close()
+
+	f, err := os.Open(logFile)
+	if err != nil {
+		t.Fatalf("Open: unexpected error: %v", err)
+	}
+	defer f.Close()
+	var entries []rpcAuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e rpcAuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("Unmarshal: unexpected error: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("got %d audit entries, want 4", len(entries))
+	}
+	if e := entries[0]; e.Method != "getblock" ||
+		e.Transport != rpcAuditTransportHTTP || e.ResultSize != 5 ||
+		e.ErrorCode != 0 || e.ParamsHash != entry.ParamsHash {
+		t.Fatalf("unexpected audit entry %+v", e)
+	}
+	if e := entries[1]; e.Method != "walletpassphrase" || !e.Redacted ||
+		e.ParamsHash != "" || e.ErrorCode != dcrjson.ErrRPCInvalidParams.Code {
+		t.Fatalf("unexpected audit entry %+v", e)
+	}
+	if e := entries[2]; e.ErrorCode != dcrjson.ErrRPCInternal.Code ||
+		e.AuthFailed {
+		t.Fatalf("unexpected audit entry %+v", e)
+	}
+	if e := entries[3]; !e.AuthFailed || e.User != "bob" ||
+		e.Method != "authenticate" || e.ParamsHash != "" {
+		t.Fatalf("unexpected audit entry %+v", e)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// blockJSONCacheDepth is the number of blocks at the tip of the main chain
+// whose verbose getblock replies are cached.  Only the most recent blocks are
+// requested often enough for caching them to be worthwhile.
+const blockJSONCacheDepth = 6
+
+// blockJSONCacheKey identifies a cached verbose getblock reply by the hash of
+// the block and whether the transactions are included verbosely.
+type blockJSONCacheKey struct {
+	hash      chainhash.Hash
+	verboseTx bool
+}
+
+// blockJSONCache houses the serialized verbose getblock replies of the blocks
+// near the tip of the main chain.  Every client which connects, such as block
+// explorers, requests the same recent blocks, so serving them from the cache
+// avoids building and serializing the same replies over and over.
+//
+// The replies include the number of confirmations and the next block hash, so
+// they are only valid for the main chain tip they were built against.  The
+// cache is emptied whenever the tip changes, which includes reorganizations.
+type blockJSONCache struct {
+	mtx       sync.Mutex
+	tip       chainhash.Hash
+	tipHeight int64
+	replies   map[blockJSONCacheKey]json.RawMessage
+}
+
+// newBlockJSONCache returns a new empty verbose getblock reply cache.
+func newBlockJSONCache() *blockJSONCache {
+	return &blockJSONCache{
+		tipHeight: -1,
+		replies:   make(map[blockJSONCacheKey]json.RawMessage),
+	}
+}
+
+// Lookup returns the cached reply for the passed key which was built against
+// the passed main chain tip, if any.
+//
+// This function is safe for concurrent access.
+func (c *blockJSONCache) Lookup(tip *chainhash.Hash, key blockJSONCacheKey) (json.RawMessage, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.tip != *tip {
+		return nil, false
+	}
+	reply, ok := c.replies[key]
+	return reply, ok
+}
+
+// Add caches the passed reply for the block at the passed height which was
+// built against the passed main chain tip.  The cached replies of any other tip
+// are discarded unless the passed tip is older than the cached one, in which
+// case the reply is not cached at all.  Replies for blocks which are not among
+// the most recent blocks of the tip are not cached either.
+//
+// This function is safe for concurrent access.
+func (c *blockJSONCache) Add(tip *chainhash.Hash, tipHeight int64, key blockJSONCacheKey, height int64, reply json.RawMessage) {
+	if height > tipHeight || tipHeight-height >= blockJSONCacheDepth {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.tip != *tip {
+		if tipHeight < c.tipHeight {
+			return
+		}
+		c.tip = *tip
+		c.tipHeight = tipHeight
+		c.replies = make(map[blockJSONCacheKey]json.RawMessage)
+	}
+	c.replies[key] = reply
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestBlockJSONCache ensures cached getblock replies are only served for the
+// tip they were built against, replies of older tips never replace those of
+// newer ones, and only the most recent blocks are cached.
+func TestBlockJSONCache(t *testing.T) {
+	c := newBlockJSONCache()
+	tip1 := chainhash.Hash{1}
+	tip2 := chainhash.Hash{2}
+	key := blockJSONCacheKey{hash: chainhash.Hash{3}}
+	verboseKey := blockJSONCacheKey{hash: chainhash.Hash{3}, verboseTx: true}
+	reply := json.RawMessage(`{"height":98}`)
+
+	c.Add(&tip1, 100, key, 98, reply)
+	if got, ok := c.Lookup(&tip1, key); !ok || string(got) != string(reply) {
+		t.Fatalf("Lookup: unexpected reply %q (found %v)", got, ok)
+	}
+	if _, ok := c.Lookup(&tip1, verboseKey); ok {
+		t.Fatal("Lookup: unexpected reply for verbose transactions")
+	}
+	if _, ok := c.Lookup(&tip2, key); ok {
+		t.Fatal("Lookup: unexpected reply for a different tip")
+	}
+
+	// Ensure blocks which are too deep or after the tip are not cached.
+	deepKey := blockJSONCacheKey{hash: chainhash.Hash{4}}
+	c.Add(&tip1, 100, deepKey, 100-blockJSONCacheDepth, reply)
+	if _, ok := c.Lookup(&tip1, deepKey); ok {
+		t.Fatal("Lookup: unexpected reply for a deep block")
+	}
+	c.Add(&tip1, 100, deepKey, 101, reply)
+	if _, ok := c.Lookup(&tip1, deepKey); ok {
+		t.Fatal("Lookup: unexpected reply for a block after the tip")
+	}
+
+	// Ensure a reply for a new tip discards those of the previous tip and
+	// replies for an older tip are ignored afterwards.
+	c.Add(&tip2, 101, verboseKey, 98, reply)
+	if _, ok := c.Lookup(&tip2, key); ok {
+		t.Fatal("Lookup: reply of the previous tip was not discarded")
+	}
+	c.Add(&tip1, 100, key, 98, reply)
+	if _, ok := c.Lookup(&tip1, key); ok {
+		t.Fatal("Lookup: unexpected reply for an older tip")
+	}
+	if _, ok := c.Lookup(&tip2, verboseKey); !ok {
+		t.Fatal("Lookup: reply of the current tip was discarded")
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/decred/dcrd/database"
+)
+
+const (
+	// healthCheckTimeout is the maximum amount of time an individual
+	// subsystem is given to respond to a health check before it is
+	// considered unresponsive.
+	healthCheckTimeout = time.Second * 5
+
+	// healthStatusOK and healthStatusUnavailable are the overall and
+	// per-subsystem statuses reported by the health endpoints.
+	healthStatusOK          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+// healthCheckResult describes the result of checking a single subsystem.
+type healthCheckResult struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// healthResult models the data returned from the /health and /ready
+// endpoints.  The results of the individual checks are only included for
+// authenticated clients.
+type healthResult struct {
+	Status string                       `json:"status"`
+	Checks map[string]healthCheckResult `json:"checks,omitempty"`
+}
+
+// healthCheck describes a named subsystem check.  The check function returns
+// a short human-readable detail about the state of the subsystem along with a
+// non-nil error when the subsystem is unhealthy.
+type healthCheck struct {
+	name  string
+	check func() (string, error)
+}
+
+// runHealthCheck runs the passed check function and waits for it to complete
+// for at most the passed timeout.  This ensures a subsystem which is wedged is
+// reported as unhealthy rather than causing the request to hang.
+func runHealthCheck(check func() (string, error), timeout time.Duration) healthCheckResult {
+	type checkResult struct {
+		detail string
+		err    error
+	}
+
+	// The channel is buffered so the goroutine running the check is able
+	// to send its result and exit once the check completes even when the
+	// timeout expired and nothing receives the result any longer.
+	c := make(chan checkResult, 1)
+	go func() {
+		detail, err := check()
+		c <- checkResult{detail, err}
+	}()
+
+	select {
+	case r := <-c:
+		if r.err != nil {
+			return healthCheckResult{
+				Status: healthStatusUnavailable,
+				Detail: r.err.Error(),
+			}
+		}
+		return healthCheckResult{Status: healthStatusOK, Detail: r.detail}
+	case <-time.After(timeout):
+		return healthCheckResult{
+			Status: healthStatusUnavailable,
+			Detail: "timeout waiting for response",
+		}
+	}
+}
+
+// checkDatabaseHealth returns an error when the database is not open.
+func (s *rpcServer) checkDatabaseHealth() (string, error) {
+	err := s.server.db.View(func(dbTx database.Tx) error {
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return "open", nil
+}
+
+// checkMempoolHealth returns an error when the mempool does not respond.
+func (s *rpcServer) checkMempoolHealth() (string, error) {
+	count := s.server.txMemPool.Count()
+	return fmt.Sprintf("%d transactions", count), nil
+}
+
+// checkPeersHealth returns an error when the number of connected peers is
+// below the configured threshold.
+func (s *rpcServer) checkPeersHealth() (string, error) {
+	connected := s.server.ConnectedCount()
+	if int(connected) < cfg.RPCHealthMinPeers {
+		return "", fmt.Errorf("%d connected peers, need at least %d",
+			connected, cfg.RPCHealthMinPeers)
+	}
+	return fmt.Sprintf("%d connected peers", connected), nil
+}
+
+// heightsAscending implements sort.Interface to sort block heights in
+// ascending order.
+type heightsAscending []int64
+
+func (s heightsAscending) Len() int           { return len(s) }
+func (s heightsAscending) Less(i, j int) bool { return s[i] < s[j] }
+func (s heightsAscending) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// medianHeight returns the median of the passed block heights, which is the
+// lower of the two middle heights when there is an even number of them, or 0
+// when there are none.  Using the median rather than the highest height means
+// a minority of peers which claim made up heights can't make the node appear
+// to be behind.  The passed slice is sorted in place.
+func medianHeight(heights []int64) int64 {
+	if len(heights) == 0 {
+		return 0
+	}
+	sort.Sort(heightsAscending(heights))
+	return heights[(len(heights)-1)/2]
+}
+
+// checkSyncHealth returns an error when the chain is more than the configured
+// number of blocks behind the median height reported by the connected peers.
+// When there are no peers to compare with, the block manager's notion of
+// whether or not it is current is used instead.
+func (s *rpcServer) checkSyncHealth() (string, error) {
+	best := s.chain.BestSnapshot()
+	var peerHeights []int64
+	for _, sp := range s.server.Peers() {
+		if height := sp.LastBlock(); height > 0 {
+			peerHeights = append(peerHeights, height)
+		}
+	}
+	peerHeight := medianHeight(peerHeights)
+	if peerHeight == 0 {
+		if !s.server.blockManager.IsCurrent() {
+			return "", errors.New("chain is not synced")
+		}
+		return fmt.Sprintf("height %d", best.Height), nil
+	}
+
+	lag := peerHeight - best.Height
+	if lag > int64(cfg.RPCHealthMaxLag) {
+		return "", fmt.Errorf("height %d is %d blocks behind peers "+
+			"(max %d)", best.Height, lag, cfg.RPCHealthMaxLag)
+	}
+	return fmt.Sprintf("height %d", best.Height), nil
+}
+
+// liveChecks returns the checks used by the /health endpoint.  They only
+// verify the core subsystems are responsive and therefore are suitable for
+// determining if the process needs to be restarted.
+func (s *rpcServer) liveChecks() []healthCheck {
+	return []healthCheck{
+		{"database", s.checkDatabaseHealth},
+		{"mempool", s.checkMempoolHealth},
+	}
+}
+
+// readyChecks returns the checks used by the /ready endpoint.  In addition to
+// the live checks, they verify the node is synced and well connected and
+// therefore are suitable for determining if the node should be routed
+// requests.
+func (s *rpcServer) readyChecks() []healthCheck {
+	return append(s.liveChecks(),
+		healthCheck{"peers", s.checkPeersHealth},
+		healthCheck{"sync", s.checkSyncHealth})
+}
+
+// handleHealth returns an HTTP handler which runs the passed checks and
+// responds with their results.  The response status code is 200 when all of
+// the checks pass and 503 otherwise so load balancers and orchestration
+// platforms can act on it without parsing the body.
+//
+// These endpoints intentionally do not require authentication since they are
+// intended to be polled by infrastructure.  Unauthenticated clients are only
+// given the overall status, while the results of the individual checks, which
+// reveal details such as the block height, the number of peers, and database
+// errors, are only given to authenticated clients.  The endpoints are subject
+// to the same limit on the number of clients as the other RPC requests.
+func (s *rpcServer) handleHealth(checks func() []healthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer recoverCrash("RPCS", s.server.blockManager.chain)
+
+		w.Header().Set("Connection", "close")
+		w.Header().Set("Content-Type", "application/json")
+		r.Close = true
+
+		// Limit the number of connections to max allowed.
+		if s.limitConnections(w, r.RemoteAddr) {
+			return
+		}
+
+		// Keep track of the number of connected clients.
+		s.incrementClients()
+		defer s.decrementClients()
+
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "405 Method Not Allowed.",
+				http.StatusMethodNotAllowed)
+			return
+		}
+
+		authenticated, _, err := s.checkAuth(r, false)
+		if err != nil {
+			s.auditAuthFailure(rpcAuditTransportHTTP, r)
+			jsonAuthFail(w)
+			return
+		}
+
+		result := healthResult{
+			Status: healthStatusOK,
+			Checks: make(map[string]healthCheckResult),
+		}
+		for _, c := range checks() {
+			checkResult := runHealthCheck(c.check, healthCheckTimeout)
+			if checkResult.Status != healthStatusOK {
+				result.Status = healthStatusUnavailable
+			}
+			result.Checks[c.name] = checkResult
+		}
+		if !authenticated {
+			result.Checks = nil
+		}
+
+		resp, err := json.Marshal(&result)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal health result: %v", err)
+			http.Error(w, "500 Internal Server Error.",
+				http.StatusInternalServerError)
+			return
+		}
+
+		code := http.StatusOK
+		if result.Status != healthStatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		w.WriteHeader(code)
+		if _, err := w.Write(resp); err != nil {
+			rpcsLog.Errorf("Failed to write health response: %v", err)
+		}
+	}
+}
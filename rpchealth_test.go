@@ -0,0 +1,71 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestMedianHeight ensures the median of the heights reported by peers is not
+// swayed by a minority of peers which report made up heights.
+func TestMedianHeight(t *testing.T) {
+	tests := []struct {
+		heights []int64
+		want    int64
+	}{
+		{nil, 0},
+		{[]int64{100}, 100},
+		{[]int64{100, 1000000}, 100},
+		{[]int64{1000000, 101, 100}, 101},
+		{[]int64{99, 1000000, 100, 2000000, 100}, 100},
+	}
+	for i, test := range tests {
+		if got := medianHeight(test.heights); got != test.want {
+			t.Errorf("medianHeight #%d: got %d, want %d", i, got,
+				test.want)
+		}
+	}
+}
+
+// TestRunHealthCheck ensures the results of checks are reported, that checks
+// which do not complete in time are reported as unavailable, and that the
+// goroutines running checks which timed out exit once the checks complete.
+func TestRunHealthCheck(t *testing.T) {
+	result := runHealthCheck(func() (string, error) {
+		return "open", nil
+	}, time.Second)
+	if result.Status != healthStatusOK || result.Detail != "open" {
+		t.Fatalf("runHealthCheck: unexpected result %+v", result)
+	}
+	result = runHealthCheck(func() (string, error) {
+		return "", errors.New("closed")
+	}, time.Second)
+	if result.Status != healthStatusUnavailable || result.Detail != "closed" {
+		t.Fatalf("runHealthCheck: unexpected result %+v", result)
+	}
+
+	before := runtime.NumGoroutine()
+	release := make(chan struct{})
+	result = runHealthCheck(func() (string, error) {
+		<-release
+		return "late", nil
+	}, time.Millisecond)
+	if result.Status != healthStatusUnavailable {
+		t.Fatalf("runHealthCheck: unexpected result for check which "+
+			"timed out %+v", result)
+	}
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatal("runHealthCheck: goroutine of check which timed " +
+				"out did not exit")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrjson"
+)
+
+// maxFinishedRPCJobs is the maximum number of finished jobs which are retained
+// so their results can still be queried.  The oldest finished jobs are
+// discarded once more jobs finish.
+const maxFinishedRPCJobs = 100
+
+var (
+	// errRPCJobNotFound is returned by the job manager when there is no
+	// job with the requested id, either because it never existed or
+	// because it finished long enough ago to be discarded.
+	errRPCJobNotFound = errors.New("job not found")
+
+	// errRPCJobNotCancellable is returned by the job manager when the work
+	// of the job to cancel can not be interrupted.
+	errRPCJobNotCancellable = errors.New("job can not be cancelled")
+
+	// errRPCJobFinished is returned by the job manager when the job to
+	// cancel already finished.
+	errRPCJobFinished = errors.New("job already finished")
+
+	// errRPCJobManagerStopped is returned by the job manager when a job is
+	// started after the manager was stopped.
+	errRPCJobManagerStopped = errors.New("the server is shutting down")
+)
+
+// rpcJobFunc performs the work of a job and returns the result the command
+// which started it would return if it had not been run as a job.  The progress
+// of the work may be reported with setProgress.
+type rpcJobFunc func(job *rpcJob) (interface{}, error)
+
+// rpcJob describes a long-running operation started by an RPC command which is
+// performed in the background while its progress is queried with the getjob
+// command.
+type rpcJob struct {
+	id        uint64
+	method    string
+	startTime time.Time
+
+	// interrupt is closed to request the work of the job to stop early.  It
+	// is nil when the work can not be interrupted.
+	interrupt chan struct{}
+
+	mtx       sync.Mutex
+	state     string
+	cancelled bool
+	progress  int64
+	total     int64
+	endTime   time.Time
+	result    interface{}
+	err       error
+}
+
+// setProgress updates the amount of the work of the job which is done out of
+// the total amount of work.
+//
+// This function is safe for concurrent access.
+func (j *rpcJob) setProgress(progress, total int64) {
+	j.mtx.Lock()
+	j.progress = progress
+	j.total = total
+	j.mtx.Unlock()
+}
+
+// Info returns the details of the job.
+//
+// This function is safe for concurrent access.
+func (j *rpcJob) Info() *dcrjson.JobResult {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	info := &dcrjson.JobResult{
+		JobID:       j.id,
+		Method:      j.method,
+		State:       j.state,
+		Cancellable: j.interrupt != nil,
+		Progress:    j.progress,
+		Total:       j.total,
+		StartTime:   j.startTime.Unix(),
+		Result:      j.result,
+	}
+	if !j.endTime.IsZero() {
+		info.EndTime = j.endTime.Unix()
+	}
+	switch err := j.err.(type) {
+	case nil:
+	case *dcrjson.RPCError:
+		info.Error = err.Message
+	default:
+		info.Error = err.Error()
+	}
+	return info
+}
+
+// rpcJobManager runs the jobs started by long-running RPC commands in the
+// background and keeps track of them so their progress can be queried and
+// they can be cancelled.  Commands which used to block until their work was
+// done, possibly for longer than clients are willing to wait, instead return
+// the id of a job immediately.
+type rpcJobManager struct {
+	// notify is invoked with the details of every job once it finished.
+	notify func(info *dcrjson.JobResult)
+
+	mtx      sync.Mutex
+	nextID   uint64
+	jobs     map[uint64]*rpcJob
+	finished []uint64
+	stopped  bool
+	wg       sync.WaitGroup
+}
+
+// newRPCJobManager returns a new job manager which invokes the passed function
+// with the details of every job once it finished.
+func newRPCJobManager(notify func(info *dcrjson.JobResult)) *rpcJobManager {
+	return &rpcJobManager{
+		notify: notify,
+		nextID: 1,
+		jobs:   make(map[uint64]*rpcJob),
+	}
+}
+
+// Start starts a job for the passed method which performs its work by invoking
+// the passed function in the background.  The job can be cancelled when the
+// passed interrupt channel is not nil, in which case the manager closes it to
+// request the work to stop and the function must return promptly with an
+// error afterwards.
+//
+// This function is safe for concurrent access.
+func (m *rpcJobManager) Start(method string, interrupt chan struct{}, run rpcJobFunc) (*rpcJob, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.stopped {
+		return nil, errRPCJobManagerStopped
+	}
+	job := &rpcJob{
+		id:        m.nextID,
+		method:    method,
+		startTime: time.Now(),
+		interrupt: interrupt,
+		state:     dcrjson.JobStateRunning,
+	}
+	m.nextID++
+	m.jobs[job.id] = job
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		result, err := run(job)
+		m.finish(job, result, err)
+	}()
+
+	rpcsLog.Debugf("Started job %d for %s", job.id, method)
+	return job, nil
+}
+
+// finish records the outcome of the passed job, discards the oldest finished
+// jobs beyond the maximum number retained, and notifies about the job.
+//
+// This function is safe for concurrent access.
+func (m *rpcJobManager) finish(job *rpcJob, result interface{}, err error) {
+	job.mtx.Lock()
+	job.endTime = time.Now()
+	switch {
+	case err == nil:
+		job.state = dcrjson.JobStateCompleted
+		job.result = result
+	case job.cancelled:
+		job.state = dcrjson.JobStateCancelled
+		job.err = err
+	default:
+		job.state = dcrjson.JobStateFailed
+		job.err = err
+	}
+	state := job.state
+	job.mtx.Unlock()
+
+	m.mtx.Lock()
+	m.finished = append(m.finished, job.id)
+	for len(m.finished) > maxFinishedRPCJobs {
+		delete(m.jobs, m.finished[0])
+		m.finished = m.finished[1:]
+	}
+	m.mtx.Unlock()
+
+	rpcsLog.Debugf("Job %d for %s %s", job.id, job.method, state)
+	if m.notify != nil {
+		m.notify(job.Info())
+	}
+}
+
+// Lookup returns the job with the passed id, if it is still known.
+//
+// This function is safe for concurrent access.
+func (m *rpcJobManager) Lookup(id uint64) (*rpcJob, bool) {
+	m.mtx.Lock()
+	job, ok := m.jobs[id]
+	m.mtx.Unlock()
+	return job, ok
+}
+
+// jobsByID provides sorting of jobs by their id.
+type jobsByID []*rpcJob
+
+func (s jobsByID) Len() int           { return len(s) }
+func (s jobsByID) Less(i, j int) bool { return s[i].id < s[j].id }
+func (s jobsByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Jobs returns the running jobs and the retained finished jobs ordered by their
+// id, which is the order they were started in.
+//
+// This function is safe for concurrent access.
+func (m *rpcJobManager) Jobs() []*rpcJob {
+	m.mtx.Lock()
+	jobs := make([]*rpcJob, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	m.mtx.Unlock()
+
+	sort.Sort(jobsByID(jobs))
+	return jobs
+}
+
+// cancel requests the work of the passed job to stop when it is cancellable
+// and still running.
+func (m *rpcJobManager) cancel(job *rpcJob) error {
+	if job.interrupt == nil {
+		return errRPCJobNotCancellable
+	}
+
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+
+	if job.state != dcrjson.JobStateRunning {
+		return errRPCJobFinished
+	}
+	if !job.cancelled {
+		job.cancelled = true
+		close(job.interrupt)
+	}
+	return nil
+}
+
+// Cancel requests the work of the job with the passed id to stop.  The job is
+// still reported as running until its work actually stopped.
+//
+// This function is safe for concurrent access.
+func (m *rpcJobManager) Cancel(id uint64) error {
+	job, ok := m.Lookup(id)
+	if !ok {
+		return errRPCJobNotFound
+	}
+	return m.cancel(job)
+}
+
+// Stop prevents new jobs from being started, cancels all cancellable jobs which
+// are still running, and waits for the work of all jobs to stop.
+//
+// This function is safe for concurrent access.
+func (m *rpcJobManager) Stop() {
+	m.mtx.Lock()
+	m.stopped = true
+	jobs := make([]*rpcJob, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	m.mtx.Unlock()
+
+	for _, job := range jobs {
+		m.cancel(job)
+	}
+	m.wg.Wait()
+}
@@ -25,6 +25,9 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"runtime/trace"
 	"sort"
 	"strconv"
 	"strings"
@@ -35,25 +38,29 @@ import (
 	"github.com/btcsuite/websocket"
 
 	"github.com/decred/bitset"
+	"github.com/decred/dcrd/addrmgr"
 	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/blockchain/indexers"
 	"github.com/decred/dcrd/blockchain/stake"
 	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/chaincfg/chainec"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/database"
 	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/fees"
 	"github.com/decred/dcrd/mempool"
 	"github.com/decred/dcrd/mining"
 	"github.com/decred/dcrd/txscript"
 	"github.com/decred/dcrd/wire"
 	"github.com/decred/dcrutil"
+	"github.com/decred/dcrutil/base58"
 )
 
 // API version constants
 const (
-	jsonrpcSemverString = "2.0.0"
+	jsonrpcSemverString = "2.43.0"
 	jsonrpcSemverMajor  = 2
-	jsonrpcSemverMinor  = 0
+	jsonrpcSemverMinor  = 43
 	jsonrpcSemverPatch  = 0
 )
 
@@ -86,6 +93,10 @@ const (
 	// the template pool.
 	getworkExpirationDiff = 3
 
+	// maxProfileDuration is the maximum number of seconds a profile capture
+	// started via the startprofile command is allowed to run.
+	maxProfileDuration = 600
+
 	// gbtNonceRange is two 32-bit big-endian hexadecimal integers which
 	// represent the valid ranges of nonces returned by the getblocktemplate
 	// RPC.
@@ -174,73 +185,121 @@ type commandHandler func(*rpcServer, interface{}, <-chan struct{}) (interface{},
 // a dependency loop.
 var rpcHandlers map[string]commandHandler
 var rpcHandlersBeforeInit = map[string]commandHandler{
-	"addnode":               handleAddNode,
-	"createrawsstx":         handleCreateRawSStx,
-	"createrawssgentx":      handleCreateRawSSGenTx,
-	"createrawssrtx":        handleCreateRawSSRtx,
-	"createrawtransaction":  handleCreateRawTransaction,
-	"debuglevel":            handleDebugLevel,
-	"decoderawtransaction":  handleDecodeRawTransaction,
-	"decodescript":          handleDecodeScript,
-	"estimatefee":           handleEstimateFee,
-	"estimatestakediff":     handleEstimateStakeDiff,
-	"existsaddress":         handleExistsAddress,
-	"existsaddresses":       handleExistsAddresses,
-	"existsexpiredtickets":  handleExistsExpiredTickets,
-	"existsliveticket":      handleExistsLiveTicket,
-	"existslivetickets":     handleExistsLiveTickets,
-	"existsmempooltxs":      handleExistsMempoolTxs,
-	"generate":              handleGenerate,
-	"getaddednodeinfo":      handleGetAddedNodeInfo,
-	"getbestblock":          handleGetBestBlock,
-	"getbestblockhash":      handleGetBestBlockHash,
-	"getblock":              handleGetBlock,
-	"getblockcount":         handleGetBlockCount,
-	"getblockhash":          handleGetBlockHash,
-	"getblockheader":        handleGetBlockHeader,
-	"getblocktemplate":      handleGetBlockTemplate,
-	"getcoinsupply":         handleGetCoinSupply,
-	"getconnectioncount":    handleGetConnectionCount,
-	"getcurrentnet":         handleGetCurrentNet,
-	"getdifficulty":         handleGetDifficulty,
-	"getgenerate":           handleGetGenerate,
-	"gethashespersec":       handleGetHashesPerSec,
-	"getheaders":            handleGetHeaders,
-	"getinfo":               handleGetInfo,
-	"getmempoolinfo":        handleGetMempoolInfo,
-	"getmininginfo":         handleGetMiningInfo,
-	"getnettotals":          handleGetNetTotals,
-	"getnetworkhashps":      handleGetNetworkHashPS,
-	"getpeerinfo":           handleGetPeerInfo,
-	"getrawmempool":         handleGetRawMempool,
-	"getrawtransaction":     handleGetRawTransaction,
-	"getstakedifficulty":    handleGetStakeDifficulty,
-	"getstakeversioninfo":   handleGetStakeVersionInfo,
-	"getstakeversions":      handleGetStakeVersions,
-	"getticketpoolvalue":    handleGetTicketPoolValue,
-	"getvoteinfo":           handleGetVoteInfo,
-	"gettxout":              handleGetTxOut,
-	"getwork":               handleGetWork,
-	"help":                  handleHelp,
-	"livetickets":           handleLiveTickets,
-	"missedtickets":         handleMissedTickets,
-	"node":                  handleNode,
-	"ping":                  handlePing,
-	"searchrawtransactions": handleSearchRawTransactions,
-	"rebroadcastmissed":     handleRebroadcastMissed,
-	"rebroadcastwinners":    handleRebroadcastWinners,
-	"sendrawtransaction":    handleSendRawTransaction,
-	"setgenerate":           handleSetGenerate,
-	"stop":                  handleStop,
-	"submitblock":           handleSubmitBlock,
-	"ticketfeeinfo":         handleTicketFeeInfo,
-	"ticketsforaddress":     handleTicketsForAddress,
-	"ticketvwap":            handleTicketVWAP,
-	"txfeeinfo":             handleTxFeeInfo,
-	"validateaddress":       handleValidateAddress,
-	"verifychain":           handleVerifyChain,
-	"verifymessage":         handleVerifyMessage,
-	"version":               handleVersion,
+	"addnode":                 handleAddNode,
+	"advancechain":            handleAdvanceChain,
+	"canceljob":               handleCancelJob,
+	"comparechainwork":        handleCompareChainWork,
+	"createrawsstx":           handleCreateRawSStx,
+	"createrawssgentx":        handleCreateRawSSGenTx,
+	"createrawssrtx":          handleCreateRawSSRtx,
+	"createrawtransaction":    handleCreateRawTransaction,
+	"creditoutput":            handleCreditOutput,
+	"debuglevel":              handleDebugLevel,
+	"decoderawtransaction":    handleDecodeRawTransaction,
+	"decodescript":            handleDecodeScript,
+	"dropindex":               handleDropIndex,
+	"dumpaddrman":             handleDumpAddrMan,
+	"dumputxoset":             handleDumpUtxoSet,
+	"estimatefee":             handleEstimateFee,
+	"estimatesmartfee":        handleEstimateSmartFee,
+	"estimatestakediff":       handleEstimateStakeDiff,
+	"estimateticketfee":       handleEstimateTicketFee,
+	"estimatetimetoconfirm":   handleEstimateTimeToConfirm,
+	"existsaddress":           handleExistsAddress,
+	"existsaddresses":         handleExistsAddresses,
+	"existsexpiredtickets":    handleExistsExpiredTickets,
+	"existsliveticket":        handleExistsLiveTicket,
+	"existslivetickets":       handleExistsLiveTickets,
+	"existsmempooltxs":        handleExistsMempoolTxs,
+	"forcestakedifficulty":    handleForceStakeDifficulty,
+	"generate":                handleGenerate,
+	"getaddednodeinfo":        handleGetAddedNodeInfo,
+	"getaddressbalance":       handleGetAddressBalance,
+	"getaddressutxos":         handleGetAddressUtxos,
+	"getbestblock":            handleGetBestBlock,
+	"getbestblockhash":        handleGetBestBlockHash,
+	"getblock":                handleGetBlock,
+	"getblockannotations":     handleGetBlockAnnotations,
+	"getblockbymediantime":    handleGetBlockByMedianTime,
+	"getblockchaininfo":       handleGetBlockChainInfo,
+	"getblockcount":           handleGetBlockCount,
+	"getblockhash":            handleGetBlockHash,
+	"getblockhashbytime":      handleGetBlockHashByTime,
+	"getblockheader":          handleGetBlockHeader,
+	"getblocktemplate":        handleGetBlockTemplate,
+	"getcoinsupply":           handleGetCoinSupply,
+	"getcoinsupplybreakdown":  handleGetCoinSupplyBreakdown,
+	"getconnectioncount":      handleGetConnectionCount,
+	"getcurrentnet":           handleGetCurrentNet,
+	"getdifficulty":           handleGetDifficulty,
+	"getfinality":             handleGetFinality,
+	"getgenerate":             handleGetGenerate,
+	"gethashespersec":         handleGetHashesPerSec,
+	"getheaders":              handleGetHeaders,
+	"getinfo":                 handleGetInfo,
+	"getjob":                  handleGetJob,
+	"getmempoolancestors":     handleGetMempoolAncestors,
+	"getmempooldescendants":   handleGetMempoolDescendants,
+	"getmempoolentry":         handleGetMempoolEntry,
+	"getmempoolgraph":         handleGetMempoolGraph,
+	"getmempoolinfo":          handleGetMempoolInfo,
+	"getmininginfo":           handleGetMiningInfo,
+	"getindexinfo":            handleGetIndexInfo,
+	"getmissedticketdetails":  handleGetMissedTicketDetails,
+	"getnettotals":            handleGetNetTotals,
+	"getnetworkhashps":        handleGetNetworkHashPS,
+	"getpeerfilterstats":      handleGetPeerFilterStats,
+	"getpeerinfo":             handleGetPeerInfo,
+	"getrawmempool":           handleGetRawMempool,
+	"getrawtransaction":       handleGetRawTransaction,
+	"getrejectedtransactions": handleGetRejectedTransactions,
+	"getrelayhistory":         handleGetRelayHistory,
+	"getstakedifficulty":      handleGetStakeDifficulty,
+	"getstakeversioninfo":     handleGetStakeVersionInfo,
+	"getstakeversions":        handleGetStakeVersions,
+	"getsyncstatus":           handleGetSyncStatus,
+	"getticketpoolvalue":      handleGetTicketPoolValue,
+	"gettreasurybalance":      handleGetTreasuryBalance,
+	"gettreasuryspends":       handleGetTreasurySpends,
+	"getvoteinfo":             handleGetVoteInfo,
+	"getvotingwalletstats":    handleGetVotingWalletStats,
+	"gettxout":                handleGetTxOut,
+	"gettxcost":               handleGetTxCost,
+	"gettxrelaystatus":        handleGetTxRelayStatus,
+	"gettxspendinginfo":       handleGetTxSpendingInfo,
+	"getvalidationstats":      handleGetValidationStats,
+	"getwindowaggregates":     handleGetWindowAggregates,
+	"getwork":                 handleGetWork,
+	"help":                    handleHelp,
+	"importaddrman":           handleImportAddrMan,
+	"invalidateblock":         handleInvalidateBlock,
+	"listjobs":                handleListJobs,
+	"livetickets":             handleLiveTickets,
+	"loadutxoset":             handleLoadUtxoSet,
+	"missedtickets":           handleMissedTickets,
+	"node":                    handleNode,
+	"ping":                    handlePing,
+	"reconsiderblock":         handleReconsiderBlock,
+	"searchrawtransactions":   handleSearchRawTransactions,
+	"rebroadcastmissed":       handleRebroadcastMissed,
+	"rebroadcastwinners":      handleRebroadcastWinners,
+	"sendrawtransaction":      handleSendRawTransaction,
+	"rebuildindex":            handleRebuildIndex,
+	"setblockannotation":      handleSetBlockAnnotation,
+	"setgenerate":             handleSetGenerate,
+	"startprofile":            handleStartProfile,
+	"stop":                    handleStop,
+	"submitblock":             handleSubmitBlock,
+	"ticketfeeinfo":           handleTicketFeeInfo,
+	"ticketsforaddress":       handleTicketsForAddress,
+	"ticketvwap":              handleTicketVWAP,
+	"txfeeinfo":               handleTxFeeInfo,
+	"validateaddress":         handleValidateAddress,
+	"verifychain":             handleVerifyChain,
+	"verifymessage":           handleVerifyMessage,
+	"verifyindex":             handleVerifyIndex,
+	"version":                 handleVersion,
+	"warpclock":               handleWarpClock,
 }
 
 // list of commands that we recognize, but for which dcrd has no support because
@@ -304,11 +363,9 @@ var rpcAskWallet = map[string]struct{}{
 
 // Commands that are currently unimplemented, but should ultimately be.
 var rpcUnimplemented = map[string]struct{}{
-	"estimatefee":       {},
-	"estimatepriority":  {},
-	"getblockchaininfo": {},
-	"getchaintips":      {},
-	"getnetworkinfo":    {},
+	"estimatepriority": {},
+	"getchaintips":     {},
+	"getnetworkinfo":   {},
 }
 
 // Commands that are available to a limited user
@@ -325,22 +382,42 @@ var rpcLimited = map[string]struct{}{
 	"help": {},
 
 	// HTTP/S-only commands
+	"comparechainwork":      {},
 	"createrawtransaction":  {},
 	"decoderawtransaction":  {},
 	"decodescript":          {},
+	"estimatefee":           {},
+	"estimatesmartfee":      {},
+	"estimateticketfee":     {},
+	"estimatetimetoconfirm": {},
+	"getaddressbalance":     {},
+	"getaddressutxos":       {},
 	"getbestblock":          {},
 	"getbestblockhash":      {},
 	"getblock":              {},
+	"getblockannotations":   {},
+	"getblockbymediantime":  {},
 	"getblockcount":         {},
 	"getblockhash":          {},
+	"getblockhashbytime":    {},
 	"getcurrentnet":         {},
 	"getdifficulty":         {},
+	"getfinality":           {},
+	"getheaders":            {},
 	"getinfo":               {},
+	"getmempoolancestors":   {},
+	"getmempooldescendants": {},
+	"getmempoolentry":       {},
+	"getmempoolgraph":       {},
 	"getnettotals":          {},
 	"getnetworkhashps":      {},
 	"getrawmempool":         {},
 	"getrawtransaction":     {},
+	"gettreasurybalance":    {},
+	"gettreasuryspends":     {},
+	"gettxcost":             {},
 	"gettxout":              {},
+	"gettxspendinginfo":     {},
 	"searchrawtransactions": {},
 	"sendrawtransaction":    {},
 	"submitblock":           {},
@@ -383,6 +460,38 @@ func rpcDecodeHexError(gotHex string) *dcrjson.RPCError {
 			gotHex))
 }
 
+// rpcWrongNetAddrError is a convenience function for returning a nicely
+// formatted RPC error which indicates the provided address is for the wrong
+// network.  The network the address is for is included when it is known.
+func rpcWrongNetAddrError(encodedAddr string) *dcrjson.RPCError {
+	msg := "Invalid address: " + encodedAddr + " is for the wrong network"
+	if decoded := base58.Decode(encodedAddr); len(decoded) >= 2 {
+		var id [2]byte
+		copy(id[:], decoded)
+		err := activeNetParams.CheckAddrID(id)
+		if werr, ok := err.(chaincfg.WrongNetError); ok {
+			msg = fmt.Sprintf("Invalid address: %s is for %s instead "+
+				"of %s", encodedAddr, werr.Net.Name,
+				werr.Expected.Name)
+		}
+	}
+	return dcrjson.NewRPCError(dcrjson.ErrRPCInvalidAddressOrKey, msg)
+}
+
+// checkSimNet returns an RPC error which indicates the passed method is only
+// available on the simulation test network when the server is running on any
+// other network.  It gates the methods which directly manipulate the chain
+// state or the clock in ways other nodes do not accept.
+func (s *rpcServer) checkSimNet(method string) error {
+	if s.server.chainParams.Net != wire.SimNet {
+		return &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: method + " is only available on simnet",
+		}
+	}
+	return nil
+}
+
 // rpcNoTxInfoError is a convenience function for returning a nicely formatted
 // RPC error which indiactes there is no information available for the provided
 // transaction hash.
@@ -406,6 +515,7 @@ type workState struct {
 	lastTxUpdate  time.Time
 	lastGenerated time.Time
 	prevHash      *chainhash.Hash
+	minVotes      uint16
 	msgBlock      *wire.MsgBlock
 	extraNonce    uint64
 }
@@ -420,13 +530,16 @@ func newWorkState() *workState {
 // getblocktemplate.
 type gbtWorkState struct {
 	sync.Mutex
-	lastTxUpdate  time.Time
-	lastGenerated time.Time
-	prevHash      *chainhash.Hash
-	minTimestamp  time.Time
-	template      *BlockTemplate
-	notifyMap     map[chainhash.Hash]map[int64]chan struct{}
-	timeSource    blockchain.MedianTimeSource
+	lastTxUpdate    time.Time
+	lastGenerated   time.Time
+	prevHash        *chainhash.Hash
+	minTimestamp    time.Time
+	numVotes        uint16
+	minVotes        uint16
+	voteWaitExpires time.Time
+	template        *BlockTemplate
+	notifyMap       map[chainhash.Hash]map[int64]chan struct{}
+	timeSource      blockchain.MedianTimeSource
 }
 
 // newGbtWorkState returns a new instance of a gbtWorkState with all internal
@@ -482,6 +595,48 @@ func handleAddNode(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (in
 	return nil, nil
 }
 
+// handleAdvanceChain implements the advancechain command.
+func handleAdvanceChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.AdvanceChainCmd)
+
+	// The chain may only be advanced by the harness on the simulation test
+	// network.
+	if err := s.checkSimNet("advancechain"); err != nil {
+		return nil, err
+	}
+
+	// Respond with an error if there are no addresses to pay the
+	// created blocks to.
+	if len(cfg.miningAddrs) == 0 {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCInternal.Code,
+			Message: "No payment addresses specified " +
+				"via --miningaddr",
+		}
+	}
+	if c.NumBlocks == 0 {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: "Please request a nonzero number of blocks to advance.",
+		}
+	}
+
+	blockHashes, err := s.server.simnetHarness.advance(c.NumBlocks)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCMisc,
+			Message: fmt.Sprintf("failed to advance the chain after "+
+				"%d blocks: %v", len(blockHashes), err),
+		}
+	}
+
+	reply := make([]string, 0, len(blockHashes))
+	for _, hash := range blockHashes {
+		reply = append(reply, hash.String())
+	}
+	return reply, nil
+}
+
 // handleNode handles node commands.
 func handleNode(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*dcrjson.NodeCmd)
@@ -596,6 +751,71 @@ func messageToHex(msg wire.Message) (string, error) {
 	return hex.EncodeToString(buf.Bytes()), nil
 }
 
+// startJob starts a job for the passed method which performs its work with the
+// passed function and returns the details of the job as the reply of the
+// command which started it.  The job can be cancelled when the passed interrupt
+// channel is not nil.  See rpcJobManager.Start for details.
+func startJob(s *rpcServer, method string, interrupt chan struct{}, run rpcJobFunc) (interface{}, error) {
+	job, err := s.jobs.Start(method, interrupt, run)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+	return job.Info(), nil
+}
+
+// handleCancelJob implements the canceljob command.
+func handleCancelJob(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.CancelJobCmd)
+
+	switch err := s.jobs.Cancel(c.JobID); err {
+	case nil:
+	case errRPCJobNotFound:
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("No job with id %d", c.JobID),
+		}
+	default:
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: fmt.Sprintf("Unable to cancel job %d: %v", c.JobID, err),
+		}
+	}
+
+	rpcsLog.Infof("Requested cancellation of job %d", c.JobID)
+	return nil, nil
+}
+
+// handleCompareChainWork implements the comparechainwork command.
+func handleCompareChainWork(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.CompareChainWorkCmd)
+
+	var chainWork [2]*big.Int
+	for i, hashStr := range []string{c.Hash1, c.Hash2} {
+		hash, err := chainhash.NewHashFromStr(hashStr)
+		if err != nil {
+			return nil, rpcDecodeHexError(hashStr)
+		}
+		chainWork[i], err = s.chain.ChainWorkByHash(hash)
+		if err != nil {
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCBlockNotFound,
+				Message: fmt.Sprintf("Block %v not found", hash),
+			}
+		}
+	}
+
+	difference := new(big.Int).Sub(chainWork[0], chainWork[1])
+	return &dcrjson.CompareChainWorkResult{
+		ChainWork1: fmt.Sprintf("%064x", chainWork[0]),
+		ChainWork2: fmt.Sprintf("%064x", chainWork[1]),
+		Difference: fmt.Sprintf("%x", difference),
+		Comparison: chainWork[0].Cmp(chainWork[1]),
+	}, nil
+}
+
 // handleCreateRawTransaction handles createrawtransaction commands.
 func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*dcrjson.CreateRawTransactionCmd)
@@ -668,11 +888,7 @@ func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan
 			}
 		}
 		if !addr.IsForNet(s.server.chainParams) {
-			return nil, dcrjson.RPCError{
-				Code: dcrjson.ErrRPCInvalidAddressOrKey,
-				Message: "Invalid address: " + encodedAddr +
-					" is for the wrong network",
-			}
+			return nil, rpcWrongNetAddrError(encodedAddr)
 		}
 
 		// Create a new script which pays to the provided address.
@@ -795,11 +1011,7 @@ func handleCreateRawSStx(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 			}
 		}
 		if !addr.IsForNet(s.server.chainParams) {
-			return nil, dcrjson.RPCError{
-				Code: dcrjson.ErrRPCInvalidAddressOrKey,
-				Message: "Invalid address: " + encodedAddr +
-					" is for the wrong network",
-			}
+			return nil, rpcWrongNetAddrError(encodedAddr)
 		}
 
 		// Create a new script which pays to the provided address with an
@@ -875,11 +1087,7 @@ func handleCreateRawSStx(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 			}
 		}
 		if !addr.IsForNet(s.server.chainParams) {
-			return nil, dcrjson.RPCError{
-				Code: dcrjson.ErrRPCInvalidAddressOrKey,
-				Message: "Invalid address: " +
-					" is for the wrong network",
-			}
+			return nil, rpcWrongNetAddrError(cout.Addr)
 		}
 
 		// Create an OP_RETURN push containing the pubkeyhash to send rewards to.
@@ -930,12 +1138,7 @@ func handleCreateRawSStx(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 			}
 		}
 		if !addr.IsForNet(s.server.chainParams) {
-			return nil, dcrjson.RPCError{
-				Code: dcrjson.ErrRPCInvalidAddressOrKey,
-				Message: fmt.Sprintf("%s: %q",
-					"Wront network",
-					addr),
-			}
+			return nil, rpcWrongNetAddrError(cout.ChangeAddr)
 		}
 
 		// Create a new script which pays to the provided address with an
@@ -1308,6 +1511,57 @@ func handleCreateRawSSRtx(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	return mtxHex, nil
 }
 
+// handleCreditOutput implements the creditoutput command.
+func handleCreditOutput(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.CreditOutputCmd)
+
+	// Outputs may only be credited on the simulation test network.
+	if err := s.checkSimNet("creditoutput"); err != nil {
+		return nil, err
+	}
+
+	addr, err := dcrutil.DecodeAddress(c.Address, s.server.chainParams)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	if !addr.IsForNet(s.server.chainParams) {
+		return nil, rpcWrongNetAddrError(c.Address)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	amount, err := dcrutil.NewAmount(c.Amount)
+	if err != nil || amount <= 0 {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: "Invalid amount",
+		}
+	}
+
+	txOut := wire.NewTxOut(int64(amount), pkScript)
+	txHash, height, err := s.server.blockManager.chain.CreditOutputs(
+		[]*wire.TxOut{txOut})
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+
+	return &dcrjson.CreditOutputResult{
+		TxID:        txHash.String(),
+		BlockHeight: height,
+		BlockIndex:  wire.NullBlockIndex,
+	}, nil
+}
+
 // handleDebugLevel handles debuglevel commands.
 func handleDebugLevel(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*dcrjson.DebugLevelCmd)
@@ -1584,11 +1838,276 @@ func handleDecodeScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 	return reply, nil
 }
 
-// handleEstimateFee implenents the estimatefee command.
-// TODO this is a very basic implementation.  It should be
-// modified to match the bitcoin-core one.
+// enabledIndexes returns the enabled optional indexes keyed by the name of the
+// option which enables them.
+func enabledIndexes(s *rpcServer) map[string]indexers.Indexer {
+	indexes := make(map[string]indexers.Indexer)
+	if s.server.txIndex != nil {
+		indexes["txindex"] = s.server.txIndex
+	}
+	if s.server.addrIndex != nil {
+		indexes["addrindex"] = s.server.addrIndex
+	}
+	if s.server.existsAddrIndex != nil {
+		indexes["existsaddrindex"] = s.server.existsAddrIndex
+	}
+	if s.server.windowAggIndex != nil {
+		indexes["windowaggindex"] = s.server.windowAggIndex
+	}
+	if s.server.spendIndex != nil {
+		indexes["spendindex"] = s.server.spendIndex
+	}
+	if s.server.balanceIndex != nil {
+		indexes["balanceindex"] = s.server.balanceIndex
+	}
+	return indexes
+}
+
+// maintainIndex starts the passed maintenance operation for the enabled index
+// identified by the passed option name and returns the details of the job for
+// the passed method which tracks it.  The progress of the job is the height the
+// operation reached out of its target height, or the number of deleted keys
+// while the index is dropped.
+func maintainIndex(s *rpcServer, method string, op indexers.IndexOp, index string) (interface{}, error) {
+	indexer, ok := enabledIndexes(s)[index]
+	if !ok || s.server.indexManager == nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Index %q is not enabled", index),
+		}
+	}
+	interrupt := make(chan struct{})
+	done, err := s.server.indexManager.MaintainIndex(op, indexer, interrupt)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+	rpcsLog.Infof("Started %s of the %s", op, indexer.Name())
+
+	updateProgress := func(job *rpcJob) {
+		for _, status := range s.server.indexManager.IndexStatuses() {
+			if status.Name != indexer.Name() {
+				continue
+			}
+			if status.Op == indexers.IndexOpDrop {
+				job.setProgress(int64(status.DeletedKeys), 0)
+			} else {
+				job.setProgress(status.Height, status.TargetHeight)
+			}
+		}
+	}
+	reply, err := startJob(s, method, interrupt, func(job *rpcJob) (interface{}, error) {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case err := <-done:
+				updateProgress(job)
+				return nil, err
+			case <-ticker.C:
+				updateProgress(job)
+			}
+		}
+	})
+	if err != nil {
+		close(interrupt)
+	}
+	return reply, err
+}
+
+// handleDropIndex implements the dropindex command.
+func handleDropIndex(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.DropIndexCmd)
+	return maintainIndex(s, "dropindex", indexers.IndexOpDrop, c.Index)
+}
+
+// handleDumpAddrMan implements the dumpaddrman command.
+func handleDumpAddrMan(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	addrs := s.server.addrManager.ExportAddresses()
+	result := dcrjson.AddrManDump{
+		Version:   addrmgr.PortableVersion,
+		Addresses: make([]dcrjson.AddrManAddress, 0, len(addrs)),
+	}
+	for i := range addrs {
+		pa := &addrs[i]
+		result.Addresses = append(result.Addresses, dcrjson.AddrManAddress{
+			Addr:        pa.Addr,
+			Src:         pa.Src,
+			Services:    uint64(pa.Services),
+			Attempts:    pa.Attempts,
+			TimeStamp:   pa.TimeStamp.Unix(),
+			LastAttempt: pa.LastAttempt.Unix(),
+			LastSuccess: pa.LastSuccess.Unix(),
+			Tried:       pa.Tried,
+		})
+	}
+	return result, nil
+}
+
+// utxoSnapshotDir returns the directory utxo set snapshots are written to and
+// relative snapshot file names are resolved against.
+func utxoSnapshotDir() string {
+	return filepath.Join(cfg.DataDir, "snapshots")
+}
+
+// utxoSnapshotResult returns the result of the dumputxoset and loadutxoset
+// commands for the described snapshot in the passed file.
+func utxoSnapshotResult(file string, info *blockchain.UtxoSnapshotInfo) *dcrjson.UtxoSnapshotResult {
+	return &dcrjson.UtxoSnapshotResult{
+		File:        file,
+		Hash:        info.Hash.String(),
+		Height:      info.Height,
+		NumUtxos:    info.NumOutputs,
+		NumTickets:  info.NumTickets,
+		UtxoSetHash: hex.EncodeToString(info.UtxoSetHash[:]),
+		Checksum:    hex.EncodeToString(info.Checksum[:]),
+	}
+}
+
+// handleDumpUtxoSet implements the dumputxoset command.
+func handleDumpUtxoSet(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.DumpUtxoSetCmd)
+
+	best := s.chain.BestSnapshot()
+	height := best.Height
+	if c.Height != nil {
+		height = *c.Height
+	}
+	if height < 0 || height > best.Height {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCOutOfRange,
+			Message: "Block height out of range",
+		}
+	}
+	if c.Async != nil && *c.Async {
+		return startJob(s, "dumputxoset", nil, func(*rpcJob) (interface{}, error) {
+			return dumpUtxoSet(s, height)
+		})
+	}
+	return dumpUtxoSet(s, height)
+}
+
+// dumpUtxoSet writes a snapshot of the utxo set which results from the main
+// chain block at the passed height to the snapshot directory and returns the
+// result of the dumputxoset command for it.
+func dumpUtxoSet(s *rpcServer, height int64) (*dcrjson.UtxoSnapshotResult, error) {
+	// The snapshot is written to a temporary file which is renamed once it
+	// is complete so a partially written snapshot is never mistaken for a
+	// valid one.
+	snapshotDir := utxoSnapshotDir()
+	if err := os.MkdirAll(snapshotDir, 0700); err != nil {
+		context := "Failed to create snapshot directory"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	f, err := ioutil.TempFile(snapshotDir, "utxoset-")
+	if err != nil {
+		context := "Failed to create snapshot file"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	info, err := s.chain.DumpUtxoSnapshot(f, height)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		context := "Failed to write snapshot"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	fileName := filepath.Join(snapshotDir, fmt.Sprintf("utxoset-%d-%v.dat",
+		info.Height, info.Hash))
+	if err := os.Rename(f.Name(), fileName); err != nil {
+		os.Remove(f.Name())
+		context := "Failed to rename snapshot file"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	rpcsLog.Infof("Wrote utxo set snapshot of block %v (height %d) with %d "+
+		"outputs to %s", info.Hash, info.Height, info.NumOutputs, fileName)
+	return utxoSnapshotResult(fileName, info), nil
+}
+
+// minFeeRate returns the minimum fee rate in atoms/kB regular transactions must
+// currently pay to be relayed and accepted to the memory pool.  Fee estimates
+// are never lower than it since transactions paying a lower fee rate would be
+// rejected regardless of how long they would take to be confirmed.
+func (s *rpcServer) minFeeRate() dcrutil.Amount {
+	minFeeRate := s.server.txMemPool.MinPoolFeeRate()
+	if minFeeRate < cfg.minRelayTxFee {
+		minFeeRate = cfg.minRelayTxFee
+	}
+	return minFeeRate
+}
+
+// handleEstimateFee implenents the estimatefee command.  It returns the fee
+// rate estimated for regular transactions to be confirmed within the requested
+// number of blocks, which is limited to the range the fee estimator provides
+// estimates for, or the minimum fee rate when there is no estimate or the
+// estimate is lower.
 func handleEstimateFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	return 0.01, nil
+	c := cmd.(*dcrjson.EstimateFeeCmd)
+
+	estimator := s.server.feeEstimator
+	numBlocks := c.NumBlocks
+	if numBlocks < 1 {
+		numBlocks = 1
+	}
+	if max := int64(estimator.MaxConfirms()); numBlocks > max {
+		numBlocks = max
+	}
+	minFeeRate := s.minFeeRate()
+	feeRate, err := estimator.EstimateFee(int(numBlocks))
+	if err != nil || dcrutil.Amount(feeRate) < minFeeRate {
+		return minFeeRate.ToCoin(), nil
+	}
+	return dcrutil.Amount(feeRate).ToCoin(), nil
+}
+
+// estimateSmartFee returns the fee rate estimated by the passed estimator for
+// transactions to be confirmed within the passed number of blocks.  When there
+// is not enough data for the requested number of blocks, the estimate for the
+// lowest higher number of blocks which has enough data is returned instead.
+// Estimates lower than the passed minimum fee rate are raised to it.
+func estimateSmartFee(estimator *fees.Estimator, confirmations int64, minFeeRate dcrutil.Amount) (*dcrjson.EstimateSmartFeeResult, error) {
+	maxConfirms := int64(estimator.MaxConfirms())
+	if confirmations < 1 || confirmations > maxConfirms {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Number of confirmations must be "+
+				"between 1 and %d", maxConfirms),
+		}
+	}
+
+	for target := confirmations; target <= maxConfirms; target++ {
+		feeRate, err := estimator.EstimateFee(int(target))
+		if err == fees.ErrNoEstimate {
+			continue
+		}
+		if err != nil {
+			context := "Failed to estimate fee rate"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if dcrutil.Amount(feeRate) < minFeeRate {
+			feeRate = int64(minFeeRate)
+		}
+		return &dcrjson.EstimateSmartFeeResult{
+			FeeRate: dcrutil.Amount(feeRate).ToCoin(),
+			Blocks:  target,
+		}, nil
+	}
+	return nil, &dcrjson.RPCError{
+		Code: dcrjson.ErrRPCMisc,
+		Message: fmt.Sprintf("Insufficient data to estimate the fee rate "+
+			"for confirmation within %d blocks", confirmations),
+	}
+}
+
+// handleEstimateSmartFee implements the estimatesmartfee command.
+func handleEstimateSmartFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.EstimateSmartFeeCmd)
+	return estimateSmartFee(s.server.feeEstimator, c.Confirmations,
+		s.minFeeRate())
 }
 
 // handleEstimateStakeDiff implements the estimatestakediff command.
@@ -1670,6 +2189,99 @@ func handleEstimateStakeDiff(s *rpcServer, cmd interface{}, closeChan <-chan str
 	}, nil
 }
 
+// handleEstimateTicketFee implements the estimateticketfee command.
+func handleEstimateTicketFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.EstimateTicketFeeCmd)
+	return estimateSmartFee(s.server.ticketFeeEstimator, c.Confirmations,
+		cfg.minRelayTxFee)
+}
+
+// handleEstimateTimeToConfirm implements the estimatetimetoconfirm command.
+func handleEstimateTimeToConfirm(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.EstimateTimeToConfirmCmd)
+
+	feeRate, err := dcrutil.NewAmount(c.FeeRate)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Invalid fee rate: %v", err),
+		}
+	}
+	if feeRate < cfg.minRelayTxFee {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Fee rate %v is below the minimum "+
+				"relay fee rate %v", feeRate, cfg.minRelayTxFee),
+		}
+	}
+	if c.Size == 0 {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: "Transaction size must be positive",
+		}
+	}
+	numBlocks := defaultConfirmEstBlocks
+	if c.NumBlocks != nil {
+		numBlocks = int(*c.NumBlocks)
+	}
+	if numBlocks < 1 || numBlocks > maxConfirmEstBlocks {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Number of blocks must be between 1 "+
+				"and %d", maxConfirmEstBlocks),
+		}
+	}
+
+	// Determine the space regular transactions used and had available in
+	// recent blocks relative to the maximum size of the block templates
+	// created by this node.
+	best := s.chain.BestSnapshot()
+	maxBlockSize := int64(cfg.BlockMaxSize)
+	samples := make([]blockSpaceSample, 0, confirmEstWindow)
+	var totalFillRate float64
+	for height := best.Height; height > 0 &&
+		height > best.Height-confirmEstWindow; height-- {
+
+		block, err := s.chain.BlockByHeight(height)
+		if err != nil {
+			context := "Failed to fetch block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		var regularSize int64
+		for _, tx := range block.Transactions()[1:] {
+			regularSize += int64(tx.MsgTx().SerializeSize())
+		}
+		blockSize := int64(block.MsgBlock().SerializeSize())
+		sample := newBlockSpaceSample(blockSize, regularSize, maxBlockSize)
+		samples = append(samples, sample)
+		totalFillRate += sample.fillRate
+	}
+
+	bytesAhead := mempoolBytesAhead(s.server.txMemPool.TxDescs(), feeRate) +
+		int64(c.Size)
+	probabilities := confirmProbabilities(bytesAhead, samples, numBlocks)
+	result := &dcrjson.EstimateTimeToConfirmResult{
+		BytesAhead:    bytesAhead,
+		Probabilities: make([]dcrjson.ConfirmProbability, 0, numBlocks),
+	}
+	if len(samples) > 0 {
+		var totalCapacity float64
+		for _, sample := range samples {
+			totalCapacity += sample.capacity
+		}
+		result.BlockCapacity = totalCapacity / float64(len(samples))
+		result.FillRate = totalFillRate / float64(len(samples))
+	}
+	for i, probability := range probabilities {
+		result.Probabilities = append(result.Probabilities,
+			dcrjson.ConfirmProbability{
+				Blocks:      uint32(i + 1),
+				Probability: probability,
+			})
+	}
+	return result, nil
+}
+
 // handleExistsAddress implements the existsaddress command.
 func handleExistsAddress(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	existsAddrIndex := s.server.existsAddrIndex
@@ -1872,8 +2484,53 @@ func handleExistsMempoolTxs(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return hex.EncodeToString([]byte(set)), nil
 }
 
-// handleGenerate handles generate commands.
-func handleGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+// handleForceStakeDifficulty implements the forcestakedifficulty command.
+func handleForceStakeDifficulty(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.ForceStakeDifficultyCmd)
+
+	// The stake difficulty may only be forced on the simulation test
+	// network.
+	if err := s.checkSimNet("forcestakedifficulty"); err != nil {
+		return nil, err
+	}
+
+	sbits, err := dcrutil.NewAmount(c.StakeDifficulty)
+	if err != nil || sbits < 0 {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: "Invalid stake difficulty",
+		}
+	}
+
+	bm := s.server.blockManager
+	nextStakeDiff, err := bm.chain.ForceStakeDifficulty(int64(sbits))
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+
+	// Update the cached stake difficulty used to accept tickets into the
+	// memory pool and remove the tickets which no longer meet it so they
+	// are not included in block templates.
+	bm.chainState.Lock()
+	bm.chainState.nextStakeDifficulty = nextStakeDiff
+	bm.chainState.Unlock()
+	_, bestHeight := bm.chainState.Best()
+	s.server.txMemPool.PruneStakeTx(nextStakeDiff, bestHeight)
+
+	if sbits == 0 {
+		rpcsLog.Infof("Removed the forced stake difficulty, next stake "+
+			"difficulty is %v", dcrutil.Amount(nextStakeDiff))
+	} else {
+		rpcsLog.Infof("Forced the stake difficulty to %v", sbits)
+	}
+	return nil, nil
+}
+
+// handleGenerate handles generate commands.
+func handleGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// Respond with an error if there are no addresses to pay the
 	// created blocks to.
 	if len(cfg.miningAddrs) == 0 {
@@ -1997,6 +2654,90 @@ func handleGetAddedNodeInfo(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return results, nil
 }
 
+// balanceIndexScript returns the balance index along with the public key
+// script which pays the passed encoded address.  The balance index is keyed by
+// script, so only outputs which pay the address with its standard script are
+// included in the results of the queries.
+func balanceIndexScript(s *rpcServer, address string) (*indexers.BalanceIndex, []byte, error) {
+	balanceIndex := s.server.balanceIndex
+	if balanceIndex == nil {
+		return nil, nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCNoTxInfo,
+			Message: "The balance index must be enabled to query " +
+				"the balance of an address (specify " +
+				"--balanceindex)",
+		}
+	}
+
+	addr, err := dcrutil.DecodeAddress(address, s.server.chainParams)
+	if err != nil {
+		return nil, nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+
+	return balanceIndex, pkScript, nil
+}
+
+// handleGetAddressBalance implements the getaddressbalance command.
+func handleGetAddressBalance(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetAddressBalanceCmd)
+	balanceIndex, pkScript, err := balanceIndexScript(s, c.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, numUtxos, err := balanceIndex.Balance(pkScript)
+	if err != nil {
+		context := "Failed to fetch address balance"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &dcrjson.AddressBalanceResult{
+		Balance:  dcrutil.Amount(balance).ToCoin(),
+		NumUtxos: numUtxos,
+	}, nil
+}
+
+// handleGetAddressUtxos implements the getaddressutxos command.
+func handleGetAddressUtxos(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetAddressUtxosCmd)
+	balanceIndex, pkScript, err := balanceIndexScript(s, c.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := balanceIndex.Utxos(pkScript)
+	if err != nil {
+		context := "Failed to fetch address utxos"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	best := s.chain.BestSnapshot()
+	scriptPubKey := hex.EncodeToString(pkScript)
+	results := make([]dcrjson.AddressUtxoResult, 0, len(utxos))
+	for _, utxo := range utxos {
+		results = append(results, dcrjson.AddressUtxoResult{
+			TxID:          utxo.OutPoint.Hash.String(),
+			Vout:          utxo.OutPoint.Index,
+			Tree:          utxo.OutPoint.Tree,
+			Amount:        dcrutil.Amount(utxo.Amount).ToCoin(),
+			ScriptPubKey:  scriptPubKey,
+			Height:        utxo.Height,
+			Confirmations: 1 + best.Height - utxo.Height,
+		})
+	}
+	return results, nil
+}
+
 // handleGetBestBlock implements the getbestblock command.
 func handleGetBestBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// All other "get block" commands give either the height, the
@@ -2068,10 +2809,28 @@ func handleGetBlock(s *rpcServer, cmd interface{},
 		return hex.EncodeToString(blkBytes), nil
 	}
 
-	best := s.chain.BestSnapshot()
+	// Use a view of the chain pinned to the current tip so the
+	// confirmations and next block hash are consistent with each other even
+	// when a reorganization happens while the reply is being built.
+	view := s.chain.NewChainView()
+	best := view.Tip()
+
+	// Serve the reply from the cache of recent blocks when it was already
+	// built against the current tip.
+	cacheKey := blockJSONCacheKey{
+		hash:      *hash,
+		verboseTx: c.VerboseTx != nil && *c.VerboseTx,
+	}
+	if reply, ok := s.blockJSONCache.Lookup(best.Hash, cacheKey); ok {
+		return reply, nil
+	}
 
 	// See if this block is an orphan and adjust Confirmations accordingly.
-	onMainChain, _ := s.chain.MainChainHasBlock(hash)
+	onMainChain, err := view.MainChainHasBlock(hash)
+	if err != nil {
+		context := "Failed to check if block is in the main chain"
+		return nil, internalRPCError(err.Error(), context)
+	}
 
 	// Get next block hash unless there are none.
 	var nextHashString string
@@ -2079,7 +2838,7 @@ func handleGetBlock(s *rpcServer, cmd interface{},
 	confirmations := int64(-1)
 	if onMainChain {
 		if int64(blockHeader.Height) < best.Height {
-			nextHash, err := s.chain.BlockHashByHeight(int64(blockHeader.Height + 1))
+			nextHash, err := view.BlockHashByHeight(int64(blockHeader.Height + 1))
 			if err != nil {
 				context := "No next block"
 				return nil, internalRPCError(err.Error(),
@@ -2090,6 +2849,17 @@ func handleGetBlock(s *rpcServer, cmd interface{},
 		confirmations = 1 + best.Height - int64(blockHeader.Height)
 	}
 
+	medianTime, err := s.chain.PastMedianTimeByHash(hash)
+	if err != nil {
+		context := "Failed to calculate median time"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	chainWork, err := s.chain.ChainWorkByHash(hash)
+	if err != nil {
+		context := "Failed to calculate chain work"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
 	sbitsFloat := float64(blockHeader.SBits) / dcrutil.AtomsPerCoin
 	blockReply := dcrjson.GetBlockVerboseResult{
 		Hash:          c.Hash,
@@ -2105,6 +2875,7 @@ func handleGetBlock(s *rpcServer, cmd interface{},
 		Revocations:   blockHeader.Revocations,
 		PoolSize:      blockHeader.PoolSize,
 		Time:          blockHeader.Timestamp.Unix(),
+		MedianTime:    medianTime.Unix(),
 		StakeVersion:  blockHeader.StakeVersion,
 		Confirmations: confirmations,
 		Height:        int64(blockHeader.Height),
@@ -2112,6 +2883,7 @@ func handleGetBlock(s *rpcServer, cmd interface{},
 		Bits:          strconv.FormatInt(int64(blockHeader.Bits), 16),
 		SBits:         sbitsFloat,
 		Difficulty:    getDifficultyRatio(blockHeader.Bits),
+		ChainWork:     fmt.Sprintf("%064x", chainWork),
 		ExtraData:     hex.EncodeToString(blockHeader.ExtraData[:]),
 		NextHash:      nextHashString,
 	}
@@ -2162,7 +2934,88 @@ func handleGetBlock(s *rpcServer, cmd interface{},
 		blockReply.RawSTx = rawSTxns
 	}
 
-	return blockReply, nil
+	// Cache the serialized reply of blocks in the main chain since the
+	// most recent ones are requested repeatedly.
+	if !onMainChain {
+		return blockReply, nil
+	}
+	reply, err := json.Marshal(&blockReply)
+	if err != nil {
+		context := "Failed to marshal block"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	s.blockJSONCache.Add(best.Hash, best.Height, cacheKey,
+		int64(blockHeader.Height), reply)
+	return json.RawMessage(reply), nil
+}
+
+// scriptFlagNames houses the names of the script flags reported by the RPC
+// server in the order they are reported.
+var scriptFlagNames = []struct {
+	flag txscript.ScriptFlags
+	name string
+}{
+	{txscript.ScriptBip16, "bip16"},
+	{txscript.ScriptStrictMultiSig, "strictmultisig"},
+	{txscript.ScriptDiscourageUpgradableNops, "discourageupgradablenops"},
+	{txscript.ScriptVerifyCheckLockTimeVerify, "checklocktimeverify"},
+	{txscript.ScriptVerifyCleanStack, "cleanstack"},
+	{txscript.ScriptVerifyDERSignatures, "dersignatures"},
+	{txscript.ScriptVerifyLowS, "lows"},
+	{txscript.ScriptVerifyMinimalData, "minimaldata"},
+	{txscript.ScriptVerifySigPushOnly, "sigpushonly"},
+	{txscript.ScriptVerifyStrictEncoding, "strictencoding"},
+	{txscript.ScriptVerifyTreasury, "treasury"},
+}
+
+// scriptFlagsToStrings returns the names of the passed script flags.
+func scriptFlagsToStrings(flags txscript.ScriptFlags) []string {
+	names := make([]string, 0, len(scriptFlagNames))
+	for _, flagName := range scriptFlagNames {
+		if flags&flagName.flag == flagName.flag {
+			names = append(names, flagName.name)
+		}
+	}
+	return names
+}
+
+// handleGetBlockChainInfo implements the getblockchaininfo command.
+func handleGetBlockChainInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.chain.BestSnapshot()
+	bestHeader := s.chain.BestBlockHeader()
+
+	consensusFlags, err := s.chain.ConsensusScriptFlags()
+	if err != nil {
+		context := "Failed to obtain consensus script flags"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	standardFlags, err := s.chain.StandardScriptFlags()
+	if err != nil {
+		context := "Failed to obtain standard script flags"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// Estimate the verification progress from the time elapsed between the
+	// genesis block and the best block relative to the current time.
+	progress := 1.0
+	genesisTime := activeNetParams.GenesisBlock.Header.Timestamp.Unix()
+	elapsed := time.Now().Unix() - genesisTime
+	if elapsed > 0 {
+		bestElapsed := bestHeader.Timestamp.Unix() - genesisTime
+		progress = math.Min(float64(bestElapsed)/float64(elapsed), 1)
+	}
+
+	return dcrjson.GetBlockChainInfoResult{
+		Chain:                activeNetParams.Name,
+		Blocks:               int32(best.Height),
+		Headers:              int32(best.Height),
+		BestBlockHash:        best.Hash.String(),
+		Difficulty:           getDifficultyRatio(best.Bits),
+		VerificationProgress: progress,
+		ChainWork:            fmt.Sprintf("%064x", best.WorkSum),
+		ConsensusScriptFlags: scriptFlagsToStrings(consensusFlags),
+		StandardScriptFlags:  scriptFlagsToStrings(standardFlags),
+	}, nil
 }
 
 // handleGetBlockCount implements the getblockcount command.
@@ -2185,6 +3038,87 @@ func handleGetBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 	return hash.String(), nil
 }
 
+// handleGetBlockAnnotations implements the getblockannotations command.
+func handleGetBlockAnnotations(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetBlockAnnotationsCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	annotations, err := s.chain.BlockAnnotations(hash)
+	if err != nil {
+		return nil, blockAnnotationRPCError(err)
+	}
+
+	result := make([]dcrjson.BlockAnnotationResult, 0, len(annotations))
+	for _, annotation := range annotations {
+		result = append(result, dcrjson.BlockAnnotationResult{
+			Key:   annotation.Key,
+			Value: annotation.Value,
+		})
+	}
+	return result, nil
+}
+
+// blockHashByMedianTime returns the hash and height of the most recent block
+// in the main chain of the passed view with a median time at or before the
+// passed unix time.  The error is suitable for returning to RPC clients.
+func blockHashByMedianTime(view *blockchain.ChainView, t int64) (*chainhash.Hash, int64, error) {
+	hash, height, err := view.BlockHashByMedianTime(time.Unix(t, 0))
+	if err != nil {
+		if _, ok := err.(blockchain.StaleChainViewError); ok {
+			context := "Chain reorganized during the search"
+			return nil, 0, internalRPCError(err.Error(), context)
+		}
+		return nil, 0, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCOutOfRange,
+			Message: err.Error(),
+		}
+	}
+	return hash, height, nil
+}
+
+// handleGetBlockByMedianTime implements the getblockbymediantime command.
+func handleGetBlockByMedianTime(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetBlockByMedianTimeCmd)
+
+	// Use a view of the chain pinned to the current tip so the search is
+	// not affected by a reorganization which happens part way through it.
+	view := s.chain.NewChainView()
+	hash, height, err := blockHashByMedianTime(view, c.Time)
+	if err != nil {
+		return nil, err
+	}
+	header, err := view.HeaderByHeight(height)
+	if err != nil {
+		context := "Failed to fetch block header"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	medianTime, err := view.PastMedianTimeByHeight(height)
+	if err != nil {
+		context := "Failed to calculate median time"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &dcrjson.GetBlockByMedianTimeResult{
+		Hash:       hash.String(),
+		Height:     height,
+		Time:       header.Timestamp.Unix(),
+		MedianTime: medianTime.Unix(),
+	}, nil
+}
+
+// handleGetBlockHashByTime implements the getblockhashbytime command.
+func handleGetBlockHashByTime(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetBlockHashByTimeCmd)
+	hash, _, err := blockHashByMedianTime(s.chain.NewChainView(), c.Time)
+	if err != nil {
+		return nil, err
+	}
+	return hash.String(), nil
+}
+
 // handleGetBlockHeader implements the getblockheader command.
 func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*dcrjson.GetBlockHeaderCmd)
@@ -2226,7 +3160,11 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	best := s.chain.BestSnapshot()
 
 	// See if this block is an orphan and adjust Confirmations accordingly.
-	onMainChain, _ := s.chain.MainChainHasBlock(hash)
+	onMainChain, err := s.chain.MainChainHasBlock(hash)
+	if err != nil {
+		context := "Failed to check if block is in the main chain"
+		return nil, internalRPCError(err.Error(), context)
+	}
 
 	// Get next block hash unless there are none.
 	var nextHashString string
@@ -2245,6 +3183,17 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 		confirmations = 1 + best.Height - height
 	}
 
+	medianTime, err := s.chain.PastMedianTimeByHash(hash)
+	if err != nil {
+		context := "Failed to calculate median time"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	chainWork, err := s.chain.ChainWorkByHash(hash)
+	if err != nil {
+		context := "Failed to calculate chain work"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
 	blockHeaderReply := dcrjson.GetBlockHeaderVerboseResult{
 		Hash:          c.Hash,
 		Confirmations: confirmations,
@@ -2263,9 +3212,11 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 		Height:        uint32(height),
 		Size:          blockHeader.Size,
 		Time:          blockHeader.Timestamp.Unix(),
+		MedianTime:    medianTime.Unix(),
 		Nonce:         blockHeader.Nonce,
 		StakeVersion:  blockHeader.StakeVersion,
 		Difficulty:    getDifficultyRatio(blockHeader.Bits),
+		ChainWork:     fmt.Sprintf("%064x", chainWork),
 		NextHash:      nextHashString,
 	}
 
@@ -2385,6 +3336,25 @@ func (state *gbtWorkState) NotifyMempoolTx(lastUpdated time.Time) {
 	}()
 }
 
+// NotifyVote notifies any long poll clients with a new block template when
+// their existing block template is stale due to a new vote on the block it
+// builds on being available.
+func (state *gbtWorkState) NotifyVote(blockHash *chainhash.Hash) {
+	go func() {
+		state.Lock()
+		defer state.Unlock()
+
+		channels, ok := state.notifyMap[*blockHash]
+		if !ok {
+			return
+		}
+		for _, c := range channels {
+			close(c)
+		}
+		delete(state.notifyMap, *blockHash)
+	}()
+}
+
 // templateUpdateChan returns a channel that will be closed once the block
 // template associated with the passed previous hash and last generated time
 // is stale.  The function will return existing channels for duplicate
@@ -2413,6 +3383,63 @@ func (state *gbtWorkState) templateUpdateChan(prevHash *chainhash.Hash, lastGene
 	return c
 }
 
+// notifyWorkExpired notifies websocket clients registered for work
+// notifications that work which does not build on the passed block with all of
+// the votes currently available for it is stale for the passed reason.
+func (s *rpcServer) notifyWorkExpired(reason string, blockHash *chainhash.Hash, height int64) {
+	voteHashes := s.server.txMemPool.VoteHashesForBlock(*blockHash)
+	s.ntfnMgr.NotifyWorkExpired(&WorkExpiredNtfnData{
+		Reason:      reason,
+		BlockHash:   *blockHash,
+		BlockHeight: height,
+		Voters:      uint16(len(voteHashes)),
+	})
+}
+
+// NotifyNewTip notifies getblocktemplate long poll clients and websocket
+// clients registered for work notifications that previously handed out work is
+// stale due to the passed block becoming the tip of the best chain.
+func (s *rpcServer) NotifyNewTip(blockHash *chainhash.Hash, height int64) {
+	s.gbtWorkState.NotifyBlockConnected(blockHash)
+	s.notifyWorkExpired(dcrjson.WorkExpiredNewTip, blockHash, height)
+}
+
+// NotifyNewVote notifies getblocktemplate long poll clients and websocket
+// clients registered for work notifications that previously handed out work is
+// stale when the passed vote, which must have already been checked to be a
+// vote, is on the tip of the best chain since work which includes it, and
+// therefore pays a higher reward, is now available.
+func (s *rpcServer) NotifyNewVote(vote *dcrutil.Tx) {
+	votedHash, _, err := stake.SSGenBlockVotedOn(vote.MsgTx())
+	if err != nil {
+		return
+	}
+	best, height := s.server.blockManager.chainState.Best()
+	if best == nil || *best != votedHash {
+		return
+	}
+
+	s.gbtWorkState.NotifyVote(best)
+	s.notifyWorkExpired(dcrjson.WorkExpiredVotes, best, height)
+}
+
+// staleWorkReason returns the BIP0022 rejection reason for a submitted block
+// with the passed header when it no longer builds on a suitable block, or an
+// empty string otherwise.  Blocks are expected to build on either the tip of
+// the best chain or, when mining off of the parent of the tip due to too few
+// votes on the tip, the parent of the tip.
+func staleWorkReason(bm *blockManager, header *wire.BlockHeader) string {
+	best, _ := bm.chainState.Best()
+	if best == nil || header.PrevBlock == *best {
+		return ""
+	}
+	tipHeader := bm.chainState.GetTopBlockHeader()
+	if header.PrevBlock == tipHeader.PrevBlock {
+		return ""
+	}
+	return "stale-prevblk"
+}
+
 // updateBlockTemplate creates or updates a block template for the work state.
 // A new block template will be generated when the current best block has
 // changed or the transactions in the memory pool have been updated and it has
@@ -2425,22 +3452,30 @@ func (state *gbtWorkState) templateUpdateChan(prevHash *chainhash.Hash, lastGene
 // addresses.
 //
 // This function MUST be called with the state locked.
-func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bool) error {
+func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bool, requestedVotes uint16) error {
 	lastTxUpdate := s.server.txMemPool.LastUpdated()
 	if lastTxUpdate.IsZero() {
 		lastTxUpdate = time.Now()
 	}
 
+	// Determine the number of votes the current best block must have
+	// before the template builds on it.
+	minVotes, voteWaitExpires := templateVoteRequirement(s.server,
+		requestedVotes)
+
 	// Generate a new block template when the current best block has
-	// changed or the transactions in the memory pool have been updated and
-	// it has been at least gbtRegenerateSecond since the last template was
-	// generated.
+	// changed, the votes available for it or the number of votes required
+	// to build on it have changed, or the transactions in the memory pool
+	// have been updated and it has been at least gbtRegenerateSecond since
+	// the last template was generated.
 	var msgBlock *wire.MsgBlock
 	var targetDifficulty string
 	latestHash, _ := s.server.blockManager.chainState.Best()
+	numVotes := uint16(len(s.server.txMemPool.VoteHashesForBlock(*latestHash)))
 	template := state.template
 	if template == nil || state.prevHash == nil ||
 		!state.prevHash.IsEqual(latestHash) ||
+		state.numVotes != numVotes || state.minVotes != minVotes ||
 		(state.lastTxUpdate != lastTxUpdate &&
 			time.Now().After(state.lastGenerated.Add(time.Second*
 				gbtRegenerateSeconds))) {
@@ -2463,11 +3498,21 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 		// block template doesn't include the coinbase, so the caller
 		// will ultimately create their own coinbase which pays to the
 		// appropriate address(es).
-		blkTemplate, err := NewBlockTemplate(s.policy, s.server, payAddr)
+		blkTemplate, err := NewBlockTemplate(s.policy, s.server, payAddr,
+			minVotes)
 		if err != nil {
 			return internalRPCError("Failed to create new block "+
 				"template: "+err.Error(), "")
 		}
+		if blkTemplate == nil && !voteWaitExpires.IsZero() {
+			return &dcrjson.RPCError{
+				Code: dcrjson.ErrRPCMisc,
+				Message: fmt.Sprintf("Waiting until %v for the "+
+					"votes on block %v before creating a block "+
+					"template", voteWaitExpires.Truncate(time.Second),
+					latestHash),
+			}
+		}
 		if blkTemplate == nil {
 			return internalRPCError("Failed to create new block "+
 				"template: not enough voters on parent and no "+
@@ -2495,6 +3540,9 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 		state.lastTxUpdate = lastTxUpdate
 		state.prevHash = latestHash
 		state.minTimestamp = minTimestamp
+		state.numVotes = numVotes
+		state.minVotes = minVotes
+		state.voteWaitExpires = voteWaitExpires
 
 		rpcsLog.Debugf("Generated block template (timestamp %v, "+
 			"target %s, merkle root %s)",
@@ -2688,15 +3736,13 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 				return nil, err
 			}
 
-			isSSGen := false
-			numSigOps, err := blockchain.CountP2SHSigOps(txU, false, isSSGen,
-				view)
+			cost, err := blockchain.CalcTxCost(txU, false, view)
 			if err != nil {
 				context := "Failed to count signature operations"
 				return nil, internalRPCError(err.Error(), context)
 			}
 
-			numSigOps += blockchain.CountSigOps(txU, false, isSSGen)
+			numSigOps := cost.TotalSigOps()
 			if numSigOps > maxSigOpsPerTx {
 				errStr := fmt.Sprintf("transaction %v has too "+
 					"many sigops: %d > %d", txHash,
@@ -2803,15 +3849,13 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 				return nil, err
 			}
 
-			isSSGen := txType == stake.TxTypeSSGen
-			numSigOps, err := blockchain.CountP2SHSigOps(txU, false, isSSGen,
-				view)
+			cost, err := blockchain.CalcTxCost(txU, false, view)
 			if err != nil {
 				context := "Failed to count signature operations"
 				return nil, internalRPCError(err.Error(), context)
 			}
 
-			numSigOps += blockchain.CountSigOps(txU, false, isSSGen)
+			numSigOps := cost.TotalSigOps()
 			if numSigOps > maxSigOpsPerTx {
 				errStr := fmt.Sprintf("transaction %v has too "+
 					"many sigops: %d > %d", stxHash,
@@ -2866,6 +3910,13 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 		Mutable:       gbtMutableFields,
 		NonceRange:    gbtNonceRange,
 		Capabilities:  gbtCapabilities,
+		Voters:        header.Voters,
+	}
+	if int64(header.Height) >= bm.server.chainParams.StakeValidationHeight {
+		reply.MinVotes = state.minVotes
+	}
+	if !state.voteWaitExpires.IsZero() {
+		reply.VoteWaitExpires = state.voteWaitExpires.Unix()
 	}
 	if useCoinbaseValue {
 		reply.CoinbaseAux = gbtCoinbaseAux
@@ -2915,14 +3966,14 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 // has passed without finding a solution.
 //
 // See https://en.bitcoin.it/wiki/BIP_0022 for more details.
-func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbaseValue bool, closeChan <-chan struct{}) (interface{}, error) {
+func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbaseValue bool, requestedVotes uint16, closeChan <-chan struct{}) (interface{}, error) {
 	state := s.gbtWorkState
 	state.Lock()
 	// The state unlock is intentionally not deferred here since it needs to
 	// be manually unlocked before waiting for a notification about block
 	// template changes.
 
-	if err := state.updateBlockTemplate(s, useCoinbaseValue); err != nil {
+	if err := state.updateBlockTemplate(s, useCoinbaseValue, requestedVotes); err != nil {
 		state.Unlock()
 		return nil, err
 	}
@@ -2969,6 +4020,14 @@ func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbase
 	// the provided ID is stale and a new block template should be returned to
 	// the caller.
 	longPollChan := state.templateUpdateChan(prevHash, lastGenerated)
+
+	// Also stop waiting once the template is no longer waiting for all of
+	// the votes on the previous block since a template with fewer votes
+	// will be generated at that point.
+	var voteWaitChan <-chan time.Time
+	if !state.voteWaitExpires.IsZero() {
+		voteWaitChan = time.After(state.voteWaitExpires.Sub(time.Now()))
+	}
 	state.Unlock()
 
 	select {
@@ -2980,13 +4039,17 @@ func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbase
 	// Wait until signal received to send the reply.
 	case <-longPollChan:
 		// Fallthrough
+
+	// Wait until the vote wait expires to send the reply.
+	case <-voteWaitChan:
+		// Fallthrough
 	}
 
 	// Get the lastest block template
 	state.Lock()
 	defer state.Unlock()
 
-	if err := state.updateBlockTemplate(s, useCoinbaseValue); err != nil {
+	if err := state.updateBlockTemplate(s, useCoinbaseValue, requestedVotes); err != nil {
 		return nil, err
 	}
 
@@ -3015,6 +4078,7 @@ func handleGetBlockTemplateRequest(s *rpcServer, request *dcrjson.TemplateReques
 	// either a coinbase value or a coinbase transaction object depending on
 	// the request.  Default to only providing a coinbase value.
 	useCoinbaseValue := true
+	var requestedVotes uint16
 	if request != nil {
 		var hasCoinbaseValue, hasCoinbaseTxn bool
 		for _, capability := range request.Capabilities {
@@ -3029,6 +4093,21 @@ func handleGetBlockTemplateRequest(s *rpcServer, request *dcrjson.TemplateReques
 		if hasCoinbaseTxn && !hasCoinbaseValue {
 			useCoinbaseValue = false
 		}
+
+		// Ensure the requested minimum number of votes is allowed by
+		// the consensus rules.
+		requestedVotes = request.MinVotes
+		params := s.server.chainParams
+		if requestedVotes != 0 && (requestedVotes < minVotesRequired(params) ||
+			requestedVotes > params.TicketsPerBlock) {
+			return nil, &dcrjson.RPCError{
+				Code: dcrjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("The minimum number of votes "+
+					"must be between %d and %d",
+					minVotesRequired(params),
+					params.TicketsPerBlock),
+			}
+		}
 	}
 
 	// When a coinbase transaction has been requested, respond with an error
@@ -3067,7 +4146,7 @@ func handleGetBlockTemplateRequest(s *rpcServer, request *dcrjson.TemplateReques
 	// be replaced with a new one.
 	if request != nil && request.LongPollID != "" {
 		return handleGetBlockTemplateLongPoll(s, request.LongPollID,
-			useCoinbaseValue, closeChan)
+			useCoinbaseValue, requestedVotes, closeChan)
 	}
 
 	// Protect concurrent access when updating block templates.
@@ -3081,7 +4160,7 @@ func handleGetBlockTemplateRequest(s *rpcServer, request *dcrjson.TemplateReques
 	// seconds since the last template was generated.  Otherwise, the
 	// timestamp for the existing block template is updated (and possibly
 	// the difficulty on testnet per the consesus rules).
-	if err := state.updateBlockTemplate(s, useCoinbaseValue); err != nil {
+	if err := state.updateBlockTemplate(s, useCoinbaseValue, requestedVotes); err != nil {
 		return nil, err
 	}
 	return state.blockTemplateResult(s.server.blockManager, useCoinbaseValue, nil)
@@ -3293,6 +4372,20 @@ func handleGetCoinSupply(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	return s.chain.TotalSubsidy(), nil
 }
 
+// handleGetCoinSupplyBreakdown implements the getcoinsupplybreakdown command.
+func handleGetCoinSupplyBreakdown(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	supply := s.chain.CoinSupply()
+	return &dcrjson.GetCoinSupplyBreakdownResult{
+		Hash:       supply.Hash.String(),
+		Height:     supply.Height,
+		Total:      supply.Total(),
+		Premine:    supply.Premine,
+		PoW:        supply.PoW,
+		PoS:        supply.PoS,
+		DevSubsidy: supply.DevSubsidy,
+	}, nil
+}
+
 // handleGetConnectionCount implements the getconnectioncount command.
 func handleGetConnectionCount(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	return s.server.ConnectedCount(), nil
@@ -3309,6 +4402,22 @@ func handleGetDifficulty(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	return getDifficultyRatio(best.Bits), nil
 }
 
+// handleGetFinality implements the getfinality command.
+func handleGetFinality(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	state, err := s.chain.FinalityState()
+	if err != nil {
+		context := "Failed to determine the finalized block"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &dcrjson.GetFinalityResult{
+		Active: state.Active,
+		Depth:  state.Depth,
+		Height: state.Height,
+		Hash:   state.Hash.String(),
+	}, nil
+}
+
 // handleGetGenerate implements the getgenerate command.
 func handleGetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	return s.server.cpuMiner.IsMining(), nil
@@ -3319,7 +4428,10 @@ func handleGetHashesPerSec(s *rpcServer, cmd interface{}, closeChan <-chan struc
 	return int64(s.server.cpuMiner.HashesPerSecond()), nil
 }
 
-// handleGetHeaders implements the getheaders command.
+// handleGetHeaders implements the getheaders command.  It mirrors the semantics
+// of the getheaders wire message so header sync can be performed over RPC by
+// clients which build their own block locators, such as bridges serving SPV
+// clients.
 func handleGetHeaders(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*dcrjson.GetHeadersCmd)
 	blockLocators, err := dcrjson.DecodeConcatenatedHashes(c.BlockLocators)
@@ -3327,6 +4439,14 @@ func handleGetHeaders(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		// Already a *dcrjson.RPCError
 		return nil, err
 	}
+	if len(blockLocators) > wire.MaxBlockLocatorsPerMsg {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Too many block locators [count %d, "+
+				"max %d]", len(blockLocators),
+				wire.MaxBlockLocatorsPerMsg),
+		}
+	}
 	var hashStop chainhash.Hash
 	if c.HashStop != "" {
 		err := chainhash.Decode(&hashStop, c.HashStop)
@@ -3377,6 +4497,49 @@ func handleGetHeaders(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return &dcrjson.GetHeadersResult{Headers: hexBlockHeaders}, nil
 }
 
+// handleGetIndexInfo implements the getindexinfo command.
+func handleGetIndexInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	result := []dcrjson.IndexInfoResult{}
+	if s.server.indexManager == nil {
+		return result, nil
+	}
+
+	names := make(map[string]string)
+	for option, indexer := range enabledIndexes(s) {
+		names[indexer.Name()] = option
+	}
+	for _, status := range s.server.indexManager.IndexStatuses() {
+		info := dcrjson.IndexInfoResult{
+			Name:         names[status.Name],
+			Active:       status.Active,
+			Operation:    string(status.Op),
+			Running:      status.Running,
+			Height:       status.Height,
+			TargetHeight: status.TargetHeight,
+			DeletedKeys:  status.DeletedKeys,
+		}
+		if status.Err != nil {
+			info.Error = status.Err.Error()
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// handleGetJob implements the getjob command.
+func handleGetJob(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetJobCmd)
+
+	job, ok := s.jobs.Lookup(c.JobID)
+	if !ok {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("No job with id %d", c.JobID),
+		}
+	}
+	return job.Info(), nil
+}
+
 // handleGetInfo implements the getinfo command. We only return the fields
 // that are not related to wallet functionality.
 func handleGetInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
@@ -3396,6 +4559,104 @@ func handleGetInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (in
 	return ret, nil
 }
 
+// mempoolTxNotFoundError returns an error which indicates the transaction with
+// the passed hash is not in the memory pool.
+func mempoolTxNotFoundError(txHash *chainhash.Hash) *dcrjson.RPCError {
+	return &dcrjson.RPCError{
+		Code:    dcrjson.ErrRPCNoTxInfo,
+		Message: fmt.Sprintf("Transaction %v is not in the memory pool", txHash),
+	}
+}
+
+// txDescHashes returns the hashes of the transactions of the passed descriptors
+// as strings.
+func txDescHashes(descs []*mempool.TxDesc) []string {
+	hashes := make([]string, 0, len(descs))
+	for _, desc := range descs {
+		hashes = append(hashes, desc.Tx.Hash().String())
+	}
+	return hashes
+}
+
+// handleGetMempoolAncestors implements the getmempoolancestors command.
+func handleGetMempoolAncestors(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetMempoolAncestorsCmd)
+	txHash, err := chainhash.NewHashFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	if *c.Verbose {
+		entries, err := s.server.txMemPool.AncestorEntries(txHash)
+		if err != nil {
+			return nil, mempoolTxNotFoundError(txHash)
+		}
+		return entries, nil
+	}
+
+	ancestors, err := s.server.txMemPool.Ancestors(txHash)
+	if err != nil {
+		return nil, mempoolTxNotFoundError(txHash)
+	}
+	return txDescHashes(ancestors), nil
+}
+
+// handleGetMempoolDescendants implements the getmempooldescendants command.
+func handleGetMempoolDescendants(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetMempoolDescendantsCmd)
+	txHash, err := chainhash.NewHashFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	if *c.Verbose {
+		entries, err := s.server.txMemPool.DescendantEntries(txHash)
+		if err != nil {
+			return nil, mempoolTxNotFoundError(txHash)
+		}
+		return entries, nil
+	}
+
+	descendants, err := s.server.txMemPool.Descendants(txHash)
+	if err != nil {
+		return nil, mempoolTxNotFoundError(txHash)
+	}
+	return txDescHashes(descendants), nil
+}
+
+// handleGetMempoolEntry implements the getmempoolentry command.
+func handleGetMempoolEntry(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetMempoolEntryCmd)
+	txHash, err := chainhash.NewHashFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	entry, err := s.server.txMemPool.MempoolEntry(txHash)
+	if err != nil {
+		return nil, mempoolTxNotFoundError(txHash)
+	}
+	return entry, nil
+}
+
+// handleGetMempoolGraph implements the getmempoolgraph command.
+func handleGetMempoolGraph(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetMempoolGraphCmd)
+	txHash, err := chainhash.NewHashFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	entries, err := s.server.txMemPool.DependencyGraph(txHash)
+	if err != nil {
+		return nil, mempoolTxNotFoundError(txHash)
+	}
+	return &dcrjson.GetMempoolGraphResult{
+		TxID:         txHash.String(),
+		Transactions: entries,
+	}, nil
+}
+
 // handleGetMempoolInfo implements the getmempoolinfo command.
 func handleGetMempoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	mempoolTxns := s.server.txMemPool.TxDescs()
@@ -3406,19 +4667,48 @@ func handleGetMempoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	}
 
 	ret := &dcrjson.GetMempoolInfoResult{
-		Size:  int64(len(mempoolTxns)),
-		Bytes: numBytes,
+		Size:          int64(len(mempoolTxns)),
+		Bytes:         numBytes,
+		MaxMempool:    int64(cfg.MaxMempoolSize) * 1024 * 1024,
+		MempoolMinFee: s.server.txMemPool.MinPoolFeeRate().ToCoin(),
 	}
 
 	return ret, nil
 }
 
+// handleGetMissedTicketDetails implements the getmissedticketdetails command.
+func handleGetMissedTicketDetails(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetMissedTicketDetailsCmd)
+
+	if s.missedTickets == nil {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCMisc,
+			Message: "Missed ticket tracking must be enabled to query " +
+				"missed ticket details (specify --trackmissedtickets)",
+		}
+	}
+
+	var tickets []chainhash.Hash
+	if c.Tickets != nil {
+		tickets = make([]chainhash.Hash, 0, len(*c.Tickets))
+		for _, ticketStr := range *c.Tickets {
+			ticket, err := chainhash.NewHashFromStr(ticketStr)
+			if err != nil {
+				return nil, rpcDecodeHexError(ticketStr)
+			}
+			tickets = append(tickets, *ticket)
+		}
+	}
+
+	return s.missedTickets.MissedTicketDetails(tickets), nil
+}
+
 // handleGetMiningInfo implements the getmininginfo command. We only return the
 // fields that are not related to wallet functionality.
 func handleGetMiningInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// Create a default getnetworkhashps command to use defaults and make
 	// use of the existing getnetworkhashps handler.
-	gnhpsCmd := dcrjson.NewGetNetworkHashPSCmd(nil, nil)
+	gnhpsCmd := dcrjson.NewGetNetworkHashPSCmd(nil, nil, nil)
 	networkHashesPerSecIface, err := handleGetNetworkHashPS(s, gnhpsCmd,
 		closeChan)
 	if err != nil {
@@ -3479,14 +4769,22 @@ func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan stru
 
 	// When the passed height is too high or zero, just return 0 now
 	// since we can't reasonably calculate the number of network hashes
-	// per second from invalid values.  When it's negative, use the current
-	// best block height.
+	// per second from invalid values.  An error is returned instead for
+	// verbose results since they describe the window the estimates were
+	// calculated over.  When it's negative, use the current best block
+	// height.
 	best := s.chain.BestSnapshot()
 	endHeight := int64(-1)
 	if c.Height != nil {
 		endHeight = int64(*c.Height)
 	}
 	if endHeight > best.Height || endHeight == 0 {
+		if c.Verbose != nil && *c.Verbose {
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCOutOfRange,
+				Message: "Block height out of range",
+			}
+		}
 		return int64(0), nil
 	}
 	if endHeight < 0 {
@@ -3522,8 +4820,32 @@ func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan stru
 
 	// Find the min and max block timestamps as well as calculate the total
 	// amount of work that happened between the start and end blocks.
-	var minTimestamp, maxTimestamp time.Time
+	// Also, calculate the estimates for each run of blocks with the same
+	// difficulty along with the work of each run so they can be weighted
+	// accordingly.  The estimates for each run are more accurate around
+	// difficulty changes since the average across the entire window does
+	// not account for how the hash rate responded to the new difficulty.
+	var minTimestamp, maxTimestamp, runStartTimestamp time.Time
+	var runBits uint32
 	totalWork := big.NewInt(0)
+	runWork := big.NewInt(0)
+	weightedTotal := big.NewInt(0)
+	weightedWork := big.NewInt(0)
+	var numRuns int64
+	addRun := func(endTimestamp time.Time) {
+		runTimeDiff := int64(endTimestamp.Sub(runStartTimestamp) /
+			time.Second)
+		if runWork.Sign() == 0 || runTimeDiff <= 0 {
+			return
+		}
+		runHashesPerSec := new(big.Int).Div(runWork,
+			big.NewInt(runTimeDiff))
+		weightedTotal.Add(weightedTotal, runHashesPerSec.Mul(
+			runHashesPerSec, runWork))
+		weightedWork.Add(weightedWork, runWork)
+		numRuns++
+	}
+	var prevTimestamp time.Time
 	for curHeight := startHeight; curHeight <= endHeight; curHeight++ {
 		hash, err := s.chain.BlockHashByHeight(curHeight)
 		if err != nil {
@@ -3554,8 +4876,22 @@ func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan stru
 		if curHeight == startHeight {
 			minTimestamp = header.Timestamp
 			maxTimestamp = minTimestamp
+			runStartTimestamp = minTimestamp
+			runBits = header.Bits
 		} else {
-			totalWork.Add(totalWork, blockchain.CalcWork(header.Bits))
+			// Finish the current run of blocks when the difficulty
+			// changes.  The new run starts at the timestamp of the
+			// last block of the previous run.
+			if header.Bits != runBits {
+				addRun(prevTimestamp)
+				runStartTimestamp = prevTimestamp
+				runBits = header.Bits
+				runWork.SetInt64(0)
+			}
+
+			work := blockchain.CalcWork(header.Bits)
+			totalWork.Add(totalWork, work)
+			runWork.Add(runWork, work)
 
 			if minTimestamp.After(header.Timestamp) {
 				minTimestamp = header.Timestamp
@@ -3564,18 +4900,41 @@ func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan stru
 				maxTimestamp = header.Timestamp
 			}
 		}
+		prevTimestamp = header.Timestamp
 	}
+	addRun(prevTimestamp)
 
 	// Calculate the difference in seconds between the min and max block
 	// timestamps and avoid division by zero in the case where there is no
 	// time difference.
 	timeDiff := int64(maxTimestamp.Sub(minTimestamp) / time.Second)
-	if timeDiff == 0 {
-		return int64(0), nil
-	}
+	var hashesPerSec int64
+	if timeDiff != 0 {
+		hashesPerSec = new(big.Int).Div(totalWork,
+			big.NewInt(timeDiff)).Int64()
+	}
+	if c.Verbose == nil || !*c.Verbose {
+		return hashesPerSec, nil
+	}
+
+	var workWeightedHashesPerSec int64
+	if weightedWork.Sign() != 0 {
+		workWeightedHashesPerSec = weightedTotal.Div(weightedTotal,
+			weightedWork).Int64()
+	}
+	return &dcrjson.GetNetworkHashPSVerboseResult{
+		StartHeight:     startHeight,
+		EndHeight:       endHeight,
+		TimeSpan:        timeDiff,
+		DifficultyRuns:  numRuns,
+		AverageHashesPS: hashesPerSec,
+		WorkWeightedPS:  workWeightedHashesPerSec,
+	}, nil
+}
 
-	hashesPerSec := new(big.Int).Div(totalWork, big.NewInt(timeDiff))
-	return hashesPerSec.Int64(), nil
+// handleGetPeerFilterStats implements the getpeerfilterstats command.
+func handleGetPeerFilterStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.server.peerFilter.stats(), nil
 }
 
 // handleGetPeerInfo implements the getpeerinfo command.
@@ -3603,7 +4962,10 @@ func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 			CurrentHeight:  statsSnap.LastBlock,
 			BanScore:       int32(p.banScore.Int()),
 			SyncNode:       p == syncPeer,
+			FirstBlocks:    atomic.LoadUint64(&p.firstBlocks),
+			FirstTxns:      atomic.LoadUint64(&p.firstTxns),
 		}
+		info.BytesSentPerMsg, info.BytesRecvPerMsg = p.msgStats.Snapshot()
 		if p.LastPingNonce() != 0 {
 			wait := float64(time.Since(statsSnap.LastPingTime).Nanoseconds())
 			// We actually want microseconds.
@@ -3642,10 +5004,59 @@ func handleGetRawMempool(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 		}
 	}
 
-	// Return verbose results if requested.
+	// Filter the results by the minimum fee rate and the age of the
+	// transactions if requested.
+	var minFeeRate dcrutil.Amount
+	if c.MinFeeRate != nil {
+		var err error
+		minFeeRate, err = dcrutil.NewAmount(*c.MinFeeRate)
+		if err != nil || minFeeRate < 0 {
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCInvalidParameter,
+				Message: "Invalid minimum fee rate",
+			}
+		}
+	}
+	var minAge, maxAge time.Duration
+	if c.MinAge != nil {
+		if *c.MinAge < 0 {
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCInvalidParameter,
+				Message: "Minimum age must not be negative",
+			}
+		}
+		minAge = time.Duration(*c.MinAge) * time.Second
+	}
+	if c.MaxAge != nil {
+		if *c.MaxAge < 0 {
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCInvalidParameter,
+				Message: "Maximum age must not be negative",
+			}
+		}
+		maxAge = time.Duration(*c.MaxAge) * time.Second
+	}
+	now := s.server.clock.Now()
+	filter := func(desc *mempool.TxDesc) bool {
+		if filterType != nil && desc.Type != *filterType {
+			return false
+		}
+		if minFeeRate > 0 && txDescFeeRate(desc) < minFeeRate {
+			return false
+		}
+		age := now.Sub(desc.Added)
+		return age >= minAge && (c.MaxAge == nil || age <= maxAge)
+	}
+
+	// Return a summary of the matching transactions if requested.
 	mp := s.server.txMemPool
+	if c.Summary != nil && *c.Summary {
+		return mempoolSummary(mp.TxDescs(), filter), nil
+	}
+
+	// Return verbose results if requested.
 	if c.Verbose != nil && *c.Verbose {
-		return mp.RawMempoolVerbose(filterType), nil
+		return mp.RawMempoolVerbose(filter), nil
 	}
 
 	// The response is simply an array of the transaction hashes if the
@@ -3653,7 +5064,7 @@ func handleGetRawMempool(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	descs := mp.TxDescs()
 	hashStrings := make([]string, 0, len(descs))
 	for i := range descs {
-		if filterType != nil && descs[i].Type != *filterType {
+		if !filter(descs[i]) {
 			continue
 		}
 		hashStrings = append(hashStrings, descs[i].Tx.Hash().String())
@@ -3661,6 +5072,54 @@ func handleGetRawMempool(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	return hashStrings, nil
 }
 
+// txDescFeeRate returns the fee rate of the passed transaction in atoms/kB.
+func txDescFeeRate(desc *mempool.TxDesc) dcrutil.Amount {
+	return dcrutil.Amount(desc.Fee * 1000 /
+		int64(desc.Tx.MsgTx().SerializeSize()))
+}
+
+// mempoolSummary returns a summary of the passed transactions for which the
+// filter function returns true.
+func mempoolSummary(descs []*mempool.TxDesc, filter func(*mempool.TxDesc) bool) *dcrjson.GetRawMempoolSummaryResult {
+	result := new(dcrjson.GetRawMempoolSummaryResult)
+	var fees, minFeeRate, maxFeeRate dcrutil.Amount
+	for _, desc := range descs {
+		if !filter(desc) {
+			continue
+		}
+
+		feeRate := txDescFeeRate(desc)
+		if result.Count == 0 || feeRate < minFeeRate {
+			minFeeRate = feeRate
+		}
+		if feeRate > maxFeeRate {
+			maxFeeRate = feeRate
+		}
+		added := desc.Added.Unix()
+		if result.Count == 0 || added < result.OldestTime {
+			result.OldestTime = added
+		}
+		result.Count++
+		result.Size += int64(desc.Tx.MsgTx().SerializeSize())
+		fees += dcrutil.Amount(desc.Fee)
+
+		switch desc.Type {
+		case stake.TxTypeRegular:
+			result.Regular++
+		case stake.TxTypeSStx:
+			result.Tickets++
+		case stake.TxTypeSSGen:
+			result.Votes++
+		case stake.TxTypeSSRtx:
+			result.Revocations++
+		}
+	}
+	result.Fees = fees.ToCoin()
+	result.MinFeeRate = minFeeRate.ToCoin()
+	result.MaxFeeRate = maxFeeRate.ToCoin()
+	return result
+}
+
 // handleGetRawTransaction implements the getrawtransaction command.
 func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*dcrjson.GetRawTransactionCmd)
@@ -3797,6 +5256,59 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 	return *rawTxn, nil
 }
 
+// handleGetRejectedTransactions implements the getrejectedtransactions command.
+func handleGetRejectedTransactions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	rejectedTxns := s.server.txMemPool.RecentlyRejected()
+	result := make([]dcrjson.RejectedTransactionResult, 0, len(rejectedTxns))
+	for i := range rejectedTxns {
+		rejected := &rejectedTxns[i]
+		result = append(result, dcrjson.RejectedTransactionResult{
+			TxID:       rejected.Hash.String(),
+			FullHash:   rejected.FullHash.String(),
+			RejectCode: rejected.RejectCode.String(),
+			Reason:     rejected.Reason,
+			Time:       rejected.Rejected.Unix(),
+			Expires:    rejected.Expires.Unix(),
+			Seen:       rejected.Seen,
+		})
+	}
+	return result, nil
+}
+
+// handleGetRelayHistory implements the getrelayhistory command.
+func handleGetRelayHistory(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetRelayHistoryCmd)
+
+	includeBlocks, includeTxns := true, true
+	if c.Type != nil {
+		switch *c.Type {
+		case "block":
+			includeTxns = false
+		case "tx":
+			includeBlocks = false
+		default:
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCInvalidParameter,
+				Message: "type must be either block or tx",
+			}
+		}
+	}
+
+	count := uint32(100)
+	if c.Count != nil {
+		count = *c.Count
+	}
+	if count == 0 {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: "count must be greater than zero",
+		}
+	}
+
+	return s.server.blockManager.relayHistory.Recent(includeBlocks,
+		includeTxns, int(count)), nil
+}
+
 // handleGetStakeDifficulty implements the getstakedifficulty command.
 func handleGetStakeDifficulty(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	best := s.chain.BestSnapshot()
@@ -3961,6 +5473,11 @@ func handleGetStakeVersions(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return result, nil
 }
 
+// handleGetSyncStatus implements the getsyncstatus command.
+func handleGetSyncStatus(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.server.blockManager.SyncStatus(), nil
+}
+
 // handleGetTicketPoolValue implements the getticketpoolvalue command.
 func handleGetTicketPoolValue(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	amt, err := s.server.blockManager.TicketPoolValue()
@@ -3971,6 +5488,46 @@ func handleGetTicketPoolValue(s *rpcServer, cmd interface{}, closeChan <-chan st
 	return amt.ToCoin(), nil
 }
 
+// handleGetTreasuryBalance implements the gettreasurybalance command.
+func handleGetTreasuryBalance(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	balance, err := s.chain.TreasuryBalance()
+	if err != nil {
+		context := "Failed to fetch the treasury balance"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &dcrjson.GetTreasuryBalanceResult{
+		Active:  balance.Active,
+		Hash:    balance.Hash.String(),
+		Height:  balance.Height,
+		Balance: balance.Balance,
+		Added:   balance.Added,
+		Spent:   balance.Spent,
+	}, nil
+}
+
+// handleGetTreasurySpends implements the gettreasuryspends command.
+func handleGetTreasurySpends(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetTreasurySpendsCmd)
+
+	spends, err := s.chain.TreasurySpends(int64(*c.NumBlocks))
+	if err != nil {
+		context := "Failed to fetch the treasury spends"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	result := make([]dcrjson.TreasurySpendResult, 0, len(spends))
+	for _, spend := range spends {
+		result = append(result, dcrjson.TreasurySpendResult{
+			TxID:        spend.Hash.String(),
+			BlockHash:   spend.BlockHash.String(),
+			BlockHeight: spend.BlockHeight,
+			Amount:      spend.Amount,
+		})
+	}
+	return result, nil
+}
+
 // handleGetVoteInfo implements the getvoteinfo command.
 func handleGetVoteInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c, ok := cmd.(*dcrjson.GetVoteInfoCmd)
@@ -4222,6 +5779,246 @@ func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 	return txOutReply, nil
 }
 
+// handleGetTxCost implements the gettxcost command.
+func handleGetTxCost(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetTxCostCmd)
+
+	// Deserialize the transaction.
+	hexStr := c.HexTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var mtx wire.MsgTx
+	err = mtx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCDeserialization,
+			Message: "TX decode failed: " + err.Error(),
+		}
+	}
+	tx := dcrutil.NewTx(&mtx)
+
+	// Calculate the costs the same way the consensus rules, the memory
+	// pool, and block template generation do.  The outputs the transaction
+	// spends may be in the main chain or the memory pool.
+	isCoinBase := blockchain.IsCoinBaseTx(&mtx)
+	var view *blockchain.UtxoViewpoint
+	if !isCoinBase {
+		view, err = s.server.txMemPool.FetchInputUtxos(tx)
+		if err != nil {
+			context := "Failed to fetch inputs"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	}
+	cost, err := blockchain.CalcTxCost(tx, isCoinBase, view)
+	if err != nil {
+		if _, ok := err.(blockchain.RuleError); ok {
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCNoTxInfo,
+				Message: err.Error(),
+			}
+		}
+		context := "Failed to calculate transaction cost"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &dcrjson.GetTxCostResult{
+		TxID:        tx.Hash().String(),
+		Size:        cost.Size,
+		SigOps:      cost.SigOps,
+		P2SHSigOps:  cost.P2SHSigOps,
+		AltSigOps:   cost.AltSigOps,
+		TotalSigOps: cost.TotalSigOps(),
+	}, nil
+}
+
+// handleGetTxRelayStatus implements the gettxrelaystatus command.
+func handleGetTxRelayStatus(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.GetTxRelayStatusCmd)
+
+	if c.TxID == nil {
+		return s.server.localTxs.RelayStatus(nil), nil
+	}
+
+	txHash, err := chainhash.NewHashFromStr(*c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(*c.TxID)
+	}
+	result := s.server.localTxs.RelayStatus(txHash)
+	if result == nil {
+		return nil, rpcNoTxInfoError(txHash)
+	}
+	return result, nil
+}
+
+// validationStageResults converts the passed accumulated timing of validation
+// stages into their RPC results.
+func validationStageResults(timings []blockchain.StageTiming) []dcrjson.ValidationStageResult {
+	results := make([]dcrjson.ValidationStageResult, 0, len(timings))
+	for i := range timings {
+		timing := &timings[i]
+		results = append(results, dcrjson.ValidationStageResult{
+			Stage:     timing.Stage.String(),
+			Count:     timing.Count,
+			TotalTime: int64(timing.Total / time.Microsecond),
+			AvgTime:   int64(timing.Average() / time.Microsecond),
+			MaxTime:   int64(timing.Max / time.Microsecond),
+		})
+	}
+	return results
+}
+
+// handleGetTxSpendingInfo implements the gettxspendinginfo command.
+func handleGetTxSpendingInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	spendIndex := s.server.spendIndex
+	if spendIndex == nil {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCNoTxInfo,
+			Message: "The spend index must be enabled to query " +
+				"whether or not an output is spent (specify " +
+				"--spendindex)",
+		}
+	}
+
+	c := cmd.(*dcrjson.GetTxSpendingInfoCmd)
+	txHash, err := chainhash.NewHashFromStr(c.Txid)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Txid)
+	}
+	includeMempool := true
+	if c.IncludeMempool != nil {
+		includeMempool = *c.IncludeMempool
+	}
+
+	// The request does not identify the transaction tree of the output
+	// since a transaction is only ever part of one of them, so look up the
+	// output in both.
+	result := &dcrjson.TxSpendingInfoResult{}
+	for _, tree := range []int8{wire.TxTreeRegular, wire.TxTreeStake} {
+		outPoint := wire.NewOutPoint(txHash, c.Vout, tree)
+		info, err := spendIndex.SpendInfo(outPoint)
+		if err != nil {
+			context := "Failed to fetch spend information"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if info != nil {
+			blockHash, err := s.chain.BlockHashByHeight(info.Height)
+			if err != nil {
+				context := "Failed to fetch block hash"
+				return nil, internalRPCError(err.Error(), context)
+			}
+
+			best := s.chain.BestSnapshot()
+			result.Spent = true
+			result.SpendingTxID = info.SpenderHash.String()
+			result.Vin = info.InputIndex
+			result.BlockHash = blockHash.String()
+			result.BlockHeight = info.Height
+			result.Confirmations = 1 + best.Height - info.Height
+			return result, nil
+		}
+
+		if !includeMempool {
+			continue
+		}
+		spender := s.server.txMemPool.FetchSpender(outPoint)
+		if spender == nil {
+			continue
+		}
+		for i, txIn := range spender.MsgTx().TxIn {
+			if txIn.PreviousOutPoint == *outPoint {
+				result.Vin = uint32(i)
+				break
+			}
+		}
+		result.Spent = true
+		result.SpendingTxID = spender.Hash().String()
+		return result, nil
+	}
+
+	return result, nil
+}
+
+// handleGetValidationStats implements the getvalidationstats command.
+func handleGetValidationStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return &dcrjson.GetValidationStatsResult{
+		Transactions: validationStageResults(s.server.txMemPool.ValidationStats()),
+		Blocks:       validationStageResults(s.chain.ValidationStats()),
+	}, nil
+}
+
+// handleGetWindowAggregates implements the getwindowaggregates command.
+func handleGetWindowAggregates(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	windowAggIndex := s.server.windowAggIndex
+	if windowAggIndex == nil {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCMisc,
+			Message: "The window aggregate index must be enabled " +
+				"(--windowaggindex) to query window aggregates",
+		}
+	}
+
+	c := cmd.(*dcrjson.GetWindowAggregatesCmd)
+	numWindows := uint32(1)
+	if c.Windows != nil {
+		numWindows = *c.Windows
+	}
+	if numWindows == 0 {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: "The number of windows must be greater than zero",
+		}
+	}
+
+	// Determine the range of windows to return, which ends with the window
+	// of the current best block.
+	windowSize := windowAggIndex.WindowSize()
+	bestHeight := s.chain.BestSnapshot().Height
+	endWindow := uint32(bestHeight / windowSize)
+	var startWindow uint32
+	if numWindows <= endWindow {
+		startWindow = endWindow - numWindows + 1
+	}
+
+	aggs, err := windowAggIndex.WindowAggregates(startWindow, endWindow)
+	if err != nil {
+		context := "Failed to fetch window aggregates"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// Return the windows in descending order starting with the most recent
+	// window.
+	result := &dcrjson.GetWindowAggregatesResult{
+		WindowSize: windowSize,
+		Windows:    make([]dcrjson.WindowAggregate, 0, len(aggs)),
+	}
+	for i := len(aggs) - 1; i >= 0; i-- {
+		agg := aggs[i]
+		startHeight := int64(agg.Window) * windowSize
+		result.Windows = append(result.Windows, dcrjson.WindowAggregate{
+			Window:          agg.Window,
+			StartHeight:     startHeight,
+			EndHeight:       startHeight + int64(agg.NumBlocks) - 1,
+			Blocks:          agg.NumBlocks,
+			Difficulty:      getDifficultyRatio(agg.Bits),
+			StakeDifficulty: dcrutil.Amount(agg.SBits).ToCoin(),
+			TotalFees:       dcrutil.Amount(agg.TotalFees).ToCoin(),
+			WorkSubsidy:     dcrutil.Amount(agg.WorkSubsidy).ToCoin(),
+			StakeSubsidy:    dcrutil.Amount(agg.StakeSubsidy).ToCoin(),
+			TaxSubsidy:      dcrutil.Amount(agg.TaxSubsidy).ToCoin(),
+			Votes:           agg.Votes,
+			Tickets:         agg.Tickets,
+			Revocations:     agg.Revocations,
+		})
+	}
+
+	return result, nil
+}
+
 // pruneOldBlockTemplates prunes all old block templates from the templatePool
 // map. Must be called with the RPC workstate locked to avoid races to the map.
 func pruneOldBlockTemplates(s *rpcServer, bestHeight int64) {
@@ -4247,6 +6044,7 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 	// generated.
 	lastTxUpdate := s.server.txMemPool.LastUpdated()
 	latestHash, latestHeight := s.server.blockManager.chainState.Best()
+	minVotes, voteWaitExpires := templateVoteRequirement(s.server, 0)
 	msgBlock := state.msgBlock
 
 	// The current code pulls down a new template every second, however with a
@@ -4256,6 +6054,7 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 	// template. TODO cj
 	if msgBlock == nil || state.prevHash == nil ||
 		!state.prevHash.IsEqual(latestHash) ||
+		state.minVotes != minVotes ||
 		(state.lastTxUpdate != lastTxUpdate &&
 			time.Now().After(state.lastGenerated.Add(time.Second))) {
 		// Reset the extra nonce and clear all expired cached template
@@ -4273,11 +6072,21 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 		// Choose a payment address at random.
 		payToAddr := cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))]
 
-		template, err := NewBlockTemplate(s.policy, s.server, payToAddr)
+		template, err := NewBlockTemplate(s.policy, s.server, payToAddr,
+			minVotes)
 		if err != nil {
 			context := "Failed to create new block template"
 			return nil, internalRPCError(err.Error(), context)
 		}
+		if template == nil && !voteWaitExpires.IsZero() {
+			return nil, &dcrjson.RPCError{
+				Code: dcrjson.ErrRPCMisc,
+				Message: fmt.Sprintf("Waiting until %v for the "+
+					"votes on block %v before creating a block "+
+					"template", voteWaitExpires.Truncate(time.Second),
+					latestHash),
+			}
+		}
 		if template == nil {
 			// This happens if the template is returned nil because there
 			// are not enough voters on HEAD and there is currently an
@@ -4295,6 +6104,7 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 		state.lastGenerated = time.Now()
 		state.lastTxUpdate = lastTxUpdate
 		state.prevHash = latestHash
+		state.minVotes = minVotes
 
 		rpcsLog.Debugf("Generated block template (timestamp %v, extra "+
 			"nonce %d, target %064x, merkle root %s)",
@@ -4451,13 +6261,22 @@ func handleGetWorkSubmission(s *rpcServer, hexData string) (interface{}, error)
 	copy(merkleRootPair[:chainhash.HashSize], submittedHeader.MerkleRoot[:])
 	copy(merkleRootPair[chainhash.HashSize:], submittedHeader.StakeRoot[:])
 
+	// Return false to indicate the solve failed when the work no longer
+	// builds on a suitable block.
+	if reason := staleWorkReason(s.server.blockManager, &submittedHeader); reason != "" {
+		rpcsLog.Infof("Block submitted via getwork rejected (%s): "+
+			"previous block %s is no longer suitable to build on",
+			reason, submittedHeader.PrevBlock)
+		return false, nil
+	}
+
 	// Look up the full block for the provided data based on the
 	// merkle root.  Return false to indicate the solve failed if
 	// it's not available.
 	blockInfo, ok := s.templatePool[merkleRootPair]
 	if !ok {
-		rpcsLog.Errorf("Block submitted via getwork has no matching "+
-			"template for merkle root %s",
+		rpcsLog.Errorf("Block submitted via getwork rejected "+
+			"(unknown-work): no matching template for merkle root %s",
 			submittedHeader.MerkleRoot)
 		return false, nil
 	}
@@ -4615,6 +6434,135 @@ func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return help, nil
 }
 
+// handleImportAddrMan implements the importaddrman command.
+func handleImportAddrMan(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.ImportAddrManCmd)
+
+	if c.AddrMan.Version != addrmgr.PortableVersion {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("unsupported address manager "+
+				"format version %d", c.AddrMan.Version),
+		}
+	}
+
+	addrs := make([]addrmgr.PortableAddress, 0, len(c.AddrMan.Addresses))
+	for i := range c.AddrMan.Addresses {
+		addr := &c.AddrMan.Addresses[i]
+		addrs = append(addrs, addrmgr.PortableAddress{
+			Addr:        addr.Addr,
+			Src:         addr.Src,
+			Services:    wire.ServiceFlag(addr.Services),
+			Attempts:    addr.Attempts,
+			TimeStamp:   time.Unix(addr.TimeStamp, 0),
+			LastAttempt: time.Unix(addr.LastAttempt, 0),
+			LastSuccess: time.Unix(addr.LastSuccess, 0),
+			Tried:       addr.Tried,
+		})
+	}
+
+	added, err := s.server.addrManager.ImportAddresses(addrs)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	return dcrjson.ImportAddrManResult{
+		Added:   added,
+		Skipped: len(addrs) - added,
+	}, nil
+}
+
+// invalidateBlockRPCError converts the passed error returned when manually
+// invalidating or reconsidering a block into an RPC error.
+func invalidateBlockRPCError(err error) error {
+	if _, ok := err.(blockchain.HashError); ok {
+		return &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+	return &dcrjson.RPCError{
+		Code:    dcrjson.ErrRPCMisc,
+		Message: err.Error(),
+	}
+}
+
+// handleInvalidateBlock implements the invalidateblock command.
+func handleInvalidateBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.InvalidateBlockCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.BlockHash)
+	}
+	if err := s.server.blockManager.InvalidateBlock(hash); err != nil {
+		return nil, invalidateBlockRPCError(err)
+	}
+
+	return nil, nil
+}
+
+// handleListJobs implements the listjobs command.
+func handleListJobs(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	jobs := s.jobs.Jobs()
+	result := make([]dcrjson.JobResult, 0, len(jobs))
+	for _, job := range jobs {
+		result = append(result, *job.Info())
+	}
+	return result, nil
+}
+
+// handleLoadUtxoSet implements the loadutxoset command.
+func handleLoadUtxoSet(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.LoadUtxoSetCmd)
+
+	fileName := c.File
+	if !filepath.IsAbs(fileName) {
+		fileName = filepath.Join(utxoSnapshotDir(), fileName)
+	}
+	if c.Async != nil && *c.Async {
+		return startJob(s, "loadutxoset", nil, func(*rpcJob) (interface{}, error) {
+			return loadUtxoSet(s, fileName)
+		})
+	}
+	return loadUtxoSet(s, fileName)
+}
+
+// loadUtxoSet verifies the utxo set snapshot in the passed file against the
+// main chain and returns the result of the loadutxoset command for it.
+func loadUtxoSet(s *rpcServer, fileName string) (*dcrjson.UtxoSnapshotResult, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+	info, err := blockchain.ReadUtxoSnapshot(f)
+	f.Close()
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: fmt.Sprintf("Invalid snapshot: %v", err),
+		}
+	}
+
+	// Ensure the contents of the snapshot match the utxo set and live
+	// ticket pool of the block it is for.
+	if err := s.chain.VerifyUtxoSnapshot(info); err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: fmt.Sprintf("Snapshot verification failed: %v", err),
+		}
+	}
+
+	rpcsLog.Infof("Verified utxo set snapshot of block %v (height %d) in %s",
+		info.Hash, info.Height, fileName)
+	return utxoSnapshotResult(fileName, info), nil
+}
+
 // handleLiveTickets implements the livetickets command.
 func handleLiveTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	lt, err := s.server.blockManager.chain.LiveTickets()
@@ -4658,6 +6606,21 @@ func handlePing(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return nil, nil
 }
 
+// handleReconsiderBlock implements the reconsiderblock command.
+func handleReconsiderBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.ReconsiderBlockCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.BlockHash)
+	}
+	if err := s.server.blockManager.ReconsiderBlock(hash); err != nil {
+		return nil, invalidateBlockRPCError(err)
+	}
+
+	return nil, nil
+}
+
 // handleRebroadcastMissed implements the rebroadcastmissed command.
 func handleRebroadcastMissed(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	hash, height := s.server.blockManager.chainState.Best()
@@ -4685,6 +6648,12 @@ func handleRebroadcastMissed(s *rpcServer, cmd interface{}, closeChan <-chan str
 	return nil, nil
 }
 
+// handleRebuildIndex implements the rebuildindex command.
+func handleRebuildIndex(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.RebuildIndexCmd)
+	return maintainIndex(s, "rebuildindex", indexers.IndexOpRebuild, c.Index)
+}
+
 // handleRebroadcastWinners implements the rebroadcastwinners command.
 func handleRebroadcastWinners(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	hash, height := s.server.blockManager.chainState.Best()
@@ -5212,6 +7181,74 @@ func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan
 	return srtList, nil
 }
 
+// checkMaxFeeRate returns an error when the fee rate paid by the passed
+// transaction exceeds the provided maximum fee rate in DCR/kB.  A maximum fee
+// rate of zero disables the check.
+//
+// The fee is calculated from the amounts of the outputs the transaction spends
+// as looked up by the memory pool rather than the input amounts committed to by
+// the transaction, since those are supplied by the caller.  The check is
+// skipped when any of the spent outputs can't be found because the memory pool
+// rejects such transactions regardless.
+func (s *rpcServer) checkMaxFeeRate(tx *dcrutil.Tx, maxFeeRate float64) error {
+	if maxFeeRate == 0 {
+		return nil
+	}
+	maxRate, err := dcrutil.NewAmount(maxFeeRate)
+	if err != nil || maxRate < 0 {
+		return &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Invalid max fee rate %v", maxFeeRate),
+		}
+	}
+
+	utxoView, err := s.server.txMemPool.FetchInputUtxos(tx)
+	if err != nil {
+		context := "Failed to fetch spent outputs"
+		return internalRPCError(err.Error(), context)
+	}
+
+	// The stakebase input of a vote does not spend an output, so its
+	// amount is the one committed to by the vote which is enforced by the
+	// consensus rules.
+	msgTx := tx.MsgTx()
+	isVote, _ := stake.IsSSGen(msgTx)
+	var totalIn, totalOut int64
+	for i, txIn := range msgTx.TxIn {
+		if i == 0 && isVote {
+			totalIn += txIn.ValueIn
+			continue
+		}
+
+		prevOut := &txIn.PreviousOutPoint
+		entry := utxoView.LookupEntry(&prevOut.Hash)
+		if entry == nil || entry.IsOutputSpent(prevOut.Index) {
+			return nil
+		}
+		totalIn += entry.AmountByIndex(prevOut.Index)
+	}
+	for _, txOut := range msgTx.TxOut {
+		totalOut += txOut.Value
+	}
+	fee := dcrutil.Amount(totalIn - totalOut)
+	serializedSize := msgTx.SerializeSize()
+	if fee <= 0 || serializedSize <= 0 {
+		return nil
+	}
+
+	feeRate := fee * 1000 / dcrutil.Amount(serializedSize)
+	if feeRate > maxRate {
+		return &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCDeserialization,
+			Message: fmt.Sprintf("TX rejected: transaction %v has a "+
+				"fee rate of %v/kB which is above the maximum "+
+				"allowed fee rate of %v/kB (set allowhighfees to "+
+				"override)", tx.Hash(), feeRate, maxRate),
+		}
+	}
+	return nil
+}
+
 // handleSendRawTransaction implements the sendrawtransaction command.
 func handleSendRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*dcrjson.SendRawTransactionCmd)
@@ -5236,6 +7273,18 @@ func handleSendRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan st
 	}
 
 	tx := dcrutil.NewTx(msgtx)
+
+	// Reject transactions which pay a fee rate above the maximum allowed
+	// unless high fees are explicitly allowed.  This protects callers from
+	// accidentally burning coins with absurd fees due to errors such as
+	// mixing up units.
+	if !allowHighFees {
+		err := s.checkMaxFeeRate(tx, *c.MaxFeeRate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	acceptedTxs, err := s.server.blockManager.ProcessTransaction(tx, false,
 		false, allowHighFees)
 	if err != nil {
@@ -5252,22 +7301,79 @@ func handleSendRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan st
 			rpcsLog.Errorf("Failed to process transaction %v: %v",
 				tx.Hash(), err)
 		}
-		return nil, &dcrjson.RPCError{
+
+		// Warn peers and websocket clients when the transaction double
+		// spends a transaction in the memory pool.
+		s.server.announceDoubleSpend(tx, err)
+
+		// The details of the violated rule are included for consensus
+		// rule violations, as are the conflicting outpoints for
+		// transactions which double spend transactions in the pool, so
+		// callers may handle them programmatically.
+		jsonErr := &dcrjson.RPCError{
 			Code:    dcrjson.ErrRPCDeserialization,
 			Message: "TX rejected: " + err.Error(),
 		}
+		if details := ruleErrorDetails(err); details != nil {
+			jsonErr.Data = details
+		} else if details := txConflictDetails(err); details != nil {
+			jsonErr.Data = details
+		}
+		return nil, jsonErr
 	}
 
-	s.server.AnnounceNewTransactions(acceptedTxs)
-
 	// Keep track of all the sendrawtransaction request txns so that they
-	// can be rebroadcast if they don't make their way into a block.
+	// can be rebroadcast if they don't make their way into a block.  This
+	// is done prior to announcing them since it also marks the transaction
+	// as locally submitted which gives it priority when relayed.
 	iv := wire.NewInvVect(wire.InvTypeTx, tx.Hash())
 	s.server.AddRebroadcastInventory(iv, tx)
 
+	s.server.AnnounceNewTransactions(acceptedTxs)
+
 	return tx.Hash().String(), nil
 }
 
+// blockAnnotationRPCError converts the passed error returned when accessing the
+// annotations of a block to the appropriate RPC error.
+func blockAnnotationRPCError(err error) error {
+	switch err.(type) {
+	case blockchain.HashError:
+		return &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	case blockchain.AnnotationError:
+		return &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+
+	context := "Failed to access block annotations"
+	return internalRPCError(err.Error(), context)
+}
+
+// handleSetBlockAnnotation implements the setblockannotation command.
+func handleSetBlockAnnotation(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.SetBlockAnnotationCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	if c.Value == nil {
+		err = s.chain.RemoveBlockAnnotation(hash, c.Key)
+	} else {
+		err = s.chain.SetBlockAnnotation(hash, c.Key, *c.Value)
+	}
+	if err != nil {
+		return nil, blockAnnotationRPCError(err)
+	}
+
+	return nil, nil
+}
+
 // handleSetGenerate implements the setgenerate command.
 func handleSetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*dcrjson.SetGenerateCmd)
@@ -5304,6 +7410,132 @@ func handleSetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return nil, nil
 }
 
+// handleStartProfile implements the startprofile command.
+func handleStartProfile(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.StartProfileCmd)
+
+	duration := *c.Duration
+	if duration == 0 || duration > maxProfileDuration {
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Duration must be between 1 and %d "+
+				"seconds", maxProfileDuration),
+		}
+	}
+
+	var ext string
+	switch c.ProfileType {
+	case "cpu", "heap":
+		ext = "pprof"
+	case "trace":
+		ext = "trace"
+	default:
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Unknown profile type %q -- valid "+
+				"types are cpu, heap, and trace", c.ProfileType),
+		}
+	}
+
+	// Only a single timed capture may be active at once since the runtime
+	// only supports one CPU profile or execution trace at a time.
+	timed := c.ProfileType != "heap"
+	if timed {
+		s.profileMtx.Lock()
+		if s.profileActive {
+			s.profileMtx.Unlock()
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCMisc,
+				Message: "A profile capture is already in progress",
+			}
+		}
+		s.profileActive = true
+		s.profileMtx.Unlock()
+	}
+	clearActive := func() {
+		if timed {
+			s.profileMtx.Lock()
+			s.profileActive = false
+			s.profileMtx.Unlock()
+		}
+	}
+
+	// Profiles are written to a profiles directory under the data
+	// directory and named by type and the time the capture started.
+	profileDir := filepath.Join(cfg.DataDir, "profiles")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		clearActive()
+		context := "Failed to create profile directory"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	fileName := filepath.Join(profileDir, fmt.Sprintf("%s-%s.%s",
+		c.ProfileType, time.Now().Format("20060102-150405"), ext))
+	f, err := os.Create(fileName)
+	if err != nil {
+		clearActive()
+		context := "Failed to create profile file"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// The heap profile is a snapshot, so write it immediately.
+	if c.ProfileType == "heap" {
+		err := pprof.WriteHeapProfile(f)
+		f.Close()
+		if err != nil {
+			context := "Failed to write heap profile"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		rpcsLog.Infof("Wrote heap profile to %s", fileName)
+		return &dcrjson.StartProfileResult{File: fileName}, nil
+	}
+
+	var stop func()
+	switch c.ProfileType {
+	case "cpu":
+		err = pprof.StartCPUProfile(f)
+		stop = pprof.StopCPUProfile
+	case "trace":
+		err = trace.Start(f)
+		stop = trace.Stop
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(fileName)
+		clearActive()
+		return nil, &dcrjson.RPCError{
+			Code: dcrjson.ErrRPCMisc,
+			Message: fmt.Sprintf("Unable to start %s profile: %v",
+				c.ProfileType, err),
+		}
+	}
+
+	// Stop the capture once the requested duration elapses or the server
+	// is shutting down, whichever comes first.
+	rpcsLog.Infof("Capturing %s profile to %s for %d seconds",
+		c.ProfileType, fileName, duration)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		timer := time.NewTimer(time.Duration(duration) * time.Second)
+		select {
+		case <-timer.C:
+		case <-s.quit:
+			timer.Stop()
+		}
+		stop()
+		f.Close()
+		clearActive()
+		rpcsLog.Infof("Finished capturing %s profile to %s",
+			c.ProfileType, fileName)
+	}()
+
+	return &dcrjson.StartProfileResult{
+		File:     fileName,
+		Duration: duration,
+	}, nil
+}
+
 // handleStop implements the stop command.
 func handleStop(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	select {
@@ -5313,6 +7545,94 @@ func handleStop(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return "dcrd stopping.", nil
 }
 
+// ruleErrorDetails returns the details of the consensus rule violation the
+// passed error represents, including when it is wrapped by a memory pool rule
+// error.  Nil is returned when the error is not due to a consensus rule
+// violation.
+func ruleErrorDetails(err error) *dcrjson.RuleErrorDetails {
+	if merr, ok := err.(mempool.RuleError); ok {
+		err = merr.Err
+	}
+	rerr, ok := err.(blockchain.RuleError)
+	if !ok {
+		return nil
+	}
+
+	details := &dcrjson.RuleErrorDetails{
+		Code: int(rerr.ErrorCode),
+		Name: rerr.ErrorCode.String(),
+	}
+	if ctx := rerr.Context; ctx != nil {
+		if ctx.Height >= 0 {
+			height := ctx.Height
+			details.Height = &height
+		}
+		if ctx.TxTree != wire.TxTreeUnknown {
+			txTree := ctx.TxTree
+			details.TxTree = &txTree
+		}
+		if ctx.TxIndex >= 0 {
+			txIndex := ctx.TxIndex
+			details.TxIndex = &txIndex
+		}
+		if ctx.InputIndex >= 0 {
+			inputIndex := ctx.InputIndex
+			details.InputIndex = &inputIndex
+		}
+	}
+	return details
+}
+
+// txConflictsJSON converts the passed memory pool conflicts to their JSON-RPC
+// representation.
+func txConflictsJSON(conflicts []mempool.Conflict) []dcrjson.TxConflict {
+	result := make([]dcrjson.TxConflict, 0, len(conflicts))
+	for i := range conflicts {
+		op := &conflicts[i].OutPoint
+		result = append(result, dcrjson.TxConflict{
+			OutPoint: dcrjson.OutPoint{
+				Hash:  op.Hash.String(),
+				Tree:  op.Tree,
+				Index: op.Index,
+			},
+			TxID: conflicts[i].TxHash.String(),
+		})
+	}
+	return result
+}
+
+// txConflictDetails returns the details of the conflicts with transactions in
+// the memory pool which caused the passed error, or nil when it is not due to
+// conflicts.
+func txConflictDetails(err error) *dcrjson.TxConflictDetails {
+	merr, ok := err.(mempool.RuleError)
+	if !ok {
+		return nil
+	}
+	terr, ok := merr.Err.(mempool.TxRuleError)
+	if !ok || len(terr.Conflicts) == 0 {
+		return nil
+	}
+
+	return &dcrjson.TxConflictDetails{
+		Conflicts: txConflictsJSON(terr.Conflicts),
+	}
+}
+
+// submitBlockReject returns the result of the submitblock command for a block
+// which was rejected for the passed reason due to the passed error, which may
+// be nil.  The reason is returned as is unless the reject details were
+// requested.
+func submitBlockReject(reason string, err error, rejectDetails bool) interface{} {
+	if !rejectDetails {
+		return reason
+	}
+	return &dcrjson.SubmitBlockRejectResult{
+		Reason:  reason,
+		Details: ruleErrorDetails(err),
+	}
+}
+
 // handleSubmitBlock implements the submitblock command.
 func handleSubmitBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*dcrjson.SubmitBlockCmd)
@@ -5335,9 +7655,23 @@ func handleSubmitBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		}
 	}
 
+	// Reject blocks built from stale work with the precise reason so the
+	// caller knows to request new work.
+	rejectDetails := c.Options != nil && c.Options.RejectDetails
+	if reason := staleWorkReason(s.server.blockManager, &block.MsgBlock().Header); reason != "" {
+		rpcsLog.Infof("Rejected block %s via submitblock: %s",
+			block.Hash(), reason)
+		return submitBlockReject(reason, nil, rejectDetails), nil
+	}
+
 	_, err = s.server.blockManager.ProcessBlock(block, blockchain.BFNone)
 	if err != nil {
-		return fmt.Sprintf("rejected: %s", err.Error()), nil
+		if rerr, ok := err.(blockchain.RuleError); ok &&
+			rerr.ErrorCode == blockchain.ErrDuplicateBlock {
+			return submitBlockReject("duplicate", err, rejectDetails), nil
+		}
+		reason := fmt.Sprintf("rejected: %s", err.Error())
+		return submitBlockReject(reason, err, rejectDetails), nil
 	}
 
 	rpcsLog.Infof("Accepted block %s via submitblock", block.Hash())
@@ -5844,16 +8178,30 @@ func handleValidateAddress(s *rpcServer, cmd interface{}, closeChan <-chan struc
 	return result, nil
 }
 
-func verifyChain(s *rpcServer, level, depth int64) error {
+// verifyChain verifies the passed number of blocks at the end of the main chain,
+// or all of them when it is zero, at the passed check level.  The progress of
+// the passed job is updated as the blocks are verified and the verification
+// stops early when the job is cancelled.  The job is nil when the verification
+// is not run as a job.
+func verifyChain(s *rpcServer, level, depth int64, job *rpcJob) error {
 	best := s.chain.BestSnapshot()
 	finishHeight := best.Height - depth
 	if finishHeight < 0 {
 		finishHeight = 0
 	}
-	rpcsLog.Infof("Verifying chain for %d blocks at level %d",
-		best.Height-finishHeight, level)
+	numBlocks := best.Height - finishHeight
+	rpcsLog.Infof("Verifying chain for %d blocks at level %d", numBlocks,
+		level)
 
 	for height := best.Height; height > finishHeight; height-- {
+		if job != nil {
+			if interruptRequested(job.interrupt) {
+				rpcsLog.Infof("Chain verify interrupted")
+				return errors.New("chain verify interrupted")
+			}
+			job.setProgress(best.Height-height, numBlocks)
+		}
+
 		// Level 0 just looks up the block.
 		block, err := s.chain.BlockByHeight(height)
 		if err != nil {
@@ -5875,6 +8223,9 @@ func verifyChain(s *rpcServer, level, depth int64) error {
 			}
 		}
 	}
+	if job != nil {
+		job.setProgress(numBlocks, numBlocks)
+	}
 	rpcsLog.Infof("Chain verify completed successfully")
 
 	return nil
@@ -5892,7 +8243,18 @@ func handleVerifyChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		checkDepth = *c.CheckDepth
 	}
 
-	err := verifyChain(s, checkLevel, checkDepth)
+	if c.Async != nil && *c.Async {
+		interrupt := make(chan struct{})
+		return startJob(s, "verifychain", interrupt, func(job *rpcJob) (interface{}, error) {
+			err := verifyChain(s, checkLevel, checkDepth, job)
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		})
+	}
+
+	err := verifyChain(s, checkLevel, checkDepth, nil)
 	return err == nil, nil
 }
 
@@ -5960,6 +8322,72 @@ func handleVerifyMessage(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	return address.EncodeAddress() == c.Address, nil
 }
 
+// rpcNotificationTypes houses the websocket notifications the server is able
+// to send to clients.  They are advertised by the version command so clients
+// can detect which notifications are supported.
+var rpcNotificationTypes = []string{
+	dcrjson.BlockConnectedNtfnMethod,
+	dcrjson.BlockDisconnectedNtfnMethod,
+	dcrjson.ReorganizationNtfnMethod,
+	dcrjson.TxAcceptedNtfnMethod,
+	dcrjson.TxAcceptedVerboseNtfnMethod,
+	dcrjson.RelevantTxAcceptedNtfnMethod,
+	dcrjson.TxConflictNtfnMethod,
+	dcrjson.DoubleSpendProofNtfnMethod,
+	dcrjson.SideChainBlockConnectedNtfnMethod,
+	dcrjson.WorkExpiredNtfnMethod,
+	dcrjson.WinningTicketsNtfnMethod,
+	dcrjson.SpentAndMissedTicketsNtfnMethod,
+	dcrjson.NewTicketsNtfnMethod,
+	dcrjson.StakeDifficultyNtfnMethod,
+	dcrjson.StakeDifficultyChangedNtfnMethod,
+	dcrjson.JobCompletedNtfnMethod,
+	dcrjson.StakeBoundaryNtfnMethod,
+}
+
+// rpcIndexNames houses the names of the options which enable the optional
+// indexes in the order the version command advertises them.
+var rpcIndexNames = []string{
+	"txindex",
+	"addrindex",
+	"existsaddrindex",
+	"windowaggindex",
+	"spendindex",
+	"balanceindex",
+}
+
+// rpcEnabledIndexes returns the names of the optional indexes which are enabled
+// and therefore make the RPCs that rely on them available.
+func rpcEnabledIndexes(s *rpcServer) []string {
+	enabled := enabledIndexes(s)
+	var indexes []string
+	for _, name := range rpcIndexNames {
+		if _, ok := enabled[name]; ok {
+			indexes = append(indexes, name)
+		}
+	}
+	return indexes
+}
+
+// rpcEnabledFeatures returns the names of the optional features of the server
+// which are enabled.
+func rpcEnabledFeatures() []string {
+	features := []string{"websocket"}
+	if !cfg.DisableTLS {
+		features = append(features, "tls")
+	}
+	if len(cfg.miningAddrs) > 0 {
+		features = append(features, "mining")
+	}
+	return features
+}
+
+// handleVerifyIndex implements the verifyindex command.
+func handleVerifyIndex(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.VerifyIndexCmd)
+	return maintainIndex(s, "verifyindex", indexers.IndexOpVerify, c.Index)
+}
+
 // handleVersion implements the version command.
 func handleVersion(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	result := map[string]dcrjson.VersionResult{
@@ -5968,11 +8396,40 @@ func handleVersion(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (in
 			Major:         jsonrpcSemverMajor,
 			Minor:         jsonrpcSemverMinor,
 			Patch:         jsonrpcSemverPatch,
+			Notifications: rpcNotificationTypes,
+			Indexes:       rpcEnabledIndexes(s),
+			Features:      rpcEnabledFeatures(),
+		},
+		"dcrd": {
+			VersionString: version(),
+			Major:         uint32(appMajor),
+			Minor:         uint32(appMinor),
+			Patch:         uint32(appPatch),
+			Prerelease:    normalizeVerString(appPreRelease),
+			BuildMetadata: normalizeVerString(appBuild),
 		},
 	}
 	return result, nil
 }
 
+// handleWarpClock implements the warpclock command.
+func handleWarpClock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*dcrjson.WarpClockCmd)
+
+	// The clock may only be warped on the simulation test network.
+	if err := s.checkSimNet("warpclock"); err != nil {
+		return nil, err
+	}
+
+	offset := s.server.warpClock.Warp(time.Duration(c.Seconds) * time.Second)
+	rpcsLog.Infof("Warped clock by %v to an offset of %v",
+		time.Duration(c.Seconds)*time.Second, offset)
+	return &dcrjson.WarpClockResult{
+		Offset: int64(offset / time.Second),
+		Time:   s.server.clock.Now().Unix(),
+	}, nil
+}
+
 // rpcServer holds the items the rpc server may need to access (config,
 // shutdown, main server, etc.)
 type rpcServer struct {
@@ -5993,6 +8450,7 @@ type rpcServer struct {
 	gbtWorkState           *gbtWorkState
 	templatePool           map[[merkleRootPairSize]byte]*workStateBlockInfo
 	helpCacher             *helpCacher
+	blockJSONCache         *blockJSONCache
 	requestProcessShutdown chan struct{}
 	quit                   chan int
 
@@ -6000,6 +8458,23 @@ type rpcServer struct {
 	coinSupplyMtx    sync.Mutex
 	coinSupplyHeight int64
 	coinSupplyTotal  int64
+
+	// profileActive tracks whether a timed profile capture started via the
+	// startprofile command is in progress.
+	profileMtx    sync.Mutex
+	profileActive bool
+
+	// missedTickets records diagnostic details about missed tickets when
+	// enabled via the --trackmissedtickets option.  It is nil otherwise.
+	missedTickets *missedTicketTracker
+
+	// auditor writes an entry for each request to the RPC audit log when
+	// enabled via the --rpcauditlog option.  It is nil otherwise.
+	auditor *rpcAuditor
+
+	// jobs runs the long-running operations started by commands such as
+	// verifychain and rebuildindex in the background.
+	jobs *rpcJobManager
 }
 
 // httpStatusLine returns a response Status-Line (RFC 2616 Section 6.1)
@@ -6071,10 +8546,14 @@ func (s *rpcServer) Stop() error {
 			return err
 		}
 	}
+	s.jobs.Stop()
 	s.ntfnMgr.Shutdown()
 	s.ntfnMgr.WaitForShutdown()
 	close(s.quit)
 	s.wg.Wait()
+	if s.auditor != nil {
+		s.auditor.Close()
+	}
 	rpcsLog.Infof("RPC server shutdown complete")
 	return nil
 }
@@ -6169,6 +8648,7 @@ func (s *rpcServer) checkAuth(r *http.Request, require bool) (bool, bool, error)
 type parsedRPCCmd struct {
 	id     interface{}
 	method string
+	params []json.RawMessage
 	cmd    interface{}
 	err    *dcrjson.RPCError
 }
@@ -6206,6 +8686,7 @@ func parseCmd(request *dcrjson.Request) *parsedRPCCmd {
 	var parsedCmd parsedRPCCmd
 	parsedCmd.id = request.ID
 	parsedCmd.method = request.Method
+	parsedCmd.params = request.Params
 
 	cmd, err := dcrjson.UnmarshalCmd(request)
 	if err != nil {
@@ -6250,6 +8731,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 	if atomic.LoadInt32(&s.shutdown) != 0 {
 		return
 	}
+	start := time.Now()
 
 	// Read and close the JSON-RPC request body from the caller.
 	body, err := ioutil.ReadAll(r.Body)
@@ -6349,6 +8831,11 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 		rpcsLog.Errorf("Failed to marshal reply: %v", err)
 		return
 	}
+	if s.auditor != nil {
+		entry := newRPCAuditEntry(start, rpcAuditTransportHTTP,
+			r.RemoteAddr, isAdmin, request.Method, request.Params)
+		s.auditor.write(entry, msg, jsonErr)
+	}
 
 	// Write the response.
 	err = s.writeHTTPResponseHeaders(r, w.Header(), http.StatusOK, buf)
@@ -6361,6 +8848,19 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 	}
 }
 
+// auditAuthFailure writes an entry to the RPC audit log for the passed HTTP
+// request received over the passed transport whose credentials were rejected.
+// It does nothing when the RPC audit log is not enabled.
+func (s *rpcServer) auditAuthFailure(transport string, r *http.Request) {
+	if s.auditor == nil {
+		return
+	}
+	user, _, _ := r.BasicAuth()
+	entry := newRPCAuthFailureEntry(time.Now(), transport, r.RemoteAddr,
+		user, "")
+	s.auditor.write(entry, nil, nil)
+}
+
 // jsonAuthFail sends a message back to the client if the http auth is rejected.
 func jsonAuthFail(w http.ResponseWriter) {
 	w.Header().Add("WWW-Authenticate", `Basic realm="dcrd RPC"`)
@@ -6383,6 +8883,12 @@ func (s *rpcServer) Start() {
 		ReadTimeout: time.Second * rpcAuthTimeoutSeconds,
 	}
 	rpcServeMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// The http server recovers panics in handlers and merely logs
+		// them, which would leave whatever the handler was doing, such
+		// as processing a transaction in the memory pool, half done
+		// with its locks held.  Treat them as crashes instead.
+		defer recoverCrash("RPCS", s.server.blockManager.chain)
+
 		w.Header().Set("Connection", "close")
 		w.Header().Set("Content-Type", "application/json")
 		r.Close = true
@@ -6397,6 +8903,7 @@ func (s *rpcServer) Start() {
 		defer s.decrementClients()
 		_, isAdmin, err := s.checkAuth(r, true)
 		if err != nil {
+			s.auditAuthFailure(rpcAuditTransportHTTP, r)
 			jsonAuthFail(w)
 			return
 		}
@@ -6407,8 +8914,11 @@ func (s *rpcServer) Start() {
 
 	// Websocket endpoint.
 	rpcServeMux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		defer recoverCrash("RPCS", s.server.blockManager.chain)
+
 		authenticated, isAdmin, err := s.checkAuth(r, false)
 		if err != nil {
+			s.auditAuthFailure(rpcAuditTransportWebsocket, r)
 			jsonAuthFail(w)
 			return
 		}
@@ -6427,6 +8937,12 @@ func (s *rpcServer) Start() {
 		s.WebsocketHandler(ws, r.RemoteAddr, authenticated, isAdmin)
 	})
 
+	// Health and readiness endpoints for use by load balancers and
+	// orchestration platforms which only report the details of the checks
+	// to authenticated clients.
+	rpcServeMux.HandleFunc("/health", s.handleHealth(s.liveChecks))
+	rpcServeMux.HandleFunc("/ready", s.handleHealth(s.readyChecks))
+
 	for _, listener := range s.listeners {
 		s.wg.Add(1)
 		go func(listener net.Listener) {
@@ -6476,6 +8992,7 @@ func newRPCServer(listenAddrs []string, policy *mining.Policy, s *server) (*rpcS
 		templatePool:           make(map[[merkleRootPairSize]byte]*workStateBlockInfo),
 		gbtWorkState:           newGbtWorkState(s.timeSource),
 		helpCacher:             newHelpCacher(),
+		blockJSONCache:         newBlockJSONCache(),
 		requestProcessShutdown: make(chan struct{}),
 		quit: make(chan int),
 	}
@@ -6490,6 +9007,19 @@ func newRPCServer(listenAddrs []string, policy *mining.Policy, s *server) (*rpcS
 		rpc.limitauthsha = sha256.Sum256([]byte(auth))
 	}
 	rpc.ntfnMgr = newWsNotificationManager(&rpc)
+	rpc.jobs = newRPCJobManager(rpc.ntfnMgr.NotifyJobCompleted)
+	if cfg.TrackMissedTickets {
+		rpc.missedTickets = newMissedTicketTracker(
+			&rpc.ntfnMgr.votingWalletStats)
+	}
+	if cfg.RPCAuditLog != "" {
+		auditor, err := newRPCAuditor(cfg.RPCAuditLog,
+			int64(cfg.RPCAuditMaxSize)*1024*1024, cfg.RPCAuditMaxRolls)
+		if err != nil {
+			return nil, err
+		}
+		rpc.auditor = auditor
+	}
 
 	// Setup TLS if not disabled.
 	listenFunc := net.Listen
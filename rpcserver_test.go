@@ -0,0 +1,37 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/indexers"
+)
+
+// TestRPCEnabledIndexes ensures the version command advertises every enabled
+// optional index which the index maintenance RPCs accept.
+func TestRPCEnabledIndexes(t *testing.T) {
+	s := &rpcServer{server: &server{}}
+	if indexes := rpcEnabledIndexes(s); len(indexes) != 0 {
+		t.Fatalf("unexpected indexes with none enabled: %v", indexes)
+	}
+
+	s.server.txIndex = &indexers.TxIndex{}
+	s.server.addrIndex = &indexers.AddrIndex{}
+	s.server.existsAddrIndex = &indexers.ExistsAddrIndex{}
+	s.server.windowAggIndex = &indexers.WindowAggIndex{}
+	s.server.spendIndex = &indexers.SpendIndex{}
+	s.server.balanceIndex = &indexers.BalanceIndex{}
+	indexes := rpcEnabledIndexes(s)
+	if len(indexes) != len(enabledIndexes(s)) {
+		t.Fatalf("advertised indexes %v do not match the enabled "+
+			"indexes %v", indexes, enabledIndexes(s))
+	}
+	if !reflect.DeepEqual(indexes, rpcIndexNames) {
+		t.Fatalf("unexpected indexes - got %v, want %v", indexes,
+			rpcIndexNames)
+	}
+}
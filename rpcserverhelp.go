@@ -34,6 +34,11 @@ var helpDescsEnUS = map[string]string{
 	"addnode-addr":      "IP address and port of the peer to operate on",
 	"addnode-subcmd":    "'add' to add a persistent peer, 'remove' to remove a persistent peer, or 'onetry' to try a single connection to a peer",
 
+	// AdvanceChainCmd help.
+	"advancechain--synopsis": "Mines a set number of blocks with the CPU miner after casting votes and purchasing tickets with a key held by the server, and returns a JSON array of their hashes.  The chain may only be advanced on the simulation test network.",
+	"advancechain-numblocks": "Number of blocks to mine",
+	"advancechain--result0":  "The hashes, in order, of the blocks mined by the call",
+
 	// NodeCmd help.
 	"node--synopsis":     "Attempts to add or remove a peer.",
 	"node-subcmd":        "'disconnect' to remove all matching non-persistent peers, 'remove' to remove a persistent peer, or 'connect' to connect to a peer",
@@ -81,6 +86,27 @@ var helpDescsEnUS = map[string]string{
 	"createrawssrtx-inputs":   "The inputs to the transaction of type sstxinput",
 	"createrawssrtx-fee":      "The fee to apply to the revocation in Coins",
 
+	// CompareChainWorkCmd help.
+	"comparechainwork--synopsis": "Compares the total work of the chains ending with the two given blocks, which need not be part of the main chain.",
+	"comparechainwork-hash1":     "The hash of the first block",
+	"comparechainwork-hash2":     "The hash of the second block",
+
+	// CompareChainWorkResult help.
+	"comparechainworkresult-chainwork1": "The total number of hashes expected to produce the chain ending with the first block in hex",
+	"comparechainworkresult-chainwork2": "The total number of hashes expected to produce the chain ending with the second block in hex",
+	"comparechainworkresult-difference": "The chain work of the first block less the chain work of the second block in hex (prefixed with a minus sign when negative)",
+	"comparechainworkresult-comparison": "-1, 0, or 1 when the chain work of the first block is less than, equal to, or greater than that of the second block",
+
+	// CreditOutputCmd help.
+	"creditoutput--synopsis": "Directly adds an output paying the given amount to the given address to the utxo set as output 0 of a transaction which is not included in any block.  Outputs may only be credited on the simulation test network.",
+	"creditoutput-address":   "The address to pay",
+	"creditoutput-amount":    "The amount to pay in DCR",
+
+	// CreditOutputResult help.
+	"creditoutputresult-txid":        "The hash of the transaction the output was credited as part of",
+	"creditoutputresult-blockheight": "The block height to provide in the fraud proof of the input which spends the output",
+	"creditoutputresult-blockindex":  "The block index to provide in the fraud proof of the input which spends the output",
+
 	// CreateRawTransactionCmd help.
 	"createrawtransaction--synopsis": "Returns a new transaction spending the provided inputs and sending to the provided addresses.\n" +
 		"The transaction inputs are not signed in the created transaction.\n" +
@@ -161,6 +187,87 @@ var helpDescsEnUS = map[string]string{
 	"decodescript--synopsis": "Returns a JSON object with information about the provided hex-encoded script.",
 	"decodescript-hexscript": "Hex-encoded script",
 
+	// AddrManAddress help.
+	"addrmanaddress-addr":        "The address in the form of ip:port, [ip]:port, or onion:port",
+	"addrmanaddress-src":         "The address of the peer the address was learned from",
+	"addrmanaddress-services":    "The services the address was last known to support",
+	"addrmanaddress-attempts":    "The number of failed connection attempts since the last successful connection",
+	"addrmanaddress-timestamp":   "The unix time the address was last seen",
+	"addrmanaddress-lastattempt": "The unix time a connection to the address was last attempted",
+	"addrmanaddress-lastsuccess": "The unix time a connection to the address last succeeded",
+	"addrmanaddress-tried":       "Whether or not the address was in the tried set",
+
+	// AddrManDump help.
+	"addrmandump-version":   "The version of the portable address manager format",
+	"addrmandump-addresses": "The known addresses",
+
+	// DropIndexCmd help.
+	"dropindex--synopsis": "Starts deleting an optional index in the background while the chain keeps running.  The index is no longer updated or queried once the drop has started and is created again on the next start while it is enabled.\n" +
+		"Dropping the transaction index also drops the address and balance indexes which depend on it.  The progress is reported by getindexinfo and by getjob for the returned job.",
+	"dropindex-index": "The name of the option which enables the index: txindex, addrindex, existsaddrindex, windowaggindex, spendindex, or balanceindex",
+
+	// DumpAddrManCmd help.
+	"dumpaddrman--synopsis": "Returns all addresses known to the address manager in a portable format that does not depend on the address manager internals.\n" +
+		"The result may be saved to a file to seed other nodes via importaddrman or to analyze the network.",
+
+	// ImportAddrManCmd help.
+	"importaddrman--synopsis": "Adds the addresses in the portable format returned by dumpaddrman to the address manager.\n" +
+		"Imported addresses are treated as newly learned addresses regardless of whether or not they were tried by the node that exported them.\n" +
+		"The import fails without adding any addresses when any of them are invalid.",
+	"importaddrman-addrman":       "The addresses to import in the format returned by dumpaddrman",
+	"importaddrmanresult-added":   "The number of addresses that were added",
+	"importaddrmanresult-skipped": "The number of addresses that were skipped because they were already known or are not routable",
+
+	// UtxoSnapshotResult help.
+	"utxosnapshotresult-file":        "The path of the snapshot file",
+	"utxosnapshotresult-hash":        "The hash of the block the snapshot is for",
+	"utxosnapshotresult-height":      "The height of the block the snapshot is for",
+	"utxosnapshotresult-numutxos":    "The number of unspent transaction outputs in the snapshot",
+	"utxosnapshotresult-numtickets":  "The number of live tickets in the snapshot",
+	"utxosnapshotresult-utxosethash": "The hex-encoded hash of the unspent transaction outputs in the format of the experimental utxo set commitments",
+	"utxosnapshotresult-checksum":    "The hex-encoded sha256 checksum of the snapshot file",
+
+	// DumpUtxoSetCmd help.
+	"dumputxoset--synopsis": "Writes a snapshot of the unspent transaction output set and live ticket pool as of the main chain block at the requested height to the snapshots directory under the data directory.\n" +
+		"Blocks are only held back while the state is rolled back to the block, the outputs are then read from a consistent database snapshot while blocks continue to be processed.",
+	"dumputxoset-height":      "The height of the main chain block to create the snapshot for (default: the current best block)",
+	"dumputxoset-async":       "Run the command as a job which is queried with getjob instead of waiting for the snapshot to be written",
+	"dumputxoset--condition0": "async=false",
+	"dumputxoset--condition1": "async=true",
+
+	// LoadUtxoSetCmd help.
+	"loadutxoset--synopsis": "Reads a snapshot written by dumputxoset and verifies its checksum and that its contents match the unspent transaction output set and live ticket pool of the main chain block it is for.\n" +
+		"The snapshot is only verified, it does not replace the chain state of the node.  Snapshots are not signed and the checksum only detects corruption, so bootstrapping a node from a snapshot is not supported.",
+	"loadutxoset-file":        "The path of the snapshot, which is relative to the snapshots directory under the data directory unless it is absolute",
+	"loadutxoset-async":       "Run the command as a job which is queried with getjob instead of waiting for the snapshot to be verified",
+	"loadutxoset--condition0": "async=false",
+	"loadutxoset--condition1": "async=true",
+
+	// CancelJobCmd help.
+	"canceljob--synopsis": "Requests a job started by a long-running command to stop.  The job is reported as running until it actually stopped and as cancelled afterwards.\n" +
+		"Jobs for dumputxoset and loadutxoset can not be cancelled.  Cancelled index drops are resumed and cancelled index rebuilds are caught up on the next start.",
+	"canceljob-jobid": "The id of the job",
+
+	// GetJobCmd help.
+	"getjob--synopsis": "Returns the state and progress of a job started by a long-running command along with its result once it completed.\n" +
+		"Finished jobs are retained until 100 more recent jobs finished.",
+	"getjob-jobid": "The id of the job",
+
+	// ListJobsCmd help.
+	"listjobs--synopsis": "Returns the state and progress of all running jobs and the most recently finished jobs in the order they were started.",
+
+	// JobResult help.
+	"jobresult-jobid":       "The id of the job",
+	"jobresult-method":      "The command which started the job",
+	"jobresult-state":       "The state of the job (running, completed, failed, or cancelled)",
+	"jobresult-cancellable": "Whether or not the job can be cancelled with canceljob",
+	"jobresult-progress":    "The amount of the work of the job which is done, such as blocks or deleted index keys depending on the command",
+	"jobresult-total":       "The total amount of work of the job, or 0 when it is not known",
+	"jobresult-starttime":   "The unix time the job was started",
+	"jobresult-endtime":     "The unix time the job finished, omitted while it is running",
+	"jobresult-error":       "The reason the job failed or was cancelled, omitted otherwise",
+	"jobresult-result":      "The result the command returns when not run as a job, only set once the job completed",
+
 	// ExistsAddressCmd help.
 	"existsaddress--synopsis": "Test for the existance of the provided address",
 	"existsaddress-address":   "The address to check",
@@ -191,6 +298,10 @@ var helpDescsEnUS = map[string]string{
 	"existsmempooltxs-txhashblob": "Blob containing the hashes to check",
 	"existsmempooltxs--result0":   "Bool blob showing if txs exist in the mempool or not",
 
+	// ForceStakeDifficultyCmd help.
+	"forcestakedifficulty--synopsis":       "Overrides the calculated stake difficulty of all blocks with the given value.  The stake difficulty may only be forced on the simulation test network.",
+	"forcestakedifficulty-stakedifficulty": "The stake difficulty to force in DCR, or 0 to use the calculated stake difficulty again",
+
 	// GenerateCmd help
 	"generate--synopsis": "Generates a set number of blocks (simnet or regtest only) and returns a JSON\n" +
 		" array of their hashes.",
@@ -272,9 +383,11 @@ var helpDescsEnUS = map[string]string{
 	"getblockverboseresult-tx":                "The transaction hashes (only when verbosetx=false)",
 	"getblockverboseresult-rawtx":             "The transactions as JSON objects (only when verbosetx=true)",
 	"getblockverboseresult-time":              "The block time in seconds since 1 Jan 1970 GMT",
+	"getblockverboseresult-mediantime":        "The median block time of the block and up to ten preceding blocks in seconds since 1 Jan 1970 GMT",
 	"getblockverboseresult-nonce":             "The block nonce",
 	"getblockverboseresult-bits":              "The bits which represent the block difficulty",
 	"getblockverboseresult-difficulty":        "The proof-of-work difficulty as a multiple of the minimum difficulty",
+	"getblockverboseresult-chainwork":         "The total number of hashes expected to produce the chain up to and including the block in hex",
 	"getblockverboseresult-previousblockhash": "The hash of the previous block",
 	"getblockverboseresult-nextblockhash":     "The hash of the next block (only if there is one)",
 	"getblockverboseresult-sbits":             "The stake difficulty of theblock",
@@ -293,6 +406,39 @@ var helpDescsEnUS = map[string]string{
 	"getblockverboseresult-extradata":         "Extra data field for the requested block",
 	"getblockverboseresult-stakeversion":      "Stake Version of the block",
 
+	// GetBlockAnnotationsCmd help.
+	"getblockannotations--synopsis": "Returns the annotations attached to a block ordered by their keys.  The block does not need to be part of the main chain.",
+	"getblockannotations-hash":      "The hash of the block",
+	"getblockannotations--result0":  "The annotations of the block",
+
+	// BlockAnnotationResult help.
+	"blockannotationresult-key":   "The key of the annotation",
+	"blockannotationresult-value": "The value of the annotation",
+
+	// GetBlockByMedianTimeCmd help.
+	"getblockbymediantime--synopsis": "Returns the most recent block in the main chain with a median time at or before the given time, which is the tip of the main chain as of that time according to median time past semantics.",
+	"getblockbymediantime-time":      "The time in seconds since 1 Jan 1970 GMT",
+
+	// GetBlockByMedianTimeResult help.
+	"getblockbymediantimeresult-hash":       "The hash of the block",
+	"getblockbymediantimeresult-height":     "The height of the block",
+	"getblockbymediantimeresult-time":       "The block time in seconds since 1 Jan 1970 GMT",
+	"getblockbymediantimeresult-mediantime": "The median block time of the block and up to ten preceding blocks in seconds since 1 Jan 1970 GMT",
+
+	// GetBlockChainInfoCmd help.
+	"getblockchaininfo--synopsis": "Returns information about the current state of the block chain.",
+
+	// GetBlockChainInfoResult help.
+	"getblockchaininforesult-chain":                "The name of the network the chain is for",
+	"getblockchaininforesult-blocks":               "The height of the best block",
+	"getblockchaininforesult-headers":              "The height of the best known block header",
+	"getblockchaininforesult-bestblockhash":        "The hash of the best block",
+	"getblockchaininforesult-difficulty":           "The proof-of-work difficulty of the best block as a multiple of the minimum difficulty",
+	"getblockchaininforesult-verificationprogress": "An estimate of the verification progress from 0 to 1 based on the timestamp of the best block",
+	"getblockchaininforesult-chainwork":            "The total number of hashes expected to produce the best chain in hex",
+	"getblockchaininforesult-consensusscriptflags": "The script verification flags enforced by the consensus rules for the next block",
+	"getblockchaininforesult-standardscriptflags":  "The script verification flags required for transactions to be accepted into the memory pool and block templates",
+
 	// GetBlockCountCmd help.
 	"getblockcount--synopsis": "Returns the number of blocks in the longest block chain.",
 	"getblockcount--result0":  "The current block count",
@@ -302,6 +448,11 @@ var helpDescsEnUS = map[string]string{
 	"getblockhash-index":     "The block height",
 	"getblockhash--result0":  "The block hash",
 
+	// GetBlockHashByTimeCmd help.
+	"getblockhashbytime--synopsis": "Returns the hash of the most recent block in the main chain with a median time at or before the given time.",
+	"getblockhashbytime-time":      "The time in seconds since 1 Jan 1970 GMT",
+	"getblockhashbytime--result0":  "The hash of the block",
+
 	// GetBlockHeaderCmd help.
 	"getblockheader--synopsis":   "Returns information about a block header given its hash.",
 	"getblockheader-hash":        "The hash of the block",
@@ -317,9 +468,11 @@ var helpDescsEnUS = map[string]string{
 	"getblockheaderverboseresult-version":           "The block version",
 	"getblockheaderverboseresult-merkleroot":        "The merkle root of the regular transaction tree",
 	"getblockheaderverboseresult-time":              "The block time in seconds since 1 Jan 1970 GMT",
+	"getblockheaderverboseresult-mediantime":        "The median block time of the block and up to ten preceding blocks in seconds since 1 Jan 1970 GMT",
 	"getblockheaderverboseresult-nonce":             "The block nonce",
 	"getblockheaderverboseresult-bits":              "The bits which represent the block difficulty",
 	"getblockheaderverboseresult-difficulty":        "The proof-of-work difficulty as a multiple of the minimum difficulty",
+	"getblockheaderverboseresult-chainwork":         "The total number of hashes expected to produce the chain up to and including the block in hex",
 	"getblockheaderverboseresult-previousblockhash": "The hash of the previous block",
 	"getblockheaderverboseresult-nextblockhash":     "The hash of the next block (only if there is one)",
 	"getblockheaderverboseresult-size":              "The size of the block in bytes",
@@ -343,6 +496,7 @@ var helpDescsEnUS = map[string]string{
 	"templaterequest-target":       "The desired target for the block template (this parameter is ignored)",
 	"templaterequest-data":         "Hex-encoded block data (only for mode=proposal)",
 	"templaterequest-workid":       "The server provided workid if provided in block template (not applicable)",
+	"templaterequest-minvotes":     "Minimum number of votes the previous block must have in order to build on it without waiting for the remaining votes; it must be at least the majority of the votes per block (default: all of the votes until the configured vote wait expires)",
 
 	// GetBlockTemplateResultTx help.
 	"getblocktemplateresulttx-data":    "Hex-encoded transaction data (byte-for-byte)",
@@ -381,6 +535,9 @@ var helpDescsEnUS = map[string]string{
 	"getblocktemplateresult-reject-reason":     "Reason the proposal was invalid as-is (only applies to proposal responses)",
 	"getblocktemplateresult-stransactions":     "Stake transactions",
 	"getblocktemplateresult-header":            "Block header",
+	"getblocktemplateresult-voters":            "Number of votes included in the block template",
+	"getblocktemplateresult-minvotes":          "Minimum number of votes the previous block was required to have to build on it",
+	"getblocktemplateresult-votewaitexpires":   "Time at which the server stops waiting for all of the votes on the previous block and generates block templates with fewer votes (only while waiting)",
 
 	// GetBlockTemplateCmd help.
 	"getblocktemplate--synopsis": "Returns a JSON object with information necessary to construct a block to mine or accepts a proposal to validate.\n" +
@@ -403,6 +560,15 @@ var helpDescsEnUS = map[string]string{
 	"getdifficulty--synopsis": "Returns the proof-of-work difficulty as a multiple of the minimum difficulty.",
 	"getdifficulty--result0":  "The difficulty",
 
+	// GetFinalityCmd help.
+	"getfinality--synopsis": "Returns the block finality enforced by the consensus rules.  Blocks at and before the finalized block may no longer be disconnected by a reorganize once an agenda which introduces a finality depth is active.",
+
+	// GetFinalityResult help.
+	"getfinalityresult-active": "Whether or not a finality depth is enforced for the current best chain",
+	"getfinalityresult-depth":  "The number of blocks which must be built on a block before it is final, or 0 if none is enforced",
+	"getfinalityresult-height": "The height of the most recent final block, which is the genesis block when no finality depth is enforced",
+	"getfinalityresult-hash":   "The hash of the most recent final block",
+
 	// GetStakeDifficultyCmd help.
 	"getstakedifficulty--synopsis":     "Returns the proof-of-stake difficulty.",
 	"getstakedifficultyresult-current": "The current top block's stake difficulty",
@@ -461,6 +627,16 @@ var helpDescsEnUS = map[string]string{
 	"choice-count":                    "How many votes received.",
 	"choice-progress":                 "Progress of the overall count.",
 
+	// GetVotingWalletStatsCmd help.
+	"getvotingwalletstats--synopsis":             "Returns notification delivery metrics for websocket clients registered as voting wallets.",
+	"getvotingwalletstatsresult-clients":         "The number of connected voting wallets",
+	"getvotingwalletstatsresult-pending":         "The number of notifications awaiting acknowledgement",
+	"getvotingwalletstatsresult-delivered":       "The number of notifications that were acknowledged",
+	"getvotingwalletstatsresult-retransmissions": "The number of times notifications were retransmitted",
+	"getvotingwalletstatsresult-expired":         "The number of notifications that were not acknowledged before the delivery deadline or the client disconnecting",
+	"getvotingwalletstatsresult-avglatency":      "The average time between first sending a notification and its acknowledgement in milliseconds",
+	"getvotingwalletstatsresult-maxlatency":      "The maximum time between first sending a notification and its acknowledgement in milliseconds",
+
 	// GetGenerateCmd help.
 	"getgenerate--synopsis": "Returns if the server is set to generate coins (mine) or not.",
 	"getgenerate--result0":  "True if mining, false if not",
@@ -500,20 +676,87 @@ var helpDescsEnUS = map[string]string{
 	"infowalletresult-errors":          "Any current errors",
 
 	// GetHeadersCmd help.
-	"getheaders--synopsis":     "Returns block headers starting with the first known block hash from the request",
-	"getheaders-blocklocators": "Concatenated hashes of blocks.  Headers are returned starting from the first known hash in this list",
-	"getheaders-hashstop":      "Optional block hash to stop including block headers for",
+	"getheaders--synopsis":     "Returns block headers starting with the first known block hash from the request, with the same semantics as the getheaders wire message",
+	"getheaders-blocklocators": "Concatenated hashes of blocks in their serialized byte order, which is the reverse of their usual hex encoding.  Headers are returned starting from the block after the first hash in this list which is in the main chain, or from the block after the genesis block when none are (max 500 hashes)",
+	"getheaders-hashstop":      "Optional hash of the last block to include the header of.  Only its header is returned when no block locators are given",
 	"getheadersresult-headers": "Serialized block headers of all located blocks, limited to some arbitrary maximum number of hashes (currently 2000, which matches the wire protocol headers message, but this is not guaranteed)",
 
+	// GetIndexInfoCmd help.
+	"getindexinfo--synopsis": "Returns the state of each enabled optional index along with the progress of its most recent maintenance operation.",
+
+	// IndexInfoResult help.
+	"indexinforesult-name":         "The name of the option which enables the index",
+	"indexinforesult-active":       "Whether or not the index is updated as blocks are connected and disconnected",
+	"indexinforesult-operation":    "The most recent maintenance operation of the index (drop, rebuild, or verify), omitted when none was started",
+	"indexinforesult-running":      "Whether or not the operation is still in progress",
+	"indexinforesult-height":       "The height of the most recent block the rebuild or verification processed",
+	"indexinforesult-targetheight": "The height the rebuild or verification is working towards",
+	"indexinforesult-deletedkeys":  "The number of keys the drop or rebuild deleted from the index",
+	"indexinforesult-error":        "The error the operation failed with, omitted when it did not fail",
+
 	// GetInfoCmd help.
 	"getinfo--synopsis": "Returns a JSON object containing various state info.",
 
+	// GetMempoolAncestorsCmd help.
+	"getmempoolancestors--synopsis":   "Returns the hashes of all transactions in the memory pool which the transaction depends on, either directly or through other transactions in the memory pool, ordered so that every transaction follows all of its ancestors.",
+	"getmempoolancestors-txid":        "The hash of the transaction in the memory pool",
+	"getmempoolancestors-verbose":     "Returns the details of the ancestors when true or their hashes when false",
+	"getmempoolancestors--condition0": "verbose=false",
+	"getmempoolancestors--condition1": "verbose=true",
+	"getmempoolancestors--result0":    "The hashes of the ancestors of the transaction",
+
+	// GetMempoolDescendantsCmd help.
+	"getmempooldescendants--synopsis":   "Returns the hashes of all transactions in the memory pool which depend on the transaction, either directly or through other transactions in the memory pool, ordered so that every transaction follows all of its ancestors among them.",
+	"getmempooldescendants-txid":        "The hash of the transaction in the memory pool",
+	"getmempooldescendants-verbose":     "Returns the details of the descendants when true or their hashes when false",
+	"getmempooldescendants--condition0": "verbose=false",
+	"getmempooldescendants--condition1": "verbose=true",
+	"getmempooldescendants--result0":    "The hashes of the descendants of the transaction",
+
+	// GetMempoolEntryCmd help.
+	"getmempoolentry--synopsis": "Returns the details of a transaction in the memory pool along with the number, combined size, and combined fees of its ancestors and descendants in the memory pool.",
+	"getmempoolentry-txid":      "The hash of the transaction in the memory pool",
+
+	// GetMempoolEntryResult help.
+	"getmempoolentryresult-txid":            "The hash of the transaction",
+	"getmempoolentryresult-size":            "Transaction size in bytes",
+	"getmempoolentryresult-fee":             "Transaction fee in decred",
+	"getmempoolentryresult-time":            "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
+	"getmempoolentryresult-height":          "Block height when transaction entered the pool",
+	"getmempoolentryresult-ancestorcount":   "The number of ancestors in the memory pool including the transaction itself",
+	"getmempoolentryresult-ancestorsize":    "The combined size in bytes of the ancestors in the memory pool including the transaction itself",
+	"getmempoolentryresult-ancestorfees":    "The combined fees in decred of the ancestors in the memory pool including the transaction itself",
+	"getmempoolentryresult-descendantcount": "The number of descendants in the memory pool including the transaction itself",
+	"getmempoolentryresult-descendantsize":  "The combined size in bytes of the descendants in the memory pool including the transaction itself",
+	"getmempoolentryresult-descendantfees":  "The combined fees in decred of the descendants in the memory pool including the transaction itself",
+	"getmempoolentryresult-depends":         "The transactions in the memory pool which the transaction spends",
+	"getmempoolentryresult-spentby":         "The transactions in the memory pool which spend the transaction",
+
+	// GetMempoolGraphCmd help.
+	"getmempoolgraph--synopsis": "Returns the dependency graph of a transaction in the memory pool, which consists of the transaction along with all of its ancestors and descendants in the memory pool.",
+	"getmempoolgraph-txid":      "The hash of the transaction in the memory pool",
+
+	// GetMempoolGraphResult help.
+	"getmempoolgraphresult-txid":         "The hash of the requested transaction",
+	"getmempoolgraphresult-transactions": "The transactions of the graph, ordered so that every transaction follows all of its ancestors among them",
+
+	// MempoolGraphEntry help.
+	"mempoolgraphentry-txid":    "The hash of the transaction",
+	"mempoolgraphentry-size":    "Transaction size in bytes",
+	"mempoolgraphentry-fee":     "Transaction fee in decred",
+	"mempoolgraphentry-time":    "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
+	"mempoolgraphentry-height":  "Block height when transaction entered the pool",
+	"mempoolgraphentry-depends": "The transactions in the memory pool which the transaction spends, which may not all be part of the graph",
+	"mempoolgraphentry-spentby": "The transactions in the memory pool which spend the transaction",
+
 	// GetMempoolInfoCmd help.
 	"getmempoolinfo--synopsis": "Returns memory pool information",
 
 	// GetMempoolInfoResult help.
-	"getmempoolinforesult-bytes": "Size in bytes of the mempool",
-	"getmempoolinforesult-size":  "Number of transactions in the mempool",
+	"getmempoolinforesult-bytes":         "Size in bytes of the mempool",
+	"getmempoolinforesult-size":          "Number of transactions in the mempool",
+	"getmempoolinforesult-maxmempool":    "Maximum size in bytes of the mempool",
+	"getmempoolinforesult-mempoolminfee": "Minimum fee rate in DCR/kB for regular transactions to be accepted, which is raised above the minimum relay fee while the mempool is full or was full recently",
 
 	// GetMiningInfoResult help.
 	"getmininginforesult-blocks":           "Height of the latest best block",
@@ -529,14 +772,40 @@ var helpDescsEnUS = map[string]string{
 	"getmininginforesult-testnet":          "Whether or not server is using testnet",
 	"getmininginforesult-stakedifficulty":  "Current estimated stake difficulty",
 
+	// GetMissedTicketDetailsCmd help.
+	"getmissedticketdetails--synopsis":           "Returns the recorded details about the likely reason tickets missed their votes.  Requires --trackmissedtickets.",
+	"getmissedticketdetails-tickets":             "The hashes of the missed tickets to return details for (default: all recorded missed tickets)",
+	"missedticketdetailsresult-ticket":           "The hash of the ticket",
+	"missedticketdetailsresult-blockhash":        "The hash of the block the ticket was missed in",
+	"missedticketdetailsresult-blockheight":      "The height of the block the ticket was missed in",
+	"missedticketdetailsresult-votedblockhash":   "The hash of the block the ticket was selected to vote on",
+	"missedticketdetailsresult-votedblockheight": "The height of the block the ticket was selected to vote on",
+	"missedticketdetailsresult-reason":           "The likely reason the vote was missed (votenotincluded, lateblock, walletdisconnected, novote, or unavailable)",
+	"missedticketdetailsresult-lotterytime":      "The unix time the winning tickets were announced (0 if unknown)",
+	"missedticketdetailsresult-voteseentime":     "The unix time the vote was first seen (0 if never seen)",
+	"missedticketdetailsresult-blockseentime":    "The unix time the block the ticket was missed in was connected",
+	"missedticketdetailsresult-votingwallets":    "The number of voting wallets connected when the winning tickets were announced",
+	"missedticketdetailsresult-acknowledged":     "Whether or not a voting wallet acknowledged the winning tickets notification",
+
 	// GetMiningInfoCmd help.
 	"getmininginfo--synopsis": "Returns a JSON object containing mining-related information.",
 
 	// GetNetworkHashPSCmd help.
-	"getnetworkhashps--synopsis": "Returns the estimated network hashes per second for the block heights provided by the parameters.",
-	"getnetworkhashps-blocks":    "The number of blocks, or -1 for blocks since last difficulty change",
-	"getnetworkhashps-height":    "Perform estimate ending with this height or -1 for current best chain block height",
-	"getnetworkhashps--result0":  "Estimated hashes per second",
+	"getnetworkhashps--synopsis":   "Returns the estimated network hashes per second for the block heights provided by the parameters.",
+	"getnetworkhashps-blocks":      "The number of blocks, or -1 for blocks since last difficulty change",
+	"getnetworkhashps-height":      "Perform estimate ending with this height or -1 for current best chain block height",
+	"getnetworkhashps-verbose":     "Specifies the average and work-weighted estimates are returned as a JSON object along with the window they were calculated over",
+	"getnetworkhashps--condition0": "verbose=false",
+	"getnetworkhashps--condition1": "verbose=true",
+	"getnetworkhashps--result0":    "Estimated hashes per second",
+
+	// GetNetworkHashPSVerboseResult help.
+	"getnetworkhashpsverboseresult-startheight":     "The height of the first block of the window",
+	"getnetworkhashpsverboseresult-endheight":       "The height of the last block of the window",
+	"getnetworkhashpsverboseresult-timespan":        "The number of seconds between the earliest and latest block timestamps in the window",
+	"getnetworkhashpsverboseresult-difficultyruns":  "The number of runs of blocks with the same difficulty the work-weighted estimate is calculated from",
+	"getnetworkhashpsverboseresult-averagehashesps": "The total work in the window divided by its time span in hashes per second",
+	"getnetworkhashpsverboseresult-workweightedps":  "The average of the estimates for each run of blocks with the same difficulty weighted by their work in hashes per second",
 
 	// GetNetTotalsCmd help.
 	"getnettotals--synopsis": "Returns a JSON object containing network traffic statistics.",
@@ -566,6 +835,17 @@ var helpDescsEnUS = map[string]string{
 	"getpeerinforesult-currentheight":  "The current height of the peer",
 	"getpeerinforesult-banscore":       "The ban score",
 	"getpeerinforesult-syncnode":       "Whether or not the peer is the sync peer",
+	"getpeerinforesult-firstblocks":    "The number of blocks the peer was the first to announce or deliver",
+	"getpeerinforesult-firsttxns":      "The number of transactions the peer was the first to announce or deliver",
+
+	"getpeerinforesult-bytessentpermsg":        "Total bytes sent by message command",
+	"getpeerinforesult-bytessentpermsg--key":   "command",
+	"getpeerinforesult-bytessentpermsg--value": "n",
+	"getpeerinforesult-bytessentpermsg--desc":  "The command of the messages as the key and the total bytes of the messages sent with it as the value",
+	"getpeerinforesult-bytesrecvpermsg":        "Total bytes received by message command",
+	"getpeerinforesult-bytesrecvpermsg--key":   "command",
+	"getpeerinforesult-bytesrecvpermsg--value": "n",
+	"getpeerinforesult-bytesrecvpermsg--desc":  "The command of the messages as the key and the total bytes of the messages received with it as the value",
 
 	// GetPeerInfoCmd help.
 	"getpeerinfo--synopsis": "Returns data about each connected network peer as an array of json objects.",
@@ -583,10 +863,27 @@ var helpDescsEnUS = map[string]string{
 	"getrawmempool--synopsis":   "Returns information about all of the transactions currently in the memory pool.",
 	"getrawmempool-verbose":     "Returns JSON object when true or an array of transaction hashes when false",
 	"getrawmempool-txtype":      "Type of tx to return. (all/regular/tickets/votes/revocations)",
+	"getrawmempool-minfeerate":  "Only return transactions which pay at least this fee rate in DCR/kB",
+	"getrawmempool-minage":      "Only return transactions which have been in the memory pool for at least this number of seconds",
+	"getrawmempool-maxage":      "Only return transactions which have been in the memory pool for at most this number of seconds",
+	"getrawmempool-summary":     "Returns a JSON object which summarizes the matching transactions instead of the transactions themselves when true",
 	"getrawmempool--condition0": "verbose=false",
 	"getrawmempool--condition1": "verbose=true",
+	"getrawmempool--condition2": "summary=true",
 	"getrawmempool--result0":    "Array of transaction hashes",
 
+	// GetRawMempoolSummaryResult help.
+	"getrawmempoolsummaryresult-count":       "The number of matching transactions",
+	"getrawmempoolsummaryresult-size":        "The total size of the matching transactions in bytes",
+	"getrawmempoolsummaryresult-fees":        "The total fees paid by the matching transactions in DCR",
+	"getrawmempoolsummaryresult-minfeerate":  "The lowest fee rate paid by the matching transactions in DCR/kB",
+	"getrawmempoolsummaryresult-maxfeerate":  "The highest fee rate paid by the matching transactions in DCR/kB",
+	"getrawmempoolsummaryresult-oldesttime":  "The local time the oldest matching transaction entered the pool in seconds since 1 Jan 1970 GMT",
+	"getrawmempoolsummaryresult-regular":     "The number of matching regular transactions",
+	"getrawmempoolsummaryresult-tickets":     "The number of matching tickets",
+	"getrawmempoolsummaryresult-votes":       "The number of matching votes",
+	"getrawmempoolsummaryresult-revocations": "The number of matching revocations",
+
 	// GetRawTransactionCmd help.
 	"getrawtransaction--synopsis":   "Returns information about a transaction given its hash.",
 	"getrawtransaction-txid":        "The hash of the transaction",
@@ -595,10 +892,87 @@ var helpDescsEnUS = map[string]string{
 	"getrawtransaction--condition1": "verbose=true",
 	"getrawtransaction--result0":    "Hex-encoded bytes of the serialized transaction",
 
+	// GetPeerFilterStatsCmd help.
+	"getpeerfilterstats--synopsis":           "Returns the number of peers which were refused or deprioritized by the peer filter rules configured with --peerfilter.",
+	"getpeerfilterstatsresult-refused":       "The number of peers which were refused",
+	"getpeerfilterstatsresult-deprioritized": "The number of peers which were deprioritized",
+	"getpeerfilterstatsresult-rules":         "The configured peer filter rules",
+	"peerfilterrulestats-rule":               "The rule as configured",
+	"peerfilterrulestats-action":             "The action taken for peers matched by the rule (refuse or deprioritize)",
+	"peerfilterrulestats-matches":            "The number of peers matched by the rule",
+
+	// GetRejectedTransactionsCmd help.
+	"getrejectedtransactions--synopsis":    "Returns the transactions received from peers that were recently rejected from the memory pool for violating the rules, ordered from the oldest to most recent rejection.  Transactions announced by peers are not downloaded again while they are remembered as rejected.",
+	"rejectedtransactionresult-txid":       "The hash of the transaction",
+	"rejectedtransactionresult-fullhash":   "The hash of the full transaction including its witness data",
+	"rejectedtransactionresult-rejectcode": "The reject code the transaction was rejected with",
+	"rejectedtransactionresult-reason":     "The reason the transaction was rejected",
+	"rejectedtransactionresult-time":       "The unix time the transaction was rejected",
+	"rejectedtransactionresult-expires":    "The unix time the transaction will no longer be remembered as rejected unless a new block is connected first",
+	"rejectedtransactionresult-seen":       "The number of times the transaction was seen again after it was rejected",
+
+	// GetRelayHistoryCmd help.
+	"getrelayhistory--synopsis": "Returns the peers which first announced and first delivered the most recently seen blocks and transactions, ordered from the most to least recently first seen.  The history is kept for the most recent 1000 blocks and 10000 transactions.",
+	"getrelayhistory-type":      "Only return the history of blocks or transactions (block or tx)",
+	"getrelayhistory-count":     "The maximum number of blocks and transactions to return",
+
+	// RelayHistoryResult help.
+	"relayhistoryresult-hash":          "The hash of the block or transaction",
+	"relayhistoryresult-type":          "The type of the inventory (block or tx)",
+	"relayhistoryresult-announcedby":   "The address of the peer which first announced the inventory, omitted when it was delivered without being announced first",
+	"relayhistoryresult-announcedtime": "The time the inventory was first announced in milliseconds since 1 Jan 1970 GMT",
+	"relayhistoryresult-receivedfrom":  "The address of the peer which first delivered the inventory, omitted when it was not delivered yet",
+	"relayhistoryresult-receivedtime":  "The time the inventory was first delivered in milliseconds since 1 Jan 1970 GMT",
+
+	// GetSyncStatusCmd help.
+	"getsyncstatus--synopsis": "Returns the state of the download of blocks from the sync peers.  During headers-first mode, the headers between checkpoints are downloaded from all sync peers in parallel and the blocks for the verified headers are downloaded from them in parallel with each peer being assigned a share of the blocks proportional to its measured throughput.",
+
+	// GetSyncStatusResult help.
+	"getsyncstatusresult-height":        "The height of the best block",
+	"getsyncstatusresult-syncpeer":      "The address of the peer the blocks after the final checkpoint are downloaded from",
+	"getsyncstatusresult-syncheight":    "The height of the latest block announced by the sync peer",
+	"getsyncstatusresult-headersfirst":  "Whether or not the blocks between checkpoints are being downloaded in headers-first mode",
+	"getsyncstatusresult-headersheight": "The height of the last downloaded header which links to the best block in headers-first mode",
+	"getsyncstatusresult-heldblocks":    "The number of blocks received before the blocks preceding them which are held until those blocks are processed",
+	"getsyncstatusresult-peers":         "The blocks assigned to each sync peer and its measured throughput ordered by peer id",
+
+	// SyncPeerStatusResult help.
+	"syncpeerstatusresult-id":             "The id of the peer",
+	"syncpeerstatusresult-addr":           "The address of the peer",
+	"syncpeerstatusresult-lastblock":      "The height of the latest block announced by the peer",
+	"syncpeerstatusresult-inflight":       "The number of blocks requested from the peer which were not received yet",
+	"syncpeerstatusresult-quota":          "The number of blocks which may be requested from the peer at once based on its share of the total throughput",
+	"syncpeerstatusresult-assignedfrom":   "The lowest height of the blocks requested from the peer which were not received yet, or 0 if there are none",
+	"syncpeerstatusresult-assignedto":     "The highest height of the blocks requested from the peer which were not received yet, or 0 if there are none",
+	"syncpeerstatusresult-blocksreceived": "The number of requested blocks received from the peer during headers-first mode",
+	"syncpeerstatusresult-bytesreceived":  "The total serialized size of the requested blocks received from the peer during headers-first mode",
+	"syncpeerstatusresult-throughput":     "The moving average of the throughput of the peer in bytes per second, which decays while the peer does not deliver requested blocks",
+
 	// GetTicketPoolValue help.
 	"getticketpoolvalue--synopsis": "Return the current value of all locked funds in the ticket pool",
 	"getticketpoolvalue--result0":  "Total value of ticket pool",
 
+	// GetTreasuryBalanceCmd help.
+	"gettreasurybalance--synopsis": "Returns the balance of the treasury as of the current best block.  The balance is zero until the treasury agenda is active.",
+
+	// GetTreasuryBalanceResult help.
+	"gettreasurybalanceresult-active":  "Whether or not the treasury agenda is active for the next block",
+	"gettreasurybalanceresult-hash":    "The hash of the current best block",
+	"gettreasurybalanceresult-height":  "The height of the current best block",
+	"gettreasurybalanceresult-balance": "The balance of the treasury in atoms",
+	"gettreasurybalanceresult-added":   "The amount added to the treasury by the block in atoms",
+	"gettreasurybalanceresult-spent":   "The amount spent from the treasury by the block in atoms",
+
+	// GetTreasurySpendsCmd help.
+	"gettreasuryspends--synopsis": "Returns the transactions which spent from the treasury in the most recent blocks of the main chain, ordered from the most recent block to the oldest one.",
+	"gettreasuryspends-numblocks": "The number of most recent blocks to return the spends of",
+
+	// TreasurySpendResult help.
+	"treasuryspendresult-txid":        "The hash of the transaction which spent from the treasury",
+	"treasuryspendresult-blockhash":   "The hash of the block which applied the spend",
+	"treasuryspendresult-blockheight": "The height of the block which applied the spend",
+	"treasuryspendresult-amount":      "The amount spent from the treasury in atoms",
+
 	// GetTxOutResult help.
 	"gettxoutresult-bestblock":     "The block hash that contains the transaction output",
 	"gettxoutresult-confirmations": "The number of confirmations",
@@ -613,6 +987,94 @@ var helpDescsEnUS = map[string]string{
 	"gettxout-vout":           "The index of the output",
 	"gettxout-includemempool": "Include the mempool when true",
 
+	// GetTxCostCmd help.
+	"gettxcost--synopsis": "Returns the costs of a transaction which are limited by the consensus rules and policy, calculated the same way the consensus rules, the memory pool, and block template generation calculate them.\n" +
+		"The outputs the transaction spends must be in the main chain or the memory pool unless it is a coinbase.",
+	"gettxcost-hextx": "Serialized, hex-encoded transaction",
+
+	// GetTxCostResult help.
+	"gettxcostresult-txid":        "The hash of the transaction",
+	"gettxcostresult-size":        "The serialized size of the transaction in bytes",
+	"gettxcostresult-sigops":      "The quickly counted number of signature operations in all input and output scripts",
+	"gettxcostresult-p2shsigops":  "The precisely counted number of signature operations in the redeem scripts of the spent pay-to-script-hash outputs",
+	"gettxcostresult-altsigops":   "The number of the signature operations which verify signatures of the alternative signature types, which are included in sigops and p2shsigops",
+	"gettxcostresult-totalsigops": "The number of signature operations that count towards the signature operation limits",
+
+	// GetTxRelayStatusCmd help.
+	"gettxrelaystatus--synopsis": "Returns the relay status of the transactions submitted via sendrawtransaction that have not been included in a block yet, ordered from the oldest to most recent submission.  Locally submitted transactions are announced immediately to all peers rather than trickled.",
+	"gettxrelaystatus-txid":      "Only return the relay status of the transaction with this hash",
+
+	// TxRelayStatusResult help.
+	"txrelaystatusresult-txid":        "The hash of the transaction",
+	"txrelaystatusresult-added":       "The time the transaction was submitted in seconds since 1 Jan 1970 GMT",
+	"txrelaystatusresult-lastoffered": "The time the transaction was last announced to a peer in seconds since 1 Jan 1970 GMT, or 0 if it has not been announced yet",
+	"txrelaystatusresult-offered":     "The number of peers the transaction was announced to",
+	"txrelaystatusresult-requested":   "The number of peers that requested the transaction",
+
+	// GetTxSpendingInfoCmd help.
+	"gettxspendinginfo--synopsis":      "Returns the transaction input which spends an output along with the block that contains it.  Outputs which do not exist are reported as unspent.  Requires the spend index (--spendindex).",
+	"gettxspendinginfo-txid":           "The hash of the transaction",
+	"gettxspendinginfo-vout":           "The index of the output",
+	"gettxspendinginfo-includemempool": "Include the memory pool when true",
+
+	// TxSpendingInfoResult help.
+	"txspendinginforesult-spent":         "Whether or not the output is spent",
+	"txspendinginforesult-spendingtxid":  "The hash of the spending transaction, omitted when the output is not spent",
+	"txspendinginforesult-vin":           "The index of the input of the spending transaction which spends the output",
+	"txspendinginforesult-blockhash":     "The hash of the block which contains the spending transaction, omitted when it is only in the memory pool",
+	"txspendinginforesult-blockheight":   "The height of the block which contains the spending transaction, omitted when it is only in the memory pool",
+	"txspendinginforesult-confirmations": "The number of confirmations of the spending transaction",
+
+	// GetAddressBalanceCmd help.
+	"getaddressbalance--synopsis": "Returns the confirmed balance and the number of unspent outputs of an address.  Only outputs which pay the standard script of the address are included, and the regular transactions of a block are only confirmed once the next block approves them.  Requires the balance index (--balanceindex).",
+	"getaddressbalance-address":   "The encoded address",
+
+	// AddressBalanceResult help.
+	"addressbalanceresult-balance":  "The confirmed balance of the address in DCR",
+	"addressbalanceresult-numutxos": "The number of confirmed unspent outputs which pay the address",
+
+	// GetAddressUtxosCmd help.
+	"getaddressutxos--synopsis": "Returns the confirmed unspent outputs of an address ordered by outpoint.  Only outputs which pay the standard script of the address are included, and the regular transactions of a block are only confirmed once the next block approves them.  Requires the balance index (--balanceindex).",
+	"getaddressutxos-address":   "The encoded address",
+
+	// AddressUtxoResult help.
+	"addressutxoresult-txid":          "The hash of the transaction which created the output",
+	"addressutxoresult-vout":          "The index of the output",
+	"addressutxoresult-tree":          "The tree of the transaction which created the output",
+	"addressutxoresult-amount":        "The amount of the output in DCR",
+	"addressutxoresult-scriptpubkey":  "The hex-encoded public key script of the output",
+	"addressutxoresult-height":        "The height of the block which contains the transaction",
+	"addressutxoresult-confirmations": "The number of confirmations of the transaction",
+
+	// GetValidationStatsCmd help.
+	"getvalidationstats--synopsis":          "Returns the accumulated time each stage of accepting transactions into the memory pool and validating blocks took since the server started.  The stages are checks (the checks which do not involve the referenced inputs, which are the sanity checks of blocks and the sanity and policy checks of transactions), fetchinputs, scripts, update (adding to the memory pool or updating the database), and total (the entire acceptance, only recorded for accepted transactions and blocks).",
+	"getvalidationstatsresult-transactions": "The timing of each stage of accepting transactions into the memory pool",
+	"getvalidationstatsresult-blocks":       "The timing of each stage of validating blocks",
+	"validationstageresult-stage":           "The name of the stage",
+	"validationstageresult-count":           "The number of times the stage completed",
+	"validationstageresult-totaltime":       "The total time the stage took in microseconds",
+	"validationstageresult-avgtime":         "The average time the stage took in microseconds",
+	"validationstageresult-maxtime":         "The maximum time the stage took in microseconds",
+
+	// GetWindowAggregatesCmd help.
+	"getwindowaggregates--synopsis":        "Returns aggregate difficulty, ticket price, fee, and subsidy information for the most recent stake difficulty windows, in descending order. Requires the window aggregate index (--windowaggindex).",
+	"getwindowaggregates-windows":          "The number of windows, starting from the window of the chain tip and descending, to return aggregate information about",
+	"getwindowaggregatesresult-windowsize": "The number of blocks in each window",
+	"getwindowaggregatesresult-windows":    "The aggregate information for each window",
+	"windowaggregate-window":               "The index of the window",
+	"windowaggregate-startheight":          "First block in the window (inclusive)",
+	"windowaggregate-endheight":            "Last block in the window connected to the main chain (inclusive)",
+	"windowaggregate-blocks":               "Number of blocks in the window connected to the main chain",
+	"windowaggregate-difficulty":           "The proof-of-work difficulty of the first block in the window as a multiple of the minimum difficulty",
+	"windowaggregate-stakedifficulty":      "The stake difficulty of the window",
+	"windowaggregate-totalfees":            "Total transaction fees in the window",
+	"windowaggregate-worksubsidy":          "Total subsidy paid to proof-of-work miners in the window",
+	"windowaggregate-stakesubsidy":         "Total subsidy paid to voters in the window",
+	"windowaggregate-taxsubsidy":           "Total subsidy paid to the organization in the window",
+	"windowaggregate-votes":                "Number of votes in the window",
+	"windowaggregate-tickets":              "Number of ticket purchases in the window",
+	"windowaggregate-revocations":          "Number of revocations in the window",
+
 	// GetWorkResult help.
 	"getworkresult-data":     "Hex-encoded block data",
 	"getworkresult-hash1":    "(DEPRECATED) Hex-encoded formatted hash buffer",
@@ -634,16 +1096,29 @@ var helpDescsEnUS = map[string]string{
 	"help--result0":    "List of commands",
 	"help--result1":    "Help for specified command",
 
+	// InvalidateBlockCmd help.
+	"invalidateblock--synopsis": "Permanently marks a block as invalid, along with all of its descendants, and reorganizes the chain away from it as needed.",
+	"invalidateblock-blockhash": "The hash of the block to mark as invalid",
+
 	// PingCmd help.
 	"ping--synopsis": "Queues a ping to be sent to each connected peer.\n" +
 		"Ping times are provided by getpeerinfo via the pingtime and pingwait fields.",
 
+	// ReconsiderBlockCmd help.
+	"reconsiderblock--synopsis": "Removes the invalidity of a block which was marked as invalid with invalidateblock, along with its ancestors and descendants, and reorganizes the chain to the chain with the most cumulative work as needed.",
+	"reconsiderblock-blockhash": "The hash of the block to reconsider",
+
 	// RebroadcastMissed help.
 	"rebroadcastmissed--synopsis": "Asks the daemon to rebroadcast missed votes.\n",
 
 	// RebroadcastWinnerCmd help.
 	"rebroadcastwinners--synopsis": "Asks the daemon to rebroadcast the winners of the voting lottery.\n",
 
+	// RebuildIndexCmd help.
+	"rebuildindex--synopsis": "Starts deleting an optional index and indexing the entire main chain again in the background while the chain keeps running.  The index is updated and queried again once it has caught up with the main chain.\n" +
+		"Rebuilding the transaction index also rebuilds the address and balance indexes which depend on it.  The progress is reported by getindexinfo and by getjob for the returned job.",
+	"rebuildindex-index": "The name of the option which enables the index: txindex, addrindex, existsaddrindex, windowaggindex, spendindex, or balanceindex",
+
 	// SearchRawTransactionsCmd help.
 	"searchrawtransactions--synopsis": "Returns raw data for transactions involving the passed address.\n" +
 		"Returned transactions are pulled from both the database, and transactions currently in the mempool.\n" +
@@ -664,28 +1139,56 @@ var helpDescsEnUS = map[string]string{
 	// SendRawTransactionCmd help.
 	"sendrawtransaction--synopsis":     "Submits the serialized, hex-encoded transaction to the local peer and relays it to the network.",
 	"sendrawtransaction-hextx":         "Serialized, hex-encoded signed transaction",
-	"sendrawtransaction-allowhighfees": "Whether or not to allow insanely high fees (dcrd does not yet implement this parameter, so it has no effect)",
+	"sendrawtransaction-allowhighfees": "Whether or not to allow insanely high fees, which also disables the maxfeerate check",
+	"sendrawtransaction-maxfeerate":    "The maximum fee rate in DCR/kB the transaction is allowed to pay or 0 to disable the check",
 	"sendrawtransaction--result0":      "The hash of the transaction",
 
+	// SetBlockAnnotationCmd help.
+	"setblockannotation--synopsis": "Attaches an annotation to a block, replacing any existing annotation with the same key, or removes the annotation when the value is omitted.  Annotations survive reorganizations.",
+	"setblockannotation-hash":      "The hash of the block",
+	"setblockannotation-key":       "The key of the annotation of up to 64 bytes",
+	"setblockannotation-value":     "The value of the annotation of up to 256 bytes or omitted to remove the annotation",
+
 	// SetGenerateCmd help.
 	"setgenerate--synopsis":    "Set the server to generate coins (mine) or not.",
 	"setgenerate-generate":     "Use true to enable generation, false to disable it",
 	"setgenerate-genproclimit": "The number of processors (cores) to limit generation to or -1 for default",
 
+	// StartProfileCmd help.
+	"startprofile--synopsis":      "Capture a CPU, heap, or execution trace profile to the profiles directory under the data directory. CPU and trace profiles are captured in the background for the requested duration, while heap profiles are written immediately.",
+	"startprofile-profiletype":    "The type of profile to capture (cpu, heap, or trace)",
+	"startprofile-duration":       "The number of seconds to capture a cpu or trace profile for (max 600, ignored for heap profiles)",
+	"startprofileresult-file":     "The path of the file the profile is written to",
+	"startprofileresult-duration": "The number of seconds the profile is being captured for (0 for heap profiles)",
+
 	// StopCmd help.
 	"stop--synopsis": "Shutdown dcrd.",
 	"stop--result0":  "The string 'dcrd stopping.'",
 
 	// SubmitBlockOptions help.
-	"submitblockoptions-workid": "This parameter is currently ignored",
+	"submitblockoptions-workid":        "This parameter is currently ignored",
+	"submitblockoptions-rejectdetails": "Return rejections as an object which includes the details of the violated consensus rule instead of a string",
+
+	// RuleErrorDetails help.
+	"ruleerrordetails-code":       "The stable numeric code of the violated consensus rule",
+	"ruleerrordetails-name":       "The name of the violated consensus rule",
+	"ruleerrordetails-height":     "The height of the block containing the violation (omitted when not known)",
+	"ruleerrordetails-txtree":     "The tree of the transaction containing the violation (omitted when not known)",
+	"ruleerrordetails-txindex":    "The index of the transaction containing the violation within its tree (omitted when not known)",
+	"ruleerrordetails-inputindex": "The index of the offending transaction input (omitted when not known)",
+
+	// SubmitBlockRejectResult help.
+	"submitblockrejectresult-reason":  "The reason the block was rejected",
+	"submitblockrejectresult-details": "The details of the violated consensus rule (omitted when the block was not rejected due to a consensus rule violation)",
 
 	// SubmitBlockCmd help.
 	"submitblock--synopsis":   "Attempts to submit a new serialized, hex-encoded block to the network.",
 	"submitblock-hexblock":    "Serialized, hex-encoded block",
-	"submitblock-options":     "This parameter is currently ignored",
+	"submitblock-options":     "Optional parameters for the submission",
 	"submitblock--condition0": "Block successfully submitted",
-	"submitblock--condition1": "Block rejected",
-	"submitblock--result1":    "The reason the block was rejected",
+	"submitblock--condition1": "Block rejected, rejectdetails=false",
+	"submitblock--condition2": "Block rejected, rejectdetails=true",
+	"submitblock--result1":    "The reason the block was rejected ('stale-prevblk' when the block does not build on the tip of the main chain or its parent, 'duplicate' when the block is already known)",
 
 	// ValidateAddressResult help.
 	"validateaddresschainresult-isvalid": "Whether or not the address is valid",
@@ -695,15 +1198,23 @@ var helpDescsEnUS = map[string]string{
 	"validateaddress--synopsis": "Verify an address is valid.",
 	"validateaddress-address":   "Decred address to validate",
 
+	// VerifyIndexCmd help.
+	"verifyindex--synopsis": "Starts verifying an optional index in the background while the chain keeps running.  The tip of every index must be part of the main chain, and the entries of the transaction and spend indexes for every block of the main chain are compared with the block.\n" +
+		"The progress and any inconsistency found are reported by getindexinfo and by getjob for the returned job.",
+	"verifyindex-index": "The name of the option which enables the index: txindex, addrindex, existsaddrindex, windowaggindex, spendindex, or balanceindex",
+
 	// VerifyChainCmd help.
 	"verifychain--synopsis": "Verifies the block chain database.\n" +
 		"The actual checks performed by the checklevel parameter are implementation specific.\n" +
 		"For dcrd this is:\n" +
 		"checklevel=0 - Look up each block and ensure it can be loaded from the database.\n" +
 		"checklevel=1 - Perform basic context-free sanity checks on each block.",
-	"verifychain-checklevel": "How thorough the block verification is",
-	"verifychain-checkdepth": "The number of blocks to check",
-	"verifychain--result0":   "Whether or not the chain verified",
+	"verifychain-checklevel":  "How thorough the block verification is",
+	"verifychain-checkdepth":  "The number of blocks to check",
+	"verifychain-async":       "Run the command as a job which is queried with getjob instead of waiting for the verification to finish",
+	"verifychain--condition0": "async=false",
+	"verifychain--condition1": "async=true",
+	"verifychain--result0":    "Whether or not the chain verified",
 
 	// VerifyMessageCmd help.
 	"verifymessage--synopsis": "Verify a signed message.",
@@ -718,6 +1229,14 @@ var helpDescsEnUS = map[string]string{
 	"session--synopsis":       "Return details regarding a websocket client's current connection session.",
 	"sessionresult-sessionid": "The unique session ID for a client's websocket connection.",
 
+	// RegisterVotingWalletCmd help.
+	"registervotingwallet--synopsis": "Register the client as a voting wallet.  The winningtickets and spentandmissedtickets notifications sent to voting wallets are retransmitted until they are acknowledged with acknotification or the delivery deadline passes.",
+
+	// AckNotificationCmd help.
+	"acknotification--synopsis": "Acknowledge receipt of a winningtickets or spentandmissedtickets notification by a voting wallet.",
+	"acknotification-method":    "The method of the notification being acknowledged",
+	"acknotification-blockhash": "The block hash included in the notification being acknowledged",
+
 	// NotifySpentAndMissedTicketsCmd help
 	"notifyspentandmissedtickets--synopsis": "Request notifications for whenever tickets are spent or missed.",
 
@@ -727,8 +1246,12 @@ var helpDescsEnUS = map[string]string{
 	// NotifyStakeDifficultyCmd help
 	"notifystakedifficulty--synopsis": "Request notifications for whenever stake difficulty goes up.",
 
+	// NotifyStakeDifficultyChangedCmd help
+	"notifystakedifficultychanged--synopsis": "Request notifications for whenever a new stake difficulty window begins.  The notification includes the stake difficulty of the previous window, the stake difficulty of the new window, and the first block height the new stake difficulty applies to.",
+
 	// NotifyWinningTicketsCmd help
 	"notifywinningtickets--synopsis": "Request notifications for whenever any tickets is chosen to vote.",
+	"notifywinningtickets-verbose":   "Specifies whether the winningtickets notifications also include the height the votes must be included by and the commitment addresses of each winning ticket",
 
 	// NotifyBlocksCmd help.
 	"notifyblocks--synopsis": "Request notifications for whenever a block is connected or disconnected from the main (best) chain.",
@@ -743,6 +1266,30 @@ var helpDescsEnUS = map[string]string{
 	// StopNotifyNewTransactionsCmd help.
 	"stopnotifynewtransactions--synopsis": "Stop sending either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
 
+	// NotifySideChainBlocksCmd help.
+	"notifysidechainblocks--synopsis": "Request sidechainblockconnected notifications for whenever a block which extends a chain other than the main (best) chain is accepted.  The notification includes the total work of the side chain and how much less work it has than the main chain.",
+
+	// StopNotifySideChainBlocksCmd help.
+	"stopnotifysidechainblocks--synopsis": "Cancel registered sidechainblockconnected notifications.",
+
+	// NotifyWorkCmd help.
+	"notifywork--synopsis": "Request workexpired notifications for whenever previously handed out mining work becomes stale due to a new block being connected to the main (best) chain or a new vote on the tip of the main chain being available.",
+
+	// StopNotifyWorkCmd help.
+	"stopnotifywork--synopsis": "Cancel registered workexpired notifications.",
+
+	// NotifyJobsCmd help.
+	"notifyjobs--synopsis": "Request jobcompleted notifications for whenever a job started by a long-running command finishes.",
+
+	// StopNotifyJobsCmd help.
+	"stopnotifyjobs--synopsis": "Cancel registered jobcompleted notifications.",
+
+	// NotifyStakeBoundariesCmd help.
+	"notifystakeboundaries--synopsis": "Request stakeboundary notifications for whenever a block connected to the main (best) chain starts a new ticket price interval or matures or expires tickets.",
+
+	// StopNotifyStakeBoundariesCmd help.
+	"stopnotifystakeboundaries--synopsis": "Cancel registered stakeboundary notifications.",
+
 	// OutPoint help.
 	"outpoint-hash":  "The hex-encoded bytes of the outpoint hash",
 	"outpoint-index": "The index of the outpoint",
@@ -757,14 +1304,23 @@ var helpDescsEnUS = map[string]string{
 	// Rescan help.
 	"rescan--synopsis":   "Rescan blocks for transactions matching the loaded transaction filter.",
 	"rescan-blockhashes": "Concatenated block hashes to rescan.  Each next block must be a child of the previous.",
+	"rescan-async":       "Run the rescan as a job which is queried with getjob instead of waiting for it to finish.  The job stops when the client disconnects.",
 
 	// -------- Decred-specific help --------
 
 	// EstimateFee help.
-	"estimatefee--synopsis": "Returns the estimated fee in dcr/kb.",
-	"estimatefee-numblocks": "(unused)",
+	"estimatefee--synopsis": "Returns the estimated fee rate in DCR/kB for regular transactions to be confirmed within the given number of blocks, which is never lower than the minimum fee rate currently required by the memory pool and is that rate when there is not enough data.",
+	"estimatefee-numblocks": "The number of blocks the transaction should be confirmed within (limited to 1 through 32)",
 	"estimatefee--result0":  "Estimated fee.",
 
+	// EstimateSmartFeeCmd help.
+	"estimatesmartfee--synopsis":     "Estimates the fee rate regular transactions need to pay in order to be confirmed within the given number of blocks from the confirmation times of the transactions seen in the memory pool, which is never lower than the minimum fee rate currently required by the memory pool.",
+	"estimatesmartfee-confirmations": "The number of blocks the transaction should be confirmed within (1 through 32)",
+
+	// EstimateSmartFeeResult help.
+	"estimatesmartfeeresult-feerate": "The estimated fee rate in DCR/kB",
+	"estimatesmartfeeresult-blocks":  "The number of blocks the estimate applies to, which is higher than requested when there is not enough data for the requested number",
+
 	// EstimateStakeDiff help.
 	"estimatestakediff--synopsis":      "Estimate the next minimum, maximum, expected, and user-specified stake difficulty",
 	"estimatestakediff-tickets":        "Use this number of new tickets in blocks to estimate the next difficulty",
@@ -773,10 +1329,44 @@ var helpDescsEnUS = map[string]string{
 	"estimatestakediffresult-expected": "Expected estimate for stake difficulty",
 	"estimatestakediffresult-user":     "Estimate for stake difficulty with the passed user amount of tickets",
 
+	// EstimateTicketFeeCmd help.
+	"estimateticketfee--synopsis":     "Estimates the fee rate ticket purchases need to pay in order to be confirmed within the given number of blocks from the confirmation times of the tickets seen in the memory pool.",
+	"estimateticketfee-confirmations": "The number of blocks the ticket purchase should be confirmed within (1 through 32)",
+
+	// EstimateTimeToConfirmCmd help.
+	"estimatetimetoconfirm--synopsis": "Estimates the probability that a regular transaction paying the given fee rate is confirmed within each of the next blocks.\n" +
+		"The estimate is based on the size of the transactions in the memory pool which pay at least the fee rate and the space regular transactions used and had available in recent blocks.\n" +
+		"Transactions which arrive later and pay a higher fee rate are not accounted for.",
+	"estimatetimetoconfirm-feerate":   "The fee rate of the transaction in DCR/kB",
+	"estimatetimetoconfirm-size":      "The serialized size of the transaction in bytes",
+	"estimatetimetoconfirm-numblocks": "The number of blocks to estimate the confirmation probability for (maximum 144)",
+
+	// EstimateTimeToConfirmResult help.
+	"estimatetimetoconfirmresult-bytesahead":    "The total size of the transactions in the memory pool which are mined first, including the transaction itself",
+	"estimatetimetoconfirmresult-blockcapacity": "The mean number of bytes of regular transactions recent blocks could have held",
+	"estimatetimetoconfirmresult-fillrate":      "The mean fraction of the space available to regular transactions which recent blocks used",
+	"estimatetimetoconfirmresult-probabilities": "The probability that the transaction is confirmed within each number of blocks",
+
+	// ConfirmProbability help.
+	"confirmprobability-blocks":      "The number of blocks",
+	"confirmprobability-probability": "The probability that the transaction is confirmed within the number of blocks",
+
 	// GetCoinSupply help
 	"getcoinsupply--synopsis": "Returns current total coin supply in atoms",
 	"getcoinsupply--result0":  "Current coin supply in atoms",
 
+	// GetCoinSupplyBreakdownCmd help.
+	"getcoinsupplybreakdown--synopsis": "Returns the total coin supply as of the current best block split by the source of the subsidy which mined the coins.",
+
+	// GetCoinSupplyBreakdownResult help.
+	"getcoinsupplybreakdownresult-hash":       "The hash of the current best block",
+	"getcoinsupplybreakdownresult-height":     "The height of the current best block",
+	"getcoinsupplybreakdownresult-total":      "The total coin supply in atoms",
+	"getcoinsupplybreakdownresult-premine":    "The coins mined by the subsidy of block one in atoms",
+	"getcoinsupplybreakdownresult-pow":        "The coins mined by the proof-of-work subsidy paid to miners in atoms",
+	"getcoinsupplybreakdownresult-pos":        "The coins mined by the proof-of-stake subsidy paid to voters in atoms",
+	"getcoinsupplybreakdownresult-devsubsidy": "The coins mined by the development subsidy paid to the organization or the treasury in atoms",
+
 	// LiveTickets help.
 	"livetickets--synopsis":     "Request tickets the live ticket hashes from the ticket database",
 	"liveticketsresult-tickets": "List of live tickets",
@@ -857,100 +1447,167 @@ var helpDescsEnUS = map[string]string{
 	"feeinforange-stddev": "Standard deviation of transaction fees in the window",
 
 	// Version help
-	"version--synopsis":       "Returns the JSON-RPC API version (semver)",
+	"version--synopsis":       "Returns the JSON-RPC API version (semver) along with the notifications, optional indexes, and features the server supports, as well as the version of dcrd",
 	"version--result0--desc":  "Version objects keyed by the program or API name",
 	"version--result0--key":   "Program or API name",
 	"version--result0--value": "Object containing the semantic version",
+
+	// WarpClockCmd help.
+	"warpclock--synopsis": "Shifts the clock used by the time-dependent consensus, mempool, and peer logic by the passed number of seconds.  The clock may only be warped on the simulation test network.",
+	"warpclock-seconds":   "The number of seconds to shift the clock by, which may be negative to shift it back or 0 to only return the current offset",
+
+	// WarpClockResult help.
+	"warpclockresult-offset": "The total number of seconds the clock is shifted by",
+	"warpclockresult-time":   "The current time of the warped clock in seconds since 1 Jan 1970 GMT",
 }
 
 // rpcResultTypes specifies the result types that each RPC command can return.
 // This information is used to generate the help.  Each result type must be a
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[string][]interface{}{
-	"addnode":               nil,
-	"createrawsstx":         {(*string)(nil)},
-	"createrawssgentx":      {(*string)(nil)},
-	"createrawssrtx":        {(*string)(nil)},
-	"createrawtransaction":  {(*string)(nil)},
-	"debuglevel":            {(*string)(nil), (*string)(nil)},
-	"decoderawtransaction":  {(*dcrjson.TxRawDecodeResult)(nil)},
-	"decodescript":          {(*dcrjson.DecodeScriptResult)(nil)},
-	"estimatefee":           {(*float64)(nil)},
-	"estimatestakediff":     {(*dcrjson.EstimateStakeDiffResult)(nil)},
-	"existsaddress":         {(*bool)(nil)},
-	"existsaddresses":       {(*string)(nil)},
-	"existsexpiredtickets":  {(*string)(nil)},
-	"existsliveticket":      {(*bool)(nil)},
-	"existslivetickets":     {(*string)(nil)},
-	"existsmempooltxs":      {(*string)(nil)},
-	"getaddednodeinfo":      {(*[]string)(nil), (*[]dcrjson.GetAddedNodeInfoResult)(nil)},
-	"getbestblock":          {(*dcrjson.GetBestBlockResult)(nil)},
-	"generate":              {(*[]string)(nil)},
-	"getbestblockhash":      {(*string)(nil)},
-	"getblock":              {(*string)(nil), (*dcrjson.GetBlockVerboseResult)(nil)},
-	"getblockcount":         {(*int64)(nil)},
-	"getblockhash":          {(*string)(nil)},
-	"getblockheader":        {(*string)(nil), (*dcrjson.GetBlockHeaderVerboseResult)(nil)},
-	"getblocktemplate":      {(*dcrjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
-	"getconnectioncount":    {(*int32)(nil)},
-	"getcurrentnet":         {(*uint32)(nil)},
-	"getdifficulty":         {(*float64)(nil)},
-	"getstakedifficulty":    {(*dcrjson.GetStakeDifficultyResult)(nil)},
-	"getstakeversioninfo":   {(*dcrjson.GetStakeVersionInfoResult)(nil)},
-	"getstakeversions":      {(*dcrjson.GetStakeVersionsResult)(nil)},
-	"getgenerate":           {(*bool)(nil)},
-	"gethashespersec":       {(*float64)(nil)},
-	"getheaders":            {(*dcrjson.GetHeadersResult)(nil)},
-	"getinfo":               {(*dcrjson.InfoChainResult)(nil)},
-	"getmempoolinfo":        {(*dcrjson.GetMempoolInfoResult)(nil)},
-	"getmininginfo":         {(*dcrjson.GetMiningInfoResult)(nil)},
-	"getnettotals":          {(*dcrjson.GetNetTotalsResult)(nil)},
-	"getnetworkhashps":      {(*int64)(nil)},
-	"getpeerinfo":           {(*[]dcrjson.GetPeerInfoResult)(nil)},
-	"getrawmempool":         {(*[]string)(nil), (*dcrjson.GetRawMempoolVerboseResult)(nil)},
-	"getrawtransaction":     {(*string)(nil), (*dcrjson.TxRawResult)(nil)},
-	"getticketpoolvalue":    {(*float64)(nil)},
-	"gettxout":              {(*dcrjson.GetTxOutResult)(nil)},
-	"getvoteinfo":           {(*dcrjson.GetVoteInfoResult)(nil)},
-	"getwork":               {(*dcrjson.GetWorkResult)(nil), (*bool)(nil)},
-	"getcoinsupply":         {(*int64)(nil)},
-	"help":                  {(*string)(nil), (*string)(nil)},
-	"livetickets":           {(*dcrjson.LiveTicketsResult)(nil)},
-	"missedtickets":         {(*dcrjson.MissedTicketsResult)(nil)},
-	"node":                  nil,
-	"ping":                  nil,
-	"rebroadcastmissed":     nil,
-	"rebroadcastwinners":    nil,
-	"searchrawtransactions": {(*string)(nil), (*[]dcrjson.SearchRawTransactionsResult)(nil)},
-	"sendrawtransaction":    {(*string)(nil)},
-	"setgenerate":           nil,
-	"stop":                  {(*string)(nil)},
-	"submitblock":           {nil, (*string)(nil)},
-	"ticketfeeinfo":         {(*dcrjson.TicketFeeInfoResult)(nil)},
-	"ticketsforaddress":     {(*dcrjson.TicketsForAddressResult)(nil)},
-	"ticketvwap":            {(*float64)(nil)},
-	"txfeeinfo":             {(*dcrjson.TxFeeInfoResult)(nil)},
-	"validateaddress":       {(*dcrjson.ValidateAddressChainResult)(nil)},
-	"verifychain":           {(*bool)(nil)},
-	"verifymessage":         {(*bool)(nil)},
-	"version":               {(*map[string]dcrjson.VersionResult)(nil)},
+	"addnode":                 nil,
+	"advancechain":            {(*[]string)(nil)},
+	"canceljob":               nil,
+	"comparechainwork":        {(*dcrjson.CompareChainWorkResult)(nil)},
+	"createrawsstx":           {(*string)(nil)},
+	"createrawssgentx":        {(*string)(nil)},
+	"createrawssrtx":          {(*string)(nil)},
+	"createrawtransaction":    {(*string)(nil)},
+	"creditoutput":            {(*dcrjson.CreditOutputResult)(nil)},
+	"debuglevel":              {(*string)(nil), (*string)(nil)},
+	"decoderawtransaction":    {(*dcrjson.TxRawDecodeResult)(nil)},
+	"decodescript":            {(*dcrjson.DecodeScriptResult)(nil)},
+	"dropindex":               {(*dcrjson.JobResult)(nil)},
+	"dumpaddrman":             {(*dcrjson.AddrManDump)(nil)},
+	"dumputxoset":             {(*dcrjson.UtxoSnapshotResult)(nil), (*dcrjson.JobResult)(nil)},
+	"estimatefee":             {(*float64)(nil)},
+	"estimatesmartfee":        {(*dcrjson.EstimateSmartFeeResult)(nil)},
+	"estimatestakediff":       {(*dcrjson.EstimateStakeDiffResult)(nil)},
+	"estimateticketfee":       {(*dcrjson.EstimateSmartFeeResult)(nil)},
+	"estimatetimetoconfirm":   {(*dcrjson.EstimateTimeToConfirmResult)(nil)},
+	"existsaddress":           {(*bool)(nil)},
+	"existsaddresses":         {(*string)(nil)},
+	"existsexpiredtickets":    {(*string)(nil)},
+	"existsliveticket":        {(*bool)(nil)},
+	"existslivetickets":       {(*string)(nil)},
+	"existsmempooltxs":        {(*string)(nil)},
+	"forcestakedifficulty":    nil,
+	"getaddednodeinfo":        {(*[]string)(nil), (*[]dcrjson.GetAddedNodeInfoResult)(nil)},
+	"getaddressbalance":       {(*dcrjson.AddressBalanceResult)(nil)},
+	"getaddressutxos":         {(*[]dcrjson.AddressUtxoResult)(nil)},
+	"getbestblock":            {(*dcrjson.GetBestBlockResult)(nil)},
+	"generate":                {(*[]string)(nil)},
+	"getbestblockhash":        {(*string)(nil)},
+	"getblock":                {(*string)(nil), (*dcrjson.GetBlockVerboseResult)(nil)},
+	"getblockannotations":     {(*[]dcrjson.BlockAnnotationResult)(nil)},
+	"getblockbymediantime":    {(*dcrjson.GetBlockByMedianTimeResult)(nil)},
+	"getblockchaininfo":       {(*dcrjson.GetBlockChainInfoResult)(nil)},
+	"getblockcount":           {(*int64)(nil)},
+	"getblockhash":            {(*string)(nil)},
+	"getblockhashbytime":      {(*string)(nil)},
+	"getblockheader":          {(*string)(nil), (*dcrjson.GetBlockHeaderVerboseResult)(nil)},
+	"getblocktemplate":        {(*dcrjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
+	"getconnectioncount":      {(*int32)(nil)},
+	"getcurrentnet":           {(*uint32)(nil)},
+	"getdifficulty":           {(*float64)(nil)},
+	"getstakedifficulty":      {(*dcrjson.GetStakeDifficultyResult)(nil)},
+	"getstakeversioninfo":     {(*dcrjson.GetStakeVersionInfoResult)(nil)},
+	"getstakeversions":        {(*dcrjson.GetStakeVersionsResult)(nil)},
+	"getfinality":             {(*dcrjson.GetFinalityResult)(nil)},
+	"getgenerate":             {(*bool)(nil)},
+	"gethashespersec":         {(*float64)(nil)},
+	"getheaders":              {(*dcrjson.GetHeadersResult)(nil)},
+	"getindexinfo":            {(*[]dcrjson.IndexInfoResult)(nil)},
+	"getinfo":                 {(*dcrjson.InfoChainResult)(nil)},
+	"getjob":                  {(*dcrjson.JobResult)(nil)},
+	"getmempoolancestors":     {(*[]string)(nil), (*[]dcrjson.GetMempoolEntryResult)(nil)},
+	"getmempooldescendants":   {(*[]string)(nil), (*[]dcrjson.GetMempoolEntryResult)(nil)},
+	"getmempoolentry":         {(*dcrjson.GetMempoolEntryResult)(nil)},
+	"getmempoolgraph":         {(*dcrjson.GetMempoolGraphResult)(nil)},
+	"getmempoolinfo":          {(*dcrjson.GetMempoolInfoResult)(nil)},
+	"getmininginfo":           {(*dcrjson.GetMiningInfoResult)(nil)},
+	"getmissedticketdetails":  {(*[]dcrjson.MissedTicketDetailsResult)(nil)},
+	"getnettotals":            {(*dcrjson.GetNetTotalsResult)(nil)},
+	"getnetworkhashps":        {(*int64)(nil), (*dcrjson.GetNetworkHashPSVerboseResult)(nil)},
+	"getpeerfilterstats":      {(*dcrjson.GetPeerFilterStatsResult)(nil)},
+	"getpeerinfo":             {(*[]dcrjson.GetPeerInfoResult)(nil)},
+	"getrawmempool":           {(*[]string)(nil), (*dcrjson.GetRawMempoolVerboseResult)(nil), (*dcrjson.GetRawMempoolSummaryResult)(nil)},
+	"getrawtransaction":       {(*string)(nil), (*dcrjson.TxRawResult)(nil)},
+	"getrejectedtransactions": {(*[]dcrjson.RejectedTransactionResult)(nil)},
+	"getrelayhistory":         {(*[]dcrjson.RelayHistoryResult)(nil)},
+	"getsyncstatus":           {(*dcrjson.GetSyncStatusResult)(nil)},
+	"getticketpoolvalue":      {(*float64)(nil)},
+	"gettreasurybalance":      {(*dcrjson.GetTreasuryBalanceResult)(nil)},
+	"gettreasuryspends":       {(*[]dcrjson.TreasurySpendResult)(nil)},
+	"gettxout":                {(*dcrjson.GetTxOutResult)(nil)},
+	"gettxcost":               {(*dcrjson.GetTxCostResult)(nil)},
+	"gettxrelaystatus":        {(*[]dcrjson.TxRelayStatusResult)(nil)},
+	"gettxspendinginfo":       {(*dcrjson.TxSpendingInfoResult)(nil)},
+	"getvalidationstats":      {(*dcrjson.GetValidationStatsResult)(nil)},
+	"getvoteinfo":             {(*dcrjson.GetVoteInfoResult)(nil)},
+	"getvotingwalletstats":    {(*dcrjson.GetVotingWalletStatsResult)(nil)},
+	"getwindowaggregates":     {(*dcrjson.GetWindowAggregatesResult)(nil)},
+	"getwork":                 {(*dcrjson.GetWorkResult)(nil), (*bool)(nil)},
+	"getcoinsupply":           {(*int64)(nil)},
+	"getcoinsupplybreakdown":  {(*dcrjson.GetCoinSupplyBreakdownResult)(nil)},
+	"help":                    {(*string)(nil), (*string)(nil)},
+	"importaddrman":           {(*dcrjson.ImportAddrManResult)(nil)},
+	"invalidateblock":         nil,
+	"listjobs":                {(*[]dcrjson.JobResult)(nil)},
+	"livetickets":             {(*dcrjson.LiveTicketsResult)(nil)},
+	"loadutxoset":             {(*dcrjson.UtxoSnapshotResult)(nil), (*dcrjson.JobResult)(nil)},
+	"missedtickets":           {(*dcrjson.MissedTicketsResult)(nil)},
+	"node":                    nil,
+	"ping":                    nil,
+	"reconsiderblock":         nil,
+	"rebroadcastmissed":       nil,
+	"rebroadcastwinners":      nil,
+	"rebuildindex":            {(*dcrjson.JobResult)(nil)},
+	"searchrawtransactions":   {(*string)(nil), (*[]dcrjson.SearchRawTransactionsResult)(nil)},
+	"sendrawtransaction":      {(*string)(nil)},
+	"setblockannotation":      nil,
+	"setgenerate":             nil,
+	"startprofile":            {(*dcrjson.StartProfileResult)(nil)},
+	"stop":                    {(*string)(nil)},
+	"submitblock":             {nil, (*string)(nil), (*dcrjson.SubmitBlockRejectResult)(nil)},
+	"ticketfeeinfo":           {(*dcrjson.TicketFeeInfoResult)(nil)},
+	"ticketsforaddress":       {(*dcrjson.TicketsForAddressResult)(nil)},
+	"ticketvwap":              {(*float64)(nil)},
+	"txfeeinfo":               {(*dcrjson.TxFeeInfoResult)(nil)},
+	"validateaddress":         {(*dcrjson.ValidateAddressChainResult)(nil)},
+	"verifyindex":             {(*dcrjson.JobResult)(nil)},
+	"verifychain":             {(*bool)(nil), (*dcrjson.JobResult)(nil)},
+	"verifymessage":           {(*bool)(nil)},
+	"version":                 {(*map[string]dcrjson.VersionResult)(nil)},
+	"warpclock":               {(*dcrjson.WarpClockResult)(nil)},
 
 	// Websocket commands.
-	"loadtxfilter":                nil,
-	"session":                     {(*dcrjson.SessionResult)(nil)},
-	"notifywinningtickets":        nil,
-	"notifyspentandmissedtickets": nil,
-	"notifynewtickets":            nil,
-	"notifystakedifficulty":       nil,
-	"notifyblocks":                nil,
-	"notifynewtransactions":       nil,
-	"notifyreceived":              nil,
-	"notifyspent":                 nil,
-	"rescan":                      nil,
-	"stopnotifyblocks":            nil,
-	"stopnotifynewtransactions":   nil,
-	"stopnotifyreceived":          nil,
-	"stopnotifyspent":             nil,
+	"acknotification":              nil,
+	"loadtxfilter":                 nil,
+	"registervotingwallet":         nil,
+	"session":                      {(*dcrjson.SessionResult)(nil)},
+	"notifywinningtickets":         nil,
+	"notifyspentandmissedtickets":  nil,
+	"notifynewtickets":             nil,
+	"notifystakedifficulty":        nil,
+	"notifystakedifficultychanged": nil,
+	"notifyblocks":                 nil,
+	"notifynewtransactions":        nil,
+	"notifysidechainblocks":        nil,
+	"notifywork":                   nil,
+	"notifyjobs":                   nil,
+	"notifystakeboundaries":        nil,
+	"notifyreceived":               nil,
+	"notifyspent":                  nil,
+	"rescan":                       nil,
+	"stopnotifyblocks":             nil,
+	"stopnotifynewtransactions":    nil,
+	"stopnotifysidechainblocks":    nil,
+	"stopnotifywork":               nil,
+	"stopnotifyjobs":               nil,
+	"stopnotifystakeboundaries":    nil,
+	"stopnotifyreceived":           nil,
+	"stopnotifyspent":              nil,
 }
 
 // helpCacher provides a concurrent safe type that provides help and usage for
@@ -0,0 +1,295 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrjson"
+)
+
+const (
+	// votingWalletRetransmitInterval is the interval at which notifications
+	// which have not yet been acknowledged by a voting wallet are sent
+	// again.
+	votingWalletRetransmitInterval = time.Second * 2
+
+	// votingWalletDeliveryDeadline is the maximum amount of time delivery
+	// of a notification to a voting wallet is attempted for.  Votes must be
+	// included in the block after the one they vote on, so there is no
+	// point in delivering notifications well after the next block is
+	// expected to be found.
+	votingWalletDeliveryDeadline = time.Second * 30
+)
+
+// votingWalletPendingNtfn describes a notification which has been sent to a
+// voting wallet but not yet acknowledged.
+type votingWalletPendingNtfn struct {
+	marshalled  []byte
+	firstSent   time.Time
+	retransmits int
+}
+
+// votingWalletState houses the notifications which are awaiting acknowledgement
+// by a websocket client that registered as a voting wallet.  Notifications are
+// keyed by their method and the hash of the block they refer to, which is what
+// the client provides when acknowledging them.
+type votingWalletState struct {
+	mtx     sync.Mutex
+	pending map[string]*votingWalletPendingNtfn
+}
+
+// votingWalletNtfnKey returns the key used to track a notification of the
+// given method for the given block hash.
+func votingWalletNtfnKey(method, blockHash string) string {
+	return method + ":" + blockHash
+}
+
+// votingWalletStats houses the delivery metrics for all voting wallets.
+type votingWalletStats struct {
+	mtx             sync.Mutex
+	clients         int64
+	pending         int64
+	delivered       uint64
+	retransmissions uint64
+	expired         uint64
+	totalLatency    time.Duration
+	maxLatency      time.Duration
+}
+
+// snapshot returns the current voting wallet delivery metrics.
+//
+// This function is safe for concurrent access.
+func (s *votingWalletStats) snapshot() *dcrjson.GetVotingWalletStatsResult {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var avgLatency time.Duration
+	if s.delivered > 0 {
+		avgLatency = s.totalLatency / time.Duration(s.delivered)
+	}
+	return &dcrjson.GetVotingWalletStatsResult{
+		Clients:         s.clients,
+		Pending:         s.pending,
+		Delivered:       s.delivered,
+		Retransmissions: s.retransmissions,
+		Expired:         s.expired,
+		AvgLatency:      int64(avgLatency / time.Millisecond),
+		MaxLatency:      int64(s.maxLatency / time.Millisecond),
+	}
+}
+
+// votingWallet returns the voting wallet delivery state for the client or nil
+// if the client has not registered as a voting wallet.
+//
+// This function is safe for concurrent access.
+func (c *wsClient) votingWallet() *votingWalletState {
+	c.Lock()
+	state := c.votingWalletState
+	c.Unlock()
+	return state
+}
+
+// trackVotingWalletNtfn records that the passed notification of the given
+// method for the given block hash was sent so that it will be retransmitted
+// until it is acknowledged or the delivery deadline passes.  Nothing is done if
+// the client has not registered as a voting wallet.
+//
+// This function is safe for concurrent access.
+func (c *wsClient) trackVotingWalletNtfn(method, blockHash string, marshalled []byte) {
+	state := c.votingWallet()
+	if state == nil {
+		return
+	}
+
+	key := votingWalletNtfnKey(method, blockHash)
+	state.mtx.Lock()
+	if state.pending == nil {
+		// The client disconnected.
+		state.mtx.Unlock()
+		return
+	}
+	_, exists := state.pending[key]
+	if !exists {
+		state.pending[key] = &votingWalletPendingNtfn{
+			marshalled: marshalled,
+			firstSent:  time.Now(),
+		}
+	}
+	state.mtx.Unlock()
+
+	if !exists {
+		stats := &c.server.ntfnMgr.votingWalletStats
+		stats.mtx.Lock()
+		stats.pending++
+		stats.mtx.Unlock()
+	}
+}
+
+// ackVotingWalletNtfn removes the notification of the given method for the
+// given block hash from the set awaiting acknowledgement and records its
+// delivery latency.  It returns whether or not the notification was awaiting
+// acknowledgement.
+//
+// This function is safe for concurrent access.
+func (c *wsClient) ackVotingWalletNtfn(method, blockHash string) bool {
+	state := c.votingWallet()
+	if state == nil {
+		return false
+	}
+
+	key := votingWalletNtfnKey(method, blockHash)
+	state.mtx.Lock()
+	ntfn, ok := state.pending[key]
+	delete(state.pending, key)
+	state.mtx.Unlock()
+	if !ok {
+		return false
+	}
+
+	if method == dcrjson.WinningTicketsNtfnMethod &&
+		c.server.missedTickets != nil {
+		c.server.missedTickets.RecordAcknowledgement(blockHash)
+	}
+
+	latency := time.Since(ntfn.firstSent)
+	stats := &c.server.ntfnMgr.votingWalletStats
+	stats.mtx.Lock()
+	stats.pending--
+	stats.delivered++
+	stats.totalLatency += latency
+	if latency > stats.maxLatency {
+		stats.maxLatency = latency
+	}
+	stats.mtx.Unlock()
+	return true
+}
+
+// retransmitVotingWalletNtfns sends all notifications which are still awaiting
+// acknowledgement again and gives up on those whose delivery deadline has
+// passed.
+func (c *wsClient) retransmitVotingWalletNtfns(state *votingWalletState) {
+	var retransmit [][]byte
+	var expired int64
+	now := time.Now()
+	state.mtx.Lock()
+	for key, ntfn := range state.pending {
+		if now.Sub(ntfn.firstSent) > votingWalletDeliveryDeadline {
+			delete(state.pending, key)
+			expired++
+			continue
+		}
+		ntfn.retransmits++
+		retransmit = append(retransmit, ntfn.marshalled)
+	}
+	state.mtx.Unlock()
+
+	stats := &c.server.ntfnMgr.votingWalletStats
+	stats.mtx.Lock()
+	stats.pending -= expired
+	stats.expired += uint64(expired)
+	stats.retransmissions += uint64(len(retransmit))
+	stats.mtx.Unlock()
+
+	if expired > 0 {
+		rpcsLog.Warnf("Failed to deliver %d notification(s) to voting "+
+			"wallet %s before the deadline", expired, c.addr)
+	}
+	for _, marshalled := range retransmit {
+		if err := c.QueueNotification(marshalled); err == ErrClientQuit {
+			return
+		}
+	}
+}
+
+// votingWalletHandler periodically retransmits the notifications which have
+// not been acknowledged by a voting wallet until the client disconnects.  It
+// must be run as a goroutine.
+func (c *wsClient) votingWalletHandler(state *votingWalletState) {
+	ticker := time.NewTicker(votingWalletRetransmitInterval)
+	defer ticker.Stop()
+
+out:
+	for {
+		select {
+		case <-ticker.C:
+			c.retransmitVotingWalletNtfns(state)
+
+		case <-c.quit:
+			break out
+		}
+	}
+
+	// Notifications that are still pending will never be delivered.
+	state.mtx.Lock()
+	numPending := int64(len(state.pending))
+	state.pending = nil
+	state.mtx.Unlock()
+
+	stats := &c.server.ntfnMgr.votingWalletStats
+	stats.mtx.Lock()
+	stats.clients--
+	stats.pending -= numPending
+	stats.expired += uint64(numPending)
+	stats.mtx.Unlock()
+
+	c.wg.Done()
+	rpcsLog.Tracef("Voting wallet handler done for %s", c.addr)
+}
+
+// handleRegisterVotingWallet implements the registervotingwallet command
+// extension for websocket connections.  It classifies the client as a voting
+// wallet so that the winningtickets and spentandmissedtickets notifications it
+// receives are retransmitted until they are acknowledged with the
+// acknotification command or the delivery deadline passes.
+func handleRegisterVotingWallet(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.Lock()
+	if wsc.votingWalletState != nil || wsc.disconnected {
+		wsc.Unlock()
+		return nil, nil
+	}
+	state := &votingWalletState{
+		pending: make(map[string]*votingWalletPendingNtfn),
+	}
+	wsc.votingWalletState = state
+	wsc.wg.Add(1)
+	wsc.Unlock()
+
+	stats := &wsc.server.ntfnMgr.votingWalletStats
+	stats.mtx.Lock()
+	stats.clients++
+	stats.mtx.Unlock()
+
+	go wsc.votingWalletHandler(state)
+	return nil, nil
+}
+
+// handleAckNotification implements the acknotification command extension for
+// websocket connections.
+func handleAckNotification(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*dcrjson.AckNotificationCmd)
+	if !ok {
+		return nil, dcrjson.ErrRPCInternal
+	}
+
+	if wsc.votingWallet() == nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: "client is not registered as a voting wallet",
+		}
+	}
+
+	// Acknowledging a notification which already expired or was previously
+	// acknowledged is not an error since retransmissions may cross with the
+	// acknowledgement.
+	wsc.ackVotingWalletNtfn(cmd.Method, cmd.BlockHash)
+	return nil, nil
+}
+
+// handleGetVotingWalletStats implements the getvotingwalletstats command.
+func handleGetVotingWalletStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.ntfnMgr.votingWalletStats.snapshot(), nil
+}
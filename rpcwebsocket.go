@@ -16,6 +16,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"strconv"
 	"sync"
 	"time"
@@ -27,6 +28,7 @@ import (
 	"github.com/decred/dcrd/blockchain/stake"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/mempool"
 	"github.com/decred/dcrd/txscript"
 	"github.com/decred/dcrd/wire"
 	"github.com/decred/dcrutil"
@@ -54,18 +56,29 @@ type wsCommandHandler func(*wsClient, interface{}) (interface{}, error)
 // causes a dependency loop.
 var wsHandlers map[string]wsCommandHandler
 var wsHandlersBeforeInit = map[string]wsCommandHandler{
-	"loadtxfilter":                handleLoadTxFilter,
-	"notifyblocks":                handleNotifyBlocks,
-	"notifywinningtickets":        handleWinningTickets,
-	"notifyspentandmissedtickets": handleSpentAndMissedTickets,
-	"notifynewtickets":            handleNewTickets,
-	"notifystakedifficulty":       handleStakeDifficulty,
-	"notifynewtransactions":       handleNotifyNewTransactions,
-	"session":                     handleSession,
-	"help":                        handleWebsocketHelp,
-	"rescan":                      handleRescan,
-	"stopnotifyblocks":            handleStopNotifyBlocks,
-	"stopnotifynewtransactions":   handleStopNotifyNewTransactions,
+	"acknotification":              handleAckNotification,
+	"loadtxfilter":                 handleLoadTxFilter,
+	"notifyblocks":                 handleNotifyBlocks,
+	"notifyjobs":                   handleNotifyJobs,
+	"notifywinningtickets":         handleWinningTickets,
+	"notifyspentandmissedtickets":  handleSpentAndMissedTickets,
+	"notifynewtickets":             handleNewTickets,
+	"notifystakeboundaries":        handleNotifyStakeBoundaries,
+	"notifystakedifficulty":        handleStakeDifficulty,
+	"notifystakedifficultychanged": handleStakeDifficultyChanged,
+	"notifynewtransactions":        handleNotifyNewTransactions,
+	"notifysidechainblocks":        handleNotifySideChainBlocks,
+	"notifywork":                   handleNotifyWork,
+	"registervotingwallet":         handleRegisterVotingWallet,
+	"session":                      handleSession,
+	"help":                         handleWebsocketHelp,
+	"rescan":                       handleRescan,
+	"stopnotifyblocks":             handleStopNotifyBlocks,
+	"stopnotifyjobs":               handleStopNotifyJobs,
+	"stopnotifynewtransactions":    handleStopNotifyNewTransactions,
+	"stopnotifysidechainblocks":    handleStopNotifySideChainBlocks,
+	"stopnotifystakeboundaries":    handleStopNotifyStakeBoundaries,
+	"stopnotifywork":               handleStopNotifyWork,
 }
 
 // wsAsyncHandlers holds the websocket commands which should be run
@@ -137,6 +150,10 @@ type wsNotificationManager struct {
 	// Access channel for current number of connected clients.
 	numClients chan int
 
+	// votingWalletStats houses the notification delivery metrics for
+	// clients registered as voting wallets.
+	votingWalletStats votingWalletStats
+
 	// Shutdown handling
 	wg   sync.WaitGroup
 	quit chan struct{}
@@ -193,6 +210,8 @@ out:
 // queueHandler maintains a queue of notifications and notification handler
 // control messages.
 func (m *wsNotificationManager) queueHandler() {
+	defer recoverCrash("RPCS", m.server.server.blockManager.chain)
+
 	queueHandler(m.queueNotification, m.notificationMsgs, m.quit)
 	m.wg.Done()
 }
@@ -241,6 +260,11 @@ func (m *wsNotificationManager) NotifyReorganization(rd *blockchain.Reorganizati
 // to the notification manager for further processing.
 func (m *wsNotificationManager) NotifyWinningTickets(
 	wtnd *WinningTicketsNtfnData) {
+	if m.server.missedTickets != nil {
+		m.server.missedTickets.RecordWinningTickets(&wtnd.BlockHash,
+			wtnd.BlockHeight)
+	}
+
 	// As NotifyWinningTickets will be called by the block manager
 	// and the RPC server may no longer be running, use a select
 	// statement to unblock enqueuing the notification once the RPC
@@ -294,11 +318,97 @@ func (m *wsNotificationManager) NotifyStakeDifficulty(
 	}
 }
 
+// NotifyStakeBoundary passes the details of a stake boundary crossed by a block
+// connected to the main chain to the notification manager for stake boundary
+// notification processing.
+func (m *wsNotificationManager) NotifyStakeBoundary(sbnd *blockchain.StakeBoundaryNtfnsData) {
+	// As NotifyStakeBoundary will be called by the block manager and the
+	// RPC server may no longer be running, use a select statement to
+	// unblock enqueuing the notification once the RPC server has begun
+	// shutting down.
+	select {
+	case m.queueNotification <- (*notificationStakeBoundary)(sbnd):
+	case <-m.quit:
+	}
+}
+
+// NotifyWorkExpired passes the details of the work miners are now expected to
+// be working on to the notification manager for work expired notification
+// processing.
+func (m *wsNotificationManager) NotifyWorkExpired(wend *WorkExpiredNtfnData) {
+	// As NotifyWorkExpired will be called by the block manager and server
+	// and the RPC server may no longer be running, use a select statement
+	// to unblock enqueuing the notification once the RPC server has begun
+	// shutting down.
+	select {
+	case m.queueNotification <- (*notificationWorkExpired)(wend):
+	case <-m.quit:
+	}
+}
+
+// NotifyJobCompleted passes the details of a job which finished to the
+// notification manager for job completed notification processing.
+func (m *wsNotificationManager) NotifyJobCompleted(info *dcrjson.JobResult) {
+	// As NotifyJobCompleted will be called by the job manager and the RPC
+	// server may no longer be running, use a select statement to unblock
+	// enqueuing the notification once the RPC server has begun shutting
+	// down.
+	select {
+	case m.queueNotification <- (*notificationJobCompleted)(info):
+	case <-m.quit:
+	}
+}
+
+// NotifyTxConflict passes a transaction which was removed from the memory pool
+// due to conflicts to the notification manager for transaction conflict
+// notification processing.
+func (m *wsNotificationManager) NotifyTxConflict(removal *mempool.ConflictRemoval) {
+	// As NotifyTxConflict will be called by the block manager and the RPC
+	// server may no longer be running, use a select statement to unblock
+	// enqueuing the notification once the RPC server has begun shutting
+	// down.
+	select {
+	case m.queueNotification <- (*notificationTxConflict)(removal):
+	case <-m.quit:
+	}
+}
+
+// NotifyDoubleSpendProof passes a valid double spend proof to the notification
+// manager for double spend notification processing.
+func (m *wsNotificationManager) NotifyDoubleSpendProof(proof *wire.MsgDoubleSpendProof) {
+	// As NotifyDoubleSpendProof will be called by the block manager, the
+	// server and the RPC server, which may no longer be running, use a
+	// select statement to unblock enqueuing the notification once the RPC
+	// server has begun shutting down.
+	select {
+	case m.queueNotification <- (*notificationDoubleSpendProof)(proof):
+	case <-m.quit:
+	}
+}
+
+// NotifySideChainBlockConnected passes the details of a block which was
+// accepted to a side chain to the notification manager for side chain block
+// notification processing.
+func (m *wsNotificationManager) NotifySideChainBlockConnected(band *blockchain.BlockAcceptedNtfnsData) {
+	// As NotifySideChainBlockConnected will be called by the block manager
+	// and the RPC server may no longer be running, use a select statement
+	// to unblock enqueuing the notification once the RPC server has begun
+	// shutting down.
+	select {
+	case m.queueNotification <- (*notificationSideChainBlockConnected)(band):
+	case <-m.quit:
+	}
+}
+
 // NotifyMempoolTx passes a transaction accepted by mempool to the
 // notification manager for transaction notification processing.  If
 // isNew is true, the tx is is a new transaction, rather than one
 // added to the mempool during a reorg.
 func (m *wsNotificationManager) NotifyMempoolTx(tx *dcrutil.Tx, isNew bool) {
+	if isNew && m.server.missedTickets != nil {
+		m.server.missedTickets.RecordMempoolTx(tx)
+	}
+
 	n := &notificationTxAcceptedByMempool{
 		isNew: isNew,
 		tx:    tx,
@@ -324,11 +434,25 @@ type WinningTicketsNtfnData struct {
 }
 
 // StakeDifficultyNtfnData is the data that is used to generate
-// stake difficulty notifications.
+// stake difficulty notifications.  StakeDifficulty is the stake difficulty
+// required for the block after the block identified by BlockHash, while
+// PrevStakeDifficulty is the stake difficulty of that block.
 type StakeDifficultyNtfnData struct {
-	BlockHash       chainhash.Hash
-	BlockHeight     int64
-	StakeDifficulty int64
+	BlockHash           chainhash.Hash
+	BlockHeight         int64
+	StakeDifficulty     int64
+	PrevStakeDifficulty int64
+}
+
+// WorkExpiredNtfnData is the data that is used to generate work expired
+// notifications.  Work which was handed out before the notification that does
+// not build on the block identified by BlockHash with the given number of
+// votes is stale.  Reason is one of the dcrjson.WorkExpired* reasons.
+type WorkExpiredNtfnData struct {
+	Reason      string
+	BlockHash   chainhash.Hash
+	BlockHeight int64
+	Voters      uint16
 }
 
 type wsClientFilter struct {
@@ -498,6 +622,12 @@ type notificationWinningTickets WinningTicketsNtfnData
 type notificationSpentAndMissedTickets blockchain.TicketNotificationsData
 type notificationNewTickets blockchain.TicketNotificationsData
 type notificationStakeDifficulty StakeDifficultyNtfnData
+type notificationStakeBoundary blockchain.StakeBoundaryNtfnsData
+type notificationWorkExpired WorkExpiredNtfnData
+type notificationJobCompleted dcrjson.JobResult
+type notificationTxConflict mempool.ConflictRemoval
+type notificationDoubleSpendProof wire.MsgDoubleSpendProof
+type notificationSideChainBlockConnected blockchain.BlockAcceptedNtfnsData
 type notificationTxAcceptedByMempool struct {
 	isNew bool
 	tx    *dcrutil.Tx
@@ -508,7 +638,10 @@ type notificationRegisterClient wsClient
 type notificationUnregisterClient wsClient
 type notificationRegisterBlocks wsClient
 type notificationUnregisterBlocks wsClient
-type notificationRegisterWinningTickets wsClient
+type notificationRegisterWinningTickets struct {
+	wsc     *wsClient
+	verbose bool
+}
 type notificationUnregisterWinningTickets wsClient
 type notificationRegisterSpentAndMissedTickets wsClient
 type notificationUnregisterSpentAndMissedTickets wsClient
@@ -516,12 +649,24 @@ type notificationRegisterNewTickets wsClient
 type notificationUnregisterNewTickets wsClient
 type notificationRegisterStakeDifficulty wsClient
 type notificationUnregisterStakeDifficulty wsClient
+type notificationRegisterStakeDifficultyChanged wsClient
+type notificationUnregisterStakeDifficultyChanged wsClient
+type notificationRegisterStakeBoundaries wsClient
+type notificationUnregisterStakeBoundaries wsClient
 type notificationRegisterNewMempoolTxs wsClient
 type notificationUnregisterNewMempoolTxs wsClient
+type notificationRegisterWork wsClient
+type notificationUnregisterWork wsClient
+type notificationRegisterSideChainBlocks wsClient
+type notificationUnregisterSideChainBlocks wsClient
+type notificationRegisterJobs wsClient
+type notificationUnregisterJobs wsClient
 
 // notificationHandler reads notifications and control messages from the queue
 // handler and processes one at a time.
 func (m *wsNotificationManager) notificationHandler() {
+	defer recoverCrash("RPCS", m.server.server.blockManager.chain)
+
 	// clients is a map of all currently connected websocket clients.
 	clients := make(map[chan struct{}]*wsClient)
 
@@ -534,10 +679,16 @@ func (m *wsNotificationManager) notificationHandler() {
 	// since it is quite a bit more efficient than using the entire struct.
 	blockNotifications := make(map[chan struct{}]*wsClient)
 	winningTicketNotifications := make(map[chan struct{}]*wsClient)
+	verboseWinningTickets := make(map[chan struct{}]struct{})
 	ticketSMNotifications := make(map[chan struct{}]*wsClient)
 	ticketNewNotifications := make(map[chan struct{}]*wsClient)
 	stakeDifficultyNotifications := make(map[chan struct{}]*wsClient)
+	stakeDiffChangedNotifications := make(map[chan struct{}]*wsClient)
+	stakeBoundaryNotifications := make(map[chan struct{}]*wsClient)
 	txNotifications := make(map[chan struct{}]*wsClient)
+	workNotifications := make(map[chan struct{}]*wsClient)
+	sideChainBlockNotifications := make(map[chan struct{}]*wsClient)
+	jobNotifications := make(map[chan struct{}]*wsClient)
 
 out:
 	for {
@@ -569,6 +720,7 @@ out:
 
 			case *notificationWinningTickets:
 				m.notifyWinningTickets(winningTicketNotifications,
+					verboseWinningTickets,
 					(*WinningTicketsNtfnData)(n))
 
 			case *notificationSpentAndMissedTickets:
@@ -582,6 +734,21 @@ out:
 			case *notificationStakeDifficulty:
 				m.notifyStakeDifficulty(stakeDifficultyNotifications,
 					(*StakeDifficultyNtfnData)(n))
+				m.notifyStakeDifficultyChanged(
+					stakeDiffChangedNotifications,
+					(*StakeDifficultyNtfnData)(n))
+
+			case *notificationStakeBoundary:
+				m.notifyStakeBoundary(stakeBoundaryNotifications,
+					(*blockchain.StakeBoundaryNtfnsData)(n))
+
+			case *notificationWorkExpired:
+				m.notifyWorkExpired(workNotifications,
+					(*WorkExpiredNtfnData)(n))
+
+			case *notificationJobCompleted:
+				m.notifyJobCompleted(jobNotifications,
+					(*dcrjson.JobResult)(n))
 
 			case *notificationTxAcceptedByMempool:
 				if n.isNew && len(txNotifications) != 0 {
@@ -589,6 +756,19 @@ out:
 				}
 				m.notifyRelevantTxAccepted(n.tx, clients)
 
+			case *notificationTxConflict:
+				m.notifyTxConflict(txNotifications, clients,
+					(*mempool.ConflictRemoval)(n))
+
+			case *notificationDoubleSpendProof:
+				m.notifyDoubleSpendProof(txNotifications, clients,
+					(*wire.MsgDoubleSpendProof)(n))
+
+			case *notificationSideChainBlockConnected:
+				m.notifySideChainBlockConnected(
+					sideChainBlockNotifications,
+					(*blockchain.BlockAcceptedNtfnsData)(n))
+
 			case *notificationRegisterBlocks:
 				wsc := (*wsClient)(n)
 				blockNotifications[wsc.quit] = wsc
@@ -598,12 +778,18 @@ out:
 				delete(blockNotifications, wsc.quit)
 
 			case *notificationRegisterWinningTickets:
-				wsc := (*wsClient)(n)
+				wsc := n.wsc
 				winningTicketNotifications[wsc.quit] = wsc
+				if n.verbose {
+					verboseWinningTickets[wsc.quit] = struct{}{}
+				} else {
+					delete(verboseWinningTickets, wsc.quit)
+				}
 
 			case *notificationUnregisterWinningTickets:
 				wsc := (*wsClient)(n)
 				delete(winningTicketNotifications, wsc.quit)
+				delete(verboseWinningTickets, wsc.quit)
 
 			case *notificationRegisterSpentAndMissedTickets:
 				wsc := (*wsClient)(n)
@@ -629,6 +815,14 @@ out:
 				wsc := (*wsClient)(n)
 				delete(stakeDifficultyNotifications, wsc.quit)
 
+			case *notificationRegisterStakeDifficultyChanged:
+				wsc := (*wsClient)(n)
+				stakeDiffChangedNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterStakeDifficultyChanged:
+				wsc := (*wsClient)(n)
+				delete(stakeDiffChangedNotifications, wsc.quit)
+
 			case *notificationRegisterClient:
 				wsc := (*wsClient)(n)
 				clients[wsc.quit] = wsc
@@ -638,7 +832,14 @@ out:
 				// Remove any requests made by the client as well as
 				// the client itself.
 				delete(blockNotifications, wsc.quit)
+				delete(winningTicketNotifications, wsc.quit)
+				delete(verboseWinningTickets, wsc.quit)
 				delete(txNotifications, wsc.quit)
+				delete(stakeDiffChangedNotifications, wsc.quit)
+				delete(stakeBoundaryNotifications, wsc.quit)
+				delete(workNotifications, wsc.quit)
+				delete(sideChainBlockNotifications, wsc.quit)
+				delete(jobNotifications, wsc.quit)
 				delete(clients, wsc.quit)
 
 			case *notificationRegisterNewMempoolTxs:
@@ -649,6 +850,38 @@ out:
 				wsc := (*wsClient)(n)
 				delete(txNotifications, wsc.quit)
 
+			case *notificationRegisterWork:
+				wsc := (*wsClient)(n)
+				workNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterWork:
+				wsc := (*wsClient)(n)
+				delete(workNotifications, wsc.quit)
+
+			case *notificationRegisterSideChainBlocks:
+				wsc := (*wsClient)(n)
+				sideChainBlockNotifications[wsc.quit] = wsc
+
+			case *notificationRegisterStakeBoundaries:
+				wsc := (*wsClient)(n)
+				stakeBoundaryNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterStakeBoundaries:
+				wsc := (*wsClient)(n)
+				delete(stakeBoundaryNotifications, wsc.quit)
+
+			case *notificationRegisterJobs:
+				wsc := (*wsClient)(n)
+				jobNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterJobs:
+				wsc := (*wsClient)(n)
+				delete(jobNotifications, wsc.quit)
+
+			case *notificationUnregisterSideChainBlocks:
+				wsc := (*wsClient)(n)
+				delete(sideChainBlockNotifications, wsc.quit)
+
 			default:
 				rpcsLog.Warn("Unhandled notification type")
 			}
@@ -860,9 +1093,13 @@ func (m *wsNotificationManager) notifyReorganization(clients map[chan struct{}]*
 }
 
 // RegisterWinningTickets requests winning tickets update notifications
-// to the passed websocket client.
-func (m *wsNotificationManager) RegisterWinningTickets(wsc *wsClient) {
-	m.queueNotification <- (*notificationRegisterWinningTickets)(wsc)
+// to the passed websocket client.  The verbose flag specifies whether the
+// client is sent the additional details needed to vote.
+func (m *wsNotificationManager) RegisterWinningTickets(wsc *wsClient, verbose bool) {
+	m.queueNotification <- &notificationRegisterWinningTickets{
+		wsc:     wsc,
+		verbose: verbose,
+	}
 }
 
 // UnregisterWinningTickets removes winning ticket notifications for
@@ -871,10 +1108,40 @@ func (m *wsNotificationManager) UnregisterWinningTickets(wsc *wsClient) {
 	m.queueNotification <- (*notificationUnregisterWinningTickets)(wsc)
 }
 
+// ticketCommitmentAddrs returns the encoded addresses committed to by the
+// passed ticket.  The ticket must be unspent since the commitments are loaded
+// from the utxo set.
+func (m *wsNotificationManager) ticketCommitmentAddrs(ticket *chainhash.Hash) ([]string, error) {
+	entry, err := m.server.chain.FetchUtxoEntry(ticket)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("ticket %v is not unspent", ticket)
+	}
+
+	// The commitments are the odd numbered outputs of the ticket.
+	minOuts := blockchain.ConvertUtxosToMinimalOutputs(entry)
+	addrs := make([]string, 0, len(minOuts)/2)
+	for i := 1; i < len(minOuts); i += 2 {
+		addr, err := stake.AddrFromSStxPkScrCommitment(minOuts[i].PkScript,
+			m.server.server.chainParams)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr.EncodeAddress())
+	}
+	return addrs, nil
+}
+
 // notifyWinningTickets notifies websocket clients that have registered for
-// winning ticket updates.
-func (*wsNotificationManager) notifyWinningTickets(
-	clients map[chan struct{}]*wsClient, wtnd *WinningTicketsNtfnData) {
+// winning ticket updates.  Clients that requested verbose notifications, whose
+// quit channels are in the passed verbose set, are additionally sent the height
+// votes must be included by and the commitment addresses of each winning
+// ticket so they do not need to issue any further requests before voting.
+func (m *wsNotificationManager) notifyWinningTickets(
+	clients map[chan struct{}]*wsClient, verbose map[chan struct{}]struct{},
+	wtnd *WinningTicketsNtfnData) {
 
 	// Create a ticket map to export as JSON.
 	ticketMap := make(map[string]string)
@@ -883,18 +1150,55 @@ func (*wsNotificationManager) notifyWinningTickets(
 	}
 
 	// Notify interested websocket clients about the connected block.
-	ntfn := dcrjson.NewWinningTicketsNtfn(wtnd.BlockHash.String(),
-		int32(wtnd.BlockHeight), ticketMap)
-
-	marshalledJSON, err := dcrjson.MarshalCmd(nil, ntfn)
-	if err != nil {
-		rpcsLog.Error("Failed to marshal winning tickets notification: "+
-			"%v", err)
-		return
-	}
+	var marshalledJSON, marshalledVerboseJSON []byte
+	for quit, wsc := range clients {
+		if _, ok := verbose[quit]; !ok {
+			if marshalledJSON == nil {
+				ntfn := dcrjson.NewWinningTicketsNtfn(
+					wtnd.BlockHash.String(),
+					int32(wtnd.BlockHeight), ticketMap, nil, nil)
+				var err error
+				marshalledJSON, err = dcrjson.MarshalCmd(nil, ntfn)
+				if err != nil {
+					rpcsLog.Errorf("Failed to marshal winning "+
+						"tickets notification: %v", err)
+					return
+				}
+			}
+			wsc.QueueNotification(marshalledJSON)
+			wsc.trackVotingWalletNtfn(dcrjson.WinningTicketsNtfnMethod,
+				wtnd.BlockHash.String(), marshalledJSON)
+			continue
+		}
 
-	for _, wsc := range clients {
-		wsc.QueueNotification(marshalledJSON)
+		if marshalledVerboseJSON == nil {
+			// Votes for a block must be included in the next block.
+			deadline := int32(wtnd.BlockHeight + 1)
+			commitments := make(map[string][]string, len(wtnd.Tickets))
+			for i := range wtnd.Tickets {
+				ticket := &wtnd.Tickets[i]
+				addrs, err := m.ticketCommitmentAddrs(ticket)
+				if err != nil {
+					rpcsLog.Warnf("Unable to load commitments for "+
+						"winning ticket %v: %v", ticket, err)
+					continue
+				}
+				commitments[ticket.String()] = addrs
+			}
+			ntfn := dcrjson.NewWinningTicketsNtfn(wtnd.BlockHash.String(),
+				int32(wtnd.BlockHeight), ticketMap, &deadline,
+				&commitments)
+			var err error
+			marshalledVerboseJSON, err = dcrjson.MarshalCmd(nil, ntfn)
+			if err != nil {
+				rpcsLog.Errorf("Failed to marshal verbose winning "+
+					"tickets notification: %v", err)
+				return
+			}
+		}
+		wsc.QueueNotification(marshalledVerboseJSON)
+		wsc.trackVotingWalletNtfn(dcrjson.WinningTicketsNtfnMethod,
+			wtnd.BlockHash.String(), marshalledVerboseJSON)
 	}
 }
 
@@ -937,6 +1241,8 @@ func (*wsNotificationManager) notifySpentAndMissedTickets(
 
 	for _, wsc := range clients {
 		wsc.QueueNotification(marshalledJSON)
+		wsc.trackVotingWalletNtfn(dcrjson.SpentAndMissedTicketsNtfnMethod,
+			tnd.Hash.String(), marshalledJSON)
 	}
 }
 
@@ -964,6 +1270,18 @@ func (m *wsNotificationManager) UnregisterStakeDifficulty(wsc *wsClient) {
 	m.queueNotification <- (*notificationUnregisterStakeDifficulty)(wsc)
 }
 
+// RegisterStakeDifficultyChanged requests stake difficulty change
+// notifications to the passed websocket client.
+func (m *wsNotificationManager) RegisterStakeDifficultyChanged(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterStakeDifficultyChanged)(wsc)
+}
+
+// UnregisterStakeDifficultyChanged removes stake difficulty change
+// notifications for the passed websocket client.
+func (m *wsNotificationManager) UnregisterStakeDifficultyChanged(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterStakeDifficultyChanged)(wsc)
+}
+
 // notifyNewTickets notifies websocket clients that have registered for
 // maturing ticket updates.
 func (*wsNotificationManager) notifyNewTickets(clients map[chan struct{}]*wsClient,
@@ -1012,6 +1330,208 @@ func (*wsNotificationManager) notifyStakeDifficulty(
 	}
 }
 
+// notifyStakeDifficultyChanged notifies websocket clients that have registered
+// for stake difficulty change updates when the block after the passed one
+// begins a new stake difficulty window.
+func (m *wsNotificationManager) notifyStakeDifficultyChanged(
+	clients map[chan struct{}]*wsClient,
+	sdnd *StakeDifficultyNtfnData) {
+
+	// Nothing to do when there are no interested clients or the next block
+	// does not begin a new stake difficulty window.
+	windowSize := m.server.server.chainParams.StakeDiffWindowSize
+	firstHeight := sdnd.BlockHeight + 1
+	if len(clients) == 0 || firstHeight%windowSize != 0 {
+		return
+	}
+
+	ntfn := dcrjson.NewStakeDifficultyChangedNtfn(sdnd.BlockHash.String(),
+		int32(sdnd.BlockHeight), sdnd.PrevStakeDifficulty,
+		sdnd.StakeDifficulty, int32(firstHeight))
+
+	marshalledJSON, err := dcrjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal stake difficulty changed "+
+			"notification: %v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// RegisterWorkUpdates requests work expired notifications to the passed
+// websocket client.
+func (m *wsNotificationManager) RegisterWorkUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterWork)(wsc)
+}
+
+// UnregisterWorkUpdates removes work expired notifications for the passed
+// websocket client.
+func (m *wsNotificationManager) UnregisterWorkUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterWork)(wsc)
+}
+
+// notifyWorkExpired notifies websocket clients that have registered for work
+// updates that work previously handed out to them is stale.
+func (*wsNotificationManager) notifyWorkExpired(clients map[chan struct{}]*wsClient,
+	wend *WorkExpiredNtfnData) {
+
+	// Nothing to do when there are no interested clients.
+	if len(clients) == 0 {
+		return
+	}
+
+	ntfn := dcrjson.NewWorkExpiredNtfn(wend.Reason, wend.BlockHash.String(),
+		wend.BlockHeight, wend.Voters)
+	marshalledJSON, err := dcrjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal work expired notification: "+
+			"%v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// RegisterStakeBoundaryUpdates requests stake boundary notifications to the
+// passed websocket client.
+func (m *wsNotificationManager) RegisterStakeBoundaryUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterStakeBoundaries)(wsc)
+}
+
+// UnregisterStakeBoundaryUpdates removes stake boundary notifications for the
+// passed websocket client.
+func (m *wsNotificationManager) UnregisterStakeBoundaryUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterStakeBoundaries)(wsc)
+}
+
+// stakeBoundaryNames maps the stake boundaries crossed by blocks to the names
+// used for them in stakeboundary notifications.
+var stakeBoundaryNames = map[blockchain.StakeBoundary]string{
+	blockchain.SBTicketPriceInterval: dcrjson.StakeBoundaryTicketPriceInterval,
+	blockchain.SBTicketsMatured:      dcrjson.StakeBoundaryTicketsMatured,
+	blockchain.SBTicketsExpired:      dcrjson.StakeBoundaryTicketsExpired,
+}
+
+// notifyStakeBoundary notifies websocket clients that have registered for
+// stake boundary updates that a block connected to the main chain crossed a
+// stake boundary.
+func (*wsNotificationManager) notifyStakeBoundary(clients map[chan struct{}]*wsClient,
+	sbnd *blockchain.StakeBoundaryNtfnsData) {
+
+	// Nothing to do when there are no interested clients.
+	if len(clients) == 0 {
+		return
+	}
+
+	boundary, ok := stakeBoundaryNames[sbnd.Boundary]
+	if !ok {
+		rpcsLog.Errorf("Unknown stake boundary %v", sbnd.Boundary)
+		return
+	}
+	var tickets []string
+	if len(sbnd.Tickets) > 0 {
+		tickets = make([]string, 0, len(sbnd.Tickets))
+		for i := range sbnd.Tickets {
+			tickets = append(tickets, sbnd.Tickets[i].String())
+		}
+	}
+	ntfn := dcrjson.NewStakeBoundaryNtfn(boundary, sbnd.Hash.String(),
+		sbnd.Height, sbnd.StakeDifficulty, sbnd.PoolSize,
+		sbnd.PrevStakeDifficulty, sbnd.IntervalEndHeight, tickets)
+	marshalledJSON, err := dcrjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal stake boundary notification: "+
+			"%v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// RegisterJobUpdates requests job completed notifications to the passed
+// websocket client.
+func (m *wsNotificationManager) RegisterJobUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterJobs)(wsc)
+}
+
+// UnregisterJobUpdates removes job completed notifications for the passed
+// websocket client.
+func (m *wsNotificationManager) UnregisterJobUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterJobs)(wsc)
+}
+
+// notifyJobCompleted notifies websocket clients that have registered for job
+// updates that a job finished.
+func (*wsNotificationManager) notifyJobCompleted(clients map[chan struct{}]*wsClient,
+	info *dcrjson.JobResult) {
+
+	// Nothing to do when there are no interested clients.
+	if len(clients) == 0 {
+		return
+	}
+
+	ntfn := dcrjson.NewJobCompletedNtfn(info.JobID, info.Method,
+		info.State, info.Error)
+	marshalledJSON, err := dcrjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal job completed notification: "+
+			"%v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// RegisterSideChainBlockUpdates requests side chain block notifications to the
+// passed websocket client.
+func (m *wsNotificationManager) RegisterSideChainBlockUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterSideChainBlocks)(wsc)
+}
+
+// UnregisterSideChainBlockUpdates removes side chain block notifications for the
+// passed websocket client.
+func (m *wsNotificationManager) UnregisterSideChainBlockUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterSideChainBlocks)(wsc)
+}
+
+// notifySideChainBlockConnected notifies websocket clients that have registered
+// for side chain block updates that a block extending a side chain was
+// accepted.
+func (*wsNotificationManager) notifySideChainBlockConnected(clients map[chan struct{}]*wsClient,
+	band *blockchain.BlockAcceptedNtfnsData) {
+
+	// Nothing to do when there are no interested clients.
+	if len(clients) == 0 {
+		return
+	}
+
+	headerBytes, err := band.Block.MsgBlock().Header.Bytes()
+	if err != nil {
+		rpcsLog.Errorf("Failed to serialize header for side chain "+
+			"block notification: %v", err)
+		return
+	}
+	workBehindTip := new(big.Int).Sub(band.BestWorkSum, band.WorkSum)
+	ntfn := dcrjson.NewSideChainBlockConnectedNtfn(
+		hex.EncodeToString(headerBytes),
+		fmt.Sprintf("%064x", band.WorkSum),
+		fmt.Sprintf("%064x", workBehindTip))
+	marshalledJSON, err := dcrjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal side chain block "+
+			"notification: %v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 // RegisterNewMempoolTxsUpdates requests notifications to the passed websocket
 // client when new transactions are added to the memory pool.
 func (m *wsNotificationManager) RegisterNewMempoolTxsUpdates(wsc *wsClient) {
@@ -1075,6 +1595,116 @@ func (m *wsNotificationManager) notifyForNewTx(clients map[chan struct{}]*wsClie
 	}
 }
 
+// notifyTxConflict notifies websocket clients that a transaction was removed
+// from the memory pool due to conflicts.  Clients which have registered for
+// updates when new transactions are added to the memory pool are always
+// notified, while other clients are only notified when their transaction
+// filter watches one of the outpoints spent by the removed transaction.
+func (m *wsNotificationManager) notifyTxConflict(txClients map[chan struct{}]*wsClient,
+	clients map[chan struct{}]*wsClient, removal *mempool.ConflictRemoval) {
+
+	clientsToNotify := make(map[chan struct{}]*wsClient, len(txClients))
+	for q, c := range txClients {
+		clientsToNotify[q] = c
+	}
+	msgTx := removal.Tx.MsgTx()
+	for q, c := range clients {
+		if _, ok := clientsToNotify[q]; ok {
+			continue
+		}
+		c.Lock()
+		f := c.filterData
+		c.Unlock()
+		if f == nil {
+			continue
+		}
+
+		f.mu.Lock()
+		for _, input := range msgTx.TxIn {
+			if f.existsUnspentOutPoint(&input.PreviousOutPoint) {
+				clientsToNotify[q] = c
+				break
+			}
+		}
+		f.mu.Unlock()
+	}
+	if len(clientsToNotify) == 0 {
+		return
+	}
+
+	ntfn := dcrjson.NewTxConflictNtfn(removal.Tx.Hash().String(),
+		txConflictsJSON(removal.Conflicts))
+	marshalledJSON, err := dcrjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal tx conflict notification: %v",
+			err)
+		return
+	}
+	for _, wsc := range clientsToNotify {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// notifyDoubleSpendProof notifies websocket clients of a double spend proof.
+// Clients which have registered for updates when new transactions are added to
+// the memory pool are always notified, while other clients are only notified
+// when their transaction filter watches the double spent outpoint.
+func (m *wsNotificationManager) notifyDoubleSpendProof(txClients map[chan struct{}]*wsClient,
+	clients map[chan struct{}]*wsClient, proof *wire.MsgDoubleSpendProof) {
+
+	clientsToNotify := make(map[chan struct{}]*wsClient, len(txClients))
+	for q, c := range txClients {
+		clientsToNotify[q] = c
+	}
+	for q, c := range clients {
+		if _, ok := clientsToNotify[q]; ok {
+			continue
+		}
+		c.Lock()
+		f := c.filterData
+		c.Unlock()
+		if f == nil {
+			continue
+		}
+
+		f.mu.Lock()
+		if f.existsUnspentOutPoint(&proof.OutPoint) {
+			clientsToNotify[q] = c
+		}
+		f.mu.Unlock()
+	}
+	if len(clientsToNotify) == 0 {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := proof.BtcEncode(&buf, wire.ProtocolVersion)
+	if err != nil {
+		rpcsLog.Errorf("Failed to serialize double spend proof: %v", err)
+		return
+	}
+	outPoint := dcrjson.OutPoint{
+		Hash:  proof.OutPoint.Hash.String(),
+		Tree:  proof.OutPoint.Tree,
+		Index: proof.OutPoint.Index,
+	}
+	txIDs := []string{
+		proof.Spends[0].Tx.TxHash().String(),
+		proof.Spends[1].Tx.TxHash().String(),
+	}
+	ntfn := dcrjson.NewDoubleSpendProofNtfn(outPoint, txIDs,
+		hex.EncodeToString(buf.Bytes()))
+	marshalledJSON, err := dcrjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal double spend proof "+
+			"notification: %v", err)
+		return
+	}
+	for _, wsc := range clientsToNotify {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 // txHexString returns the serialized transaction encoded in hexadecimal.
 func txHexString(tx *wire.MsgTx) string {
 	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
@@ -1253,6 +1883,11 @@ type wsClient struct {
 	// information about all new transactions.
 	verboseTxUpdates bool
 
+	// votingWalletState is set when the client has registered as a voting
+	// wallet and tracks the notifications awaiting acknowledgement.  It is
+	// protected by the embedded mutex.
+	votingWalletState *votingWalletState
+
 	filterData *wsClientFilter
 
 	// Networking infrastructure.
@@ -1264,12 +1899,26 @@ type wsClient struct {
 	wg           sync.WaitGroup
 }
 
+// auditReply writes an entry to the RPC audit log for a request for the passed
+// method and parameters which was received at the passed time and replied to
+// with the passed marshalled reply and error.  It does nothing when the RPC
+// audit log is not enabled.
+func (c *wsClient) auditReply(start time.Time, method string, params []json.RawMessage, reply []byte, replyErr error) {
+	if c.server.auditor == nil {
+		return
+	}
+	entry := newRPCAuditEntry(start, rpcAuditTransportWebsocket, c.addr,
+		c.isAdmin, method, params)
+	c.server.auditor.write(entry, reply, replyErr)
+}
+
 // handleMessage is the main handler for incoming requests.  It enforces
 // authentication, parses the incoming json, looks up and executes handlers
 // (including pass through for standard RPC commands), and sends the appropriate
 // response.  It also detects commands which are marked as long-running and
 // sends them off to the asyncHander for processing.
 func (c *wsClient) handleMessage(msg []byte) {
+	start := time.Now()
 	if !c.authenticated {
 		// Disconnect immediately if the provided command fails to
 		// parse when the client is not already authenticated.
@@ -1302,6 +1951,12 @@ func (c *wsClient) handleMessage(msg []byte) {
 		limitcmp := subtle.ConstantTimeCompare(authHash[:], c.server.limitauthsha[:])
 		if cmp != 1 && limitcmp != 1 {
 			rpcsLog.Warnf("Auth failure.")
+			if c.server.auditor != nil {
+				entry := newRPCAuthFailureEntry(start,
+					rpcAuditTransportWebsocket, c.addr,
+					authCmd.Username, parsedCmd.method)
+				c.server.auditor.write(entry, nil, nil)
+			}
 			c.Disconnect()
 			return
 		}
@@ -1315,6 +1970,7 @@ func (c *wsClient) handleMessage(msg []byte) {
 				"%v", err.Error())
 			return
 		}
+		c.auditReply(start, parsedCmd.method, parsedCmd.params, reply, nil)
 		c.SendMessage(reply, nil)
 		return
 	}
@@ -1334,6 +1990,7 @@ func (c *wsClient) handleMessage(msg []byte) {
 				"reply: %v", err)
 			return
 		}
+		c.auditReply(start, "", nil, reply, jsonErr)
 		c.SendMessage(reply, nil)
 		return
 	}
@@ -1357,6 +2014,8 @@ func (c *wsClient) handleMessage(msg []byte) {
 					"reply: %v", err)
 				return
 			}
+			c.auditReply(start, request.Method, request.Params, reply,
+				jsonErr)
 			c.SendMessage(reply, nil)
 			return
 		}
@@ -1372,6 +2031,7 @@ func (c *wsClient) handleMessage(msg []byte) {
 				"reply: %v", err)
 			return
 		}
+		c.auditReply(start, cmd.method, cmd.params, reply, cmd.err)
 		c.SendMessage(reply, nil)
 		return
 	}
@@ -1415,6 +2075,7 @@ func (c *wsClient) handleMessage(msg []byte) {
 			return
 		}
 
+		c.auditReply(start, cmd.method, cmd.params, reply, jsonErr)
 		c.SendMessage(reply, nil)
 		return
 	}
@@ -1427,12 +2088,15 @@ func (c *wsClient) handleMessage(msg []byte) {
 			cmd.method, err)
 		return
 	}
+	c.auditReply(start, cmd.method, cmd.params, reply, jsonErr)
 	c.SendMessage(reply, nil)
 }
 
 // inHandler handles all incoming messages for the websocket connection.  It
 // must be run as a goroutine.
 func (c *wsClient) inHandler() {
+	defer recoverCrash("RPCS", c.server.server.blockManager.chain)
+
 out:
 	for {
 		// Break out of the loop once the quit channel has been closed.
@@ -1468,6 +2132,8 @@ out:
 // manager) which are queuing the data.  The data is passed on to outHandler to
 // actually be written.  It must be run as a goroutine.
 func (c *wsClient) notificationQueueHandler() {
+	defer recoverCrash("RPCS", c.server.server.blockManager.chain)
+
 	ntfnSentChan := make(chan bool, 1) // nonblocking sync
 
 	// pendingNtfns is used as a queue for notifications that are ready to
@@ -1539,6 +2205,8 @@ cleanup:
 // messages while allowing the sender to continue running asynchronously.  It
 // must be run as a goroutine.
 func (c *wsClient) outHandler() {
+	defer recoverCrash("RPCS", c.server.server.blockManager.chain)
+
 out:
 	for {
 		// Send any messages ready for send until the quit channel is
@@ -1591,6 +2259,8 @@ func (c *wsClient) asyncHandler() {
 	// runHandler runs the handler for the passed command and sends the
 	// reply.
 	runHandler := func(parsedCmd *parsedRPCCmd) {
+		defer recoverCrash("RPCS", c.server.server.blockManager.chain)
+
 		wsHandler, ok := wsHandlers[parsedCmd.method]
 		if !ok {
 			rpcsLog.Warnf("No handler for command <%s>",
@@ -1598,7 +2268,10 @@ func (c *wsClient) asyncHandler() {
 			return
 		}
 
-		// Invoke the handler and marshal and send response.
+		// Invoke the handler and marshal and send response.  The latency
+		// of long-running requests is audited from when they start being
+		// handled rather than from when they were queued.
+		start := time.Now()
 		result, jsonErr := wsHandler(c, parsedCmd.cmd)
 		reply, err := createMarshalledReply(parsedCmd.id, result,
 			jsonErr)
@@ -1607,6 +2280,8 @@ func (c *wsClient) asyncHandler() {
 				"command: %v", parsedCmd.method, err)
 			return
 		}
+		c.auditReply(start, parsedCmd.method, parsedCmd.params, reply,
+			jsonErr)
 		c.SendMessage(reply, nil)
 	}
 
@@ -1882,7 +2557,13 @@ func handleSession(wsc *wsClient, icmd interface{}) (interface{}, error) {
 // extension for websocket connections.
 func handleWinningTickets(wsc *wsClient, icmd interface{}) (interface{},
 	error) {
-	wsc.server.ntfnMgr.RegisterWinningTickets(wsc)
+	cmd, ok := icmd.(*dcrjson.NotifyWinningTicketsCmd)
+	if !ok {
+		return nil, dcrjson.ErrRPCInternal
+	}
+
+	verbose := cmd.Verbose != nil && *cmd.Verbose
+	wsc.server.ntfnMgr.RegisterWinningTickets(wsc, verbose)
 	return nil, nil
 }
 
@@ -1910,6 +2591,14 @@ func handleStakeDifficulty(wsc *wsClient, icmd interface{}) (interface{},
 	return nil, nil
 }
 
+// handleStakeDifficultyChanged implements the notifystakedifficultychanged
+// command extension for websocket connections.
+func handleStakeDifficultyChanged(wsc *wsClient, icmd interface{}) (interface{},
+	error) {
+	wsc.server.ntfnMgr.RegisterStakeDifficultyChanged(wsc)
+	return nil, nil
+}
+
 // handleStopNotifyBlocks implements the stopnotifyblocks command extension for
 // websocket connections.
 func handleStopNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -1917,6 +2606,62 @@ func handleStopNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error
 	return nil, nil
 }
 
+// handleNotifyJobs implements the notifyjobs command extension for websocket
+// connections.
+func handleNotifyJobs(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.RegisterJobUpdates(wsc)
+	return nil, nil
+}
+
+// handleStopNotifyJobs implements the stopnotifyjobs command extension for
+// websocket connections.
+func handleStopNotifyJobs(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterJobUpdates(wsc)
+	return nil, nil
+}
+
+// handleNotifyStakeBoundaries implements the notifystakeboundaries command
+// extension for websocket connections.
+func handleNotifyStakeBoundaries(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.RegisterStakeBoundaryUpdates(wsc)
+	return nil, nil
+}
+
+// handleStopNotifyStakeBoundaries implements the stopnotifystakeboundaries
+// command extension for websocket connections.
+func handleStopNotifyStakeBoundaries(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterStakeBoundaryUpdates(wsc)
+	return nil, nil
+}
+
+// handleNotifyWork implements the notifywork command extension for websocket
+// connections.
+func handleNotifyWork(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.RegisterWorkUpdates(wsc)
+	return nil, nil
+}
+
+// handleStopNotifyWork implements the stopnotifywork command extension for
+// websocket connections.
+func handleStopNotifyWork(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterWorkUpdates(wsc)
+	return nil, nil
+}
+
+// handleNotifySideChainBlocks implements the notifysidechainblocks command
+// extension for websocket connections.
+func handleNotifySideChainBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.RegisterSideChainBlockUpdates(wsc)
+	return nil, nil
+}
+
+// handleStopNotifySideChainBlocks implements the stopnotifysidechainblocks
+// command extension for websocket connections.
+func handleStopNotifySideChainBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterSideChainBlockUpdates(wsc)
+	return nil, nil
+}
+
 // handleNotifyNewTransations implements the notifynewtransactions command
 // extension for websocket connections.
 func handleNotifyNewTransactions(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -2078,6 +2823,22 @@ func handleRescan(wsc *wsClient, icmd interface{}) (interface{}, error) {
 		return nil, err
 	}
 
+	if cmd.Async != nil && *cmd.Async {
+		interrupt := make(chan struct{})
+		return startJob(wsc.server, "rescan", interrupt, func(job *rpcJob) (interface{}, error) {
+			return rescanBlocks(wsc, filter, blockHashes, job)
+		})
+	}
+	return rescanBlocks(wsc, filter, blockHashes, nil)
+}
+
+// rescanBlocks rescans the passed blocks, which must be a chain of main chain
+// blocks, for transactions relevant to the passed filter of the passed client
+// and returns the result of the rescan command.  The progress of the passed
+// job is updated as the blocks are rescanned and the rescan stops early when
+// either the job is cancelled or the client disconnects.  The job is nil when
+// the rescan is not run as a job.
+func rescanBlocks(wsc *wsClient, filter *wsClientFilter, blockHashes []chainhash.Hash, job *rpcJob) (*dcrjson.RescanResult, error) {
 	discoveredData := make([]dcrjson.RescannedBlock, 0, len(blockHashes))
 
 	// Iterate over each block in the request and rescan.  When a block
@@ -2085,6 +2846,17 @@ func handleRescan(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	bc := wsc.server.server.blockManager.chain
 	var lastBlockHash *chainhash.Hash
 	for i := range blockHashes {
+		if job != nil {
+			select {
+			case <-job.interrupt:
+				return nil, errors.New("rescan interrupted")
+			case <-wsc.quit:
+				return nil, errors.New("client disconnected")
+			default:
+			}
+			job.setProgress(int64(i), int64(len(blockHashes)))
+		}
+
 		block, err := bc.BlockByHash(&blockHashes[i])
 		if err != nil {
 			return nil, &dcrjson.RPCError{
@@ -2110,6 +2882,9 @@ func handleRescan(wsc *wsClient, icmd interface{}) (interface{}, error) {
 		}
 	}
 
+	if job != nil {
+		job.setProgress(int64(len(blockHashes)), int64(len(blockHashes)))
+	}
 	return &dcrjson.RescanResult{DiscoveredData: discoveredData}, nil
 }
 
@@ -23,10 +23,12 @@ import (
 	"github.com/decred/dcrd/addrmgr"
 	"github.com/decred/dcrd/blockchain"
 	"github.com/decred/dcrd/blockchain/indexers"
+	"github.com/decred/dcrd/blockchain/stake"
 	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/connmgr"
 	"github.com/decred/dcrd/database"
+	"github.com/decred/dcrd/fees"
 	"github.com/decred/dcrd/mempool"
 	"github.com/decred/dcrd/mining"
 	"github.com/decred/dcrd/peer"
@@ -160,6 +162,7 @@ type server struct {
 	banPeers             chan *serverPeer
 	query                chan interface{}
 	relayInv             chan relayMsg
+	relayAddrs           chan relayAddrMsg
 	broadcast            chan broadcastMsg
 	peerHeightsUpdate    chan updatePeerHeightsMsg
 	wg                   sync.WaitGroup
@@ -167,8 +170,24 @@ type server struct {
 	nat                  NAT
 	db                   database.DB
 	timeSource           blockchain.MedianTimeSource
+	clock                blockchain.Clock
 	services             wire.ServiceFlag
 
+	// warpClock is the clock of the server when running on the simulation
+	// test network so that it may be warped via the warpclock RPC.  It is
+	// nil on all other networks.
+	warpClock *blockchain.WarpClock
+
+	// simnetHarness purchases tickets and votes in order to quickly advance
+	// the chain via the advancechain RPC when running on the simulation
+	// test network.  It is nil on all other networks.
+	simnetHarness *simnetHarness
+
+	// discoverPort is the port advertised along with the external address
+	// discovered from the addresses outbound peers report seeing the local
+	// node as.  It is zero when discovery is disabled.
+	discoverPort uint16
+
 	// The following fields are used for optional indexes.  They will be nil
 	// if the associated index is not enabled.  These fields are set during
 	// initial creation of the server and never changed afterwards, so they
@@ -176,11 +195,46 @@ type server struct {
 	txIndex         *indexers.TxIndex
 	addrIndex       *indexers.AddrIndex
 	existsAddrIndex *indexers.ExistsAddrIndex
+	windowAggIndex  *indexers.WindowAggIndex
+	spendIndex      *indexers.SpendIndex
+	balanceIndex    *indexers.BalanceIndex
+
+	// indexManager manages the optional indexes.  It is nil if none of
+	// them are enabled.  It is set during initial creation of the server
+	// and never changed afterwards.
+	indexManager *indexers.Manager
+
+	// localTxs tracks the transactions submitted via the RPC server in
+	// order to prioritize their relay.
+	localTxs *localTxRelay
+
+	// dsProofs tracks the outpoints of the double spend proofs which were
+	// recently processed in order to avoid relaying them more than once.
+	dsProofs *recentDSProofs
+
+	// peerFilter refuses or deprioritizes peers according to the configured
+	// peer filter rules.
+	peerFilter *peerFilter
+
+	// feeEstimator and ticketFeeEstimator estimate the fee rates regular
+	// transactions and ticket purchases need to pay in order to be
+	// confirmed within a number of blocks.
+	feeEstimator       *fees.Estimator
+	ticketFeeEstimator *fees.Estimator
+
+	// addrRelaySecret is the random secret used to select the peers that
+	// addresses are relayed to.
+	addrRelaySecret [32]byte
 }
 
 // serverPeer extends the peer to maintain state shared by the server and
 // the blockmanager.
 type serverPeer struct {
+	// The following variables must only be used atomically.
+	// Putting the uint64s first makes them 64-bit aligned for 32-bit systems.
+	firstBlocks uint64 // Blocks the peer was the first to relay.
+	firstTxns   uint64 // Transactions the peer was the first to relay.
+
 	*peer.Peer
 
 	connReq         *connmgr.ConnReq
@@ -193,28 +247,117 @@ type serverPeer struct {
 	requestedTxns   map[chainhash.Hash]struct{}
 	requestedBlocks map[chainhash.Hash]struct{}
 	filter          *bloom.Filter
+	addrMtx         sync.Mutex
 	knownAddresses  map[string]struct{}
 	banScore        connmgr.DynamicBanScore
 	quit            chan struct{}
 
+	// requestedPackages houses the transactions a package was requested for
+	// from the peer which have not been delivered yet.  It is only accessed
+	// from the block manager.
+	requestedPackages map[chainhash.Hash]struct{}
+
+	// addrTokens is the number of addresses the peer may currently send
+	// before further addresses are ignored and addrTokensUpdated is when it
+	// was last replenished.  They are only accessed from the input handler
+	// of the peer.
+	addrTokens        float64
+	addrTokensUpdated time.Time
+
+	// msgStats accounts the bytes of the messages sent to and received
+	// from the peer by their commands.
+	msgStats *peerMsgStats
+
+	// deprioritized indicates the peer matched a peer filter rule which
+	// deprioritizes it.  It is set when the version message is received
+	// before the peer is handed to the block manager.
+	deprioritized bool
+
 	// The following chans are used to sync blockmanager and server.
 	txProcessed    chan struct{}
 	blockProcessed chan struct{}
 }
 
+// peerMsgStats accounts the bytes of the messages sent to and received from a
+// peer by their commands.  It implements the wire.MessageStatsCollector
+// interface so the peer reports its messages to it.
+type peerMsgStats struct {
+	mtx  sync.Mutex
+	sent map[string]uint64
+	recv map[string]uint64
+}
+
+// Ensure the peerMsgStats type implements the wire.MessageStatsCollector
+// interface.
+var _ wire.MessageStatsCollector = (*peerMsgStats)(nil)
+
+// newPeerMsgStats returns a new empty peer message accounting.
+func newPeerMsgStats() *peerMsgStats {
+	return &peerMsgStats{
+		sent: make(map[string]uint64),
+		recv: make(map[string]uint64),
+	}
+}
+
+// MessageRead accounts the bytes of a message received from the peer.  Messages
+// which failed to be read are not accounted by their command since it is
+// chosen freely by the peer and they are not understood anyways.
+//
+// This is part of the wire.MessageStatsCollector interface.
+func (s *peerMsgStats) MessageRead(stats *wire.MessageStats) {
+	if stats.Err != nil {
+		return
+	}
+	s.mtx.Lock()
+	s.recv[stats.Command] += uint64(stats.Bytes)
+	s.mtx.Unlock()
+}
+
+// MessageWritten accounts the bytes of a message sent to the peer.
+//
+// This is part of the wire.MessageStatsCollector interface.
+func (s *peerMsgStats) MessageWritten(stats *wire.MessageStats) {
+	if stats.Bytes == 0 {
+		return
+	}
+	s.mtx.Lock()
+	s.sent[stats.Command] += uint64(stats.Bytes)
+	s.mtx.Unlock()
+}
+
+// Snapshot returns copies of the bytes sent to and received from the peer by
+// message command.
+//
+// This function is safe for concurrent access.
+func (s *peerMsgStats) Snapshot() (map[string]uint64, map[string]uint64) {
+	s.mtx.Lock()
+	sent := make(map[string]uint64, len(s.sent))
+	for cmd, n := range s.sent {
+		sent[cmd] = n
+	}
+	recv := make(map[string]uint64, len(s.recv))
+	for cmd, n := range s.recv {
+		recv[cmd] = n
+	}
+	s.mtx.Unlock()
+	return sent, recv
+}
+
 // newServerPeer returns a new serverPeer instance. The peer needs to be set by
 // the caller.
 func newServerPeer(s *server, isPersistent bool) *serverPeer {
 	return &serverPeer{
-		server:          s,
-		persistent:      isPersistent,
-		requestedTxns:   make(map[chainhash.Hash]struct{}),
-		requestedBlocks: make(map[chainhash.Hash]struct{}),
-		filter:          bloom.LoadFilter(nil),
-		knownAddresses:  make(map[string]struct{}),
-		quit:            make(chan struct{}),
-		txProcessed:     make(chan struct{}, 1),
-		blockProcessed:  make(chan struct{}, 1),
+		server:            s,
+		persistent:        isPersistent,
+		requestedTxns:     make(map[chainhash.Hash]struct{}),
+		requestedBlocks:   make(map[chainhash.Hash]struct{}),
+		requestedPackages: make(map[chainhash.Hash]struct{}),
+		filter:            bloom.LoadFilter(nil),
+		knownAddresses:    make(map[string]struct{}),
+		quit:              make(chan struct{}),
+		msgStats:          newPeerMsgStats(),
+		txProcessed:       make(chan struct{}, 1),
+		blockProcessed:    make(chan struct{}, 1),
 	}
 }
 
@@ -225,17 +368,31 @@ func (sp *serverPeer) newestBlock() (*chainhash.Hash, int64, error) {
 	return best.Hash, best.Height, nil
 }
 
+// handlePanic writes a crash diagnostics bundle for a panic in one of the
+// goroutines of the peer, such as from the message listeners which feed the
+// block manager and the memory pool, and exits using the format required by
+// the configuration for the peer package.
+func (sp *serverPeer) handlePanic(p *peer.Peer, r interface{}) {
+	handleCrash("PEER", r, sp.server.blockManager.chain)
+}
+
 // addKnownAddresses adds the given addresses to the set of known addreses to
-// the peer to prevent sending duplicate addresses.
+// the peer to prevent sending duplicate addresses.  It is safe for concurrent
+// access.
 func (sp *serverPeer) addKnownAddresses(addresses []*wire.NetAddress) {
+	sp.addrMtx.Lock()
 	for _, na := range addresses {
 		sp.knownAddresses[addrmgr.NetAddressKey(na)] = struct{}{}
 	}
+	sp.addrMtx.Unlock()
 }
 
-// addressKnown true if the given address is already known to the peer.
+// addressKnown true if the given address is already known to the peer.  It is
+// safe for concurrent access.
 func (sp *serverPeer) addressKnown(na *wire.NetAddress) bool {
+	sp.addrMtx.Lock()
 	_, exists := sp.knownAddresses[addrmgr.NetAddressKey(na)]
+	sp.addrMtx.Unlock()
 	return exists
 }
 
@@ -257,6 +414,21 @@ func (sp *serverPeer) relayTxDisabled() bool {
 	return sp.disableRelayTx
 }
 
+// wantsTxRelay returns whether or not the passed transaction should be relayed
+// to the peer.  Transactions are not relayed to peers that have transaction
+// relaying disabled or that have a bloom filter loaded which the transaction
+// does not match.  Note that the filter is updated when the transaction matches
+// it.
+func (sp *serverPeer) wantsTxRelay(tx *dcrutil.Tx) bool {
+	if sp.relayTxDisabled() {
+		return false
+	}
+	if sp.filter.IsLoaded() && !sp.filter.MatchTxAndUpdate(tx) {
+		return false
+	}
+	return true
+}
+
 // pushAddrMsg sends an addr message to the connected peer using the provided
 // addresses.
 func (sp *serverPeer) pushAddrMsg(addresses []*wire.NetAddress) {
@@ -276,6 +448,29 @@ func (sp *serverPeer) pushAddrMsg(addresses []*wire.NetAddress) {
 	sp.addKnownAddresses(known)
 }
 
+// creditFirstRelay credits the peer for being the first to relay a block or a
+// transaction depending on the passed flag.
+//
+// This function is safe for concurrent access.
+func (sp *serverPeer) creditFirstRelay(isBlock bool) {
+	if isBlock {
+		atomic.AddUint64(&sp.firstBlocks, 1)
+		return
+	}
+	atomic.AddUint64(&sp.firstTxns, 1)
+}
+
+// relayScore returns a score of the quality of the peer as a source of new
+// blocks and transactions based on how many of them it was the first to relay.
+// Blocks weigh more than transactions since the peer is mostly relied upon to
+// learn about new blocks quickly.
+//
+// This function is safe for concurrent access.
+func (sp *serverPeer) relayScore() uint64 {
+	return atomic.LoadUint64(&sp.firstBlocks)*blockRelayScoreWeight +
+		atomic.LoadUint64(&sp.firstTxns)
+}
+
 // addBanScore increases the persistent and decaying ban score fields by the
 // values passed as parameters. If the resulting score exceeds half of the ban
 // threshold, a warning is logged including the reason provided. Further, if
@@ -314,6 +509,22 @@ func (sp *serverPeer) addBanScore(persistent, transient uint32, reason string) {
 // to negotiate the protocol version details as well as kick start the
 // communications.
 func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
+	// Refuse or deprioritize the peer according to the configured peer
+	// filter rules.
+	switch sp.server.peerFilter.filter(msg) {
+	case pfRefuse:
+		srvrLog.Debugf("Refusing peer %v (user agent %q, protocol "+
+			"version %d, services %v) due to peer filter rules", p,
+			msg.UserAgent, msg.ProtocolVersion, msg.Services)
+		p.Disconnect()
+		return
+	case pfDeprioritize:
+		srvrLog.Debugf("Deprioritizing peer %v (user agent %q, protocol "+
+			"version %d, services %v) due to peer filter rules", p,
+			msg.UserAgent, msg.ProtocolVersion, msg.Services)
+		sp.deprioritized = true
+	}
+
 	// Add the remote peer time as a sample for creating an offset against
 	// the local clock to keep the network time in sync.
 	sp.server.timeSource.AddTimeSample(p.Addr(), msg.Timestamp)
@@ -334,22 +545,32 @@ func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
 		addrManager := sp.server.addrManager
 		// Outbound connections.
 		if !p.Inbound() {
+			// Record the address the peer reports seeing the local
+			// node as so the external address can be discovered.
+			if port := sp.server.discoverPort; port != 0 {
+				local := wire.NewNetAddressIPPort(msg.AddrYou.IP,
+					port, sp.server.services)
+				na := addrManager.AddReportedLocalAddress(local, p.NA())
+				if na != nil {
+					srvrLog.Infof("Advertising discovered external "+
+						"address %s", addrmgr.NetAddressKey(na))
+				}
+			}
+
 			// TODO(davec): Only do this if not doing the initial block
 			// download and the local address is routable.
 			if !cfg.DisableListen /* && isCurrent? */ {
 				// Get address that best matches.
 				lna := addrManager.GetBestLocalAddress(p.NA())
 				if addrmgr.IsRoutable(lna) {
-					// Filter addresses the peer already knows about.
-					addresses := []*wire.NetAddress{lna}
-					sp.pushAddrMsg(addresses)
+					sp.advertiseLocalAddr(lna)
 				}
 			}
 
 			// Request known addresses if the server address manager
 			// needs more.
 			if addrManager.NeedMoreAddresses() {
-				p.QueueMessage(wire.NewMsgGetAddr(), nil)
+				sp.requestAddrs()
 			}
 
 			// Mark the address as a known good address.
@@ -361,6 +582,22 @@ func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
 	sp.server.AddPeer(sp)
 }
 
+// OnVerAck is invoked when a peer receives a verack wire message, which
+// completes the version handshake.  It immediately announces the locally
+// submitted transactions to the peer so they are not delayed until the next
+// time they are rebroadcast.
+func (sp *serverPeer) OnVerAck(p *peer.Peer, msg *wire.MsgVerAck) {
+	sp.server.pushLocalTxInv(sp, sp.server.localTxs.Txns())
+
+	// Let peers which support compact blocks know they may be requested
+	// from them.  Peers are only asked to announce new blocks with compact
+	// blocks once they relay new blocks first.
+	if p.ProtocolVersion() >= wire.CmpctBlockVersion && !cfg.BlocksOnly {
+		p.QueueMessage(wire.NewMsgSendCmpct(false,
+			wire.CmpctBlockEncodingVersion), nil)
+	}
+}
+
 // OnMemPool is invoked when a peer receives a mempool wire message.  It creates
 // and sends an inventory message with the contents of the memory pool up to the
 // maximum inventory allowed per message.  When the peer has a bloom filter
@@ -466,7 +703,7 @@ func (sp *serverPeer) OnGetMiningState(p *peer.Peer, msg *wire.MsgGetMiningState
 	// per mining state message.  There is nothing to send when there are no
 	// eligible blocks.
 	blockHashes := SortParentsByVotes(mp, *newest, children,
-		bm.server.chainParams)
+		minVotesRequired(bm.server.chainParams))
 	numBlocks := len(blockHashes)
 	if numBlocks == 0 {
 		return
@@ -534,6 +771,90 @@ func (sp *serverPeer) OnTx(p *peer.Peer, msg *wire.MsgTx) {
 	<-sp.txProcessed
 }
 
+// OnPackage is invoked when a peer receives a package wire message.  It blocks
+// until the transactions of the package have been fully processed.
+func (sp *serverPeer) OnPackage(p *peer.Peer, msg *wire.MsgPackage) {
+	if cfg.BlocksOnly {
+		peerLog.Tracef("Ignoring package from %v - blocksonly enabled",
+			p)
+		return
+	}
+
+	txns := make([]*dcrutil.Tx, 0, len(msg.Transactions))
+	for _, msgTx := range msg.Transactions {
+		tx := dcrutil.NewTx(msgTx)
+		p.AddKnownInventory(wire.NewInvVect(wire.InvTypeTx, tx.Hash()))
+		txns = append(txns, tx)
+	}
+
+	// Queue the package up to be handled by the block manager and
+	// intentionally block further receives until it is fully processed
+	// the same way as individual transactions.
+	sp.server.blockManager.QueuePackage(txns, sp)
+	<-sp.txProcessed
+}
+
+// OnGetPackage is invoked when a peer receives a getpackage wire message.  It
+// sends the requested transaction along with its unconfirmed ancestors from the
+// memory pool as a package, or a notfound message when the transaction is not
+// available.  Only packages for transactions which were announced to or
+// received from the peer are served, so peers can't probe the memory pool for
+// transactions which have not been relayed to them.
+func (sp *serverPeer) OnGetPackage(p *peer.Peer, msg *wire.MsgGetPackage) {
+	iv := wire.NewInvVect(wire.InvTypeTx, &msg.TxHash)
+	var txns []*dcrutil.Tx
+	var err error
+	if p.HasKnownInventory(iv) {
+		txns, err = sp.server.txMemPool.PackageTxns(&msg.TxHash)
+	} else {
+		err = fmt.Errorf("transaction was not announced to the peer")
+	}
+	if err != nil {
+		peerLog.Debugf("Unable to send package for transaction %v to "+
+			"%s: %v", msg.TxHash, sp, err)
+		notFound := wire.NewMsgNotFound()
+		notFound.AddInvVect(iv)
+		p.QueueMessage(notFound, nil)
+		return
+	}
+
+	msgPackage := wire.NewMsgPackage()
+	for _, tx := range txns {
+		msgPackage.AddTransaction(tx.MsgTx())
+		p.AddKnownInventory(wire.NewInvVect(wire.InvTypeTx, tx.Hash()))
+	}
+	p.QueueMessage(msgPackage, nil)
+}
+
+// OnDoubleSpendProof is invoked when a peer receives a dsproof wire message.
+// Valid proofs which have not already been processed are relayed to the other
+// peers and websocket clients are notified of the double spend.
+func (sp *serverPeer) OnDoubleSpendProof(p *peer.Peer, msg *wire.MsgDoubleSpendProof) {
+	if cfg.BlocksOnly {
+		peerLog.Tracef("Ignoring dsproof for %v from %v - blocksonly "+
+			"enabled", msg.OutPoint, p)
+		return
+	}
+
+	err := sp.server.processDoubleSpendProof(msg, sp)
+	if err == nil {
+		return
+	}
+	peerLog.Debugf("Rejected dsproof for %v from %s: %v", msg.OutPoint, p,
+		err)
+
+	// Peers which send proofs that do not show a valid double spend are
+	// misbehaving.  Proofs for outputs which are unknown might only be the
+	// result of the output being spent by a block in the meantime, so only
+	// a decaying score is added in that case to limit the number of proofs
+	// that require looking up the output.
+	if _, ok := err.(blockchain.RuleError); ok {
+		sp.addBanScore(100, 0, "invalid dsproof")
+		return
+	}
+	sp.addBanScore(0, 10, "unverifiable dsproof")
+}
+
 // OnBlock is invoked when a peer receives a block wire message.  It blocks
 // until the network block has been fully processed.
 func (sp *serverPeer) OnBlock(p *peer.Peer, msg *wire.MsgBlock, buf []byte) {
@@ -558,6 +879,68 @@ func (sp *serverPeer) OnBlock(p *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 	<-sp.blockProcessed
 }
 
+// OnCmpctBlock is invoked when a peer receives a cmpctblock wire message.  It
+// blocks until the compact block has been handled by the block manager, which
+// includes processing the block when it could be reconstructed.
+func (sp *serverPeer) OnCmpctBlock(p *peer.Peer, msg *wire.MsgCmpctBlock) {
+	blockHash := msg.Header.BlockHash()
+	p.AddKnownInventory(wire.NewInvVect(wire.InvTypeBlock, &blockHash))
+
+	sp.server.blockManager.QueueCmpctBlock(msg, sp)
+	<-sp.blockProcessed
+}
+
+// OnBlockTxn is invoked when a peer receives a blocktxn wire message.  It blocks
+// until the block the transactions were requested for has been processed.
+func (sp *serverPeer) OnBlockTxn(p *peer.Peer, msg *wire.MsgBlockTxn) {
+	sp.server.blockManager.QueueBlockTxn(msg, sp)
+	<-sp.blockProcessed
+}
+
+// OnGetBlockTxn is invoked when a peer receives a getblocktxn wire message.  It
+// sends the requested transactions of a recent block to the peer, or the full
+// block when it is too old to be served as a compact block.
+func (sp *serverPeer) OnGetBlockTxn(p *peer.Peer, msg *wire.MsgGetBlockTxn) {
+	chain := sp.server.blockManager.chain
+	block, err := chain.FetchBlockFromHash(&msg.BlockHash)
+	if err != nil {
+		peerLog.Debugf("Unable to fetch block %v requested by %s: %v",
+			msg.BlockHash, sp, err)
+		return
+	}
+	best := chain.BestSnapshot()
+	if block.Height() < best.Height-maxCmpctBlockDepth {
+		doneChan := make(chan struct{}, 1)
+		err := sp.server.pushBlockMsg(sp, &msg.BlockHash, doneChan, nil)
+		if err == nil {
+			<-doneChan
+		}
+		return
+	}
+
+	msgBlock := block.MsgBlock()
+	blockTxn := wire.NewMsgBlockTxn(&msg.BlockHash)
+	for _, index := range msg.Indexes {
+		if index >= uint32(len(msgBlock.Transactions)) {
+			sp.addBanScore(100, 0, "getblocktxn with out of range "+
+				"index")
+			return
+		}
+		blockTxn.Transactions = append(blockTxn.Transactions,
+			msgBlock.Transactions[index])
+	}
+	for _, index := range msg.SIndexes {
+		if index >= uint32(len(msgBlock.STransactions)) {
+			sp.addBanScore(100, 0, "getblocktxn with out of range "+
+				"stake index")
+			return
+		}
+		blockTxn.STransactions = append(blockTxn.STransactions,
+			msgBlock.STransactions[index])
+	}
+	p.QueueMessage(blockTxn, nil)
+}
+
 // OnInv is invoked when a peer receives an inv wire message and is used to
 // examine the inventory being advertised by the remote peer and react
 // accordingly.  We pass the message down to blockmanager which will call
@@ -590,6 +973,15 @@ func (sp *serverPeer) OnInv(p *peer.Peer, msg *wire.MsgInv) {
 	}
 }
 
+// OnNotFound is invoked when a peer receives a notfound wire message.  The
+// message is passed down to the block manager so that any transactions which
+// were requested from the peer can be requested from other peers.
+func (sp *serverPeer) OnNotFound(p *peer.Peer, msg *wire.MsgNotFound) {
+	if len(msg.InvList) > 0 {
+		sp.server.blockManager.QueueNotFound(msg, sp)
+	}
+}
+
 // OnHeaders is invoked when a peer receives a headers wire message.  The
 // message is passed down to the block manager.
 func (sp *serverPeer) OnHeaders(p *peer.Peer, msg *wire.MsgHeaders) {
@@ -636,9 +1028,12 @@ func (sp *serverPeer) OnGetData(p *peer.Peer, msg *wire.MsgGetData) {
 		var err error
 		switch iv.Type {
 		case wire.InvTypeTx:
+			sp.server.localTxs.RecordRequested(&iv.Hash, sp.ID())
 			err = sp.server.pushTxMsg(sp, &iv.Hash, c, waitChan)
 		case wire.InvTypeBlock:
 			err = sp.server.pushBlockMsg(sp, &iv.Hash, c, waitChan)
+		case wire.InvTypeCmpctBlock:
+			err = sp.server.pushCmpctBlockMsg(sp, &iv.Hash, c, waitChan)
 		case wire.InvTypeFilteredBlock:
 			err = sp.server.pushMerkleBlockMsg(sp, &iv.Hash, c, waitChan)
 		default:
@@ -850,7 +1245,7 @@ func (sp *serverPeer) OnGetHeaders(p *peer.Peer, msg *wire.MsgGetHeaders) {
 // used by remote peers to add data to an already loaded bloom filter.  The peer
 // will be disconnected if a filter is not loaded when this message is received.
 func (sp *serverPeer) OnFilterAdd(p *peer.Peer, msg *wire.MsgFilterAdd) {
-	if sp.filter.IsLoaded() {
+	if !sp.filter.IsLoaded() {
 		peerLog.Debugf("%s sent a filteradd request with no filter "+
 			"loaded -- disconnecting", p)
 		p.Disconnect()
@@ -928,6 +1323,13 @@ func (sp *serverPeer) OnAddr(p *peer.Peer, msg *wire.MsgAddr) {
 		return
 	}
 
+	// Limit the rate at which addresses from the peer are processed to
+	// prevent it from flooding the address manager with addresses.
+	// Addresses which exceed the limit are ignored.
+	now := time.Now()
+	sp.updateAddrTokens(now)
+	addrs := make([]*wire.NetAddress, 0, len(msg.AddrList))
+	var relayAddrs []*wire.NetAddress
 	for _, na := range msg.AddrList {
 		// Don't add more address if we're disconnecting.
 		if !p.Connected() {
@@ -937,13 +1339,34 @@ func (sp *serverPeer) OnAddr(p *peer.Peer, msg *wire.MsgAddr) {
 		// Set the timestamp to 5 days ago if it's more than 24 hours
 		// in the future so this address is one of the first to be
 		// removed when space is needed.
-		now := time.Now()
 		if na.Timestamp.After(now.Add(time.Minute * 10)) {
 			na.Timestamp = now.Add(-1 * time.Hour * 24 * 5)
 		}
 
 		// Add address to known addresses for this peer.
 		sp.addKnownAddresses([]*wire.NetAddress{na})
+
+		if sp.addrTokens < 1 {
+			continue
+		}
+		sp.addrTokens--
+		addrs = append(addrs, na)
+
+		// Relay routable addresses from small messages which were
+		// recently announced.
+		if len(msg.AddrList) <= maxRelayAddrsPerMsg &&
+			now.Sub(na.Timestamp) <= maxRelayAddrAge &&
+			addrmgr.IsRoutable(na) {
+
+			relayAddrs = append(relayAddrs, na)
+		}
+	}
+	if ignored := len(msg.AddrList) - len(addrs); ignored > 0 {
+		peerLog.Debugf("Ignoring %d of %d addresses from %s due to "+
+			"rate limiting", ignored, len(msg.AddrList), p)
+	}
+	if len(addrs) == 0 {
+		return
 	}
 
 	// Add addresses to server address manager.  The address manager handles
@@ -951,7 +1374,11 @@ func (sp *serverPeer) OnAddr(p *peer.Peer, msg *wire.MsgAddr) {
 	// addresses, and last seen updates.
 	// XXX bitcoind gives a 2 hour time penalty here, do we want to do the
 	// same?
-	sp.server.addrManager.AddAddresses(msg.AddrList, p.NA())
+	sp.server.addrManager.AddAddresses(addrs, p.NA())
+
+	if len(relayAddrs) > 0 {
+		sp.server.RelayAddresses(relayAddrs, sp)
+	}
 }
 
 // OnRead is invoked when a peer receives a message and it is used to update
@@ -992,6 +1419,12 @@ func (s *server) AddRebroadcastInventory(iv *wire.InvVect, data interface{}) {
 		return
 	}
 
+	// Track locally submitted transactions so they are given priority
+	// when they are relayed.
+	if tx, ok := data.(*dcrutil.Tx); ok && iv.Type == wire.InvTypeTx {
+		s.localTxs.Add(tx)
+	}
+
 	s.modifyRebroadcastInv <- broadcastInventoryAdd{invVect: iv, data: data}
 }
 
@@ -1003,6 +1436,10 @@ func (s *server) RemoveRebroadcastInventory(iv *wire.InvVect) {
 		return
 	}
 
+	if iv.Type == wire.InvTypeTx {
+		s.localTxs.Remove(&iv.Hash)
+	}
+
 	s.modifyRebroadcastInv <- broadcastInventoryDel(iv)
 }
 
@@ -1027,10 +1464,30 @@ func (s *server) AnnounceNewTransactions(newTxs []*dcrutil.Tx) {
 			// about stale block templates due to the new transaction.
 			s.rpcServer.gbtWorkState.NotifyMempoolTx(
 				s.txMemPool.LastUpdated())
+
+			// Notify mining clients about stale work when the
+			// transaction is a new vote on the current tip.
+			if isVote, _ := stake.IsSSGen(tx.MsgTx()); isVote {
+				s.rpcServer.NotifyNewVote(tx)
+			}
 		}
 	}
 }
 
+// txReplaced notifies websocket clients of a transaction which was replaced in
+// the memory pool by a transaction which spends the same outputs and stops
+// rebroadcasting it when it was submitted locally.  The replacement itself is
+// announced like any other accepted transaction.  It is invoked with the memory
+// pool lock held, so the rebroadcast inventory is modified asynchronously.
+func (s *server) txReplaced(removal *mempool.ConflictRemoval) {
+	if s.rpcServer != nil {
+		s.rpcServer.ntfnMgr.NotifyTxConflict(removal)
+	}
+
+	iv := wire.NewInvVect(wire.InvTypeTx, removal.Tx.Hash())
+	go s.RemoveRebroadcastInventory(iv)
+}
+
 // pushTxMsg sends a tx message for the provided transaction hash to the
 // connected peer.  An error is returned if the transaction hash is not known.
 func (s *server) pushTxMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<- struct{}, waitChan <-chan struct{}) error {
@@ -1064,15 +1521,28 @@ func (s *server) pushTxMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<-
 // pushBlockMsg sends a block message for the provided block hash to the
 // connected peer.  An error is returned if the block hash is not known.
 func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<- struct{}, waitChan <-chan struct{}) error {
-	block, err := sp.server.blockManager.chain.FetchBlockFromHash(hash)
-	if err != nil {
-		peerLog.Tracef("Unable to fetch requested block hash %v: %v",
-			hash, err)
+	// Serve blocks which are stored in the block files directly from
+	// memory mappings of the files when possible.  This avoids reading the
+	// blocks requested by syncing peers into memory only to serialize them
+	// again.  Other blocks, such as those only in the side chain and orphan
+	// caches, are fetched from the chain.
+	var msg wire.Message
+	blockBytes, release, err := s.fetchMappedBlock(hash)
+	if err == nil {
+		msg = wire.NewRawMessage(wire.CmdBlock, blockBytes)
+	} else {
+		block, err := sp.server.blockManager.chain.FetchBlockFromHash(hash)
+		if err != nil {
+			peerLog.Tracef("Unable to fetch requested block hash %v: %v",
+				hash, err)
 
-		if doneChan != nil {
-			doneChan <- struct{}{}
+			if doneChan != nil {
+				doneChan <- struct{}{}
+			}
+			return err
 		}
-		return err
+		msg = block.MsgBlock()
+		release = nil
 	}
 
 	// Once we have fetched data wait for any previous operation to finish.
@@ -1088,7 +1558,25 @@ func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan cha
 	if !sendInv {
 		dc = doneChan
 	}
-	sp.QueueMessage(block.MsgBlock(), dc)
+
+	// The memory mapping of the block must remain valid until the message
+	// has been written, so release it once the peer is done with the
+	// message before notifying the caller.
+	if release != nil {
+		sent := make(chan struct{}, 1)
+		go func(dc chan<- struct{}) {
+			<-sent
+			if err := release(); err != nil {
+				peerLog.Warnf("Unable to release mapped block %v: %v",
+					hash, err)
+			}
+			if dc != nil {
+				dc <- struct{}{}
+			}
+		}(dc)
+		dc = sent
+	}
+	sp.QueueMessage(msg, dc)
 
 	// When the peer requests the final block that was advertised in
 	// response to a getblocks message which requested more blocks than
@@ -1106,6 +1594,61 @@ func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan cha
 	return nil
 }
 
+// pushCmpctBlockMsg sends a cmpctblock message for the provided block hash to
+// the connected peer.  Blocks which are too old for the peer to have their
+// transactions are sent in full instead.  An error is returned if the block
+// hash is not known.
+func (s *server) pushCmpctBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<- struct{}, waitChan <-chan struct{}) error {
+	chain := sp.server.blockManager.chain
+	block, err := chain.FetchBlockFromHash(hash)
+	if err != nil {
+		peerLog.Tracef("Unable to fetch requested block hash %v: %v",
+			hash, err)
+
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
+	best := chain.BestSnapshot()
+	nonce, err := wire.RandomUint64()
+	if err != nil || block.Height() < best.Height-maxCmpctBlockDepth ||
+		sp.continueHash != nil {
+
+		return s.pushBlockMsg(sp, hash, doneChan, waitChan)
+	}
+	msg := wire.NewMsgCmpctBlockFromBlock(block.MsgBlock(), nonce)
+
+	// Once we have fetched data wait for any previous operation to finish.
+	if waitChan != nil {
+		<-waitChan
+	}
+
+	sp.QueueMessage(msg, doneChan)
+	return nil
+}
+
+// fetchMappedBlock returns the serialized block with the passed hash from a
+// memory mapping of the block files of the database along with a function which
+// releases the mapping.  An error is returned when the block is not in the
+// block files or the database does not support memory mapping them.
+func (s *server) fetchMappedBlock(hash *chainhash.Hash) ([]byte, func() error, error) {
+	var blockBytes []byte
+	var release func() error
+	err := s.db.View(func(dbTx database.Tx) error {
+		fetcher, ok := dbTx.(database.MappedBlockFetcher)
+		if !ok {
+			return errors.New("database does not support memory " +
+				"mapped blocks")
+		}
+
+		var err error
+		blockBytes, release, err = fetcher.FetchMappedBlock(hash)
+		return err
+	})
+	return blockBytes, release, err
+}
+
 // pushMerkleBlockMsg sends a merkleblock message for the provided block hash to
 // the connected peer.  Since a merkle block requires the peer to have a filter
 // loaded, this call will simply be ignored if there is no filter loaded.  An
@@ -1131,9 +1674,10 @@ func (s *server) pushMerkleBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneCh
 		return err
 	}
 
-	// Generate a merkle block by filtering the requested block according
-	// to the filter for the peer.
-	merkle, matchedTxIndices := bloom.NewMerkleBlock(blk, sp.filter)
+	// Generate a merkle block by filtering both transaction trees of the
+	// requested block according to the filter for the peer.
+	merkle, matchedTxIndices, matchedSTxIndices :=
+		newFilteredMerkleBlock(blk, sp.filter)
 
 	// Once we have fetched data wait for any previous operation to finish.
 	if waitChan != nil {
@@ -1142,25 +1686,37 @@ func (s *server) pushMerkleBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneCh
 
 	// Send the merkleblock.  Only send the done channel with this message
 	// if no transactions will be sent afterwards.
+	numMatched := len(matchedTxIndices) + len(matchedSTxIndices)
 	var dc chan<- struct{}
-	if len(matchedTxIndices) == 0 {
+	if numMatched == 0 {
 		dc = doneChan
 	}
 	sp.QueueMessage(merkle, dc)
 
-	// Finally, send any matched transactions.
-	blkTransactions := blk.MsgBlock().Transactions
-	for i, txIndex := range matchedTxIndices {
+	// Finally, send any matched regular transactions followed by any
+	// matched stake transactions.
+	msgBlock := blk.MsgBlock()
+	matchedTxns := make([]*wire.MsgTx, 0, numMatched)
+	for _, txIndex := range matchedTxIndices {
+		if txIndex < uint32(len(msgBlock.Transactions)) {
+			matchedTxns = append(matchedTxns,
+				msgBlock.Transactions[txIndex])
+		}
+	}
+	for _, txIndex := range matchedSTxIndices {
+		if txIndex < uint32(len(msgBlock.STransactions)) {
+			matchedTxns = append(matchedTxns,
+				msgBlock.STransactions[txIndex])
+		}
+	}
+	for i, tx := range matchedTxns {
 		// Only send the done channel on the final transaction.
 		var dc chan<- struct{}
-		if i == len(matchedTxIndices)-1 {
+		if i == len(matchedTxns)-1 {
 			dc = doneChan
 		}
-		if txIndex < uint32(len(blkTransactions)) {
-			sp.QueueMessage(blkTransactions[txIndex], dc)
-		}
+		sp.QueueMessage(tx, dc)
 	}
-
 	return nil
 }
 
@@ -1307,21 +1863,52 @@ func (s *server) handleBanPeerMsg(state *peerState, sp *serverPeer) {
 // handleRelayInvMsg deals with relaying inventory to peers that are not already
 // known to have it.  It is invoked from the peerHandler goroutine.
 func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
+	var cmpctBlock *wire.MsgCmpctBlock
 	state.forAllPeers(func(sp *serverPeer) {
 		if !sp.Connected() {
 			return
 		}
 
+		// If the inventory is a block and the peer asked for new blocks
+		// to be announced with compact blocks, send it a compact block
+		// instead of an inventory message.  The compact block is only
+		// created once for all peers.
+		if msg.invVect.Type == wire.InvTypeBlock && sp.WantsCmpctBlocks() {
+			block, ok := msg.data.(*dcrutil.Block)
+			if !ok {
+				peerLog.Warnf("Underlying data for compact block" +
+					" is not a block")
+				return
+			}
+			if sp.HasKnownInventory(msg.invVect) {
+				return
+			}
+			if cmpctBlock == nil {
+				nonce, err := wire.RandomUint64()
+				if err != nil {
+					peerLog.Errorf("Failed to generate compact "+
+						"block nonce: %v", err)
+					return
+				}
+				cmpctBlock = wire.NewMsgCmpctBlockFromBlock(
+					block.MsgBlock(), nonce)
+			}
+			sp.AddKnownInventory(msg.invVect)
+			sp.QueueMessage(cmpctBlock, nil)
+			return
+		}
+
 		// If the inventory is a block and the peer prefers headers,
 		// generate and send a headers message instead of an inventory
 		// message.
 		if msg.invVect.Type == wire.InvTypeBlock && sp.WantsHeaders() {
-			blockHeader, ok := msg.data.(wire.BlockHeader)
+			block, ok := msg.data.(*dcrutil.Block)
 			if !ok {
 				peerLog.Warnf("Underlying data for headers" +
-					" is not a block header")
+					" is not a block")
 				return
 			}
+			blockHeader := block.MsgBlock().Header
 			msgHeaders := wire.NewMsgHeaders()
 			if err := msgHeaders.AddBlockHeader(&blockHeader); err != nil {
 				peerLog.Errorf("Failed to add block"+
@@ -1333,24 +1920,32 @@ func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
 		}
 
 		if msg.invVect.Type == wire.InvTypeTx {
-			// Don't relay the transaction to the peer when it has
-			// transaction relaying disabled.
-			if sp.relayTxDisabled() {
+			tx, ok := msg.data.(*dcrutil.Tx)
+			if !ok {
+				peerLog.Warnf("Underlying data for tx" +
+					" inv relay is not a transaction")
 				return
 			}
-			// Don't relay the transaction if there is a bloom
+
+			// Don't relay the transaction to the peer when it has
+			// transaction relaying disabled or there is a bloom
 			// filter loaded and the transaction doesn't match it.
-			if sp.filter.IsLoaded() {
-				tx, ok := msg.data.(*dcrutil.Tx)
-				if !ok {
-					peerLog.Warnf("Underlying data for tx" +
-						" inv relay is not a transaction")
-					return
-				}
+			if !sp.wantsTxRelay(tx) {
+				return
+			}
 
-				if !sp.filter.MatchTxAndUpdate(tx) {
-					return
-				}
+			// Announce locally submitted transactions to the peer
+			// immediately rather than trickling them with the next
+			// batch.  They are announced every time they are
+			// relayed, even when the peer is already known to have
+			// them, as they are rebroadcast until mined.
+			if s.localTxs.IsLocal(tx.Hash()) {
+				invMsg := wire.NewMsgInvSizeHint(1)
+				invMsg.AddInvVect(msg.invVect)
+				sp.AddKnownInventory(msg.invVect)
+				sp.QueueMessage(invMsg, nil)
+				s.localTxs.RecordOffered(tx.Hash(), sp.ID())
+				return
 			}
 		}
 
@@ -1375,6 +1970,15 @@ func (s *server) handleBroadcastMsg(state *peerState, bmsg *broadcastMsg) {
 			}
 		}
 
+		// Double spend proofs are only sent to peers which support them
+		// and have not disabled transaction relay.
+		if _, ok := bmsg.message.(*wire.MsgDoubleSpendProof); ok {
+			if sp.ProtocolVersion() < wire.DoubleSpendProofVersion ||
+				sp.relayTxDisabled() {
+				return
+			}
+		}
+
 		sp.QueueMessage(bmsg.message, nil)
 	})
 }
@@ -1465,6 +2069,35 @@ func (s *server) handleQuery(state *peerState, querymsg interface{}) {
 			Permanent: msg.permanent,
 		})
 		msg.reply <- nil
+
+	case lanPeerMsg:
+		// Ignore peers discovered on the local network when the max
+		// number of peers is reached or they are already connected.
+		if state.Count() >= cfg.MaxPeers {
+			return
+		}
+		connected := false
+		state.forAllPeers(func(sp *serverPeer) {
+			if sp.Addr() == msg.addr {
+				connected = true
+			}
+		})
+		if connected {
+			return
+		}
+
+		netAddr, err := addrStringToNetAddr(msg.addr)
+		if err != nil {
+			srvrLog.Debugf("Unable to connect to local network peer "+
+				"%s: %v", msg.addr, err)
+			return
+		}
+
+		go s.connManager.Connect(&connmgr.ConnReq{
+			Addr:      netAddr,
+			Permanent: false,
+		})
+
 	case removeNodeMsg:
 		found := disconnectPeer(state.persistentPeers, msg.cmp, func(sp *serverPeer) {
 			// Keep group counts ok since we remove from
@@ -1552,24 +2185,32 @@ func disconnectPeer(peerList map[int32]*serverPeer, compareFunc func(*serverPeer
 func newPeerConfig(sp *serverPeer) *peer.Config {
 	return &peer.Config{
 		Listeners: peer.MessageListeners{
-			OnVersion:        sp.OnVersion,
-			OnMemPool:        sp.OnMemPool,
-			OnGetMiningState: sp.OnGetMiningState,
-			OnMiningState:    sp.OnMiningState,
-			OnTx:             sp.OnTx,
-			OnBlock:          sp.OnBlock,
-			OnInv:            sp.OnInv,
-			OnHeaders:        sp.OnHeaders,
-			OnGetData:        sp.OnGetData,
-			OnGetBlocks:      sp.OnGetBlocks,
-			OnGetHeaders:     sp.OnGetHeaders,
-			OnFilterAdd:      sp.OnFilterAdd,
-			OnFilterClear:    sp.OnFilterClear,
-			OnFilterLoad:     sp.OnFilterLoad,
-			OnGetAddr:        sp.OnGetAddr,
-			OnAddr:           sp.OnAddr,
-			OnRead:           sp.OnRead,
-			OnWrite:          sp.OnWrite,
+			OnVersion:          sp.OnVersion,
+			OnVerAck:           sp.OnVerAck,
+			OnMemPool:          sp.OnMemPool,
+			OnGetMiningState:   sp.OnGetMiningState,
+			OnMiningState:      sp.OnMiningState,
+			OnTx:               sp.OnTx,
+			OnPackage:          sp.OnPackage,
+			OnGetPackage:       sp.OnGetPackage,
+			OnBlock:            sp.OnBlock,
+			OnCmpctBlock:       sp.OnCmpctBlock,
+			OnBlockTxn:         sp.OnBlockTxn,
+			OnInv:              sp.OnInv,
+			OnNotFound:         sp.OnNotFound,
+			OnHeaders:          sp.OnHeaders,
+			OnGetData:          sp.OnGetData,
+			OnGetBlockTxn:      sp.OnGetBlockTxn,
+			OnGetBlocks:        sp.OnGetBlocks,
+			OnGetHeaders:       sp.OnGetHeaders,
+			OnFilterAdd:        sp.OnFilterAdd,
+			OnFilterClear:      sp.OnFilterClear,
+			OnFilterLoad:       sp.OnFilterLoad,
+			OnGetAddr:          sp.OnGetAddr,
+			OnAddr:             sp.OnAddr,
+			OnDoubleSpendProof: sp.OnDoubleSpendProof,
+			OnRead:             sp.OnRead,
+			OnWrite:            sp.OnWrite,
 		},
 		NewestBlock:      sp.newestBlock,
 		HostToNetAddress: sp.server.addrManager.HostToNetAddress,
@@ -1579,7 +2220,10 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		ChainParams:      sp.server.chainParams,
 		Services:         sp.server.services,
 		DisableRelayTx:   cfg.BlocksOnly,
-		ProtocolVersion:  wire.SendHeadersVersion,
+		ProtocolVersion:  wire.DoubleSpendProofVersion,
+		Clock:            sp.server.clock,
+		MessageStats:     sp.msgStats,
+		HandlePanic:      sp.handlePanic,
 	}
 }
 
@@ -1684,6 +2328,10 @@ out:
 		case invMsg := <-s.relayInv:
 			s.handleRelayInvMsg(state, invMsg)
 
+		// New addresses to relay to a subset of the other peers.
+		case amsg := <-s.relayAddrs:
+			s.handleRelayAddrMsg(state, &amsg)
+
 		// Message to broadcast to all connected peers except those
 		// which are excluded by the message.
 		case bmsg := <-s.broadcast:
@@ -1705,6 +2353,7 @@ out:
 	s.connManager.Stop()
 	s.blockManager.Stop()
 	s.addrManager.Stop()
+	s.saveFeeEstimates()
 
 	// Drain channels before exiting so nothing is left waiting around
 	// to send.
@@ -1715,6 +2364,7 @@ cleanup:
 		case <-s.donePeers:
 		case <-s.peerHeightsUpdate:
 		case <-s.relayInv:
+		case <-s.relayAddrs:
 		case <-s.broadcast:
 		case <-s.query:
 		default:
@@ -1957,6 +2607,11 @@ func (s *server) Start() {
 		go s.upnpUpdateThread()
 	}
 
+	if cfg.LANPeers {
+		s.wg.Add(1)
+		go s.lanPeerHandler()
+	}
+
 	if !cfg.DisableRPC {
 		s.wg.Add(1)
 
@@ -1994,6 +2649,11 @@ func (s *server) Stop() error {
 		s.rpcServer.Stop()
 	}
 
+	// Interrupt the index maintenance operations in progress.
+	if s.indexManager != nil {
+		s.indexManager.Stop()
+	}
+
 	// Signal the remaining goroutines to quit.
 	close(s.quit)
 	return nil
@@ -2156,11 +2816,15 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	if cfg.NoPeerBloomFilters {
 		services &^= wire.SFNodeBloom
 	}
+	if cfg.Prune != 0 {
+		services &^= wire.SFNodeNetwork
+	}
 
 	amgr := addrmgr.New(cfg.DataDir, dcrdLookup)
 
 	var listeners []net.Listener
 	var nat NAT
+	var discoverPort uint16
 	if !cfg.DisableListen {
 		ipv4Addrs, ipv6Addrs, wildcard, err :=
 			parseListeners(listenAddrs)
@@ -2214,6 +2878,17 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 			// nil nat here is fine, just means no upnp on network.
 		}
 
+		// Discover the external address from the addresses outbound
+		// peers report seeing the local node as when enabled.  Peers
+		// only see the address of the proxy when one is used.
+		if discover && cfg.DiscoverIP && cfg.Proxy == "" {
+			port, err := strconv.ParseUint(
+				activeNetParams.DefaultPort, 10, 16)
+			if err == nil {
+				discoverPort = uint16(port)
+			}
+		}
+
 		// TODO(oga) nonstandard port...
 		if wildcard {
 			port, err :=
@@ -2283,38 +2958,83 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		}
 	}
 
+	// Accept links from trusted peers over mutual TLS and dial the trusted
+	// peers over it when configured.
+	dial := dcrdDial
+	var tlsPeerAddrs []net.Addr
+	if len(cfg.TLSPeerListeners) > 0 {
+		tlsConfig, err := newTLSPeerConfig(cfg.TLSPeerCert, cfg.TLSPeerKey,
+			cfg.TLSPeerCAFile, "")
+		if err != nil {
+			return nil, err
+		}
+		tlsListeners, err := tlsPeerListeners(cfg.TLSPeerListeners,
+			tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, tlsListeners...)
+	}
+	if len(cfg.TLSPeers) > 0 {
+		dialer, addrs, err := newTLSPeerDialer(cfg.TLSPeers,
+			cfg.TLSPeerCert, cfg.TLSPeerKey, cfg.TLSPeerCAFile)
+		if err != nil {
+			return nil, err
+		}
+		dial = dialer.Dial
+		tlsPeerAddrs = addrs
+	}
+
+	// The clock may only be warped on the simulation test network.
+	clock := blockchain.SystemClock()
+	var warpClock *blockchain.WarpClock
+	if cfg.SimNet {
+		warpClock = blockchain.NewWarpClock(clock)
+		clock = warpClock
+	}
+
 	s := server{
 		chainParams:          chainParams,
 		addrManager:          amgr,
+		discoverPort:         discoverPort,
 		newPeers:             make(chan *serverPeer, cfg.MaxPeers),
 		donePeers:            make(chan *serverPeer, cfg.MaxPeers),
 		banPeers:             make(chan *serverPeer, cfg.MaxPeers),
 		query:                make(chan interface{}),
 		relayInv:             make(chan relayMsg, cfg.MaxPeers),
+		relayAddrs:           make(chan relayAddrMsg, cfg.MaxPeers),
 		broadcast:            make(chan broadcastMsg, cfg.MaxPeers),
 		quit:                 make(chan struct{}),
 		modifyRebroadcastInv: make(chan interface{}),
 		peerHeightsUpdate:    make(chan updatePeerHeightsMsg),
 		nat:                  nat,
 		db:                   db,
-		timeSource:           blockchain.NewMedianTime(),
+		timeSource:           blockchain.NewMedianTimeWithClock(clock),
+		clock:                clock,
+		warpClock:            warpClock,
 		services:             services,
 		sigCache:             txscript.NewSigCache(cfg.SigCacheMaxSize),
+		localTxs:             newLocalTxRelay(),
+		dsProofs:             newRecentDSProofs(),
+		peerFilter:           newPeerFilter(cfg.peerFilterRules),
+	}
+	if _, err := rand.Read(s.addrRelaySecret[:]); err != nil {
+		return nil, err
 	}
 
 	// Create the transaction and address indexes if needed.
 	//
 	// CAUTION: the txindex needs to be first in the indexes array because
-	// the addrindex uses data from the txindex during catchup.  If the
-	// addrindex is run first, it may not have the transactions from the
-	// current block indexed.
+	// the addrindex and balanceindex use data from the txindex during
+	// catchup.  If they are run first, they may not have the transactions
+	// from the current block indexed.
 	var indexes []indexers.Indexer
-	if cfg.TxIndex || cfg.AddrIndex {
-		// Enable transaction index if address index is enabled since it
-		// requires it.
+	if cfg.TxIndex || cfg.AddrIndex || cfg.BalanceIndex {
+		// Enable transaction index if the address or balance index is
+		// enabled since they require it.
 		if !cfg.TxIndex {
 			indxLog.Infof("Transaction index enabled because it " +
-				"is required by the address index")
+				"is required by the address or balance index")
 			cfg.TxIndex = true
 		} else {
 			indxLog.Info("Transaction index is enabled")
@@ -2333,11 +3053,27 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		s.existsAddrIndex = indexers.NewExistsAddrIndex(db, chainParams)
 		indexes = append(indexes, s.existsAddrIndex)
 	}
+	if cfg.WindowAggIndex {
+		indxLog.Info("Window aggregate index is enabled")
+		s.windowAggIndex = indexers.NewWindowAggIndex(db, chainParams)
+		indexes = append(indexes, s.windowAggIndex)
+	}
+	if cfg.SpendIndex {
+		indxLog.Info("Spend index is enabled")
+		s.spendIndex = indexers.NewSpendIndex(db)
+		indexes = append(indexes, s.spendIndex)
+	}
+	if cfg.BalanceIndex {
+		indxLog.Info("Balance index is enabled")
+		s.balanceIndex = indexers.NewBalanceIndex(db)
+		indexes = append(indexes, s.balanceIndex)
+	}
 
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager blockchain.IndexManager
 	if len(indexes) > 0 {
-		indexManager = indexers.NewManager(db, indexes, chainParams)
+		s.indexManager = indexers.NewManager(db, indexes, chainParams)
+		indexManager = s.indexManager
 	}
 	bm, err := newBlockManager(&s, indexManager)
 	if err != nil {
@@ -2345,6 +3081,21 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	}
 	s.blockManager = bm
 
+	// Create the fee estimators and restore their state saved on the last
+	// shutdown.
+	bestHeight := bm.chain.BestSnapshot().Height
+	s.feeEstimator, err = newFeeEstimator(feeEstimatesFileName,
+		minFeeEstimateBucketFee, maxFeeEstimateBucketFee, bestHeight)
+	if err != nil {
+		return nil, err
+	}
+	s.ticketFeeEstimator, err = newFeeEstimator(ticketFeeEstimatesFileName,
+		minTicketFeeEstimateBucketFee, maxTicketFeeEstimateBucketFee,
+		bestHeight)
+	if err != nil {
+		return nil, err
+	}
+
 	txC := mempool.Config{
 		Policy: mempool.Policy{
 			DisableRelayPriority: cfg.NoRelayPriority,
@@ -2354,6 +3105,10 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 			MaxSigOpsPerTx:       blockchain.MaxSigOpsPerBlock / 5,
 			MinRelayTxFee:        cfg.minRelayTxFee,
 			AllowOldVotes:        cfg.AllowOldVotes,
+			AcceptReplacement:    !cfg.NoReplacement,
+			IncrementalRelayFee:  cfg.incrRelayFee,
+			MaxReplacedTxs:       cfg.MaxReplacedTxs,
+			MaxPoolSize:          int64(cfg.MaxMempoolSize) * 1024 * 1024,
 		},
 		ChainParams: chainParams,
 		// EnableAddrIndex: !cfg.NoAddrIndex, TODO
@@ -2370,12 +3125,16 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 			bm.chainState.Unlock()
 			return sDiff, nil
 		},
-		FetchUtxoView:   s.blockManager.chain.FetchUtxoView,
-		Chain:           s.blockManager.chain,
-		SigCache:        s.sigCache,
-		TimeSource:      s.timeSource,
-		AddrIndex:       s.addrIndex,
-		ExistsAddrIndex: s.existsAddrIndex,
+		FetchUtxoView:             s.blockManager.chain.FetchUtxoView,
+		Chain:                     s.blockManager.chain,
+		SigCache:                  s.sigCache,
+		TimeSource:                s.timeSource,
+		Clock:                     s.clock,
+		AddrIndex:                 s.addrIndex,
+		ExistsAddrIndex:           s.existsAddrIndex,
+		AddTxToFeeEstimation:      s.addTxToFeeEstimation,
+		RemoveTxFromFeeEstimation: s.removeTxFromFeeEstimation,
+		TxReplaced:                s.txReplaced,
 	}
 	s.txMemPool = mempool.New(&txC)
 
@@ -2390,6 +3149,13 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	}
 	s.cpuMiner = newCPUMiner(&policy, &s)
 
+	if cfg.SimNet {
+		s.simnetHarness, err = newSimnetHarness(&s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Only setup a function to return new addresses to connect to when
 	// not running in connect-only mode.  The simulation network is always
 	// in connect-only mode since it is only intended to connect to
@@ -2446,7 +3212,7 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		OnAccept:       s.inboundPeerConnected,
 		RetryDuration:  connectionRetryInterval,
 		TargetOutbound: uint32(targetOutbound),
-		Dial:           dcrdDial,
+		Dial:           dial,
 		OnConnection:   s.outboundPeerConnected,
 		GetNewAddress:  newAddressFunc,
 	})
@@ -2471,6 +3237,12 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 			Permanent: true,
 		})
 	}
+	for _, addr := range tlsPeerAddrs {
+		go s.connManager.Connect(&connmgr.ConnReq{
+			Addr:      addr,
+			Permanent: true,
+		})
+	}
 
 	if !cfg.DisableRPC {
 		s.rpcServer, err = newRPCServer(cfg.RPCListeners, &policy, &s)
@@ -0,0 +1,304 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainec"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+const (
+	// simnetHarnessTicketFee is the fee in atoms paid by each ticket the
+	// simnet harness purchases.
+	simnetHarnessTicketFee = 1e6
+
+	// simnetHarnessTicketFeeLimits are the fee limits of the commitments of
+	// the tickets the simnet harness purchases.  They do not permit a fee
+	// for votes and permit a fee of up to 2^24 atoms for revocations.
+	simnetHarnessTicketFeeLimits = 0x5800
+
+	// simnetHarnessVoteBits are the vote bits of the votes the simnet
+	// harness casts.  They approve the regular transaction tree of the block
+	// voted on.
+	simnetHarnessVoteBits = dcrutil.BlockValid
+)
+
+// simnetHarness advances the chain on the simulation test network without
+// relying on wallets to purchase tickets and vote.  It owns a key which is
+// generated when the server starts and is only kept in memory.  Before each
+// block is mined, it casts votes with its tickets which were selected to vote
+// on the current best block and purchases tickets to replace them using
+// outputs which are credited directly to the utxo set.
+//
+// Since the key is not persisted, the tickets of the harness are missed once
+// the server is restarted.
+type simnetHarness struct {
+	server   *server
+	privKey  chainec.PrivateKey
+	addr     dcrutil.Address
+	pkScript []byte
+
+	// mtx serializes advancing the chain and protects the tickets the
+	// harness purchased which have not yet voted.
+	mtx     sync.Mutex
+	tickets map[chainhash.Hash]struct{}
+}
+
+// newSimnetHarness returns a new simnet harness for the passed server with a
+// newly generated key.
+func newSimnetHarness(s *server) (*simnetHarness, error) {
+	keyBytes, _, _, err := chainec.Secp256k1.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	privKey, pubKey := chainec.Secp256k1.PrivKeyFromBytes(keyBytes)
+	addr, err := dcrutil.NewAddressPubKeyHash(
+		dcrutil.Hash160(pubKey.SerializeCompressed()), s.chainParams,
+		chainec.ECTypeSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &simnetHarness{
+		server:   s,
+		privKey:  privKey,
+		addr:     addr,
+		pkScript: pkScript,
+		tickets:  make(map[chainhash.Hash]struct{}),
+	}, nil
+}
+
+// submit adds the passed transaction to the memory pool and announces it.
+func (h *simnetHarness) submit(msgTx *wire.MsgTx) error {
+	tx := dcrutil.NewTx(msgTx)
+	acceptedTxs, err := h.server.blockManager.ProcessTransaction(tx, false,
+		false, true)
+	if err != nil {
+		return fmt.Errorf("transaction %v rejected: %v", tx.Hash(), err)
+	}
+	h.server.AnnounceNewTransactions(acceptedTxs)
+	return nil
+}
+
+// vote casts votes on the passed block with the tickets of the harness which
+// were selected to vote on it.
+//
+// This function MUST be called with the harness lock held.
+func (h *simnetHarness) vote(blockHash *chainhash.Hash, height int64) error {
+	chain := h.server.blockManager.chain
+	winners, _, _, err := chain.LotteryDataForBlock(blockHash)
+	if err != nil {
+		return err
+	}
+
+	voteSubsidy := blockchain.CalcStakeVoteSubsidy(chain.FetchSubsidyCache(),
+		height, h.server.chainParams)
+	blockRefScript, err := txscript.GenerateSSGenBlockRef(*blockHash,
+		uint32(height))
+	if err != nil {
+		return err
+	}
+	voteBitsScript, err := txscript.GenerateSSGenVotes(simnetHarnessVoteBits)
+	if err != nil {
+		return err
+	}
+	payScript, err := txscript.PayToSSGen(h.addr)
+	if err != nil {
+		return err
+	}
+
+	for i := range winners {
+		ticketHash := &winners[i]
+		if _, ok := h.tickets[*ticketHash]; !ok {
+			continue
+		}
+		delete(h.tickets, *ticketHash)
+
+		entry, err := chain.FetchUtxoEntry(ticketHash)
+		if err != nil {
+			return err
+		}
+		if entry == nil || entry.IsOutputSpent(0) {
+			continue
+		}
+		ticketPrice := entry.AmountByIndex(0)
+		ticketScript := entry.PkScriptByIndex(0)
+
+		// The single commitment of the ticket is its purchase amount, so
+		// the vote pays the ticket price along with the vote subsidy.
+		commitAmounts := []int64{ticketPrice + simnetHarnessTicketFee}
+		reward := stake.CalculateRewards(commitAmounts, ticketPrice,
+			voteSubsidy)
+
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+				math.MaxUint32, wire.TxTreeRegular),
+			Sequence:        wire.MaxTxInSequenceNum,
+			ValueIn:         voteSubsidy,
+			BlockHeight:     wire.NullBlockHeight,
+			BlockIndex:      wire.NullBlockIndex,
+			SignatureScript: h.server.chainParams.StakeBaseSigScript,
+		})
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: *wire.NewOutPoint(ticketHash, 0,
+				wire.TxTreeStake),
+			Sequence:    wire.MaxTxInSequenceNum,
+			ValueIn:     ticketPrice,
+			BlockHeight: uint32(entry.BlockHeight()),
+			BlockIndex:  entry.BlockIndex(),
+		})
+		tx.AddTxOut(wire.NewTxOut(0, blockRefScript))
+		tx.AddTxOut(wire.NewTxOut(0, voteBitsScript))
+		tx.AddTxOut(wire.NewTxOut(reward[0], payScript))
+		sigScript, err := txscript.SignatureScript(tx, 1, ticketScript,
+			txscript.SigHashAll, h.privKey, true)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[1].SignatureScript = sigScript
+
+		if err := h.submit(tx); err != nil {
+			return err
+		}
+		srvrLog.Debugf("Simnet harness voted on block %v with ticket %v",
+			blockHash, ticketHash)
+	}
+
+	return nil
+}
+
+// ticketTx returns an unsigned ticket of the harness with the passed price
+// which commits to paying its full purchase amount, which is the price plus the
+// ticket fee, to the harness.  The previous output it spends is left unset.
+func (h *simnetHarness) ticketTx(ticketPrice int64) *wire.MsgTx {
+	amount := ticketPrice + simnetHarnessTicketFee
+
+	// The scripts only fail to be created for unsupported addresses, which
+	// is impossible since the address of the harness is a standard
+	// pay-to-pubkey-hash address.
+	ticketScript, _ := txscript.PayToSStx(h.addr)
+	commitScript, _ := txscript.GenerateSStxAddrPush(h.addr,
+		dcrutil.Amount(amount), simnetHarnessTicketFeeLimits)
+	changeScript, _ := txscript.PayToSStxChange(h.addr)
+
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(&wire.TxIn{
+		Sequence: wire.MaxTxInSequenceNum,
+		ValueIn:  amount,
+	})
+	tx.AddTxOut(wire.NewTxOut(ticketPrice, ticketScript))
+	tx.AddTxOut(wire.NewTxOut(0, commitScript))
+	tx.AddTxOut(wire.NewTxOut(0, changeScript))
+	return tx
+}
+
+// purchaseTickets purchases the passed number of tickets at the passed price
+// with outputs which are credited directly to the utxo set.
+//
+// This function MUST be called with the harness lock held.
+func (h *simnetHarness) purchaseTickets(numTickets int, ticketPrice int64) error {
+	outputs := make([]*wire.TxOut, numTickets)
+	for i := range outputs {
+		outputs[i] = wire.NewTxOut(ticketPrice+simnetHarnessTicketFee,
+			h.pkScript)
+	}
+	chain := h.server.blockManager.chain
+	fundingHash, height, err := chain.CreditOutputs(outputs)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < numTickets; i++ {
+		tx := h.ticketTx(ticketPrice)
+		tx.TxIn[0].PreviousOutPoint = *wire.NewOutPoint(fundingHash,
+			uint32(i), wire.TxTreeRegular)
+		tx.TxIn[0].BlockHeight = uint32(height)
+		tx.TxIn[0].BlockIndex = wire.NullBlockIndex
+		sigScript, err := txscript.SignatureScript(tx, 0, h.pkScript,
+			txscript.SigHashAll, h.privKey, true)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[0].SignatureScript = sigScript
+
+		if err := h.submit(tx); err != nil {
+			return err
+		}
+		h.tickets[tx.TxHash()] = struct{}{}
+	}
+
+	return nil
+}
+
+// advance mines the passed number of blocks with the CPU miner and returns
+// their hashes.  Before each block is mined, the votes of the harness on the
+// current best block are cast and as many tickets as are selected to vote on
+// each block are purchased.
+//
+// An error is returned when fewer votes than required by the consensus rules
+// are available for a block after stake validation height, which happens when
+// the tickets of the harness do not make up the majority of the ticket pool.
+// The hashes of the blocks which were mined before the error are returned
+// along with it.
+//
+// This function is safe for concurrent access.
+func (h *simnetHarness) advance(numBlocks uint32) ([]*chainhash.Hash, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	bm := h.server.blockManager
+	params := h.server.chainParams
+	blockHashes := make([]*chainhash.Hash, 0, numBlocks)
+	for i := uint32(0); i < numBlocks; i++ {
+		best := bm.chain.BestSnapshot()
+		if err := h.vote(best.Hash, best.Height); err != nil {
+			return blockHashes, err
+		}
+
+		// The CPU miner waits indefinitely for enough votes, so ensure
+		// they are available before attempting to mine the block.
+		if best.Height+1 >= params.StakeValidationHeight {
+			numVotes := len(h.server.txMemPool.VoteHashesForBlock(
+				*best.Hash))
+			minVotes := int(minVotesRequired(params))
+			if numVotes < minVotes {
+				return blockHashes, fmt.Errorf("only %d of the %d "+
+					"votes required to extend block %v are "+
+					"available", numVotes, minVotes, best.Hash)
+			}
+		}
+
+		bm.chainState.Lock()
+		ticketPrice := bm.chainState.nextStakeDifficulty
+		bm.chainState.Unlock()
+		err := h.purchaseTickets(int(params.TicketsPerBlock), ticketPrice)
+		if err != nil {
+			return blockHashes, err
+		}
+
+		hashes, err := h.server.cpuMiner.GenerateNBlocks(1)
+		if err != nil {
+			return blockHashes, err
+		}
+		blockHashes = append(blockHashes, hashes...)
+	}
+
+	return blockHashes, nil
+}
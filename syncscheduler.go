@@ -0,0 +1,415 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+)
+
+const (
+	// maxSyncWindowBlocks is the maximum number of blocks that are either
+	// requested from the sync peers or received out of order and held
+	// until the blocks preceding them are received during headers-first
+	// mode.  It bounds the memory used by held blocks.
+	maxSyncWindowBlocks = 1024
+
+	// minSyncPeerBlocks is the minimum number of blocks a sync peer is
+	// assigned regardless of its measured throughput.  It ensures new and
+	// slow peers are still assigned enough blocks to measure their
+	// throughput as it changes.
+	minSyncPeerBlocks = 16
+
+	// syncRateSmoothing is the weight given to each new throughput sample
+	// of a sync peer in its exponentially weighted moving average.
+	syncRateSmoothing = 0.2
+
+	// syncStallDecay is the amount of time a sync peer with outstanding
+	// requests may go without delivering a block before its throughput is
+	// considered to be decaying.  The throughput of a stalled peer decays
+	// proportionally to the time it has stalled so that its blocks are
+	// rebalanced towards the other peers.
+	syncStallDecay = time.Second * 5
+
+	// syncStallTimeout is the amount of time a sync peer with outstanding
+	// requests may go without delivering a block before it is considered
+	// to have stalled the download.
+	syncStallTimeout = time.Second * 30
+
+	// syncStallCheckInterval is the interval at which the sync peers are
+	// checked for stalls during headers-first mode.
+	syncStallCheckInterval = time.Second * 5
+)
+
+// syncRequest houses a block requested from a sync peer.
+type syncRequest struct {
+	node        *headerNode
+	requestedAt time.Time
+}
+
+// syncPeerState houses the scheduling state and measured throughput of a
+// single sync peer.
+type syncPeerState struct {
+	inFlight map[chainhash.Hash]syncRequest
+
+	// lastProgress is the last time the peer delivered a block or was
+	// assigned blocks while it had none outstanding.
+	lastProgress time.Time
+
+	// missingHeight is the lowest height of the blocks the peer responded
+	// it does not have, or zero when it has not responded so.  Blocks at or
+	// above it are no longer assigned to the peer.
+	missingHeight int64
+
+	blocksReceived uint64
+	bytesReceived  uint64
+
+	// rate is the moving average of the throughput of the peer in bytes
+	// per second.  It is zero until the first block is received.
+	rate float64
+}
+
+// effectiveRate returns the throughput of the peer in bytes per second taking
+// into account how long it has stalled with outstanding requests.
+func (s *syncPeerState) effectiveRate(now time.Time) float64 {
+	if len(s.inFlight) == 0 {
+		return s.rate
+	}
+	stalled := now.Sub(s.lastProgress)
+	if stalled <= syncStallDecay {
+		return s.rate
+	}
+	return s.rate * float64(syncStallDecay) / float64(stalled)
+}
+
+// headerNodesByHeight provides sorting of header nodes from the lowest to the
+// highest height.
+type headerNodesByHeight []*headerNode
+
+func (s headerNodesByHeight) Len() int           { return len(s) }
+func (s headerNodesByHeight) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s headerNodesByHeight) Less(i, j int) bool { return s[i].height < s[j].height }
+
+// syncStatusByID provides sorting of sync peer status results by peer id.
+type syncStatusByID []dcrjson.SyncPeerStatusResult
+
+func (s syncStatusByID) Len() int           { return len(s) }
+func (s syncStatusByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s syncStatusByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+
+// blockSyncScheduler schedules the blocks which are downloaded during
+// headers-first mode across all of the sync peers.  Each peer is assigned a
+// share of the blocks in the sync window proportional to its measured
+// throughput, so faster peers are assigned more blocks, and the shares are
+// rebalanced as the throughput of the peers changes.  Blocks are assigned to
+// each peer in contiguous ranges of heights.
+//
+// Since blocks in headers-first mode must be processed in order, blocks which
+// are received before the blocks preceding them are held until those blocks
+// are processed.  The blocks which were assigned to a peer that disconnects or
+// does not have them are reassigned to the other peers before any new blocks.
+//
+// The scheduler is not safe for concurrent access.  It is only accessed from
+// the block handler goroutine.
+type blockSyncScheduler struct {
+	peers    map[*serverPeer]*syncPeerState
+	assigned map[chainhash.Hash]*serverPeer
+	held     map[chainhash.Hash]*blockMsg
+
+	// retries are the blocks which must be reassigned ordered by their
+	// height.
+	retries []*headerNode
+}
+
+// newBlockSyncScheduler returns a new block sync scheduler without any peers.
+func newBlockSyncScheduler() *blockSyncScheduler {
+	return &blockSyncScheduler{
+		peers:    make(map[*serverPeer]*syncPeerState),
+		assigned: make(map[chainhash.Hash]*serverPeer),
+		held:     make(map[chainhash.Hash]*blockMsg),
+	}
+}
+
+// AddPeer adds the passed peer to the peers blocks may be assigned to.
+func (s *blockSyncScheduler) AddPeer(sp *serverPeer) {
+	if _, ok := s.peers[sp]; ok {
+		return
+	}
+	s.peers[sp] = &syncPeerState{
+		inFlight: make(map[chainhash.Hash]syncRequest),
+	}
+}
+
+// Peers returns the peers blocks may be assigned to.
+func (s *blockSyncScheduler) Peers() []*serverPeer {
+	peers := make([]*serverPeer, 0, len(s.peers))
+	for sp := range s.peers {
+		peers = append(peers, sp)
+	}
+	return peers
+}
+
+// requeue adds the passed blocks to the blocks which must be reassigned.
+func (s *blockSyncScheduler) requeue(nodes ...*headerNode) {
+	s.retries = append(s.retries, nodes...)
+	sort.Sort(headerNodesByHeight(s.retries))
+}
+
+// RemovePeer removes the passed peer from the peers blocks may be assigned
+// to.  The blocks which were assigned to it and not yet received are
+// reassigned to the other peers.
+func (s *blockSyncScheduler) RemovePeer(sp *serverPeer) {
+	state, ok := s.peers[sp]
+	if !ok {
+		return
+	}
+	nodes := make([]*headerNode, 0, len(state.inFlight))
+	for hash, req := range state.inFlight {
+		delete(s.assigned, hash)
+		nodes = append(nodes, req.node)
+	}
+	delete(s.peers, sp)
+	s.requeue(nodes...)
+}
+
+// Reset discards all assigned and held blocks.  It is used when the list of
+// headers the blocks belong to is reset.  The measured throughput of the peers
+// is retained.
+func (s *blockSyncScheduler) Reset() {
+	for _, state := range s.peers {
+		state.inFlight = make(map[chainhash.Hash]syncRequest)
+	}
+	s.assigned = make(map[chainhash.Hash]*serverPeer)
+	s.held = make(map[chainhash.Hash]*blockMsg)
+	s.retries = nil
+}
+
+// quotas returns the number of blocks each peer may have outstanding.  The
+// blocks in the sync window which are not held are divided between the peers
+// proportionally to their throughput with each peer being assigned at least
+// minSyncPeerBlocks.  Peers without a measured throughput are assigned the
+// minimum.
+func (s *blockSyncScheduler) quotas(now time.Time) map[*serverPeer]int {
+	window := maxSyncWindowBlocks - len(s.held)
+	var totalRate float64
+	rates := make(map[*serverPeer]float64, len(s.peers))
+	for sp, state := range s.peers {
+		rate := state.effectiveRate(now)
+		rates[sp] = rate
+		totalRate += rate
+	}
+
+	quotas := make(map[*serverPeer]int, len(s.peers))
+	for sp, rate := range rates {
+		quota := minSyncPeerBlocks
+		if totalRate > 0 {
+			share := int(float64(window) * rate / totalRate)
+			if share > quota {
+				quota = share
+			}
+		}
+		quotas[sp] = quota
+	}
+	return quotas
+}
+
+// FreeSlots returns the number of additional blocks which should be assigned
+// to each peer along with the number of additional blocks which may be
+// assigned to all peers combined without exceeding the sync window.  Peers
+// which have blocks outstanding are only included once they can be assigned
+// at least minInFlightBlocks more in order to avoid requesting a single block
+// at a time.
+func (s *blockSyncScheduler) FreeSlots(now time.Time) (map[*serverPeer]int, int) {
+	free := make(map[*serverPeer]int, len(s.peers))
+	for sp, quota := range s.quotas(now) {
+		numInFlight := len(s.peers[sp].inFlight)
+		n := quota - numInFlight
+		if n <= 0 || (numInFlight > 0 && n < minInFlightBlocks) {
+			continue
+		}
+		free[sp] = n
+	}
+	remaining := maxSyncWindowBlocks - len(s.assigned) - len(s.held)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return free, remaining
+}
+
+// Assign records that the passed block is requested from the passed peer.
+func (s *blockSyncScheduler) Assign(sp *serverPeer, node *headerNode, now time.Time) {
+	state, ok := s.peers[sp]
+	if !ok {
+		return
+	}
+	if len(state.inFlight) == 0 {
+		state.lastProgress = now
+	}
+	state.inFlight[*node.hash] = syncRequest{node: node, requestedAt: now}
+	s.assigned[*node.hash] = sp
+}
+
+// IsAssigned returns whether or not the block with the passed hash is
+// assigned to a peer.
+func (s *blockSyncScheduler) IsAssigned(hash *chainhash.Hash) bool {
+	_, ok := s.assigned[*hash]
+	return ok
+}
+
+// Received records that the passed peer delivered the block with the passed
+// hash and serialized size and updates the measured throughput of the peer.
+// It returns whether or not the block was assigned to the peer.
+func (s *blockSyncScheduler) Received(sp *serverPeer, hash *chainhash.Hash, size int, now time.Time) bool {
+	if s.assigned[*hash] != sp {
+		return false
+	}
+	state := s.peers[sp]
+	req := state.inFlight[*hash]
+	delete(state.inFlight, *hash)
+	delete(s.assigned, *hash)
+
+	// The block was transferred in the time since the peer delivered its
+	// previous block or, when the peer was idle, since it was requested.
+	start := state.lastProgress
+	if req.requestedAt.After(start) {
+		start = req.requestedAt
+	}
+	elapsed := now.Sub(start)
+	if elapsed < time.Millisecond {
+		elapsed = time.Millisecond
+	}
+	sample := float64(size) / elapsed.Seconds()
+	if state.rate == 0 {
+		state.rate = sample
+	} else {
+		state.rate += syncRateSmoothing * (sample - state.rate)
+	}
+	state.lastProgress = now
+	state.blocksReceived++
+	state.bytesReceived += uint64(size)
+	return true
+}
+
+// NotFound records that the passed peer does not have the block with the
+// passed hash so it is reassigned to the other peers.  It returns whether or
+// not the block was assigned to the peer.
+func (s *blockSyncScheduler) NotFound(sp *serverPeer, hash *chainhash.Hash) bool {
+	if s.assigned[*hash] != sp {
+		return false
+	}
+	state := s.peers[sp]
+	req := state.inFlight[*hash]
+	delete(state.inFlight, *hash)
+	delete(s.assigned, *hash)
+	if state.missingHeight == 0 || req.node.height < state.missingHeight {
+		state.missingHeight = req.node.height
+	}
+	s.requeue(req.node)
+	return true
+}
+
+// MayHave returns whether or not the passed peer may be assigned the block at
+// the passed height since it has not responded that it does not have a block
+// at or below that height.
+func (s *blockSyncScheduler) MayHave(sp *serverPeer, height int64) bool {
+	state, ok := s.peers[sp]
+	if !ok {
+		return false
+	}
+	return state.missingHeight == 0 || height < state.missingHeight
+}
+
+// Stalled returns the peers which have outstanding requests and did not
+// deliver a block within the stall timeout.
+func (s *blockSyncScheduler) Stalled(now time.Time) []*serverPeer {
+	var stalled []*serverPeer
+	for sp, state := range s.peers {
+		if len(state.inFlight) > 0 &&
+			now.Sub(state.lastProgress) > syncStallTimeout {
+
+			stalled = append(stalled, sp)
+		}
+	}
+	return stalled
+}
+
+// HasRetries returns whether or not there are blocks which must be reassigned.
+func (s *blockSyncScheduler) HasRetries() bool {
+	return len(s.retries) > 0
+}
+
+// PeekRetry returns the lowest block which must be reassigned without removing
+// it.  It returns nil when there are no such blocks.
+func (s *blockSyncScheduler) PeekRetry() *headerNode {
+	if len(s.retries) == 0 {
+		return nil
+	}
+	return s.retries[0]
+}
+
+// PopRetry removes the lowest block which must be reassigned.
+func (s *blockSyncScheduler) PopRetry() {
+	if len(s.retries) == 0 {
+		return
+	}
+	s.retries[0] = nil
+	s.retries = s.retries[1:]
+}
+
+// Hold holds the passed block until the blocks preceding it are processed.
+func (s *blockSyncScheduler) Hold(bmsg *blockMsg) {
+	bmsg.held = true
+	s.held[*bmsg.block.Hash()] = bmsg
+}
+
+// TakeHeld returns and stops holding the block with the passed hash.  It
+// returns nil when the block is not held.
+func (s *blockSyncScheduler) TakeHeld(hash *chainhash.Hash) *blockMsg {
+	bmsg, ok := s.held[*hash]
+	if !ok {
+		return nil
+	}
+	delete(s.held, *hash)
+	return bmsg
+}
+
+// NumHeld returns the number of blocks which are held.
+func (s *blockSyncScheduler) NumHeld() int {
+	return len(s.held)
+}
+
+// Status returns the assigned blocks and measured throughput of each peer
+// ordered by peer id.
+func (s *blockSyncScheduler) Status(now time.Time) []dcrjson.SyncPeerStatusResult {
+	quotas := s.quotas(now)
+	result := make([]dcrjson.SyncPeerStatusResult, 0, len(s.peers))
+	for sp, state := range s.peers {
+		status := dcrjson.SyncPeerStatusResult{
+			ID:             sp.ID(),
+			Addr:           sp.Addr(),
+			LastBlock:      sp.LastBlock(),
+			InFlight:       len(state.inFlight),
+			Quota:          quotas[sp],
+			BlocksReceived: state.blocksReceived,
+			BytesReceived:  state.bytesReceived,
+			Throughput:     state.effectiveRate(now),
+		}
+		for _, req := range state.inFlight {
+			height := req.node.height
+			if status.AssignedFrom == 0 || height < status.AssignedFrom {
+				status.AssignedFrom = height
+			}
+			if height > status.AssignedTo {
+				status.AssignedTo = height
+			}
+		}
+		result = append(result, status)
+	}
+	sort.Sort(syncStatusByID(result))
+	return result
+}
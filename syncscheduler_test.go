@@ -0,0 +1,224 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// syncTestNodes returns header nodes for the passed range of heights with
+// hashes derived from their heights.
+func syncTestNodes(first, last int64) []*headerNode {
+	nodes := make([]*headerNode, 0, last-first+1)
+	for height := first; height <= last; height++ {
+		var hash chainhash.Hash
+		hash[0] = byte(height)
+		hash[1] = byte(height >> 8)
+		nodes = append(nodes, &headerNode{height: height, hash: &hash})
+	}
+	return nodes
+}
+
+// TestBlockSyncSchedulerQuotas ensures the blocks in the sync window are
+// divided between the sync peers proportionally to their measured throughput
+// and rebalanced when a peer stalls.
+func TestBlockSyncSchedulerQuotas(t *testing.T) {
+	s := newBlockSyncScheduler()
+	fast, slow := &serverPeer{}, &serverPeer{}
+	s.AddPeer(fast)
+	s.AddPeer(slow)
+
+	// Ensure peers without a measured throughput are assigned the minimum.
+	now := time.Now()
+	free, remaining := s.FreeSlots(now)
+	if free[fast] != minSyncPeerBlocks || free[slow] != minSyncPeerBlocks {
+		t.Fatalf("unexpected free slots for unmeasured peers - got %d "+
+			"and %d, want %d", free[fast], free[slow],
+			minSyncPeerBlocks)
+	}
+	if remaining != maxSyncWindowBlocks {
+		t.Fatalf("unexpected remaining slots - got %d, want %d",
+			remaining, maxSyncWindowBlocks)
+	}
+
+	// Assign blocks to both peers and deliver them such that the fast
+	// peer is 100 times faster than the slow peer.
+	nodes := syncTestNodes(1, 2*minSyncPeerBlocks)
+	for i, node := range nodes {
+		sp := fast
+		if i >= minSyncPeerBlocks {
+			sp = slow
+		}
+		s.Assign(sp, node, now)
+	}
+	if _, remaining := s.FreeSlots(now); remaining != maxSyncWindowBlocks-len(nodes) {
+		t.Fatalf("unexpected remaining slots - got %d, want %d",
+			remaining, maxSyncWindowBlocks-len(nodes))
+	}
+	const blockSize = 100000
+	for i, node := range nodes[:minSyncPeerBlocks] {
+		received := now.Add(time.Duration(i+1) * time.Millisecond * 10)
+		if !s.Received(fast, node.hash, blockSize, received) {
+			t.Fatalf("Received: block %d was not assigned to the peer",
+				node.height)
+		}
+	}
+	for i, node := range nodes[minSyncPeerBlocks:] {
+		received := now.Add(time.Duration(i+1) * time.Second)
+		if !s.Received(slow, node.hash, blockSize, received) {
+			t.Fatalf("Received: block %d was not assigned to the peer",
+				node.height)
+		}
+	}
+	if s.Received(fast, nodes[0].hash, blockSize, now) {
+		t.Fatal("Received: block which was already received was " +
+			"reported as assigned")
+	}
+
+	// Ensure the fast peer is assigned nearly the entire window while the
+	// slow peer is still assigned the minimum.
+	now = now.Add(time.Second * time.Duration(minSyncPeerBlocks))
+	quotas := s.quotas(now)
+	wantFast := maxSyncWindowBlocks * 100 / 101
+	if quotas[fast] != wantFast || quotas[slow] != minSyncPeerBlocks {
+		t.Fatalf("unexpected quotas - got %d and %d, want %d and %d",
+			quotas[fast], quotas[slow], wantFast, minSyncPeerBlocks)
+	}
+
+	// Ensure the throughput of the fast peer decays once it stalls with
+	// outstanding requests so its blocks are rebalanced.
+	stalled := syncTestNodes(100, 100)[0]
+	s.Assign(fast, stalled, now)
+	now = now.Add(syncStallDecay * 200)
+	quotas = s.quotas(now)
+	if quotas[fast] >= quotas[slow] {
+		t.Fatalf("quota of stalled peer %d is not less than quota %d",
+			quotas[fast], quotas[slow])
+	}
+
+	// Ensure peers with outstanding requests are only assigned more
+	// blocks once they may be assigned at least minInFlightBlocks more.
+	newPeer := &serverPeer{}
+	s.AddPeer(newPeer)
+	nodes = syncTestNodes(200, 200+minSyncPeerBlocks-minInFlightBlocks)
+	for _, node := range nodes[:len(nodes)-1] {
+		s.Assign(newPeer, node, now)
+	}
+	if free, _ := s.FreeSlots(now); free[newPeer] != minInFlightBlocks {
+		t.Fatalf("unexpected free slots for peer - got %d, want %d",
+			free[newPeer], minInFlightBlocks)
+	}
+	s.Assign(newPeer, nodes[len(nodes)-1], now)
+	if free, _ := s.FreeSlots(now); free[newPeer] != 0 {
+		t.Fatalf("unexpected free slots for peer - got %d, want 0",
+			free[newPeer])
+	}
+}
+
+// TestBlockSyncSchedulerRetries ensures blocks which were assigned to peers
+// that disconnected or do not have them are reassigned in order of height.
+func TestBlockSyncSchedulerRetries(t *testing.T) {
+	s := newBlockSyncScheduler()
+	sp1, sp2 := &serverPeer{}, &serverPeer{}
+	s.AddPeer(sp1)
+	s.AddPeer(sp2)
+
+	now := time.Now()
+	nodes := syncTestNodes(1, 10)
+	for i, node := range nodes {
+		if i%2 == 0 {
+			s.Assign(sp1, node, now)
+		} else {
+			s.Assign(sp2, node, now)
+		}
+	}
+
+	// Ensure a block the peer does not have is reassigned and the peer is
+	// no longer assigned blocks at or above its height.
+	if !s.NotFound(sp2, nodes[5].hash) {
+		t.Fatal("NotFound: block was not assigned to the peer")
+	}
+	if s.NotFound(sp1, nodes[5].hash) {
+		t.Fatal("NotFound: block was reported as assigned to the " +
+			"wrong peer")
+	}
+	if s.MayHave(sp2, nodes[5].height) || !s.MayHave(sp2, nodes[4].height) {
+		t.Fatal("MayHave: unexpected result for peer without block")
+	}
+	if !s.MayHave(sp1, nodes[9].height) {
+		t.Fatal("MayHave: unexpected result for peer with block")
+	}
+
+	// Ensure the blocks assigned to a removed peer are reassigned along
+	// with the block that was not found in order of height.
+	s.RemovePeer(sp1)
+	if s.MayHave(sp1, 1) {
+		t.Fatal("MayHave: unexpected result for removed peer")
+	}
+	wantHeights := []int64{1, 3, 5, 6, 7, 9}
+	for _, want := range wantHeights {
+		node := s.PeekRetry()
+		if node == nil {
+			t.Fatalf("PeekRetry: missing retry for height %d", want)
+		}
+		if node.height != want {
+			t.Fatalf("PeekRetry: unexpected height - got %d, want %d",
+				node.height, want)
+		}
+		if s.IsAssigned(node.hash) {
+			t.Fatalf("IsAssigned: retry for height %d is assigned",
+				want)
+		}
+		s.PopRetry()
+	}
+	if s.HasRetries() {
+		t.Fatal("HasRetries: unexpected retries")
+	}
+
+	// Ensure resetting the scheduler discards the assigned blocks.
+	if !s.IsAssigned(nodes[1].hash) {
+		t.Fatal("IsAssigned: block is not assigned")
+	}
+	s.Reset()
+	if s.IsAssigned(nodes[1].hash) {
+		t.Fatal("IsAssigned: block is assigned after reset")
+	}
+	if _, remaining := s.FreeSlots(now); remaining != maxSyncWindowBlocks {
+		t.Fatalf("unexpected remaining slots after reset - got %d, "+
+			"want %d", remaining, maxSyncWindowBlocks)
+	}
+}
+
+// TestBlockSyncSchedulerStalled ensures peers with outstanding requests which
+// do not deliver a block within the stall timeout are reported as stalled.
+func TestBlockSyncSchedulerStalled(t *testing.T) {
+	s := newBlockSyncScheduler()
+	idle, active, stalled := &serverPeer{}, &serverPeer{}, &serverPeer{}
+	s.AddPeer(idle)
+	s.AddPeer(active)
+	s.AddPeer(stalled)
+
+	now := time.Now()
+	nodes := syncTestNodes(1, 4)
+	s.Assign(active, nodes[0], now)
+	s.Assign(active, nodes[1], now)
+	s.Assign(stalled, nodes[2], now)
+
+	later := now.Add(syncStallTimeout + time.Second)
+	if !s.Received(active, nodes[0].hash, 1000, later) {
+		t.Fatal("Received: block was not assigned to the peer")
+	}
+	got := s.Stalled(later)
+	if len(got) != 1 || got[0] != stalled {
+		t.Fatalf("Stalled: unexpected stalled peers %v", got)
+	}
+	if got := s.Stalled(now); len(got) != 0 {
+		t.Fatalf("Stalled: unexpected stalled peers before the stall "+
+			"timeout %v", got)
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+const (
+	// txRequestTimeout is the amount of time a peer is given to respond to
+	// a request for a transaction before the transaction is requested from
+	// another peer that announced it.
+	txRequestTimeout = time.Second * 30
+
+	// txRequestCheckInterval is the interval at which outstanding
+	// transaction requests are checked for timeouts.
+	txRequestCheckInterval = time.Second * 5
+
+	// maxInFlightTxPerPeer is the maximum number of transactions that may
+	// be requested from a single peer at once.  Announcements beyond this
+	// limit are only used as fallbacks should another peer fail to deliver
+	// the transaction.  This limits the bandwidth a single peer can cause
+	// to be wasted by announcing transactions it never delivers.
+	maxInFlightTxPerPeer = 5000
+
+	// txRequestRate is the average number of transactions per second that
+	// may be requested from a single peer, and txRequestBurst is the
+	// number of transactions that may be requested from it at once.
+	// Announcements beyond the rate are only requested once the peer has
+	// been granted more requests again, or from other peers that announced
+	// the transactions.  This limits how quickly a single peer can make
+	// requests be wasted on transactions it never delivers even when it
+	// does not exceed its limit of in-flight requests.
+	txRequestRate  = 100
+	txRequestBurst = 1000
+
+	// maxTxAnnouncers is the maximum number of peers that are remembered
+	// as having announced a given transaction.
+	maxTxAnnouncers = 8
+
+	// maxTrackedTxRequests is the maximum number of transactions that are
+	// tracked by the request scheduler.
+	maxTrackedTxRequests = maxRequestedTxns
+)
+
+// txRequest houses the scheduling state for a single announced transaction.
+type txRequest struct {
+	// peer is the peer the transaction is currently requested from or nil
+	// when it is not currently requested from any peer.
+	peer        *serverPeer
+	requestedAt time.Time
+
+	// announcers are the peers that announced the transaction and have not
+	// yet been asked for it, in the order they announced it.
+	announcers []*serverPeer
+}
+
+// addAnnouncer adds the passed peer to the peers that may be asked for the
+// transaction when it is not already present and there is room.
+func (r *txRequest) addAnnouncer(sp *serverPeer) {
+	if len(r.announcers) >= maxTxAnnouncers {
+		return
+	}
+	for _, announcer := range r.announcers {
+		if announcer == sp {
+			return
+		}
+	}
+	r.announcers = append(r.announcers, sp)
+}
+
+// removeAnnouncer removes the passed peer from the peers that may be asked for
+// the transaction.
+func (r *txRequest) removeAnnouncer(sp *serverPeer) {
+	for i, announcer := range r.announcers {
+		if announcer == sp {
+			copy(r.announcers[i:], r.announcers[i+1:])
+			r.announcers[len(r.announcers)-1] = nil
+			r.announcers = r.announcers[:len(r.announcers)-1]
+			return
+		}
+	}
+}
+
+// txRequestPeer houses the request scheduling state of a single peer.
+type txRequestPeer struct {
+	// inFlight is the number of transactions currently requested from the
+	// peer.
+	inFlight int
+
+	// tokens is the number of transactions which may currently be requested
+	// from the peer before further requests are delayed and tokensUpdated
+	// is when it was last replenished.
+	tokens        float64
+	tokensUpdated time.Time
+}
+
+// txRequestEviction describes a transaction which is no longer tracked because
+// the maximum number of tracked transactions was reached along with the peer it
+// was requested from, if any.
+type txRequestEviction struct {
+	hash chainhash.Hash
+	peer *serverPeer
+}
+
+// txRequestTracker schedules requests for transactions announced by peers so
+// that each transaction is only requested from a single peer at a time.  The
+// other peers that announced a transaction are remembered and the transaction
+// is requested from the next one when the current peer disconnects, responds
+// with a notfound, or fails to deliver it before the request times out.
+// Requests from each peer are limited both by the number of requests in flight
+// and by the rate they are made at.
+//
+// The tracker is not safe for concurrent access.  It is only accessed from the
+// block handler goroutine.
+type txRequestTracker struct {
+	requests   map[chainhash.Hash]*txRequest
+	peers      map[*serverPeer]*txRequestPeer
+	unassigned map[chainhash.Hash]struct{}
+
+	// isConnected returns whether the passed peer is connected.  It is
+	// only replaced by tests which use peers without connections.
+	isConnected func(sp *serverPeer) bool
+}
+
+// newTxRequestTracker returns a new empty transaction request tracker.
+func newTxRequestTracker() *txRequestTracker {
+	return &txRequestTracker{
+		requests:   make(map[chainhash.Hash]*txRequest),
+		peers:      make(map[*serverPeer]*txRequestPeer),
+		unassigned: make(map[chainhash.Hash]struct{}),
+		isConnected: func(sp *serverPeer) bool {
+			return sp.Connected()
+		},
+	}
+}
+
+// peerState returns the scheduling state of the passed peer.  Peers which are
+// not known yet start out with the full burst of requests.
+func (t *txRequestTracker) peerState(sp *serverPeer, now time.Time) *txRequestPeer {
+	state, ok := t.peers[sp]
+	if !ok {
+		state = &txRequestPeer{
+			tokens:        txRequestBurst,
+			tokensUpdated: now,
+		}
+		t.peers[sp] = state
+	}
+	return state
+}
+
+// canRequest returns whether another transaction may be requested from the
+// passed peer without exceeding its limit of in-flight requests or its request
+// rate.
+func (t *txRequestTracker) canRequest(sp *serverPeer, now time.Time) bool {
+	state := t.peerState(sp, now)
+	if state.inFlight >= maxInFlightTxPerPeer {
+		return false
+	}
+
+	// Replenish the requests the peer is granted over time.
+	if elapsed := now.Sub(state.tokensUpdated).Seconds(); elapsed > 0 {
+		state.tokens = math.Min(state.tokens+elapsed*txRequestRate,
+			txRequestBurst)
+		state.tokensUpdated = now
+	}
+	return state.tokens >= 1
+}
+
+// assign records that the transaction with the passed hash is requested from
+// the passed peer.
+func (t *txRequestTracker) assign(hash *chainhash.Hash, req *txRequest, sp *serverPeer, now time.Time) {
+	req.peer = sp
+	req.requestedAt = now
+	state := t.peerState(sp, now)
+	state.inFlight++
+	if state.tokens >= 1 {
+		state.tokens--
+	}
+	delete(t.unassigned, *hash)
+}
+
+// unassign records that the transaction with the passed hash is no longer
+// requested from any peer.
+func (t *txRequestTracker) unassign(hash *chainhash.Hash, req *txRequest) {
+	if req.peer == nil {
+		return
+	}
+	if state, ok := t.peers[req.peer]; ok && state.inFlight > 0 {
+		state.inFlight--
+	}
+	req.peer = nil
+	t.unassigned[*hash] = struct{}{}
+}
+
+// remove stops tracking the transaction with the passed hash.
+func (t *txRequestTracker) remove(hash *chainhash.Hash) {
+	req, ok := t.requests[*hash]
+	if !ok {
+		return
+	}
+	t.unassign(hash, req)
+	delete(t.unassigned, *hash)
+	delete(t.requests, *hash)
+}
+
+// Announce records that the passed peer announced the transaction with the
+// passed hash and returns whether or not the transaction should be requested
+// from it.  The transaction is only requested when it is not already requested
+// from another peer and the peer has not reached its limit of in-flight
+// requests or its request rate.  Otherwise, the peer is remembered as a
+// fallback.
+//
+// When another transaction had to stop being tracked to make room for the
+// transaction, it is returned so the caller can forget about its request.
+func (t *txRequestTracker) Announce(hash *chainhash.Hash, sp *serverPeer, now time.Time) (bool, *txRequestEviction) {
+	var evicted *txRequestEviction
+	req, ok := t.requests[*hash]
+	if !ok {
+		// Evict a random request when the maximum number of tracked
+		// requests would be exceeded.  See limitMap for why random
+		// eviction is acceptable.
+		if len(t.requests)+1 > maxTrackedTxRequests {
+			for evictHash, evictReq := range t.requests {
+				evicted = &txRequestEviction{
+					hash: evictHash,
+					peer: evictReq.peer,
+				}
+				t.remove(&evictHash)
+				break
+			}
+		}
+		req = &txRequest{}
+		t.requests[*hash] = req
+		t.unassigned[*hash] = struct{}{}
+	}
+
+	if req.peer == sp {
+		return false, evicted
+	}
+	if req.peer != nil || !t.canRequest(sp, now) {
+		req.addAnnouncer(sp)
+		return false, evicted
+	}
+
+	req.removeAnnouncer(sp)
+	t.assign(hash, req, sp, now)
+	return true, evicted
+}
+
+// Requested records that the transaction with the passed hash was explicitly
+// requested from the passed peer without regard to the scheduling limits.
+func (t *txRequestTracker) Requested(hash *chainhash.Hash, sp *serverPeer, now time.Time) {
+	req, ok := t.requests[*hash]
+	if !ok {
+		req = &txRequest{}
+		t.requests[*hash] = req
+	}
+	t.unassign(hash, req)
+	req.removeAnnouncer(sp)
+	t.assign(hash, req, sp, now)
+}
+
+// Received stops tracking the transaction with the passed hash since it was
+// received.  It returns the peer the transaction was requested from, if any.
+func (t *txRequestTracker) Received(hash *chainhash.Hash) *serverPeer {
+	req, ok := t.requests[*hash]
+	if !ok {
+		return nil
+	}
+	sp := req.peer
+	t.remove(hash)
+	return sp
+}
+
+// NotFound records that the passed peer responded that it does not have the
+// transaction with the passed hash.  It returns whether or not the transaction
+// was requested from the peer.
+func (t *txRequestTracker) NotFound(hash *chainhash.Hash, sp *serverPeer) bool {
+	req, ok := t.requests[*hash]
+	if !ok {
+		return false
+	}
+	req.removeAnnouncer(sp)
+	if req.peer != sp {
+		return false
+	}
+	t.unassign(hash, req)
+	return true
+}
+
+// RemovePeer stops considering the passed peer for any transaction requests.
+// Transactions which were requested from the peer become eligible to be
+// requested from the other peers that announced them.
+func (t *txRequestTracker) RemovePeer(sp *serverPeer) {
+	for hash, req := range t.requests {
+		req.removeAnnouncer(sp)
+		if req.peer == sp {
+			t.unassign(&hash, req)
+		}
+	}
+	delete(t.peers, sp)
+}
+
+// Expire makes the transactions whose requests have been outstanding for longer
+// than the request timeout eligible to be requested from the other peers that
+// announced them.  It returns the hashes of the transactions that timed out
+// keyed by the peer that failed to deliver them.
+func (t *txRequestTracker) Expire(now time.Time) map[*serverPeer][]chainhash.Hash {
+	var expired map[*serverPeer][]chainhash.Hash
+	for hash, req := range t.requests {
+		if req.peer == nil || now.Sub(req.requestedAt) < txRequestTimeout {
+			continue
+		}
+		if expired == nil {
+			expired = make(map[*serverPeer][]chainhash.Hash)
+		}
+		expired[req.peer] = append(expired[req.peer], hash)
+		t.unassign(&hash, req)
+	}
+	return expired
+}
+
+// Reassign assigns the transactions which are not currently requested from any
+// peer to the next peer that announced them and has not reached its limit of
+// in-flight requests or its request rate.  It returns the hashes of the transactions to request
+// keyed by the peer to request them from along with the hashes of the
+// transactions which are no longer tracked because no peers that announced
+// them remain.
+func (t *txRequestTracker) Reassign(now time.Time) (map[*serverPeer][]chainhash.Hash, []chainhash.Hash) {
+	var assigned map[*serverPeer][]chainhash.Hash
+	var dropped []chainhash.Hash
+	for hash := range t.unassigned {
+		hash := hash
+		req := t.requests[hash]
+		for len(req.announcers) > 0 {
+			sp := req.announcers[0]
+			if !t.isConnected(sp) {
+				req.announcers[0] = nil
+				req.announcers = req.announcers[1:]
+				continue
+			}
+			if !t.canRequest(sp, now) {
+				break
+			}
+			req.announcers[0] = nil
+			req.announcers = req.announcers[1:]
+
+			if assigned == nil {
+				assigned = make(map[*serverPeer][]chainhash.Hash)
+			}
+			assigned[sp] = append(assigned[sp], hash)
+			t.assign(&hash, req, sp, now)
+			break
+		}
+
+		if req.peer == nil && len(req.announcers) == 0 {
+			dropped = append(dropped, hash)
+			delete(t.unassigned, hash)
+			delete(t.requests, hash)
+		}
+	}
+	return assigned, dropped
+}
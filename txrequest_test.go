@@ -0,0 +1,220 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// txRequestTestHash returns a transaction hash derived from the passed number.
+func txRequestTestHash(n int) *chainhash.Hash {
+	var hash chainhash.Hash
+	hash[0], hash[1], hash[2] = byte(n), byte(n>>8), byte(n>>16)
+	return &hash
+}
+
+// newTxRequestTestTracker returns a tracker which considers all peers connected
+// except for the ones in the returned set.
+func newTxRequestTestTracker() (*txRequestTracker, map[*serverPeer]struct{}) {
+	disconnected := make(map[*serverPeer]struct{})
+	t := newTxRequestTracker()
+	t.isConnected = func(sp *serverPeer) bool {
+		_, ok := disconnected[sp]
+		return !ok
+	}
+	return t, disconnected
+}
+
+// TestTxRequestFallback ensures a transaction is only requested from one of the
+// peers which announced it at a time and is requested from the next one when
+// the peer it was requested from does not have it, fails to deliver it in time,
+// or disconnects.
+func TestTxRequestFallback(t *testing.T) {
+	tracker, disconnected := newTxRequestTestTracker()
+	sp1, sp2, sp3 := &serverPeer{}, &serverPeer{}, &serverPeer{}
+	hash := txRequestTestHash(1)
+	now := time.Now()
+
+	// Only the first announcement results in a request.
+	if request, _ := tracker.Announce(hash, sp1, now); !request {
+		t.Fatal("Announce: transaction not requested from first peer")
+	}
+	for _, sp := range []*serverPeer{sp1, sp2, sp3} {
+		if request, _ := tracker.Announce(hash, sp, now); request {
+			t.Fatal("Announce: transaction requested again")
+		}
+	}
+
+	// A notfound from a peer the transaction was not requested from only
+	// removes it as a fallback.
+	if tracker.NotFound(hash, sp3) {
+		t.Fatal("NotFound: transaction reported as requested from " +
+			"fallback peer")
+	}
+	if !tracker.NotFound(hash, sp1) {
+		t.Fatal("NotFound: transaction not reported as requested")
+	}
+	assigned, dropped := tracker.Reassign(now)
+	if len(assigned) != 1 || len(assigned[sp2]) != 1 ||
+		assigned[sp2][0] != *hash || len(dropped) != 0 {
+
+		t.Fatalf("Reassign: unexpected result after notfound - got %v, %v",
+			assigned, dropped)
+	}
+	if request, _ := tracker.Announce(hash, sp3, now); request {
+		t.Fatal("Announce: transaction requested again")
+	}
+
+	// Nothing expires before the timeout, and the transaction is not
+	// requested again while it is still requested from a peer.
+	if expired := tracker.Expire(now.Add(txRequestTimeout - 1)); expired != nil {
+		t.Fatalf("Expire: unexpected expired requests %v", expired)
+	}
+	if assigned, _ := tracker.Reassign(now); assigned != nil {
+		t.Fatalf("Reassign: unexpected requests %v", assigned)
+	}
+	expired := tracker.Expire(now.Add(txRequestTimeout))
+	if len(expired) != 1 || len(expired[sp2]) != 1 {
+		t.Fatalf("Expire: unexpected expired requests %v", expired)
+	}
+
+	// The transaction is forgotten once the only remaining fallback peer
+	// turns out to be disconnected.
+	disconnected[sp3] = struct{}{}
+	assigned, dropped = tracker.Reassign(now)
+	if assigned != nil || len(dropped) != 1 || dropped[0] != *hash {
+		t.Fatalf("Reassign: unexpected result with disconnected peer - "+
+			"got %v, %v", assigned, dropped)
+	}
+	if len(tracker.requests) != 0 || len(tracker.unassigned) != 0 {
+		t.Fatal("Reassign: dropped transaction still tracked")
+	}
+
+	// Transactions requested from a peer which is removed are requested
+	// from the other peers which announced them, and a delivery reports
+	// the peer the transaction was requested from.
+	tracker.Announce(hash, sp1, now)
+	tracker.Announce(hash, sp2, now)
+	tracker.RemovePeer(sp1)
+	if _, ok := tracker.peers[sp1]; ok {
+		t.Fatal("RemovePeer: peer state not removed")
+	}
+	assigned, _ = tracker.Reassign(now)
+	if len(assigned[sp2]) != 1 {
+		t.Fatalf("Reassign: unexpected requests after removing peer %v",
+			assigned)
+	}
+	if sp := tracker.Received(hash); sp != sp2 {
+		t.Fatalf("Received: unexpected peer - got %p, want %p", sp, sp2)
+	}
+	if sp := tracker.Received(hash); sp != nil {
+		t.Fatal("Received: transaction still tracked")
+	}
+	if tracker.peers[sp2].inFlight != 0 {
+		t.Fatalf("Received: unexpected in-flight requests %d",
+			tracker.peers[sp2].inFlight)
+	}
+}
+
+// TestTxRequestRateLimit ensures the transactions requested from a peer are
+// limited to the request burst and then to the request rate, and that no more
+// transactions are requested from a peer once its limit of in-flight requests
+// is reached.
+func TestTxRequestRateLimit(t *testing.T) {
+	tracker, _ := newTxRequestTestTracker()
+	sp := &serverPeer{}
+	now := time.Now()
+
+	// The full burst is requested at once while the following
+	// announcements are only remembered.
+	const numDelayed = 2 * txRequestRate
+	for i := 0; i < txRequestBurst+numDelayed; i++ {
+		request, _ := tracker.Announce(txRequestTestHash(i), sp, now)
+		if want := i < txRequestBurst; request != want {
+			t.Fatalf("Announce #%d: unexpected request - got %v, "+
+				"want %v", i, request, want)
+		}
+	}
+	if assigned, _ := tracker.Reassign(now); assigned != nil {
+		t.Fatalf("Reassign: requests exceed the burst - got %d",
+			len(assigned[sp]))
+	}
+
+	// The delayed announcements are requested at the request rate.
+	assigned, _ := tracker.Reassign(now.Add(time.Second))
+	if len(assigned[sp]) != txRequestRate {
+		t.Fatalf("Reassign: unexpected number of requests after a "+
+			"second - got %d, want %d", len(assigned[sp]),
+			txRequestRate)
+	}
+	assigned, _ = tracker.Reassign(now.Add(time.Hour))
+	if len(assigned[sp]) != numDelayed-txRequestRate {
+		t.Fatalf("Reassign: unexpected number of remaining requests - "+
+			"got %d, want %d", len(assigned[sp]),
+			numDelayed-txRequestRate)
+	}
+	if got := tracker.peers[sp].inFlight; got != txRequestBurst+numDelayed {
+		t.Fatalf("unexpected in-flight requests - got %d, want %d", got,
+			txRequestBurst+numDelayed)
+	}
+
+	// Requests are limited by the in-flight requests regardless of the
+	// request rate.
+	tracker.peers[sp].inFlight = maxInFlightTxPerPeer
+	later := now.Add(2 * time.Hour)
+	hash := txRequestTestHash(txRequestBurst + numDelayed)
+	if request, _ := tracker.Announce(hash, sp, later); request {
+		t.Fatal("Announce: requested transaction beyond the in-flight " +
+			"limit")
+	}
+}
+
+// TestTxRequestEviction ensures a tracked transaction is evicted once the
+// maximum number of tracked transactions is reached and the eviction reports
+// the peer it was requested from.
+func TestTxRequestEviction(t *testing.T) {
+	tracker, _ := newTxRequestTestTracker()
+	now := time.Now()
+
+	// Announce the maximum number of transactions from enough peers that
+	// all of them are requested.
+	var sp *serverPeer
+	for i := 0; i < maxTrackedTxRequests; i++ {
+		if i%txRequestBurst == 0 {
+			sp = &serverPeer{}
+		}
+		request, evicted := tracker.Announce(txRequestTestHash(i), sp, now)
+		if !request || evicted != nil {
+			t.Fatalf("Announce #%d: unexpected result - got %v, %v", i,
+				request, evicted)
+		}
+	}
+
+	hash := txRequestTestHash(maxTrackedTxRequests)
+	request, evicted := tracker.Announce(hash, sp, now)
+	if request || evicted == nil || evicted.peer == nil {
+		t.Fatalf("Announce: unexpected result - got %v, %v", request,
+			evicted)
+	}
+	if len(tracker.requests) != maxTrackedTxRequests {
+		t.Fatalf("Announce: unexpected number of tracked transactions - "+
+			"got %d, want %d", len(tracker.requests),
+			maxTrackedTxRequests)
+	}
+	if _, ok := tracker.requests[evicted.hash]; ok {
+		t.Fatal("Announce: evicted transaction still tracked")
+	}
+	if _, ok := tracker.requests[*hash]; !ok {
+		t.Fatal("Announce: announced transaction not tracked")
+	}
+	if got := tracker.peers[evicted.peer].inFlight; got != txRequestBurst-1 {
+		t.Fatalf("Announce: unexpected in-flight requests of the peer "+
+			"of the evicted transaction - got %d, want %d", got,
+			txRequestBurst-1)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainec"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// benchSpendTx returns a transaction with the given number of inputs and
+// outputs which spends pay-to-pubkey-hash outputs along with the public key
+// script of the outputs it spends.  The first input is signed with SigHashAll
+// so the result may be executed.
+func benchSpendTx(b *testing.B, numInputs, numOutputs int) (*wire.MsgTx, []byte) {
+	privKeyBytes, err := hex.DecodeString("22a47fa09a223f2aa079edf85a7c2" +
+		"d4f8720ee63e502ee2869afab7de234b80c")
+	if err != nil {
+		b.Fatalf("failed to decode private key: %v", err)
+	}
+	privKey, pubKey := chainec.Secp256k1.PrivKeyFromBytes(privKeyBytes)
+	pubKeyHash := dcrutil.Hash160(pubKey.SerializeCompressed())
+	addr, err := dcrutil.NewAddressPubKeyHash(pubKeyHash,
+		&chaincfg.MainNetParams, chainec.ECTypeSecp256k1)
+	if err != nil {
+		b.Fatalf("failed to create address: %v", err)
+	}
+	pkScript, err := PayToAddrScript(addr)
+	if err != nil {
+		b.Fatalf("failed to create public key script: %v", err)
+	}
+
+	tx := wire.NewMsgTx()
+	for i := 0; i < numInputs; i++ {
+		prevHash := chainhash.Hash{byte(i), byte(i >> 8)}
+		prevOut := wire.NewOutPoint(&prevHash, uint32(i), wire.TxTreeRegular)
+		tx.AddTxIn(wire.NewTxIn(prevOut, nil))
+	}
+	for i := 0; i < numOutputs; i++ {
+		tx.AddTxOut(wire.NewTxOut(100000000, pkScript))
+	}
+
+	sigScript, err := SignatureScript(tx, 0, pkScript, SigHashAll, privKey,
+		true)
+	if err != nil {
+		b.Fatalf("failed to sign input: %v", err)
+	}
+	tx.TxIn[0].SignatureScript = sigScript
+	return tx, pkScript
+}
+
+// BenchmarkCalcSignatureHash benchmarks calculating the signature hash of a
+// transaction input without the benefit of a cached prefix hash.
+func BenchmarkCalcSignatureHash(b *testing.B) {
+	tx, pkScript := benchSpendTx(b, 20, 20)
+	pops, err := parseScript(pkScript)
+	if err != nil {
+		b.Fatalf("failed to parse script: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := calcSignatureHash(pops, SigHashAll, tx, i%len(tx.TxIn),
+			nil)
+		if err != nil {
+			b.Fatalf("failed to calculate signature hash: %v", err)
+		}
+	}
+}
+
+// BenchmarkCalcSignatureHashCachedPrefix benchmarks calculating the signature
+// hash of a transaction input when the prefix hash has already been
+// calculated, as is the case when validating multiple inputs of the same
+// transaction.
+func BenchmarkCalcSignatureHashCachedPrefix(b *testing.B) {
+	tx, pkScript := benchSpendTx(b, 20, 20)
+	pops, err := parseScript(pkScript)
+	if err != nil {
+		b.Fatalf("failed to parse script: %v", err)
+	}
+	prefixHash := tx.TxHash()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := calcSignatureHash(pops, SigHashAll, tx, i%len(tx.TxIn),
+			&prefixHash)
+		if err != nil {
+			b.Fatalf("failed to calculate signature hash: %v", err)
+		}
+	}
+}
+
+// BenchmarkExecutePayToPubKeyHash benchmarks creating an engine for and
+// executing a signed pay-to-pubkey-hash script pair with the flags enforced
+// by the consensus rules.
+func BenchmarkExecutePayToPubKeyHash(b *testing.B) {
+	tx, pkScript := benchSpendTx(b, 1, 2)
+	flags := ScriptBip16 | ScriptVerifyDERSignatures |
+		ScriptVerifyStrictEncoding | ScriptVerifyMinimalData |
+		ScriptVerifyCleanStack | ScriptVerifyCheckLockTimeVerify
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm, err := NewEngine(pkScript, tx, 0, flags, 0, nil)
+		if err != nil {
+			b.Fatalf("failed to create engine: %v", err)
+		}
+		if err := vm.Execute(); err != nil {
+			b.Fatalf("failed to execute script: %v", err)
+		}
+	}
+}
+
+// BenchmarkParseScript benchmarks parsing a typical signature script.
+func BenchmarkParseScript(b *testing.B) {
+	tx, _ := benchSpendTx(b, 1, 1)
+	sigScript := tx.TxIn[0].SignatureScript
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseScript(sigScript); err != nil {
+			b.Fatalf("failed to parse script: %v", err)
+		}
+	}
+}
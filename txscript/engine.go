@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/chaincfg/chainec"
+	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/wire"
 )
 
@@ -65,6 +67,11 @@ const (
 	// ScriptVerifyStrictEncoding defines that signature scripts and
 	// public keys must follow the strict encoding requirements.
 	ScriptVerifyStrictEncoding
+
+	// ScriptVerifyTreasury defines that OP_TREASURY verifies the signature
+	// of the spender of a treasury output rather than being treated as an
+	// upgradable NOP.
+	ScriptVerifyTreasury
 )
 
 const (
@@ -97,11 +104,31 @@ type Engine struct {
 	condStack       []int
 	numOps          int
 	flags           ScriptFlags
+	opcodes         *[256]opcode // opcodes in effect for the flags
 	sigCache        *SigCache
+	sigHashes       *TxSigHashes
 	bip16           bool     // treat execution as pay-to-script-hash
 	savedFirstStack [][]byte // stack from first script for bip16 scripts
 }
 
+// cachedPrefixHash returns the already calculated prefix hash of the
+// transaction to use for a signature hash of the passed type, or nil when it
+// must be calculated.  The shared signature hashes are always used when they
+// were provided, while the cached hash of the transaction is only used when
+// chaincfg.SigHashOptimization is enabled.
+func (vm *Engine) cachedPrefixHash(hashType SigHashType) *chainhash.Hash {
+	if hashType&sigHashMask != SigHashAll {
+		return nil
+	}
+	if vm.sigHashes != nil {
+		return &vm.sigHashes.PrefixHash
+	}
+	if optimizeSigVerification && chaincfg.SigHashOptimization {
+		return vm.tx.CachedTxHash()
+	}
+	return nil
+}
+
 // hasFlag returns whether the script engine instance has the passed flag set.
 func (vm *Engine) hasFlag(flag ScriptFlags) bool {
 	return vm.flags&flag == flag
@@ -309,7 +336,7 @@ func (vm *Engine) Step() (done bool, err error) {
 			}
 
 			script := vm.savedFirstStack[len(vm.savedFirstStack)-1]
-			pops, err := parseScript(script)
+			pops, err := parseScriptTemplate(script, vm.opcodes)
 			if err != nil {
 				return false, err
 			}
@@ -595,6 +622,19 @@ func (vm *Engine) SetAltStack(data [][]byte) {
 func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
 	flags ScriptFlags, scriptVersion uint16, sigCache *SigCache) (*Engine, error) {
 
+	return NewEngineWithSigHashes(scriptPubKey, tx, txIdx, flags,
+		scriptVersion, sigCache, nil)
+}
+
+// NewEngineWithSigHashes returns a new script engine like NewEngine which uses
+// the passed signature hashes of the transaction rather than calculating them
+// for every signature.  The signature hashes must have been calculated for the
+// provided transaction.  Passing nil calculates all signature hashes from
+// scratch like NewEngine.
+func NewEngineWithSigHashes(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
+	flags ScriptFlags, scriptVersion uint16, sigCache *SigCache,
+	sigHashes *TxSigHashes) (*Engine, error) {
+
 	// The provided transaction input index must refer to a valid input.
 	if txIdx < 0 || txIdx >= len(tx.TxIn) {
 		return nil, ErrInvalidIndex
@@ -609,7 +649,9 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
 	// allowing the clean stack flag without the P2SH flag would make it
 	// possible to have a situation where P2SH would not be a soft fork when
 	// it should be.
-	vm := Engine{version: scriptVersion, flags: flags, sigCache: sigCache}
+	vm := Engine{version: scriptVersion, flags: flags, sigCache: sigCache,
+		sigHashes: sigHashes}
+	vm.opcodes = opcodeTableForFlags(flags)
 	if vm.hasFlag(ScriptVerifyCleanStack) && !vm.hasFlag(ScriptBip16) {
 		return nil, ErrInvalidFlags
 	}
@@ -633,7 +675,9 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
 	// The engine stores the scripts in parsed form using a slice.  This
 	// allows multiple scripts to be executed in sequence.  For example,
 	// with a pay-to-script-hash transaction, there will be ultimately be
-	// a third script to execute.
+	// a third script to execute.  The scripts are parsed using the opcodes
+	// that are in effect for the provided flags so that any activated
+	// opcodes are executed in place of the NOPs they replace.
 	scripts := [][]byte{scriptSig, scriptPubKey}
 	vm.scripts = make([][]parsedOpcode, len(scripts))
 	for i, scr := range scripts {
@@ -641,7 +685,7 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
 			return nil, ErrStackLongScript
 		}
 		var err error
-		vm.scripts[i], err = parseScript(scr)
+		vm.scripts[i], err = parseScriptTemplate(scr, vm.opcodes)
 		if err != nil {
 			return nil, err
 		}
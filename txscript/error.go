@@ -190,3 +190,43 @@ var (
 	// ErrP2SHStakeOpCodes indicates a P2SH script contained stake op codes.
 	ErrP2SHStakeOpCodes = errors.New("stake opcodes were found in a p2sh script")
 )
+
+// Output script builder errors.
+var (
+	// ErrOutputScriptNoPayment is returned from OutputScriptBuilder.Script
+	// when no payment was specified.
+	ErrOutputScriptNoPayment = errors.New("output script has no payment")
+
+	// ErrOutputScriptMultiplePayments is returned from an
+	// OutputScriptBuilder when more than one payment is specified.
+	ErrOutputScriptMultiplePayments = errors.New("output script has " +
+		"multiple payments")
+
+	// ErrOutputScriptMultipleTags is returned from an OutputScriptBuilder
+	// when the output is tagged with more than one stake opcode.
+	ErrOutputScriptMultipleTags = errors.New("output script has " +
+		"multiple stake tags")
+
+	// ErrOutputScriptStakeTag is returned from an OutputScriptBuilder when a
+	// payment which does not support stake tags is tagged with a stake
+	// opcode.
+	ErrOutputScriptStakeTag = errors.New("payment can not be tagged with " +
+		"a stake opcode")
+
+	// ErrBadHashLength is returned from an OutputScriptBuilder when a
+	// public key or script hash is not 20 bytes.
+	ErrBadHashLength = errors.New("hash is not 20 bytes")
+
+	// ErrBadNumPubKeys is returned from an OutputScriptBuilder when a
+	// multisig payment has no public keys or more than the maximum allowed.
+	ErrBadNumPubKeys = errors.New("invalid number of public keys")
+
+	// ErrBadPubKey is returned from an OutputScriptBuilder when a public key
+	// is not a compressed or uncompressed secp256k1 public key.
+	ErrBadPubKey = errors.New("invalid public key encoding")
+
+	// ErrBadCommitmentAmount is returned from an OutputScriptBuilder when
+	// the amount of a ticket commitment is negative or exceeds the maximum
+	// allowed amount.
+	ErrBadCommitmentAmount = errors.New("invalid commitment amount")
+)
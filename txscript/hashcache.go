@@ -0,0 +1,33 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// TxSigHashes houses the parts of the signature hashes of a transaction which
+// are the same for all of its inputs.  Calculating them once per transaction
+// and sharing them between the script engines which validate its inputs avoids
+// recalculating them for every signature.
+//
+// The prefix hash of the transaction is the same for every SigHashAll
+// signature hash since only the signature scripts of the inputs, which are not
+// part of the prefix, are modified for them.
+//
+// The signature hashes must not be used after the transaction they were
+// calculated for is modified.
+type TxSigHashes struct {
+	PrefixHash chainhash.Hash
+}
+
+// NewTxSigHashes returns the signature hashes of the passed transaction which
+// are shared by all of its inputs.
+func NewTxSigHashes(tx *wire.MsgTx) *TxSigHashes {
+	return &TxSigHashes{
+		PrefixHash: tx.TxHash(),
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript_test
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainec"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrutil"
+)
+
+// TestTxSigHashes ensures the script engine validates signatures with the
+// shared signature hashes of a transaction and actually uses them.
+func TestTxSigHashes(t *testing.T) {
+	t.Parallel()
+
+	privKey, pubKey := chainec.Secp256k1.PrivKeyFromBytes([]byte{
+		0x22, 0xa4, 0x7f, 0xa0, 0x9a, 0x22, 0x3f, 0x2a,
+		0xa0, 0x79, 0xed, 0xf8, 0x5a, 0x7c, 0x2d, 0x4f,
+		0x87, 0x20, 0xee, 0x63, 0xe5, 0x02, 0xee, 0x28,
+		0x69, 0xaf, 0xab, 0x7d, 0xe2, 0x34, 0xb8, 0x0c,
+	})
+	addr, err := dcrutil.NewAddressPubKeyHash(
+		dcrutil.Hash160(pubKey.SerializeCompressed()),
+		&chaincfg.MainNetParams, chainec.ECTypeSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error creating address: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("unexpected error creating script: %v", err)
+	}
+
+	// Create and sign a transaction which spends two outputs.
+	tx := wire.NewMsgTx()
+	for i := 0; i < 2; i++ {
+		prevOut := wire.NewOutPoint(&chainhash.Hash{byte(i)}, 0,
+			wire.TxTreeRegular)
+		tx.AddTxIn(wire.NewTxIn(prevOut, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(1e8, pkScript))
+	for i := range tx.TxIn {
+		sigScript, err := txscript.SignatureScript(tx, i, pkScript,
+			txscript.SigHashAll, privKey, true)
+		if err != nil {
+			t.Fatalf("unexpected error signing input %d: %v", i, err)
+		}
+		tx.TxIn[i].SignatureScript = sigScript
+	}
+
+	// Ensure the signature hashes of the transaction are the prefix hash.
+	sigHashes := txscript.NewTxSigHashes(tx)
+	if sigHashes.PrefixHash != tx.TxHash() {
+		t.Fatalf("unexpected prefix hash - got %v, want %v",
+			sigHashes.PrefixHash, tx.TxHash())
+	}
+
+	// Signature hashes of another transaction.
+	otherTx := tx.Copy()
+	otherTx.TxOut[0].Value++
+	otherSigHashes := txscript.NewTxSigHashes(otherTx)
+
+	flags := txscript.ScriptBip16 | txscript.ScriptVerifyDERSignatures
+	for i := range tx.TxIn {
+		// Ensure the inputs are valid with the shared signature hashes.
+		vm, err := txscript.NewEngineWithSigHashes(pkScript, tx, i, flags,
+			0, nil, sigHashes)
+		if err != nil {
+			t.Fatalf("unexpected error creating engine: %v", err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Errorf("input %d: unexpected error with signature "+
+				"hashes: %v", i, err)
+		}
+
+		// Ensure the signature hashes are used by checking that the
+		// signature hashes of another transaction result in invalid
+		// signatures.
+		vm, err = txscript.NewEngineWithSigHashes(pkScript, tx, i, flags,
+			0, nil, otherSigHashes)
+		if err != nil {
+			t.Fatalf("unexpected error creating engine: %v", err)
+		}
+		if err := vm.Execute(); err == nil {
+			t.Errorf("input %d: signature hashes of another "+
+				"transaction were not used", i)
+		}
+	}
+}
@@ -2374,13 +2374,7 @@ func opcodeCheckSig(op *parsedOpcode, vm *Engine) error {
 	subScript = removeOpcodeByData(subScript, fullSigBytes)
 
 	// Generate the signature hash based on the signature hash type.
-	var prefixHash *chainhash.Hash
-	if hashType&sigHashMask == SigHashAll {
-		if optimizeSigVerification {
-			ph := vm.tx.CachedTxHash()
-			prefixHash = ph
-		}
-	}
+	prefixHash := vm.cachedPrefixHash(hashType)
 	hash, err := calcSignatureHash(subScript, hashType, &vm.tx, vm.txIdx,
 		prefixHash)
 	if err != nil {
@@ -2596,13 +2590,7 @@ func opcodeCheckMultiSig(op *parsedOpcode, vm *Engine) error {
 		}
 
 		// Generate the signature hash based on the signature hash type.
-		var prefixHash *chainhash.Hash
-		if hashType&sigHashMask == SigHashAll {
-			if optimizeSigVerification {
-				ph := vm.tx.CachedTxHash()
-				prefixHash = ph
-			}
-		}
+		prefixHash := vm.cachedPrefixHash(hashType)
 		hash, err := calcSignatureHash(script, hashType, &vm.tx, vm.txIdx,
 			prefixHash)
 		if err != nil {
@@ -2759,13 +2747,7 @@ func opcodeCheckSigAlt(op *parsedOpcode, vm *Engine) error {
 	subScript = removeOpcodeByData(subScript, fullSigBytes)
 
 	// Generate the signature hash based on the signature hash type.
-	var prefixHash *chainhash.Hash
-	if hashType&sigHashMask == SigHashAll {
-		if optimizeSigVerification {
-			ph := vm.tx.CachedTxHash()
-			prefixHash = ph
-		}
-	}
+	prefixHash := vm.cachedPrefixHash(hashType)
 	hash, err := calcSignatureHash(subScript, hashType, &vm.tx, vm.txIdx,
 		prefixHash)
 	if err != nil {
@@ -0,0 +1,122 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+	"sync"
+)
+
+// opcodeActivation describes an opcode which replaces one of the upgradable
+// NOP opcodes once the script flag associated with it is set.  The flag is
+// expected to be set by the caller once the consensus vote for the agenda that
+// introduces the opcode is active, so the same script is executed according to
+// the rules that were in effect for the block that contains it.
+type opcodeActivation struct {
+	flag   ScriptFlags
+	opcode opcode
+}
+
+var (
+	// opcodeActivations houses the registered opcode activations.  Future
+	// consensus upgrades that introduce new opcodes add their definitions
+	// via registerOpcodeActivation from an init function, as is done for
+	// OP_TREASURY.
+	opcodeActivations []opcodeActivation
+
+	// activationFlags is the combination of the script flags of all of the
+	// registered opcode activations.  It is used to avoid creating a new
+	// opcode table for each combination of unrelated flags.
+	activationFlags ScriptFlags
+
+	// opcodeTablesMtx protects opcodeTables.
+	opcodeTablesMtx sync.Mutex
+
+	// opcodeTables houses the opcode tables which have been created for
+	// combinations of activation flags.
+	opcodeTables = make(map[ScriptFlags]*[256]opcode)
+)
+
+// isUpgradableNop returns whether or not the passed opcode value is one of the
+// NOP opcodes which are reserved for future upgrades.
+func isUpgradableNop(value byte) bool {
+	switch {
+	case value == OP_NOP1:
+		return true
+	case value >= OP_NOP3 && value <= OP_NOP10:
+		return true
+	case value >= OP_UNKNOWN192 && value <= OP_UNKNOWN248:
+		return true
+	}
+	return false
+}
+
+// registerOpcodeActivation registers the passed opcode definition to replace
+// the existing definition of the opcode with the same value when the passed
+// script flag is set.  Only the upgradable NOP opcodes may be replaced and the
+// replacement must not take any additional data, since existing scripts would
+// otherwise parse differently.
+//
+// This function MUST only be called from an init function since the opcode
+// tables are not updated once created.  It panics when the activation is not
+// valid since that is a programming error.
+func registerOpcodeActivation(flag ScriptFlags, op opcode) {
+	if flag == 0 || flag&(flag-1) != 0 {
+		panic(fmt.Sprintf("opcode %s must be activated by exactly one "+
+			"script flag", op.name))
+	}
+	if !isUpgradableNop(op.value) {
+		panic(fmt.Sprintf("opcode %s replaces %s which is not an "+
+			"upgradable NOP", op.name, opcodeArray[op.value].name))
+	}
+	if op.length != 1 {
+		panic(fmt.Sprintf("opcode %s must not take additional data",
+			op.name))
+	}
+	for _, activation := range opcodeActivations {
+		if activation.opcode.value == op.value {
+			panic(fmt.Sprintf("opcode %s replaces %s which is "+
+				"already replaced by %s", op.name,
+				opcodeArray[op.value].name, activation.opcode.name))
+		}
+	}
+
+	opcodeActivations = append(opcodeActivations, opcodeActivation{
+		flag:   flag,
+		opcode: op,
+	})
+	activationFlags |= flag
+	if _, ok := OpcodeByName[op.name]; !ok {
+		OpcodeByName[op.name] = op.value
+	}
+}
+
+// opcodeTableForFlags returns the opcode table which defines the opcodes that
+// are in effect for the passed script flags.  The base opcode table is returned
+// when no opcode activations apply.
+//
+// This function is safe for concurrent access.
+func opcodeTableForFlags(flags ScriptFlags) *[256]opcode {
+	flags &= activationFlags
+	if flags == 0 {
+		return &opcodeArray
+	}
+
+	opcodeTablesMtx.Lock()
+	defer opcodeTablesMtx.Unlock()
+
+	if table, ok := opcodeTables[flags]; ok {
+		return table
+	}
+	table := new([256]opcode)
+	*table = opcodeArray
+	for _, activation := range opcodeActivations {
+		if flags&activation.flag == activation.flag {
+			table[activation.opcode.value] = activation.opcode
+		}
+	}
+	opcodeTables[flags] = table
+	return table
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// TestOpcodeActivation ensures opcodes registered via the opcode activation
+// framework are only executed in place of the NOPs they replace when the
+// associated script flag is set and that invalid activations are rejected.
+func TestOpcodeActivation(t *testing.T) {
+	// Restore the registered activations once the test completes since they
+	// are global state.
+	defer func(activations []opcodeActivation, flags ScriptFlags) {
+		opcodeActivations = activations
+		activationFlags = flags
+		opcodeTables = make(map[ScriptFlags]*[256]opcode)
+		delete(OpcodeByName, "OP_TESTFAIL")
+	}(opcodeActivations, activationFlags)
+
+	const testFlag = ScriptFlags(1 << 31)
+	errTestFail := errors.New("test opcode executed")
+	registerOpcodeActivation(testFlag, opcode{
+		value:  OP_NOP10,
+		name:   "OP_TESTFAIL",
+		length: 1,
+		opfunc: func(*parsedOpcode, *Engine) error {
+			return errTestFail
+		},
+	})
+
+	if opcodeTableForFlags(0) != &opcodeArray {
+		t.Fatal("opcode table without activation flags is not the base " +
+			"opcode table")
+	}
+	if name := opcodeTableForFlags(testFlag)[OP_NOP10].name; name != "OP_TESTFAIL" {
+		t.Fatalf("unexpected activated opcode name -- got %s, want "+
+			"OP_TESTFAIL", name)
+	}
+	if OpcodeByName["OP_TESTFAIL"] != OP_NOP10 {
+		t.Fatal("activated opcode is not available by name")
+	}
+
+	tx := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{{}},
+	}
+	pkScript := []byte{OP_NOP10, OP_TRUE}
+	tests := []struct {
+		name  string
+		flags ScriptFlags
+		err   error
+	}{
+		{name: "not activated", flags: 0, err: nil},
+		{name: "unrelated flags", flags: ScriptVerifyLowS, err: nil},
+		{name: "activated", flags: testFlag, err: errTestFail},
+	}
+	for _, test := range tests {
+		vm, err := NewEngine(pkScript, tx, 0, test.flags, 0, nil)
+		if err != nil {
+			t.Errorf("%s: failed to create engine: %v", test.name, err)
+			continue
+		}
+		if err := vm.Execute(); err != test.err {
+			t.Errorf("%s: unexpected execute result -- got %v, want "+
+				"%v", test.name, err, test.err)
+		}
+	}
+
+	// Ensure invalid activations are rejected.
+	invalid := []struct {
+		name string
+		flag ScriptFlags
+		op   opcode
+	}{
+		{
+			name: "no flag",
+			flag: 0,
+			op:   opcode{OP_NOP9, "OP_TESTA", 1, opcodeNop},
+		},
+		{
+			name: "multiple flags",
+			flag: testFlag | ScriptVerifyLowS,
+			op:   opcode{OP_NOP9, "OP_TESTB", 1, opcodeNop},
+		},
+		{
+			name: "not an upgradable nop",
+			flag: testFlag,
+			op:   opcode{OP_CHECKSIG, "OP_TESTC", 1, opcodeNop},
+		},
+		{
+			name: "takes data",
+			flag: testFlag,
+			op:   opcode{OP_NOP9, "OP_TESTD", 2, opcodeNop},
+		},
+		{
+			name: "already replaced",
+			flag: testFlag,
+			op:   opcode{OP_NOP10, "OP_TESTE", 1, opcodeNop},
+		},
+	}
+	for _, test := range invalid {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: invalid activation did not "+
+						"panic", test.name)
+				}
+			}()
+			registerOpcodeActivation(test.flag, test.op)
+		}()
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/binary"
+
+	"github.com/decred/dcrd/chaincfg/chainec"
+	"github.com/decred/dcrutil"
+)
+
+// hash160Size is the size of the public key and script hashes committed to by
+// pay-to-pubkey-hash and pay-to-script-hash scripts.
+const hash160Size = 20
+
+// OutputScriptBuilder provides a facility for building the public key scripts
+// of the standard types of transaction outputs.  Unlike ScriptBuilder, which
+// builds arbitrary scripts, it validates the parameters of each payment and
+// ensures stake opcodes are only used to tag the outputs which support them.
+//
+// A payment and, optionally, a stake tag are specified by chaining calls to
+// the builder.  Any errors are deferred until the Script function is called.
+//
+// For example, the following would build a ticket purchase output which pays
+// to a public key hash:
+// 	script, err := txscript.NewOutputScriptBuilder().StakeSubmission().
+// 		PayToPubKeyHash(pkHash).Script()
+// 	if err != nil {
+// 		// Handle the error.
+// 		return
+// 	}
+type OutputScriptBuilder struct {
+	stakeOpcode byte
+	class       ScriptClass
+	fromAddr    bool
+	payment     []byte
+	err         error
+}
+
+// NewOutputScriptBuilder returns a new instance of an output script builder.
+// See OutputScriptBuilder for details.
+func NewOutputScriptBuilder() *OutputScriptBuilder {
+	return &OutputScriptBuilder{class: NonStandardTy}
+}
+
+// tag tags the output with the passed stake opcode.
+func (b *OutputScriptBuilder) tag(opcode byte) *OutputScriptBuilder {
+	if b.err != nil {
+		return b
+	}
+	if b.stakeOpcode != 0 {
+		b.err = ErrOutputScriptMultipleTags
+		return b
+	}
+
+	b.stakeOpcode = opcode
+	return b
+}
+
+// StakeSubmission tags the output with OP_SSTX for use as the ticket output of
+// a ticket purchase.
+func (b *OutputScriptBuilder) StakeSubmission() *OutputScriptBuilder {
+	return b.tag(OP_SSTX)
+}
+
+// StakeChange tags the output with OP_SSTXCHANGE for use as a change output of
+// a ticket purchase.
+func (b *OutputScriptBuilder) StakeChange() *OutputScriptBuilder {
+	return b.tag(OP_SSTXCHANGE)
+}
+
+// StakeGen tags the output with OP_SSGEN for use as a payment output of a
+// vote.
+func (b *OutputScriptBuilder) StakeGen() *OutputScriptBuilder {
+	return b.tag(OP_SSGEN)
+}
+
+// StakeRevocation tags the output with OP_SSRTX for use as a payment output of
+// a revocation.
+func (b *OutputScriptBuilder) StakeRevocation() *OutputScriptBuilder {
+	return b.tag(OP_SSRTX)
+}
+
+// setPayment sets the payment of the output to the passed script of the given
+// class.
+func (b *OutputScriptBuilder) setPayment(class ScriptClass, payment []byte, err error) *OutputScriptBuilder {
+	if b.err != nil {
+		return b
+	}
+	if b.class != NonStandardTy {
+		b.err = ErrOutputScriptMultiplePayments
+		return b
+	}
+	if err != nil {
+		b.err = err
+		return b
+	}
+
+	b.class = class
+	b.payment = payment
+	return b
+}
+
+// PayToPubKeyHash sets the payment of the output to the passed 20-byte hash of
+// a secp256k1 public key.
+func (b *OutputScriptBuilder) PayToPubKeyHash(pkHash []byte) *OutputScriptBuilder {
+	if len(pkHash) != hash160Size {
+		return b.setPayment(PubKeyHashTy, nil, ErrBadHashLength)
+	}
+	script, err := payToPubKeyHashScript(pkHash)
+	return b.setPayment(PubKeyHashTy, script, err)
+}
+
+// PayToScriptHash sets the payment of the output to the passed 20-byte script
+// hash.
+func (b *OutputScriptBuilder) PayToScriptHash(scriptHash []byte) *OutputScriptBuilder {
+	if len(scriptHash) != hash160Size {
+		return b.setPayment(ScriptHashTy, nil, ErrBadHashLength)
+	}
+	script, err := payToScriptHashScript(scriptHash)
+	return b.setPayment(ScriptHashTy, script, err)
+}
+
+// PayToAddress sets the payment of the output to the passed address.  All of
+// the address types supported by PayToAddrScript are supported, however only
+// secp256k1 pay-to-pubkey-hash and pay-to-script-hash addresses may be tagged
+// with a stake opcode.
+func (b *OutputScriptBuilder) PayToAddress(addr dcrutil.Address) *OutputScriptBuilder {
+	script, err := PayToAddrScript(addr)
+	if err != nil {
+		return b.setPayment(NonStandardTy, nil, err)
+	}
+
+	var class ScriptClass
+	switch addr := addr.(type) {
+	case *dcrutil.AddressPubKeyHash:
+		class = PubKeyHashTy
+		if addr.DSA(addr.Net()) != chainec.ECTypeSecp256k1 {
+			class = PubkeyHashAltTy
+		}
+	case *dcrutil.AddressScriptHash:
+		class = ScriptHashTy
+	case *dcrutil.AddressSecpPubKey:
+		class = PubKeyTy
+	default:
+		class = PubkeyAltTy
+	}
+	b.setPayment(class, script, nil)
+	if b.err == nil {
+		b.fromAddr = true
+	}
+	return b
+}
+
+// MultiSig sets the payment of the output to a bare multisignature script which
+// requires nrequired signatures for the passed serialized secp256k1 public
+// keys.
+func (b *OutputScriptBuilder) MultiSig(nrequired int, pubKeys ...[]byte) *OutputScriptBuilder {
+	if len(pubKeys) == 0 || len(pubKeys) > MaxPubKeysPerMultiSig {
+		return b.setPayment(MultiSigTy, nil, ErrBadNumPubKeys)
+	}
+	if nrequired < 1 || nrequired > len(pubKeys) {
+		return b.setPayment(MultiSigTy, nil, ErrBadNumRequired)
+	}
+
+	builder := NewScriptBuilder().AddInt64(int64(nrequired))
+	for _, pubKey := range pubKeys {
+		compressed := len(pubKey) == 33 &&
+			(pubKey[0] == 0x02 || pubKey[0] == 0x03)
+		uncompressed := len(pubKey) == 65 && pubKey[0] == 0x04
+		if !compressed && !uncompressed {
+			return b.setPayment(MultiSigTy, nil, ErrBadPubKey)
+		}
+		builder.AddData(pubKey)
+	}
+	builder.AddInt64(int64(len(pubKeys)))
+	builder.AddOp(OP_CHECKMULTISIG)
+	script, err := builder.Script()
+	return b.setPayment(MultiSigTy, script, err)
+}
+
+// Commitment sets the payment of the output to a ticket commitment, which is
+// the OP_RETURN output of a ticket purchase that commits to the address the
+// vote or revocation of the ticket must pay the passed amount to along with
+// the fee limits of those transactions.  Only secp256k1 pay-to-pubkey-hash and
+// pay-to-script-hash addresses are supported.
+func (b *OutputScriptBuilder) Commitment(addr dcrutil.Address, amount dcrutil.Amount, limits uint16) *OutputScriptBuilder {
+	var isScriptHash bool
+	switch a := addr.(type) {
+	case *dcrutil.AddressPubKeyHash:
+		if a == nil || a.DSA(a.Net()) != chainec.ECTypeSecp256k1 {
+			return b.setPayment(NullDataTy, nil, ErrUnsupportedAddress)
+		}
+	case *dcrutil.AddressScriptHash:
+		if a == nil {
+			return b.setPayment(NullDataTy, nil, ErrUnsupportedAddress)
+		}
+		isScriptHash = true
+	default:
+		return b.setPayment(NullDataTy, nil, ErrUnsupportedAddress)
+	}
+	if amount < 0 || amount > dcrutil.MaxAmount {
+		return b.setPayment(NullDataTy, nil, ErrBadCommitmentAmount)
+	}
+
+	// The commitment is the hash followed by the amount and the limits.
+	// The high bit of the amount, which is never set for valid amounts,
+	// flags the hash as a script hash.
+	var data [hash160Size + 10]byte
+	copy(data[:], addr.ScriptAddress())
+	binary.LittleEndian.PutUint64(data[hash160Size:], uint64(amount))
+	if isScriptHash {
+		data[hash160Size+7] |= 1 << 7
+	}
+	binary.LittleEndian.PutUint16(data[hash160Size+8:], limits)
+
+	script, err := NewScriptBuilder().AddOp(OP_RETURN).AddData(data[:]).
+		Script()
+	return b.setPayment(NullDataTy, script, err)
+}
+
+// Script returns the output script built by the builder.  An error is returned
+// when any of the parameters passed to the builder are invalid, when no payment
+// was specified, or when the output is tagged with a stake opcode and the
+// payment is not a secp256k1 pay-to-pubkey-hash or pay-to-script-hash payment.
+func (b *OutputScriptBuilder) Script() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.class == NonStandardTy {
+		return nil, ErrOutputScriptNoPayment
+	}
+	if b.stakeOpcode == 0 {
+		return b.payment, nil
+	}
+
+	if b.class != PubKeyHashTy && b.class != ScriptHashTy {
+		// Maintain the error returned for addresses which are not
+		// supported in stake outputs by the PayToSStx family of
+		// functions.
+		if b.fromAddr {
+			return nil, ErrUnsupportedAddress
+		}
+		return nil, ErrOutputScriptStakeTag
+	}
+	script := make([]byte, 0, len(b.payment)+1)
+	script = append(script, b.stakeOpcode)
+	return append(script, b.payment...), nil
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainec"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrutil"
+)
+
+// TestOutputScriptBuilder ensures the OutputScriptBuilder returns the expected
+// scripts and errors.
+func TestOutputScriptBuilder(t *testing.T) {
+	t.Parallel()
+
+	pkHash := decodeHex("e34cce70c86373273efcc54ce7d2a491bb4a0e84")
+	scriptHash := decodeHex("e8c300c87986efa84c37c0519929019ef86eb5b4")
+	pubKey1 := decodeHex("02192d74d0cb94344c9569c2e77901573d8d7903c3ebec3" +
+		"a957724895dca52c6b4")
+	pubKey2 := decodeHex("03b0bd634234abbb1ba1e986e884185c61cf43e001f9137" +
+		"f23c2c409273eb16e65")
+	p2pkh := newAddressPubKeyHash(pkHash)
+	p2sh := newAddressScriptHash(scriptHash)
+	p2pk := newAddressPubKey(pubKey1)
+	p2pkhEdwards, err := dcrutil.NewAddressPubKeyHash(pkHash,
+		&chaincfg.MainNetParams, chainec.ECTypeEdwards)
+	if err != nil {
+		t.Fatalf("unable to create edwards public key hash address: %v",
+			err)
+	}
+
+	tests := []struct {
+		name     string
+		build    func() ([]byte, error)
+		expected string
+		err      error
+	}{
+		{
+			name: "pay to pubkey hash",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					PayToPubKeyHash(pkHash).Script()
+			},
+			expected: "DUP HASH160 DATA_20 0xe34cce70c86373273efcc54ce7d2a4" +
+				"91bb4a0e84 EQUALVERIFY CHECKSIG",
+		},
+		{
+			name: "pay to script hash",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					PayToScriptHash(scriptHash).Script()
+			},
+			expected: "HASH160 DATA_20 0xe8c300c87986efa84c37c0519929019ef8" +
+				"6eb5b4 EQUAL",
+		},
+		{
+			name: "ticket submission to pubkey hash",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().StakeSubmission().
+					PayToPubKeyHash(pkHash).Script()
+			},
+			expected: "SSTX DUP HASH160 DATA_20 0xe34cce70c86373273efcc54ce" +
+				"7d2a491bb4a0e84 EQUALVERIFY CHECKSIG",
+		},
+		{
+			name: "stake tag after payment",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					PayToScriptHash(scriptHash).StakeGen().Script()
+			},
+			expected: "SSGEN HASH160 DATA_20 0xe8c300c87986efa84c37c0519929" +
+				"019ef86eb5b4 EQUAL",
+		},
+		{
+			name: "ticket change to address",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().StakeChange().
+					PayToAddress(p2pkh).Script()
+			},
+			expected: "SSTXCHANGE DUP HASH160 DATA_20 0xe34cce70c86373273ef" +
+				"cc54ce7d2a491bb4a0e84 EQUALVERIFY CHECKSIG",
+		},
+		{
+			name: "revocation to script hash address",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().StakeRevocation().
+					PayToAddress(p2sh).Script()
+			},
+			expected: "SSRTX HASH160 DATA_20 0xe8c300c87986efa84c37c0519929" +
+				"019ef86eb5b4 EQUAL",
+		},
+		{
+			name: "pay to pubkey address",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					PayToAddress(p2pk).Script()
+			},
+			expected: "DATA_33 0x02192d74d0cb94344c9569c2e77901573d8d7903c3" +
+				"ebec3a957724895dca52c6b4 CHECKSIG",
+		},
+		{
+			name: "1-of-2 multisig",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					MultiSig(1, pubKey1, pubKey2).Script()
+			},
+			expected: "1 DATA_33 0x02192d74d0cb94344c9569c2e77901573d8d7903" +
+				"c3ebec3a957724895dca52c6b4 DATA_33 0x03b0bd634234abbb1b" +
+				"a1e986e884185c61cf43e001f9137f23c2c409273eb16e65 2 " +
+				"CHECKMULTISIG",
+		},
+		{
+			name: "commitment to pubkey hash",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					Commitment(p2pkh, 0x0102030405, 0x5800).Script()
+			},
+			expected: "RETURN DATA_30 0xe34cce70c86373273efcc54ce7d2a491bb4" +
+				"a0e8405040302010000000058",
+		},
+		{
+			name: "commitment to script hash",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					Commitment(p2sh, 0x0102030405, 0x5800).Script()
+			},
+			expected: "RETURN DATA_30 0xe8c300c87986efa84c37c0519929019ef86" +
+				"eb5b405040302010000800058",
+		},
+		{
+			name: "no payment",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().StakeGen().Script()
+			},
+			err: txscript.ErrOutputScriptNoPayment,
+		},
+		{
+			name: "multiple payments",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					PayToPubKeyHash(pkHash).PayToScriptHash(scriptHash).
+					Script()
+			},
+			err: txscript.ErrOutputScriptMultiplePayments,
+		},
+		{
+			name: "multiple stake tags",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().StakeGen().
+					StakeRevocation().PayToPubKeyHash(pkHash).Script()
+			},
+			err: txscript.ErrOutputScriptMultipleTags,
+		},
+		{
+			name: "stake tagged multisig",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().StakeSubmission().
+					MultiSig(1, pubKey1).Script()
+			},
+			err: txscript.ErrOutputScriptStakeTag,
+		},
+		{
+			name: "stake tagged pubkey address",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().StakeSubmission().
+					PayToAddress(p2pk).Script()
+			},
+			err: txscript.ErrUnsupportedAddress,
+		},
+		{
+			name: "stake tagged edwards pubkey hash address",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().StakeGen().
+					PayToAddress(p2pkhEdwards).Script()
+			},
+			err: txscript.ErrUnsupportedAddress,
+		},
+		{
+			name: "short pubkey hash",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					PayToPubKeyHash(pkHash[:19]).Script()
+			},
+			err: txscript.ErrBadHashLength,
+		},
+		{
+			name: "nil address",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					PayToAddress(nil).Script()
+			},
+			err: txscript.ErrUnsupportedAddress,
+		},
+		{
+			name: "multisig without keys",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					MultiSig(1).Script()
+			},
+			err: txscript.ErrBadNumPubKeys,
+		},
+		{
+			name: "multisig requiring too many signatures",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					MultiSig(3, pubKey1, pubKey2).Script()
+			},
+			err: txscript.ErrBadNumRequired,
+		},
+		{
+			name: "multisig requiring no signatures",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					MultiSig(0, pubKey1, pubKey2).Script()
+			},
+			err: txscript.ErrBadNumRequired,
+		},
+		{
+			name: "multisig with invalid key",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					MultiSig(1, pubKey1, pkHash).Script()
+			},
+			err: txscript.ErrBadPubKey,
+		},
+		{
+			name: "commitment to pubkey address",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					Commitment(p2pk, 1, 0).Script()
+			},
+			err: txscript.ErrUnsupportedAddress,
+		},
+		{
+			name: "commitment with negative amount",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					Commitment(p2pkh, -1, 0).Script()
+			},
+			err: txscript.ErrBadCommitmentAmount,
+		},
+		{
+			name: "commitment exceeding max amount",
+			build: func() ([]byte, error) {
+				return txscript.NewOutputScriptBuilder().
+					Commitment(p2pkh, dcrutil.MaxAmount+1, 0).Script()
+			},
+			err: txscript.ErrBadCommitmentAmount,
+		},
+	}
+
+	for _, test := range tests {
+		script, err := test.build()
+		if err != test.err {
+			t.Errorf("%s: unexpected error - got %v, want %v",
+				test.name, err, test.err)
+			continue
+		}
+
+		expected := mustParseShortForm(test.expected)
+		if !bytes.Equal(script, expected) {
+			t.Errorf("%s: unexpected script - got %x, want %x",
+				test.name, script, expected)
+			continue
+		}
+	}
+}
@@ -285,15 +285,23 @@ func removeOpcodeByData(pkscript []parsedOpcode, data []byte) []parsedOpcode {
 
 }
 
-// CalcSignatureHash is an exported version for testing.
+// CalcSignatureHash is an exported version for testing.  The cached prefix hash
+// is only used when chaincfg.SigHashOptimization is enabled.
 func CalcSignatureHash(script []parsedOpcode, hashType SigHashType,
 	tx *wire.MsgTx, idx int, cachedPrefix *chainhash.Hash) ([]byte, error) {
+	if !chaincfg.SigHashOptimization {
+		cachedPrefix = nil
+	}
 	return calcSignatureHash(script, hashType, tx, idx, cachedPrefix)
 }
 
 // calcSignatureHash will, given a script and hash type for the current script
 // engine instance, calculate the signature hash to be used for signing and
-// verification.
+// verification.  The cached prefix hash is used for SigHashAll signature hashes
+// when it is not nil, so callers must only pass prefix hashes which are known
+// to belong to the transaction, such as the ones of the shared signature hashes
+// or, when chaincfg.SigHashOptimization is enabled, the cached hash of the
+// transaction.
 func calcSignatureHash(script []parsedOpcode, hashType SigHashType,
 	tx *wire.MsgTx, idx int, cachedPrefix *chainhash.Hash) ([]byte, error) {
 	// The SigHashSingle signature type signs only the corresponding input
@@ -398,8 +406,7 @@ func calcSignatureHash(script []parsedOpcode, hashType SigHashType,
 	var prefixHash chainhash.Hash
 	if cachedPrefix != nil &&
 		(hashType&sigHashMask == SigHashAll) &&
-		(hashType&SigHashAnyOneCanPay == 0) &&
-		chaincfg.SigHashOptimization {
+		(hashType&SigHashAnyOneCanPay == 0) {
 		prefixHash = *cachedPrefix
 	} else {
 		prefixHash = txCopy.TxHash()
@@ -434,37 +441,7 @@ func asSmallInt(op *opcode) int {
 // requested then we attempt to count the number of operations for a multisig
 // op. Otherwise we use the maximum.
 func getSigOpCount(pops []parsedOpcode, precise bool) int {
-	nSigs := 0
-	for i, pop := range pops {
-		switch pop.opcode.value {
-		case OP_CHECKSIG:
-			fallthrough
-		case OP_CHECKSIGVERIFY:
-			fallthrough
-		case OP_CHECKSIGALT:
-			fallthrough
-		case OP_CHECKSIGALTVERIFY:
-			nSigs++
-		case OP_CHECKMULTISIG:
-			fallthrough
-		case OP_CHECKMULTISIGVERIFY:
-			// If we are being precise then look for familiar
-			// patterns for multisig, for now all we recognize is
-			// OP_1 - OP_16 to signify the number of pubkeys.
-			// Otherwise, we use the max of 20.
-			if precise && i > 0 &&
-				pops[i-1].opcode.value >= OP_1 &&
-				pops[i-1].opcode.value <= OP_16 {
-				nSigs += asSmallInt(pops[i-1].opcode)
-			} else {
-				nSigs += MaxPubKeysPerMultiSig
-			}
-		default:
-			// Not a sigop.
-		}
-	}
-
-	return nSigs
+	return getSigOpCost(pops, precise).SigOps
 }
 
 // GetSigOpCount provides a quick count of the number of signature operations
@@ -472,10 +449,7 @@ func getSigOpCount(pops []parsedOpcode, precise bool) int {
 // If the script fails to parse, then the count up to the point of failure is
 // returned.
 func GetSigOpCount(script []byte) int {
-	// Don't check error since parseScript returns the parsed-up-to-error
-	// list of pops.
-	pops, _ := parseScript(script)
-	return getSigOpCount(pops, false)
+	return GetSigOpCost(script).SigOps
 }
 
 // GetPreciseSigOpCount returns the number of signature operations in
@@ -484,43 +458,7 @@ func GetSigOpCount(script []byte) int {
 // operations in the transaction.  If the script fails to parse, then the count
 // up to the point of failure is returned.
 func GetPreciseSigOpCount(scriptSig, scriptPubKey []byte, bip16 bool) int {
-	// Don't check error since parseScript returns the parsed-up-to-error
-	// list of pops.
-	pops, _ := parseScript(scriptPubKey)
-
-	// Treat non P2SH transactions as normal.
-	if !(bip16 && isScriptHash(pops)) {
-		return getSigOpCount(pops, true)
-	}
-
-	// The public key script is a pay-to-script-hash, so parse the signature
-	// script to get the final item.  Scripts that fail to fully parse count
-	// as 0 signature operations.
-	sigPops, err := parseScript(scriptSig)
-	if err != nil {
-		return 0
-	}
-
-	// The signature script must only push data to the stack for P2SH to be
-	// a valid pair, so the signature operation count is 0 when that is not
-	// the case.
-	if !isPushOnly(sigPops) || len(sigPops) == 0 {
-		return 0
-	}
-
-	// The P2SH script is the last item the signature script pushes to the
-	// stack.  When the script is empty, there are no signature operations.
-	shScript := sigPops[len(sigPops)-1].data
-	if len(shScript) == 0 {
-		return 0
-	}
-
-	// Parse the P2SH script and don't check the error since parseScript
-	// returns the parsed-up-to-error list of pops and the consensus rules
-	// dictate signature operations are counted up to the first parse
-	// failure.
-	shPops, _ := parseScript(shScript)
-	return getSigOpCount(shPops, true)
+	return GetPreciseSigOpCost(scriptSig, scriptPubKey, bip16).SigOps
 }
 
 // IsUnspendable returns whether the passed public key script is unspendable, or
@@ -0,0 +1,115 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+// SigOpCost houses the cost of the signature operations in scripts.  SigOps is
+// the number of signature operations as counted by the consensus rules, which
+// includes the AltSigOps operations that verify signatures of the alternative
+// signature types via OP_CHECKSIGALT and OP_CHECKSIGALTVERIFY.  They are
+// reported separately since the alternative signature types are more expensive
+// to verify than secp256k1 ECDSA signatures.
+type SigOpCost struct {
+	SigOps    int
+	AltSigOps int
+}
+
+// Add adds the passed cost to the cost.
+func (c *SigOpCost) Add(cost SigOpCost) {
+	c.SigOps += cost.SigOps
+	c.AltSigOps += cost.AltSigOps
+}
+
+// getSigOpCost is the implementation function for calculating the cost of the
+// signature operations in the script provided by pops.  If precise mode is
+// requested then we attempt to count the number of operations for a multisig
+// op.  Otherwise we use the maximum.
+func getSigOpCost(pops []parsedOpcode, precise bool) SigOpCost {
+	var cost SigOpCost
+	for i, pop := range pops {
+		switch pop.opcode.value {
+		case OP_CHECKSIG:
+			fallthrough
+		case OP_CHECKSIGVERIFY:
+			cost.SigOps++
+		case OP_CHECKSIGALT:
+			fallthrough
+		case OP_CHECKSIGALTVERIFY:
+			cost.SigOps++
+			cost.AltSigOps++
+		case OP_CHECKMULTISIG:
+			fallthrough
+		case OP_CHECKMULTISIGVERIFY:
+			// If we are being precise then look for familiar
+			// patterns for multisig, for now all we recognize is
+			// OP_1 - OP_16 to signify the number of pubkeys.
+			// Otherwise, we use the max of 20.
+			if precise && i > 0 &&
+				pops[i-1].opcode.value >= OP_1 &&
+				pops[i-1].opcode.value <= OP_16 {
+				cost.SigOps += asSmallInt(pops[i-1].opcode)
+			} else {
+				cost.SigOps += MaxPubKeysPerMultiSig
+			}
+		default:
+			// Not a sigop.
+		}
+	}
+
+	return cost
+}
+
+// GetSigOpCost returns the quickly counted cost of the signature operations in
+// a script in the same way as GetSigOpCount.  If the script fails to parse,
+// then the cost up to the point of failure is returned.
+func GetSigOpCost(script []byte) SigOpCost {
+	// Don't check error since parseScript returns the parsed-up-to-error
+	// list of pops.
+	pops, _ := parseScript(script)
+	return getSigOpCost(pops, false)
+}
+
+// GetPreciseSigOpCost returns the cost of the signature operations in
+// scriptPubKey in the same way as GetPreciseSigOpCount.  If bip16 is true then
+// scriptSig may be searched for the Pay-To-Script-Hash script in order to find
+// the precise cost of the signature operations in the transaction.  If the
+// script fails to parse, then the cost up to the point of failure is returned.
+func GetPreciseSigOpCost(scriptSig, scriptPubKey []byte, bip16 bool) SigOpCost {
+	// Don't check error since parseScript returns the parsed-up-to-error
+	// list of pops.
+	pops, _ := parseScript(scriptPubKey)
+
+	// Treat non P2SH transactions as normal.
+	if !(bip16 && isScriptHash(pops)) {
+		return getSigOpCost(pops, true)
+	}
+
+	// The public key script is a pay-to-script-hash, so parse the signature
+	// script to get the final item.  Scripts that fail to fully parse cost
+	// nothing.
+	sigPops, err := parseScript(scriptSig)
+	if err != nil {
+		return SigOpCost{}
+	}
+
+	// The signature script must only push data to the stack for P2SH to be
+	// a valid pair, so the cost is nothing when that is not the case.
+	if !isPushOnly(sigPops) || len(sigPops) == 0 {
+		return SigOpCost{}
+	}
+
+	// The P2SH script is the last item the signature script pushes to the
+	// stack.  When the script is empty, there are no signature operations.
+	shScript := sigPops[len(sigPops)-1].data
+	if len(shScript) == 0 {
+		return SigOpCost{}
+	}
+
+	// Parse the P2SH script and don't check the error since parseScript
+	// returns the parsed-up-to-error list of pops and the consensus rules
+	// dictate signature operations are counted up to the first parse
+	// failure.
+	shPops, _ := parseScript(shScript)
+	return getSigOpCost(shPops, true)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript_test
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/txscript"
+)
+
+// TestGetSigOpCost ensures the cost of the signature operations in scripts
+// counts alternative signature operations separately and matches the
+// signature operation counts.
+func TestGetSigOpCost(t *testing.T) {
+	t.Parallel()
+
+	redeemScript := mustParseShortForm("CHECKSIGALTVERIFY 2 CHECKMULTISIG")
+	scriptSig, err := txscript.NewScriptBuilder().AddData(redeemScript).
+		Script()
+	if err != nil {
+		t.Fatalf("unexpected error building signature script: %v", err)
+	}
+	p2shScript := mustParseShortForm("HASH160 DATA_20 0x433ec2ac1ffa1b7b7" +
+		"d027f564529c57197f9ae88 EQUAL")
+
+	tests := []struct {
+		name      string
+		scriptSig []byte
+		pkScript  []byte
+		quick     txscript.SigOpCost
+		precise   txscript.SigOpCost
+	}{
+		{
+			name: "pay to pubkey hash",
+			pkScript: mustParseShortForm("DUP HASH160 DATA_20 0x433e" +
+				"c2ac1ffa1b7b7d027f564529c57197f9ae88 EQUALVERIFY " +
+				"CHECKSIG"),
+			quick:   txscript.SigOpCost{SigOps: 1},
+			precise: txscript.SigOpCost{SigOps: 1},
+		},
+		{
+			name:     "alternative signature",
+			pkScript: mustParseShortForm("1 CHECKSIGALT"),
+			quick:    txscript.SigOpCost{SigOps: 1, AltSigOps: 1},
+			precise:  txscript.SigOpCost{SigOps: 1, AltSigOps: 1},
+		},
+		{
+			name:     "multisig and alternative signature",
+			pkScript: redeemScript,
+			quick:    txscript.SigOpCost{SigOps: 21, AltSigOps: 1},
+			precise:  txscript.SigOpCost{SigOps: 3, AltSigOps: 1},
+		},
+		{
+			name:      "pay to script hash",
+			scriptSig: scriptSig,
+			pkScript:  p2shScript,
+			quick:     txscript.SigOpCost{},
+			precise:   txscript.SigOpCost{SigOps: 3, AltSigOps: 1},
+		},
+	}
+
+	for _, test := range tests {
+		quick := txscript.GetSigOpCost(test.pkScript)
+		if quick != test.quick {
+			t.Errorf("%s: unexpected quick cost - got %+v, want %+v",
+				test.name, quick, test.quick)
+		}
+		count := txscript.GetSigOpCount(test.pkScript)
+		if count != quick.SigOps {
+			t.Errorf("%s: quick count %d does not match cost %+v",
+				test.name, count, quick)
+		}
+
+		precise := txscript.GetPreciseSigOpCost(test.scriptSig,
+			test.pkScript, true)
+		if precise != test.precise {
+			t.Errorf("%s: unexpected precise cost - got %+v, want %+v",
+				test.name, precise, test.precise)
+		}
+		count = txscript.GetPreciseSigOpCount(test.scriptSig,
+			test.pkScript, true)
+		if count != precise.SigOps {
+			t.Errorf("%s: precise count %d does not match cost %+v",
+				test.name, count, precise)
+		}
+	}
+
+	// Ensure adding costs adds both counts.
+	cost := txscript.SigOpCost{SigOps: 1}
+	cost.Add(txscript.SigOpCost{SigOps: 2, AltSigOps: 1})
+	if cost != (txscript.SigOpCost{SigOps: 3, AltSigOps: 1}) {
+		t.Errorf("Add: unexpected cost - got %+v", cost)
+	}
+}
@@ -758,105 +758,23 @@ func payToSchnorrPubKeyScript(serializedPubKey []byte) ([]byte, error) {
 // public key hash, but tags the output with OP_SSTX. For use in constructing
 // valid SStxs.
 func PayToSStx(addr dcrutil.Address) ([]byte, error) {
-	if addr == nil {
-		return nil, ErrUnsupportedAddress
-	}
-
-	// Only pay to pubkey hash and pay to script hash are
-	// supported.
-	scriptType := PubKeyHashTy
-	switch addr := addr.(type) {
-	case *dcrutil.AddressPubKeyHash:
-		if addr.DSA(addr.Net()) != chainec.ECTypeSecp256k1 {
-			return nil, ErrUnsupportedAddress
-		}
-		break
-	case *dcrutil.AddressScriptHash:
-		scriptType = ScriptHashTy
-		break
-	default:
-		return nil, ErrUnsupportedAddress
-	}
-
-	hash := addr.ScriptAddress()
-
-	if scriptType == PubKeyHashTy {
-		return NewScriptBuilder().AddOp(OP_SSTX).AddOp(OP_DUP).
-			AddOp(OP_HASH160).AddData(hash).AddOp(OP_EQUALVERIFY).
-			AddOp(OP_CHECKSIG).Script()
-	}
-	return NewScriptBuilder().AddOp(OP_SSTX).AddOp(OP_HASH160).
-		AddData(hash).AddOp(OP_EQUAL).Script()
+	return NewOutputScriptBuilder().StakeSubmission().PayToAddress(addr).
+		Script()
 }
 
 // PayToSStxChange creates a new script to pay a transaction output to a
 // public key hash, but tags the output with OP_SSTXCHANGE. For use in constructing
 // valid SStxs.
 func PayToSStxChange(addr dcrutil.Address) ([]byte, error) {
-	if addr == nil {
-		return nil, ErrUnsupportedAddress
-	}
-
-	// Only pay to pubkey hash and pay to script hash are
-	// supported.
-	scriptType := PubKeyHashTy
-	switch addr := addr.(type) {
-	case *dcrutil.AddressPubKeyHash:
-		if addr.DSA(addr.Net()) != chainec.ECTypeSecp256k1 {
-			return nil, ErrUnsupportedAddress
-		}
-		break
-	case *dcrutil.AddressScriptHash:
-		scriptType = ScriptHashTy
-		break
-	default:
-		return nil, ErrUnsupportedAddress
-	}
-
-	hash := addr.ScriptAddress()
-
-	if scriptType == PubKeyHashTy {
-		return NewScriptBuilder().AddOp(OP_SSTXCHANGE).AddOp(OP_DUP).
-			AddOp(OP_HASH160).AddData(hash).AddOp(OP_EQUALVERIFY).
-			AddOp(OP_CHECKSIG).Script()
-	}
-	return NewScriptBuilder().AddOp(OP_SSTXCHANGE).AddOp(OP_HASH160).
-		AddData(hash).AddOp(OP_EQUAL).Script()
+	return NewOutputScriptBuilder().StakeChange().PayToAddress(addr).
+		Script()
 }
 
 // PayToSSGen creates a new script to pay a transaction output to a public key
 // hash or script hash, but tags the output with OP_SSGEN. For use in constructing
 // valid SSGen txs.
 func PayToSSGen(addr dcrutil.Address) ([]byte, error) {
-	if addr == nil {
-		return nil, ErrUnsupportedAddress
-	}
-
-	// Only pay to pubkey hash and pay to script hash are
-	// supported.
-	scriptType := PubKeyHashTy
-	switch addr := addr.(type) {
-	case *dcrutil.AddressPubKeyHash:
-		if addr.DSA(addr.Net()) != chainec.ECTypeSecp256k1 {
-			return nil, ErrUnsupportedAddress
-		}
-		break
-	case *dcrutil.AddressScriptHash:
-		scriptType = ScriptHashTy
-		break
-	default:
-		return nil, ErrUnsupportedAddress
-	}
-
-	hash := addr.ScriptAddress()
-
-	if scriptType == PubKeyHashTy {
-		return NewScriptBuilder().AddOp(OP_SSGEN).AddOp(OP_DUP).
-			AddOp(OP_HASH160).AddData(hash).AddOp(OP_EQUALVERIFY).
-			AddOp(OP_CHECKSIG).Script()
-	}
-	return NewScriptBuilder().AddOp(OP_SSGEN).AddOp(OP_HASH160).
-		AddData(hash).AddOp(OP_EQUAL).Script()
+	return NewOutputScriptBuilder().StakeGen().PayToAddress(addr).Script()
 }
 
 // PayToSSGenPKHDirect creates a new script to pay a transaction output to a
@@ -890,35 +808,8 @@ func PayToSSGenSHDirect(sh []byte) ([]byte, error) {
 // public key hash, but tags the output with OP_SSRTX. For use in constructing
 // valid SSRtx.
 func PayToSSRtx(addr dcrutil.Address) ([]byte, error) {
-	if addr == nil {
-		return nil, ErrUnsupportedAddress
-	}
-
-	// Only pay to pubkey hash and pay to script hash are
-	// supported.
-	scriptType := PubKeyHashTy
-	switch addr := addr.(type) {
-	case *dcrutil.AddressPubKeyHash:
-		if addr.DSA(addr.Net()) != chainec.ECTypeSecp256k1 {
-			return nil, ErrUnsupportedAddress
-		}
-		break
-	case *dcrutil.AddressScriptHash:
-		scriptType = ScriptHashTy
-		break
-	default:
-		return nil, ErrUnsupportedAddress
-	}
-
-	hash := addr.ScriptAddress()
-
-	if scriptType == PubKeyHashTy {
-		return NewScriptBuilder().AddOp(OP_SSRTX).AddOp(OP_DUP).
-			AddOp(OP_HASH160).AddData(hash).AddOp(OP_EQUALVERIFY).
-			AddOp(OP_CHECKSIG).Script()
-	}
-	return NewScriptBuilder().AddOp(OP_SSRTX).AddOp(OP_HASH160).
-		AddData(hash).AddOp(OP_EQUAL).Script()
+	return NewOutputScriptBuilder().StakeRevocation().PayToAddress(addr).
+		Script()
 }
 
 // PayToSSRtxPKHDirect creates a new script to pay a transaction output to a
@@ -952,51 +843,7 @@ func PayToSSRtxSHDirect(sh []byte) ([]byte, error) {
 // an SStx.
 func GenerateSStxAddrPush(addr dcrutil.Address, amount dcrutil.Amount,
 	limits uint16) ([]byte, error) {
-	if addr == nil {
-		return nil, ErrUnsupportedAddress
-	}
-
-	// Only pay to pubkey hash and pay to script hash are
-	// supported.
-	scriptType := PubKeyHashTy
-	switch addr := addr.(type) {
-	case *dcrutil.AddressPubKeyHash:
-		if addr.DSA(addr.Net()) != chainec.ECTypeSecp256k1 {
-			return nil, ErrUnsupportedAddress
-		}
-		break
-	case *dcrutil.AddressScriptHash:
-		scriptType = ScriptHashTy
-		break
-	default:
-		return nil, ErrUnsupportedAddress
-	}
-
-	// Prefix
-	dataPushes := []byte{
-		0x6a, // OP_RETURN
-		0x1e, // OP_DATA_30
-	}
-
-	hash := addr.ScriptAddress()
-
-	amountBuffer := make([]byte, 8)
-	binary.LittleEndian.PutUint64(amountBuffer, uint64(amount))
-
-	// Set the bit flag indicating pay to script hash.
-	if scriptType == ScriptHashTy {
-		amountBuffer[7] |= 1 << 7
-	}
-
-	limitsBuffer := make([]byte, 2)
-	binary.LittleEndian.PutUint16(limitsBuffer, limits)
-
-	// Concatenate the prefix, pubkeyhash, and amount.
-	addrOut := append(dataPushes, hash...)
-	addrOut = append(addrOut, amountBuffer...)
-	addrOut = append(addrOut, limitsBuffer...)
-
-	return addrOut, nil
+	return NewOutputScriptBuilder().Commitment(addr, amount, limits).Script()
 }
 
 // GenerateSSGenBlockRef generates an OP_RETURN push for the block header hash and
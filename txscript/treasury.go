@@ -0,0 +1,61 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import "fmt"
+
+// OP_TREASURY is the opcode which makes up the public key script of outputs
+// that fund the treasury.  It replaces the upgradable NOP OP_UNKNOWN193 once
+// the ScriptVerifyTreasury flag is set.
+const OP_TREASURY = OP_UNKNOWN193
+
+func init() {
+	registerOpcodeActivation(ScriptVerifyTreasury, opcode{
+		value:  OP_TREASURY,
+		name:   "OP_TREASURY",
+		length: 1,
+		opfunc: opcodeTreasury,
+	})
+}
+
+// opcodeTreasury treats the top two items on the data stack as a public key
+// and a signature and verifies the signature of the transaction input which
+// spends a treasury output in the same manner as OP_CHECKSIG.  Only the
+// signature is verified by the script; the consensus rules are responsible for
+// ensuring the public key is authorized to spend from the treasury.
+//
+// Stack transformation: [... signature pubkey] -> [... bool]
+func opcodeTreasury(op *parsedOpcode, vm *Engine) error {
+	return opcodeCheckSig(op, vm)
+}
+
+// TreasuryScript returns the public key script which pays to the treasury.
+// Spending an output which pays to it requires a signature script of the form
+// <signature> <pubkey>, such as the one created by SignatureScript with this
+// script as the subscript.
+func TreasuryScript() []byte {
+	return []byte{OP_TREASURY}
+}
+
+// IsTreasuryScript returns whether or not the passed public key script pays to
+// the treasury.
+func IsTreasuryScript(pkScript []byte) bool {
+	return len(pkScript) == 1 && pkScript[0] == OP_TREASURY
+}
+
+// ExtractTreasurySpendPubKey returns the public key from the passed signature
+// script which spends a treasury output.  An error is returned when the script
+// is not of the form <signature> <pubkey>.
+func ExtractTreasurySpendPubKey(sigScript []byte) ([]byte, error) {
+	pops, err := parseScript(sigScript)
+	if err != nil {
+		return nil, err
+	}
+	if len(pops) != 2 || !isPushOnly(pops) || len(pops[1].data) == 0 {
+		return nil, fmt.Errorf("treasury spend signature script is not " +
+			"of the form <signature> <pubkey>")
+	}
+	return pops[1].data, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainec"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestTreasurySpend ensures the signature of a transaction input which spends
+// a treasury output is only verified once the treasury script flag is set.
+func TestTreasurySpend(t *testing.T) {
+	t.Parallel()
+
+	secp256k1 := chainec.Secp256k1
+	keyBytes, _, _, err := secp256k1.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	key, pubKey := secp256k1.PrivKeyFromBytes(keyBytes)
+
+	tx := &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  chainhash.Hash{0x01},
+				Index: 0,
+				Tree:  wire.TxTreeRegular,
+			},
+			Sequence: wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1000}},
+	}
+	pkScript := TreasuryScript()
+	if !IsTreasuryScript(pkScript) {
+		t.Fatal("treasury script is not recognized")
+	}
+	sigScript, err := SignatureScript(tx, 0, pkScript, SigHashAll, key, true)
+	if err != nil {
+		t.Fatalf("failed to sign treasury spend: %v", err)
+	}
+	gotPubKey, err := ExtractTreasurySpendPubKey(sigScript)
+	if err != nil {
+		t.Fatalf("failed to extract treasury spend public key: %v", err)
+	}
+	if !bytes.Equal(gotPubKey, pubKey.SerializeCompressed()) {
+		t.Fatalf("unexpected treasury spend public key -- got %x, want %x",
+			gotPubKey, pubKey.SerializeCompressed())
+	}
+
+	// Signing the spend commits to the outputs, so modifying them after
+	// the fact must invalidate the signature.
+	badTx := tx.Copy()
+	badTx.TxOut[0].Value++
+
+	const flags = ScriptBip16 | ScriptVerifyStrictEncoding |
+		ScriptVerifyCleanStack
+	tests := []struct {
+		name  string
+		tx    *wire.MsgTx
+		flags ScriptFlags
+		valid bool
+	}{
+		{
+			name:  "valid signature",
+			tx:    tx,
+			flags: flags | ScriptVerifyTreasury,
+			valid: true,
+		},
+		{
+			name:  "invalid signature",
+			tx:    badTx,
+			flags: flags | ScriptVerifyTreasury,
+			valid: false,
+		},
+		{
+			name:  "not activated",
+			tx:    tx,
+			flags: flags,
+			valid: false,
+		},
+	}
+	for _, test := range tests {
+		test.tx.TxIn[0].SignatureScript = sigScript
+		vm, err := NewEngine(pkScript, test.tx, 0, test.flags, 0, nil)
+		if err != nil {
+			t.Errorf("%s: failed to create engine: %v", test.name, err)
+			continue
+		}
+		err = vm.Execute()
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected execute error: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: execute succeeded for invalid spend", test.name)
+		}
+	}
+
+	// Ensure signature scripts which are not of the required form are
+	// rejected.
+	invalid := [][]byte{
+		nil,
+		{OP_DATA_1, 0x01},
+		{OP_DATA_1, 0x01, OP_DATA_1, 0x02, OP_DATA_1, 0x03},
+		{OP_DATA_1, 0x01, OP_0},
+		{OP_DATA_1, 0x01, OP_VERIFY},
+	}
+	for i, script := range invalid {
+		if _, err := ExtractTreasurySpendPubKey(script); err == nil {
+			t.Errorf("#%d: extracted public key from invalid treasury "+
+				"spend signature script %x", i, script)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build gofuzz
+
+// This file contains the go-fuzz targets for decoding wire protocol messages.
+// The seed corpus is housed in testdata/fuzz/corpus and go-fuzz stores the
+// inputs it discovers alongside it.  Run the fuzzer with:
+//
+//   go-fuzz-build github.com/decred/dcrd/wire
+//   go-fuzz -bin=wire-fuzz.zip -workdir=testdata/fuzz
+//
+// The -func flag of go-fuzz-build selects FuzzReadMessage instead of Fuzz.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// fuzzCommands houses the commands of the messages exercised by Fuzz.  The
+// first byte of the input selects the command by its index modulo the number
+// of commands, so new commands must only ever be appended in order to keep the
+// existing corpus meaningful.
+var fuzzCommands = []string{
+	CmdVersion,
+	CmdVerAck,
+	CmdGetAddr,
+	CmdAddr,
+	CmdGetBlocks,
+	CmdBlock,
+	CmdInv,
+	CmdGetData,
+	CmdNotFound,
+	CmdTx,
+	CmdPing,
+	CmdPong,
+	CmdGetHeaders,
+	CmdHeaders,
+	CmdAlert,
+	CmdMemPool,
+	CmdMiningState,
+	CmdGetMiningState,
+	CmdFilterAdd,
+	CmdFilterClear,
+	CmdFilterLoad,
+	CmdMerkleBlock,
+	CmdReject,
+	CmdSendHeaders,
+	CmdDoubleSpendProof,
+	CmdSendCmpct,
+	CmdCmpctBlock,
+	CmdGetBlockTxn,
+	CmdBlockTxn,
+	CmdGetPackage,
+	CmdPackage,
+}
+
+// Fuzz is the go-fuzz entry point for decoding message payloads.  The first
+// byte of the input selects the type of message and the remaining bytes are
+// decoded as its payload, which avoids the need for the fuzzer to discover
+// valid message headers and checksums.
+//
+// Payloads which decode successfully must encode to a serialization that
+// decodes and encodes back to the same bytes, otherwise it panics so the input
+// is reported as a crasher.
+func Fuzz(data []byte) int {
+	if len(data) == 0 {
+		return -1
+	}
+	command := fuzzCommands[int(data[0])%len(fuzzCommands)]
+	payload := data[1:]
+
+	msg, err := makeEmptyMessage(command)
+	if err != nil {
+		panic(err)
+	}
+	if uint32(len(payload)) > msg.MaxPayloadLength(ProtocolVersion) {
+		return -1
+	}
+	if err := msg.BtcDecode(bytes.NewBuffer(payload), ProtocolVersion); err != nil {
+		return 0
+	}
+
+	encoded := fuzzEncode(msg)
+	msg2, err := makeEmptyMessage(command)
+	if err != nil {
+		panic(err)
+	}
+	err = msg2.BtcDecode(bytes.NewBuffer(encoded), ProtocolVersion)
+	if err != nil {
+		panic(fmt.Sprintf("failed to decode re-encoded %s message: %v",
+			command, err))
+	}
+	if reencoded := fuzzEncode(msg2); !bytes.Equal(encoded, reencoded) {
+		panic(fmt.Sprintf("%s message does not round trip - got %x, "+
+			"want %x", command, reencoded, encoded))
+	}
+
+	return 1
+}
+
+// FuzzReadMessage is the go-fuzz entry point for reading complete messages,
+// including the message header, from a stream.  Since the header contains a
+// checksum of the payload, it is mostly useful for exercising the handling of
+// malformed headers.
+func FuzzReadMessage(data []byte) int {
+	r := bytes.NewReader(data)
+	for {
+		_, _, err := ReadMessage(r, ProtocolVersion, MainNet)
+		if err != nil {
+			if r.Len() == len(data) {
+				return 0
+			}
+			return 1
+		}
+	}
+}
+
+// fuzzEncode returns the encoded payload of the passed message and panics
+// when it can not be encoded since every decoded message must be encodable.
+func fuzzEncode(msg Message) []byte {
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, ProtocolVersion); err != nil {
+		panic(fmt.Sprintf("failed to encode decoded %s message: %v",
+			msg.Command(), err))
+	}
+	return buf.Bytes()
+}
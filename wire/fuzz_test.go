@@ -0,0 +1,38 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build gofuzz
+
+package wire
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestFuzzCorpus ensures every input in the seed corpus decodes successfully
+// and round trips through the fuzz target.  Run it with -tags gofuzz.
+func TestFuzzCorpus(t *testing.T) {
+	dir := filepath.Join("testdata", "fuzz", "corpus")
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read corpus: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatal("corpus is empty")
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Errorf("failed to read %s: %v", file.Name(), err)
+			continue
+		}
+		if got := Fuzz(data); got != 1 {
+			t.Errorf("Fuzz(%s): unexpected result - got %d, want 1",
+				file.Name(), got)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"time"
 	"unicode/utf8"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
@@ -29,30 +30,37 @@ const MaxMessagePayload = (1024 * 1024 * 32) // 32MB
 
 // Commands used in message headers which describe the type of message.
 const (
-	CmdVersion        = "version"
-	CmdVerAck         = "verack"
-	CmdGetAddr        = "getaddr"
-	CmdAddr           = "addr"
-	CmdGetBlocks      = "getblocks"
-	CmdInv            = "inv"
-	CmdGetData        = "getdata"
-	CmdNotFound       = "notfound"
-	CmdBlock          = "block"
-	CmdTx             = "tx"
-	CmdGetHeaders     = "getheaders"
-	CmdHeaders        = "headers"
-	CmdPing           = "ping"
-	CmdPong           = "pong"
-	CmdAlert          = "alert"
-	CmdMemPool        = "mempool"
-	CmdMiningState    = "miningstate"
-	CmdGetMiningState = "getminings"
-	CmdFilterAdd      = "filteradd"
-	CmdFilterClear    = "filterclear"
-	CmdFilterLoad     = "filterload"
-	CmdMerkleBlock    = "merkleblock"
-	CmdReject         = "reject"
-	CmdSendHeaders    = "sendheaders"
+	CmdVersion          = "version"
+	CmdVerAck           = "verack"
+	CmdGetAddr          = "getaddr"
+	CmdAddr             = "addr"
+	CmdGetBlocks        = "getblocks"
+	CmdInv              = "inv"
+	CmdGetData          = "getdata"
+	CmdNotFound         = "notfound"
+	CmdBlock            = "block"
+	CmdTx               = "tx"
+	CmdGetHeaders       = "getheaders"
+	CmdHeaders          = "headers"
+	CmdPing             = "ping"
+	CmdPong             = "pong"
+	CmdAlert            = "alert"
+	CmdMemPool          = "mempool"
+	CmdMiningState      = "miningstate"
+	CmdGetMiningState   = "getminings"
+	CmdFilterAdd        = "filteradd"
+	CmdFilterClear      = "filterclear"
+	CmdFilterLoad       = "filterload"
+	CmdMerkleBlock      = "merkleblock"
+	CmdReject           = "reject"
+	CmdSendHeaders      = "sendheaders"
+	CmdDoubleSpendProof = "dsproof"
+	CmdSendCmpct        = "sendcmpct"
+	CmdCmpctBlock       = "cmpctblock"
+	CmdGetBlockTxn      = "getblocktxn"
+	CmdBlockTxn         = "blocktxn"
+	CmdGetPackage       = "getpackage"
+	CmdPackage          = "package"
 )
 
 // Message is an interface that describes a decred message.  A type that
@@ -143,6 +151,27 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdSendHeaders:
 		msg = &MsgSendHeaders{}
 
+	case CmdDoubleSpendProof:
+		msg = &MsgDoubleSpendProof{}
+
+	case CmdSendCmpct:
+		msg = &MsgSendCmpct{}
+
+	case CmdCmpctBlock:
+		msg = &MsgCmpctBlock{}
+
+	case CmdGetBlockTxn:
+		msg = &MsgGetBlockTxn{}
+
+	case CmdBlockTxn:
+		msg = &MsgBlockTxn{}
+
+	case CmdGetPackage:
+		msg = &MsgGetPackage{}
+
+	case CmdPackage:
+		msg = &MsgPackage{}
+
 	default:
 		return nil, fmt.Errorf("unhandled command [%s]", command)
 	}
@@ -205,6 +234,43 @@ func discardInput(r io.Reader, n uint32) {
 // information and returns the number of bytes written.    This function is the
 // same as WriteMessage except it also returns the number of bytes written.
 func WriteMessageN(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) (int, error) {
+	return WriteMessageWithStats(w, msg, pver, dcrnet, nil)
+}
+
+// WriteMessageWithStats writes a decred Message to w including the necessary
+// header information and returns the number of bytes written.  This function
+// is the same as WriteMessageN except it also reports the statistics of the
+// message to the passed collector, which may be nil, before the registered
+// collectors.  This allows callers, such as peers, to account for their own
+// messages without registering a collector which receives every message.
+func WriteMessageWithStats(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet, c MessageStatsCollector) (int, error) {
+	// Avoid timing the write when there are no collectors to report the
+	// statistics to.
+	collectors := loadMsgStatsCollectors()
+	if len(collectors) == 0 && c == nil {
+		return writeMessageN(w, msg, pver, dcrnet)
+	}
+
+	start := time.Now()
+	n, err := writeMessageN(w, msg, pver, dcrnet)
+	stats := &MessageStats{
+		Command: msg.Command(),
+		Bytes:   n,
+		Latency: time.Since(start),
+		Err:     err,
+	}
+	if c != nil {
+		c.MessageWritten(stats)
+	}
+	for _, registered := range collectors {
+		registered.MessageWritten(stats)
+	}
+	return n, err
+}
+
+// writeMessageN is the internal function which implements WriteMessageN.  See
+// the comment for WriteMessageN for more details.
+func writeMessageN(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) (int, error) {
 	totalBytes := 0
 
 	// Enforce max command size.
@@ -217,13 +283,19 @@ func WriteMessageN(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) (i
 	}
 	copy(command[:], []byte(cmd))
 
-	// Encode the message payload.
-	var bw bytes.Buffer
-	err := msg.BtcEncode(&bw, pver)
-	if err != nil {
-		return totalBytes, err
+	// Encode the message payload.  The payload of raw messages is already
+	// serialized, so it is used directly.
+	var payload []byte
+	if rawMsg, ok := msg.(*RawMessage); ok {
+		payload = rawMsg.Payload
+	} else {
+		var bw bytes.Buffer
+		err := msg.BtcEncode(&bw, pver)
+		if err != nil {
+			return totalBytes, err
+		}
+		payload = bw.Bytes()
 	}
-	payload := bw.Bytes()
 	lenp := len(payload)
 
 	// Enforce maximum overall message payload.
@@ -256,17 +328,8 @@ func WriteMessageN(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) (i
 	hw := bytes.NewBuffer(make([]byte, 0, MessageHeaderSize))
 	writeElements(hw, hdr.magic, command, hdr.length, hdr.checksum)
 
-	// Write header.
-	n, err := w.Write(hw.Bytes())
-	totalBytes += n
-	if err != nil {
-		return totalBytes, err
-	}
-
-	// Write payload.
-	n, err = w.Write(payload)
-	totalBytes += n
-	return totalBytes, err
+	// Write the header and payload.
+	return writeHeaderAndPayload(w, hw.Bytes(), payload)
 }
 
 // WriteMessage writes a decred Message to w including the necessary header
@@ -285,6 +348,15 @@ func WriteMessage(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) err
 // message.  This function is the same as ReadMessage except it also returns the
 // number of bytes read.
 func ReadMessageN(r io.Reader, pver uint32, dcrnet CurrencyNet) (int, Message, []byte, error) {
+	return ReadMessageWithStats(r, pver, dcrnet, nil)
+}
+
+// ReadMessageWithStats reads, validates, and parses the next decred Message
+// from r for the provided protocol version and decred network.  This function
+// is the same as ReadMessageN except it also reports the statistics of the
+// message to the passed collector, which may be nil, before the registered
+// collectors.
+func ReadMessageWithStats(r io.Reader, pver uint32, dcrnet CurrencyNet, c MessageStatsCollector) (int, Message, []byte, error) {
 	totalBytes := 0
 	n, hdr, err := readMessageHeader(r)
 	totalBytes += n
@@ -292,6 +364,38 @@ func ReadMessageN(r io.Reader, pver uint32, dcrnet CurrencyNet) (int, Message, [
 		return totalBytes, nil, nil, err
 	}
 
+	// Avoid timing the read when there are no collectors to report the
+	// statistics to.
+	collectors := loadMsgStatsCollectors()
+	if len(collectors) == 0 && c == nil {
+		n, msg, payload, err := readMessagePayload(r, hdr, pver, dcrnet)
+		return totalBytes + n, msg, payload, err
+	}
+
+	start := time.Now()
+	n, msg, payload, err := readMessagePayload(r, hdr, pver, dcrnet)
+	totalBytes += n
+	stats := &MessageStats{
+		Command: hdr.command,
+		Bytes:   totalBytes,
+		Latency: time.Since(start),
+		Err:     err,
+	}
+	if c != nil {
+		c.MessageRead(stats)
+	}
+	for _, registered := range collectors {
+		registered.MessageRead(stats)
+	}
+	return totalBytes, msg, payload, err
+}
+
+// readMessagePayload reads, validates, and parses the payload of the message
+// with the passed header from r.  It returns the number of bytes read in
+// addition to the parsed Message and raw bytes which comprise the payload.
+func readMessagePayload(r io.Reader, hdr *messageHeader, pver uint32, dcrnet CurrencyNet) (int, Message, []byte, error) {
+	totalBytes := 0
+
 	// Enforce maximum message payload.
 	if hdr.length > MaxMessagePayload {
 		str := fmt.Sprintf("message payload is too large - header "+
@@ -338,7 +442,7 @@ func ReadMessageN(r io.Reader, pver uint32, dcrnet CurrencyNet) (int, Message, [
 
 	// Read payload.
 	payload := make([]byte, hdr.length)
-	n, err = io.ReadFull(r, payload)
+	n, err := io.ReadFull(r, payload)
 	totalBytes += n
 	if err != nil {
 		return totalBytes, nil, nil, err
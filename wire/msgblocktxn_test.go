@@ -0,0 +1,129 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestBlockTxn tests the MsgBlockTxn API against the latest protocol version
+// and ensures it is rejected by older protocol versions.
+func TestBlockTxn(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "blocktxn"
+	blockHash := testBlock.BlockHash()
+	msg := NewMsgBlockTxn(&blockHash)
+	msg.Transactions = []*MsgTx{multiTx}
+	msg.STransactions = testBlock.STransactions
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgBlockTxn: wrong command - got %v want %v", cmd,
+			wantCmd)
+	}
+
+	// Ensure max payload is expected value.
+	wantPayload := uint32(chainhash.HashSize + MaxBlockPayload)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Test encode with latest protocol version.
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, pver)
+	if err != nil {
+		t.Errorf("encode of MsgBlockTxn failed %v err <%v>", msg, err)
+	}
+
+	// Test decode with latest protocol version.
+	readmsg := MsgBlockTxn{}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), pver)
+	if err != nil {
+		t.Errorf("decode of MsgBlockTxn failed [%v] err <%v>", buf, err)
+	}
+	if !reflect.DeepEqual(&readmsg, msg) {
+		t.Errorf("decode of MsgBlockTxn got: %v want: %v",
+			spew.Sdump(&readmsg), spew.Sdump(msg))
+	}
+
+	// Older protocol versions should fail encode and decode since the
+	// message didn't exist yet.
+	oldPver := CmpctBlockVersion - 1
+	err = msg.BtcEncode(&buf, oldPver)
+	if err == nil {
+		t.Errorf("encode of MsgBlockTxn passed for old protocol "+
+			"version %v", oldPver)
+	}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), oldPver)
+	if err == nil {
+		t.Errorf("decode of MsgBlockTxn passed for old protocol "+
+			"version %v", oldPver)
+	}
+}
+
+// TestBlockTxnWireErrors performs negative tests against wire encode and
+// decode of MsgBlockTxn to confirm error paths work correctly.
+func TestBlockTxnWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	blockHash := testBlock.BlockHash()
+	msg := NewMsgBlockTxn(&blockHash)
+	msg.Transactions = []*MsgTx{multiTx}
+	msg.STransactions = testBlock.STransactions
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("encode of MsgBlockTxn failed %v err <%v>", msg, err)
+	}
+	encoded := buf.Bytes()
+	stakeOffset := 33 + multiTx.SerializeSize()
+
+	tests := []struct {
+		max      int   // Max size of fixed buffer to induce errors
+		writeErr error // Expected write error
+		readErr  error // Expected read error
+	}{
+		// Force error in block hash.
+		{0, io.ErrShortWrite, io.EOF},
+		// Force error in regular transaction count.
+		{32, io.ErrShortWrite, io.EOF},
+		// Force error in regular transactions.
+		{33, io.ErrShortWrite, io.EOF},
+		// Force error in stake transaction count.
+		{stakeOffset, io.ErrShortWrite, io.EOF},
+		// Force error in stake transactions.
+		{stakeOffset + 1, io.ErrShortWrite, io.EOF},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := msg.BtcEncode(w, pver)
+		if err != test.writeErr {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var readmsg MsgBlockTxn
+		r := newFixedReader(test.max, encoded)
+		err = readmsg.BtcDecode(r, pver)
+		if err != test.readErr {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+	}
+}
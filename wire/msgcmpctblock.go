@@ -0,0 +1,271 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// ShortTxIDSize is the number of bytes of the short transaction ids of compact
+// blocks.  The short transaction id of a transaction is its short hash, keyed
+// by the key returned by MsgCmpctBlock.ShortTxIDKey, truncated to this size.
+const ShortTxIDSize = 6
+
+// PrefilledTx is a transaction which is included in full in a compact block
+// along with its index in the transaction tree of the block.
+type PrefilledTx struct {
+	Index uint32
+	Tx    *MsgTx
+}
+
+// CmpctTxTree houses one of the transaction trees of a compact block.  The
+// prefilled transactions are ordered by their index and the short transaction
+// ids fill the remaining indexes of the tree in order.
+type CmpctTxTree struct {
+	ShortIDs     []uint64
+	PrefilledTxs []PrefilledTx
+}
+
+// NumTxns returns the number of transactions in the transaction tree.
+func (t *CmpctTxTree) NumTxns() int {
+	return len(t.ShortIDs) + len(t.PrefilledTxs)
+}
+
+// readCmpctTxTree reads an encoded transaction tree of a compact block from r
+// depending on the protocol version.
+func readCmpctTxTree(r io.Reader, pver uint32, t *CmpctTxTree) error {
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+
+	// Prevent more short ids than could possibly fit into a transaction
+	// tree.  It would be possible to cause memory exhaustion and panics
+	// without a sane upper bound on this count.
+	if count > maxTxPerTree {
+		str := fmt.Sprintf("too many short ids to fit into a block "+
+			"[count %d, max %d]", count, maxTxPerTree)
+		return messageError("readCmpctTxTree", str)
+	}
+
+	var buf [8]byte
+	t.ShortIDs = make([]uint64, 0, count)
+	for i := uint64(0); i < count; i++ {
+		if _, err := io.ReadFull(r, buf[:ShortTxIDSize]); err != nil {
+			return err
+		}
+		t.ShortIDs = append(t.ShortIDs, binary.LittleEndian.Uint64(buf[:]))
+	}
+
+	count, err = ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > maxTxPerTree-uint64(len(t.ShortIDs)) {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", count+uint64(len(t.ShortIDs)),
+			maxTxPerTree)
+		return messageError("readCmpctTxTree", str)
+	}
+
+	// The indexes of the prefilled transactions must be in increasing order
+	// and refer to the transactions of the tree.
+	numTxns := uint64(len(t.ShortIDs)) + count
+	t.PrefilledTxs = make([]PrefilledTx, 0, count)
+	for i := uint64(0); i < count; i++ {
+		index, err := ReadVarInt(r, pver)
+		if err != nil {
+			return err
+		}
+		if index >= numTxns || (i > 0 &&
+			index <= uint64(t.PrefilledTxs[i-1].Index)) {
+
+			str := fmt.Sprintf("prefilled transaction index %d is "+
+				"out of order or range for a tree with %d "+
+				"transactions", index, numTxns)
+			return messageError("readCmpctTxTree", str)
+		}
+
+		tx := MsgTx{}
+		if err := tx.BtcDecode(r, pver); err != nil {
+			return err
+		}
+		t.PrefilledTxs = append(t.PrefilledTxs, PrefilledTx{
+			Index: uint32(index),
+			Tx:    &tx,
+		})
+	}
+
+	return nil
+}
+
+// writeCmpctTxTree serializes a transaction tree of a compact block to w
+// depending on the protocol version.
+func writeCmpctTxTree(w io.Writer, pver uint32, t *CmpctTxTree) error {
+	err := WriteVarInt(w, pver, uint64(len(t.ShortIDs)))
+	if err != nil {
+		return err
+	}
+
+	var buf [8]byte
+	for _, shortID := range t.ShortIDs {
+		binary.LittleEndian.PutUint64(buf[:], shortID)
+		if _, err := w.Write(buf[:ShortTxIDSize]); err != nil {
+			return err
+		}
+	}
+
+	err = WriteVarInt(w, pver, uint64(len(t.PrefilledTxs)))
+	if err != nil {
+		return err
+	}
+	for _, prefilled := range t.PrefilledTxs {
+		err := WriteVarInt(w, pver, uint64(prefilled.Index))
+		if err != nil {
+			return err
+		}
+		if err := prefilled.Tx.BtcEncode(w, pver); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MsgCmpctBlock implements the Message interface and represents a decred
+// cmpctblock message.  It is used to relay a block with the transactions the
+// receiver most likely already has replaced by short transaction ids, which
+// allows the receiver to reconstruct the block from its memory pool without
+// downloading the transactions again.  Any transactions the receiver does not
+// have are then requested with a getblocktxn message (MsgGetBlockTxn).
+//
+// This message was not added until protocol version CmpctBlockVersion.
+type MsgCmpctBlock struct {
+	Header  BlockHeader
+	Nonce   uint64
+	Regular CmpctTxTree
+	Stake   CmpctTxTree
+}
+
+// ShortTxIDKey returns the key used to calculate the short transaction ids of
+// the compact block.  It is derived from the first 16 bytes of the BLAKE256
+// hash of the serialized block header followed by the nonce, so the short ids
+// of a block differ between peers and collisions can't be crafted in advance.
+func (msg *MsgCmpctBlock) ShortTxIDKey() chainhash.ShortHashKey {
+	var buf bytes.Buffer
+	buf.Grow(MaxBlockHeaderPayload + 8)
+	// Writing to a bytes.Buffer never fails.
+	_ = writeBlockHeader(&buf, 0, &msg.Header)
+	_ = binarySerializer.PutUint64(&buf, littleEndian, msg.Nonce)
+	hash := chainhash.HashH(buf.Bytes())
+	return chainhash.ShortHashKeyFromHash(&hash)
+}
+
+// BtcDecode decodes r using the decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < CmpctBlockVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+
+	err := readBlockHeader(r, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	msg.Nonce, err = binarySerializer.Uint64(r, littleEndian)
+	if err != nil {
+		return err
+	}
+	if err := readCmpctTxTree(r, pver, &msg.Regular); err != nil {
+		return err
+	}
+	return readCmpctTxTree(r, pver, &msg.Stake)
+}
+
+// BtcEncode encodes the receiver to w using the decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < CmpctBlockVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.BtcEncode", str)
+	}
+
+	err := writeBlockHeader(w, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	err = binarySerializer.PutUint64(w, littleEndian, msg.Nonce)
+	if err != nil {
+		return err
+	}
+	if err := writeCmpctTxTree(w, pver, &msg.Regular); err != nil {
+		return err
+	}
+	return writeCmpctTxTree(w, pver, &msg.Stake)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCmpctBlock) Command() string {
+	return CmdCmpctBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) MaxPayloadLength(pver uint32) uint32 {
+	// A compact block is never larger than the block it represents along
+	// with the nonce 8 bytes, 2 additional counts, and the index of every
+	// transaction.
+	maxIndexes := 2 * MaxTxPerTxTree(pver) * MaxVarIntPayload
+	return MaxBlockPayload + 8 + 2*MaxVarIntPayload + uint32(maxIndexes)
+}
+
+// NewMsgCmpctBlock returns a new decred cmpctblock message that conforms to the
+// Message interface using the passed parameters and defaults for the remaining
+// fields.  See MsgCmpctBlock for details.
+func NewMsgCmpctBlock(header *BlockHeader, nonce uint64) *MsgCmpctBlock {
+	return &MsgCmpctBlock{
+		Header: *header,
+		Nonce:  nonce,
+	}
+}
+
+// NewMsgCmpctBlockFromBlock returns a new decred cmpctblock message for the
+// passed block which uses the passed nonce to derive its short transaction
+// ids.  The coinbase is prefilled since the receiver can't possibly have it
+// while all other transactions are replaced by their short ids.
+func NewMsgCmpctBlockFromBlock(block *MsgBlock, nonce uint64) *MsgCmpctBlock {
+	msg := NewMsgCmpctBlock(&block.Header, nonce)
+	key := msg.ShortTxIDKey()
+
+	// shortIDs returns the short ids of the passed transactions.
+	shortIDs := func(txns []*MsgTx) []uint64 {
+		ids := make([]uint64, 0, len(txns))
+		for _, tx := range txns {
+			txHash := tx.TxHash()
+			ids = append(ids, key.TruncatedShortHash(&txHash,
+				ShortTxIDSize))
+		}
+		return ids
+	}
+	if len(block.Transactions) > 0 {
+		msg.Regular.PrefilledTxs = []PrefilledTx{{
+			Index: 0,
+			Tx:    block.Transactions[0],
+		}}
+		msg.Regular.ShortIDs = shortIDs(block.Transactions[1:])
+	}
+	msg.Stake.ShortIDs = shortIDs(block.STransactions)
+	return msg
+}
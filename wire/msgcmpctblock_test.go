@@ -0,0 +1,213 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// newTestCmpctBlockSource returns a copy of the test block with additional
+// regular transactions so its compact block has both prefilled transactions
+// and short ids in the regular transaction tree.
+func newTestCmpctBlockSource() *MsgBlock {
+	block := testBlock
+	block.Transactions = append([]*MsgTx{}, testBlock.Transactions...)
+	for i := 0; i < 3; i++ {
+		tx := multiTx.Copy()
+		tx.TxOut[0].Value += int64(i)
+		block.Transactions = append(block.Transactions, tx)
+	}
+	return &block
+}
+
+// TestCmpctBlock tests the MsgCmpctBlock API against the latest protocol
+// version and ensures it is rejected by older protocol versions.
+func TestCmpctBlock(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "cmpctblock"
+	block := newTestCmpctBlockSource()
+	msg := NewMsgCmpctBlockFromBlock(block, 0x1122334455667788)
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgCmpctBlock: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure the coinbase is prefilled and all other transactions are
+	// replaced by their short ids.
+	if len(msg.Regular.PrefilledTxs) != 1 ||
+		msg.Regular.PrefilledTxs[0].Index != 0 ||
+		msg.Regular.PrefilledTxs[0].Tx != block.Transactions[0] {
+
+		t.Fatalf("NewMsgCmpctBlockFromBlock: coinbase is not prefilled")
+	}
+	if msg.Regular.NumTxns() != len(block.Transactions) ||
+		msg.Stake.NumTxns() != len(block.STransactions) {
+
+		t.Fatalf("NewMsgCmpctBlockFromBlock: unexpected number of "+
+			"transactions - got %d/%d, want %d/%d",
+			msg.Regular.NumTxns(), msg.Stake.NumTxns(),
+			len(block.Transactions), len(block.STransactions))
+	}
+	key := msg.ShortTxIDKey()
+	for i, tx := range block.Transactions[1:] {
+		txHash := tx.TxHash()
+		shortID := key.TruncatedShortHash(&txHash, ShortTxIDSize)
+		if shortID != msg.Regular.ShortIDs[i] {
+			t.Errorf("ShortIDs #%d: got %x, want %x", i,
+				msg.Regular.ShortIDs[i], shortID)
+		}
+		if shortID>>(8*ShortTxIDSize) != 0 {
+			t.Errorf("ShortIDs #%d: %x exceeds the short id size", i,
+				shortID)
+		}
+	}
+
+	// Ensure the short id key depends on the nonce.
+	otherMsg := NewMsgCmpctBlock(&block.Header, msg.Nonce+1)
+	if otherMsg.ShortTxIDKey() == key {
+		t.Errorf("ShortTxIDKey: same key for different nonces")
+	}
+
+	// Test encode with latest protocol version.
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, pver)
+	if err != nil {
+		t.Errorf("encode of MsgCmpctBlock failed %v err <%v>", msg, err)
+	}
+
+	// Test decode with latest protocol version.
+	readmsg := MsgCmpctBlock{}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), pver)
+	if err != nil {
+		t.Errorf("decode of MsgCmpctBlock failed [%v] err <%v>", buf, err)
+	}
+	// Decoding always allocates the prefilled transactions of the trees.
+	msg.Stake.PrefilledTxs = []PrefilledTx{}
+	if !reflect.DeepEqual(&readmsg, msg) {
+		t.Errorf("decode of MsgCmpctBlock got: %v want: %v",
+			spew.Sdump(&readmsg), spew.Sdump(msg))
+	}
+
+	// Older protocol versions should fail encode and decode since the
+	// message didn't exist yet.
+	oldPver := CmpctBlockVersion - 1
+	err = msg.BtcEncode(&buf, oldPver)
+	if err == nil {
+		t.Errorf("encode of MsgCmpctBlock passed for old protocol "+
+			"version %v", oldPver)
+	}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), oldPver)
+	if err == nil {
+		t.Errorf("decode of MsgCmpctBlock passed for old protocol "+
+			"version %v", oldPver)
+	}
+}
+
+// TestCmpctBlockBadPrefilledIndex ensures decoding compact blocks with
+// prefilled transactions which are out of order or do not refer to a
+// transaction of the tree fails.
+func TestCmpctBlockBadPrefilledIndex(t *testing.T) {
+	pver := ProtocolVersion
+
+	tests := []struct {
+		name     string
+		indexes  []uint32
+		shortIDs int
+	}{
+		{"out of range", []uint32{2}, 1},
+		{"duplicate", []uint32{0, 0}, 1},
+		{"out of order", []uint32{1, 0}, 0},
+	}
+	for _, test := range tests {
+		msg := NewMsgCmpctBlock(&testBlock.Header, 0)
+		msg.Regular.ShortIDs = make([]uint64, test.shortIDs)
+		for _, index := range test.indexes {
+			msg.Regular.PrefilledTxs = append(msg.Regular.PrefilledTxs,
+				PrefilledTx{Index: index, Tx: multiTx})
+		}
+		var buf bytes.Buffer
+		if err := msg.BtcEncode(&buf, pver); err != nil {
+			t.Fatalf("%s: encode of MsgCmpctBlock failed err <%v>",
+				test.name, err)
+		}
+
+		var readmsg MsgCmpctBlock
+		err := readmsg.BtcDecode(&buf, pver)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("%s: decode of MsgCmpctBlock - got error %v "+
+				"<%T>, want *MessageError", test.name, err, err)
+		}
+	}
+}
+
+// TestCmpctBlockWireErrors performs negative tests against wire encode and
+// decode of MsgCmpctBlock to confirm error paths work correctly.
+func TestCmpctBlockWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := NewMsgCmpctBlockFromBlock(newTestCmpctBlockSource(), 1)
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("encode of MsgCmpctBlock failed %v err <%v>", msg, err)
+	}
+	encoded := buf.Bytes()
+	const hdrSize = MaxBlockHeaderPayload
+	numShortIDs := len(msg.Regular.ShortIDs)
+	prefilledOffset := hdrSize + 8 + 1 + numShortIDs*ShortTxIDSize
+	stakeOffset := prefilledOffset + 2 +
+		msg.Regular.PrefilledTxs[0].Tx.SerializeSize()
+
+	tests := []struct {
+		max      int   // Max size of fixed buffer to induce errors
+		writeErr error // Expected write error
+		readErr  error // Expected read error
+	}{
+		// Force error in header.
+		{0, io.ErrShortWrite, io.EOF},
+		// Force error in nonce.
+		{hdrSize, io.ErrShortWrite, io.EOF},
+		// Force error in short id count.
+		{hdrSize + 8, io.ErrShortWrite, io.EOF},
+		// Force error in short ids.
+		{hdrSize + 9, io.ErrShortWrite, io.EOF},
+		// Force error in prefilled transaction count.
+		{prefilledOffset, io.ErrShortWrite, io.EOF},
+		// Force error in prefilled transaction index.
+		{prefilledOffset + 1, io.ErrShortWrite, io.EOF},
+		// Force error in prefilled transaction.
+		{prefilledOffset + 2, io.ErrShortWrite, io.EOF},
+		// Force error in stake tree.
+		{stakeOffset, io.ErrShortWrite, io.EOF},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := msg.BtcEncode(w, pver)
+		if err != test.writeErr {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var readmsg MsgCmpctBlock
+		r := newFixedReader(test.max, encoded)
+		err = readmsg.BtcDecode(r, pver)
+		if err != test.readErr {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+	}
+}
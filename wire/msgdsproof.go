@@ -0,0 +1,124 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// DoubleSpendProofSpend describes one of the two conflicting spends of the
+// outpoint of a double spend proof.
+//
+// In order to keep proofs compact, the signature scripts of all inputs of the
+// transaction other than the one which spends the outpoint are expected to be
+// empty.  This does not prevent the spend from being verified since signatures
+// only commit to the signature script of the input they are for.
+type DoubleSpendProofSpend struct {
+	// InputIndex is the index of the input of the transaction which spends
+	// the outpoint.
+	InputIndex uint32
+
+	// Tx is the spending transaction.
+	Tx MsgTx
+}
+
+// MsgDoubleSpendProof implements the Message interface and represents a decred
+// dsproof message.  It is used to relay proof that two different signed
+// transactions spend the same outpoint so that those accepting unconfirmed
+// transactions can be warned of the double spend.
+//
+// This message was not added until protocol version DoubleSpendProofVersion.
+type MsgDoubleSpendProof struct {
+	OutPoint OutPoint
+	Spends   [2]DoubleSpendProofSpend
+}
+
+// BtcDecode decodes r using the decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgDoubleSpendProof) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < DoubleSpendProofVersion {
+		str := fmt.Sprintf("dsproof message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgDoubleSpendProof.BtcDecode", str)
+	}
+
+	err := ReadOutPoint(r, pver, 0, &msg.OutPoint)
+	if err != nil {
+		return err
+	}
+
+	for i := range msg.Spends {
+		spend := &msg.Spends[i]
+		spend.InputIndex, err = binarySerializer.Uint32(r, littleEndian)
+		if err != nil {
+			return err
+		}
+		if err := spend.Tx.BtcDecode(r, pver); err != nil {
+			return err
+		}
+		if spend.InputIndex >= uint32(len(spend.Tx.TxIn)) {
+			str := fmt.Sprintf("dsproof input index %d is out of "+
+				"range for a transaction with %d inputs",
+				spend.InputIndex, len(spend.Tx.TxIn))
+			return messageError("MsgDoubleSpendProof.BtcDecode", str)
+		}
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgDoubleSpendProof) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < DoubleSpendProofVersion {
+		str := fmt.Sprintf("dsproof message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgDoubleSpendProof.BtcEncode", str)
+	}
+
+	err := WriteOutPoint(w, pver, 0, &msg.OutPoint)
+	if err != nil {
+		return err
+	}
+
+	for i := range msg.Spends {
+		spend := &msg.Spends[i]
+		err = binarySerializer.PutUint32(w, littleEndian, spend.InputIndex)
+		if err != nil {
+			return err
+		}
+		if err := spend.Tx.BtcEncode(w, pver); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgDoubleSpendProof) Command() string {
+	return CmdDoubleSpendProof
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgDoubleSpendProof) MaxPayloadLength(pver uint32) uint32 {
+	// Outpoint hash 32 bytes + outpoint index 4 bytes + outpoint tree 1
+	// byte + 2 * (input index 4 bytes + max transaction size).
+	var tx MsgTx
+	return 37 + 2*(4+tx.MaxPayloadLength(pver))
+}
+
+// NewMsgDoubleSpendProof returns a new decred dsproof message that conforms to
+// the Message interface using the passed parameters.  See MsgDoubleSpendProof
+// for details.
+func NewMsgDoubleSpendProof(outPoint *OutPoint, spend1, spend2 *DoubleSpendProofSpend) *MsgDoubleSpendProof {
+	return &MsgDoubleSpendProof{
+		OutPoint: *outPoint,
+		Spends:   [2]DoubleSpendProofSpend{*spend1, *spend2},
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// newTestDoubleSpendProof returns a double spend proof built from copies of the
+// multiTx test transaction which spend its first input.
+func newTestDoubleSpendProof() *MsgDoubleSpendProof {
+	tx1 := multiTx.Copy()
+	tx2 := multiTx.Copy()
+	tx2.TxOut[0].Value--
+	return NewMsgDoubleSpendProof(&tx1.TxIn[0].PreviousOutPoint,
+		&DoubleSpendProofSpend{InputIndex: 0, Tx: *tx1},
+		&DoubleSpendProofSpend{InputIndex: 0, Tx: *tx2})
+}
+
+// TestDoubleSpendProof tests the MsgDoubleSpendProof API against the latest
+// protocol version.
+func TestDoubleSpendProof(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "dsproof"
+	msg := newTestDoubleSpendProof()
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgDoubleSpendProof: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value.
+	wantPayload := uint32(37 + 2*(4+MaxBlockPayload))
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Test encode with latest protocol version.
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, pver)
+	if err != nil {
+		t.Errorf("encode of MsgDoubleSpendProof failed %v err <%v>", msg,
+			err)
+	}
+
+	// Test decode with latest protocol version.
+	readmsg := MsgDoubleSpendProof{}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), pver)
+	if err != nil {
+		t.Errorf("decode of MsgDoubleSpendProof failed [%v] err <%v>",
+			buf, err)
+	}
+	if !reflect.DeepEqual(&readmsg, msg) {
+		t.Errorf("decode of MsgDoubleSpendProof got: %v want: %v",
+			spew.Sdump(&readmsg), spew.Sdump(msg))
+	}
+
+	// Older protocol versions should fail encode and decode since the
+	// message didn't exist yet.
+	oldPver := DoubleSpendProofVersion - 1
+	err = msg.BtcEncode(&buf, oldPver)
+	if err == nil {
+		t.Errorf("encode of MsgDoubleSpendProof passed for old "+
+			"protocol version %v", oldPver)
+	}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), oldPver)
+	if err == nil {
+		t.Errorf("decode of MsgDoubleSpendProof passed for old "+
+			"protocol version %v", oldPver)
+	}
+}
+
+// TestDoubleSpendProofBadInputIndex ensures decoding a double spend proof with
+// an input index which does not refer to an input of the spending transaction
+// fails.
+func TestDoubleSpendProofBadInputIndex(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := newTestDoubleSpendProof()
+	msg.Spends[1].InputIndex = uint32(len(msg.Spends[1].Tx.TxIn))
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("encode of MsgDoubleSpendProof failed %v err <%v>", msg,
+			err)
+	}
+
+	var readmsg MsgDoubleSpendProof
+	err := readmsg.BtcDecode(&buf, pver)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("decode of MsgDoubleSpendProof with bad input index - "+
+			"got error %v <%T>, want *MessageError", err, err)
+	}
+}
+
+// TestDoubleSpendProofWireErrors performs negative tests against wire encode
+// and decode of MsgDoubleSpendProof to confirm error paths work correctly.
+func TestDoubleSpendProofWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := newTestDoubleSpendProof()
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("encode of MsgDoubleSpendProof failed %v err <%v>", msg,
+			err)
+	}
+	encoded := buf.Bytes()
+	spend1Size := 4 + msg.Spends[0].Tx.SerializeSize()
+
+	tests := []struct {
+		max      int   // Max size of fixed buffer to induce errors
+		writeErr error // Expected write error
+		readErr  error // Expected read error
+	}{
+		// Force error in outpoint.
+		{0, io.ErrShortWrite, io.EOF},
+		// Force error in first input index.
+		{37, io.ErrShortWrite, io.EOF},
+		// Force error in first transaction.
+		{41, io.ErrShortWrite, io.EOF},
+		// Force error in second input index.
+		{37 + spend1Size, io.ErrShortWrite, io.EOF},
+		// Force error in second transaction.
+		{41 + spend1Size, io.ErrShortWrite, io.EOF},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := msg.BtcEncode(w, pver)
+		if err != test.writeErr {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var readmsg MsgDoubleSpendProof
+		r := newFixedReader(test.max, encoded)
+		err = readmsg.BtcDecode(r, pver)
+		if err != test.readErr {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// readTxIndexes reads a list of encoded transaction indexes, which must be in
+// increasing order, from r depending on the protocol version.
+func readTxIndexes(r io.Reader, pver uint32) ([]uint32, error) {
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prevent more indexes than could possibly fit into a transaction
+	// tree.  It would be possible to cause memory exhaustion and panics
+	// without a sane upper bound on this count.
+	if count > maxTxPerTree {
+		str := fmt.Sprintf("too many transaction indexes to fit into a "+
+			"block [count %d, max %d]", count, maxTxPerTree)
+		return nil, messageError("readTxIndexes", str)
+	}
+
+	indexes := make([]uint32, 0, count)
+	for i := uint64(0); i < count; i++ {
+		index, err := ReadVarInt(r, pver)
+		if err != nil {
+			return nil, err
+		}
+		if index >= maxTxPerTree || (i > 0 &&
+			index <= uint64(indexes[i-1])) {
+
+			str := fmt.Sprintf("transaction index %d is out of "+
+				"order or range", index)
+			return nil, messageError("readTxIndexes", str)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+
+	return indexes, nil
+}
+
+// writeTxIndexes serializes a list of transaction indexes to w depending on
+// the protocol version.
+func writeTxIndexes(w io.Writer, pver uint32, indexes []uint32) error {
+	err := WriteVarInt(w, pver, uint64(len(indexes)))
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if err := WriteVarInt(w, pver, uint64(index)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MsgGetBlockTxn implements the Message interface and represents a decred
+// getblocktxn message.  It is used to request the transactions of a compact
+// block (MsgCmpctBlock) which could not be found in the memory pool by their
+// indexes in the regular and stake transaction trees of the block.  The
+// transactions are delivered with a blocktxn message (MsgBlockTxn).
+//
+// This message was not added until protocol version CmpctBlockVersion.
+type MsgGetBlockTxn struct {
+	BlockHash chainhash.Hash
+	Indexes   []uint32
+	SIndexes  []uint32
+}
+
+// BtcDecode decodes r using the decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < CmpctBlockVersion {
+		str := fmt.Sprintf("getblocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetBlockTxn.BtcDecode", str)
+	}
+
+	err := readElement(r, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+	msg.Indexes, err = readTxIndexes(r, pver)
+	if err != nil {
+		return err
+	}
+	msg.SIndexes, err = readTxIndexes(r, pver)
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < CmpctBlockVersion {
+		str := fmt.Sprintf("getblocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetBlockTxn.BtcEncode", str)
+	}
+
+	err := writeElement(w, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+	if err := writeTxIndexes(w, pver, msg.Indexes); err != nil {
+		return err
+	}
+	return writeTxIndexes(w, pver, msg.SIndexes)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetBlockTxn) Command() string {
+	return CmdGetBlockTxn
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + 2 * (num indexes (varInt) + max allowed indexes).
+	maxIndexes := MaxTxPerTxTree(pver) * MaxVarIntPayload
+	return chainhash.HashSize + 2*(MaxVarIntPayload+uint32(maxIndexes))
+}
+
+// NewMsgGetBlockTxn returns a new decred getblocktxn message that conforms to
+// the Message interface using the passed parameters.  See MsgGetBlockTxn for
+// details.
+func NewMsgGetBlockTxn(blockHash *chainhash.Hash, indexes, sIndexes []uint32) *MsgGetBlockTxn {
+	return &MsgGetBlockTxn{
+		BlockHash: *blockHash,
+		Indexes:   indexes,
+		SIndexes:  sIndexes,
+	}
+}
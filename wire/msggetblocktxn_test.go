@@ -0,0 +1,137 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestGetBlockTxn tests the MsgGetBlockTxn API against the latest protocol
+// version and ensures it is rejected by older protocol versions.
+func TestGetBlockTxn(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "getblocktxn"
+	blockHash := testBlock.BlockHash()
+	msg := NewMsgGetBlockTxn(&blockHash, []uint32{1, 2, 300}, []uint32{0})
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgGetBlockTxn: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value.
+	maxIndexes := uint32(MaxTxPerTxTree(pver)) * MaxVarIntPayload
+	wantPayload := chainhash.HashSize + 2*(MaxVarIntPayload+maxIndexes)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Test encode with latest protocol version.
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, pver)
+	if err != nil {
+		t.Errorf("encode of MsgGetBlockTxn failed %v err <%v>", msg, err)
+	}
+
+	// Test decode with latest protocol version.
+	readmsg := MsgGetBlockTxn{}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), pver)
+	if err != nil {
+		t.Errorf("decode of MsgGetBlockTxn failed [%v] err <%v>", buf, err)
+	}
+	if !reflect.DeepEqual(&readmsg, msg) {
+		t.Errorf("decode of MsgGetBlockTxn got: %v want: %v",
+			spew.Sdump(&readmsg), spew.Sdump(msg))
+	}
+
+	// Older protocol versions should fail encode and decode since the
+	// message didn't exist yet.
+	oldPver := CmpctBlockVersion - 1
+	err = msg.BtcEncode(&buf, oldPver)
+	if err == nil {
+		t.Errorf("encode of MsgGetBlockTxn passed for old protocol "+
+			"version %v", oldPver)
+	}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), oldPver)
+	if err == nil {
+		t.Errorf("decode of MsgGetBlockTxn passed for old protocol "+
+			"version %v", oldPver)
+	}
+
+	// Ensure indexes which are not in increasing order are rejected.
+	msg.Indexes = []uint32{2, 1}
+	buf.Reset()
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("encode of MsgGetBlockTxn failed %v err <%v>", msg, err)
+	}
+	err = readmsg.BtcDecode(&buf, pver)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("decode of MsgGetBlockTxn with unordered indexes - got "+
+			"error %v <%T>, want *MessageError", err, err)
+	}
+}
+
+// TestGetBlockTxnWireErrors performs negative tests against wire encode and
+// decode of MsgGetBlockTxn to confirm error paths work correctly.
+func TestGetBlockTxnWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	blockHash := testBlock.BlockHash()
+	msg := NewMsgGetBlockTxn(&blockHash, []uint32{1}, []uint32{0})
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("encode of MsgGetBlockTxn failed %v err <%v>", msg, err)
+	}
+	encoded := buf.Bytes()
+
+	tests := []struct {
+		max      int   // Max size of fixed buffer to induce errors
+		writeErr error // Expected write error
+		readErr  error // Expected read error
+	}{
+		// Force error in block hash.
+		{0, io.ErrShortWrite, io.EOF},
+		// Force error in regular index count.
+		{32, io.ErrShortWrite, io.EOF},
+		// Force error in regular indexes.
+		{33, io.ErrShortWrite, io.EOF},
+		// Force error in stake index count.
+		{34, io.ErrShortWrite, io.EOF},
+		// Force error in stake indexes.
+		{35, io.ErrShortWrite, io.EOF},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := msg.BtcEncode(w, pver)
+		if err != test.writeErr {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var readmsg MsgGetBlockTxn
+		r := newFixedReader(test.max, encoded)
+		err = readmsg.BtcDecode(r, pver)
+		if err != test.readErr {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// MsgGetPackage implements the Message interface and represents a decred
+// getpackage message.  It is used to request a transaction along with all of
+// its unconfirmed ancestors as a unit, typically after the transaction was
+// received as an orphan since its parents could not be accepted on their own.
+// The transactions are delivered with a package message (MsgPackage), or a
+// notfound message (MsgNotFound) when the package is not available.
+//
+// This message was not added until protocol version PackageRelayVersion.
+type MsgGetPackage struct {
+	TxHash chainhash.Hash
+}
+
+// BtcDecode decodes r using the decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetPackage) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("getpackage message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetPackage.BtcDecode", str)
+	}
+
+	return readElement(r, &msg.TxHash)
+}
+
+// BtcEncode encodes the receiver to w using the decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetPackage) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("getpackage message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetPackage.BtcEncode", str)
+	}
+
+	return writeElement(w, &msg.TxHash)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetPackage) Command() string {
+	return CmdGetPackage
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetPackage) MaxPayloadLength(pver uint32) uint32 {
+	return chainhash.HashSize
+}
+
+// NewMsgGetPackage returns a new decred getpackage message that conforms to the
+// Message interface using the passed parameters.  See MsgGetPackage for details.
+func NewMsgGetPackage(txHash *chainhash.Hash) *MsgGetPackage {
+	return &MsgGetPackage{
+		TxHash: *txHash,
+	}
+}
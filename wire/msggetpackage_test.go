@@ -0,0 +1,74 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestGetPackage tests the MsgGetPackage API against the latest protocol
+// version and ensures it is rejected by older protocol versions.
+func TestGetPackage(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "getpackage"
+	txHash := multiTx.TxHash()
+	msg := NewMsgGetPackage(&txHash)
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgGetPackage: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value.
+	wantPayload := uint32(chainhash.HashSize)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Test encode with latest protocol version.
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, pver)
+	if err != nil {
+		t.Errorf("encode of MsgGetPackage failed %v err <%v>", msg, err)
+	}
+	if !bytes.Equal(buf.Bytes(), txHash[:]) {
+		t.Errorf("encode of MsgGetPackage got: %x want: %x", buf.Bytes(),
+			txHash[:])
+	}
+
+	// Test decode with latest protocol version.
+	readmsg := MsgGetPackage{}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), pver)
+	if err != nil {
+		t.Errorf("decode of MsgGetPackage failed [%v] err <%v>", buf, err)
+	}
+	if !reflect.DeepEqual(&readmsg, msg) {
+		t.Errorf("decode of MsgGetPackage got: %v want: %v",
+			spew.Sdump(&readmsg), spew.Sdump(msg))
+	}
+
+	// Older protocol versions should fail encode and decode since the
+	// message didn't exist yet.
+	oldPver := PackageRelayVersion - 1
+	err = msg.BtcEncode(&buf, oldPver)
+	if err == nil {
+		t.Errorf("encode of MsgGetPackage passed for old protocol "+
+			"version %v", oldPver)
+	}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), oldPver)
+	if err == nil {
+		t.Errorf("decode of MsgGetPackage passed for old protocol "+
+			"version %v", oldPver)
+	}
+}
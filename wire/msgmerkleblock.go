@@ -17,12 +17,21 @@ import (
 func maxFlagsPerMerkleBlock(pver uint32) uint32 {
 	// Each transaction is represented by a single bit, so the result is the
 	// max number of transactions per block divided by 8 bits per byte.
-	// Then an extra one to cover partials.
-	return uint32(MaxTxPerTxTree(ProtocolVersion)/8) + 1
+	// Then an extra one to cover partials.  This is doubled since the flags
+	// for both the regular and stake transaction trees are included and
+	// each tree starts at a byte boundary.
+	return 2 * (uint32(MaxTxPerTxTree(ProtocolVersion)/8) + 1)
 }
 
 // MsgMerkleBlock implements the Message interface and represents a decred
-// merkleblock message which is used to reset a Bloom filter.
+// merkleblock message which is used to send a block filtered by the bloom
+// filter loaded by the remote peer.  It includes a partial merkle tree for
+// both the regular and stake transaction trees.
+//
+// The Flags field houses the flag bits of the regular transaction tree
+// followed by the flag bits of the stake transaction tree.  The bits of each
+// tree are packed least significant bit first and the bits of the stake
+// transaction tree start at the next byte boundary.
 //
 // This message was not added until protocol version BIP0037Version.
 type MsgMerkleBlock struct {
@@ -114,7 +123,7 @@ func (msg *MsgMerkleBlock) BtcDecode(r io.Reader, pver uint32) error {
 		return messageError("MsgMerkleBlock.BtcDecode", str)
 	}
 
-	hashes = make([]chainhash.Hash, count)
+	hashes = make([]chainhash.Hash, scount)
 	msg.SHashes = make([]*chainhash.Hash, 0, scount)
 	for i := uint64(0); i < scount; i++ {
 		hash := &hashes[i]
@@ -145,7 +154,7 @@ func (msg *MsgMerkleBlock) BtcEncode(w io.Writer, pver uint32) error {
 	numSHashes := uint64(len(msg.SHashes))
 	if numSHashes > maxTxPerTree {
 		str := fmt.Sprintf("too many stake transaction hashes for message "+
-			"[count %v, max %v]", numHashes, maxTxPerTree)
+			"[count %v, max %v]", numSHashes, maxTxPerTree)
 		return messageError("MsgMerkleBlock.BtcDecode", str)
 	}
 
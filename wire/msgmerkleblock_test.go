@@ -416,6 +416,38 @@ func TestMerkleBlockOverflowErrors(t *testing.T) {
 	}
 }
 
+// TestMerkleBlockStakeTree tests encoding and decoding a merkle block with
+// more stake transaction tree hashes than regular transaction tree hashes.
+func TestMerkleBlockStakeTree(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := NewMsgMerkleBlock(&testMerkleBlock.Header)
+	msg.Transactions = 1
+	msg.STransactions = 5
+	for i := 0; i < 3; i++ {
+		var hash chainhash.Hash
+		hash[0] = byte(i)
+		if i == 0 {
+			msg.AddTxHash(&hash)
+		}
+		msg.AddSTxHash(&hash)
+	}
+	msg.Flags = []byte{0x01, 0x0b}
+
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	var readMsg MsgMerkleBlock
+	if err := readMsg.BtcDecode(&buf, pver); err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(msg, &readMsg) {
+		t.Fatalf("BtcDecode got: %s want: %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+}
+
 // testMerkleBlock is a basic normative merkle block that is used throughout the
 // tests.
 var testMerkleBlock = MsgMerkleBlock{
@@ -0,0 +1,122 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxPackageTxns is the maximum number of transactions in a package message,
+// which includes the requested transaction itself.
+const MaxPackageTxns = 25
+
+// MsgPackage implements the Message interface and represents a decred package
+// message.  It is used to deliver a transaction which was requested with a
+// getpackage message (MsgGetPackage) along with its unconfirmed ancestors.  The
+// transactions are ordered so that every transaction follows all of its
+// ancestors, which means the requested transaction is last.
+//
+// This message was not added until protocol version PackageRelayVersion.
+type MsgPackage struct {
+	Transactions []*MsgTx
+}
+
+// AddTransaction adds a transaction to the package.
+func (msg *MsgPackage) AddTransaction(tx *MsgTx) error {
+	if len(msg.Transactions)+1 > MaxPackageTxns {
+		str := fmt.Sprintf("too many transactions in message [max %v]",
+			MaxPackageTxns)
+		return messageError("MsgPackage.AddTransaction", str)
+	}
+
+	msg.Transactions = append(msg.Transactions, tx)
+	return nil
+}
+
+// BtcDecode decodes r using the decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgPackage) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("package message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgPackage.BtcDecode", str)
+	}
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+
+	// Limit to max transactions per message.
+	if count > MaxPackageTxns {
+		str := fmt.Sprintf("too many transactions for message "+
+			"[count %v, max %v]", count, MaxPackageTxns)
+		return messageError("MsgPackage.BtcDecode", str)
+	}
+
+	msg.Transactions = make([]*MsgTx, 0, count)
+	for i := uint64(0); i < count; i++ {
+		tx := MsgTx{}
+		if err := tx.BtcDecode(r, pver); err != nil {
+			return err
+		}
+		msg.Transactions = append(msg.Transactions, &tx)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgPackage) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("package message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgPackage.BtcEncode", str)
+	}
+
+	// Limit to max transactions per message.
+	count := len(msg.Transactions)
+	if count > MaxPackageTxns {
+		str := fmt.Sprintf("too many transactions for message "+
+			"[count %v, max %v]", count, MaxPackageTxns)
+		return messageError("MsgPackage.BtcEncode", str)
+	}
+
+	err := WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+	for _, tx := range msg.Transactions {
+		if err := tx.BtcEncode(w, pver); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgPackage) Command() string {
+	return CmdPackage
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgPackage) MaxPayloadLength(pver uint32) uint32 {
+	// A package could never be mined if its transactions did not fit into
+	// a block along with the count.
+	return MaxVarIntPayload + MaxBlockPayload
+}
+
+// NewMsgPackage returns a new decred package message that conforms to the
+// Message interface.  See MsgPackage for details.
+func NewMsgPackage() *MsgPackage {
+	return &MsgPackage{
+		Transactions: make([]*MsgTx, 0, 2),
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestPackage tests the MsgPackage API against the latest protocol version and
+// ensures it is rejected by older protocol versions.
+func TestPackage(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "package"
+	msg := NewMsgPackage()
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgPackage: wrong command - got %v want %v", cmd,
+			wantCmd)
+	}
+
+	// Ensure max payload is expected value.
+	wantPayload := uint32(MaxVarIntPayload + MaxBlockPayload)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure transactions are added properly.
+	child := multiTx.Copy()
+	child.TxIn[0].PreviousOutPoint.Hash = multiTx.TxHash()
+	msg.AddTransaction(multiTx)
+	msg.AddTransaction(child)
+	if len(msg.Transactions) != 2 {
+		t.Fatalf("AddTransaction: wrong number of transactions - got "+
+			"%d, want 2", len(msg.Transactions))
+	}
+
+	// Test encode with latest protocol version.
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, pver)
+	if err != nil {
+		t.Errorf("encode of MsgPackage failed %v err <%v>", msg, err)
+	}
+
+	// Test decode with latest protocol version.
+	readmsg := MsgPackage{}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), pver)
+	if err != nil {
+		t.Errorf("decode of MsgPackage failed [%v] err <%v>", buf, err)
+	}
+	if !reflect.DeepEqual(&readmsg, msg) {
+		t.Errorf("decode of MsgPackage got: %v want: %v",
+			spew.Sdump(&readmsg), spew.Sdump(msg))
+	}
+
+	// Older protocol versions should fail encode and decode since the
+	// message didn't exist yet.
+	oldPver := PackageRelayVersion - 1
+	err = msg.BtcEncode(&buf, oldPver)
+	if err == nil {
+		t.Errorf("encode of MsgPackage passed for old protocol "+
+			"version %v", oldPver)
+	}
+	err = readmsg.BtcDecode(bytes.NewReader(buf.Bytes()), oldPver)
+	if err == nil {
+		t.Errorf("decode of MsgPackage passed for old protocol "+
+			"version %v", oldPver)
+	}
+
+	// Ensure adding more than the max allowed transactions per package
+	// returns an error.
+	for i := len(msg.Transactions); i < MaxPackageTxns; i++ {
+		if err := msg.AddTransaction(multiTx); err != nil {
+			t.Fatalf("AddTransaction #%d: unexpected error: %v", i,
+				err)
+		}
+	}
+	if err := msg.AddTransaction(multiTx); err == nil {
+		t.Errorf("AddTransaction: expected error on too many " +
+			"transactions not received")
+	}
+}
+
+// TestPackageWireErrors performs negative tests against wire encode and decode
+// of MsgPackage to confirm error paths work correctly.
+func TestPackageWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := NewMsgPackage()
+	msg.AddTransaction(multiTx)
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("encode of MsgPackage failed %v err <%v>", msg, err)
+	}
+	encoded := buf.Bytes()
+
+	// Message that forces an error by having more than the max allowed
+	// transactions.
+	tooMany := NewMsgPackage()
+	for i := 0; i < MaxPackageTxns; i++ {
+		tooMany.AddTransaction(multiTx)
+	}
+	tooMany.Transactions = append(tooMany.Transactions, multiTx)
+	tooManyEncoded := []byte{byte(MaxPackageTxns + 1)}
+
+	tests := []struct {
+		msg      *MsgPackage // Value to encode
+		buf      []byte      // Wire encoding
+		max      int         // Max size of fixed buffer to induce errors
+		writeErr error       // Expected write error
+		readErr  error       // Expected read error
+	}{
+		// Force error in transaction count.
+		{msg, encoded, 0, io.ErrShortWrite, io.EOF},
+		// Force error in transactions.
+		{msg, encoded, 1, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max transactions.
+		{tooMany, tooManyEncoded, len(tooManyEncoded), &MessageError{},
+			&MessageError{}},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.msg.BtcEncode(w, pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var readmsg MsgPackage
+		r := newFixedReader(test.max, test.buf)
+		err = readmsg.BtcDecode(r, pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+	}
+}
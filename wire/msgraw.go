@@ -0,0 +1,61 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import "io"
+
+// RawMessage implements the Message interface and represents a message with an
+// already serialized payload, such as a block read from the database.  Writing
+// it sends the payload as is, which avoids decoding the payload only to encode
+// it again and allows sending payloads which are not on the heap, such as
+// memory mapped files, without copying them.
+//
+// Raw messages are only intended to be written, so they can't be decoded.
+type RawMessage struct {
+	command string
+	Payload []byte
+}
+
+// BtcDecode always returns an error since raw messages can't be decoded.  This
+// is part of the Message interface implementation.
+func (msg *RawMessage) BtcDecode(r io.Reader, pver uint32) error {
+	return messageError("RawMessage.BtcDecode", "raw messages can't be "+
+		"decoded")
+}
+
+// BtcEncode encodes the receiver to w by writing the serialized payload.  This
+// is part of the Message interface implementation.
+func (msg *RawMessage) BtcEncode(w io.Writer, pver uint32) error {
+	_, err := w.Write(msg.Payload)
+	return err
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *RawMessage) Command() string {
+	return msg.command
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  It is the maximum payload length of the message type of the
+// command when the command is known.  This is part of the Message interface
+// implementation.
+func (msg *RawMessage) MaxPayloadLength(pver uint32) uint32 {
+	if m, err := makeEmptyMessage(msg.command); err == nil {
+		return m.MaxPayloadLength(pver)
+	}
+	return MaxMessagePayload
+}
+
+// NewRawMessage returns a new raw message for the passed command with the
+// passed serialized payload that conforms to the Message interface.  The
+// payload is not copied, so it must not be modified until the message has been
+// written.  See RawMessage for details.
+func NewRawMessage(command string, payload []byte) *RawMessage {
+	return &RawMessage{
+		command: command,
+		Payload: payload,
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestRawMessage ensures writing a raw message with a serialized block payload
+// produces the same bytes as writing the block itself and raw messages are
+// rejected when they can't be written or decoded.
+func TestRawMessage(t *testing.T) {
+	pver := ProtocolVersion
+	dcrnet := MainNet
+
+	var blockBuf bytes.Buffer
+	if err := testBlock.Serialize(&blockBuf); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+
+	// Ensure the command and max payload length are those of a block.
+	msg := NewRawMessage(CmdBlock, blockBuf.Bytes())
+	if cmd := msg.Command(); cmd != CmdBlock {
+		t.Errorf("NewRawMessage: wrong command - got %v want %v", cmd,
+			CmdBlock)
+	}
+	wantPayload := testBlock.MaxPayloadLength(pver)
+	if maxPayload := msg.MaxPayloadLength(pver); maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length - got %v, "+
+			"want %v", maxPayload, wantPayload)
+	}
+	unknownMsg := NewRawMessage("unknown", nil)
+	if maxPayload := unknownMsg.MaxPayloadLength(pver); maxPayload !=
+		MaxMessagePayload {
+
+		t.Errorf("MaxPayloadLength: wrong max payload length for unknown "+
+			"command - got %v, want %v", maxPayload, MaxMessagePayload)
+	}
+
+	// Ensure the written message matches the written block.
+	var want, got bytes.Buffer
+	wantN, err := WriteMessageN(&want, &testBlock, pver, dcrnet)
+	if err != nil {
+		t.Fatalf("WriteMessageN: unexpected error: %v", err)
+	}
+	gotN, err := WriteMessageN(&got, msg, pver, dcrnet)
+	if err != nil {
+		t.Fatalf("WriteMessageN: unexpected error: %v", err)
+	}
+	if gotN != wantN || !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Fatalf("WriteMessageN: mismatched raw message - got %d bytes, "+
+			"want %d bytes", gotN, wantN)
+	}
+
+	// Ensure the written message can be read back as a block.
+	_, readMsg, _, err := ReadMessageN(&got, pver, dcrnet)
+	if err != nil {
+		t.Fatalf("ReadMessageN: unexpected error: %v", err)
+	}
+	if readMsg.(*MsgBlock).BlockHash() != testBlock.BlockHash() {
+		t.Fatalf("ReadMessageN: mismatched block hash")
+	}
+
+	// Ensure payloads which exceed the max payload length are rejected.
+	tooLarge := NewRawMessage(CmdPing, make([]byte, 9))
+	_, err = WriteMessageN(&got, tooLarge, pver, dcrnet)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("WriteMessageN: expected error for too large payload "+
+			"- got %v", err)
+	}
+
+	// Ensure raw messages can't be decoded.
+	if err := msg.BtcDecode(&blockBuf, pver); err == nil {
+		t.Errorf("BtcDecode: expected error")
+	}
+}
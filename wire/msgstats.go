@@ -0,0 +1,108 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MessageStats describes a message which was read or written.
+type MessageStats struct {
+	// Command is the command of the message from its header.
+	Command string
+
+	// Bytes is the total number of bytes of the message which were read or
+	// written including the header.
+	Bytes int
+
+	// Latency is the time taken to read the message payload once the
+	// header was read, or to encode and write the entire message.
+	Latency time.Duration
+
+	// Err is the error the message failed to be read or written with, if
+	// any.
+	Err error
+}
+
+// MessageStatsCollector is the interface implemented by types which collect
+// statistics about the messages read and written by ReadMessageN and
+// WriteMessageN, or only about those read and written with the collector by
+// ReadMessageWithStats and WriteMessageWithStats.  The methods are invoked
+// synchronously from the read and write paths, so they must be safe for
+// concurrent access and return quickly.
+type MessageStatsCollector interface {
+	// MessageRead is invoked once a message header was read, after either
+	// the rest of the message was read or reading it failed.  Nothing is
+	// reported when the header itself could not be read.
+	MessageRead(stats *MessageStats)
+
+	// MessageWritten is invoked once a message was written or writing it
+	// failed.
+	MessageWritten(stats *MessageStats)
+}
+
+// msgStatsCollector houses a registered collector.  A pointer to it identifies
+// the registration so that collectors of types which are not comparable may be
+// unregistered.
+type msgStatsCollector struct {
+	MessageStatsCollector
+}
+
+var (
+	// msgStatsMtx protects registering and unregistering collectors.
+	msgStatsMtx sync.Mutex
+
+	// msgStatsCollectors holds the currently registered collectors as a
+	// []*msgStatsCollector.  It is replaced rather than modified when a
+	// collector is registered or unregistered so the read and write paths
+	// may load it without locking.
+	msgStatsCollectors atomic.Value
+)
+
+// RegisterMessageStatsCollector registers the passed collector to receive
+// statistics about all messages read and written by ReadMessageN and
+// WriteMessageN, including via ReadMessage, WriteMessage, ReadMessageWithStats,
+// and WriteMessageWithStats.  The returned function unregisters the collector.
+//
+// Registered collectors are process-wide and receive the messages of every
+// caller, so statistics which only concern some connections, such as those of
+// a single peer, should be collected with ReadMessageWithStats and
+// WriteMessageWithStats instead.
+//
+// This function is safe for concurrent access.
+func RegisterMessageStatsCollector(c MessageStatsCollector) func() {
+	registered := &msgStatsCollector{c}
+
+	msgStatsMtx.Lock()
+	collectors := loadMsgStatsCollectors()
+	newCollectors := make([]*msgStatsCollector, len(collectors), len(collectors)+1)
+	copy(newCollectors, collectors)
+	msgStatsCollectors.Store(append(newCollectors, registered))
+	msgStatsMtx.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			msgStatsMtx.Lock()
+			collectors := loadMsgStatsCollectors()
+			newCollectors := make([]*msgStatsCollector, 0, len(collectors))
+			for _, entry := range collectors {
+				if entry != registered {
+					newCollectors = append(newCollectors, entry)
+				}
+			}
+			msgStatsCollectors.Store(newCollectors)
+			msgStatsMtx.Unlock()
+		})
+	}
+}
+
+// loadMsgStatsCollectors returns the currently registered collectors.
+func loadMsgStatsCollectors() []*msgStatsCollector {
+	collectors, _ := msgStatsCollectors.Load().([]*msgStatsCollector)
+	return collectors
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// testStatsCollector is a MessageStatsCollector which records the statistics
+// it receives.
+type testStatsCollector struct {
+	sync.Mutex
+	read    []MessageStats
+	written []MessageStats
+}
+
+// MessageRead records the statistics of a message which was read.
+func (c *testStatsCollector) MessageRead(stats *MessageStats) {
+	c.Lock()
+	c.read = append(c.read, *stats)
+	c.Unlock()
+}
+
+// MessageWritten records the statistics of a message which was written.
+func (c *testStatsCollector) MessageWritten(stats *MessageStats) {
+	c.Lock()
+	c.written = append(c.written, *stats)
+	c.Unlock()
+}
+
+// TestMessageStatsCollector ensures registered collectors receive the
+// statistics of the messages which are read and written and no longer do once
+// unregistered.
+func TestMessageStatsCollector(t *testing.T) {
+	c := new(testStatsCollector)
+	unregister := RegisterMessageStatsCollector(c)
+
+	var buf bytes.Buffer
+	msg := NewMsgPing(123)
+	written, err := WriteMessageN(&buf, msg, ProtocolVersion, MainNet)
+	if err != nil {
+		t.Fatalf("WriteMessageN: unexpected error: %v", err)
+	}
+	read, _, _, err := ReadMessageN(&buf, ProtocolVersion, MainNet)
+	if err != nil {
+		t.Fatalf("ReadMessageN: unexpected error: %v", err)
+	}
+
+	// Ensure a message from the wrong network is reported with the error it
+	// failed to be read with.
+	if err := WriteMessage(&buf, msg, ProtocolVersion, SimNet); err != nil {
+		t.Fatalf("WriteMessage: unexpected error: %v", err)
+	}
+	if _, _, err := ReadMessage(&buf, ProtocolVersion, MainNet); err == nil {
+		t.Fatal("ReadMessage: did not receive expected error")
+	}
+
+	unregister()
+	unregister()
+	WriteMessage(&buf, msg, ProtocolVersion, MainNet)
+	ReadMessage(&buf, ProtocolVersion, MainNet)
+
+	if len(c.written) != 2 || len(c.read) != 2 {
+		t.Fatalf("got %d written and %d read messages, want 2 and 2",
+			len(c.written), len(c.read))
+	}
+	if stats := c.written[0]; stats.Command != CmdPing ||
+		stats.Bytes != written || stats.Err != nil {
+		t.Fatalf("unexpected written message stats %+v", stats)
+	}
+	if stats := c.read[0]; stats.Command != CmdPing ||
+		stats.Bytes != read || stats.Err != nil {
+		t.Fatalf("unexpected read message stats %+v", stats)
+	}
+	if stats := c.read[1]; stats.Command != CmdPing ||
+		stats.Bytes != MessageHeaderSize || stats.Err == nil {
+		t.Fatalf("unexpected read message stats %+v", stats)
+	}
+}
+
+// TestMessageStatsWithCollector ensures the collector passed to
+// ReadMessageWithStats and WriteMessageWithStats only receives the statistics
+// of the messages read and written with it.
+func TestMessageStatsWithCollector(t *testing.T) {
+	c := new(testStatsCollector)
+
+	var buf bytes.Buffer
+	msg := NewMsgPing(123)
+	written, err := WriteMessageWithStats(&buf, msg, ProtocolVersion,
+		MainNet, c)
+	if err != nil {
+		t.Fatalf("WriteMessageWithStats: unexpected error: %v", err)
+	}
+	WriteMessage(&buf, msg, ProtocolVersion, MainNet)
+	read, _, _, err := ReadMessageWithStats(&buf, ProtocolVersion, MainNet, c)
+	if err != nil {
+		t.Fatalf("ReadMessageWithStats: unexpected error: %v", err)
+	}
+	ReadMessage(&buf, ProtocolVersion, MainNet)
+
+	// A nil collector is the same as not passing one.
+	WriteMessageWithStats(&buf, msg, ProtocolVersion, MainNet, nil)
+	ReadMessageWithStats(&buf, ProtocolVersion, MainNet, nil)
+
+	if len(c.written) != 1 || len(c.read) != 1 {
+		t.Fatalf("got %d written and %d read messages, want 1 and 1",
+			len(c.written), len(c.read))
+	}
+	if stats := c.written[0]; stats.Command != CmdPing ||
+		stats.Bytes != written || stats.Err != nil {
+		t.Fatalf("unexpected written message stats %+v", stats)
+	}
+	if stats := c.read[0]; stats.Command != CmdPing ||
+		stats.Bytes != read || stats.Err != nil {
+		t.Fatalf("unexpected read message stats %+v", stats)
+	}
+}
@@ -17,7 +17,7 @@ const (
 	InitialProcotolVersion uint32 = 1
 
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 4
+	ProtocolVersion uint32 = 7
 
 	// BIP0111Version is the protocol version which added the SFNodeBloom
 	// service flag.
@@ -26,6 +26,20 @@ const (
 	// SendHeadersVersion is the protocol version which added a new
 	// sendheaders message.
 	SendHeadersVersion uint32 = 3
+
+	// DoubleSpendProofVersion is the protocol version which added a new
+	// dsproof message.
+	DoubleSpendProofVersion uint32 = 5
+
+	// CmpctBlockVersion is the protocol version which added the sendcmpct,
+	// cmpctblock, getblocktxn, and blocktxn messages for compact block
+	// relay.
+	CmpctBlockVersion uint32 = 6
+
+	// PackageRelayVersion is the protocol version which added the
+	// getpackage and package messages for relaying transactions along with
+	// their unconfirmed ancestors.
+	PackageRelayVersion uint32 = 7
 )
 
 // ServiceFlag identifies services supported by a decred peer.
@@ -0,0 +1,23 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build go1.8
+
+package wire
+
+import (
+	"io"
+	"net"
+)
+
+// writeHeaderAndPayload writes the passed serialized message header followed
+// by the payload to w and returns the number of bytes written.  Both are
+// written with a single vectored write when w is a network connection which
+// supports them, which avoids copying the payload in order to send the message
+// as a whole.
+func writeHeaderAndPayload(w io.Writer, header, payload []byte) (int, error) {
+	buffers := net.Buffers{header, payload}
+	n, err := buffers.WriteTo(w)
+	return int(n), err
+}
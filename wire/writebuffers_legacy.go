@@ -0,0 +1,28 @@
+// Copyright (c) 2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build !go1.8
+
+package wire
+
+import "io"
+
+// writeHeaderAndPayload writes the passed serialized message header followed
+// by the payload to w and returns the number of bytes written.  Vectored writes
+// are not available prior to Go 1.8, so they are written separately.
+func writeHeaderAndPayload(w io.Writer, header, payload []byte) (int, error) {
+	totalBytes := 0
+
+	// Write header.
+	n, err := w.Write(header)
+	totalBytes += n
+	if err != nil {
+		return totalBytes, err
+	}
+
+	// Write payload.
+	n, err = w.Write(payload)
+	totalBytes += n
+	return totalBytes, err
+}